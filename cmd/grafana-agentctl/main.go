@@ -9,8 +9,10 @@ import (
 	"os/signal"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/grafana/agent/pkg/build"
 	"github.com/grafana/agent/pkg/config"
@@ -56,6 +58,8 @@ func main() {
 	cmd.AddCommand(
 		configSyncCmd(),
 		configCheckCmd(),
+		configHistoryCmd(),
+		configRollbackCmd(),
 		walStatsCmd(),
 		targetStatsCmd(),
 		samplesCmd(),
@@ -141,6 +145,71 @@ the exit code will be 1.`,
 	return cmd
 }
 
+func configHistoryCmd() *cobra.Command {
+	var agentAddr string
+
+	cmd := &cobra.Command{
+		Use:   "config-history",
+		Short: "List the configs a Grafana Agent Flow applied successfully",
+		Long: `config-history lists the revisions of the config history of a Grafana Agent
+Flow, latest first. Each revision is a config which loaded successfully, either
+from the config file, from a remote config bundle, or from a rollback.`,
+		Args: cobra.NoArgs,
+
+		Run: func(_ *cobra.Command, _ []string) {
+			revisions, err := agentctl.ListConfigHistory(context.Background(), agentAddr)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to list config history: %s\n", err)
+				os.Exit(1)
+			}
+
+			table := tablewriter.NewWriter(os.Stdout)
+			defer table.Render()
+
+			table.SetHeader([]string{"Revision", "Time", "Source", "SHA256"})
+			for _, rev := range revisions {
+				table.Append([]string{fmt.Sprint(rev.Revision), rev.Time.Format(time.RFC3339), rev.Source, rev.SHA256})
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&agentAddr, "addr", "a", "http://localhost:12345", "address of the agent to connect to")
+	return cmd
+}
+
+func configRollbackCmd() *cobra.Command {
+	var agentAddr string
+
+	cmd := &cobra.Command{
+		Use:   "config-rollback [revision]",
+		Short: "Roll back a Grafana Agent Flow to a previous config",
+		Long: `config-rollback makes a Grafana Agent Flow apply the config of a revision of
+its config history again, as listed by config-history. The config file of the
+agent is overwritten with the config of the revision.
+
+When the agent polls a remote config bundle, the bundle which was active isn't
+applied again until the remote bundle changes.`,
+		Args: cobra.ExactArgs(1),
+
+		Run: func(_ *cobra.Command, args []string) {
+			revision, err := strconv.Atoi(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid revision %q\n", args[0])
+				os.Exit(1)
+			}
+
+			if err := agentctl.RollbackConfig(context.Background(), agentAddr, revision); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to roll back config: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stdout, "rolled back to revision %d\n", revision)
+		},
+	}
+
+	cmd.Flags().StringVarP(&agentAddr, "addr", "a", "http://localhost:12345", "address of the agent to connect to")
+	return cmd
+}
+
 func samplesCmd() *cobra.Command {
 	var selector string
 
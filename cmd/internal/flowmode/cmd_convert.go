@@ -0,0 +1,107 @@
+package flowmode
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/converter"
+	convert_diag "github.com/grafana/agent/converter/diag"
+)
+
+func convertCommand() *cobra.Command {
+	f := &flowConvert{
+		output:       "",
+		sourceFormat: "",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "convert [flags] file",
+		Short: "Convert a supported config file to River",
+		Long: `The convert subcommand translates a supported config file to a River
+configuration file.
+
+If the file argument is not supplied or if the file argument is "-", then convert will read from stdin.
+
+The -o flag can be used to write the converted file to disk. When -o is not provided, convert will write the result to stdout.
+
+The -f flag sets the format of the file to convert, one of "prometheus" or "promtail".`,
+		Args:         cobra.RangeArgs(0, 1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				// Read from stdin when there are no args provided.
+				return f.Run("-")
+			}
+			return f.Run(args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&f.output, "output", "o", f.output, "The filepath where the output is written.")
+	cmd.Flags().StringVarP(&f.sourceFormat, "source-format", "f", f.sourceFormat, "The format of the source file. Supported formats: 'prometheus', 'promtail'.")
+	return cmd
+}
+
+type flowConvert struct {
+	output       string
+	sourceFormat string
+}
+
+func (fc *flowConvert) Run(configFile string) error {
+	if fc.sourceFormat == "" {
+		return fmt.Errorf("source-format is a required flag")
+	}
+
+	if configFile == "-" {
+		return convert(os.Stdin, fc)
+	}
+
+	fi, err := os.Stat(configFile)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("cannot convert a directory")
+	}
+
+	f, err := os.Open(configFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return convert(f, fc)
+}
+
+func convert(r io.Reader, fc *flowConvert) error {
+	inputBytes, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	riverBytes, diags := converter.Convert(inputBytes, converter.Input(fc.sourceFormat))
+
+	// The converted config is only written when the conversion has no errors.
+	var hasErrors bool
+	for _, diag := range diags {
+		switch diag.Severity {
+		case convert_diag.SeverityLevelError:
+			hasErrors = true
+			fmt.Fprintf(os.Stderr, "error: %s\n", diag)
+		default:
+			fmt.Fprintf(os.Stderr, "warning: %s\n", diag)
+		}
+	}
+	if hasErrors {
+		return fmt.Errorf("encountered errors during conversion")
+	}
+
+	if fc.output == "" {
+		_, err := os.Stdout.Write(riverBytes)
+		return err
+	}
+
+	return os.WriteFile(fc.output, riverBytes, 0664)
+}
@@ -0,0 +1,100 @@
+package flowmode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/river/diag"
+)
+
+func lintCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lint [flags] file",
+		Short: "Validate a River file without running it",
+		Long: `The lint subcommand checks the specified River configuration file
+without starting any component.
+
+lint parses the file, resolves references between components, and checks
+the arguments of every component against its schema, including the types of
+the exports of other components assigned to arguments.
+
+Exports are only known when components run, so failed validations of
+arguments which depend on the exports of other components are reported as
+warnings. lint exits with a non-zero code when errors are found.
+
+If the file argument is not supplied or if the file argument is "-", then lint will read from stdin.`,
+		Args:         cobra.RangeArgs(0, 1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				// Read from stdin when there are no args provided.
+				return runLint("-")
+			}
+			return runLint(args[0])
+		},
+	}
+
+	return cmd
+}
+
+func runLint(configFile string) error {
+	var (
+		name = configFile
+		r    io.Reader
+	)
+	switch configFile {
+	case "-":
+		name = "<stdin>"
+		r = os.Stdin
+	default:
+		fi, err := os.Stat(configFile)
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return fmt.Errorf("cannot lint a directory")
+		}
+
+		f, err := os.Open(configFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	bb, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var diags diag.Diagnostics
+	f, err := flow.ReadFile(name, bb)
+	if err != nil {
+		if !errors.As(err, &diags) {
+			return err
+		}
+	} else {
+		diags = flow.Lint(f)
+	}
+
+	if len(diags) > 0 {
+		p := diag.NewPrinter(diag.PrinterConfig{
+			Color:              !color.NoColor,
+			ContextLinesBefore: 1,
+			ContextLinesAfter:  1,
+		})
+		_ = p.Fprint(os.Stderr, map[string][]byte{name: bb}, diags)
+	}
+	if diags.HasErrors() {
+		return fmt.Errorf("encountered errors during linting")
+	}
+	return nil
+}
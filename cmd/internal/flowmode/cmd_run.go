@@ -2,6 +2,7 @@ package flowmode
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -9,8 +10,12 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/web/api"
@@ -27,10 +32,14 @@ import (
 	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/config/instrumentation"
 	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/componentpolicy"
 	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/flow/plugin"
 	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/kv"
 	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/grafana/agent/pkg/usagestats"
+	"github.com/grafana/agent/pkg/util/certreload"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
@@ -48,10 +57,15 @@ func runCommand() *cobra.Command {
 		uiPrefix:         "/",
 		disableReporting: false,
 		enablePprof:      true,
+
+		remotePollFrequency: time.Minute,
+		remoteVerify:        remoteVerifySHA256,
+
+		configHistorySize: 10,
 	}
 
 	cmd := &cobra.Command{
-		Use:   "run [flags] file",
+		Use:   "run [flags] path...",
 		Short: "Run Grafana Agent Flow",
 		Long: `The run subcommand runs Grafana Agent Flow in the foreground until an interrupt
 is received.
@@ -60,6 +74,12 @@ run must be provided an argument pointing at the River file to use. If the
 River file wasn't specified, can't be loaded, or contains errors, run will exit
 immediately.
 
+The config may instead be split across several River files by providing
+multiple paths, or a directory, whose *.river files are read in the order of
+their names. The files are merged into a single config, and blocks may only be
+declared in one of them. Remote config bundles, rollbacks, and editing from the
+UI aren't supported then.
+
 run starts an HTTP server which can be used to debug Grafana Agent Flow or
 force it to reload (by sending a GET or POST request to /-/reload). The listen
 address can be changed through the --server.http.listen-addr flag.
@@ -68,6 +88,20 @@ By default, the HTTP server exposes a debugging UI at /. The path of the
 debugging UI can be changed by providing a different value to
 --server.http.ui-path-prefix.
 
+The debugging UI is read-only unless --server.http.ui-read-write-token-file is
+provided. In read-write mode, the arguments of components can be edited from
+the UI, which rewrites the River file and reloads it. Edits must be
+authenticated with the token read from the file.
+
+The HTTP server accepts every request unless --server.http.auth-file is
+provided. The auth file is a YAML file with a read and a write policy, each
+listing the basic auth users, bearer tokens, and client certificate common
+names it accepts. Requests which change the state of the agent, such as
+/-/reload, must pass the write policy, and other requests either policy.
+/-/ready isn't authenticated. HTTPS is served when --server.http.tls-cert-file
+and --server.http.tls-key-file are provided, and client certificates are
+verified against --server.http.tls-client-ca-file.
+
 Additionally, the HTTP server exposes the following debug endpoints:
 
   /debug/pprof   Go performance profiling tools
@@ -75,12 +109,35 @@ Additionally, the HTTP server exposes the following debug endpoints:
 If reloading the config file fails, Grafana Agent Flow will continue running in
 its last valid state. Components which failed may be be listed as unhealthy,
 depending on the nature of the reload error.
+The errors of the most recent reload are served as JSON from
+/api/v0/diagnostics. The currently loaded config, with every block holding its
+evaluated arguments and secrets redacted, is served from /-/config.
+
+If --config.remote.url is provided, run polls a River config bundle from an
+HTTP(S) URL, an s3:// URL, or a gs:// URL and verifies its detached checksum
+or signature before loading it. Bundles which fail to load are rolled back to
+the previous bundle. The file argument then caches the last bundle which
+loaded successfully, so that run can start while the bundle can't be fetched.
+
+The last --config.history-size configs which loaded successfully are kept in
+the config-history directory of --storage.path. They are listed from
+/api/v0/config/history, and a POST request to
+/api/v0/config/history/<revision>/rollback applies a previous revision again.
+
+If --component.policy-file is provided, only the components allowed by the
+policy file may be used, including by modules. Loading a config which uses any
+other component fails. The policy file is a YAML file with allow and deny lists
+of component name patterns, such as "otelcol.exporter.*".
+
+If --component.plugins-dir is provided, every executable file in the directory
+is started as a plugin, which registers an external component. Plugins are
+started again for every block of their component in the config.
 `,
-		Args:         cobra.ExactArgs(1),
+		Args:         cobra.MinimumNArgs(1),
 		SilenceUsage: true,
 
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return r.Run(args[0])
+			return r.Run(args...)
 		},
 	}
 
@@ -89,6 +146,11 @@ depending on the nature of the reload error.
 	cmd.Flags().StringVar(&r.inMemoryAddr, "server.http.memory-addr", r.inMemoryAddr, "Address to listen for in-memory HTTP traffic on. Change if it collides with a real address")
 	cmd.Flags().StringVar(&r.storagePath, "storage.path", r.storagePath, "Base directory where components can store data")
 	cmd.Flags().StringVar(&r.uiPrefix, "server.http.ui-path-prefix", r.uiPrefix, "Prefix to serve the HTTP UI at")
+	cmd.Flags().StringVar(&r.uiReadWriteTokenFile, "server.http.ui-read-write-token-file", r.uiReadWriteTokenFile, "File containing the token authenticating edits from the HTTP UI. Enables editing components from the UI when set")
+	cmd.Flags().StringVar(&r.httpAuthFile, "server.http.auth-file", r.httpAuthFile, "YAML file with the credentials allowed to read from and write to the HTTP server")
+	cmd.Flags().StringVar(&r.httpTLSCertFile, "server.http.tls-cert-file", r.httpTLSCertFile, "Certificate file to serve HTTPS with")
+	cmd.Flags().StringVar(&r.httpTLSKeyFile, "server.http.tls-key-file", r.httpTLSKeyFile, "Key file to serve HTTPS with")
+	cmd.Flags().StringVar(&r.httpTLSClientCAFile, "server.http.tls-client-ca-file", r.httpTLSClientCAFile, "File with the CAs verifying client certificates presented to the HTTPS server")
 	cmd.Flags().
 		BoolVar(&r.enablePprof, "server.http.enable-pprof", r.enablePprof, "Enable /debug/pprof profiling endpoints.")
 	cmd.Flags().
@@ -97,6 +159,26 @@ depending on the nature of the reload error.
 		StringVar(&r.clusterAdvAddr, "cluster.advertise-address", r.clusterAdvAddr, "Address to advertise to the cluster")
 	cmd.Flags().
 		StringVar(&r.clusterJoinAddr, "cluster.join-addresses", r.clusterJoinAddr, "Comma-separated list of addresses to join the cluster at")
+	cmd.Flags().
+		StringVar(&r.clusterKeyFile, "cluster.key-file", r.clusterKeyFile, "File holding keys to encrypt and authenticate traffic between cluster nodes")
+	cmd.Flags().
+		StringVar(&r.clusterAllowedPeers, "cluster.allowed-peers", r.clusterAllowedPeers, "Comma-separated list of patterns of node names allowed to join the cluster. Requires --cluster.key-file")
+	cmd.Flags().
+		StringVar(&r.remoteURL, "config.remote.url", r.remoteURL, "URL of a River config bundle to poll, using the http, https, s3, or gs scheme")
+	cmd.Flags().
+		DurationVar(&r.remotePollFrequency, "config.remote.poll-frequency", r.remotePollFrequency, "How often to poll the remote config bundle")
+	cmd.Flags().
+		StringVar(&r.remoteVerify, "config.remote.verify", r.remoteVerify, "How to verify remote config bundles: sha256, signature, or none")
+	cmd.Flags().
+		StringVar(&r.remotePublicKeyFile, "config.remote.public-key-file", r.remotePublicKeyFile, "PEM file holding the Ed25519 public key verifying remote config signatures")
+	cmd.Flags().
+		IntVar(&r.configHistorySize, "config.history-size", r.configHistorySize, "Number of configs which loaded successfully to keep for rollbacks. 0 disables the config history")
+	cmd.Flags().
+		DurationVar(&r.componentCPUSamplingInterval, "component.cpu-sampling-interval", r.componentCPUSamplingInterval, "How often to profile the agent to estimate the CPU time spent by each component. 0 disables sampling")
+	cmd.Flags().
+		StringVar(&r.componentPolicyFile, "component.policy-file", r.componentPolicyFile, "YAML file restricting which components may be used")
+	cmd.Flags().
+		StringVar(&r.componentPluginsDir, "component.plugins-dir", r.componentPluginsDir, "Directory of plugins implementing external components")
 	cmd.Flags().
 		BoolVar(&r.disableReporting, "disable-reporting", r.disableReporting, "Disable reporting of enabled components to Grafana.")
 	return cmd
@@ -112,18 +194,70 @@ type flowRun struct {
 	clusterEnabled   bool
 	clusterAdvAddr   string
 	clusterJoinAddr  string
+
+	clusterKeyFile      string
+	clusterAllowedPeers string
+
+	uiReadWriteTokenFile string
+
+	httpAuthFile        string
+	httpTLSCertFile     string
+	httpTLSKeyFile      string
+	httpTLSClientCAFile string
+
+	remoteURL           string
+	remotePollFrequency time.Duration
+	remoteVerify        string
+	remotePublicKeyFile string
+
+	configHistorySize int
+
+	componentCPUSamplingInterval time.Duration
+	componentPolicyFile          string
+	componentPluginsDir          string
 }
 
-func (fr *flowRun) Run(configFile string) error {
+func (fr *flowRun) Run(paths ...string) error {
 	var wg sync.WaitGroup
 	defer wg.Wait()
 
 	ctx, cancel := interruptContext()
 	defer cancel()
 
-	if configFile == "" {
+	if len(paths) == 0 || paths[0] == "" {
 		return fmt.Errorf("file argument not provided")
 	}
+	source := configSource{paths: paths}
+	configFile := paths[0]
+	if source.MultiFile() {
+		switch {
+		case fr.remoteURL != "":
+			return fmt.Errorf("--config.remote.url can't be used when the config is read from multiple files")
+		case fr.uiReadWriteTokenFile != "":
+			return fmt.Errorf("--server.http.ui-read-write-token-file can't be used when the config is read from multiple files")
+		}
+	}
+
+	useTLS := fr.httpTLSCertFile != "" || fr.httpTLSKeyFile != ""
+	switch {
+	case fr.httpTLSClientCAFile != "" && !useTLS:
+		return fmt.Errorf("--server.http.tls-client-ca-file requires --server.http.tls-cert-file and --server.http.tls-key-file")
+	case useTLS && fr.clusterEnabled:
+		// Cluster peers talk to each other over plain HTTP/2.
+		return fmt.Errorf("--server.http.tls-cert-file can't be used with --cluster.enabled")
+	}
+
+	var auth *httpAuth
+	if fr.httpAuthFile != "" {
+		var err error
+		auth, err = readHTTPAuth(fr.httpAuthFile)
+		if err != nil {
+			return err
+		}
+		if auth.usesClientCerts() && fr.httpTLSClientCAFile == "" {
+			return fmt.Errorf("client certificates in the HTTP auth file require --server.http.tls-client-ca-file")
+		}
+	}
 
 	l, err := logging.New(os.Stderr, logging.DefaultOptions)
 	if err != nil {
@@ -158,7 +292,7 @@ func (fr *flowRun) Run(configFile string) error {
 	reg := prometheus.DefaultRegisterer
 	reg.MustRegister(newResourcesCollector(l))
 
-	clusterer, err := cluster.New(l, reg, fr.clusterEnabled, fr.httpListenAddr, fr.clusterAdvAddr, fr.clusterJoinAddr)
+	clusterer, err := cluster.New(l, reg, fr.clusterEnabled, fr.httpListenAddr, fr.clusterAdvAddr, fr.clusterJoinAddr, fr.clusterKeyFile, fr.clusterAllowedPeers)
 	if err != nil {
 		return fmt.Errorf("building clusterer: %w", err)
 	}
@@ -169,6 +303,31 @@ func (fr *flowRun) Run(configFile string) error {
 		}
 	}()
 
+	kvStore, err := kv.New(fr.storagePath)
+	if err != nil {
+		return fmt.Errorf("building key-value store: %w", err)
+	}
+	lifetimeTotals, err := api.NewLifetimeTotals(l, prometheus.DefaultGatherer, kvStore.Bucket(api.TotalsBucket))
+	if err != nil {
+		return fmt.Errorf("building lifetime totals: %w", err)
+	}
+
+	// Components of plugins must be registered before the config is loaded.
+	if fr.componentPluginsDir != "" {
+		if err := plugin.LoadDir(l, fr.componentPluginsDir); err != nil {
+			return fmt.Errorf("loading component plugins: %w", err)
+		}
+	}
+
+	var policy *componentpolicy.Policy
+	if fr.componentPolicyFile != "" {
+		policy, err = componentpolicy.ReadFile(fr.componentPolicyFile)
+		if err != nil {
+			return fmt.Errorf("reading component policy: %w", err)
+		}
+		level.Info(l).Log("msg", "restricting components to the component policy", "file", fr.componentPolicyFile)
+	}
+
 	// In-memory listener, used for inner HTTP traffic without the network.
 	memLis := memconn.NewListener(nil)
 
@@ -176,11 +335,16 @@ func (fr *flowRun) Run(configFile string) error {
 		Logger:         l,
 		Tracer:         t,
 		Clusterer:      clusterer,
+		KV:             kvStore,
 		DataPath:       fr.storagePath,
 		Reg:            reg,
 		HTTPPathPrefix: "/api/v0/component/",
 		HTTPListenAddr: fr.inMemoryAddr,
 
+		ComponentCPUSamplingInterval: fr.componentCPUSamplingInterval,
+		ComponentOutputs:             api.ComponentOutputs(prometheus.DefaultGatherer),
+		ComponentPolicy:              policy,
+
 		// Send requests to fr.inMemoryAddr directly to our in-memory listener.
 		DialFunc: func(ctx context.Context, network, address string) (net.Conn, error) {
 			switch address {
@@ -192,12 +356,12 @@ func (fr *flowRun) Run(configFile string) error {
 		},
 	})
 
-	reload := func() error {
-		flowCfg, err := loadFlowFile(configFile)
+	load := func() error {
+		flowCfg, err := source.Load()
 		defer instrumentation.InstrumentLoad(err == nil)
 
 		if err != nil {
-			return fmt.Errorf("reading config file %q: %w", configFile, err)
+			return fmt.Errorf("reading config file %q: %w", source.Name(), err)
 		}
 		if err := f.LoadFile(flowCfg, nil); err != nil {
 			return fmt.Errorf("error during the initial gragent load: %w", err)
@@ -206,6 +370,104 @@ func (fr *flowRun) Run(configFile string) error {
 		return nil
 	}
 
+	// Configs which loaded successfully are recorded in the config history so
+	// that they can be rolled back to.
+	var history *configHistory
+	if fr.configHistorySize > 0 {
+		history, err = newConfigHistory(filepath.Join(fr.storagePath, "config-history"), fr.configHistorySize)
+		if err != nil {
+			return fmt.Errorf("building config history: %w", err)
+		}
+	}
+	recordHistory := func(bb []byte, source string) {
+		if history == nil {
+			return
+		}
+		if err := history.Record(bb, source); err != nil {
+			level.Warn(l).Log("msg", "failed to record config in the config history", "err", err)
+		}
+	}
+
+	// reload loads the config file and records the outcome so it can be
+	// retrieved from the diagnostics API.
+	diagnostics := newReloadDiagnostics(source, f.ComponentChanges)
+	reload := func() (*reloadResult, error) {
+		err := load()
+		if err == nil {
+			// Configs read from multiple files are recorded merged together.
+			files, _ := source.ReadFiles()
+			bb := files[configFile]
+			if source.MultiFile() {
+				bb = mergeConfigFiles(files)
+			}
+			recordHistory(bb, configSourceFile)
+		}
+		return diagnostics.Record(err), err
+	}
+
+	// Remote config bundles are loaded in place of the config file, which
+	// caches the last bundle loaded successfully.
+	var remote *remoteConfig
+	if fr.remoteURL != "" {
+		loadRemote := func(bb []byte) error {
+			flowCfg, err := readFlowFile(configFile, bb)
+			defer instrumentation.InstrumentLoad(err == nil)
+
+			if err != nil {
+				return fmt.Errorf("reading remote config: %w", err)
+			}
+			return f.LoadFile(flowCfg, nil)
+		}
+		recordRemote := func(bb []byte, err error) {
+			diagnostics.RecordContent(bb, err)
+			if err == nil {
+				recordHistory(bb, configSourceRemote)
+			}
+		}
+
+		remote, err = newRemoteConfig(l, reg, remoteConfigOptions{
+			URL:           fr.remoteURL,
+			PollFrequency: fr.remotePollFrequency,
+			Verify:        fr.remoteVerify,
+			PublicKeyFile: fr.remotePublicKeyFile,
+			CachePath:     configFile,
+		}, loadRemote, recordRemote)
+		if err != nil {
+			return fmt.Errorf("building remote config: %w", err)
+		}
+	}
+
+	// rollback applies a config from the config history again. The config file
+	// is overwritten with it, so that it's kept by later reloads and restarts.
+	rollback := func(bb []byte) (*reloadResult, error) {
+		var err error
+		if remote != nil {
+			err = remote.Rollback(bb)
+		} else if source.MultiFile() {
+			err = fmt.Errorf("rollbacks aren't supported when the config is read from multiple files")
+		} else {
+			err = func() error {
+				flowCfg, err := readFlowFile(configFile, bb)
+				defer instrumentation.InstrumentLoad(err == nil)
+
+				if err != nil {
+					return fmt.Errorf("reading config revision: %w", err)
+				}
+				if err := f.LoadFile(flowCfg, nil); err != nil {
+					return err
+				}
+				if err := writeFileAtomic(configFile, bb); err != nil {
+					return fmt.Errorf("config rolled back, but writing the config file failed: %w", err)
+				}
+				return nil
+			}()
+		}
+		if err == nil {
+			recordHistory(bb, configSourceRollback)
+		}
+		return diagnostics.RecordContent(bb, err), err
+	}
+
 	// Flow controller
 	{
 		wg.Add(1)
@@ -215,6 +477,15 @@ func (fr *flowRun) Run(configFile string) error {
 		}()
 	}
 
+	// Lifetime totals of components
+	{
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lifetimeTotals.Run(ctx, time.Minute)
+		}()
+	}
+
 	// HTTP server
 	{
 		// Network listener.
@@ -239,6 +510,10 @@ func (fr *flowRun) Run(configFile string) error {
 		cr, ch := clusterer.Node.Handler()
 		r.PathPrefix(cr).Handler(ch)
 
+		// Register routes for the key-value store.
+		kr, kh := kvStore.Handler()
+		r.PathPrefix(kr).Handler(kh)
+
 		r.HandleFunc("/-/ready", func(w http.ResponseWriter, _ *http.Request) {
 			if f.Ready() {
 				w.WriteHeader(http.StatusOK)
@@ -249,44 +524,141 @@ func (fr *flowRun) Run(configFile string) error {
 			}
 		})
 
-		r.HandleFunc("/-/reload", func(w http.ResponseWriter, _ *http.Request) {
+		r.HandleFunc("/-/reload", func(w http.ResponseWriter, req *http.Request) {
 			level.Info(l).Log("msg", "reload requested via /-/reload endpoint")
-			defer level.Info(l).Log("msg", "config reloaded")
 
-			err := reload()
+			res, err := reload()
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
-				return
+				level.Error(l).Log("msg", "failed to reload config", "err", err)
+			} else {
+				level.Info(l).Log("msg", "config reloaded")
 			}
-			fmt.Fprintln(w, "config reloaded")
+			diagnostics.writeReloadResponse(w, req, err, res)
 		}).Methods(http.MethodGet, http.MethodPost)
+		r.Handle("/api/v0/diagnostics", diagnostics.Handler()).Methods(http.MethodGet)
+
+		r.HandleFunc("/-/config", func(w http.ResponseWriter, _ *http.Request) {
+			bb, err := f.EvaluatedConfig()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_, _ = w.Write(bb)
+		}).Methods(http.MethodGet)
+
+		if history != nil {
+			r.Handle("/api/v0/config/history", history.ListHandler()).Methods(http.MethodGet)
+			r.Handle("/api/v0/config/history/{revision}", history.ContentHandler()).Methods(http.MethodGet)
+			r.HandleFunc("/api/v0/config/history/{revision}/rollback", func(w http.ResponseWriter, req *http.Request) {
+				revision, err := strconv.Atoi(mux.Vars(req)["revision"])
+				if err != nil {
+					http.Error(w, "invalid revision", http.StatusBadRequest)
+					return
+				}
+				bb, err := history.Content(revision)
+				if errors.Is(err, errRevisionNotFound) {
+					http.Error(w, err.Error(), http.StatusNotFound)
+					return
+				} else if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+
+				level.Info(l).Log("msg", "rollback requested via the config history API", "revision", revision)
+				res, err := rollback(bb)
+				if err != nil {
+					level.Error(l).Log("msg", "failed to roll back config", "revision", revision, "err", err)
+				} else {
+					level.Info(l).Log("msg", "config rolled back", "revision", revision)
+				}
+				diagnostics.writeContentResponse(w, req, bb, err, res)
+			}).Methods(http.MethodPost)
+		}
 
 		// Register Routes must be the last
 		fa := api.NewFlowAPI(f)
+		fa.EnableThroughput(prometheus.DefaultGatherer)
+		fa.EnableLifetimeTotals(lifetimeTotals)
+		if fr.uiReadWriteTokenFile != "" {
+			token, err := readUIToken(fr.uiReadWriteTokenFile)
+			if err != nil {
+				return err
+			}
+			if auth != nil {
+				// The UI sends its token as a bearer token, which must pass the
+				// write policy too.
+				auth.Write.BearerTokens = append(auth.Write.BearerTokens, token)
+			}
+			fa.EnableReadWrite(api.NewFileEditor(configFile, func() error {
+				level.Info(l).Log("msg", "reload requested by component edit from the UI")
+				_, err := reload()
+				return err
+			}), token)
+		}
 		fa.RegisterRoutes(path.Join(fr.uiPrefix, "/api/v0/web"), r)
 
 		// NOTE(rfratto): keep this at the bottom of all other routes, otherwise it
 		// will take precedence over anything else mapped in uiPrefix.
 		ui.RegisterRoutes(fr.uiPrefix, r)
 
-		srv := &http.Server{Handler: h2c.NewHandler(r, &http2.Server{})}
+		// Requests over the in-memory listener come from the agent itself and
+		// aren't authenticated. Readiness checks and traffic between cluster
+		// peers aren't authenticated either.
+		var netHandler http.Handler = r
+		if auth != nil {
+			netHandler = auth.Wrap(r, "/-/ready", cr)
+			level.Info(l).Log("msg", "authenticating http traffic", "file", fr.httpAuthFile)
+		}
+		srv := &http.Server{Handler: h2c.NewHandler(netHandler, &http2.Server{})}
+		memSrv := &http.Server{Handler: h2c.NewHandler(r, &http2.Server{})}
+
+		if useTLS {
+			reloader, err := certreload.New(l, certreload.Files{
+				CertFile:     fr.httpTLSCertFile,
+				KeyFile:      fr.httpTLSKeyFile,
+				ClientCAFile: fr.httpTLSClientCAFile,
+			}, 0)
+			if err != nil {
+				return fmt.Errorf("loading HTTP server certificates: %w", err)
+			}
+			srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			if fr.httpTLSClientCAFile != "" {
+				// Client certificates are optional, since the auth file may
+				// accept other credentials.
+				srv.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+			reloader.Apply(srv.TLSConfig)
+		}
 
-		level.Info(l).Log("msg", "now listening for http traffic", "addr", fr.httpListenAddr)
+		level.Info(l).Log("msg", "now listening for http traffic", "addr", fr.httpListenAddr, "tls", useTLS)
 
-		listeners := []net.Listener{netLis, memLis}
-		for _, lis := range listeners {
+		servers := []struct {
+			srv *http.Server
+			lis net.Listener
+		}{{srv, netLis}, {memSrv, memLis}}
+		for _, s := range servers {
 			wg.Add(1)
-			go func(lis net.Listener) {
+			go func(srv *http.Server, lis net.Listener) {
 				defer wg.Done()
 				defer cancel()
 
-				if err := srv.Serve(lis); err != nil {
+				var err error
+				if srv.TLSConfig != nil {
+					err = srv.ServeTLS(lis, "", "")
+				} else {
+					err = srv.Serve(lis)
+				}
+				if err != nil {
 					level.Info(l).Log("msg", "http server closed", "addr", lis.Addr(), "err", err)
 				}
-			}(lis)
+			}(s.srv, s.lis)
 		}
 
-		defer func() { _ = srv.Shutdown(ctx) }()
+		defer func() {
+			_ = srv.Shutdown(ctx)
+			_ = memSrv.Shutdown(ctx)
+		}()
 	}
 
 	// Report usage of enabled components
@@ -312,17 +684,26 @@ func (fr *flowRun) Run(configFile string) error {
 	// Perform the initial reload. This is done after starting the HTTP server so
 	// that /metric and pprof endpoints are available while the Flow controller
 	// is loading.
-	if err := reload(); err != nil {
+	//
+	// When using remote config without a cached bundle, the initial load
+	// fetches the bundle instead.
+	_, statErr := os.Stat(configFile)
+	if remote != nil && errors.Is(statErr, os.ErrNotExist) {
+		level.Info(l).Log("msg", "config file doesn't exist, fetching the remote config", "url", fr.remoteURL)
+		if err := remote.Poll(ctx); err != nil {
+			return fmt.Errorf("could not perform the initial load successfully: %w", err)
+		}
+	} else if _, err := reload(); err != nil {
 		var diags diag.Diagnostics
 		if errors.As(err, &diags) {
-			bb, _ := os.ReadFile(configFile)
+			files, _ := source.ReadFiles()
 
 			p := diag.NewPrinter(diag.PrinterConfig{
 				Color:              !color.NoColor,
 				ContextLinesBefore: 1,
 				ContextLinesAfter:  1,
 			})
-			_ = p.Fprint(os.Stderr, map[string][]byte{configFile: bb}, diags)
+			_ = p.Fprint(os.Stderr, files, diags)
 
 			// Print newline after the diagnostics.
 			fmt.Println()
@@ -332,6 +713,17 @@ func (fr *flowRun) Run(configFile string) error {
 
 		// Exit if the initial load files
 		return err
+	} else if remote != nil {
+		bb, _ := os.ReadFile(configFile)
+		remote.SetActive(bb)
+	}
+
+	if remote != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			remote.Run(ctx)
+		}()
 	}
 
 	reloadSignal := make(chan os.Signal, 1)
@@ -343,7 +735,7 @@ func (fr *flowRun) Run(configFile string) error {
 		case <-ctx.Done():
 			return nil
 		case <-reloadSignal:
-			if err := reload(); err != nil {
+			if _, err := reload(); err != nil {
 				level.Error(l).Log("msg", "failed to reload config", "err", err)
 			} else {
 				level.Info(l).Log("msg", "config reloaded")
@@ -365,12 +757,21 @@ func getEnabledComponentsFunc(f *flow.Flow) func() map[string]interface{} {
 	}
 }
 
-func loadFlowFile(filename string) (*flow.File, error) {
+// readUIToken reads the token authenticating edits from the UI.
+func readUIToken(filename string) (string, error) {
 	bb, err := os.ReadFile(filename)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("reading UI read-write token file: %w", err)
+	}
+	token := strings.TrimSpace(string(bb))
+	if token == "" {
+		return "", fmt.Errorf("UI read-write token file %q is empty", filename)
 	}
+	return token, nil
+}
 
+// readFlowFile parses the content bb of the config file filename.
+func readFlowFile(filename string, bb []byte) (*flow.File, error) {
 	instrumentation.InstrumentConfig(bb)
 
 	return flow.ReadFile(filename, bb)
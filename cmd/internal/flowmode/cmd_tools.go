@@ -0,0 +1,90 @@
+package flowmode
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func toolsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tools",
+		Short: "Utilities for inspecting a running Grafana Agent Flow",
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+
+	cmd.AddCommand(getConfigCommand())
+	return cmd
+}
+
+func getConfigCommand() *cobra.Command {
+	g := &flowGetConfig{
+		addr:    "127.0.0.1:12345",
+		timeout: 10 * time.Second,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "get-config [flags]",
+		Short: "Print the config loaded by a running Grafana Agent Flow",
+		Long: `The get-config subcommand prints the config currently loaded by a running
+Grafana Agent Flow, as served from its /-/config endpoint.
+
+Every block holds the arguments it was last evaluated to rather than the
+expressions written in the config file, and values of secret types are
+redacted. The config of each module follows the config file, introduced by a
+comment holding the ID of the module.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return g.Run(cmd.Context(), os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVar(&g.addr, "server.http.listen-addr", g.addr, "Address of the HTTP server of the running agent")
+	cmd.Flags().DurationVar(&g.timeout, "timeout", g.timeout, "Timeout for retrieving the config")
+	return cmd
+}
+
+type flowGetConfig struct {
+	addr    string
+	timeout time.Duration
+}
+
+func (g *flowGetConfig) Run(ctx context.Context, w io.Writer) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+
+	url := g.addr
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(url, "/")+"/-/config", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("failed to retrieve the config: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -0,0 +1,45 @@
+package flowmode
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/-/config" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("logging {\n\tlevel = \"debug\"\n}\n"))
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	g := &flowGetConfig{addr: srv.URL, timeout: time.Second}
+	require.NoError(t, g.Run(context.Background(), &buf))
+	require.Equal(t, "logging {\n\tlevel = \"debug\"\n}\n", buf.String())
+
+	// Addresses without a scheme are accessed over HTTP.
+	buf.Reset()
+	g.addr = srv.Listener.Addr().String()
+	require.NoError(t, g.Run(context.Background(), &buf))
+	require.Equal(t, "logging {\n\tlevel = \"debug\"\n}\n", buf.String())
+}
+
+func TestGetConfig_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "failed to render config", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	g := &flowGetConfig{addr: srv.URL, timeout: time.Second}
+	err := g.Run(context.Background(), &bytes.Buffer{})
+	require.EqualError(t, err, "failed to retrieve the config: 500 Internal Server Error: failed to render config")
+}
@@ -0,0 +1,174 @@
+package flowmode
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Sources of the configs recorded in the config history.
+const (
+	configSourceFile     = "file"
+	configSourceRemote   = "remote"
+	configSourceRollback = "rollback"
+)
+
+// configHistoryIndex is the name of the file of the config history directory
+// which lists the recorded revisions.
+const configHistoryIndex = "history.json"
+
+// configRevision describes a config which was applied successfully.
+type configRevision struct {
+	Revision int       `json:"revision"`
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	SHA256   string    `json:"sha256"`
+}
+
+// configHistory keeps the last configs which were applied successfully in a
+// directory, so that they can be rolled back to.
+type configHistory struct {
+	dir  string
+	size int
+
+	mut       sync.Mutex
+	revisions []configRevision // Oldest first.
+}
+
+// newConfigHistory opens the config history in dir, which keeps the last size
+// configs.
+func newConfigHistory(dir string, size int) (*configHistory, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("config history size must be greater than 0")
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("creating config history directory: %w", err)
+	}
+
+	h := &configHistory{dir: dir, size: size}
+
+	bb, err := os.ReadFile(filepath.Join(dir, configHistoryIndex))
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading config history: %w", err)
+	}
+	if err := json.Unmarshal(bb, &h.revisions); err != nil {
+		return nil, fmt.Errorf("parsing config history: %w", err)
+	}
+	return h, nil
+}
+
+// Record records bb as a new revision applied from source. Nothing is recorded
+// if bb is the config of the latest revision.
+func (h *configHistory) Record(bb []byte, source string) error {
+	sum := fmt.Sprintf("%x", sha256.Sum256(bb))
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	rev := configRevision{Revision: 1, Time: time.Now(), Source: source, SHA256: sum}
+	if n := len(h.revisions); n > 0 {
+		if h.revisions[n-1].SHA256 == sum {
+			return nil
+		}
+		rev.Revision = h.revisions[n-1].Revision + 1
+	}
+
+	if err := writeFileAtomic(h.path(rev.Revision), bb); err != nil {
+		return fmt.Errorf("writing config revision: %w", err)
+	}
+
+	revisions := append(h.revisions, rev)
+	var pruned []configRevision
+	if len(revisions) > h.size {
+		pruned = revisions[:len(revisions)-h.size]
+		revisions = revisions[len(revisions)-h.size:]
+	}
+
+	index, err := json.Marshal(revisions)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(h.dir, configHistoryIndex), index); err != nil {
+		return fmt.Errorf("writing config history: %w", err)
+	}
+	h.revisions = append([]configRevision(nil), revisions...)
+
+	for _, old := range pruned {
+		_ = os.Remove(h.path(old.Revision))
+	}
+	return nil
+}
+
+// Revisions returns the recorded revisions, latest first.
+func (h *configHistory) Revisions() []configRevision {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	res := make([]configRevision, 0, len(h.revisions))
+	for i := len(h.revisions) - 1; i >= 0; i-- {
+		res = append(res, h.revisions[i])
+	}
+	return res
+}
+
+// Content returns the config of revision.
+func (h *configHistory) Content(revision int) ([]byte, error) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	for _, rev := range h.revisions {
+		if rev.Revision == revision {
+			return os.ReadFile(h.path(revision))
+		}
+	}
+	return nil, errRevisionNotFound
+}
+
+var errRevisionNotFound = errors.New("config revision not found")
+
+func (h *configHistory) path(revision int) string {
+	return filepath.Join(h.dir, fmt.Sprintf("%d.river", revision))
+}
+
+// ListHandler returns an http.Handler which serves the recorded revisions as
+// JSON.
+func (h *configHistory) ListHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(h.Revisions())
+	})
+}
+
+// ContentHandler returns an http.Handler which serves the config of the
+// revision in the path.
+func (h *configHistory) ContentHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		revision, err := strconv.Atoi(mux.Vars(r)["revision"])
+		if err != nil {
+			http.Error(w, "invalid revision", http.StatusBadRequest)
+			return
+		}
+
+		bb, err := h.Content(revision)
+		if errors.Is(err, errRevisionNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(bb)
+	})
+}
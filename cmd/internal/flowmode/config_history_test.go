@@ -0,0 +1,85 @@
+package flowmode
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigHistory(t *testing.T) {
+	dir := t.TempDir()
+
+	h, err := newConfigHistory(dir, 2)
+	require.NoError(t, err)
+
+	require.NoError(t, h.Record([]byte("first"), configSourceFile))
+	// Configs which didn't change aren't recorded again.
+	require.NoError(t, h.Record([]byte("first"), configSourceFile))
+	require.NoError(t, h.Record([]byte("second"), configSourceRemote))
+	require.NoError(t, h.Record([]byte("third"), configSourceRollback))
+
+	// Only the last two revisions are kept.
+	revisions := h.Revisions()
+	require.Len(t, revisions, 2)
+	require.Equal(t, 3, revisions[0].Revision)
+	require.Equal(t, configSourceRollback, revisions[0].Source)
+	require.Equal(t, 2, revisions[1].Revision)
+
+	_, err = h.Content(1)
+	require.ErrorIs(t, err, errRevisionNotFound)
+	require.NoFileExists(t, h.path(1))
+
+	// The history is kept across restarts.
+	h, err = newConfigHistory(dir, 2)
+	require.NoError(t, err)
+	reloaded := h.Revisions()
+	require.Len(t, reloaded, 2)
+	for i := range revisions {
+		require.Equal(t, revisions[i].Revision, reloaded[i].Revision)
+		require.Equal(t, revisions[i].SHA256, reloaded[i].SHA256)
+		require.True(t, revisions[i].Time.Equal(reloaded[i].Time))
+	}
+
+	bb, err := h.Content(2)
+	require.NoError(t, err)
+	require.Equal(t, "second", string(bb))
+
+	require.NoError(t, h.Record([]byte("fourth"), configSourceFile))
+	require.Equal(t, 4, h.Revisions()[0].Revision)
+}
+
+func TestConfigHistory_Handlers(t *testing.T) {
+	h, err := newConfigHistory(t.TempDir(), 10)
+	require.NoError(t, err)
+	require.NoError(t, h.Record([]byte("first"), configSourceFile))
+
+	r := mux.NewRouter()
+	r.Handle("/api/v0/config/history", h.ListHandler())
+	r.Handle("/api/v0/config/history/{revision}", h.ContentHandler())
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{path: "/api/v0/config/history/1", status: http.StatusOK, body: "first"},
+		{path: "/api/v0/config/history/2", status: http.StatusNotFound},
+		{path: "/api/v0/config/history/latest", status: http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		require.Equal(t, tc.status, rec.Code, tc.path)
+		if tc.body != "" {
+			require.Equal(t, tc.body, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v0/config/history", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"revision":1`)
+	require.Contains(t, rec.Body.String(), `"source":"file"`)
+}
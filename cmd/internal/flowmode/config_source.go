@@ -0,0 +1,137 @@
+package flowmode
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/pkg/config/instrumentation"
+	"github.com/grafana/agent/pkg/flow"
+)
+
+// configSource is the set of River files passed to run. Directories are
+// expanded into the *.river files they directly contain, and all files are
+// merged into a single config.
+type configSource struct {
+	paths []string
+}
+
+// MultiFile returns whether the config may be made of more than one file,
+// which is the case when more than one path or a directory was passed.
+// Features which rewrite the config file aren't supported then.
+func (s configSource) MultiFile() bool {
+	if len(s.paths) != 1 {
+		return true
+	}
+	fi, err := os.Stat(s.paths[0])
+	return err == nil && fi.IsDir()
+}
+
+// Name returns the name of the config used in messages.
+func (s configSource) Name() string {
+	return strings.Join(s.paths, ", ")
+}
+
+// ReadFiles reads the files making up the config, keyed by file name. The
+// files read successfully are returned along with the first error.
+func (s configSource) ReadFiles() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for _, path := range s.paths {
+		names, err := expandConfigPath(path)
+		if err != nil {
+			return files, err
+		}
+		for _, name := range names {
+			if _, ok := files[name]; ok {
+				return files, fmt.Errorf("config file %q passed more than once", name)
+			}
+			bb, err := os.ReadFile(name)
+			if err != nil {
+				return files, err
+			}
+			files[name] = bb
+		}
+	}
+	if len(files) == 0 {
+		return files, fmt.Errorf("no *.river files found in %s", s.Name())
+	}
+	return files, nil
+}
+
+// Load reads and parses the files making up the config.
+func (s configSource) Load() (*flow.File, error) {
+	files, err := s.ReadFiles()
+	if err != nil {
+		return nil, err
+	}
+	return s.Parse(files)
+}
+
+// Parse parses files read by ReadFiles into a single flow.File.
+func (s configSource) Parse(files map[string][]byte) (*flow.File, error) {
+	if !s.MultiFile() {
+		return readFlowFile(s.paths[0], files[s.paths[0]])
+	}
+
+	instrumentation.InstrumentConfig(mergeConfigFiles(files))
+	return flow.ReadFiles(s.Name(), files)
+}
+
+// expandConfigPath returns the config files found at path: path itself if
+// it's a file, or the *.river files directly inside of path, sorted by name,
+// if it's a directory.
+func expandConfigPath(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if filepath.Ext(entry.Name()) != ".river" {
+			continue
+		}
+		// Follow symlinks, which is how files of mounted Kubernetes ConfigMaps
+		// are exposed.
+		name := filepath.Join(path, entry.Name())
+		if fi, err := os.Stat(name); err != nil || fi.IsDir() {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// mergeConfigFiles concatenates files into a single config, in the order of
+// their names, with each file introduced by a comment holding its name. It's
+// used where a single config needs to be stored, such as the config history.
+func mergeConfigFiles(files map[string][]byte) []byte {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "// File: %s\n", name)
+		buf.Write(files[name])
+		if bb := files[name]; len(bb) > 0 && bb[len(bb)-1] != '\n' {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes()
+}
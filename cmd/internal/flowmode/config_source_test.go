@@ -0,0 +1,85 @@
+package flowmode
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigSource_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "b.river"), `logging {}`)
+	writeTestFile(t, filepath.Join(dir, "a.river"), `tracing {}`)
+	writeTestFile(t, filepath.Join(dir, "README.md"), `not a config`)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested.river"), 0755))
+
+	source := configSource{paths: []string{dir}}
+	require.True(t, source.MultiFile())
+
+	files, err := source.ReadFiles()
+	require.NoError(t, err)
+	require.Equal(t, map[string][]byte{
+		filepath.Join(dir, "a.river"): []byte(`tracing {}`),
+		filepath.Join(dir, "b.river"): []byte(`logging {}`),
+	}, files)
+
+	f, err := source.Parse(files)
+	require.NoError(t, err)
+	require.Len(t, f.ConfigBlocks, 2)
+	require.Equal(t, []string{"tracing"}, f.ConfigBlocks[0].Name)
+	require.Equal(t, []string{"logging"}, f.ConfigBlocks[1].Name)
+
+	require.Equal(t, "// File: "+filepath.Join(dir, "a.river")+"\ntracing {}\n\n// File: "+filepath.Join(dir, "b.river")+"\nlogging {}\n", string(mergeConfigFiles(files)))
+}
+
+func TestConfigSource_MultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.river"), filepath.Join(dir, "b.river")
+	writeTestFile(t, a, "logging {}\n")
+	writeTestFile(t, b, "logging {}\n")
+
+	require.False(t, configSource{paths: []string{a}}.MultiFile())
+
+	source := configSource{paths: []string{b, a}}
+	require.True(t, source.MultiFile())
+
+	// Blocks declared in multiple files are reported in the file declaring
+	// them last.
+	_, err := source.Load()
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 1)
+	require.Equal(t, b, diags[0].StartPos.Filename)
+
+	_, err = configSource{paths: []string{a, a}}.ReadFiles()
+	require.ErrorContains(t, err, "passed more than once")
+}
+
+func TestConfigSource_EmptyDirectory(t *testing.T) {
+	_, err := configSource{paths: []string{t.TempDir()}}.Load()
+	require.ErrorContains(t, err, "no *.river files found")
+}
+
+func TestBuildReloadDiagnostics_MultipleFiles(t *testing.T) {
+	files := map[string][]byte{
+		"a.river": []byte("logging {}\n"),
+		"b.river": []byte("logging {}\n"),
+	}
+	source := configSource{paths: []string{"a.river", "b.river"}}
+	_, err := source.Parse(files)
+	require.Error(t, err)
+
+	diags := buildReloadDiagnostics(files, err)
+	require.Len(t, diags, 1)
+	require.Equal(t, "b.river", diags[0].File)
+	require.Equal(t, "logging", diags[0].Component)
+	require.Equal(t, "logging {}", diags[0].Snippet)
+}
+
+func writeTestFile(t *testing.T, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(name, []byte(content), 0644))
+}
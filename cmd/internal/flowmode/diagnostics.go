@@ -0,0 +1,365 @@
+package flowmode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/parser"
+)
+
+// maxSnippetLines is the maximum number of source lines included in the
+// snippet of a reloadDiagnostic.
+const maxSnippetLines = 10
+
+// reloadDiagnostic is a machine-readable description of a problem found
+// while reloading the config file.
+type reloadDiagnostic struct {
+	Severity    string `json:"severity"`
+	Component   string `json:"component,omitempty"`
+	File        string `json:"file,omitempty"`
+	StartLine   int    `json:"start_line,omitempty"`
+	StartColumn int    `json:"start_column,omitempty"`
+	EndLine     int    `json:"end_line,omitempty"`
+	EndColumn   int    `json:"end_column,omitempty"`
+	Message     string `json:"message"`
+	Value       string `json:"value,omitempty"`
+	Suggestion  string `json:"suggestion,omitempty"`
+	Snippet     string `json:"snippet,omitempty"`
+}
+
+// reloadResult describes the outcome of a reload.
+type reloadResult struct {
+	Status      string             `json:"status"` // "success" or "error"
+	Time        time.Time          `json:"time"`
+	Error       string             `json:"error,omitempty"`
+	Diagnostics []reloadDiagnostic `json:"diagnostics,omitempty"`
+
+	// Changes holds the changes made to the components by a successful
+	// reload.
+	Changes *flow.ComponentChanges `json:"changes,omitempty"`
+}
+
+// reloadDiagnostics records the result of the most recent reload.
+type reloadDiagnostics struct {
+	source  configSource
+	changes func() flow.ComponentChanges
+
+	mut  sync.RWMutex
+	last *reloadResult
+}
+
+// newReloadDiagnostics creates a new reloadDiagnostics for the config files
+// of source. changes is called after successful reloads to retrieve the
+// changes made to the components.
+func newReloadDiagnostics(source configSource, changes func() flow.ComponentChanges) *reloadDiagnostics {
+	return &reloadDiagnostics{source: source, changes: changes}
+}
+
+// Record records the result of a reload which returned err and returns it.
+func (rd *reloadDiagnostics) Record(err error) *reloadResult {
+	var files map[string][]byte
+	if err != nil {
+		files, _ = rd.source.ReadFiles()
+	}
+	return rd.recordFiles(files, err)
+}
+
+// RecordContent records the result of loading the config bb, which returned
+// err, and returns it. It's used when the loaded config isn't the content of
+// the config file, which is only supported for a single config file.
+func (rd *reloadDiagnostics) RecordContent(bb []byte, err error) *reloadResult {
+	return rd.recordFiles(rd.contentFiles(bb), err)
+}
+
+func (rd *reloadDiagnostics) recordFiles(files map[string][]byte, err error) *reloadResult {
+	res := &reloadResult{Status: "success", Time: time.Now()}
+	if err != nil {
+		res.Status = "error"
+		res.Error = err.Error()
+		res.Diagnostics = buildReloadDiagnostics(files, err)
+	} else if rd.changes != nil {
+		changes := rd.changes()
+		res.Changes = &changes
+	}
+
+	rd.mut.Lock()
+	defer rd.mut.Unlock()
+	rd.last = res
+	return res
+}
+
+// Handler returns an http.Handler which serves the result of the most recent
+// reload as JSON.
+func (rd *reloadDiagnostics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		rd.mut.RLock()
+		last := rd.last
+		rd.mut.RUnlock()
+
+		if last == nil {
+			http.Error(w, "config not loaded yet", http.StatusNotFound)
+			return
+		}
+		writeReloadResult(w, http.StatusOK, last)
+	})
+}
+
+// writeReloadResponse writes the result of a reload requested over HTTP. The
+// result is written as JSON when the client accepts it, and as pretty-printed
+// diagnostics with snippets of the config file otherwise.
+func (rd *reloadDiagnostics) writeReloadResponse(w http.ResponseWriter, r *http.Request, err error, res *reloadResult) {
+	var files map[string][]byte
+	if err != nil {
+		files, _ = rd.source.ReadFiles()
+	}
+	rd.writeFilesResponse(w, r, files, err, res)
+}
+
+// writeContentResponse writes the result of loading the config bb, requested
+// over HTTP, like writeReloadResponse. It's used when the loaded config isn't
+// the content of the config file.
+func (rd *reloadDiagnostics) writeContentResponse(w http.ResponseWriter, r *http.Request, bb []byte, err error, res *reloadResult) {
+	rd.writeFilesResponse(w, r, rd.contentFiles(bb), err, res)
+}
+
+func (rd *reloadDiagnostics) writeFilesResponse(w http.ResponseWriter, r *http.Request, files map[string][]byte, err error, res *reloadResult) {
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusBadRequest
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		writeReloadResult(w, status, res)
+		return
+	}
+
+	if err == nil {
+		fmt.Fprintln(w, "config reloaded")
+		if res != nil && res.Changes != nil {
+			writeChanges(w, res.Changes)
+		}
+		return
+	}
+
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	var buf bytes.Buffer
+	_ = diag.Fprint(&buf, files, diags)
+	http.Error(w, buf.String(), status)
+}
+
+// contentFiles returns the files of a config bb which isn't the content of
+// the config file, attributing it to the config file.
+func (rd *reloadDiagnostics) contentFiles(bb []byte) map[string][]byte {
+	if len(rd.source.paths) != 1 {
+		return nil
+	}
+	return map[string][]byte{rd.source.paths[0]: bb}
+}
+
+// writeChanges writes a human-readable summary of changes to w.
+func writeChanges(w io.Writer, changes *flow.ComponentChanges) {
+	fmt.Fprintf(w, "%d components added, %d updated, %d removed, %d failed, %d unchanged\n",
+		len(changes.Added), len(changes.Updated), len(changes.Removed), len(changes.Failed), changes.Unchanged)
+
+	for _, list := range []struct {
+		name string
+		ids  []string
+	}{
+		{"added", changes.Added},
+		{"updated", changes.Updated},
+		{"removed", changes.Removed},
+		{"failed", changes.Failed},
+	} {
+		for _, id := range list.ids {
+			fmt.Fprintf(w, "  %s: %s\n", list.name, id)
+		}
+	}
+}
+
+func writeReloadResult(w http.ResponseWriter, status int, res *reloadResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// buildReloadDiagnostics converts err into a list of reloadDiagnostics.
+// files holds the contents of the config files, keyed by file name, and is
+// used to find the component and source snippet of each diagnostic.
+func buildReloadDiagnostics(files map[string][]byte, err error) []reloadDiagnostic {
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		return []reloadDiagnostic{{Severity: "error", Message: err.Error()}}
+	}
+
+	type sourceFile struct {
+		blocks []*ast.BlockStmt
+		lines  []string
+	}
+	sources := make(map[string]sourceFile, len(files))
+	for filename, bb := range files {
+		// The file may fail to parse; diagnostics are still reported without
+		// their component in that case.
+		var blocks []*ast.BlockStmt
+		if node, err := parser.ParseFile(filename, bb); err == nil {
+			for _, stmt := range node.Body {
+				if block, ok := stmt.(*ast.BlockStmt); ok {
+					blocks = append(blocks, block)
+				}
+			}
+		}
+		sources[filename] = sourceFile{blocks: blocks, lines: strings.Split(string(bb), "\n")}
+	}
+
+	res := make([]reloadDiagnostic, 0, len(diags))
+	for _, d := range diags {
+		rd := reloadDiagnostic{
+			Severity:    severityString(d.Severity),
+			File:        d.StartPos.Filename,
+			StartLine:   d.StartPos.Line,
+			StartColumn: d.StartPos.Column,
+			EndLine:     d.EndPos.Line,
+			EndColumn:   d.EndPos.Column,
+			Message:     d.Message,
+			Value:       d.Value,
+			Suggestion:  suggestFix(d.Message),
+		}
+		if rd.EndLine == 0 {
+			rd.EndLine, rd.EndColumn = rd.StartLine, rd.StartColumn
+		}
+
+		if src, ok := sources[d.StartPos.Filename]; ok {
+			rd.Component = findBlockID(src.blocks, d)
+			rd.Snippet = snippet(src.lines, rd.StartLine, rd.EndLine)
+		}
+		res = append(res, rd)
+	}
+	return res
+}
+
+func severityString(s diag.Severity) string {
+	switch s {
+	case diag.SeverityLevelWarn:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// findBlockID returns the ID of the top-level block containing d.
+func findBlockID(blocks []*ast.BlockStmt, d diag.Diagnostic) string {
+	for _, block := range blocks {
+		start, end := ast.StartPos(block).Position(), ast.EndPos(block).Position()
+		if start.Offset <= d.StartPos.Offset && d.StartPos.Offset <= end.Offset {
+			id := strings.Join(block.Name, ".")
+			if block.Label != "" {
+				id += "." + block.Label
+			}
+			return id
+		}
+	}
+	return ""
+}
+
+// snippet returns lines start through end (starting at 1) of the source,
+// truncated to maxSnippetLines.
+func snippet(lines []string, start, end int) string {
+	if start < 1 || start > len(lines) {
+		return ""
+	}
+	if end < start {
+		end = start
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	if end-start+1 > maxSnippetLines {
+		end = start + maxSnippetLines - 1
+	}
+	return strings.Join(lines[start-1:end], "\n")
+}
+
+// suggestFix returns a suggestion for fixing the problem described by msg, if
+// one is known.
+func suggestFix(msg string) string {
+	var name string
+	if _, err := fmt.Sscanf(msg, "Unrecognized component name %q", &name); err == nil {
+		if closest := closestComponentName(name); closest != "" {
+			return fmt.Sprintf("did you mean %q?", closest)
+		}
+		return ""
+	}
+
+	switch {
+	case strings.HasSuffix(msg, "does not support labels"):
+		return "remove the label of the block"
+	case strings.HasSuffix(msg, "must have a label"):
+		return "add a label to the block, for example \"default\""
+	case strings.Contains(msg, "missing required attribute"), strings.Contains(msg, "missing required block"):
+		return "add the missing attribute or block to the component"
+	case strings.Contains(msg, "unrecognized attribute name"), strings.Contains(msg, "unrecognized block name"):
+		return "remove the attribute or block, or check its spelling in the component reference"
+	}
+	return ""
+}
+
+// closestComponentName returns the registered component name closest to
+// name, if it is close enough to be a likely typo.
+func closestComponentName(name string) string {
+	var (
+		closest string
+		best    = len(name)/3 + 1
+	)
+	for _, candidate := range component.AllNames() {
+		if d := editDistance(name, candidate); d < best {
+			closest, best = candidate, d
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min(vv ...int) int {
+	m := vv[0]
+	for _, v := range vv[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,79 @@
+package flowmode
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/token"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/grafana/agent/component/prometheus/scrape"
+)
+
+func TestBuildReloadDiagnostics(t *testing.T) {
+	src := []byte(`logging {
+  level = "info"
+}
+
+prometheus.scrap "default" {
+  targets = []
+}
+`)
+
+	err := diag.Diagnostics{{
+		Severity: diag.SeverityLevelError,
+		StartPos: token.Position{Filename: "config.river", Offset: 30, Line: 5, Column: 1},
+		EndPos:   token.Position{Filename: "config.river", Offset: 80, Line: 7, Column: 1},
+		Message:  `Unrecognized component name "prometheus.scrap"`,
+	}}
+
+	diags := buildReloadDiagnostics(map[string][]byte{"config.river": src}, err)
+	require.Equal(t, []reloadDiagnostic{{
+		Severity:    "error",
+		Component:   "prometheus.scrap.default",
+		File:        "config.river",
+		StartLine:   5,
+		StartColumn: 1,
+		EndLine:     7,
+		EndColumn:   1,
+		Message:     `Unrecognized component name "prometheus.scrap"`,
+		Suggestion:  `did you mean "prometheus.scrape"?`,
+		Snippet:     "prometheus.scrap \"default\" {\n  targets = []\n}",
+	}}, diags)
+}
+
+func TestBuildReloadDiagnostics_Error(t *testing.T) {
+	diags := buildReloadDiagnostics(map[string][]byte{"config.river": nil}, diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: token.Position{Filename: "other.river", Line: 2, Column: 3},
+		Message:  "missing required attribute \"targets\"",
+	})
+	require.Equal(t, []reloadDiagnostic{{
+		Severity:    "error",
+		File:        "other.river",
+		StartLine:   2,
+		StartColumn: 3,
+		EndLine:     2,
+		EndColumn:   3,
+		Message:     "missing required attribute \"targets\"",
+		Suggestion:  "add the missing attribute or block to the component",
+	}}, diags)
+}
+
+func TestReloadDiagnostics_Changes(t *testing.T) {
+	changes := flow.ComponentChanges{
+		Updated:   []string{"prometheus.scrape.default"},
+		Unchanged: 2,
+	}
+	rd := newReloadDiagnostics(configSource{paths: []string{"config.river"}}, func() flow.ComponentChanges { return changes })
+
+	res := rd.RecordContent(nil, nil)
+	require.Equal(t, "success", res.Status)
+	require.Equal(t, &changes, res.Changes)
+
+	res = rd.RecordContent(nil, errors.New("failed"))
+	require.Equal(t, "error", res.Status)
+	require.Nil(t, res.Changes)
+}
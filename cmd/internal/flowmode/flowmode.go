@@ -24,8 +24,11 @@ func Run() {
 	cmd.SetVersionTemplate("{{ .Version }}\n")
 
 	cmd.AddCommand(
+		convertCommand(),
 		fmtCommand(),
+		lintCommand(),
 		runCommand(),
+		toolsCommand(),
 	)
 
 	if err := cmd.Execute(); err != nil {
@@ -0,0 +1,215 @@
+package flowmode
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+// httpAuth authenticates requests to the HTTP server, using separate
+// policies for requests which only read state and requests which change it,
+// such as reloading the config.
+type httpAuth struct {
+	// Read authenticates reads. Credentials of Write are also accepted for
+	// reads.
+	Read httpAuthPolicy `yaml:"read,omitempty"`
+	// Write authenticates requests which change state.
+	Write httpAuthPolicy `yaml:"write,omitempty"`
+
+	// cache holds the hashes of basic auth credentials which have been
+	// verified, since verifying bcrypt hashes on every request is slow.
+	cacheMut sync.Mutex
+	cache    map[[sha256.Size]byte]struct{}
+}
+
+// httpAuthPolicy lists the credentials accepted by a policy. A policy without
+// any credentials rejects every request unless Anonymous is set.
+type httpAuthPolicy struct {
+	// Anonymous allows requests without credentials.
+	Anonymous bool `yaml:"anonymous,omitempty"`
+	// BasicAuthUsers maps usernames to bcrypt hashes of their passwords.
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users,omitempty"`
+	// BearerTokens lists the accepted bearer tokens.
+	BearerTokens []string `yaml:"bearer_tokens,omitempty"`
+	// ClientCertCommonNames lists patterns matched against the common name of
+	// verified client certificates, with the wildcards supported by
+	// path.Match.
+	ClientCertCommonNames []string `yaml:"client_cert_common_names,omitempty"`
+}
+
+// maxAuthCacheSize is the number of verified basic auth credentials to
+// remember.
+const maxAuthCacheSize = 100
+
+// readHTTPAuth reads the authentication policies of the HTTP server from the
+// YAML file filename.
+func readHTTPAuth(filename string) (*httpAuth, error) {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading HTTP auth file: %w", err)
+	}
+	auth, err := parseHTTPAuth(bb)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP auth file %s: %w", filename, err)
+	}
+	return auth, nil
+}
+
+func parseHTTPAuth(bb []byte) (*httpAuth, error) {
+	var auth httpAuth
+	if err := yaml.UnmarshalStrict(bb, &auth); err != nil {
+		return nil, err
+	}
+	for _, p := range []struct {
+		name   string
+		policy *httpAuthPolicy
+	}{{"read", &auth.Read}, {"write", &auth.Write}} {
+		if err := p.policy.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+	}
+	return &auth, nil
+}
+
+func (p *httpAuthPolicy) validate() error {
+	for user, hash := range p.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return fmt.Errorf("password of basic auth user %q isn't a bcrypt hash: %w", user, err)
+		}
+	}
+	for _, token := range p.BearerTokens {
+		if token == "" {
+			return fmt.Errorf("bearer tokens must not be empty")
+		}
+	}
+	for _, pattern := range p.ClientCertCommonNames {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid client certificate common name pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// usesClientCerts returns true if any policy accepts client certificates.
+func (a *httpAuth) usesClientCerts() bool {
+	return len(a.Read.ClientCertCommonNames) > 0 || len(a.Write.ClientCertCommonNames) > 0
+}
+
+// isWriteRequest returns true if r changes the state of the agent. /-/reload
+// also reloads the config on GET requests.
+func isWriteRequest(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return r.URL.Path == "/-/reload"
+	default:
+		return true
+	}
+}
+
+// Wrap returns a handler authenticating requests before passing them to
+// next. Requests to paths starting with one of the exempt prefixes aren't
+// authenticated.
+func (a *httpAuth) Wrap(next http.Handler, exempt ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range exempt {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		policies := []*httpAuthPolicy{&a.Write}
+		if !isWriteRequest(r) {
+			policies = append(policies, &a.Read)
+		}
+
+		authenticated := false
+		for _, p := range policies {
+			if a.allows(p, r) {
+				authenticated = true
+				break
+			}
+		}
+		switch {
+		case authenticated:
+			next.ServeHTTP(w, r)
+		case hasCredentials(r):
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			if len(a.Read.BasicAuthUsers) > 0 || len(a.Write.BasicAuthUsers) > 0 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="Grafana Agent"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	})
+}
+
+// hasCredentials returns true if r carries any credentials, so that rejected
+// requests can be told apart from unauthenticated ones.
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || (r.TLS != nil && len(r.TLS.PeerCertificates) > 0)
+}
+
+// allows returns true if r is authenticated by p.
+func (a *httpAuth) allows(p *httpAuthPolicy, r *http.Request) bool {
+	if p.Anonymous {
+		return true
+	}
+
+	if user, pass, ok := r.BasicAuth(); ok {
+		if hash, found := p.BasicAuthUsers[user]; found && a.checkPassword(user, pass, hash) {
+			return true
+		}
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		for _, expect := range p.BearerTokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(expect)) == 1 {
+				return true
+			}
+		}
+	}
+
+	// Client certificates have been verified against the client CAs during the
+	// TLS handshake.
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
+		cn := r.TLS.PeerCertificates[0].Subject.CommonName
+		for _, pattern := range p.ClientCertCommonNames {
+			if ok, _ := path.Match(pattern, cn); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *httpAuth) checkPassword(user, pass, hash string) bool {
+	key := sha256.Sum256([]byte(user + "\x00" + pass + "\x00" + hash))
+
+	a.cacheMut.Lock()
+	_, cached := a.cache[key]
+	a.cacheMut.Unlock()
+	if cached {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) != nil {
+		return false
+	}
+
+	a.cacheMut.Lock()
+	defer a.cacheMut.Unlock()
+	if a.cache == nil || len(a.cache) >= maxAuthCacheSize {
+		a.cache = make(map[[sha256.Size]byte]struct{})
+	}
+	a.cache[key] = struct{}{}
+	return true
+}
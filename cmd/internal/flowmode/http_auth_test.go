@@ -0,0 +1,115 @@
+package flowmode
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHTTPAuth(t *testing.T) {
+	viewerHash, err := bcrypt.GenerateFromPassword([]byte("viewer-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	auth, err := parseHTTPAuth([]byte(fmt.Sprintf(`
+read:
+  basic_auth_users:
+    viewer: %q
+  client_cert_common_names: ["*.read.example.com"]
+write:
+  bearer_tokens: [admin-token]
+  client_cert_common_names: [deployer]
+`, viewerHash)))
+	require.NoError(t, err)
+
+	handler := auth.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), "/-/ready")
+
+	anonymous := func(*http.Request) {}
+	viewer := func(r *http.Request) { r.SetBasicAuth("viewer", "viewer-password") }
+	wrongPassword := func(r *http.Request) { r.SetBasicAuth("viewer", "admin-token") }
+	admin := func(r *http.Request) { r.Header.Set("Authorization", "Bearer admin-token") }
+	clientCert := func(cn string) func(*http.Request) {
+		return func(r *http.Request) {
+			r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: cn}}}}
+		}
+	}
+
+	tt := []struct {
+		name   string
+		method string
+		path   string
+		auth   func(*http.Request)
+		expect int
+	}{
+		{"anonymous read", http.MethodGet, "/metrics", anonymous, http.StatusUnauthorized},
+		{"anonymous readiness", http.MethodGet, "/-/ready", anonymous, http.StatusOK},
+		{"viewer read", http.MethodGet, "/metrics", viewer, http.StatusOK},
+		{"wrong password", http.MethodGet, "/metrics", wrongPassword, http.StatusForbidden},
+		{"viewer reload", http.MethodGet, "/-/reload", viewer, http.StatusForbidden},
+		{"viewer write", http.MethodPost, "/api/v0/config/history/1/rollback", viewer, http.StatusForbidden},
+		{"admin read", http.MethodGet, "/metrics", admin, http.StatusOK},
+		{"admin reload", http.MethodPost, "/-/reload", admin, http.StatusOK},
+		{"read client cert", http.MethodGet, "/", clientCert("agent.read.example.com"), http.StatusOK},
+		{"read client cert write", http.MethodPost, "/-/reload", clientCert("agent.read.example.com"), http.StatusForbidden},
+		{"write client cert", http.MethodPost, "/-/reload", clientCert("deployer"), http.StatusOK},
+		{"unknown client cert", http.MethodGet, "/", clientCert("someone"), http.StatusForbidden},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			tc.auth(req)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tc.expect, rec.Code)
+			if tc.expect == http.StatusUnauthorized {
+				require.Equal(t, `Basic realm="Grafana Agent"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestHTTPAuth_Anonymous(t *testing.T) {
+	auth, err := parseHTTPAuth([]byte(`
+read:
+  anonymous: true
+write:
+  bearer_tokens: [admin-token]
+`))
+	require.NoError(t, err)
+
+	handler := auth.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/reload", nil))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestParseHTTPAuth_Invalid(t *testing.T) {
+	tt := []struct {
+		input  string
+		expect string
+	}{
+		{"read:\n  basic_auth_users:\n    viewer: plaintext\n", `read: password of basic auth user "viewer" isn't a bcrypt hash`},
+		{"write:\n  bearer_tokens: ['']\n", "write: bearer tokens must not be empty"},
+		{"read:\n  client_cert_common_names: ['[']\n", `read: invalid client certificate common name pattern "["`},
+		{"admin:\n  anonymous: true\n", "field admin not found"},
+	}
+	for _, tc := range tt {
+		_, err := parseHTTPAuth([]byte(tc.input))
+		require.ErrorContains(t, err, tc.expect)
+	}
+}
@@ -0,0 +1,483 @@
+package flowmode
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aws_config "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/oauth2/google"
+)
+
+// Methods used to verify remote config bundles.
+const (
+	remoteVerifySignature = "signature"
+	remoteVerifySHA256    = "sha256"
+	remoteVerifyNone      = "none"
+)
+
+// Suffixes appended to the URL of a config bundle to find its detached
+// signature or checksum.
+const (
+	remoteSignatureSuffix = ".sig"
+	remoteSHA256Suffix    = ".sha256"
+)
+
+// maxRemoteConfigSize is the largest config bundle which can be fetched.
+const maxRemoteConfigSize = 16 << 20
+
+// remoteConfigOptions configures a remoteConfig.
+type remoteConfigOptions struct {
+	URL           string
+	PollFrequency time.Duration
+	Verify        string
+	PublicKeyFile string
+
+	// CachePath is the file the active config bundle is written to, so that
+	// it can be loaded on startup when the remote is unreachable.
+	CachePath string
+}
+
+// remoteConfig periodically fetches a config bundle, verifies it, and applies
+// it. Bundles which fail to load are rolled back to the previous bundle.
+type remoteConfig struct {
+	log       log.Logger
+	opts      remoteConfigOptions
+	fetcher   remoteFetcher
+	publicKey ed25519.PublicKey
+	apply     func(bb []byte) error
+	onResult  func(bb []byte, err error)
+
+	mut    sync.Mutex
+	active []byte
+	failed [sha256.Size]byte // Checksum of the last bundle which failed to apply.
+
+	activeInfo    *prometheus.GaugeVec
+	lastSuccess   prometheus.Gauge
+	fetchFailures prometheus.Counter
+	applyFailures prometheus.Counter
+	rollbacks     prometheus.Counter
+}
+
+// remoteFetcher fetches the content of remote files.
+type remoteFetcher interface {
+	// Fetch returns the content of the file at rawURL.
+	Fetch(ctx context.Context, rawURL string) ([]byte, error)
+}
+
+// newRemoteConfig returns a remoteConfig which loads config bundles with
+// apply. onResult, if set, is called with the result of applying each new
+// bundle, excluding rollbacks.
+func newRemoteConfig(l log.Logger, reg prometheus.Registerer, opts remoteConfigOptions, apply func(bb []byte) error, onResult func(bb []byte, err error)) (*remoteConfig, error) {
+	if opts.PollFrequency <= 0 {
+		return nil, fmt.Errorf("remote config poll frequency must be greater than 0")
+	}
+
+	fetcher, err := newRemoteFetcher(opts.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	rc := &remoteConfig{
+		log:      log.With(l, "component", "remote_config"),
+		opts:     opts,
+		fetcher:  fetcher,
+		apply:    apply,
+		onResult: onResult,
+
+		activeInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_remote_config_info",
+			Help: "Checksum of the active remote config bundle.",
+		}, []string{"sha256"}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_remote_config_last_fetch_success_timestamp_seconds",
+			Help: "Timestamp of the last successful fetch of the remote config bundle.",
+		}),
+		fetchFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_remote_config_fetch_failures_total",
+			Help: "Remote config bundle fetches which failed or didn't pass verification.",
+		}),
+		applyFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_remote_config_apply_failures_total",
+			Help: "Remote config bundles which failed to load.",
+		}),
+		rollbacks: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_remote_config_rollbacks_total",
+			Help: "Rollbacks to the previous remote config bundle after a bundle failed to load.",
+		}),
+	}
+
+	switch opts.Verify {
+	case remoteVerifySignature:
+		if opts.PublicKeyFile == "" {
+			return nil, fmt.Errorf("verifying remote config signatures requires a public key file")
+		}
+		if rc.publicKey, err = readPublicKey(opts.PublicKeyFile); err != nil {
+			return nil, err
+		}
+	case remoteVerifySHA256, remoteVerifyNone:
+	default:
+		return nil, fmt.Errorf("unknown remote config verification method %q, must be one of %s, %s, or %s",
+			opts.Verify, remoteVerifySignature, remoteVerifySHA256, remoteVerifyNone)
+	}
+
+	for _, c := range []prometheus.Collector{rc.activeInfo, rc.lastSuccess, rc.fetchFailures, rc.applyFailures, rc.rollbacks} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return rc, nil
+}
+
+// SetActive records bb as the active config bundle, after it was loaded from
+// the cache on startup.
+func (rc *remoteConfig) SetActive(bb []byte) {
+	rc.mut.Lock()
+	defer rc.mut.Unlock()
+	rc.setActive(bb)
+}
+
+func (rc *remoteConfig) setActive(bb []byte) {
+	rc.active = bb
+	rc.activeInfo.Reset()
+	rc.activeInfo.WithLabelValues(fmt.Sprintf("%x", sha256.Sum256(bb))).Set(1)
+}
+
+// Run polls the config bundle until ctx is canceled.
+func (rc *remoteConfig) Run(ctx context.Context) {
+	t := time.NewTicker(rc.opts.PollFrequency)
+	defer t.Stop()
+
+	for {
+		if err := rc.Poll(ctx); err != nil {
+			level.Error(rc.log).Log("msg", "failed to update remote config", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Poll fetches and verifies the config bundle, and applies it if it changed.
+// If the bundle fails to load, the previous bundle is applied again.
+func (rc *remoteConfig) Poll(ctx context.Context) error {
+	bb, err := rc.fetch(ctx)
+	if err != nil {
+		rc.fetchFailures.Inc()
+		return err
+	}
+	rc.lastSuccess.SetToCurrentTime()
+
+	rc.mut.Lock()
+	defer rc.mut.Unlock()
+
+	sum := sha256.Sum256(bb)
+	switch {
+	case rc.active != nil && bytes.Equal(bb, rc.active):
+		return nil
+	case sum == rc.failed:
+		// Don't retry bundles which already failed to load.
+		return nil
+	}
+
+	err = rc.apply(bb)
+	if rc.onResult != nil {
+		rc.onResult(bb, err)
+	}
+	if err != nil {
+		rc.applyFailures.Inc()
+		rc.failed = sum
+
+		if rc.active == nil {
+			return fmt.Errorf("loading remote config %x: %w", sum, err)
+		}
+		rc.rollbacks.Inc()
+		if rollbackErr := rc.apply(rc.active); rollbackErr != nil {
+			return fmt.Errorf("loading remote config %x: %w; rolling back to the previous config also failed: %s", sum, err, rollbackErr)
+		}
+		return fmt.Errorf("loading remote config %x, rolled back to the previous config: %w", sum, err)
+	}
+
+	level.Info(rc.log).Log("msg", "applied remote config", "sha256", fmt.Sprintf("%x", sum))
+	rc.setActive(bb)
+	rc.failed = [sha256.Size]byte{}
+
+	if err := writeFileAtomic(rc.opts.CachePath, bb); err != nil {
+		level.Warn(rc.log).Log("msg", "failed to cache remote config", "path", rc.opts.CachePath, "err", err)
+	}
+	return nil
+}
+
+// Rollback applies bb in place of the active bundle. The bundle which was
+// active isn't applied again until the remote bundle changes.
+func (rc *remoteConfig) Rollback(bb []byte) error {
+	rc.mut.Lock()
+	defer rc.mut.Unlock()
+
+	if err := rc.apply(bb); err != nil {
+		return err
+	}
+	if rc.active != nil {
+		rc.failed = sha256.Sum256(rc.active)
+	}
+	rc.setActive(bb)
+
+	if err := writeFileAtomic(rc.opts.CachePath, bb); err != nil {
+		level.Warn(rc.log).Log("msg", "failed to cache remote config", "path", rc.opts.CachePath, "err", err)
+	}
+	return nil
+}
+
+// fetch fetches the config bundle and verifies it.
+func (rc *remoteConfig) fetch(ctx context.Context) ([]byte, error) {
+	bb, err := rc.fetcher.Fetch(ctx, rc.opts.URL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching remote config: %w", err)
+	}
+
+	switch rc.opts.Verify {
+	case remoteVerifySignature:
+		sig, err := rc.fetcher.Fetch(ctx, rc.opts.URL+remoteSignatureSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("fetching remote config signature: %w", err)
+		}
+		if err := verifySignature(rc.publicKey, bb, sig); err != nil {
+			return nil, err
+		}
+	case remoteVerifySHA256:
+		sum, err := rc.fetcher.Fetch(ctx, rc.opts.URL+remoteSHA256Suffix)
+		if err != nil {
+			return nil, fmt.Errorf("fetching remote config checksum: %w", err)
+		}
+		if err := verifySHA256(bb, sum); err != nil {
+			return nil, err
+		}
+	}
+	return bb, nil
+}
+
+// readPublicKey reads a PEM-encoded Ed25519 public key from filename.
+func readPublicKey(filename string) (ed25519.PublicKey, error) {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("reading remote config public key: %w", err)
+	}
+	block, _ := pem.Decode(bb)
+	if block == nil {
+		return nil, fmt.Errorf("remote config public key file %q holds no PEM block", filename)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing remote config public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("remote config public key must be an Ed25519 key, got %T", key)
+	}
+	return edKey, nil
+}
+
+// verifySignature verifies that sig is an Ed25519 signature of bb. sig may
+// be raw or base64-encoded.
+func verifySignature(key ed25519.PublicKey, bb []byte, sig []byte) error {
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil {
+			return fmt.Errorf("invalid remote config signature: %w", err)
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(key, bb, sig) {
+		return fmt.Errorf("remote config signature verification failed")
+	}
+	return nil
+}
+
+// verifySHA256 verifies that sum holds the SHA256 checksum of bb, in the
+// format written by sha256sum.
+func verifySHA256(bb []byte, sum []byte) error {
+	fields := strings.Fields(string(sum))
+	if len(fields) == 0 {
+		return fmt.Errorf("remote config checksum is empty")
+	}
+	expected, err := hex.DecodeString(fields[0])
+	if err != nil || len(expected) != sha256.Size {
+		return fmt.Errorf("invalid remote config checksum %q", fields[0])
+	}
+	if actual := sha256.Sum256(bb); !bytes.Equal(actual[:], expected) {
+		return fmt.Errorf("remote config checksum mismatch: expected %x, got %x", expected, actual)
+	}
+	return nil
+}
+
+// writeFileAtomic writes bb to filename by renaming a temporary file, so that
+// filename never holds a partial config.
+func writeFileAtomic(filename string, bb []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(bb); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+// newRemoteFetcher returns a remoteFetcher for the scheme of rawURL.
+func newRemoteFetcher(rawURL string) (remoteFetcher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote config URL: %w", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return &httpFetcher{client: http.DefaultClient}, nil
+	case "s3":
+		return &s3Fetcher{}, nil
+	case "gs":
+		return &gcsFetcher{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported remote config URL scheme %q, must be one of https, s3, or gs", u.Scheme)
+	}
+}
+
+// httpFetcher fetches files over HTTP or HTTPS.
+type httpFetcher struct {
+	client *http.Client
+}
+
+func (f *httpFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doFetch(f.client, req)
+}
+
+func doFetch(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from %s", resp.StatusCode, req.URL.Redacted())
+	}
+	return readLimited(resp.Body)
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	bb, err := io.ReadAll(io.LimitReader(r, maxRemoteConfigSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(bb) > maxRemoteConfigSize {
+		return nil, fmt.Errorf("remote file is larger than %d bytes", maxRemoteConfigSize)
+	}
+	return bb, nil
+}
+
+// s3Fetcher fetches objects from S3 using the default AWS credentials chain.
+type s3Fetcher struct {
+	once   sync.Once
+	client *s3.Client
+	err    error
+}
+
+func (f *s3Fetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	f.once.Do(func() {
+		var cfg aws.Config
+		cfg, f.err = aws_config.LoadDefaultConfig(ctx)
+		f.client = s3.NewFromConfig(cfg)
+	})
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	bucket, key, err := bucketAndObject(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	out, err := f.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return readLimited(out.Body)
+}
+
+// gcsFetcher fetches objects from Google Cloud Storage using the application
+// default credentials.
+type gcsFetcher struct {
+	once   sync.Once
+	client *http.Client
+	err    error
+}
+
+func (f *gcsFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	f.once.Do(func() {
+		// The client outlives ctx, which only bounds the current fetch.
+		f.client, f.err = google.DefaultClient(context.Background(), "https://www.googleapis.com/auth/devstorage.read_only")
+	})
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	bucket, object, err := bucketAndObject(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	objectURL := fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o/%s?alt=media", url.PathEscape(bucket), url.PathEscape(object))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doFetch(f.client, req)
+}
+
+// bucketAndObject splits a URL such as s3://bucket/path/to/object into its
+// bucket and object name.
+func bucketAndObject(rawURL string) (bucket, object string, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	object = strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || object == "" {
+		return "", "", fmt.Errorf("remote config URL %q must name a bucket and an object", rawURL)
+	}
+	return u.Host, object, nil
+}
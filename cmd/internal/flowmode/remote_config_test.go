@@ -0,0 +1,212 @@
+package flowmode
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// remoteServer serves a config bundle along with its checksum and signature.
+type remoteServer struct {
+	mut   sync.Mutex
+	files map[string][]byte
+	key   ed25519.PrivateKey
+}
+
+func (rs *remoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rs.mut.Lock()
+	defer rs.mut.Unlock()
+
+	bb, ok := rs.files[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write(bb)
+}
+
+// SetBundle serves bb as /config.river with a valid checksum and signature.
+func (rs *remoteServer) SetBundle(bb []byte) {
+	rs.mut.Lock()
+	defer rs.mut.Unlock()
+
+	rs.files = map[string][]byte{
+		"/config.river":        bb,
+		"/config.river.sha256": []byte(fmt.Sprintf("%x  config.river\n", sha256.Sum256(bb))),
+		"/config.river.sig":    []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(rs.key, bb))),
+	}
+}
+
+// fakeLoader records the bundles it loads. Bundles equal to "invalid" fail to
+// load.
+type fakeLoader struct {
+	loaded []string
+}
+
+func (fl *fakeLoader) Load(bb []byte) error {
+	if string(bb) == "invalid" {
+		return fmt.Errorf("invalid config")
+	}
+	fl.loaded = append(fl.loaded, string(bb))
+	return nil
+}
+
+func newTestRemoteConfig(t *testing.T, opts remoteConfigOptions) (*remoteConfig, *remoteServer, *fakeLoader) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	rs := &remoteServer{key: priv}
+	srv := httptest.NewServer(rs)
+	t.Cleanup(srv.Close)
+
+	opts.URL = srv.URL + "/config.river"
+	opts.PollFrequency = time.Minute
+	opts.PublicKeyFile = keyFile
+	opts.CachePath = filepath.Join(t.TempDir(), "config.river")
+
+	fl := &fakeLoader{}
+	rc, err := newRemoteConfig(log.NewNopLogger(), prometheus.NewRegistry(), opts, fl.Load, nil)
+	require.NoError(t, err)
+	return rc, rs, fl
+}
+
+func TestRemoteConfig(t *testing.T) {
+	for _, verify := range []string{remoteVerifySHA256, remoteVerifySignature, remoteVerifyNone} {
+		t.Run(verify, func(t *testing.T) {
+			rc, rs, fl := newTestRemoteConfig(t, remoteConfigOptions{Verify: verify})
+
+			rs.SetBundle([]byte("first"))
+			require.NoError(t, rc.Poll(context.Background()))
+			require.NoError(t, rc.Poll(context.Background()))
+			require.Equal(t, []string{"first"}, fl.loaded)
+
+			cached, err := os.ReadFile(rc.opts.CachePath)
+			require.NoError(t, err)
+			require.Equal(t, "first", string(cached))
+
+			rs.SetBundle([]byte("second"))
+			require.NoError(t, rc.Poll(context.Background()))
+			require.Equal(t, []string{"first", "second"}, fl.loaded)
+			require.Equal(t, 1.0, testutil.ToFloat64(rc.activeInfo.WithLabelValues(fmt.Sprintf("%x", sha256.Sum256([]byte("second"))))))
+		})
+	}
+}
+
+func TestRemoteConfig_Verification(t *testing.T) {
+	tt := []struct {
+		verify string
+		file   string
+	}{
+		{verify: remoteVerifySHA256, file: "/config.river.sha256"},
+		{verify: remoteVerifySignature, file: "/config.river.sig"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.verify, func(t *testing.T) {
+			rc, rs, fl := newTestRemoteConfig(t, remoteConfigOptions{Verify: tc.verify})
+
+			rs.SetBundle([]byte("first"))
+			rs.files["/config.river"] = []byte("tampered")
+			require.Error(t, rc.Poll(context.Background()))
+
+			delete(rs.files, tc.file)
+			require.Error(t, rc.Poll(context.Background()))
+
+			require.Empty(t, fl.loaded)
+			require.Equal(t, 2.0, testutil.ToFloat64(rc.fetchFailures))
+			require.NoFileExists(t, rc.opts.CachePath)
+		})
+	}
+}
+
+func TestRemoteConfig_Rollback(t *testing.T) {
+	rc, rs, fl := newTestRemoteConfig(t, remoteConfigOptions{Verify: remoteVerifySHA256})
+
+	rs.SetBundle([]byte("first"))
+	require.NoError(t, rc.Poll(context.Background()))
+
+	rs.SetBundle([]byte("invalid"))
+	require.ErrorContains(t, rc.Poll(context.Background()), "rolled back to the previous config")
+	require.Equal(t, []string{"first", "first"}, fl.loaded)
+
+	// Bundles which failed to load aren't retried.
+	require.NoError(t, rc.Poll(context.Background()))
+	require.Equal(t, []string{"first", "first"}, fl.loaded)
+	require.Equal(t, 1.0, testutil.ToFloat64(rc.applyFailures))
+	require.Equal(t, 1.0, testutil.ToFloat64(rc.rollbacks))
+
+	cached, err := os.ReadFile(rc.opts.CachePath)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(cached))
+}
+
+func TestRemoteConfig_ManualRollback(t *testing.T) {
+	rc, rs, fl := newTestRemoteConfig(t, remoteConfigOptions{Verify: remoteVerifySHA256})
+
+	rs.SetBundle([]byte("first"))
+	require.NoError(t, rc.Poll(context.Background()))
+	rs.SetBundle([]byte("second"))
+	require.NoError(t, rc.Poll(context.Background()))
+
+	require.NoError(t, rc.Rollback([]byte("first")))
+	require.Equal(t, []string{"first", "second", "first"}, fl.loaded)
+
+	cached, err := os.ReadFile(rc.opts.CachePath)
+	require.NoError(t, err)
+	require.Equal(t, "first", string(cached))
+
+	// The bundle rolled back from isn't applied again until it changes.
+	require.NoError(t, rc.Poll(context.Background()))
+	require.Equal(t, []string{"first", "second", "first"}, fl.loaded)
+
+	rs.SetBundle([]byte("third"))
+	require.NoError(t, rc.Poll(context.Background()))
+	require.Equal(t, []string{"first", "second", "first", "third"}, fl.loaded)
+}
+
+func TestRemoteConfig_InvalidOptions(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	apply := func([]byte) error { return nil }
+
+	_, err := newRemoteConfig(log.NewNopLogger(), reg, remoteConfigOptions{URL: "ftp://host/config.river", PollFrequency: time.Minute, Verify: remoteVerifyNone}, apply, nil)
+	require.ErrorContains(t, err, "unsupported remote config URL scheme")
+
+	_, err = newRemoteConfig(log.NewNopLogger(), reg, remoteConfigOptions{URL: "s3://bucket/config.river", PollFrequency: time.Minute, Verify: remoteVerifySignature}, apply, nil)
+	require.ErrorContains(t, err, "requires a public key file")
+
+	_, err = newRemoteConfig(log.NewNopLogger(), reg, remoteConfigOptions{URL: "s3://bucket/config.river", PollFrequency: time.Minute, Verify: "md5"}, apply, nil)
+	require.ErrorContains(t, err, "unknown remote config verification method")
+}
+
+func TestBucketAndObject(t *testing.T) {
+	bucket, object, err := bucketAndObject("gs://bucket/path/to/config.river")
+	require.NoError(t, err)
+	require.Equal(t, "bucket", bucket)
+	require.Equal(t, "path/to/config.river", object)
+
+	_, _, err = bucketAndObject("s3://bucket/")
+	require.Error(t, err)
+}
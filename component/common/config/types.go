@@ -4,6 +4,7 @@ package config
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -24,6 +25,10 @@ type HTTPClientConfig struct {
 	TLSConfig       TLSConfig         `river:"tls_config,block,optional"`
 	FollowRedirects bool              `river:"follow_redirects,attr,optional"`
 	EnableHTTP2     bool              `river:"enable_http2,attr,optional"`
+
+	NoProxy              string `river:"no_proxy,attr,optional"`
+	ProxyFromEnvironment bool   `river:"proxy_from_environment,attr,optional"`
+	ProxyConnectHeader   Header `river:"proxy_connect_header,attr,optional"`
 }
 
 // SetToDefault implements the river.Defaulter
@@ -33,6 +38,9 @@ func (h *HTTPClientConfig) SetToDefault() {
 
 // Validate returns an error if h is invalid.
 func (h *HTTPClientConfig) Validate() error {
+	if err := h.proxyConfig().Validate(); err != nil {
+		return err
+	}
 	// Backwards compatibility with the bearer_token field.
 	if len(h.BearerToken) > 0 && len(h.BearerTokenFile) > 0 {
 		return fmt.Errorf("at most one of bearer_token & bearer_token_file must be configured")
@@ -108,9 +116,16 @@ func (h *HTTPClientConfig) Convert() *config.HTTPClientConfig {
 		TLSConfig:       *h.TLSConfig.Convert(),
 		FollowRedirects: h.FollowRedirects,
 		EnableHTTP2:     h.EnableHTTP2,
-		ProxyConfig: config.ProxyConfig{
-			ProxyURL: h.ProxyURL.Convert(),
-		},
+		ProxyConfig:     *h.proxyConfig().Convert(),
+	}
+}
+
+func (h *HTTPClientConfig) proxyConfig() *ProxyConfig {
+	return &ProxyConfig{
+		ProxyURL:             h.ProxyURL,
+		NoProxy:              h.NoProxy,
+		ProxyFromEnvironment: h.ProxyFromEnvironment,
+		ProxyConnectHeader:   h.ProxyConnectHeader,
 	}
 }
 
@@ -177,6 +192,75 @@ func (u URL) Convert() config.URL {
 	return config.URL{URL: u.URL}
 }
 
+// Header holds the values of HTTP headers, which may be secrets.
+type Header map[string][]rivertypes.Secret
+
+// Convert converts our type to the native prometheus type
+func (h Header) Convert() config.Header {
+	if h == nil {
+		return nil
+	}
+	res := make(config.Header, len(h))
+	for name, values := range h {
+		for _, v := range values {
+			res[name] = append(res[name], config.Secret(v))
+		}
+	}
+	return res
+}
+
+// ProxyConfig configures the proxy of clients which aren't configured by an
+// HTTPClientConfig. proxy_url may use the http, https, socks5, or socks5h
+// schemes.
+type ProxyConfig struct {
+	ProxyURL             URL    `river:"proxy_url,attr,optional"`
+	NoProxy              string `river:"no_proxy,attr,optional"`
+	ProxyFromEnvironment bool   `river:"proxy_from_environment,attr,optional"`
+	ProxyConnectHeader   Header `river:"proxy_connect_header,attr,optional"`
+}
+
+// Validate returns an error if p is invalid.
+func (p *ProxyConfig) Validate() error {
+	if p.hasProxyURL() {
+		switch p.ProxyURL.Scheme {
+		case "", "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("unsupported proxy_url scheme %q, expecting http, https, socks5, or socks5h", p.ProxyURL.Scheme)
+		}
+	}
+	return p.Convert().Validate()
+}
+
+// Convert converts our type to the native prometheus type
+func (p *ProxyConfig) Convert() *config.ProxyConfig {
+	return &config.ProxyConfig{
+		ProxyURL:             p.ProxyURL.Convert(),
+		NoProxy:              p.NoProxy,
+		ProxyFromEnvironment: p.ProxyFromEnvironment,
+		ProxyConnectHeader:   p.ProxyConnectHeader.Convert(),
+	}
+}
+
+// Configured returns true if p sets a proxy.
+func (p *ProxyConfig) Configured() bool {
+	return p != nil && (p.hasProxyURL() || p.ProxyFromEnvironment)
+}
+
+func (p *ProxyConfig) hasProxyURL() bool {
+	return p.ProxyURL.URL != nil && p.ProxyURL.String() != ""
+}
+
+// Apply configures t to use the proxy of p. t is left unchanged if p doesn't
+// set a proxy, so that it keeps its default.
+func (p *ProxyConfig) Apply(t *http.Transport) {
+	if !p.Configured() {
+		return
+	}
+	c := p.Convert()
+	t.Proxy = c.Proxy()
+	t.ProxyConnectHeader = c.GetProxyConnectHeader()
+}
+
 // Authorization sets up HTTP authorization credentials.
 type Authorization struct {
 	Type            string            `river:"type,attr,optional"`
@@ -292,16 +376,19 @@ func (o *OAuth2Config) Convert() *config.OAuth2 {
 	if o == nil {
 		return nil
 	}
-	return &config.OAuth2{
+	oa := &config.OAuth2{
 		ClientID:         o.ClientID,
 		ClientSecret:     config.Secret(o.ClientSecret),
 		ClientSecretFile: o.ClientSecretFile,
 		Scopes:           o.Scopes,
 		TokenURL:         o.TokenURL,
 		EndpointParams:   o.EndpointParams,
-		TLSConfig:        *o.TLSConfig.Convert(),
 		ProxyConfig: config.ProxyConfig{
 			ProxyURL: o.ProxyURL.Convert(),
 		},
 	}
+	if o.TLSConfig != nil {
+		oa.TLSConfig = *o.TLSConfig.Convert()
+	}
+	return oa
 }
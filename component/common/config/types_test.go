@@ -1,6 +1,7 @@
 package config
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/grafana/agent/pkg/river"
@@ -212,3 +213,70 @@ func TestHTTPClientBadConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &httpClientConfig)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func TestHTTPClientConfigProxy(t *testing.T) {
+	var exampleRiverConfig = `
+	proxy_url = "socks5://proxy.example.com:1080"
+	no_proxy  = "localhost,10.0.0.0/8"
+	proxy_connect_header = {
+		"X-Proxy-Token" = ["secret"],
+	}
+`
+
+	var httpClientConfig HTTPClientConfig
+	err := river.Unmarshal([]byte(exampleRiverConfig), &httpClientConfig)
+	require.NoError(t, err)
+
+	proxy := httpClientConfig.Convert().ProxyConfig
+	require.Equal(t, "localhost,10.0.0.0/8", proxy.NoProxy)
+	require.Equal(t, []string{"secret"}, proxy.GetProxyConnectHeader()["X-Proxy-Token"])
+
+	proxyFunc := proxy.Proxy()
+	for target, expect := range map[string]string{
+		"https://loki.example.com/loki/api/v1/push": "socks5://proxy.example.com:1080",
+		"http://10.1.2.3:9090/api/v1/write":         "",
+		"http://localhost:3100/loki/api/v1/push":    "",
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		u, err := proxyFunc(req)
+		require.NoError(t, err)
+		if expect == "" {
+			require.Nil(t, u, target)
+		} else {
+			require.Equal(t, expect, u.String(), target)
+		}
+	}
+}
+
+func TestHTTPClientConfigProxyInvalid(t *testing.T) {
+	tt := []struct {
+		config string
+		expect string
+	}{
+		{`no_proxy = "localhost"`, "if no_proxy is configured, proxy_url must also be configured"},
+		{"proxy_url = \"http://proxy:3128\"\nproxy_from_environment = true", "if proxy_from_environment is configured, proxy_url must not be configured"},
+		{`proxy_url = "ftp://proxy:21"`, `unsupported proxy_url scheme "ftp"`},
+	}
+	for _, tc := range tt {
+		var httpClientConfig HTTPClientConfig
+		err := river.Unmarshal([]byte(tc.config), &httpClientConfig)
+		require.ErrorContains(t, err, tc.expect)
+	}
+}
+
+func TestProxyConfigApply(t *testing.T) {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	(&ProxyConfig{}).Apply(transport)
+	require.NotNil(t, transport.Proxy, "transports keep their proxy if none is configured")
+
+	var proxy ProxyConfig
+	require.NoError(t, river.Unmarshal([]byte(`proxy_url = "http://proxy:3128"`), &proxy))
+	proxy.Apply(transport)
+
+	req, err := http.NewRequest(http.MethodGet, "https://vault.example.com", nil)
+	require.NoError(t, err)
+	u, err := transport.Proxy(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://proxy:3128", u.String())
+}
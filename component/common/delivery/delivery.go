@@ -0,0 +1,149 @@
+// Package delivery measures the end-to-end delivery latency of telemetry data
+// flowing through a pipeline.
+//
+// Source components which opt in stamp the data they produce with the time
+// it was ingested and their component ID. Write components then measure how
+// long it took the stamped data to reach them, exporting a histogram per
+// source component.
+//
+// Prometheus samples carry the stamp in the context passed to
+// storage.Appendable.Appender, so that a stamp covers a whole batch of
+// samples. Loki entries carry the stamp in reserved labels, which write
+// components remove before sending the entries.
+package delivery
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/storage"
+)
+
+// Reserved labels holding the stamp of Loki entries.
+const (
+	LabelSource    = "__delivery_source__"
+	LabelTimestamp = "__delivery_timestamp__"
+)
+
+// Stamp records where and when telemetry data was ingested.
+type Stamp struct {
+	// Source is the ID of the component which ingested the data.
+	Source string
+	Time   time.Time
+}
+
+type contextKey struct{}
+
+// ContextWithStamp returns a copy of ctx holding s. The stamp already held by
+// ctx is kept, if any, so that the latency is measured from the first source
+// of a pipeline.
+func ContextWithStamp(ctx context.Context, s Stamp) context.Context {
+	if _, ok := StampFromContext(ctx); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// StampFromContext returns the stamp held by ctx.
+func StampFromContext(ctx context.Context) (Stamp, bool) {
+	s, ok := ctx.Value(contextKey{}).(Stamp)
+	return s, ok
+}
+
+// StampLabels returns a copy of ls holding s. The stamp already held by ls is
+// kept, if any.
+func StampLabels(ls model.LabelSet, s Stamp) model.LabelSet {
+	if _, ok := ls[LabelTimestamp]; ok {
+		return ls
+	}
+	stamped := make(model.LabelSet, len(ls)+2)
+	for k, v := range ls {
+		stamped[k] = v
+	}
+	stamped[LabelSource] = model.LabelValue(s.Source)
+	stamped[LabelTimestamp] = model.LabelValue(strconv.FormatInt(s.Time.UnixNano(), 10))
+	return stamped
+}
+
+// ExtractLabels returns the stamp held by ls, along with a copy of ls without
+// the reserved labels of the stamp. ls is returned unchanged if it doesn't
+// hold a stamp.
+func ExtractLabels(ls model.LabelSet) (model.LabelSet, Stamp, bool) {
+	_, hasSource := ls[LabelSource]
+	ts, hasTimestamp := ls[LabelTimestamp]
+	if !hasSource && !hasTimestamp {
+		return ls, Stamp{}, false
+	}
+
+	stripped := make(model.LabelSet, len(ls))
+	for k, v := range ls {
+		if k != LabelSource && k != LabelTimestamp {
+			stripped[k] = v
+		}
+	}
+
+	nanos, err := strconv.ParseInt(string(ts), 10, 64)
+	if err != nil {
+		return stripped, Stamp{}, false
+	}
+	return stripped, Stamp{Source: string(ls[LabelSource]), Time: time.Unix(0, nanos)}, true
+}
+
+// Tracker measures the delivery latency of stamped data reaching a write
+// component.
+type Tracker struct {
+	latency *prometheus.HistogramVec
+}
+
+// NewTracker creates a Tracker registering its metrics to reg.
+func NewTracker(reg prometheus.Registerer) *Tracker {
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "agent_delivery_latency_seconds",
+		Help:    "Time between data being ingested by a source component and reaching this component.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"source"})
+	_ = reg.Register(latency)
+
+	return &Tracker{latency: latency}
+}
+
+// Observe records the delivery latency of data stamped with s.
+func (t *Tracker) Observe(s Stamp) {
+	t.latency.WithLabelValues(s.Source).Observe(time.Since(s.Time).Seconds())
+}
+
+// TrackAppendable returns a storage.Appendable which observes the delivery
+// latency of stamped batches of samples to t when they're committed to next.
+func TrackAppendable(next storage.Appendable, t *Tracker) storage.Appendable {
+	return trackingAppendable{next: next, tracker: t}
+}
+
+type trackingAppendable struct {
+	next    storage.Appendable
+	tracker *Tracker
+}
+
+func (a trackingAppendable) Appender(ctx context.Context) storage.Appender {
+	app := a.next.Appender(ctx)
+	if s, ok := StampFromContext(ctx); ok {
+		return &trackingAppender{Appender: app, tracker: a.tracker, stamp: s}
+	}
+	return app
+}
+
+type trackingAppender struct {
+	storage.Appender
+	tracker *Tracker
+	stamp   Stamp
+}
+
+func (a *trackingAppender) Commit() error {
+	err := a.Appender.Commit()
+	if err == nil {
+		a.tracker.Observe(a.stamp)
+	}
+	return err
+}
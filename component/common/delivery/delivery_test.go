@@ -0,0 +1,100 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextWithStamp(t *testing.T) {
+	first := Stamp{Source: "prometheus.scrape.a", Time: time.Unix(10, 0)}
+	second := Stamp{Source: "prometheus.scrape.b", Time: time.Unix(20, 0)}
+
+	_, ok := StampFromContext(context.Background())
+	require.False(t, ok)
+
+	ctx := ContextWithStamp(ContextWithStamp(context.Background(), first), second)
+	s, ok := StampFromContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, first, s, "the stamp of the first source should be kept")
+}
+
+func TestLabels(t *testing.T) {
+	original := model.LabelSet{"job": "varlogs"}
+	s := Stamp{Source: "loki.source.file.logs", Time: time.Unix(0, 1234567890)}
+
+	stamped := StampLabels(original, s)
+	require.Equal(t, model.LabelSet{
+		"job":          "varlogs",
+		LabelSource:    "loki.source.file.logs",
+		LabelTimestamp: "1234567890",
+	}, stamped)
+	require.Len(t, original, 1, "the original labels should be left untouched")
+
+	restamped := StampLabels(stamped, Stamp{Source: "other", Time: time.Now()})
+	require.Equal(t, stamped, restamped, "the stamp of the first source should be kept")
+
+	stripped, extracted, ok := ExtractLabels(stamped)
+	require.True(t, ok)
+	require.Equal(t, original, stripped)
+	require.Equal(t, s.Source, extracted.Source)
+	require.True(t, s.Time.Equal(extracted.Time))
+	require.Len(t, stamped, 3, "the stamped labels should be left untouched")
+
+	unstamped, _, ok := ExtractLabels(original)
+	require.False(t, ok)
+	require.Equal(t, original, unstamped)
+
+	invalid, _, ok := ExtractLabels(model.LabelSet{"job": "varlogs", LabelTimestamp: "now"})
+	require.False(t, ok)
+	require.Equal(t, original, invalid, "invalid stamps should still be removed")
+}
+
+func TestTrackAppendable(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	app := TrackAppendable(nopAppendable{}, NewTracker(reg))
+
+	// Unstamped batches aren't observed.
+	require.NoError(t, app.Appender(context.Background()).Commit())
+
+	ctx := ContextWithStamp(context.Background(), Stamp{Source: "prometheus.scrape.default", Time: time.Now()})
+	a := app.Appender(ctx)
+	_, err := a.Append(0, labels.FromStrings("__name__", "up"), 0, 1)
+	require.NoError(t, err)
+	require.NoError(t, a.Commit())
+
+	// Rolled back batches aren't observed.
+	require.NoError(t, app.Appender(ctx).Rollback())
+
+	count, err := testutil.GatherAndCount(reg, "agent_delivery_latency_seconds")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+	metric := families[0].GetMetric()[0]
+	require.Equal(t, "prometheus.scrape.default", metric.GetLabel()[0].GetValue())
+	require.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+}
+
+type nopAppendable struct{}
+
+func (nopAppendable) Appender(context.Context) storage.Appender { return nopAppender{} }
+
+type nopAppender struct {
+	storage.Appender
+}
+
+func (nopAppender) Append(storage.SeriesRef, labels.Labels, int64, float64) (storage.SeriesRef, error) {
+	return 0, nil
+}
+func (nopAppender) Commit() error   { return nil }
+func (nopAppender) Rollback() error { return nil }
@@ -0,0 +1,253 @@
+// Package livedebugging streams samples of the data flowing through a
+// component to clients attached to it, such as the Flow UI.
+//
+// Components publish their data to a Hub, which only encodes and sends it
+// while clients are attached. Each stream samples the published data, is
+// limited to a number of samples per second, and ends after a timeout, so
+// that forgotten streams don't add overhead to the component.
+package livedebugging
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+)
+
+// Limits of the streams of a hub.
+const (
+	// DefaultRate is the default maximum number of samples per second sent to
+	// a stream.
+	DefaultRate = 10
+	// MaxRate is the maximum number of samples per second which may be
+	// requested for a stream.
+	MaxRate = 100
+	// DefaultTimeout is the default duration after which a stream ends.
+	DefaultTimeout = time.Minute
+	// MaxTimeout is the maximum duration which may be requested for a stream.
+	MaxTimeout = 10 * time.Minute
+)
+
+// streamBuffer is the number of samples buffered for a stream. Samples are
+// dropped when the client of a stream doesn't keep up.
+const streamBuffer = 64
+
+// Sample is a sample of the data flowing through a component.
+type Sample struct {
+	Time time.Time       `json:"time"`
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Options configures a stream.
+type Options struct {
+	// Ratio is the ratio of published data which is sampled, between 0
+	// (exclusive) and 1.
+	Ratio float64
+	// Rate is the maximum number of samples per second sent to the stream.
+	Rate float64
+	// Timeout is the duration after which the stream ends.
+	Timeout time.Duration
+}
+
+// DefaultOptions holds the default options of a stream.
+var DefaultOptions = Options{
+	Ratio:   1,
+	Rate:    DefaultRate,
+	Timeout: DefaultTimeout,
+}
+
+// stream is a client streaming the samples of a hub.
+type stream struct {
+	ratio   float64
+	limiter *rate.Limiter
+	samples chan []byte
+}
+
+// Hub sends samples of the data published by a component to the streams
+// attached to it. The zero value isn't usable; create hubs with NewHub.
+type Hub struct {
+	streamsGauge prometheus.Gauge
+	droppedTotal prometheus.Counter
+
+	active  atomic.Int32
+	mut     sync.RWMutex
+	streams map[*stream]struct{}
+}
+
+// NewHub creates a new Hub, registering its metrics to reg.
+func NewHub(reg prometheus.Registerer) (*Hub, error) {
+	h := &Hub{
+		streamsGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_livedebugging_streams",
+			Help: "Number of clients streaming samples of the data flowing through the component.",
+		}),
+		droppedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_livedebugging_dropped_samples_total",
+			Help: "Total number of samples dropped because a client didn't keep up with its stream.",
+		}),
+
+		streams: make(map[*stream]struct{}),
+	}
+	for _, c := range []prometheus.Collector{h.streamsGauge, h.droppedTotal} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// Active reports whether any stream is attached to h. Components may use it
+// to avoid preparing data which wouldn't be published.
+func (h *Hub) Active() bool {
+	return h != nil && h.active.Load() > 0
+}
+
+// Publish sends a sample of the given kind to the streams which sample it
+// and aren't over their limit. data returns the data of the sample, which
+// must be encodable to JSON. data is only called when the sample is sent to
+// at least one stream, and at most once.
+func (h *Hub) Publish(kind string, data func() interface{}) {
+	if !h.Active() {
+		return
+	}
+
+	h.mut.RLock()
+	defer h.mut.RUnlock()
+
+	var (
+		line    []byte
+		encoded bool
+	)
+	for s := range h.streams {
+		if s.ratio < 1 && rand.Float64() >= s.ratio {
+			continue
+		}
+		if !s.limiter.Allow() {
+			continue
+		}
+
+		if !encoded {
+			encoded = true
+			if bb, err := json.Marshal(data()); err == nil {
+				line, _ = json.Marshal(Sample{Time: time.Now(), Kind: kind, Data: bb})
+			}
+		}
+		if line == nil {
+			return
+		}
+
+		select {
+		case s.samples <- line:
+		default:
+			h.droppedTotal.Inc()
+		}
+	}
+}
+
+func (h *Hub) subscribe(opts Options) *stream {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	s := &stream{
+		ratio:   opts.Ratio,
+		limiter: rate.NewLimiter(rate.Limit(opts.Rate), 1),
+		samples: make(chan []byte, streamBuffer),
+	}
+	h.streams[s] = struct{}{}
+	h.active.Store(int32(len(h.streams)))
+	h.streamsGauge.Set(float64(len(h.streams)))
+	return s
+}
+
+func (h *Hub) unsubscribe(s *stream) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	delete(h.streams, s)
+	h.active.Store(int32(len(h.streams)))
+	h.streamsGauge.Set(float64(len(h.streams)))
+}
+
+// ServeHTTP streams the samples published to h as newline-delimited JSON
+// until the client disconnects or the stream times out. The stream is
+// configured by the following query parameters:
+//
+//   - sample: the ratio of published data which is sampled.
+//   - rate: the maximum number of samples per second.
+//   - timeout: the duration after which the stream ends.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	opts, err := ParseOptions(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+
+	s := h.subscribe(opts)
+	defer h.unsubscribe(s)
+
+	timeout := time.NewTimer(opts.Timeout)
+	defer timeout.Stop()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timeout.C:
+			return
+		case line := <-s.samples:
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// ParseOptions parses the options of a stream from the query parameters of
+// r. Options which aren't set default to DefaultOptions.
+func ParseOptions(r *http.Request) (Options, error) {
+	var (
+		opts  = DefaultOptions
+		query = r.URL.Query()
+		err   error
+	)
+
+	if v := query.Get("sample"); v != "" {
+		opts.Ratio, err = strconv.ParseFloat(v, 64)
+		if err != nil || opts.Ratio <= 0 || opts.Ratio > 1 {
+			return opts, fmt.Errorf("sample must be a number greater than 0 and at most 1, got %q", v)
+		}
+	}
+	if v := query.Get("rate"); v != "" {
+		opts.Rate, err = strconv.ParseFloat(v, 64)
+		if err != nil || opts.Rate <= 0 || opts.Rate > MaxRate {
+			return opts, fmt.Errorf("rate must be a number greater than 0 and at most %d, got %q", MaxRate, v)
+		}
+	}
+	if v := query.Get("timeout"); v != "" {
+		opts.Timeout, err = time.ParseDuration(v)
+		if err != nil || opts.Timeout <= 0 || opts.Timeout > MaxTimeout {
+			return opts, fmt.Errorf("timeout must be a duration greater than 0 and at most %s, got %q", MaxTimeout, v)
+		}
+	}
+	return opts, nil
+}
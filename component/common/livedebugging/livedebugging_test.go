@@ -0,0 +1,87 @@
+package livedebugging
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_Stream(t *testing.T) {
+	h, err := NewHub(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	var published int
+	publish := func() {
+		h.Publish("target", func() interface{} {
+			published++
+			return map[string]string{"__address__": "localhost:9100"}
+		})
+	}
+
+	// Nothing is encoded while no stream is attached.
+	publish()
+	require.False(t, h.Active())
+	require.Equal(t, 0, published)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?rate=100&timeout=5s")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Eventually(t, h.Active, time.Second, 10*time.Millisecond)
+
+	publish()
+	require.Equal(t, 1, published)
+
+	line, err := bufio.NewReader(resp.Body).ReadBytes('\n')
+	require.NoError(t, err)
+
+	var sample Sample
+	require.NoError(t, json.Unmarshal(line, &sample))
+	require.Equal(t, "target", sample.Kind)
+	require.JSONEq(t, `{"__address__": "localhost:9100"}`, string(sample.Data))
+}
+
+func TestHub_Timeout(t *testing.T) {
+	h, err := NewHub(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?timeout=50ms")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The response ends once the stream times out.
+	_, err = bufio.NewReader(resp.Body).ReadBytes('\n')
+	require.Error(t, err)
+	require.Eventually(t, func() bool { return !h.Active() }, time.Second, 10*time.Millisecond)
+}
+
+func TestParseOptions(t *testing.T) {
+	parse := func(query string) (Options, error) {
+		return ParseOptions(httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+	}
+
+	opts, err := parse("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultOptions, opts)
+
+	opts, err = parse("sample=0.1&rate=50&timeout=5m")
+	require.NoError(t, err)
+	require.Equal(t, Options{Ratio: 0.1, Rate: 50, Timeout: 5 * time.Minute}, opts)
+
+	for _, query := range []string{"sample=0", "sample=2", "rate=1000", "timeout=1h", "timeout=soon"} {
+		_, err := parse(query)
+		require.Error(t, err, query)
+	}
+}
@@ -0,0 +1,144 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AdaptiveConfig configures a client to adapt the size of its batches and
+// the number of batches it sends concurrently to how the Loki endpoint keeps
+// up, instead of using a fixed BatchSize and sending one batch at a time.
+//
+// Both are adapted with an additive increase, multiplicative decrease (AIMD)
+// algorithm: they slowly grow while batches are sent successfully, and are
+// halved whenever the endpoint is overloaded, which is when it responds with
+// 429 or 5xx, can't be reached, or responds slower than TargetLatency.
+type AdaptiveConfig struct {
+	// Enabled adapts the batch size and concurrency. BatchSize is then the
+	// initial batch size.
+	Enabled bool
+
+	MinBatchSize   int
+	MaxBatchSize   int
+	MinConcurrency int
+	MaxConcurrency int
+	TargetLatency  time.Duration
+}
+
+// adaptiveLimiter implements the AIMD algorithm of AdaptiveConfig.
+type adaptiveLimiter struct {
+	cfg              AdaptiveConfig
+	batchSizeGauge   prometheus.Gauge
+	concurrencyGauge prometheus.Gauge
+
+	mut          sync.Mutex
+	cond         *sync.Cond
+	batchSize    float64
+	concurrency  float64
+	inflight     int
+	lastDecrease time.Time
+}
+
+func newAdaptiveLimiter(cfg AdaptiveConfig, batchSize int, batchSizeGauge, concurrencyGauge prometheus.Gauge) *adaptiveLimiter {
+	l := &adaptiveLimiter{
+		cfg:              cfg,
+		batchSizeGauge:   batchSizeGauge,
+		concurrencyGauge: concurrencyGauge,
+
+		batchSize:   clamp(float64(batchSize), float64(cfg.MinBatchSize), float64(cfg.MaxBatchSize)),
+		concurrency: float64(cfg.MinConcurrency),
+	}
+	l.cond = sync.NewCond(&l.mut)
+	l.updateGauges()
+	return l
+}
+
+// BatchSize returns the current maximum size of batches.
+func (l *adaptiveLimiter) BatchSize() int {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	return int(l.batchSize)
+}
+
+// limit returns the current maximum number of batches sent concurrently.
+func (l *adaptiveLimiter) limit() int {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	return int(l.concurrency)
+}
+
+// Acquire blocks until another batch can be sent. Release must be called
+// once the batch was sent or dropped.
+func (l *adaptiveLimiter) Acquire() {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	for l.inflight >= int(l.concurrency) {
+		l.cond.Wait()
+	}
+	l.inflight++
+}
+
+// Release releases the slot taken by Acquire.
+func (l *adaptiveLimiter) Release() {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	l.inflight--
+	l.cond.Broadcast()
+}
+
+// Observe adapts the limits to the outcome of a request sent at start.
+// status is the HTTP status of the response, or a negative value if no
+// response was received.
+func (l *adaptiveLimiter) Observe(start time.Time, status int, err error) {
+	overloaded := time.Since(start) > l.cfg.TargetLatency
+	if err != nil {
+		if status > 0 && status != 429 && status/100 != 5 {
+			// The request was rejected, which says nothing about the load of
+			// the endpoint.
+			return
+		}
+		overloaded = true
+	}
+
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if overloaded {
+		// Requests which were already in flight when the limits were last
+		// decreased were sent under the previous limits; don't decrease them
+		// again for the same congestion.
+		if start.Before(l.lastDecrease) {
+			return
+		}
+		l.batchSize = clamp(l.batchSize/2, float64(l.cfg.MinBatchSize), float64(l.cfg.MaxBatchSize))
+		l.concurrency = clamp(l.concurrency/2, float64(l.cfg.MinConcurrency), float64(l.cfg.MaxConcurrency))
+		l.lastDecrease = time.Now()
+	} else {
+		// Grow by about one request and one minimum batch size once every
+		// request of the current concurrency succeeded.
+		step := 1 / l.concurrency
+		l.batchSize = clamp(l.batchSize+step*float64(l.cfg.MinBatchSize), float64(l.cfg.MinBatchSize), float64(l.cfg.MaxBatchSize))
+		l.concurrency = clamp(l.concurrency+step, float64(l.cfg.MinConcurrency), float64(l.cfg.MaxConcurrency))
+		l.cond.Broadcast()
+	}
+	l.updateGauges()
+}
+
+func (l *adaptiveLimiter) updateGauges() {
+	l.batchSizeGauge.Set(float64(int(l.batchSize)))
+	l.concurrencyGauge.Set(float64(int(l.concurrency)))
+}
+
+func clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
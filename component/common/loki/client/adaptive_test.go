@@ -0,0 +1,159 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+var testAdaptiveConfig = AdaptiveConfig{
+	Enabled:        true,
+	MinBatchSize:   100,
+	MaxBatchSize:   1000,
+	MinConcurrency: 1,
+	MaxConcurrency: 4,
+	TargetLatency:  time.Minute,
+}
+
+func newTestLimiter(batchSize int) *adaptiveLimiter {
+	return newAdaptiveLimiter(testAdaptiveConfig, batchSize, prometheus.NewGauge(prometheus.GaugeOpts{}), prometheus.NewGauge(prometheus.GaugeOpts{}))
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	l := newTestLimiter(200)
+	require.Equal(t, 200, l.BatchSize())
+	require.Equal(t, 1, l.limit())
+
+	// Each success grows the limits until they reach their maximum.
+	for i := 0; i < 100; i++ {
+		l.Observe(time.Now(), 204, nil)
+	}
+	require.Equal(t, 1000, l.BatchSize())
+	require.Equal(t, 4, l.limit())
+
+	// Rejected requests don't change the limits.
+	l.Observe(time.Now(), 400, errors.New("bad request"))
+	require.Equal(t, 1000, l.BatchSize())
+	require.Equal(t, 4, l.limit())
+
+	// An overloaded endpoint halves the limits.
+	start := time.Now()
+	l.Observe(start, 429, errors.New("too many requests"))
+	require.Equal(t, 500, l.BatchSize())
+	require.Equal(t, 2, l.limit())
+
+	// Requests sent before the decrease don't decrease the limits again.
+	l.Observe(start, 503, errors.New("unavailable"))
+	require.Equal(t, 500, l.BatchSize())
+	require.Equal(t, 2, l.limit())
+
+	// Neither the batch size nor the concurrency go below their minimum.
+	for i := 0; i < 10; i++ {
+		l.Observe(time.Now(), -1, errors.New("connection refused"))
+	}
+	require.Equal(t, 100, l.BatchSize())
+	require.Equal(t, 1, l.limit())
+}
+
+func TestAdaptiveLimiter_Latency(t *testing.T) {
+	l := newTestLimiter(1000)
+	l.cfg.TargetLatency = time.Millisecond
+
+	// Slow requests are considered as overloading the endpoint.
+	l.Observe(time.Now().Add(-time.Second), 204, nil)
+	require.Equal(t, 500, l.BatchSize())
+}
+
+func TestAdaptiveLimiter_Acquire(t *testing.T) {
+	l := newTestLimiter(200)
+	l.Acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		require.FailNow(t, "acquired more slots than the concurrency")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	l.Release()
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for slot")
+	}
+}
+
+func TestClient_Adaptive(t *testing.T) {
+	var (
+		mut         sync.Mutex
+		inflight    int
+		maxInflight int
+		received    atomic.Int64
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mut.Lock()
+		inflight++
+		if inflight > maxInflight {
+			maxInflight = inflight
+		}
+		mut.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mut.Lock()
+		inflight--
+		mut.Unlock()
+		received.Inc()
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	serverURL := flagext.URLValue{}
+	require.NoError(t, serverURL.Set(server.URL))
+
+	cfg := testAdaptiveConfig
+	cfg.MinBatchSize, cfg.MaxBatchSize = 10, 10
+	c, err := New(NewMetrics(prometheus.NewRegistry(), nil), Config{
+		URL:           serverURL,
+		BatchWait:     time.Minute,
+		BatchSize:     10,
+		Timeout:       time.Second,
+		BackoffConfig: backoff.Config{MaxRetries: 1},
+		Adaptive:      cfg,
+	}, nil, 0, log.NewNopLogger())
+	require.NoError(t, err)
+
+	// Every entry fills a batch, so that the client sends batches as fast
+	// as the endpoint allows.
+	const entries = 50
+	for i := 0; i < entries; i++ {
+		c.Chan() <- loki.Entry{
+			Labels: model.LabelSet{"app": "test"},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: "0123456789"},
+		}
+	}
+	c.Stop()
+
+	require.Equal(t, int64(entries), received.Load())
+	require.Greater(t, maxInflight, 1, "expected batches to be sent concurrently")
+	require.LessOrEqual(t, maxInflight, cfg.MaxConcurrency)
+}
@@ -30,14 +30,18 @@ type batch struct {
 	createdAt time.Time
 
 	maxStreams int
+
+	// Caches the labels string of streams across batches. May be nil.
+	labelStrings *loki.LabelStringCache
 }
 
-func newBatch(maxStreams int, entries ...loki.Entry) *batch {
+func newBatch(maxStreams int, labelStrings *loki.LabelStringCache, entries ...loki.Entry) *batch {
 	b := &batch{
-		streams:    map[string]*logproto.Stream{},
-		bytes:      0,
-		createdAt:  time.Now(),
-		maxStreams: maxStreams,
+		streams:      map[string]*logproto.Stream{},
+		bytes:        0,
+		createdAt:    time.Now(),
+		maxStreams:   maxStreams,
+		labelStrings: labelStrings,
 	}
 
 	// Add entries to the batch
@@ -54,7 +58,7 @@ func (b *batch) add(entry loki.Entry) error {
 	b.bytes += len(entry.Line)
 
 	// Append the entry to an already existing stream (if any)
-	labels := labelsMapToString(entry.Labels, ReservedLabelTenantID)
+	labels := b.streamLabels(entry.Labels)
 	if stream, ok := b.streams[labels]; ok {
 		stream.Entries = append(stream.Entries, entry.Entry)
 		return nil
@@ -72,6 +76,28 @@ func (b *batch) add(entry loki.Entry) error {
 	return nil
 }
 
+// entriesCount returns the number of entries in the batch.
+func (b *batch) entriesCount() int {
+	var count int
+	for _, stream := range b.streams {
+		count += len(stream.Entries)
+	}
+	return count
+}
+
+// streamLabels returns the labels string of the stream of an entry with the
+// labels ls.
+func (b *batch) streamLabels(ls model.LabelSet) string {
+	if b.labelStrings == nil {
+		return streamLabelsString(ls)
+	}
+	return b.labelStrings.String(ls)
+}
+
+func streamLabelsString(ls model.LabelSet) string {
+	return labelsMapToString(ls, ReservedLabelTenantID)
+}
+
 func labelsMapToString(ls model.LabelSet, without ...model.LabelName) string {
 	lstrs := make([]string, 0, len(ls))
 Outer:
@@ -27,7 +27,7 @@ func TestBatch_MaxStreams(t *testing.T) {
 		{Labels: model.LabelSet{"app": "app-4"}, Entry: logproto.Entry{Timestamp: time.Unix(6, 0).UTC(), Line: "line6"}},
 	}
 
-	b := newBatch(maxStream)
+	b := newBatch(maxStream, nil)
 
 	errCount := 0
 	for _, entry := range inputEntries {
@@ -78,7 +78,7 @@ func TestBatch_add(t *testing.T) {
 		testData := testData
 
 		t.Run(testName, func(t *testing.T) {
-			b := newBatch(0)
+			b := newBatch(0, nil)
 
 			for _, entry := range testData.inputEntries {
 				err := b.add(entry)
@@ -98,24 +98,24 @@ func TestBatch_encode(t *testing.T) {
 		expectedEntriesCount int
 	}{
 		"empty batch": {
-			inputBatch:           newBatch(0),
+			inputBatch:           newBatch(0, nil),
 			expectedEntriesCount: 0,
 		},
 		"single stream with single log entry": {
-			inputBatch: newBatch(0,
+			inputBatch: newBatch(0, nil,
 				loki.Entry{Labels: model.LabelSet{}, Entry: logEntries[0].Entry},
 			),
 			expectedEntriesCount: 1,
 		},
 		"single stream with multiple log entries": {
-			inputBatch: newBatch(0,
+			inputBatch: newBatch(0, nil,
 				loki.Entry{Labels: model.LabelSet{}, Entry: logEntries[0].Entry},
 				loki.Entry{Labels: model.LabelSet{}, Entry: logEntries[1].Entry},
 			),
 			expectedEntriesCount: 2,
 		},
 		"multiple streams with multiple log entries": {
-			inputBatch: newBatch(0,
+			inputBatch: newBatch(0, nil,
 				loki.Entry{Labels: model.LabelSet{"type": "a"}, Entry: logEntries[0].Entry},
 				loki.Entry{Labels: model.LabelSet{"type": "a"}, Entry: logEntries[1].Entry},
 				loki.Entry{Labels: model.LabelSet{"type": "b"}, Entry: logEntries[2].Entry},
@@ -138,7 +138,7 @@ func TestBatch_encode(t *testing.T) {
 }
 
 func TestHashCollisions(t *testing.T) {
-	b := newBatch(0)
+	b := newBatch(0, nil)
 
 	ls1 := model.LabelSet{"app": "l", "uniq0": "0", "uniq1": "1"}
 	ls2 := model.LabelSet{"app": "m", "uniq0": "1", "uniq1": "1"}
@@ -51,6 +51,11 @@ type Metrics struct {
 	droppedEntries   *prometheus.CounterVec
 	requestDuration  *prometheus.HistogramVec
 	batchRetries     *prometheus.CounterVec
+	retriesByStatus  *prometheus.CounterVec
+	throttledTenants *prometheus.GaugeVec
+	pendingEntries   *prometheus.GaugeVec
+	batchSize        *prometheus.GaugeVec
+	concurrency      *prometheus.GaugeVec
 	countersWithHost []*prometheus.CounterVec
 	streamLag        *prometheus.GaugeVec
 }
@@ -86,6 +91,28 @@ func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
 		Name: "loki_write_batch_retries_total",
 		Help: "Number of times batches has had to be retried.",
 	}, []string{HostLabel})
+	m.retriesByStatus = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_write_batch_retries_by_status_total",
+		Help: "Number of times batches have had to be retried, by the status code of the failed request.",
+	}, []string{HostLabel, "status_code"})
+	m.throttledTenants = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_write_throttled_tenants",
+		Help: "Number of tenants whose batches are held back because the endpoint rate limited them.",
+	}, []string{HostLabel})
+
+	m.pendingEntries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_write_pending_entries",
+		Help: "Number of log entries waiting in batches to be sent, including batches being retried.",
+	}, []string{HostLabel})
+
+	m.batchSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_write_adaptive_batch_size_bytes",
+		Help: "Maximum size of batches, as adapted to the load of the endpoint.",
+	}, []string{HostLabel})
+	m.concurrency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_write_adaptive_concurrency",
+		Help: "Maximum number of batches sent concurrently, as adapted to the load of the endpoint.",
+	}, []string{HostLabel})
 
 	m.countersWithHost = []*prometheus.CounterVec{
 		m.encodedBytes, m.sentBytes, m.droppedBytes, m.sentEntries, m.droppedEntries,
@@ -106,6 +133,11 @@ func NewMetrics(reg prometheus.Registerer, streamLagLabels []string) *Metrics {
 		m.droppedEntries = mustRegisterOrGet(reg, m.droppedEntries).(*prometheus.CounterVec)
 		m.requestDuration = mustRegisterOrGet(reg, m.requestDuration).(*prometheus.HistogramVec)
 		m.batchRetries = mustRegisterOrGet(reg, m.batchRetries).(*prometheus.CounterVec)
+		m.retriesByStatus = mustRegisterOrGet(reg, m.retriesByStatus).(*prometheus.CounterVec)
+		m.throttledTenants = mustRegisterOrGet(reg, m.throttledTenants).(*prometheus.GaugeVec)
+		m.pendingEntries = mustRegisterOrGet(reg, m.pendingEntries).(*prometheus.GaugeVec)
+		m.batchSize = mustRegisterOrGet(reg, m.batchSize).(*prometheus.GaugeVec)
+		m.concurrency = mustRegisterOrGet(reg, m.concurrency).(*prometheus.GaugeVec)
 		m.streamLag = mustRegisterOrGet(reg, m.streamLag).(*prometheus.GaugeVec)
 	}
 
@@ -138,12 +170,26 @@ type client struct {
 	logger          log.Logger
 	cfg             Config
 	client          *http.Client
+	encoder         encoder
 	entries         chan loki.Entry
 
 	once sync.Once
 	wg   sync.WaitGroup
 
+	// throttled holds the tenants rate limited by the endpoint, whose
+	// batches are sent by their own goroutine tracked by throttledWg.
+	throttledMut  sync.Mutex
+	throttledCond *sync.Cond
+	throttled     map[string]*throttledTenant
+	throttledWg   sync.WaitGroup
+
+	// Adapts the batch size and concurrency when enabled, in which case
+	// batches are sent by goroutines tracked by sends.
+	limiter *adaptiveLimiter
+	sends   sync.WaitGroup
+
 	externalLabels model.LabelSet
+	labelStrings   *loki.LabelStringCache
 
 	// ctx is used in any upstream calls from the `client`.
 	ctx        context.Context
@@ -178,10 +224,14 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 		name:            asSha256(cfg),
 
 		externalLabels: cfg.ExternalLabels.LabelSet,
+		labelStrings:   loki.NewLabelStringCache(maxStreams, streamLabelsString),
 		ctx:            ctx,
 		cancel:         cancel,
 		maxStreams:     maxStreams,
+
+		throttled: make(map[string]*throttledTenant),
 	}
+	c.throttledCond = sync.NewCond(&c.throttledMut)
 	if cfg.Name != "" {
 		c.name = cfg.Name
 	}
@@ -191,6 +241,11 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 		return nil, err
 	}
 
+	c.encoder, err = newEncoder(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+
 	c.client, err = config.NewClientFromConfig(cfg.Client, "GrafanaAgent", config.WithHTTP2Disabled())
 	if err != nil {
 		return nil, err
@@ -203,6 +258,15 @@ func newClient(metrics *Metrics, cfg Config, streamLagLabels []string, maxStream
 	for _, counter := range c.metrics.countersWithHost {
 		counter.WithLabelValues(c.cfg.URL.Host).Add(0)
 	}
+	c.metrics.throttledTenants.WithLabelValues(c.cfg.URL.Host).Set(0)
+	c.metrics.pendingEntries.WithLabelValues(c.cfg.URL.Host).Add(0)
+
+	if cfg.Adaptive.Enabled {
+		c.limiter = newAdaptiveLimiter(cfg.Adaptive, cfg.BatchSize,
+			c.metrics.batchSize.WithLabelValues(c.cfg.URL.Host),
+			c.metrics.concurrency.WithLabelValues(c.cfg.URL.Host),
+		)
+	}
 
 	c.wg.Add(1)
 	go c.run()
@@ -244,8 +308,11 @@ func (c *client) run() {
 		maxWaitCheck.Stop()
 		// Send all pending batches
 		for tenantID, batch := range batches {
-			c.sendBatch(tenantID, batch)
+			c.flushBatch(tenantID, batch)
 		}
+		// Batches being sent may hand over to throttled tenants.
+		c.sends.Wait()
+		c.throttledWg.Wait()
 
 		c.wg.Done()
 	}()
@@ -261,16 +328,18 @@ func (c *client) run() {
 
 			// If the batch doesn't exist yet, we create a new one with the entry
 			if !ok {
-				batches[tenantID] = newBatch(c.maxStreams, e)
+				batches[tenantID] = newBatch(c.maxStreams, c.labelStrings, e)
+				c.metrics.pendingEntries.WithLabelValues(c.cfg.URL.Host).Inc()
 				break
 			}
 
 			// If adding the entry to the batch will increase the size over the max
 			// size allowed, we do send the current batch and then create a new one
-			if batch.sizeBytesAfter(e) > c.cfg.BatchSize {
-				c.sendBatch(tenantID, batch)
+			if batch.sizeBytesAfter(e) > c.batchSize() {
+				c.flushBatch(tenantID, batch)
 
-				batches[tenantID] = newBatch(c.maxStreams, e)
+				batches[tenantID] = newBatch(c.maxStreams, c.labelStrings, e)
+				c.metrics.pendingEntries.WithLabelValues(c.cfg.URL.Host).Inc()
 				break
 			}
 
@@ -281,6 +350,7 @@ func (c *client) run() {
 				c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Inc()
 				return
 			}
+			c.metrics.pendingEntries.WithLabelValues(c.cfg.URL.Host).Inc()
 		case <-maxWaitCheck.C:
 			// Send all batches whose max wait time has been reached
 			for tenantID, batch := range batches {
@@ -288,13 +358,39 @@ func (c *client) run() {
 					continue
 				}
 
-				c.sendBatch(tenantID, batch)
+				c.flushBatch(tenantID, batch)
 				delete(batches, tenantID)
 			}
 		}
 	}
 }
 
+// batchSize returns the size after which batches are sent.
+func (c *client) batchSize() int {
+	if c.limiter != nil {
+		return c.limiter.BatchSize()
+	}
+	return c.cfg.BatchSize
+}
+
+// flushBatch sends batch. When the batch size and concurrency are adapted,
+// the batch is sent in the background once the number of batches being sent
+// allows it.
+func (c *client) flushBatch(tenantID string, batch *batch) {
+	if c.limiter == nil {
+		c.sendBatch(tenantID, batch)
+		return
+	}
+
+	c.limiter.Acquire()
+	c.sends.Add(1)
+	go func() {
+		defer c.sends.Done()
+		defer c.limiter.Release()
+		c.sendBatch(tenantID, batch)
+	}()
+}
+
 func (c *client) Chan() chan<- loki.Entry {
 	return c.entries
 }
@@ -309,26 +405,80 @@ func asSha256(o interface{}) string {
 
 func (c *client) sendBatch(tenantID string, batch *batch) {
 	buf, entriesCount, err := batch.encode()
+	if err == nil {
+		buf, err = c.encoder.Encode(buf)
+	}
 	if err != nil {
 		level.Error(c.logger).Log("msg", "error encoding batch", "error", err)
+		c.metrics.pendingEntries.WithLabelValues(c.cfg.URL.Host).Sub(float64(batch.entriesCount()))
+		return
+	}
+	c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(float64(len(buf)))
+
+	p := &pendingBatch{
+		batch:        batch,
+		buf:          buf,
+		entriesCount: entriesCount,
+		backoff:      backoff.New(c.ctx, c.cfg.BackoffConfig),
+	}
+	// Batches of a throttled tenant are queued behind the batch it retries,
+	// so that they're sent in order.
+	if c.queueThrottled(tenantID, p) {
+		return
+	}
+	c.pushBatch(tenantID, p, false)
+}
+
+// pendingBatch is an encoded batch along with the state of its retries.
+type pendingBatch struct {
+	batch        *batch
+	buf          []byte
+	entriesCount int
+	backoff      *backoff.Backoff
+}
+
+// pushBatch sends p, retrying it until it succeeds or its backoff gives up.
+// Unless throttled is set, a batch rate limited by the endpoint is handed
+// over to a goroutine retrying the batches of its tenant, so that other
+// tenants aren't held back.
+func (c *client) pushBatch(tenantID string, p *pendingBatch, throttled bool) {
+	bufBytes := float64(len(p.buf))
+
+	// The entries of the batch are no longer pending once it was sent or
+	// dropped, unlike when it's handed over to a throttled tenant.
+	handedOver := false
+	defer func() {
+		if !handedOver {
+			c.metrics.pendingEntries.WithLabelValues(c.cfg.URL.Host).Sub(float64(p.batch.entriesCount()))
+		}
+	}()
+
+	// The retries of a batch handed over to a throttled tenant stop once the
+	// client is stopped without retries.
+	if p.backoff.NumRetries() > 0 && !p.backoff.Ongoing() {
+		c.dropBatch(p, http.StatusTooManyRequests, p.backoff.Err())
 		return
 	}
-	bufBytes := float64(len(buf))
-	c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 
-	backoff := backoff.New(c.ctx, c.cfg.BackoffConfig)
-	var status int
+	var (
+		status     int
+		retryAfter time.Duration
+		err        error
+	)
 	for {
 		start := time.Now()
 		// send uses `timeout` internally, so `context.Background` is good enough.
-		status, err = c.send(context.Background(), tenantID, buf)
+		status, retryAfter, err = c.send(context.Background(), tenantID, p.buf)
 
 		c.metrics.requestDuration.WithLabelValues(strconv.Itoa(status), c.cfg.URL.Host).Observe(time.Since(start).Seconds())
+		if c.limiter != nil {
+			c.limiter.Observe(start, status, err)
+		}
 
 		if err == nil {
 			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
-			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
-			for _, s := range batch.streams {
+			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(p.entriesCount))
+			for _, s := range p.batch.streams {
 				lbls, err := parser.ParseMetric(s.Labels)
 				if err != nil {
 					// is this possible?
@@ -372,31 +522,49 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 
 		level.Warn(c.logger).Log("msg", "error sending batch, will retry", "status", status, "error", err)
 		c.metrics.batchRetries.WithLabelValues(c.cfg.URL.Host).Inc()
-		backoff.Wait()
+		c.metrics.retriesByStatus.WithLabelValues(c.cfg.URL.Host, strconv.Itoa(status)).Inc()
+		delay := c.retryDelay(p.backoff, retryAfter)
 
 		// Make sure it sends at least once before checking for retry.
-		if !backoff.Ongoing() {
+		if !p.backoff.Ongoing() {
 			break
 		}
+		if status == http.StatusTooManyRequests && !throttled {
+			c.throttleTenant(tenantID, p, delay)
+			handedOver = true
+			return
+		}
+		c.sleep(delay)
 	}
 
 	if err != nil {
-		level.Error(c.logger).Log("msg", "final error sending batch", "status", status, "error", err)
-		c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
-		c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(entriesCount))
+		c.dropBatch(p, status, err)
 	}
 }
 
-func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, error) {
+// dropBatch drops p after it failed to be sent with err.
+func (c *client) dropBatch(p *pendingBatch, status int, err error) {
+	level.Error(c.logger).Log("msg", "final error sending batch", "status", status, "error", err)
+	c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host).Add(float64(len(p.buf)))
+	c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(p.entriesCount))
+}
+
+// send pushes buf to the endpoint. It returns the status code of the
+// response, and how long the endpoint asked to wait before retrying, if it
+// did.
+func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, time.Duration, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
 	defer cancel()
 	req, err := http.NewRequest("POST", c.cfg.URL.String(), bytes.NewReader(buf))
 	if err != nil {
-		return -1, err
+		return -1, 0, err
 	}
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", UserAgent)
+	if enc := c.encoder.ContentEncoding(); enc != "" {
+		req.Header.Set("Content-Encoding", enc)
+	}
 
 	// If the tenant ID is not empty, the component is running in multi-tenant
 	// mode, so we should send it to Loki
@@ -417,7 +585,7 @@ func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, er
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return -1, err
+		return -1, 0, err
 	}
 	defer lokiutil.LogError("closing response body", resp.Body.Close)
 
@@ -429,7 +597,7 @@ func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, er
 		}
 		err = fmt.Errorf("server returned HTTP status %s (%d): %s", resp.Status, resp.StatusCode, line)
 	}
-	return resp.StatusCode, err
+	return resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), err
 }
 
 func (c *client) getTenantID(labels model.LabelSet) string {
@@ -4,6 +4,7 @@ package client
 // and run the clients that can send log entries to a Loki instance.
 
 import (
+	"compress/gzip"
 	"io"
 	"math"
 	"net/http"
@@ -20,6 +21,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/util"
 	lokiflag "github.com/grafana/loki/pkg/util/flagext"
+	"github.com/klauspost/compress/zstd"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/config"
@@ -446,6 +448,63 @@ func TestClient_StopNow(t *testing.T) {
 	}
 }
 
+func TestClient_Compression(t *testing.T) {
+	for _, compression := range []Compression{CompressionSnappy, CompressionGzip, CompressionZstd} {
+		t.Run(string(compression), func(t *testing.T) {
+			receivedReqsChan := make(chan receivedReq, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				contentEncoding := req.Header.Get("Content-Encoding")
+				switch compression {
+				case CompressionGzip:
+					assert.Equal(t, "gzip", contentEncoding)
+					r, err := gzip.NewReader(req.Body)
+					require.NoError(t, err)
+					req.Body = io.NopCloser(r)
+				case CompressionZstd:
+					assert.Equal(t, "zstd", contentEncoding)
+					r, err := zstd.NewReader(req.Body)
+					require.NoError(t, err)
+					req.Body = r.IOReadCloser()
+				default:
+					assert.Empty(t, contentEncoding)
+				}
+				req.ContentLength = -1
+				createServerHandler(receivedReqsChan, http.StatusOK)(rw, req)
+			}))
+			defer server.Close()
+
+			serverURL := flagext.URLValue{}
+			require.NoError(t, serverURL.Set(server.URL))
+
+			c, err := New(NewMetrics(prometheus.NewRegistry(), nil), Config{
+				URL:         serverURL,
+				BatchWait:   10 * time.Millisecond,
+				BatchSize:   10,
+				Timeout:     time.Second,
+				Compression: compression,
+			}, nil, 0, log.NewNopLogger())
+			require.NoError(t, err)
+			defer c.Stop()
+
+			c.Chan() <- logEntries[0]
+
+			select {
+			case req := <-receivedReqsChan:
+				require.Len(t, req.pushReq.Streams, 1)
+				require.Equal(t, []logproto.Entry{logEntries[0].Entry}, req.pushReq.Streams[0].Entries)
+			case <-time.After(5 * time.Second):
+				require.FailNow(t, "timed out waiting for push request")
+			}
+		})
+	}
+}
+
+func TestCompression_Validate(t *testing.T) {
+	require.NoError(t, Compression("").Validate())
+	require.NoError(t, CompressionZstd.Validate())
+	require.EqualError(t, Compression("lz4").Validate(), `unsupported compression "lz4", expected one of "snappy", "gzip" or "zstd"`)
+}
+
 func createServerHandler(receivedReqsChan chan receivedReq, status int) http.HandlerFunc {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		// Parse the request
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression applied to push requests.
+type Compression string
+
+// Supported values for Compression.
+const (
+	// CompressionSnappy sends the snappy-compressed protobuf payload Loki
+	// expects as is.
+	CompressionSnappy Compression = "snappy"
+	// CompressionGzip additionally compresses the payload with gzip and sets
+	// the Content-Encoding header accordingly.
+	CompressionGzip Compression = "gzip"
+	// CompressionZstd additionally compresses the payload with zstd and sets
+	// the Content-Encoding header accordingly.
+	CompressionZstd Compression = "zstd"
+)
+
+// Validate returns an error if c isn't a supported compression.
+func (c Compression) Validate() error {
+	switch c {
+	case "", CompressionSnappy, CompressionGzip, CompressionZstd:
+		return nil
+	default:
+		return fmt.Errorf("unsupported compression %q, expected one of %q, %q or %q", c, CompressionSnappy, CompressionGzip, CompressionZstd)
+	}
+}
+
+// encoder applies a Content-Encoding to encoded batches.
+type encoder interface {
+	// Encode returns the encoded form of buf.
+	Encode(buf []byte) ([]byte, error)
+	// ContentEncoding returns the value of the Content-Encoding header, if
+	// any.
+	ContentEncoding() string
+}
+
+func newEncoder(c Compression) (encoder, error) {
+	switch c {
+	case "", CompressionSnappy:
+		return noopEncoder{}, nil
+	case CompressionGzip:
+		return gzipEncoder{}, nil
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		return zstdEncoder{enc: enc}, nil
+	default:
+		return nil, c.Validate()
+	}
+}
+
+type noopEncoder struct{}
+
+func (noopEncoder) Encode(buf []byte) ([]byte, error) { return buf, nil }
+func (noopEncoder) ContentEncoding() string           { return "" }
+
+type gzipEncoder struct{}
+
+func (gzipEncoder) Encode(buf []byte) ([]byte, error) {
+	var out bytes.Buffer
+	w := gzip.NewWriter(&out)
+	if _, err := w.Write(buf); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func (gzipEncoder) ContentEncoding() string { return "gzip" }
+
+type zstdEncoder struct {
+	// enc is safe for concurrent use through EncodeAll.
+	enc *zstd.Encoder
+}
+
+func (e zstdEncoder) Encode(buf []byte) ([]byte, error) {
+	return e.enc.EncodeAll(buf, nil), nil
+}
+
+func (zstdEncoder) ContentEncoding() string { return "zstd" }
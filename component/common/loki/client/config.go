@@ -43,6 +43,14 @@ type Config struct {
 	// single tenant mode)
 	TenantID string `yaml:"tenant_id"`
 
+	// Compression applied to push requests on top of the snappy-compressed
+	// payload. Defaults to CompressionSnappy.
+	Compression Compression `yaml:"compression,omitempty"`
+
+	// Adaptive adapts the batch size and the number of concurrent requests
+	// to how the endpoint keeps up.
+	Adaptive AdaptiveConfig `yaml:"-"`
+
 	// deprecated use StreamLagLabels from config.Config instead
 	StreamLagLabels flagext.StringSliceCSV `yaml:"stream_lag_labels"`
 }
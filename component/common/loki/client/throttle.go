@@ -0,0 +1,136 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+)
+
+// maxThrottledBatches is the number of batches which can be queued for a
+// throttled tenant. Once reached, sending a batch of the tenant blocks until
+// the queue drains, slowing down the client like before tenants were
+// throttled separately.
+const maxThrottledBatches = 10
+
+// throttledTenant holds the batches of a tenant rate limited by the endpoint,
+// which are sent in order by a single goroutine.
+type throttledTenant struct {
+	// delay is how long to wait before retrying the first batch.
+	delay   time.Duration
+	batches []*pendingBatch
+}
+
+// throttleTenant starts retrying the batches of tenantID in the background,
+// starting with p after delay. It's called when the endpoint responds to p
+// with 429 Too Many Requests.
+func (c *client) throttleTenant(tenantID string, p *pendingBatch, delay time.Duration) {
+	c.throttledMut.Lock()
+	defer c.throttledMut.Unlock()
+
+	// Batches sent concurrently may already have throttled the tenant.
+	if t, ok := c.throttled[tenantID]; ok {
+		t.batches = append([]*pendingBatch{p}, t.batches...)
+		return
+	}
+
+	level.Warn(c.logger).Log("msg", "endpoint is rate limiting tenant, backing off its batches", "tenant", tenantID, "delay", delay)
+	t := &throttledTenant{delay: delay, batches: []*pendingBatch{p}}
+	c.throttled[tenantID] = t
+	c.metrics.throttledTenants.WithLabelValues(c.cfg.URL.Host).Inc()
+
+	c.throttledWg.Add(1)
+	go c.runThrottled(tenantID, t)
+}
+
+// queueThrottled queues p behind the batches of tenantID if the tenant is
+// throttled, and reports whether it did.
+func (c *client) queueThrottled(tenantID string, p *pendingBatch) bool {
+	c.throttledMut.Lock()
+	defer c.throttledMut.Unlock()
+
+	t, ok := c.throttled[tenantID]
+	if !ok {
+		return false
+	}
+	// The tenant stays throttled while it has batches queued.
+	for len(t.batches) >= maxThrottledBatches {
+		c.throttledCond.Wait()
+	}
+	t.batches = append(t.batches, p)
+	return true
+}
+
+// runThrottled sends the batches queued for tenantID, retrying them inline,
+// until none is left.
+func (c *client) runThrottled(tenantID string, t *throttledTenant) {
+	defer c.throttledWg.Done()
+
+	c.sleep(t.delay)
+	for {
+		c.throttledMut.Lock()
+		if len(t.batches) == 0 {
+			delete(c.throttled, tenantID)
+			c.metrics.throttledTenants.WithLabelValues(c.cfg.URL.Host).Dec()
+			c.throttledMut.Unlock()
+			level.Info(c.logger).Log("msg", "endpoint stopped rate limiting tenant", "tenant", tenantID)
+			return
+		}
+		p := t.batches[0]
+		t.batches = t.batches[1:]
+		c.throttledCond.Broadcast()
+		c.throttledMut.Unlock()
+
+		c.pushBatch(tenantID, p, true)
+	}
+}
+
+// retryDelay returns how long to wait before retrying a batch, counting the
+// retry in b. The delay of b is extended to retryAfter, when the endpoint
+// asked to wait longer, up to the maximum backoff.
+func (c *client) retryDelay(b *backoff.Backoff, retryAfter time.Duration) time.Duration {
+	delay := b.NextDelay()
+	if retryAfter > delay {
+		delay = retryAfter
+		if max := c.cfg.BackoffConfig.MaxBackoff; max > 0 && delay > max {
+			delay = max
+		}
+	}
+	return delay
+}
+
+// sleep waits for d, returning early if the client is stopped without
+// retries.
+func (c *client) sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-c.ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date. It returns 0 if the header is empty
+// or invalid.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
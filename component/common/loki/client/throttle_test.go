@@ -0,0 +1,136 @@
+package client
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ThrottledTenant(t *testing.T) {
+	type push struct {
+		tenant string
+		line   string
+	}
+	var (
+		mut    sync.Mutex
+		pushes []push
+		// tenant-a is rate limited until unthrottle is closed.
+		unthrottle = make(chan struct{})
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var pushReq logproto.PushRequest
+		if err := util.ParseProtoReader(req.Context(), req.Body, int(req.ContentLength), math.MaxInt32, &pushReq, util.RawSnappy); err != nil {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		tenant := req.Header.Get("X-Scope-OrgID")
+		if tenant == "tenant-a" {
+			select {
+			case <-unthrottle:
+			default:
+				rw.Header().Set("Retry-After", "1")
+				rw.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		mut.Lock()
+		defer mut.Unlock()
+		for _, s := range pushReq.Streams {
+			for _, e := range s.Entries {
+				pushes = append(pushes, push{tenant: tenant, line: e.Line})
+			}
+		}
+	}))
+	defer server.Close()
+
+	var serverURL flagext.URLValue
+	require.NoError(t, serverURL.Set(server.URL))
+
+	reg := prometheus.NewRegistry()
+	c, err := New(NewMetrics(reg, nil), Config{
+		URL:           serverURL,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     1024 * 1024,
+		BackoffConfig: backoff.Config{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, MaxRetries: 100},
+		Timeout:       time.Second,
+	}, nil, 0, log.NewNopLogger())
+	require.NoError(t, err)
+
+	entry := func(tenant, line string) loki.Entry {
+		return loki.Entry{
+			Labels: model.LabelSet{ReservedLabelTenantID: model.LabelValue(tenant)},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: line},
+		}
+	}
+	received := func() []push {
+		mut.Lock()
+		defer mut.Unlock()
+		return append([]push(nil), pushes...)
+	}
+
+	c.Chan() <- entry("tenant-a", "a1")
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.(*client).metrics.throttledTenants.WithLabelValues(serverURL.Host)) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// Other tenants aren't held back by the throttled tenant, whose batches
+	// are queued.
+	c.Chan() <- entry("tenant-b", "b1")
+	c.Chan() <- entry("tenant-a", "a2")
+	require.Eventually(t, func() bool {
+		return len(received()) == 1
+	}, time.Second, 5*time.Millisecond)
+	require.Equal(t, []push{{"tenant-b", "b1"}}, received())
+
+	// The batches of the tenant are sent in order once it's no longer rate
+	// limited.
+	close(unthrottle)
+	require.Eventually(t, func() bool {
+		return len(received()) == 3
+	}, 2*time.Second, 5*time.Millisecond)
+	require.Equal(t, []push{{"tenant-b", "b1"}, {"tenant-a", "a1"}, {"tenant-a", "a2"}}, received())
+
+	c.Stop()
+	require.Zero(t, testutil.ToFloat64(c.(*client).metrics.throttledTenants.WithLabelValues(serverURL.Host)))
+	require.NotZero(t, testutil.ToFloat64(c.(*client).metrics.retriesByStatus.WithLabelValues(serverURL.Host, "429")))
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP loki_write_dropped_entries_total Number of log entries dropped because failed to be sent to the ingester after all retries.
+		# TYPE loki_write_dropped_entries_total counter
+		loki_write_dropped_entries_total{host="`+serverURL.Host+`"} 0
+	`), "loki_write_dropped_entries_total"))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		value  string
+		expect time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{"Wed, 01 Mar 2023 12:00:30 GMT", 30 * time.Second},
+		{"Wed, 01 Mar 2023 11:59:00 GMT", 0},
+	}
+	for _, tc := range tt {
+		require.Equal(t, tc.expect, parseRetryAfter(tc.value, now), "value %q", tc.value)
+	}
+}
@@ -0,0 +1,239 @@
+package loki
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// DefaultLabelSetInternerSize is the default number of distinct label sets
+// kept by a LabelSetInterner.
+const DefaultLabelSetInternerSize = 10000
+
+// LabelSetInterner deduplicates the label sets of log entries, so that
+// entries with the same labels share a single model.LabelSet instead of each
+// allocating their own.
+//
+// Interned label sets are shared and must never be modified; Clone them
+// first. This is already required from receivers of log entries, as entries
+// are fanned out to multiple receivers without being copied.
+//
+// A LabelSetInterner is safe for concurrent use. A nil LabelSetInterner
+// doesn't intern label sets.
+type LabelSetInterner struct {
+	maxSize int
+
+	mut  sync.RWMutex
+	sets map[model.Fingerprint][]model.LabelSet
+	size int
+}
+
+// NewLabelSetInterner creates a LabelSetInterner keeping up to maxSize
+// distinct label sets. Once maxSize is reached, previously interned label
+// sets are forgotten.
+func NewLabelSetInterner(maxSize int) *LabelSetInterner {
+	if maxSize <= 0 {
+		maxSize = DefaultLabelSetInternerSize
+	}
+	return &LabelSetInterner{
+		maxSize: maxSize,
+		sets:    make(map[model.Fingerprint][]model.LabelSet),
+	}
+}
+
+// Intern returns the interned label set equal to ls, interning ls itself if
+// there's none yet.
+func (i *LabelSetInterner) Intern(ls model.LabelSet) model.LabelSet {
+	fp := ls.FastFingerprint()
+	if interned, ok := i.lookup(fp, func(cand model.LabelSet) bool { return cand.Equal(ls) }); ok {
+		return interned
+	}
+	return i.store(fp, ls)
+}
+
+// FromLabels returns the interned label set holding lbls. lbls is only
+// converted to a new model.LabelSet if it wasn't interned yet.
+func (i *LabelSetInterner) FromLabels(lbls labels.Labels) model.LabelSet {
+	return i.fromLabels(lbls, false)
+}
+
+// FromPublicLabels works like FromLabels but ignores the labels with a
+// double underscore prefix, which are internal to the pipeline.
+func (i *LabelSetInterner) FromPublicLabels(lbls labels.Labels) model.LabelSet {
+	return i.fromLabels(lbls, true)
+}
+
+func (i *LabelSetInterner) fromLabels(lbls labels.Labels, publicOnly bool) model.LabelSet {
+	skip := func(l labels.Label) bool {
+		return publicOnly && strings.HasPrefix(l.Name, model.ReservedLabelPrefix)
+	}
+
+	// Compute the same fingerprint as model.LabelSet.FastFingerprint without
+	// converting lbls.
+	var (
+		fp    uint64
+		count int
+	)
+	for _, l := range lbls {
+		if skip(l) {
+			continue
+		}
+		sum := hashAdd(hashNew(), l.Name)
+		sum = hashAddByte(sum, model.SeparatorByte)
+		sum = hashAdd(sum, l.Value)
+		fp ^= sum
+		count++
+	}
+	if count == 0 {
+		fp = hashNew()
+	}
+
+	equal := func(cand model.LabelSet) bool {
+		if len(cand) != count {
+			return false
+		}
+		for _, l := range lbls {
+			if skip(l) {
+				continue
+			}
+			if v, ok := cand[model.LabelName(l.Name)]; !ok || string(v) != l.Value {
+				return false
+			}
+		}
+		return true
+	}
+	if interned, ok := i.lookup(model.Fingerprint(fp), equal); ok {
+		return interned
+	}
+
+	ls := make(model.LabelSet, count)
+	for _, l := range lbls {
+		if skip(l) {
+			continue
+		}
+		ls[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return i.store(model.Fingerprint(fp), ls)
+}
+
+func (i *LabelSetInterner) lookup(fp model.Fingerprint, equal func(model.LabelSet) bool) (model.LabelSet, bool) {
+	if i == nil {
+		return nil, false
+	}
+	i.mut.RLock()
+	defer i.mut.RUnlock()
+
+	for _, cand := range i.sets[fp] {
+		if equal(cand) {
+			return cand, true
+		}
+	}
+	return nil, false
+}
+
+func (i *LabelSetInterner) store(fp model.Fingerprint, ls model.LabelSet) model.LabelSet {
+	if i == nil {
+		return ls
+	}
+	i.mut.Lock()
+	defer i.mut.Unlock()
+
+	// Another goroutine may have interned the same label set in the meantime.
+	for _, cand := range i.sets[fp] {
+		if cand.Equal(ls) {
+			return cand
+		}
+	}
+
+	if i.size >= i.maxSize {
+		// Label sets which were handed out remain valid, they're just no
+		// longer shared with new entries.
+		i.sets = make(map[model.Fingerprint][]model.LabelSet)
+		i.size = 0
+	}
+	i.sets[fp] = append(i.sets[fp], ls)
+	i.size++
+	return ls
+}
+
+// LabelStringCache caches the string representation of label sets, so that
+// it isn't formatted again for every entry of a stream.
+//
+// A LabelStringCache is safe for concurrent use.
+type LabelStringCache struct {
+	format  func(model.LabelSet) string
+	maxSize int
+
+	mut     sync.Mutex
+	strings map[model.Fingerprint][]labelString
+	size    int
+}
+
+type labelString struct {
+	ls model.LabelSet
+	s  string
+}
+
+// NewLabelStringCache creates a LabelStringCache keeping the string of up to
+// maxSize distinct label sets, as returned by format. Once maxSize is
+// reached, previously cached strings are forgotten.
+func NewLabelStringCache(maxSize int, format func(model.LabelSet) string) *LabelStringCache {
+	if maxSize <= 0 {
+		maxSize = DefaultLabelSetInternerSize
+	}
+	return &LabelStringCache{
+		format:  format,
+		maxSize: maxSize,
+		strings: make(map[model.Fingerprint][]labelString),
+	}
+}
+
+// String returns the string representation of ls.
+func (c *LabelStringCache) String(ls model.LabelSet) string {
+	fp := ls.FastFingerprint()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for _, cand := range c.strings[fp] {
+		if cand.ls.Equal(ls) {
+			return cand.s
+		}
+	}
+
+	if c.size >= c.maxSize {
+		c.strings = make(map[model.Fingerprint][]labelString)
+		c.size = 0
+	}
+	// ls may be modified by its owner once we return; keep a copy.
+	s := c.format(ls)
+	c.strings[fp] = append(c.strings[fp], labelString{ls: ls.Clone(), s: s})
+	c.size++
+	return s
+}
+
+// Inline FNV-1a hashing, matching the one used by model.LabelSet.FastFingerprint.
+const (
+	offset64 = 14695981039346656037
+	prime64  = 1099511628211
+)
+
+func hashNew() uint64 {
+	return offset64
+}
+
+func hashAdd(h uint64, s string) uint64 {
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= prime64
+	}
+	return h
+}
+
+func hashAddByte(h uint64, b byte) uint64 {
+	h ^= uint64(b)
+	h *= prime64
+	return h
+}
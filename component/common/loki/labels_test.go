@@ -0,0 +1,111 @@
+package loki
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelSetInterner(t *testing.T) {
+	i := NewLabelSetInterner(10)
+
+	first := i.FromPublicLabels(labels.FromStrings("__internal", "x", "app", "foo", "env", "prod"))
+	require.Equal(t, model.LabelSet{"app": "foo", "env": "prod"}, first)
+
+	// Equal label sets share the same map, however they're interned.
+	second := i.FromLabels(labels.FromStrings("env", "prod", "app", "foo"))
+	requireSameLabelSet(t, first, second)
+	third := i.Intern(model.LabelSet{"app": "foo", "env": "prod"})
+	requireSameLabelSet(t, first, third)
+
+	other := i.FromLabels(labels.FromStrings("app", "bar"))
+	require.Equal(t, model.LabelSet{"app": "bar"}, other)
+
+	empty := i.FromPublicLabels(labels.FromStrings("__internal", "x"))
+	require.Empty(t, empty)
+	requireSameLabelSet(t, empty, i.Intern(model.LabelSet{}))
+}
+
+func TestLabelSetInterner_Fingerprint(t *testing.T) {
+	// Label sets built from labels must be found when interning the
+	// equivalent model.LabelSet, which relies on both being hashed the same.
+	i := NewLabelSetInterner(10)
+	for _, lbls := range []labels.Labels{
+		labels.FromStrings("a", "b"),
+		labels.FromStrings("a", "b", "c", "d"),
+		labels.FromStrings("job", "loki.source.syslog", "host", "server-1"),
+	} {
+		ls := i.FromLabels(lbls)
+		requireSameLabelSet(t, ls, i.Intern(ls.Clone()))
+	}
+}
+
+func TestLabelSetInterner_MaxSize(t *testing.T) {
+	i := NewLabelSetInterner(2)
+
+	a := i.Intern(model.LabelSet{"app": "a"})
+	i.Intern(model.LabelSet{"app": "b"})
+	// Interning a third label set forgets the previous ones.
+	i.Intern(model.LabelSet{"app": "c"})
+
+	a2 := i.Intern(model.LabelSet{"app": "a"})
+	require.Equal(t, a, a2)
+	require.False(t, sameLabelSet(a, a2), "expected a new label set once the interner was reset")
+}
+
+func TestLabelSetInterner_Nil(t *testing.T) {
+	var i *LabelSetInterner
+
+	ls := model.LabelSet{"app": "foo"}
+	requireSameLabelSet(t, ls, i.Intern(ls))
+	require.Equal(t, ls, i.FromPublicLabels(labels.FromStrings("__internal", "x", "app", "foo")))
+}
+
+func TestLabelSetInterner_Allocs(t *testing.T) {
+	i := NewLabelSetInterner(10)
+	lbls := labels.FromStrings("__internal", "x", "app", "foo", "env", "prod")
+	i.FromPublicLabels(lbls)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		i.FromPublicLabels(lbls)
+	})
+	require.Zero(t, allocs, "interned label sets should not be allocated again")
+}
+
+func TestLabelStringCache(t *testing.T) {
+	var calls int
+	c := NewLabelStringCache(10, func(ls model.LabelSet) string {
+		calls++
+		return ls.String()
+	})
+
+	ls := model.LabelSet{"app": "foo"}
+	require.Equal(t, `{app="foo"}`, c.String(ls))
+	require.Equal(t, `{app="foo"}`, c.String(model.LabelSet{"app": "foo"}))
+	require.Equal(t, 1, calls)
+
+	// Modifying a label set after its string was cached must not affect the
+	// cache.
+	ls["app"] = "bar"
+	require.Equal(t, `{app="bar"}`, c.String(ls))
+	require.Equal(t, `{app="foo"}`, c.String(model.LabelSet{"app": "foo"}))
+	require.Equal(t, 2, calls)
+}
+
+func requireSameLabelSet(t *testing.T, expect, actual model.LabelSet) {
+	t.Helper()
+	require.Equal(t, expect, actual)
+	require.True(t, sameLabelSet(expect, actual), "expected label sets to share the same map")
+}
+
+// sameLabelSet returns whether a and b are the same map, by checking whether
+// modifying one modifies the other.
+func sameLabelSet(a, b model.LabelSet) bool {
+	const probe = model.LabelName("__probe__")
+	a[probe] = "1"
+	defer delete(a, probe)
+	_, ok := b[probe]
+	return ok
+}
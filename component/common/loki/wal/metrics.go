@@ -0,0 +1,65 @@
+package wal
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the metrics of a WAL and its readers. Metrics are shared
+// between all WALs opened by a component.
+type Metrics struct {
+	entriesWritten  prometheus.Counter
+	writeFailures   prometheus.Counter
+	segmentsDeleted *prometheus.CounterVec
+	size            prometheus.Gauge
+	entriesRead     *prometheus.CounterVec
+	readFailures    *prometheus.CounterVec
+	readerSegment   *prometheus.GaugeVec
+}
+
+// NewMetrics creates and registers the WAL metrics.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+
+	m.entriesWritten = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_write_wal_entries_written_total",
+		Help: "Number of log entries written to the WAL.",
+	})
+	m.writeFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_write_wal_write_failures_total",
+		Help: "Number of log entries which failed to be written to the WAL.",
+	})
+	m.segmentsDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_write_wal_segments_deleted_total",
+		Help: "Number of WAL segments deleted, by reason.",
+	}, []string{"reason"})
+	m.size = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "loki_write_wal_size_bytes",
+		Help: "Size of the WAL segments on disk.",
+	})
+	m.entriesRead = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_write_wal_entries_read_total",
+		Help: "Number of log entries read from the WAL and forwarded to an endpoint.",
+	}, []string{"endpoint"})
+	m.readFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_write_wal_read_failures_total",
+		Help: "Number of failures reading the WAL.",
+	}, []string{"endpoint"})
+	m.readerSegment = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "loki_write_wal_reader_segment",
+		Help: "WAL segment an endpoint is currently reading.",
+	}, []string{"endpoint"})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.entriesWritten,
+			m.writeFailures,
+			m.segmentsDeleted,
+			m.size,
+			m.entriesRead,
+			m.readFailures,
+			m.readerSegment,
+		)
+	}
+
+	return &m
+}
@@ -0,0 +1,235 @@
+package wal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+)
+
+const (
+	// readPeriod is how often a reader checks for new records once it has
+	// caught up with the WAL.
+	readPeriod = 50 * time.Millisecond
+
+	// retryPeriod is how long a reader waits before retrying after failing
+	// to read the WAL.
+	retryPeriod = 5 * time.Second
+)
+
+// liveReaderMetrics are passed to wlog.LiveReader, which requires them.
+// They're not registered.
+var liveReaderMetrics = wlog.NewLiveReaderMetrics(nil)
+
+// Reader reads entries from a WAL and forwards them to a channel, keeping
+// track of its position so that it can resume after a restart.
+//
+// The position of a reader advances once an entry has been handed over to
+// the channel.
+type Reader struct {
+	w    *WAL
+	name string
+	log  log.Logger
+	ch   chan<- loki.Entry
+
+	mut sync.Mutex
+	pos Position
+
+	stopOnce sync.Once
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+func newReader(w *WAL, name string, pos Position, ch chan<- loki.Entry) *Reader {
+	r := &Reader{
+		w:    w,
+		name: name,
+		log:  log.With(w.log, "wal_reader", name),
+		ch:   ch,
+		pos:  pos,
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Position returns the current position of the reader.
+func (r *Reader) Position() Position {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.pos
+}
+
+func (r *Reader) setPosition(pos Position) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.pos = pos
+	r.w.metrics.readerSegment.WithLabelValues(r.name).Set(float64(pos.Segment))
+}
+
+// Stop stops the reader and records its position in the WAL so that a new
+// reader with the same name resumes from it.
+func (r *Reader) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.quit)
+		<-r.done
+		r.w.removeReader(r)
+		r.w.metrics.readerSegment.DeleteLabelValues(r.name)
+	})
+}
+
+func (r *Reader) run() {
+	defer close(r.done)
+
+	for {
+		err := r.readSegment()
+		select {
+		case <-r.quit:
+			return
+		default:
+		}
+		if err == nil {
+			continue
+		}
+
+		level.Error(r.log).Log("msg", "failed to read WAL segment", "segment", r.Position().Segment, "err", err)
+		r.w.metrics.readFailures.WithLabelValues(r.name).Inc()
+
+		select {
+		case <-r.quit:
+			return
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+// readSegment reads the segment of the current position until the segment
+// is complete or the reader is stopped. It returns nil once the position
+// has been moved to the next segment.
+func (r *Reader) readSegment() error {
+	pos := r.Position()
+
+	first, last, err := wlog.Segments(r.w.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	if last == -1 {
+		return fmt.Errorf("no segments found")
+	}
+	if pos.Segment < first {
+		level.Warn(r.log).Log("msg", "WAL segments were deleted before being read, skipping to the oldest remaining segment", "segment", pos.Segment, "oldest", first)
+		pos = Position{Segment: first}
+		r.setPosition(pos)
+	}
+
+	segment, err := wlog.OpenReadSegment(wlog.SegmentName(r.w.cfg.Dir, pos.Segment))
+	if err != nil {
+		return err
+	}
+	defer segment.Close()
+
+	var (
+		lr = wlog.NewLiveReader(r.log, liveReaderMetrics, segment)
+
+		ticker   = time.NewTicker(readPeriod)
+		read     = 0
+		complete = false
+	)
+	defer ticker.Stop()
+
+	for {
+		for lr.Next() {
+			read++
+			if read <= pos.Records {
+				continue
+			}
+			if !r.dispatch(lr.Record()) {
+				return nil
+			}
+			r.setPosition(Position{Segment: pos.Segment, Records: read})
+		}
+
+		_, last, err := wlog.Segments(r.w.cfg.Dir)
+		if err != nil {
+			return err
+		}
+
+		if err := lr.Err(); !errors.Is(err, io.EOF) {
+			if last <= pos.Segment {
+				return err
+			}
+			// A newer segment exists, so the rest of this one will never be
+			// readable. This happens when the agent stopped while writing a
+			// record.
+			level.Warn(r.log).Log("msg", "skipping the rest of a corrupted WAL segment", "segment", pos.Segment, "err", err)
+			r.w.metrics.readFailures.WithLabelValues(r.name).Inc()
+			complete = true
+		}
+
+		if complete {
+			r.setPosition(Position{Segment: pos.Segment + 1})
+			return nil
+		}
+		if last > pos.Segment {
+			// The segment was completed by the writer. Read whatever is left
+			// in it before moving on.
+			complete = true
+			continue
+		}
+
+		select {
+		case <-r.quit:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch decodes a record and forwards its entries. It returns false if the
+// reader was stopped before all entries were forwarded.
+func (r *Reader) dispatch(rec []byte) bool {
+	lset, entries, err := decodeRecord(rec, r.w.labelSets)
+	if err != nil {
+		level.Warn(r.log).Log("msg", "dropping undecodable WAL record", "err", err)
+		r.w.metrics.readFailures.WithLabelValues(r.name).Inc()
+		return true
+	}
+
+	for _, e := range entries {
+		select {
+		case <-r.quit:
+			return false
+		// Interned labels are read-only, so they can be shared by the
+		// entries.
+		case r.ch <- loki.Entry{Labels: lset, Entry: e}:
+			r.w.metrics.entriesRead.WithLabelValues(r.name).Inc()
+		}
+	}
+	return true
+}
+
+func decodeRecord(rec []byte, labelSets *loki.LabelSetInterner) (model.LabelSet, []logproto.Entry, error) {
+	if len(rec) == 0 || rec[0] != recordStreamV1 {
+		return nil, nil, fmt.Errorf("unknown record type")
+	}
+
+	var stream logproto.Stream
+	if err := stream.Unmarshal(rec[1:]); err != nil {
+		return nil, nil, err
+	}
+	lbls, err := parser.ParseMetric(stream.Labels)
+	if err != nil {
+		return nil, nil, err
+	}
+	return labelSets.FromLabels(lbls), stream.Entries, nil
+}
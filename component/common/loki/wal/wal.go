@@ -0,0 +1,371 @@
+// Package wal implements a write-ahead log for log entries. Entries are
+// appended to the WAL and read back by one Reader per consumer, so that
+// entries which have not been consumed yet survive restarts.
+package wal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+)
+
+// segmentSize is the size of each WAL segment. It is much smaller than the
+// default wlog segment size so that size-based truncation is reasonably
+// granular.
+const segmentSize = 8 * 1024 * 1024
+
+// positionsFile is the name of the file inside the WAL directory which holds
+// the positions of readers.
+const positionsFile = "positions.json"
+
+// recordStreamV1 identifies a record holding a single marshaled
+// logproto.Stream.
+const recordStreamV1 byte = 1
+
+// Config configures a WAL.
+type Config struct {
+	// Dir is the directory where segments and reader positions are stored.
+	Dir string
+
+	// MaxSegmentAge is the age after which segments are deleted, even if
+	// they have not been read by all readers. Zero disables age-based
+	// truncation.
+	MaxSegmentAge time.Duration
+
+	// MaxSize is the maximum size in bytes of all segments. The oldest
+	// segments are deleted once the size is exceeded. Zero disables
+	// size-based truncation.
+	MaxSize int64
+
+	// TruncateFrequency is how often segments are truncated and reader
+	// positions are persisted.
+	TruncateFrequency time.Duration
+}
+
+// Position is the position of a Reader in the WAL.
+type Position struct {
+	// Segment is the index of the segment being read.
+	Segment int `json:"segment"`
+	// Records is the number of records of Segment which have been read.
+	Records int `json:"records"`
+}
+
+// WAL is a write-ahead log of log entries.
+type WAL struct {
+	cfg     Config
+	log     log.Logger
+	metrics *Metrics
+	wl      *wlog.WL
+
+	// Shared with readers, so that entries of the same stream share their
+	// labels once read back.
+	labelSets    *loki.LabelSetInterner
+	labelStrings *loki.LabelStringCache
+
+	mut sync.Mutex
+	// readers are the currently running readers, by name.
+	readers map[string]*Reader
+	// stored holds the positions of readers which are not running, either
+	// loaded from disk or recorded when a reader stopped.
+	stored map[string]Position
+
+	closeOnce sync.Once
+	quit      chan struct{}
+	done      chan struct{}
+}
+
+// New opens or creates the WAL in cfg.Dir.
+func New(cfg Config, logger log.Logger, metrics *Metrics) (*WAL, error) {
+	// wlog metrics are not registered; the same directory may be reopened any
+	// number of times over the lifetime of a component.
+	wl, err := wlog.NewSize(logger, nil, cfg.Dir, segmentSize, false)
+	if err != nil {
+		return nil, fmt.Errorf("opening WAL: %w", err)
+	}
+
+	stored, err := readPositions(filepath.Join(cfg.Dir, positionsFile))
+	if err != nil {
+		level.Warn(logger).Log("msg", "failed to read WAL reader positions, readers will start at the end of the WAL", "err", err)
+		stored = map[string]Position{}
+	}
+
+	w := &WAL{
+		cfg:     cfg,
+		log:     logger,
+		metrics: metrics,
+		wl:      wl,
+		readers: make(map[string]*Reader),
+
+		labelSets:    loki.NewLabelSetInterner(loki.DefaultLabelSetInternerSize),
+		labelStrings: loki.NewLabelStringCache(loki.DefaultLabelSetInternerSize, model.LabelSet.String),
+		stored:       stored,
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Append writes an entry to the WAL.
+func (w *WAL) Append(e loki.Entry) error {
+	stream := logproto.Stream{
+		Labels:  w.labelStrings.String(e.Labels),
+		Entries: []logproto.Entry{e.Entry},
+	}
+	buf := make([]byte, 1+stream.Size())
+	buf[0] = recordStreamV1
+	if _, err := stream.MarshalToSizedBuffer(buf[1:]); err != nil {
+		w.metrics.writeFailures.Inc()
+		return err
+	}
+
+	if err := w.wl.Log(buf); err != nil {
+		w.metrics.writeFailures.Inc()
+		return err
+	}
+	w.metrics.entriesWritten.Inc()
+	return nil
+}
+
+// NewReader creates a new Reader which forwards entries to ch once started.
+// The reader resumes from the last position recorded for name. Readers
+// without a recorded position start at the end of the WAL.
+func (w *WAL) NewReader(name string, ch chan<- loki.Entry) (*Reader, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	if _, ok := w.readers[name]; ok {
+		return nil, fmt.Errorf("WAL reader %q already exists", name)
+	}
+
+	pos, ok := w.stored[name]
+	if !ok {
+		var err error
+		if pos, err = w.headPosition(); err != nil {
+			return nil, err
+		}
+	}
+	delete(w.stored, name)
+
+	r := newReader(w, name, pos, ch)
+	w.readers[name] = r
+	return r, nil
+}
+
+// headPosition returns a position pointing after the last record of the WAL.
+func (w *WAL) headPosition() (Position, error) {
+	seg, offset, err := w.wl.LastSegmentAndOffset()
+	if err != nil {
+		return Position{}, err
+	}
+	if offset > 0 {
+		// The number of records in the head segment isn't tracked; cut a new
+		// segment instead.
+		if seg, err = w.wl.NextSegmentSync(); err != nil {
+			return Position{}, err
+		}
+	}
+	return Position{Segment: seg}, nil
+}
+
+// removeReader is called by readers once they stopped.
+func (w *WAL) removeReader(r *Reader) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	if w.readers[r.name] == r {
+		delete(w.readers, r.name)
+		w.stored[r.name] = r.Position()
+	}
+}
+
+// Close persists the positions of readers and closes the WAL. All readers
+// must be stopped before calling Close.
+func (w *WAL) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.quit)
+		<-w.done
+
+		if perr := w.savePositions(); perr != nil {
+			level.Error(w.log).Log("msg", "failed to save WAL reader positions", "err", perr)
+		}
+		err = w.wl.Close()
+	})
+	return err
+}
+
+func (w *WAL) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.cfg.TruncateFrequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+			if err := w.truncate(); err != nil {
+				level.Error(w.log).Log("msg", "failed to truncate WAL", "err", err)
+			}
+			if err := w.savePositions(); err != nil {
+				level.Error(w.log).Log("msg", "failed to save WAL reader positions", "err", err)
+			}
+		}
+	}
+}
+
+// truncate deletes segments which are no longer needed. Segments are only
+// ever deleted from the start of the WAL, and the segment being written to
+// is never deleted.
+func (w *WAL) truncate() error {
+	segments, err := listSegments(w.cfg.Dir)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	// Segments before the oldest position of all running readers have been
+	// fully consumed.
+	consumed := -1
+	w.mut.Lock()
+	for _, r := range w.readers {
+		if seg := r.Position().Segment; consumed == -1 || seg < consumed {
+			consumed = seg
+		}
+	}
+	w.mut.Unlock()
+
+	var totalSize int64
+	for _, s := range segments {
+		totalSize += s.size
+	}
+
+	var (
+		minModTime = time.Now().Add(-w.cfg.MaxSegmentAge)
+		head       = segments[len(segments)-1].index
+	)
+	for _, s := range segments {
+		if s.index == head {
+			break
+		}
+
+		var reason string
+		switch {
+		case s.index < consumed:
+			reason = "consumed"
+		case w.cfg.MaxSegmentAge > 0 && s.modTime.Before(minModTime):
+			reason = "max_age"
+		case w.cfg.MaxSize > 0 && totalSize > w.cfg.MaxSize:
+			reason = "max_size"
+		default:
+			// Keep the remaining segments contiguous.
+			w.metrics.size.Set(float64(totalSize))
+			return nil
+		}
+
+		if err := os.Remove(filepath.Join(w.cfg.Dir, s.name)); err != nil {
+			return err
+		}
+		if reason != "consumed" {
+			level.Warn(w.log).Log("msg", "deleted WAL segment before all readers consumed it", "segment", s.index, "reason", reason)
+		}
+		totalSize -= s.size
+		w.metrics.segmentsDeleted.WithLabelValues(reason).Inc()
+	}
+
+	w.metrics.size.Set(float64(totalSize))
+	return nil
+}
+
+// savePositions atomically writes the positions of all running readers to
+// disk.
+func (w *WAL) savePositions() error {
+	w.mut.Lock()
+	positions := make(map[string]Position, len(w.readers)+len(w.stored))
+	for name, pos := range w.stored {
+		positions[name] = pos
+	}
+	for name, r := range w.readers {
+		positions[name] = r.Position()
+	}
+	w.mut.Unlock()
+
+	bb, err := json.Marshal(positions)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(w.cfg.Dir, positionsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bb, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func readPositions(path string) (map[string]Position, error) {
+	bb, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]Position{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	positions := map[string]Position{}
+	if err := json.Unmarshal(bb, &positions); err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+type segmentRef struct {
+	name    string
+	index   int
+	size    int64
+	modTime time.Time
+}
+
+// listSegments returns the segments in dir, ordered by index.
+func listSegments(dir string) ([]segmentRef, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// os.ReadDir returns entries sorted by name. Segment names are zero-padded,
+	// so this is also the order of their indices.
+	var refs []segmentRef
+	for _, f := range files {
+		k, err := strconv.Atoi(f.Name())
+		if err != nil {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		refs = append(refs, segmentRef{
+			name:    f.Name(),
+			index:   k,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+	return refs, nil
+}
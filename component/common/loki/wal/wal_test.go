@@ -0,0 +1,168 @@
+package wal
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) Config {
+	return Config{
+		Dir:               t.TempDir(),
+		TruncateFrequency: time.Hour,
+	}
+}
+
+func testEntry(i int) loki.Entry {
+	return loki.Entry{
+		Labels: model.LabelSet{"app": "test", "index": model.LabelValue(fmt.Sprint(i))},
+		Entry: logproto.Entry{
+			Timestamp: time.Unix(int64(i), 0).UTC(),
+			Line:      fmt.Sprintf("line %d", i),
+		},
+	}
+}
+
+func receive(t *testing.T, ch <-chan loki.Entry) loki.Entry {
+	t.Helper()
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for entry")
+		return loki.Entry{}
+	}
+}
+
+func TestWAL_ReadWrite(t *testing.T) {
+	w, err := New(testConfig(t), util.TestLogger(t), NewMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	defer w.Close()
+
+	ch := make(chan loki.Entry)
+	r, err := w.NewReader("endpoint", ch)
+	require.NoError(t, err)
+	defer r.Stop()
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, w.Append(testEntry(i)))
+	}
+	for i := 0; i < 10; i++ {
+		require.Equal(t, testEntry(i), receive(t, ch))
+	}
+}
+
+func TestWAL_Resume(t *testing.T) {
+	cfg := testConfig(t)
+	metrics := NewMetrics(prometheus.NewRegistry())
+
+	w, err := New(cfg, util.TestLogger(t), metrics)
+	require.NoError(t, err)
+
+	ch := make(chan loki.Entry)
+	r, err := w.NewReader("endpoint", ch)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, w.Append(testEntry(i)))
+	}
+
+	// Only consume some of the entries before "restarting".
+	for i := 0; i < 3; i++ {
+		require.Equal(t, testEntry(i), receive(t, ch))
+	}
+	r.Stop()
+	require.NoError(t, w.Close())
+
+	w, err = New(cfg, util.TestLogger(t), metrics)
+	require.NoError(t, err)
+	defer w.Close()
+
+	r, err = w.NewReader("endpoint", ch)
+	require.NoError(t, err)
+	defer r.Stop()
+
+	// Entries appended after the restart are read after the replayed ones.
+	require.NoError(t, w.Append(testEntry(10)))
+	for i := 3; i <= 10; i++ {
+		require.Equal(t, testEntry(i), receive(t, ch))
+	}
+
+	// A new reader only receives entries written after it was created.
+	other := make(chan loki.Entry, 1)
+	r2, err := w.NewReader("other", other)
+	require.NoError(t, err)
+	defer r2.Stop()
+
+	require.NoError(t, w.Append(testEntry(11)))
+	require.Equal(t, testEntry(11), receive(t, other))
+	require.Equal(t, testEntry(11), receive(t, ch))
+}
+
+func TestWAL_Truncate(t *testing.T) {
+	cfg := testConfig(t)
+	w, err := New(cfg, util.TestLogger(t), NewMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	defer w.Close()
+
+	ch := make(chan loki.Entry)
+	r, err := w.NewReader("endpoint", ch)
+	require.NoError(t, err)
+	defer r.Stop()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, w.Append(testEntry(i)))
+		_, err := w.wl.NextSegmentSync()
+		require.NoError(t, err)
+	}
+	require.Equal(t, testEntry(0), receive(t, ch))
+	require.Eventually(t, func() bool {
+		return r.Position().Segment == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Only the segment being read and the ones after it are kept.
+	require.NoError(t, w.truncate())
+	segments, err := listSegments(cfg.Dir)
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+	require.Equal(t, 1, segments[0].index)
+
+	// Exceeding the maximum size deletes unread segments too, except for the
+	// segment being written to.
+	w.cfg.MaxSize = 1
+	require.NoError(t, w.truncate())
+	segments, err = listSegments(cfg.Dir)
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	// The entry which was already being sent is still delivered, after which
+	// the reader skips to the oldest remaining segment.
+	require.NoError(t, w.Append(testEntry(3)))
+	require.Equal(t, testEntry(1), receive(t, ch))
+	require.Equal(t, testEntry(3), receive(t, ch))
+}
+
+func TestWAL_CorruptedPositions(t *testing.T) {
+	cfg := testConfig(t)
+	require.NoError(t, os.WriteFile(cfg.Dir+"/"+positionsFile, []byte("{"), 0o644))
+
+	w, err := New(cfg, util.TestLogger(t), NewMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+	defer w.Close()
+
+	ch := make(chan loki.Entry)
+	r, err := w.NewReader("endpoint", ch)
+	require.NoError(t, err)
+	defer r.Stop()
+
+	require.NoError(t, w.Append(testEntry(0)))
+	require.Equal(t, testEntry(0), receive(t, ch))
+}
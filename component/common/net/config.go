@@ -4,9 +4,12 @@ package net
 
 import (
 	"flag"
+	"fmt"
 	"math"
+	"strings"
 	"time"
 
+	"github.com/alecthomas/units"
 	weaveworks "github.com/weaveworks/common/server"
 )
 
@@ -16,6 +19,10 @@ const (
 	// using zero as default grpc port to assing random free port when not configured
 	DefaultGRPCPort = 0
 
+	// DefaultListenNetwork listens on both IPv4 and IPv6 when the listen
+	// address allows it.
+	DefaultListenNetwork = "tcp"
+
 	// defaults inherited from weaveworks
 	durationInfinity = time.Duration(math.MaxInt64)
 	size4MB          = 4 << 20
@@ -40,26 +47,69 @@ type ServerConfig struct {
 type HTTPConfig struct {
 	ListenAddress      string        `river:"listen_address,attr,optional"`
 	ListenPort         int           `river:"listen_port,attr,optional"`
+	ListenNetwork      string        `river:"listen_network,attr,optional"`
 	ConnLimit          int           `river:"conn_limit,attr,optional"`
 	ServerReadTimeout  time.Duration `river:"server_read_timeout,attr,optional"`
 	ServerWriteTimeout time.Duration `river:"server_write_timeout,attr,optional"`
 	ServerIdleTimeout  time.Duration `river:"server_idle_timeout,attr,optional"`
+
+	// MaxDecompressedBodySize limits the size of compressed request bodies
+	// after decompression. Zero uses DefaultMaxDecompressedBodySize.
+	MaxDecompressedBodySize units.Base2Bytes `river:"max_decompressed_body_size,attr,optional"`
+
+	// TLS optionally configures the HTTP server to serve HTTPS.
+	TLS *TLSConfig `river:"tls,block,optional"`
+}
+
+// TLSConfig configures TLS for a server started by weaveworks.Server.
+type TLSConfig struct {
+	CertFile       string `river:"cert_file,attr"`
+	KeyFile        string `river:"key_file,attr"`
+	ClientCAFile   string `river:"client_ca_file,attr,optional"`
+	ClientAuthType string `river:"client_auth_type,attr,optional"`
+}
+
+// Into applies the configs from TLSConfig into a weaveworks.TLSConfig.
+func (t *TLSConfig) Into(c *weaveworks.TLSConfig) {
+	c.TLSCertPath = t.CertFile
+	c.TLSKeyPath = t.KeyFile
+	c.ClientCAs = t.ClientCAFile
+	c.ClientAuth = t.ClientAuthType
+}
+
+// maxDecompressedBodySize returns the limit of the size of request bodies
+// after decompression.
+func (h *HTTPConfig) maxDecompressedBodySize() int64 {
+	if h == nil || h.MaxDecompressedBodySize <= 0 {
+		return DefaultMaxDecompressedBodySize
+	}
+	return int64(h.MaxDecompressedBodySize)
+}
+
+// Validate implements river.Validator.
+func (h *HTTPConfig) Validate() error {
+	return validateListenNetwork(h.ListenNetwork)
 }
 
 // Into applies the configs from HTTPConfig into a weaveworks.Into.
 func (h *HTTPConfig) Into(c *weaveworks.Config) {
-	c.HTTPListenAddress = h.ListenAddress
+	c.HTTPListenAddress = listenHost(h.ListenAddress)
 	c.HTTPListenPort = h.ListenPort
+	c.HTTPListenNetwork = listenNetwork(h.ListenNetwork)
 	c.HTTPConnLimit = h.ConnLimit
 	c.HTTPServerReadTimeout = h.ServerReadTimeout
 	c.HTTPServerWriteTimeout = h.ServerWriteTimeout
 	c.HTTPServerIdleTimeout = h.ServerIdleTimeout
+	if h.TLS != nil {
+		h.TLS.Into(&c.HTTPTLSConfig)
+	}
 }
 
 // GRPCConfig configures the gRPC weaveworks started by weaveworks.Server.
 type GRPCConfig struct {
 	ListenAddress              string        `river:"listen_address,attr,optional"`
 	ListenPort                 int           `river:"listen_port,attr,optional"`
+	ListenNetwork              string        `river:"listen_network,attr,optional"`
 	ConnLimit                  int           `river:"conn_limit,attr,optional"`
 	MaxConnectionAge           time.Duration `river:"max_connection_age,attr,optional"`
 	MaxConnectionAgeGrace      time.Duration `river:"max_connection_age_grace,attr,optional"`
@@ -69,10 +119,16 @@ type GRPCConfig struct {
 	ServerMaxConcurrentStreams uint          `river:"server_max_concurrent_streams,attr,optional"`
 }
 
+// Validate implements river.Validator.
+func (g *GRPCConfig) Validate() error {
+	return validateListenNetwork(g.ListenNetwork)
+}
+
 // Into applies the configs from GRPCConfig into a weaveworks.Into.
 func (g *GRPCConfig) Into(c *weaveworks.Config) {
-	c.GRPCListenAddress = g.ListenAddress
+	c.GRPCListenAddress = listenHost(g.ListenAddress)
 	c.GRPCListenPort = g.ListenPort
+	c.GRPCListenNetwork = listenNetwork(g.ListenNetwork)
 	c.GRPCConnLimit = g.ConnLimit
 	c.GRPCServerMaxConnectionAge = g.MaxConnectionAge
 	c.GRPCServerMaxConnectionAgeGrace = g.MaxConnectionAgeGrace
@@ -82,6 +138,36 @@ func (g *GRPCConfig) Into(c *weaveworks.Config) {
 	c.GPRCServerMaxConcurrentStreams = g.ServerMaxConcurrentStreams
 }
 
+// validateListenNetwork validates the network a server listens on, which
+// restricts the address family of the server. An empty network uses
+// DefaultListenNetwork.
+func validateListenNetwork(network string) error {
+	switch network {
+	case "", "tcp", "tcp4", "tcp6":
+		return nil
+	default:
+		return fmt.Errorf("unrecognized listen_network %q, must be one of tcp, tcp4, or tcp6", network)
+	}
+}
+
+func listenNetwork(network string) string {
+	if network == "" {
+		return DefaultListenNetwork
+	}
+	return network
+}
+
+// listenHost returns the listen address in the form expected by
+// weaveworks.Server, which appends the port to it. IPv6 literals, such as
+// "::" or "fe80::1%eth0", are enclosed in brackets so that the port can be
+// appended.
+func listenHost(addr string) string {
+	if strings.Contains(addr, ":") && !strings.HasPrefix(addr, "[") {
+		return "[" + addr + "]"
+	}
+	return addr
+}
+
 // Convert converts the River-based ServerConfig into a weaveworks.Config object.
 func (c *ServerConfig) convert() weaveworks.Config {
 	cfg := newWeaveworksDefaultConfig()
@@ -118,14 +204,18 @@ func DefaultServerConfig() *ServerConfig {
 		HTTP: &HTTPConfig{
 			ListenAddress:      "",
 			ListenPort:         DefaultHTTPPort,
+			ListenNetwork:      DefaultListenNetwork,
 			ConnLimit:          0,
 			ServerReadTimeout:  30 * time.Second,
 			ServerWriteTimeout: 30 * time.Second,
 			ServerIdleTimeout:  120 * time.Second,
+
+			MaxDecompressedBodySize: DefaultMaxDecompressedBodySize,
 		},
 		GRPC: &GRPCConfig{
 			ListenAddress:              "",
 			ListenPort:                 DefaultGRPCPort,
+			ListenNetwork:              DefaultListenNetwork,
 			ConnLimit:                  0,
 			MaxConnectionAge:           durationInfinity,
 			MaxConnectionAgeGrace:      durationInfinity,
@@ -102,6 +102,49 @@ func TestConfig(t *testing.T) {
 				require.Equal(t, time.Minute, config.ServerGracefulShutdownTimeout)
 			},
 		},
+		"http tls": {
+			raw: `
+			http {
+				tls {
+					cert_file        = "/etc/agent/server.crt"
+					key_file         = "/etc/agent/server.key"
+					client_ca_file   = "/etc/agent/ca.crt"
+					client_auth_type = "RequireAndVerifyClientCert"
+				}
+			}`,
+			assert: func(t *testing.T, config weaveworks.Config) {
+				require.Equal(t, "/etc/agent/server.crt", config.HTTPTLSConfig.TLSCertPath)
+				require.Equal(t, "/etc/agent/server.key", config.HTTPTLSConfig.TLSKeyPath)
+				require.Equal(t, "/etc/agent/ca.crt", config.HTTPTLSConfig.ClientCAs)
+				require.Equal(t, "RequireAndVerifyClientCert", config.HTTPTLSConfig.ClientAuth)
+				// defaults should still apply to the rest of the block
+				require.Equal(t, DefaultHTTPPort, config.HTTPListenPort)
+			},
+		},
+		"ipv6 listen addresses": {
+			raw: `
+			http {
+				listen_address = "::1"
+				listen_network = "tcp6"
+			}
+			grpc {
+				listen_address = "[::]"
+			}`,
+			assert: func(t *testing.T, config weaveworks.Config) {
+				require.Equal(t, "[::1]", config.HTTPListenAddress)
+				require.Equal(t, "tcp6", config.HTTPListenNetwork)
+				require.Equal(t, "[::]", config.GRPCListenAddress)
+				require.Equal(t, DefaultListenNetwork, config.GRPCListenNetwork)
+			},
+		},
+		"invalid listen network": {
+			raw: `
+			http {
+				listen_network = "udp"
+			}`,
+			errExpected: true,
+			assert:      func(t *testing.T, config weaveworks.Config) {},
+		},
 		"all params": {
 			raw: `
 			graceful_shutdown_timeout = "1m"
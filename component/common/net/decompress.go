@@ -0,0 +1,106 @@
+package net
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultMaxDecompressedBodySize is the default limit of the size of request
+// bodies after decompression.
+const DefaultMaxDecompressedBodySize = 100 << 20
+
+// Content-Encodings which are passed on to handlers as is. Handlers for the
+// Prometheus remote write and Loki push protocols decode snappy bodies, and
+// the Loki push handler decodes deflate bodies.
+var passthroughEncodings = map[string]struct{}{
+	"":         {},
+	"identity": {},
+	"snappy":   {},
+	"deflate":  {},
+}
+
+var (
+	errUnsupportedEncoding = errors.New("unsupported Content-Encoding")
+	errBodyTooLarge        = errors.New("request body too large")
+)
+
+// decompressHandler decompresses request bodies compressed with gzip, zstd
+// or brotli before passing requests to next, so that handlers only have to
+// deal with uncompressed bodies. Requests with any other unsupported
+// Content-Encoding are rejected with 415 Unsupported Media Type, and requests
+// whose bodies exceed maxSize after decompression with 413 Request Entity Too
+// Large.
+func decompressHandler(maxSize int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+		if _, ok := passthroughEncodings[encoding]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := decompress(encoding, r.Body, maxSize)
+		switch {
+		case errors.Is(err, errUnsupportedEncoding):
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		case errors.Is(err, errBodyTooLarge):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		case err != nil:
+			http.Error(w, fmt.Sprintf("failed to decompress request body: %s", err), http.StatusBadRequest)
+			return
+		}
+		_ = r.Body.Close()
+
+		r.Header.Del("Content-Encoding")
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		r.ContentLength = int64(len(body))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// decompress reads the body compressed with encoding, returning
+// errBodyTooLarge once more than maxSize bytes have been decompressed.
+func decompress(encoding string, body io.Reader, maxSize int64) ([]byte, error) {
+	var r io.Reader
+	switch encoding {
+	case "gzip", "x-gzip":
+		gr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	case "zstd":
+		zr, err := zstd.NewReader(body, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	case "br":
+		r = brotli.NewReader(body)
+	default:
+		return nil, fmt.Errorf("%w %q, expected one of gzip, zstd or br", errUnsupportedEncoding, encoding)
+	}
+
+	// Read one byte more than the limit to detect bodies exceeding it.
+	buf, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(buf)) > maxSize {
+		return nil, fmt.Errorf("%w: the decompressed size exceeds the limit of %d bytes", errBodyTooLarge, maxSize)
+	}
+	return buf, nil
+}
@@ -0,0 +1,94 @@
+package net
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompressHandler(t *testing.T) {
+	const body = "hello, world"
+
+	tt := []struct {
+		name       string
+		encoding   string
+		body       []byte
+		maxSize    int64
+		expectCode int
+		expectBody string
+	}{
+		{name: "uncompressed", encoding: "", body: []byte(body), expectCode: http.StatusOK, expectBody: body},
+		{name: "snappy is passed through", encoding: "snappy", body: []byte(body), expectCode: http.StatusOK, expectBody: body},
+		{name: "gzip", encoding: "gzip", body: gzipBytes(t, body), expectCode: http.StatusOK, expectBody: body},
+		{name: "zstd", encoding: "zstd", body: zstdBytes(t, body), expectCode: http.StatusOK, expectBody: body},
+		{name: "brotli", encoding: "br", body: brotliBytes(t, body), expectCode: http.StatusOK, expectBody: body},
+		{name: "case insensitive", encoding: "GZIP", body: gzipBytes(t, body), expectCode: http.StatusOK, expectBody: body},
+		{name: "exactly at the limit", encoding: "gzip", body: gzipBytes(t, body), maxSize: int64(len(body)), expectCode: http.StatusOK, expectBody: body},
+		{name: "too large", encoding: "gzip", body: gzipBytes(t, body), maxSize: int64(len(body)) - 1, expectCode: http.StatusRequestEntityTooLarge},
+		{name: "unsupported encoding", encoding: "compress", body: []byte(body), expectCode: http.StatusUnsupportedMediaType},
+		{name: "invalid compressed body", encoding: "gzip", body: []byte(body), expectCode: http.StatusBadRequest},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tc.encoding != "snappy" {
+					require.Empty(t, r.Header.Get("Content-Encoding"), "decompressed bodies must not have a Content-Encoding")
+				}
+				b, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				_, _ = w.Write(b)
+			})
+
+			maxSize := tc.maxSize
+			if maxSize == 0 {
+				maxSize = DefaultMaxDecompressedBodySize
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tc.body))
+			if tc.encoding != "" {
+				req.Header.Set("Content-Encoding", tc.encoding)
+			}
+			rec := httptest.NewRecorder()
+			decompressHandler(maxSize, next).ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectCode, rec.Code, rec.Body.String())
+			if tc.expectCode == http.StatusOK {
+				require.Equal(t, tc.expectBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func gzipBytes(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := io.Copy(w, strings.NewReader(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, s string) []byte {
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer enc.Close()
+	return enc.EncodeAll([]byte(s), nil)
+}
+
+func brotliBytes(t *testing.T, s string) []byte {
+	var buf bytes.Buffer
+	w := brotli.NewWriter(&buf)
+	_, err := io.Copy(w, strings.NewReader(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
@@ -2,10 +2,12 @@ package net
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
+	"github.com/grafana/agent/pkg/util/certreload"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/weaveworks/common/logging"
@@ -20,6 +22,8 @@ type TargetServer struct {
 	config           *weaveworks.Config
 	metricsNamespace string
 	server           *weaveworks.Server
+
+	maxDecompressedBodySize int64
 }
 
 // NewTargetServer creates a new TargetServer, applying some defaults to the server configuration.
@@ -29,13 +33,15 @@ func NewTargetServer(logger log.Logger, metricsNamespace string, reg prometheus.
 		return nil, fmt.Errorf("metrics namespace is not prometheus compatible: %s", metricsNamespace)
 	}
 
+	if config == nil {
+		config = DefaultServerConfig()
+	}
+
 	ts := &TargetServer{
 		logger:           logger,
 		metricsNamespace: metricsNamespace,
-	}
 
-	if config == nil {
-		config = DefaultServerConfig()
+		maxDecompressedBodySize: config.HTTP.maxDecompressedBodySize(),
 	}
 
 	// convert from River into the weaveworks config
@@ -62,13 +68,37 @@ func NewTargetServer(logger log.Logger, metricsNamespace string, reg prometheus.
 // MountAndRun mounts the handlers and starting the server.
 func (ts *TargetServer) MountAndRun(mountRoute func(router *mux.Router)) error {
 	level.Info(ts.logger).Log("msg", "starting server")
+
+	// weaveworks loads the client CAs only once, so certificates are loaded
+	// by a reloader instead to pick up rotated files.
+	var reloader *certreload.Reloader
+	if tlsCfg := ts.config.HTTPTLSConfig; tlsCfg.TLSCertPath != "" && tlsCfg.TLSKeyPath != "" {
+		var err error
+		reloader, err = certreload.New(ts.logger, certreload.Files{
+			CertFile:     tlsCfg.TLSCertPath,
+			KeyFile:      tlsCfg.TLSKeyPath,
+			ClientCAFile: tlsCfg.ClientCAs,
+		}, 0)
+		if err != nil {
+			return err
+		}
+	}
+
 	srv, err := weaveworks.New(*ts.config)
 	if err != nil {
 		return err
 	}
+	if reloader != nil {
+		reloader.Apply(srv.HTTPServer.TLSConfig)
+	}
 
 	ts.server = srv
 	mountRoute(ts.server.HTTP)
+	// Decompress request bodies for all handlers, so that every push-style
+	// receiver accepts compressed payloads.
+	ts.server.HTTP.Use(func(next http.Handler) http.Handler {
+		return decompressHandler(ts.maxDecompressedBodySize, next)
+	})
 
 	go func() {
 		err := srv.Run()
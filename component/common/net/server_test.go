@@ -53,3 +53,26 @@ func TestTargetServer_NilConfig(t *testing.T) {
 	require.Equal(t, "[::]:8080", ts.HTTPListenAddr())
 	// not asserting over grpc port since a random should have been assigned
 }
+
+func TestTargetServer_IPv6(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	cfg := DefaultServerConfig()
+	cfg.HTTP.ListenAddress, cfg.HTTP.ListenPort = "::1", 0
+	cfg.GRPC.ListenAddress = "::1"
+	ts, err := NewTargetServer(util.TestLogger(t), "test_namespace", reg, cfg)
+	require.NoError(t, err)
+
+	err = ts.MountAndRun(func(router *mux.Router) {
+		router.Methods("GET").Path("/hello").Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	})
+	require.NoError(t, err)
+	defer ts.StopAndShutdown()
+
+	require.True(t, strings.HasPrefix(ts.HTTPListenAddr(), "[::1]:"))
+	res, err := http.Get(fmt.Sprintf("http://%s/hello", ts.HTTPListenAddr()))
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, 200, res.StatusCode)
+}
@@ -0,0 +1,413 @@
+// Package recorder retains the data seen by a component on disk for a limited
+// time, so that it can be exported after an incident even if it was dropped
+// further down the pipeline.
+package recorder
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// segmentDuration is the time span of the records of a segment file.
+	// Segments are deleted as a whole once they're past the retention.
+	segmentDuration = time.Minute
+
+	// flushInterval is how often the records of the current segment are
+	// flushed to disk.
+	flushInterval = 5 * time.Second
+
+	segmentExt = ".ndjson"
+)
+
+// Arguments configures the retention of a Recorder.
+type Arguments struct {
+	Retention time.Duration    `river:"retention,attr,optional"`
+	MaxSize   units.Base2Bytes `river:"max_size,attr,optional"`
+}
+
+// DefaultArguments holds the default retention of a Recorder.
+var DefaultArguments = Arguments{
+	Retention: 15 * time.Minute,
+	MaxSize:   512 * units.MiB,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.Retention < segmentDuration {
+		return fmt.Errorf("retention must be at least %s", segmentDuration)
+	}
+	if args.MaxSize <= 0 {
+		return fmt.Errorf("max_size must be greater than 0")
+	}
+	return nil
+}
+
+// record is a line of a segment file.
+type record struct {
+	RecordedAt time.Time `json:"recorded_at"`
+	Data       any       `json:"data"`
+}
+
+// segment is a segment file holding the records of segmentDuration, starting
+// at start.
+type segment struct {
+	path  string
+	start time.Time
+	size  int64
+}
+
+// A Recorder retains records in segment files of a directory, deleting the
+// segments which are past the retention or which don't fit in the maximum
+// size.
+type Recorder struct {
+	log log.Logger
+	dir string
+
+	recordsTotal   prometheus.Counter
+	recordFailures prometheus.Counter
+	bytesStored    prometheus.Gauge
+
+	mut      sync.Mutex
+	args     Arguments
+	segments []*segment // Sorted by start; the last one is being written.
+	file     *os.File
+	w        *bufio.Writer
+}
+
+// New creates a Recorder storing its segments in dir, registering its
+// metrics to reg. Segments left in dir by a previous Recorder are kept until
+// they're past the retention.
+func New(l log.Logger, reg prometheus.Registerer, dir string, args Arguments) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("creating recorder directory: %w", err)
+	}
+
+	r := &Recorder{
+		log:  l,
+		dir:  dir,
+		args: args,
+
+		recordsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_recorder_records_total",
+			Help: "Total number of records written by the recorder.",
+		}),
+		recordFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "agent_recorder_record_failures_total",
+			Help: "Total number of records which couldn't be written by the recorder.",
+		}),
+		bytesStored: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_recorder_stored_bytes",
+			Help: "Size of the records retained by the recorder on disk.",
+		}),
+	}
+	for _, c := range []prometheus.Collector{r.recordsTotal, r.recordFailures, r.bytesStored} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.loadSegments(); err != nil {
+		return nil, err
+	}
+	r.mut.Lock()
+	r.enforceRetention(time.Now())
+	r.mut.Unlock()
+	return r, nil
+}
+
+// loadSegments loads the segments left in the directory.
+func (r *Recorder) loadSegments() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return fmt.Errorf("reading recorder directory: %w", err)
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		nanos, err := strconv.ParseInt(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		r.segments = append(r.segments, &segment{
+			path:  filepath.Join(r.dir, name),
+			start: time.Unix(0, nanos),
+			size:  info.Size(),
+		})
+	}
+	sort.Slice(r.segments, func(i, j int) bool { return r.segments[i].start.Before(r.segments[j].start) })
+	return nil
+}
+
+// ApplyConfig updates the retention of the Recorder.
+func (r *Recorder) ApplyConfig(args Arguments) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.args = args
+	r.enforceRetention(time.Now())
+}
+
+// Record retains data, which must be encodable as JSON, as seen at t.
+func (r *Recorder) Record(t time.Time, data any) error {
+	line, err := json.Marshal(record{RecordedAt: t, Data: data})
+	if err != nil {
+		r.recordFailures.Inc()
+		return err
+	}
+	line = append(line, '\n')
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if err := r.rotate(t); err != nil {
+		r.recordFailures.Inc()
+		return err
+	}
+	n, err := r.w.Write(line)
+	r.segments[len(r.segments)-1].size += int64(n)
+	if err != nil {
+		r.recordFailures.Inc()
+		return err
+	}
+	r.recordsTotal.Inc()
+	return nil
+}
+
+// rotate opens a new segment if records seen at t don't belong to the
+// current one.
+func (r *Recorder) rotate(t time.Time) error {
+	if r.file != nil {
+		current := r.segments[len(r.segments)-1]
+		if t.Sub(current.start) < segmentDuration {
+			return nil
+		}
+		if err := r.closeSegment(); err != nil {
+			level.Warn(r.log).Log("msg", "failed to close recorder segment", "path", current.path, "err", err)
+		}
+	}
+
+	path := filepath.Join(r.dir, fmt.Sprintf("%020d%s", t.UnixNano(), segmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("creating recorder segment: %w", err)
+	}
+	r.file, r.w = f, bufio.NewWriter(f)
+	r.segments = append(r.segments, &segment{path: path, start: t})
+	return nil
+}
+
+func (r *Recorder) closeSegment() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.w.Flush()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.file, r.w = nil, nil
+	return err
+}
+
+// enforceRetention deletes the segments past the retention, then the oldest
+// segments until the recorded data fits in the maximum size. The segment
+// being written is never deleted.
+func (r *Recorder) enforceRetention(now time.Time) {
+	var total int64
+	for _, s := range r.segments {
+		total += s.size
+	}
+
+	cutoff := now.Add(-r.args.Retention)
+	for len(r.segments) > 0 {
+		oldest := r.segments[0]
+		if r.file != nil && len(r.segments) == 1 {
+			break
+		}
+		if oldest.start.Add(segmentDuration).After(cutoff) && total <= int64(r.args.MaxSize) {
+			break
+		}
+		if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+			level.Warn(r.log).Log("msg", "failed to delete recorder segment", "path", oldest.path, "err", err)
+			break
+		}
+		total -= oldest.size
+		r.segments = r.segments[1:]
+	}
+	r.bytesStored.Set(float64(total))
+}
+
+// Run flushes the current segment and enforces the retention periodically
+// until ctx is canceled, then closes the current segment.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.mut.Lock()
+			defer r.mut.Unlock()
+			if err := r.closeSegment(); err != nil {
+				level.Warn(r.log).Log("msg", "failed to close recorder segment", "err", err)
+			}
+			return
+		case <-ticker.C:
+			r.mut.Lock()
+			if r.w != nil {
+				if err := r.w.Flush(); err != nil {
+					level.Warn(r.log).Log("msg", "failed to flush recorder segment", "err", err)
+				}
+			}
+			r.enforceRetention(time.Now())
+			r.mut.Unlock()
+		}
+	}
+}
+
+// Export writes the records seen between start and end, inclusive, to w as
+// newline-delimited JSON, in the order they were recorded.
+func (r *Recorder) Export(w io.Writer, start, end time.Time) error {
+	r.mut.Lock()
+	if r.w != nil {
+		if err := r.w.Flush(); err != nil {
+			r.mut.Unlock()
+			return err
+		}
+	}
+	var segments []segment
+	for _, s := range r.segments {
+		if s.start.After(end) || s.start.Add(segmentDuration).Before(start) {
+			continue
+		}
+		segments = append(segments, *s)
+	}
+	r.mut.Unlock()
+
+	for _, s := range segments {
+		if err := exportSegment(w, s, start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportSegment writes the records of s seen between start and end to w. The
+// segment is only read up to the size it had when the export started.
+func exportSegment(w io.Writer, s segment, start, end time.Time) error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		// The segment was deleted since the export started.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(io.LimitReader(f, s.size))
+	sc.Buffer(nil, 64*1024*1024)
+	for sc.Scan() {
+		var rec struct {
+			RecordedAt time.Time `json:"recorded_at"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			// Skip records truncated by a crash.
+			continue
+		}
+		if rec.RecordedAt.Before(start) || rec.RecordedAt.After(end) {
+			continue
+		}
+		if _, err := w.Write(append(sc.Bytes(), '\n')); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Handler returns an HTTP handler serving /export, which exports the records
+// seen in a time window. The window is set by the start and end query
+// parameters as RFC3339 timestamps, or by the since query parameter as a
+// duration before now. It defaults to the whole retention.
+func (r *Recorder) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/export", r.handleExport)
+	return mux
+}
+
+func (r *Recorder) handleExport(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	start, end, err := r.exportWindow(req, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if err := r.Export(w, start, end); err != nil {
+		level.Warn(r.log).Log("msg", "failed to export records", "err", err)
+	}
+}
+
+// exportWindow parses the time window of an export request.
+func (r *Recorder) exportWindow(req *http.Request, now time.Time) (start, end time.Time, err error) {
+	q := req.URL.Query()
+
+	end = now
+	if v := q.Get("end"); v != "" {
+		if end, err = time.Parse(time.RFC3339, v); err != nil {
+			return start, end, fmt.Errorf("invalid end: %w", err)
+		}
+	}
+
+	r.mut.Lock()
+	start = end.Add(-r.args.Retention)
+	r.mut.Unlock()
+	switch {
+	case q.Get("start") != "" && q.Get("since") != "":
+		return start, end, fmt.Errorf("start and since can't both be set")
+	case q.Get("start") != "":
+		if start, err = time.Parse(time.RFC3339, q.Get("start")); err != nil {
+			return start, end, fmt.Errorf("invalid start: %w", err)
+		}
+	case q.Get("since") != "":
+		since, err := time.ParseDuration(q.Get("since"))
+		if err != nil {
+			return start, end, fmt.Errorf("invalid since: %w", err)
+		}
+		start = now.Add(-since)
+	}
+	if start.After(end) {
+		return start, end, fmt.Errorf("start must not be after end")
+	}
+	return start, end, nil
+}
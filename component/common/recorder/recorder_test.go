@@ -0,0 +1,128 @@
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRecorder(t *testing.T, dir string, args Arguments) *Recorder {
+	t.Helper()
+	r, err := New(log.NewNopLogger(), prometheus.NewRegistry(), dir, args)
+	require.NoError(t, err)
+	return r
+}
+
+func exportLines(t *testing.T, r *Recorder, start, end time.Time) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, r.Export(&buf, start, end))
+	return strings.Split(strings.TrimSpace(buf.String()), "\n")
+}
+
+func TestRecorder_Export(t *testing.T) {
+	now := time.Now()
+	r := newTestRecorder(t, t.TempDir(), DefaultArguments)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, r.Record(now.Add(time.Duration(i)*time.Minute), map[string]int{"n": i}))
+	}
+	require.Len(t, r.segments, 5)
+
+	lines := exportLines(t, r, now.Add(time.Minute), now.Add(3*time.Minute))
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], `"data":{"n":1}`)
+	require.Contains(t, lines[2], `"data":{"n":3}`)
+}
+
+func TestRecorder_Retention(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	args := Arguments{Retention: 2 * time.Minute, MaxSize: DefaultArguments.MaxSize}
+	r := newTestRecorder(t, dir, args)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, r.Record(now.Add(time.Duration(i-4)*time.Minute), i))
+	}
+	r.mut.Lock()
+	r.enforceRetention(now)
+	r.mut.Unlock()
+
+	// Segments ending before the retention are deleted.
+	lines := exportLines(t, r, now.Add(-time.Hour), now)
+	require.Len(t, lines, 3)
+	require.Contains(t, lines[0], `"data":2`)
+
+	// Oldest segments are deleted until the records fit in the maximum size,
+	// except for the segment being written.
+	r.ApplyConfig(Arguments{Retention: time.Hour, MaxSize: 1})
+	lines = exportLines(t, r, now.Add(-time.Hour), now)
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], `"data":4`)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestRecorder_Reopen(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+
+	r := newTestRecorder(t, dir, DefaultArguments)
+	require.NoError(t, r.Record(now, "before restart"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Run(ctx)
+
+	// Records of a previous run are kept.
+	r = newTestRecorder(t, dir, DefaultArguments)
+	require.NoError(t, r.Record(now.Add(time.Second), "after restart"))
+	lines := exportLines(t, r, now.Add(-time.Minute), now.Add(time.Minute))
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "before restart")
+	require.Contains(t, lines[1], "after restart")
+}
+
+func TestRecorder_ExportWindow(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	r := newTestRecorder(t, t.TempDir(), DefaultArguments)
+
+	tt := []struct {
+		query      string
+		start, end time.Time
+		expect     string
+	}{
+		{query: "", start: now.Add(-DefaultArguments.Retention), end: now},
+		{query: "since=5m", start: now.Add(-5 * time.Minute), end: now},
+		{
+			query: "start=2023-06-01T11:00:00Z&end=2023-06-01T11:30:00Z",
+			start: now.Add(-time.Hour),
+			end:   now.Add(-30 * time.Minute),
+		},
+		{query: "start=2023-06-01T11:00:00Z&since=5m", expect: "start and since can't both be set"},
+		{query: "start=2023-06-01T13:00:00Z", expect: "start must not be after end"},
+		{query: "since=bad", expect: "invalid since"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.query, func(t *testing.T) {
+			start, end, err := r.exportWindow(httptest.NewRequest("GET", "/export?"+tc.query, nil), now)
+			if tc.expect != "" {
+				require.ErrorContains(t, err, tc.expect)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.start, start)
+			require.Equal(t, tc.end, end)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+// Package tenant maps the values of a label of log entries or series to the
+// tenants they're sent to, using a mapping file which is reloaded when it
+// changes.
+package tenant
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+)
+
+// Policies for values of the source label which aren't in the mapping file.
+const (
+	// UnmatchedReject drops data whose label value isn't mapped to a tenant.
+	UnmatchedReject = "reject"
+	// UnmatchedDefault sends data whose label value isn't mapped to a tenant
+	// to the default tenant.
+	UnmatchedDefault = "default"
+)
+
+// Arguments configures mapping the values of a label to tenants.
+type Arguments struct {
+	SourceLabel    string        `river:"source_label,attr"`
+	File           string        `river:"file,attr"`
+	Unmatched      string        `river:"unmatched,attr,optional"`
+	DefaultTenant  string        `river:"default_tenant,attr,optional"`
+	ReloadInterval time.Duration `river:"reload_interval,attr,optional"`
+}
+
+// DefaultArguments holds the default arguments of a tenant mapping.
+var DefaultArguments = Arguments{
+	Unmatched:      UnmatchedReject,
+	ReloadInterval: time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if !model.LabelName(args.SourceLabel).IsValid() {
+		return fmt.Errorf("invalid source_label %q", args.SourceLabel)
+	}
+	if args.File == "" {
+		return fmt.Errorf("file must not be empty")
+	}
+	switch args.Unmatched {
+	case UnmatchedReject:
+		if args.DefaultTenant != "" {
+			return fmt.Errorf("default_tenant can only be set when unmatched is %q", UnmatchedDefault)
+		}
+	case UnmatchedDefault:
+		if args.DefaultTenant == "" {
+			return fmt.Errorf("default_tenant must be set when unmatched is %q", UnmatchedDefault)
+		}
+	default:
+		return fmt.Errorf("unrecognized unmatched policy %q, must be one of %s or %s", args.Unmatched, UnmatchedReject, UnmatchedDefault)
+	}
+	if args.ReloadInterval <= 0 {
+		return fmt.Errorf("reload_interval must be greater than 0")
+	}
+	return nil
+}
+
+// Mapping is a loaded tenant mapping. A Mapping is never modified once
+// loaded, so it may be used concurrently.
+type Mapping struct {
+	label         model.LabelName
+	tenants       map[string]string // Label value to tenant.
+	defaultTenant string            // Empty if unmatched values are rejected.
+}
+
+// parseMapping parses the content of a mapping file, which maps label values
+// to tenants as a YAML (or JSON) object.
+func parseMapping(args *Arguments, content []byte) (*Mapping, error) {
+	tenants := make(map[string]string)
+	if err := yaml.Unmarshal(content, &tenants); err != nil {
+		return nil, fmt.Errorf("parsing tenant mapping file %s: %w", args.File, err)
+	}
+	for value, tenant := range tenants {
+		if tenant == "" {
+			return nil, fmt.Errorf("tenant mapping file %s maps %q to an empty tenant", args.File, value)
+		}
+	}
+
+	m := &Mapping{
+		label:   model.LabelName(args.SourceLabel),
+		tenants: tenants,
+	}
+	if args.Unmatched == UnmatchedDefault {
+		m.defaultTenant = args.DefaultTenant
+	}
+	return m, nil
+}
+
+// Label returns the name of the label mapped to tenants.
+func (m *Mapping) Label() string { return string(m.label) }
+
+// DefaultTenant returns the tenant of unmatched label values, or an empty
+// string if they're rejected.
+func (m *Mapping) DefaultTenant() string { return m.defaultTenant }
+
+// Tenant returns the tenant of data with the given labels. ok is false if the
+// data must be rejected.
+func (m *Mapping) Tenant(labels model.LabelSet) (tenant string, ok bool) {
+	if tenant, found := m.tenants[string(labels[m.label])]; found {
+		return tenant, true
+	}
+	return m.defaultTenant, m.defaultTenant != ""
+}
+
+// Values returns the sorted label values mapped to each tenant.
+func (m *Mapping) Values() map[string][]string {
+	res := make(map[string][]string)
+	for value, tenant := range m.tenants {
+		res[tenant] = append(res[tenant], value)
+	}
+	for _, values := range res {
+		sort.Strings(values)
+	}
+	return res
+}
+
+// Mapper keeps the tenant mapping of a component up to date with its mapping
+// file.
+type Mapper struct {
+	log log.Logger
+
+	reloadFailures prometheus.Counter
+	mappedValues   prometheus.Gauge
+
+	mut     sync.RWMutex
+	args    *Arguments
+	content []byte
+	mapping *Mapping
+}
+
+// NewMapper creates a new Mapper, registering its metrics to reg.
+func NewMapper(l log.Logger, reg prometheus.Registerer) (*Mapper, error) {
+	m := &Mapper{
+		log: l,
+
+		reloadFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "tenant_mapping_reload_failures_total",
+			Help: "Total number of times the tenant mapping file couldn't be reloaded.",
+		}),
+		mappedValues: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tenant_mapping_values",
+			Help: "Number of label values mapped to tenants by the tenant mapping file.",
+		}),
+	}
+	for _, c := range []prometheus.Collector{m.reloadFailures, m.mappedValues} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ApplyConfig loads the mapping file of args. Mapping is disabled if args is
+// nil. The previous mapping is kept if the file can't be loaded.
+func (m *Mapper) ApplyConfig(args *Arguments) error {
+	if args == nil {
+		m.mut.Lock()
+		m.args, m.content, m.mapping = nil, nil, nil
+		m.mut.Unlock()
+		m.mappedValues.Set(0)
+		return nil
+	}
+
+	content, err := os.ReadFile(args.File)
+	if err != nil {
+		return fmt.Errorf("reading tenant mapping file: %w", err)
+	}
+	mapping, err := parseMapping(args, content)
+	if err != nil {
+		return err
+	}
+
+	m.mut.Lock()
+	m.args, m.content, m.mapping = args, content, mapping
+	m.mut.Unlock()
+	m.mappedValues.Set(float64(len(mapping.tenants)))
+	return nil
+}
+
+// Mapping returns the current mapping, or nil if mapping is disabled.
+func (m *Mapper) Mapping() *Mapping {
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+	return m.mapping
+}
+
+// Run reloads the mapping file every reload interval until ctx is canceled,
+// calling onChange after the mapping changed. onChange may be nil.
+func (m *Mapper) Run(ctx context.Context, onChange func()) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.reloadInterval()):
+			if m.reload() && onChange != nil {
+				onChange()
+			}
+		}
+	}
+}
+
+func (m *Mapper) reloadInterval() time.Duration {
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+	if m.args == nil {
+		return DefaultArguments.ReloadInterval
+	}
+	return m.args.ReloadInterval
+}
+
+// reload reloads the mapping file if it changed, and reports whether the
+// mapping changed.
+func (m *Mapper) reload() bool {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.args == nil {
+		return false
+	}
+
+	content, err := os.ReadFile(m.args.File)
+	if err == nil && bytes.Equal(content, m.content) {
+		return false
+	}
+	var mapping *Mapping
+	if err == nil {
+		mapping, err = parseMapping(m.args, content)
+	}
+	if err != nil {
+		m.reloadFailures.Inc()
+		level.Error(m.log).Log("msg", "failed to reload tenant mapping file, keeping the previous mapping", "file", m.args.File, "err", err)
+		return false
+	}
+
+	level.Info(m.log).Log("msg", "reloaded tenant mapping file", "file", m.args.File, "values", len(mapping.tenants))
+	m.content, m.mapping = content, mapping
+	m.mappedValues.Set(float64(len(mapping.tenants)))
+	return true
+}
@@ -0,0 +1,103 @@
+package tenant
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect string
+	}{
+		{
+			name:   "invalid source label",
+			config: "source_label = \"team-name\"\nfile = \"tenants.yaml\"",
+			expect: `invalid source_label "team-name"`,
+		},
+		{
+			name:   "default without tenant",
+			config: "source_label = \"team\"\nfile = \"tenants.yaml\"\nunmatched = \"default\"",
+			expect: "default_tenant must be set",
+		},
+		{
+			name:   "reject with tenant",
+			config: "source_label = \"team\"\nfile = \"tenants.yaml\"\ndefault_tenant = \"shared\"",
+			expect: "default_tenant can only be set",
+		},
+		{
+			name:   "invalid policy",
+			config: "source_label = \"team\"\nfile = \"tenants.yaml\"\nunmatched = \"drop\"",
+			expect: `unrecognized unmatched policy "drop"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestMapping_Tenant(t *testing.T) {
+	args := &Arguments{SourceLabel: "team", File: "tenants.yaml", Unmatched: UnmatchedReject}
+	m, err := parseMapping(args, []byte("platform: tenant-a\nstorage: tenant-a\nweb: tenant-b\n"))
+	require.NoError(t, err)
+
+	tenant, ok := m.Tenant(model.LabelSet{"team": "web"})
+	require.True(t, ok)
+	require.Equal(t, "tenant-b", tenant)
+
+	_, ok = m.Tenant(model.LabelSet{"team": "unknown"})
+	require.False(t, ok)
+	_, ok = m.Tenant(model.LabelSet{"job": "no-team"})
+	require.False(t, ok)
+
+	require.Equal(t, map[string][]string{
+		"tenant-a": {"platform", "storage"},
+		"tenant-b": {"web"},
+	}, m.Values())
+
+	args.Unmatched, args.DefaultTenant = UnmatchedDefault, "shared"
+	m, err = parseMapping(args, []byte(`{"web": "tenant-b"}`))
+	require.NoError(t, err)
+	tenant, ok = m.Tenant(model.LabelSet{"team": "unknown"})
+	require.True(t, ok)
+	require.Equal(t, "shared", tenant)
+}
+
+func TestMapper_Reload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tenants.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("web: tenant-a\n"), 0644))
+
+	m, err := NewMapper(log.NewNopLogger(), prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, m.ApplyConfig(&Arguments{SourceLabel: "team", File: file, Unmatched: UnmatchedReject}))
+	require.False(t, m.reload(), "unchanged files aren't reloaded")
+
+	require.NoError(t, os.WriteFile(file, []byte("web: tenant-b\n"), 0644))
+	require.True(t, m.reload())
+	tenant, _ := m.Mapping().Tenant(model.LabelSet{"team": "web"})
+	require.Equal(t, "tenant-b", tenant)
+
+	// Invalid files keep the previous mapping.
+	require.NoError(t, os.WriteFile(file, []byte("web: [tenant-c]\n"), 0644))
+	require.False(t, m.reload())
+	tenant, _ = m.Mapping().Tenant(model.LabelSet{"team": "web"})
+	require.Equal(t, "tenant-b", tenant)
+	require.Equal(t, 1.0, testutil.ToFloat64(m.reloadFailures))
+
+	require.NoError(t, m.ApplyConfig(nil))
+	require.Nil(t, m.Mapping())
+}
@@ -0,0 +1,145 @@
+// Package throttle implements cooperative CPU throttling for components with
+// heavy work loops.
+package throttle
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Throttle caps the CPU time spent in a work loop to a share of the host CPU
+// using a token bucket. Tokens are CPU-seconds: they are refilled at the rate
+// allowed by the quota, and each unit of work is charged the time it took.
+//
+// Throttling is cooperative: work is never interrupted, but a unit of work
+// which used more than its budget delays the next units until the budget has
+// been refilled. Work is charged its wall-clock time, so work which runs
+// concurrently on multiple cores is undercounted.
+//
+// A Throttle with a quota of 0 never throttles.
+type Throttle struct {
+	cores float64
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+
+	mut    sync.Mutex
+	rate   float64 // CPU-seconds refilled per second.
+	tokens float64 // Available CPU-seconds, negative when in debt.
+	last   time.Time
+}
+
+// New returns a Throttle allowing work to use quota percent of the host CPU,
+// where 100 is all cores of the host.
+func New(quota float64) (*Throttle, error) {
+	t := &Throttle{
+		cores: float64(runtime.NumCPU()),
+		now:   time.Now,
+		sleep: sleepContext,
+	}
+	if err := t.SetQuota(quota); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ValidateQuota returns an error if quota isn't a valid percentage of the
+// host CPU.
+func ValidateQuota(quota float64) error {
+	if quota < 0 || quota > 100 {
+		return fmt.Errorf("cpu_quota must be between 0 and 100, got %v", quota)
+	}
+	return nil
+}
+
+// SetQuota updates the quota of t. The tokens accumulated so far are kept.
+func (t *Throttle) SetQuota(quota float64) error {
+	if err := ValidateQuota(quota); err != nil {
+		return err
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.refill()
+	t.rate = quota / 100 * t.cores
+	// Allow bursts of up to one second worth of CPU time.
+	if t.tokens > t.rate {
+		t.tokens = t.rate
+	}
+	return nil
+}
+
+// Do runs fn once the budget of t allows it, and charges the time it took.
+// It returns how long fn was delayed. fn isn't run if ctx is canceled while
+// waiting.
+func (t *Throttle) Do(ctx context.Context, fn func()) (time.Duration, error) {
+	waited, err := t.wait(ctx)
+	if err != nil {
+		return waited, err
+	}
+
+	start := t.now()
+	fn()
+	t.charge(t.now().Sub(start))
+	return waited, nil
+}
+
+// wait blocks until t isn't in debt anymore.
+func (t *Throttle) wait(ctx context.Context) (time.Duration, error) {
+	var waited time.Duration
+	for {
+		t.mut.Lock()
+		t.refill()
+		if t.rate == 0 || t.tokens >= 0 {
+			t.mut.Unlock()
+			return waited, nil
+		}
+		delay := time.Duration(-t.tokens / t.rate * float64(time.Second))
+		t.mut.Unlock()
+
+		if err := t.sleep(ctx, delay); err != nil {
+			return waited, err
+		}
+		waited += delay
+	}
+}
+
+// charge removes the CPU time used by a unit of work from the bucket.
+func (t *Throttle) charge(d time.Duration) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if t.rate == 0 {
+		return
+	}
+	t.refill()
+	t.tokens -= d.Seconds()
+}
+
+// refill adds the tokens accumulated since the last refill. t.mut must be
+// held.
+func (t *Throttle) refill() {
+	now := t.now()
+	if !t.last.IsZero() {
+		t.tokens += now.Sub(t.last).Seconds() * t.rate
+		if t.tokens > t.rate {
+			t.tokens = t.rate
+		}
+	}
+	t.last = now
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,92 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock which only moves when work runs or the throttle
+// sleeps.
+type fakeClock struct {
+	now time.Time
+}
+
+func newTestThrottle(t *testing.T, cores, quota float64) (*Throttle, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	th := &Throttle{
+		cores: cores,
+		now:   func() time.Time { return clock.now },
+		sleep: func(ctx context.Context, d time.Duration) error {
+			clock.now = clock.now.Add(d)
+			return ctx.Err()
+		},
+	}
+	require.NoError(t, th.SetQuota(quota))
+	return th, clock
+}
+
+func TestThrottle(t *testing.T) {
+	// 25% of 2 cores allows half a CPU-second per second.
+	th, clock := newTestThrottle(t, 2, 25)
+
+	work := func(d time.Duration) func() {
+		return func() { clock.now = clock.now.Add(d) }
+	}
+
+	// The first unit of work isn't delayed, but uses more than the budget.
+	waited, err := th.Do(context.Background(), work(time.Second))
+	require.NoError(t, err)
+	require.Zero(t, waited)
+
+	// The budget is refilled while work runs, so the second unit waits for
+	// the remaining half CPU-second to be repaid.
+	waited, err = th.Do(context.Background(), work(100*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, time.Second, waited)
+
+	// Idle time accumulates at most one second worth of CPU time.
+	clock.now = clock.now.Add(time.Hour)
+	waited, err = th.Do(context.Background(), work(2*time.Second))
+	require.NoError(t, err)
+	require.Zero(t, waited)
+	waited, err = th.Do(context.Background(), work(time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, 3*time.Second, waited)
+}
+
+func TestThrottle_Disabled(t *testing.T) {
+	th, clock := newTestThrottle(t, 2, 0)
+
+	for i := 0; i < 10; i++ {
+		waited, err := th.Do(context.Background(), func() { clock.now = clock.now.Add(time.Minute) })
+		require.NoError(t, err)
+		require.Zero(t, waited)
+	}
+}
+
+func TestThrottle_Canceled(t *testing.T) {
+	th, clock := newTestThrottle(t, 1, 10)
+
+	_, err := th.Do(context.Background(), func() { clock.now = clock.now.Add(time.Second) })
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var ran bool
+	_, err = th.Do(ctx, func() { ran = true })
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, ran)
+}
+
+func TestValidateQuota(t *testing.T) {
+	require.NoError(t, ValidateQuota(0))
+	require.NoError(t, ValidateQuota(100))
+	require.EqualError(t, ValidateQuota(150), "cpu_quota must be between 0 and 100, got 150")
+	require.Error(t, ValidateQuota(-1))
+}
@@ -0,0 +1,283 @@
+// Package usage accounts for the telemetry data forwarded by write
+// components, so that its cost can be attributed to the pipelines generating
+// it.
+//
+// Write components account for the data they forward by destination, such
+// as an endpoint or a tenant, and by source component. Prometheus samples
+// carry their source in the context passed to storage.Appendable.Appender,
+// which is set by the first component of a pipeline appending them. Loki
+// entries are attributed to the source component which stamped them for
+// measuring their delivery latency, if any.
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component/common/delivery"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+)
+
+type contextKey struct{}
+
+// ContextWithSource returns a copy of ctx holding the ID of the component
+// which is the source of the data appended with it. The source already held
+// by ctx is kept, if any, so that data is attributed to the first component
+// of a pipeline.
+func ContextWithSource(ctx context.Context, source string) context.Context {
+	if _, ok := ctx.Value(contextKey{}).(string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, source)
+}
+
+// SourceFromContext returns the source held by ctx, or an empty string if
+// ctx doesn't hold one.
+func SourceFromContext(ctx context.Context) string {
+	source, _ := ctx.Value(contextKey{}).(string)
+	return source
+}
+
+// Logs counts the log lines and bytes forwarded by a write component, by
+// tenant and source component.
+type Logs struct {
+	lines *prometheus.CounterVec
+	bytes *prometheus.CounterVec
+}
+
+// NewLogs creates Logs registering its metrics to reg.
+func NewLogs(reg prometheus.Registerer) (*Logs, error) {
+	l := &Logs{
+		lines: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_usage_log_lines_total",
+			Help: "Total number of log lines forwarded, by tenant and source component.",
+		}, []string{"tenant", "source"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_usage_log_bytes_total",
+			Help: "Total number of bytes of log lines forwarded, by tenant and source component.",
+		}, []string{"tenant", "source"}),
+	}
+	for _, c := range []prometheus.Collector{l.lines, l.bytes} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Observe accounts for entry being forwarded to tenant. stamp is the
+// delivery stamp of the entry, if it held one.
+func (l *Logs) Observe(tenant string, stamp delivery.Stamp, entry logproto.Entry) {
+	l.lines.WithLabelValues(tenant, stamp.Source).Inc()
+	l.bytes.WithLabelValues(tenant, stamp.Source).Add(float64(len(entry.Line)))
+}
+
+// Route is a destination of the series forwarded by a write component, such
+// as the queue of an endpoint.
+type Route struct {
+	Endpoint string
+	Tenant   string
+
+	// Match reports whether a series is forwarded through the route. All
+	// series are forwarded through routes without Match.
+	Match func(labels.Labels) bool
+}
+
+// numShards is the number of shards of the series tracked by ActiveSeries,
+// reducing lock contention between concurrent appenders.
+const numShards = 16
+
+// ActiveSeries counts the active series forwarded through each route of a
+// write component, by source component. A series is active if a sample of it
+// was appended within the window of the ActiveSeries.
+//
+// Tracking active series costs memory for each of them, so ActiveSeries is
+// disabled until a window is set.
+type ActiveSeries struct {
+	gauge *prometheus.GaugeVec
+
+	mut    sync.RWMutex
+	window time.Duration
+	routes []Route
+	// gen changes with the routes, invalidating the routes of tracked series.
+	gen uint64
+
+	shards [numShards]seriesShard
+}
+
+type seriesShard struct {
+	mut    sync.Mutex
+	series map[uint64]trackedSeries
+}
+
+type trackedSeries struct {
+	lastSeen int64
+	source   string
+	gen      uint64
+	// routes are the indices of the routes forwarding the series.
+	routes []uint16
+}
+
+// RefreshInterval is how often ActiveSeries should be refreshed.
+const RefreshInterval = 30 * time.Second
+
+// NewActiveSeries creates an ActiveSeries registering its metrics to reg.
+func NewActiveSeries(reg prometheus.Registerer) (*ActiveSeries, error) {
+	a := &ActiveSeries{
+		gauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_usage_active_series",
+			Help: "Number of active series forwarded, by endpoint, tenant, and source component.",
+		}, []string{"endpoint", "tenant", "source"}),
+	}
+	for i := range a.shards {
+		a.shards[i].series = make(map[uint64]trackedSeries)
+	}
+	if err := reg.Register(a.gauge); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ApplyConfig sets the window of active series and the routes series are
+// forwarded through. The ActiveSeries is disabled if window is 0.
+func (a *ActiveSeries) ApplyConfig(window time.Duration, routes []Route) {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	a.window = window
+	a.routes = routes
+	a.gen++
+}
+
+// Observe accounts for a sample of the series l appended by source at now.
+func (a *ActiveSeries) Observe(source string, l labels.Labels, now time.Time) {
+	a.mut.RLock()
+	defer a.mut.RUnlock()
+	if a.window == 0 {
+		return
+	}
+
+	h := l.Hash()
+	shard := &a.shards[h%numShards]
+	shard.mut.Lock()
+	defer shard.mut.Unlock()
+
+	s, ok := shard.series[h]
+	if !ok || s.gen != a.gen {
+		// The routes of series are only matched when they're first seen or
+		// the routes changed.
+		s.routes = s.routes[:0]
+		for i, r := range a.routes {
+			if r.Match == nil || r.Match(l) {
+				s.routes = append(s.routes, uint16(i))
+			}
+		}
+		s.gen = a.gen
+	}
+	s.lastSeen = now.UnixNano()
+	s.source = source
+	shard.series[h] = s
+}
+
+type seriesKey struct {
+	route  uint16
+	source string
+}
+
+// Refresh forgets the series which are no longer active at now, and updates
+// the metrics of the active series.
+func (a *ActiveSeries) Refresh(now time.Time) {
+	a.mut.RLock()
+	defer a.mut.RUnlock()
+
+	cutoff := now.Add(-a.window).UnixNano()
+	counts := make(map[seriesKey]int)
+	for i := range a.shards {
+		shard := &a.shards[i]
+		shard.mut.Lock()
+		for h, s := range shard.series {
+			if a.window == 0 || s.lastSeen < cutoff {
+				delete(shard.series, h)
+				continue
+			}
+			// Series tracked before the routes changed are counted once
+			// they're seen again.
+			if s.gen != a.gen {
+				continue
+			}
+			for _, r := range s.routes {
+				counts[seriesKey{route: r, source: s.source}]++
+			}
+		}
+		shard.mut.Unlock()
+	}
+
+	a.gauge.Reset()
+	for key, n := range counts {
+		// Routes may share their endpoint and tenant, such as endpoints
+		// without names sharing their URL.
+		r := a.routes[key.route]
+		a.gauge.WithLabelValues(r.Endpoint, r.Tenant, key.source).Add(float64(n))
+	}
+}
+
+// Run refreshes a until ctx is canceled.
+func (a *ActiveSeries) Run(ctx context.Context) {
+	ticker := time.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			a.Refresh(now)
+		}
+	}
+}
+
+// TrackAppendable returns a storage.Appendable which accounts for the series
+// successfully appended to next in a.
+func TrackAppendable(next storage.Appendable, a *ActiveSeries) storage.Appendable {
+	return trackingAppendable{next: next, active: a}
+}
+
+type trackingAppendable struct {
+	next   storage.Appendable
+	active *ActiveSeries
+}
+
+func (t trackingAppendable) Appender(ctx context.Context) storage.Appender {
+	return &trackingAppender{
+		Appender: t.next.Appender(ctx),
+		active:   t.active,
+		source:   SourceFromContext(ctx),
+	}
+}
+
+type trackingAppender struct {
+	storage.Appender
+	active *ActiveSeries
+	source string
+}
+
+func (a *trackingAppender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	ref, err := a.Appender.Append(ref, l, t, v)
+	if err == nil {
+		a.active.Observe(a.source, l, time.Now())
+	}
+	return ref, err
+}
+
+func (a *trackingAppender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	ref, err := a.Appender.AppendHistogram(ref, l, t, h, fh)
+	if err == nil {
+		a.active.Observe(a.source, l, time.Now())
+	}
+	return ref, err
+}
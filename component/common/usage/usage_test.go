@@ -0,0 +1,126 @@
+package usage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/delivery"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextWithSource(t *testing.T) {
+	ctx := context.Background()
+	require.Equal(t, "", SourceFromContext(ctx))
+
+	ctx = ContextWithSource(ctx, "prometheus.scrape.app")
+	ctx = ContextWithSource(ctx, "prometheus.relabel.app")
+	require.Equal(t, "prometheus.scrape.app", SourceFromContext(ctx), "the first source must be kept")
+}
+
+func TestLogs(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	l, err := NewLogs(reg)
+	require.NoError(t, err)
+
+	stamp := delivery.Stamp{Source: "loki.source.file.logs", Time: time.Now()}
+	l.Observe("team-a", stamp, logproto.Entry{Line: "hello"})
+	l.Observe("team-a", stamp, logproto.Entry{Line: "world!"})
+	l.Observe("", delivery.Stamp{}, logproto.Entry{Line: "unstamped"})
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_usage_log_bytes_total Total number of bytes of log lines forwarded, by tenant and source component.
+		# TYPE agent_usage_log_bytes_total counter
+		agent_usage_log_bytes_total{source="",tenant=""} 9
+		agent_usage_log_bytes_total{source="loki.source.file.logs",tenant="team-a"} 11
+		# HELP agent_usage_log_lines_total Total number of log lines forwarded, by tenant and source component.
+		# TYPE agent_usage_log_lines_total counter
+		agent_usage_log_lines_total{source="",tenant=""} 1
+		agent_usage_log_lines_total{source="loki.source.file.logs",tenant="team-a"} 2
+	`)))
+}
+
+func TestActiveSeries(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	a, err := NewActiveSeries(reg)
+	require.NoError(t, err)
+
+	now := time.Now()
+	// Series aren't tracked until a window is set.
+	a.Observe("prometheus.scrape.app", labels.FromStrings("__name__", "up"), now)
+	a.Refresh(now)
+	require.Equal(t, 0, testutil.CollectAndCount(reg))
+
+	a.ApplyConfig(time.Minute, []Route{
+		{Endpoint: "mimir", Tenant: "a", Match: func(l labels.Labels) bool { return l.Get("team") == "a" }},
+		{Endpoint: "backup"},
+	})
+	a.Observe("prometheus.scrape.app", labels.FromStrings("__name__", "up", "team", "a"), now)
+	a.Observe("prometheus.scrape.app", labels.FromStrings("__name__", "up", "team", "b"), now)
+	a.Observe("prometheus.scrape.other", labels.FromStrings("__name__", "other", "team", "a"), now.Add(-30*time.Second))
+	// Samples of the same series are counted once.
+	a.Observe("prometheus.scrape.app", labels.FromStrings("__name__", "up", "team", "a"), now)
+
+	a.Refresh(now)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_usage_active_series Number of active series forwarded, by endpoint, tenant, and source component.
+		# TYPE agent_usage_active_series gauge
+		agent_usage_active_series{endpoint="backup",source="prometheus.scrape.app",tenant=""} 2
+		agent_usage_active_series{endpoint="backup",source="prometheus.scrape.other",tenant=""} 1
+		agent_usage_active_series{endpoint="mimir",source="prometheus.scrape.app",tenant="a"} 1
+		agent_usage_active_series{endpoint="mimir",source="prometheus.scrape.other",tenant="a"} 1
+	`)))
+
+	// Series are forgotten once they stopped being active.
+	a.Refresh(now.Add(45 * time.Second))
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_usage_active_series Number of active series forwarded, by endpoint, tenant, and source component.
+		# TYPE agent_usage_active_series gauge
+		agent_usage_active_series{endpoint="backup",source="prometheus.scrape.app",tenant=""} 2
+		agent_usage_active_series{endpoint="mimir",source="prometheus.scrape.app",tenant="a"} 1
+	`)))
+
+	// Series are matched again once the routes changed.
+	a.ApplyConfig(time.Minute, []Route{{Endpoint: "mimir", Tenant: "b", Match: func(l labels.Labels) bool { return l.Get("team") == "b" }}})
+	a.Observe("prometheus.scrape.app", labels.FromStrings("__name__", "up", "team", "a"), now)
+	a.Observe("prometheus.scrape.app", labels.FromStrings("__name__", "up", "team", "b"), now)
+	a.Refresh(now)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_usage_active_series Number of active series forwarded, by endpoint, tenant, and source component.
+		# TYPE agent_usage_active_series gauge
+		agent_usage_active_series{endpoint="mimir",source="prometheus.scrape.app",tenant="b"} 1
+	`)))
+}
+
+func TestTrackAppendable(t *testing.T) {
+	a, err := NewActiveSeries(prometheus.NewRegistry())
+	require.NoError(t, err)
+	a.ApplyConfig(time.Minute, []Route{{Endpoint: "mimir"}})
+
+	appendable := TrackAppendable(nopAppendable{}, a)
+	app := appendable.Appender(ContextWithSource(context.Background(), "prometheus.scrape.app"))
+	_, err = app.Append(0, labels.FromStrings("__name__", "up"), 0, 1)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	a.Refresh(time.Now())
+	require.Equal(t, 1.0, testutil.ToFloat64(a.gauge.WithLabelValues("mimir", "", "prometheus.scrape.app")))
+}
+
+type nopAppendable struct{}
+
+func (nopAppendable) Appender(context.Context) storage.Appender { return nopAppender{} }
+
+type nopAppender struct{ storage.Appender }
+
+func (nopAppender) Append(storage.SeriesRef, labels.Labels, int64, float64) (storage.SeriesRef, error) {
+	return 0, nil
+}
+
+func (nopAppender) Commit() error { return nil }
@@ -61,6 +61,8 @@ package component
 import (
 	"context"
 	"net/http"
+
+	"github.com/grafana/agent/component/common/livedebugging"
 )
 
 // The Arguments contains the input fields for a specific component, which is
@@ -126,6 +128,16 @@ type HTTPComponent interface {
 	Handler() http.Handler
 }
 
+// LiveDebuggingComponent is an extension interface for components which can
+// stream samples of the data flowing through them to clients debugging them.
+type LiveDebuggingComponent interface {
+	Component
+
+	// LiveDebugging returns the hub the component publishes its data to. The
+	// same hub must be returned for the lifetime of the component.
+	LiveDebugging() *livedebugging.Hub
+}
+
 // ClusteredComponent is an extension interface for components which implement
 // clustering-specific behavior.
 type ClusteredComponent interface {
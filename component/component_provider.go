@@ -61,8 +61,9 @@ type Info struct {
 	// this component depends on, or is depended on by, respectively.
 	References, ReferencedBy []string
 
-	Registration Registration // Component registration.
-	Health       Health       // Current component health.
+	Registration Registration       // Component registration.
+	Health       Health             // Current component health.
+	Migrations   []AppliedMigration // Migrations of deprecated arguments applied to the component.
 
 	Arguments Arguments   // Current arguments value of the component.
 	Exports   Exports     // Current exports value of the component.
@@ -79,6 +80,13 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			UpdatedTime time.Time `json:"updatedTime"`
 		}
 
+		migrationJSON struct {
+			Deprecated  string `json:"deprecated"`
+			Replacement string `json:"replacement"`
+			Since       string `json:"since"`
+			Position    string `json:"position"`
+		}
+
 		componentDetailJSON struct {
 			Name         string               `json:"name,omitempty"`
 			Type         string               `json:"type,omitempty"`
@@ -87,10 +95,13 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			References   []string             `json:"referencesTo"`
 			ReferencedBy []string             `json:"referencedBy"`
 			Health       *componentHealthJSON `json:"health"`
+			Migrations   []migrationJSON      `json:"migrations,omitempty"`
 			Original     string               `json:"original"`
 			Arguments    json.RawMessage      `json:"arguments,omitempty"`
 			Exports      json.RawMessage      `json:"exports,omitempty"`
 			DebugInfo    json.RawMessage      `json:"debugInfo,omitempty"`
+
+			LiveDebugging bool `json:"liveDebugging,omitempty"`
 		}
 	)
 
@@ -109,6 +120,16 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		referencedBy = []string{}
 	}
 
+	migrations := make([]migrationJSON, 0, len(info.Migrations))
+	for _, m := range info.Migrations {
+		migrations = append(migrations, migrationJSON{
+			Deprecated:  m.Deprecated,
+			Replacement: m.ReplacementPath(),
+			Since:       m.Since,
+			Position:    m.Position,
+		})
+	}
+
 	arguments, err = riverjson.MarshalBody(info.Arguments)
 	if err != nil {
 		return nil, err
@@ -122,6 +143,8 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		return nil, err
 	}
 
+	_, liveDebugging := info.Component.(LiveDebuggingComponent)
+
 	return json.Marshal(&componentDetailJSON{
 		Name:         info.Registration.Name,
 		Type:         "block",
@@ -134,8 +157,11 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 			Message:     info.Health.Message,
 			UpdatedTime: info.Health.UpdateTime,
 		},
-		Arguments: arguments,
-		Exports:   exports,
-		DebugInfo: debugInfo,
+		Migrations: migrations,
+		Arguments:  arguments,
+		Exports:    exports,
+		DebugInfo:  debugInfo,
+
+		LiveDebugging: liveDebugging,
 	})
 }
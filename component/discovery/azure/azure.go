@@ -35,10 +35,13 @@ type Arguments struct {
 	RefreshInterval time.Duration    `river:"refresh_interval,attr,optional"`
 	ResourceGroup   string           `river:"resource_group,attr,optional"`
 
-	ProxyURL        config.URL       `river:"proxy_url,attr,optional"`
-	FollowRedirects bool             `river:"follow_redirects,attr,optional"`
-	EnableHTTP2     bool             `river:"enable_http2,attr,optional"`
-	TLSConfig       config.TLSConfig `river:"tls_config,block,optional"`
+	ProxyURL             config.URL       `river:"proxy_url,attr,optional"`
+	NoProxy              string           `river:"no_proxy,attr,optional"`
+	ProxyFromEnvironment bool             `river:"proxy_from_environment,attr,optional"`
+	ProxyConnectHeader   config.Header    `river:"proxy_connect_header,attr,optional"`
+	FollowRedirects      bool             `river:"follow_redirects,attr,optional"`
+	EnableHTTP2          bool             `river:"enable_http2,attr,optional"`
+	TLSConfig            config.TLSConfig `river:"tls_config,block,optional"`
 }
 
 type OAuth struct {
@@ -67,6 +70,15 @@ func (a *Arguments) Validate() error {
 	if a.OAuth == nil && a.ManagedIdentity == nil || a.OAuth != nil && a.ManagedIdentity != nil {
 		return fmt.Errorf("exactly one of oauth or managed_identity must be specified")
 	}
+	proxy := config.ProxyConfig{
+		ProxyURL:             a.ProxyURL,
+		NoProxy:              a.NoProxy,
+		ProxyFromEnvironment: a.ProxyFromEnvironment,
+		ProxyConnectHeader:   a.ProxyConnectHeader,
+	}
+	if err := proxy.Validate(); err != nil {
+		return err
+	}
 	return a.TLSConfig.Validate()
 }
 
@@ -89,6 +101,9 @@ func (a *Arguments) Convert() *prom_discovery.SDConfig {
 
 	httpClientConfig := config.DefaultHTTPClientConfig
 	httpClientConfig.ProxyURL = a.ProxyURL
+	httpClientConfig.NoProxy = a.NoProxy
+	httpClientConfig.ProxyFromEnvironment = a.ProxyFromEnvironment
+	httpClientConfig.ProxyConnectHeader = a.ProxyConnectHeader
 	httpClientConfig.FollowRedirects = a.FollowRedirects
 	httpClientConfig.EnableHTTP2 = a.EnableHTTP2
 	httpClientConfig.TLSConfig = a.TLSConfig
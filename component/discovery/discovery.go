@@ -2,6 +2,7 @@ package discovery
 
 import (
 	"context"
+	"math"
 	"sort"
 	"strings"
 	"sync"
@@ -9,7 +10,10 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/events"
+	"github.com/grafana/ckit/peer"
 	"github.com/grafana/ckit/shard"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/discovery"
 	"github.com/prometheus/prometheus/discovery/targetgroup"
@@ -20,6 +24,11 @@ import (
 // component.
 type Target map[string]string
 
+// PinLabel is the label used to assign a target to a specific cluster peer.
+// Targets with this label set to the name of a peer are always owned by that
+// peer instead of being distributed by hashing.
+const PinLabel = "__cluster_pin__"
+
 // DistributedTargets uses the node's Lookup method to distribute discovery
 // targets when a Flow component runs in a cluster.
 type DistributedTargets struct {
@@ -34,35 +43,186 @@ func NewDistributedTargets(e bool, n cluster.Node, t []Target) DistributedTarget
 	return DistributedTargets{e, n, t}
 }
 
+// loadFactor bounds how many targets a peer can own relative to an even
+// distribution. Peers own at most ceil(loadFactor * targets / peers) targets.
+const loadFactor = 1.25
+
 // Get distributes discovery targets a clustered environment.
 //
-// If a cluster size is 1, then all targets will be returned.
+// If a cluster size is 1, then all targets will be returned. Targets pinned
+// to a peer with PinLabel are only returned by that peer; targets pinned to a
+// peer which isn't participating in the cluster are distributed as usual.
+//
+// Targets are distributed with bounded-load consistent hashing: each target is
+// owned by the first peer on the hash ring which doesn't own more than its
+// share of targets. Peers joining or leaving the cluster only move the targets
+// they take or give up, along with the few targets which overflow to a
+// different peer as the bound changes.
 func (t *DistributedTargets) Get() []Target {
 	// TODO(@tpaschalis): Make this into a single code-path to simplify logic.
 	if !t.useClustering || t.node == nil {
 		return t.targets
 	}
 
-	res := make([]Target, 0, (len(t.targets)+1)/len(t.node.Peers()))
+	peers := t.node.Peers()
 
-	// TODO(@tpaschalis): Make sure OpReadWrite is the correct operation;
-	// eg. this determines how clustering behaves when nodes are shutting down.
-	for _, tgt := range t.targets {
-		peers, err := t.node.Lookup(shard.StringKey(tgt.NonMetaLabels().String()), 1, shard.OpReadWrite)
-		if err != nil {
+	// Only participants can own targets, so pins to peers in other states are
+	// ignored.
+	var self string
+	names := make(map[string]struct{}, len(peers))
+	for _, p := range peers {
+		if p.State != peer.StateParticipant {
+			continue
+		}
+		names[p.Name] = struct{}{}
+		if p.Self {
+			self = p.Name
+		}
+	}
+
+	var (
+		owned  = make([]bool, len(t.targets))
+		loads  = make(map[string]int, len(names))
+		hashed = make([]hashedTarget, 0, len(t.targets))
+	)
+	for i, tgt := range t.targets {
+		if pin, ok := tgt[PinLabel]; ok {
+			if _, known := names[pin]; known {
+				loads[pin]++
+				owned[i] = pin == self
+				continue
+			}
+		}
+		hashed = append(hashed, hashedTarget{
+			index: i,
+			key:   shard.StringKey(tgt.NonMetaLabels().String()),
+		})
+	}
+
+	// Every peer must assign targets in the same order to agree on which
+	// peers are full.
+	sort.SliceStable(hashed, func(i, j int) bool { return hashed[i].key < hashed[j].key })
+
+	capacity := boundedCapacity(len(t.targets), len(names))
+	for _, h := range hashed {
+		owner, ok := t.owner(h.key, loads, capacity, len(names))
+		if !ok {
 			// This can only fail in case we ask for more owners than the
 			// available peers. This will never happen, but in any case we fall
 			// back to owning the target ourselves.
-			res = append(res, tgt)
+			owned[h.index] = true
+			continue
 		}
-		if peers[0].Self {
+		loads[owner.Name]++
+		owned[h.index] = owner.Self
+	}
+
+	res := make([]Target, 0, (len(t.targets)+1)/len(peers))
+	for i, tgt := range t.targets {
+		if owned[i] {
 			res = append(res, tgt)
 		}
 	}
-
 	return res
 }
 
+// hashedTarget is a target distributed by hashing.
+type hashedTarget struct {
+	index int // Index of the target in DistributedTargets.targets.
+	key   shard.Key
+}
+
+// owner returns the peer owning key: the first peer on the hash ring owning
+// fewer than capacity targets. If every peer is full, the first peer is
+// returned. numPeers is the number of peers which can own targets.
+func (t *DistributedTargets) owner(key shard.Key, loads map[string]int, capacity int, numPeers int) (peer.Peer, bool) {
+	// TODO(@tpaschalis): Make sure OpReadWrite is the correct operation;
+	// eg. this determines how clustering behaves when nodes are shutting down.
+	owners, err := t.node.Lookup(key, 1, shard.OpReadWrite)
+	if err != nil || len(owners) == 0 {
+		return peer.Peer{}, false
+	}
+	if loads[owners[0].Name] < capacity || numPeers <= 1 {
+		return owners[0], true
+	}
+
+	// The preferred owner is full; walk the ring for the next peer which
+	// isn't. The set of peers may have changed since numPeers was computed,
+	// in which case the preferred owner is kept.
+	candidates, err := t.node.Lookup(key, numPeers, shard.OpReadWrite)
+	if err != nil {
+		return owners[0], true
+	}
+	for _, c := range candidates {
+		if loads[c.Name] < capacity {
+			return c, true
+		}
+	}
+	return owners[0], true
+}
+
+// boundedCapacity returns how many of numTargets targets each of numPeers
+// peers can own.
+func boundedCapacity(numTargets, numPeers int) int {
+	if numPeers <= 1 {
+		return numTargets
+	}
+	return int(math.Ceil(loadFactor * float64(numTargets) / float64(numPeers)))
+}
+
+// DistributionTracker counts the targets which move to or from the local peer
+// between distributions, such as when peers join or leave the cluster.
+// Targets which are added or removed between distributions aren't counted.
+type DistributionTracker struct {
+	moved *prometheus.CounterVec
+
+	mut   sync.Mutex
+	owned map[string]bool // Whether each target of the last distribution was owned, by its labels.
+}
+
+// NewDistributionTracker creates a DistributionTracker which registers its
+// metrics to reg.
+func NewDistributionTracker(reg prometheus.Registerer) (*DistributionTracker, error) {
+	moved := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "agent_cluster_targets_moved_total",
+		Help: "Targets which moved to (direction=\"in\") or away from (direction=\"out\") this node between distributions.",
+	}, []string{"direction"})
+	if err := reg.Register(moved); err != nil {
+		return nil, err
+	}
+	return &DistributionTracker{moved: moved}, nil
+}
+
+// Observe records that owned are the targets out of all owned by the local
+// peer.
+func (dt *DistributionTracker) Observe(all []Target, owned []Target) {
+	next := make(map[string]bool, len(all))
+	for _, tgt := range all {
+		next[tgt.Labels().String()] = false
+	}
+	for _, tgt := range owned {
+		next[tgt.Labels().String()] = true
+	}
+
+	dt.mut.Lock()
+	defer dt.mut.Unlock()
+
+	var in, out int
+	for key, isOwned := range next {
+		wasOwned, ok := dt.owned[key]
+		switch {
+		case !ok || wasOwned == isOwned:
+		case isOwned:
+			in++
+		default:
+			out++
+		}
+	}
+	dt.moved.WithLabelValues("in").Add(float64(in))
+	dt.moved.WithLabelValues("out").Add(float64(out))
+	dt.owned = next
+}
+
 // Labels converts Target into a set of sorted labels.
 func (t Target) Labels() labels.Labels {
 	var lset labels.Labels
@@ -105,6 +265,9 @@ type Component struct {
 	latestDisc    discovery.Discoverer
 	newDiscoverer chan struct{}
 
+	targetsMut sync.Mutex
+	targets    map[string]Target // Last exported targets, by their labels.
+
 	creator Creator
 }
 
@@ -196,6 +359,7 @@ func (c *Component) runDiscovery(ctx context.Context, d Discoverer) {
 			}
 		}
 		c.opts.OnStateChange(Exports{Targets: allTargets})
+		c.publishTargetChanges(allTargets)
 	}
 
 	ticker := time.NewTicker(maxUpdateFrequency)
@@ -225,3 +389,35 @@ func (c *Component) runDiscovery(ctx context.Context, d Discoverer) {
 		}
 	}
 }
+
+// publishTargetChanges publishes an event for each target which appeared or
+// disappeared since the last exported targets.
+func (c *Component) publishTargetChanges(targets []Target) {
+	c.targetsMut.Lock()
+	defer c.targetsMut.Unlock()
+
+	cur := make(map[string]Target, len(targets))
+	for _, t := range targets {
+		key := t.Labels().String()
+		cur[key] = t
+		if _, ok := c.targets[key]; !ok {
+			c.opts.Events.Publish(events.TypeTargetAppeared, "", copyTarget(t))
+		}
+	}
+	for key, t := range c.targets {
+		if _, ok := cur[key]; !ok {
+			c.opts.Events.Publish(events.TypeTargetDisappeared, "", copyTarget(t))
+		}
+	}
+	c.targets = cur
+}
+
+// copyTarget returns a copy of the labels of t, which is shared with the
+// exports of the component.
+func copyTarget(t Target) map[string]string {
+	res := make(map[string]string, len(t))
+	for k, v := range t {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,148 @@
+package discovery
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/grafana/ckit"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistributedTargets_Pinning(t *testing.T) {
+	targets := []Target{
+		{"__address__": "a:80", PinLabel: "agent-1"},
+		{"__address__": "b:80", PinLabel: "agent-2"},
+		{"__address__": "c:80", PinLabel: "agent-3"},
+		{"__address__": "d:80", PinLabel: "missing"},
+		{"__address__": "e:80"},
+	}
+
+	// agent-2 owns every target by hashing and agent-3 is shutting down.
+	peers := []peer.Peer{
+		{Name: "agent-1", Self: true, State: peer.StateParticipant},
+		{Name: "agent-2", State: peer.StateParticipant},
+		{Name: "agent-3", State: peer.StateTerminating},
+	}
+	node := &fakeNode{peers: peers, owner: peers[1]}
+
+	dt := NewDistributedTargets(true, node, targets)
+	require.Equal(t, []Target{targets[0]}, dt.Get())
+
+	// Targets pinned to agent-3 or to an unknown peer fall back to hashing.
+	node.owner = peers[0]
+	require.Equal(t, []Target{targets[0], targets[2], targets[3], targets[4]}, dt.Get())
+}
+
+func TestDistributedTargets_BoundedLoad(t *testing.T) {
+	targets := make([]Target, 1000)
+	for i := range targets {
+		targets[i] = Target{"__address__": fmt.Sprintf("target-%d:80", i)}
+	}
+
+	// distribute returns the owner of each target when the cluster is made of
+	// the given peers.
+	distribute := func(names ...string) map[string]string {
+		peers := make([]peer.Peer, len(names))
+		for i, name := range names {
+			peers[i] = peer.Peer{Name: name, State: peer.StateParticipant}
+		}
+		sharder := shard.Ring(512)
+		sharder.SetPeers(peers)
+
+		owners := make(map[string]string, len(targets))
+		for i := range peers {
+			self := make([]peer.Peer, len(peers))
+			copy(self, peers)
+			self[i].Self = true
+			node := &ringNode{peers: self, sharder: sharder}
+
+			dt := NewDistributedTargets(true, node, targets)
+			owned := dt.Get()
+			require.LessOrEqual(t, len(owned), boundedCapacity(len(targets), len(peers)))
+			for _, tgt := range owned {
+				addr := tgt["__address__"]
+				require.NotContains(t, owners, addr, "target owned by several peers")
+				owners[addr] = peers[i].Name
+			}
+		}
+		require.Len(t, owners, len(targets))
+		return owners
+	}
+
+	before := distribute("agent-1", "agent-2", "agent-3", "agent-4")
+	after := distribute("agent-1", "agent-2", "agent-3", "agent-4", "agent-5")
+
+	var moved int
+	for addr, owner := range before {
+		if after[addr] != owner {
+			moved++
+		}
+	}
+	// Ideally, only the 200 targets taken by agent-5 move.
+	require.Less(t, moved, 250)
+}
+
+func TestDistributionTracker(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	tracker, err := NewDistributionTracker(reg)
+	require.NoError(t, err)
+
+	var (
+		a = Target{"__address__": "a:80"}
+		b = Target{"__address__": "b:80"}
+		c = Target{"__address__": "c:80"}
+	)
+	tracker.Observe([]Target{a, b}, []Target{a})
+	tracker.Observe([]Target{a, b, c}, []Target{b, c})
+
+	require.Equal(t, 1.0, testutil.ToFloat64(tracker.moved.WithLabelValues("in")))
+	require.Equal(t, 1.0, testutil.ToFloat64(tracker.moved.WithLabelValues("out")))
+}
+
+// ringNode is a cluster.Node which looks up owners with a real sharder.
+type ringNode struct {
+	peers   []peer.Peer
+	sharder shard.Sharder
+}
+
+func (n *ringNode) Lookup(key shard.Key, numOwners int, op shard.Op) ([]peer.Peer, error) {
+	owners, err := n.sharder.Lookup(key, numOwners, op)
+	if err != nil {
+		return nil, err
+	}
+	// Mark the local peer, which the sharder doesn't know about.
+	for i := range owners {
+		for _, p := range n.peers {
+			if p.Name == owners[i].Name {
+				owners[i].Self = p.Self
+			}
+		}
+	}
+	return owners, nil
+}
+
+func (n *ringNode) Observe(ckit.Observer) {}
+
+func (n *ringNode) Peers() []peer.Peer { return n.peers }
+
+func (n *ringNode) Handler() (string, http.Handler) { return "", nil }
+
+type fakeNode struct {
+	peers []peer.Peer
+	owner peer.Peer
+}
+
+func (n *fakeNode) Lookup(shard.Key, int, shard.Op) ([]peer.Peer, error) {
+	return []peer.Peer{n.owner}, nil
+}
+
+func (n *fakeNode) Observe(ckit.Observer) {}
+
+func (n *fakeNode) Peers() []peer.Peer { return n.peers }
+
+func (n *fakeNode) Handler() (string, http.Handler) { return "", nil }
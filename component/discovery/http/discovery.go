@@ -0,0 +1,158 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/build"
+	prom_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/refresh"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+// urlLabel is the label holding the URL targets were discovered from,
+// matching the one set by Prometheus HTTP service discovery.
+const urlLabel = model.MetaLabelPrefix + "url"
+
+// userAgent is the User-Agent of discovery requests.
+var userAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
+
+var matchContentType = regexp.MustCompile(`^(?i:application\/json(;\s*charset=("utf-8"|utf-8))?)$`)
+
+// bodyData is the data the body template is executed with.
+type bodyData struct {
+	// Now is the time of the refresh.
+	Now time.Time
+	// RefreshInterval is the interval between refreshes.
+	RefreshInterval time.Duration
+}
+
+// httpDiscovery discovers the target groups returned in the Prometheus HTTP
+// service discovery format by an HTTP endpoint.
+type httpDiscovery struct {
+	*refresh.Discovery
+
+	url             string
+	method          string
+	headers         http.Header
+	body            *template.Template
+	client          *http.Client
+	refreshInterval time.Duration
+	tgLastLength    int
+}
+
+func newDiscovery(args Arguments, logger log.Logger) (*httpDiscovery, error) {
+	client, err := prom_config.NewClientFromConfig(*args.HTTPClientConfig.Convert(), "http")
+	if err != nil {
+		return nil, err
+	}
+	client.Timeout = args.RefreshInterval
+
+	body, err := parseBody(args.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(http.Header, len(args.Headers)+3)
+	headers.Set("User-Agent", userAgent)
+	headers.Set("Accept", "application/json")
+	if args.Method == http.MethodPost && args.Body != "" {
+		headers.Set("Content-Type", "application/json")
+	}
+	// Configured headers override the default ones.
+	for name, value := range args.Headers {
+		headers.Set(name, string(value))
+	}
+
+	d := &httpDiscovery{
+		url:             args.URL,
+		method:          args.Method,
+		headers:         headers,
+		body:            body,
+		client:          client,
+		refreshInterval: args.RefreshInterval,
+	}
+	d.Discovery = refresh.NewDiscovery(logger, "http", args.RefreshInterval, d.refresh)
+	return d, nil
+}
+
+func (d *httpDiscovery) refresh(ctx context.Context) ([]*targetgroup.Group, error) {
+	var body io.Reader
+	if d.method == http.MethodPost {
+		var buf bytes.Buffer
+		err := d.body.Execute(&buf, bodyData{
+			Now:             time.Now(),
+			RefreshInterval: d.refreshInterval,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute body template: %w", err)
+		}
+		body = &buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, d.method, d.url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = d.headers.Clone()
+	req.Header.Set("X-Prometheus-Refresh-Interval-Seconds", strconv.FormatFloat(d.refreshInterval.Seconds(), 'f', -1, 64))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned HTTP status %s", resp.Status)
+	}
+	if contentType := strings.TrimSpace(resp.Header.Get("Content-Type")); !matchContentType.MatchString(contentType) {
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+
+	var targetGroups []*targetgroup.Group
+	if err := json.NewDecoder(resp.Body).Decode(&targetGroups); err != nil {
+		return nil, err
+	}
+
+	for i, tg := range targetGroups {
+		if tg == nil {
+			return nil, errors.New("nil target group item found")
+		}
+
+		tg.Source = urlSource(d.url, i)
+		if tg.Labels == nil {
+			tg.Labels = model.LabelSet{}
+		}
+		tg.Labels[urlLabel] = model.LabelValue(d.url)
+	}
+
+	// Generate empty updates for sources that disappeared.
+	l := len(targetGroups)
+	for i := l; i < d.tgLastLength; i++ {
+		targetGroups = append(targetGroups, &targetgroup.Group{Source: urlSource(d.url, i)})
+	}
+	d.tgLastLength = l
+
+	return targetGroups, nil
+}
+
+// urlSource returns a source ID for the i-th target group of url.
+func urlSource(url string, i int) string {
+	return fmt.Sprintf("%s:%d", url, i)
+}
@@ -0,0 +1,109 @@
+// Package http implements the discovery.http component.
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.http",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the discovery.http component.
+type Arguments struct {
+	URL              string                       `river:"url,attr"`
+	RefreshInterval  time.Duration                `river:"refresh_interval,attr,optional"`
+	Method           string                       `river:"method,attr,optional"`
+	Headers          map[string]rivertypes.Secret `river:"headers,attr,optional"`
+	Body             string                       `river:"body,attr,optional"`
+	HTTPClientConfig config.HTTPClientConfig      `river:",squash"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	RefreshInterval:  time.Minute,
+	Method:           http.MethodGet,
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.URL == "" {
+		return fmt.Errorf("url must not be empty")
+	}
+	u, err := url.Parse(args.URL)
+	if err != nil {
+		return fmt.Errorf("parsing url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("url is missing a host")
+	}
+
+	if args.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+
+	switch args.Method {
+	case http.MethodGet:
+		if args.Body != "" {
+			return fmt.Errorf("body can only be used with the POST method")
+		}
+	case http.MethodPost:
+	default:
+		return fmt.Errorf("unsupported method %q, must be %q or %q", args.Method, http.MethodGet, http.MethodPost)
+	}
+	if _, err := parseBody(args.Body); err != nil {
+		return err
+	}
+
+	for name := range args.Headers {
+		if strings.EqualFold(name, "Authorization") {
+			return fmt.Errorf("the Authorization header must be set with the authorization block")
+		}
+	}
+
+	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
+	return args.HTTPClientConfig.Validate()
+}
+
+// parseBody parses the template of the request body.
+func parseBody(body string) (*template.Template, error) {
+	tmpl, err := template.New("body").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// New returns a new instance of a discovery.http component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		return newDiscovery(args.(Arguments), opts.Logger)
+	})
+}
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	var exampleRiverConfig = `
+	url = "https://www.example.com:12345/foo"
+	refresh_interval = "15s"
+	method = "POST"
+	headers = {
+		"X-Scope-OrgID" = "tenant",
+	}
+	body = "{\"refresh_interval\": \"{{ .RefreshInterval }}\"}"
+	oauth2 {
+		client_id = "client"
+		client_secret = "secret"
+		token_url = "https://auth.example.com/token"
+	}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	require.Equal(t, "https://www.example.com:12345/foo", args.URL)
+	require.Equal(t, 15*time.Second, args.RefreshInterval)
+	require.Equal(t, http.MethodPost, args.Method)
+	require.Equal(t, "tenant", string(args.Headers["X-Scope-OrgID"]))
+	require.Equal(t, "client", args.HTTPClientConfig.OAuth2.ClientID)
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect string
+	}{
+		{
+			name:   "bad scheme",
+			config: `url = "ftp://www.example.com/foo"`,
+			expect: "url scheme must be http or https",
+		},
+		{
+			name: "unsupported method",
+			config: `
+			url = "https://www.example.com/foo"
+			method = "PUT"`,
+			expect: `unsupported method "PUT", must be "GET" or "POST"`,
+		},
+		{
+			name: "body without POST",
+			config: `
+			url = "https://www.example.com/foo"
+			body = "{}"`,
+			expect: "body can only be used with the POST method",
+		},
+		{
+			name: "invalid body template",
+			config: `
+			url = "https://www.example.com/foo"
+			method = "POST"
+			body = "{{ .Now "`,
+			expect: "invalid body template",
+		},
+		{
+			name: "authorization header",
+			config: `
+			url = "https://www.example.com/foo"
+			headers = { "authorization" = "Bearer token" }`,
+			expect: "the Authorization header must be set with the authorization block",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	var (
+		tokenRequests atomic.Int32
+		targets       atomic.Value
+	)
+	targets.Store(`[{"targets": ["a:9100", "b:9100"], "labels": {"env": "prod"}}, {"targets": ["c:9100"]}]`)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		tokenRequests.Add(1)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"access_token": "token", "token_type": "Bearer", "expires_in": 3600}`)
+	})
+	mux.HandleFunc("/sd", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.Equal(t, "tenant", r.Header.Get("X-Scope-OrgID"))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "30", r.Header.Get("X-Prometheus-Refresh-Interval-Seconds"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, `{"interval": "30s"}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, targets.Load().(string))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var args Arguments
+	err := river.Unmarshal([]byte(fmt.Sprintf(`
+	url = "%[1]s/sd"
+	refresh_interval = "30s"
+	method = "POST"
+	headers = {
+		"X-Scope-OrgID" = "tenant",
+	}
+	body = "{\"interval\": \"{{ .RefreshInterval }}\"}"
+	oauth2 {
+		client_id = "client"
+		client_secret = "secret"
+		token_url = "%[1]s/token"
+	}
+`, srv.URL)), &args)
+	require.NoError(t, err)
+
+	d, err := newDiscovery(args, log.NewNopLogger())
+	require.NoError(t, err)
+
+	tgs, err := d.refresh(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []*targetgroup.Group{
+		{
+			Source: srv.URL + "/sd:0",
+			Targets: []model.LabelSet{
+				{model.AddressLabel: "a:9100"},
+				{model.AddressLabel: "b:9100"},
+			},
+			Labels: model.LabelSet{"env": "prod", urlLabel: model.LabelValue(srv.URL + "/sd")},
+		},
+		{
+			Source:  srv.URL + "/sd:1",
+			Targets: []model.LabelSet{{model.AddressLabel: "c:9100"}},
+			Labels:  model.LabelSet{urlLabel: model.LabelValue(srv.URL + "/sd")},
+		},
+	}, tgs)
+
+	// Groups which disappeared are sent empty, and the OAuth2 token is reused
+	// until it expires.
+	targets.Store(`[{"targets": ["a:9100"]}]`)
+	tgs, err = d.refresh(context.Background())
+	require.NoError(t, err)
+	require.Len(t, tgs, 2)
+	require.Equal(t, &targetgroup.Group{Source: srv.URL + "/sd:1"}, tgs[1])
+	require.Equal(t, int32(1), tokenRequests.Load())
+}
+
+func TestRefresh_UnsupportedContentType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = fmt.Fprint(w, "[]")
+	}))
+	defer srv.Close()
+
+	args := DefaultArguments
+	args.URL = srv.URL
+	require.NoError(t, args.Validate())
+
+	d, err := newDiscovery(args, log.NewNopLogger())
+	require.NoError(t, err)
+
+	_, err = d.refresh(context.Background())
+	require.EqualError(t, err, `unsupported content type "text/plain"`)
+}
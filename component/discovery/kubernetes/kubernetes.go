@@ -2,10 +2,15 @@
 package kubernetes
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/discovery"
 	promk8s "github.com/prometheus/prometheus/discovery/kubernetes"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func init() {
@@ -43,6 +48,13 @@ func (args *Arguments) SetToDefault() {
 
 // Validate implements river.Validator.
 func (args *Arguments) Validate() error {
+	if err := args.AttachMetadata.validate(); err != nil {
+		return err
+	}
+	if err := validateSelectors(args.Role, args.Selectors, args.AttachMetadata.Node); err != nil {
+		return err
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	return args.HTTPClientConfig.Validate()
 }
@@ -84,6 +96,60 @@ type SelectorConfig struct {
 	Field string `river:"field,attr,optional"`
 }
 
+// selectorRoles lists the roles of the selectors supported by each role.
+// Selectors are applied by the informers watching resources of their role, so
+// roles which watch other resources to build their targets also support
+// selectors for those resources.
+var selectorRoles = map[string][]string{
+	"pod":           {"pod"},
+	"service":       {"service"},
+	"endpoints":     {"pod", "service", "endpoints"},
+	"endpointslice": {"pod", "service", "endpointslice"},
+	"node":          {"node"},
+	"ingress":       {"ingress"},
+}
+
+// validateSelectors validates the selectors of role. Node selectors are also
+// supported by roles watching pods if node metadata is attached, and are
+// applied to the informer of the nodes whose metadata is attached.
+func validateSelectors(role string, selectors []SelectorConfig, attachNode bool) error {
+	allowed, ok := selectorRoles[role]
+	if !ok {
+		return fmt.Errorf("invalid role %q, expecting one of: pod, service, endpoints, endpointslice, node or ingress", role)
+	}
+	if attachNode && role != "service" && role != "node" && role != "ingress" {
+		allowed = append(allowed[:len(allowed):len(allowed)], "node")
+	}
+
+	found := make(map[string]struct{}, len(selectors))
+	for _, s := range selectors {
+		if _, ok := found[s.Role]; ok {
+			return fmt.Errorf("duplicated selector role: %s", s.Role)
+		}
+		found[s.Role] = struct{}{}
+
+		if !contains(allowed, s.Role) {
+			return fmt.Errorf("%s role supports only %s selectors", role, strings.Join(allowed, ", "))
+		}
+		if _, err := fields.ParseSelector(s.Field); err != nil {
+			return fmt.Errorf("invalid field selector for %s role: %w", s.Role, err)
+		}
+		if _, err := labels.Parse(s.Label); err != nil {
+			return fmt.Errorf("invalid label selector for %s role: %w", s.Role, err)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
 func (sc *SelectorConfig) convert() *promk8s.SelectorConfig {
 	return &promk8s.SelectorConfig{
 		Role:  promk8s.Role(sc.Role),
@@ -92,8 +158,24 @@ func (sc *SelectorConfig) convert() *promk8s.SelectorConfig {
 	}
 }
 
+// AttachMetadataConfig configures the metadata attached to discovered targets.
 type AttachMetadataConfig struct {
-	Node bool `river:"node,attr,optional"`
+	Node            bool     `river:"node,attr,optional"`
+	NodeLabels      []string `river:"node_labels,attr,optional"`
+	NodeAnnotations []string `river:"node_annotations,attr,optional"`
+}
+
+func (am *AttachMetadataConfig) validate() error {
+	if !am.Node && (len(am.NodeLabels) > 0 || len(am.NodeAnnotations) > 0) {
+		return fmt.Errorf("node must be true to use node_labels or node_annotations")
+	}
+	return nil
+}
+
+// filtersNodeMetadata reports whether node metadata needs to be filtered or
+// extended beyond what Prometheus attaches.
+func (am *AttachMetadataConfig) filtersNodeMetadata() bool {
+	return am.Node && (len(am.NodeLabels) > 0 || len(am.NodeAnnotations) > 0)
 }
 
 func (am *AttachMetadataConfig) convert() *promk8s.AttachMetadataConfig {
@@ -106,6 +188,36 @@ func (am *AttachMetadataConfig) convert() *promk8s.AttachMetadataConfig {
 func New(opts component.Options, args Arguments) (component.Component, error) {
 	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
 		newArgs := args.(Arguments)
-		return promk8s.New(opts.Logger, newArgs.Convert())
+		conf := newArgs.Convert()
+		d, err := promk8s.New(opts.Logger, conf)
+		if err != nil || !newArgs.AttachMetadata.filtersNodeMetadata() {
+			return d, err
+		}
+
+		// nodes is left nil unless annotations are attached.
+		var nodes discovery.Discoverer
+		if len(newArgs.AttachMetadata.NodeAnnotations) > 0 {
+			nodes, err = promk8s.New(opts.Logger, nodesConfig(conf))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return newNodeMetadataDiscoverer(d, nodes, newArgs.AttachMetadata.NodeLabels, newArgs.AttachMetadata.NodeAnnotations), nil
 	})
 }
+
+// nodesConfig returns the configuration discovering the nodes whose
+// annotations are attached to the targets discovered by conf.
+func nodesConfig(conf *promk8s.SDConfig) *promk8s.SDConfig {
+	res := *conf
+	res.Role = promk8s.RoleNode
+	res.NamespaceDiscovery = promk8s.NamespaceDiscovery{}
+	res.AttachMetadata = promk8s.AttachMetadataConfig{}
+	res.Selectors = nil
+	for _, s := range conf.Selectors {
+		if s.Role == promk8s.RoleNode {
+			res.Selectors = append(res.Selectors, s)
+		}
+	}
+	return &res
+}
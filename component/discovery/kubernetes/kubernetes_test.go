@@ -46,3 +46,119 @@ func TestAttachMetadata(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.NoError(t, err)
 }
+
+func TestSelectors(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		role = "endpoints"
+		selectors {
+			role  = "endpoints"
+			label = "app in (web, api)"
+		}
+		selectors {
+			role  = "pod"
+			field = "spec.nodeName=node-1"
+		}
+	`), &args)
+	require.NoError(t, err)
+
+	selectors := args.Convert().Selectors
+	require.Len(t, selectors, 2)
+	require.Equal(t, "app in (web, api)", selectors[0].Label)
+	require.Equal(t, "spec.nodeName=node-1", selectors[1].Field)
+}
+
+func TestBadSelectors(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name:   "invalid role",
+			cfg:    `role = "pods"`,
+			expect: `invalid role "pods", expecting one of: pod, service, endpoints, endpointslice, node or ingress`,
+		},
+		{
+			name: "unsupported selector role",
+			cfg: `
+				role = "pod"
+				selectors {
+					role = "service"
+				}
+			`,
+			expect: "pod role supports only pod selectors",
+		},
+		{
+			name: "node selectors without node metadata",
+			cfg: `
+				role = "endpoints"
+				selectors {
+					role = "node"
+				}
+			`,
+			expect: "endpoints role supports only pod, service, endpoints selectors",
+		},
+		{
+			name: "duplicated selector role",
+			cfg: `
+				role = "pod"
+				selectors {
+					role = "pod"
+				}
+				selectors {
+					role = "pod"
+				}
+			`,
+			expect: "duplicated selector role: pod",
+		},
+		{
+			name: "invalid label selector",
+			cfg: `
+				role = "pod"
+				selectors {
+					role  = "pod"
+					label = "app in web"
+				}
+			`,
+			expect: "invalid label selector for pod role",
+		},
+		{
+			name: "invalid field selector",
+			cfg: `
+				role = "pod"
+				selectors {
+					role  = "pod"
+					field = "spec.nodeName"
+				}
+			`,
+			expect: "invalid field selector for pod role",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestNodeSelectorsWithAttachMetadata(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		role = "pod"
+		selectors {
+			role  = "pod"
+			field = "spec.nodeName=node-1"
+		}
+		selectors {
+			role  = "node"
+			field = "metadata.name=node-1"
+		}
+		attach_metadata {
+			node = true
+		}
+	`), &args)
+	require.NoError(t, err)
+}
@@ -0,0 +1,211 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/prometheus/prometheus/util/strutil"
+)
+
+// Labels of the node metadata attached to targets, matching the ones used by
+// Prometheus.
+const (
+	nodeNameLabel               = "__meta_kubernetes_node_name"
+	nodeLabelPrefix             = "__meta_kubernetes_node_label_"
+	nodeLabelPresentPrefix      = "__meta_kubernetes_node_labelpresent_"
+	nodeAnnotationPrefix        = "__meta_kubernetes_node_annotation_"
+	nodeAnnotationPresentPrefix = "__meta_kubernetes_node_annotationpresent_"
+)
+
+// nodeMetadataDiscoverer wraps the discoverer of a role with node metadata
+// attached. It only keeps the selected node labels on targets, and attaches
+// the selected node annotations, which Prometheus doesn't attach, from a
+// second discoverer of nodes.
+type nodeMetadataDiscoverer struct {
+	targets discovery.Discoverer
+	// nodes discovers the annotations of nodes. It's nil if no annotations
+	// are attached.
+	nodes discovery.Discoverer
+
+	// Sanitized names of the node labels to keep, or nil to keep all of them.
+	labels map[string]struct{}
+	// Sanitized names of the node annotations to attach.
+	annotations map[string]struct{}
+
+	// Annotation labels to attach to targets, by node name. Only accessed by
+	// Run.
+	nodeAnnotations map[string]model.LabelSet
+}
+
+func newNodeMetadataDiscoverer(targets, nodes discovery.Discoverer, labels, annotations []string) *nodeMetadataDiscoverer {
+	d := &nodeMetadataDiscoverer{
+		targets:         targets,
+		nodes:           nodes,
+		annotations:     sanitizedSet(annotations),
+		nodeAnnotations: make(map[string]model.LabelSet),
+	}
+	if len(labels) > 0 {
+		d.labels = sanitizedSet(labels)
+	}
+	return d
+}
+
+func sanitizedSet(names []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		set[strutil.SanitizeLabelName(n)] = struct{}{}
+	}
+	return set
+}
+
+// Run implements discovery.Discoverer.
+func (d *nodeMetadataDiscoverer) Run(ctx context.Context, up chan<- []*targetgroup.Group) {
+	targetsCh := make(chan []*targetgroup.Group)
+	go d.targets.Run(ctx, targetsCh)
+
+	// nodesCh is left nil when annotations aren't attached, so it's never
+	// selected.
+	var nodesCh chan []*targetgroup.Group
+	if d.nodes != nil {
+		nodesCh = make(chan []*targetgroup.Group)
+		go d.nodes.Run(ctx, nodesCh)
+	}
+
+	// The latest groups by source, which are sent again when the annotations
+	// of nodes change.
+	groups := make(map[string]*targetgroup.Group)
+
+	for {
+		var send []*targetgroup.Group
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case tgs := <-targetsCh:
+			for _, tg := range tgs {
+				if tg == nil {
+					continue
+				}
+				if len(tg.Targets) == 0 {
+					delete(groups, tg.Source)
+				} else {
+					groups[tg.Source] = tg
+				}
+				send = append(send, d.process(tg))
+			}
+
+		case tgs := <-nodesCh:
+			if !d.updateNodes(tgs) {
+				continue
+			}
+			for _, tg := range groups {
+				send = append(send, d.process(tg))
+			}
+		}
+
+		if len(send) == 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case up <- send:
+		}
+	}
+}
+
+// updateNodes updates the annotations of nodes and reports whether any of
+// them changed.
+func (d *nodeMetadataDiscoverer) updateNodes(tgs []*targetgroup.Group) bool {
+	var changed bool
+	for _, tg := range tgs {
+		if tg == nil {
+			continue
+		}
+		name := strings.TrimPrefix(tg.Source, "node/")
+
+		annotations := make(model.LabelSet)
+		for k, v := range tg.Labels {
+			if selectedName(d.annotations, string(k), nodeAnnotationPrefix, nodeAnnotationPresentPrefix) {
+				annotations[k] = v
+			}
+		}
+
+		old, exists := d.nodeAnnotations[name]
+		switch {
+		case len(tg.Labels) == 0:
+			// The node was deleted.
+			if exists {
+				delete(d.nodeAnnotations, name)
+				changed = true
+			}
+		case !exists || !old.Equal(annotations):
+			d.nodeAnnotations[name] = annotations
+			changed = true
+		}
+	}
+	return changed
+}
+
+// process returns a copy of tg with the node metadata of its labels and
+// targets updated.
+func (d *nodeMetadataDiscoverer) process(tg *targetgroup.Group) *targetgroup.Group {
+	res := &targetgroup.Group{
+		Source: tg.Source,
+		Labels: d.processLabels(tg.Labels),
+	}
+	if len(tg.Targets) > 0 {
+		res.Targets = make([]model.LabelSet, 0, len(tg.Targets))
+		for _, t := range tg.Targets {
+			res.Targets = append(res.Targets, d.processLabels(t))
+		}
+	}
+	return res
+}
+
+func (d *nodeMetadataDiscoverer) processLabels(ls model.LabelSet) model.LabelSet {
+	if ls == nil {
+		return nil
+	}
+
+	res := make(model.LabelSet, len(ls))
+	for k, v := range ls {
+		if d.labels != nil && isPrefixed(string(k), nodeLabelPrefix, nodeLabelPresentPrefix) &&
+			!selectedName(d.labels, string(k), nodeLabelPrefix, nodeLabelPresentPrefix) {
+			continue
+		}
+		res[k] = v
+	}
+
+	if name, ok := ls[nodeNameLabel]; ok {
+		for k, v := range d.nodeAnnotations[string(name)] {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// selectedName reports whether the label named name has one of the prefixes
+// followed by a name in set.
+func selectedName(set map[string]struct{}, name string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			_, ok := set[strings.TrimPrefix(name, p)]
+			return ok
+		}
+	}
+	return false
+}
+
+func isPrefixed(name string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDiscoverer sends the groups written to its channel.
+type fakeDiscoverer chan []*targetgroup.Group
+
+func (f fakeDiscoverer) Run(ctx context.Context, up chan<- []*targetgroup.Group) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tgs := <-f:
+			select {
+			case <-ctx.Done():
+				return
+			case up <- tgs:
+			}
+		}
+	}
+}
+
+func receiveGroups(t *testing.T, ch chan []*targetgroup.Group) []*targetgroup.Group {
+	t.Helper()
+
+	select {
+	case tgs := <-ch:
+		return tgs
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for target groups")
+		return nil
+	}
+}
+
+func TestNodeMetadataDiscoverer(t *testing.T) {
+	targets, nodes := make(fakeDiscoverer), make(fakeDiscoverer)
+	d := newNodeMetadataDiscoverer(targets, nodes,
+		[]string{"topology.kubernetes.io/zone"},
+		[]string{"example.com/rack"},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	up := make(chan []*targetgroup.Group)
+	go d.Run(ctx, up)
+
+	// Node labels which aren't selected are dropped.
+	targets <- []*targetgroup.Group{{
+		Source: "pod/default/app",
+		Labels: model.LabelSet{
+			"__meta_kubernetes_pod_name": "app",
+			nodeNameLabel:                "node-1",
+			"__meta_kubernetes_node_label_topology_kubernetes_io_zone":        "eu-west-1a",
+			"__meta_kubernetes_node_labelpresent_topology_kubernetes_io_zone": "true",
+			"__meta_kubernetes_node_label_kubernetes_io_os":                   "linux",
+			"__meta_kubernetes_node_labelpresent_kubernetes_io_os":            "true",
+		},
+		Targets: []model.LabelSet{{model.AddressLabel: "10.0.0.1:8080"}},
+	}}
+	expect := model.LabelSet{
+		"__meta_kubernetes_pod_name": "app",
+		nodeNameLabel:                "node-1",
+		"__meta_kubernetes_node_label_topology_kubernetes_io_zone":        "eu-west-1a",
+		"__meta_kubernetes_node_labelpresent_topology_kubernetes_io_zone": "true",
+	}
+	tgs := receiveGroups(t, up)
+	require.Len(t, tgs, 1)
+	require.Equal(t, expect, tgs[0].Labels)
+
+	// Groups are sent again with the selected annotations once the node is
+	// discovered.
+	nodes <- []*targetgroup.Group{{
+		Source: "node/node-1",
+		Labels: model.LabelSet{
+			nodeNameLabel: "node-1",
+			"__meta_kubernetes_node_annotation_example_com_rack":        "r12",
+			"__meta_kubernetes_node_annotationpresent_example_com_rack": "true",
+			"__meta_kubernetes_node_annotation_example_com_owner":       "team-a",
+		},
+	}}
+	expect["__meta_kubernetes_node_annotation_example_com_rack"] = "r12"
+	expect["__meta_kubernetes_node_annotationpresent_example_com_rack"] = "true"
+	tgs = receiveGroups(t, up)
+	require.Len(t, tgs, 1)
+	require.Equal(t, expect, tgs[0].Labels)
+	require.Equal(t, []model.LabelSet{{model.AddressLabel: "10.0.0.1:8080"}}, tgs[0].Targets)
+
+	// Annotations are removed once the node is deleted.
+	nodes <- []*targetgroup.Group{{Source: "node/node-1"}}
+	delete(expect, "__meta_kubernetes_node_annotation_example_com_rack")
+	delete(expect, "__meta_kubernetes_node_annotationpresent_example_com_rack")
+	tgs = receiveGroups(t, up)
+	require.Len(t, tgs, 1)
+	require.Equal(t, expect, tgs[0].Labels)
+}
+
+func TestAttachMetadataValidate(t *testing.T) {
+	am := AttachMetadataConfig{NodeLabels: []string{"topology.kubernetes.io/zone"}}
+	require.EqualError(t, am.validate(), "node must be true to use node_labels or node_annotations")
+
+	am.Node = true
+	require.NoError(t, am.validate())
+}
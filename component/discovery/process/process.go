@@ -0,0 +1,134 @@
+// Package process implements the discovery.process component.
+package process
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/refresh"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.process",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+const (
+	pidLabel         = "__process_pid__"
+	exeLabel         = model.MetaLabelPrefix + "process_exe"
+	cwdLabel         = model.MetaLabelPrefix + "process_cwd"
+	commandlineLabel = model.MetaLabelPrefix + "process_commandline"
+	uidLabel         = model.MetaLabelPrefix + "process_uid"
+	usernameLabel    = model.MetaLabelPrefix + "process_username"
+	containerIDLabel = model.MetaLabelPrefix + "process_container_id"
+)
+
+// Arguments configures the discovery.process component.
+type Arguments struct {
+	RefreshInterval time.Duration `river:"refresh_interval,attr,optional"`
+	ProcFSPath      string        `river:"procfs_path,attr,optional"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	RefreshInterval: 60 * time.Second,
+	ProcFSPath:      "/proc",
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+	if args.ProcFSPath == "" {
+		return fmt.Errorf("procfs_path must not be empty")
+	}
+	return nil
+}
+
+// New returns a new instance of a discovery.process component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		return newDiscovery(args.(Arguments), opts.Logger), nil
+	})
+}
+
+// process is a process running on the host.
+type process struct {
+	pid         int
+	exe         string
+	cwd         string
+	commandline string
+	uid         string
+	username    string
+	containerID string
+}
+
+func newDiscovery(args Arguments, logger log.Logger) *refresh.Discovery {
+	return refresh.NewDiscovery(logger, "process", args.RefreshInterval, func(ctx context.Context) ([]*targetgroup.Group, error) {
+		procs, err := discoverProcesses(args.ProcFSPath, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		tg := &targetgroup.Group{Source: "process"}
+		for _, p := range procs {
+			tg.Targets = append(tg.Targets, processTarget(p))
+		}
+		return []*targetgroup.Group{tg}, nil
+	})
+}
+
+// processTarget returns the labels of the target of p. Labels whose value is
+// unknown are left out.
+func processTarget(p process) model.LabelSet {
+	lset := model.LabelSet{pidLabel: model.LabelValue(strconv.Itoa(p.pid))}
+	for name, value := range map[model.LabelName]string{
+		exeLabel:         p.exe,
+		cwdLabel:         p.cwd,
+		commandlineLabel: p.commandline,
+		uidLabel:         p.uid,
+		usernameLabel:    p.username,
+		containerIDLabel: p.containerID,
+	} {
+		if value != "" {
+			lset[name] = model.LabelValue(value)
+		}
+	}
+	return lset
+}
+
+// containerIDRegexp matches the ID of the container at the end of a cgroup
+// path, such as "/docker/<id>", "/kubepods/.../cri-containerd-<id>.scope" or
+// "/system.slice/crio-<id>.scope".
+var containerIDRegexp = regexp.MustCompile(`/(?:[a-z-]+-)?([0-9a-f]{64})(?:\.scope)?$`)
+
+// containerID returns the ID of the container the cgroup path belongs to, or
+// an empty string if it's not the cgroup of a container.
+func containerID(cgroupPath string) string {
+	m := containerIDRegexp.FindStringSubmatch(cgroupPath)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
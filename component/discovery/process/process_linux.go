@@ -0,0 +1,71 @@
+//go:build linux
+
+package process
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/procfs"
+)
+
+// discoverProcesses lists the processes of the procfs mounted at procfsPath.
+// Details which can't be read, such as the executable of processes owned by
+// other users when running unprivileged, are left empty.
+func discoverProcesses(procfsPath string, logger log.Logger) ([]process, error) {
+	fs, err := procfs.NewFS(procfsPath)
+	if err != nil {
+		return nil, err
+	}
+	procs, err := fs.AllProcs()
+	if err != nil {
+		return nil, err
+	}
+
+	usernames := make(map[string]string)
+	res := make([]process, 0, len(procs))
+	for _, p := range procs {
+		status, err := p.NewStatus()
+		if err != nil {
+			// The process exited since it was listed.
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			level.Debug(logger).Log("msg", "failed to read process status", "pid", p.PID, "err", err)
+		}
+
+		proc := process{pid: p.PID, uid: status.UIDs[0]}
+		proc.exe, _ = p.Executable()
+		proc.cwd, _ = p.Cwd()
+		if cmdline, err := p.CmdLine(); err == nil {
+			proc.commandline = strings.Join(cmdline, " ")
+		}
+
+		if proc.uid != "" {
+			username, ok := usernames[proc.uid]
+			if !ok {
+				if u, err := user.LookupId(proc.uid); err == nil {
+					username = u.Username
+				}
+				usernames[proc.uid] = username
+			}
+			proc.username = username
+		}
+
+		if cgroups, err := p.Cgroups(); err == nil {
+			for _, cg := range cgroups {
+				if id := containerID(cg.Path); id != "" {
+					proc.containerID = id
+					break
+				}
+			}
+		}
+
+		res = append(res, proc)
+	}
+	return res, nil
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package process
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverProcesses(t *testing.T) {
+	procs, err := discoverProcesses("/proc", log.NewNopLogger())
+	require.NoError(t, err)
+
+	var self *process
+	for i := range procs {
+		if procs[i].pid == os.Getpid() {
+			self = &procs[i]
+		}
+	}
+	require.NotNil(t, self, "the test process should be discovered")
+
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	require.Equal(t, exe, self.exe)
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.Equal(t, wd, self.cwd)
+
+	require.Equal(t, strconv.Itoa(os.Getuid()), self.uid)
+	if u, err := user.Current(); err == nil {
+		require.Equal(t, u.Username, self.username)
+	}
+}
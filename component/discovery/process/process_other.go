@@ -0,0 +1,14 @@
+//go:build !linux
+
+package process
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/go-kit/log"
+)
+
+func discoverProcesses(_ string, _ log.Logger) ([]process, error) {
+	return nil, fmt.Errorf("discovery.process is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,67 @@
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	var exampleRiverConfig = `
+	refresh_interval = "10s"
+	procfs_path = "/host/proc"
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	require.Equal(t, 10*time.Second, args.RefreshInterval)
+	require.Equal(t, "/host/proc", args.ProcFSPath)
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`refresh_interval = "0s"`), &args)
+	require.ErrorContains(t, err, "refresh_interval must be greater than 0")
+}
+
+func TestContainerID(t *testing.T) {
+	const id = "3f4e3b2a1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f"
+
+	tt := []struct {
+		path   string
+		expect string
+	}{
+		{path: "/docker/" + id, expect: id},
+		{path: "/system.slice/docker-" + id + ".scope", expect: id},
+		{path: "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-" + id + ".scope", expect: id},
+		{path: "/kubepods/besteffort/pod1234/" + id, expect: id},
+		{path: "/system.slice/crio-" + id + ".scope", expect: id},
+		{path: "/user.slice/user-1000.slice/session-2.scope", expect: ""},
+		{path: "/", expect: ""},
+	}
+
+	for _, tc := range tt {
+		require.Equal(t, tc.expect, containerID(tc.path), tc.path)
+	}
+}
+
+func TestProcessTarget(t *testing.T) {
+	lset := processTarget(process{
+		pid:         42,
+		exe:         "/usr/bin/app",
+		commandline: "/usr/bin/app --flag",
+		uid:         "1000",
+	})
+
+	require.Equal(t, model.LabelSet{
+		pidLabel:         "42",
+		exeLabel:         "/usr/bin/app",
+		commandlineLabel: "/usr/bin/app --flag",
+		uidLabel:         "1000",
+	}, lset)
+}
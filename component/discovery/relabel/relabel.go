@@ -2,11 +2,14 @@ package relabel
 
 import (
 	"context"
+	"reflect"
 	"sync"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/livedebugging"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/discovery"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
 )
@@ -40,17 +43,61 @@ type Exports struct {
 
 // Component implements the discovery.relabel component.
 type Component struct {
-	opts component.Options
+	opts  component.Options
+	debug *livedebugging.Hub
 
-	mut sync.RWMutex
-	rcs []*relabel.Config
+	cacheHits   prometheus_client.Counter
+	cacheMisses prometheus_client.Counter
+	cacheSize   prometheus_client.Gauge
+
+	mut   sync.RWMutex
+	rules []*flow_relabel.Config
+	rcs   []*relabel.Config
+
+	// cache holds the result of relabeling each target of the last update,
+	// keyed by the fingerprint of the target's labels. It's cleared when the
+	// rules change.
+	cache map[uint64]*cacheEntry
+}
+
+// cacheEntry is the result of relabeling a target.
+type cacheEntry struct {
+	input  labels.Labels    // Labels of the target before relabeling.
+	output discovery.Target // Target after relabeling; nil if it was dropped.
 }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component              = (*Component)(nil)
+	_ component.LiveDebuggingComponent = (*Component)(nil)
+)
 
 // New creates a new discovery.relabel component.
 func New(o component.Options, args Arguments) (*Component, error) {
-	c := &Component{opts: o}
+	c := &Component{
+		opts: o,
+		cacheHits: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_discovery_relabel_cache_hits",
+			Help: "Total number of targets whose relabeling result was reused",
+		}),
+		cacheMisses: prometheus_client.NewCounter(prometheus_client.CounterOpts{
+			Name: "agent_discovery_relabel_cache_misses",
+			Help: "Total number of targets which were relabeled",
+		}),
+		cacheSize: prometheus_client.NewGauge(prometheus_client.GaugeOpts{
+			Name: "agent_discovery_relabel_cache_size",
+			Help: "Number of targets whose relabeling result is cached",
+		}),
+	}
+	for _, metric := range []prometheus_client.Collector{c.cacheHits, c.cacheMisses, c.cacheSize} {
+		if err := o.Registerer.Register(metric); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if c.debug, err = livedebugging.NewHub(o.Registerer); err != nil {
+		return nil, err
+	}
 
 	// Call to Update() to set the output once at the start
 	if err := c.Update(args); err != nil {
@@ -73,15 +120,49 @@ func (c *Component) Update(args component.Arguments) error {
 
 	newArgs := args.(Arguments)
 
-	targets := make([]discovery.Target, 0, len(newArgs.Targets))
-	relabelConfigs := flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelConfigs)
-	c.rcs = relabelConfigs
+	if !reflect.DeepEqual(c.rules, newArgs.RelabelConfigs) {
+		c.rules = newArgs.RelabelConfigs
+		c.rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelConfigs)
+		c.cache = nil
+	}
 
+	// Only targets which were added or changed since the last update are
+	// relabeled. Entries of targets which were removed aren't carried over to
+	// the new cache.
+	var (
+		targets  = make([]discovery.Target, 0, len(newArgs.Targets))
+		newCache = make(map[uint64]*cacheEntry, len(newArgs.Targets))
+	)
 	for _, t := range newArgs.Targets {
 		lset := componentMapToPromLabels(t)
-		lset, keep := relabel.Process(lset, relabelConfigs...)
-		if keep {
-			targets = append(targets, promLabelsToComponent(lset))
+		fp := lset.Hash()
+
+		entry, ok := newCache[fp]
+		if !ok {
+			entry, ok = c.cache[fp]
+		}
+		if ok && labels.Equal(entry.input, lset) {
+			c.cacheHits.Inc()
+		} else {
+			c.cacheMisses.Inc()
+			entry = &cacheEntry{input: lset}
+			if out, keep := relabel.Process(lset.Copy(), c.rcs...); keep {
+				entry.output = promLabelsToComponent(out)
+			}
+		}
+		newCache[fp] = entry
+
+		if entry.output != nil {
+			targets = append(targets, entry.output)
+		}
+	}
+	c.cache = newCache
+	c.cacheSize.Set(float64(len(newCache)))
+
+	if c.debug.Active() {
+		for _, t := range targets {
+			t := t
+			c.debug.Publish("target", func() interface{} { return t })
 		}
 	}
 
@@ -93,13 +174,12 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
-func componentMapToPromLabels(ls discovery.Target) labels.Labels {
-	res := make([]labels.Label, 0, len(ls))
-	for k, v := range ls {
-		res = append(res, labels.Label{Name: k, Value: v})
-	}
+// LiveDebugging implements component.LiveDebuggingComponent. The exported
+// targets are published whenever they're recomputed.
+func (c *Component) LiveDebugging() *livedebugging.Hub { return c.debug }
 
-	return res
+func componentMapToPromLabels(ls discovery.Target) labels.Labels {
+	return labels.FromMap(ls)
 }
 
 func promLabelsToComponent(ls labels.Labels) discovery.Target {
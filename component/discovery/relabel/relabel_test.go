@@ -1,14 +1,20 @@
 package relabel_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/agent/component"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/discovery/relabel"
 	"github.com/grafana/agent/pkg/flow/componenttest"
 	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -123,3 +129,80 @@ rule {
 	require.Equal(t, gotUpdated[0].SourceLabels, gotOriginal[0].SourceLabels)
 	require.Equal(t, gotUpdated[0].Regex, gotOriginal[0].Regex)
 }
+
+func TestIncrementalRelabeling(t *testing.T) {
+	var (
+		reg     = prometheus.NewRegistry()
+		exports relabel.Exports
+	)
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    reg,
+		OnStateChange: func(e component.Exports) { exports = e.(relabel.Exports) },
+	}
+
+	var args relabel.Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+targets = [
+	{ "__address__" = "a:9100", "app" = "backend" },
+	{ "__address__" = "b:9100", "app" = "frontend" },
+]
+
+rule {
+	source_labels = ["__address__"]
+	target_label  = "instance"
+}
+
+rule {
+	source_labels = ["app"]
+	action        = "drop"
+	regex         = "frontend"
+}`), &args))
+
+	c, err := relabel.New(opts, args)
+	require.NoError(t, err)
+	require.Equal(t, []discovery.Target{
+		{"__address__": "a:9100", "app": "backend", "instance": "a:9100"},
+	}, exports.Output)
+
+	expectCache := func(hits, misses, size int) {
+		t.Helper()
+		expect := fmt.Sprintf(`
+# HELP agent_discovery_relabel_cache_hits Total number of targets whose relabeling result was reused
+# TYPE agent_discovery_relabel_cache_hits counter
+agent_discovery_relabel_cache_hits %d
+# HELP agent_discovery_relabel_cache_misses Total number of targets which were relabeled
+# TYPE agent_discovery_relabel_cache_misses counter
+agent_discovery_relabel_cache_misses %d
+# HELP agent_discovery_relabel_cache_size Number of targets whose relabeling result is cached
+# TYPE agent_discovery_relabel_cache_size gauge
+agent_discovery_relabel_cache_size %d
+# HELP agent_livedebugging_dropped_samples_total Total number of samples dropped because a client didn't keep up with its stream.
+# TYPE agent_livedebugging_dropped_samples_total counter
+agent_livedebugging_dropped_samples_total 0
+# HELP agent_livedebugging_streams Number of clients streaming samples of the data flowing through the component.
+# TYPE agent_livedebugging_streams gauge
+agent_livedebugging_streams 0
+`, hits, misses, size)
+		require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expect)))
+	}
+	expectCache(0, 2, 2)
+
+	// Only the added target is relabeled, and the removed one is evicted.
+	args.Targets = []discovery.Target{
+		{"__address__": "a:9100", "app": "backend"},
+		{"__address__": "c:9100", "app": "backend"},
+	}
+	require.NoError(t, c.Update(args))
+	require.Equal(t, []discovery.Target{
+		{"__address__": "a:9100", "app": "backend", "instance": "a:9100"},
+		{"__address__": "c:9100", "app": "backend", "instance": "c:9100"},
+	}, exports.Output)
+	expectCache(1, 3, 2)
+
+	// Changing the rules relabels every target again.
+	args.RelabelConfigs = args.RelabelConfigs[:1]
+	require.NoError(t, c.Update(args))
+	require.Len(t, exports.Output, 2)
+	expectCache(1, 5, 2)
+}
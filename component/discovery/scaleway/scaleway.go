@@ -0,0 +1,148 @@
+package scaleway
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	prom_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	prom_discovery "github.com/prometheus/prometheus/discovery/scaleway"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.scaleway",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Roles of the targets to discover.
+const (
+	RoleInstance  = "instance"
+	RoleBaremetal = "baremetal"
+)
+
+// Arguments configures the discovery.scaleway component.
+type Arguments struct {
+	Project         string            `river:"project_id,attr"`
+	Role            string            `river:"role,attr"`
+	APIURL          string            `river:"api_url,attr,optional"`
+	Zone            string            `river:"zone,attr,optional"`
+	AccessKey       string            `river:"access_key,attr"`
+	SecretKey       rivertypes.Secret `river:"secret_key,attr,optional"`
+	SecretKeyFile   string            `river:"secret_key_file,attr,optional"`
+	NameFilter      string            `river:"name_filter,attr,optional"`
+	TagsFilter      []string          `river:"tags_filter,attr,optional"`
+	RefreshInterval time.Duration     `river:"refresh_interval,attr,optional"`
+	Port            int               `river:"port,attr,optional"`
+
+	ProxyURL             config.URL       `river:"proxy_url,attr,optional"`
+	NoProxy              string           `river:"no_proxy,attr,optional"`
+	ProxyFromEnvironment bool             `river:"proxy_from_environment,attr,optional"`
+	ProxyConnectHeader   config.Header    `river:"proxy_connect_header,attr,optional"`
+	TLSConfig            config.TLSConfig `river:"tls_config,block,optional"`
+	FollowRedirects      bool             `river:"follow_redirects,attr,optional"`
+	EnableHTTP2          bool             `river:"enable_http2,attr,optional"`
+}
+
+// DefaultArguments holds the default arguments of the discovery.scaleway
+// component.
+var DefaultArguments = Arguments{
+	APIURL:          "https://api.scaleway.com",
+	Zone:            "fr-par-1",
+	RefreshInterval: time.Minute,
+	Port:            80,
+
+	FollowRedirects: config.DefaultHTTPClientConfig.FollowRedirects,
+	EnableHTTP2:     config.DefaultHTTPClientConfig.EnableHTTP2,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	switch a.Role {
+	case RoleInstance, RoleBaremetal:
+	default:
+		return fmt.Errorf("unsupported role %q, must be %q or %q", a.Role, RoleInstance, RoleBaremetal)
+	}
+
+	if a.Project == "" {
+		return fmt.Errorf("project_id must not be empty")
+	}
+	if a.AccessKey == "" {
+		return fmt.Errorf("access_key must not be empty")
+	}
+	if (a.SecretKey == "" && a.SecretKeyFile == "") ||
+		(a.SecretKey != "" && a.SecretKeyFile != "") {
+
+		return fmt.Errorf("exactly one of secret_key or secret_key_file must be specified")
+	}
+
+	proxy := config.ProxyConfig{
+		ProxyURL:             a.ProxyURL,
+		NoProxy:              a.NoProxy,
+		ProxyFromEnvironment: a.ProxyFromEnvironment,
+		ProxyConnectHeader:   a.ProxyConnectHeader,
+	}
+	if err := proxy.Validate(); err != nil {
+		return err
+	}
+	return a.TLSConfig.Validate()
+}
+
+// Convert converts Arguments to the Prometheus SD type.
+func (a *Arguments) Convert() *prom_discovery.SDConfig {
+	httpClientConfig := config.DefaultHTTPClientConfig
+	httpClientConfig.ProxyURL = a.ProxyURL
+	httpClientConfig.NoProxy = a.NoProxy
+	httpClientConfig.ProxyFromEnvironment = a.ProxyFromEnvironment
+	httpClientConfig.ProxyConnectHeader = a.ProxyConnectHeader
+	httpClientConfig.TLSConfig = a.TLSConfig
+	httpClientConfig.FollowRedirects = a.FollowRedirects
+	httpClientConfig.EnableHTTP2 = a.EnableHTTP2
+
+	cfg := &prom_discovery.SDConfig{
+		Project:          a.Project,
+		APIURL:           a.APIURL,
+		Zone:             a.Zone,
+		AccessKey:        a.AccessKey,
+		SecretKey:        prom_config.Secret(a.SecretKey),
+		SecretKeyFile:    a.SecretKeyFile,
+		NameFilter:       a.NameFilter,
+		TagsFilter:       a.TagsFilter,
+		HTTPClientConfig: *httpClientConfig.Convert(),
+		RefreshInterval:  model.Duration(a.RefreshInterval),
+		Port:             a.Port,
+	}
+
+	// The type of the role is unexported, so it can only be set from
+	// constants.
+	switch a.Role {
+	case RoleInstance:
+		cfg.Role = RoleInstance
+	case RoleBaremetal:
+		cfg.Role = RoleBaremetal
+	}
+	return cfg
+}
+
+// New returns a new instance of a discovery.scaleway component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		newArgs := args.(Arguments)
+		return prom_discovery.NewDiscovery(newArgs.Convert(), opts.Logger)
+	})
+}
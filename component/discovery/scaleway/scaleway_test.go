@@ -0,0 +1,96 @@
+package scaleway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	prom_common_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	var exampleRiverConfig = `
+	project_id = "11111111-1111-1111-1111-111111111111"
+	role = "instance"
+	access_key = "SCWXXXXXXXXXXXXXXXXX"
+	secret_key = "00000000-0000-0000-0000-000000000000"
+	tags_filter = ["prod"]
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	require.Equal(t, RoleInstance, args.Role)
+	require.Equal(t, "https://api.scaleway.com", args.APIURL)
+	require.Equal(t, "fr-par-1", args.Zone)
+	require.Equal(t, time.Minute, args.RefreshInterval)
+	require.Equal(t, 80, args.Port)
+	require.Equal(t, []string{"prod"}, args.TagsFilter)
+	require.True(t, args.FollowRedirects)
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	tt := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name: "bad role",
+			config: `
+			project_id = "11111111-1111-1111-1111-111111111111"
+			role = "kapsule"
+			access_key = "SCWXXXXXXXXXXXXXXXXX"
+			secret_key = "00000000-0000-0000-0000-000000000000"
+			`,
+			expectedErr: `unsupported role "kapsule", must be "instance" or "baremetal"`,
+		},
+		{
+			name: "too many secret keys",
+			config: `
+			project_id = "11111111-1111-1111-1111-111111111111"
+			role = "baremetal"
+			access_key = "SCWXXXXXXXXXXXXXXXXX"
+			secret_key = "00000000-0000-0000-0000-000000000000"
+			secret_key_file = "/etc/scaleway/secret_key"
+			`,
+			expectedErr: "exactly one of secret_key or secret_key_file must be specified",
+		},
+		{
+			name: "missing secret key",
+			config: `
+			project_id = "11111111-1111-1111-1111-111111111111"
+			role = "baremetal"
+			access_key = "SCWXXXXXXXXXXXXXXXXX"
+			`,
+			expectedErr: "exactly one of secret_key or secret_key_file must be specified",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	args := DefaultArguments
+	args.Project = "11111111-1111-1111-1111-111111111111"
+	args.Role = RoleBaremetal
+	args.AccessKey = "SCWXXXXXXXXXXXXXXXXX"
+	args.SecretKey = "00000000-0000-0000-0000-000000000000"
+	args.RefreshInterval = 5 * time.Minute
+
+	converted := args.Convert()
+	require.Equal(t, "baremetal", string(converted.Role))
+	require.Equal(t, "fr-par-1", converted.Zone)
+	require.Equal(t, prom_common_config.Secret("00000000-0000-0000-0000-000000000000"), converted.SecretKey)
+	require.Equal(t, model.Duration(5*time.Minute), converted.RefreshInterval)
+	require.True(t, converted.HTTPClientConfig.FollowRedirects)
+}
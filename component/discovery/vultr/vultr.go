@@ -0,0 +1,70 @@
+package vultr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	prom_discovery "github.com/prometheus/prometheus/discovery/vultr"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "discovery.vultr",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the discovery.vultr component.
+type Arguments struct {
+	RefreshInterval  time.Duration           `river:"refresh_interval,attr,optional"`
+	Port             int                     `river:"port,attr,optional"`
+	HTTPClientConfig config.HTTPClientConfig `river:",squash"`
+}
+
+// DefaultArguments holds the default arguments of the discovery.vultr
+// component.
+var DefaultArguments = Arguments{
+	RefreshInterval:  time.Minute,
+	Port:             80,
+	HTTPClientConfig: config.DefaultHTTPClientConfig,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
+	return a.HTTPClientConfig.Validate()
+}
+
+// Convert converts Arguments to the Prometheus SD type.
+func (a *Arguments) Convert() *prom_discovery.SDConfig {
+	return &prom_discovery.SDConfig{
+		RefreshInterval:  model.Duration(a.RefreshInterval),
+		Port:             a.Port,
+		HTTPClientConfig: *a.HTTPClientConfig.Convert(),
+	}
+}
+
+// New returns a new instance of a discovery.vultr component.
+func New(opts component.Options, args Arguments) (component.Component, error) {
+	return discovery.New(opts, args, func(args component.Arguments) (discovery.Discoverer, error) {
+		newArgs := args.(Arguments)
+		return prom_discovery.NewDiscovery(newArgs.Convert(), opts.Logger)
+	})
+}
@@ -0,0 +1,59 @@
+package vultr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	prom_common_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	var exampleRiverConfig = `
+	refresh_interval = "5m"
+	port = 9100
+	bearer_token = "token"
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.NoError(t, err)
+
+	require.Equal(t, 5*time.Minute, args.RefreshInterval)
+	require.Equal(t, 9100, args.Port)
+	// Bearer tokens are converted to the authorization block on validation.
+	require.Equal(t, "Bearer", args.HTTPClientConfig.Authorization.Type)
+	require.Equal(t, "token", string(args.HTTPClientConfig.Authorization.Credentials))
+	require.True(t, args.HTTPClientConfig.FollowRedirects)
+}
+
+func TestBadRiverConfig(t *testing.T) {
+	var badConfigTooManyBearerTokens = `
+	bearer_token = "token"
+	bearer_token_file = "/path/to/file.token"
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(badConfigTooManyBearerTokens), &args)
+	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
+
+	var badConfigRefreshInterval = `
+	refresh_interval = "0s"
+	bearer_token = "token"
+	`
+	var args2 Arguments
+	err = river.Unmarshal([]byte(badConfigRefreshInterval), &args2)
+	require.ErrorContains(t, err, "refresh_interval must be greater than 0")
+}
+
+func TestConvert(t *testing.T) {
+	args := DefaultArguments
+	args.HTTPClientConfig.BearerToken = "token"
+
+	converted := args.Convert()
+	require.Equal(t, model.Duration(time.Minute), converted.RefreshInterval)
+	require.Equal(t, 80, converted.Port)
+	require.Equal(t, prom_common_config.Secret("token"), converted.HTTPClientConfig.BearerToken)
+}
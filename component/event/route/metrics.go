@@ -0,0 +1,40 @@
+package route
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	eventsRouted    *prometheus.CounterVec
+	eventsDropped   prometheus.CounterFunc
+	webhookFailures *prometheus.CounterVec
+}
+
+// newMetrics creates a new set of metrics. If reg is non-nil, the metrics
+// will also be registered. dropped returns the number of dropped events.
+func newMetrics(reg prometheus.Registerer, dropped func() float64) *metrics {
+	var m metrics
+
+	m.eventsRouted = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "event_route_events_routed_total",
+		Help: "Total number of events routed, by type",
+	}, []string{"type"})
+	m.eventsDropped = prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Name: "event_route_events_dropped_total",
+		Help: "Total number of events dropped because the buffer was full",
+	}, dropped)
+	m.webhookFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "event_route_webhook_failures_total",
+		Help: "Total number of events which failed to be sent to each webhook",
+	}, []string{"url"})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.eventsRouted,
+			m.eventsDropped,
+			m.webhookFailures,
+		)
+	}
+
+	return &m
+}
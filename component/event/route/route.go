@@ -0,0 +1,306 @@
+// Package route implements the event.route component.
+package route
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/events"
+	"github.com/grafana/loki/pkg/logproto"
+	prom_config "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "event.route",
+		Args: Arguments{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// eventTypeLabel is the label holding the type of the event of log entries.
+const eventTypeLabel = "event_type"
+
+// Arguments holds values which are used to configure the event.route
+// component.
+type Arguments struct {
+	// Patterns of the types and sources of the selected events. Empty lists
+	// select all events.
+	Types   []string `river:"types,attr,optional"`
+	Sources []string `river:"sources,attr,optional"`
+
+	// Where selected events are forwarded to as log entries.
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr,optional"`
+	// Labels set on log entries, along with the type of the event.
+	Labels map[string]string `river:"labels,attr,optional"`
+
+	// Webhooks selected events are sent to.
+	Webhooks []Webhook `river:"webhook,block,optional"`
+
+	// Number of events buffered while they're being forwarded.
+	BufferSize int `river:"buffer_size,attr,optional"`
+}
+
+// DefaultArguments holds the default settings of event.route.
+var DefaultArguments = Arguments{
+	BufferSize: 1000,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if len(a.ForwardTo) == 0 && len(a.Webhooks) == 0 {
+		return fmt.Errorf("at least one of forward_to or webhook must be set")
+	}
+	if a.BufferSize <= 0 {
+		return fmt.Errorf("buffer_size must be greater than 0")
+	}
+	if err := a.filter().Validate(); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	for name, value := range a.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+		if !model.LabelValue(value).IsValid() {
+			return fmt.Errorf("invalid value for label %q", name)
+		}
+	}
+	return nil
+}
+
+func (a *Arguments) filter() events.Filter {
+	return events.Filter{Types: a.Types, Sources: a.Sources}
+}
+
+// Webhook sends events as JSON in POST requests.
+type Webhook struct {
+	URL              string                   `river:"url,attr"`
+	Headers          map[string]string        `river:"headers,attr,optional"`
+	Timeout          time.Duration            `river:"timeout,attr,optional"`
+	HTTPClientConfig *config.HTTPClientConfig `river:",squash"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (w *Webhook) SetToDefault() {
+	*w = Webhook{
+		Timeout:          10 * time.Second,
+		HTTPClientConfig: config.CloneDefaultHTTPClientConfig(),
+	}
+}
+
+// Validate implements river.Validator.
+func (w *Webhook) Validate() error {
+	if w.URL == "" {
+		return fmt.Errorf("webhook url must not be empty")
+	}
+	if w.Timeout <= 0 {
+		return fmt.Errorf("webhook timeout must be greater than 0")
+	}
+	return w.HTTPClientConfig.Validate()
+}
+
+// webhook is a Webhook with its client.
+type webhook struct {
+	url     string
+	headers map[string]string
+	timeout time.Duration
+	client  *http.Client
+}
+
+// Component implements the event.route component.
+type Component struct {
+	opts    component.Options
+	metrics *metrics
+
+	updated chan struct{}
+
+	mut       sync.RWMutex
+	sub       *events.Subscription
+	forwardTo []loki.LogsReceiver
+	labels    model.LabelSet
+	webhooks  []*webhook
+
+	// Events dropped by closed subscriptions.
+	dropped atomic.Uint64
+}
+
+var (
+	_ component.Component = (*Component)(nil)
+)
+
+// New creates a new event.route component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	if o.Events == nil {
+		return nil, fmt.Errorf("event.route requires an event bus")
+	}
+
+	c := &Component{
+		opts:    o,
+		updated: make(chan struct{}, 1),
+	}
+	c.metrics = newMetrics(o.Registerer, c.droppedEvents)
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		c.sub.Close()
+	}()
+
+	for {
+		c.mut.RLock()
+		ch := c.sub.Events()
+		c.mut.RUnlock()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.updated:
+			// Receive from the new subscription.
+		case e := <-ch:
+			c.route(ctx, e)
+		}
+	}
+}
+
+// route forwards e as a log entry and sends it to webhooks.
+func (c *Component) route(ctx context.Context, e events.Event) {
+	c.metrics.eventsRouted.WithLabelValues(e.Type).Inc()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to encode event", "type", e.Type, "err", err)
+		return
+	}
+
+	c.mut.RLock()
+	var (
+		forwardTo = c.forwardTo
+		labels    = c.labels
+		webhooks  = c.webhooks
+	)
+	c.mut.RUnlock()
+
+	if len(forwardTo) > 0 {
+		entryLabels := labels.Clone()
+		entryLabels[eventTypeLabel] = model.LabelValue(e.Type)
+		entry := loki.Entry{
+			Labels: entryLabels,
+			Entry:  logproto.Entry{Timestamp: e.Timestamp, Line: string(line)},
+		}
+		for _, f := range forwardTo {
+			select {
+			case <-ctx.Done():
+				return
+			case f <- entry.Clone():
+			}
+		}
+	}
+
+	for _, w := range webhooks {
+		if err := w.send(ctx, line); err != nil {
+			c.metrics.webhookFailures.WithLabelValues(w.url).Inc()
+			level.Warn(c.opts.Logger).Log("msg", "failed to send event to webhook", "url", w.url, "type", e.Type, "err", err)
+		}
+	}
+}
+
+// send posts the JSON-encoded event to the webhook.
+func (w *webhook) send(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	webhooks := make([]*webhook, 0, len(newArgs.Webhooks))
+	for _, w := range newArgs.Webhooks {
+		client, err := prom_config.NewClientFromConfig(*w.HTTPClientConfig.Convert(), c.opts.ID)
+		if err != nil {
+			return fmt.Errorf("webhook %s: %w", w.URL, err)
+		}
+		webhooks = append(webhooks, &webhook{
+			url:     w.URL,
+			headers: w.Headers,
+			timeout: w.Timeout,
+			client:  client,
+		})
+	}
+
+	labels := make(model.LabelSet, len(newArgs.Labels))
+	for name, value := range newArgs.Labels {
+		labels[model.LabelName(name)] = model.LabelValue(value)
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.sub != nil {
+		c.dropped.Add(c.sub.Dropped())
+		c.sub.Close()
+	}
+	c.sub = c.opts.Events.Subscribe(newArgs.filter(), newArgs.BufferSize)
+	c.forwardTo = newArgs.ForwardTo
+	c.labels = labels
+	c.webhooks = webhooks
+
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// droppedEvents returns the number of events dropped because the buffer was
+// full.
+func (c *Component) droppedEvents() float64 {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return float64(c.dropped.Load() + c.sub.Dropped())
+}
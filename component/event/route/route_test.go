@@ -0,0 +1,113 @@
+package route
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/events"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	cfg := `
+		types      = ["endpoint.*"]
+		sources    = ["prometheus.scrape.*"]
+		forward_to = []
+		labels     = { job = "agent_events" }
+
+		webhook {
+			url     = "http://localhost:8080/hook"
+			headers = { "X-Token" = "secret" }
+			timeout = "5s"
+		}
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+	require.Equal(t, []string{"endpoint.*"}, args.Types)
+	require.Equal(t, 1000, args.BufferSize)
+	require.Len(t, args.Webhooks, 1)
+	require.Equal(t, 5*time.Second, args.Webhooks[0].Timeout)
+
+	require.ErrorContains(t, river.Unmarshal([]byte(`types = ["[a"]
+		webhook { url = "http://localhost" }`), &args), "invalid pattern")
+	require.ErrorContains(t, river.Unmarshal([]byte(`types = ["a"]`), &args), "at least one of forward_to or webhook")
+}
+
+func TestRoute(t *testing.T) {
+	received := make(chan events.Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "secret", r.Header.Get("X-Token"))
+
+		bb, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var e events.Event
+		require.NoError(t, json.Unmarshal(bb, &e))
+		received <- e
+	}))
+	defer srv.Close()
+
+	bus := events.NewBus()
+	logs := make(loki.LogsReceiver)
+
+	var args Arguments
+	args.SetToDefault()
+	args.Types = []string{"endpoint.*"}
+	args.ForwardTo = []loki.LogsReceiver{logs}
+	args.Labels = map[string]string{"job": "agent_events"}
+	var hook Webhook
+	hook.SetToDefault()
+	hook.URL = srv.URL
+	hook.Headers = map[string]string{"X-Token": "secret"}
+	args.Webhooks = []Webhook{hook}
+
+	c, err := New(component.Options{
+		ID:            "event.route.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		Events:        bus.Client("event.route.test"),
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	scrape := bus.Client("prometheus.scrape.default")
+	scrape.Publish(events.TypeTargetAppeared, "", nil)
+	scrape.Publish(events.TypeEndpointDown, "connection refused", map[string]string{"endpoint": "http://localhost:9090/metrics"})
+
+	select {
+	case entry := <-logs:
+		require.Equal(t, model.LabelSet{"job": "agent_events", "event_type": "endpoint.down"}, entry.Labels)
+
+		var e events.Event
+		require.NoError(t, json.Unmarshal([]byte(entry.Line), &e))
+		require.Equal(t, events.TypeEndpointDown, e.Type)
+		require.Equal(t, "prometheus.scrape.default", e.Source)
+		require.True(t, e.Timestamp.Equal(entry.Timestamp))
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no log entry received")
+	}
+
+	select {
+	case e := <-received:
+		require.Equal(t, events.TypeEndpointDown, e.Type)
+		require.Equal(t, "connection refused", e.Message)
+		require.Equal(t, map[string]string{"endpoint": "http://localhost:9090/metrics"}, e.Attributes)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no webhook request received")
+	}
+}
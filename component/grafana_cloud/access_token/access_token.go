@@ -0,0 +1,353 @@
+// Package access_token implements the grafana_cloud.access_token component.
+package access_token
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	common_config "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	prom_config "github.com/prometheus/common/config"
+)
+
+var userAgent = fmt.Sprintf("GrafanaAgent/%s", build.Version)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "grafana_cloud.access_token",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments control the grafana_cloud.access_token component.
+type Arguments struct {
+	APIURL            string            `river:"api_url,attr,optional"`
+	Region            string            `river:"region,attr"`
+	AccessPolicyID    string            `river:"access_policy_id,attr"`
+	AccessPolicyToken rivertypes.Secret `river:"access_policy_token,attr"`
+	RequiredScopes    []string          `river:"required_scopes,attr,optional"`
+
+	TokenTTL      time.Duration `river:"token_ttl,attr,optional"`
+	RefreshBefore time.Duration `river:"refresh_before,attr,optional"`
+	Timeout       time.Duration `river:"timeout,attr,optional"`
+
+	Client common_config.HTTPClientConfig `river:"client,block,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	APIURL:        "https://grafana.com/api",
+	TokenTTL:      1 * time.Hour,
+	RefreshBefore: 15 * time.Minute,
+	Timeout:       30 * time.Second,
+	Client:        common_config.DefaultHTTPClientConfig,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.APIURL == "" {
+		return fmt.Errorf("api_url must not be empty")
+	}
+	if args.Region == "" {
+		return fmt.Errorf("region must not be empty")
+	}
+	if args.AccessPolicyID == "" {
+		return fmt.Errorf("access_policy_id must not be empty")
+	}
+	if args.TokenTTL <= 0 {
+		return fmt.Errorf("token_ttl must be greater than 0")
+	}
+	if args.RefreshBefore <= 0 {
+		return fmt.Errorf("refresh_before must be greater than 0")
+	}
+	if args.RefreshBefore >= args.TokenTTL {
+		return fmt.Errorf("refresh_before must be less than token_ttl")
+	}
+	if args.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+
+	// The access policy token is always used to authenticate against the API.
+	cli := args.Client
+	if cli.BasicAuth != nil || cli.Authorization != nil || cli.OAuth2 != nil || cli.BearerToken != "" || cli.BearerTokenFile != "" {
+		return fmt.Errorf("the client block must not configure authentication; use access_policy_token instead")
+	}
+	return nil
+}
+
+// Exports holds settings exported by grafana_cloud.access_token.
+type Exports struct {
+	Token rivertypes.Secret `river:"token,attr"`
+}
+
+// retryInterval is how long to wait between failed refresh attempts.
+const retryInterval = 30 * time.Second
+
+// Component implements the grafana_cloud.access_token component.
+type Component struct {
+	log  log.Logger
+	opts component.Options
+
+	mut       sync.Mutex
+	args      Arguments
+	cli       *cloudClient
+	current   *token
+	policy    *accessPolicy
+	nextFetch time.Time
+
+	// updated is written to whenever args updates.
+	updated chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+	_ component.DebugComponent  = (*Component)(nil)
+)
+
+// New returns a new, unstarted, grafana_cloud.access_token component. It
+// immediately exchanges the access policy token and returns an error if the
+// exchange fails.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		log:  opts.Logger,
+		opts: opts,
+
+		updated: make(chan struct{}, 1),
+
+		health: component.Health{
+			Health:     component.HealthTypeUnknown,
+			Message:    "component started",
+			UpdateTime: time.Now(),
+		},
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run starts the grafana_cloud.access_token component, refreshing the token
+// before it expires.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(c.untilNextFetch()):
+			c.refresh(ctx)
+		case <-c.updated:
+			// no-op; force the next wait to be reread.
+		}
+	}
+}
+
+func (c *Component) untilNextFetch() time.Duration {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if wait := time.Until(c.nextFetch); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// refresh exchanges a new token and updates the health of the component.
+func (c *Component) refresh(ctx context.Context) {
+	startTime := time.Now()
+	err := c.exchange(ctx)
+
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+
+	if err == nil {
+		c.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "exchanged access token",
+			UpdateTime: startTime,
+		}
+	} else {
+		c.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("exchanging access token failed: %s", err),
+			UpdateTime: startTime,
+		}
+	}
+}
+
+// exchange checks the scopes of the access policy and creates a new token for
+// it, exporting the new token on success. c.mut must not be held when
+// calling.
+func (c *Component) exchange(ctx context.Context) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.args.Timeout)
+	defer cancel()
+
+	// Retry failed exchanges after a short while, but never later than the
+	// expiry of the token which is currently exported.
+	c.nextFetch = time.Now().Add(retryInterval)
+	if c.current != nil && c.current.ExpiresAt.Before(c.nextFetch) {
+		c.nextFetch = c.current.ExpiresAt
+	}
+
+	policy, err := c.cli.getAccessPolicy(ctx, c.args.AccessPolicyID)
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to retrieve access policy", "err", err)
+		return fmt.Errorf("retrieving access policy: %w", err)
+	}
+	if missing := missingScopes(policy.Scopes, c.args.RequiredScopes); len(missing) > 0 {
+		level.Error(c.log).Log("msg", "access policy is missing required scopes", "policy", policy.Name, "missing", strings.Join(missing, ","))
+		return fmt.Errorf("access policy %q is missing required scopes: %s", policy.Name, strings.Join(missing, ", "))
+	}
+
+	now := time.Now()
+	t, err := c.cli.createToken(ctx, createTokenRequest{
+		AccessPolicyID: c.args.AccessPolicyID,
+		Name:           tokenName(c.opts.ID, now),
+		DisplayName:    fmt.Sprintf("Grafana Agent %s", c.opts.ID),
+		ExpiresAt:      now.Add(c.args.TokenTTL).UTC().Truncate(time.Second),
+	})
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to create access token", "err", err)
+		return fmt.Errorf("creating access token: %w", err)
+	}
+	if t.ExpiresAt.IsZero() {
+		t.ExpiresAt = now.Add(c.args.TokenTTL)
+	}
+
+	level.Info(c.log).Log("msg", "exchanged access token", "policy", policy.Name, "expires_at", t.ExpiresAt)
+
+	c.policy = policy
+	c.current = t
+	c.nextFetch = t.ExpiresAt.Add(-c.args.RefreshBefore)
+	c.opts.OnStateChange(Exports{Token: rivertypes.Secret(t.Token)})
+	return nil
+}
+
+// Update updates the grafana_cloud.access_token component. A new token is
+// exchanged immediately if the arguments changed.
+func (c *Component) Update(args component.Arguments) (err error) {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	if c.current != nil && reflect.DeepEqual(c.args, newArgs) {
+		c.mut.Unlock()
+		return nil
+	}
+
+	httpClient, err := prom_config.NewClientFromConfig(
+		*newArgs.Client.Convert(),
+		c.opts.ID,
+		prom_config.WithUserAgent(userAgent),
+	)
+	if err != nil {
+		c.mut.Unlock()
+		return err
+	}
+
+	c.args = newArgs
+	c.cli = &cloudClient{
+		cli:       httpClient,
+		apiURL:    strings.TrimSuffix(newArgs.APIURL, "/"),
+		region:    newArgs.Region,
+		authToken: string(newArgs.AccessPolicyToken),
+	}
+	c.mut.Unlock()
+
+	// It's important to propagate the error in update so the initial state of
+	// the component is calculated correctly, otherwise the exports will be empty
+	// and may cause unexpected errors in downstream components.
+	c.refresh(context.Background())
+	if h := c.CurrentHealth(); h.Health == component.HealthTypeUnhealthy {
+		return fmt.Errorf("%s", h.Message)
+	}
+
+	// Send an updated event if one wasn't already read.
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// CurrentHealth returns the current health of the component.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
+
+// DebugInfo returns non-sensitive information about the current token.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var info debugInfo
+	if c.policy != nil {
+		info.AccessPolicy = c.policy.Name
+		info.Scopes = c.policy.Scopes
+	}
+	if c.current != nil {
+		info.TokenName = c.current.Name
+		info.ExpiresAt = c.current.ExpiresAt
+	}
+	info.NextRefresh = c.nextFetch
+	return info
+}
+
+type debugInfo struct {
+	AccessPolicy string    `river:"access_policy,attr,optional"`
+	Scopes       []string  `river:"scopes,attr,optional"`
+	TokenName    string    `river:"token_name,attr,optional"`
+	ExpiresAt    time.Time `river:"expires_at,attr,optional"`
+	NextRefresh  time.Time `river:"next_refresh,attr,optional"`
+}
+
+// missingScopes returns the scopes from required which are not in granted.
+func missingScopes(granted, required []string) []string {
+	set := make(map[string]struct{}, len(granted))
+	for _, s := range granted {
+		set[s] = struct{}{}
+	}
+
+	var missing []string
+	for _, s := range required {
+		if _, ok := set[s]; !ok {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+var invalidTokenNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// tokenName returns a unique token name for the component with the given ID.
+// Token names may only contain lowercase alphanumeric characters and dashes.
+func tokenName(componentID string, now time.Time) string {
+	id := invalidTokenNameChars.ReplaceAllString(strings.ToLower(componentID), "-")
+	return fmt.Sprintf("grafana-agent-%s-%d", strings.Trim(id, "-"), now.Unix())
+}
@@ -0,0 +1,193 @@
+package access_token
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCloudAPI implements the subset of the Grafana Cloud API used by the
+// component.
+type fakeCloudAPI struct {
+	t *testing.T
+
+	mut      sync.Mutex
+	scopes   []string
+	requests []createTokenRequest
+}
+
+func (f *fakeCloudAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer privileged" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	require.Equal(f.t, "us", r.URL.Query().Get("region"))
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/accesspolicies/policy-id":
+		_ = json.NewEncoder(w).Encode(accessPolicy{ID: "policy-id", Name: "agent-writers", Scopes: f.scopes})
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/tokens":
+		var req createTokenRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		f.requests = append(f.requests, req)
+		_ = json.NewEncoder(w).Encode(token{
+			ID:        fmt.Sprint(len(f.requests)),
+			Name:      req.Name,
+			ExpiresAt: req.ExpiresAt,
+			Token:     fmt.Sprintf("glc_%d", len(f.requests)),
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeCloudAPI) tokensCreated() int {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	return len(f.requests)
+}
+
+func testArgs(url string) Arguments {
+	args := DefaultArguments
+	args.APIURL = url
+	args.Region = "us"
+	args.AccessPolicyID = "policy-id"
+	args.AccessPolicyToken = "privileged"
+	args.RequiredScopes = []string{"metrics:write", "logs:write"}
+	return args
+}
+
+func TestComponent(t *testing.T) {
+	api := &fakeCloudAPI{t: t, scopes: []string{"metrics:write", "logs:write", "traces:write"}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	exports := make(chan component.Exports, 10)
+	opts := component.Options{
+		ID:            "grafana_cloud.access_token.default",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) { exports <- e },
+	}
+
+	args := testArgs(srv.URL)
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	select {
+	case e := <-exports:
+		require.Equal(t, Exports{Token: rivertypes.Secret("glc_1")}, e)
+	default:
+		require.FailNow(t, "expected the token to be exported by New")
+	}
+	require.Equal(t, component.HealthTypeHealthy, c.CurrentHealth().Health)
+
+	req := api.requests[0]
+	require.Equal(t, "policy-id", req.AccessPolicyID)
+	require.Regexp(t, "^grafana-agent-grafana-cloud-access-token-default-[0-9]+$", req.Name)
+	require.WithinDuration(t, time.Now().Add(time.Hour), req.ExpiresAt, 5*time.Second)
+
+	// Updating with the same arguments must not create a new token.
+	require.NoError(t, c.Update(args))
+	require.Equal(t, 1, api.tokensCreated())
+
+	// Changing the arguments exchanges a new token.
+	args.TokenTTL = 2 * time.Hour
+	require.NoError(t, c.Update(args))
+	require.Equal(t, 2, api.tokensCreated())
+	require.Equal(t, Exports{Token: rivertypes.Secret("glc_2")}, <-exports)
+}
+
+func TestComponent_Refresh(t *testing.T) {
+	api := &fakeCloudAPI{t: t, scopes: []string{"metrics:write", "logs:write"}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	opts := component.Options{
+		ID:            "grafana_cloud.access_token.default",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	args := testArgs(srv.URL)
+	args.TokenTTL = 2 * time.Second
+	args.RefreshBefore = 1900 * time.Millisecond
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		return api.tokensCreated() >= 3
+	}, 5*time.Second, 50*time.Millisecond, "token was never refreshed")
+}
+
+func TestComponent_MissingScopes(t *testing.T) {
+	api := &fakeCloudAPI{t: t, scopes: []string{"metrics:write"}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	opts := component.Options{
+		ID:            "grafana_cloud.access_token.default",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	_, err := New(opts, testArgs(srv.URL))
+	require.ErrorContains(t, err, `access policy "agent-writers" is missing required scopes: logs:write`)
+	require.Equal(t, 0, api.tokensCreated())
+}
+
+func TestComponent_Unauthorized(t *testing.T) {
+	api := &fakeCloudAPI{t: t}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	opts := component.Options{
+		ID:            "grafana_cloud.access_token.default",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	args := testArgs(srv.URL)
+	args.AccessPolicyToken = "wrong"
+	_, err := New(opts, args)
+	require.ErrorContains(t, err, "401 Unauthorized")
+	require.NotContains(t, err.Error(), "wrong")
+}
+
+func TestUnmarshalRiver(t *testing.T) {
+	cfg := `
+		region              = "prod-us-central-0"
+		access_policy_id    = "abc"
+		access_policy_token = "token"
+		required_scopes     = ["metrics:write"]
+		refresh_before      = "1h"
+	`
+	var args Arguments
+	require.ErrorContains(t, river.Unmarshal([]byte(cfg), &args), "refresh_before must be less than token_ttl")
+
+	cfg = `
+		region              = "prod-us-central-0"
+		access_policy_id    = "abc"
+		access_policy_token = "token"
+	`
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+	require.Equal(t, "https://grafana.com/api", args.APIURL)
+	require.Equal(t, time.Hour, args.TokenTTL)
+}
@@ -0,0 +1,115 @@
+package access_token
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// accessPolicy is the subset of a Grafana Cloud access policy used to check
+// scopes.
+type accessPolicy struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// createTokenRequest is the body sent to the Grafana Cloud API to create a
+// new token for an access policy.
+type createTokenRequest struct {
+	AccessPolicyID string    `json:"accessPolicyId"`
+	Name           string    `json:"name"`
+	DisplayName    string    `json:"displayName,omitempty"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// token is a token created by the Grafana Cloud API.
+type token struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Token     string    `json:"token"`
+}
+
+// cloudClient is a minimal client for the Grafana Cloud access policy API.
+type cloudClient struct {
+	cli       *http.Client
+	apiURL    string
+	region    string
+	authToken string
+}
+
+// getAccessPolicy retrieves the access policy with the given ID.
+func (c *cloudClient) getAccessPolicy(ctx context.Context, id string) (*accessPolicy, error) {
+	var policy accessPolicy
+	if err := c.do(ctx, http.MethodGet, "/v1/accesspolicies/"+url.PathEscape(id), nil, &policy); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// createToken creates a new token for an access policy.
+func (c *cloudClient) createToken(ctx context.Context, req createTokenRequest) (*token, error) {
+	var t token
+	if err := c.do(ctx, http.MethodPost, "/v1/tokens", req, &t); err != nil {
+		return nil, err
+	}
+	if t.Token == "" {
+		return nil, fmt.Errorf("response did not contain a token")
+	}
+	return &t, nil
+}
+
+func (c *cloudClient) do(ctx context.Context, method, path string, body, into any) error {
+	u, err := url.Parse(c.apiURL + path)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("region", c.region)
+	u.RawQuery = q.Encode()
+
+	var reqBody io.Reader
+	if body != nil {
+		bb, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(bb)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.authToken)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return fmt.Errorf("performing request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bb, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		// Never include the response body, it may echo back the credentials
+		// which were sent.
+		return fmt.Errorf("%s %s: unexpected status code %s", method, path, resp.Status)
+	}
+	if err := json.Unmarshal(bb, into); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+// Package file_match provides the local.file_match component.
+package file_match
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/local/file"
+)
+
+// eventSyncDelay holds the time to wait after a filesystem event before
+// matching files again. Events received in the meantime are coalesced, so that
+// event storms don't cause the files to be matched continuously.
+const eventSyncDelay = time.Second
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "local.file_match",
+		Args:    Arguments{},
+		Exports: discovery.Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the local.file_match
+// component.
+type Arguments struct {
+	// PathTargets holds the targets whose __path__ label is a glob of the files
+	// to match.
+	PathTargets []discovery.Target `river:"path_targets,attr"`
+	// PathExclude holds globs of files to exclude from every target.
+	PathExclude []string `river:"path_exclude,attr,optional"`
+	// Detector indicates how to detect that files were created or removed.
+	Detector file.Detector `river:"detector,attr,optional"`
+	// SyncPeriod determines how often files are matched. With the fsnotify
+	// detector, it's how often files are matched in case events were missed.
+	SyncPeriod time.Duration `river:"sync_period,attr,optional"`
+}
+
+// DefaultArguments provides the default arguments for the local.file_match
+// component.
+var DefaultArguments = Arguments{
+	Detector:   file.DetectorPoll,
+	SyncPeriod: 10 * time.Second,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.SyncPeriod <= 0 {
+		return fmt.Errorf("sync_period must be greater than 0")
+	}
+	for _, pattern := range a.PathExclude {
+		if _, err := doublestar.PathMatch(pattern, pattern); err != nil {
+			return fmt.Errorf("invalid path_exclude glob %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Component implements the local.file_match component.
+type Component struct {
+	opts component.Options
+
+	mut         sync.Mutex
+	args        Arguments
+	watcher     *fsnotify.Watcher // Only set with the fsnotify detector.
+	watchedDirs map[string]struct{}
+	exported    []discovery.Target
+
+	// syncCh is written to when files should be matched immediately, and
+	// eventCh when filesystem events were received.
+	syncCh  chan struct{}
+	eventCh chan struct{}
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new local.file_match component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts: o,
+
+		syncCh:  make(chan struct{}, 1),
+		eventCh: make(chan struct{}, 1),
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		c.stopWatcher()
+	}()
+
+	// Run may be called again after it returned, so the watcher stopped when
+	// it last returned must be recreated.
+	c.mut.Lock()
+	if err := c.configureWatcher(); err != nil {
+		c.mut.Unlock()
+		return err
+	}
+	c.sync()
+	syncPeriod := c.args.SyncPeriod
+	c.mut.Unlock()
+
+	syncTimer := time.NewTimer(syncPeriod)
+	defer syncTimer.Stop()
+
+	// eventTimer fires eventSyncDelay after the first event since files were
+	// last matched.
+	var eventTimer <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.eventCh:
+			if eventTimer == nil {
+				eventTimer = time.After(eventSyncDelay)
+			}
+			continue
+		case <-syncTimer.C:
+		case <-c.syncCh:
+		case <-eventTimer:
+		}
+
+		c.mut.Lock()
+		c.sync()
+		syncPeriod = c.args.SyncPeriod
+		c.mut.Unlock()
+
+		eventTimer = nil
+		if !syncTimer.Stop() {
+			select {
+			case <-syncTimer.C:
+			default:
+			}
+		}
+		syncTimer.Reset(syncPeriod)
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.args = newArgs
+
+	if err := c.configureWatcher(); err != nil {
+		return err
+	}
+
+	// Match files immediately so that the exports are up to date before
+	// components depending on them are evaluated.
+	c.sync()
+
+	select {
+	case c.syncCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// configureWatcher creates or stops the fsnotify watcher depending on the
+// detector. c.mut must be held.
+func (c *Component) configureWatcher() error {
+	if c.args.Detector != file.DetectorFSNotify {
+		c.stopWatcher()
+		return nil
+	} else if c.watcher != nil {
+		return nil
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create fsnotify watcher: %w", err)
+	}
+	c.watcher = w
+	c.watchedDirs = make(map[string]struct{})
+	go c.forwardEvents(w)
+	return nil
+}
+
+// forwardEvents notifies the component of the events of w until w is closed.
+func (c *Component) forwardEvents(w *fsnotify.Watcher) {
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			level.Debug(c.opts.Logger).Log("msg", "got fsnotify event", "name", ev.Name, "op", ev.Op.String())
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been lost, so match files again.
+			level.Warn(c.opts.Logger).Log("msg", "got error from fsnotify watcher", "err", err)
+		}
+
+		select {
+		case c.eventCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// stopWatcher closes the fsnotify watcher, if any. c.mut must be held.
+func (c *Component) stopWatcher() {
+	if c.watcher == nil {
+		return
+	}
+	if err := c.watcher.Close(); err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to close fsnotify watcher", "err", err)
+	}
+	c.watcher = nil
+	c.watchedDirs = nil
+}
+
+// sync matches files and exports their targets if they changed. c.mut must be
+// held.
+func (c *Component) sync() {
+	m := newMatcher(c.opts.Logger, c.args.PathExclude)
+	targets := m.match(c.args.PathTargets)
+
+	if c.watcher != nil {
+		c.watchDirs(m.dirs)
+	}
+
+	if c.exported != nil && reflect.DeepEqual(c.exported, targets) {
+		return
+	}
+	c.exported = targets
+	c.opts.OnStateChange(discovery.Exports{Targets: targets})
+}
+
+// watchDirs changes the directories watched by the fsnotify watcher to dirs.
+// c.mut must be held.
+func (c *Component) watchDirs(dirs map[string]struct{}) {
+	for dir := range c.watchedDirs {
+		if _, ok := dirs[dir]; !ok {
+			_ = c.watcher.Remove(dir)
+			delete(c.watchedDirs, dir)
+		}
+	}
+	for dir := range dirs {
+		if _, ok := c.watchedDirs[dir]; ok {
+			continue
+		}
+		if err := c.watcher.Add(dir); err != nil {
+			// The directory may not exist yet; it's watched once files are
+			// matched in it again.
+			level.Debug(c.opts.Logger).Log("msg", "failed to watch directory", "dir", dir, "err", err)
+			continue
+		}
+		c.watchedDirs[dir] = struct{}{}
+	}
+}
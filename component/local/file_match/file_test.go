@@ -0,0 +1,76 @@
+package file_match_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/local/file"
+	"github.com/grafana/agent/component/local/file_match"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMatch(t *testing.T) {
+	t.Run("Polling detector", func(t *testing.T) {
+		// Files created after the initial match are found on the next sync.
+		runFileMatchTest(t, file.DetectorPoll, 100*time.Millisecond)
+	})
+
+	t.Run("Event detector", func(t *testing.T) {
+		// Files created after the initial match are found from filesystem
+		// events, long before the next sync.
+		runFileMatchTest(t, file.DetectorFSNotify, time.Hour)
+	})
+}
+
+func runFileMatchTest(t *testing.T, detector file.Detector, syncPeriod time.Duration) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.log", "b.log", "debug/c.log", "d.txt"} {
+		writeFile(t, filepath.Join(dir, name))
+	}
+
+	tc, err := componenttest.NewControllerFromID(nil, "local.file_match")
+	require.NoError(t, err)
+	go func() {
+		err := tc.Run(componenttest.TestContext(t), file_match.Arguments{
+			PathTargets: []discovery.Target{{
+				"__path__":         filepath.Join(dir, "**", "*.log"),
+				"__path_exclude__": filepath.Join(dir, "b.log"),
+				"job":              "logs",
+			}},
+			PathExclude: []string{filepath.Join(dir, "debug", "*")},
+			Detector:    detector,
+			SyncPeriod:  syncPeriod,
+		})
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, tc.WaitExports(time.Second))
+	require.Equal(t, discovery.Exports{Targets: []discovery.Target{
+		{"__path__": filepath.Join(dir, "a.log"), "job": "logs"},
+	}}, tc.Exports())
+
+	// Wait for the component to start watching before creating files.
+	time.Sleep(100 * time.Millisecond)
+	writeFile(t, filepath.Join(dir, "e.log"))
+
+	require.NoError(t, tc.WaitExports(5*time.Second))
+	require.Equal(t, discovery.Exports{Targets: []discovery.Target{
+		{"__path__": filepath.Join(dir, "a.log"), "job": "logs"},
+		{"__path__": filepath.Join(dir, "e.log"), "job": "logs"},
+	}}, tc.Exports())
+}
+
+func TestArguments_Validate(t *testing.T) {
+	args := file_match.DefaultArguments
+	args.PathExclude = []string{"/var/log/[.log"}
+	require.ErrorContains(t, args.Validate(), "invalid path_exclude glob")
+}
+
+func writeFile(t *testing.T, path string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("hello"), 0644))
+}
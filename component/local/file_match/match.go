@@ -0,0 +1,111 @@
+package file_match
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/discovery"
+)
+
+const (
+	pathLabel        = "__path__"
+	pathExcludeLabel = "__path_exclude__"
+)
+
+// matcher matches the files of path targets.
+type matcher struct {
+	log     log.Logger
+	exclude []string
+
+	// dirs holds the directories files were matched in, along with the
+	// directories globs start from.
+	dirs map[string]struct{}
+}
+
+func newMatcher(l log.Logger, exclude []string) *matcher {
+	return &matcher{
+		log:     l,
+		exclude: exclude,
+		dirs:    make(map[string]struct{}),
+	}
+}
+
+// match returns a target for every regular file matched by the __path__ glob
+// of targets, with __path__ set to the path of the file. Files matched by the
+// __path_exclude__ glob of a target or by the globs excluded from every target
+// are skipped.
+func (m *matcher) match(targets []discovery.Target) []discovery.Target {
+	res := make([]discovery.Target, 0, len(targets))
+
+	for _, t := range targets {
+		pattern, ok := t[pathLabel]
+		if !ok || pattern == "" {
+			continue
+		}
+		m.dirs[globBase(pattern)] = struct{}{}
+
+		files, err := doublestar.Glob(pattern)
+		if err != nil {
+			level.Error(m.log).Log("msg", "failed to match files", "glob", pattern, "err", err)
+			continue
+		}
+		sort.Strings(files)
+
+		for _, path := range files {
+			if m.excluded(path, t[pathExcludeLabel]) {
+				continue
+			}
+			fi, err := os.Stat(path)
+			if err != nil || !fi.Mode().IsRegular() {
+				continue
+			}
+			m.dirs[filepath.Dir(path)] = struct{}{}
+
+			target := make(discovery.Target, len(t))
+			for k, v := range t {
+				if k != pathExcludeLabel {
+					target[k] = v
+				}
+			}
+			target[pathLabel] = path
+			res = append(res, target)
+		}
+	}
+	return res
+}
+
+// excluded returns whether path is matched by targetExclude or by one of the
+// globs excluded from every target.
+func (m *matcher) excluded(path string, targetExclude string) bool {
+	patterns := m.exclude
+	if targetExclude != "" {
+		patterns = append([]string{targetExclude}, patterns...)
+	}
+
+	for _, pattern := range patterns {
+		match, err := doublestar.PathMatch(pattern, path)
+		if err != nil {
+			level.Error(m.log).Log("msg", "invalid exclusion glob", "glob", pattern, "err", err)
+			continue
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// globBase returns the longest directory of pattern without glob
+// metacharacters.
+func globBase(pattern string) string {
+	idx := strings.IndexAny(pattern, "*?[{")
+	if idx < 0 {
+		return filepath.Dir(pattern)
+	}
+	return filepath.Dir(pattern[:idx+1])
+}
@@ -98,12 +98,13 @@ func newMatcherStage(logger log.Logger, jobName *string, config MatchConfig, reg
 	}
 
 	return &matcherStage{
-		dropReason: dropReason,
-		dropCount:  getDropCountMetric(registerer),
-		matchers:   selector.Matchers(),
-		stage:      pl,
-		action:     config.Action,
-		filter:     filter,
+		dropReason:     dropReason,
+		dropCount:      getDropCountMetric(registerer),
+		matchedEntries: getMatchedEntriesMetric(registerer).WithLabelValues(config.PipelineName, config.Action),
+		matchers:       selector.Matchers(),
+		stage:          pl,
+		action:         config.Action,
+		filter:         filter,
 	}, nil
 }
 
@@ -124,14 +125,23 @@ func getDropCountMetric(registerer prometheus.Registerer) *prometheus.CounterVec
 	return dropCount
 }
 
+// getMatchedEntriesMetric returns the counter of entries handled by match
+// stages, which reports which branch of the pipeline handled each entry.
+func getMatchedEntriesMetric(registerer prometheus.Registerer) *prometheus.CounterVec {
+	return registerCounterVec(registerer, "loki_process", "match_stage_matched_entries_total",
+		"A count of all log entries matched by a match stage, by pipeline name and action",
+		[]string{"pipeline_name", "action"})
+}
+
 // matcherStage applies Label matchers to determine if the include stages should be run
 type matcherStage struct {
-	dropReason string
-	dropCount  *prometheus.CounterVec
-	matchers   []*labels.Matcher
-	filter     logql.Filter
-	stage      Stage
-	action     string
+	dropReason     string
+	dropCount      *prometheus.CounterVec
+	matchedEntries prometheus.Counter
+	matchers       []*labels.Matcher
+	filter         logql.Filter
+	stage          Stage
+	action         string
 }
 
 func (m *matcherStage) Run(in chan Entry) chan Entry {
@@ -162,6 +172,7 @@ func (m *matcherStage) runKeep(in chan Entry) chan Entry {
 				out <- e
 				continue
 			}
+			m.matchedEntries.Inc()
 			next <- e
 		}
 	}()
@@ -177,6 +188,7 @@ func (m *matcherStage) runDrop(in chan Entry) chan Entry {
 				out <- e
 				continue
 			}
+			m.matchedEntries.Inc()
 			m.dropCount.WithLabelValues(m.dropReason).Inc()
 		}
 	}()
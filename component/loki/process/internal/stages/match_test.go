@@ -6,12 +6,15 @@ package stages
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/grafana/agent/pkg/util"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var testMatchRiver = `
@@ -93,6 +96,51 @@ func TestMatchStage(t *testing.T) {
 	close(in)
 }
 
+var testMatchMetricsRiver = `
+stage.match {
+		pipeline_name = "noisy"
+		selector      = "{app=\"noisy\"}"
+		action        = "drop"
+
+		drop_counter_reason = "discard_noisy"
+}
+
+stage.match {
+		pipeline_name = "api"
+		selector      = "{app=\"api\"}"
+		stage.static_labels {
+				values = { "team" = "backend" }
+		}
+}
+`
+
+func TestMatchStage_Metrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util.TestFlowLogger(t), loadConfig(testMatchMetricsRiver), nil, registry)
+	require.NoError(t, err)
+
+	entries := []Entry{
+		newEntry(nil, toLabelSet(map[string]string{"app": "noisy"}), "1", time.Now()),
+		newEntry(nil, toLabelSet(map[string]string{"app": "noisy"}), "2", time.Now()),
+		newEntry(nil, toLabelSet(map[string]string{"app": "api"}), "3", time.Now()),
+		newEntry(nil, toLabelSet(map[string]string{"app": "other"}), "4", time.Now()),
+	}
+	out := processEntries(pl, entries...)
+	require.Len(t, out, 2)
+
+	expect := `
+# HELP loki_process_dropped_lines_total A count of all log lines dropped as a result of a pipeline stage
+# TYPE loki_process_dropped_lines_total counter
+loki_process_dropped_lines_total{reason="discard_noisy"} 2
+# HELP loki_process_match_stage_matched_entries_total A count of all log entries matched by a match stage, by pipeline name and action
+# TYPE loki_process_match_stage_matched_entries_total counter
+loki_process_match_stage_matched_entries_total{action="drop",pipeline_name="noisy"} 2
+loki_process_match_stage_matched_entries_total{action="keep",pipeline_name="api"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expect),
+		"loki_process_dropped_lines_total", "loki_process_match_stage_matched_entries_total"))
+}
+
 func TestMatcher(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -194,6 +194,9 @@ loki_process_dropped_lines_total{reason="match_stage"} 1
 # HELP loki_process_custom_loki_count should only inc on non dropped labels
 # TYPE loki_process_custom_loki_count counter
 loki_process_custom_loki_count 1
+# HELP loki_process_match_stage_matched_entries_total A count of all log entries matched by a match stage, by pipeline name and action
+# TYPE loki_process_match_stage_matched_entries_total counter
+loki_process_match_stage_matched_entries_total{action="drop",pipeline_name=""} 1
 `
 
 func TestMetricsWithDropInPipeline(t *testing.T) {
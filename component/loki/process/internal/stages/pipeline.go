@@ -40,11 +40,13 @@ type StageConfig struct {
 	MatchConfig        *MatchConfig        `river:"match,block,optional"`
 	DropConfig         *DropConfig         `river:"drop,block,optional"`
 	PackConfig         *PackConfig         `river:"pack,block,optional"`
+	UnpackConfig       *UnpackConfig       `river:"unpack,block,optional"`
 	TemplateConfig     *TemplateConfig     `river:"template,block,optional"`
 	TenantConfig       *TenantConfig       `river:"tenant,block,optional"`
 	LimitConfig        *LimitConfig        `river:"limit,block,optional"`
 	MetricsConfig      *MetricsConfig      `river:"metrics,block,optional"`
 	GeoIPConfig        *GeoIPConfig        `river:"geoip,block,optional"`
+	SamplingConfig     *SamplingConfig     `river:"sampling,block,optional"`
 }
 
 var rateLimiter *rate.Limiter
@@ -196,3 +198,56 @@ func SetReadLineRateLimiter(rateVal float64, burstVal int, drop bool) {
 	rateLimiter = rate.NewLimiter(rate.Limit(rateVal), burstVal)
 	rateLimiterDrop = drop
 }
+
+// StageResult holds the entries output by a single stage of a pipeline.
+type StageResult struct {
+	Name    string
+	Entries []Entry
+}
+
+// Preview runs entries through each stage of the pipeline in turn and
+// returns the entries output by every stage, so that intermediate results can
+// be inspected. Entries dropped by a stage are missing from its output.
+//
+// Preview must not be called on a pipeline which is already running, as
+// stages keep state between entries.
+func (p *Pipeline) Preview(entries []loki.Entry) []StageResult {
+	current := make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		entry := Entry{Extracted: map[string]interface{}{}, Entry: e.Clone()}
+		for labelName, labelValue := range entry.Labels {
+			entry.Extracted[string(labelName)] = string(labelValue)
+		}
+		current = append(current, entry)
+	}
+
+	results := make([]StageResult, 0, len(p.stages))
+	for _, stage := range p.stages {
+		in := make(chan Entry)
+		out := stage.Run(in)
+		go func(entries []Entry) {
+			defer close(in)
+			for _, e := range entries {
+				in <- e
+			}
+		}(current)
+
+		var next []Entry
+		for e := range out {
+			next = append(next, e)
+		}
+
+		// Later stages modify entries in place, so each result holds a copy.
+		snapshot := make([]Entry, 0, len(next))
+		for _, e := range next {
+			extracted := make(map[string]interface{}, len(e.Extracted))
+			for k, v := range e.Extracted {
+				extracted[k] = v
+			}
+			snapshot = append(snapshot, Entry{Extracted: extracted, Entry: e.Clone()})
+		}
+		results = append(results, StageResult{Name: stage.Name(), Entries: snapshot})
+		current = next
+	}
+	return results
+}
@@ -0,0 +1,145 @@
+package stages
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// Configuration errors.
+var (
+	ErrSamplingStageInvalidRate   = errors.New("sampling stage `rate` must be between 0 and 1")
+	ErrSamplingStageConflictedKey = errors.New("sampling stage config error, `source` and `label` cannot both be defined at the same time")
+)
+
+var defaultSamplingReason = "sampling_stage"
+
+// SamplingConfig contains the configuration for a samplingStage.
+type SamplingConfig struct {
+	DropReason   string  `river:"drop_counter_reason,attr,optional"`
+	SamplingRate float64 `river:"rate,attr"`
+	Source       string  `river:"source,attr,optional"`
+	Label        string  `river:"label,attr,optional"`
+}
+
+func validateSamplingConfig(cfg *SamplingConfig) error {
+	if cfg.SamplingRate < 0 || cfg.SamplingRate > 1 || math.IsNaN(cfg.SamplingRate) {
+		return ErrSamplingStageInvalidRate
+	}
+	if cfg.Source != "" && cfg.Label != "" {
+		return ErrSamplingStageConflictedKey
+	}
+	if cfg.DropReason == "" {
+		cfg.DropReason = defaultSamplingReason
+	}
+	return nil
+}
+
+// newSamplingStage creates a samplingStage from config.
+func newSamplingStage(logger log.Logger, config SamplingConfig, registerer prometheus.Registerer) (Stage, error) {
+	err := validateSamplingConfig(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &samplingStage{
+		logger:    log.With(logger, "component", "stage", "type", "sampling"),
+		cfg:       &config,
+		boundary:  samplingBoundary(config.SamplingRate),
+		dropCount: getDropCountMetric(registerer),
+		rnd:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// samplingBoundary returns the value below which a uniformly distributed
+// uint64 is considered to be sampled for the given rate.
+func samplingBoundary(rate float64) uint64 {
+	if rate >= 1 {
+		return math.MaxUint64
+	}
+	return uint64(rate * math.MaxUint64)
+}
+
+// samplingStage forwards a configurable fraction of the entries it receives.
+//
+// When a sampling key is configured, the decision is derived from a hash of
+// the key's value so that all entries sharing the same value (for example, all
+// lines of a single request or trace) are either kept or dropped together.
+// Entries without the key are sampled randomly.
+type samplingStage struct {
+	logger    log.Logger
+	cfg       *SamplingConfig
+	boundary  uint64
+	dropCount *prometheus.CounterVec
+
+	rndMut sync.Mutex
+	rnd    *rand.Rand
+}
+
+func (m *samplingStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			if m.isSampled(e) {
+				out <- e
+				continue
+			}
+			m.dropCount.WithLabelValues(m.cfg.DropReason).Inc()
+		}
+	}()
+	return out
+}
+
+func (m *samplingStage) isSampled(e Entry) bool {
+	// Rates of 0 and 1 never need a sampling decision.
+	switch m.boundary {
+	case 0:
+		return false
+	case math.MaxUint64:
+		return true
+	}
+
+	if key, ok := m.samplingKey(e); ok {
+		return xxhash.Sum64String(key) < m.boundary
+	}
+
+	m.rndMut.Lock()
+	defer m.rndMut.Unlock()
+	return m.rnd.Uint64() < m.boundary
+}
+
+// samplingKey returns the value of the configured sampling key for e, if any.
+func (m *samplingStage) samplingKey(e Entry) (string, bool) {
+	switch {
+	case m.cfg.Label != "":
+		v, ok := e.Labels[model.LabelName(m.cfg.Label)]
+		return string(v), ok
+	case m.cfg.Source != "":
+		v, ok := e.Extracted[m.cfg.Source]
+		if !ok {
+			return "", false
+		}
+		s, err := getString(v)
+		if err != nil {
+			level.Debug(m.logger).Log("msg", "failed to convert extracted value to string, falling back to random sampling", "source", m.cfg.Source, "err", err)
+			return "", false
+		}
+		return s, true
+	default:
+		return "", false
+	}
+}
+
+// Name implements Stage
+func (m *samplingStage) Name() string {
+	return StageTypeSampling
+}
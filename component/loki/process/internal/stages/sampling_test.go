@@ -0,0 +1,160 @@
+package stages
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSamplingRiver = `
+stage.json {
+		expressions = { "trace_id" = "" }
+}
+
+stage.sampling {
+		rate   = 0.5
+		source = "trace_id"
+}
+`
+
+func TestSamplingPipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	pl, err := NewPipeline(util.TestFlowLogger(t), loadConfig(testSamplingRiver), &plName, registry)
+	require.NoError(t, err)
+
+	// All lines of a trace must share a sampling decision.
+	var entries []Entry
+	for trace := 0; trace < 100; trace++ {
+		for i := 0; i < 5; i++ {
+			line := fmt.Sprintf(`{"trace_id": "trace-%d", "msg": "line %d"}`, trace, i)
+			entries = append(entries, newEntry(nil, nil, line, time.Now()))
+		}
+	}
+	out := processEntries(pl, entries...)
+
+	perTrace := map[interface{}]int{}
+	for _, e := range out {
+		perTrace[e.Extracted["trace_id"]]++
+	}
+	for trace, count := range perTrace {
+		assert.Equal(t, 5, count, "trace %s was partially sampled", trace)
+	}
+	assert.InDelta(t, 50, len(perTrace), 20)
+}
+
+func TestSamplingStage(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    SamplingConfig
+		labels    model.LabelSet
+		extracted map[string]interface{}
+		entries   int
+		wantMin   int
+		wantMax   int
+	}{
+		{
+			name:    "rate of zero drops everything",
+			config:  SamplingConfig{SamplingRate: 0},
+			entries: 1000,
+			wantMin: 0,
+			wantMax: 0,
+		},
+		{
+			name:    "rate of one keeps everything",
+			config:  SamplingConfig{SamplingRate: 1},
+			entries: 1000,
+			wantMin: 1000,
+			wantMax: 1000,
+		},
+		{
+			name:    "random sampling",
+			config:  SamplingConfig{SamplingRate: 0.1},
+			entries: 10000,
+			wantMin: 800,
+			wantMax: 1200,
+		},
+		{
+			name:    "missing key falls back to random sampling",
+			config:  SamplingConfig{SamplingRate: 0.1, Label: "trace_id"},
+			labels:  model.LabelSet{"app": "loki"},
+			entries: 10000,
+			wantMin: 800,
+			wantMax: 1200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			s, err := newSamplingStage(util.TestFlowLogger(t), tt.config, registry)
+			require.NoError(t, err)
+
+			entries := make([]Entry, 0, tt.entries)
+			for i := 0; i < tt.entries; i++ {
+				entries = append(entries, newEntry(tt.extracted, tt.labels, "line", time.Now()))
+			}
+			out := processEntries(s, entries...)
+			assert.GreaterOrEqual(t, len(out), tt.wantMin)
+			assert.LessOrEqual(t, len(out), tt.wantMax)
+		})
+	}
+}
+
+func TestSamplingStage_ConsistentByLabel(t *testing.T) {
+	s, err := newSamplingStage(util.TestFlowLogger(t), SamplingConfig{SamplingRate: 0.5, Label: "request_id"}, prometheus.NewRegistry())
+	require.NoError(t, err)
+	ss := s.(*samplingStage)
+
+	for i := 0; i < 100; i++ {
+		lbls := model.LabelSet{"request_id": model.LabelValue(fmt.Sprintf("req-%d", i))}
+		first := ss.isSampled(newEntry(nil, lbls, "a", time.Now()))
+		for j := 0; j < 10; j++ {
+			require.Equal(t, first, ss.isSampled(newEntry(nil, lbls, "b", time.Now())))
+		}
+	}
+}
+
+func TestValidateSamplingConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SamplingConfig
+		wantErr error
+	}{
+		{
+			name:    "negative rate",
+			config:  SamplingConfig{SamplingRate: -0.1},
+			wantErr: ErrSamplingStageInvalidRate,
+		},
+		{
+			name:    "rate above one",
+			config:  SamplingConfig{SamplingRate: 1.5},
+			wantErr: ErrSamplingStageInvalidRate,
+		},
+		{
+			name:    "both source and label",
+			config:  SamplingConfig{SamplingRate: 0.5, Source: "trace_id", Label: "trace_id"},
+			wantErr: ErrSamplingStageConflictedKey,
+		},
+		{
+			name:   "valid",
+			config: SamplingConfig{SamplingRate: 0.5, Source: "trace_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSamplingConfig(&tt.config)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, defaultSamplingReason, tt.config.DropReason)
+		})
+	}
+}
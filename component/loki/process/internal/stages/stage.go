@@ -37,9 +37,11 @@ const (
 	StageTypeLimit        = "limit"
 	StageTypeMultiline    = "multiline"
 	StageTypePack         = "pack"
+	StageTypeUnpack       = "unpack"
 	StageTypeLabelAllow   = "labelallow"
 	StageTypeStaticLabels = "static_labels"
 	StageTypeGeoIP        = "geoip"
+	StageTypeSampling     = "sampling"
 )
 
 // Processor takes an existing set of labels, timestamp and log entry and returns either a possibly mutated
@@ -196,6 +198,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		}
 	case cfg.PackConfig != nil:
 		s = newPackStage(logger, *cfg.PackConfig, registerer)
+	case cfg.UnpackConfig != nil:
+		s, err = newUnpackStage(logger, *cfg.UnpackConfig)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.LabelAllowConfig != nil:
 		s, err = newLabelAllowStage(*cfg.LabelAllowConfig)
 		if err != nil {
@@ -216,6 +223,11 @@ func New(logger log.Logger, jobName *string, cfg StageConfig, registerer prometh
 		if err != nil {
 			return nil, err
 		}
+	case cfg.SamplingConfig != nil:
+		s, err = newSamplingStage(logger, *cfg.SamplingConfig, registerer)
+		if err != nil {
+			return nil, err
+		}
 
 	default:
 		panic("unreachable; should have decoded into one of the StageConfig fields")
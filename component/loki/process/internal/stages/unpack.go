@@ -0,0 +1,79 @@
+package stages
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/loki/pkg/logqlmodel"
+	json "github.com/json-iterator/go"
+	"github.com/prometheus/common/model"
+)
+
+// UnpackConfig contains the configuration for an unpackStage.
+type UnpackConfig struct {
+	Labels []string `river:"labels,attr,optional"`
+}
+
+// newUnpackStage creates an unpackStage from config.
+func newUnpackStage(logger log.Logger, config UnpackConfig) (Stage, error) {
+	labels := make(map[string]struct{}, len(config.Labels))
+	for _, l := range config.Labels {
+		if !model.LabelName(l).IsValid() {
+			return nil, fmt.Errorf(ErrInvalidLabelName, l)
+		}
+		labels[l] = struct{}{}
+	}
+
+	return toStage(&unpackStage{
+		logger: log.With(logger, "component", "stage", "type", "unpack"),
+		labels: labels,
+	}), nil
+}
+
+// unpackStage reverses the pack stage, replacing packed log lines with their
+// original entry and restoring the packed values as labels.
+type unpackStage struct {
+	logger log.Logger
+	labels map[string]struct{} // If empty, every packed value is restored.
+}
+
+// Process implements Stage.
+func (u *unpackStage) Process(labels model.LabelSet, extracted map[string]interface{}, _ *time.Time, entry *string) {
+	if entry == nil {
+		return
+	}
+
+	// Other JSON lines are passed through unchanged, as they weren't packed.
+	if json.Get([]byte(*entry), logqlmodel.PackedEntryKey).ValueType() != json.StringValue {
+		level.Debug(u.logger).Log("msg", "log line is not a packed entry, unpacking will be skipped")
+		return
+	}
+
+	var p Packed
+	if err := json.Unmarshal([]byte(*entry), &p); err != nil {
+		level.Debug(u.logger).Log("msg", "failed to unmarshal packed entry, unpacking will be skipped", "err", err)
+		return
+	}
+
+	for k, v := range p.Labels {
+		extracted[k] = v
+
+		if _, ok := u.labels[k]; len(u.labels) > 0 && !ok {
+			continue
+		}
+		lname, lvalue := model.LabelName(k), model.LabelValue(v)
+		if !lname.IsValid() || !lvalue.IsValid() {
+			level.Debug(u.logger).Log("msg", "invalid packed label, it will only be added to the extracted data", "label", k)
+			continue
+		}
+		labels[lname] = lvalue
+	}
+	*entry = p.Entry
+}
+
+// Name implements Stage.
+func (u *unpackStage) Name() string {
+	return StageTypeUnpack
+}
@@ -0,0 +1,118 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUnpackRiver = `
+stage.pack {
+		labels           = ["pod", "container"]
+		ingest_timestamp = false
+}
+stage.unpack {}`
+
+// TestUnpackPipeline verifies that unpacking a packed entry restores the
+// original line and labels.
+func TestUnpackPipeline(t *testing.T) {
+	plName := "test_unpack_pipeline"
+	pl, err := NewPipeline(util.TestFlowLogger(t), loadConfig(testUnpackRiver), &plName, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	lbls := model.LabelSet{
+		"pod":       "foo-xsfs3",
+		"container": "foo",
+		"namespace": "dev",
+	}
+	testTime := time.Now()
+
+	out := processEntries(pl, newEntry(nil, lbls.Clone(), testMatchLogLineApp1, testTime))[0]
+	assert.Equal(t, lbls, out.Labels)
+	assert.Equal(t, testMatchLogLineApp1, out.Line)
+	assert.Equal(t, testTime, out.Timestamp)
+}
+
+func TestUnpackStage(t *testing.T) {
+	tests := []struct {
+		name              string
+		config            UnpackConfig
+		line              string
+		expectedLine      string
+		expectedLabels    model.LabelSet
+		expectedExtracted map[string]interface{}
+	}{
+		{
+			name:              "unpack all values",
+			line:              `{"pod":"foo","container":"bar","_entry":"test line"}`,
+			expectedLine:      "test line",
+			expectedLabels:    model.LabelSet{"app": "test", "pod": "foo", "container": "bar"},
+			expectedExtracted: map[string]interface{}{"pod": "foo", "container": "bar"},
+		},
+		{
+			name:              "unpack selected labels",
+			config:            UnpackConfig{Labels: []string{"pod"}},
+			line:              `{"pod":"foo","container":"bar","_entry":"test line"}`,
+			expectedLine:      "test line",
+			expectedLabels:    model.LabelSet{"app": "test", "pod": "foo"},
+			expectedExtracted: map[string]interface{}{"pod": "foo", "container": "bar"},
+		},
+		{
+			name:              "invalid label names are only extracted",
+			line:              `{"trace.id":"1234","_entry":"test line"}`,
+			expectedLine:      "test line",
+			expectedLabels:    model.LabelSet{"app": "test"},
+			expectedExtracted: map[string]interface{}{"trace.id": "1234"},
+		},
+		{
+			name:              "unpacked entry is JSON",
+			line:              `{"pod":"foo","_entry":"{\"msg\":\"hello\"}"}`,
+			expectedLine:      `{"msg":"hello"}`,
+			expectedLabels:    model.LabelSet{"app": "test", "pod": "foo"},
+			expectedExtracted: map[string]interface{}{"pod": "foo"},
+		},
+		{
+			name:              "JSON line without entry key",
+			line:              `{"pod":"foo","msg":"test line"}`,
+			expectedLine:      `{"pod":"foo","msg":"test line"}`,
+			expectedLabels:    model.LabelSet{"app": "test"},
+			expectedExtracted: map[string]interface{}{},
+		},
+		{
+			name:              "non-string values",
+			line:              `{"pod":1,"_entry":"test line"}`,
+			expectedLine:      `{"pod":1,"_entry":"test line"}`,
+			expectedLabels:    model.LabelSet{"app": "test"},
+			expectedExtracted: map[string]interface{}{},
+		},
+		{
+			name:              "plain line",
+			line:              "test line",
+			expectedLine:      "test line",
+			expectedLabels:    model.LabelSet{"app": "test"},
+			expectedExtracted: map[string]interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st, err := newUnpackStage(util.TestFlowLogger(t), tt.config)
+			require.NoError(t, err)
+
+			out := processEntries(st, newEntry(nil, model.LabelSet{"app": "test"}, tt.line, time.Unix(1, 0)))[0]
+			assert.Equal(t, tt.expectedLine, out.Line)
+			assert.Equal(t, tt.expectedLabels, out.Labels)
+			assert.Equal(t, tt.expectedExtracted, out.Extracted)
+		})
+	}
+}
+
+func TestUnpackStage_InvalidConfig(t *testing.T) {
+	_, err := newUnpackStage(util.TestFlowLogger(t), UnpackConfig{Labels: []string{"not.valid"}})
+	require.EqualError(t, err, `invalid label name: not.valid`)
+}
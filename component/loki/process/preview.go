@@ -0,0 +1,185 @@
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/process/internal/stages"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+const (
+	// maxPreviewEntries is the maximum number of entries a preview request can
+	// hold.
+	maxPreviewEntries = 1000
+
+	// maxPreviewBodySize is the maximum size of the body of a preview request.
+	maxPreviewBodySize = 4 << 20
+)
+
+// PreviewRequest is the JSON body of a preview request.
+type PreviewRequest struct {
+	Entries []PreviewEntry `json:"entries"`
+}
+
+// PreviewEntry is a log entry sent to or output by a stage of a preview.
+type PreviewEntry struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Line      string                 `json:"line"`
+	Labels    map[string]string      `json:"labels,omitempty"`
+	Extracted map[string]interface{} `json:"extracted,omitempty"`
+}
+
+// PreviewStage holds the entries output by a stage of a preview.
+type PreviewStage struct {
+	Index   int            `json:"index"`
+	Name    string         `json:"name"`
+	Entries []PreviewEntry `json:"entries"`
+}
+
+// PreviewResponse is the result of a preview.
+type PreviewResponse struct {
+	Stages []PreviewStage `json:"stages"`
+	Output []PreviewEntry `json:"output"`
+}
+
+// Handler implements component.HTTPComponent. It serves /preview, which runs
+// sample log entries through the stages of the component and returns the
+// entries output by each stage. Previews use a separate copy of the pipeline,
+// so they don't affect the entries processed by the component or its
+// metrics.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/preview", c.handlePreview)
+	return mux
+}
+
+func (c *Component) handlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries, err := readPreviewEntries(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := c.preview(entries)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(res)
+}
+
+// readPreviewEntries reads the entries of a preview request. JSON bodies
+// hold a PreviewRequest; other bodies hold one log line per line, such as the
+// content of a sample log file.
+func readPreviewEntries(r *http.Request) ([]loki.Entry, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPreviewBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxPreviewBodySize {
+		return nil, fmt.Errorf("request body is larger than %d bytes", maxPreviewBodySize)
+	}
+
+	var entries []loki.Entry
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req PreviewRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, fmt.Errorf("invalid preview request: %w", err)
+		}
+		for _, e := range req.Entries {
+			ts := e.Timestamp
+			if ts.IsZero() {
+				ts = time.Now()
+			}
+			labels := make(model.LabelSet, len(e.Labels))
+			for k, v := range e.Labels {
+				labels[model.LabelName(k)] = model.LabelValue(v)
+			}
+			entries = append(entries, loki.Entry{
+				Labels: labels,
+				Entry:  logproto.Entry{Timestamp: ts, Line: e.Line},
+			})
+		}
+	} else {
+		sc := bufio.NewScanner(bytes.NewReader(body))
+		sc.Buffer(nil, maxPreviewBodySize)
+		now := time.Now()
+		for sc.Scan() {
+			entries = append(entries, loki.Entry{
+				Labels: model.LabelSet{},
+				Entry:  logproto.Entry{Timestamp: now, Line: sc.Text()},
+			})
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(entries) > maxPreviewEntries {
+		return nil, fmt.Errorf("preview requests can hold at most %d entries, got %d", maxPreviewEntries, len(entries))
+	}
+	return entries, nil
+}
+
+// preview runs entries through a new pipeline built from the current stages
+// of the component.
+func (c *Component) preview(entries []loki.Entry) (*PreviewResponse, error) {
+	c.mut.RLock()
+	stageConfigs := c.stages
+	c.mut.RUnlock()
+
+	pipeline, err := stages.NewPipeline(c.opts.Logger, stageConfigs, &c.opts.ID, prometheus.NewRegistry())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &PreviewResponse{
+		Stages: []PreviewStage{},
+		Output: make([]PreviewEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		res.Output = append(res.Output, toPreviewEntry(stages.Entry{Entry: e}))
+	}
+
+	for i, result := range pipeline.Preview(entries) {
+		stage := PreviewStage{Index: i, Name: result.Name, Entries: make([]PreviewEntry, 0, len(result.Entries))}
+		for _, e := range result.Entries {
+			stage.Entries = append(stage.Entries, toPreviewEntry(e))
+		}
+		res.Stages = append(res.Stages, stage)
+		res.Output = stage.Entries
+	}
+	return res, nil
+}
+
+func toPreviewEntry(e stages.Entry) PreviewEntry {
+	labels := make(map[string]string, len(e.Labels))
+	for k, v := range e.Labels {
+		labels[string(k)] = string(v)
+	}
+	return PreviewEntry{
+		Timestamp: e.Timestamp,
+		Line:      e.Line,
+		Labels:    labels,
+		Extracted: e.Extracted,
+	}
+}
@@ -0,0 +1,117 @@
+package process
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/process/internal/stages"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func newPreviewComponent(t *testing.T, stg string) *Component {
+	t.Helper()
+
+	type cfg struct {
+		Stages []stages.StageConfig `river:"stage,enum"`
+	}
+	var stagesCfg cfg
+	require.NoError(t, river.Unmarshal([]byte(stg), &stagesCfg))
+
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+	c, err := New(opts, Arguments{
+		ForwardTo: []loki.LogsReceiver{make(loki.LogsReceiver)},
+		Stages:    stagesCfg.Stages,
+	})
+	require.NoError(t, err)
+
+	// The component isn't run, so its pipeline must be stopped explicitly.
+	t.Cleanup(func() { close(c.processIn) })
+	return c
+}
+
+func TestPreview(t *testing.T) {
+	c := newPreviewComponent(t, `
+		stage.logfmt {
+			mapping = { "level" = "" }
+		}
+		stage.labels {
+			values = { "level" = "" }
+		}
+		stage.drop {
+			source = "level"
+			value  = "debug"
+		}`)
+
+	body := `{"entries": [
+		{"line": "level=info msg=hello", "labels": {"job": "test"}},
+		{"line": "level=debug msg=noisy"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res PreviewResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	require.Len(t, res.Stages, 3)
+	require.Equal(t, "logfmt", res.Stages[0].Name)
+	require.Len(t, res.Stages[0].Entries, 2)
+	require.Equal(t, "info", res.Stages[0].Entries[0].Extracted["level"])
+	require.Equal(t, "test", res.Stages[0].Entries[0].Extracted["job"])
+	require.Empty(t, res.Stages[0].Entries[0].Labels["level"], "later stages must not modify earlier results")
+
+	require.Equal(t, map[string]string{"job": "test", "level": "info"}, res.Stages[1].Entries[0].Labels)
+
+	// The debug entry is dropped by the last stage.
+	require.Len(t, res.Stages[2].Entries, 1)
+	require.Equal(t, res.Stages[2].Entries, res.Output)
+	require.Equal(t, "level=info msg=hello", res.Output[0].Line)
+}
+
+func TestPreview_SampleFile(t *testing.T) {
+	c := newPreviewComponent(t, `
+		stage.replace {
+			expression = "(secret=\\S+)"
+			replace    = "secret=<redacted>"
+		}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader("first secret=a\nsecond\n"))
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res PreviewResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.Output, 2)
+	require.Equal(t, "first secret=<redacted>", res.Output[0].Line)
+	require.Equal(t, "second", res.Output[1].Line)
+}
+
+func TestPreview_InvalidRequest(t *testing.T) {
+	c := newPreviewComponent(t, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/preview", nil)
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/preview", strings.NewReader("{"))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
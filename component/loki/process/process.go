@@ -4,8 +4,10 @@ import (
 	"context"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/livedebugging"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/loki/process/internal/stages"
 )
@@ -35,12 +37,15 @@ type Exports struct {
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component              = (*Component)(nil)
+	_ component.HTTPComponent          = (*Component)(nil)
+	_ component.LiveDebuggingComponent = (*Component)(nil)
 )
 
 // Component implements the loki.process component.
 type Component struct {
-	opts component.Options
+	opts  component.Options
+	debug *livedebugging.Hub
 
 	mut          sync.RWMutex
 	receiver     loki.LogsReceiver
@@ -53,8 +58,13 @@ type Component struct {
 
 // New creates a new loki.process component.
 func New(o component.Options, args Arguments) (*Component, error) {
+	debug, err := livedebugging.NewHub(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
 	c := &Component{
-		opts: o,
+		opts:  o,
+		debug: debug,
 	}
 
 	// Create and immediately export the receiver which remains the same for
@@ -150,6 +160,10 @@ func (c *Component) handleOut(ctx context.Context, wg *sync.WaitGroup) {
 		case <-ctx.Done():
 			return
 		case entry := <-c.processOut:
+			if c.debug.Active() {
+				c.debug.Publish("log_entry", func() interface{} { return newLogEntrySample(entry) })
+			}
+
 			c.mut.RLock()
 			for _, f := range c.fanout {
 				select {
@@ -164,6 +178,25 @@ func (c *Component) handleOut(ctx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
+// LiveDebugging implements component.LiveDebuggingComponent. Entries are
+// published as they leave the pipeline.
+func (c *Component) LiveDebugging() *livedebugging.Hub { return c.debug }
+
+// logEntrySample is a log entry published for live debugging.
+type logEntrySample struct {
+	Labels    string    `json:"labels"`
+	Timestamp time.Time `json:"timestamp"`
+	Line      string    `json:"line"`
+}
+
+func newLogEntrySample(e loki.Entry) logEntrySample {
+	return logEntrySample{
+		Labels:    e.Labels.String(),
+		Timestamp: e.Timestamp,
+		Line:      e.Line,
+	}
+}
+
 func stagesChanged(prev, next []stages.StageConfig) bool {
 	if len(prev) != len(next) {
 		return true
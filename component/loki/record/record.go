@@ -0,0 +1,120 @@
+package record
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/recorder"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "loki.record",
+		Args:    Arguments{},
+		Exports: Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the loki.record
+// component.
+type Arguments struct {
+	Recorder recorder.Arguments `river:",squash"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	args.Recorder.SetToDefault()
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	return args.Recorder.Validate()
+}
+
+// Exports holds the values exported by the loki.record component.
+type Exports struct {
+	Receiver loki.LogsReceiver `river:"receiver,attr"`
+}
+
+// Entry is a recorded log entry.
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Labels    string    `json:"labels"`
+	Line      string    `json:"line"`
+}
+
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.HTTPComponent = (*Component)(nil)
+)
+
+// Component implements the loki.record component.
+type Component struct {
+	opts     component.Options
+	receiver loki.LogsReceiver
+	recorder *recorder.Recorder
+}
+
+// New creates a new loki.record component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	rec, err := recorder.New(o.Logger, o.Registerer, o.DataPath, args.Recorder)
+	if err != nil {
+		return nil, err
+	}
+	c := &Component{
+		opts:     o,
+		receiver: make(loki.LogsReceiver),
+		recorder: rec,
+	}
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.recorder.Run(ctx)
+	}()
+	defer func() { <-done }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver:
+			err := c.recorder.Record(time.Now(), Entry{
+				Timestamp: entry.Timestamp,
+				Labels:    entry.Labels.String(),
+				Line:      entry.Line,
+			})
+			if err != nil {
+				level.Warn(c.opts.Logger).Log("msg", "failed to record log entry", "err", err)
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.recorder.ApplyConfig(args.(Arguments).Recorder)
+	return nil
+}
+
+// Handler implements component.HTTPComponent. It serves /export, which
+// exports the entries recorded in a time window.
+func (c *Component) Handler() http.Handler {
+	return c.recorder.Handler()
+}
@@ -68,6 +68,10 @@ type Component struct {
 
 	cache        *lru.Cache
 	maxCacheSize int
+
+	// Deduplicates relabeled label sets, as many input label sets usually
+	// relabel to the same output.
+	labelSets *loki.LabelSetInterner
 }
 
 var (
@@ -86,6 +90,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		metrics:      newMetrics(o.Registerer),
 		cache:        cache,
 		maxCacheSize: args.MaxCacheSize,
+		labelSets:    loki.NewLabelSetInterner(args.MaxCacheSize),
 	}
 
 	// Create and immediately export the receiver which remains the same for
@@ -219,10 +224,5 @@ func (c *Component) process(e loki.Entry) model.LabelSet {
 		})
 	}
 	lbls, _ = relabel.Process(lbls, c.rcs...)
-
-	relabeled := make(model.LabelSet, len(lbls))
-	for i := range lbls {
-		relabeled[model.LabelName(lbls[i].Name)] = model.LabelValue(lbls[i].Value)
-	}
-	return relabeled
+	return c.labelSets.FromLabels(lbls)
 }
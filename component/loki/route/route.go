@@ -0,0 +1,212 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "loki.route",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// defaultRouteName is the name of the default route in metrics.
+const defaultRouteName = "default"
+
+// Arguments holds values which are used to configure the loki.route
+// component.
+type Arguments struct {
+	// The routes log entries are matched against, in order.
+	Routes []Route `river:"route,block,optional"`
+
+	// Whether log entries are only forwarded to the first matching route.
+	MatchOnce bool `river:"match_once,attr,optional"`
+
+	// Where log entries which match no route are forwarded to. Such entries
+	// are dropped if it's empty.
+	DefaultForwardTo []loki.LogsReceiver `river:"default_forward_to,attr,optional"`
+}
+
+// Route forwards log entries whose labels match Selector.
+type Route struct {
+	// The name of the route, used in metrics.
+	Name string `river:"name,attr"`
+
+	// The label matchers log entries must match, such as {team="a"}.
+	Selector string `river:"selector,attr"`
+
+	// Where matching log entries are forwarded to.
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if len(a.Routes) == 0 {
+		return fmt.Errorf("at least one route must be configured")
+	}
+
+	names := make(map[string]struct{}, len(a.Routes))
+	for i, r := range a.Routes {
+		switch _, dup := names[r.Name]; {
+		case r.Name == "":
+			return fmt.Errorf("route %d: name must not be empty", i)
+		case r.Name == defaultRouteName:
+			return fmt.Errorf("route %d: name %q is reserved for the default route", i, defaultRouteName)
+		case dup:
+			return fmt.Errorf("route %d: duplicate route name %q", i, r.Name)
+		}
+		names[r.Name] = struct{}{}
+
+		if _, err := parser.ParseMetricSelector(r.Selector); err != nil {
+			return fmt.Errorf("route %q: invalid selector: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// Exports holds values which are exported by the loki.route component.
+type Exports struct {
+	Receiver loki.LogsReceiver `river:"receiver,attr"`
+}
+
+// route is a parsed Route.
+type route struct {
+	name      string
+	matchers  []*labels.Matcher
+	forwardTo []loki.LogsReceiver
+}
+
+// matches returns true if lbls match all the matchers of the route.
+func (r *route) matches(lbls model.LabelSet) bool {
+	for _, m := range r.matchers {
+		if !m.Matches(string(lbls[model.LabelName(m.Name)])) {
+			return false
+		}
+	}
+	return true
+}
+
+// Component implements the loki.route component.
+type Component struct {
+	opts    component.Options
+	metrics *metrics
+
+	mut          sync.RWMutex
+	routes       []*route
+	matchOnce    bool
+	defaultRoute *route
+	receiver     loki.LogsReceiver
+}
+
+var (
+	_ component.Component = (*Component)(nil)
+)
+
+// New creates a new loki.route component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		metrics: newMetrics(o.Registerer),
+	}
+
+	// Create and immediately export the receiver which remains the same for
+	// the component's lifetime.
+	c.receiver = make(loki.LogsReceiver)
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver:
+			c.metrics.entriesProcessed.Inc()
+
+			c.mut.RLock()
+			matched := c.match(entry.Labels)
+			c.mut.RUnlock()
+
+			if len(matched) == 0 {
+				level.Debug(c.opts.Logger).Log("msg", "dropping entry which matched no route", "labels", entry.Labels.String())
+				c.metrics.entriesDropped.Inc()
+				continue
+			}
+
+			for _, r := range matched {
+				c.metrics.entriesRouted.WithLabelValues(r.name).Inc()
+				for _, f := range r.forwardTo {
+					select {
+					case <-ctx.Done():
+						return nil
+					case f <- entry:
+					}
+				}
+			}
+		}
+	}
+}
+
+// match returns the routes log entries with the given labels are forwarded
+// to. c.mut must be held.
+func (c *Component) match(lbls model.LabelSet) []*route {
+	var matched []*route
+	for _, r := range c.routes {
+		if r.matches(lbls) {
+			matched = append(matched, r)
+			if c.matchOnce {
+				break
+			}
+		}
+	}
+	if len(matched) == 0 && len(c.defaultRoute.forwardTo) > 0 {
+		matched = append(matched, c.defaultRoute)
+	}
+	return matched
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	routes := make([]*route, 0, len(newArgs.Routes))
+	for _, r := range newArgs.Routes {
+		matchers, err := parser.ParseMetricSelector(r.Selector)
+		if err != nil {
+			return fmt.Errorf("route %q: invalid selector: %w", r.Name, err)
+		}
+		routes = append(routes, &route{
+			name:      r.Name,
+			matchers:  matchers,
+			forwardTo: r.ForwardTo,
+		})
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.routes = routes
+	c.matchOnce = newArgs.MatchOnce
+	c.defaultRoute = &route{name: defaultRouteName, forwardTo: newArgs.DefaultForwardTo}
+	return nil
+}
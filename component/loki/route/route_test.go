@@ -0,0 +1,211 @@
+package route
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "routes",
+			cfg: `
+				route {
+					name       = "team_a"
+					selector   = "{team=\"a\", env=~\"prod|staging\"}"
+					forward_to = []
+				}
+				default_forward_to = []
+			`,
+		},
+		{
+			name:        "no routes",
+			cfg:         `default_forward_to = []`,
+			expectedErr: "at least one route must be configured",
+		},
+		{
+			name: "duplicate name",
+			cfg: `
+				route {
+					name       = "team_a"
+					selector   = "{team=\"a\"}"
+					forward_to = []
+				}
+				route {
+					name       = "team_a"
+					selector   = "{team=\"b\"}"
+					forward_to = []
+				}
+			`,
+			expectedErr: `route 1: duplicate route name "team_a"`,
+		},
+		{
+			name: "reserved name",
+			cfg: `
+				route {
+					name       = "default"
+					selector   = "{team=\"a\"}"
+					forward_to = []
+				}
+			`,
+			expectedErr: `route 0: name "default" is reserved for the default route`,
+		},
+		{
+			name: "invalid selector",
+			cfg: `
+				route {
+					name       = "team_a"
+					selector   = "team=a"
+					forward_to = []
+				}
+			`,
+			expectedErr: `route "team_a": invalid selector: `,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		matchOnce bool
+		expected  map[string][]string // Lines received by each receiver.
+	}{
+		{
+			name: "all matching routes",
+			expected: map[string][]string{
+				"team_a":  {"a-prod", "a-dev"},
+				"prod":    {"a-prod", "b-prod"},
+				"default": {"c-dev"},
+			},
+		},
+		{
+			name:      "first matching route",
+			matchOnce: true,
+			expected: map[string][]string{
+				"team_a":  {"a-prod", "a-dev"},
+				"prod":    {"b-prod"},
+				"default": {"c-dev"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			receivers := map[string]loki.LogsReceiver{
+				"team_a":  make(loki.LogsReceiver),
+				"prod":    make(loki.LogsReceiver),
+				"default": make(loki.LogsReceiver),
+			}
+
+			reg := prometheus.NewRegistry()
+			opts := component.Options{
+				Logger:        util.TestFlowLogger(t),
+				Registerer:    reg,
+				OnStateChange: func(e component.Exports) {},
+			}
+			args := Arguments{
+				Routes: []Route{
+					{Name: "team_a", Selector: `{team="a"}`, ForwardTo: []loki.LogsReceiver{receivers["team_a"]}},
+					{Name: "prod", Selector: `{env="prod"}`, ForwardTo: []loki.LogsReceiver{receivers["prod"]}},
+				},
+				MatchOnce:        tc.matchOnce,
+				DefaultForwardTo: []loki.LogsReceiver{receivers["default"]},
+			}
+
+			c, err := New(opts, args)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go c.Run(ctx)
+
+			received := make(map[string][]string)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for {
+					select {
+					case e := <-receivers["team_a"]:
+						received["team_a"] = append(received["team_a"], e.Line)
+					case e := <-receivers["prod"]:
+						received["prod"] = append(received["prod"], e.Line)
+					case e := <-receivers["default"]:
+						received["default"] = append(received["default"], e.Line)
+					case <-time.After(100 * time.Millisecond):
+						return
+					}
+				}
+			}()
+
+			for _, lbls := range []model.LabelSet{
+				{"team": "a", "env": "prod"},
+				{"team": "b", "env": "prod"},
+				{"team": "a", "env": "dev"},
+				{"team": "c", "env": "dev"},
+			} {
+				c.receiver <- loki.Entry{
+					Labels: lbls,
+					Entry: logproto.Entry{
+						Timestamp: time.Now(),
+						Line:      string(lbls["team"]) + "-" + string(lbls["env"]),
+					},
+				}
+			}
+			<-done
+
+			require.Equal(t, tc.expected, received)
+			require.Equal(t, 4.0, testutil.ToFloat64(c.metrics.entriesProcessed))
+			require.Equal(t, float64(len(tc.expected["prod"])), testutil.ToFloat64(c.metrics.entriesRouted.WithLabelValues("prod")))
+			require.Equal(t, 1.0, testutil.ToFloat64(c.metrics.entriesRouted.WithLabelValues("default")))
+		})
+	}
+}
+
+func TestRoute_Dropped(t *testing.T) {
+	ch := make(loki.LogsReceiver)
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+	c, err := New(opts, Arguments{
+		Routes: []Route{{Name: "team_a", Selector: `{team="a"}`, ForwardTo: []loki.LogsReceiver{ch}}},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	c.receiver <- loki.Entry{Labels: model.LabelSet{"team": "b"}}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.metrics.entriesDropped) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,136 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"gopkg.in/yaml.v3"
+)
+
+type DebugInfo struct {
+	Error                  string                   `river:"error,attr,optional"`
+	PrometheusRules        []DebugK8sPrometheusRule `river:"prometheus_rule,block,optional"`
+	LokiRuleNamespaces     []DebugLokiNamespace     `river:"loki_rule_namespace,block,optional"`
+	InvalidPrometheusRules []DebugInvalidRule       `river:"invalid_prometheus_rule,block,optional"`
+	PendingChanges         []DebugPendingChange     `river:"pending_change,block,optional"`
+}
+
+type DebugK8sPrometheusRule struct {
+	Namespace     string `river:"namespace,attr"`
+	Name          string `river:"name,attr"`
+	UID           string `river:"uid,attr"`
+	NumRuleGroups int    `river:"num_rule_groups,attr"`
+}
+
+type DebugLokiNamespace struct {
+	Name          string `river:"name,attr"`
+	NumRuleGroups int    `river:"num_rule_groups,attr"`
+}
+
+// DebugInvalidRule is a PrometheusRule resource which failed validation.
+type DebugInvalidRule struct {
+	Namespace string `river:"namespace,attr"`
+	Name      string `river:"name,attr"`
+	Error     string `river:"error,attr"`
+}
+
+// DebugPendingChange is a change to a Loki rule group which isn't applied
+// because dry_run is enabled. Actual and Desired hold the rule group in YAML
+// before and after the change.
+type DebugPendingChange struct {
+	Namespace string `river:"namespace,attr"`
+	Group     string `river:"group,attr"`
+	Kind      string `river:"kind,attr"`
+	Actual    string `river:"actual,attr,optional"`
+	Desired   string `river:"desired,attr,optional"`
+}
+
+func (c *Component) DebugInfo() interface{} {
+	var output DebugInfo
+	c.stateMut.RLock()
+	for _, r := range c.invalidRules {
+		output.InvalidPrometheusRules = append(output.InvalidPrometheusRules, DebugInvalidRule{
+			Namespace: r.namespace,
+			Name:      r.name,
+			Error:     r.err.Error(),
+		})
+	}
+	output.PendingChanges = debugPendingChanges(c.pendingChanges)
+	c.stateMut.RUnlock()
+
+	for ns := range c.currentState {
+		if !isManagedLokiNamespace(c.args.LokiNameSpacePrefix, ns) {
+			continue
+		}
+
+		output.LokiRuleNamespaces = append(output.LokiRuleNamespaces, DebugLokiNamespace{
+			Name:          ns,
+			NumRuleGroups: len(c.currentState[ns]),
+		})
+	}
+
+	// This should load from the informer cache, so it shouldn't fail under normal circumstances.
+	managedK8sNamespaces, err := c.namespaceLister.List(c.namespaceSelector)
+	if err != nil {
+		return DebugInfo{
+			Error: fmt.Sprintf("failed to list namespaces: %v", err),
+		}
+	}
+
+	for _, n := range managedK8sNamespaces {
+		// This should load from the informer cache, so it shouldn't fail under normal circumstances.
+		rules, err := c.ruleLister.PrometheusRules(n.Name).List(c.ruleSelector)
+		if err != nil {
+			return DebugInfo{
+				Error: fmt.Sprintf("failed to list rules: %v", err),
+			}
+		}
+
+		for _, r := range rules {
+			output.PrometheusRules = append(output.PrometheusRules, DebugK8sPrometheusRule{
+				Namespace:     n.Name,
+				Name:          r.Name,
+				UID:           string(r.UID),
+				NumRuleGroups: len(r.Spec.Groups),
+			})
+		}
+	}
+
+	return output
+}
+
+func debugPendingChanges(diffs ruleGroupDiffsByNamespace) []DebugPendingChange {
+	namespaces := make([]string, 0, len(diffs))
+	for ns := range diffs {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	var res []DebugPendingChange
+	for _, ns := range namespaces {
+		for _, d := range diffs[ns] {
+			change := DebugPendingChange{
+				Namespace: ns,
+				Group:     d.name(),
+				Kind:      string(d.Kind),
+			}
+			if d.Kind != ruleGroupDiffKindAdd {
+				change.Actual = marshalRuleGroup(d.Actual)
+			}
+			if d.Kind != ruleGroupDiffKindRemove {
+				change.Desired = marshalRuleGroup(d.Desired)
+			}
+			res = append(res, change)
+		}
+	}
+	return res
+}
+
+func marshalRuleGroup(g rulefmt.RuleGroup) string {
+	buf, err := yaml.Marshal(g)
+	if err != nil {
+		return fmt.Sprintf("failed to marshal rule group: %v", err)
+	}
+	return string(buf)
+}
@@ -0,0 +1,401 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/loki/pkg/logql/syntax"
+	"github.com/hashicorp/go-multierror"
+	promv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	yamlv3 "gopkg.in/yaml.v3" // Used for prometheus rulefmt compatibility instead of gopkg.in/yaml.v2
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/yaml" // Used for CRD compatibility instead of gopkg.in/yaml.v2
+)
+
+// This type must be hashable, so it is kept simple. The indexer will maintain a
+// cache of current state, so this is mostly used for logging.
+type event struct {
+	typ       eventType
+	objectKey string
+}
+
+type eventType string
+
+const (
+	eventTypeResourceChanged eventType = "resource-changed"
+	eventTypeSyncLoki        eventType = "sync-loki"
+)
+
+type queuedEventHandler struct {
+	log   log.Logger
+	queue workqueue.RateLimitingInterface
+}
+
+func newQueuedEventHandler(log log.Logger, queue workqueue.RateLimitingInterface) *queuedEventHandler {
+	return &queuedEventHandler{
+		log:   log,
+		queue: queue,
+	}
+}
+
+// OnAdd implements the cache.ResourceEventHandler interface.
+func (c *queuedEventHandler) OnAdd(obj interface{}, _ bool) {
+	c.publishEvent(obj)
+}
+
+// OnUpdate implements the cache.ResourceEventHandler interface.
+func (c *queuedEventHandler) OnUpdate(oldObj, newObj interface{}) {
+	c.publishEvent(newObj)
+}
+
+// OnDelete implements the cache.ResourceEventHandler interface.
+func (c *queuedEventHandler) OnDelete(obj interface{}) {
+	c.publishEvent(obj)
+}
+
+func (c *queuedEventHandler) publishEvent(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to get key for object", "err", err)
+		return
+	}
+
+	c.queue.AddRateLimited(event{
+		typ:       eventTypeResourceChanged,
+		objectKey: key,
+	})
+}
+
+func (c *Component) eventLoop(ctx context.Context) {
+	for {
+		eventInterface, shutdown := c.queue.Get()
+		if shutdown {
+			level.Info(c.log).Log("msg", "shutting down event loop")
+			return
+		}
+
+		evt := eventInterface.(event)
+		c.metrics.eventsTotal.WithLabelValues(string(evt.typ)).Inc()
+		err := c.processEvent(ctx, evt)
+
+		if err != nil {
+			retries := c.queue.NumRequeues(evt)
+			if retries < 5 {
+				c.metrics.eventsRetried.WithLabelValues(string(evt.typ)).Inc()
+				c.queue.AddRateLimited(evt)
+				level.Error(c.log).Log(
+					"msg", "failed to process event, will retry",
+					"retries", fmt.Sprintf("%d/5", retries),
+					"err", err,
+				)
+				continue
+			} else {
+				c.metrics.eventsFailed.WithLabelValues(string(evt.typ)).Inc()
+				level.Error(c.log).Log(
+					"msg", "failed to process event, max retries exceeded",
+					"retries", fmt.Sprintf("%d/5", retries),
+					"err", err,
+				)
+				c.reportUnhealthy(err)
+			}
+		} else {
+			c.reportHealthy()
+		}
+
+		c.queue.Forget(evt)
+	}
+}
+
+func (c *Component) processEvent(ctx context.Context, e event) error {
+	defer c.queue.Done(e)
+
+	switch e.typ {
+	case eventTypeResourceChanged:
+		level.Info(c.log).Log("msg", "processing event", "type", e.typ, "key", e.objectKey)
+	case eventTypeSyncLoki:
+		level.Debug(c.log).Log("msg", "syncing current state from ruler")
+		err := c.syncLoki(ctx)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown event type: %s", e.typ)
+	}
+
+	return c.reconcileState(ctx)
+}
+
+func (c *Component) syncLoki(ctx context.Context) error {
+	rulesByNamespace, err := c.lokiClient.ListRules(ctx, "")
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to list rules from loki", "err", err)
+		return err
+	}
+
+	for ns := range rulesByNamespace {
+		if !isManagedLokiNamespace(c.args.LokiNameSpacePrefix, ns) {
+			delete(rulesByNamespace, ns)
+		}
+	}
+
+	c.currentState = rulesByNamespace
+
+	return nil
+}
+
+func (c *Component) reconcileState(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	desiredState, invalid, err := c.loadStateFromK8s()
+	if err != nil {
+		return err
+	}
+	c.setInvalidRules(invalid)
+
+	diffs := diffRuleState(desiredState, c.currentState)
+	if c.args.DryRun {
+		c.setPendingChanges(diffs)
+		return nil
+	}
+	c.setPendingChanges(nil)
+
+	var result error
+	for ns, diff := range diffs {
+		err = c.applyChanges(ctx, ns, diff)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+	}
+
+	return result
+}
+
+// invalidRule is a PrometheusRule resource which failed validation. The rule
+// groups of invalid resources are left as is in Loki, so that a bad change
+// doesn't delete rules which were previously synced.
+type invalidRule struct {
+	namespace string
+	name      string
+	err       error
+}
+
+func (c *Component) loadStateFromK8s() (ruleGroupsByNamespace, []invalidRule, error) {
+	matchedNamespaces, err := c.namespaceLister.List(c.namespaceSelector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	desiredState := make(ruleGroupsByNamespace)
+	var invalid []invalidRule
+	for _, ns := range matchedNamespaces {
+		crdState, err := c.ruleLister.PrometheusRules(ns.Name).List(c.ruleSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list rules: %w", err)
+		}
+
+		for _, pr := range crdState {
+			lokiNs := lokiNamespaceForRuleCRD(c.args.LokiNameSpacePrefix, pr)
+
+			groups, err := convertCRDRuleGroupToRuleGroup(pr.Spec, c.args.ExternalLabels)
+			if err != nil {
+				level.Warn(c.log).Log("msg", "skipping invalid PrometheusRule", "namespace", pr.Namespace, "name", pr.Name, "err", err)
+				invalid = append(invalid, invalidRule{namespace: pr.Namespace, name: pr.Name, err: err})
+				if current, ok := c.currentState[lokiNs]; ok {
+					desiredState[lokiNs] = current
+				}
+				continue
+			}
+
+			desiredState[lokiNs] = groups
+		}
+	}
+
+	return desiredState, invalid, nil
+}
+
+// convertCRDRuleGroupToRuleGroup converts and validates the rule groups of a
+// PrometheusRule resource. externalLabels are added to every rule which
+// doesn't already have a label of the same name.
+func convertCRDRuleGroupToRuleGroup(crd promv1.PrometheusRuleSpec, externalLabels map[string]string) ([]rulefmt.RuleGroup, error) {
+	if len(externalLabels) > 0 {
+		crd = *crd.DeepCopy()
+		for i := range crd.Groups {
+			for j := range crd.Groups[i].Rules {
+				rule := &crd.Groups[i].Rules[j]
+				for name, value := range externalLabels {
+					if _, ok := rule.Labels[name]; ok {
+						continue
+					}
+					if rule.Labels == nil {
+						rule.Labels = make(map[string]string, len(externalLabels))
+					}
+					rule.Labels[name] = value
+				}
+			}
+		}
+	}
+
+	buf, err := yaml.Marshal(crd)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups rulefmt.RuleGroups
+	dec := yamlv3.NewDecoder(bytes.NewReader(buf))
+	dec.KnownFields(true)
+	if err := dec.Decode(&groups); err != nil {
+		return nil, err
+	}
+
+	// The rules are validated, including their LogQL expressions, so that
+	// invalid rules are never sent to the ruler.
+	if err := validateRuleGroups(groups.Groups); err != nil {
+		return nil, err
+	}
+
+	return groups.Groups, nil
+}
+
+// validateRuleGroups validates rule groups like rulefmt.Parse, except that
+// the expressions of the rules are LogQL instead of PromQL.
+func validateRuleGroups(groups []rulefmt.RuleGroup) error {
+	var result error
+	seen := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		if g.Name == "" {
+			result = multierror.Append(result, fmt.Errorf("rule group name must not be empty"))
+			continue
+		}
+		if _, ok := seen[g.Name]; ok {
+			result = multierror.Append(result, fmt.Errorf("rule group %q is repeated", g.Name))
+		}
+		seen[g.Name] = struct{}{}
+
+		for i, r := range g.Rules {
+			if err := validateRule(r); err != nil {
+				result = multierror.Append(result, fmt.Errorf("rule group %q, rule %d: %w", g.Name, i+1, err))
+			}
+		}
+	}
+	return result
+}
+
+func validateRule(r rulefmt.RuleNode) error {
+	switch {
+	case r.Record.Value == "" && r.Alert.Value == "":
+		return fmt.Errorf("one of record or alert must be set")
+	case r.Record.Value != "" && r.Alert.Value != "":
+		return fmt.Errorf("only one of record or alert can be set")
+	case r.Expr.Value == "":
+		return fmt.Errorf("expr must not be empty")
+	}
+
+	if r.Record.Value != "" {
+		if len(r.Annotations) > 0 {
+			return fmt.Errorf("recording rules can't have annotations")
+		}
+		if r.For != 0 {
+			return fmt.Errorf("recording rules can't have a for duration")
+		}
+		if !model.IsValidMetricName(model.LabelValue(r.Record.Value)) {
+			return fmt.Errorf("invalid recording rule name %q", r.Record.Value)
+		}
+	}
+	for name := range r.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+
+	// The Loki ruler only evaluates LogQL expressions which return samples.
+	if _, err := syntax.ParseSampleExpr(r.Expr.Value); err != nil {
+		return fmt.Errorf("invalid LogQL expression %q: %w", r.Expr.Value, err)
+	}
+	return nil
+}
+
+func (c *Component) setInvalidRules(invalid []invalidRule) {
+	c.stateMut.Lock()
+	defer c.stateMut.Unlock()
+	c.invalidRules = invalid
+	c.metrics.invalidRules.Set(float64(len(invalid)))
+}
+
+func (c *Component) setPendingChanges(diffs ruleGroupDiffsByNamespace) {
+	c.stateMut.Lock()
+	defer c.stateMut.Unlock()
+	c.pendingChanges = diffs
+
+	var n int
+	for ns, diff := range diffs {
+		n += len(diff)
+		for _, d := range diff {
+			level.Debug(c.log).Log("msg", "dry run: not applying rule group change", "namespace", ns, "group", d.name(), "kind", d.Kind)
+		}
+	}
+	c.metrics.pendingChanges.Set(float64(n))
+}
+
+func (c *Component) applyChanges(ctx context.Context, namespace string, diffs []ruleGroupDiff) error {
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	for _, diff := range diffs {
+		switch diff.Kind {
+		case ruleGroupDiffKindAdd:
+			err := c.lokiClient.CreateRuleGroup(ctx, namespace, diff.Desired)
+			if err != nil {
+				return err
+			}
+			level.Info(c.log).Log("msg", "added rule group", "namespace", namespace, "group", diff.Desired.Name)
+		case ruleGroupDiffKindRemove:
+			err := c.lokiClient.DeleteRuleGroup(ctx, namespace, diff.Actual.Name)
+			if err != nil {
+				return err
+			}
+			level.Info(c.log).Log("msg", "removed rule group", "namespace", namespace, "group", diff.Actual.Name)
+		case ruleGroupDiffKindUpdate:
+			err := c.lokiClient.CreateRuleGroup(ctx, namespace, diff.Desired)
+			if err != nil {
+				return err
+			}
+			level.Info(c.log).Log("msg", "updated rule group", "namespace", namespace, "group", diff.Desired.Name)
+		default:
+			level.Error(c.log).Log("msg", "unknown rule group diff kind", "kind", diff.Kind)
+		}
+	}
+
+	// resync loki state after applying changes
+	return c.syncLoki(ctx)
+}
+
+// lokiNamespaceForRuleCRD returns the namespace that the rule CRD should be
+// stored in loki. This function, along with isManagedNamespace, is used to
+// determine if a rule CRD is managed by the agent.
+func lokiNamespaceForRuleCRD(prefix string, pr *promv1.PrometheusRule) string {
+	return fmt.Sprintf("%s/%s/%s/%s", prefix, pr.Namespace, pr.Name, pr.UID)
+}
+
+// isManagedLokiNamespace returns true if the namespace is managed by the agent.
+// Unmanaged namespaces are left as is by the operator.
+func isManagedLokiNamespace(prefix, namespace string) bool {
+	prefixPart := regexp.QuoteMeta(prefix)
+	namespacePart := `.+`
+	namePart := `.+`
+	uuidPart := `[0-9a-fA-F]{8}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{4}\b-[0-9a-fA-F]{12}`
+	managedNamespaceRegex := regexp.MustCompile(
+		fmt.Sprintf("^%s/%s/%s/%s$", prefixPart, namespacePart, namePart, uuidPart),
+	)
+	return managedNamespaceRegex.MatchString(namespace)
+}
@@ -0,0 +1,319 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	rulerClient "github.com/grafana/agent/pkg/mimir/client"
+	v1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	promListers "github.com/prometheus-operator/prometheus-operator/pkg/client/listers/monitoring/v1"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/stretchr/testify/require"
+	yamlv3 "gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	coreListers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+type fakeLokiClient struct {
+	rulesMut sync.RWMutex
+	rules    map[string][]rulefmt.RuleGroup
+}
+
+var _ rulerClient.Interface = &fakeLokiClient{}
+
+func newFakeLokiClient() *fakeLokiClient {
+	return &fakeLokiClient{
+		rules: make(map[string][]rulefmt.RuleGroup),
+	}
+}
+
+func (m *fakeLokiClient) CreateRuleGroup(ctx context.Context, namespace string, rule rulefmt.RuleGroup) error {
+	m.rulesMut.Lock()
+	defer m.rulesMut.Unlock()
+	m.deleteLocked(namespace, rule.Name)
+	m.rules[namespace] = append(m.rules[namespace], rule)
+	return nil
+}
+
+func (m *fakeLokiClient) DeleteRuleGroup(ctx context.Context, namespace, group string) error {
+	m.rulesMut.Lock()
+	defer m.rulesMut.Unlock()
+	m.deleteLocked(namespace, group)
+	return nil
+}
+
+func (m *fakeLokiClient) deleteLocked(namespace, group string) {
+	for ns, v := range m.rules {
+		if namespace != "" && namespace != ns {
+			continue
+		}
+		for i, g := range v {
+			if g.Name == group {
+				m.rules[ns] = append(m.rules[ns][:i], m.rules[ns][i+1:]...)
+
+				if len(m.rules[ns]) == 0 {
+					delete(m.rules, ns)
+				}
+
+				return
+			}
+		}
+	}
+}
+
+func (m *fakeLokiClient) ListRules(ctx context.Context, namespace string) (map[string][]rulefmt.RuleGroup, error) {
+	m.rulesMut.RLock()
+	defer m.rulesMut.RUnlock()
+	output := make(map[string][]rulefmt.RuleGroup)
+	for ns, v := range m.rules {
+		if namespace != "" && namespace != ns {
+			continue
+		}
+		output[ns] = v
+	}
+	return output, nil
+}
+
+func TestEventLoop(t *testing.T) {
+	nsIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	nsLister := coreListers.NewNamespaceLister(nsIndexer)
+
+	ruleIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	ruleLister := promListers.NewPrometheusRuleLister(ruleIndexer)
+
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "namespace",
+			UID:  types.UID("33f8860c-bd06-4c0d-a0b1-a114d6b9937b"),
+		},
+	}
+
+	rule := &v1.PrometheusRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "name",
+			Namespace: "namespace",
+			UID:       types.UID("64aab764-c95e-4ee9-a932-cd63ba57e6cf"),
+		},
+		Spec: v1.PrometheusRuleSpec{
+			Groups: []v1.RuleGroup{
+				{
+					Name: "group",
+					Rules: []v1.Rule{
+						{
+							Alert: "alert",
+							Expr:  intstr.FromString(`sum(rate({app="a"} |= "error" [5m])) > 0`),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	component := Component{
+		log:               log.NewLogfmtLogger(os.Stdout),
+		queue:             workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		namespaceLister:   nsLister,
+		namespaceSelector: labels.Everything(),
+		ruleLister:        ruleLister,
+		ruleSelector:      labels.Everything(),
+		lokiClient:        newFakeLokiClient(),
+		args:              Arguments{LokiNameSpacePrefix: "agent"},
+		metrics:           newMetrics(),
+	}
+	eventHandler := newQueuedEventHandler(component.log, component.queue)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go component.eventLoop(ctx)
+
+	// Add a namespace and rule to kubernetes
+	nsIndexer.Add(ns)
+	ruleIndexer.Add(rule)
+	eventHandler.OnAdd(rule, false)
+
+	// Wait for the rule to be added to loki
+	require.Eventually(t, func() bool {
+		rules, err := component.lokiClient.ListRules(ctx, "")
+		require.NoError(t, err)
+		return len(rules) == 1
+	}, time.Second, 10*time.Millisecond)
+	component.queue.AddRateLimited(event{typ: eventTypeSyncLoki})
+
+	// Update the rule in kubernetes
+	rule.Spec.Groups[0].Rules = append(rule.Spec.Groups[0].Rules, v1.Rule{
+		Alert: "alert2",
+		Expr:  intstr.FromString(`sum(rate({app="b"} |= "error" [5m])) > 0`),
+	})
+	ruleIndexer.Update(rule)
+	eventHandler.OnUpdate(rule, rule)
+
+	// Wait for the rule to be updated in loki
+	require.Eventually(t, func() bool {
+		allRules, err := component.lokiClient.ListRules(ctx, "")
+		require.NoError(t, err)
+		rules := allRules[lokiNamespaceForRuleCRD("agent", rule)][0].Rules
+		return len(rules) == 2
+	}, time.Second, 10*time.Millisecond)
+	component.queue.AddRateLimited(event{typ: eventTypeSyncLoki})
+
+	// Remove the rule from kubernetes
+	ruleIndexer.Delete(rule)
+	eventHandler.OnDelete(rule)
+
+	// Wait for the rule to be removed from loki
+	require.Eventually(t, func() bool {
+		rules, err := component.lokiClient.ListRules(ctx, "")
+		require.NoError(t, err)
+		return len(rules) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestReconcile_ValidationAndDryRun(t *testing.T) {
+	nsIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	ruleIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	require.NoError(t, nsIndexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace"}}))
+
+	newRule := func(name, uid, expr string) *v1.PrometheusRule {
+		return &v1.PrometheusRule{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "namespace", UID: types.UID(uid)},
+			Spec: v1.PrometheusRuleSpec{
+				Groups: []v1.RuleGroup{{
+					Name: "group",
+					Rules: []v1.Rule{
+						{Alert: "alert", Expr: intstr.FromString(expr)},
+						{Alert: "labeled", Expr: intstr.FromString(expr), Labels: map[string]string{"cluster": "own"}},
+					},
+				}},
+			},
+		}
+	}
+	valid := newRule("valid", "64aab764-c95e-4ee9-a932-cd63ba57e6cf", `count_over_time({app="a"} |= "error" [5m]) > 0`)
+	invalid := newRule("invalid", "7e5f1c36-0d52-4c4e-8d5b-3f0a3c1f9d2e", `{app=`)
+	require.NoError(t, ruleIndexer.Add(valid))
+	require.NoError(t, ruleIndexer.Add(invalid))
+
+	loki := newFakeLokiClient()
+	invalidNs := lokiNamespaceForRuleCRD("agent", invalid)
+	previous := rulefmt.RuleGroup{Name: "previous"}
+	require.NoError(t, loki.CreateRuleGroup(context.Background(), invalidNs, previous))
+
+	component := Component{
+		log:               log.NewNopLogger(),
+		namespaceLister:   coreListers.NewNamespaceLister(nsIndexer),
+		namespaceSelector: labels.Everything(),
+		ruleLister:        promListers.NewPrometheusRuleLister(ruleIndexer),
+		ruleSelector:      labels.Everything(),
+		lokiClient:        loki,
+		args: Arguments{
+			LokiNameSpacePrefix: "agent",
+			ExternalLabels:      map[string]string{"cluster": "prod"},
+			DryRun:              true,
+		},
+		metrics: newMetrics(),
+	}
+	ctx := context.Background()
+	require.NoError(t, component.syncLoki(ctx))
+
+	// In dry run mode, changes are only reported.
+	require.NoError(t, component.reconcileState(ctx))
+	info := component.DebugInfo().(DebugInfo)
+	require.Len(t, info.PendingChanges, 1)
+	require.Equal(t, "add", info.PendingChanges[0].Kind)
+	require.Contains(t, info.PendingChanges[0].Desired, "cluster: prod")
+	require.Len(t, info.InvalidPrometheusRules, 1)
+	require.Equal(t, "invalid", info.InvalidPrometheusRules[0].Name)
+	rules, err := loki.ListRules(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	// Without dry run, valid rules are synced with external labels which
+	// don't override their own labels, and the rule groups of invalid rules
+	// are kept as is.
+	component.args.DryRun = false
+	require.NoError(t, component.reconcileState(ctx))
+	require.Empty(t, component.DebugInfo().(DebugInfo).PendingChanges)
+	rules, err = loki.ListRules(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, []rulefmt.RuleGroup{previous}, rules[invalidNs])
+
+	synced := rules[lokiNamespaceForRuleCRD("agent", valid)]
+	require.Len(t, synced, 1)
+	require.Equal(t, "prod", synced[0].Rules[0].Labels["cluster"])
+	require.Equal(t, "own", synced[0].Rules[1].Labels["cluster"])
+}
+
+func TestValidateRule(t *testing.T) {
+	tt := []struct {
+		name   string
+		rule   rulefmt.Rule
+		expect string
+	}{
+		{
+			name: "valid alert",
+			rule: rulefmt.Rule{Alert: "alert", Expr: `sum(rate({app="a"} |= "error" [5m])) > 0`},
+		},
+		{
+			name: "valid recording rule",
+			rule: rulefmt.Rule{Record: "app:errors:rate5m", Expr: `sum by (app) (rate({app="a"} |= "error" [5m]))`},
+		},
+		{
+			name:   "log query",
+			rule:   rulefmt.Rule{Alert: "alert", Expr: `{app="a"} |= "error"`},
+			expect: "invalid LogQL expression",
+		},
+		{
+			name:   "promql",
+			rule:   rulefmt.Rule{Alert: "alert", Expr: `up == 0`},
+			expect: "invalid LogQL expression",
+		},
+		{
+			name:   "record and alert",
+			rule:   rulefmt.Rule{Record: "record", Alert: "alert", Expr: `sum(rate({app="a"} [5m]))`},
+			expect: "only one of record or alert can be set",
+		},
+		{
+			name:   "invalid record name",
+			rule:   rulefmt.Rule{Record: "app-errors", Expr: `sum(rate({app="a"} [5m]))`},
+			expect: `invalid recording rule name "app-errors"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var node rulefmt.RuleNode
+			buf, err := yamlv3.Marshal(tc.rule)
+			require.NoError(t, err)
+			require.NoError(t, yamlv3.Unmarshal(buf, &node))
+
+			err = validateRule(node)
+			if tc.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expect)
+			}
+		})
+	}
+}
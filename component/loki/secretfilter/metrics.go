@@ -0,0 +1,40 @@
+package secretfilter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	entriesProcessed prometheus.Counter
+	entriesRedacted  prometheus.Counter
+	redactions       *prometheus.CounterVec
+}
+
+// newMetrics creates a new set of metrics. If reg is non-nil, the metrics
+// will also be registered.
+func newMetrics(reg prometheus.Registerer) *metrics {
+	var m metrics
+
+	m.entriesProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_secretfilter_entries_processed_total",
+		Help: "Total number of log entries processed.",
+	})
+	m.entriesRedacted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_secretfilter_entries_redacted_total",
+		Help: "Total number of log entries in which at least one secret was redacted.",
+	})
+	m.redactions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_secretfilter_redactions_total",
+		Help: "Total number of secrets redacted, by rule.",
+	}, []string{"rule"})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.entriesProcessed,
+			m.entriesRedacted,
+			m.redactions,
+		)
+	}
+
+	return &m
+}
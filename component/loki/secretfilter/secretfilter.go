@@ -0,0 +1,285 @@
+package secretfilter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "loki.secretfilter",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// ruleNamePlaceholder is replaced with the name of the matching rule in
+// redaction markers.
+const ruleNamePlaceholder = "$RULE"
+
+// Arguments holds values which are used to configure the loki.secretfilter
+// component.
+type Arguments struct {
+	// Where the filtered log entries should be forwarded to.
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
+
+	// The string secrets are replaced with.
+	RedactWith string `river:"redact_with,attr,optional"`
+
+	// Whether the built-in rule for generic high-entropy secrets is used.
+	IncludeGeneric bool `river:"include_generic,attr,optional"`
+
+	// Names of built-in rules which shouldn't be used.
+	DisabledRules []string `river:"disabled_rules,attr,optional"`
+
+	// Regexes matching values which must never be redacted.
+	Allowlist []string `river:"allowlist,attr,optional"`
+
+	// Additional rules, applied after the built-in ones.
+	Rules []Rule `river:"rule,block,optional"`
+}
+
+// DefaultArguments provides the default arguments for the loki.secretfilter
+// component.
+var DefaultArguments = Arguments{
+	RedactWith:     "<REDACTED:" + ruleNamePlaceholder + ">",
+	IncludeGeneric: true,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	builtin := builtinRuleNames()
+	for _, name := range a.DisabledRules {
+		if _, ok := builtin[name]; !ok {
+			return fmt.Errorf("disabled_rules: unknown built-in rule %q", name)
+		}
+	}
+
+	seen := make(map[string]struct{}, len(a.Rules))
+	for _, r := range a.Rules {
+		if _, ok := builtin[r.Name]; ok {
+			return fmt.Errorf("rule %q conflicts with a built-in rule", r.Name)
+		}
+		if _, ok := seen[r.Name]; ok {
+			return fmt.Errorf("found multiple rules named %q", r.Name)
+		}
+		seen[r.Name] = struct{}{}
+	}
+
+	for _, expr := range a.Allowlist {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid allowlist regex %q: %w", expr, err)
+		}
+	}
+	return nil
+}
+
+// rules returns the rules enabled by the arguments, in the order they're
+// applied.
+func (a *Arguments) rules() []Rule {
+	disabled := make(map[string]struct{}, len(a.DisabledRules))
+	for _, name := range a.DisabledRules {
+		disabled[name] = struct{}{}
+	}
+	if !a.IncludeGeneric {
+		disabled[genericRuleName] = struct{}{}
+	}
+
+	var res []Rule
+	for _, r := range builtinRules {
+		if _, ok := disabled[r.Name]; !ok {
+			res = append(res, r)
+		}
+	}
+	return append(res, a.Rules...)
+}
+
+// Exports holds values which are exported by the loki.secretfilter component.
+type Exports struct {
+	Receiver loki.LogsReceiver `river:"receiver,attr"`
+}
+
+// Component implements the loki.secretfilter component.
+type Component struct {
+	opts     component.Options
+	metrics  *metrics
+	receiver loki.LogsReceiver
+
+	mut    sync.RWMutex
+	filter *filter
+	fanout []loki.LogsReceiver
+}
+
+var (
+	_ component.Component = (*Component)(nil)
+)
+
+// New creates a new loki.secretfilter component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		metrics: newMetrics(o.Registerer),
+	}
+
+	// Create and immediately export the receiver which remains the same for
+	// the component's lifetime.
+	c.receiver = make(loki.LogsReceiver)
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver:
+			c.mut.RLock()
+			filter, fanout := c.filter, c.fanout
+			c.mut.RUnlock()
+
+			c.metrics.entriesProcessed.Inc()
+			entry.Line = filter.redact(entry.Line)
+
+			for _, f := range fanout {
+				select {
+				case <-ctx.Done():
+					return nil
+				case f <- entry:
+				}
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	f, err := newFilter(newArgs, c.metrics)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.filter = f
+	c.fanout = newArgs.ForwardTo
+	return nil
+}
+
+// filter redacts secrets from log lines.
+type filter struct {
+	rules     []filterRule
+	allowlist []*regexp.Regexp
+	metrics   *metrics
+}
+
+type filterRule struct {
+	compiledRule
+	marker string
+}
+
+func newFilter(args Arguments, m *metrics) (*filter, error) {
+	rules, err := compileRules(args.rules())
+	if err != nil {
+		return nil, err
+	}
+
+	f := &filter{metrics: m}
+	for _, r := range rules {
+		f.rules = append(f.rules, filterRule{
+			compiledRule: r,
+			marker:       strings.ReplaceAll(args.RedactWith, ruleNamePlaceholder, r.Name),
+		})
+	}
+	for _, expr := range args.Allowlist {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist regex %q: %w", expr, err)
+		}
+		f.allowlist = append(f.allowlist, re)
+	}
+	return f, nil
+}
+
+// redact returns line with the secrets matched by the filter's rules
+// replaced. Rules are applied one after the other, so later rules don't match
+// secrets already redacted by earlier ones.
+func (f *filter) redact(line string) string {
+	var redacted bool
+
+	for _, r := range f.rules {
+		matches := r.re.FindAllStringSubmatchIndex(line, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		var (
+			sb    strings.Builder
+			last  int
+			count int
+		)
+		for _, m := range matches {
+			start, end := m[2*r.SecretGroup], m[2*r.SecretGroup+1]
+			if start < 0 {
+				// The capture group holding the secret didn't participate in
+				// the match.
+				continue
+			}
+			secret := line[start:end]
+			if r.MinEntropy > 0 && shannonEntropy(secret) < r.MinEntropy {
+				continue
+			}
+			if f.allowed(secret) {
+				continue
+			}
+
+			sb.WriteString(line[last:start])
+			sb.WriteString(r.marker)
+			last = end
+			count++
+		}
+		if count == 0 {
+			continue
+		}
+		sb.WriteString(line[last:])
+		line = sb.String()
+
+		redacted = true
+		f.metrics.redactions.WithLabelValues(r.Name).Add(float64(count))
+	}
+
+	if redacted {
+		f.metrics.entriesRedacted.Inc()
+	}
+	return line
+}
+
+func (f *filter) allowed(secret string) bool {
+	for _, re := range f.allowlist {
+		if re.MatchString(secret) {
+			return true
+		}
+	}
+	return false
+}
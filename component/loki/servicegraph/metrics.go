@@ -0,0 +1,53 @@
+package servicegraph
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	requestTotal         *prometheus.CounterVec
+	requestFailedTotal   *prometheus.CounterVec
+	requestServerSeconds *prometheus.HistogramVec
+	droppedEntries       prometheus.Counter
+}
+
+// newMetrics creates a new set of metrics. If reg is non-nil, the metrics
+// will also be registered.
+//
+// The names of the edge metrics match the ones of the trace-based service
+// graph, with a logs namespace, so that existing dashboards can be reused.
+func newMetrics(reg prometheus.Registerer) *metrics {
+	var m metrics
+
+	m.requestTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logs",
+		Name:      "service_graph_request_total",
+		Help:      "Total count of requests between two nodes.",
+	}, []string{"client", "server"})
+	m.requestFailedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "logs",
+		Name:      "service_graph_request_failed_total",
+		Help:      "Total count of failed requests between two nodes.",
+	}, []string{"client", "server"})
+	m.requestServerSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "logs",
+		Name:      "service_graph_request_server_seconds",
+		Help:      "Time for a request between two nodes as seen from the server.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 12),
+	}, []string{"client", "server"})
+	m.droppedEntries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_servicegraph_dropped_entries_total",
+		Help: "Total number of log entries which didn't describe a request between two nodes.",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.requestTotal,
+			m.requestFailedTotal,
+			m.requestServerSeconds,
+			m.droppedEntries,
+		)
+	}
+
+	return &m
+}
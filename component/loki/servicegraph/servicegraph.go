@@ -0,0 +1,235 @@
+package servicegraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logfmt/logfmt"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "loki.servicegraph",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Supported formats of log lines.
+const (
+	FormatLogfmt = "logfmt"
+	FormatJSON   = "json"
+)
+
+// Arguments holds values which are used to configure the loki.servicegraph
+// component.
+type Arguments struct {
+	// Where the log entries should be forwarded to after being inspected.
+	ForwardTo []loki.LogsReceiver `river:"forward_to,attr,optional"`
+
+	// The format of the log lines.
+	Format string `river:"format,attr,optional"`
+
+	// Fields holding the names of the client and server of a request.
+	ClientField string `river:"client_field,attr"`
+	ServerField string `river:"server_field,attr"`
+
+	// Field holding the status code of a request, and the lowest status code
+	// of a failed request.
+	StatusField     string `river:"status_field,attr,optional"`
+	FailedStatusMin int    `river:"failed_status_min,attr,optional"`
+
+	// Field holding the duration of a request.
+	DurationField string `river:"duration_field,attr,optional"`
+}
+
+// DefaultArguments provides the default arguments for the loki.servicegraph
+// component.
+var DefaultArguments = Arguments{
+	Format:          FormatLogfmt,
+	FailedStatusMin: 500,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	switch a.Format {
+	case FormatLogfmt, FormatJSON:
+	default:
+		return fmt.Errorf("unsupported format %q, must be %q or %q", a.Format, FormatLogfmt, FormatJSON)
+	}
+	if a.ClientField == "" {
+		return fmt.Errorf("client_field must not be empty")
+	}
+	if a.ServerField == "" {
+		return fmt.Errorf("server_field must not be empty")
+	}
+	if a.FailedStatusMin <= 0 {
+		return fmt.Errorf("failed_status_min must be greater than 0")
+	}
+	return nil
+}
+
+// Exports holds values which are exported by the loki.servicegraph component.
+type Exports struct {
+	Receiver loki.LogsReceiver `river:"receiver,attr"`
+}
+
+// Component implements the loki.servicegraph component.
+type Component struct {
+	opts     component.Options
+	metrics  *metrics
+	receiver loki.LogsReceiver
+
+	mut  sync.RWMutex
+	args Arguments
+}
+
+var (
+	_ component.Component = (*Component)(nil)
+)
+
+// New creates a new loki.servicegraph component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		metrics: newMetrics(o.Registerer),
+	}
+
+	// Create and immediately export the receiver which remains the same for
+	// the component's lifetime.
+	c.receiver = make(loki.LogsReceiver)
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver:
+			c.mut.RLock()
+			args := c.args
+			c.mut.RUnlock()
+
+			c.observe(args, entry)
+
+			for _, f := range args.ForwardTo {
+				select {
+				case <-ctx.Done():
+					return nil
+				case f <- entry:
+				}
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.args = newArgs
+	return nil
+}
+
+// observe records the request described by entry, if any.
+func (c *Component) observe(args Arguments, entry loki.Entry) {
+	fields := parseFields(args.Format, entry.Line)
+	lookup := func(name string) string {
+		if v, ok := fields[name]; ok {
+			return v
+		}
+		return string(entry.Labels[model.LabelName(name)])
+	}
+
+	client, server := lookup(args.ClientField), lookup(args.ServerField)
+	if client == "" || server == "" {
+		c.metrics.droppedEntries.Inc()
+		return
+	}
+
+	c.metrics.requestTotal.WithLabelValues(client, server).Inc()
+
+	if args.StatusField != "" {
+		if status, err := strconv.Atoi(lookup(args.StatusField)); err == nil && status >= args.FailedStatusMin {
+			c.metrics.requestFailedTotal.WithLabelValues(client, server).Inc()
+		}
+	}
+
+	if args.DurationField != "" {
+		if d, ok := parseDuration(lookup(args.DurationField)); ok {
+			c.metrics.requestServerSeconds.WithLabelValues(client, server).Observe(d.Seconds())
+		}
+	}
+}
+
+// parseFields returns the top-level fields of line. Lines which can't be
+// parsed return the fields found before the error.
+func parseFields(format string, line string) map[string]string {
+	fields := make(map[string]string)
+
+	switch format {
+	case FormatJSON:
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			return fields
+		}
+		for k, v := range obj {
+			switch v := v.(type) {
+			case string:
+				fields[k] = v
+			case float64, bool:
+				fields[k] = fmt.Sprint(v)
+			}
+		}
+
+	case FormatLogfmt:
+		dec := logfmt.NewDecoder(strings.NewReader(line))
+		for dec.ScanRecord() {
+			for dec.ScanKeyval() {
+				fields[string(dec.Key())] = string(dec.Value())
+			}
+		}
+	}
+
+	return fields
+}
+
+// parseDuration parses s either as a Go duration, such as "12ms", or as a
+// number of seconds.
+func parseDuration(s string) (time.Duration, bool) {
+	if s == "" {
+		return 0, false
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(f * float64(time.Second)), true
+	}
+	return 0, false
+}
@@ -0,0 +1,172 @@
+package servicegraph
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name: "unknown format",
+			config: `
+				format       = "csv"
+				client_field = "src"
+				server_field = "dst"
+			`,
+			err: `unsupported format "csv"`,
+		},
+		{
+			name: "empty client field",
+			config: `
+				client_field = ""
+				server_field = "dst"
+			`,
+			err: "client_field must not be empty",
+		},
+		{
+			name: "invalid failed status",
+			config: `
+				client_field      = "src"
+				server_field      = "dst"
+				failed_status_min = 0
+			`,
+			err: "failed_status_min must be greater than 0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestComponent(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		lines  []string
+	}{
+		{
+			name: "logfmt",
+			config: `
+				client_field   = "src"
+				server_field   = "dst"
+				status_field   = "status"
+				duration_field = "duration"
+			`,
+			lines: []string{
+				`src=frontend dst=cart status=200 duration=20ms`,
+				`src=frontend dst=cart status=503 duration=0.5`,
+				`msg="no request in here"`,
+			},
+		},
+		{
+			name: "json",
+			config: `
+				format         = "json"
+				client_field   = "src"
+				server_field   = "dst"
+				status_field   = "status"
+				duration_field = "duration"
+			`,
+			lines: []string{
+				`{"src": "frontend", "dst": "cart", "status": 200, "duration": 0.02}`,
+				`{"src": "frontend", "dst": "cart", "status": 503, "duration": "500ms"}`,
+				`not json`,
+			},
+		},
+		{
+			name: "client from labels",
+			config: `
+				client_field   = "app"
+				server_field   = "dst"
+				status_field   = "status"
+				duration_field = "duration"
+			`,
+			lines: []string{
+				`dst=cart status=200 duration=20ms`,
+				`dst=cart status=503 duration=500ms`,
+				`status=200`,
+			},
+		},
+	}
+
+	expect := `
+# HELP logs_service_graph_request_failed_total Total count of failed requests between two nodes.
+# TYPE logs_service_graph_request_failed_total counter
+logs_service_graph_request_failed_total{client="frontend",server="cart"} 1
+# HELP logs_service_graph_request_total Total count of requests between two nodes.
+# TYPE logs_service_graph_request_total counter
+logs_service_graph_request_total{client="frontend",server="cart"} 2
+# HELP loki_servicegraph_dropped_entries_total Total number of log entries which didn't describe a request between two nodes.
+# TYPE loki_servicegraph_dropped_entries_total counter
+loki_servicegraph_dropped_entries_total 1
+`
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			require.NoError(t, river.Unmarshal([]byte(tc.config), &args))
+
+			reg := prometheus.NewRegistry()
+			ch := make(loki.LogsReceiver, len(tc.lines))
+			args.ForwardTo = []loki.LogsReceiver{ch}
+
+			var exports Exports
+			c, err := New(component.Options{
+				Logger:        util.TestFlowLogger(t),
+				Registerer:    reg,
+				OnStateChange: func(e component.Exports) { exports = e.(Exports) },
+			}, args)
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go c.Run(ctx)
+
+			for _, line := range tc.lines {
+				exports.Receiver <- loki.Entry{
+					Labels: model.LabelSet{"app": "frontend"},
+					Entry:  logproto.Entry{Timestamp: time.Now(), Line: line},
+				}
+			}
+
+			// Entries are forwarded unchanged.
+			for _, line := range tc.lines {
+				select {
+				case e := <-ch:
+					require.Equal(t, line, e.Line)
+				case <-time.After(5 * time.Second):
+					require.FailNow(t, "timed out waiting for log entries")
+				}
+			}
+
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expect),
+				"logs_service_graph_request_total",
+				"logs_service_graph_request_failed_total",
+				"loki_servicegraph_dropped_entries_total",
+			))
+			require.Equal(t, 1, testutil.CollectAndCount(c.metrics.requestServerSeconds))
+		})
+	}
+}
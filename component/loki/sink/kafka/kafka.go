@@ -0,0 +1,248 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/delivery"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "loki.sink.kafka",
+		Args:    Arguments{},
+		Exports: Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Exports holds the receiver that is used to send log entries to the
+// loki.sink.kafka component.
+type Exports struct {
+	Receiver loki.LogsReceiver `river:"receiver,attr"`
+}
+
+// Reasons for dropping log entries.
+const (
+	reasonTopic   = "topic"
+	reasonKey     = "key"
+	reasonEncode  = "encode"
+	reasonProduce = "produce"
+)
+
+var (
+	_ component.Component = (*Component)(nil)
+)
+
+// Component implements the loki.sink.kafka component.
+type Component struct {
+	opts     component.Options
+	receiver loki.LogsReceiver
+	delivery *delivery.Tracker
+
+	// newProducer builds the producer sending messages to brokers. It's
+	// replaced by tests.
+	newProducer func(brokers []string, cfg *sarama.Config) (sarama.AsyncProducer, error)
+
+	entriesTotal        *prometheus.CounterVec
+	droppedEntriesTotal *prometheus.CounterVec
+
+	mut       sync.RWMutex
+	producer  sarama.AsyncProducer
+	templates *templates
+	format    string
+	errorsWg  sync.WaitGroup
+}
+
+// New creates a new loki.sink.kafka component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	return newComponent(o, args, sarama.NewAsyncProducer)
+}
+
+func newComponent(o component.Options, args Arguments, newProducer func([]string, *sarama.Config) (sarama.AsyncProducer, error)) (*Component, error) {
+	c := &Component{
+		opts:        o,
+		receiver:    make(loki.LogsReceiver),
+		delivery:    delivery.NewTracker(o.Registerer),
+		newProducer: newProducer,
+
+		entriesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_sink_kafka_entries_total",
+			Help: "Total number of log entries sent to Kafka topics.",
+		}, []string{"topic"}),
+		droppedEntriesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_sink_kafka_dropped_entries_total",
+			Help: "Total number of log entries which couldn't be sent to Kafka.",
+		}, []string{"reason"}),
+	}
+	if err := o.Registerer.Register(c.entriesTotal); err != nil {
+		return nil, err
+	}
+	if err := o.Registerer.Register(c.droppedEntriesTotal); err != nil {
+		return nil, err
+	}
+
+	// Create and immediately export the receiver which remains the same for
+	// the component's lifetime.
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		c.closeProducer()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver:
+			labels, stamp, stamped := delivery.ExtractLabels(entry.Labels)
+			entry.Labels = labels
+			if stamped {
+				c.delivery.Observe(stamp)
+			}
+
+			c.mut.RLock()
+			msg, ok := c.buildMessage(entry)
+			if !ok {
+				c.mut.RUnlock()
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				c.mut.RUnlock()
+				return nil
+			case c.producer.Input() <- msg:
+				c.entriesTotal.WithLabelValues(msg.Topic).Inc()
+			}
+			c.mut.RUnlock()
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	tmpls, err := newArgs.templates()
+	if err != nil {
+		return err
+	}
+	cfg, err := newArgs.saramaConfig()
+	if err != nil {
+		return err
+	}
+	producer, err := c.newProducer(newArgs.Brokers, cfg)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	// Closing the previous producer flushes the messages it buffered.
+	c.closeProducer()
+
+	c.producer = producer
+	c.templates = tmpls
+	c.format = newArgs.Format
+
+	c.errorsWg.Add(1)
+	go func() {
+		defer c.errorsWg.Done()
+		for err := range producer.Errors() {
+			c.droppedEntriesTotal.WithLabelValues(reasonProduce).Inc()
+			level.Error(c.opts.Logger).Log("msg", "failed to send log entry to Kafka", "topic", err.Msg.Topic, "err", err.Err)
+		}
+	}()
+	return nil
+}
+
+// closeProducer closes the current producer, if any, after flushing its
+// messages. c.mut must be held.
+func (c *Component) closeProducer() {
+	if c.producer == nil {
+		return
+	}
+	// Errors are read until the producer closes its errors channel.
+	c.producer.AsyncClose()
+	c.errorsWg.Wait()
+	c.producer = nil
+}
+
+// buildMessage builds the message sending entry to Kafka. ok is false if the
+// entry was dropped. c.mut must be held.
+func (c *Component) buildMessage(entry loki.Entry) (msg *sarama.ProducerMessage, ok bool) {
+	labels := make(map[string]string, len(entry.Labels))
+	for k, v := range entry.Labels {
+		labels[string(k)] = string(v)
+	}
+
+	topic, err := execute(c.templates.topic, labels)
+	if err != nil || topic == "" {
+		c.droppedEntriesTotal.WithLabelValues(reasonTopic).Inc()
+		level.Debug(c.opts.Logger).Log("msg", "dropping log entry without a topic", "labels", entry.Labels.String(), "err", err)
+		return nil, false
+	}
+	msg = &sarama.ProducerMessage{
+		Topic:     topic,
+		Timestamp: entry.Timestamp,
+	}
+
+	if c.templates.key != nil {
+		key, err := execute(c.templates.key, labels)
+		if err != nil {
+			c.droppedEntriesTotal.WithLabelValues(reasonKey).Inc()
+			level.Debug(c.opts.Logger).Log("msg", "dropping log entry whose key can't be rendered", "labels", entry.Labels.String(), "err", err)
+			return nil, false
+		}
+		// Messages without a key are spread over partitions.
+		if key != "" {
+			msg.Key = sarama.StringEncoder(key)
+		}
+	}
+
+	switch c.format {
+	case formatRaw:
+		msg.Value = sarama.StringEncoder(entry.Line)
+	default:
+		bb, err := json.Marshal(jsonMessage{
+			Timestamp: entry.Timestamp,
+			Labels:    labels,
+			Line:      entry.Line,
+		})
+		if err != nil {
+			c.droppedEntriesTotal.WithLabelValues(reasonEncode).Inc()
+			level.Error(c.opts.Logger).Log("msg", "failed to encode log entry", "err", err)
+			return nil, false
+		}
+		msg.Value = sarama.ByteEncoder(bb)
+	}
+	return msg, true
+}
+
+// jsonMessage is the value of messages when format is json.
+type jsonMessage struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Labels    map[string]string `json:"labels"`
+	Line      string            `json:"line"`
+}
@@ -0,0 +1,171 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		brokers     = ["localhost:9092"]
+		topic       = "logs.{{ .namespace }}"
+		key         = "{{ .pod }}"
+		compression = "zstd"
+
+		authentication {
+			type = "sasl"
+			sasl_config {
+				mechanism = "SCRAM-SHA-512"
+				user      = "agent"
+				password  = "secret"
+			}
+		}
+	`), &args)
+	require.NoError(t, err)
+	require.Equal(t, formatJSON, args.Format)
+	require.Equal(t, "all", args.RequiredAcks)
+
+	cfg, err := args.saramaConfig()
+	require.NoError(t, err)
+	require.Equal(t, sarama.CompressionZSTD, cfg.Producer.Compression)
+	require.Equal(t, sarama.WaitForAll, cfg.Producer.RequiredAcks)
+	require.True(t, cfg.Net.SASL.Enable)
+	require.Equal(t, "agent", cfg.Net.SASL.User)
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect string
+	}{
+		{
+			name:   "invalid topic template",
+			config: "brokers = [\"localhost:9092\"]\ntopic = \"logs.{{ .namespace\"",
+			expect: "invalid topic template",
+		},
+		{
+			name:   "invalid format",
+			config: "brokers = [\"localhost:9092\"]\ntopic = \"logs\"\nformat = \"logfmt\"",
+			expect: `unrecognized format "logfmt"`,
+		},
+		{
+			name:   "invalid required acks",
+			config: "brokers = [\"localhost:9092\"]\ntopic = \"logs\"\nrequired_acks = \"some\"",
+			expect: `unrecognized required_acks "some"`,
+		},
+		{
+			name:   "no brokers",
+			config: "brokers = []\ntopic = \"logs\"",
+			expect: "at least one broker must be set",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestComponent(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		brokers = ["localhost:9092"]
+		topic   = "logs.{{ .namespace }}"
+		key     = "{{ .pod }}"
+	`), &args))
+
+	var producer *mocks.AsyncProducer
+	reg := prometheus.NewRegistry()
+	exports := make(chan Exports, 1)
+	c, err := newComponent(component.Options{
+		ID:            "loki.sink.kafka.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    reg,
+		OnStateChange: func(e component.Exports) { exports <- e.(Exports) },
+	}, args, func(_ []string, cfg *sarama.Config) (sarama.AsyncProducer, error) {
+		producer = mocks.NewAsyncProducer(t, cfg)
+		return producer, nil
+	})
+	require.NoError(t, err)
+
+	ts := time.Date(2023, 5, 2, 10, 0, 0, 0, time.UTC)
+	producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		require.Equal(t, "logs.default", msg.Topic)
+		require.Equal(t, sarama.StringEncoder("api-0"), msg.Key)
+
+		bb, err := msg.Value.Encode()
+		require.NoError(t, err)
+		var value jsonMessage
+		require.NoError(t, json.Unmarshal(bb, &value))
+		require.Equal(t, jsonMessage{
+			Timestamp: ts,
+			Labels:    map[string]string{"namespace": "default", "pod": "api-0"},
+			Line:      "hello",
+		}, value)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, c.Run(ctx))
+	}()
+
+	receiver := (<-exports).Receiver
+	send := func(labels model.LabelSet, line string) {
+		receiver <- loki.Entry{Labels: labels, Entry: logproto.Entry{Timestamp: ts, Line: line}}
+	}
+	send(model.LabelSet{"namespace": "default", "pod": "api-0"}, "hello")
+	// Entries without the labels used by the topic are dropped.
+	send(model.LabelSet{"pod": "api-0"}, "no namespace")
+
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.droppedEntriesTotal.WithLabelValues(reasonTopic)) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, 1.0, testutil.ToFloat64(c.entriesTotal.WithLabelValues("logs.default")))
+
+	// Stopping the component closes the producer, which fails the test if
+	// expectations are left.
+	cancel()
+	<-done
+}
+
+func TestBuildMessage_Raw(t *testing.T) {
+	tmpls, err := (&Arguments{Topic: "logs", Key: "{{ .pod }}"}).templates()
+	require.NoError(t, err)
+	c := &Component{
+		templates: tmpls,
+		format:    formatRaw,
+
+		droppedEntriesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "dropped"}, []string{"reason"}),
+	}
+
+	msg, ok := c.buildMessage(loki.Entry{
+		Labels: model.LabelSet{"job": "test"},
+		Entry:  logproto.Entry{Line: "hello"},
+	})
+	require.True(t, ok)
+	require.Equal(t, "logs", msg.Topic)
+	require.Nil(t, msg.Key) // The entry has no pod label.
+	require.Equal(t, sarama.StringEncoder("hello"), msg.Value)
+}
@@ -0,0 +1,166 @@
+package kafka
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/Shopify/sarama"
+	source_kafka "github.com/grafana/agent/component/loki/source/kafka"
+)
+
+// Arguments holds values which are used to configure the loki.sink.kafka
+// component.
+type Arguments struct {
+	Brokers        []string                         `river:"brokers,attr"`
+	Topic          string                           `river:"topic,attr"`
+	Key            string                           `river:"key,attr,optional"`
+	Format         string                           `river:"format,attr,optional"`
+	Version        string                           `river:"version,attr,optional"`
+	RequiredAcks   string                           `river:"required_acks,attr,optional"`
+	Compression    string                           `river:"compression,attr,optional"`
+	FlushFrequency time.Duration                    `river:"flush_frequency,attr,optional"`
+	MaxRetries     int                              `river:"max_retries,attr,optional"`
+	Authentication source_kafka.KafkaAuthentication `river:"authentication,block,optional"`
+}
+
+// Supported values of the format argument.
+const (
+	formatJSON = "json"
+	formatRaw  = "raw"
+)
+
+// DefaultArguments provides the default arguments for the loki.sink.kafka
+// component.
+var DefaultArguments = Arguments{
+	Format:         formatJSON,
+	Version:        "2.2.1",
+	RequiredAcks:   "all",
+	Compression:    "none",
+	FlushFrequency: 100 * time.Millisecond,
+	MaxRetries:     3,
+	Authentication: source_kafka.DefaultArguments.Authentication,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if len(args.Brokers) == 0 {
+		return fmt.Errorf("at least one broker must be set")
+	}
+	if _, err := args.templates(); err != nil {
+		return err
+	}
+	switch args.Format {
+	case formatJSON, formatRaw:
+	default:
+		return fmt.Errorf("unrecognized format %q, must be one of json or raw", args.Format)
+	}
+	if args.FlushFrequency < 0 {
+		return fmt.Errorf("flush_frequency must not be negative")
+	}
+	if args.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative")
+	}
+	_, err := args.producerConfig()
+	return err
+}
+
+// saramaConfig builds the configuration of the Kafka producer from args,
+// including its authentication.
+func (args *Arguments) saramaConfig() (*sarama.Config, error) {
+	cfg, err := args.producerConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err = args.Authentication.SaramaConfig(*cfg)
+	if err != nil {
+		return nil, fmt.Errorf("authentication: %w", err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// producerConfig builds the configuration of the Kafka producer from args,
+// without its authentication.
+func (args *Arguments) producerConfig() (*sarama.Config, error) {
+	cfg := sarama.NewConfig()
+	cfg.ClientID = "grafana-agent"
+
+	version, err := sarama.ParseKafkaVersion(args.Version)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Version = version
+
+	switch args.RequiredAcks {
+	case "all":
+		cfg.Producer.RequiredAcks = sarama.WaitForAll
+	case "leader":
+		cfg.Producer.RequiredAcks = sarama.WaitForLocal
+	case "none":
+		cfg.Producer.RequiredAcks = sarama.NoResponse
+	default:
+		return nil, fmt.Errorf("unrecognized required_acks %q, must be one of all, leader, or none", args.RequiredAcks)
+	}
+
+	switch args.Compression {
+	case "none":
+		cfg.Producer.Compression = sarama.CompressionNone
+	case "gzip":
+		cfg.Producer.Compression = sarama.CompressionGZIP
+	case "snappy":
+		cfg.Producer.Compression = sarama.CompressionSnappy
+	case "lz4":
+		cfg.Producer.Compression = sarama.CompressionLZ4
+	case "zstd":
+		cfg.Producer.Compression = sarama.CompressionZSTD
+	default:
+		return nil, fmt.Errorf("unrecognized compression %q, must be one of none, gzip, snappy, lz4, or zstd", args.Compression)
+	}
+
+	cfg.Producer.Flush.Frequency = args.FlushFrequency
+	cfg.Producer.Retry.Max = args.MaxRetries
+	cfg.Producer.Return.Errors = true
+	cfg.Producer.Return.Successes = false
+	return cfg, cfg.Validate()
+}
+
+// templates holds the parsed templates of the topic and key of messages.
+type templates struct {
+	topic *template.Template
+	key   *template.Template // nil if messages don't have a key.
+}
+
+func (args *Arguments) templates() (*templates, error) {
+	var (
+		res templates
+		err error
+	)
+	res.topic, err = template.New("topic").Option("missingkey=error").Parse(args.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topic template: %w", err)
+	}
+	if args.Key != "" {
+		// Missing labels are rendered as empty strings in keys, so that entries
+		// without them are still sent.
+		res.key, err = template.New("key").Option("missingkey=zero").Parse(args.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key template: %w", err)
+		}
+	}
+	return &res, nil
+}
+
+// execute renders tmpl with the labels of an entry.
+func execute(tmpl *template.Template, labels map[string]string) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, labels); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
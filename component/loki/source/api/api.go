@@ -32,6 +32,10 @@ type Arguments struct {
 	Labels               map[string]string   `river:"labels,attr,optional"`
 	RelabelRules         relabel.Rules       `river:"relabel_rules,attr,optional"`
 	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+
+	Elasticsearch *lokipush.ElasticsearchConfig `river:"elasticsearch,block,optional"`
+	SplunkHEC     *lokipush.SplunkHECConfig     `river:"splunk_hec,block,optional"`
+	Limits        *lokipush.LimitsConfig        `river:"limits,block,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
@@ -153,6 +157,9 @@ func (c *Component) Update(args component.Arguments) error {
 	c.server.SetLabels(newArgs.labelSet())
 	c.server.SetRelabelRules(newArgs.RelabelRules)
 	c.server.SetKeepTimestamp(newArgs.UseIncomingTimestamp)
+	c.server.SetElasticsearchConfig(newArgs.Elasticsearch)
+	c.server.SetSplunkHECConfig(newArgs.SplunkHEC)
+	c.server.SetLimitsConfig(newArgs.Limits)
 
 	return nil
 }
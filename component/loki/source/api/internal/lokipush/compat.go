@@ -0,0 +1,85 @@
+package lokipush
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+// ElasticsearchConfig enables the endpoint compatible with the Elasticsearch
+// bulk API.
+type ElasticsearchConfig struct {
+	TimestampField string `river:"timestamp_field,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (c *ElasticsearchConfig) SetToDefault() {
+	*c = ElasticsearchConfig{TimestampField: "@timestamp"}
+}
+
+// SplunkHECConfig enables the endpoints compatible with the Splunk HTTP Event
+// Collector.
+type SplunkHECConfig struct {
+	Token rivertypes.Secret `river:"token,attr,optional"`
+}
+
+// SetElasticsearchConfig enables the Elasticsearch bulk API endpoint when cfg
+// isn't nil.
+func (s *PushAPIServer) SetElasticsearchConfig(cfg *ElasticsearchConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.elasticsearch = cfg
+}
+
+func (s *PushAPIServer) getElasticsearchConfig() *ElasticsearchConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.elasticsearch
+}
+
+// SetSplunkHECConfig enables the Splunk HTTP Event Collector endpoints when
+// cfg isn't nil.
+func (s *PushAPIServer) SetSplunkHECConfig(cfg *SplunkHECConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.splunkHEC = cfg
+}
+
+func (s *PushAPIServer) getSplunkHECConfig() *SplunkHECConfig {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.splunkHEC
+}
+
+// finalLabels adds the configured labels to the labels of an entry and
+// relabels them. Labels starting with "__" are dropped after relabeling. The
+// returned bool is false if the entry was dropped.
+func finalLabels(ls model.LabelSet, addLabels model.LabelSet, relabelRules []*relabel.Config) (model.LabelSet, bool) {
+	lb := labels.NewBuilder(nil)
+	for k, v := range ls {
+		lb.Set(string(k), string(v))
+	}
+	for k, v := range addLabels {
+		lb.Set(string(k), string(v))
+	}
+
+	lbls := lb.Labels(nil)
+	sort.Sort(lbls)
+	processed, keep := relabel.Process(lbls, relabelRules...)
+	if !keep || len(processed) == 0 {
+		return nil, false
+	}
+
+	filtered := make(model.LabelSet, len(processed))
+	for _, l := range processed {
+		if strings.HasPrefix(l.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+	}
+	return filtered, true
+}
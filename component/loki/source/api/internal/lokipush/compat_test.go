@@ -0,0 +1,167 @@
+package lokipush
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/common/loki/client/fake"
+	fnet "github.com/grafana/agent/component/common/net"
+	frelabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompatTestServer(t *testing.T) (*PushAPIServer, *fake.Client, string) {
+	eh := fake.NewClient(func() {})
+	t.Cleanup(eh.Stop)
+
+	port := getFreePort(t)
+	serverConfig := &fnet.ServerConfig{
+		HTTP: &fnet.HTTPConfig{
+			ListenAddress: localhost,
+			ListenPort:    port,
+		},
+		GRPC: &fnet.GRPCConfig{ListenPort: getFreePort(t)},
+	}
+
+	pt, err := NewPushAPIServer(log.NewNopLogger(), serverConfig, eh, prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, pt.Run())
+	t.Cleanup(pt.Shutdown)
+
+	pt.SetLabels(model.LabelSet{"pushserver": "compat"})
+	return pt, eh, fmt.Sprintf("http://%s:%d", localhost, port)
+}
+
+func post(t *testing.T, url string, header http.Header, body string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var resp map[string]interface{}
+	_ = json.NewDecoder(res.Body).Decode(&resp)
+	return res.StatusCode, resp
+}
+
+func TestElasticsearchBulk(t *testing.T) {
+	pt, eh, url := newCompatTestServer(t)
+
+	// The endpoint is disabled by default.
+	status, _ := post(t, url+"/_bulk", nil, "")
+	require.Equal(t, http.StatusNotFound, status)
+
+	pt.SetElasticsearchConfig(&ElasticsearchConfig{TimestampField: "@timestamp"})
+	pt.SetKeepTimestamp(true)
+
+	var relabelRule frelabel.Config
+	require.NoError(t, river.Unmarshal([]byte(`
+action        = "replace"
+source_labels = ["__elasticsearch_index"]
+target_label  = "index"
+`), &relabelRule))
+	pt.SetRelabelRules(frelabel.Rules{&relabelRule})
+
+	body := strings.Join([]string{
+		`{"index":{"_index":"logs"}}`,
+		`{"@timestamp":"2023-06-01T10:00:00.5Z","log":"first"}`,
+		`{"create":{}}`,
+		`{"@timestamp":1685613600000,"log":"second"}`,
+		`{"delete":{"_index":"logs","_id":"1"}}`,
+		``,
+	}, "\n")
+	status, resp := post(t, url+"/fluentbit/_bulk", http.Header{"Content-Type": {"application/x-ndjson"}}, body)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, true, resp["errors"])
+	require.Len(t, resp["items"], 3)
+
+	require.Eventually(t, func() bool {
+		return len(eh.Received()) == 2
+	}, 10*time.Second, time.Millisecond)
+
+	entries := eh.Received()
+	require.Equal(t, model.LabelSet{"pushserver": "compat", "index": "logs"}, entries[0].Labels)
+	require.Equal(t, `{"@timestamp":"2023-06-01T10:00:00.5Z","log":"first"}`, entries[0].Line)
+	require.Equal(t, time.Date(2023, 6, 1, 10, 0, 0, 5e8, time.UTC), entries[0].Timestamp.UTC())
+
+	// The index of the path is used when actions don't target an index.
+	require.Equal(t, model.LabelSet{"pushserver": "compat", "index": "fluentbit"}, entries[1].Labels)
+	require.Equal(t, time.UnixMilli(1685613600000), entries[1].Timestamp)
+
+	// Malformed requests are rejected.
+	status, _ = post(t, url+"/_bulk", nil, "not json\n")
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestSplunkHEC(t *testing.T) {
+	pt, eh, url := newCompatTestServer(t)
+
+	status, _ := post(t, url+"/services/collector/event", nil, "")
+	require.Equal(t, http.StatusNotFound, status)
+
+	pt.SetSplunkHECConfig(&SplunkHECConfig{Token: "s3cr3t"})
+	pt.SetKeepTimestamp(true)
+
+	var relabelRule frelabel.Config
+	require.NoError(t, river.Unmarshal([]byte(`
+action        = "replace"
+source_labels = ["__splunk_sourcetype"]
+target_label  = "sourcetype"
+`), &relabelRule))
+	pt.SetRelabelRules(frelabel.Rules{&relabelRule})
+
+	// Requests must carry the token.
+	status, resp := post(t, url+"/services/collector/event", nil, `{"event":"hello"}`)
+	require.Equal(t, http.StatusUnauthorized, status)
+	require.Equal(t, float64(hecCodeTokenMissing), resp["code"])
+
+	status, resp = post(t, url+"/services/collector/event", http.Header{"Authorization": {"Splunk wrong"}}, `{"event":"hello"}`)
+	require.Equal(t, http.StatusForbidden, status)
+	require.Equal(t, float64(hecCodeInvalidToken), resp["code"])
+
+	auth := http.Header{"Authorization": {"Splunk s3cr3t"}}
+
+	// Events are concatenated JSON objects.
+	status, resp = post(t, url+"/services/collector/event", auth,
+		`{"time":1685613600.25,"sourcetype":"syslog","event":"hello"}{"event":{"message":"structured"}}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, float64(hecCodeSuccess), resp["code"])
+
+	status, _ = post(t, url+"/services/collector/raw?sourcetype=raw", auth, "line1\nline2\n")
+	require.Equal(t, http.StatusOK, status)
+
+	require.Eventually(t, func() bool {
+		return len(eh.Received()) == 4
+	}, 10*time.Second, time.Millisecond)
+
+	entries := eh.Received()
+	require.Equal(t, "hello", entries[0].Line)
+	require.Equal(t, model.LabelSet{"pushserver": "compat", "sourcetype": "syslog"}, entries[0].Labels)
+	require.Equal(t, time.Unix(1685613600, 25e7), entries[0].Timestamp)
+	require.Equal(t, `{"message":"structured"}`, entries[1].Line)
+	require.Equal(t, model.LabelSet{"pushserver": "compat"}, entries[1].Labels)
+	require.Equal(t, "line1", entries[2].Line)
+	require.Equal(t, model.LabelSet{"pushserver": "compat", "sourcetype": "raw"}, entries[2].Labels)
+
+	// Invalid events reject the whole request.
+	status, resp = post(t, url+"/services/collector/event", auth, `{"event":"valid"}{"time":1}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, float64(hecCodeNoEvent), resp["code"])
+	require.Len(t, eh.Received(), 4)
+
+	res, err := http.Get(url + "/services/collector/health")
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+}
@@ -0,0 +1,233 @@
+package lokipush
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+)
+
+// elasticsearchVersion is the version of Elasticsearch reported to clients
+// detecting the version of the cluster they send documents to.
+const elasticsearchVersion = "8.0.0"
+
+// bulkItem is the result of an action of a bulk request.
+type bulkItem struct {
+	Index  string     `json:"_index,omitempty"`
+	Status int        `json:"status"`
+	Result string     `json:"result,omitempty"`
+	Error  *bulkError `json:"error,omitempty"`
+}
+
+type bulkError struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+type bulkResponse struct {
+	Took   int                   `json:"took"`
+	Errors bool                  `json:"errors"`
+	Items  []map[string]bulkItem `json:"items"`
+}
+
+// handleElasticsearchBulk handles requests to the Elasticsearch bulk API,
+// such as the ones sent by the es output of Fluent Bit. The source of each
+// indexed document becomes the line of an entry.
+func (s *PushAPIServer) handleElasticsearchBulk(w http.ResponseWriter, r *http.Request) {
+	cfg := s.getElasticsearchConfig()
+	if cfg == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	defer r.Body.Close()
+
+	// Take snapshot of current configs and apply consistently for the entire request.
+	addLabels := s.getLabels()
+	relabelRules := s.getRelabelRules()
+	keepTimestamp := s.getKeepTimestamp()
+
+	start := time.Now()
+	defaultIndex := mux.Vars(r)["index"]
+	resp := bulkResponse{Items: []map[string]bulkItem{}}
+	var entries []loki.Entry
+
+	reader := bufio.NewReader(r.Body)
+	for {
+		actionLine, err := readBulkLine(reader)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			level.Warn(s.logger).Log("msg", "failed to read incoming bulk request", "err", err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		action, index, err := parseBulkAction(actionLine)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if index == "" {
+			index = defaultIndex
+		}
+
+		var item bulkItem
+		switch action {
+		case "index", "create":
+			doc, err := readBulkLine(reader)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("missing source of %s action", action), http.StatusBadRequest)
+				return
+			}
+			item = bulkItem{Index: index, Status: http.StatusCreated, Result: "created"}
+
+			ls, keep := finalLabels(model.LabelSet{"__elasticsearch_index": model.LabelValue(index)}, addLabels, relabelRules)
+			if !keep {
+				break
+			}
+			e := loki.Entry{
+				Labels: ls,
+				Entry: logproto.Entry{
+					Timestamp: time.Now(),
+					Line:      string(doc),
+				},
+			}
+			if keepTimestamp {
+				if ts, ok := documentTimestamp(doc, cfg.TimestampField); ok {
+					e.Timestamp = ts
+				}
+			}
+			entries = append(entries, e)
+
+		case "update":
+			// Skip the partial document of the update.
+			if _, err := readBulkLine(reader); err != nil {
+				http.Error(w, "missing source of update action", http.StatusBadRequest)
+				return
+			}
+			fallthrough
+		default:
+			resp.Errors = true
+			item = bulkItem{
+				Index:  index,
+				Status: http.StatusBadRequest,
+				Error: &bulkError{
+					Type:   "illegal_argument_exception",
+					Reason: fmt.Sprintf("unsupported bulk action %q", action),
+				},
+			}
+		}
+		resp.Items = append(resp.Items, map[string]bulkItem{action: item})
+	}
+
+	// Documents are only forwarded once the whole request is accepted, as
+	// senders retry rejected requests.
+	if err := s.checkLimits(r, entries); err != nil {
+		s.writeLimitError(w, err)
+		return
+	}
+	for _, e := range entries {
+		s.handler.Chan() <- e
+	}
+
+	resp.Took = int(time.Since(start).Milliseconds())
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		level.Error(s.logger).Log("msg", "failed to respond to bulk request", "err", err)
+	}
+}
+
+// handleElasticsearchInfo responds to clients detecting the version of
+// Elasticsearch before sending requests.
+func (s *PushAPIServer) handleElasticsearchInfo(w http.ResponseWriter, r *http.Request) {
+	if s.getElasticsearchConfig() == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	resp := map[string]interface{}{
+		"name":         "grafana-agent",
+		"cluster_name": "grafana-agent",
+		"version":      map[string]string{"number": elasticsearchVersion},
+		"tagline":      "You Know, for Search",
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		level.Error(s.logger).Log("msg", "failed to respond to info request", "err", err)
+	}
+}
+
+// readBulkLine returns the next non-empty line of a bulk request.
+func readBulkLine(r *bufio.Reader) ([]byte, error) {
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			return line, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseBulkAction returns the action of an action line of a bulk request, and
+// the index it targets.
+func parseBulkAction(line []byte) (action string, index string, err error) {
+	var actions map[string]struct {
+		Index string `json:"_index"`
+	}
+	if err := json.Unmarshal(line, &actions); err != nil {
+		return "", "", fmt.Errorf("malformed action line: %w", err)
+	}
+	if len(actions) != 1 {
+		return "", "", fmt.Errorf("malformed action line: expected a single action, got %d", len(actions))
+	}
+	for action, meta := range actions {
+		return action, meta.Index, nil
+	}
+	return "", "", nil
+}
+
+// documentTimestamp returns the timestamp held by field of a document. Both
+// RFC3339 dates and milliseconds since the epoch are supported, which are the
+// default date formats of Elasticsearch.
+func documentTimestamp(doc []byte, field string) (time.Time, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return time.Time{}, false
+	}
+	raw, ok := fields[field]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return ts, true
+		}
+		if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.UnixMilli(ms), true
+		}
+		return time.Time{}, false
+	}
+
+	var ms float64
+	if err := json.Unmarshal(raw, &ms); err == nil {
+		return time.UnixMilli(int64(ms)), true
+	}
+	return time.Time{}, false
+}
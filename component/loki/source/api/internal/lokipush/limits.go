@@ -0,0 +1,294 @@
+package lokipush
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"golang.org/x/time/rate"
+)
+
+// tenantHeader is the header holding the tenant of a push request. Requests
+// without it share the limits of the empty tenant.
+const tenantHeader = "X-Scope-OrgID"
+
+// maxIdleTenants is the number of tenants tracked before the limiters of idle
+// tenants are discarded, so that clients sending arbitrary tenants can't grow
+// the tracked tenants without bounds.
+const maxIdleTenants = 1000
+
+// Reasons of rejected requests.
+const (
+	reasonRateLimited     = "rate_limited"
+	reasonRequestTooLarge = "request_too_large"
+	reasonLineTooLong     = "line_too_long"
+	reasonTooManyStreams  = "too_many_streams"
+)
+
+// LimitsConfig limits the entries accepted from each tenant. Limits which
+// are zero are disabled.
+type LimitsConfig struct {
+	LinesPerSecond       float64          `river:"lines_per_second,attr,optional"`
+	LinesBurst           int              `river:"lines_burst,attr,optional"`
+	BytesPerSecond       units.Base2Bytes `river:"bytes_per_second,attr,optional"`
+	BytesBurst           units.Base2Bytes `river:"bytes_burst,attr,optional"`
+	MaxLineSize          units.Base2Bytes `river:"max_line_size,attr,optional"`
+	MaxStreamsPerRequest int              `river:"max_streams_per_request,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (c *LimitsConfig) Validate() error {
+	switch {
+	case c.LinesPerSecond < 0:
+		return fmt.Errorf("lines_per_second must not be negative")
+	case c.LinesBurst < 0:
+		return fmt.Errorf("lines_burst must not be negative")
+	case c.BytesPerSecond < 0:
+		return fmt.Errorf("bytes_per_second must not be negative")
+	case c.BytesBurst < 0:
+		return fmt.Errorf("bytes_burst must not be negative")
+	case c.MaxLineSize < 0:
+		return fmt.Errorf("max_line_size must not be negative")
+	case c.MaxStreamsPerRequest < 0:
+		return fmt.Errorf("max_streams_per_request must not be negative")
+	case c.LinesBurst > 0 && c.LinesPerSecond == 0:
+		return fmt.Errorf("lines_burst requires lines_per_second to be set")
+	case c.BytesBurst > 0 && c.BytesPerSecond == 0:
+		return fmt.Errorf("bytes_burst requires bytes_per_second to be set")
+	}
+	return nil
+}
+
+// limitError is returned when a request is rejected by the limits.
+type limitError struct {
+	status     int
+	reason     string
+	retryAfter time.Duration
+	msg        string
+}
+
+func (e *limitError) Error() string { return e.msg }
+
+// writeHeaders sets the Retry-After header of responses to rate limited
+// requests.
+func (e *limitError) writeHeaders(w http.ResponseWriter) {
+	if e.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(e.retryAfter.Seconds()))))
+	}
+}
+
+// limitsMetrics counts the requests and entries rejected by the limits.
+type limitsMetrics struct {
+	rejectedRequests *prometheus.CounterVec
+	rejectedEntries  *prometheus.CounterVec
+	rejectedBytes    *prometheus.CounterVec
+}
+
+func newLimitsMetrics(reg prometheus.Registerer) *limitsMetrics {
+	m := &limitsMetrics{
+		rejectedRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_api_rejected_requests_total",
+			Help: "Number of push requests rejected by the limits.",
+		}, []string{"tenant", "reason"}),
+		rejectedEntries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_api_rejected_entries_total",
+			Help: "Number of entries of push requests rejected by the limits.",
+		}, []string{"tenant", "reason"}),
+		rejectedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_api_rejected_bytes_total",
+			Help: "Number of bytes of entry lines of push requests rejected by the limits.",
+		}, []string{"tenant", "reason"}),
+	}
+	if reg != nil {
+		reg.MustRegister(m.rejectedRequests, m.rejectedEntries, m.rejectedBytes)
+	}
+	return m
+}
+
+// limiter enforces a LimitsConfig, keeping separate rate limiters for every
+// tenant.
+type limiter struct {
+	cfg LimitsConfig
+
+	mut     sync.Mutex
+	tenants map[string]*tenantLimiter
+}
+
+type tenantLimiter struct {
+	lines, bytes *rate.Limiter // nil when the rate isn't limited.
+}
+
+// newLimiter returns a limiter for cfg. A nil limiter accepts everything.
+func newLimiter(cfg *LimitsConfig) *limiter {
+	if cfg == nil {
+		return nil
+	}
+	return &limiter{cfg: *cfg, tenants: make(map[string]*tenantLimiter)}
+}
+
+// check returns an error if the entries of a request from tenant exceed the
+// limits. Requests are accepted or rejected as a whole, so that clients can
+// retry rejected requests without sending duplicates.
+func (l *limiter) check(tenant string, entries []loki.Entry) *limitError {
+	if l == nil || len(entries) == 0 {
+		return nil
+	}
+
+	var size int
+	streams := make(map[model.Fingerprint]struct{})
+	for _, e := range entries {
+		if max := int(l.cfg.MaxLineSize); max > 0 && len(e.Line) > max {
+			return &limitError{
+				status: http.StatusBadRequest,
+				reason: reasonLineTooLong,
+				msg:    fmt.Sprintf("line of %d bytes for stream %s exceeds the max_line_size of %d bytes", len(e.Line), e.Labels, max),
+			}
+		}
+		size += len(e.Line)
+		streams[e.Labels.Fingerprint()] = struct{}{}
+	}
+	if max := l.cfg.MaxStreamsPerRequest; max > 0 && len(streams) > max {
+		return &limitError{
+			status: http.StatusBadRequest,
+			reason: reasonTooManyStreams,
+			msg:    fmt.Sprintf("request contains %d streams, exceeding the max_streams_per_request of %d", len(streams), max),
+		}
+	}
+
+	now := time.Now()
+	return l.tenant(tenant, now).reserve(len(entries), size, now)
+}
+
+func (l *limiter) tenant(tenant string, now time.Time) *tenantLimiter {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	if t, ok := l.tenants[tenant]; ok {
+		return t
+	}
+	if len(l.tenants) >= maxIdleTenants {
+		// Limiters which are full behave like new ones, so they can be
+		// discarded.
+		for name, t := range l.tenants {
+			if t.idle(now) {
+				delete(l.tenants, name)
+			}
+		}
+	}
+
+	t := &tenantLimiter{}
+	if l.cfg.LinesPerSecond > 0 {
+		burst := l.cfg.LinesBurst
+		if burst == 0 {
+			burst = int(math.Ceil(l.cfg.LinesPerSecond))
+		}
+		t.lines = rate.NewLimiter(rate.Limit(l.cfg.LinesPerSecond), burst)
+	}
+	if l.cfg.BytesPerSecond > 0 {
+		burst := l.cfg.BytesBurst
+		if burst == 0 {
+			burst = l.cfg.BytesPerSecond
+		}
+		t.bytes = rate.NewLimiter(rate.Limit(l.cfg.BytesPerSecond), int(burst))
+	}
+	l.tenants[tenant] = t
+	return t
+}
+
+func (t *tenantLimiter) idle(now time.Time) bool {
+	for _, lim := range []*rate.Limiter{t.lines, t.bytes} {
+		if lim != nil && lim.TokensAt(now) < float64(lim.Burst()) {
+			return false
+		}
+	}
+	return true
+}
+
+// reserve takes lines and bytes from the rate limiters of the tenant. Nothing
+// is taken if either of them doesn't allow the request.
+func (t *tenantLimiter) reserve(lines, bytes int, now time.Time) *limitError {
+	var reserved []*rate.Reservation
+	cancel := func() {
+		for _, r := range reserved {
+			r.CancelAt(now)
+		}
+	}
+
+	for _, c := range []struct {
+		lim  *rate.Limiter
+		n    int
+		unit string
+	}{
+		{t.lines, lines, "lines"},
+		{t.bytes, bytes, "bytes"},
+	} {
+		if c.lim == nil {
+			continue
+		}
+		if c.n > c.lim.Burst() {
+			// The request would never be accepted, so it mustn't be retried.
+			cancel()
+			return &limitError{
+				status: http.StatusBadRequest,
+				reason: reasonRequestTooLarge,
+				msg:    fmt.Sprintf("request of %d %s exceeds the burst of %d %s", c.n, c.unit, c.lim.Burst(), c.unit),
+			}
+		}
+		r := c.lim.ReserveN(now, c.n)
+		if delay := r.DelayFrom(now); delay > 0 {
+			r.CancelAt(now)
+			cancel()
+			return &limitError{
+				status:     http.StatusTooManyRequests,
+				reason:     reasonRateLimited,
+				retryAfter: delay,
+				msg:        fmt.Sprintf("ingestion rate limit of %g %s per second exceeded while adding %d %s", float64(c.lim.Limit()), c.unit, c.n, c.unit),
+			}
+		}
+		reserved = append(reserved, r)
+	}
+	return nil
+}
+
+// SetLimitsConfig sets the limits of accepted requests. Changing the limits
+// resets the rate limiters of all tenants.
+func (s *PushAPIServer) SetLimitsConfig(cfg *LimitsConfig) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	if s.limiter == nil && cfg == nil || s.limiter != nil && cfg != nil && s.limiter.cfg == *cfg {
+		return
+	}
+	s.limiter = newLimiter(cfg)
+}
+
+func (s *PushAPIServer) getLimiter() *limiter {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.limiter
+}
+
+// checkLimits returns an error if the entries of r exceed the limits, and
+// counts the rejected entries.
+func (s *PushAPIServer) checkLimits(r *http.Request, entries []loki.Entry) *limitError {
+	tenant := r.Header.Get(tenantHeader)
+	err := s.getLimiter().check(tenant, entries)
+	if err == nil {
+		return nil
+	}
+
+	var size int
+	for _, e := range entries {
+		size += len(e.Line)
+	}
+	s.limitsMetrics.rejectedRequests.WithLabelValues(tenant, err.reason).Inc()
+	s.limitsMetrics.rejectedEntries.WithLabelValues(tenant, err.reason).Add(float64(len(entries)))
+	s.limitsMetrics.rejectedBytes.WithLabelValues(tenant, err.reason).Add(float64(size))
+	return err
+}
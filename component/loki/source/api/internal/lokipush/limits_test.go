@@ -0,0 +1,129 @@
+package lokipush
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func testEntries(stream string, lines ...string) []loki.Entry {
+	entries := make([]loki.Entry, 0, len(lines))
+	for _, line := range lines {
+		entries = append(entries, loki.Entry{
+			Labels: model.LabelSet{"stream": model.LabelValue(stream)},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: line},
+		})
+	}
+	return entries
+}
+
+func TestLimitsConfig(t *testing.T) {
+	var cfg LimitsConfig
+	require.NoError(t, river.Unmarshal([]byte(`
+		lines_per_second        = 100
+		bytes_per_second        = "1MiB"
+		bytes_burst             = "4MiB"
+		max_line_size           = "256KiB"
+		max_streams_per_request = 10
+	`), &cfg))
+	require.Equal(t, LimitsConfig{
+		LinesPerSecond:       100,
+		BytesPerSecond:       1 << 20,
+		BytesBurst:           4 << 20,
+		MaxLineSize:          256 << 10,
+		MaxStreamsPerRequest: 10,
+	}, cfg)
+
+	err := river.Unmarshal([]byte(`lines_burst = 10`), &LimitsConfig{})
+	require.ErrorContains(t, err, "lines_burst requires lines_per_second to be set")
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(&LimitsConfig{
+		LinesPerSecond:       0.001,
+		LinesBurst:           4,
+		BytesPerSecond:       1,
+		BytesBurst:           10,
+		MaxLineSize:          5,
+		MaxStreamsPerRequest: 1,
+	})
+
+	err := l.check("", testEntries("a", "too long"))
+	require.Equal(t, reasonLineTooLong, err.reason)
+	require.Equal(t, http.StatusBadRequest, err.status)
+
+	err = l.check("", append(testEntries("a", "1"), testEntries("b", "2")...))
+	require.Equal(t, reasonTooManyStreams, err.reason)
+
+	err = l.check("", testEntries("a", "1", "2", "3", "4", "5"))
+	require.Equal(t, reasonRequestTooLarge, err.reason)
+
+	require.Nil(t, l.check("", testEntries("a", "1", "2")))
+
+	// Rejected requests don't take lines from the limiter: the bytes of this
+	// request exceed the bytes left, but the lines which are left are still
+	// accepted afterwards.
+	err = l.check("", testEntries("a", "12345", "12345"))
+	require.Equal(t, reasonRateLimited, err.reason)
+	require.Equal(t, http.StatusTooManyRequests, err.status)
+	require.Greater(t, err.retryAfter, time.Duration(0))
+	require.Nil(t, l.check("", testEntries("a", "3", "4")))
+
+	err = l.check("", testEntries("a", "5"))
+	require.Equal(t, reasonRateLimited, err.reason)
+
+	// Tenants are limited separately.
+	require.Nil(t, l.check("tenant-2", testEntries("a", "1", "2", "3")))
+
+	// A nil limiter accepts everything.
+	var none *limiter
+	require.Nil(t, none.check("", testEntries("a", strings.Repeat("x", 1<<20))))
+}
+
+func TestLimits(t *testing.T) {
+	pt, eh, url := newCompatTestServer(t)
+	pt.SetLimitsConfig(&LimitsConfig{LinesPerSecond: 0.001, LinesBurst: 2})
+
+	status, _ := post(t, url+"/api/v1/raw", nil, "line1\nline2\n")
+	require.Equal(t, http.StatusNoContent, status)
+
+	req, err := http.NewRequest(http.MethodPost, url+"/api/v1/raw", strings.NewReader("line3\n"))
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	require.NotEmpty(t, res.Header.Get("Retry-After"))
+
+	status, _ = post(t, url+"/api/v1/raw", http.Header{tenantHeader: {"other"}}, "line4\n")
+	require.Equal(t, http.StatusNoContent, status)
+
+	require.Eventually(t, func() bool {
+		return len(eh.Received()) == 3
+	}, 10*time.Second, time.Millisecond)
+	require.Equal(t, float64(1), testutil.ToFloat64(pt.limitsMetrics.rejectedRequests.WithLabelValues("", reasonRateLimited)))
+	require.Equal(t, float64(1), testutil.ToFloat64(pt.limitsMetrics.rejectedEntries.WithLabelValues("", reasonRateLimited)))
+
+	// Setting the same limits again keeps the state of the limiters.
+	pt.SetLimitsConfig(&LimitsConfig{LinesPerSecond: 0.001, LinesBurst: 2})
+	status, _ = post(t, url+"/api/v1/raw", nil, "line5\n")
+	require.Equal(t, http.StatusTooManyRequests, status)
+
+	// Compatibility endpoints enforce the limits too.
+	pt.SetSplunkHECConfig(&SplunkHECConfig{})
+	status, resp := post(t, url+"/services/collector/event", nil, `{"event":"hello"}`)
+	require.Equal(t, http.StatusTooManyRequests, status)
+	require.Equal(t, float64(hecCodeServerBusy), resp["code"])
+
+	pt.SetLimitsConfig(nil)
+	status, _ = post(t, url+"/api/v1/raw", nil, "line6\n")
+	require.Equal(t, http.StatusNoContent, status)
+}
@@ -36,6 +36,11 @@ type PushAPIServer struct {
 	labels        model.LabelSet
 	relabelRules  []*relabel.Config
 	keepTimestamp bool
+	elasticsearch *ElasticsearchConfig
+	splunkHEC     *SplunkHECConfig
+	limiter       *limiter
+
+	limitsMetrics *limitsMetrics
 }
 
 func NewPushAPIServer(logger log.Logger,
@@ -45,9 +50,10 @@ func NewPushAPIServer(logger log.Logger,
 ) (*PushAPIServer, error) {
 
 	s := &PushAPIServer{
-		logger:       logger,
-		serverConfig: serverConfig,
-		handler:      handler,
+		logger:        logger,
+		serverConfig:  serverConfig,
+		handler:       handler,
+		limitsMetrics: newLimitsMetrics(registerer),
 	}
 
 	srv, err := fnet.NewTargetServer(logger, "loki_source_api", registerer, serverConfig)
@@ -66,6 +72,18 @@ func (s *PushAPIServer) Run() error {
 		router.Path("/api/v1/push").Methods("POST").Handler(http.HandlerFunc(s.handleLoki))
 		router.Path("/api/v1/raw").Methods("POST").Handler(http.HandlerFunc(s.handlePlaintext))
 		router.Path("/ready").Methods("GET").Handler(http.HandlerFunc(s.ready))
+
+		// Compatibility endpoints respond with 404 unless they're enabled.
+		router.Path("/").Methods("GET", "HEAD").Handler(http.HandlerFunc(s.handleElasticsearchInfo))
+		router.Path("/_bulk").Methods("POST", "PUT").Handler(http.HandlerFunc(s.handleElasticsearchBulk))
+		router.Path("/{index}/_bulk").Methods("POST", "PUT").Handler(http.HandlerFunc(s.handleElasticsearchBulk))
+		router.Path("/services/collector").Methods("POST").Handler(http.HandlerFunc(s.handleSplunkEvent))
+		router.Path("/services/collector/event").Methods("POST").Handler(http.HandlerFunc(s.handleSplunkEvent))
+		router.Path("/services/collector/event/1.0").Methods("POST").Handler(http.HandlerFunc(s.handleSplunkEvent))
+		router.Path("/services/collector/raw").Methods("POST").Handler(http.HandlerFunc(s.handleSplunkRaw))
+		router.Path("/services/collector/raw/1.0").Methods("POST").Handler(http.HandlerFunc(s.handleSplunkRaw))
+		router.Path("/services/collector/health").Methods("GET").Handler(http.HandlerFunc(s.handleSplunkHealth))
+		router.Path("/services/collector/health/1.0").Methods("GET").Handler(http.HandlerFunc(s.handleSplunkHealth))
 	})
 	return err
 }
@@ -137,7 +155,10 @@ func (s *PushAPIServer) handleLoki(w http.ResponseWriter, r *http.Request) {
 	relabelRules := s.getRelabelRules()
 	keepTimestamp := s.getKeepTimestamp()
 
-	var lastErr error
+	var (
+		entries []loki.Entry
+		lastErr error
+	)
 	for _, stream := range req.Streams {
 		ls, err := promql_parser.ParseMetric(stream.Labels)
 		if err != nil {
@@ -181,10 +202,18 @@ func (s *PushAPIServer) handleLoki(w http.ResponseWriter, r *http.Request) {
 			} else {
 				e.Timestamp = time.Now()
 			}
-			s.handler.Chan() <- e
+			entries = append(entries, e)
 		}
 	}
 
+	if err := s.checkLimits(r, entries); err != nil {
+		s.writeLimitError(w, err)
+		return
+	}
+	for _, e := range entries {
+		s.handler.Chan() <- e
+	}
+
 	if lastErr != nil {
 		level.Warn(s.logger).Log("msg", "at least one entry in the push request failed to process", "err", lastErr.Error())
 		http.Error(w, lastErr.Error(), http.StatusBadRequest)
@@ -197,10 +226,10 @@ func (s *PushAPIServer) handleLoki(w http.ResponseWriter, r *http.Request) {
 // NOTE: This code is copied from Promtail (3478e180211c17bfe2f3f3305f668d5520f40481) with changes kept to the minimum.
 // Only the HTTP handler functions are copied to allow for flow-specific server configuration and lifecycle management.
 func (s *PushAPIServer) handlePlaintext(w http.ResponseWriter, r *http.Request) {
-	entries := s.handler.Chan()
 	defer r.Body.Close()
 	body := bufio.NewReader(r.Body)
 	addLabels := s.getLabels()
+	var entries []loki.Entry
 	for {
 		line, err := body.ReadString('\n')
 		if err != nil && err != io.EOF {
@@ -215,21 +244,36 @@ func (s *PushAPIServer) handlePlaintext(w http.ResponseWriter, r *http.Request)
 			}
 			continue
 		}
-		entries <- loki.Entry{
+		entries = append(entries, loki.Entry{
 			Labels: addLabels,
 			Entry: logproto.Entry{
 				Timestamp: time.Now(),
 				Line:      line,
 			},
-		}
+		})
 		if err == io.EOF {
 			break
 		}
 	}
 
+	if err := s.checkLimits(r, entries); err != nil {
+		s.writeLimitError(w, err)
+		return
+	}
+	for _, e := range entries {
+		s.handler.Chan() <- e
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// writeLimitError responds to a request rejected by the limits.
+func (s *PushAPIServer) writeLimitError(w http.ResponseWriter, err *limitError) {
+	level.Debug(s.logger).Log("msg", "rejected push request exceeding limits", "reason", err.reason, "err", err.msg)
+	err.writeHeaders(w)
+	http.Error(w, err.msg, err.status)
+}
+
 // NOTE: This code is copied from Promtail (3478e180211c17bfe2f3f3305f668d5520f40481) with changes kept to the minimum.
 // Only the HTTP handler functions are copied to allow for flow-specific server configuration and lifecycle management.
 func (s *PushAPIServer) ready(w http.ResponseWriter, r *http.Request) {
@@ -6,6 +6,7 @@ package lokipush
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"net"
@@ -198,6 +199,59 @@ func TestPlaintextPushTarget(t *testing.T) {
 	pt.Shutdown()
 }
 
+func TestCompressedPlaintextPushTarget(t *testing.T) {
+	w := log.NewSyncWriter(os.Stderr)
+	logger := log.NewLogfmtLogger(w)
+
+	eh := fake.NewClient(func() {})
+	defer eh.Stop()
+
+	port := getFreePort(t)
+	serverConfig := &fnet.ServerConfig{
+		HTTP: &fnet.HTTPConfig{
+			ListenAddress: localhost,
+			ListenPort:    port,
+		},
+		GRPC: &fnet.GRPCConfig{ListenPort: getFreePort(t)},
+	}
+
+	pt, err := NewPushAPIServer(logger, serverConfig, eh, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	err = pt.Run()
+	require.NoError(t, err)
+	defer pt.Shutdown()
+
+	var body bytes.Buffer
+	gw := gzip.NewWriter(&body)
+	_, err = gw.Write([]byte("line1\nline2\n"))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s:%d/api/v1/raw", localhost, port), &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	require.Eventually(t, func() bool {
+		return len(eh.Received()) == 2
+	}, 10*time.Second, time.Millisecond)
+	require.Equal(t, "line1", eh.Received()[0].Line)
+	require.Equal(t, "line2", eh.Received()[1].Line)
+
+	// Unsupported encodings are rejected.
+	req, err = http.NewRequest(http.MethodPost, fmt.Sprintf("http://%s:%d/api/v1/raw", localhost, port), bytes.NewBufferString("line3"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "lzw")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+}
+
 func TestReady(t *testing.T) {
 	w := log.NewSyncWriter(os.Stderr)
 	logger := log.NewLogfmtLogger(w)
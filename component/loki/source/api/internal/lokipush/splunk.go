@@ -0,0 +1,270 @@
+package lokipush
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+)
+
+// Status codes of the Splunk HTTP Event Collector.
+const (
+	hecCodeSuccess      = 0
+	hecCodeTokenMissing = 2
+	hecCodeInvalidToken = 4
+	hecCodeNoData       = 5
+	hecCodeInvalidData  = 6
+	hecCodeServerBusy   = 9
+	hecCodeNoEvent      = 12
+	hecCodeHealthy      = 17
+)
+
+// hecEvent is an event sent to the event endpoint of the Splunk HTTP Event
+// Collector.
+type hecEvent struct {
+	Time       json.RawMessage `json:"time"`
+	Host       string          `json:"host"`
+	Source     string          `json:"source"`
+	SourceType string          `json:"sourcetype"`
+	Index      string          `json:"index"`
+	Event      json.RawMessage `json:"event"`
+}
+
+type hecResponse struct {
+	Text string `json:"text"`
+	Code int    `json:"code"`
+}
+
+// handleSplunkEvent handles requests to the event endpoint of the Splunk
+// HTTP Event Collector. The event of each request becomes the line of an
+// entry.
+func (s *PushAPIServer) handleSplunkEvent(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeSplunk(w, r) {
+		return
+	}
+	defer r.Body.Close()
+
+	// Take snapshot of current configs and apply consistently for the entire request.
+	addLabels := s.getLabels()
+	relabelRules := s.getRelabelRules()
+	keepTimestamp := s.getKeepTimestamp()
+
+	// Events are concatenated JSON objects, which may not be separated by
+	// newlines.
+	dec := json.NewDecoder(r.Body)
+	var entries []loki.Entry
+	for {
+		var ev hecEvent
+		if err := dec.Decode(&ev); err == io.EOF {
+			break
+		} else if err != nil {
+			writeSplunkResponse(w, http.StatusBadRequest, "Invalid data format", hecCodeInvalidData)
+			return
+		}
+		if len(ev.Event) == 0 {
+			writeSplunkResponse(w, http.StatusBadRequest, "Event field is required", hecCodeNoEvent)
+			return
+		}
+
+		ls, keep := finalLabels(splunkLabels(ev.Host, ev.Source, ev.SourceType, ev.Index), addLabels, relabelRules)
+		if !keep {
+			continue
+		}
+		e := loki.Entry{
+			Labels: ls,
+			Entry: logproto.Entry{
+				Timestamp: time.Now(),
+				Line:      eventLine(ev.Event),
+			},
+		}
+		if keepTimestamp {
+			if ts, ok := eventTimestamp(ev.Time); ok {
+				e.Timestamp = ts
+			}
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 && dec.InputOffset() == 0 {
+		writeSplunkResponse(w, http.StatusBadRequest, "No data", hecCodeNoData)
+		return
+	}
+
+	// Events are only forwarded once the whole request is valid, as senders
+	// retry rejected requests.
+	if err := s.checkLimits(r, entries); err != nil {
+		writeSplunkLimitError(w, err)
+		return
+	}
+	for _, e := range entries {
+		s.handler.Chan() <- e
+	}
+	writeSplunkResponse(w, http.StatusOK, "Success", hecCodeSuccess)
+}
+
+// handleSplunkRaw handles requests to the raw endpoint of the Splunk HTTP
+// Event Collector. Each line of the request becomes an entry.
+func (s *PushAPIServer) handleSplunkRaw(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeSplunk(w, r) {
+		return
+	}
+	defer r.Body.Close()
+
+	addLabels := s.getLabels()
+	relabelRules := s.getRelabelRules()
+
+	q := r.URL.Query()
+	ls, keep := finalLabels(splunkLabels(q.Get("host"), q.Get("source"), q.Get("sourcetype"), q.Get("index")), addLabels, relabelRules)
+
+	reader := bufio.NewReader(r.Body)
+	var entries []loki.Entry
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			level.Warn(s.logger).Log("msg", "failed to read incoming raw request", "err", err.Error())
+			writeSplunkResponse(w, http.StatusBadRequest, "Invalid data format", hecCodeInvalidData)
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" && keep {
+			entries = append(entries, loki.Entry{
+				Labels: ls.Clone(),
+				Entry: logproto.Entry{
+					Timestamp: time.Now(),
+					Line:      line,
+				},
+			})
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if err := s.checkLimits(r, entries); err != nil {
+		writeSplunkLimitError(w, err)
+		return
+	}
+	for _, e := range entries {
+		s.handler.Chan() <- e
+	}
+	writeSplunkResponse(w, http.StatusOK, "Success", hecCodeSuccess)
+}
+
+// handleSplunkHealth handles requests to the health endpoint of the Splunk
+// HTTP Event Collector.
+func (s *PushAPIServer) handleSplunkHealth(w http.ResponseWriter, r *http.Request) {
+	if s.getSplunkHECConfig() == nil {
+		http.NotFound(w, r)
+		return
+	}
+	writeSplunkResponse(w, http.StatusOK, "HEC is healthy", hecCodeHealthy)
+}
+
+// authorizeSplunk checks the token of a request if one is configured. A
+// response is written if the request isn't authorized.
+func (s *PushAPIServer) authorizeSplunk(w http.ResponseWriter, r *http.Request) bool {
+	cfg := s.getSplunkHECConfig()
+	if cfg == nil {
+		http.NotFound(w, r)
+		return false
+	}
+
+	if cfg.Token != "" {
+		token, ok := splunkToken(r)
+		if !ok {
+			writeSplunkResponse(w, http.StatusUnauthorized, "Token is required", hecCodeTokenMissing)
+			return false
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) != 1 {
+			writeSplunkResponse(w, http.StatusForbidden, "Invalid token", hecCodeInvalidToken)
+			return false
+		}
+	}
+
+	return true
+}
+
+// splunkToken returns the token of a request, sent in the Authorization
+// header as "Splunk <token>", or as basic auth password.
+func splunkToken(r *http.Request) (string, bool) {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Splunk ") {
+		return strings.TrimPrefix(auth, "Splunk "), true
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password, true
+	}
+	return "", false
+}
+
+func splunkLabels(host, source, sourceType, index string) model.LabelSet {
+	ls := model.LabelSet{}
+	for name, value := range map[model.LabelName]string{
+		"__splunk_host":       host,
+		"__splunk_source":     source,
+		"__splunk_sourcetype": sourceType,
+		"__splunk_index":      index,
+	} {
+		if value != "" {
+			ls[name] = model.LabelValue(value)
+		}
+	}
+	return ls
+}
+
+// eventLine returns the line of an event. Events which aren't strings are
+// kept as JSON.
+func eventLine(event json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(event, &s); err == nil {
+		return s
+	}
+	return string(event)
+}
+
+// eventTimestamp returns the time of an event, in seconds since the epoch
+// with an optional fraction.
+func eventTimestamp(raw json.RawMessage) (time.Time, bool) {
+	if len(raw) == 0 {
+		return time.Time{}, false
+	}
+
+	var seconds float64
+	if err := json.Unmarshal(raw, &seconds); err != nil {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return time.Time{}, false
+		}
+		if seconds, err = strconv.ParseFloat(s, 64); err != nil {
+			return time.Time{}, false
+		}
+	}
+
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(math.Round(frac*1e6))*1e3), true
+}
+
+// writeSplunkLimitError responds to a request rejected by the limits.
+// Splunk senders retry requests rejected because the server is busy.
+func writeSplunkLimitError(w http.ResponseWriter, err *limitError) {
+	code := hecCodeInvalidData
+	if err.status == http.StatusTooManyRequests {
+		code = hecCodeServerBusy
+	}
+	err.writeHeaders(w)
+	writeSplunkResponse(w, err.status, err.msg, code)
+}
+
+func writeSplunkResponse(w http.ResponseWriter, status int, text string, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(hecResponse{Text: text, Code: code})
+}
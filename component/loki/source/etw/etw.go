@@ -0,0 +1,225 @@
+//go:build windows && (amd64 || arm64)
+
+package etw
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/source/etw/internal/etw"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.etw",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+)
+
+// Component implements the loki.source.etw component.
+type Component struct {
+	opts    component.Options
+	handler chan loki.Entry
+
+	eventsTotal       *prometheus.CounterVec
+	decodeErrorsTotal prometheus.Counter
+
+	mut       sync.RWMutex
+	args      Arguments
+	session   *session
+	receivers []loki.LogsReceiver
+}
+
+// session is a running ETW session of the component.
+type session struct {
+	s    *etw.Session
+	done chan struct{}
+}
+
+// New creates a new loki.source.etw component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		handler: make(chan loki.Entry),
+
+		eventsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_source_etw_events_total",
+			Help: "Total number of events received from ETW providers.",
+		}, []string{"provider"}),
+		decodeErrorsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_source_etw_decode_errors_total",
+			Help: "Total number of events whose properties couldn't be decoded.",
+		}),
+	}
+	if err := o.Registerer.Register(c.eventsTotal); err != nil {
+		return nil, err
+	}
+	if err := o.Registerer.Register(c.decodeErrorsTotal); err != nil {
+		return nil, err
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		c.stopSession()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.handler:
+			c.mut.RLock()
+			for _, receiver := range c.receivers {
+				select {
+				case <-ctx.Done():
+					c.mut.RUnlock()
+					return nil
+				case receiver <- entry:
+				}
+			}
+			c.mut.RUnlock()
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	providers := make([]etw.Provider, 0, len(newArgs.Providers))
+	for _, pc := range newArgs.Providers {
+		p, err := pc.provider()
+		if err != nil {
+			return err
+		}
+		if pc.Name != "" {
+			p.GUID, err = etw.LookupProvider(pc.Name)
+			if err != nil {
+				return err
+			}
+		}
+		providers = append(providers, p)
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	// Sessions are named, so the previous session must be stopped before the
+	// new one is started.
+	c.stopSession()
+
+	labels := make(model.LabelSet, len(newArgs.Labels)+1)
+	labels[model.LabelName("job")] = model.LabelValue(c.opts.ID)
+	for k, v := range newArgs.Labels {
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	done := make(chan struct{})
+	s, err := etw.NewSession(newArgs.sessionName(c.opts.ID), providers, func(e *etw.Event, err error) {
+		c.handleEvent(e, err, labels, newArgs.UseIncomingTimestamp, done)
+	})
+	if err != nil {
+		return err
+	}
+	c.session = &session{s: s, done: done}
+	go func() {
+		if err := s.Process(); err != nil {
+			level.Error(c.opts.Logger).Log("msg", "failed to process ETW events", "err", err)
+		}
+	}()
+
+	c.args = newArgs
+	c.receivers = newArgs.ForwardTo
+	return nil
+}
+
+// stopSession stops the current session, if any. c.mut must be held.
+func (c *Component) stopSession() {
+	if c.session == nil {
+		return
+	}
+	close(c.session.done)
+	if err := c.session.s.Close(); err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to stop ETW session", "err", err)
+	}
+	c.session = nil
+}
+
+// handleEvent converts e into a log entry and sends it to the component until
+// done is closed.
+func (c *Component) handleEvent(e *etw.Event, decodeErr error, labels model.LabelSet, useIncomingTimestamp bool, done chan struct{}) {
+	provider := e.Provider
+	if provider == "" {
+		provider = e.ProviderGUID
+	}
+	c.eventsTotal.WithLabelValues(provider).Inc()
+	if decodeErr != nil {
+		c.decodeErrorsTotal.Inc()
+		level.Debug(c.opts.Logger).Log("msg", "failed to decode ETW event", "provider", provider, "event_id", e.EventID, "err", decodeErr)
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to encode ETW event", "err", err)
+		return
+	}
+
+	ts := time.Now()
+	if useIncomingTimestamp {
+		ts = e.Timestamp
+	}
+	entry := loki.Entry{
+		Labels: labels.Clone(),
+		Entry: logproto.Entry{
+			Timestamp: ts,
+			Line:      string(line),
+		},
+	}
+
+	select {
+	case c.handler <- entry:
+	case <-done:
+	}
+}
+
+// DebugInfo returns debug information for the component.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	return debugInfo{
+		SessionName: c.args.sessionName(c.opts.ID),
+		Providers:   len(c.args.Providers),
+	}
+}
+
+type debugInfo struct {
+	SessionName string `river:"session_name,attr"`
+	Providers   int    `river:"providers,attr"`
+}
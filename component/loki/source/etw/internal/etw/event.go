@@ -0,0 +1,88 @@
+// Package etw implements a consumer of Event Tracing for Windows (ETW)
+// sessions.
+package etw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Level is the level of an ETW event. Lower levels are more severe.
+type Level uint8
+
+// Levels of ETW events.
+const (
+	LevelCritical    Level = 1
+	LevelError       Level = 2
+	LevelWarning     Level = 3
+	LevelInformation Level = 4
+	LevelVerbose     Level = 5
+)
+
+var levelNames = map[string]Level{
+	"critical":    LevelCritical,
+	"error":       LevelError,
+	"warning":     LevelWarning,
+	"information": LevelInformation,
+	"verbose":     LevelVerbose,
+}
+
+// ParseLevel parses the name of a level, such as "warning".
+func ParseLevel(name string) (Level, error) {
+	l, ok := levelNames[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown level %q, must be one of critical, error, warning, information, or verbose", name)
+	}
+	return l, nil
+}
+
+// ParseKeywords parses a keywords bitmask, written as a decimal number or a
+// hexadecimal number prefixed by 0x.
+func ParseKeywords(s string) (uint64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	kw, err := strconv.ParseUint(s, 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid keywords %q: %w", s, err)
+	}
+	return kw, nil
+}
+
+// Provider is an ETW provider to enable in a session.
+type Provider struct {
+	// GUID of the provider, such as "{1C95126E-7EEA-49A9-A3FE-A378B03DDB4D}".
+	GUID string
+	// Level is the most verbose level of the events to receive.
+	Level Level
+	// MatchAnyKeyword and MatchAllKeyword filter the events to receive on
+	// their keywords. Events are received if they have any of the keywords of
+	// MatchAnyKeyword and all the keywords of MatchAllKeyword.
+	MatchAnyKeyword uint64
+	MatchAllKeyword uint64
+}
+
+// Event is an event received from an ETW session.
+type Event struct {
+	Timestamp    time.Time      `json:"timestamp"`
+	Provider     string         `json:"provider,omitempty"`
+	ProviderGUID string         `json:"provider_guid"`
+	EventID      uint16         `json:"event_id"`
+	EventName    string         `json:"event_name,omitempty"`
+	Version      uint8          `json:"version"`
+	Level        Level          `json:"level"`
+	LevelName    string         `json:"level_name,omitempty"`
+	Task         string         `json:"task,omitempty"`
+	Opcode       string         `json:"opcode,omitempty"`
+	Keywords     string         `json:"keywords"`
+	ProcessID    uint32         `json:"process_id"`
+	ThreadID     uint32         `json:"thread_id"`
+	Data         map[string]any `json:"data,omitempty"`
+}
+
+// FormatKeywords formats a keywords bitmask as it's written in events.
+func FormatKeywords(kw uint64) string {
+	return fmt.Sprintf("0x%016x", kw)
+}
@@ -0,0 +1,277 @@
+//go:build windows && (amd64 || arm64)
+
+package etw
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// renderEvent converts r into an Event, decoding its properties with TDH.
+func renderEvent(r *eventRecord) (*Event, error) {
+	h := r.EventHeader
+	e := &Event{
+		Timestamp:    filetimeToTime(h.TimeStamp),
+		ProviderGUID: h.ProviderID.String(),
+		EventID:      h.EventDescriptor.ID,
+		Version:      h.EventDescriptor.Version,
+		Level:        Level(h.EventDescriptor.Level),
+		Keywords:     FormatKeywords(h.EventDescriptor.Keyword),
+		ProcessID:    h.ProcessID,
+		ThreadID:     h.ThreadID,
+	}
+
+	if h.Flags&eventHeaderFlagStringOnly != 0 {
+		if r.UserData != nil && r.UserDataLength > 0 {
+			e.Data = map[string]any{"message": windows.UTF16PtrToString((*uint16)(r.UserData))}
+		}
+		return e, nil
+	}
+
+	info, err := getEventInformation(r)
+	if err != nil {
+		return e, fmt.Errorf("getting event information: %w", err)
+	}
+	e.Provider = utf16At(info.buf, info.hdr.ProviderNameOffset)
+	e.EventName = utf16At(info.buf, info.hdr.EventNameOffset)
+	e.LevelName = utf16At(info.buf, info.hdr.LevelNameOffset)
+	e.Task = utf16At(info.buf, info.hdr.TaskNameOffset)
+	e.Opcode = utf16At(info.buf, info.hdr.OpcodeNameOffset)
+
+	p := &propertyParser{
+		record:  r,
+		info:    info,
+		data:    r.UserData,
+		left:    r.UserDataLength,
+		ptrSize: 8,
+		values:  make(map[int]uint32),
+		maps:    make(map[uint32][]byte),
+	}
+	if h.Flags&eventHeaderFlag32BitHeader != 0 {
+		p.ptrSize = 4
+	}
+	data, err := p.parse(0, int(info.hdr.TopLevelPropertyCount))
+	if len(data) > 0 {
+		e.Data = data
+	}
+	if err != nil {
+		return e, fmt.Errorf("decoding event properties: %w", err)
+	}
+	return e, nil
+}
+
+func filetimeToTime(ft int64) time.Time {
+	t := windows.Filetime{
+		LowDateTime:  uint32(ft),
+		HighDateTime: uint32(ft >> 32),
+	}
+	return time.Unix(0, t.Nanoseconds())
+}
+
+// eventInfo holds the TRACE_EVENT_INFO of an event.
+type eventInfo struct {
+	buf []byte
+	hdr *traceEventInfo
+}
+
+func getEventInformation(r *eventRecord) (*eventInfo, error) {
+	var size uint32
+	var buf []byte
+	for {
+		var ptr uintptr
+		if len(buf) > 0 {
+			ptr = uintptr(unsafe.Pointer(&buf[0]))
+		}
+		ret, _, _ := procTdhGetEventInformation.Call(uintptr(unsafe.Pointer(r)), 0, 0, ptr, uintptr(unsafe.Pointer(&size)))
+		if syscall.Errno(ret) == windows.ERROR_INSUFFICIENT_BUFFER {
+			buf = alignedBuffer(size)
+			continue
+		} else if ret != 0 {
+			return nil, syscall.Errno(ret)
+		}
+		break
+	}
+	if len(buf) < int(unsafe.Sizeof(traceEventInfo{})) {
+		return nil, fmt.Errorf("event information truncated")
+	}
+	return &eventInfo{buf: buf, hdr: (*traceEventInfo)(unsafe.Pointer(&buf[0]))}, nil
+}
+
+func (info *eventInfo) property(i int) *eventPropertyInfo {
+	offset := unsafe.Sizeof(traceEventInfo{}) + uintptr(i)*unsafe.Sizeof(eventPropertyInfo{})
+	return (*eventPropertyInfo)(unsafe.Pointer(&info.buf[offset]))
+}
+
+// propertyParser decodes the properties of an event from its user data.
+type propertyParser struct {
+	record  *eventRecord
+	info    *eventInfo
+	data    unsafe.Pointer // Next property to decode.
+	left    uint16         // Bytes left in data.
+	ptrSize uintptr
+
+	// values holds the integer properties decoded so far, which may hold the
+	// length or the number of elements of following properties.
+	values map[int]uint32
+	// maps holds the EVENT_MAP_INFO of the properties by the offset of their
+	// name. Properties without a map have a nil value.
+	maps map[uint32][]byte
+
+	buf []uint16
+}
+
+// parse decodes count properties starting from the property at index start.
+func (p *propertyParser) parse(start, count int) (map[string]any, error) {
+	res := make(map[string]any, count)
+	for i := start; i < start+count; i++ {
+		prop := p.info.property(i)
+		name := utf16At(p.info.buf, prop.NameOffset)
+
+		n := int(prop.Count)
+		if prop.Flags&propertyParamCount != 0 {
+			n = int(p.values[int(prop.Count)])
+		}
+		isArray := prop.Flags&(propertyParamCount|propertyParamFixedCount) != 0 || n > 1
+
+		var elems []any
+		for j := 0; j < n || (!isArray && j == 0); j++ {
+			if p.left == 0 {
+				break
+			}
+			v, err := p.parseValue(i, prop)
+			if err != nil {
+				return res, fmt.Errorf("property %q: %w", name, err)
+			}
+			elems = append(elems, v)
+		}
+
+		switch {
+		case isArray:
+			res[name] = elems
+		case len(elems) > 0:
+			res[name] = elems[0]
+		}
+	}
+	return res, nil
+}
+
+func (p *propertyParser) parseValue(i int, prop *eventPropertyInfo) (any, error) {
+	if prop.Flags&propertyStruct != 0 {
+		return p.parse(int(prop.InType), int(prop.OutType))
+	}
+
+	length := prop.Length
+	if prop.Flags&propertyParamLength != 0 {
+		length = uint16(p.values[int(prop.Length)])
+	}
+	// IPv6 addresses are described with a length of 0.
+	if prop.InType == tdhInTypeBinary && prop.OutType == tdhOutTypeIPv6 && length == 0 {
+		length = 16
+	}
+
+	mapInfo, err := p.mapInfo(prop)
+	if err != nil {
+		return nil, err
+	}
+	var mapPtr uintptr
+	if len(mapInfo) > 0 {
+		mapPtr = uintptr(unsafe.Pointer(&mapInfo[0]))
+	}
+
+	if len(p.buf) == 0 {
+		p.buf = make([]uint16, 256)
+	}
+	var consumed uint16
+	for {
+		size := uint32(len(p.buf) * 2)
+		ret, _, _ := procTdhFormatProperty.Call(
+			uintptr(unsafe.Pointer(p.info.hdr)),
+			mapPtr,
+			p.ptrSize,
+			uintptr(prop.InType),
+			uintptr(prop.OutType),
+			uintptr(length),
+			uintptr(p.left),
+			uintptr(p.data),
+			uintptr(unsafe.Pointer(&size)),
+			uintptr(unsafe.Pointer(&p.buf[0])),
+			uintptr(unsafe.Pointer(&consumed)),
+		)
+		if syscall.Errno(ret) == windows.ERROR_INSUFFICIENT_BUFFER {
+			p.buf = make([]uint16, size/2+1)
+			continue
+		} else if ret != 0 {
+			return nil, syscall.Errno(ret)
+		}
+		break
+	}
+
+	if v, ok := p.readInteger(prop.InType); ok {
+		p.values[i] = v
+	}
+	if consumed > p.left {
+		consumed = p.left
+	}
+	p.data = unsafe.Add(p.data, consumed)
+	p.left -= consumed
+
+	return windows.UTF16ToString(p.buf), nil
+}
+
+// readInteger reads the unsigned integer of type inType at the start of the
+// data left.
+func (p *propertyParser) readInteger(inType uint16) (uint32, bool) {
+	switch inType {
+	case tdhInTypeInt8, tdhInTypeUInt8:
+		if p.left >= 1 {
+			return uint32(*(*uint8)(p.data)), true
+		}
+	case tdhInTypeInt16, tdhInTypeUInt16:
+		if p.left >= 2 {
+			return uint32(*(*uint16)(p.data)), true
+		}
+	case tdhInTypeInt32, tdhInTypeUInt32, tdhInTypeHexInt32:
+		if p.left >= 4 {
+			return *(*uint32)(p.data), true
+		}
+	}
+	return 0, false
+}
+
+// mapInfo returns the EVENT_MAP_INFO of prop, which is used to format the
+// values of enumerations, or nil if prop has no map.
+func (p *propertyParser) mapInfo(prop *eventPropertyInfo) ([]byte, error) {
+	if prop.MapNameOffset == 0 {
+		return nil, nil
+	}
+	if m, ok := p.maps[prop.MapNameOffset]; ok {
+		return m, nil
+	}
+
+	var size uint32
+	var buf []byte
+	for {
+		var ptr uintptr
+		if len(buf) > 0 {
+			ptr = uintptr(unsafe.Pointer(&buf[0]))
+		}
+		ret, _, _ := procTdhGetEventMapInformation.Call(uintptr(unsafe.Pointer(p.record)), uintptr(unsafe.Pointer(&p.info.buf[prop.MapNameOffset])), ptr, uintptr(unsafe.Pointer(&size)))
+		switch syscall.Errno(ret) {
+		case windows.ERROR_INSUFFICIENT_BUFFER:
+			buf = alignedBuffer(size)
+			continue
+		case windows.ERROR_NOT_FOUND:
+			buf = nil
+		case 0:
+		default:
+			return nil, syscall.Errno(ret)
+		}
+		break
+	}
+	p.maps[prop.MapNameOffset] = buf
+	return buf, nil
+}
@@ -0,0 +1,256 @@
+//go:build windows && (amd64 || arm64)
+
+package etw
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Handler is invoked for every event received by a session. The error is set
+// if the properties of the event couldn't be decoded, in which case only the
+// fields from the header of the event are set.
+type Handler func(e *Event, err error)
+
+// Session is a real-time ETW session.
+type Session struct {
+	name    string
+	handler Handler
+
+	// id identifies the session in the context of received events, as Go
+	// pointers can't be kept by the ETW API.
+	id uintptr
+
+	sessionHandle uint64
+	traceHandle   uint64
+	logfile       *eventTraceLogfile
+
+	closeOnce sync.Once
+}
+
+var (
+	sessionsMut sync.RWMutex
+	sessions    = map[uintptr]*Session{}
+	nextID      uintptr
+
+	// eventCallback is shared by all sessions, as the number of callbacks
+	// which can be created is limited.
+	eventCallback = windows.NewCallback(func(r *eventRecord) uintptr {
+		sessionsMut.RLock()
+		s := sessions[r.UserContext]
+		sessionsMut.RUnlock()
+
+		if s != nil {
+			s.handler(renderEvent(r))
+		}
+		return 0
+	})
+)
+
+// NewSession starts a real-time ETW session called name with the given
+// providers enabled. A previous session with the same name, such as a session
+// leaked by a process which crashed, is stopped first.
+//
+// Process must be called to receive events.
+func NewSession(name string, providers []Provider, handler Handler) (*Session, error) {
+	guids := make([]windows.GUID, 0, len(providers))
+	for _, p := range providers {
+		guid, err := windows.GUIDFromString(p.GUID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid provider GUID %q: %w", p.GUID, err)
+		}
+		guids = append(guids, guid)
+	}
+
+	s := &Session{name: name, handler: handler}
+
+	handle, err := startTrace(name)
+	if errors.Is(err, windows.ERROR_ALREADY_EXISTS) {
+		if err := stopTrace(name); err != nil {
+			return nil, fmt.Errorf("stopping existing session %q: %w", name, err)
+		}
+		handle, err = startTrace(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("starting session %q: %w", name, err)
+	}
+	s.sessionHandle = handle
+
+	for i, p := range providers {
+		r, _, _ := procEnableTraceEx2.Call(
+			uintptr(s.sessionHandle),
+			uintptr(unsafe.Pointer(&guids[i])),
+			eventControlCodeEnableProvider,
+			uintptr(p.Level),
+			uintptr(p.MatchAnyKeyword),
+			uintptr(p.MatchAllKeyword),
+			0,
+			0,
+		)
+		if r != 0 {
+			_ = stopTrace(name)
+			return nil, fmt.Errorf("enabling provider %s: %w", p.GUID, syscall.Errno(r))
+		}
+	}
+
+	sessionsMut.Lock()
+	nextID++
+	s.id = nextID
+	sessions[s.id] = s
+	sessionsMut.Unlock()
+
+	loggerName, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		_ = s.Close()
+		return nil, err
+	}
+	s.logfile = &eventTraceLogfile{
+		LoggerName:          loggerName,
+		ProcessTraceMode:    processTraceModeRealTime | processTraceModeEventRecord,
+		EventRecordCallback: eventCallback,
+		Context:             s.id,
+	}
+	r, _, err := procOpenTraceW.Call(uintptr(unsafe.Pointer(s.logfile)))
+	if uint64(r) == invalidProcessTraceHandle {
+		_ = s.Close()
+		return nil, fmt.Errorf("opening session %q: %w", name, err)
+	}
+	s.traceHandle = uint64(r)
+
+	return s, nil
+}
+
+// Process delivers the events of the session to its handler until the session
+// is closed.
+func (s *Session) Process() error {
+	handles := []uint64{s.traceHandle}
+	r, _, _ := procProcessTrace.Call(uintptr(unsafe.Pointer(&handles[0])), 1, 0, 0)
+	switch syscall.Errno(r) {
+	case 0, windows.ERROR_CANCELLED:
+		return nil
+	default:
+		return fmt.Errorf("processing session %q: %w", s.name, syscall.Errno(r))
+	}
+}
+
+// Close stops the session.
+func (s *Session) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = stopTrace(s.name)
+		if s.traceHandle != 0 {
+			_, _, _ = procCloseTrace.Call(uintptr(s.traceHandle))
+		}
+
+		sessionsMut.Lock()
+		delete(sessions, s.id)
+		sessionsMut.Unlock()
+	})
+	return err
+}
+
+// newTraceProperties returns properties for a real-time session called name.
+func newTraceProperties(name string) (*eventTraceProperties, error) {
+	loggerName, err := windows.UTF16FromString(name)
+	if err != nil {
+		return nil, err
+	}
+
+	size := uint32(unsafe.Sizeof(eventTraceProperties{})) + uint32(len(loggerName))*2
+	buf := alignedBuffer(size)
+	props := (*eventTraceProperties)(unsafe.Pointer(&buf[0]))
+	props.Wnode.BufferSize = size
+	props.Wnode.Flags = wnodeFlagTracedGUID
+	props.Wnode.ClientContext = 2 // System time.
+	props.LogFileMode = eventTraceRealTimeMode
+	props.LoggerNameOffset = uint32(unsafe.Sizeof(eventTraceProperties{}))
+	return props, nil
+}
+
+func startTrace(name string) (uint64, error) {
+	props, err := newTraceProperties(name)
+	if err != nil {
+		return 0, err
+	}
+	loggerName, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	var handle uint64
+	r, _, _ := procStartTraceW.Call(
+		uintptr(unsafe.Pointer(&handle)),
+		uintptr(unsafe.Pointer(loggerName)),
+		uintptr(unsafe.Pointer(props)),
+	)
+	if r != 0 {
+		return 0, syscall.Errno(r)
+	}
+	return handle, nil
+}
+
+func stopTrace(name string) error {
+	props, err := newTraceProperties(name)
+	if err != nil {
+		return err
+	}
+	loggerName, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+
+	r, _, _ := procControlTraceW.Call(
+		0,
+		uintptr(unsafe.Pointer(loggerName)),
+		uintptr(unsafe.Pointer(props)),
+		eventTraceControlStop,
+	)
+	switch syscall.Errno(r) {
+	case 0, windows.ERROR_MORE_DATA, windows.ERROR_WMI_INSTANCE_NOT_FOUND:
+		return nil
+	default:
+		return syscall.Errno(r)
+	}
+}
+
+// LookupProvider returns the GUID of the provider registered on the system
+// with the given name, such as "Microsoft-Windows-DNS-Client".
+func LookupProvider(name string) (string, error) {
+	var size uint32
+	var buf []byte
+	for {
+		var ptr uintptr
+		if len(buf) > 0 {
+			ptr = uintptr(unsafe.Pointer(&buf[0]))
+		}
+		r, _, _ := procTdhEnumerateProviders.Call(ptr, uintptr(unsafe.Pointer(&size)))
+		if syscall.Errno(r) == windows.ERROR_INSUFFICIENT_BUFFER {
+			// Providers may be registered between calls, so the size is checked
+			// again.
+			buf = alignedBuffer(size)
+			continue
+		} else if r != 0 {
+			return "", fmt.Errorf("enumerating providers: %w", syscall.Errno(r))
+		}
+		break
+	}
+	if len(buf) < 8 {
+		return "", fmt.Errorf("provider %q not found", name)
+	}
+
+	count := *(*uint32)(unsafe.Pointer(&buf[0]))
+	infoSize := uint32(unsafe.Sizeof(traceProviderInfo{}))
+	for i := uint32(0); i < count; i++ {
+		info := (*traceProviderInfo)(unsafe.Pointer(&buf[8+i*infoSize]))
+		if strings.EqualFold(utf16At(buf, info.ProviderNameOffset), name) {
+			return info.ProviderGUID.String(), nil
+		}
+	}
+	return "", fmt.Errorf("provider %q not found", name)
+}
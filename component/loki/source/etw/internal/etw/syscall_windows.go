@@ -0,0 +1,246 @@
+//go:build windows && (amd64 || arm64)
+
+package etw
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	advapi32 = windows.NewLazySystemDLL("advapi32.dll")
+	tdh      = windows.NewLazySystemDLL("tdh.dll")
+
+	procStartTraceW    = advapi32.NewProc("StartTraceW")
+	procControlTraceW  = advapi32.NewProc("ControlTraceW")
+	procEnableTraceEx2 = advapi32.NewProc("EnableTraceEx2")
+	procOpenTraceW     = advapi32.NewProc("OpenTraceW")
+	procProcessTrace   = advapi32.NewProc("ProcessTrace")
+	procCloseTrace     = advapi32.NewProc("CloseTrace")
+
+	procTdhEnumerateProviders     = tdh.NewProc("TdhEnumerateProviders")
+	procTdhGetEventInformation    = tdh.NewProc("TdhGetEventInformation")
+	procTdhGetEventMapInformation = tdh.NewProc("TdhGetEventMapInformation")
+	procTdhFormatProperty         = tdh.NewProc("TdhFormatProperty")
+)
+
+const (
+	wnodeFlagTracedGUID = 0x00020000
+
+	eventTraceRealTimeMode = 0x00000100
+	eventTraceControlStop  = 1
+
+	eventControlCodeEnableProvider = 1
+
+	processTraceModeRealTime    = 0x00000100
+	processTraceModeEventRecord = 0x10000000
+
+	invalidProcessTraceHandle = ^uint64(0)
+
+	eventHeaderFlagStringOnly  = 0x0004
+	eventHeaderFlag32BitHeader = 0x0020
+
+	propertyStruct          = 0x0001
+	propertyParamLength     = 0x0002
+	propertyParamCount      = 0x0004
+	propertyParamFixedCount = 0x0020
+
+	tdhInTypeInt8     = 2
+	tdhInTypeUInt8    = 3
+	tdhInTypeInt16    = 4
+	tdhInTypeUInt16   = 5
+	tdhInTypeInt32    = 6
+	tdhInTypeUInt32   = 7
+	tdhInTypeBinary   = 14
+	tdhInTypeHexInt32 = 20
+	tdhOutTypeIPv6    = 24
+)
+
+// Layouts of the structures of the ETW and TDH APIs, from evntrace.h,
+// evntcons.h, and tdh.h.
+
+type wnodeHeader struct {
+	BufferSize        uint32
+	ProviderID        uint32
+	HistoricalContext uint64
+	TimeStamp         int64
+	GUID              windows.GUID
+	ClientContext     uint32
+	Flags             uint32
+}
+
+type eventTraceProperties struct {
+	Wnode               wnodeHeader
+	BufferSize          uint32
+	MinimumBuffers      uint32
+	MaximumBuffers      uint32
+	MaximumFileSize     uint32
+	LogFileMode         uint32
+	FlushTimer          uint32
+	EnableFlags         uint32
+	AgeLimit            int32
+	NumberOfBuffers     uint32
+	FreeBuffers         uint32
+	EventsLost          uint32
+	BuffersWritten      uint32
+	LogBuffersLost      uint32
+	RealTimeBuffersLost uint32
+	LoggerThreadID      windows.Handle
+	LogFileNameOffset   uint32
+	LoggerNameOffset    uint32
+}
+
+type eventTraceHeader struct {
+	Size           uint16
+	FieldTypeFlags uint16
+	Version        uint32
+	ThreadID       uint32
+	ProcessID      uint32
+	TimeStamp      int64
+	GUID           windows.GUID
+	ProcessorTime  uint64
+}
+
+type eventTrace struct {
+	Header           eventTraceHeader
+	InstanceID       uint32
+	ParentInstanceID uint32
+	ParentGUID       windows.GUID
+	MofData          uintptr
+	MofLength        uint32
+	BufferContext    uint32
+}
+
+type traceLogfileHeader struct {
+	BufferSize         uint32
+	Version            uint32
+	ProviderVersion    uint32
+	NumberOfProcessors uint32
+	EndTime            int64
+	TimerResolution    uint32
+	MaximumFileSize    uint32
+	LogFileMode        uint32
+	BuffersWritten     uint32
+	LogInstanceGUID    windows.GUID
+	LoggerName         *uint16
+	LogFileName        *uint16
+	TimeZone           windows.Timezoneinformation
+	BootTime           int64
+	PerfFreq           int64
+	StartTime          int64
+	ReservedFlags      uint32
+	BuffersLost        uint32
+}
+
+type eventTraceLogfile struct {
+	LogFileName         *uint16
+	LoggerName          *uint16
+	CurrentTime         int64
+	BuffersRead         uint32
+	ProcessTraceMode    uint32
+	CurrentEvent        eventTrace
+	LogfileHeader       traceLogfileHeader
+	BufferCallback      uintptr
+	BufferSize          uint32
+	Filled              uint32
+	EventsLost          uint32
+	EventRecordCallback uintptr
+	IsKernelTrace       uint32
+	Context             uintptr
+}
+
+type eventDescriptor struct {
+	ID      uint16
+	Version uint8
+	Channel uint8
+	Level   uint8
+	Opcode  uint8
+	Task    uint16
+	Keyword uint64
+}
+
+type eventHeader struct {
+	Size            uint16
+	HeaderType      uint16
+	Flags           uint16
+	EventProperty   uint16
+	ThreadID        uint32
+	ProcessID       uint32
+	TimeStamp       int64
+	ProviderID      windows.GUID
+	EventDescriptor eventDescriptor
+	ProcessorTime   uint64
+	ActivityID      windows.GUID
+}
+
+type eventRecord struct {
+	EventHeader       eventHeader
+	BufferContext     uint32
+	ExtendedDataCount uint16
+	UserDataLength    uint16
+	ExtendedData      unsafe.Pointer
+	UserData          unsafe.Pointer
+	UserContext       uintptr
+}
+
+type traceEventInfo struct {
+	ProviderGUID          windows.GUID
+	EventGUID             windows.GUID
+	EventDescriptor       eventDescriptor
+	DecodingSource        uint32
+	ProviderNameOffset    uint32
+	LevelNameOffset       uint32
+	ChannelNameOffset     uint32
+	KeywordsNameOffset    uint32
+	TaskNameOffset        uint32
+	OpcodeNameOffset      uint32
+	EventMessageOffset    uint32
+	ProviderMessageOffset uint32
+	BinaryXMLOffset       uint32
+	BinaryXMLSize         uint32
+	EventNameOffset       uint32
+	EventAttributesOffset uint32
+	PropertyCount         uint32
+	TopLevelPropertyCount uint32
+	Flags                 uint32
+}
+
+// eventPropertyInfo describes a property of an event. For struct properties,
+// InType and OutType hold the index of the first member and the number of
+// members of the struct.
+type eventPropertyInfo struct {
+	Flags         uint32
+	NameOffset    uint32
+	InType        uint16
+	OutType       uint16
+	MapNameOffset uint32
+	Count         uint16
+	Length        uint16
+	Reserved      uint32
+}
+
+type traceProviderInfo struct {
+	ProviderGUID       windows.GUID
+	SchemaSource       uint32
+	ProviderNameOffset uint32
+}
+
+// alignedBuffer returns a buffer of size bytes which is aligned for the
+// structures of the TDH API.
+func alignedBuffer(size uint32) []byte {
+	buf := make([]uint64, (size+7)/8)
+	if len(buf) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&buf[0])), size)
+}
+
+// utf16At returns the NUL-terminated UTF-16 string at offset of buf, or an
+// empty string if offset is 0.
+func utf16At(buf []byte, offset uint32) string {
+	if offset == 0 || int(offset) >= len(buf) {
+		return ""
+	}
+	return windows.UTF16PtrToString((*uint16)(unsafe.Pointer(&buf[offset])))
+}
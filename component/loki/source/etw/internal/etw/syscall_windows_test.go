@@ -0,0 +1,22 @@
+//go:build windows && (amd64 || arm64)
+
+package etw
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestStructSizes checks the layouts of the structures against their sizes on
+// 64-bit Windows.
+func TestStructSizes(t *testing.T) {
+	require.EqualValues(t, 120, unsafe.Sizeof(eventTraceProperties{}))
+	require.EqualValues(t, 88, unsafe.Sizeof(eventTrace{}))
+	require.EqualValues(t, 80, unsafe.Sizeof(eventHeader{}))
+	require.EqualValues(t, 112, unsafe.Sizeof(eventRecord{}))
+	require.EqualValues(t, 112, unsafe.Sizeof(traceEventInfo{}))
+	require.EqualValues(t, 24, unsafe.Sizeof(eventPropertyInfo{}))
+	require.EqualValues(t, 24, unsafe.Sizeof(traceProviderInfo{}))
+}
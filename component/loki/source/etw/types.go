@@ -0,0 +1,87 @@
+package etw
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/loki/source/etw/internal/etw"
+)
+
+// Arguments holds values which are used to configure the loki.source.etw
+// component.
+type Arguments struct {
+	Providers            []ProviderConfig    `river:"provider,block"`
+	SessionName          string              `river:"session_name,attr,optional"`
+	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+	Labels               map[string]string   `river:"labels,attr,optional"`
+	ForwardTo            []loki.LogsReceiver `river:"forward_to,attr"`
+}
+
+// ProviderConfig configures an ETW provider to receive events from.
+type ProviderConfig struct {
+	Name            string `river:"name,attr,optional"`
+	GUID            string `river:"guid,attr,optional"`
+	Level           string `river:"level,attr,optional"`
+	MatchAnyKeyword string `river:"match_any_keyword,attr,optional"`
+	MatchAllKeyword string `river:"match_all_keyword,attr,optional"`
+}
+
+// DefaultProviderConfig holds the default settings of a provider.
+var DefaultProviderConfig = ProviderConfig{
+	Level: "information",
+}
+
+// SetToDefault implements river.Defaulter.
+func (pc *ProviderConfig) SetToDefault() {
+	*pc = DefaultProviderConfig
+}
+
+// Validate implements river.Validator.
+func (pc *ProviderConfig) Validate() error {
+	if (pc.Name == "") == (pc.GUID == "") {
+		return fmt.Errorf("exactly one of name or guid must be set for a provider")
+	}
+	_, err := pc.provider()
+	return err
+}
+
+// provider converts pc into an etw.Provider. The GUID of the provider is left
+// empty if pc identifies the provider by name.
+func (pc *ProviderConfig) provider() (etw.Provider, error) {
+	level, err := etw.ParseLevel(pc.Level)
+	if err != nil {
+		return etw.Provider{}, err
+	}
+	matchAny, err := etw.ParseKeywords(pc.MatchAnyKeyword)
+	if err != nil {
+		return etw.Provider{}, fmt.Errorf("match_any_keyword: %w", err)
+	}
+	matchAll, err := etw.ParseKeywords(pc.MatchAllKeyword)
+	if err != nil {
+		return etw.Provider{}, fmt.Errorf("match_all_keyword: %w", err)
+	}
+
+	return etw.Provider{
+		GUID:            pc.GUID,
+		Level:           level,
+		MatchAnyKeyword: matchAny,
+		MatchAllKeyword: matchAll,
+	}, nil
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if len(args.Providers) == 0 {
+		return fmt.Errorf("at least one provider must be set")
+	}
+	return nil
+}
+
+// sessionName returns the name of the ETW session of the component with the
+// given ID.
+func (args *Arguments) sessionName(id string) string {
+	if args.SessionName != "" {
+		return args.SessionName
+	}
+	return "grafana-agent-" + id
+}
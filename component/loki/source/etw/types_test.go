@@ -0,0 +1,92 @@
+package etw
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/component/loki/source/etw/internal/etw"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	in := `
+		provider {
+			name              = "Microsoft-Windows-DNS-Client"
+			level             = "verbose"
+			match_any_keyword = "0x8000000000000000"
+		}
+
+		provider {
+			guid = "{2F07E2EE-15DB-40F1-90EF-9D7BA282188A}"
+		}
+
+		forward_to = []
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+	require.Len(t, args.Providers, 2)
+
+	p, err := args.Providers[0].provider()
+	require.NoError(t, err)
+	require.Equal(t, etw.Provider{Level: etw.LevelVerbose, MatchAnyKeyword: 1 << 63}, p)
+
+	p, err = args.Providers[1].provider()
+	require.NoError(t, err)
+	require.Equal(t, etw.Provider{GUID: "{2F07E2EE-15DB-40F1-90EF-9D7BA282188A}", Level: etw.LevelInformation}, p)
+
+	require.Equal(t, "grafana-agent-loki.source.etw.dns", args.sessionName("loki.source.etw.dns"))
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		in     string
+		expect string
+	}{
+		{
+			name:   "no providers",
+			in:     `forward_to = []`,
+			expect: "missing required block \"provider\"",
+		},
+		{
+			name: "name and guid",
+			in: `
+				provider {
+					name = "Microsoft-Windows-DNS-Client"
+					guid = "{1C95126E-7EEA-49A9-A3FE-A378B03DDB4D}"
+				}
+				forward_to = []
+			`,
+			expect: "exactly one of name or guid must be set",
+		},
+		{
+			name: "invalid level",
+			in: `
+				provider {
+					name  = "Microsoft-Windows-DNS-Client"
+					level = "loud"
+				}
+				forward_to = []
+			`,
+			expect: "unknown level \"loud\"",
+		},
+		{
+			name: "invalid keywords",
+			in: `
+				provider {
+					name              = "Microsoft-Windows-DNS-Client"
+					match_all_keyword = "0xZZ"
+				}
+				forward_to = []
+			`,
+			expect: "match_all_keyword: invalid keywords",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			require.ErrorContains(t, river.Unmarshal([]byte(tc.in), &args), tc.expect)
+		})
+	}
+}
@@ -5,12 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/delivery"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/common/loki/positions"
 	"github.com/grafana/agent/component/discovery"
@@ -39,10 +39,18 @@ const (
 type Arguments struct {
 	Targets   []discovery.Target  `river:"targets,attr"`
 	ForwardTo []loki.LogsReceiver `river:"forward_to,attr"`
+
+	// TrackDeliveryLatency stamps read entries so that write components
+	// measure their end-to-end delivery latency.
+	TrackDeliveryLatency bool `river:"track_delivery_latency,attr,optional"`
+
+	Clustering Clustering `river:"clustering,block,optional"`
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component          = (*Component)(nil)
+	_ component.HTTPComponent      = (*Component)(nil)
+	_ component.ClusteredComponent = (*Component)(nil)
 )
 
 // Component implements the loki.source.file component.
@@ -116,6 +124,9 @@ func (c *Component) Run(ctx context.Context) error {
 			return nil
 		case entry := <-c.handler:
 			c.mut.RLock()
+			if c.args.TrackDeliveryLatency {
+				entry.Labels = delivery.StampLabels(entry.Labels, delivery.Stamp{Source: c.opts.ID, Time: time.Now()})
+			}
 			for _, receiver := range c.receivers {
 				receiver <- entry
 			}
@@ -153,6 +164,38 @@ func (c *Component) Update(args component.Arguments) error {
 
 	newArgs := args.(Arguments)
 
+	// Forget the stopped readers, so that peers fetching positions during the
+	// handoff below don't try to stop them again.
+	c.mut.Lock()
+	c.readers = make(map[positions.Entry]reader)
+	c.mut.Unlock()
+
+	// When clustering, only the targets owned by this component are tailed.
+	// Targets owned by other peers keep their positions, so that they can be
+	// handed back if the targets are owned by this component again.
+	targets := c.ownedTargets(newArgs)
+	disowned := make(map[positions.Entry]struct{})
+	if newArgs.Clustering.Enabled {
+		for _, target := range newArgs.Targets {
+			e, _ := targetEntry(target)
+			disowned[e] = struct{}{}
+		}
+
+		var gained []positions.Entry
+		for _, target := range targets {
+			e, _ := targetEntry(target)
+			delete(disowned, e)
+			if _, tailed := oldPaths[e]; !tailed {
+				gained = append(gained, e)
+			}
+		}
+
+		// Fetch the positions of newly owned targets from the peers which
+		// tailed them before. This must be done before c.mut is held, as peers
+		// may concurrently fetch positions from this component.
+		c.handoffPositions(gained)
+	}
+
 	c.mut.Lock()
 	defer c.mut.Unlock()
 	c.args = newArgs
@@ -160,24 +203,15 @@ func (c *Component) Update(args component.Arguments) error {
 
 	c.readers = make(map[positions.Entry]reader)
 
-	if len(newArgs.Targets) == 0 {
+	if len(targets) == 0 {
 		level.Debug(c.opts.Logger).Log("msg", "no files targets were passed, nothing will be tailed")
 		return nil
 	}
 
-	for _, target := range newArgs.Targets {
-		path := target[pathLabel]
-
-		var labels = make(model.LabelSet)
-		for k, v := range target {
-			if strings.HasPrefix(k, model.ReservedLabelPrefix) {
-				continue
-			}
-			labels[model.LabelName(k)] = model.LabelValue(v)
-		}
-
+	for _, target := range targets {
 		// Deduplicate targets which have the same public label set.
-		readersKey := positions.Entry{Path: path, Labels: labels.String()}
+		readersKey, labels := targetEntry(target)
+		path := readersKey.Path
 		if _, exist := c.readers[readersKey]; exist {
 			continue
 		}
@@ -199,12 +233,22 @@ func (c *Component) Update(args component.Arguments) error {
 	// Remove from the positions file any entries that had a Reader before, but
 	// are no longer in the updated set of Targets.
 	for r := range missing(c.readers, oldPaths) {
+		if _, ok := disowned[r]; ok {
+			continue
+		}
 		c.posFile.Remove(r.Path, r.Labels)
 	}
 
 	return nil
 }
 
+// ClusterUpdatesRegistration implements component.ClusterComponent.
+func (c *Component) ClusterUpdatesRegistration() bool {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.args.Clustering.Enabled
+}
+
 // readerWithHandler combines a reader with an entry handler associated with
 // it. Closing the reader will also close the handler.
 type readerWithHandler struct {
@@ -0,0 +1,214 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki/positions"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/ckit/peer"
+	"github.com/prometheus/common/model"
+)
+
+// handoffTimeout bounds how long fetching positions from a peer can take.
+const handoffTimeout = 5 * time.Second
+
+// Clustering holds values that configure clustering-specific behavior.
+type Clustering struct {
+	Enabled bool `river:"enabled,attr"`
+}
+
+// HandoffRequest is the JSON body of a request to /handoff, listing the files
+// the sender is taking over.
+type HandoffRequest struct {
+	Entries []positions.Entry `json:"entries"`
+}
+
+// HandoffPosition is the position a peer read a file up to.
+type HandoffPosition struct {
+	Path     string `json:"path"`
+	Labels   string `json:"labels"`
+	Position int64  `json:"position"`
+}
+
+// HandoffResponse is the JSON response to a request to /handoff.
+type HandoffResponse struct {
+	Positions []HandoffPosition `json:"positions"`
+}
+
+// Handler implements component.HTTPComponent. It serves /handoff, which peers
+// call before tailing files previously owned by this component. Files which
+// this component no longer owns stop being tailed before their positions are
+// returned, so that the peer resumes where this component stopped.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/handoff", c.handleHandoff)
+	return mux
+}
+
+func (c *Component) handleHandoff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req HandoffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid handoff request: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	c.releaseDisowned(req.Entries)
+
+	res := HandoffResponse{Positions: []HandoffPosition{}}
+	for _, e := range req.Entries {
+		pos, err := c.posFile.Get(e.Path, e.Labels)
+		if err != nil || pos == 0 {
+			continue
+		}
+		res.Positions = append(res.Positions, HandoffPosition{Path: e.Path, Labels: e.Labels, Position: pos})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// releaseDisowned stops tailing any of entries which are no longer owned by
+// this component, saving their positions.
+func (c *Component) releaseDisowned(entries []positions.Entry) {
+	c.mut.Lock()
+	owned := make(map[positions.Entry]struct{})
+	for _, target := range c.ownedTargets(c.args) {
+		e, _ := targetEntry(target)
+		owned[e] = struct{}{}
+	}
+
+	var released []reader
+	for _, e := range entries {
+		r, ok := c.readers[e]
+		if _, isOwned := owned[e]; !ok || isOwned {
+			continue
+		}
+		delete(c.readers, e)
+		released = append(released, r)
+	}
+	c.mut.Unlock()
+
+	// Readers must be stopped without holding c.mut, as stopping flushes
+	// entries to the Run goroutine.
+	for _, r := range released {
+		r.Stop()
+	}
+}
+
+// ownedTargets returns the targets of args owned by this component. All
+// targets are owned unless clustering is enabled.
+func (c *Component) ownedTargets(args Arguments) []discovery.Target {
+	if !args.Clustering.Enabled || c.opts.Clusterer == nil {
+		return args.Targets
+	}
+	dt := discovery.NewDistributedTargets(true, c.opts.Clusterer.Node, args.Targets)
+	return dt.Get()
+}
+
+// handoffPositions fetches the positions of entries from the other peers of
+// the cluster, and records the furthest position of each entry which is
+// ahead of its local position.
+func (c *Component) handoffPositions(entries []positions.Entry) {
+	if len(entries) == 0 || c.opts.Clusterer == nil {
+		return
+	}
+
+	var (
+		wg  sync.WaitGroup
+		mut sync.Mutex
+		res = make(map[positions.Entry]int64, len(entries))
+	)
+	for _, p := range c.opts.Clusterer.Node.Peers() {
+		if p.Self || p.Addr == "" {
+			continue
+		}
+
+		wg.Add(1)
+		go func(p peer.Peer) {
+			defer wg.Done()
+
+			peerPositions, err := c.fetchPositions(p, entries)
+			if err != nil {
+				level.Warn(c.opts.Logger).Log("msg", "failed to fetch file positions from peer", "peer", p.Name, "err", err)
+				return
+			}
+
+			mut.Lock()
+			defer mut.Unlock()
+			for _, pos := range peerPositions {
+				e := positions.Entry{Path: pos.Path, Labels: pos.Labels}
+				if pos.Position > res[e] {
+					res[e] = pos.Position
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	for e, pos := range res {
+		if local, err := c.posFile.Get(e.Path, e.Labels); err == nil && local >= pos {
+			continue
+		}
+		level.Debug(c.opts.Logger).Log("msg", "resuming file from position handed off by peer", "filename", e.Path, "position", pos)
+		c.posFile.Put(e.Path, e.Labels, pos)
+	}
+}
+
+// fetchPositions requests the positions of entries from the peer p.
+func (c *Component) fetchPositions(p peer.Peer, entries []positions.Entry) ([]HandoffPosition, error) {
+	body, err := json.Marshal(HandoffRequest{Entries: entries})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+	defer cancel()
+
+	url := "http://" + p.Addr + strings.TrimSuffix(c.opts.HTTPPath, "/") + "/handoff"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var res HandoffResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res.Positions, nil
+}
+
+// targetEntry returns the positions entry of target along with the labels
+// sent with its log lines.
+func targetEntry(target discovery.Target) (positions.Entry, model.LabelSet) {
+	var labels = make(model.LabelSet)
+	for k, v := range target {
+		if strings.HasPrefix(k, model.ReservedLabelPrefix) {
+			continue
+		}
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return positions.Entry{Path: target[pathLabel], Labels: labels.String()}, labels
+}
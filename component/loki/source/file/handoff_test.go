@@ -0,0 +1,147 @@
+//go:build !race
+
+package file
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/ckit"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	f, err := os.CreateTemp(t.TempDir(), "example")
+	require.NoError(t, err)
+	defer f.Close()
+
+	var (
+		cl       = &fakeCluster{owner: "agent-b"}
+		httpPath = "/api/v0/component/loki.source.file.test/"
+		args     = func(ch loki.LogsReceiver) Arguments {
+			return Arguments{
+				Targets:    []discovery.Target{{"__path__": f.Name()}},
+				ForwardTo:  []loki.LogsReceiver{ch},
+				Clustering: Clustering{Enabled: true},
+			}
+		}
+	)
+
+	// Peers send handoff requests to the HTTP server of agent-b.
+	var compB *Component
+	srv := httptest.NewServer(http.StripPrefix("/api/v0/component/loki.source.file.test", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		compB.Handler().ServeHTTP(w, r)
+	})))
+	defer srv.Close()
+	srvURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	cl.addrB = srvURL.Host
+
+	chB := make(loki.LogsReceiver)
+	compB = newClusteredComponent(t, cl.view("agent-b"), httpPath, args(chB))
+	go func() { _ = compB.Run(ctx) }()
+
+	_, err = f.WriteString("first\n")
+	require.NoError(t, err)
+	requireLine(t, chB, "first")
+
+	// agent-a takes over the file and resumes where agent-b stopped.
+	cl.setOwner("agent-a")
+	chA := make(loki.LogsReceiver)
+	compA := newClusteredComponent(t, cl.view("agent-a"), httpPath, args(chA))
+	go func() { _ = compA.Run(ctx) }()
+
+	_, err = f.WriteString("second\n")
+	require.NoError(t, err)
+	requireLine(t, chA, "second")
+
+	select {
+	case e := <-chB:
+		require.FailNow(t, "agent-b read a line after handing off the file", e.Line)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func newClusteredComponent(t *testing.T, node cluster.Node, httpPath string, args Arguments) *Component {
+	t.Helper()
+
+	c, err := New(component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		DataPath:      t.TempDir(),
+		Clusterer:     &cluster.Clusterer{Node: node},
+		HTTPPath:      httpPath,
+	}, args)
+	require.NoError(t, err)
+	return c
+}
+
+func requireLine(t *testing.T, ch loki.LogsReceiver, line string) {
+	t.Helper()
+
+	select {
+	case e := <-ch:
+		require.Equal(t, line, e.Line)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for log line", line)
+	}
+}
+
+// fakeCluster is a cluster of agent-a and agent-b where a single peer owns
+// every target.
+type fakeCluster struct {
+	mut   sync.Mutex
+	owner string
+	addrB string
+}
+
+func (fc *fakeCluster) setOwner(owner string) {
+	fc.mut.Lock()
+	defer fc.mut.Unlock()
+	fc.owner = owner
+}
+
+// view returns the Node of the peer called self.
+func (fc *fakeCluster) view(self string) cluster.Node {
+	return &fakeClusterNode{cluster: fc, self: self}
+}
+
+type fakeClusterNode struct {
+	cluster *fakeCluster
+	self    string
+}
+
+func (n *fakeClusterNode) Lookup(shard.Key, int, shard.Op) ([]peer.Peer, error) {
+	n.cluster.mut.Lock()
+	defer n.cluster.mut.Unlock()
+	return []peer.Peer{{Name: n.cluster.owner, Self: n.cluster.owner == n.self, State: peer.StateParticipant}}, nil
+}
+
+func (n *fakeClusterNode) Observe(ckit.Observer) {}
+
+func (n *fakeClusterNode) Peers() []peer.Peer {
+	return []peer.Peer{
+		{Name: "agent-a", Self: n.self == "agent-a", State: peer.StateParticipant},
+		{Name: "agent-b", Addr: n.cluster.addrB, Self: n.self == "agent-b", State: peer.StateParticipant},
+	}
+}
+
+func (n *fakeClusterNode) Handler() (string, http.Handler) { return "", nil }
@@ -0,0 +1,241 @@
+package fluentforward
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/component/loki/source/fluentforward/internal/forward"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.fluentforward",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// tagLabel is the internal label holding the tag of an event.
+const tagLabel = "__fluentforward_tag"
+
+// Arguments are the arguments for the component.
+type Arguments struct {
+	ListenAddress        string              `river:"listen_address,attr,optional"`
+	SharedKey            rivertypes.Secret   `river:"shared_key,attr,optional"`
+	SelfHostname         string              `river:"self_hostname,attr,optional"`
+	Labels               map[string]string   `river:"labels,attr,optional"`
+	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+	RelabelRules         flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
+	ForwardTo            []loki.LogsReceiver `river:"forward_to,attr"`
+}
+
+// DefaultArguments provides the default arguments for the
+// loki.source.fluentforward component.
+var DefaultArguments = Arguments{
+	ListenAddress: "0.0.0.0:24224",
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.ListenAddress == "" {
+		return fmt.Errorf("listen_address must not be empty")
+	}
+	for k := range a.Labels {
+		if !model.LabelName(k).IsValid() {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	return nil
+}
+
+var _ component.Component = (*Component)(nil)
+
+// Component receives log entries over the Fluent Forward protocol.
+type Component struct {
+	o       component.Options
+	metrics *forward.Metrics
+	entries chan loki.Entry
+
+	mut       sync.RWMutex
+	args      Arguments
+	server    *forward.Server
+	receivers []loki.LogsReceiver
+}
+
+// New creates a new loki.source.fluentforward component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		o:       o,
+		metrics: forward.NewMetrics(o.Registerer),
+		entries: make(chan loki.Entry),
+	}
+
+	// Call to Update() to start the server and set receivers once at the start.
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		if c.server != nil {
+			c.server.Stop()
+			c.server = nil
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.entries:
+			c.mut.RLock()
+			receivers := c.receivers
+			c.mut.RUnlock()
+
+			for _, r := range receivers {
+				select {
+				case <-ctx.Done():
+					return nil
+				case r <- entry:
+				}
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.receivers = newArgs.ForwardTo
+
+	// Connections are kept open if only the receivers changed.
+	if c.server != nil && serverArgsEqual(c.args, newArgs) {
+		return nil
+	}
+	if c.server != nil {
+		c.server.Stop()
+		c.server = nil
+	}
+	c.args = newArgs
+
+	hostname := newArgs.SelfHostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	cfg := forward.Config{
+		ListenAddress: newArgs.ListenAddress,
+		SharedKey:     string(newArgs.SharedKey),
+		SelfHostname:  hostname,
+	}
+
+	var rcs []*relabel.Config
+	if len(newArgs.RelabelRules) > 0 {
+		rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
+	}
+
+	s, err := forward.NewServer(c.o.Logger, c.metrics, cfg, c.newHandler(newArgs, rcs))
+	if err != nil {
+		return err
+	}
+	c.server = s
+	return nil
+}
+
+// serverArgsEqual reports whether a and b only differ by their receivers.
+func serverArgsEqual(a, b Arguments) bool {
+	a.ForwardTo, b.ForwardTo = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// newHandler returns the handler converting events into log entries. The
+// handler captures its configuration so that it never needs to take the
+// component's lock, which is held while the server is stopped.
+func (c *Component) newHandler(args Arguments, rcs []*relabel.Config) forward.Handler {
+	return func(ctx context.Context, events []forward.Event) {
+		for _, ev := range events {
+			entry, ok := c.convertEvent(args, rcs, ev)
+			if !ok {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case c.entries <- entry:
+			}
+		}
+	}
+}
+
+func (c *Component) convertEvent(args Arguments, rcs []*relabel.Config, ev forward.Event) (loki.Entry, bool) {
+	lb := labels.NewBuilder(nil)
+	for k, v := range args.Labels {
+		lb.Set(k, v)
+	}
+	lb.Set(tagLabel, ev.Tag)
+
+	processed, keep := relabel.Process(lb.Labels(nil), rcs...)
+	if !keep {
+		return loki.Entry{}, false
+	}
+
+	filtered := make(model.LabelSet)
+	for _, lbl := range processed {
+		if strings.HasPrefix(lbl.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+	if filtered["job"] == "" {
+		filtered["job"] = model.LabelValue(c.o.ID)
+	}
+
+	line, err := json.Marshal(forward.Normalize(ev.Record))
+	if err != nil {
+		level.Error(c.o.Logger).Log("msg", "failed to encode record", "tag", ev.Tag, "err", err)
+		return loki.Entry{}, false
+	}
+
+	timestamp := time.Now()
+	if args.UseIncomingTimestamp {
+		timestamp = ev.Time
+	}
+
+	return loki.Entry{
+		Labels: filtered,
+		Entry: logproto.Entry{
+			Timestamp: timestamp,
+			Line:      string(line),
+		},
+	}, true
+}
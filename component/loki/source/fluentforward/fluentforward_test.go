@@ -0,0 +1,183 @@
+package fluentforward
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+var testHandle = &codec.MsgpackHandle{RawToString: true, WriteExt: true}
+
+func eventTime(t time.Time) codec.RawExt {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint32(data[:4], uint32(t.Unix()))
+	binary.BigEndian.PutUint32(data[4:], uint32(t.Nanosecond()))
+	return codec.RawExt{Tag: 0, Data: data}
+}
+
+func startComponent(t *testing.T, args Arguments) (*Component, chan loki.Entry) {
+	t.Helper()
+
+	ch := make(chan loki.Entry)
+	args.ListenAddress = "127.0.0.1:0"
+	args.ForwardTo = []loki.LogsReceiver{ch}
+
+	c, err := New(component.Options{
+		ID:            "loki.source.fluentforward.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go c.Run(ctx)
+	return c, ch
+}
+
+func dial(t *testing.T, c *Component) (net.Conn, *codec.Encoder, *codec.Decoder) {
+	t.Helper()
+
+	c.mut.RLock()
+	addr := c.server.Addr().String()
+	c.mut.RUnlock()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+	return conn, codec.NewEncoder(conn, testHandle), codec.NewDecoder(conn, testHandle)
+}
+
+func receive(t *testing.T, ch chan loki.Entry) loki.Entry {
+	t.Helper()
+
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for log entries")
+		return loki.Entry{}
+	}
+}
+
+func TestFluentForward_Modes(t *testing.T) {
+	ts := time.Date(2023, 6, 1, 12, 0, 0, 500, time.UTC)
+
+	var packed bytes.Buffer
+	gz := gzip.NewWriter(&packed)
+	enc := codec.NewEncoder(gz, testHandle)
+	require.NoError(t, enc.Encode([]interface{}{eventTime(ts), map[string]interface{}{"log": "packed 1"}}))
+	require.NoError(t, enc.Encode([]interface{}{ts.Unix(), map[string]interface{}{"log": "packed 2"}}))
+	require.NoError(t, gz.Close())
+
+	c, ch := startComponent(t, Arguments{
+		UseIncomingTimestamp: true,
+		Labels:               map[string]string{"source": "fluent"},
+		RelabelRules: flow_relabel.Rules{{
+			SourceLabels: []string{tagLabel},
+			TargetLabel:  "tag",
+			Regex:        flow_relabel.Regexp{Regexp: flow_relabel.DefaultRelabelConfig.Regex.Regexp},
+			Replacement:  "$1",
+			Action:       flow_relabel.Replace,
+		}},
+	})
+	_, wr, rd := dial(t, c)
+
+	// Message mode.
+	require.NoError(t, wr.Encode([]interface{}{"app.message", ts.Unix(), map[string]interface{}{"log": "message", "nested": map[string]interface{}{"a": 1}}}))
+	e := receive(t, ch)
+	require.JSONEq(t, `{"log": "message", "nested": {"a": 1}}`, e.Line)
+	require.Equal(t, ts.Truncate(time.Second), e.Timestamp.UTC())
+	require.Equal(t, model.LabelSet{"job": "loki.source.fluentforward.test", "source": "fluent", "tag": "app.message"}, e.Labels)
+
+	// Forward mode, with an acknowledgement.
+	require.NoError(t, wr.Encode([]interface{}{
+		"app.forward",
+		[]interface{}{
+			[]interface{}{eventTime(ts), map[string]interface{}{"log": "forward 1"}},
+			[]interface{}{eventTime(ts), map[string]interface{}{"log": "forward 2"}},
+		},
+		map[string]interface{}{"chunk": "abc"},
+	}))
+	for _, line := range []string{`{"log":"forward 1"}`, `{"log":"forward 2"}`} {
+		e := receive(t, ch)
+		require.Equal(t, line, e.Line)
+		require.Equal(t, ts, e.Timestamp.UTC())
+		require.Equal(t, model.LabelValue("app.forward"), e.Labels["tag"])
+	}
+	var ack map[string]interface{}
+	require.NoError(t, rd.Decode(&ack))
+	require.Equal(t, "abc", ack["ack"])
+
+	// Compressed PackedForward mode.
+	require.NoError(t, wr.Encode([]interface{}{"app.packed", packed.Bytes(), map[string]interface{}{"compressed": "gzip"}}))
+	require.Equal(t, `{"log":"packed 1"}`, receive(t, ch).Line)
+	require.Equal(t, `{"log":"packed 2"}`, receive(t, ch).Line)
+}
+
+func TestFluentForward_SharedKey(t *testing.T) {
+	const key = "secret"
+
+	digest := func(salt []byte, hostname string, nonce []byte, key string) string {
+		h := sha512.New()
+		h.Write(salt)
+		h.Write([]byte(hostname))
+		h.Write(nonce)
+		h.Write([]byte(key))
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	c, ch := startComponent(t, Arguments{SharedKey: key, SelfHostname: "agent"})
+
+	handshake := func(t *testing.T, clientKey string) ([]interface{}, *codec.Encoder) {
+		_, wr, rd := dial(t, c)
+
+		var helo []interface{}
+		require.NoError(t, rd.Decode(&helo))
+		require.Equal(t, "HELO", helo[0])
+		nonce := helo[1].(map[interface{}]interface{})["nonce"].([]byte)
+
+		salt := []byte("salt")
+		require.NoError(t, wr.Encode([]interface{}{"PING", "client", salt, digest(salt, "client", nonce, clientKey), "", ""}))
+
+		var pong []interface{}
+		require.NoError(t, rd.Decode(&pong))
+		require.Equal(t, "PONG", pong[0])
+		if pong[1] == true {
+			require.Equal(t, "agent", pong[3])
+			require.Equal(t, digest(salt, "agent", nonce, key), pong[4])
+		}
+		return pong, wr
+	}
+
+	t.Run("valid key", func(t *testing.T) {
+		pong, wr := handshake(t, key)
+		require.Equal(t, true, pong[1])
+
+		require.NoError(t, wr.Encode([]interface{}{"app", time.Now().Unix(), map[string]interface{}{"log": "authenticated"}}))
+		require.Equal(t, `{"log":"authenticated"}`, receive(t, ch).Line)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		pong, _ := handshake(t, "wrong")
+		require.Equal(t, false, pong[1])
+		require.Equal(t, "shared_key mismatch", pong[2])
+	})
+}
@@ -0,0 +1,221 @@
+package forward
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/hashicorp/go-msgpack/codec"
+)
+
+// eventTimeExtType is the msgpack extension type of the EventTime values used
+// for timestamps with nanosecond precision.
+const eventTimeExtType = 0
+
+// handle is the msgpack handle used for reading and writing messages. Raw
+// strings are decoded as Go strings and maps are decoded with string keys.
+var handle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{RawToString: true, WriteExt: true}
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}()
+
+// Event is a single event received over the Forward protocol.
+type Event struct {
+	Tag    string
+	Time   time.Time
+	Record map[string]interface{}
+}
+
+// decodeMessage decodes the events and the options of a single message in
+// any of the Message, Forward and PackedForward modes of the protocol.
+func decodeMessage(msg []interface{}) ([]Event, map[string]interface{}, error) {
+	if len(msg) < 2 {
+		return nil, nil, fmt.Errorf("message has %d elements, expected at least 2", len(msg))
+	}
+	tag, ok := msg[0].(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("tag must be a string, got %T", msg[0])
+	}
+
+	switch entries := msg[1].(type) {
+	case []interface{}:
+		// Forward mode: [tag, [[time, record], ...], option]
+		opts, err := decodeOptions(msg, 2)
+		if err != nil {
+			return nil, nil, err
+		}
+		events := make([]Event, 0, len(entries))
+		for _, e := range entries {
+			entry, ok := e.([]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("entry must be an array, got %T", e)
+			}
+			ev, err := decodeEntry(tag, entry)
+			if err != nil {
+				return nil, nil, err
+			}
+			events = append(events, ev)
+		}
+		return events, opts, nil
+
+	case string, []byte:
+		// PackedForward mode: [tag, msgpack stream of [time, record], option]
+		opts, err := decodeOptions(msg, 2)
+		if err != nil {
+			return nil, nil, err
+		}
+		events, err := decodePacked(tag, toBytes(entries), opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return events, opts, nil
+
+	default:
+		// Message mode: [tag, time, record, option]
+		if len(msg) < 3 {
+			return nil, nil, fmt.Errorf("message has %d elements, expected at least 3", len(msg))
+		}
+		opts, err := decodeOptions(msg, 3)
+		if err != nil {
+			return nil, nil, err
+		}
+		ev, err := decodeEntry(tag, msg[1:3])
+		if err != nil {
+			return nil, nil, err
+		}
+		return []Event{ev}, opts, nil
+	}
+}
+
+// decodeOptions returns the option map found at index i of msg, if any.
+func decodeOptions(msg []interface{}, i int) (map[string]interface{}, error) {
+	if len(msg) <= i || msg[i] == nil {
+		return nil, nil
+	}
+	opts, ok := msg[i].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("option must be a map, got %T", msg[i])
+	}
+	return opts, nil
+}
+
+// decodePacked decodes the stream of entries of a PackedForward message.
+func decodePacked(tag string, data []byte, opts map[string]interface{}) ([]Event, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if compressed, _ := opts["compressed"].(string); compressed == "gzip" {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress entries: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	} else if compressed != "" && compressed != "text" {
+		return nil, fmt.Errorf("unsupported compression %q", compressed)
+	}
+
+	var (
+		events []Event
+		dec    = codec.NewDecoder(r, handle)
+	)
+	for {
+		var entry []interface{}
+		if err := dec.Decode(&entry); err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode packed entries: %w", err)
+		}
+		ev, err := decodeEntry(tag, entry)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+}
+
+// decodeEntry decodes a [time, record] pair.
+func decodeEntry(tag string, entry []interface{}) (Event, error) {
+	if len(entry) != 2 {
+		return Event{}, fmt.Errorf("entry has %d elements, expected 2", len(entry))
+	}
+	ts, err := decodeTime(entry[0])
+	if err != nil {
+		return Event{}, err
+	}
+	record, ok := entry[1].(map[string]interface{})
+	if !ok {
+		return Event{}, fmt.Errorf("record must be a map, got %T", entry[1])
+	}
+	return Event{Tag: tag, Time: ts, Record: record}, nil
+}
+
+// decodeTime decodes a timestamp given either in seconds or as an EventTime.
+func decodeTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case int64:
+		return time.Unix(v, 0), nil
+	case uint64:
+		return time.Unix(int64(v), 0), nil
+	case float64:
+		sec := int64(v)
+		return time.Unix(sec, int64((v-float64(sec))*float64(time.Second))), nil
+	case codec.RawExt:
+		if v.Tag != eventTimeExtType || len(v.Data) != 8 {
+			return time.Time{}, fmt.Errorf("invalid EventTime extension of type %d and length %d", v.Tag, len(v.Data))
+		}
+		sec := binary.BigEndian.Uint32(v.Data[:4])
+		nsec := binary.BigEndian.Uint32(v.Data[4:])
+		return time.Unix(int64(sec), int64(nsec)), nil
+	default:
+		return time.Time{}, fmt.Errorf("time must be a number or an EventTime, got %T", v)
+	}
+}
+
+func toBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case string:
+		return []byte(v)
+	case []byte:
+		return v
+	default:
+		return nil
+	}
+}
+
+// Normalize converts the values decoded from a record into values which can
+// be encoded as JSON.
+func Normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = Normalize(e)
+		}
+		return out
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[fmt.Sprint(Normalize(k))] = Normalize(e)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = Normalize(e)
+		}
+		return out
+	case codec.RawExt:
+		if ts, err := decodeTime(v); err == nil {
+			return ts.UTC().Format(time.RFC3339Nano)
+		}
+		return v.Data
+	default:
+		return v
+	}
+}
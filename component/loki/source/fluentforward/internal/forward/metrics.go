@@ -0,0 +1,42 @@
+package forward
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of Fluent Forward metrics.
+type Metrics struct {
+	entries      prometheus.Counter
+	errors       prometheus.Counter
+	authFailures prometheus.Counter
+}
+
+// NewMetrics creates a new set of Fluent Forward metrics. If reg is non-nil,
+// the metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+
+	m.entries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "agent",
+		Name:      "loki_source_fluentforward_entries_total",
+		Help:      "Total number of entries received over the Forward protocol",
+	})
+	m.errors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "agent",
+		Name:      "loki_source_fluentforward_parsing_errors_total",
+		Help:      "Total number of messages which couldn't be parsed",
+	})
+	m.authFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "agent",
+		Name:      "loki_source_fluentforward_auth_failures_total",
+		Help:      "Total number of connections which failed the shared key handshake",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.entries,
+			m.errors,
+			m.authFailures,
+		)
+	}
+
+	return &m
+}
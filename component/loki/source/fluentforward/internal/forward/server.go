@@ -0,0 +1,223 @@
+// Package forward implements a server for the Fluent Forward protocol used by
+// Fluentd and Fluent Bit to send events to each other.
+package forward
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/hashicorp/go-msgpack/codec"
+)
+
+// Config configures a Server.
+type Config struct {
+	// ListenAddress is the TCP address to listen on.
+	ListenAddress string
+	// SharedKey enables the handshake phase of the protocol when non-empty.
+	SharedKey string
+	// SelfHostname is the hostname reported to clients during the handshake.
+	SelfHostname string
+}
+
+// Handler is called with the events of every message received. Messages are
+// acknowledged to clients asking for it once Handler returns. ctx is canceled
+// when the server is stopped.
+type Handler func(ctx context.Context, events []Event)
+
+// Server receives events over the Fluent Forward protocol.
+type Server struct {
+	log     log.Logger
+	cfg     Config
+	metrics *Metrics
+	handler Handler
+
+	listener net.Listener
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	connsMut sync.Mutex
+	conns    map[net.Conn]struct{}
+}
+
+// NewServer creates a Server listening on the configured address.
+func NewServer(l log.Logger, m *Metrics, cfg Config, h Handler) (*Server, error) {
+	listener, err := net.Listen("tcp", cfg.ListenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cfg.ListenAddress, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		log:      log.With(l, "listen_address", listener.Addr().String()),
+		cfg:      cfg,
+		metrics:  m,
+		handler:  h,
+		listener: listener,
+		ctx:      ctx,
+		cancel:   cancel,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.acceptLoop()
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Stop closes the listener and all open connections and waits for the
+// handlers to return.
+func (s *Server) Stop() {
+	s.cancel()
+	_ = s.listener.Close()
+
+	s.connsMut.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.connsMut.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.ctx.Err() == nil {
+				level.Error(s.log).Log("msg", "failed to accept connection", "err", err)
+			}
+			return
+		}
+
+		s.connsMut.Lock()
+		s.conns[conn] = struct{}{}
+		s.connsMut.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.connsMut.Lock()
+				delete(s.conns, conn)
+				s.connsMut.Unlock()
+				_ = conn.Close()
+			}()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	l := log.With(s.log, "remote_addr", conn.RemoteAddr().String())
+	dec := codec.NewDecoder(bufio.NewReader(conn), handle)
+	enc := codec.NewEncoder(conn, handle)
+
+	if s.cfg.SharedKey != "" {
+		if err := s.handshake(dec, enc); err != nil {
+			s.metrics.authFailures.Inc()
+			level.Warn(l).Log("msg", "handshake failed", "err", err)
+			return
+		}
+	}
+
+	for {
+		var msg []interface{}
+		if err := dec.Decode(&msg); err != nil {
+			if !errors.Is(err, io.EOF) && s.ctx.Err() == nil {
+				// The stream can't be resynchronized after a decoding error.
+				s.metrics.errors.Inc()
+				level.Warn(l).Log("msg", "failed to decode message, closing connection", "err", err)
+			}
+			return
+		}
+
+		events, opts, err := decodeMessage(msg)
+		if err != nil {
+			s.metrics.errors.Inc()
+			level.Warn(l).Log("msg", "dropping invalid message", "err", err)
+			continue
+		}
+
+		s.handler(s.ctx, events)
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.metrics.entries.Add(float64(len(events)))
+
+		if chunk, ok := opts["chunk"]; ok {
+			if err := enc.Encode(map[string]interface{}{"ack": chunk}); err != nil {
+				level.Warn(l).Log("msg", "failed to acknowledge message", "err", err)
+				return
+			}
+		}
+	}
+}
+
+// handshake authenticates the client with the shared key, as described in
+// the handshake phase of the Forward protocol.
+func (s *Server) handshake(dec *codec.Decoder, enc *codec.Encoder) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	helo := []interface{}{"HELO", map[string]interface{}{
+		"nonce":     nonce,
+		"auth":      []byte{},
+		"keepalive": true,
+	}}
+	if err := enc.Encode(helo); err != nil {
+		return fmt.Errorf("failed to send HELO: %w", err)
+	}
+
+	// ["PING", client_hostname, shared_key_salt, shared_key_hexdigest, username, password]
+	var ping []interface{}
+	if err := dec.Decode(&ping); err != nil {
+		return fmt.Errorf("failed to read PING: %w", err)
+	}
+	if len(ping) < 4 || ping[0] != "PING" {
+		return fmt.Errorf("expected PING message")
+	}
+	hostname, _ := ping[1].(string)
+	salt := toBytes(ping[2])
+	digest, _ := ping[3].(string)
+
+	expect := sharedKeyDigest(salt, hostname, nonce, s.cfg.SharedKey)
+	if subtle.ConstantTimeCompare([]byte(digest), []byte(expect)) != 1 {
+		_ = enc.Encode([]interface{}{"PONG", false, "shared_key mismatch", "", ""})
+		return fmt.Errorf("shared key mismatch for client %q", hostname)
+	}
+
+	pong := []interface{}{"PONG", true, "", s.cfg.SelfHostname, sharedKeyDigest(salt, s.cfg.SelfHostname, nonce, s.cfg.SharedKey)}
+	if err := enc.Encode(pong); err != nil {
+		return fmt.Errorf("failed to send PONG: %w", err)
+	}
+	return nil
+}
+
+func sharedKeyDigest(salt []byte, hostname string, nonce []byte, key string) string {
+	h := sha512.New()
+	h.Write(salt)
+	h.Write([]byte(hostname))
+	h.Write(nonce)
+	h.Write([]byte(key))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -34,7 +34,7 @@ type Arguments struct {
 	// TODO(@tpaschalis) Having these types defined in an internal package
 	// means that an external caller cannot build this component's Arguments
 	// by hand for now.
-	PullTarget   *gt.PullConfig      `river:"pull,block,optional"`
+	PullTargets  []*gt.PullConfig    `river:"pull,block,optional"`
 	PushTarget   *gt.PushConfig      `river:"push,block,optional"`
 	ForwardTo    []loki.LogsReceiver `river:"forward_to,attr"`
 	RelabelRules flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
@@ -47,9 +47,18 @@ func (a *Arguments) SetToDefault() {
 
 // Validate implements river.Validator.
 func (a *Arguments) Validate() error {
-	if (a.PullTarget != nil) == (a.PushTarget != nil) {
+	if (len(a.PullTargets) > 0) == (a.PushTarget != nil) {
 		return fmt.Errorf("exactly one of 'push' or 'pull' must be provided")
 	}
+
+	seen := make(map[string]struct{}, len(a.PullTargets))
+	for _, p := range a.PullTargets {
+		key := p.ProjectID + "/" + p.Subscription
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("subscription %q of project %q is pulled from more than once", p.Subscription, p.ProjectID)
+		}
+		seen[key] = struct{}{}
+	}
 	return nil
 }
 
@@ -59,9 +68,9 @@ type Component struct {
 	metrics       *gt.Metrics
 	serverMetrics *util.UncheckedCollector
 
-	mut    sync.RWMutex
-	fanout []loki.LogsReceiver
-	target gt.Target
+	mut     sync.RWMutex
+	fanout  []loki.LogsReceiver
+	targets []gt.Target
 
 	handler loki.LogsReceiver
 }
@@ -91,10 +100,7 @@ func (c *Component) Run(ctx context.Context) error {
 	defer func() {
 		level.Info(c.opts.Logger).Log("msg", "loki.source.gcplog component shutting down, stopping the targets")
 		c.mut.RLock()
-		err := c.target.Stop()
-		if err != nil {
-			level.Error(c.opts.Logger).Log("msg", "error while stopping gcplog target", "err", err)
-		}
+		c.stopTargets()
 		c.mut.RUnlock()
 	}()
 
@@ -125,24 +131,24 @@ func (c *Component) Update(args component.Arguments) error {
 		rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
 	}
 
-	if c.target != nil {
-		err := c.target.Stop()
+	c.stopTargets()
+	c.targets = nil
+	jobName := strings.Replace(c.opts.ID, ".", "_", -1)
+
+	for _, pullConfig := range newArgs.PullTargets {
+		clientOptions, err := pullConfig.ClientOptions(context.Background())
 		if err != nil {
-			level.Error(c.opts.Logger).Log("msg", "error while stopping gcplog target", "err", err)
+			level.Error(c.opts.Logger).Log("msg", "failed to configure gcplog target credentials", "project_id", pullConfig.ProjectID, "err", err)
+			return err
 		}
-	}
-	entryHandler := loki.NewEntryHandler(c.handler, func() {})
-	jobName := strings.Replace(c.opts.ID, ".", "_", -1)
 
-	if newArgs.PullTarget != nil {
-		// TODO(@tpaschalis) Are there any options from "google.golang.org/api/option"
-		// we should expose as configuration and pass here?
-		t, err := gt.NewPullTarget(c.metrics, c.opts.Logger, entryHandler, jobName, newArgs.PullTarget, rcs)
+		entryHandler := loki.NewEntryHandler(c.handler, func() {})
+		t, err := gt.NewPullTarget(c.metrics, c.opts.Logger, entryHandler, jobName, pullConfig, rcs, clientOptions...)
 		if err != nil {
-			level.Error(c.opts.Logger).Log("msg", "failed to create gcplog target with provided config", "err", err)
+			level.Error(c.opts.Logger).Log("msg", "failed to create gcplog target with provided config", "project_id", pullConfig.ProjectID, "err", err)
 			return err
 		}
-		c.target = t
+		c.targets = append(c.targets, t)
 	}
 	if newArgs.PushTarget != nil {
 		// [gt.NewPushTarget] registers new metrics every time it is called. To
@@ -152,12 +158,13 @@ func (c *Component) Update(args component.Arguments) error {
 		registry := prometheus.NewRegistry()
 		c.serverMetrics.SetCollector(registry)
 
+		entryHandler := loki.NewEntryHandler(c.handler, func() {})
 		t, err := gt.NewPushTarget(c.metrics, c.opts.Logger, entryHandler, jobName, newArgs.PushTarget, rcs, registry)
 		if err != nil {
 			level.Error(c.opts.Logger).Log("msg", "failed to create gcplog target with provided config", "err", err)
 			return err
 		}
-		c.target = t
+		c.targets = append(c.targets, t)
 	}
 
 	return nil
@@ -167,9 +174,23 @@ func (c *Component) Update(args component.Arguments) error {
 func (c *Component) DebugInfo() interface{} {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
-	return targetDebugInfo{Details: c.target.Details()}
+
+	var info targetDebugInfo
+	for _, t := range c.targets {
+		info.Details = append(info.Details, t.Details())
+	}
+	return info
+}
+
+// stopTargets stops the running targets. It must be called with mut held.
+func (c *Component) stopTargets() {
+	for _, t := range c.targets {
+		if err := t.Stop(); err != nil {
+			level.Error(c.opts.Logger).Log("msg", "error while stopping gcplog target", "err", err)
+		}
+	}
 }
 
 type targetDebugInfo struct {
-	Details map[string]string `river:"target_info,attr"`
+	Details []map[string]string `river:"target_info,attr"`
 }
@@ -14,6 +14,7 @@ import (
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	gt "github.com/grafana/agent/component/loki/source/gcplog/internal/gcplogtarget"
 
+	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/regexp"
 	"github.com/phayes/freeport"
@@ -26,6 +27,136 @@ import (
 // the mock PubSub client inside the component, but we'll find a workaround.
 func TestPull(t *testing.T) {}
 
+func TestArguments_Pull(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "multiple projects",
+			cfg: `
+				pull {
+					project_id   = "project-a"
+					subscription = "logs"
+
+					credentials {
+						file = "/etc/gcp/project-a.json"
+					}
+				}
+				pull {
+					project_id   = "project-b"
+					subscription = "logs"
+
+					credentials {
+						impersonate_service_account = "reader@project-b.iam.gserviceaccount.com"
+					}
+				}
+			`,
+		},
+		{
+			name: "duplicate subscription",
+			cfg: `
+				pull {
+					project_id   = "project-a"
+					subscription = "logs"
+				}
+				pull {
+					project_id   = "project-a"
+					subscription = "logs"
+				}
+			`,
+			expect: `subscription "logs" of project "project-a" is pulled from more than once`,
+		},
+		{
+			name: "conflicting credentials",
+			cfg: `
+				pull {
+					project_id   = "project-a"
+					subscription = "logs"
+
+					credentials {
+						file = "/etc/gcp/project-a.json"
+						json = "{}"
+					}
+				}
+			`,
+			expect: "at most one of file and json can be set",
+		},
+		{
+			name: "delegates without impersonation",
+			cfg: `
+				pull {
+					project_id   = "project-a"
+					subscription = "logs"
+
+					credentials {
+						delegates = ["intermediate@project-a.iam.gserviceaccount.com"]
+					}
+				}
+			`,
+			expect: "delegates can only be set when impersonate_service_account is set",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg+"\nforward_to = []\n"), &args)
+			if tc.expect == "" {
+				require.NoError(t, err)
+				require.Len(t, args.PullTargets, 2)
+			} else {
+				require.ErrorContains(t, err, tc.expect)
+			}
+		})
+	}
+}
+
+func TestArguments_Push(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		push {
+			max_concurrent_requests = 100
+			queue_size              = 1000
+		}
+		forward_to = []
+	`), &args)
+	require.NoError(t, err)
+	require.Equal(t, 100, args.PushTarget.MaxConcurrentRequests)
+	require.Equal(t, 1000, args.PushTarget.QueueSize)
+	require.Equal(t, gt.QueueFullReject, args.PushTarget.QueueFullAction)
+
+	err = river.Unmarshal([]byte(`
+		push {
+			queue_size        = 1000
+			queue_full_action = "block"
+		}
+		forward_to = []
+	`), &args)
+	require.ErrorContains(t, err, `invalid queue_full_action "block", must be "reject" or "drop"`)
+}
+
+func TestCredentialsConfig_ClientOptions(t *testing.T) {
+	opts, err := (&gt.PullConfig{ProjectID: "project-a", Subscription: "logs"}).ClientOptions(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, opts, "Application Default Credentials should be used by default")
+
+	creds := gt.CredentialsConfig{File: "/etc/gcp/project-a.json"}
+	opts, err = creds.ClientOptions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, opts, 1)
+
+	// The impersonated token source replaces the base credentials.
+	creds = gt.CredentialsConfig{
+		JSON: `{"type": "authorized_user", "client_id": "id", "client_secret": "secret", "refresh_token": "token"}`,
+	}
+	creds.ImpersonateServiceAccount = "reader@project-b.iam.gserviceaccount.com"
+	opts, err = creds.ClientOptions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, opts, 1)
+}
+
 func TestPush(t *testing.T) {
 	opts := component.Options{
 		Logger:        util.TestFlowLogger(t),
@@ -49,7 +49,7 @@ type GCPLogEntry struct {
 	// above fields for now anyway we will be sending the entire entry to Loki.
 }
 
-func parseGCPLogsEntry(data []byte, other model.LabelSet, otherInternal labels.Labels, useIncomingTimestamp bool, useFullLine bool, relabelConfig []*relabel.Config) (loki.Entry, error) {
+func parseGCPLogsEntry(data []byte, other model.LabelSet, otherInternal labels.Labels, useIncomingTimestamp bool, useFullLine bool, relabelConfig []*relabel.Config, labelSets *loki.LabelSetInterner) (loki.Entry, error) {
 	var ge GCPLogEntry
 
 	if err := json.Unmarshal(data, &ge); err != nil {
@@ -96,7 +96,7 @@ func parseGCPLogsEntry(data []byte, other model.LabelSet, otherInternal labels.L
 	}
 
 	// add labels coming from scrapeconfig
-	labels = labels.Merge(other)
+	labels = labelSets.Intern(labels.Merge(other))
 
 	ts := time.Now()
 	line := string(data)
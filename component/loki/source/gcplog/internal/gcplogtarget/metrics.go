@@ -16,8 +16,13 @@ type Metrics struct {
 	gcplogErrors                  *prometheus.CounterVec
 	gcplogTargetLastSuccessScrape *prometheus.GaugeVec
 
-	gcpPushEntries *prometheus.CounterVec
-	gcpPushErrors  *prometheus.CounterVec
+	gcpPushEntries          *prometheus.CounterVec
+	gcpPushErrors           *prometheus.CounterVec
+	gcpPushInflight         prometheus.Gauge
+	gcpPushRejectedRequests *prometheus.CounterVec
+	gcpPushDroppedEntries   *prometheus.CounterVec
+	gcpPushQueueLength      prometheus.Gauge
+	gcpPushQueueCapacity    prometheus.Gauge
 }
 
 // NewMetrics creates a new set of metrics. Metrics will be registered to reg.
@@ -52,12 +57,42 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 		Help: "Number of parsing errors while receiving gcplog messages",
 	}, []string{"reason"})
 
+	m.gcpPushInflight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "loki_source_gcplog_push_inflight_requests",
+		Help: "Number of push requests being processed",
+	})
+
+	m.gcpPushRejectedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_gcplog_push_rejected_requests_total",
+		Help: "Number of push requests rejected with 503 because the target was overloaded",
+	}, []string{"reason"})
+
+	m.gcpPushDroppedEntries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_gcplog_push_dropped_entries_total",
+		Help: "Number of entries acknowledged to Pub/Sub but dropped before being forwarded",
+	}, []string{"reason"})
+
+	m.gcpPushQueueLength = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "loki_source_gcplog_push_queue_length",
+		Help: "Number of entries waiting in the push queue to be forwarded",
+	})
+
+	m.gcpPushQueueCapacity = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "loki_source_gcplog_push_queue_capacity",
+		Help: "Maximum number of entries in the push queue",
+	})
+
 	reg.MustRegister(
 		m.gcplogEntries,
 		m.gcplogErrors,
 		m.gcplogTargetLastSuccessScrape,
 		m.gcpPushEntries,
 		m.gcpPushErrors,
+		m.gcpPushInflight,
+		m.gcpPushRejectedRequests,
+		m.gcpPushDroppedEntries,
+		m.gcpPushQueueLength,
+		m.gcpPushQueueCapacity,
 	)
 	return &m
 }
@@ -31,6 +31,7 @@ type PullTarget struct {
 	config        *PullConfig
 	relabelConfig []*relabel.Config
 	jobName       string
+	labelSets     *loki.LabelSetInterner
 
 	// lifecycle management
 	ctx     context.Context
@@ -72,6 +73,7 @@ func NewPullTarget(metrics *Metrics, logger log.Logger, handler loki.EntryHandle
 		relabelConfig: relabel,
 		config:        config,
 		jobName:       jobName,
+		labelSets:     loki.NewLabelSetInterner(loki.DefaultLabelSetInternerSize),
 		ctx:           ctx,
 		cancel:        cancel,
 		ps:            ps,
@@ -106,7 +108,7 @@ func (t *PullTarget) run() error {
 		case <-t.ctx.Done():
 			return t.ctx.Err()
 		case m := <-t.msgs:
-			entry, err := parseGCPLogsEntry(m.Data, lbls, nil, t.config.UseIncomingTimestamp, t.config.UseFullLine, t.relabelConfig)
+			entry, err := parseGCPLogsEntry(m.Data, lbls, nil, t.config.UseIncomingTimestamp, t.config.UseFullLine, t.relabelConfig, t.labelSets)
 			if err != nil {
 				level.Error(t.logger).Log("event", "error formating log entry", "cause", err)
 				m.Ack()
@@ -150,8 +152,10 @@ func (t *PullTarget) Labels() model.LabelSet {
 // Details returns some debug information about the target.
 func (t *PullTarget) Details() map[string]string {
 	return map[string]string{
-		"strategy": "pull",
-		"labels":   t.Labels().String(),
+		"strategy":     "pull",
+		"project_id":   t.config.ProjectID,
+		"subscription": t.config.Subscription,
+		"labels":       t.Labels().String(),
 	}
 }
 
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -34,6 +35,18 @@ type PushTarget struct {
 	handler        loki.EntryHandler
 	relabelConfigs []*relabel.Config
 	server         *fnet.TargetServer
+	labelSets      *loki.LabelSetInterner
+
+	// requests limits the number of push requests processed concurrently. It
+	// is nil if the number of requests isn't limited.
+	requests chan struct{}
+
+	// queue holds the entries of acknowledged push requests until they're
+	// forwarded to entries. It is nil if requests are only acknowledged once
+	// their entry was forwarded.
+	queue chan loki.Entry
+	quit  chan struct{}
+	wg    sync.WaitGroup
 }
 
 // NewPushTarget constructs a PushTarget.
@@ -52,6 +65,17 @@ func NewPushTarget(metrics *Metrics, logger log.Logger, handler loki.EntryHandle
 		entries:        handler.Chan(),
 		handler:        handler,
 		relabelConfigs: relabel,
+		labelSets:      loki.NewLabelSetInterner(loki.DefaultLabelSetInternerSize),
+		quit:           make(chan struct{}),
+	}
+	if config.MaxConcurrentRequests > 0 {
+		pt.requests = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+	metrics.gcpPushQueueCapacity.Set(float64(config.QueueSize))
+	if config.QueueSize > 0 {
+		pt.queue = make(chan loki.Entry, config.QueueSize)
+		pt.wg.Add(1)
+		go pt.forwardQueue()
 	}
 
 	err = pt.server.MountAndRun(func(router *mux.Router) {
@@ -67,6 +91,22 @@ func NewPushTarget(metrics *Metrics, logger log.Logger, handler loki.EntryHandle
 func (p *PushTarget) push(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
+	// Reject requests before reading their body, so that bursts of requests
+	// don't pile up while entries can't be forwarded fast enough. Pub/Sub
+	// redelivers rejected messages later.
+	if p.requests != nil {
+		select {
+		case p.requests <- struct{}{}:
+			defer func() { <-p.requests }()
+		default:
+			p.metrics.gcpPushRejectedRequests.WithLabelValues("max_concurrent_requests").Inc()
+			http.Error(w, "too many concurrent push requests", http.StatusServiceUnavailable)
+			return
+		}
+	}
+	p.metrics.gcpPushInflight.Inc()
+	defer p.metrics.gcpPushInflight.Dec()
+
 	// Create no-op context.WithTimeout returns to simplify logic
 	ctx := r.Context()
 	cancel := context.CancelFunc(func() {})
@@ -97,7 +137,7 @@ func (p *PushTarget) push(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	entry, err := translate(pushMessage, p.Labels(), p.config.UseIncomingTimestamp, p.config.UseFullLine, p.relabelConfigs, r.Header.Get("X-Scope-OrgID"))
+	entry, err := translate(pushMessage, p.Labels(), p.config.UseIncomingTimestamp, p.config.UseFullLine, p.relabelConfigs, r.Header.Get("X-Scope-OrgID"), p.labelSets)
 	if err != nil {
 		p.metrics.gcpPushErrors.WithLabelValues("translation").Inc()
 		level.Warn(p.logger).Log("msg", "failed to translate gcp push request", "err", err.Error())
@@ -107,6 +147,22 @@ func (p *PushTarget) push(w http.ResponseWriter, r *http.Request) {
 
 	level.Debug(p.logger).Log("msg", fmt.Sprintf("Received line: %s", entry.Line))
 
+	if p.queue != nil {
+		if !p.enqueue(entry) {
+			if p.config.QueueFullAction == QueueFullDrop {
+				p.metrics.gcpPushDroppedEntries.WithLabelValues("queue_full").Inc()
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			p.metrics.gcpPushRejectedRequests.WithLabelValues("queue_full").Inc()
+			http.Error(w, "push queue is full", http.StatusServiceUnavailable)
+			return
+		}
+		p.metrics.gcpPushEntries.WithLabelValues().Inc()
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	if err := p.doSendEntry(ctx, entry); err != nil {
 		// NOTE: timeout errors can be tracked with from the metrics exposed by
 		// the spun weaveworks server.
@@ -130,6 +186,40 @@ func (p *PushTarget) doSendEntry(ctx context.Context, entry loki.Entry) error {
 	}
 }
 
+// enqueue adds entry to the queue, returning false if the queue is full.
+func (p *PushTarget) enqueue(entry loki.Entry) bool {
+	p.metrics.gcpPushQueueLength.Inc()
+	select {
+	case p.queue <- entry:
+		return true
+	default:
+		p.metrics.gcpPushQueueLength.Dec()
+		return false
+	}
+}
+
+// forwardQueue forwards the entries of the queue until the target is
+// stopped.
+func (p *PushTarget) forwardQueue() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.quit:
+			return
+		case entry := <-p.queue:
+			select {
+			case p.entries <- entry:
+				p.metrics.gcpPushQueueLength.Dec()
+			case <-p.quit:
+				p.metrics.gcpPushQueueLength.Dec()
+				p.metrics.gcpPushDroppedEntries.WithLabelValues("shutdown").Inc()
+				return
+			}
+		}
+	}
+}
+
 // Labels return the model.LabelSet that the target applies to log entries.
 func (p *PushTarget) Labels() model.LabelSet {
 	lbls := make(model.LabelSet, len(p.config.Labels))
@@ -152,6 +242,17 @@ func (p *PushTarget) Details() map[string]string {
 func (p *PushTarget) Stop() error {
 	level.Info(p.logger).Log("msg", "stopping gcp push target", "job", p.jobName)
 	p.server.StopAndShutdown()
+
+	close(p.quit)
+	p.wg.Wait()
+	if dropped := len(p.queue); dropped > 0 {
+		// The requests of these entries were already acknowledged, so they
+		// won't be redelivered.
+		level.Warn(p.logger).Log("msg", "dropping queued entries of stopped gcp push target", "entries", dropped)
+		p.metrics.gcpPushQueueLength.Sub(float64(dropped))
+		p.metrics.gcpPushDroppedEntries.WithLabelValues("shutdown").Add(float64(dropped))
+	}
+
 	p.handler.Stop()
 	return nil
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/phayes/freeport"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/stretchr/testify/require"
@@ -473,3 +474,139 @@ func waitForMessages(eh *fake.Client) {
 		countdown--
 	}
 }
+
+func TestPushTarget_MaxConcurrentRequests(t *testing.T) {
+	eh := newBlockingEntryHandler()
+	defer eh.Stop()
+
+	config := testPushConfig(t)
+	config.PushTimeout = time.Second
+	config.MaxConcurrentRequests = 1
+
+	metrics := NewMetrics(prometheus.NewRegistry())
+	pt, err := NewPushTarget(metrics, log.NewNopLogger(), eh, t.Name()+"_test_job", config, nil, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = pt.Stop()
+	}()
+
+	// The first request blocks until it times out, as its entry can't be
+	// forwarded.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		res, err := sendTestPushRequest(pt)
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(metrics.gcpPushInflight) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	res, err := sendTestPushRequest(pt)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.gcpPushRejectedRequests.WithLabelValues("max_concurrent_requests")))
+
+	// Don't stop the handler while the first request may still use it.
+	<-done
+}
+
+func TestPushTarget_Queue(t *testing.T) {
+	eh := fake.NewClient(func() {})
+	defer eh.Stop()
+
+	config := testPushConfig(t)
+	config.QueueSize = 10
+
+	pt, err := NewPushTarget(NewMetrics(prometheus.NewRegistry()), log.NewNopLogger(), eh, t.Name()+"_test_job", config, nil, nil)
+	require.NoError(t, err)
+	defer func() {
+		_ = pt.Stop()
+	}()
+
+	res, err := sendTestPushRequest(pt)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	waitForMessages(eh)
+	require.Len(t, eh.Received(), 1)
+	require.Equal(t, expectedMessageData, eh.Received()[0].Line)
+}
+
+func TestPushTarget_QueueFull(t *testing.T) {
+	tt := []struct {
+		action       string
+		expectStatus int
+	}{
+		{action: QueueFullReject, expectStatus: http.StatusServiceUnavailable},
+		{action: QueueFullDrop, expectStatus: http.StatusNoContent},
+	}
+	for _, tc := range tt {
+		t.Run(tc.action, func(t *testing.T) {
+			eh := newBlockingEntryHandler()
+			defer eh.Stop()
+
+			config := testPushConfig(t)
+			config.QueueSize = 1
+			config.QueueFullAction = tc.action
+
+			metrics := NewMetrics(prometheus.NewRegistry())
+			pt, err := NewPushTarget(metrics, log.NewNopLogger(), eh, "test_job_"+tc.action, config, nil, nil)
+			require.NoError(t, err)
+
+			requireStatus := func(status int) {
+				t.Helper()
+				res, err := sendTestPushRequest(pt)
+				require.NoError(t, err)
+				res.Body.Close()
+				require.Equal(t, status, res.StatusCode)
+			}
+
+			// The first entry is taken from the queue but can't be forwarded,
+			// and the second one fills the queue.
+			requireStatus(http.StatusNoContent)
+			require.Eventually(t, func() bool { return len(pt.queue) == 0 }, 5*time.Second, 10*time.Millisecond)
+			requireStatus(http.StatusNoContent)
+			require.Equal(t, 2.0, testutil.ToFloat64(metrics.gcpPushQueueLength))
+
+			requireStatus(tc.expectStatus)
+			if tc.action == QueueFullDrop {
+				require.Equal(t, 1.0, testutil.ToFloat64(metrics.gcpPushDroppedEntries.WithLabelValues("queue_full")))
+			} else {
+				require.Equal(t, 1.0, testutil.ToFloat64(metrics.gcpPushRejectedRequests.WithLabelValues("queue_full")))
+			}
+
+			// Entries which weren't forwarded are dropped once the target stops.
+			require.NoError(t, pt.Stop())
+			require.Equal(t, 2.0, testutil.ToFloat64(metrics.gcpPushDroppedEntries.WithLabelValues("shutdown")))
+			require.Zero(t, testutil.ToFloat64(metrics.gcpPushQueueLength))
+		})
+	}
+}
+
+func testPushConfig(t *testing.T) *PushConfig {
+	port, err := freeport.GetFreePort()
+	require.NoError(t, err)
+	return &PushConfig{
+		Server: &fnet.ServerConfig{
+			HTTP: &fnet.HTTPConfig{
+				ListenAddress: "localhost",
+				ListenPort:    port,
+			},
+			// assign random grpc port
+			GRPC: &fnet.GRPCConfig{ListenPort: 0},
+		},
+	}
+}
+
+func sendTestPushRequest(pt *PushTarget) (*http.Response, error) {
+	req, err := makeGCPPushRequest("http://"+pt.server.HTTPListenAddr(), testPayload)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
@@ -50,7 +50,7 @@ func (pm PushMessage) Validate() error {
 
 // translate converts a GCP PushMessage into a loki.Entry. It parses the
 // push-specific labels and delegates the rest to parseGCPLogsEntry.
-func translate(m PushMessage, other model.LabelSet, useIncomingTimestamp bool, useFullLine bool, relabelConfigs []*relabel.Config, xScopeOrgID string) (loki.Entry, error) {
+func translate(m PushMessage, other model.LabelSet, useIncomingTimestamp bool, useFullLine bool, relabelConfigs []*relabel.Config, xScopeOrgID string, labelSets *loki.LabelSetInterner) (loki.Entry, error) {
 	// Collect all push-specific labels. Every one of them is first configured
 	// as optional, and the user can relabel it if needed. The relabeling and
 	// internal drop is handled in parseGCPLogsEntry.
@@ -61,14 +61,16 @@ func translate(m PushMessage, other model.LabelSet, useIncomingTimestamp bool, u
 		lbs.Set(fmt.Sprintf("__gcp_attributes_%s", convertToLokiCompatibleLabel(k)), v)
 	}
 
-	// Add fixed labels coming from the target configuration
-	fixedLabels := other.Clone()
+	// Add fixed labels coming from the target configuration. They're only
+	// read, so they're only copied when the tenant ID is added.
+	fixedLabels := other
 
 	// If the incoming request carries the tenant id, inject it as the reserved
 	// label, so it's used by the remote write client.
 	if xScopeOrgID != "" {
 		// Expose tenant ID through relabel to use as logs or metrics label.
 		lbs.Set(ReservedLabelTenantID, xScopeOrgID)
+		fixedLabels = other.Clone()
 		fixedLabels[ReservedLabelTenantID] = model.LabelValue(xScopeOrgID)
 	}
 
@@ -77,7 +79,7 @@ func translate(m PushMessage, other model.LabelSet, useIncomingTimestamp bool, u
 		return loki.Entry{}, fmt.Errorf("failed to decode data: %w", err)
 	}
 
-	entry, err := parseGCPLogsEntry(decodedData, fixedLabels, lbs.Labels(nil), useIncomingTimestamp, useFullLine, relabelConfigs)
+	entry, err := parseGCPLogsEntry(decodedData, fixedLabels, lbs.Labels(nil), useIncomingTimestamp, useFullLine, relabelConfigs, labelSets)
 	if err != nil {
 		return loki.Entry{}, fmt.Errorf("failed to parse logs entry: %w", err)
 	}
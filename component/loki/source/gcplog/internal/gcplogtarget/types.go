@@ -1,10 +1,15 @@
 package gcplogtarget
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"cloud.google.com/go/pubsub"
 	fnet "github.com/grafana/agent/component/common/net"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
 )
 
 // Target is a common interface implemented by both GCPLog targets.
@@ -20,21 +25,106 @@ type PullConfig struct {
 	Labels               map[string]string `river:"labels,attr,optional"`
 	UseIncomingTimestamp bool              `river:"use_incoming_timestamp,attr,optional"`
 	UseFullLine          bool              `river:"use_full_line,attr,optional"`
+
+	Credentials *CredentialsConfig `river:"credentials,block,optional"`
+}
+
+// Validate implements river.Validator.
+func (p *PullConfig) Validate() error {
+	if p.ProjectID == "" {
+		return fmt.Errorf("project_id must not be empty")
+	}
+	if p.Subscription == "" {
+		return fmt.Errorf("subscription must not be empty")
+	}
+	return nil
+}
+
+// ClientOptions returns the options used to create the Pub/Sub client of the
+// target. Application Default Credentials are used when no credentials are
+// configured.
+func (p *PullConfig) ClientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	if p.Credentials == nil {
+		return nil, nil
+	}
+	return p.Credentials.ClientOptions(ctx)
+}
+
+// CredentialsConfig configures the credentials used to pull from a
+// subscription, allowing each target to authenticate as a different service
+// account.
+type CredentialsConfig struct {
+	File string            `river:"file,attr,optional"`
+	JSON rivertypes.Secret `river:"json,attr,optional"`
+
+	// ImpersonateServiceAccount is the email of a service account to
+	// impersonate with the credentials set by File, JSON, or Application
+	// Default Credentials.
+	ImpersonateServiceAccount string   `river:"impersonate_service_account,attr,optional"`
+	Delegates                 []string `river:"delegates,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (c *CredentialsConfig) Validate() error {
+	if c.File != "" && c.JSON != "" {
+		return fmt.Errorf("at most one of file and json can be set")
+	}
+	if len(c.Delegates) > 0 && c.ImpersonateServiceAccount == "" {
+		return fmt.Errorf("delegates can only be set when impersonate_service_account is set")
+	}
+	return nil
 }
 
+// ClientOptions returns the client options authenticating with c.
+func (c *CredentialsConfig) ClientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+	switch {
+	case c.File != "":
+		opts = append(opts, option.WithCredentialsFile(c.File))
+	case c.JSON != "":
+		opts = append(opts, option.WithCredentialsJSON([]byte(c.JSON)))
+	}
+
+	if c.ImpersonateServiceAccount == "" {
+		return opts, nil
+	}
+	ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+		TargetPrincipal: c.ImpersonateServiceAccount,
+		Scopes:          []string{pubsub.ScopePubSub},
+		Delegates:       c.Delegates,
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to impersonate %s: %w", c.ImpersonateServiceAccount, err)
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
+
+// Actions of a push target when its queue is full.
+const (
+	// QueueFullReject responds to push requests with 503 so that Pub/Sub
+	// retries them later.
+	QueueFullReject = "reject"
+	// QueueFullDrop acknowledges push requests and drops their entry.
+	QueueFullDrop = "drop"
+)
+
 // PushConfig configures a GCPLog target with the 'push' strategy.
 type PushConfig struct {
-	Server               *fnet.ServerConfig `river:",squash"`
-	PushTimeout          time.Duration      `river:"push_timeout,attr,optional"`
-	Labels               map[string]string  `river:"labels,attr,optional"`
-	UseIncomingTimestamp bool               `river:"use_incoming_timestamp,attr,optional"`
-	UseFullLine          bool               `river:"use_full_line,attr,optional"`
+	Server                *fnet.ServerConfig `river:",squash"`
+	PushTimeout           time.Duration      `river:"push_timeout,attr,optional"`
+	Labels                map[string]string  `river:"labels,attr,optional"`
+	UseIncomingTimestamp  bool               `river:"use_incoming_timestamp,attr,optional"`
+	UseFullLine           bool               `river:"use_full_line,attr,optional"`
+	MaxConcurrentRequests int                `river:"max_concurrent_requests,attr,optional"`
+	QueueSize             int                `river:"queue_size,attr,optional"`
+	QueueFullAction       string             `river:"queue_full_action,attr,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
 func (p *PushConfig) SetToDefault() {
 	*p = PushConfig{
-		Server: fnet.DefaultServerConfig(),
+		Server:          fnet.DefaultServerConfig(),
+		QueueFullAction: QueueFullReject,
 	}
 }
 
@@ -43,5 +133,16 @@ func (p *PushConfig) Validate() error {
 	if p.PushTimeout < 0 {
 		return fmt.Errorf("push_timeout must be greater than zero")
 	}
+	if p.MaxConcurrentRequests < 0 {
+		return fmt.Errorf("max_concurrent_requests must not be negative")
+	}
+	if p.QueueSize < 0 {
+		return fmt.Errorf("queue_size must not be negative")
+	}
+	switch p.QueueFullAction {
+	case QueueFullReject, QueueFullDrop:
+	default:
+		return fmt.Errorf("invalid queue_full_action %q, must be %q or %q", p.QueueFullAction, QueueFullReject, QueueFullDrop)
+	}
 	return nil
 }
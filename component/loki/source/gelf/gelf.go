@@ -2,6 +2,7 @@ package gelf
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/grafana/agent/component"
@@ -79,7 +80,7 @@ func (c *Component) Update(args component.Arguments) error {
 		rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
 	}
 
-	t, err := target.NewTarget(c.metrics, c.o.Logger, c.handler, rcs, convertConfig(newArgs))
+	t, err := target.NewTarget(c.metrics, c.o.Logger, c.handler, rcs, convertConfig(newArgs), newArgs.Protocol)
 	if err != nil {
 		return err
 	}
@@ -89,8 +90,8 @@ func (c *Component) Update(args component.Arguments) error {
 
 // Arguments are the arguments for the component.
 type Arguments struct {
-	// ListenAddress only supports UDP.
 	ListenAddress        string              `river:"listen_address,attr,optional"`
+	Protocol             string              `river:"protocol,attr,optional"`
 	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
 	RelabelRules         flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
 	Receivers            []loki.LogsReceiver `river:"forward_to,attr"`
@@ -99,6 +100,7 @@ type Arguments struct {
 func defaultArgs() Arguments {
 	return Arguments{
 		ListenAddress:        "0.0.0.0:12201",
+		Protocol:             target.ProtocolUDP,
 		UseIncomingTimestamp: false,
 	}
 }
@@ -108,6 +110,16 @@ func (r *Arguments) SetToDefault() {
 	*r = defaultArgs()
 }
 
+// Validate implements river.Validator.
+func (r *Arguments) Validate() error {
+	switch r.Protocol {
+	case target.ProtocolUDP, target.ProtocolTCP:
+		return nil
+	default:
+		return fmt.Errorf("protocol must be %q or %q, got %q", target.ProtocolUDP, target.ProtocolTCP, r.Protocol)
+	}
+}
+
 func convertConfig(a Arguments) *scrapeconfig.GelfTargetConfig {
 	return &scrapeconfig.GelfTargetConfig{
 		ListenAddress:        a.ListenAddress,
@@ -10,9 +10,12 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	"github.com/phayes/freeport"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,6 +56,77 @@ func TestGelf(t *testing.T) {
 	require.True(t, found)
 }
 
+func TestGelf_TCP(t *testing.T) {
+	opts := component.Options{
+		ID:            "loki.source.gelf.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	var rules struct {
+		Rules []*flow_relabel.Config `river:"rule,block"`
+	}
+	require.NoError(t, river.Unmarshal([]byte(`
+		rule {
+			source_labels = ["__gelf_message_field_container_name"]
+			target_label  = "container"
+		}
+		rule {
+			source_labels = ["__gelf_message_level"]
+			target_label  = "level"
+		}`), &rules))
+
+	ch1 := make(chan loki.Entry)
+	tcpListenerAddr := getFreeAddr(t)
+	args := defaultArgs()
+	args.ListenAddress = tcpListenerAddr
+	args.Protocol = "tcp"
+	args.RelabelRules = rules.Rules
+	args.Receivers = []loki.LogsReceiver{ch1}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFunc()
+	go c.Run(ctx)
+
+	wr, err := net.Dial("tcp", tcpListenerAddr)
+	require.NoError(t, err)
+	defer wr.Close()
+
+	// Messages are null-delimited, and may be split across writes.
+	_, err = wr.Write([]byte(`{"version":"1.1","host":"example.org","short_message":"first","level":3,"_container_name":"app"}` + "\x00" + `{"version":"1.1",`))
+	require.NoError(t, err)
+	_, err = wr.Write([]byte(`"host":"example.org","short_message":"second","level":7}` + "\x00"))
+	require.NoError(t, err)
+
+	for _, expect := range []struct {
+		line   string
+		labels model.LabelSet
+	}{
+		{"first", model.LabelSet{"container": "app", "level": "error", "job": "loki.source.gelf.test"}},
+		{"second", model.LabelSet{"level": "debug", "job": "loki.source.gelf.test"}},
+	} {
+		select {
+		case <-ctx.Done():
+			require.FailNow(t, "timed out waiting for log entries")
+		case e := <-ch1:
+			require.Contains(t, e.Entry.Line, expect.line)
+			require.Equal(t, expect.labels, e.Labels)
+		}
+	}
+}
+
+func TestArguments_Validate(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		forward_to = []
+		protocol   = "http"
+	`), &args)
+	require.EqualError(t, err, `protocol must be "udp" or "tcp", got "http"`)
+}
+
 func getFreeAddr(t *testing.T) string {
 	t.Helper()
 
@@ -7,6 +7,9 @@ package target
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -38,14 +41,31 @@ var SeverityLevels = map[int32]string{
 	7: "debug",
 }
 
-// Target listens to gelf messages on udp.
+// Supported protocols to receive gelf messages with.
+const (
+	ProtocolUDP = "udp"
+	ProtocolTCP = "tcp"
+)
+
+// extraFieldLabelPrefix is the prefix of the internal labels holding the
+// additional fields of gelf messages.
+const extraFieldLabelPrefix = "__gelf_message_field_"
+
+// messageReader reads gelf messages from a listener.
+type messageReader interface {
+	ReadMessage() (*gelf.Message, error)
+	Close() error
+}
+
+// Target listens to gelf messages on udp or tcp.
 type Target struct {
 	metrics       *Metrics
 	logger        log.Logger
 	handler       loki.EntryHandler
 	config        *scrapeconfig.GelfTargetConfig
+	protocol      string
 	relabelConfig []*relabel.Config
-	gelfReader    *gelf.Reader
+	gelfReader    messageReader
 	encodeBuff    *bytes.Buffer
 	wg            sync.WaitGroup
 
@@ -60,13 +80,26 @@ func NewTarget(
 	handler loki.EntryHandler,
 	relabel []*relabel.Config,
 	config *scrapeconfig.GelfTargetConfig,
+	protocol string,
 ) (*Target, error) {
 
 	if config.ListenAddress == "" {
 		config.ListenAddress = ":12201"
 	}
 
-	gelfReader, err := gelf.NewReader(config.ListenAddress)
+	var (
+		gelfReader messageReader
+		err        error
+	)
+	switch protocol {
+	case ProtocolUDP, "":
+		protocol = ProtocolUDP
+		gelfReader, err = gelf.NewReader(config.ListenAddress)
+	case ProtocolTCP:
+		gelfReader, err = newTCPReader(config.ListenAddress)
+	default:
+		err = fmt.Errorf("unsupported protocol %q", protocol)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +110,7 @@ func NewTarget(
 		logger:        logger,
 		handler:       handler,
 		config:        config,
+		protocol:      protocol,
 		relabelConfig: relabel,
 		gelfReader:    gelfReader,
 		encodeBuff:    bytes.NewBuffer(make([]byte, 0, 1024)),
@@ -93,14 +127,18 @@ func (t *Target) run() {
 	t.wg.Add(1)
 	go func() {
 		defer t.wg.Done()
-		level.Info(t.logger).Log("msg", "listening for GELF UDP messages", "listen_address", t.config.ListenAddress)
+		level.Info(t.logger).Log("msg", "listening for GELF messages", "protocol", t.protocol, "listen_address", t.config.ListenAddress)
 		for {
 			select {
 			case <-t.ctx.Done():
-				level.Info(t.logger).Log("msg", "GELF UDP listener shutdown", "listen_address", t.config.ListenAddress)
+				level.Info(t.logger).Log("msg", "GELF listener shutdown", "protocol", t.protocol, "listen_address", t.config.ListenAddress)
 				return
 			default:
 				msg, err := t.gelfReader.ReadMessage()
+				if err != nil && t.ctx.Err() != nil {
+					// The reader was closed while shutting down.
+					continue
+				}
 				if err != nil {
 					level.Error(t.logger).Log("msg", "error while reading gelf message", "listen_address", t.config.ListenAddress, "err", err)
 					t.metrics.gelfErrors.Inc()
@@ -126,6 +164,9 @@ func (t *Target) handleMessage(msg *gelf.Message) {
 	lb.Set("__gelf_message_host", msg.Host)
 	lb.Set("__gelf_message_version", msg.Version)
 	lb.Set("__gelf_message_facility", msg.Facility)
+	for k, v := range msg.Extra {
+		lb.Set(extraFieldLabelName(k), extraFieldValue(v))
+	}
 
 	processed, _ := relabel.Process(lb.Labels(nil), t.relabelConfig...)
 
@@ -160,6 +201,33 @@ func (t *Target) handleMessage(msg *gelf.Message) {
 	}
 }
 
+// extraFieldLabelName returns the name of the internal label holding the
+// additional field named field. The leading underscore of the field is
+// dropped and characters which aren't valid in label names are replaced with
+// underscores.
+func extraFieldLabelName(field string) string {
+	name := []byte(strings.TrimPrefix(field, "_"))
+	for i, b := range name {
+		if !(b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')) {
+			name[i] = '_'
+		}
+	}
+	return extraFieldLabelPrefix + string(name)
+}
+
+// extraFieldValue formats the value of an additional field as a label value.
+func extraFieldValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
 func secondsToUnixTimestamp(seconds float64) time.Time {
 	return time.Unix(0, int64(seconds*float64(time.Second)))
 }
@@ -193,7 +261,7 @@ func (t *Target) Details() interface{} {
 
 // Stop shuts down the GelfTarget.
 func (t *Target) Stop() {
-	level.Info(t.logger).Log("msg", "Shutting down GELF UDP listener", "listen_address", t.config.ListenAddress)
+	level.Info(t.logger).Log("msg", "Shutting down GELF listener", "protocol", t.protocol, "listen_address", t.config.ListenAddress)
 	t.ctxCancel()
 	if err := t.gelfReader.Close(); err != nil {
 		level.Error(t.logger).Log("msg", "error while closing gelf reader", "err", err)
@@ -0,0 +1,174 @@
+package target
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/grafana/go-gelf/v2/gelf"
+)
+
+// maxTCPMessageSize is the maximum size of a single GELF message received
+// over TCP.
+const maxTCPMessageSize = 2 << 20
+
+// tcpReader reads GELF messages from TCP connections. Messages are
+// uncompressed and delimited by null bytes, as sent by the Docker GELF
+// logging driver and Graylog forwarders.
+type tcpReader struct {
+	listener net.Listener
+	results  chan tcpResult
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	mut   sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+type tcpResult struct {
+	msg *gelf.Message
+	err error
+}
+
+func newTCPReader(addr string) (*tcpReader, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &tcpReader{
+		listener: listener,
+		results:  make(chan tcpResult),
+		done:     make(chan struct{}),
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.accept()
+	}()
+	return r, nil
+}
+
+func (r *tcpReader) accept() {
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			if !r.send(tcpResult{err: fmt.Errorf("accepting connection: %w", err)}) {
+				return
+			}
+			continue
+		}
+
+		r.mut.Lock()
+		select {
+		case <-r.done:
+			// The reader was closed after the connection was accepted.
+			r.mut.Unlock()
+			_ = conn.Close()
+			return
+		default:
+			r.conns[conn] = struct{}{}
+		}
+		r.mut.Unlock()
+
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.handleConnection(conn)
+		}()
+	}
+}
+
+func (r *tcpReader) handleConnection(conn net.Conn) {
+	defer func() {
+		r.mut.Lock()
+		delete(r.conns, conn)
+		r.mut.Unlock()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxTCPMessageSize)
+	scanner.Split(scanNullDelimited)
+
+	for scanner.Scan() {
+		frame := bytes.TrimSpace(scanner.Bytes())
+		if len(frame) == 0 {
+			continue
+		}
+
+		var res tcpResult
+		msg := new(gelf.Message)
+		if err := msg.UnmarshalJSON(frame); err != nil {
+			res.err = fmt.Errorf("unmarshaling message from %s: %w", conn.RemoteAddr(), err)
+		} else {
+			res.msg = msg
+		}
+		if !r.send(res) {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		r.send(tcpResult{err: fmt.Errorf("reading from %s: %w", conn.RemoteAddr(), err)})
+	}
+}
+
+// send passes res to ReadMessage. It returns false if the reader was closed.
+func (r *tcpReader) send(res tcpResult) bool {
+	select {
+	case r.results <- res:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// ReadMessage returns the next message received from any connection.
+func (r *tcpReader) ReadMessage() (*gelf.Message, error) {
+	select {
+	case res := <-r.results:
+		return res.msg, res.err
+	case <-r.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close stops accepting connections and closes the open ones.
+func (r *tcpReader) Close() error {
+	close(r.done)
+	err := r.listener.Close()
+
+	r.mut.Lock()
+	for conn := range r.conns {
+		_ = conn.Close()
+	}
+	r.mut.Unlock()
+
+	r.wg.Wait()
+	return err
+}
+
+// scanNullDelimited is a bufio.SplitFunc which splits null-delimited frames.
+// A frame that isn't followed by a null byte at the end of the stream is
+// returned as is.
+func scanNullDelimited(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
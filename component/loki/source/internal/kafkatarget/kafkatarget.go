@@ -38,6 +38,10 @@ type KafkaTarget struct {
 	relabelConfig        []*relabel.Config
 	useIncomingTimestamp bool
 	messageParser        MessageParser
+
+	// Deduplicates the labels of messages, which mostly differ by the
+	// labels relabeled from their key.
+	labelSets *loki.LabelSetInterner
 }
 
 func NewKafkaTarget(
@@ -62,6 +66,7 @@ func NewKafkaTarget(
 		relabelConfig:        relabelConfig,
 		useIncomingTimestamp: useIncomingTimestamp,
 		messageParser:        messageParser,
+		labelSets:            loki.NewLabelSetInterner(loki.DefaultLabelSetInternerSize),
 	}
 }
 
@@ -85,9 +90,11 @@ func (t *KafkaTarget) run() {
 			Value: mk,
 		}}, t.relabelConfig)
 
-		out := t.lbs.Clone()
+		// Labels are shared between entries and never modified, so t.lbs
+		// doesn't need to be copied for every message.
+		out := t.lbs
 		if len(lbs) > 0 {
-			out = out.Merge(lbs)
+			out = t.labelSets.Intern(out.Merge(lbs))
 		}
 		entries, err := t.messageParser.Parse(message, out, t.relabelConfig, t.useIncomingTimestamp)
 		if err != nil {
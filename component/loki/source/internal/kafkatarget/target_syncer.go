@@ -73,7 +73,7 @@ func NewSyncer(
 	default:
 		return nil, fmt.Errorf("unrecognized consumer group partition assignor: %s", cfg.KafkaConfig.Assignor)
 	}
-	config, err = withAuthentication(*config, cfg.KafkaConfig.Authentication)
+	config, err = WithAuthentication(*config, cfg.KafkaConfig.Authentication)
 	if err != nil {
 		return nil, fmt.Errorf("error setting up kafka authentication: %w", err)
 	}
@@ -116,7 +116,9 @@ func NewSyncer(
 	return t, nil
 }
 
-func withAuthentication(cfg sarama.Config, authCfg Authentication) (*sarama.Config, error) {
+// WithAuthentication returns a copy of cfg configured to authenticate with
+// Kafka brokers using authCfg.
+func WithAuthentication(cfg sarama.Config, authCfg Authentication) (*sarama.Config, error) {
 	if len(authCfg.Type) == 0 || authCfg.Type == AuthenticationTypeNone {
 		return &cfg, nil
 	}
@@ -204,7 +204,7 @@ func Test_validateConfig(t *testing.T) {
 	}
 }
 
-func Test_withAuthentication(t *testing.T) {
+func Test_WithAuthentication(t *testing.T) {
 	var (
 		tlsConf = config.TLSConfig{
 			CAFile:             "testdata/example.com.ca.pem",
@@ -224,7 +224,7 @@ func Test_withAuthentication(t *testing.T) {
 	)
 
 	// no authentication
-	noAuthCfg, err := withAuthentication(*cfg, Authentication{
+	noAuthCfg, err := WithAuthentication(*cfg, Authentication{
 		Type: AuthenticationTypeNone,
 	})
 	assert.Nil(t, err)
@@ -233,14 +233,14 @@ func Test_withAuthentication(t *testing.T) {
 	assert.NoError(t, noAuthCfg.Validate())
 
 	// specify unsupported auth type
-	illegalAuthTypeCfg, err := withAuthentication(*cfg, Authentication{
+	illegalAuthTypeCfg, err := WithAuthentication(*cfg, Authentication{
 		Type: "illegal",
 	})
 	assert.NotNil(t, err)
 	assert.Nil(t, illegalAuthTypeCfg)
 
 	// mTLS authentication
-	mTLSCfg, err := withAuthentication(*cfg, Authentication{
+	mTLSCfg, err := WithAuthentication(*cfg, Authentication{
 		Type:      AuthenticationTypeSSL,
 		TLSConfig: tlsConf,
 	})
@@ -254,7 +254,7 @@ func Test_withAuthentication(t *testing.T) {
 	assert.NoError(t, mTLSCfg.Validate())
 
 	// mTLS authentication expect ignore sasl
-	mTLSCfg, err = withAuthentication(*cfg, Authentication{
+	mTLSCfg, err = WithAuthentication(*cfg, Authentication{
 		Type:      AuthenticationTypeSSL,
 		TLSConfig: tlsConf,
 		SASLConfig: SASLConfig{
@@ -268,7 +268,7 @@ func Test_withAuthentication(t *testing.T) {
 	assert.Equal(t, false, mTLSCfg.Net.SASL.Enable)
 
 	// SASL/PLAIN
-	saslCfg, err := withAuthentication(*cfg, Authentication{
+	saslCfg, err := WithAuthentication(*cfg, Authentication{
 		Type: AuthenticationTypeSASL,
 		SASLConfig: SASLConfig{
 			Mechanism: sarama.SASLTypePlaintext,
@@ -285,7 +285,7 @@ func Test_withAuthentication(t *testing.T) {
 	assert.NoError(t, saslCfg.Validate())
 
 	// SASL/SCRAM
-	saslCfg, err = withAuthentication(*cfg, Authentication{
+	saslCfg, err = WithAuthentication(*cfg, Authentication{
 		Type: AuthenticationTypeSASL,
 		SASLConfig: SASLConfig{
 			Mechanism: sarama.SASLTypeSCRAMSHA512,
@@ -302,7 +302,7 @@ func Test_withAuthentication(t *testing.T) {
 	assert.NoError(t, saslCfg.Validate())
 
 	// SASL unsupported mechanism
-	_, err = withAuthentication(*cfg, Authentication{
+	_, err = WithAuthentication(*cfg, Authentication{
 		Type: AuthenticationTypeSASL,
 		SASLConfig: SASLConfig{
 			Mechanism: sarama.SASLTypeGSSAPI,
@@ -314,7 +314,7 @@ func Test_withAuthentication(t *testing.T) {
 	assert.Equal(t, err.Error(), "error unsupported sasl mechanism: GSSAPI")
 
 	// SASL over TLS
-	saslCfg, err = withAuthentication(*cfg, Authentication{
+	saslCfg, err = WithAuthentication(*cfg, Authentication{
 		Type: AuthenticationTypeSASL,
 		SASLConfig: SASLConfig{
 			Mechanism: sarama.SASLTypeSCRAMSHA512,
@@ -12,6 +12,7 @@ import (
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	kt "github.com/grafana/agent/component/loki/source/internal/kafkatarget"
 	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 )
 
@@ -93,6 +94,7 @@ type Component struct {
 	target *kt.TargetSyncer
 
 	handler loki.LogsReceiver
+	entries prometheus.Counter
 }
 
 // New creates a new loki.source.kafka component.
@@ -103,6 +105,13 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		fanout:  args.ForwardTo,
 		target:  nil,
 		handler: make(loki.LogsReceiver),
+		entries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_source_kafka_entries_total",
+			Help: "Total number of log entries read from Kafka.",
+		}),
+	}
+	if err := o.Registerer.Register(c.entries); err != nil {
+		return nil, err
 	}
 
 	// Call to Update() to start readers and set receivers once at the start.
@@ -133,6 +142,7 @@ func (c *Component) Run(ctx context.Context) error {
 		case <-ctx.Done():
 			return nil
 		case entry := <-c.handler:
+			c.entries.Inc()
 			c.mut.RLock()
 			for _, receiver := range c.fanout {
 				receiver <- entry
@@ -191,6 +201,13 @@ func (args *Arguments) Convert() kt.Config {
 	}
 }
 
+// SaramaConfig returns a copy of cfg configured to authenticate with Kafka
+// brokers using auth. It allows other components connecting to Kafka brokers
+// to share the authentication settings of loki.source.kafka.
+func (auth KafkaAuthentication) SaramaConfig(cfg sarama.Config) (*sarama.Config, error) {
+	return kt.WithAuthentication(cfg, auth.Convert())
+}
+
 func (auth KafkaAuthentication) Convert() kt.Authentication {
 	var secret flagext.Secret
 	if auth.SASLConfig.Password != "" {
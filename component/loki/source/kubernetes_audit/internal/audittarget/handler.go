@@ -0,0 +1,220 @@
+package audittarget
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/agent/component/common/loki"
+	lokiClient "github.com/grafana/agent/component/common/loki/client"
+)
+
+// Sender is an interface that decouples the audit webhook handler from the
+// destination where read loki entries should be written to.
+type Sender interface {
+	Send(ctx context.Context, entry loki.Entry)
+}
+
+// Config holds the settings of a Handler.
+type Config struct {
+	// Labels are added to every entry before relabeling.
+	Labels model.LabelSet
+
+	// RelabelRules are applied to the internal labels of every entry.
+	RelabelRules []*relabel.Config
+
+	// UseIncomingTimestamp sets the entry timestamp to the stage timestamp of
+	// the audit event instead of the time it was received.
+	UseIncomingTimestamp bool
+
+	// BearerToken, when not empty, must be presented by the API server in the
+	// Authorization header of every request.
+	BearerToken string
+}
+
+// Handler implements a http.Handler that receives batches of audit events
+// from the Kubernetes API server webhook backend.
+type Handler struct {
+	metrics *Metrics
+	logger  log.Logger
+	sender  Sender
+	config  Config
+}
+
+// NewHandler creates a new handler.
+func NewHandler(sender Sender, logger log.Logger, metrics *Metrics, config Config) *Handler {
+	return &Handler{
+		metrics: metrics,
+		logger:  logger,
+		sender:  sender,
+		config:  config,
+	}
+}
+
+// ServeHTTP satisfies the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	if !h.authorized(req) {
+		h.metrics.errorsAPIRequest.WithLabelValues("unauthorized").Inc()
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var (
+		bodyReader io.Reader = req.Body
+		err        error
+	)
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		bodyReader, err = gzip.NewReader(req.Body)
+		if err != nil {
+			h.metrics.errorsAPIRequest.WithLabelValues("pre_read").Inc()
+			level.Error(h.logger).Log("msg", "failed to create gzip reader", "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var eventList EventList
+	if err := json.NewDecoder(bodyReader).Decode(&eventList); err != nil {
+		h.metrics.errorsAPIRequest.WithLabelValues("read_or_format").Inc()
+		level.Error(h.logger).Log("msg", "failed to unmarshal audit event list", "err", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.metrics.batchSize.Observe(float64(len(eventList.Items)))
+
+	commonLabels := labels.NewBuilder(nil)
+	for k, v := range h.config.Labels {
+		commonLabels.Set(string(k), string(v))
+	}
+	if tenantHeader := req.Header.Get("X-Scope-OrgID"); tenantHeader != "" {
+		commonLabels.Set(lokiClient.ReservedLabelTenantID, tenantHeader)
+	}
+
+	for _, item := range eventList.Items {
+		var event Event
+		if err := json.Unmarshal(item, &event); err != nil {
+			h.metrics.errorsEvent.Inc()
+			level.Warn(h.logger).Log("msg", "failed to decode audit event", "err", err)
+			continue
+		}
+
+		var line bytes.Buffer
+		if err := json.Compact(&line, item); err != nil {
+			h.metrics.errorsEvent.Inc()
+			level.Warn(h.logger).Log("msg", "failed to compact audit event", "err", err)
+			continue
+		}
+
+		h.metrics.eventsReceived.WithLabelValues(event.Stage).Inc()
+
+		entryLabels, keep := h.postProcessLabels(eventLabels(commonLabels.Labels(nil), &event))
+		if !keep {
+			continue
+		}
+		h.sender.Send(req.Context(), loki.Entry{
+			Labels: entryLabels,
+			Entry: logproto.Entry{
+				Timestamp: h.timestamp(&event),
+				Line:      line.String(),
+			},
+		})
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// authorized reports whether req carries the configured bearer token. All
+// requests are authorized if no token is configured.
+func (h *Handler) authorized(req *http.Request) bool {
+	if h.config.BearerToken == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.config.BearerToken)) == 1
+}
+
+func (h *Handler) timestamp(e *Event) time.Time {
+	if h.config.UseIncomingTimestamp {
+		if !e.StageTimestamp.IsZero() {
+			return e.StageTimestamp
+		}
+		if !e.RequestReceivedTimestamp.IsZero() {
+			return e.RequestReceivedTimestamp
+		}
+	}
+	return time.Now()
+}
+
+// eventLabels adds the internal labels describing e to base.
+func eventLabels(base labels.Labels, e *Event) labels.Labels {
+	lb := labels.NewBuilder(base)
+	lb.Set("__kubernetes_audit_level", e.Level)
+	lb.Set("__kubernetes_audit_stage", e.Stage)
+	lb.Set("__kubernetes_audit_id", e.AuditID)
+	lb.Set("__kubernetes_audit_verb", e.Verb)
+	lb.Set("__kubernetes_audit_request_uri", e.RequestURI)
+	lb.Set("__kubernetes_audit_user", e.User.Username)
+	lb.Set("__kubernetes_audit_user_groups", strings.Join(e.User.Groups, ","))
+	lb.Set("__kubernetes_audit_user_agent", e.UserAgent)
+	lb.Set("__kubernetes_audit_source_ips", strings.Join(e.SourceIPs, ","))
+	if ref := e.ObjectRef; ref != nil {
+		lb.Set("__kubernetes_audit_resource", ref.Resource)
+		lb.Set("__kubernetes_audit_subresource", ref.Subresource)
+		lb.Set("__kubernetes_audit_namespace", ref.Namespace)
+		lb.Set("__kubernetes_audit_name", ref.Name)
+		lb.Set("__kubernetes_audit_api_group", ref.APIGroup)
+		lb.Set("__kubernetes_audit_api_version", ref.APIVersion)
+	}
+	if e.ResponseStatus != nil {
+		lb.Set("__kubernetes_audit_response_code", strconv.Itoa(e.ResponseStatus.Code))
+	}
+	return lb.Labels(nil)
+}
+
+// postProcessLabels applies relabels, then drops not relabeled internal and
+// invalid labels. It returns false if the event was dropped by relabeling.
+func (h *Handler) postProcessLabels(lbs labels.Labels) (model.LabelSet, bool) {
+	if len(h.config.RelabelRules) > 0 {
+		var keep bool
+		lbs, keep = relabel.Process(lbs, h.config.RelabelRules...)
+		if !keep {
+			return nil, false
+		}
+	}
+
+	entryLabels := make(model.LabelSet)
+	for _, lbl := range lbs {
+		// if internal label and not reserved, drop
+		if strings.HasPrefix(lbl.Name, "__") && lbl.Name != lokiClient.ReservedLabelTenantID {
+			continue
+		}
+
+		// ignore invalid labels
+		if !model.LabelName(lbl.Name).IsValid() || !model.LabelValue(lbl.Value).IsValid() {
+			continue
+		}
+
+		entryLabels[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+	return entryLabels, true
+}
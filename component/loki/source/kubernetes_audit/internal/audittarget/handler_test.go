@@ -0,0 +1,255 @@
+package audittarget
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/agent/component/common/loki"
+)
+
+const testEventList = `{
+  "kind": "EventList",
+  "apiVersion": "audit.k8s.io/v1",
+  "metadata": {},
+  "items": [
+    {
+      "level": "Metadata",
+      "auditID": "5a6b2e1d-2f3c-4a4f-9c0c-6d1b0a6b9f11",
+      "stage": "ResponseComplete",
+      "requestURI": "/api/v1/namespaces/default/pods/nginx/log",
+      "verb": "get",
+      "user": {"username": "system:admin", "groups": ["system:masters", "system:authenticated"]},
+      "sourceIPs": ["10.0.0.1"],
+      "userAgent": "kubectl/v1.27.2",
+      "objectRef": {"resource": "pods", "namespace": "default", "name": "nginx", "apiVersion": "v1", "subresource": "log"},
+      "responseStatus": {"metadata": {}, "code": 200},
+      "requestReceivedTimestamp": "2023-06-20T10:00:00.000000Z",
+      "stageTimestamp": "2023-06-20T10:00:01.000000Z"
+    },
+    {
+      "level": "RequestResponse",
+      "auditID": "0e4c8f4e-7c6f-4b1d-8a4b-2b7a4cf6af52",
+      "stage": "ResponseComplete",
+      "requestURI": "/apis/apps/v1/namespaces/kube-system/deployments/coredns",
+      "verb": "patch",
+      "user": {"username": "jane"},
+      "objectRef": {"resource": "deployments", "namespace": "kube-system", "name": "coredns", "apiGroup": "apps", "apiVersion": "v1"},
+      "responseStatus": {"metadata": {}, "code": 403},
+      "requestReceivedTimestamp": "2023-06-20T10:00:02.000000Z",
+      "stageTimestamp": "2023-06-20T10:00:03.000000Z"
+    }
+  ]
+}`
+
+type receiver struct {
+	entries []loki.Entry
+}
+
+func (r *receiver) Send(ctx context.Context, entry loki.Entry) {
+	r.entries = append(r.entries, entry)
+}
+
+var keepVerbAndUser = []*relabel.Config{
+	{
+		SourceLabels: model.LabelNames{"__kubernetes_audit_verb"},
+		Regex:        relabel.MustNewRegexp("(.*)"),
+		Replacement:  "$1",
+		TargetLabel:  "verb",
+		Action:       relabel.Replace,
+	},
+	{
+		SourceLabels: model.LabelNames{"__kubernetes_audit_user"},
+		Regex:        relabel.MustNewRegexp("(.*)"),
+		Replacement:  "$1",
+		TargetLabel:  "user",
+		Action:       relabel.Replace,
+	},
+	{
+		SourceLabels: model.LabelNames{"__kubernetes_audit_resource"},
+		Regex:        relabel.MustNewRegexp("(.*)"),
+		Replacement:  "$1",
+		TargetLabel:  "resource",
+		Action:       relabel.Replace,
+	},
+}
+
+func TestHandler(t *testing.T) {
+	type testcase struct {
+		Config Config
+		Body   string
+		Header http.Header
+		Gzip   bool
+		Status int
+		Assert func(t *testing.T, entries []loki.Entry)
+	}
+
+	tests := map[string]testcase{
+		"internal labels are dropped when not relabeled": {
+			Config: Config{Labels: model.LabelSet{"job": "audit"}},
+			Body:   testEventList,
+			Status: http.StatusOK,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Len(t, entries, 2)
+				for _, e := range entries {
+					require.Equal(t, model.LabelSet{"job": "audit"}, e.Labels)
+					require.WithinDuration(t, time.Now(), e.Timestamp, 5*time.Second)
+				}
+				require.True(t, strings.HasPrefix(entries[0].Line, `{"level":"Metadata","auditID":"5a6b2e1d`))
+			},
+		},
+		"relabel internal labels": {
+			Config: Config{RelabelRules: keepVerbAndUser},
+			Body:   testEventList,
+			Status: http.StatusOK,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Len(t, entries, 2)
+				require.Equal(t, model.LabelSet{"verb": "get", "user": "system:admin", "resource": "pods"}, entries[0].Labels)
+				require.Equal(t, model.LabelSet{"verb": "patch", "user": "jane", "resource": "deployments"}, entries[1].Labels)
+			},
+		},
+		"relabel drop": {
+			Config: Config{RelabelRules: []*relabel.Config{
+				{
+					SourceLabels: model.LabelNames{"__kubernetes_audit_verb"},
+					Regex:        relabel.MustNewRegexp("get|list|watch"),
+					Action:       relabel.Drop,
+				},
+			}},
+			Body:   testEventList,
+			Status: http.StatusOK,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Len(t, entries, 1)
+				require.Contains(t, entries[0].Line, `"verb":"patch"`)
+			},
+		},
+		"incoming timestamp": {
+			Config: Config{UseIncomingTimestamp: true},
+			Body:   testEventList,
+			Status: http.StatusOK,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Len(t, entries, 2)
+				require.Equal(t, time.Date(2023, 6, 20, 10, 0, 1, 0, time.UTC), entries[0].Timestamp.UTC())
+				require.Equal(t, time.Date(2023, 6, 20, 10, 0, 3, 0, time.UTC), entries[1].Timestamp.UTC())
+			},
+		},
+		"gzip body": {
+			Body:   testEventList,
+			Gzip:   true,
+			Status: http.StatusOK,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Len(t, entries, 2)
+			},
+		},
+		"tenant header": {
+			Body:   testEventList,
+			Header: http.Header{"X-Scope-Orgid": []string{"tenant1"}},
+			Status: http.StatusOK,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Len(t, entries, 2)
+				require.Equal(t, model.LabelValue("tenant1"), entries[0].Labels["__tenant_id__"])
+			},
+		},
+		"missing bearer token": {
+			Config: Config{BearerToken: "s3cr3t"},
+			Body:   testEventList,
+			Status: http.StatusUnauthorized,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Empty(t, entries)
+			},
+		},
+		"wrong bearer token": {
+			Config: Config{BearerToken: "s3cr3t"},
+			Body:   testEventList,
+			Header: http.Header{"Authorization": []string{"Bearer nope"}},
+			Status: http.StatusUnauthorized,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Empty(t, entries)
+			},
+		},
+		"valid bearer token": {
+			Config: Config{BearerToken: "s3cr3t"},
+			Body:   testEventList,
+			Header: http.Header{"Authorization": []string{"Bearer s3cr3t"}},
+			Status: http.StatusOK,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Len(t, entries, 2)
+			},
+		},
+		"malformed body": {
+			Body:   `{"items": [`,
+			Status: http.StatusBadRequest,
+			Assert: func(t *testing.T, entries []loki.Entry) {
+				require.Empty(t, entries)
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var body bytes.Buffer
+			if tc.Gzip {
+				gw := gzip.NewWriter(&body)
+				_, err := gw.Write([]byte(tc.Body))
+				require.NoError(t, err)
+				require.NoError(t, gw.Close())
+			} else {
+				body.WriteString(tc.Body)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "http://localhost:8080/kubernetes/audit/api/v1/webhook", &body)
+			require.NoError(t, err)
+			for k, vs := range tc.Header {
+				for _, v := range vs {
+					req.Header.Add(k, v)
+				}
+			}
+			if tc.Gzip {
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+
+			r := &receiver{}
+			h := NewHandler(r, log.NewNopLogger(), NewMetrics(prometheus.NewRegistry()), tc.Config)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.Status, rec.Code)
+			tc.Assert(t, r.entries)
+		})
+	}
+}
+
+func TestEventLabels(t *testing.T) {
+	lbls := eventLabels(nil, &Event{
+		Level: "Metadata",
+		Stage: "ResponseComplete",
+		Verb:  "delete",
+		User:  UserInfo{Username: "bob", Groups: []string{"a", "b"}},
+		ObjectRef: &ObjectRef{
+			Resource:  "secrets",
+			Namespace: "prod",
+			Name:      "db",
+		},
+		ResponseStatus: &ResponseStatus{Code: 200},
+	})
+
+	require.Equal(t, "delete", lbls.Get("__kubernetes_audit_verb"))
+	require.Equal(t, "bob", lbls.Get("__kubernetes_audit_user"))
+	require.Equal(t, "a,b", lbls.Get("__kubernetes_audit_user_groups"))
+	require.Equal(t, "secrets", lbls.Get("__kubernetes_audit_resource"))
+	require.Equal(t, "prod", lbls.Get("__kubernetes_audit_namespace"))
+	require.Equal(t, "db", lbls.Get("__kubernetes_audit_name"))
+	require.Equal(t, "200", lbls.Get("__kubernetes_audit_response_code"))
+}
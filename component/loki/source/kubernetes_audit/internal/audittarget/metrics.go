@@ -0,0 +1,48 @@
+package audittarget
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds the metrics exposed by the audit webhook handler.
+type Metrics struct {
+	errorsAPIRequest *prometheus.CounterVec
+	eventsReceived   *prometheus.CounterVec
+	errorsEvent      prometheus.Counter
+	batchSize        prometheus.Histogram
+}
+
+// NewMetrics creates a new set of handler metrics and registers them against
+// reg if it is not nil.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+
+	m.errorsAPIRequest = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_kubernetes_audit_request_errors_total",
+		Help: "Number of errors while receiving Kubernetes audit webhook requests",
+	}, []string{"reason"})
+
+	m.eventsReceived = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_source_kubernetes_audit_events_total",
+		Help: "Number of Kubernetes audit events received",
+	}, []string{"stage"})
+
+	m.errorsEvent = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_source_kubernetes_audit_event_errors_total",
+		Help: "Number of Kubernetes audit events which could not be decoded",
+	})
+
+	m.batchSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "loki_source_kubernetes_audit_batch_size",
+		Help: "Number of audit events in each received webhook request",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.errorsAPIRequest,
+			m.eventsReceived,
+			m.errorsEvent,
+			m.batchSize,
+		)
+	}
+
+	return &m
+}
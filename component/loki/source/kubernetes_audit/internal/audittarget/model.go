@@ -0,0 +1,56 @@
+package audittarget
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventList mirrors the audit.k8s.io/v1 EventList object which the
+// Kubernetes API server sends to audit webhook backends. Items are kept as raw
+// JSON so that every event can be forwarded verbatim as a log line.
+//
+// See https://kubernetes.io/docs/tasks/debug/debug-cluster/audit/#webhook-backend
+type EventList struct {
+	Kind       string            `json:"kind"`
+	APIVersion string            `json:"apiVersion"`
+	Items      []json.RawMessage `json:"items"`
+}
+
+// Event holds the subset of the audit.k8s.io/v1 Event fields which are
+// exposed as internal labels.
+type Event struct {
+	Level          string          `json:"level"`
+	AuditID        string          `json:"auditID"`
+	Stage          string          `json:"stage"`
+	RequestURI     string          `json:"requestURI"`
+	Verb           string          `json:"verb"`
+	User           UserInfo        `json:"user"`
+	SourceIPs      []string        `json:"sourceIPs"`
+	UserAgent      string          `json:"userAgent"`
+	ObjectRef      *ObjectRef      `json:"objectRef"`
+	ResponseStatus *ResponseStatus `json:"responseStatus"`
+
+	RequestReceivedTimestamp time.Time `json:"requestReceivedTimestamp"`
+	StageTimestamp           time.Time `json:"stageTimestamp"`
+}
+
+// UserInfo describes the user which performed the audited request.
+type UserInfo struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups"`
+}
+
+// ObjectRef describes the object targeted by the audited request.
+type ObjectRef struct {
+	Resource    string `json:"resource"`
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	APIGroup    string `json:"apiGroup"`
+	APIVersion  string `json:"apiVersion"`
+	Subresource string `json:"subresource"`
+}
+
+// ResponseStatus is the status returned to the client of the audited request.
+type ResponseStatus struct {
+	Code int `json:"code"`
+}
@@ -0,0 +1,222 @@
+package kubernetes_audit
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	fnet "github.com/grafana/agent/component/common/net"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/component/loki/source/kubernetes_audit/internal/audittarget"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/util"
+)
+
+// WebhookPath is the HTTP path on which audit event batches are received.
+const WebhookPath = "/kubernetes/audit/api/v1/webhook"
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.kubernetes_audit",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the
+// loki.source.kubernetes_audit component.
+type Arguments struct {
+	Server               *fnet.ServerConfig  `river:",squash"`
+	ForwardTo            []loki.LogsReceiver `river:"forward_to,attr"`
+	Labels               map[string]string   `river:"labels,attr,optional"`
+	RelabelRules         flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
+	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+	BearerToken          rivertypes.Secret   `river:"bearer_token,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = Arguments{
+		Server: fnet.DefaultServerConfig(),
+	}
+}
+
+func (a *Arguments) handlerConfig() audittarget.Config {
+	labelSet := make(model.LabelSet, len(a.Labels))
+	for k, v := range a.Labels {
+		labelSet[model.LabelName(k)] = model.LabelValue(v)
+	}
+
+	return audittarget.Config{
+		Labels:               labelSet,
+		RelabelRules:         flow_relabel.ComponentToPromRelabelConfigs(a.RelabelRules),
+		UseIncomingTimestamp: a.UseIncomingTimestamp,
+		BearerToken:          string(a.BearerToken),
+	}
+}
+
+// Component implements the loki.source.kubernetes_audit component.
+type Component struct {
+	opts           component.Options
+	logger         log.Logger
+	serverMetrics  *util.UncheckedCollector
+	handlerMetrics *audittarget.Metrics
+
+	// destination is where the handler writes received log entries to.
+	destination loki.LogsReceiver
+
+	serverMut sync.Mutex
+	args      Arguments
+	server    *fnet.TargetServer
+
+	// Use separate receivers mutex to avoid a deadlock when Update drains the
+	// current server while Run is forwarding entries.
+	receiversMut sync.RWMutex
+	receivers    []loki.LogsReceiver
+}
+
+// New creates a new loki.source.kubernetes_audit component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:           o,
+		logger:         log.With(o.Logger, "component", "kubernetes_audit"),
+		serverMetrics:  util.NewUncheckedCollector(nil),
+		handlerMetrics: audittarget.NewMetrics(o.Registerer),
+		destination:    make(loki.LogsReceiver),
+		receivers:      args.ForwardTo,
+	}
+
+	o.Registerer.MustRegister(c.serverMetrics)
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.serverMut.Lock()
+		defer c.serverMut.Unlock()
+		c.shutdownServer()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.destination:
+			c.receiversMut.RLock()
+			receivers := c.receivers
+			c.receiversMut.RUnlock()
+
+			for _, receiver := range receivers {
+				select {
+				case receiver <- entry:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.receiversMut.Lock()
+	c.receivers = newArgs.ForwardTo
+	c.receiversMut.Unlock()
+
+	c.serverMut.Lock()
+	defer c.serverMut.Unlock()
+
+	// The handler is created together with the server, so any change to the
+	// arguments other than forward_to requires the server to be restarted.
+	restartRequired := c.server == nil ||
+		!reflect.DeepEqual(c.args.Server, newArgs.Server) ||
+		!reflect.DeepEqual(c.args.Labels, newArgs.Labels) ||
+		!reflect.DeepEqual(c.args.RelabelRules, newArgs.RelabelRules) ||
+		c.args.UseIncomingTimestamp != newArgs.UseIncomingTimestamp ||
+		c.args.BearerToken != newArgs.BearerToken
+	if !restartRequired {
+		c.args = newArgs
+		return nil
+	}
+
+	c.shutdownServer()
+
+	// [fnet.NewTargetServer] registers new metrics every time it is called. To
+	// avoid issues with re-registering metrics with the same name, we create a
+	// new registry for the server every time we create one, and pass it to an
+	// unchecked collector to bypass uniqueness checking.
+	registry := prometheus.NewRegistry()
+	c.serverMetrics.SetCollector(registry)
+
+	jobName := strings.ReplaceAll(c.opts.ID, ".", "_")
+	srv, err := fnet.NewTargetServer(c.logger, jobName, registry, newArgs.Server)
+	if err != nil {
+		return err
+	}
+
+	handler := audittarget.NewHandler(c, c.logger, c.handlerMetrics, newArgs.handlerConfig())
+	if err := srv.MountAndRun(func(router *mux.Router) {
+		router.Path(WebhookPath).Methods("POST").Handler(handler)
+	}); err != nil {
+		return err
+	}
+
+	c.server = srv
+	c.args = newArgs
+	return nil
+}
+
+// Send implements audittarget.Sender so that the component is able to
+// receive entries decoded by the handler.
+func (c *Component) Send(ctx context.Context, entry loki.Entry) {
+	select {
+	case c.destination <- entry:
+	case <-ctx.Done():
+	}
+}
+
+// DebugInfo returns information about the status of the listener.
+func (c *Component) DebugInfo() interface{} {
+	c.serverMut.Lock()
+	defer c.serverMut.Unlock()
+
+	var res debugInfo
+	if c.server != nil {
+		res.Ready = true
+		res.Address = c.server.HTTPListenAddr()
+	}
+	return res
+}
+
+type debugInfo struct {
+	Ready   bool   `river:"ready,attr"`
+	Address string `river:"address,attr"`
+}
+
+// shutdownServer will shut down the currently used server.
+// It is not goroutine-safe and serverMut must be held when it's called.
+func (c *Component) shutdownServer() {
+	if c.server != nil {
+		c.server.StopAndShutdown()
+		c.server = nil
+	}
+}
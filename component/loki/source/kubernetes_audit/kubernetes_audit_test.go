@@ -0,0 +1,100 @@
+package kubernetes_audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phayes/freeport"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	fnet "github.com/grafana/agent/component/common/net"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+)
+
+const testEventList = `{"kind":"EventList","apiVersion":"audit.k8s.io/v1","metadata":{},"items":[{"level":"Metadata","auditID":"5a6b2e1d-2f3c-4a4f-9c0c-6d1b0a6b9f11","stage":"ResponseComplete","requestURI":"/api/v1/namespaces/default/pods","verb":"list","user":{"username":"system:admin"},"objectRef":{"resource":"pods","namespace":"default","apiVersion":"v1"},"responseStatus":{"metadata":{},"code":200}}]}`
+
+func TestComponent(t *testing.T) {
+	opts := component.Options{
+		ID:            "loki.source.kubernetes_audit.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	port, err := freeport.GetFreePort()
+	require.NoError(t, err)
+
+	ch := make(loki.LogsReceiver)
+	args := Arguments{
+		Server: &fnet.ServerConfig{
+			HTTP: &fnet.HTTPConfig{
+				ListenAddress: "localhost",
+				ListenPort:    port,
+			},
+			// assign random grpc port
+			GRPC: &fnet.GRPCConfig{ListenPort: 0},
+		},
+		ForwardTo:   []loki.LogsReceiver{ch},
+		Labels:      map[string]string{"job": "kubernetes-audit"},
+		BearerToken: "token",
+	}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	url := fmt.Sprintf("http://localhost:%d%s", port, WebhookPath)
+	client := http.Client{Timeout: 5 * time.Second}
+
+	require.Eventually(t, func() bool {
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(testEventList))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer token")
+
+		res, err := client.Do(req)
+		if err != nil {
+			return false
+		}
+		defer res.Body.Close()
+		return res.StatusCode == http.StatusOK
+	}, 5*time.Second, 100*time.Millisecond, "server never accepted the audit events")
+
+	select {
+	case e := <-ch:
+		require.Equal(t, model.LabelSet{"job": "kubernetes-audit"}, e.Labels)
+		require.Contains(t, e.Line, `"verb":"list"`)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for log line")
+	}
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	cfg := `
+		http {
+			listen_port = 9443
+			tls {
+				cert_file = "/etc/agent/server.crt"
+				key_file  = "/etc/agent/server.key"
+			}
+		}
+		bearer_token = "token"
+		forward_to   = []
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+	require.Equal(t, 9443, args.Server.HTTP.ListenPort)
+	require.Equal(t, "/etc/agent/server.crt", args.Server.HTTP.TLS.CertFile)
+	require.Equal(t, "token", string(args.BearerToken))
+}
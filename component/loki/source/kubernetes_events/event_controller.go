@@ -2,6 +2,7 @@ package kubernetes_events
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -16,6 +17,7 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/prometheus/common/model"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
 	cachetools "k8s.io/client-go/tools/cache"
@@ -23,19 +25,28 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// Formats of the log lines generated for events.
+const (
+	logFormatLogfmt = "logfmt"
+	logFormatJSON   = "json"
+)
+
 type eventControllerTask struct {
-	Log          log.Logger
-	Config       *rest.Config // Config to connect to Kubernetes.
-	Namespace    string       // Namespace to watch for events in.
-	JobName      string       // Label value to use for job.
-	InstanceName string       // Label value to use for instance.
-	Receiver     loki.LogsReceiver
-	Positions    positions.Positions
+	Log           log.Logger
+	Config        *rest.Config // Config to connect to Kubernetes.
+	Namespace     string       // Namespace to watch for events in.
+	EventType     string       // Type of events to watch. Empty for all types.
+	FieldSelector string       // Field selector for the events to watch.
+	LogFormat     string       // Format of generated log lines.
+	JobName       string       // Label value to use for job.
+	InstanceName  string       // Label value to use for instance.
+	Receiver      loki.LogsReceiver
+	Positions     positions.Positions
 }
 
 // Hash implements [runner.Task].
 func (t eventControllerTask) Hash() uint64 {
-	return xxhash.Sum64String(t.Namespace)
+	return xxhash.Sum64String(t.Namespace + "/" + t.EventType)
 }
 
 // Equals implements [runner.Task].
@@ -54,12 +65,14 @@ type eventController struct {
 }
 
 func newEventController(task eventControllerTask) *eventController {
-	var key string
-	if task.Namespace == "" {
-		key = positions.CursorKey("events")
-	} else {
-		key = positions.CursorKey("events-" + task.Namespace)
+	name := "events"
+	if task.Namespace != "" {
+		name += "-" + task.Namespace
 	}
+	if task.EventType != "" {
+		name += "-type-" + task.EventType
+	}
+	key := positions.CursorKey(name)
 
 	lastTimestamp, _ := task.Positions.Get(key, "")
 
@@ -89,9 +102,17 @@ func (ctrl *eventController) runError(ctx context.Context) error {
 		return fmt.Errorf("adding core to scheme: %w", err)
 	}
 
+	selector, err := fields.ParseSelector(ctrl.task.FieldSelector)
+	if err != nil {
+		return fmt.Errorf("parsing field selector: %w", err)
+	}
+
 	opts := cache.Options{
 		Scheme:     scheme,
 		Namespaces: []string{ctrl.task.Namespace},
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Event{}: {Field: selector},
+		},
 	}
 	informers, err := cache.New(ctrl.task.Config, opts)
 	if err != nil {
@@ -230,19 +251,29 @@ func (ctrl *eventController) handleEvent(ctx context.Context, event *corev1.Even
 }
 
 func (ctrl *eventController) parseEvent(event *corev1.Event) (model.LabelSet, string, error) {
-	var (
-		msg  strings.Builder
-		lset = make(model.LabelSet)
-	)
-
 	obj := event.InvolvedObject
 	if obj.Name == "" {
 		return nil, "", fmt.Errorf("no involved object for event")
 	}
 
-	lset[model.LabelName("namespace")] = model.LabelValue(obj.Namespace)
-	lset[model.LabelName("job")] = model.LabelValue(ctrl.task.JobName)
-	lset[model.LabelName("instance")] = model.LabelValue(ctrl.task.InstanceName)
+	lset := model.LabelSet{
+		model.LabelName("namespace"): model.LabelValue(obj.Namespace),
+		model.LabelName("job"):       model.LabelValue(ctrl.task.JobName),
+		model.LabelName("instance"):  model.LabelValue(ctrl.task.InstanceName),
+	}
+
+	if ctrl.task.LogFormat == logFormatJSON {
+		msg, err := formatJSON(event)
+		return lset, msg, err
+	}
+	return lset, formatLogfmt(event), nil
+}
+
+func formatLogfmt(event *corev1.Event) string {
+	var (
+		msg strings.Builder
+		obj = event.InvolvedObject
+	)
 
 	fmt.Fprintf(&msg, "name=%s ", obj.Name)
 	if obj.Kind != "" {
@@ -284,7 +315,73 @@ func (ctrl *eventController) parseEvent(event *corev1.Event) (model.LabelSet, st
 
 	fmt.Fprintf(&msg, "msg=%q ", event.Message)
 
-	return lset, msg.String(), nil
+	return msg.String()
+}
+
+// jsonEvent is the log line of an event in the JSON format.
+type jsonEvent struct {
+	InvolvedObject      jsonObject `json:"involvedObject"`
+	Reason              string     `json:"reason,omitempty"`
+	Type                string     `json:"type,omitempty"`
+	Action              string     `json:"action,omitempty"`
+	Message             string     `json:"message"`
+	Count               int32      `json:"count"`
+	SeriesCount         int32      `json:"seriesCount,omitempty"`
+	FirstTimestamp      *time.Time `json:"firstTimestamp,omitempty"`
+	LastTimestamp       *time.Time `json:"lastTimestamp,omitempty"`
+	EventRV             string     `json:"eventRV,omitempty"`
+	ReportingController string     `json:"reportingController,omitempty"`
+	ReportingInstance   string     `json:"reportingInstance,omitempty"`
+	SourceComponent     string     `json:"sourceComponent,omitempty"`
+	SourceHost          string     `json:"sourceHost,omitempty"`
+}
+
+type jsonObject struct {
+	Kind            string `json:"kind,omitempty"`
+	Namespace       string `json:"namespace,omitempty"`
+	Name            string `json:"name"`
+	APIVersion      string `json:"apiVersion,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	FieldPath       string `json:"fieldPath,omitempty"`
+}
+
+func formatJSON(event *corev1.Event) (string, error) {
+	obj := event.InvolvedObject
+	line := jsonEvent{
+		InvolvedObject: jsonObject{
+			Kind:            obj.Kind,
+			Namespace:       obj.Namespace,
+			Name:            obj.Name,
+			APIVersion:      obj.APIVersion,
+			ResourceVersion: obj.ResourceVersion,
+			FieldPath:       obj.FieldPath,
+		},
+		Reason:              event.Reason,
+		Type:                event.Type,
+		Action:              event.Action,
+		Message:             event.Message,
+		Count:               event.Count,
+		EventRV:             event.ResourceVersion,
+		ReportingController: event.ReportingController,
+		ReportingInstance:   event.ReportingInstance,
+		SourceComponent:     event.Source.Component,
+		SourceHost:          event.Source.Host,
+	}
+	if event.Series != nil {
+		line.SeriesCount = event.Series.Count
+	}
+	if !event.FirstTimestamp.IsZero() {
+		line.FirstTimestamp = &event.FirstTimestamp.Time
+	}
+	if ts := eventTimestamp(event); !ts.IsZero() {
+		line.LastTimestamp = &ts
+	}
+
+	bb, err := json.Marshal(line)
+	if err != nil {
+		return "", fmt.Errorf("encoding event: %w", err)
+	}
+	return string(bb), nil
 }
 
 func eventTimestamp(event *corev1.Event) time.Time {
@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/agent/component/common/loki/positions"
 	"github.com/grafana/agent/pkg/runner"
 	"github.com/oklog/run"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/rest"
 )
 
@@ -44,6 +45,12 @@ type Arguments struct {
 
 	JobName    string   `river:"job_name,attr,optional"`
 	Namespaces []string `river:"namespaces,attr,optional"`
+	LogFormat  string   `river:"log_format,attr,optional"`
+
+	// Filters for the events to watch, applied by the Kubernetes API server.
+	ExcludeNamespaces []string `river:"exclude_namespaces,attr,optional"`
+	EventTypes        []string `river:"event_types,attr,optional"`
+	ExcludeEventTypes []string `river:"exclude_event_types,attr,optional"`
 
 	// Client settings to connect to Kubernetes.
 	Client kubernetes.ClientArguments `river:"client,block,optional"`
@@ -51,7 +58,8 @@ type Arguments struct {
 
 // DefaultArguments holds default settings for loki.source.kubernetes_events.
 var DefaultArguments = Arguments{
-	JobName: "loki.source.kubernetes_events",
+	JobName:   "loki.source.kubernetes_events",
+	LogFormat: logFormatLogfmt,
 
 	Client: kubernetes.ClientArguments{
 		HTTPClientConfig: config.DefaultHTTPClientConfig,
@@ -68,9 +76,30 @@ func (args *Arguments) Validate() error {
 	if args.JobName == "" {
 		return fmt.Errorf("job_name must not be an empty string")
 	}
+	if args.LogFormat != logFormatLogfmt && args.LogFormat != logFormatJSON {
+		return fmt.Errorf("unrecognized log_format %q, must be one of %q or %q", args.LogFormat, logFormatLogfmt, logFormatJSON)
+	}
+	if v, ok := firstShared(args.Namespaces, args.ExcludeNamespaces); ok {
+		return fmt.Errorf("namespace %q can't be in both namespaces and exclude_namespaces", v)
+	}
+	if v, ok := firstShared(args.EventTypes, args.ExcludeEventTypes); ok {
+		return fmt.Errorf("event type %q can't be in both event_types and exclude_event_types", v)
+	}
 	return nil
 }
 
+// firstShared returns the first value of a which is also in b.
+func firstShared(a, b []string) (string, bool) {
+	for _, v := range a {
+		for _, other := range b {
+			if v == other {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
 // Component implements the loki.source.kubernetes_events component, which
 // watches events from Kubernetes and forwards received events to other Loki
 // components.
@@ -203,18 +232,23 @@ func (c *Component) Update(args component.Arguments) error {
 		}
 	}
 
-	// Create a task for each defined namespace.
+	// Create a task for each defined namespace and event type.
 	var newTasks []eventControllerTask
 	for _, namespace := range getNamespaces(newArgs) {
-		newTasks = append(newTasks, eventControllerTask{
-			Log:          c.log,
-			Config:       restConfig,
-			JobName:      newArgs.JobName,
-			InstanceName: c.opts.ID,
-			Namespace:    namespace,
-			Receiver:     c.handler,
-			Positions:    c.positions,
-		})
+		for _, eventType := range getEventTypes(newArgs) {
+			newTasks = append(newTasks, eventControllerTask{
+				Log:           c.log,
+				Config:        restConfig,
+				JobName:       newArgs.JobName,
+				InstanceName:  c.opts.ID,
+				Namespace:     namespace,
+				EventType:     eventType,
+				FieldSelector: fieldSelector(newArgs, eventType),
+				LogFormat:     newArgs.LogFormat,
+				Receiver:      c.handler,
+				Positions:     c.positions,
+			})
+		}
 	}
 
 	c.tasksMut.Lock()
@@ -240,6 +274,32 @@ func getNamespaces(args Arguments) []string {
 	return args.Namespaces
 }
 
+// getEventTypes gets a list of event types to watch from the arguments. If
+// the list of event types is empty, returns a slice to watch all types.
+func getEventTypes(args Arguments) []string {
+	if len(args.EventTypes) == 0 {
+		return []string{""} // Empty string means to watch all types
+	}
+	return args.EventTypes
+}
+
+// fieldSelector returns the field selector for events of eventType, excluding
+// the namespaces and event types to exclude. Field selectors can't match one
+// of multiple values, so every type of event_types is watched separately.
+func fieldSelector(args Arguments, eventType string) string {
+	var selectors []fields.Selector
+	if eventType != "" {
+		selectors = append(selectors, fields.OneTermEqualSelector("type", eventType))
+	}
+	for _, namespace := range args.ExcludeNamespaces {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("metadata.namespace", namespace))
+	}
+	for _, ty := range args.ExcludeEventTypes {
+		selectors = append(selectors, fields.OneTermNotEqualSelector("type", ty))
+	}
+	return fields.AndSelectors(selectors...).String()
+}
+
 // DebugInfo implements [component.DebugComponent].
 func (c *Component) DebugInfo() interface{} {
 	type Info struct {
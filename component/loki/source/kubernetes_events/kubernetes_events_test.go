@@ -0,0 +1,116 @@
+package kubernetes_events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name:   "invalid log format",
+			cfg:    `log_format = "text"`,
+			expect: `unrecognized log_format "text", must be one of "logfmt" or "json"`,
+		},
+		{
+			name: "namespace watched and excluded",
+			cfg: `
+				namespaces         = ["default", "kube-system"]
+				exclude_namespaces = ["kube-system"]
+			`,
+			expect: `namespace "kube-system" can't be in both namespaces and exclude_namespaces`,
+		},
+		{
+			name: "event type included and excluded",
+			cfg: `
+				event_types         = ["Warning"]
+				exclude_event_types = ["Warning"]
+			`,
+			expect: `event type "Warning" can't be in both event_types and exclude_event_types`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte("forward_to = []\n"+tc.cfg), &args)
+			require.EqualError(t, err, tc.expect)
+		})
+	}
+}
+
+func TestFieldSelector(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		forward_to          = []
+		exclude_namespaces  = ["kube-system"]
+		exclude_event_types = ["Normal"]
+	`), &args))
+	require.Equal(t, "metadata.namespace!=kube-system,type!=Normal", fieldSelector(args, ""))
+	require.Equal(t, "type=Warning,metadata.namespace!=kube-system,type!=Normal", fieldSelector(args, "Warning"))
+
+	require.Equal(t, "", fieldSelector(DefaultArguments, ""))
+}
+
+func TestParseEvent(t *testing.T) {
+	ts := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "42"},
+		InvolvedObject: corev1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: "default",
+			Name:      "app-1",
+		},
+		Reason:        "BackOff",
+		Type:          "Warning",
+		Message:       `Back-off restarting failed container "app"`,
+		Count:         3,
+		LastTimestamp: metav1.NewTime(ts),
+	}
+	expectLabels := model.LabelSet{
+		"namespace": "default",
+		"job":       "loki.source.kubernetes_events",
+		"instance":  "loki.source.kubernetes_events.test",
+	}
+
+	t.Run("logfmt", func(t *testing.T) {
+		ctrl := &eventController{task: eventControllerTask{
+			JobName:      "loki.source.kubernetes_events",
+			InstanceName: "loki.source.kubernetes_events.test",
+			LogFormat:    logFormatLogfmt,
+		}}
+		lset, msg, err := ctrl.parseEvent(event)
+		require.NoError(t, err)
+		require.Equal(t, expectLabels, lset)
+		require.Equal(t, `name=app-1 kind=Pod eventRV=42 reason=BackOff type=Warning count=3 msg="Back-off restarting failed container \"app\"" `, msg)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ctrl := &eventController{task: eventControllerTask{
+			JobName:      "loki.source.kubernetes_events",
+			InstanceName: "loki.source.kubernetes_events.test",
+			LogFormat:    logFormatJSON,
+		}}
+		lset, msg, err := ctrl.parseEvent(event)
+		require.NoError(t, err)
+		require.Equal(t, expectLabels, lset)
+		require.JSONEq(t, `{
+			"involvedObject": {"kind": "Pod", "namespace": "default", "name": "app-1"},
+			"reason":         "BackOff",
+			"type":           "Warning",
+			"message":        "Back-off restarting failed container \"app\"",
+			"count":          3,
+			"lastTimestamp":  "2023-06-01T12:00:00Z",
+			"eventRV":        "42"
+		}`, msg)
+	})
+}
@@ -0,0 +1,35 @@
+package lumber
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of Lumberjack metrics.
+type Metrics struct {
+	entries prometheus.Counter
+	errors  prometheus.Counter
+}
+
+// NewMetrics creates a new set of Lumberjack metrics. If reg is non-nil, the
+// metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+
+	m.entries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "agent",
+		Name:      "loki_source_lumberjack_entries_total",
+		Help:      "Total number of entries received over the Lumberjack protocol",
+	})
+	m.errors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "agent",
+		Name:      "loki_source_lumberjack_parsing_errors_total",
+		Help:      "Total number of batches which couldn't be parsed",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.entries,
+			m.errors,
+		)
+	}
+
+	return &m
+}
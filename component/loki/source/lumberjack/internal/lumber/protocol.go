@@ -0,0 +1,204 @@
+package lumber
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Versions of the protocol.
+const (
+	versionV1 byte = '1'
+	versionV2 byte = '2'
+)
+
+// Types of frames.
+const (
+	frameWindowSize byte = 'W'
+	frameCompressed byte = 'C'
+	frameJSON       byte = 'J'
+	frameData       byte = 'D'
+	frameAck        byte = 'A'
+)
+
+// maxFrameSize is the largest payload accepted in a single frame, to avoid
+// allocating memory for corrupted lengths.
+const maxFrameSize = 64 << 20
+
+// Event is a single event received over the Lumberjack protocol.
+type Event struct {
+	Seq    uint32
+	Fields map[string]interface{}
+}
+
+// batch is a window of events sent by a client, which is acknowledged at
+// once.
+type batch struct {
+	version byte
+	events  []Event
+}
+
+// readBatch reads the window size frame starting a batch and all the events
+// of the batch.
+func readBatch(r *bufio.Reader) (batch, error) {
+	version, typ, err := readHeader(r)
+	if err != nil {
+		return batch{}, err
+	}
+	if typ != frameWindowSize {
+		return batch{}, fmt.Errorf("expected window size frame, got frame type %q", typ)
+	}
+	size, err := readUint32(r)
+	if err != nil {
+		return batch{}, err
+	}
+
+	b := batch{version: version, events: make([]Event, 0, initialCap(size))}
+	for uint32(len(b.events)) < size {
+		events, err := readDataFrames(r, version)
+		if err != nil {
+			return batch{}, err
+		}
+		b.events = append(b.events, events...)
+	}
+	return b, nil
+}
+
+// readDataFrames reads a single data frame, or all the data frames of a
+// compressed frame.
+func readDataFrames(r *bufio.Reader, expectVersion byte) ([]Event, error) {
+	version, typ, err := readHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if version != expectVersion {
+		return nil, fmt.Errorf("frame version %q doesn't match window version %q", version, expectVersion)
+	}
+
+	switch typ {
+	case frameCompressed:
+		n, err := readUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxFrameSize {
+			return nil, fmt.Errorf("compressed frame of %d bytes is too large", n)
+		}
+		zr, err := zlib.NewReader(io.LimitReader(r, int64(n)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read compressed frame: %w", err)
+		}
+		defer zr.Close()
+
+		var (
+			events []Event
+			inner  = bufio.NewReader(zr)
+		)
+		for {
+			if _, err := inner.Peek(1); err == io.EOF {
+				return events, nil
+			}
+			evs, err := readDataFrames(inner, expectVersion)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, evs...)
+		}
+
+	case frameJSON:
+		seq, err := readUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := readPayload(r)
+		if err != nil {
+			return nil, err
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, fmt.Errorf("invalid JSON event: %w", err)
+		}
+		return []Event{{Seq: seq, Fields: fields}}, nil
+
+	case frameData:
+		seq, err := readUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		pairs, err := readUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		fields := make(map[string]interface{}, initialCap(pairs))
+		for i := uint32(0); i < pairs; i++ {
+			k, err := readPayload(r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := readPayload(r)
+			if err != nil {
+				return nil, err
+			}
+			fields[string(k)] = string(v)
+		}
+		return []Event{{Seq: seq, Fields: fields}}, nil
+
+	default:
+		return nil, fmt.Errorf("unexpected frame type %q", typ)
+	}
+}
+
+func readHeader(r io.Reader) (version, typ byte, err error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, 0, err
+	}
+	if hdr[0] != versionV1 && hdr[0] != versionV2 {
+		return 0, 0, fmt.Errorf("unsupported protocol version %q", hdr[0])
+	}
+	return hdr[0], hdr[1], nil
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b[:]), nil
+}
+
+// readPayload reads a length-prefixed payload.
+func readPayload(r io.Reader) ([]byte, error) {
+	n, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("payload of %d bytes is too large", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// writeAck acknowledges all the events up to seq.
+func writeAck(w io.Writer, version byte, seq uint32) error {
+	msg := [6]byte{version, frameAck}
+	binary.BigEndian.PutUint32(msg[2:], seq)
+	_, err := w.Write(msg[:])
+	return err
+}
+
+// initialCap bounds the capacity preallocated for n elements announced by a
+// client.
+func initialCap(n uint32) int {
+	if n > 1024 {
+		return 1024
+	}
+	return int(n)
+}
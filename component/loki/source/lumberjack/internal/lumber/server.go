@@ -0,0 +1,146 @@
+// Package lumber implements a server for the Lumberjack protocol used by
+// Elastic Beats, such as Filebeat and Winlogbeat, to send events to
+// Logstash.
+package lumber
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Handler is called with the events of every batch received. Batches are
+// acknowledged to clients once Handler returns. ctx is canceled when the
+// server is stopped.
+type Handler func(ctx context.Context, events []Event)
+
+// Server receives events over the Lumberjack protocol.
+type Server struct {
+	log     log.Logger
+	metrics *Metrics
+	handler Handler
+
+	listener net.Listener
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+
+	connsMut sync.Mutex
+	conns    map[net.Conn]struct{}
+}
+
+// NewServer creates a Server listening on addr. Connections use TLS when
+// tlsConfig is non-nil.
+func NewServer(l log.Logger, m *Metrics, addr string, tlsConfig *tls.Config, h Handler) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		log:      log.With(l, "listen_address", listener.Addr().String()),
+		metrics:  m,
+		handler:  h,
+		listener: listener,
+		ctx:      ctx,
+		cancel:   cancel,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.acceptLoop()
+	return s, nil
+}
+
+// Addr returns the address the server is listening on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Stop closes the listener and all open connections and waits for the
+// handlers to return.
+func (s *Server) Stop() {
+	s.cancel()
+	_ = s.listener.Close()
+
+	s.connsMut.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.connsMut.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.ctx.Err() == nil {
+				level.Error(s.log).Log("msg", "failed to accept connection", "err", err)
+			}
+			return
+		}
+
+		s.connsMut.Lock()
+		s.conns[conn] = struct{}{}
+		s.connsMut.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.connsMut.Lock()
+				delete(s.conns, conn)
+				s.connsMut.Unlock()
+				_ = conn.Close()
+			}()
+			s.handleConn(conn)
+		}()
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	l := log.With(s.log, "remote_addr", conn.RemoteAddr().String())
+	r := bufio.NewReader(conn)
+
+	for {
+		b, err := readBatch(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && s.ctx.Err() == nil {
+				// The stream can't be resynchronized after an invalid frame.
+				s.metrics.errors.Inc()
+				level.Warn(l).Log("msg", "failed to read batch, closing connection", "err", err)
+			}
+			return
+		}
+		if len(b.events) == 0 {
+			continue
+		}
+
+		s.handler(s.ctx, b.events)
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.metrics.entries.Add(float64(len(b.events)))
+
+		if err := writeAck(conn, b.version, b.events[len(b.events)-1].Seq); err != nil {
+			level.Warn(l).Log("msg", "failed to acknowledge batch", "err", err)
+			return
+		}
+	}
+}
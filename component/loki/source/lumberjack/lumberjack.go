@@ -0,0 +1,296 @@
+package lumberjack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/component/loki/source/lumberjack/internal/lumber"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.lumberjack",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Internal labels set from the fields of events.
+const (
+	labelBeat        = "__lumberjack_beat"
+	labelHost        = "__lumberjack_host"
+	labelLogFilePath = "__lumberjack_log_file_path"
+	labelFieldPrefix = "__lumberjack_field_"
+)
+
+// Arguments are the arguments for the component.
+type Arguments struct {
+	ListenAddress        string              `river:"listen_address,attr,optional"`
+	Labels               map[string]string   `river:"labels,attr,optional"`
+	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+	RelabelRules         flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
+	ForwardTo            []loki.LogsReceiver `river:"forward_to,attr"`
+	TLSConfig            *config.TLSConfig   `river:"tls_config,block,optional"`
+}
+
+// DefaultArguments provides the default arguments for the
+// loki.source.lumberjack component.
+var DefaultArguments = Arguments{
+	ListenAddress: "0.0.0.0:5044",
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.ListenAddress == "" {
+		return fmt.Errorf("listen_address must not be empty")
+	}
+	for k := range a.Labels {
+		if !model.LabelName(k).IsValid() {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	return nil
+}
+
+var _ component.Component = (*Component)(nil)
+
+// Component receives log entries over the Lumberjack protocol.
+type Component struct {
+	o       component.Options
+	metrics *lumber.Metrics
+	entries chan loki.Entry
+
+	mut       sync.RWMutex
+	args      Arguments
+	server    *lumber.Server
+	receivers []loki.LogsReceiver
+}
+
+// New creates a new loki.source.lumberjack component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		o:       o,
+		metrics: lumber.NewMetrics(o.Registerer),
+		entries: make(chan loki.Entry),
+	}
+
+	// Call to Update() to start the server and set receivers once at the start.
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		if c.server != nil {
+			c.server.Stop()
+			c.server = nil
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.entries:
+			c.mut.RLock()
+			receivers := c.receivers
+			c.mut.RUnlock()
+
+			for _, r := range receivers {
+				select {
+				case <-ctx.Done():
+					return nil
+				case r <- entry:
+				}
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.receivers = newArgs.ForwardTo
+
+	// Connections are kept open if only the receivers changed.
+	if c.server != nil && serverArgsEqual(c.args, newArgs) {
+		return nil
+	}
+
+	tlsConfig, err := newTLSConfig(newArgs.TLSConfig)
+	if err != nil {
+		return err
+	}
+
+	if c.server != nil {
+		c.server.Stop()
+		c.server = nil
+	}
+	c.args = newArgs
+
+	var rcs []*relabel.Config
+	if len(newArgs.RelabelRules) > 0 {
+		rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
+	}
+
+	s, err := lumber.NewServer(c.o.Logger, c.metrics, newArgs.ListenAddress, tlsConfig, c.newHandler(newArgs, rcs))
+	if err != nil {
+		return err
+	}
+	c.server = s
+	return nil
+}
+
+// serverArgsEqual reports whether a and b only differ by their receivers.
+func serverArgsEqual(a, b Arguments) bool {
+	a.ForwardTo, b.ForwardTo = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// newHandler returns the handler converting events into log entries. The
+// handler captures its configuration so that it never needs to take the
+// component's lock, which is held while the server is stopped.
+func (c *Component) newHandler(args Arguments, rcs []*relabel.Config) lumber.Handler {
+	return func(ctx context.Context, events []lumber.Event) {
+		for _, ev := range events {
+			entry, ok := c.convertEvent(args, rcs, ev)
+			if !ok {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case c.entries <- entry:
+			}
+		}
+	}
+}
+
+func (c *Component) convertEvent(args Arguments, rcs []*relabel.Config, ev lumber.Event) (loki.Entry, bool) {
+	lb := labels.NewBuilder(nil)
+	for k, v := range args.Labels {
+		lb.Set(k, v)
+	}
+	if v, ok := lookupString(ev.Fields, "@metadata", "beat"); ok {
+		lb.Set(labelBeat, v)
+	}
+	if v, ok := lookupString(ev.Fields, "host", "name"); ok {
+		lb.Set(labelHost, v)
+	}
+	if v, ok := lookupString(ev.Fields, "log", "file", "path"); ok {
+		lb.Set(labelLogFilePath, v)
+	}
+	if fields, ok := ev.Fields["fields"].(map[string]interface{}); ok {
+		for k, v := range fields {
+			if s, ok := v.(string); ok {
+				lb.Set(fieldLabelName(k), s)
+			}
+		}
+	}
+
+	processed, keep := relabel.Process(lb.Labels(nil), rcs...)
+	if !keep {
+		return loki.Entry{}, false
+	}
+
+	filtered := make(model.LabelSet)
+	for _, lbl := range processed {
+		if strings.HasPrefix(lbl.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+	if filtered["job"] == "" {
+		filtered["job"] = model.LabelValue(c.o.ID)
+	}
+
+	// Use the message of the event as the log line, falling back to the whole
+	// event for events without one.
+	line, ok := ev.Fields["message"].(string)
+	if !ok {
+		line, ok = ev.Fields["line"].(string)
+	}
+	if !ok {
+		bb, err := json.Marshal(ev.Fields)
+		if err != nil {
+			level.Error(c.o.Logger).Log("msg", "failed to encode event", "err", err)
+			return loki.Entry{}, false
+		}
+		line = string(bb)
+	}
+
+	timestamp := time.Now()
+	if args.UseIncomingTimestamp {
+		if v, ok := ev.Fields["@timestamp"].(string); ok {
+			if ts, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				timestamp = ts
+			}
+		}
+	}
+
+	return loki.Entry{
+		Labels: filtered,
+		Entry: logproto.Entry{
+			Timestamp: timestamp,
+			Line:      line,
+		},
+	}, true
+}
+
+// lookupString returns the string value found by following path through the
+// nested objects of fields.
+func lookupString(fields map[string]interface{}, path ...string) (string, bool) {
+	var v interface{} = fields
+	for _, key := range path {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		v = obj[key]
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// fieldLabelName returns the name of the internal label holding the custom
+// field named field. Characters which aren't valid in label names are
+// replaced with underscores.
+func fieldLabelName(field string) string {
+	name := []byte(field)
+	for i, b := range name {
+		if !((b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') || b == '_') {
+			name[i] = '_'
+		}
+	}
+	return labelFieldPrefix + string(name)
+}
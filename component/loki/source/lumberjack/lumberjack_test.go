@@ -0,0 +1,185 @@
+package lumberjack
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+// frame encodes a frame of the given version and type.
+func frame(version, typ byte, parts ...interface{}) []byte {
+	buf := bytes.NewBuffer([]byte{version, typ})
+	for _, p := range parts {
+		switch p := p.(type) {
+		case uint32:
+			_ = binary.Write(buf, binary.BigEndian, p)
+		case string:
+			_ = binary.Write(buf, binary.BigEndian, uint32(len(p)))
+			buf.WriteString(p)
+		}
+	}
+	return buf.Bytes()
+}
+
+func compressed(frames ...[]byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	for _, f := range frames {
+		_, _ = zw.Write(f)
+	}
+	_ = zw.Close()
+
+	res := bytes.NewBuffer([]byte{'2', 'C'})
+	_ = binary.Write(res, binary.BigEndian, uint32(buf.Len()))
+	res.Write(buf.Bytes())
+	return res.Bytes()
+}
+
+func startComponent(t *testing.T, args Arguments) (*Component, chan loki.Entry) {
+	t.Helper()
+
+	ch := make(chan loki.Entry)
+	args.ListenAddress = "127.0.0.1:0"
+	args.ForwardTo = []loki.LogsReceiver{ch}
+
+	c, err := New(component.Options{
+		ID:            "loki.source.lumberjack.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go c.Run(ctx)
+	return c, ch
+}
+
+func dial(t *testing.T, c *Component) net.Conn {
+	t.Helper()
+
+	c.mut.RLock()
+	addr := c.server.Addr().String()
+	c.mut.RUnlock()
+
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+	return conn
+}
+
+func receive(t *testing.T, ch chan loki.Entry) loki.Entry {
+	t.Helper()
+
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for log entries")
+		return loki.Entry{}
+	}
+}
+
+func readAck(t *testing.T, conn net.Conn) (byte, uint32) {
+	t.Helper()
+
+	var ack [6]byte
+	_, err := io.ReadFull(conn, ack[:])
+	require.NoError(t, err)
+	require.Equal(t, byte('A'), ack[1])
+	return ack[0], binary.BigEndian.Uint32(ack[2:])
+}
+
+func TestLumberjack_V2(t *testing.T) {
+	c, ch := startComponent(t, Arguments{
+		UseIncomingTimestamp: true,
+		Labels:               map[string]string{"source": "beats"},
+		RelabelRules: flow_relabel.Rules{
+			{
+				SourceLabels: []string{labelHost},
+				TargetLabel:  "host",
+				Regex:        flow_relabel.Regexp{Regexp: flow_relabel.DefaultRelabelConfig.Regex.Regexp},
+				Replacement:  "$1",
+				Action:       flow_relabel.Replace,
+			},
+			{
+				SourceLabels: []string{labelFieldPrefix + "env_name"},
+				TargetLabel:  "env",
+				Regex:        flow_relabel.Regexp{Regexp: flow_relabel.DefaultRelabelConfig.Regex.Regexp},
+				Replacement:  "$1",
+				Action:       flow_relabel.Replace,
+			},
+		},
+	})
+	conn := dial(t, c)
+
+	events := []string{
+		`{"@timestamp": "2023-06-01T12:00:00.123Z", "@metadata": {"beat": "filebeat"}, "host": {"name": "web-1"}, "fields": {"env.name": "prod"}, "message": "GET /"}`,
+		`{"@timestamp": "2023-06-01T12:00:01Z", "event": {"code": 4624}}`,
+	}
+	var batch []byte
+	batch = append(batch, frame('2', 'W', uint32(2))...)
+	batch = append(batch, compressed(
+		frame('2', 'J', uint32(1), events[0]),
+		frame('2', 'J', uint32(2), events[1]),
+	)...)
+	_, err := conn.Write(batch)
+	require.NoError(t, err)
+
+	e := receive(t, ch)
+	require.Equal(t, "GET /", e.Line)
+	require.Equal(t, time.Date(2023, 6, 1, 12, 0, 0, 123000000, time.UTC), e.Timestamp.UTC())
+	require.Equal(t, model.LabelSet{"job": "loki.source.lumberjack.test", "source": "beats", "host": "web-1", "env": "prod"}, e.Labels)
+
+	e = receive(t, ch)
+	require.JSONEq(t, `{"@timestamp": "2023-06-01T12:00:01Z", "event": {"code": 4624}}`, e.Line)
+
+	version, seq := readAck(t, conn)
+	require.Equal(t, byte('2'), version)
+	require.Equal(t, uint32(2), seq)
+}
+
+func TestLumberjack_V1(t *testing.T) {
+	c, ch := startComponent(t, Arguments{})
+	conn := dial(t, c)
+
+	var batch []byte
+	batch = append(batch, frame('1', 'W', uint32(1))...)
+	batch = append(batch, frame('1', 'D', uint32(7), uint32(2), "line", "hello", "file", "/var/log/app.log")...)
+	_, err := conn.Write(batch)
+	require.NoError(t, err)
+
+	require.Equal(t, "hello", receive(t, ch).Line)
+
+	version, seq := readAck(t, conn)
+	require.Equal(t, byte('1'), version)
+	require.Equal(t, uint32(7), seq)
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	tlsConfig, err := newTLSConfig(nil)
+	require.NoError(t, err)
+	require.Nil(t, tlsConfig)
+
+	_, err = newTLSConfig(&config.TLSConfig{CertFile: "/does/not/exist.crt"})
+	require.ErrorContains(t, err, "unable to load server certificate")
+
+	_, err = newTLSConfig(&config.TLSConfig{})
+	require.EqualError(t, err, "certificate and key must be configured")
+}
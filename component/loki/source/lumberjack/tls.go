@@ -0,0 +1,63 @@
+package lumberjack
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/grafana/agent/component/common/config"
+)
+
+// newTLSConfig creates TLS server settings from a tls_config block. Clients
+// are required to present a certificate signed by the CA when one is
+// configured. It returns nil if c is nil.
+func newTLSConfig(c *config.TLSConfig) (*tls.Config, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	certBytes, err := readPEM(c.Cert, c.CertFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load server certificate: %w", err)
+	}
+	keyBytes, err := readPEM(string(c.Key), c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load server key: %w", err)
+	}
+	if len(certBytes) == 0 || len(keyBytes) == 0 {
+		return nil, fmt.Errorf("certificate and key must be configured")
+	}
+
+	cert, err := tls.X509KeyPair(certBytes, keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load server certificate or key: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   uint16(c.MinVersion),
+	}
+
+	caBytes, err := readPEM(c.CA, c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load client CA certificate: %w", err)
+	}
+	if len(caBytes) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caBytes) {
+			return nil, fmt.Errorf("unable to parse client CA certificate")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// readPEM returns the contents of file if set, or pem otherwise.
+func readPEM(pem, file string) ([]byte, error) {
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return []byte(pem), nil
+}
@@ -0,0 +1,126 @@
+package oslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+// Labels set on every log entry, if the message has a value for them.
+const (
+	labelSubsystem = "subsystem"
+
+	labelCategory    = "__oslog_category"
+	labelProcess     = "__oslog_process"
+	labelProcessPath = "__oslog_process_path"
+	labelSender      = "__oslog_sender"
+	labelMessageType = "__oslog_message_type"
+)
+
+// timestampFormat is the format of the timestamps written by log stream.
+const timestampFormat = "2006-01-02 15:04:05.000000-0700"
+
+// event is a message of the unified logging system, as written by
+// `log stream --style ndjson`.
+type event struct {
+	Timestamp        string `json:"timestamp"`
+	EventType        string `json:"eventType"`
+	MessageType      string `json:"messageType"`
+	EventMessage     string `json:"eventMessage"`
+	Subsystem        string `json:"subsystem"`
+	Category         string `json:"category"`
+	ProcessImagePath string `json:"processImagePath"`
+	SenderImagePath  string `json:"senderImagePath"`
+	ProcessID        int    `json:"processID"`
+}
+
+// converter converts the lines written by log stream into log entries.
+type converter struct {
+	id   string
+	args Arguments
+	rcs  []*relabel.Config
+}
+
+// convert converts line into a log entry. ok is false if line doesn't hold a
+// log message, such as the header written by log stream, or if the entry was
+// dropped by the relabel rules.
+func (c *converter) convert(line []byte) (entry loki.Entry, ok bool, err error) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] != '{' {
+		return loki.Entry{}, false, nil
+	}
+
+	var ev event
+	if err := json.Unmarshal(line, &ev); err != nil {
+		return loki.Entry{}, false, fmt.Errorf("decoding log message: %w", err)
+	}
+	if ev.EventType != "" && ev.EventType != "logEvent" {
+		return loki.Entry{}, false, nil
+	}
+
+	lb := labels.NewBuilder(nil)
+	for k, v := range c.args.Labels {
+		lb.Set(k, v)
+	}
+	setNonEmpty(lb, labelSubsystem, ev.Subsystem)
+	setNonEmpty(lb, labelCategory, ev.Category)
+	setNonEmpty(lb, labelProcessPath, ev.ProcessImagePath)
+	if ev.ProcessImagePath != "" {
+		lb.Set(labelProcess, path.Base(ev.ProcessImagePath))
+	}
+	if ev.SenderImagePath != "" {
+		lb.Set(labelSender, path.Base(ev.SenderImagePath))
+	}
+	setNonEmpty(lb, labelMessageType, ev.MessageType)
+
+	processed, keep := relabel.Process(lb.Labels(nil), c.rcs...)
+	if !keep {
+		return loki.Entry{}, false, nil
+	}
+
+	filtered := make(model.LabelSet)
+	for _, lbl := range processed {
+		if strings.HasPrefix(lbl.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+	if filtered["job"] == "" {
+		filtered["job"] = model.LabelValue(c.id)
+	}
+
+	text := ev.EventMessage
+	if c.args.FormatAsJSON {
+		text = string(line)
+	}
+
+	timestamp := time.Now()
+	if c.args.UseIncomingTimestamp {
+		if ts, err := time.Parse(timestampFormat, ev.Timestamp); err == nil {
+			timestamp = ts
+		}
+	}
+
+	return loki.Entry{
+		Labels: filtered,
+		Entry: logproto.Entry{
+			Timestamp: timestamp,
+			Line:      text,
+		},
+	}, true, nil
+}
+
+func setNonEmpty(lb *labels.Builder, name, value string) {
+	if value != "" {
+		lb.Set(name, value)
+	}
+}
@@ -0,0 +1,268 @@
+//go:build darwin
+
+package oslog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.oslog",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// logCommand is the path of the command used to stream log messages.
+const logCommand = "/usr/bin/log"
+
+// restartBackoff is how long to wait before restarting log stream after it
+// exited.
+const restartBackoff = 5 * time.Second
+
+// maxLineSize is the maximum size of a line written by log stream.
+const maxLineSize = 1024 * 1024
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// Component implements the loki.source.oslog component.
+type Component struct {
+	opts    component.Options
+	entries chan loki.Entry
+
+	entriesTotal     prometheus.Counter
+	parseErrorsTotal prometheus.Counter
+	restartsTotal    prometheus.Counter
+
+	mut       sync.RWMutex
+	args      Arguments
+	stream    *stream
+	receivers []loki.LogsReceiver
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+// stream is a running log stream of the component.
+type stream struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New creates a new loki.source.oslog component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		entries: make(chan loki.Entry),
+
+		entriesTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_source_oslog_entries_total",
+			Help: "Total number of log messages read from the unified logging system.",
+		}),
+		parseErrorsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_source_oslog_parse_errors_total",
+			Help: "Total number of lines written by log stream which couldn't be decoded.",
+		}),
+		restartsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_source_oslog_stream_restarts_total",
+			Help: "Total number of times log stream was restarted after exiting.",
+		}),
+	}
+	for _, m := range []prometheus.Collector{c.entriesTotal, c.parseErrorsTotal, c.restartsTotal} {
+		if err := o.Registerer.Register(m); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer func() {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		c.stopStream()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.entries:
+			c.mut.RLock()
+			receivers := c.receivers
+			c.mut.RUnlock()
+
+			for _, r := range receivers {
+				select {
+				case <-ctx.Done():
+					return nil
+				case r <- entry:
+				}
+			}
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.receivers = newArgs.ForwardTo
+
+	// The stream is kept running if only the receivers changed.
+	if c.stream != nil && streamArgsEqual(c.args, newArgs) {
+		return nil
+	}
+
+	conv := &converter{id: c.opts.ID, args: newArgs}
+	if len(newArgs.RelabelRules) > 0 {
+		conv.rcs = flow_relabel.ComponentToPromRelabelConfigs(newArgs.RelabelRules)
+	}
+
+	c.stopStream()
+	c.args = newArgs
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &stream{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		c.runStream(ctx, newArgs.streamArgs(), conv)
+	}()
+	c.stream = s
+	return nil
+}
+
+// streamArgsEqual reports whether a and b only differ by their receivers.
+func streamArgsEqual(a, b Arguments) bool {
+	a.ForwardTo, b.ForwardTo = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// stopStream stops the current log stream, if any. c.mut must be held.
+func (c *Component) stopStream() {
+	if c.stream == nil {
+		return
+	}
+	c.stream.cancel()
+	<-c.stream.done
+	c.stream = nil
+}
+
+// runStream runs log stream with the given arguments until ctx is canceled,
+// restarting it whenever it exits.
+func (c *Component) runStream(ctx context.Context, args []string, conv *converter) {
+	for {
+		err := c.streamOnce(ctx, args, conv)
+		if ctx.Err() != nil {
+			return
+		}
+
+		level.Error(c.opts.Logger).Log("msg", "log stream exited, restarting", "backoff", restartBackoff, "err", err)
+		c.setHealth(component.HealthTypeUnhealthy, fmt.Sprintf("log stream exited: %s", err))
+		c.restartsTotal.Inc()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(restartBackoff):
+		}
+	}
+}
+
+// streamOnce runs log stream once, sending the log entries it writes to the
+// component until it exits or ctx is canceled.
+func (c *Component) streamOnce(ctx context.Context, args []string, conv *converter) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, logCommand, args...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	level.Debug(c.opts.Logger).Log("msg", "started log stream", "args", strings.Join(args, " "))
+	c.setHealth(component.HealthTypeHealthy, "log stream started")
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		entry, ok, err := conv.convert(scanner.Bytes())
+		if err != nil {
+			c.parseErrorsTotal.Inc()
+			level.Debug(c.opts.Logger).Log("msg", "failed to decode log stream line", "err", err)
+			continue
+		} else if !ok {
+			continue
+		}
+		c.entriesTotal.Inc()
+
+		select {
+		case <-ctx.Done():
+		case c.entries <- entry:
+		}
+	}
+	scanErr := scanner.Err()
+	if scanErr != nil {
+		// log stream would block writing the rest of its output otherwise.
+		_ = cmd.Process.Kill()
+	}
+
+	err = cmd.Wait()
+	switch {
+	case err != nil && stderr.Len() > 0:
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	case err != nil:
+		return err
+	case scanErr != nil:
+		return scanErr
+	default:
+		return fmt.Errorf("log stream exited without an error")
+	}
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
+
+func (c *Component) setHealth(t component.HealthType, msg string) {
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+	c.health = component.Health{
+		Health:     t,
+		Message:    msg,
+		UpdateTime: time.Now(),
+	}
+}
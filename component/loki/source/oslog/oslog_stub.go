@@ -0,0 +1,42 @@
+//go:build !darwin
+
+package oslog
+
+import (
+	"context"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "loki.source.oslog",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			level.Info(opts.Logger).Log("msg", "loki.source.oslog only works on macOS platforms")
+			return &FakeComponent{}, nil
+		},
+	})
+}
+
+var (
+	_ component.Component = (*FakeComponent)(nil)
+)
+
+// FakeComponent implements the loki.source.oslog component for non-macOS
+// environments.
+type FakeComponent struct {
+}
+
+// Run implements component.Component.
+func (f *FakeComponent) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements component.Component.
+func (f *FakeComponent) Update(_ component.Arguments) error {
+	return nil
+}
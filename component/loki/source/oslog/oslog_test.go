@@ -0,0 +1,122 @@
+package oslog
+
+import (
+	"testing"
+	"time"
+
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_StreamArgs(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect []string
+	}{
+		{
+			name:   "defaults",
+			config: `forward_to = []`,
+			expect: []string{"stream", "--style", "ndjson", "--type", "log", "--level", "default"},
+		},
+		{
+			name: "predicate",
+			config: `
+				predicate  = "process == \"kernel\""
+				level      = "debug"
+				forward_to = []
+			`,
+			expect: []string{"stream", "--style", "ndjson", "--type", "log", "--level", "debug", "--predicate", `process == "kernel"`},
+		},
+		{
+			name: "subsystems and predicate",
+			config: `
+				predicate  = "messageType == \"error\""
+				subsystems = ["com.apple.xpc", "com.example.kiosk"]
+				forward_to = []
+			`,
+			expect: []string{"stream", "--style", "ndjson", "--type", "log", "--level", "default", "--predicate",
+				`(subsystem IN {"com.apple.xpc", "com.example.kiosk"}) AND (messageType == "error")`},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			require.NoError(t, river.Unmarshal([]byte(tc.config), &args))
+			require.Equal(t, tc.expect, args.streamArgs())
+		})
+	}
+}
+
+func TestArguments_Validate(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		level      = "verbose"
+		forward_to = []
+	`), &args)
+	require.ErrorContains(t, err, `unrecognized level "verbose"`)
+
+	err = river.Unmarshal([]byte(`
+		subsystems = [""]
+		forward_to = []
+	`), &args)
+	require.ErrorContains(t, err, "subsystems must not be empty")
+}
+
+const testLine = `{"traceID":1234,"eventMessage":"kiosk started","eventType":"logEvent","source":null,` +
+	`"formatString":"%{public}s","activityIdentifier":0,"subsystem":"com.example.kiosk","category":"lifecycle",` +
+	`"threadID":5678,"senderImageUUID":"A","processImagePath":"\/Applications\/Kiosk.app\/Contents\/MacOS\/Kiosk",` +
+	`"senderImagePath":"\/usr\/lib\/libKiosk.dylib","timestamp":"2023-05-02 10:00:00.123456+0200",` +
+	`"messageType":"Default","processImageUUID":"B","processID":42,"senderProgramCounter":1,"parentActivityIdentifier":0,` +
+	`"timezoneName":""}`
+
+func TestConverter(t *testing.T) {
+	conv := &converter{
+		id: "loki.source.oslog.default",
+		args: Arguments{
+			Labels:               map[string]string{"host": "kiosk-1"},
+			UseIncomingTimestamp: true,
+		},
+	}
+	rule := flow_relabel.DefaultRelabelConfig
+	rule.SourceLabels = []string{"__oslog_process"}
+	rule.TargetLabel = "process"
+	conv.rcs = flow_relabel.ComponentToPromRelabelConfigs(flow_relabel.Rules{&rule})
+
+	entry, ok, err := conv.convert([]byte(testLine))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, model.LabelSet{
+		"job":       "loki.source.oslog.default",
+		"host":      "kiosk-1",
+		"subsystem": "com.example.kiosk",
+		"process":   "Kiosk",
+	}, entry.Labels)
+	require.Equal(t, "kiosk started", entry.Line)
+	require.Equal(t, time.Date(2023, 5, 2, 8, 0, 0, 123456000, time.UTC), entry.Timestamp.UTC())
+
+	conv.args.FormatAsJSON = true
+	entry, ok, err = conv.convert([]byte(testLine))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, testLine, entry.Line)
+}
+
+func TestConverter_SkipsOtherLines(t *testing.T) {
+	conv := &converter{id: "loki.source.oslog.default"}
+
+	_, ok, err := conv.convert([]byte(`Filtering the log data using "subsystem == \"com.example.kiosk\""`))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, ok, err = conv.convert([]byte(`{"eventType":"activityCreateEvent","eventMessage":"activity"}`))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, ok, err = conv.convert([]byte(`{"eventType":`))
+	require.Error(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,88 @@
+package oslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/agent/component/common/loki"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	"github.com/prometheus/common/model"
+)
+
+// Arguments holds values which are used to configure the loki.source.oslog
+// component.
+type Arguments struct {
+	Predicate            string              `river:"predicate,attr,optional"`
+	Subsystems           []string            `river:"subsystems,attr,optional"`
+	Level                string              `river:"level,attr,optional"`
+	FormatAsJSON         bool                `river:"format_as_json,attr,optional"`
+	UseIncomingTimestamp bool                `river:"use_incoming_timestamp,attr,optional"`
+	Labels               map[string]string   `river:"labels,attr,optional"`
+	RelabelRules         flow_relabel.Rules  `river:"relabel_rules,attr,optional"`
+	ForwardTo            []loki.LogsReceiver `river:"forward_to,attr"`
+}
+
+// DefaultArguments provides the default arguments for the loki.source.oslog
+// component.
+var DefaultArguments = Arguments{
+	Level: "default",
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	switch args.Level {
+	case "default", "info", "debug":
+	default:
+		return fmt.Errorf("unrecognized level %q, must be one of default, info, or debug", args.Level)
+	}
+	for _, s := range args.Subsystems {
+		if s == "" {
+			return fmt.Errorf("subsystems must not be empty")
+		}
+	}
+	for k := range args.Labels {
+		if !model.LabelName(k).IsValid() {
+			return fmt.Errorf("invalid label name %q", k)
+		}
+	}
+	return nil
+}
+
+// predicate returns the predicate filtering the log messages to stream, which
+// combines the subsystems with the predicate of args. An empty string is
+// returned if messages aren't filtered.
+func (args *Arguments) predicate() string {
+	var subsystems string
+	if len(args.Subsystems) > 0 {
+		quoted := make([]string, 0, len(args.Subsystems))
+		for _, s := range args.Subsystems {
+			quoted = append(quoted, strconv.Quote(s))
+		}
+		subsystems = fmt.Sprintf("subsystem IN {%s}", strings.Join(quoted, ", "))
+	}
+
+	switch {
+	case subsystems == "":
+		return args.Predicate
+	case args.Predicate == "":
+		return subsystems
+	default:
+		return fmt.Sprintf("(%s) AND (%s)", subsystems, args.Predicate)
+	}
+}
+
+// streamArgs returns the arguments of the log command which streams the
+// messages selected by args.
+func (args *Arguments) streamArgs() []string {
+	res := []string{"stream", "--style", "ndjson", "--type", "log", "--level", args.Level}
+	if p := args.predicate(); p != "" {
+		res = append(res, "--predicate", p)
+	}
+	return res
+}
@@ -43,6 +43,9 @@ type SyslogTarget struct {
 
 	transport Transport
 
+	// Deduplicates the labels of messages from the same hosts and apps.
+	labelSets *loki.LabelSetInterner
+
 	messages     chan message
 	messagesDone chan struct{}
 }
@@ -69,6 +72,7 @@ func NewSyslogTarget(
 		config:        config,
 		relabelConfig: relabel,
 		messagesDone:  make(chan struct{}),
+		labelSets:     loki.NewLabelSetInterner(loki.DefaultLabelSetInternerSize),
 	}
 
 	switch t.transportProtocol() {
@@ -150,13 +154,7 @@ func (t *SyslogTarget) handleMessage(connLabels labels.Labels, msg syslog.Messag
 
 	processed, _ := relabel.Process(lb.Labels(nil), t.relabelConfig...)
 
-	filtered := make(model.LabelSet)
-	for _, lbl := range processed {
-		if strings.HasPrefix(lbl.Name, "__") {
-			continue
-		}
-		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
-	}
+	filtered := t.labelSets.FromPublicLabels(processed)
 
 	var timestamp time.Time
 	if t.config.UseIncomingTimestamp && rfc5424Msg.Timestamp != nil {
@@ -25,6 +25,7 @@ import (
 	"github.com/prometheus/common/config"
 	"github.com/prometheus/prometheus/model/labels"
 
+	"github.com/grafana/agent/pkg/util/certreload"
 	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/clients/pkg/promtail/targets/syslog/syslogparser"
 )
@@ -191,7 +192,7 @@ func (t *TCPTransport) Run() error {
 	)
 
 	if tlsEnabled {
-		tlsConfig, err := newTLSConfig(tlsConfig)
+		tlsConfig, err := newTLSConfig(t.logger, tlsConfig)
 		if err != nil {
 			return fmt.Errorf("error setting up syslog target: %w", err)
 		}
@@ -210,7 +211,10 @@ func (t *TCPTransport) Run() error {
 // newTLSConfig creates TLS server settings from a [config.TLSConfig]. Use this
 // function to create TLS server settings, and [config.NewTLSConfig] to create
 // TLS client settings.
-func newTLSConfig(config config.TLSConfig) (*tls.Config, error) {
+//
+// Certificates and client CAs read from files are reloaded when the files
+// change, unless an inline client CA is used.
+func newTLSConfig(logger log.Logger, config config.TLSConfig) (*tls.Config, error) {
 	var (
 		configuredCert = len(config.Cert) > 0 || len(config.CertFile) > 0
 		configuredKey  = len(config.Key) > 0 || len(config.KeyFile) > 0
@@ -273,6 +277,18 @@ func newTLSConfig(config config.TLSConfig) (*tls.Config, error) {
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
 	}
 
+	if len(config.CertFile) > 0 && len(config.KeyFile) > 0 && len(config.CA) == 0 {
+		reloader, err := certreload.New(logger, certreload.Files{
+			CertFile:     config.CertFile,
+			KeyFile:      config.KeyFile,
+			ClientCAFile: config.CAFile,
+		}, 0)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load server certificate or key: %w", err)
+		}
+		reloader.Apply(tlsConfig)
+	}
+
 	return tlsConfig, nil
 }
 
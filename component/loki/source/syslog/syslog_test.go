@@ -201,3 +201,45 @@ func mustNewRegexp(s string) flow_relabel.Regexp {
 	}
 	return flow_relabel.Regexp{Regexp: re}
 }
+
+func TestIPv6Listener(t *testing.T) {
+	opts := component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+	}
+
+	port, err := freeport.GetFreePort()
+	require.NoError(t, err)
+	addr := fmt.Sprintf("[::1]:%d", port)
+
+	ch := make(chan loki.Entry)
+	args := Arguments{
+		SyslogListeners: []ListenerConfig{{ListenAddress: addr, ListenProtocol: "tcp"}},
+		ForwardTo:       []loki.LogsReceiver{ch},
+	}
+	require.NoError(t, args.SyslogListeners[0].Validate())
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+	time.Sleep(200 * time.Millisecond)
+
+	con, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	writeMessageToStream(con, `<165>1 2023-01-05T09:13:17.001Z host1 app - id1 - An IPv6 event log entry...`, fmtNewline)
+	require.NoError(t, con.Close())
+
+	select {
+	case logEntry := <-ch:
+		require.Equal(t, "An IPv6 event log entry...", logEntry.Line)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for log line")
+	}
+
+	invalid := ListenerConfig{ListenAddress: fmt.Sprintf("::1:%d", port), ListenProtocol: "tcp"}
+	require.ErrorContains(t, invalid.Validate(), "IPv6 addresses enclosed in brackets")
+}
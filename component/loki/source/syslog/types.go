@@ -2,6 +2,7 @@ package syslog
 
 import (
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/grafana/agent/component/common/config"
@@ -40,6 +41,9 @@ func (sc *ListenerConfig) Validate() error {
 	if sc.ListenProtocol != "tcp" && sc.ListenProtocol != "udp" {
 		return fmt.Errorf("syslog listener protocol should be either 'tcp' or 'udp', got %s", sc.ListenProtocol)
 	}
+	if _, _, err := net.SplitHostPort(sc.ListenAddress); err != nil {
+		return fmt.Errorf("syslog listener address %q should be of the form host:port, with IPv6 addresses enclosed in brackets such as [::1]:514: %w", sc.ListenAddress, err)
+	}
 
 	return nil
 }
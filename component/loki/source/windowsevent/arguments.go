@@ -1,11 +1,25 @@
 package windowsevent
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/grafana/agent/component/common/loki"
 )
 
+// Positions in the event log loki.source.windowsevent can start reading from.
+const (
+	// StartAtBookmark resumes after the last event saved in the bookmark, or
+	// reads new events only when there's no bookmark yet.
+	StartAtBookmark = "bookmark"
+	// StartAtOldest resumes after the last event saved in the bookmark, or
+	// reads all events retained in the event log when there's no bookmark yet.
+	StartAtOldest = "oldest"
+	// StartAtNewest ignores the bookmark when the component starts and reads
+	// new events only.
+	StartAtNewest = "newest"
+)
+
 // Arguments holds values which are used to configure the loki.source.windowsevent
 // component.
 type Arguments struct {
@@ -13,6 +27,7 @@ type Arguments struct {
 	EventLogName         string              `river:"eventlog_name,attr,optional"`
 	XPathQuery           string              `river:"xpath_query,attr,optional"`
 	BookmarkPath         string              `river:"bookmark_path,attr,optional"`
+	StartAt              string              `river:"start_at,attr,optional"`
 	PollInterval         time.Duration       `river:"poll_interval,attr,optional"`
 	ExcludeEventData     bool                `river:"exclude_event_data,attr,optional"`
 	ExcludeUserdata      bool                `river:"exclude_user_data,attr,optional"`
@@ -26,6 +41,7 @@ func defaultArgs() Arguments {
 		EventLogName:         "",
 		XPathQuery:           "*",
 		BookmarkPath:         "",
+		StartAt:              StartAtBookmark,
 		PollInterval:         3 * time.Second,
 		ExcludeEventData:     false,
 		ExcludeUserdata:      false,
@@ -37,3 +53,16 @@ func defaultArgs() Arguments {
 func (r *Arguments) SetToDefault() {
 	*r = defaultArgs()
 }
+
+// Validate implements river.Validator.
+func (r *Arguments) Validate() error {
+	switch r.StartAt {
+	case StartAtBookmark, StartAtOldest, StartAtNewest:
+	default:
+		return fmt.Errorf("invalid start_at %q, must be one of %q, %q or %q", r.StartAt, StartAtBookmark, StartAtOldest, StartAtNewest)
+	}
+	if r.PollInterval <= 0 {
+		return fmt.Errorf("poll_interval must be greater than 0")
+	}
+	return nil
+}
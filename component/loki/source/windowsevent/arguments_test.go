@@ -0,0 +1,52 @@
+package windowsevent
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`forward_to = []`), &args))
+	require.Equal(t, StartAtBookmark, args.StartAt)
+
+	require.NoError(t, river.Unmarshal([]byte(`
+		start_at   = "oldest"
+		forward_to = []
+	`), &args))
+	require.Equal(t, StartAtOldest, args.StartAt)
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		in     string
+		expect string
+	}{
+		{
+			name: "invalid start_at",
+			in: `
+				start_at   = "end"
+				forward_to = []
+			`,
+			expect: "invalid start_at \"end\"",
+		},
+		{
+			name: "invalid poll_interval",
+			in: `
+				poll_interval = "0s"
+				forward_to    = []
+			`,
+			expect: "poll_interval must be greater than 0",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			require.ErrorContains(t, river.Unmarshal([]byte(tc.in), &args), tc.expect)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package windowsevent
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// readBookmarkFile returns the bookmark saved at path, or an empty string if
+// no bookmark was saved yet.
+func readBookmarkFile(path string) (string, error) {
+	bb, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(bb), err
+}
+
+// writeBookmarkFile saves the bookmark at path. The bookmark is written to a
+// temporary file which then replaces the previous one, so that a crash while
+// saving never leaves a truncated bookmark behind.
+func writeBookmarkFile(path string, bookmark string) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath) // No-op once the file has been renamed.
+
+	if _, err := f.WriteString(bookmark); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,29 @@
+package windowsevent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookmarkFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "bookmark.xml")
+
+	bookmark, err := readBookmarkFile(path)
+	require.NoError(t, err)
+	require.Empty(t, bookmark, "missing bookmark should be empty")
+
+	require.NoError(t, writeBookmarkFile(path, "<BookmarkList>first</BookmarkList>"))
+	require.NoError(t, writeBookmarkFile(path, "<BookmarkList>second</BookmarkList>"))
+
+	bookmark, err = readBookmarkFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "<BookmarkList>second</BookmarkList>", bookmark)
+
+	// No temporary file should be left behind.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
@@ -67,3 +67,72 @@ func TestEventLogger(t *testing.T) {
 	cancelFunc()
 	require.True(t, found)
 }
+
+func TestBookmarkResume(t *testing.T) {
+	var loggerName = "agent_test"
+	_ = eventlog.InstallAsEventCreate(loggerName, eventlog.Info|eventlog.Warning|eventlog.Error)
+	wlog, err := eventlog.Open(loggerName)
+	require.NoError(t, err)
+	dataPath := t.TempDir()
+
+	// run starts the component until an entry containing msg is received,
+	// returning the lines received containing prefix.
+	run := func(prefix, msg string) []string {
+		rec := make(loki.LogsReceiver)
+		c, err := New(component.Options{
+			ID:            "loki.source.windowsevent.test",
+			Logger:        util.TestFlowLogger(t),
+			DataPath:      dataPath,
+			OnStateChange: func(e component.Exports) {},
+			Registerer:    prometheus.NewRegistry(),
+		}, Arguments{
+			EventLogName: "Application",
+			XPathQuery:   "*",
+			StartAt:      StartAtBookmark,
+			PollInterval: 10 * time.Millisecond,
+			ForwardTo:    []loki.LogsReceiver{rec},
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = c.Run(ctx)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+
+		require.NoError(t, wlog.Info(2, msg))
+
+		var lines []string
+		for {
+			select {
+			case <-ctx.Done():
+				require.FailNow(t, "timed out waiting for event", msg)
+			case e := <-rec:
+				if strings.Contains(e.Line, prefix) {
+					lines = append(lines, e.Line)
+				}
+				if strings.Contains(e.Line, msg) {
+					return lines
+				}
+			}
+		}
+	}
+
+	prefix := time.Now().Format(time.RFC3339Nano)
+	lines := run(prefix, prefix+" first")
+	require.Len(t, lines, 1)
+
+	// Events written while the component was stopped are read on restart,
+	// without reading the previous ones again.
+	require.NoError(t, wlog.Info(2, prefix+" offline"))
+	lines = run(prefix, prefix+" second")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], prefix+" offline")
+	require.Contains(t, lines[1], prefix+" second")
+}
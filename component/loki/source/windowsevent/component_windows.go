@@ -2,15 +2,11 @@ package windowsevent
 
 import (
 	"context"
-	"os"
-	"path"
+	"path/filepath"
 	"sync"
 
 	"github.com/grafana/agent/component"
-	"github.com/grafana/agent/component/common/loki"
-	"github.com/grafana/loki/clients/pkg/promtail/api"
-	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
-	"github.com/grafana/loki/clients/pkg/promtail/targets/windows"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 func init() {
@@ -30,35 +26,26 @@ var (
 
 // Component implements the loki.source.windowsevent component.
 type Component struct {
-	opts component.Options
+	opts        component.Options
+	bookmarkLag prometheus.Gauge
 
-	mut       sync.RWMutex
-	args      Arguments
-	target    *windows.Target
-	handle    *handler
-	receivers []loki.LogsReceiver
-}
-
-type handler struct {
-	handler chan api.Entry
-}
-
-func (h *handler) Chan() chan<- api.Entry {
-	return h.handler
-}
-
-func (h *handler) Stop() {
-	// This is a noop.
+	mut     sync.Mutex
+	args    Arguments
+	target  *target
+	started bool // Whether a target was started by the component.
 }
 
 // New creates a new loki.source.windowsevent component.
 func New(o component.Options, args Arguments) (*Component, error) {
-
 	c := &Component{
-		opts:      o,
-		receivers: args.ForwardTo,
-		handle:    &handler{handler: make(chan api.Entry)},
-		args:      args,
+		opts: o,
+		bookmarkLag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "loki_source_windowsevent_bookmark_lag_seconds",
+			Help: "Age of the last event saved in the bookmark, measured when the bookmark was saved.",
+		}),
+	}
+	if err := o.Registerer.Register(c.bookmarkLag); err != nil {
+		return nil, err
 	}
 
 	// Call to Update() to start readers and set receivers once at the start.
@@ -77,23 +64,9 @@ func (c *Component) Run(ctx context.Context) error {
 			_ = c.target.Stop()
 		}
 	}()
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case entry := <-c.handle.handler:
-			c.mut.RLock()
-			lokiEntry := loki.Entry{
-				Labels: entry.Labels,
-				Entry:  entry.Entry,
-			}
-			for _, receiver := range c.receivers {
-				receiver <- lokiEntry
-			}
-			c.mut.RUnlock()
-		}
-	}
 
+	<-ctx.Done()
+	return nil
 }
 
 // Update implements component.Component.
@@ -105,51 +78,26 @@ func (c *Component) Update(args component.Arguments) error {
 
 	// If no bookmark specified create one in the datapath.
 	if newArgs.BookmarkPath == "" {
-		newArgs.BookmarkPath = path.Join(c.opts.DataPath, "bookmark.xml")
+		newArgs.BookmarkPath = filepath.Join(c.opts.DataPath, "bookmark.xml")
 	}
 
-	// Create the bookmark file and parent folders if they don't exist.
-	_, err := os.Stat(newArgs.BookmarkPath)
-	if os.IsNotExist(err) {
-		err := os.MkdirAll(path.Dir(newArgs.BookmarkPath), 644)
-		if err != nil {
-			return err
-		}
-		f, err := os.Create(newArgs.BookmarkPath)
-		if err != nil {
+	// Stop the original target first so that it's done saving the bookmark
+	// before the new target reads it.
+	if c.target != nil {
+		if err := c.target.Stop(); err != nil {
 			return err
 		}
-		_ = f.Close()
+		c.target = nil
 	}
 
-	winTarget, err := windows.New(c.opts.Logger, c.handle, nil, convertConfig(newArgs))
+	// A newest start_at only ignores the bookmark when the component starts,
+	// so that updates don't skip the events written in the meantime.
+	winTarget, err := newTarget(c.opts.Logger, newArgs, !c.started, c.bookmarkLag)
 	if err != nil {
 		return err
 	}
-	// Stop the original target.
-	if c.target != nil {
-		err := c.target.Stop()
-		if err != nil {
-			return err
-		}
-	}
 	c.target = winTarget
-
+	c.started = true
 	c.args = newArgs
-	c.receivers = newArgs.ForwardTo
 	return nil
 }
-
-func convertConfig(arg Arguments) *scrapeconfig.WindowsEventsTargetConfig {
-	return &scrapeconfig.WindowsEventsTargetConfig{
-		Locale:               uint32(arg.Locale),
-		EventlogName:         arg.EventLogName,
-		Query:                arg.XPathQuery,
-		UseIncomingTimestamp: arg.UseIncomingTimestamp,
-		BookmarkPath:         arg.BookmarkPath,
-		PollInterval:         arg.PollInterval,
-		ExcludeEventData:     arg.ExcludeEventData,
-		ExcludeEventMessage:  false,
-		ExcludeUserData:      arg.ExcludeUserdata,
-	}
-}
@@ -0,0 +1,116 @@
+package windowsevent
+
+// This code is copied from Promtail's Windows events target, which doesn't
+// export how events are formatted.
+
+import (
+	"fmt"
+	"syscall"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/grafana/loki/clients/pkg/promtail/targets/windows/win_eventlog"
+)
+
+type event struct {
+	Source   string `json:"source,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+	Computer string `json:"computer,omitempty"`
+	EventID  int    `json:"event_id,omitempty"`
+	Version  int    `json:"version,omitempty"`
+
+	Level  int `json:"level,omitempty"`
+	Task   int `json:"task,omitempty"`
+	Opcode int `json:"opCode,omitempty"`
+
+	LevelText  string `json:"levelText,omitempty"`
+	TaskText   string `json:"taskText,omitempty"`
+	OpcodeText string `json:"opCodeText,omitempty"`
+
+	Keywords      string       `json:"keywords,omitempty"`
+	TimeCreated   string       `json:"timeCreated,omitempty"`
+	EventRecordID int          `json:"eventRecordID,omitempty"`
+	Correlation   *correlation `json:"correlation,omitempty"`
+	Execution     *execution   `json:"execution,omitempty"`
+
+	Security  *security `json:"security,omitempty"`
+	UserData  string    `json:"user_data,omitempty"`
+	EventData string    `json:"event_data,omitempty"`
+	Message   string    `json:"message,omitempty"`
+}
+
+type security struct {
+	UserID   string `json:"userId,omitempty"`
+	UserName string `json:"userName,omitempty"`
+}
+
+type execution struct {
+	ProcessID   uint32 `json:"processId,omitempty"`
+	ThreadID    uint32 `json:"threadId,omitempty"`
+	ProcessName string `json:"processName,omitempty"`
+}
+
+type correlation struct {
+	ActivityID        string `json:"activityID,omitempty"`
+	RelatedActivityID string `json:"relatedActivityID,omitempty"`
+}
+
+// formatLine formats a Loki log line from a windows event.
+func formatLine(args Arguments, ev win_eventlog.Event) (string, error) {
+	structuredEvent := event{
+		Source:        ev.Source.Name,
+		Channel:       ev.Channel,
+		Computer:      ev.Computer,
+		EventID:       ev.EventID,
+		Version:       ev.Version,
+		Level:         ev.Level,
+		Task:          ev.Task,
+		Opcode:        ev.Opcode,
+		LevelText:     ev.LevelText,
+		TaskText:      ev.TaskText,
+		OpcodeText:    ev.OpcodeText,
+		Keywords:      ev.Keywords,
+		TimeCreated:   ev.TimeCreated.SystemTime,
+		EventRecordID: ev.EventRecordID,
+		Message:       ev.Message,
+	}
+
+	if !args.ExcludeEventData {
+		structuredEvent.EventData = string(ev.EventData.InnerXML)
+	}
+	if !args.ExcludeUserdata {
+		structuredEvent.UserData = string(ev.UserData.InnerXML)
+	}
+	if ev.Correlation.ActivityID != "" || ev.Correlation.RelatedActivityID != "" {
+		structuredEvent.Correlation = &correlation{
+			ActivityID:        ev.Correlation.ActivityID,
+			RelatedActivityID: ev.Correlation.RelatedActivityID,
+		}
+	}
+	// best effort to get the username of the event.
+	if ev.Security.UserID != "" {
+		var userName string
+		usid, err := syscall.StringToSid(ev.Security.UserID)
+		if err == nil {
+			username, domain, _, err := usid.LookupAccount("")
+			if err == nil {
+				userName = fmt.Sprint(domain, "\\", username)
+			}
+		}
+		structuredEvent.Security = &security{
+			UserID:   ev.Security.UserID,
+			UserName: userName,
+		}
+	}
+	if ev.Execution.ProcessID != 0 {
+		structuredEvent.Execution = &execution{
+			ProcessID: ev.Execution.ProcessID,
+			ThreadID:  ev.Execution.ThreadID,
+		}
+		_, _, processName, err := win_eventlog.GetFromSnapProcess(ev.Execution.ProcessID)
+		if err == nil {
+			structuredEvent.Execution.ProcessName = processName
+		}
+	}
+	return jsoniter.MarshalToString(structuredEvent)
+}
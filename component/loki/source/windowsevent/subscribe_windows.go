@@ -0,0 +1,67 @@
+package windowsevent
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/grafana/loki/clients/pkg/promtail/targets/windows/win_eventlog"
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modwevtapi       = windows.NewLazySystemDLL("wevtapi.dll")
+	procEvtSubscribe = modwevtapi.NewProc("EvtSubscribe")
+)
+
+// evtSubscribeFlag holds the EVT_SUBSCRIBE_FLAGS values, which define where
+// a subscription starts reading events from. win_eventlog doesn't allow
+// subscribing from the oldest record.
+//
+// https://learn.microsoft.com/en-us/windows/win32/api/winevt/ne-winevt-evt_subscribe_flags
+type evtSubscribeFlag uint32
+
+const (
+	evtSubscribeToFutureEvents      evtSubscribeFlag = 1
+	evtSubscribeStartAtOldestRecord evtSubscribeFlag = 2
+	evtSubscribeStartAfterBookmark  evtSubscribeFlag = 3
+)
+
+// subscribe creates a pull subscription to the events of logName matching
+// query. bookmark is only used with evtSubscribeStartAfterBookmark.
+func subscribe(logName, query string, bookmark win_eventlog.EvtHandle, flags evtSubscribeFlag) (win_eventlog.EvtHandle, error) {
+	sigEvent, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer windows.CloseHandle(sigEvent)
+
+	logNamePtr, err := syscall.UTF16PtrFromString(logName)
+	if err != nil {
+		return 0, err
+	}
+	queryPtr, err := syscall.UTF16PtrFromString(query)
+	if err != nil {
+		return 0, err
+	}
+	if flags != evtSubscribeStartAfterBookmark {
+		bookmark = 0
+	}
+
+	r0, _, e1 := syscall.SyscallN(procEvtSubscribe.Addr(),
+		0, // Local session.
+		uintptr(sigEvent),
+		uintptr(unsafe.Pointer(logNamePtr)),
+		uintptr(unsafe.Pointer(queryPtr)),
+		uintptr(bookmark),
+		0, // No callback context.
+		0, // No callback, events are pulled with EvtNext.
+		uintptr(flags),
+	)
+	if r0 == 0 {
+		if e1 != 0 {
+			return 0, e1
+		}
+		return 0, syscall.EINVAL
+	}
+	return win_eventlog.EvtHandle(r0), nil
+}
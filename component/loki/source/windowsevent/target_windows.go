@@ -0,0 +1,256 @@
+package windowsevent
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/loki/clients/pkg/promtail/targets/windows/win_eventlog"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// target reads events from a Windows Event Log. Unlike Promtail's target, it
+// only moves its bookmark once events have been sent to all receivers, so
+// that restarts resume after the last event that was forwarded.
+type target struct {
+	log         log.Logger
+	args        Arguments
+	receivers   []loki.LogsReceiver
+	bookmarkLag prometheus.Gauge
+
+	bm           *bookmark
+	subscription win_eventlog.EvtHandle
+	fetcher      *win_eventlog.EventFetcher
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newTarget subscribes to the event log and starts reading events.
+// ignoreBookmark subscribes to new events only, with the saved bookmark
+// being overwritten once events are read.
+func newTarget(l log.Logger, args Arguments, ignoreBookmark bool, bookmarkLag prometheus.Gauge) (*target, error) {
+	bm, err := newBookmark(args.BookmarkPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bookmark from %s: %w", args.BookmarkPath, err)
+	}
+
+	var flags evtSubscribeFlag
+	switch {
+	case args.StartAt == StartAtNewest && ignoreBookmark:
+		flags = evtSubscribeToFutureEvents
+	case !bm.isNew:
+		flags = evtSubscribeStartAfterBookmark
+	case args.StartAt == StartAtOldest:
+		flags = evtSubscribeStartAtOldestRecord
+	default:
+		flags = evtSubscribeToFutureEvents
+	}
+
+	query := args.XPathQuery
+	if query == "" {
+		query = "*"
+	}
+	subscription, err := subscribe(args.EventLogName, query, bm.handle, flags)
+	if err != nil {
+		_ = bm.close()
+		return nil, fmt.Errorf("error subscribing to windows events: %w", err)
+	}
+
+	t := &target{
+		log:         l,
+		args:        args,
+		receivers:   args.ForwardTo,
+		bookmarkLag: bookmarkLag,
+
+		bm:           bm,
+		subscription: subscription,
+		fetcher:      win_eventlog.NewEventFetcher(),
+
+		done: make(chan struct{}),
+	}
+
+	t.wg.Add(1)
+	go t.loop()
+	return t, nil
+}
+
+// loop fetches new events and sends them to the receivers until the target
+// is stopped.
+func (t *target) loop() {
+	defer t.wg.Done()
+
+	interval := time.NewTicker(t.args.PollInterval)
+	defer interval.Stop()
+
+	for {
+		// Fetch events until there's no more.
+		for {
+			events, handles, err := t.fetcher.FetchEvents(t.subscription, uint32(t.args.Locale))
+			if err != nil {
+				if err != win_eventlog.ERROR_NO_MORE_ITEMS {
+					level.Error(t.log).Log("msg", "error fetching events", "err", err)
+				}
+				break
+			}
+			ok := t.handleEvents(events, handles)
+			_ = win_eventlog.Close(handles)
+			if !ok {
+				return
+			}
+		}
+
+		// No more events, wait for the next poll.
+		select {
+		case <-t.done:
+			return
+		case <-interval.C:
+		}
+	}
+}
+
+// handleEvents sends events to the receivers and then saves the position of
+// the last event sent in the bookmark. It returns false if the target was
+// stopped while sending events.
+func (t *target) handleEvents(events []win_eventlog.Event, handles []win_eventlog.EvtHandle) bool {
+	if len(handles) == 0 {
+		return true
+	}
+
+	// FetchEvents drops the events it can't render, in which case events
+	// can't be matched with their handle. The bookmark then only moves once
+	// the whole batch is sent.
+	aligned := len(events) == len(handles)
+
+	for i, ev := range events {
+		entry, err := t.renderEntry(ev)
+		if err != nil {
+			level.Warn(t.log).Log("msg", "error formatting event", "err", err)
+			continue
+		}
+		if !t.send(entry) {
+			if aligned && i > 0 {
+				t.saveBookmark(handles[i-1], events[i-1])
+			}
+			return false
+		}
+	}
+
+	var last win_eventlog.Event
+	if len(events) > 0 {
+		last = events[len(events)-1]
+	}
+	t.saveBookmark(handles[len(handles)-1], last)
+	return true
+}
+
+// send sends entry to all receivers. It returns false if the target was
+// stopped before entry was sent to all of them.
+func (t *target) send(entry loki.Entry) bool {
+	for _, receiver := range t.receivers {
+		select {
+		case <-t.done:
+			return false
+		case receiver <- entry:
+		}
+	}
+	return true
+}
+
+func (t *target) saveBookmark(handle win_eventlog.EvtHandle, last win_eventlog.Event) {
+	if err := t.bm.save(handle); err != nil {
+		level.Error(t.log).Log("msg", "error saving bookmark", "err", err)
+		return
+	}
+	if created, err := time.Parse(time.RFC3339Nano, last.TimeCreated.SystemTime); err == nil {
+		t.bookmarkLag.Set(time.Since(created).Seconds())
+	}
+}
+
+// renderEntry renders a Loki entry from a windows event.
+func (t *target) renderEntry(ev win_eventlog.Event) (loki.Entry, error) {
+	line, err := formatLine(t.args, ev)
+	if err != nil {
+		return loki.Entry{}, err
+	}
+
+	entry := loki.Entry{
+		Labels: make(model.LabelSet),
+		Entry: logproto.Entry{
+			Timestamp: time.Now(),
+			Line:      line,
+		},
+	}
+	if t.args.UseIncomingTimestamp {
+		ts, err := time.Parse(time.RFC3339Nano, ev.TimeCreated.SystemTime)
+		if err != nil {
+			level.Warn(t.log).Log("msg", "error parsing timestamp", "err", err)
+		} else {
+			entry.Timestamp = ts
+		}
+	}
+	if channel := model.LabelValue(ev.Channel); channel != "" && channel.IsValid() {
+		entry.Labels["channel"] = channel
+	}
+	if computer := model.LabelValue(ev.Computer); computer != "" && computer.IsValid() {
+		entry.Labels["computer"] = computer
+	}
+	return entry, nil
+}
+
+// Stop stops reading events. Events which were being sent are read again
+// from the bookmark by the next target.
+func (t *target) Stop() error {
+	close(t.done)
+	t.wg.Wait()
+
+	_ = win_eventlog.Close([]win_eventlog.EvtHandle{t.subscription})
+	return t.bm.close()
+}
+
+// bookmark holds the position of the last event sent in the event log.
+type bookmark struct {
+	path   string
+	handle win_eventlog.EvtHandle
+	isNew  bool
+
+	// 16KiB buffer for rendering the bookmark.
+	buf []byte
+}
+
+func newBookmark(path string) (*bookmark, error) {
+	// Rendered bookmarks keep their trailing NUL character, which
+	// CreateBookmark expects.
+	saved, err := readBookmarkFile(path)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := win_eventlog.CreateBookmark(saved)
+	if err != nil {
+		return nil, err
+	}
+	return &bookmark{
+		path:   path,
+		handle: handle,
+		isNew:  saved == "",
+		buf:    make([]byte, 16<<10),
+	}, nil
+}
+
+// save moves the bookmark to event and persists it.
+func (b *bookmark) save(event win_eventlog.EvtHandle) error {
+	rendered, err := win_eventlog.UpdateBookmark(b.handle, event, b.buf)
+	if err != nil {
+		return err
+	}
+	return writeBookmarkFile(b.path, rendered)
+}
+
+func (b *bookmark) close() error {
+	return win_eventlog.Close([]win_eventlog.EvtHandle{b.handle})
+}
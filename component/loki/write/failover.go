@@ -0,0 +1,164 @@
+package write
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/atomic"
+)
+
+// Policies deciding which endpoints log entries are sent to.
+const (
+	// EndpointPolicyMirror sends every entry to every endpoint.
+	EndpointPolicyMirror = "mirror"
+	// EndpointPolicyFailover sends entries to the first endpoint which is up,
+	// in the order the endpoints are defined.
+	EndpointPolicyFailover = "failover"
+)
+
+func validateEndpointPolicy(policy string, endpoints int) error {
+	switch policy {
+	case "", EndpointPolicyMirror:
+		return nil
+	case EndpointPolicyFailover:
+		if endpoints < 2 {
+			return fmt.Errorf("endpoint_policy %q requires at least two endpoint blocks", policy)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown endpoint_policy %q, must be %q or %q", policy, EndpointPolicyMirror, EndpointPolicyFailover)
+	}
+}
+
+// endpoint is a client along with the state of the endpoint it sends to. An
+// endpoint is down from the time a push request failed with a retryable
+// error until a push request succeeds.
+type endpoint struct {
+	client client.Client
+	up     atomic.Bool
+
+	upGauge prometheus.Gauge
+	changed chan<- struct{}
+}
+
+// init sets the client of the endpoint, which starts out up.
+func (e *endpoint) init(c client.Client, upGauge prometheus.Gauge) {
+	e.client = c
+	e.upGauge = upGauge
+	e.up.Store(true)
+	upGauge.Set(1)
+}
+
+func (e *endpoint) setUp(up bool) {
+	if e.up.Swap(up) == up {
+		return
+	}
+	if up {
+		e.upGauge.Set(1)
+	} else {
+		e.upGauge.Set(0)
+	}
+	select {
+	case e.changed <- struct{}{}:
+	default:
+	}
+}
+
+// tripperware returns a client.Tripperware which tracks whether the endpoint
+// is up from the responses to push requests.
+func (e *endpoint) tripperware() client.Tripperware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			resp, err := next.RoundTrip(req)
+			switch {
+			case err != nil, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode/100 == 5:
+				// These responses are retried by the client.
+				e.setUp(false)
+			case resp.StatusCode/100 == 2:
+				e.setUp(true)
+			}
+			return resp, err
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// firstUp returns the first endpoint which is up, or the first endpoint if
+// every endpoint is down.
+func firstUp(endpoints []*endpoint) *endpoint {
+	for _, e := range endpoints {
+		if e.up.Load() {
+			return e
+		}
+	}
+	return endpoints[0]
+}
+
+// precedingDown reports whether every endpoint before the one at index i is
+// down.
+func precedingDown(endpoints []*endpoint, i int) bool {
+	for _, e := range endpoints[:i] {
+		if e.up.Load() {
+			return false
+		}
+	}
+	return true
+}
+
+// failoverForwarder forwards the entries read from the WAL for an endpoint
+// which uses the failover policy. The first endpoint is sent every entry, so
+// that entries written while it was down are replayed once it recovers. The
+// other endpoints are only sent the entries read while every endpoint before
+// them is down; they skip the other entries.
+type failoverForwarder struct {
+	ch      chan loki.Entry
+	skipped prometheus.Counter
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newFailoverForwarder(endpoints []*endpoint, i int, skipped prometheus.Counter) *failoverForwarder {
+	f := &failoverForwarder{
+		ch:      make(chan loki.Entry),
+		skipped: skipped,
+		quit:    make(chan struct{}),
+	}
+	f.wg.Add(1)
+	go f.run(endpoints, i)
+	return f
+}
+
+func (f *failoverForwarder) run(endpoints []*endpoint, i int) {
+	defer f.wg.Done()
+	for {
+		select {
+		case <-f.quit:
+			return
+		case entry := <-f.ch:
+			if !precedingDown(endpoints, i) {
+				f.skipped.Inc()
+				continue
+			}
+			select {
+			case <-f.quit:
+				return
+			case endpoints[i].client.Chan() <- entry:
+			}
+		}
+	}
+}
+
+// Stop stops the forwarder. The WAL reader sending to it must be stopped
+// first.
+func (f *failoverForwarder) Stop() {
+	close(f.quit)
+	f.wg.Wait()
+}
@@ -0,0 +1,179 @@
+package write
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/loki/pkg/logproto"
+	loki_util "github.com/grafana/loki/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestEndpointPolicy_Validate(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		endpoint_policy = "failover"
+		endpoint {
+			url = "http://localhost:3100/loki/api/v1/push"
+		}
+	`), &args)
+	require.ErrorContains(t, err, `endpoint_policy "failover" requires at least two endpoint blocks`)
+
+	err = river.Unmarshal([]byte(`endpoint_policy = "round_robin"`), &args)
+	require.ErrorContains(t, err, `unknown endpoint_policy "round_robin"`)
+}
+
+// failoverServer is a Loki server which can be made to fail push requests.
+type failoverServer struct {
+	*httptest.Server
+	failing atomic.Bool
+
+	mut   sync.Mutex
+	lines []string
+}
+
+func newFailoverServer(t *testing.T) *failoverServer {
+	s := &failoverServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var pushReq logproto.PushRequest
+		require.NoError(t, loki_util.ParseProtoReader(context.Background(), r.Body, int(r.ContentLength), math.MaxInt32, &pushReq, loki_util.RawSnappy))
+
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		for _, stream := range pushReq.Streams {
+			for _, e := range stream.Entries {
+				s.lines = append(s.lines, e.Line)
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *failoverServer) Lines() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]string{}, s.lines...)
+}
+
+func newFailoverComponent(t *testing.T, primary, secondary string, wal bool) *Component {
+	cfg := fmt.Sprintf(`
+		endpoint_policy = "failover"
+		endpoint {
+			name                = "primary"
+			url                 = "%s"
+			batch_wait          = "10ms"
+			min_backoff_period  = "10ms"
+			max_backoff_period  = "50ms"
+			max_backoff_retries = 0
+		}
+		endpoint {
+			name       = "secondary"
+			url        = "%s"
+			batch_wait = "10ms"
+		}
+		wal {
+			enabled = %t
+		}
+	`, primary, secondary, wal)
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	c, err := New(component.Options{
+		ID:            "loki.write.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		DataPath:      t.TempDir(),
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		require.NoError(t, c.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-runDone
+	})
+	return c
+}
+
+func failoverEntry(line string) loki.Entry {
+	return loki.Entry{
+		Labels: model.LabelSet{"foo": "bar"},
+		Entry:  logproto.Entry{Timestamp: time.Now(), Line: line},
+	}
+}
+
+func TestFailover(t *testing.T) {
+	primary, secondary := newFailoverServer(t), newFailoverServer(t)
+	c := newFailoverComponent(t, primary.URL, secondary.URL, false)
+
+	c.receiver <- failoverEntry("line 0")
+	require.Eventually(t, func() bool { return primary.Lines() != nil }, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, secondary.Lines())
+
+	// Entries are sent to the secondary endpoint while the primary is down.
+	primary.failing.Store(true)
+	c.receiver <- failoverEntry("line 1")
+	require.Eventually(t, func() bool { return !c.endpoints[0].up.Load() }, 5*time.Second, 10*time.Millisecond)
+	c.receiver <- failoverEntry("line 2")
+	require.Eventually(t, func() bool {
+		lines := secondary.Lines()
+		return len(lines) > 0 && lines[len(lines)-1] == "line 2"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The primary endpoint is used again once it recovers.
+	primary.failing.Store(false)
+	require.Eventually(t, func() bool { return c.endpoints[0].up.Load() }, 5*time.Second, 10*time.Millisecond)
+	sent := len(secondary.Lines())
+	c.receiver <- failoverEntry("line 3")
+	require.Eventually(t, func() bool {
+		lines := primary.Lines()
+		return lines[len(lines)-1] == "line 3"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, secondary.Lines(), sent)
+}
+
+func TestFailover_WALReplay(t *testing.T) {
+	primary, secondary := newFailoverServer(t), newFailoverServer(t)
+	primary.failing.Store(true)
+	c := newFailoverComponent(t, primary.URL, secondary.URL, true)
+
+	c.receiver <- failoverEntry("line 0")
+	require.Eventually(t, func() bool { return !c.endpoints[0].up.Load() }, 5*time.Second, 10*time.Millisecond)
+	for i := 1; i <= 3; i++ {
+		c.receiver <- failoverEntry(fmt.Sprintf("line %d", i))
+	}
+	require.Eventually(t, func() bool {
+		lines := secondary.Lines()
+		return len(lines) > 0 && lines[len(lines)-1] == "line 3"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Every entry is replayed to the primary endpoint once it recovers.
+	primary.failing.Store(false)
+	require.Eventually(t, func() bool {
+		return len(primary.Lines()) == 4
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, []string{"line 0", "line 1", "line 2", "line 3"}, primary.Lines())
+}
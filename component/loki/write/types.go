@@ -27,7 +27,9 @@ type EndpointOptions struct {
 	MaxBackoff        time.Duration           `river:"max_backoff_period,attr,optional"`  // increase exponentially to this level
 	MaxBackoffRetries int                     `river:"max_backoff_retries,attr,optional"` // give up after this many; zero means infinite retries
 	TenantID          string                  `river:"tenant_id,attr,optional"`
+	Compression       string                  `river:"compression,attr,optional"`
 	HTTPClientConfig  *types.HTTPClientConfig `river:",squash"`
+	Adaptive          *AdaptiveOptions        `river:"adaptive,block,optional"`
 }
 
 // GetDefaultEndpointOptions defines the default settings for sending logs to a
@@ -43,6 +45,7 @@ func GetDefaultEndpointOptions() EndpointOptions {
 		MinBackoff:        500 * time.Millisecond,
 		MaxBackoff:        5 * time.Minute,
 		MaxBackoffRetries: 10,
+		Compression:       string(client.CompressionSnappy),
 		HTTPClientConfig:  types.CloneDefaultHTTPClientConfig(),
 	}
 
@@ -59,6 +62,13 @@ func (r *EndpointOptions) Validate() error {
 	if _, err := url.Parse(r.URL); err != nil {
 		return fmt.Errorf("failed to parse remote url %q: %w", r.URL, err)
 	}
+	if err := client.Compression(r.Compression).Validate(); err != nil {
+		return err
+	}
+
+	if r.Adaptive != nil && (r.BatchSize < r.Adaptive.MinBatchSize || r.BatchSize > r.Adaptive.MaxBatchSize) {
+		return fmt.Errorf("batch_size must be between min_batch_size and max_batch_size when adaptive is set")
+	}
 
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	if r.HTTPClientConfig != nil {
@@ -68,6 +78,96 @@ func (r *EndpointOptions) Validate() error {
 	return nil
 }
 
+// AdaptiveOptions configures an endpoint to adapt the size of batches and the
+// number of batches sent concurrently to how the endpoint keeps up.
+type AdaptiveOptions struct {
+	MinBatchSize   units.Base2Bytes `river:"min_batch_size,attr,optional"`
+	MaxBatchSize   units.Base2Bytes `river:"max_batch_size,attr,optional"`
+	MinConcurrency int              `river:"min_concurrency,attr,optional"`
+	MaxConcurrency int              `river:"max_concurrency,attr,optional"`
+	TargetLatency  time.Duration    `river:"target_latency,attr,optional"`
+}
+
+// DefaultAdaptiveOptions holds the default settings for adapting batches.
+var DefaultAdaptiveOptions = AdaptiveOptions{
+	MinBatchSize:   64 * units.KiB,
+	MaxBatchSize:   4 * units.MiB,
+	MinConcurrency: 1,
+	MaxConcurrency: 4,
+	TargetLatency:  2 * time.Second,
+}
+
+// SetToDefault implements river.Defaulter.
+func (o *AdaptiveOptions) SetToDefault() {
+	*o = DefaultAdaptiveOptions
+}
+
+// Validate implements river.Validator.
+func (o *AdaptiveOptions) Validate() error {
+	switch {
+	case o.MinBatchSize <= 0:
+		return fmt.Errorf("min_batch_size must be greater than 0")
+	case o.MaxBatchSize < o.MinBatchSize:
+		return fmt.Errorf("max_batch_size must not be less than min_batch_size")
+	case o.MinConcurrency <= 0:
+		return fmt.Errorf("min_concurrency must be greater than 0")
+	case o.MaxConcurrency < o.MinConcurrency:
+		return fmt.Errorf("max_concurrency must not be less than min_concurrency")
+	case o.TargetLatency <= 0:
+		return fmt.Errorf("target_latency must be greater than 0")
+	}
+	return nil
+}
+
+func (o *AdaptiveOptions) convert() client.AdaptiveConfig {
+	if o == nil {
+		return client.AdaptiveConfig{}
+	}
+	return client.AdaptiveConfig{
+		Enabled:        true,
+		MinBatchSize:   int(o.MinBatchSize),
+		MaxBatchSize:   int(o.MaxBatchSize),
+		MinConcurrency: o.MinConcurrency,
+		MaxConcurrency: o.MaxConcurrency,
+		TargetLatency:  o.TargetLatency,
+	}
+}
+
+// WALOptions configures the write-ahead log of the loki.write component.
+type WALOptions struct {
+	Enabled           bool             `river:"enabled,attr,optional"`
+	MaxSegmentAge     time.Duration    `river:"max_segment_age,attr,optional"`
+	MaxSize           units.Base2Bytes `river:"max_size,attr,optional"`
+	TruncateFrequency time.Duration    `river:"truncate_frequency,attr,optional"`
+}
+
+// DefaultWALOptions holds the default settings for the WAL.
+var DefaultWALOptions = WALOptions{
+	Enabled:           false,
+	MaxSegmentAge:     1 * time.Hour,
+	MaxSize:           1 * units.GiB,
+	TruncateFrequency: 1 * time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (o *WALOptions) SetToDefault() {
+	*o = DefaultWALOptions
+}
+
+// Validate implements river.Validator.
+func (o *WALOptions) Validate() error {
+	switch {
+	case o.TruncateFrequency <= 0:
+		return fmt.Errorf("truncate_frequency must be greater than 0")
+	case o.MaxSegmentAge < 0:
+		return fmt.Errorf("max_segment_age must not be negative")
+	case o.MaxSize < 0:
+		return fmt.Errorf("max_size must not be negative")
+	}
+
+	return nil
+}
+
 func (args Arguments) convertClientConfigs() []client.Config {
 	var res []client.Config
 	for _, cfg := range args.Endpoints {
@@ -87,6 +187,8 @@ func (args Arguments) convertClientConfigs() []client.Config {
 			ExternalLabels: lokiflagext.LabelSet{LabelSet: toLabelSet(args.ExternalLabels)},
 			Timeout:        cfg.RemoteTimeout,
 			TenantID:       cfg.TenantID,
+			Compression:    client.Compression(cfg.Compression),
+			Adaptive:       cfg.Adaptive.convert(),
 		}
 		res = append(res, cc)
 	}
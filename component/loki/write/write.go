@@ -3,12 +3,21 @@ package write
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/delivery"
 	"github.com/grafana/agent/component/common/loki"
 	"github.com/grafana/agent/component/common/loki/client"
+	"github.com/grafana/agent/component/common/loki/wal"
+	"github.com/grafana/agent/component/common/tenant"
+	"github.com/grafana/agent/component/common/usage"
 	"github.com/grafana/agent/pkg/build"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
 )
 
 var streamLagLabels = []string{"filename"}
@@ -32,6 +41,14 @@ type Arguments struct {
 	Endpoints      []EndpointOptions `river:"endpoint,block,optional"`
 	ExternalLabels map[string]string `river:"external_labels,attr,optional"`
 	MaxStreams     int               `river:"max_streams,attr,optional"`
+	WAL            WALOptions        `river:"wal,block,optional"`
+	TenantMapping  *tenant.Arguments `river:"tenant_mapping,block,optional"`
+	EndpointPolicy string            `river:"endpoint_policy,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	return validateEndpointPolicy(args.EndpointPolicy, len(args.Endpoints))
 }
 
 // Exports holds the receiver that is used to send log entries to the
@@ -46,20 +63,74 @@ var (
 
 // Component implements the loki.write component.
 type Component struct {
-	opts    component.Options
-	metrics *client.Metrics
+	opts       component.Options
+	metrics    *client.Metrics
+	walMetrics *wal.Metrics
+	delivery   *delivery.Tracker
+	tenants    *tenant.Mapper
+	usage      *usage.Logs
+
+	rejectedEntries prometheus.Counter
+	endpointUp      *prometheus.GaugeVec
+	failoverSkipped *prometheus.CounterVec
+
+	// endpointsChanged is notified when an endpoint goes up or down.
+	endpointsChanged chan struct{}
+
+	mut       sync.RWMutex
+	args      Arguments
+	receiver  loki.LogsReceiver
+	endpoints []*endpoint
+	// endpointTenants are the distinct tenants of the endpoints, which
+	// entries not mapped to a tenant are accounted for.
+	endpointTenants []string
 
-	mut      sync.RWMutex
-	args     Arguments
-	receiver loki.LogsReceiver
-	clients  []client.Client
+	// wal and walReaders are only set when the WAL is enabled. Entries are
+	// then written to the WAL, and each client is fed by its own reader.
+	// With the failover policy, readers send to a forwarder deciding which
+	// entries are sent to the client.
+	wal        *wal.WAL
+	walReaders []*wal.Reader
+	forwarders []*failoverForwarder
 }
 
 // New creates a new loki.write component.
 func New(o component.Options, args Arguments) (*Component, error) {
 	c := &Component{
-		opts:    o,
-		metrics: client.NewMetrics(o.Registerer, streamLagLabels),
+		opts:       o,
+		metrics:    client.NewMetrics(o.Registerer, streamLagLabels),
+		walMetrics: wal.NewMetrics(o.Registerer),
+		delivery:   delivery.NewTracker(o.Registerer),
+
+		rejectedEntries: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "loki_write_tenant_rejected_entries_total",
+			Help: "Total number of log entries dropped because the tenant mapping didn't map them to a tenant.",
+		}),
+		endpointUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "loki_write_endpoint_up",
+			Help: "Whether the last push request to the endpoint succeeded (1) or failed with a retryable error (0).",
+		}, []string{"endpoint"}),
+		failoverSkipped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "loki_write_failover_skipped_entries_total",
+			Help: "Total number of log entries read from the WAL which weren't sent to the endpoint because a preceding endpoint was up.",
+		}, []string{"endpoint"}),
+
+		endpointsChanged: make(chan struct{}, 1),
+	}
+	for _, metric := range []prometheus.Collector{c.rejectedEntries, c.endpointUp, c.failoverSkipped} {
+		if err := o.Registerer.Register(metric); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	c.tenants, err = tenant.NewMapper(o.Logger, o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+	c.usage, err = usage.NewLogs(o.Registerer)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create and immediately export the receiver which remains the same for
@@ -77,22 +148,114 @@ func New(o component.Options, args Arguments) (*Component, error) {
 
 // Run implements component.Component.
 func (c *Component) Run(ctx context.Context) error {
+	defer c.closeWAL()
+
+	go c.tenants.Run(ctx, nil)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case entry := <-c.receiver:
-			for _, client := range c.clients {
-				if client != nil {
-					select {
-					case <-ctx.Done():
-						return nil
-					case client.Chan() <- entry:
-						// no-op
-					}
+			labels, stamp, stamped := delivery.ExtractLabels(entry.Labels)
+			entry.Labels = labels
+			if stamped {
+				c.delivery.Observe(stamp)
+			}
+
+			if mapping := c.tenants.Mapping(); mapping != nil {
+				id, ok := mapping.Tenant(entry.Labels)
+				if !ok {
+					c.rejectedEntries.Inc()
+					level.Debug(c.opts.Logger).Log("msg", "dropping log entry not mapped to a tenant", "labels", entry.Labels.String())
+					continue
 				}
+				// The labels may be shared with other receivers of the entry.
+				entry.Labels = entry.Labels.Clone()
+				entry.Labels[client.ReservedLabelTenantID] = model.LabelValue(id)
+			}
+
+			c.mut.RLock()
+			c.observeUsage(entry, stamp)
+			if c.wal != nil {
+				err := c.wal.Append(entry)
+				c.mut.RUnlock()
+				if err != nil {
+					level.Error(c.opts.Logger).Log("msg", "failed to write entry to WAL", "err", err)
+				}
+				continue
+			}
+			endpoints, policy := c.endpoints, c.args.EndpointPolicy
+			c.mut.RUnlock()
+
+			if policy == EndpointPolicyFailover {
+				if !c.sendFailover(ctx, endpoints, entry) {
+					return nil
+				}
+				continue
+			}
+
+			for _, e := range endpoints {
+				select {
+				case <-ctx.Done():
+					return nil
+				case e.client.Chan() <- entry:
+					// no-op
+				}
+			}
+		}
+	}
+}
+
+// observeUsage accounts for entry being forwarded. Entries mapped to a tenant
+// are accounted for that tenant, and other entries for the tenants of the
+// endpoints. observeUsage must be called with mut held.
+func (c *Component) observeUsage(entry loki.Entry, stamp delivery.Stamp) {
+	if id, ok := entry.Labels[client.ReservedLabelTenantID]; ok {
+		c.usage.Observe(string(id), stamp, entry.Entry)
+		return
+	}
+	for _, id := range c.endpointTenants {
+		c.usage.Observe(id, stamp, entry.Entry)
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sendFailover sends entry to the first endpoint which is up. The endpoint is
+// chosen again if an endpoint goes up or down before the entry was sent.
+// sendFailover returns false if ctx was canceled first.
+//
+// The entry is also offered to the endpoints before the chosen one, which are
+// down. Their clients only accept it once they stopped retrying, so that a
+// push request eventually tells whether the endpoint recovered.
+func (c *Component) sendFailover(ctx context.Context, endpoints []*endpoint, entry loki.Entry) bool {
+	for {
+		target := firstUp(endpoints)
+		for _, e := range endpoints {
+			if e == target {
+				break
+			}
+			select {
+			case e.client.Chan() <- entry:
+			default:
 			}
 		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case target.client.Chan() <- entry:
+			return true
+		case <-c.endpointsChanged:
+		}
 	}
 }
 
@@ -100,28 +263,108 @@ func (c *Component) Run(ctx context.Context) error {
 func (c *Component) Update(args component.Arguments) error {
 	newArgs := args.(Arguments)
 
+	if err := c.tenants.ApplyConfig(newArgs.TenantMapping); err != nil {
+		return err
+	}
+
 	c.mut.Lock()
 	defer c.mut.Unlock()
+	oldArgs := c.args
 	c.args = newArgs
 
-	for _, client := range c.clients {
-		if client != nil {
-			client.Stop()
-		}
+	// Readers must be stopped before the forwarders and clients they send to.
+	c.stopReaders()
+
+	for _, e := range c.endpoints {
+		e.client.Stop()
 	}
-	c.clients = make([]client.Client, len(newArgs.Endpoints))
+	c.endpoints = nil
+	c.endpointTenants = nil
+	c.endpointUp.Reset()
+	c.failoverSkipped.Reset()
 
+	if c.wal != nil && oldArgs.WAL != newArgs.WAL {
+		if err := c.wal.Close(); err != nil {
+			level.Error(c.opts.Logger).Log("msg", "failed to close WAL", "err", err)
+		}
+		c.wal = nil
+	}
+	if c.wal == nil && newArgs.WAL.Enabled {
+		w, err := wal.New(wal.Config{
+			Dir:               filepath.Join(c.opts.DataPath, "wal"),
+			MaxSegmentAge:     newArgs.WAL.MaxSegmentAge,
+			MaxSize:           int64(newArgs.WAL.MaxSize),
+			TruncateFrequency: newArgs.WAL.TruncateFrequency,
+		}, log.With(c.opts.Logger, "subcomponent", "wal"), c.walMetrics)
+		if err != nil {
+			return err
+		}
+		c.wal = w
+	}
 	cfgs := newArgs.convertClientConfigs()
 	// TODO (@tpaschalis) We could use a client.NewMulti here to push the
 	// fanout logic back to the client layer, but I opted to keep it explicit
 	// here a) for easier debugging and b) possible improvements in the future.
 	for _, cfg := range cfgs {
-		client, err := client.New(c.metrics, cfg, streamLagLabels, newArgs.MaxStreams, c.opts.Logger)
+		e := &endpoint{changed: c.endpointsChanged}
+		client, err := client.NewWithTripperware(c.metrics, cfg, streamLagLabels, newArgs.MaxStreams, c.opts.Logger, e.tripperware())
 		if err != nil {
 			return err
 		}
-		c.clients = append(c.clients, client)
+		// Nothing is sent to the client before Update returns, so the
+		// endpoint can be set up after creating it.
+		e.init(client, c.endpointUp.WithLabelValues(client.Name()))
+		c.endpoints = append(c.endpoints, e)
+		if !containsString(c.endpointTenants, cfg.TenantID) {
+			c.endpointTenants = append(c.endpointTenants, cfg.TenantID)
+		}
+	}
+
+	if c.wal == nil {
+		return nil
+	}
+	for i, e := range c.endpoints {
+		ch := e.client.Chan()
+		if newArgs.EndpointPolicy == EndpointPolicyFailover {
+			f := newFailoverForwarder(c.endpoints, i, c.failoverSkipped.WithLabelValues(e.client.Name()))
+			c.forwarders = append(c.forwarders, f)
+			ch = f.ch
+		}
+
+		r, err := c.wal.NewReader(e.client.Name(), ch)
+		if err != nil {
+			return err
+		}
+		c.walReaders = append(c.walReaders, r)
 	}
 
 	return nil
 }
+
+// stopReaders stops the WAL readers and then the forwarders they send to.
+func (c *Component) stopReaders() {
+	for _, r := range c.walReaders {
+		r.Stop()
+	}
+	c.walReaders = nil
+
+	for _, f := range c.forwarders {
+		f.Stop()
+	}
+	c.forwarders = nil
+}
+
+// closeWAL stops the WAL readers and closes the WAL, if enabled.
+func (c *Component) closeWAL() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.stopReaders()
+
+	if c.wal != nil {
+		if err := c.wal.Close(); err != nil {
+			level.Error(c.opts.Logger).Log("msg", "failed to close WAL", "err", err)
+		}
+		c.wal = nil
+	}
+}
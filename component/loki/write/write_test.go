@@ -7,10 +7,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/delivery"
 	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/common/loki/client"
+	"github.com/grafana/agent/component/common/usage"
 	"github.com/grafana/agent/component/discovery"
 	lsf "github.com/grafana/agent/component/loki/source/file"
 	"github.com/grafana/agent/pkg/flow/componenttest"
@@ -18,6 +24,8 @@ import (
 	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/loki/pkg/logproto"
 	loki_util "github.com/grafana/loki/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
 )
@@ -53,6 +61,53 @@ func TestBadRiverConfig(t *testing.T) {
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
 
+func TestAdaptiveRiverConfig(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+	endpoint {
+		url = "http://0.0.0.0:11111/loki/api/v1/push"
+
+		adaptive {
+			max_concurrency = 8
+		}
+	}
+`), &args)
+	require.NoError(t, err)
+
+	cfgs := args.convertClientConfigs()
+	require.Len(t, cfgs, 1)
+	require.Equal(t, client.AdaptiveConfig{
+		Enabled:        true,
+		MinBatchSize:   64 * 1024,
+		MaxBatchSize:   4 * 1024 * 1024,
+		MinConcurrency: 1,
+		MaxConcurrency: 8,
+		TargetLatency:  2 * time.Second,
+	}, cfgs[0].Adaptive)
+
+	err = river.Unmarshal([]byte(`
+	endpoint {
+		url        = "http://0.0.0.0:11111/loki/api/v1/push"
+		batch_size = "8MiB"
+
+		adaptive {}
+	}
+`), &args)
+	require.ErrorContains(t, err, "batch_size must be between min_batch_size and max_batch_size when adaptive is set")
+
+	err = river.Unmarshal([]byte(`
+	endpoint {
+		url = "http://0.0.0.0:11111/loki/api/v1/push"
+
+		adaptive {
+			min_concurrency = 4
+			max_concurrency = 2
+		}
+	}
+`), &args)
+	require.ErrorContains(t, err, "max_concurrency must not be less than min_concurrency")
+}
+
 func Test(t *testing.T) {
 	// Set up the server that will receive the log entry, and expose it on ch.
 	ch := make(chan logproto.PushRequest)
@@ -211,3 +266,179 @@ func TestEntrySentToTwoWriteComponents(t *testing.T) {
 		}
 	}
 }
+
+func TestWAL_ReplayAfterRestart(t *testing.T) {
+	// The first server never responds, so that entries pile up in the WAL.
+	var (
+		unblock  = make(chan struct{})
+		received = make(chan struct{}, 1)
+	)
+	srv1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+		<-unblock
+	}))
+	defer srv1.Close()
+	defer close(unblock)
+
+	lines := make(chan string, 10)
+	srv2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pushReq logproto.PushRequest
+		require.NoError(t, loki_util.ParseProtoReader(context.Background(), r.Body, int(r.ContentLength), math.MaxInt32, &pushReq, loki_util.RawSnappy))
+		for _, s := range pushReq.Streams {
+			for _, e := range s.Entries {
+				lines <- e.Line
+			}
+		}
+	}))
+	defer srv2.Close()
+
+	dataPath := t.TempDir()
+	newComponent := func(url string) *Component {
+		cfg := fmt.Sprintf(`
+			endpoint {
+				name       = "default"
+				url        = "%s"
+				batch_wait = "10ms"
+			}
+			wal {
+				enabled = true
+			}
+		`, url)
+		var args Arguments
+		require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+		c, err := New(component.Options{
+			ID:            "loki.write.test",
+			Logger:        util.TestFlowLogger(t),
+			Registerer:    prometheus.NewRegistry(),
+			OnStateChange: func(e component.Exports) {},
+			DataPath:      dataPath,
+		}, args)
+		require.NoError(t, err)
+		return c
+	}
+	entry := func(line string) loki.Entry {
+		return loki.Entry{
+			Labels: model.LabelSet{"foo": "bar"},
+			Entry:  logproto.Entry{Timestamp: time.Now(), Line: line},
+		}
+	}
+
+	c1 := newComponent(srv1.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	runDone := make(chan struct{})
+	go func() {
+		defer close(runDone)
+		require.NoError(t, c1.Run(ctx))
+	}()
+
+	// Wait for the first entry to be stuck in a push request, then write more
+	// entries which can't be sent.
+	c1.receiver <- entry("line 0")
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for push request")
+	}
+	for i := 1; i <= 3; i++ {
+		c1.receiver <- entry(fmt.Sprintf("line %d", i))
+	}
+
+	cancel()
+	<-runDone
+
+	// Restart with a working endpoint of the same name. The entries which
+	// were never handed over to the first client are replayed.
+	c2 := newComponent(srv2.URL)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c2.Run(ctx) }()
+
+	for i := 1; i <= 3; i++ {
+		select {
+		case line := <-lines:
+			require.Equal(t, fmt.Sprintf("line %d", i), line)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for replayed logs")
+		}
+	}
+}
+
+func TestTenantMapping(t *testing.T) {
+	type push struct {
+		tenant string
+		req    logproto.PushRequest
+	}
+	ch := make(chan push)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pushReq logproto.PushRequest
+		require.NoError(t, loki_util.ParseProtoReader(context.Background(), r.Body, int(r.ContentLength), math.MaxInt32, &pushReq, loki_util.RawSnappy))
+		ch <- push{tenant: r.Header.Get("X-Scope-OrgID"), req: pushReq}
+	}))
+	defer srv.Close()
+
+	mappingFile := filepath.Join(t.TempDir(), "tenants.yaml")
+	require.NoError(t, os.WriteFile(mappingFile, []byte("web: tenant-web\n"), 0644))
+
+	cfg := fmt.Sprintf(`
+		endpoint {
+			url        = "%s"
+			batch_wait = "10ms"
+		}
+		tenant_mapping {
+			source_label = "team"
+			file         = "%s"
+		}
+	`, srv.URL, mappingFile)
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "loki.write")
+	require.NoError(t, err)
+	go func() {
+		require.NoError(t, tc.Run(componenttest.TestContext(t), args))
+	}()
+	require.NoError(t, tc.WaitExports(time.Second))
+
+	receiver := tc.Exports().(Exports).Receiver
+	// Entries of teams which aren't in the mapping file are rejected.
+	receiver <- loki.Entry{Labels: model.LabelSet{"team": "unknown"}, Entry: logproto.Entry{Timestamp: time.Now(), Line: "rejected"}}
+	receiver <- loki.Entry{Labels: model.LabelSet{"team": "web"}, Entry: logproto.Entry{Timestamp: time.Now(), Line: "accepted"}}
+
+	select {
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "failed waiting for logs")
+	case p := <-ch:
+		require.Equal(t, "tenant-web", p.tenant)
+		require.Len(t, p.req.Streams, 1)
+		require.Equal(t, `{team="web"}`, p.req.Streams[0].Labels)
+		require.Len(t, p.req.Streams[0].Entries, 1)
+		require.Equal(t, "accepted", p.req.Streams[0].Entries[0].Line)
+	}
+}
+
+func TestObserveUsage(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	u, err := usage.NewLogs(reg)
+	require.NoError(t, err)
+	c := &Component{usage: u, endpointTenants: []string{"", "tenant-b"}}
+
+	stamp := delivery.Stamp{Source: "loki.source.file.logs", Time: time.Now()}
+	// Entries mapped to a tenant are only accounted for that tenant.
+	c.observeUsage(loki.Entry{
+		Labels: model.LabelSet{client.ReservedLabelTenantID: "tenant-a"},
+		Entry:  logproto.Entry{Line: "mapped"},
+	}, stamp)
+	c.observeUsage(loki.Entry{Entry: logproto.Entry{Line: "unmapped"}}, delivery.Stamp{})
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_usage_log_lines_total Total number of log lines forwarded, by tenant and source component.
+		# TYPE agent_usage_log_lines_total counter
+		agent_usage_log_lines_total{source="",tenant=""} 1
+		agent_usage_log_lines_total{source="",tenant="tenant-b"} 1
+		agent_usage_log_lines_total{source="loki.source.file.logs",tenant="tenant-a"} 1
+	`), "agent_usage_log_lines_total"))
+}
@@ -0,0 +1,64 @@
+package component
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArgumentMigration describes a deprecated argument of a component, and the
+// argument replacing it. The Flow controller rewrites deprecated arguments
+// to their replacements when loading a component, so that configuration
+// files can be updated after upgrading the agent.
+type ArgumentMigration struct {
+	// Path of the deprecated attribute or block, with the names of the blocks
+	// it's nested in separated by ".", such as "endpoint.bearer_token".
+	Deprecated string
+
+	// Name of the attribute or block replacing Deprecated, inside the same
+	// block as Deprecated.
+	Replacement string
+
+	// Version of the agent which deprecated the argument, such as "v0.32.0".
+	Since string
+}
+
+// ReplacementPath returns the path of the argument replacing the deprecated
+// one.
+func (m ArgumentMigration) ReplacementPath() string {
+	if i := strings.LastIndex(m.Deprecated, "."); i >= 0 {
+		return m.Deprecated[:i+1] + m.Replacement
+	}
+	return m.Replacement
+}
+
+// String returns a human-readable description of the migration.
+func (m ArgumentMigration) String() string {
+	return fmt.Sprintf("%s is deprecated since %s in favor of %s", m.Deprecated, m.Since, m.ReplacementPath())
+}
+
+// AppliedMigration is an ArgumentMigration which was applied when loading a
+// component.
+type AppliedMigration struct {
+	ArgumentMigration
+
+	// Position of the deprecated argument in the configuration file.
+	Position string
+}
+
+// validateMigrations validates the migrations of a registration.
+func validateMigrations(migrations []ArgumentMigration) error {
+	seen := make(map[string]struct{}, len(migrations))
+	for _, m := range migrations {
+		if m.Deprecated == "" || m.Replacement == "" {
+			return fmt.Errorf("argument migrations must set both Deprecated and Replacement")
+		}
+		if strings.Contains(m.Replacement, ".") {
+			return fmt.Errorf("replacement %q of %q must be in the same block", m.Replacement, m.Deprecated)
+		}
+		if _, ok := seen[m.Deprecated]; ok {
+			return fmt.Errorf("argument %q has multiple migrations", m.Deprecated)
+		}
+		seen[m.Deprecated] = struct{}{}
+	}
+	return nil
+}
@@ -1,11 +1,19 @@
 package rules
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"gopkg.in/yaml.v3"
+)
 
 type DebugInfo struct {
-	Error               string                   `river:"error,attr,optional"`
-	PrometheusRules     []DebugK8sPrometheusRule `river:"prometheus_rule,block,optional"`
-	MimirRuleNamespaces []DebugMimirNamespace    `river:"mimir_rule_namespace,block,optional"`
+	Error                  string                   `river:"error,attr,optional"`
+	PrometheusRules        []DebugK8sPrometheusRule `river:"prometheus_rule,block,optional"`
+	MimirRuleNamespaces    []DebugMimirNamespace    `river:"mimir_rule_namespace,block,optional"`
+	InvalidPrometheusRules []DebugInvalidRule       `river:"invalid_prometheus_rule,block,optional"`
+	PendingChanges         []DebugPendingChange     `river:"pending_change,block,optional"`
 }
 
 type DebugK8sPrometheusRule struct {
@@ -20,8 +28,37 @@ type DebugMimirNamespace struct {
 	NumRuleGroups int    `river:"num_rule_groups,attr"`
 }
 
+// DebugInvalidRule is a PrometheusRule resource which failed validation.
+type DebugInvalidRule struct {
+	Namespace string `river:"namespace,attr"`
+	Name      string `river:"name,attr"`
+	Error     string `river:"error,attr"`
+}
+
+// DebugPendingChange is a change to a Mimir rule group which isn't applied
+// because dry_run is enabled. Actual and Desired hold the rule group in YAML
+// before and after the change.
+type DebugPendingChange struct {
+	Namespace string `river:"namespace,attr"`
+	Group     string `river:"group,attr"`
+	Kind      string `river:"kind,attr"`
+	Actual    string `river:"actual,attr,optional"`
+	Desired   string `river:"desired,attr,optional"`
+}
+
 func (c *Component) DebugInfo() interface{} {
 	var output DebugInfo
+	c.stateMut.RLock()
+	for _, r := range c.invalidRules {
+		output.InvalidPrometheusRules = append(output.InvalidPrometheusRules, DebugInvalidRule{
+			Namespace: r.namespace,
+			Name:      r.name,
+			Error:     r.err.Error(),
+		})
+	}
+	output.PendingChanges = debugPendingChanges(c.pendingChanges)
+	c.stateMut.RUnlock()
+
 	for ns := range c.currentState {
 		if !isManagedMimirNamespace(c.args.MimirNameSpacePrefix, ns) {
 			continue
@@ -62,3 +99,38 @@ func (c *Component) DebugInfo() interface{} {
 
 	return output
 }
+
+func debugPendingChanges(diffs ruleGroupDiffsByNamespace) []DebugPendingChange {
+	namespaces := make([]string, 0, len(diffs))
+	for ns := range diffs {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	var res []DebugPendingChange
+	for _, ns := range namespaces {
+		for _, d := range diffs[ns] {
+			change := DebugPendingChange{
+				Namespace: ns,
+				Group:     d.name(),
+				Kind:      string(d.Kind),
+			}
+			if d.Kind != ruleGroupDiffKindAdd {
+				change.Actual = marshalRuleGroup(d.Actual)
+			}
+			if d.Kind != ruleGroupDiffKindRemove {
+				change.Desired = marshalRuleGroup(d.Desired)
+			}
+			res = append(res, change)
+		}
+	}
+	return res
+}
+
+func marshalRuleGroup(g rulefmt.RuleGroup) string {
+	buf, err := yaml.Marshal(g)
+	if err != nil {
+		return fmt.Sprintf("failed to marshal rule group: %v", err)
+	}
+	return string(buf)
+}
@@ -21,6 +21,14 @@ type ruleGroupDiff struct {
 	Desired rulefmt.RuleGroup
 }
 
+// name returns the name of the rule group changed by d.
+func (d ruleGroupDiff) name() string {
+	if d.Kind == ruleGroupDiffKindRemove {
+		return d.Actual.Name
+	}
+	return d.Desired.Name
+}
+
 type ruleGroupsByNamespace map[string][]rulefmt.RuleGroup
 type ruleGroupDiffsByNamespace map[string][]ruleGroupDiff
 
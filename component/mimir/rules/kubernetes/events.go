@@ -151,12 +151,19 @@ func (c *Component) reconcileState(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	desiredState, err := c.loadStateFromK8s()
+	desiredState, invalid, err := c.loadStateFromK8s()
 	if err != nil {
 		return err
 	}
+	c.setInvalidRules(invalid)
 
 	diffs := diffRuleState(desiredState, c.currentState)
+	if c.args.DryRun {
+		c.setPendingChanges(diffs)
+		return nil
+	}
+	c.setPendingChanges(nil)
+
 	var result error
 	for ns, diff := range diffs {
 		err = c.applyChanges(ctx, ns, diff)
@@ -169,40 +176,78 @@ func (c *Component) reconcileState(ctx context.Context) error {
 	return result
 }
 
-func (c *Component) loadStateFromK8s() (ruleGroupsByNamespace, error) {
+// invalidRule is a PrometheusRule resource which failed validation. The rule
+// groups of invalid resources are left as is in Mimir, so that a bad change
+// doesn't delete rules which were previously synced.
+type invalidRule struct {
+	namespace string
+	name      string
+	err       error
+}
+
+func (c *Component) loadStateFromK8s() (ruleGroupsByNamespace, []invalidRule, error) {
 	matchedNamespaces, err := c.namespaceLister.List(c.namespaceSelector)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		return nil, nil, fmt.Errorf("failed to list namespaces: %w", err)
 	}
 
 	desiredState := make(ruleGroupsByNamespace)
+	var invalid []invalidRule
 	for _, ns := range matchedNamespaces {
 		crdState, err := c.ruleLister.PrometheusRules(ns.Name).List(c.ruleSelector)
 		if err != nil {
-			return nil, fmt.Errorf("failed to list rules: %w", err)
+			return nil, nil, fmt.Errorf("failed to list rules: %w", err)
 		}
 
 		for _, pr := range crdState {
 			mimirNs := mimirNamespaceForRuleCRD(c.args.MimirNameSpacePrefix, pr)
 
-			groups, err := convertCRDRuleGroupToRuleGroup(pr.Spec)
+			groups, err := convertCRDRuleGroupToRuleGroup(pr.Spec, c.args.ExternalLabels)
 			if err != nil {
-				return nil, fmt.Errorf("failed to convert rule group: %w", err)
+				level.Warn(c.log).Log("msg", "skipping invalid PrometheusRule", "namespace", pr.Namespace, "name", pr.Name, "err", err)
+				invalid = append(invalid, invalidRule{namespace: pr.Namespace, name: pr.Name, err: err})
+				if current, ok := c.currentState[mimirNs]; ok {
+					desiredState[mimirNs] = current
+				}
+				continue
 			}
 
 			desiredState[mimirNs] = groups
 		}
 	}
 
-	return desiredState, nil
+	return desiredState, invalid, nil
 }
 
-func convertCRDRuleGroupToRuleGroup(crd promv1.PrometheusRuleSpec) ([]rulefmt.RuleGroup, error) {
+// convertCRDRuleGroupToRuleGroup converts and validates the rule groups of a
+// PrometheusRule resource. externalLabels are added to every rule which
+// doesn't already have a label of the same name.
+func convertCRDRuleGroupToRuleGroup(crd promv1.PrometheusRuleSpec, externalLabels map[string]string) ([]rulefmt.RuleGroup, error) {
+	if len(externalLabels) > 0 {
+		crd = *crd.DeepCopy()
+		for i := range crd.Groups {
+			for j := range crd.Groups[i].Rules {
+				rule := &crd.Groups[i].Rules[j]
+				for name, value := range externalLabels {
+					if _, ok := rule.Labels[name]; ok {
+						continue
+					}
+					if rule.Labels == nil {
+						rule.Labels = make(map[string]string, len(externalLabels))
+					}
+					rule.Labels[name] = value
+				}
+			}
+		}
+	}
+
 	buf, err := yaml.Marshal(crd)
 	if err != nil {
 		return nil, err
 	}
 
+	// Parsing validates the rules, including their PromQL expressions, so
+	// that invalid rules are never sent to the ruler.
 	groups, errs := rulefmt.Parse(buf)
 	if len(errs) > 0 {
 		return nil, multierror.Append(nil, errs...)
@@ -211,6 +256,28 @@ func convertCRDRuleGroupToRuleGroup(crd promv1.PrometheusRuleSpec) ([]rulefmt.Ru
 	return groups.Groups, nil
 }
 
+func (c *Component) setInvalidRules(invalid []invalidRule) {
+	c.stateMut.Lock()
+	defer c.stateMut.Unlock()
+	c.invalidRules = invalid
+	c.metrics.invalidRules.Set(float64(len(invalid)))
+}
+
+func (c *Component) setPendingChanges(diffs ruleGroupDiffsByNamespace) {
+	c.stateMut.Lock()
+	defer c.stateMut.Unlock()
+	c.pendingChanges = diffs
+
+	var n int
+	for ns, diff := range diffs {
+		n += len(diff)
+		for _, d := range diff {
+			level.Debug(c.log).Log("msg", "dry run: not applying rule group change", "namespace", ns, "group", d.name(), "kind", d.Kind)
+		}
+	}
+	c.metrics.pendingChanges.Set(float64(n))
+}
+
 func (c *Component) applyChanges(ctx context.Context, namespace string, diffs []ruleGroupDiff) error {
 	if len(diffs) == 0 {
 		return nil
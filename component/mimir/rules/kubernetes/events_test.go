@@ -183,3 +183,83 @@ func TestEventLoop(t *testing.T) {
 		return len(rules) == 0
 	}, time.Second, 10*time.Millisecond)
 }
+
+func TestReconcile_ValidationAndDryRun(t *testing.T) {
+	nsIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	ruleIndexer := cache.NewIndexer(
+		cache.DeletionHandlingMetaNamespaceKeyFunc,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+	require.NoError(t, nsIndexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace"}}))
+
+	newRule := func(name, uid, expr string) *v1.PrometheusRule {
+		return &v1.PrometheusRule{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "namespace", UID: types.UID(uid)},
+			Spec: v1.PrometheusRuleSpec{
+				Groups: []v1.RuleGroup{{
+					Name: "group",
+					Rules: []v1.Rule{
+						{Alert: "alert", Expr: intstr.FromString(expr)},
+						{Alert: "labeled", Expr: intstr.FromString(expr), Labels: map[string]string{"cluster": "own"}},
+					},
+				}},
+			},
+		}
+	}
+	valid := newRule("valid", "64aab764-c95e-4ee9-a932-cd63ba57e6cf", "up == 0")
+	invalid := newRule("invalid", "7e5f1c36-0d52-4c4e-8d5b-3f0a3c1f9d2e", "up ==")
+	require.NoError(t, ruleIndexer.Add(valid))
+	require.NoError(t, ruleIndexer.Add(invalid))
+
+	mimir := newFakeMimirClient()
+	invalidNs := mimirNamespaceForRuleCRD("agent", invalid)
+	previous := rulefmt.RuleGroup{Name: "previous"}
+	require.NoError(t, mimir.CreateRuleGroup(context.Background(), invalidNs, previous))
+
+	component := Component{
+		log:               log.NewNopLogger(),
+		namespaceLister:   coreListers.NewNamespaceLister(nsIndexer),
+		namespaceSelector: labels.Everything(),
+		ruleLister:        promListers.NewPrometheusRuleLister(ruleIndexer),
+		ruleSelector:      labels.Everything(),
+		mimirClient:       mimir,
+		args: Arguments{
+			MimirNameSpacePrefix: "agent",
+			ExternalLabels:       map[string]string{"cluster": "prod"},
+			DryRun:               true,
+		},
+		metrics: newMetrics(),
+	}
+	ctx := context.Background()
+	require.NoError(t, component.syncMimir(ctx))
+
+	// In dry run mode, changes are only reported.
+	require.NoError(t, component.reconcileState(ctx))
+	info := component.DebugInfo().(DebugInfo)
+	require.Len(t, info.PendingChanges, 1)
+	require.Equal(t, "add", info.PendingChanges[0].Kind)
+	require.Contains(t, info.PendingChanges[0].Desired, "cluster: prod")
+	require.Len(t, info.InvalidPrometheusRules, 1)
+	require.Equal(t, "invalid", info.InvalidPrometheusRules[0].Name)
+	rules, err := mimir.ListRules(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	// Without dry run, valid rules are synced with external labels which
+	// don't override their own labels, and the rule groups of invalid rules
+	// are kept as is.
+	component.args.DryRun = false
+	require.NoError(t, component.reconcileState(ctx))
+	require.Empty(t, component.DebugInfo().(DebugInfo).PendingChanges)
+	rules, err = mimir.ListRules(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, []rulefmt.RuleGroup{previous}, rules[invalidNs])
+
+	synced := rules[mimirNamespaceForRuleCRD("agent", valid)]
+	require.Len(t, synced, 1)
+	require.Equal(t, "prod", synced[0].Rules[0].Labels["cluster"])
+	require.Equal(t, "own", synced[0].Rules[1].Labels["cluster"])
+}
@@ -62,6 +62,11 @@ type Component struct {
 
 	currentState ruleGroupsByNamespace
 
+	// Results of the last reconciliation, exposed as debug information.
+	stateMut       sync.RWMutex
+	invalidRules   []invalidRule
+	pendingChanges ruleGroupDiffsByNamespace // Only set in dry run mode.
+
 	metrics   *metrics
 	healthMut sync.RWMutex
 	health    component.Health
@@ -74,6 +79,9 @@ type metrics struct {
 	eventsFailed  *prometheus.CounterVec
 	eventsRetried *prometheus.CounterVec
 
+	invalidRules   prometheus.Gauge
+	pendingChanges prometheus.Gauge
+
 	mimirClientTiming *prometheus.HistogramVec
 }
 
@@ -83,6 +91,8 @@ func (m *metrics) Register(r prometheus.Registerer) error {
 		m.eventsTotal,
 		m.eventsFailed,
 		m.eventsRetried,
+		m.invalidRules,
+		m.pendingChanges,
 		m.mimirClientTiming,
 	)
 	return nil
@@ -110,6 +120,16 @@ func newMetrics() *metrics {
 			Name:      "events_retried_total",
 			Help:      "Total number of retries across all events, partitioned by event type.",
 		}, []string{"type"}),
+		invalidRules: prometheus.NewGauge(prometheus.GaugeOpts{
+			Subsystem: "mimir_rules",
+			Name:      "invalid_prometheus_rules",
+			Help:      "Number of PrometheusRule resources which failed validation and aren't synced.",
+		}),
+		pendingChanges: prometheus.NewGauge(prometheus.GaugeOpts{
+			Subsystem: "mimir_rules",
+			Name:      "dry_run_pending_changes",
+			Help:      "Number of rule group changes which would be applied if dry_run was disabled.",
+		}),
 		mimirClientTiming: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 			Subsystem: "mimir_rules",
 			Name:      "mimir_client_request_duration_seconds",
@@ -40,3 +40,14 @@ func TestBadRiverConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func TestBadExternalLabels(t *testing.T) {
+	var exampleRiverConfig = `
+	address = "GRAFANA_CLOUD_METRICS_URL"
+	external_labels = {"cluster-name" = "prod"}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, `invalid external label name "cluster-name"`)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/grafana/agent/component/common/config"
+	"github.com/prometheus/common/model"
 )
 
 type Arguments struct {
@@ -14,6 +15,8 @@ type Arguments struct {
 	HTTPClientConfig     config.HTTPClientConfig `river:",squash"`
 	SyncInterval         time.Duration           `river:"sync_interval,attr,optional"`
 	MimirNameSpacePrefix string                  `river:"mimir_namespace_prefix,attr,optional"`
+	ExternalLabels       map[string]string       `river:"external_labels,attr,optional"`
+	DryRun               bool                    `river:"dry_run,attr,optional"`
 
 	RuleSelector          LabelSelector `river:"rule_selector,block,optional"`
 	RuleNamespaceSelector LabelSelector `river:"rule_namespace_selector,block,optional"`
@@ -38,6 +41,11 @@ func (args *Arguments) Validate() error {
 	if args.MimirNameSpacePrefix == "" {
 		return fmt.Errorf("mimir_namespace_prefix must not be empty")
 	}
+	for name := range args.ExternalLabels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid external label name %q", name)
+		}
+	}
 
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	return args.HTTPClientConfig.Validate()
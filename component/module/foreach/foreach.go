@@ -0,0 +1,303 @@
+package foreach
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"go.uber.org/multierr"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "module.foreach",
+		Args:    Arguments{},
+		Exports: Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the module.foreach
+// component.
+type Arguments struct {
+	// Collection holds one element per module instance to run. It must be a
+	// list or an object.
+	Collection any `river:"collection,attr"`
+
+	// Content to load for each module instance.
+	Content rivertypes.OptionalSecret `river:"content,attr"`
+
+	// Var is the name of the argument each instance receives its element of
+	// the collection in.
+	Var string `river:"var,attr,optional"`
+
+	// KeyVar is the name of the argument each instance receives the key of
+	// its element in, if set.
+	KeyVar string `river:"key_var,attr,optional"`
+
+	// Arguments to pass into every module instance.
+	Arguments map[string]any `river:"arguments,block,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Var: "item",
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.Var == "" {
+		return fmt.Errorf("var must not be empty")
+	}
+	if args.KeyVar == args.Var {
+		return fmt.Errorf("key_var must be different from var")
+	}
+	for _, name := range []string{args.Var, args.KeyVar} {
+		if _, ok := args.Arguments[name]; ok && name != "" {
+			return fmt.Errorf("argument %q is reserved for the elements of the collection", name)
+		}
+	}
+	_, err := elements(args.Collection)
+	return err
+}
+
+// Exports holds values which are exported from the run module instances.
+type Exports struct {
+	// Exports of each module instance, by the key of its element in the
+	// collection.
+	Exports map[string]map[string]any `river:"exports,attr"`
+}
+
+// Component implements the module.foreach component.
+type Component struct {
+	opts component.Options
+
+	mut       sync.Mutex
+	ctx       context.Context // Set once the component is running.
+	instances map[string]*instance
+	health    component.Health
+
+	exportsMut sync.Mutex
+	exports    map[string]map[string]any
+}
+
+// instance is a module instance run for one element of the collection.
+type instance struct {
+	mod    component.Module
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New creates a new module.foreach component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:      o,
+		instances: make(map[string]*instance),
+		exports:   make(map[string]map[string]any),
+	}
+	c.publishExports()
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	c.mut.Lock()
+	c.ctx = ctx
+	for _, inst := range c.instances {
+		c.start(inst)
+	}
+	c.mut.Unlock()
+
+	<-ctx.Done()
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for key := range c.instances {
+		c.stop(key)
+	}
+	return nil
+}
+
+// Update implements component.Component. Module instances are started for new
+// elements of the collection and stopped for removed ones. Existing instances
+// are reloaded with their current element, so that their components keep
+// running.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	elems, err := elements(newArgs.Collection)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for key := range c.instances {
+		if _, ok := elems[key]; !ok {
+			c.stop(key)
+		}
+	}
+
+	var errs error
+	for _, key := range sortedKeys(elems) {
+		inst, ok := c.instances[key]
+		if !ok {
+			key := key
+			mod, err := c.opts.ModuleController.NewModule(key, func(exports map[string]any) {
+				c.setExports(key, exports)
+			})
+			if err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("instance %q: %w", key, err))
+				continue
+			}
+			inst = &instance{mod: mod}
+			c.instances[key] = inst
+		}
+
+		if err := inst.mod.LoadConfig([]byte(newArgs.Content.Value), instanceArguments(newArgs, key, elems[key])); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("instance %q: %w", key, err))
+		}
+		if c.ctx != nil && inst.cancel == nil {
+			c.start(inst)
+		}
+	}
+
+	if errs != nil {
+		c.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    fmt.Sprintf("failed to load module content: %s", errs),
+			UpdateTime: time.Now(),
+		}
+		return errs
+	}
+	c.health = component.Health{
+		Health:     component.HealthTypeHealthy,
+		Message:    fmt.Sprintf("module content loaded for %d instances", len(c.instances)),
+		UpdateTime: time.Now(),
+	}
+	return nil
+}
+
+// start runs inst until the component stops running or inst is removed.
+// c.mut must be held.
+func (c *Component) start(inst *instance) {
+	ctx, cancel := context.WithCancel(c.ctx)
+	inst.cancel = cancel
+	inst.done = make(chan struct{})
+
+	go func() {
+		defer close(inst.done)
+		inst.mod.Run(ctx)
+	}()
+}
+
+// stop stops the instance for key and removes it along with its exports.
+// c.mut must be held.
+func (c *Component) stop(key string) {
+	inst := c.instances[key]
+	delete(c.instances, key)
+
+	if inst.cancel != nil {
+		inst.cancel()
+		<-inst.done
+	} else {
+		// Instances which never ran must still be run to release their ID, so
+		// that the key can be reused later.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		inst.mod.Run(ctx)
+	}
+
+	c.exportsMut.Lock()
+	delete(c.exports, key)
+	c.exportsMut.Unlock()
+	c.publishExports()
+}
+
+func (c *Component) setExports(key string, exports map[string]any) {
+	c.exportsMut.Lock()
+	c.exports[key] = exports
+	c.exportsMut.Unlock()
+	c.publishExports()
+}
+
+func (c *Component) publishExports() {
+	c.exportsMut.Lock()
+	defer c.exportsMut.Unlock()
+
+	exports := make(map[string]map[string]any, len(c.exports))
+	for key, e := range c.exports {
+		exports[key] = e
+	}
+	c.opts.OnStateChange(Exports{Exports: exports})
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.health
+}
+
+// instanceArguments returns the arguments of the instance for the element
+// elem with the given key.
+func instanceArguments(args Arguments, key string, elem any) map[string]any {
+	res := make(map[string]any, len(args.Arguments)+2)
+	for name, value := range args.Arguments {
+		res[name] = value
+	}
+	res[args.Var] = elem
+	if args.KeyVar != "" {
+		res[args.KeyVar] = key
+	}
+	return res
+}
+
+// elements returns the elements of collection by their key. The key of list
+// elements is their index.
+func elements(collection any) (map[string]any, error) {
+	switch collection := collection.(type) {
+	case []any:
+		res := make(map[string]any, len(collection))
+		for i, elem := range collection {
+			res[strconv.Itoa(i)] = elem
+		}
+		return res, nil
+	case map[string]any:
+		return collection, nil
+	default:
+		return nil, fmt.Errorf("collection must be a list or an object, got %T", collection)
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,198 @@
+package foreach_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	_ "github.com/grafana/agent/component/local/file"
+	"github.com/grafana/agent/component/module/foreach"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+// instanceContent exports the element and key each instance receives.
+const instanceContent = `
+	argument "item" {}
+	argument "key" {
+		optional = true
+		default  = ""
+	}
+	argument "suffix" {
+		optional = true
+		default  = ""
+	}
+
+	export "name" {
+		value = argument.item.value + argument.suffix.value
+	}
+	export "key" {
+		value = argument.key.value
+	}
+`
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "list",
+			cfg: `
+				collection = ["a", "b"]
+				content    = ""
+			`,
+		},
+		{
+			name: "object",
+			cfg: `
+				collection = { a = 1, b = 2 }
+				content    = ""
+				key_var    = "key"
+			`,
+		},
+		{
+			name: "invalid collection",
+			cfg: `
+				collection = "a"
+				content    = ""
+			`,
+			expectedErr: "collection must be a list or an object, got string",
+		},
+		{
+			name: "same key_var and var",
+			cfg: `
+				collection = []
+				content    = ""
+				key_var    = "item"
+			`,
+			expectedErr: "key_var must be different from var",
+		},
+		{
+			name: "reserved argument",
+			cfg: `
+				collection = []
+				content    = ""
+				arguments {
+					item = "a"
+				}
+			`,
+			expectedErr: `argument "item" is reserved for the elements of the collection`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args foreach.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestForeach(t *testing.T) {
+	s, err := logging.New(os.Stderr, logging.DefaultOptions)
+	require.NoError(t, err)
+	f := flow.New(flow.Options{
+		Logger:    s,
+		DataPath:  t.TempDir(),
+		Clusterer: &cluster.Clusterer{Node: cluster.NewLocalNode("")},
+	})
+
+	load := func(collection string) {
+		cfg := `
+			local.file "content" {
+				filename = "` + writeContent(t) + `"
+			}
+
+			module.foreach "test" {
+				collection = ` + collection + `
+				content    = local.file.content.content
+				key_var    = "key"
+
+				arguments {
+					suffix = "!"
+				}
+			}
+		`
+		ff, err := flow.ReadFile("config.river", []byte(cfg))
+		require.NoError(t, err)
+		require.NoError(t, f.LoadFile(ff, nil))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	load(`["a", "b"]`)
+	go f.Run(ctx)
+
+	requireExports(t, f, map[string]map[string]any{
+		"0": {"name": "a!", "key": "0"},
+		"1": {"name": "b!", "key": "1"},
+	})
+
+	// Removed elements stop their instance, and existing instances are
+	// reloaded with their new element.
+	load(`["c"]`)
+	requireExports(t, f, map[string]map[string]any{
+		"0": {"name": "c!", "key": "0"},
+	})
+
+	load(`{ first = "d", second = "e" }`)
+	requireExports(t, f, map[string]map[string]any{
+		"first":  {"name": "d!", "key": "first"},
+		"second": {"name": "e!", "key": "second"},
+	})
+}
+
+func writeContent(t *testing.T) string {
+	t.Helper()
+
+	path := t.TempDir() + "/content.river"
+	require.NoError(t, os.WriteFile(path, []byte(instanceContent), 0644))
+	return path
+}
+
+func requireExports(t *testing.T, f *flow.Flow, expected map[string]map[string]any) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		info, err := f.GetComponent(component.ID{LocalID: "module.foreach.test"}, component.InfoOptions{GetExports: true})
+		if err != nil {
+			return false
+		}
+		exports, ok := info.Exports.(foreach.Exports)
+		if !ok {
+			return false
+		}
+		return equalExports(expected, exports.Exports)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func equalExports(expected, actual map[string]map[string]any) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for key, e := range expected {
+		a, ok := actual[key]
+		if !ok || len(e) != len(a) {
+			return false
+		}
+		for name, value := range e {
+			if a[name] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -3,6 +3,8 @@ package git
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"path/filepath"
 	"reflect"
@@ -14,6 +16,7 @@ import (
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/module"
 	"github.com/grafana/agent/component/module/git/internal/vcs"
+	"github.com/grafana/dskit/backoff"
 )
 
 func init() {
@@ -34,15 +37,22 @@ type Arguments struct {
 	Revision   string `river:"revision,attr,optional"`
 	Path       string `river:"path,attr"`
 
-	PullFrequency time.Duration `river:"pull_frequency,attr,optional"`
+	ExpectedCommit string `river:"expected_commit,attr,optional"`
+	Shallow        bool   `river:"shallow,attr,optional"`
+
+	PullFrequency    time.Duration `river:"pull_frequency,attr,optional"`
+	MinBackoffPeriod time.Duration `river:"min_backoff_period,attr,optional"`
+	MaxBackoffPeriod time.Duration `river:"max_backoff_period,attr,optional"`
 
 	Arguments map[string]any `river:"arguments,block,optional"`
 }
 
 // DefaultArguments holds default settings for Arguments.
 var DefaultArguments = Arguments{
-	Revision:      "HEAD",
-	PullFrequency: time.Minute,
+	Revision:         "HEAD",
+	PullFrequency:    time.Minute,
+	MinBackoffPeriod: 5 * time.Second,
+	MaxBackoffPeriod: 5 * time.Minute,
 }
 
 // SetToDefault implements river.Defaulter.
@@ -50,6 +60,17 @@ func (args *Arguments) SetToDefault() {
 	*args = DefaultArguments
 }
 
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.MinBackoffPeriod <= 0 {
+		return fmt.Errorf("min_backoff_period must be greater than zero")
+	}
+	if args.MaxBackoffPeriod < args.MinBackoffPeriod {
+		return fmt.Errorf("max_backoff_period must not be less than min_backoff_period")
+	}
+	return nil
+}
+
 // Component implements the module.git component.
 type Component struct {
 	opts component.Options
@@ -61,6 +82,10 @@ type Component struct {
 	repoOpts vcs.GitRepoOptions
 	args     Arguments
 
+	// syncFailed is set when the last sync with the repository failed, so
+	// that it's retried with backoff.
+	syncFailed bool
+
 	argsChanged chan struct{}
 
 	healthMut sync.RWMutex
@@ -102,9 +127,43 @@ func (c *Component) Run(ctx context.Context) error {
 	go c.mod.RunFlowController(ctx)
 
 	var (
-		ticker  *time.Ticker
-		tickerC <-chan time.Time
+		timer   *time.Timer
+		timerC  <-chan time.Time
+		retries *backoff.Backoff
 	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	// schedule schedules the next sync of the repository. Failed syncs are
+	// retried with backoff, regardless of the pull frequency.
+	schedule := func() {
+		c.mut.RLock()
+		args, failed := c.args, c.syncFailed
+		c.mut.RUnlock()
+
+		var next time.Duration
+		switch {
+		case failed:
+			next = retries.NextDelay()
+		case args.PullFrequency > 0:
+			retries.Reset()
+			next = args.PullFrequency
+		default:
+			retries.Reset()
+			timerC = nil
+			return
+		}
+
+		if timer == nil {
+			timer = time.NewTimer(next)
+		} else {
+			timer.Reset(next)
+		}
+		timerC = timer.C
+	}
 
 	for {
 		select {
@@ -112,43 +171,53 @@ func (c *Component) Run(ctx context.Context) error {
 			return nil
 
 		case <-c.argsChanged:
-			c.mut.Lock()
-			{
-				level.Info(c.log).Log("msg", "updating repository pull frequency", "new_frequency", c.args.PullFrequency)
-
-				if c.args.PullFrequency > 0 {
-					if ticker == nil {
-						ticker = time.NewTicker(c.args.PullFrequency)
-						tickerC = ticker.C
-					} else {
-						ticker.Reset(c.args.PullFrequency)
-					}
-				} else {
-					if ticker != nil {
-						ticker.Stop()
-					}
-					ticker = nil
-					tickerC = nil
+			c.mut.RLock()
+			args := c.args
+			c.mut.RUnlock()
+
+			level.Info(c.log).Log("msg", "updating repository pull frequency", "new_frequency", args.PullFrequency)
+
+			retries = backoff.New(ctx, backoff.Config{
+				MinBackoff: args.MinBackoffPeriod,
+				MaxBackoff: args.MaxBackoffPeriod,
+			})
+			if timer != nil && !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
 				}
 			}
-			c.mut.Unlock()
+			schedule()
 
-		case <-tickerC:
+		case <-timerC:
 			level.Info(c.log).Log("msg", "updating repository", "new_frequency", c.args.PullFrequency)
 			c.tickPollFile(ctx)
+			schedule()
 		}
 	}
 }
 
 func (c *Component) tickPollFile(ctx context.Context) {
 	c.mut.Lock()
-	err := c.pollFile(ctx, c.args)
+	err := c.sync(ctx, c.args)
 	c.mut.Unlock()
 
 	c.updateHealth(err)
 }
 
 func (c *Component) updateHealth(err error) {
+	// Module content loaded from the cached clone after a failed update is
+	// reported along with the revision still in use.
+	var updateErr vcs.UpdateFailedError
+	if errors.As(err, &updateErr) {
+		c.mut.RLock()
+		rev, revErr := c.repo.CurrentRevision()
+		c.mut.RUnlock()
+		if revErr == nil {
+			err = fmt.Errorf("using cached revision %s: %w", rev, err)
+		}
+	}
+
 	c.healthMut.Lock()
 	defer c.healthMut.Unlock()
 
@@ -168,9 +237,19 @@ func (c *Component) updateHealth(err error) {
 }
 
 // Update implements component.Component.
+//
+// If the repository can't be updated but a previous clone of it exists,
+// Update loads the module content from that clone and doesn't fail; the
+// failure is reported in the health of the component instead, and the
+// update is retried with backoff.
 func (c *Component) Update(args component.Arguments) (err error) {
+	var syncErr error
 	defer func() {
-		c.updateHealth(err)
+		if err == nil {
+			c.updateHealth(syncErr)
+		} else {
+			c.updateHealth(err)
+		}
 	}()
 
 	c.mut.Lock()
@@ -178,14 +257,16 @@ func (c *Component) Update(args component.Arguments) (err error) {
 
 	newArgs := args.(Arguments)
 
-	// TODO(rfratto): store in a repo-specific directory so changing repositories
-	// doesn't risk break the module loader if there's a SHA collision between
-	// the two different repositories.
+	// The clone is kept across restarts so that the module can be loaded while
+	// the repository is unreachable. NewGitRepo clones the repository again if
+	// the clone on disk is of a different repository.
 	repoPath := filepath.Join(c.opts.DataPath, "repo")
 
 	repoOpts := vcs.GitRepoOptions{
-		Repository: newArgs.Repository,
-		Revision:   newArgs.Revision,
+		Repository:     newArgs.Repository,
+		Revision:       newArgs.Revision,
+		ExpectedCommit: newArgs.ExpectedCommit,
+		Shallow:        newArgs.Shallow,
 	}
 
 	// Create or update the repo field.
@@ -198,8 +279,9 @@ func (c *Component) Update(args component.Arguments) (err error) {
 		c.repoOpts = repoOpts
 	}
 
-	if err := c.pollFile(context.Background(), newArgs); err != nil {
-		return err
+	syncErr = c.sync(context.Background(), newArgs)
+	if !isUpdateFailed(syncErr) {
+		return syncErr
 	}
 
 	// Schedule an update for handling the changed arguments.
@@ -212,12 +294,27 @@ func (c *Component) Update(args component.Arguments) (err error) {
 	return nil
 }
 
+// sync calls pollFile and records whether it failed. sync must only be called
+// with c.mut held.
+func (c *Component) sync(ctx context.Context, args Arguments) error {
+	err := c.pollFile(ctx, args)
+	c.syncFailed = err != nil
+	if isUpdateFailed(err) {
+		level.Warn(c.log).Log("msg", "failed to update repository, using cached revision", "err", err)
+	}
+	return err
+}
+
 // pollFile fetches the latest content from the repository and updates the
 // controller. pollFile must only be called with c.mut held.
+//
+// If fetching fails with a vcs.UpdateFailedError, the controller is still
+// updated from the local clone and the error is returned afterwards.
 func (c *Component) pollFile(ctx context.Context, args Arguments) error {
 	// Make sure our repo is up-to-date.
-	if err := c.repo.Update(ctx); err != nil {
-		return err
+	updateErr := c.repo.Update(ctx)
+	if updateErr != nil && !isUpdateFailed(updateErr) {
+		return updateErr
 	}
 
 	// Finally, configure our controller.
@@ -226,7 +323,15 @@ func (c *Component) pollFile(ctx context.Context, args Arguments) error {
 		return err
 	}
 
-	return c.mod.LoadFlowContent(args.Arguments, string(bb))
+	if err := c.mod.LoadFlowContent(args.Arguments, string(bb)); err != nil {
+		return err
+	}
+	return updateErr
+}
+
+func isUpdateFailed(err error) bool {
+	var updateErr vcs.UpdateFailedError
+	return errors.As(err, &updateErr)
 }
 
 // CurrentHealth implements component.HealthComponent.
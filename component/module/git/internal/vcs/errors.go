@@ -45,3 +45,26 @@ type InvalidRevisionError struct {
 func (err InvalidRevisionError) Error() string {
 	return fmt.Sprintf("invalid revision %s", err.Revision)
 }
+
+// RevisionMismatchError represents a revision which doesn't resolve to the
+// expected commit.
+type RevisionMismatchError struct {
+	Revision string
+	Expected string
+	Actual   string
+}
+
+// Error returns the error string, denoting the expected and actual commits.
+func (err RevisionMismatchError) Error() string {
+	return fmt.Sprintf("revision %s resolved to commit %s, expected %s", err.Revision, err.Actual, err.Expected)
+}
+
+// ShallowRevisionError represents a revision which can't be cloned shallowly.
+type ShallowRevisionError struct {
+	Revision string
+}
+
+// Error returns the error string, denoting the revision.
+func (err ShallowRevisionError) Error() string {
+	return fmt.Sprintf("revision %s is not a branch or a tag of the repository, which shallow clones require", err.Revision)
+}
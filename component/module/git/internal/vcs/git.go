@@ -6,89 +6,88 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
 )
 
 type GitRepoOptions struct {
 	Repository string
 	Revision   string
+
+	// ExpectedCommit, if set, is the SHA of the commit Revision must resolve
+	// to. Abbreviated SHAs are allowed. The repository is never checked out to
+	// other commits.
+	ExpectedCommit string
+
+	// Shallow only downloads the commit Revision points to, instead of the
+	// full history of the repository. Revision must then be HEAD, a branch, or
+	// a tag.
+	Shallow bool
 }
 
 // GitRepo manages a Git repository for the purposes of retrieving a file from
 // it.
 type GitRepo struct {
-	opts     GitRepoOptions
-	repo     *git.Repository
-	workTree *git.Worktree
+	opts        GitRepoOptions
+	storagePath string
+	repo        *git.Repository
+	workTree    *git.Worktree
 }
 
 // NewGitRepo creates a new instance of a GitRepo, where the Git repository is
 // managed at storagePath.
 //
-// If storagePath is empty on disk, NewGitRepo initializes GitRepo by cloning
-// the repository. Otherwise, NewGitRepo will do a fetch.
-//
-// After GitRepo is initialized, it checks out to the Revision specified in
-// GitRepoOptions.
+// If storagePath is empty on disk, or holds a clone of another repository,
+// NewGitRepo initializes GitRepo by cloning the repository and checking it
+// out to the Revision specified in GitRepoOptions. Otherwise, the existing
+// clone is used as is, so that the repository can still be read while its
+// remote is unreachable; call Update to fetch the latest contents.
 func NewGitRepo(ctx context.Context, storagePath string, opts GitRepoOptions) (*GitRepo, error) {
-	var (
-		repo *git.Repository
-		err  error
-	)
-
-	if !isRepoCloned(storagePath) {
-		repo, err = git.PlainCloneContext(ctx, storagePath, false, &git.CloneOptions{
-			URL:               opts.Repository,
-			ReferenceName:     plumbing.HEAD,
-			RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
-			Tags:              git.AllTags,
-		})
-	} else {
-		repo, err = git.PlainOpen(storagePath)
+	if isRepoCloned(storagePath) && !isClonedFrom(storagePath, opts) {
+		if err := os.RemoveAll(storagePath); err != nil {
+			return nil, err
+		}
 	}
-	if err != nil {
-		return nil, DownloadFailedError{
-			Repository: opts.Repository,
-			Inner:      err,
+
+	if isRepoCloned(storagePath) {
+		repo, err := git.PlainOpen(storagePath)
+		if err != nil {
+			return nil, err
 		}
+		return newGitRepo(storagePath, repo, opts)
 	}
 
-	// Fetch the latest contents. This may be a no-op if we just did a clone.
-	err = repo.FetchContext(ctx, &git.FetchOptions{
-		RemoteName: "origin",
-		Force:      true,
-	})
-	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
-		return nil, UpdateFailedError{
+	repo, err := clone(ctx, storagePath, opts)
+	if err != nil {
+		return nil, DownloadFailedError{
 			Repository: opts.Repository,
 			Inner:      err,
 		}
 	}
-
-	// Finally, hard reset to our requested revision.
-	hash, err := findRevision(opts.Revision, repo)
+	r, err := newGitRepo(storagePath, repo, opts)
 	if err != nil {
-		return nil, InvalidRevisionError{Revision: opts.Revision}
+		return nil, err
 	}
-
-	workTree, err := repo.Worktree()
-	if err != nil {
+	if err := r.checkout(); err != nil {
 		return nil, err
 	}
-	err = workTree.Reset(&git.ResetOptions{
-		Commit: hash,
-		Mode:   git.HardReset,
-	})
+	return r, nil
+}
+
+func newGitRepo(storagePath string, repo *git.Repository, opts GitRepoOptions) (*GitRepo, error) {
+	workTree, err := repo.Worktree()
 	if err != nil {
 		return nil, err
 	}
-
 	return &GitRepo{
-		opts:     opts,
-		repo:     repo,
-		workTree: workTree,
+		opts:        opts,
+		storagePath: storagePath,
+		repo:        repo,
+		workTree:    workTree,
 	}, nil
 }
 
@@ -97,35 +96,166 @@ func isRepoCloned(dir string) bool {
 	return dirError == nil && len(fi) > 0
 }
 
+// isClonedFrom returns true if dir holds a clone of the repository from opts,
+// made with the same depth.
+func isClonedFrom(dir string, opts GitRepoOptions) bool {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return false
+	}
+	remote, err := repo.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 || remote.Config().URLs[0] != opts.Repository {
+		return false
+	}
+
+	_, err = os.Stat(filepath.Join(dir, git.GitDirName, "shallow"))
+	return opts.Shallow == (err == nil)
+}
+
+// clone clones the repository from opts into dir.
+func clone(ctx context.Context, dir string, opts GitRepoOptions) (*git.Repository, error) {
+	if !opts.Shallow {
+		return git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+			URL:               opts.Repository,
+			ReferenceName:     plumbing.HEAD,
+			RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+			Tags:              git.AllTags,
+		})
+	}
+
+	ref, err := remoteReference(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return git.PlainCloneContext(ctx, dir, false, &git.CloneOptions{
+		URL:               opts.Repository,
+		ReferenceName:     ref,
+		SingleBranch:      true,
+		Depth:             1,
+		RecurseSubmodules: git.DefaultSubmoduleRecursionDepth,
+		Tags:              git.NoTags,
+	})
+}
+
+// remoteReference returns the name of the reference of the remote repository
+// which Revision names.
+func remoteReference(ctx context.Context, opts GitRepoOptions) (plumbing.ReferenceName, error) {
+	if opts.Revision == "" || opts.Revision == plumbing.HEAD.String() {
+		return plumbing.HEAD, nil
+	}
+
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{opts.Repository},
+	})
+	refs, err := remote.ListContext(ctx, &git.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	candidates := []plumbing.ReferenceName{
+		plumbing.NewBranchReferenceName(opts.Revision),
+		plumbing.NewTagReferenceName(opts.Revision),
+		plumbing.ReferenceName(opts.Revision),
+	}
+	for _, name := range candidates {
+		for _, ref := range refs {
+			if ref.Name() == name {
+				return name, nil
+			}
+		}
+	}
+	return "", ShallowRevisionError{Revision: opts.Revision}
+}
+
 // Update updates the repository by fetching new content and re-checking out to
 // latest version of Revision.
+//
+// If the repository can't be fetched, Update still checks out to Revision if
+// it's available locally and returns an UpdateFailedError. The files of the
+// repository can then still be read.
 func (repo *GitRepo) Update(ctx context.Context) error {
+	var updateErr error
+	if err := repo.fetch(ctx); err != nil {
+		updateErr = UpdateFailedError{
+			Repository: repo.opts.Repository,
+			Inner:      err,
+		}
+	}
+
+	if err := repo.checkout(); err != nil {
+		return err
+	}
+	return updateErr
+}
+
+func (repo *GitRepo) fetch(ctx context.Context) error {
+	if repo.opts.Shallow {
+		return repo.reclone(ctx)
+	}
+
 	err := repo.repo.FetchContext(ctx, &git.FetchOptions{
 		RemoteName: "origin",
 		Force:      true,
 	})
 	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
-		return UpdateFailedError{
-			Repository: repo.opts.Repository,
-			Inner:      err,
-		}
+		return err
 	}
+	return nil
+}
 
-	// Find the latest revision being requested and hard-reset to it.
+// reclone replaces a shallow clone with a new one. Shallow clones can't be
+// fetched into reliably, so each update downloads the latest commit again.
+// The previous clone is kept if the new one fails.
+func (repo *GitRepo) reclone(ctx context.Context) error {
+	tmpPath := repo.storagePath + ".new"
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return err
+	}
+	if _, err := clone(ctx, tmpPath, repo.opts); err != nil {
+		_ = os.RemoveAll(tmpPath)
+		return err
+	}
+
+	if err := os.RemoveAll(repo.storagePath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, repo.storagePath); err != nil {
+		return err
+	}
+
+	r, err := git.PlainOpen(repo.storagePath)
+	if err != nil {
+		return err
+	}
+	workTree, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+	repo.repo, repo.workTree = r, workTree
+	return nil
+}
+
+// checkout hard resets the repository to Revision, after checking that it
+// resolves to ExpectedCommit.
+func (repo *GitRepo) checkout() error {
 	hash, err := findRevision(repo.opts.Revision, repo.repo)
 	if err != nil {
 		return InvalidRevisionError{Revision: repo.opts.Revision}
 	}
 
-	err = repo.workTree.Reset(&git.ResetOptions{
+	if expected := strings.ToLower(repo.opts.ExpectedCommit); expected != "" && !strings.HasPrefix(hash.String(), expected) {
+		return RevisionMismatchError{
+			Revision: repo.opts.Revision,
+			Expected: repo.opts.ExpectedCommit,
+			Actual:   hash.String(),
+		}
+	}
+
+	return repo.workTree.Reset(&git.ResetOptions{
 		Commit: hash,
 		Mode:   git.HardReset,
 	})
-	if err != nil {
-		return err
-	}
-
-	return nil
 }
 
 // ReadFile returns a file from the repository specified by path.
@@ -156,6 +286,12 @@ func findRevision(rev string, repo *git.Repository) (plumbing.Hash, error) {
 	// 3. Try to resolve the revision directly.
 
 	if tagRef, err := repo.Tag(rev); err == nil {
+		// Annotated tags point to a tag object rather than to the commit.
+		if tag, err := repo.TagObject(tagRef.Hash()); err == nil {
+			if commit, err := tag.Commit(); err == nil {
+				return commit.Hash, nil
+			}
+		}
 		return tagRef.Hash(), nil
 	}
 
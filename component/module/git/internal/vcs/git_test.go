@@ -2,6 +2,7 @@ package vcs_test
 
 import (
 	"context"
+	"os"
 	"testing"
 
 	"github.com/go-git/go-git/v5"
@@ -59,6 +60,105 @@ func Test_GitRepo(t *testing.T) {
 	require.Equal(t, "See you later!", string(bb))
 }
 
+func Test_GitRepo_ExpectedCommit(t *testing.T) {
+	origRepo := initRepository(t)
+	first := origRepo.Commit(t, "a.txt", "Hello, world!")
+
+	branch, err := origRepo.CurrentRef()
+	require.NoError(t, err)
+
+	newRepoDir := t.TempDir()
+	newRepo, err := vcs.NewGitRepo(context.Background(), newRepoDir, vcs.GitRepoOptions{
+		Repository:     origRepo.Directory,
+		Revision:       branch,
+		ExpectedCommit: first[:12],
+	})
+	require.NoError(t, err)
+
+	// Moving the revision to another commit must not change the checked out
+	// content.
+	origRepo.Commit(t, "a.txt", "See you later!")
+
+	err = newRepo.Update(context.Background())
+	require.ErrorAs(t, err, &vcs.RevisionMismatchError{})
+
+	bb, err := newRepo.ReadFile("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "Hello, world!", string(bb))
+
+	rev, err := newRepo.CurrentRevision()
+	require.NoError(t, err)
+	require.Equal(t, first, rev)
+}
+
+func Test_GitRepo_Offline(t *testing.T) {
+	origRepo := initRepository(t)
+	origRepo.Commit(t, "a.txt", "Hello, world!")
+
+	newRepoDir := t.TempDir()
+	opts := vcs.GitRepoOptions{
+		Repository: origRepo.Directory,
+		Revision:   "HEAD",
+	}
+	_, err := vcs.NewGitRepo(context.Background(), newRepoDir, opts)
+	require.NoError(t, err)
+
+	// Make the repository unreachable.
+	require.NoError(t, os.RemoveAll(origRepo.Directory))
+
+	// The existing clone is used when the repository is unreachable.
+	newRepo, err := vcs.NewGitRepo(context.Background(), newRepoDir, opts)
+	require.NoError(t, err)
+
+	err = newRepo.Update(context.Background())
+	require.ErrorAs(t, err, &vcs.UpdateFailedError{})
+
+	bb, err := newRepo.ReadFile("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "Hello, world!", string(bb))
+}
+
+func Test_GitRepo_Shallow(t *testing.T) {
+	origRepo := initRepository(t)
+	origRepo.Commit(t, "a.txt", "Hello, world!")
+
+	branch, err := origRepo.CurrentRef()
+	require.NoError(t, err)
+
+	newRepoDir := t.TempDir()
+	newRepo, err := vcs.NewGitRepo(context.Background(), newRepoDir, vcs.GitRepoOptions{
+		Repository: origRepo.Directory,
+		Revision:   branch,
+		Shallow:    true,
+	})
+	require.NoError(t, err)
+
+	bb, err := newRepo.ReadFile("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "Hello, world!", string(bb))
+
+	second := origRepo.Commit(t, "a.txt", "See you later!")
+
+	err = newRepo.Update(context.Background())
+	require.NoError(t, err)
+
+	bb, err = newRepo.ReadFile("a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "See you later!", string(bb))
+
+	rev, err := newRepo.CurrentRevision()
+	require.NoError(t, err)
+	require.Equal(t, second, rev)
+
+	// Shallow clones can only be made of branches and tags.
+	_, err = vcs.NewGitRepo(context.Background(), t.TempDir(), vcs.GitRepoOptions{
+		Repository: origRepo.Directory,
+		Revision:   second,
+		Shallow:    true,
+	})
+	require.ErrorAs(t, err, &vcs.ShallowRevisionError{})
+}
+
 type testRepository struct {
 	Directory string
 	Repo      *git.Repository
@@ -73,6 +173,21 @@ func (repo *testRepository) CurrentRef() (string, error) {
 	return ref.Name().Short(), nil
 }
 
+// Commit writes contents to the file at path and commits it, returning the
+// SHA of the new commit.
+func (repo *testRepository) Commit(t *testing.T, path string, contents string) string {
+	t.Helper()
+
+	require.NoError(t, repo.WriteFile(path, []byte(contents)))
+
+	_, err := repo.Worktree.Add(".")
+	require.NoError(t, err)
+
+	hash, err := repo.Worktree.Commit("update "+path, &git.CommitOptions{})
+	require.NoError(t, err)
+	return hash.String()
+}
+
 func (repo *testRepository) WriteFile(path string, contents []byte) error {
 	f, err := repo.Worktree.Filesystem.Create(path)
 	if err != nil {
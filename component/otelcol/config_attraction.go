@@ -1,5 +1,12 @@
 package otelcol
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
 type AttrActionKeyValueSlice []AttrActionKeyValue
 
 func (actions AttrActionKeyValueSlice) Convert() []interface{} {
@@ -49,6 +56,14 @@ type AttrActionKeyValue struct {
 	// If the value cannot be converted, the original value will be left as-is
 	ConvertedType string `river:"converted_type,attr,optional"`
 
+	// HashFunction specifies the hash function used by the HASH action, either
+	// "sha1" or "sha256". Defaults to "sha1".
+	HashFunction string `river:"hash_function,attr,optional"`
+
+	// Salt is prepended to values before they're hashed by the HASH action,
+	// so that hashes can't be reversed by hashing known values.
+	Salt rivertypes.Secret `river:"salt,attr,optional"`
+
 	// Action specifies the type of action to perform.
 	// The set of values are {INSERT, UPDATE, UPSERT, DELETE, HASH}.
 	// Both lower case and upper case are supported.
@@ -65,8 +80,9 @@ type AttrActionKeyValue struct {
 	//           Either Value, FromAttribute or FromContext must be set.
 	// DELETE  - Deletes the attribute. If the key doesn't exist,
 	//           no action is performed.
-	// HASH    - Calculates the SHA-1 hash of an existing value and overwrites the
-	//           value with it's SHA-1 hash result.
+	// HASH    - Calculates the hash of an existing value and overwrites the
+	//           value with it's hash result. SHA-1 is used unless
+	//           HashFunction is set.
 	// EXTRACT - Extracts values using a regular expression rule from the input
 	//           'key' to target keys specified in the 'rule'. If a target key
 	//           already exists, it will be overridden.
@@ -75,6 +91,42 @@ type AttrActionKeyValue struct {
 	Action string `river:"action,attr"`
 }
 
+// Supported values for HashFunction.
+const (
+	HashFunctionSHA1   = "sha1"
+	HashFunctionSHA256 = "sha256"
+)
+
+// Validate implements river.Validator.
+func (args *AttrActionKeyValue) Validate() error {
+	if args.HashFunction == "" && args.Salt == "" {
+		return nil
+	}
+
+	if !strings.EqualFold(args.Action, "hash") {
+		return fmt.Errorf("hash_function and salt can only be set for the hash action")
+	}
+	switch args.HashFunction {
+	case "", HashFunctionSHA1, HashFunctionSHA256:
+	default:
+		return fmt.Errorf("unsupported hash_function %q, expected %q or %q", args.HashFunction, HashFunctionSHA1, HashFunctionSHA256)
+	}
+	if args.RegexPattern != "" {
+		return fmt.Errorf("pattern can't be used with hash_function or salt")
+	}
+	return nil
+}
+
+// IsCustomHash returns true if the action is a HASH action which can't be
+// performed by the upstream processor, because it uses a salt or a hash
+// function other than SHA-1.
+func (args *AttrActionKeyValue) IsCustomHash() bool {
+	if !strings.EqualFold(args.Action, "hash") {
+		return false
+	}
+	return args.Salt != "" || (args.HashFunction != "" && args.HashFunction != HashFunctionSHA1)
+}
+
 // Convert converts args into the upstream type.
 func (args *AttrActionKeyValue) convert() map[string]interface{} {
 	if args == nil {
@@ -1,9 +1,11 @@
 package otelcol
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/alecthomas/units"
+	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/component/otelcol/auth"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
@@ -92,6 +94,14 @@ type HTTPClientArguments struct {
 	// Auth is a binding to an otelcol.auth.* component extension which handles
 	// authentication.
 	Auth *auth.Handler `river:"auth,attr,optional"`
+
+	// Proxy overrides the proxy from the environment when it sets a proxy.
+	Proxy config.ProxyConfig `river:",squash"`
+}
+
+// Validate returns an error if args is invalid.
+func (args *HTTPClientArguments) Validate() error {
+	return args.Proxy.Validate()
 }
 
 // Convert converts args into the upstream type.
@@ -106,6 +116,11 @@ func (args *HTTPClientArguments) Convert() *otelconfighttp.HTTPClientSettings {
 		auth = &otelconfigauth.Authentication{AuthenticatorID: args.Auth.ID}
 	}
 
+	var customRoundTripper func(http.RoundTripper) (http.RoundTripper, error)
+	if args.Proxy.Configured() {
+		customRoundTripper = proxyRoundTripper(args.Proxy)
+	}
+
 	return &otelconfighttp.HTTPClientSettings{
 		Endpoint: args.Endpoint,
 
@@ -113,11 +128,11 @@ func (args *HTTPClientArguments) Convert() *otelconfighttp.HTTPClientSettings {
 
 		TLSSetting: *args.TLS.Convert(),
 
-		ReadBufferSize:  int(args.ReadBufferSize),
-		WriteBufferSize: int(args.WriteBufferSize),
-		Timeout:         args.Timeout,
-		Headers:         args.Headers,
-		// CustomRoundTripper: func(http.RoundTripper) (http.RoundTripper, error) { panic("not implemented") }, TODO (@tpaschalis)
+		ReadBufferSize:      int(args.ReadBufferSize),
+		WriteBufferSize:     int(args.WriteBufferSize),
+		Timeout:             args.Timeout,
+		Headers:             args.Headers,
+		CustomRoundTripper:  customRoundTripper,
 		MaxIdleConns:        args.MaxIdleConns,
 		MaxIdleConnsPerHost: args.MaxIdleConnsPerHost,
 		MaxConnsPerHost:     args.MaxConnsPerHost,
@@ -0,0 +1,145 @@
+package otelcol
+
+import (
+	"time"
+
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+)
+
+// KafkaAuthenticationArguments configures how to authenticate to the Kafka
+// broker.
+type KafkaAuthenticationArguments struct {
+	Plaintext *KafkaPlaintextArguments `river:"plaintext,block,optional"`
+	SASL      *KafkaSASLArguments      `river:"sasl,block,optional"`
+	TLS       *TLSClientArguments      `river:"tls,block,optional"`
+	Kerberos  *KafkaKerberosArguments  `river:"kerberos,block,optional"`
+}
+
+// Convert converts args into the upstream type.
+func (args KafkaAuthenticationArguments) Convert() kafkaexporter.Authentication {
+	var res kafkaexporter.Authentication
+
+	if args.Plaintext != nil {
+		conv := args.Plaintext.Convert()
+		res.PlainText = &conv
+	}
+	if args.SASL != nil {
+		conv := args.SASL.Convert()
+		res.SASL = &conv
+	}
+	if args.TLS != nil {
+		res.TLS = args.TLS.Convert()
+	}
+	if args.Kerberos != nil {
+		conv := args.Kerberos.Convert()
+		res.Kerberos = &conv
+	}
+
+	return res
+}
+
+// KafkaPlaintextArguments configures plaintext authentication against the
+// Kafka broker.
+type KafkaPlaintextArguments struct {
+	Username string            `river:"username,attr"`
+	Password rivertypes.Secret `river:"password,attr"`
+}
+
+// Convert converts args into the upstream type.
+func (args KafkaPlaintextArguments) Convert() kafkaexporter.PlainTextConfig {
+	return kafkaexporter.PlainTextConfig{
+		Username: args.Username,
+		Password: string(args.Password),
+	}
+}
+
+// KafkaSASLArguments configures SASL authentication against the Kafka
+// broker.
+type KafkaSASLArguments struct {
+	Username  string               `river:"username,attr"`
+	Password  rivertypes.Secret    `river:"password,attr"`
+	Mechanism string               `river:"mechanism,attr"`
+	AWSMSK    KafkaAWSMSKArguments `river:"aws_msk,block,optional"`
+}
+
+// Convert converts args into the upstream type.
+func (args KafkaSASLArguments) Convert() kafkaexporter.SASLConfig {
+	return kafkaexporter.SASLConfig{
+		Username:  args.Username,
+		Password:  string(args.Password),
+		Mechanism: args.Mechanism,
+		AWSMSK:    args.AWSMSK.Convert(),
+	}
+}
+
+// KafkaAWSMSKArguments exposes additional SASL authentication measures
+// required to use the AWS_MSK_IAM mechanism.
+type KafkaAWSMSKArguments struct {
+	Region     string `river:"region,attr"`
+	BrokerAddr string `river:"broker_addr,attr"`
+}
+
+// Convert converts args into the upstream type.
+func (args KafkaAWSMSKArguments) Convert() kafkaexporter.AWSMSKConfig {
+	return kafkaexporter.AWSMSKConfig{
+		Region:     args.Region,
+		BrokerAddr: args.BrokerAddr,
+	}
+}
+
+// KafkaKerberosArguments configures Kerberos authentication against the Kafka
+// broker.
+type KafkaKerberosArguments struct {
+	ServiceName string            `river:"service_name,attr,optional"`
+	Realm       string            `river:"realm,attr,optional"`
+	UseKeyTab   bool              `river:"use_keytab,attr,optional"`
+	Username    string            `river:"username,attr"`
+	Password    rivertypes.Secret `river:"password,attr,optional"`
+	ConfigPath  string            `river:"config_file,attr,optional"`
+	KeyTabPath  string            `river:"keytab_file,attr,optional"`
+}
+
+// Convert converts args into the upstream type.
+func (args KafkaKerberosArguments) Convert() kafkaexporter.KerberosConfig {
+	return kafkaexporter.KerberosConfig{
+		ServiceName: args.ServiceName,
+		Realm:       args.Realm,
+		UseKeyTab:   args.UseKeyTab,
+		Username:    args.Username,
+		Password:    string(args.Password),
+		ConfigPath:  args.ConfigPath,
+		KeyTabPath:  args.KeyTabPath,
+	}
+}
+
+// KafkaMetadataArguments configures how Kafka components retrieve metadata
+// from the Kafka broker.
+type KafkaMetadataArguments struct {
+	IncludeAllTopics bool                        `river:"include_all_topics,attr,optional"`
+	Retry            KafkaMetadataRetryArguments `river:"retry,block,optional"`
+}
+
+// Convert converts args into the upstream type.
+func (args KafkaMetadataArguments) Convert() kafkaexporter.Metadata {
+	return kafkaexporter.Metadata{
+		Full:  args.IncludeAllTopics,
+		Retry: args.Retry.Convert(),
+	}
+}
+
+// KafkaMetadataRetryArguments configures how to retry retrieving metadata
+// from the Kafka broker. Retrying is useful to avoid race conditions when the
+// Kafka broker is starting at the same time as the component.
+type KafkaMetadataRetryArguments struct {
+	MaxRetries int           `river:"max_retries,attr,optional"`
+	Backoff    time.Duration `river:"backoff,attr,optional"`
+}
+
+// Convert converts args into the upstream type.
+func (args KafkaMetadataRetryArguments) Convert() kafkaexporter.MetadataRetry {
+	return kafkaexporter.MetadataRetry{
+		Max:     args.MaxRetries,
+		Backoff: args.Backoff,
+	}
+}
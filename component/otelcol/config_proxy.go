@@ -0,0 +1,72 @@
+package otelcol
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/grafana/agent/component/common/config"
+)
+
+// The upstream HTTP clients clone http.DefaultTransport and don't allow
+// changing the proxy of their transport, which always uses the proxy from the
+// environment. Instead, the proxy of a component is passed to the transport in
+// the context of its requests.
+func init() {
+	t, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	fallback := t.Proxy
+	t.Proxy = func(req *http.Request) (*url.URL, error) {
+		if p, ok := req.Context().Value(proxyContextKey{}).(*contextProxy); ok {
+			return p.proxy(req)
+		}
+		if fallback == nil {
+			return nil, nil
+		}
+		return fallback(req)
+	}
+
+	if t.GetProxyConnectHeader == nil {
+		t.GetProxyConnectHeader = func(ctx context.Context, _ *url.URL, _ string) (http.Header, error) {
+			if p, ok := ctx.Value(proxyContextKey{}).(*contextProxy); ok {
+				return p.connectHeader, nil
+			}
+			// Fall back to the ProxyConnectHeader of the transport.
+			return nil, nil
+		}
+	}
+}
+
+// proxyContextKey is the key of the *contextProxy of a request in its
+// context.
+type proxyContextKey struct{}
+
+// contextProxy is the proxy used by requests carrying it in their context.
+type contextProxy struct {
+	proxy         func(*http.Request) (*url.URL, error)
+	connectHeader http.Header
+}
+
+// proxyRoundTripper returns a function wrapping the round tripper of an
+// upstream HTTP client to send its requests through the proxy of p.
+func proxyRoundTripper(p config.ProxyConfig) func(http.RoundTripper) (http.RoundTripper, error) {
+	c := p.Convert()
+	cp := &contextProxy{proxy: c.Proxy(), connectHeader: c.GetProxyConnectHeader()}
+	if cp.proxy == nil {
+		cp.proxy = func(*http.Request) (*url.URL, error) { return nil, nil }
+	}
+
+	return func(next http.RoundTripper) (http.RoundTripper, error) {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			ctx := context.WithValue(req.Context(), proxyContextKey{}, cp)
+			return next.RoundTrip(req.WithContext(ctx))
+		}), nil
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -0,0 +1,61 @@
+package otelcol_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+	otelcomponent "go.opentelemetry.io/collector/component"
+)
+
+func TestHTTPClientArguments_Proxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Host)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	var args otelcol.HTTPClientArguments
+	err := river.Unmarshal([]byte(`
+		endpoint  = "http://collector.invalid:4318"
+		proxy_url = "`+proxy.URL+`"
+		no_proxy  = "direct.invalid"
+	`), &args)
+	require.NoError(t, err)
+
+	cli, err := args.Convert().ToClient(nil, otelcomponent.TelemetrySettings{})
+	require.NoError(t, err)
+
+	resp, err := cli.Get("http://collector.invalid:4318/v1/traces")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, []string{"collector.invalid:4318"}, proxied)
+
+	// Hosts matching no_proxy are connected to directly.
+	_, err = cli.Get("http://direct.invalid/v1/traces")
+	require.Error(t, err)
+	require.Len(t, proxied, 1)
+
+	// Clients without a proxy don't use the proxy of other clients.
+	var direct otelcol.HTTPClientArguments
+	require.NoError(t, river.Unmarshal([]byte(`endpoint = "http://collector.invalid:4318"`), &direct))
+	directCli, err := direct.Convert().ToClient(nil, otelcomponent.TelemetrySettings{})
+	require.NoError(t, err)
+	_, err = directCli.Get("http://collector.invalid:4318/v1/traces")
+	require.Error(t, err)
+	require.Len(t, proxied, 1)
+}
+
+func TestHTTPClientArguments_InvalidProxy(t *testing.T) {
+	var args otelcol.HTTPClientArguments
+	err := river.Unmarshal([]byte(`
+		endpoint = "http://collector.invalid:4318"
+		no_proxy = "localhost"
+	`), &args)
+	require.EqualError(t, err, "if no_proxy is configured, proxy_url must also be configured")
+}
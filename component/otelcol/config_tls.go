@@ -1,10 +1,12 @@
 package otelcol
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/spiffe"
 	otelconfigtls "go.opentelemetry.io/collector/config/configtls"
 )
 
@@ -22,10 +24,16 @@ func (args *TLSServerArguments) Convert() *otelconfigtls.TLSServerSetting {
 		return nil
 	}
 
-	return &otelconfigtls.TLSServerSetting{
+	res := &otelconfigtls.TLSServerSetting{
 		TLSSetting:   *args.TLSSetting.Convert(),
 		ClientCAFile: args.ClientCAFile,
 	}
+	// Servers using an SVID require clients to present an SVID of the same
+	// trust domain.
+	if args.TLSSetting.SPIFFE != nil && res.ClientCAFile == "" {
+		res.ClientCAFile = res.CAFile
+	}
+	return res
 }
 
 // TLSClientArguments holds shared TLS settings for components which launch
@@ -62,13 +70,35 @@ type TLSSetting struct {
 	MinVersion     string            `river:"min_version,attr,optional"`
 	MaxVersion     string            `river:"max_version,attr,optional"`
 	ReloadInterval time.Duration     `river:"reload_interval,attr,optional"`
+
+	SPIFFE *SPIFFEArguments `river:"spiffe,block,optional"`
+}
+
+// SPIFFEArguments configures retrieving TLS certificates from the SPIFFE
+// Workload API.
+type SPIFFEArguments struct {
+	SocketPath string `river:"socket_path,attr"`
 }
 
+const (
+	// spiffeWaitTimeout is how long converting TLS settings waits for the
+	// first SVID of a Workload API.
+	spiffeWaitTimeout = 5 * time.Second
+
+	// DefaultSPIFFEReloadInterval is how often certificates retrieved from the
+	// Workload API are reloaded when reload_interval isn't set.
+	DefaultSPIFFEReloadInterval = time.Minute
+)
+
 func (args *TLSSetting) Convert() *otelconfigtls.TLSSetting {
 	if args == nil {
 		return nil
 	}
 
+	if args.SPIFFE != nil {
+		return args.convertSPIFFE()
+	}
+
 	return &otelconfigtls.TLSSetting{
 		CAPem:          []byte(args.CA),
 		CAFile:         args.CAFile,
@@ -82,8 +112,44 @@ func (args *TLSSetting) Convert() *otelconfigtls.TLSSetting {
 	}
 }
 
+// convertSPIFFE converts args to TLS settings reading the SVID of the
+// workload from the files kept up to date with the Workload API.
+func (args *TLSSetting) convertSPIFFE() *otelconfigtls.TLSSetting {
+	files := spiffe.Watch(args.SPIFFE.SocketPath)
+
+	// If no SVID is available yet, the files don't exist and loading the TLS
+	// settings fails until the next update of the component.
+	ctx, cancel := context.WithTimeout(context.Background(), spiffeWaitTimeout)
+	defer cancel()
+	_ = files.Wait(ctx)
+
+	reloadInterval := args.ReloadInterval
+	if reloadInterval == 0 {
+		reloadInterval = DefaultSPIFFEReloadInterval
+	}
+
+	return &otelconfigtls.TLSSetting{
+		CAFile:         files.BundleFile,
+		CertFile:       files.CertFile,
+		KeyFile:        files.KeyFile,
+		MinVersion:     args.MinVersion,
+		MaxVersion:     args.MaxVersion,
+		ReloadInterval: reloadInterval,
+	}
+}
+
 // Validate implements river.Validator.
 func (t *TLSSetting) Validate() error {
+	if t.SPIFFE != nil {
+		usingFiles := len(t.CA) > 0 || len(t.CAFile) > 0 || len(t.Cert) > 0 || len(t.CertFile) > 0 || len(t.Key) > 0 || len(t.KeyFile) > 0
+		if usingFiles {
+			return fmt.Errorf("the spiffe block can't be combined with ca_pem, ca_file, cert_pem, cert_file, key_pem, or key_file")
+		}
+		if t.SPIFFE.SocketPath == "" {
+			return fmt.Errorf("spiffe socket_path must not be empty")
+		}
+	}
+
 	if len(t.CA) > 0 && len(t.CAFile) > 0 {
 		return fmt.Errorf("at most one of ca_pem and ca_file must be configured")
 	}
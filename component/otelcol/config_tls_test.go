@@ -0,0 +1,57 @@
+package otelcol_test
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSSetting_SPIFFE(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			cfg: `
+				spiffe {
+					socket_path = "/run/spire/sockets/agent.sock"
+				}
+			`,
+		},
+		{
+			name: "combined with files",
+			cfg: `
+				cert_file = "/etc/tls/cert.pem"
+				spiffe {
+					socket_path = "/run/spire/sockets/agent.sock"
+				}
+			`,
+			expectedErr: "the spiffe block can't be combined with ca_pem, ca_file, cert_pem, cert_file, key_pem, or key_file",
+		},
+		{
+			name: "empty socket path",
+			cfg: `
+				spiffe {
+					socket_path = ""
+				}
+			`,
+			expectedErr: "spiffe socket_path must not be empty",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args otelcol.TLSSetting
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
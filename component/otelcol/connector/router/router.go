@@ -0,0 +1,115 @@
+// Package router provides an otelcol.connector.router component.
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/lazyconsumer"
+	"github.com/grafana/agent/pkg/util/zapadapter"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.connector.router",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.connector.router component.
+type Arguments struct {
+	// Routes are evaluated in order against the resource of each batch of
+	// telemetry.
+	Routes []Route `river:"route,block,optional"`
+
+	// MatchOnce sends telemetry only to the first matching route instead of
+	// all of them.
+	MatchOnce bool `river:"match_once,attr,optional"`
+
+	// DefaultOutput configures where to send telemetry which doesn't match any
+	// route. Such telemetry is dropped when it isn't set.
+	DefaultOutput *otelcol.ConsumerArguments `river:"default_output,block,optional"`
+}
+
+// Route sends telemetry whose resource matches Condition to Output.
+type Route struct {
+	// Condition is an OTTL condition evaluated in the resource context.
+	Condition string `river:"condition,attr"`
+
+	// Output configures where to send matching telemetry. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if len(args.Routes) == 0 {
+		return fmt.Errorf("at least one route must be configured")
+	}
+	_, err := newRouter(*args, otelcomponent.TelemetrySettings{Logger: zap.NewNop()})
+	return err
+}
+
+// Component is the otelcol.connector.router component.
+type Component struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	opts     component.Options
+	consumer *lazyconsumer.Consumer
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new otelcol.connector.router component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &Component{
+		ctx:    ctx,
+		cancel: cancel,
+
+		opts:     opts,
+		consumer: lazyconsumer.New(ctx),
+	}
+
+	// Immediately export the consumer, which never changes through the
+	// lifetime of the component.
+	opts.OnStateChange(otelcol.ConsumerExports{Input: c.consumer})
+
+	if err := c.Update(args); err != nil {
+		cancel()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.cancel()
+
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(newConfig component.Arguments) error {
+	args := newConfig.(Arguments)
+
+	r, err := newRouter(args, otelcomponent.TelemetrySettings{
+		Logger: zapadapter.New(c.opts.Logger),
+	})
+	if err != nil {
+		return err
+	}
+	c.consumer.SetConsumers(r, r, r)
+	return nil
+}
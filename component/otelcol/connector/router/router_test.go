@@ -0,0 +1,164 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "routes",
+			cfg: `
+				match_once = true
+
+				route {
+					condition = "attributes[\"env\"] == \"prod\""
+					output {}
+				}
+
+				route {
+					condition = "IsMatch(attributes[\"service.name\"], \"^checkout-.*\") == true"
+					output {}
+				}
+
+				default_output {}
+			`,
+		},
+		{
+			name: "no routes",
+			cfg: `
+				default_output {}
+			`,
+			expectedErr: "at least one route must be configured",
+		},
+		{
+			name: "invalid condition",
+			cfg: `
+				route {
+					condition = "attributes["
+					output {}
+				}
+			`,
+			expectedErr: "route 0: invalid condition: ",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRouter(t *testing.T) {
+	tests := []struct {
+		name      string
+		matchOnce bool
+		expected  map[string][]string
+	}{
+		{
+			name: "all matching routes",
+			expected: map[string][]string{
+				"prod":    {"prod", "prod"},
+				"tenant":  {"prod", "prod"},
+				"default": {"dev"},
+			},
+		},
+		{
+			name:      "first matching route",
+			matchOnce: true,
+			expected: map[string][]string{
+				"prod":    {"prod", "prod"},
+				"default": {"dev"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := componenttest.TestContext(t)
+			ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "otelcol.connector.router")
+			require.NoError(t, err)
+
+			received := make(chan [2]string, 10)
+			output := func(name string) *otelcol.ConsumerArguments {
+				return &otelcol.ConsumerArguments{
+					Traces: []otelcol.Consumer{&fakeconsumer.Consumer{
+						ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+							for i := 0; i < td.ResourceSpans().Len(); i++ {
+								env, _ := td.ResourceSpans().At(i).Resource().Attributes().Get("env")
+								received <- [2]string{name, env.Str()}
+							}
+							return nil
+						},
+					}},
+				}
+			}
+
+			args := Arguments{
+				MatchOnce: tc.matchOnce,
+				Routes: []Route{
+					{Condition: `attributes["env"] == "prod"`, Output: output("prod")},
+					{Condition: `attributes["tenant"] == "a"`, Output: output("tenant")},
+				},
+				DefaultOutput: output("default"),
+			}
+
+			go func() {
+				err := ctrl.Run(ctx, args)
+				require.NoError(t, err)
+			}()
+			require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+			require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+			input := ctrl.Exports().(otelcol.ConsumerExports).Input
+
+			td := ptrace.NewTraces()
+			for _, env := range []string{"prod", "dev", "prod"} {
+				rs := td.ResourceSpans().AppendEmpty()
+				rs.Resource().Attributes().PutStr("env", env)
+				if env == "prod" {
+					rs.Resource().Attributes().PutStr("tenant", "a")
+				}
+				rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+			}
+			require.NoError(t, input.ConsumeTraces(context.Background(), td))
+
+			// Telemetry without a matching pipeline for its signal is dropped.
+			ld := plog.NewLogs()
+			ld.ResourceLogs().AppendEmpty().Resource().Attributes().PutStr("env", "prod")
+			require.NoError(t, input.ConsumeLogs(context.Background(), ld))
+
+			actual := make(map[string][]string)
+			for done := false; !done; {
+				select {
+				case r := <-received:
+					actual[r[0]] = append(actual[r[0]], r[1])
+				case <-time.After(100 * time.Millisecond):
+					done = true
+				}
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
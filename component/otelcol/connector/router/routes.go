@@ -0,0 +1,179 @@
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// router sends each resource of incoming telemetry to the outputs of the
+// routes its resource matches. Resources which match several routes are
+// copied to each of them.
+type router struct {
+	logger     *zap.Logger
+	conditions []*ottl.Statement[ottlresource.TransformContext]
+	matchOnce  bool
+
+	// outputs holds the output of each route, followed by the default
+	// output, which may be nil.
+	outputs []*otelcol.ConsumerArguments
+}
+
+var (
+	_ otelconsumer.Traces  = (*router)(nil)
+	_ otelconsumer.Metrics = (*router)(nil)
+	_ otelconsumer.Logs    = (*router)(nil)
+)
+
+// conditionFunctions returns the functions which can be used in route
+// conditions. OTTL only parses conditions as part of a statement, so each
+// condition is parsed as the where clause of a call to route, which does
+// nothing.
+func conditionFunctions() map[string]interface{} {
+	return map[string]interface{}{
+		"Concat":  ottlfuncs.Concat[ottlresource.TransformContext],
+		"Int":     ottlfuncs.Int[ottlresource.TransformContext],
+		"IsMatch": ottlfuncs.IsMatch[ottlresource.TransformContext],
+
+		"route": func() (ottl.ExprFunc[ottlresource.TransformContext], error) {
+			return func(ottlresource.TransformContext) (interface{}, error) {
+				return nil, nil
+			}, nil
+		},
+	}
+}
+
+func newRouter(args Arguments, set otelcomponent.TelemetrySettings) (*router, error) {
+	parser := ottlresource.NewParser(conditionFunctions(), set)
+
+	r := &router{
+		logger:    set.Logger,
+		matchOnce: args.MatchOnce,
+	}
+	for i, route := range args.Routes {
+		parsed, err := parser.ParseStatements([]string{"route() where " + route.Condition})
+		if err != nil {
+			return nil, fmt.Errorf("route %d: invalid condition: %w", i, err)
+		}
+		r.conditions = append(r.conditions, parsed[0])
+		r.outputs = append(r.outputs, route.Output)
+	}
+	r.outputs = append(r.outputs, args.DefaultOutput)
+	return r, nil
+}
+
+// match returns the indexes in r.outputs of the outputs telemetry of res must
+// be sent to.
+func (r *router) match(res pcommon.Resource) []int {
+	var matched []int
+
+	ctx := ottlresource.NewTransformContext(res)
+	for i, cond := range r.conditions {
+		_, ok, err := cond.Execute(ctx)
+		if err != nil {
+			r.logger.Debug("failed to evaluate route condition", zap.Int("route", i), zap.Error(err))
+			continue
+		}
+		if ok {
+			matched = append(matched, i)
+			if r.matchOnce {
+				break
+			}
+		}
+	}
+
+	if len(matched) == 0 {
+		matched = append(matched, len(r.outputs)-1)
+	}
+	return matched
+}
+
+// Capabilities implements otelconsumer.baseConsumer.
+func (r *router) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelconsumer.Traces.
+func (r *router) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	batches := make([]*ptrace.Traces, len(r.outputs))
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		for _, out := range r.match(rs.Resource()) {
+			if batches[out] == nil {
+				batch := ptrace.NewTraces()
+				batches[out] = &batch
+			}
+			rs.CopyTo(batches[out].ResourceSpans().AppendEmpty())
+		}
+	}
+
+	var errs error
+	for i, batch := range batches {
+		if batch == nil || r.outputs[i] == nil {
+			continue
+		}
+		errs = multierr.Append(errs, fanoutconsumer.Traces(r.outputs[i].Traces).ConsumeTraces(ctx, *batch))
+	}
+	return errs
+}
+
+// ConsumeMetrics implements otelconsumer.Metrics.
+func (r *router) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	batches := make([]*pmetric.Metrics, len(r.outputs))
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		for _, out := range r.match(rm.Resource()) {
+			if batches[out] == nil {
+				batch := pmetric.NewMetrics()
+				batches[out] = &batch
+			}
+			rm.CopyTo(batches[out].ResourceMetrics().AppendEmpty())
+		}
+	}
+
+	var errs error
+	for i, batch := range batches {
+		if batch == nil || r.outputs[i] == nil {
+			continue
+		}
+		errs = multierr.Append(errs, fanoutconsumer.Metrics(r.outputs[i].Metrics).ConsumeMetrics(ctx, *batch))
+	}
+	return errs
+}
+
+// ConsumeLogs implements otelconsumer.Logs.
+func (r *router) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	batches := make([]*plog.Logs, len(r.outputs))
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for _, out := range r.match(rl.Resource()) {
+			if batches[out] == nil {
+				batch := plog.NewLogs()
+				batches[out] = &batch
+			}
+			rl.CopyTo(batches[out].ResourceLogs().AppendEmpty())
+		}
+	}
+
+	var errs error
+	for i, batch := range batches {
+		if batch == nil || r.outputs[i] == nil {
+			continue
+		}
+		errs = multierr.Append(errs, fanoutconsumer.Logs(r.outputs[i].Logs).ConsumeLogs(ctx, *batch))
+	}
+	return errs
+}
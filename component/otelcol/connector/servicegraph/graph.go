@@ -0,0 +1,378 @@
+package servicegraph
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+// Names of the metrics of the service graph, matching the ones generated by
+// Tempo.
+const (
+	metricRequestTotal   = "traces_service_graph_request_total"
+	metricRequestFailed  = "traces_service_graph_request_failed_total"
+	metricRequestServer  = "traces_service_graph_request_server_seconds"
+	metricRequestClient  = "traces_service_graph_request_client_seconds"
+	scopeName            = "otelcol.connector.servicegraph"
+	clientLabel          = "client"
+	serverLabel          = "server"
+	clientDimensionLabel = "client_"
+	serverDimensionLabel = "server_"
+)
+
+// edge is a request between a client and a server, built from the client span
+// and the server span of the request.
+type edge struct {
+	clientService, serverService string
+	clientLatency, serverLatency time.Duration
+	clientDims, serverDims       map[string]string
+	hasClient, hasServer         bool
+
+	// The edge is failed if either of its spans has an error status.
+	failed bool
+
+	expiration time.Time
+}
+
+// series holds the metrics of the edges between a client and a server.
+type series struct {
+	attrs map[string]string
+
+	total, failed                uint64
+	serverLatency, clientLatency histogram
+}
+
+type histogram struct {
+	counts []uint64 // One count per bucket, plus one for +Inf.
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(bounds []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds)+1)
+	}
+	h.counts[sort.SearchFloat64s(bounds, v)]++
+	h.count++
+	h.sum += v
+}
+
+// graph pairs the client and server spans of requests to build the metrics of
+// the service graph. It implements otelconsumer.Traces.
+type graph struct {
+	bounds     []float64 // Latency buckets in seconds.
+	dimensions []string
+	metrics    *debugMetrics
+	start      time.Time
+	now        func() time.Time
+
+	mut      sync.Mutex
+	maxItems int
+	ttl      time.Duration
+	edges    map[string]*edge
+	series   map[string]*series
+}
+
+var _ otelconsumer.Traces = (*graph)(nil)
+
+func newGraph(args Arguments, metrics *debugMetrics, start time.Time) *graph {
+	bounds := make([]float64, 0, len(args.LatencyHistogramBuckets))
+	for _, b := range args.LatencyHistogramBuckets {
+		bounds = append(bounds, b.Seconds())
+	}
+
+	return &graph{
+		bounds:     bounds,
+		dimensions: args.Dimensions,
+		metrics:    metrics,
+		start:      start,
+		now:        time.Now,
+
+		maxItems: args.Store.MaxItems,
+		ttl:      args.Store.TTL,
+		edges:    make(map[string]*edge),
+		series:   make(map[string]*series),
+	}
+}
+
+// needsReset returns true if the metrics of g can't be kept with args.
+func (g *graph) needsReset(args Arguments) bool {
+	if len(args.LatencyHistogramBuckets) != len(g.bounds) {
+		return true
+	}
+	for i, b := range args.LatencyHistogramBuckets {
+		if b.Seconds() != g.bounds[i] {
+			return true
+		}
+	}
+	return !reflect.DeepEqual(args.Dimensions, g.dimensions)
+}
+
+// setStore updates the settings of the store of incomplete edges.
+func (g *graph) setStore(cfg StoreConfig) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	g.maxItems = cfg.MaxItems
+	g.ttl = cfg.TTL
+}
+
+// Capabilities implements otelconsumer.Traces.
+func (g *graph) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelconsumer.Traces.
+func (g *graph) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	now := g.now()
+	g.expire(now)
+
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		resourceAttrs := rs.Resource().Attributes()
+
+		serviceName := ""
+		if v, ok := resourceAttrs.Get(semconv.AttributeServiceName); ok {
+			serviceName = v.AsString()
+		}
+
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				g.consumeSpan(now, serviceName, resourceAttrs, spans.At(k))
+			}
+		}
+	}
+
+	g.metrics.storeSize.Set(float64(len(g.edges)))
+	return nil
+}
+
+// consumeSpan adds the client or server side of an edge from span. g.mut must
+// be held.
+func (g *graph) consumeSpan(now time.Time, serviceName string, resourceAttrs pcommon.Map, span ptrace.Span) {
+	var (
+		key      string
+		isClient bool
+	)
+	switch span.Kind() {
+	case ptrace.SpanKindClient, ptrace.SpanKindProducer:
+		// The server span of the request is a child of the client span.
+		key = span.TraceID().HexString() + span.SpanID().HexString()
+		isClient = true
+	case ptrace.SpanKindServer, ptrace.SpanKindConsumer:
+		if span.ParentSpanID().IsEmpty() {
+			return
+		}
+		key = span.TraceID().HexString() + span.ParentSpanID().HexString()
+	default:
+		return
+	}
+
+	e, ok := g.edges[key]
+	if !ok {
+		if len(g.edges) >= g.maxItems {
+			g.metrics.droppedSpans.Inc()
+			return
+		}
+		e = &edge{expiration: now.Add(g.ttl)}
+		g.edges[key] = e
+	}
+
+	latency := span.EndTimestamp().AsTime().Sub(span.StartTimestamp().AsTime())
+	dims := g.spanDimensions(resourceAttrs, span.Attributes())
+	if isClient {
+		e.clientService, e.clientLatency, e.clientDims, e.hasClient = serviceName, latency, dims, true
+	} else {
+		e.serverService, e.serverLatency, e.serverDims, e.hasServer = serviceName, latency, dims, true
+	}
+	if span.Status().Code() == ptrace.StatusCodeError {
+		e.failed = true
+	}
+
+	if e.hasClient && e.hasServer {
+		g.record(e)
+		delete(g.edges, key)
+	}
+}
+
+// spanDimensions returns the values of the dimensions of a span, taken from
+// the span attributes or the resource attributes.
+func (g *graph) spanDimensions(resourceAttrs, spanAttrs pcommon.Map) map[string]string {
+	if len(g.dimensions) == 0 {
+		return nil
+	}
+	dims := make(map[string]string, len(g.dimensions))
+	for _, d := range g.dimensions {
+		if v, ok := spanAttrs.Get(d); ok {
+			dims[d] = v.AsString()
+		} else if v, ok := resourceAttrs.Get(d); ok {
+			dims[d] = v.AsString()
+		}
+	}
+	return dims
+}
+
+// record adds a completed edge to the metrics. g.mut must be held.
+func (g *graph) record(e *edge) {
+	attrs := map[string]string{
+		clientLabel: e.clientService,
+		serverLabel: e.serverService,
+	}
+	for _, d := range g.dimensions {
+		if v, ok := e.clientDims[d]; ok {
+			attrs[clientDimensionLabel+d] = v
+		}
+		if v, ok := e.serverDims[d]; ok {
+			attrs[serverDimensionLabel+d] = v
+		}
+	}
+
+	key := seriesKey(attrs)
+	s, ok := g.series[key]
+	if !ok {
+		s = &series{attrs: attrs}
+		g.series[key] = s
+	}
+
+	s.total++
+	if e.failed {
+		s.failed++
+	}
+	s.serverLatency.observe(g.bounds, e.serverLatency.Seconds())
+	s.clientLatency.observe(g.bounds, e.clientLatency.Seconds())
+}
+
+// expire removes the edges which didn't receive their other span before
+// their expiration. g.mut must be held.
+func (g *graph) expire(now time.Time) {
+	for key, e := range g.edges {
+		if now.Before(e.expiration) {
+			continue
+		}
+		g.metrics.unpairedSpans.Inc()
+		delete(g.edges, key)
+	}
+}
+
+// buildMetrics returns the metrics of the service graph at now, or false if
+// no edges were recorded yet.
+func (g *graph) buildMetrics(now time.Time) (pmetric.Metrics, bool) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+
+	g.expire(now)
+	g.metrics.storeSize.Set(float64(len(g.edges)))
+
+	if len(g.series) == 0 {
+		return pmetric.Metrics{}, false
+	}
+
+	keys := make([]string, 0, len(g.series))
+	for key := range g.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var (
+		start = pcommon.NewTimestampFromTime(g.start)
+		ts    = pcommon.NewTimestampFromTime(now)
+	)
+
+	md := pmetric.NewMetrics()
+	sm := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName(scopeName)
+
+	total := newSum(sm, metricRequestTotal, "Total count of requests between two nodes.")
+	failed := newSum(sm, metricRequestFailed, "Total count of failed requests between two nodes.")
+	server := newHistogram(sm, metricRequestServer, "Time for a request between two nodes as seen from the server.")
+	client := newHistogram(sm, metricRequestClient, "Time for a request between two nodes as seen from the client.")
+
+	for _, key := range keys {
+		s := g.series[key]
+
+		addSumPoint(total, s.attrs, start, ts, s.total)
+		addSumPoint(failed, s.attrs, start, ts, s.failed)
+		g.addHistogramPoint(server, s.attrs, start, ts, s.serverLatency)
+		g.addHistogramPoint(client, s.attrs, start, ts, s.clientLatency)
+	}
+	return md, true
+}
+
+func newSum(sm pmetric.ScopeMetrics, name, description string) pmetric.Sum {
+	m := sm.Metrics().AppendEmpty()
+	m.SetName(name)
+	m.SetDescription(description)
+	sum := m.SetEmptySum()
+	sum.SetIsMonotonic(true)
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	return sum
+}
+
+func newHistogram(sm pmetric.ScopeMetrics, name, description string) pmetric.Histogram {
+	m := sm.Metrics().AppendEmpty()
+	m.SetName(name)
+	m.SetDescription(description)
+	m.SetUnit("s")
+	hist := m.SetEmptyHistogram()
+	hist.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	return hist
+}
+
+func addSumPoint(sum pmetric.Sum, attrs map[string]string, start, ts pcommon.Timestamp, v uint64) {
+	dp := sum.DataPoints().AppendEmpty()
+	setAttributes(dp.Attributes(), attrs)
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetIntValue(int64(v))
+}
+
+func (g *graph) addHistogramPoint(hist pmetric.Histogram, attrs map[string]string, start, ts pcommon.Timestamp, h histogram) {
+	dp := hist.DataPoints().AppendEmpty()
+	setAttributes(dp.Attributes(), attrs)
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetCount(h.count)
+	dp.SetSum(h.sum)
+	dp.ExplicitBounds().FromRaw(g.bounds)
+	dp.BucketCounts().FromRaw(h.counts)
+}
+
+func setAttributes(m pcommon.Map, attrs map[string]string) {
+	m.EnsureCapacity(len(attrs))
+	for k, v := range attrs {
+		m.PutStr(k, v)
+	}
+}
+
+// seriesKey returns a unique key for a set of attributes.
+func seriesKey(attrs map[string]string) string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(name)
+		sb.WriteByte(0xff)
+		sb.WriteString(attrs[name])
+		sb.WriteByte(0xff)
+	}
+	return sb.String()
+}
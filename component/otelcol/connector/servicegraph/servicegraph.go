@@ -0,0 +1,258 @@
+// Package servicegraph provides an otelcol.connector.servicegraph component.
+package servicegraph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
+	"github.com/grafana/agent/component/otelcol/internal/lazyconsumer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.connector.servicegraph",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.connector.servicegraph component.
+type Arguments struct {
+	// Buckets of the histograms of request latencies.
+	LatencyHistogramBuckets []time.Duration `river:"latency_histogram_buckets,attr,optional"`
+
+	// Span or resource attributes added as labels to the metrics, for both the
+	// client and the server of each edge.
+	Dimensions []string `river:"dimensions,attr,optional"`
+
+	Store StoreConfig `river:"store,block,optional"`
+
+	// How often the metrics of the service graph are sent to the output.
+	MetricsFlushInterval time.Duration `river:"metrics_flush_interval,attr,optional"`
+
+	// Output configures where to send the metrics of the service graph.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// StoreConfig configures the store of edges waiting for their other span.
+type StoreConfig struct {
+	// Maximum number of incomplete edges kept in the store.
+	MaxItems int `river:"max_items,attr,optional"`
+
+	// How long an incomplete edge waits for its other span.
+	TTL time.Duration `river:"ttl,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	LatencyHistogramBuckets: []time.Duration{
+		2 * time.Millisecond,
+		4 * time.Millisecond,
+		6 * time.Millisecond,
+		8 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		1 * time.Second,
+		1400 * time.Millisecond,
+		2 * time.Second,
+		5 * time.Second,
+		10 * time.Second,
+		15 * time.Second,
+	},
+	Store: StoreConfig{
+		MaxItems: 1000,
+		TTL:      2 * time.Second,
+	},
+	MetricsFlushInterval: 60 * time.Second,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	for i, b := range args.LatencyHistogramBuckets {
+		if b <= 0 {
+			return fmt.Errorf("latency_histogram_buckets must be greater than 0")
+		}
+		if i > 0 && b <= args.LatencyHistogramBuckets[i-1] {
+			return fmt.Errorf("latency_histogram_buckets must be in increasing order")
+		}
+	}
+	if args.Store.MaxItems <= 0 {
+		return fmt.Errorf("store.max_items must be greater than 0")
+	}
+	if args.Store.TTL <= 0 {
+		return fmt.Errorf("store.ttl must be greater than 0")
+	}
+	if args.MetricsFlushInterval <= 0 {
+		return fmt.Errorf("metrics_flush_interval must be greater than 0")
+	}
+	return nil
+}
+
+// Component is the otelcol.connector.servicegraph component.
+type Component struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	opts     component.Options
+	consumer *lazyconsumer.Consumer
+	metrics  *debugMetrics
+
+	mut   sync.Mutex
+	args  Arguments
+	graph *graph
+
+	updated chan struct{}
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new otelcol.connector.servicegraph component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	metrics, err := newDebugMetrics(opts.Registerer)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	c := &Component{
+		ctx:    ctx,
+		cancel: cancel,
+
+		opts:     opts,
+		consumer: lazyconsumer.New(ctx),
+		metrics:  metrics,
+
+		updated: make(chan struct{}, 1),
+	}
+
+	// Immediately export the consumer, which never changes through the
+	// lifetime of the component.
+	opts.OnStateChange(otelcol.ConsumerExports{Input: c.consumer})
+
+	if err := c.Update(args); err != nil {
+		cancel()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.cancel()
+
+	c.mut.Lock()
+	interval := c.args.MetricsFlushInterval
+	c.mut.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-c.updated:
+			c.mut.Lock()
+			interval = c.args.MetricsFlushInterval
+			c.mut.Unlock()
+			ticker.Reset(interval)
+
+		case <-ticker.C:
+			c.flush(ctx)
+		}
+	}
+}
+
+// flush sends the metrics of the service graph to the output.
+func (c *Component) flush(ctx context.Context) {
+	c.mut.Lock()
+	g := c.graph
+	next := fanoutconsumer.Metrics(c.args.Output.Metrics)
+	c.mut.Unlock()
+
+	md, ok := g.buildMetrics(time.Now())
+	if !ok {
+		return
+	}
+	if err := next.ConsumeMetrics(ctx, md); err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to send service graph metrics", "err", err)
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(newConfig component.Arguments) error {
+	args := newConfig.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.graph == nil || c.graph.needsReset(args) {
+		// The metrics collected so far can't be kept when their labels or
+		// buckets change.
+		c.graph = newGraph(args, c.metrics, time.Now())
+	} else {
+		c.graph.setStore(args.Store)
+	}
+	c.args = args
+	c.consumer.SetConsumers(c.graph, nil, nil)
+
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// debugMetrics are the internal metrics of the component.
+type debugMetrics struct {
+	unpairedSpans prometheus.Counter
+	droppedSpans  prometheus.Counter
+	storeSize     prometheus.Gauge
+}
+
+func newDebugMetrics(reg prometheus.Registerer) (*debugMetrics, error) {
+	m := &debugMetrics{
+		unpairedSpans: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "traces_service_graph_unpaired_spans_total",
+			Help: "Total number of spans whose client or server span wasn't received before the edge expired.",
+		}),
+		droppedSpans: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "traces_service_graph_dropped_spans_total",
+			Help: "Total number of spans dropped because the store of edges was full.",
+		}),
+		storeSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "traces_service_graph_store_size",
+			Help: "Number of edges waiting for their client or server span.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{m.unpairedSpans, m.droppedSpans, m.storeSize} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,162 @@
+package servicegraph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+var (
+	testTraceID = pcommon.TraceID([16]byte{1})
+	clientSpan  = pcommon.SpanID([8]byte{1})
+	serverSpan  = pcommon.SpanID([8]byte{2})
+)
+
+// addSpan adds a span of service to td.
+func addSpan(td ptrace.Traces, service string, kind ptrace.SpanKind, id, parent pcommon.SpanID, latency time.Duration, failed bool) {
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr(semconv.AttributeServiceName, service)
+	rs.Resource().Attributes().PutStr("cluster", service+"-cluster")
+
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(testTraceID)
+	span.SetSpanID(id)
+	span.SetParentSpanID(parent)
+	span.SetKind(kind)
+
+	start := time.Unix(100, 0)
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(latency)))
+	if failed {
+		span.Status().SetCode(ptrace.StatusCodeError)
+	}
+}
+
+func Test(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.connector.servicegraph")
+	require.NoError(t, err)
+
+	cfg := `
+		dimensions             = ["cluster"]
+		metrics_flush_interval = "10ms"
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	metricsCh := make(chan pmetric.Metrics, 1)
+	args.Output = &otelcol.ConsumerArguments{
+		Metrics: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeMetricsFunc: func(ctx context.Context, md pmetric.Metrics) error {
+				select {
+				case metricsCh <- md:
+				default:
+				}
+				return nil
+			},
+		}},
+	}
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	// The server and client spans of a request can arrive in any order.
+	td := ptrace.NewTraces()
+	addSpan(td, "api", ptrace.SpanKindServer, serverSpan, clientSpan, 30*time.Millisecond, true)
+	addSpan(td, "frontend", ptrace.SpanKindClient, clientSpan, pcommon.NewSpanIDEmpty(), 45*time.Millisecond, false)
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	require.NoError(t, exports.Input.ConsumeTraces(ctx, td))
+
+	var md pmetric.Metrics
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for metrics")
+	case md = <-metricsCh:
+	}
+
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 4, metrics.Len())
+
+	total := metrics.At(0)
+	require.Equal(t, "traces_service_graph_request_total", total.Name())
+	require.Equal(t, map[string]interface{}{
+		"client":         "frontend",
+		"server":         "api",
+		"client_cluster": "frontend-cluster",
+		"server_cluster": "api-cluster",
+	}, total.Sum().DataPoints().At(0).Attributes().AsRaw())
+	require.Equal(t, int64(1), total.Sum().DataPoints().At(0).IntValue())
+
+	failed := metrics.At(1)
+	require.Equal(t, "traces_service_graph_request_failed_total", failed.Name())
+	require.Equal(t, int64(1), failed.Sum().DataPoints().At(0).IntValue())
+
+	client := metrics.At(3)
+	require.Equal(t, "traces_service_graph_request_client_seconds", client.Name())
+	dp := client.Histogram().DataPoints().At(0)
+	require.Equal(t, uint64(1), dp.Count())
+	require.InDelta(t, 0.045, dp.Sum(), 1e-9)
+	// 45ms falls in the bucket ending at 50ms.
+	require.Equal(t, uint64(1), dp.BucketCounts().At(5))
+}
+
+func TestGraph_Store(t *testing.T) {
+	metrics, err := newDebugMetrics(prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	args := DefaultArguments
+	args.Store = StoreConfig{MaxItems: 1, TTL: time.Second}
+
+	now := time.Unix(0, 0)
+	g := newGraph(args, metrics, now)
+	g.now = func() time.Time { return now }
+
+	// The second edge doesn't fit in the store.
+	td := ptrace.NewTraces()
+	addSpan(td, "frontend", ptrace.SpanKindClient, clientSpan, pcommon.NewSpanIDEmpty(), time.Millisecond, false)
+	addSpan(td, "frontend", ptrace.SpanKindClient, serverSpan, pcommon.NewSpanIDEmpty(), time.Millisecond, false)
+	require.NoError(t, g.ConsumeTraces(context.Background(), td))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.droppedSpans))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.storeSize))
+
+	// The first edge expires without its server span.
+	now = now.Add(time.Second)
+	_, ok := g.buildMetrics(now)
+	require.False(t, ok, "no edges should be recorded")
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.unpairedSpans))
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.storeSize))
+}
+
+func TestArguments_Validate(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		latency_histogram_buckets = ["1s", "100ms"]
+		output {}
+	`), &args)
+	require.EqualError(t, err, "latency_histogram_buckets must be in increasing order")
+}
@@ -0,0 +1,235 @@
+package spanlogs
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logfmt/logfmt"
+	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
+	"github.com/grafana/agent/pkg/operator/config"
+	"github.com/prometheus/client_golang/prometheus"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+	"golang.org/x/time/rate"
+)
+
+const (
+	typeSpan    = "span"
+	typeRoot    = "root"
+	typeProcess = "process"
+	typeEvent   = "event"
+
+	// hintAttributes lists the attributes which otelcol.exporter.loki converts
+	// to labels.
+	hintAttributes = "loki.attribute.labels"
+)
+
+// converter converts spans to log records, with logfmt lines built from the
+// spans, their resources, and their events.
+type converter struct {
+	args   Arguments
+	labels map[string]struct{}
+	next   otelconsumer.Logs
+
+	// limiter is nil when lines aren't rate limited.
+	limiter *rate.Limiter
+	dropped prometheus.Counter
+}
+
+var _ otelconsumer.Traces = (*converter)(nil)
+
+func newConverter(args Arguments, dropped prometheus.Counter) *converter {
+	labels := make(map[string]struct{}, len(args.Labels))
+	for _, l := range args.Labels {
+		labels[l] = struct{}{}
+	}
+
+	c := &converter{
+		args:    args,
+		labels:  labels,
+		next:    fanoutconsumer.Logs(args.Output.Logs),
+		dropped: dropped,
+	}
+	if rl := args.RateLimit; rl != nil {
+		burst := rl.Burst
+		if burst == 0 {
+			burst = int(math.Ceil(rl.Rate))
+		}
+		c.limiter = rate.NewLimiter(rate.Limit(rl.Rate), burst)
+	}
+	return c
+}
+
+// Capabilities implements otelconsumer.Traces.
+func (c *converter) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelconsumer.Traces.
+func (c *converter) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	ld := plog.NewLogs()
+
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		processKeyVals := c.processKeyVals(rs.Resource())
+
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			spans := rs.ScopeSpans().At(j).Spans()
+
+			var lastTraceID pcommon.TraceID
+			for k := 0; k < spans.Len(); k++ {
+				span := spans.At(k)
+
+				if c.args.Spans {
+					keyVals := append(c.spanKeyVals(span), processKeyVals...)
+					c.appendRecord(records, typeSpan, span, span.StartTimestamp(), keyVals)
+				}
+
+				if c.args.Roots && span.ParentSpanID().IsEmpty() {
+					keyVals := append(c.spanKeyVals(span), processKeyVals...)
+					c.appendRecord(records, typeRoot, span, span.StartTimestamp(), keyVals)
+				}
+
+				if c.args.Processes && lastTraceID != span.TraceID() {
+					lastTraceID = span.TraceID()
+					c.appendRecord(records, typeProcess, span, span.StartTimestamp(), processKeyVals)
+				}
+
+				if c.args.Events {
+					for l := 0; l < span.Events().Len(); l++ {
+						event := span.Events().At(l)
+						keyVals := append(c.eventKeyVals(span, event), processKeyVals...)
+						c.appendRecord(records, typeEvent, span, event.Timestamp(), keyVals)
+					}
+				}
+			}
+		}
+	}
+
+	ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		return rl.ScopeLogs().At(0).LogRecords().Len() == 0
+	})
+	if ld.ResourceLogs().Len() == 0 {
+		return nil
+	}
+	return c.next.ConsumeLogs(ctx, ld)
+}
+
+func (c *converter) processKeyVals(resource pcommon.Resource) []interface{} {
+	var svc string
+	if att, ok := resource.Attributes().Get(semconv.AttributeServiceName); ok {
+		svc = att.Str()
+	}
+	keyVals := []interface{}{c.args.Overrides.ServiceKey, svc}
+
+	for _, name := range c.args.ProcessAttributes {
+		if att, ok := resource.Attributes().Get(name); ok {
+			keyVals = append(keyVals, name, attributeValue(att))
+		}
+	}
+	return keyVals
+}
+
+func (c *converter) spanKeyVals(span ptrace.Span) []interface{} {
+	keyVals := []interface{}{
+		c.args.Overrides.SpanNameKey, span.Name(),
+		c.args.Overrides.DurationKey, spanDuration(span),
+	}
+
+	// Skip STATUS_CODE_UNSET to be less spammy
+	if span.Status().Code() != ptrace.StatusCodeUnset {
+		keyVals = append(keyVals, c.args.Overrides.StatusKey, span.Status().Code().String())
+	}
+
+	for _, name := range c.args.SpanAttributes {
+		if att, ok := span.Attributes().Get(name); ok {
+			keyVals = append(keyVals, name, attributeValue(att))
+		}
+	}
+	return keyVals
+}
+
+func (c *converter) eventKeyVals(span ptrace.Span, event ptrace.SpanEvent) []interface{} {
+	keyVals := []interface{}{
+		c.args.Overrides.SpanNameKey, span.Name(),
+		c.args.Overrides.EventNameKey, event.Name(),
+	}
+
+	for _, name := range c.args.EventAttributes {
+		if att, ok := event.Attributes().Get(name); ok {
+			keyVals = append(keyVals, name, attributeValue(att))
+		}
+	}
+	return keyVals
+}
+
+// appendRecord appends a log record of the given kind to records, unless
+// it's over the rate limit. The keys
+// listed in the labels argument are added as attributes, along with the hint
+// for otelcol.exporter.loki to convert them to labels.
+func (c *converter) appendRecord(records plog.LogRecordSlice, kind string, span ptrace.Span, ts pcommon.Timestamp, keyVals []interface{}) {
+	if c.limiter != nil && !c.limiter.Allow() {
+		c.dropped.Inc()
+		return
+	}
+
+	keyVals = append(keyVals, c.args.Overrides.TraceIDKey, span.TraceID().HexString())
+	line, err := logfmt.MarshalKeyvals(keyVals...)
+	if err != nil {
+		// Keys are always strings and values are always formattable, so this
+		// should never happen.
+		return
+	}
+
+	lr := records.AppendEmpty()
+	lr.Body().SetStr(string(line))
+	lr.SetTimestamp(ts)
+	lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Now()))
+	lr.SetTraceID(span.TraceID())
+	lr.SetSpanID(span.SpanID())
+
+	attrs := lr.Attributes()
+	labelNames := []string{c.args.Overrides.LogsTag}
+	attrs.PutStr(c.args.Overrides.LogsTag, kind)
+	for i := 0; i < len(keyVals); i += 2 {
+		key := keyVals[i].(string)
+		if _, ok := c.labels[key]; !ok {
+			continue
+		}
+		// Loki does not accept "." as a valid character for labels
+		name := config.SanitizeLabelName(key)
+		if _, exists := attrs.Get(name); !exists {
+			labelNames = append(labelNames, name)
+		}
+		attrs.PutStr(name, fmt.Sprintf("%v", keyVals[i+1]))
+	}
+	attrs.PutStr(hintAttributes, strings.Join(labelNames, ","))
+}
+
+func spanDuration(span ptrace.Span) string {
+	dur := int64(span.EndTimestamp() - span.StartTimestamp())
+	return strconv.FormatInt(dur, 10) + "ns"
+}
+
+func attributeValue(att pcommon.Value) interface{} {
+	switch att.Type() {
+	case pcommon.ValueTypeStr:
+		return att.Str()
+	case pcommon.ValueTypeInt:
+		return att.Int()
+	case pcommon.ValueTypeDouble:
+		return att.Double()
+	case pcommon.ValueTypeBool:
+		return att.Bool()
+	default:
+		return att.AsString()
+	}
+}
@@ -0,0 +1,176 @@
+// Package spanlogs provides an otelcol.connector.spanlogs component.
+package spanlogs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/lazyconsumer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.connector.spanlogs",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.connector.spanlogs component.
+type Arguments struct {
+	// Which log lines are created: one per span, per root span, per process
+	// (batch of spans of a resource within a trace), and per span event.
+	Spans     bool `river:"spans,attr,optional"`
+	Roots     bool `river:"roots,attr,optional"`
+	Processes bool `river:"processes,attr,optional"`
+	Events    bool `river:"events,attr,optional"`
+
+	// Attributes included in log lines, read from spans, resources, and span
+	// events respectively.
+	SpanAttributes    []string `river:"span_attributes,attr,optional"`
+	ProcessAttributes []string `river:"process_attributes,attr,optional"`
+	EventAttributes   []string `river:"event_attributes,attr,optional"`
+
+	// Keys of log lines which are also added as labels to the log records.
+	Labels []string `river:"labels,attr,optional"`
+
+	Overrides OverrideConfig   `river:"overrides,block,optional"`
+	RateLimit *RateLimitConfig `river:"rate_limit,block,optional"`
+
+	// Output configures where to send the log records.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// OverrideConfig overrides the keys used in log lines.
+type OverrideConfig struct {
+	LogsTag      string `river:"logs_instance_tag,attr,optional"`
+	ServiceKey   string `river:"service_key,attr,optional"`
+	SpanNameKey  string `river:"span_name_key,attr,optional"`
+	StatusKey    string `river:"status_key,attr,optional"`
+	DurationKey  string `river:"duration_key,attr,optional"`
+	TraceIDKey   string `river:"trace_id_key,attr,optional"`
+	EventNameKey string `river:"event_name_key,attr,optional"`
+}
+
+// RateLimitConfig limits the number of log lines created per second. Lines
+// over the limit are dropped.
+type RateLimitConfig struct {
+	Rate  float64 `river:"rate,attr"`
+	Burst int     `river:"burst,attr,optional"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Overrides: OverrideConfig{
+		LogsTag:      "traces",
+		ServiceKey:   "svc",
+		SpanNameKey:  "span",
+		StatusKey:    "status",
+		DurationKey:  "dur",
+		TraceIDKey:   "tid",
+		EventNameKey: "event",
+	},
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if !args.Spans && !args.Roots && !args.Processes && !args.Events {
+		return fmt.Errorf("at least one of spans, roots, processes, or events must be enabled")
+	}
+
+	o := args.Overrides
+	for name, key := range map[string]string{
+		"logs_instance_tag": o.LogsTag,
+		"service_key":       o.ServiceKey,
+		"span_name_key":     o.SpanNameKey,
+		"status_key":        o.StatusKey,
+		"duration_key":      o.DurationKey,
+		"trace_id_key":      o.TraceIDKey,
+		"event_name_key":    o.EventNameKey,
+	} {
+		if key == "" {
+			return fmt.Errorf("overrides.%s must not be empty", name)
+		}
+	}
+
+	if rl := args.RateLimit; rl != nil {
+		if rl.Rate <= 0 {
+			return fmt.Errorf("rate_limit.rate must be greater than 0")
+		}
+		if rl.Burst < 0 {
+			return fmt.Errorf("rate_limit.burst must not be negative")
+		}
+	}
+	return nil
+}
+
+// Component is the otelcol.connector.spanlogs component.
+type Component struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	opts         component.Options
+	consumer     *lazyconsumer.Consumer
+	droppedLines prometheus.Counter
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new otelcol.connector.spanlogs component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &Component{
+		ctx:    ctx,
+		cancel: cancel,
+
+		opts:     opts,
+		consumer: lazyconsumer.New(ctx),
+		droppedLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otelcol_connector_spanlogs_dropped_lines_total",
+			Help: "Total number of log lines dropped by the rate limit.",
+		}),
+	}
+	if err := opts.Registerer.Register(c.droppedLines); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// Immediately export the consumer, which never changes through the
+	// lifetime of the component.
+	opts.OnStateChange(otelcol.ConsumerExports{Input: c.consumer})
+
+	if err := c.Update(args); err != nil {
+		cancel()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	defer c.cancel()
+
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(newConfig component.Arguments) error {
+	args := newConfig.(Arguments)
+
+	c.consumer.SetConsumers(newConverter(args, c.droppedLines), nil, nil)
+	return nil
+}
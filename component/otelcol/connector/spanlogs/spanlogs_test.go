@@ -0,0 +1,198 @@
+package spanlogs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+func testTraces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr(semconv.AttributeServiceName, "api")
+	rs.Resource().Attributes().PutStr("k8s.namespace.name", "prod")
+
+	start := time.Unix(100, 0)
+	span := rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetTraceID(pcommon.TraceID([16]byte{1}))
+	span.SetSpanID(pcommon.SpanID([8]byte{1}))
+	span.SetName("GET /users")
+	span.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	span.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(25 * time.Millisecond)))
+	span.Status().SetCode(ptrace.StatusCodeError)
+	span.Attributes().PutStr("http.method", "GET")
+	span.Attributes().PutInt("http.status_code", 500)
+
+	event := span.Events().AppendEmpty()
+	event.SetName("exception")
+	event.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(10 * time.Millisecond)))
+	event.Attributes().PutStr("exception.message", "connection refused")
+
+	child := rs.ScopeSpans().At(0).Spans().AppendEmpty()
+	child.SetTraceID(pcommon.TraceID([16]byte{1}))
+	child.SetSpanID(pcommon.SpanID([8]byte{2}))
+	child.SetParentSpanID(pcommon.SpanID([8]byte{1}))
+	child.SetName("SELECT users")
+	child.SetStartTimestamp(pcommon.NewTimestampFromTime(start))
+	child.SetEndTimestamp(pcommon.NewTimestampFromTime(start.Add(5 * time.Millisecond)))
+	return td
+}
+
+func Test(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.connector.spanlogs")
+	require.NoError(t, err)
+
+	cfg := `
+		roots              = true
+		events             = true
+		span_attributes    = ["http.status_code"]
+		process_attributes = ["k8s.namespace.name"]
+		event_attributes   = ["exception.message"]
+		labels             = ["svc", "k8s.namespace.name"]
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	logsCh := make(chan plog.Logs, 1)
+	args.Output = &otelcol.ConsumerArguments{
+		Logs: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeLogsFunc: func(ctx context.Context, ld plog.Logs) error {
+				select {
+				case logsCh <- ld:
+				default:
+				}
+				return nil
+			},
+		}},
+	}
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	require.NoError(t, exports.Input.ConsumeTraces(ctx, testTraces()))
+
+	var ld plog.Logs
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for logs")
+	case ld = <-logsCh:
+	}
+
+	// Only the root span is logged, along with its event.
+	records := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	require.Equal(t, 2, records.Len())
+
+	root := records.At(0)
+	require.Equal(t, `span="GET /users" dur=25000000ns status=STATUS_CODE_ERROR http.status_code=500 svc=api k8s.namespace.name=prod tid=01000000000000000000000000000000`, root.Body().Str())
+	require.Equal(t, map[string]interface{}{
+		"traces":                "root",
+		"svc":                   "api",
+		"k8s_namespace_name":    "prod",
+		"loki.attribute.labels": "traces,svc,k8s_namespace_name",
+	}, root.Attributes().AsRaw())
+	require.Equal(t, pcommon.NewTimestampFromTime(time.Unix(100, 0)), root.Timestamp())
+	require.Equal(t, pcommon.TraceID([16]byte{1}), root.TraceID())
+
+	event := records.At(1)
+	require.Equal(t, `span="GET /users" event=exception exception.message="connection refused" svc=api k8s.namespace.name=prod tid=01000000000000000000000000000000`, event.Body().Str())
+	require.Equal(t, "event", event.Attributes().AsRaw()["traces"])
+	require.Equal(t, pcommon.NewTimestampFromTime(time.Unix(100, 0).Add(10*time.Millisecond)), event.Timestamp())
+}
+
+func TestConverter_Processes(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		spans     = true
+		processes = true
+		output {}
+	`), &args))
+
+	var got plog.Logs
+	args.Output.Logs = []otelcol.Consumer{&fakeconsumer.Consumer{
+		ConsumeLogsFunc: func(_ context.Context, ld plog.Logs) error {
+			got = ld
+			return nil
+		},
+	}}
+
+	require.NoError(t, newConverter(args, prometheus.NewCounter(prometheus.CounterOpts{})).ConsumeTraces(context.Background(), testTraces()))
+
+	// Both spans are logged, and a single process line for their trace.
+	var kinds []interface{}
+	records := got.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i := 0; i < records.Len(); i++ {
+		kinds = append(kinds, records.At(i).Attributes().AsRaw()["traces"])
+	}
+	require.Equal(t, []interface{}{"span", "process", "span"}, kinds)
+}
+
+func TestConverter_RateLimit(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		spans  = true
+		events = true
+		output {}
+
+		rate_limit {
+			rate  = 0.001
+			burst = 2
+		}
+	`), &args))
+
+	var got plog.Logs
+	args.Output.Logs = []otelcol.Consumer{&fakeconsumer.Consumer{
+		ConsumeLogsFunc: func(_ context.Context, ld plog.Logs) error {
+			got = ld
+			return nil
+		},
+	}}
+
+	// Three lines are created but only the burst of two is sent.
+	dropped := prometheus.NewCounter(prometheus.CounterOpts{})
+	require.NoError(t, newConverter(args, dropped).ConsumeTraces(context.Background(), testTraces()))
+	require.Equal(t, 2, got.LogRecordCount())
+	require.Equal(t, 1.0, testutil.ToFloat64(dropped))
+}
+
+func TestArguments_Validate(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		output {}
+	`), &args)
+	require.EqualError(t, err, "at least one of spans, roots, processes, or events must be enabled")
+
+	err = river.Unmarshal([]byte(`
+		spans = true
+		output {}
+		rate_limit {
+			rate = 0
+		}
+	`), &args)
+	require.EqualError(t, err, "rate_limit.rate must be greater than 0")
+}
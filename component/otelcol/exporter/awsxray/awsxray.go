@@ -0,0 +1,96 @@
+// Package awsxray provides an otelcol.exporter.awsxray component.
+package awsxray
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/exporter"
+	"github.com/grafana/agent/component/otelcol/exporter/awsxray/internal/awsxrayexporter"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.exporter.awsxray",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := awsxrayexporter.NewFactory()
+			return exporter.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.exporter.awsxray component.
+type Arguments struct {
+	Region      string `river:"region,attr,optional"`
+	Endpoint    string `river:"endpoint,attr,optional"`
+	RoleARN     string `river:"role_arn,attr,optional"`
+	ResourceARN string `river:"resource_arn,attr,optional"`
+
+	RequestTimeout time.Duration `river:"request_timeout,attr,optional"`
+	MaxRetries     int           `river:"max_retries,attr,optional"`
+	NoVerifySSL    bool          `river:"no_verify_ssl,attr,optional"`
+	ProxyAddress   string        `river:"proxy_address,attr,optional"`
+
+	IndexedAttributes  []string `river:"indexed_attributes,attr,optional"`
+	IndexAllAttributes bool     `river:"index_all_attributes,attr,optional"`
+}
+
+var (
+	_ exporter.Arguments = Arguments{}
+)
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	RequestTimeout: 30 * time.Second,
+	MaxRetries:     2,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	cfg, err := args.Convert()
+	if err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+// Convert implements exporter.Arguments.
+func (args Arguments) Convert() (otelconfig.Exporter, error) {
+	return &awsxrayexporter.Config{
+		ExporterSettings: otelconfig.NewExporterSettings(otelconfig.NewComponentID("awsxray")),
+
+		Region:      args.Region,
+		Endpoint:    args.Endpoint,
+		RoleARN:     args.RoleARN,
+		ResourceARN: args.ResourceARN,
+
+		RequestTimeout: args.RequestTimeout,
+		MaxRetries:     args.MaxRetries,
+		NoVerifySSL:    args.NoVerifySSL,
+		ProxyAddress:   args.ProxyAddress,
+
+		IndexedAttributes:  args.IndexedAttributes,
+		IndexAllAttributes: args.IndexAllAttributes,
+	}, nil
+}
+
+// Extensions implements exporter.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements exporter.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
@@ -0,0 +1,160 @@
+package awsxray_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/exporter/awsxray"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Test performs a basic integration test which runs the
+// otelcol.exporter.awsxray component and ensures that it sends segments to
+// the X-Ray API.
+func Test(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "access-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret-key")
+
+	ch := make(chan []string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/TraceSegments", r.URL.Path)
+
+		var req struct {
+			TraceSegmentDocuments []string
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		ch <- req.TraceSegmentDocuments
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"UnprocessedTraceSegments": []}`))
+	}))
+	defer srv.Close()
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.exporter.awsxray")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		region             = "us-east-1"
+		endpoint           = %q
+		resource_arn       = "arn:aws:ec2:us-east-1:123456789012:instance/i-0123"
+		indexed_attributes = ["customer.id"]
+	`, srv.URL)
+	var args awsxray.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	require.NoError(t, exports.Input.ConsumeTraces(ctx, createTestTraces()))
+
+	var docs []string
+	select {
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "failed waiting for segments")
+	case docs = <-ch:
+	}
+
+	// The span with an expired trace ID is dropped.
+	require.Len(t, docs, 2)
+
+	var server, client map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(docs[0]), &server))
+	require.NoError(t, json.Unmarshal([]byte(docs[1]), &client))
+
+	require.Equal(t, "checkout", server["name"])
+	require.Equal(t, "0102030405060708", server["id"])
+	require.Regexp(t, `^1-[0-9a-f]{8}-[0-9a-f]{24}$`, server["trace_id"])
+	require.Equal(t, "arn:aws:ec2:us-east-1:123456789012:instance/i-0123", server["resource_arn"])
+	require.Equal(t, map[string]interface{}{"customer_id": "c-42"}, server["annotations"])
+	require.Equal(t, map[string]interface{}{
+		"default": map[string]interface{}{"http.route": "/cart"},
+	}, server["metadata"])
+	require.Nil(t, server["type"])
+
+	require.Equal(t, "payments", client["name"])
+	require.Equal(t, "subsegment", client["type"])
+	require.Equal(t, "remote", client["namespace"])
+	require.Equal(t, "0102030405060708", client["parent_id"])
+	require.Equal(t, server["trace_id"], client["trace_id"])
+	require.Equal(t, true, client["fault"])
+	require.Equal(t, map[string]interface{}{
+		"request":  map[string]interface{}{"method": "POST", "url": "https://payments.local/charge"},
+		"response": map[string]interface{}{"status": float64(503)},
+	}, client["http"])
+}
+
+func TestArguments_Validate(t *testing.T) {
+	var args awsxray.Arguments
+	err := river.Unmarshal([]byte(`request_timeout = "0s"`), &args)
+	require.EqualError(t, err, "request_timeout must be greater than 0")
+}
+
+func createTestTraces() ptrace.Traces {
+	traces := ptrace.NewTraces()
+
+	rs := traces.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	spans := rs.ScopeSpans().AppendEmpty().Spans()
+
+	now := time.Now()
+	traceID := newTraceID(now)
+
+	server := spans.AppendEmpty()
+	server.SetName("POST /cart")
+	server.SetKind(ptrace.SpanKindServer)
+	server.SetTraceID(traceID)
+	server.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+	server.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+	server.SetEndTimestamp(pcommon.NewTimestampFromTime(now.Add(time.Second)))
+	server.Attributes().PutStr("customer.id", "c-42")
+	server.Attributes().PutStr("http.route", "/cart")
+
+	client := spans.AppendEmpty()
+	client.SetName("charge")
+	client.SetKind(ptrace.SpanKindClient)
+	client.SetTraceID(traceID)
+	client.SetSpanID(pcommon.SpanID{2, 2, 3, 4, 5, 6, 7, 8})
+	client.SetParentSpanID(server.SpanID())
+	client.SetStartTimestamp(pcommon.NewTimestampFromTime(now))
+	client.SetEndTimestamp(pcommon.NewTimestampFromTime(now.Add(500 * time.Millisecond)))
+	client.Attributes().PutStr("peer.service", "payments")
+	client.Attributes().PutStr("http.method", "POST")
+	client.Attributes().PutStr("http.url", "https://payments.local/charge")
+	client.Attributes().PutInt("http.status_code", 503)
+
+	expired := spans.AppendEmpty()
+	expired.SetName("old")
+	expired.SetTraceID(newTraceID(now.Add(-60 * 24 * time.Hour)))
+	expired.SetSpanID(pcommon.SpanID{3, 2, 3, 4, 5, 6, 7, 8})
+
+	return traces
+}
+
+// newTraceID returns a trace ID starting with the epoch of ts, as required
+// by X-Ray.
+func newTraceID(ts time.Time) pcommon.TraceID {
+	var id pcommon.TraceID
+	binary.BigEndian.PutUint32(id[:4], uint32(ts.Unix()))
+	copy(id[4:], []byte{0xde, 0xad, 0xbe, 0xef, 1, 2, 3, 4, 5, 6, 7, 8})
+	return id
+}
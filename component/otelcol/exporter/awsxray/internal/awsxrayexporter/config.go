@@ -0,0 +1,55 @@
+package awsxrayexporter
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"go.opentelemetry.io/collector/config"
+)
+
+// Config configures the AWS X-Ray exporter.
+type Config struct {
+	config.ExporterSettings `mapstructure:",squash"`
+
+	// Region is the AWS region traces are sent to. When empty, the region is
+	// read from the environment, the shared AWS configuration, or the EC2
+	// instance metadata, in that order.
+	Region string `mapstructure:"region"`
+	// Endpoint overrides the X-Ray API endpoint.
+	Endpoint string `mapstructure:"endpoint"`
+	// RoleARN is an IAM role assumed to send traces.
+	RoleARN string `mapstructure:"role_arn"`
+	// ResourceARN is set as the resource_arn of every segment.
+	ResourceARN string `mapstructure:"resource_arn"`
+
+	RequestTimeout time.Duration `mapstructure:"request_timeout"`
+	MaxRetries     int           `mapstructure:"max_retries"`
+	NoVerifySSL    bool          `mapstructure:"no_verify_ssl"`
+	ProxyAddress   string        `mapstructure:"proxy_address"`
+
+	// IndexedAttributes lists the span attributes converted to X-Ray
+	// annotations, which can be searched. Other attributes are converted to
+	// metadata.
+	IndexedAttributes []string `mapstructure:"indexed_attributes"`
+	// IndexAllAttributes converts all span attributes to annotations.
+	IndexAllAttributes bool `mapstructure:"index_all_attributes"`
+}
+
+var _ config.Exporter = (*Config)(nil)
+
+// Validate checks that the exporter configuration is valid.
+func (cfg *Config) Validate() error {
+	if cfg.RequestTimeout <= 0 {
+		return fmt.Errorf("request_timeout must be greater than 0")
+	}
+	if cfg.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative")
+	}
+	if cfg.ProxyAddress != "" {
+		if _, err := url.Parse(cfg.ProxyAddress); err != nil {
+			return fmt.Errorf("invalid proxy_address: %w", err)
+		}
+	}
+	return nil
+}
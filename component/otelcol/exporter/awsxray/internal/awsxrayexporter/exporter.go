@@ -0,0 +1,144 @@
+package awsxrayexporter
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/xray"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+// maxSegmentsPerRequest is the maximum number of segment documents the
+// PutTraceSegments API accepts in a single request.
+const maxSegmentsPerRequest = 50
+
+type tracesExporter struct {
+	logger *zap.Logger
+	client *xray.XRay
+	conv   *segmentConverter
+}
+
+func newTracesExporter(logger *zap.Logger, cfg *Config) (*tracesExporter, error) {
+	sess, err := newSession(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tracesExporter{
+		logger: logger,
+		client: xray.New(sess),
+		conv: &segmentConverter{
+			resourceARN:        cfg.ResourceARN,
+			indexedAttributes:  toSet(cfg.IndexedAttributes),
+			indexAllAttributes: cfg.IndexAllAttributes,
+		},
+	}, nil
+}
+
+// newSession creates an AWS session for cfg, resolving the region and
+// assuming the configured role.
+func newSession(cfg *Config) (*session.Session, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: cfg.NoVerifySSL}
+	if cfg.ProxyAddress != "" {
+		proxyURL, err := url.Parse(cfg.ProxyAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy_address: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	awsConfig := aws.NewConfig().
+		WithHTTPClient(&http.Client{Transport: transport, Timeout: cfg.RequestTimeout}).
+		WithMaxRetries(cfg.MaxRetries)
+	if cfg.Region != "" {
+		awsConfig = awsConfig.WithRegion(cfg.Region)
+	}
+	if cfg.Endpoint != "" {
+		awsConfig = awsConfig.WithEndpoint(cfg.Endpoint)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating AWS session: %w", err)
+	}
+
+	if aws.StringValue(sess.Config.Region) == "" {
+		region, err := ec2metadata.New(sess).Region()
+		if err != nil {
+			return nil, fmt.Errorf("region not set and could not be retrieved from EC2 instance metadata: %w", err)
+		}
+		sess.Config.Region = aws.String(region)
+	}
+
+	if cfg.RoleARN != "" {
+		sess.Config.Credentials = stscreds.NewCredentials(sess, cfg.RoleARN)
+	}
+	return sess, nil
+}
+
+func (e *tracesExporter) pushTraces(ctx context.Context, td ptrace.Traces) error {
+	documents := make([]*string, 0, td.SpanCount())
+
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		rs := rss.At(i)
+		sss := rs.ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				doc, err := e.conv.convert(spans.At(k), rs.Resource())
+				if err != nil {
+					// Spans X-Ray can't accept are dropped rather than failing the
+					// whole request.
+					e.logger.Debug("dropping span which can't be converted to an X-Ray segment", zap.Error(err))
+					continue
+				}
+				documents = append(documents, aws.String(doc))
+			}
+		}
+	}
+
+	for len(documents) > 0 {
+		n := len(documents)
+		if n > maxSegmentsPerRequest {
+			n = maxSegmentsPerRequest
+		}
+
+		out, err := e.client.PutTraceSegmentsWithContext(ctx, &xray.PutTraceSegmentsInput{
+			TraceSegmentDocuments: documents[:n],
+		})
+		if err != nil {
+			return fmt.Errorf("sending segments to X-Ray: %w", err)
+		}
+		for _, u := range out.UnprocessedTraceSegments {
+			e.logger.Warn("X-Ray rejected a segment",
+				zap.String("id", aws.StringValue(u.Id)),
+				zap.String("error_code", aws.StringValue(u.ErrorCode)),
+				zap.String("message", aws.StringValue(u.Message)),
+			)
+		}
+
+		documents = documents[n:]
+	}
+	return nil
+}
+
+func toSet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,42 @@
+// Package awsxrayexporter implements an exporter which sends traces to AWS
+// X-Ray.
+package awsxrayexporter
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+const (
+	// The value of exporter "type" in configuration.
+	typeStr = "awsxray"
+)
+
+// NewFactory returns a new factory for the AWS X-Ray exporter.
+func NewFactory() component.ExporterFactory {
+	return component.NewExporterFactory(
+		typeStr,
+		createDefaultConfig,
+		component.WithTracesExporter(createTracesExporter, component.StabilityLevelBeta),
+	)
+}
+
+func createDefaultConfig() config.Exporter {
+	return &Config{
+		ExporterSettings: config.NewExporterSettings(config.NewComponentID(typeStr)),
+		RequestTimeout:   30 * time.Second,
+		MaxRetries:       2,
+	}
+}
+
+func createTracesExporter(ctx context.Context, set component.ExporterCreateSettings, cfg config.Exporter) (component.TracesExporter, error) {
+	e, err := newTracesExporter(set.Logger, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
+	return exporterhelper.NewTracesExporter(ctx, set, cfg, e.pushTraces)
+}
@@ -0,0 +1,372 @@
+package awsxrayexporter
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+// segment is an X-Ray segment or subsegment document. Only the fields set
+// from OpenTelemetry spans are included.
+type segment struct {
+	Name        string  `json:"name"`
+	ID          string  `json:"id"`
+	TraceID     string  `json:"trace_id"`
+	StartTime   float64 `json:"start_time"`
+	EndTime     float64 `json:"end_time"`
+	Type        string  `json:"type,omitempty"`
+	ParentID    string  `json:"parent_id,omitempty"`
+	Namespace   string  `json:"namespace,omitempty"`
+	Origin      string  `json:"origin,omitempty"`
+	ResourceARN string  `json:"resource_arn,omitempty"`
+
+	Error    bool   `json:"error,omitempty"`
+	Fault    bool   `json:"fault,omitempty"`
+	Throttle bool   `json:"throttle,omitempty"`
+	Cause    *cause `json:"cause,omitempty"`
+
+	HTTP        *httpData                         `json:"http,omitempty"`
+	AWS         *awsData                          `json:"aws,omitempty"`
+	Annotations map[string]interface{}            `json:"annotations,omitempty"`
+	Metadata    map[string]map[string]interface{} `json:"metadata,omitempty"`
+}
+
+type cause struct {
+	Exceptions []exception `json:"exceptions"`
+}
+
+type exception struct {
+	ID      string `json:"id"`
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type httpData struct {
+	Request  *httpRequest  `json:"request,omitempty"`
+	Response *httpResponse `json:"response,omitempty"`
+}
+
+type httpRequest struct {
+	Method    string `json:"method,omitempty"`
+	URL       string `json:"url,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+}
+
+type httpResponse struct {
+	Status        int64 `json:"status,omitempty"`
+	ContentLength int64 `json:"content_length,omitempty"`
+}
+
+type awsData struct {
+	AccountID string   `json:"account_id,omitempty"`
+	EC2       *ec2Data `json:"ec2,omitempty"`
+	EKS       *eksData `json:"eks,omitempty"`
+}
+
+type ec2Data struct {
+	InstanceID       string `json:"instance_id,omitempty"`
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+}
+
+type eksData struct {
+	ClusterName string `json:"cluster_name,omitempty"`
+	Pod         string `json:"pod,omitempty"`
+	ContainerID string `json:"container_id,omitempty"`
+}
+
+const (
+	// X-Ray rejects traces whose ID encodes a time older than maxTraceAge or
+	// further than maxTraceSkew in the future.
+	maxTraceAge  = 28 * 24 * time.Hour
+	maxTraceSkew = 5 * time.Minute
+
+	maxNameLength = 200
+)
+
+// segmentConverter converts spans into X-Ray segment documents.
+type segmentConverter struct {
+	resourceARN        string
+	indexedAttributes  map[string]struct{}
+	indexAllAttributes bool
+
+	// nowFunc is used to validate trace IDs; time.Now is used when nil.
+	nowFunc func() time.Time
+}
+
+// convert returns the JSON segment document of span. Spans which are the
+// entry point of a service become segments, and other spans become
+// subsegments of their parent.
+func (c *segmentConverter) convert(span ptrace.Span, resource pcommon.Resource) (string, error) {
+	traceID, err := c.convertTraceID(span.TraceID())
+	if err != nil {
+		return "", err
+	}
+	if span.SpanID().IsEmpty() {
+		return "", fmt.Errorf("span %q has no span ID", span.Name())
+	}
+
+	attrs := span.Attributes()
+	resourceAttrs := resource.Attributes()
+
+	seg := segment{
+		ID:          span.SpanID().HexString(),
+		TraceID:     traceID,
+		StartTime:   toEpochSeconds(span.StartTimestamp()),
+		EndTime:     toEpochSeconds(span.EndTimestamp()),
+		ResourceARN: c.resourceARN,
+	}
+
+	remote := span.Kind() == ptrace.SpanKindClient || span.Kind() == ptrace.SpanKindProducer
+	if span.Kind() == ptrace.SpanKindServer || span.ParentSpanID().IsEmpty() {
+		seg.Name = getString(resourceAttrs, semconv.AttributeServiceName)
+		seg.Origin, seg.AWS = convertCloud(resourceAttrs)
+	} else {
+		seg.Type = "subsegment"
+		seg.ParentID = span.ParentSpanID().HexString()
+		if remote {
+			seg.Name = getString(attrs, semconv.AttributePeerService)
+			seg.Namespace = "remote"
+		}
+		if getString(attrs, semconv.AttributeRPCSystem) == "aws-api" {
+			seg.Namespace = "aws"
+		}
+	}
+	if seg.Name == "" {
+		seg.Name = span.Name()
+	}
+	seg.Name = sanitizeName(seg.Name)
+
+	seg.HTTP = convertHTTP(attrs)
+	c.convertStatus(&seg, span)
+	c.convertAttributes(&seg, attrs)
+	return marshalSegment(seg)
+}
+
+// convertTraceID converts id to the X-Ray format, which holds the time the
+// trace started in its first four bytes.
+func (c *segmentConverter) convertTraceID(id pcommon.TraceID) (string, error) {
+	if id.IsEmpty() {
+		return "", fmt.Errorf("span has no trace ID")
+	}
+
+	hexID := id.HexString()
+	epoch, err := parseEpoch(hexID[:8])
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	if c.nowFunc != nil {
+		now = c.nowFunc()
+	}
+	if epoch.Before(now.Add(-maxTraceAge)) || epoch.After(now.Add(maxTraceSkew)) {
+		return "", fmt.Errorf("trace ID %s doesn't start with a recent timestamp, as required by X-Ray", hexID)
+	}
+	return "1-" + hexID[:8] + "-" + hexID[8:], nil
+}
+
+func parseEpoch(hexEpoch string) (time.Time, error) {
+	b, err := hex.DecodeString(hexEpoch)
+	if err != nil {
+		return time.Time{}, err
+	}
+	secs := int64(b[0])<<24 | int64(b[1])<<16 | int64(b[2])<<8 | int64(b[3])
+	return time.Unix(secs, 0), nil
+}
+
+func (c *segmentConverter) convertStatus(seg *segment, span ptrace.Span) {
+	status := int64(0)
+	if seg.HTTP != nil && seg.HTTP.Response != nil {
+		status = seg.HTTP.Response.Status
+	}
+
+	switch {
+	case status == 429:
+		seg.Error, seg.Throttle = true, true
+	case status >= 400 && status < 500:
+		seg.Error = true
+	case status >= 500:
+		seg.Fault = true
+	case span.Status().Code() == ptrace.StatusCodeError:
+		seg.Fault = true
+	}
+
+	events := span.Events()
+	for i := 0; i < events.Len(); i++ {
+		event := events.At(i)
+		if event.Name() != "exception" {
+			continue
+		}
+		if seg.Cause == nil {
+			seg.Cause = &cause{}
+		}
+		seg.Cause.Exceptions = append(seg.Cause.Exceptions, exception{
+			ID:      newExceptionID(),
+			Type:    getString(event.Attributes(), semconv.AttributeExceptionType),
+			Message: getString(event.Attributes(), semconv.AttributeExceptionMessage),
+		})
+	}
+}
+
+// convertAttributes stores span attributes as annotations when they're
+// indexed, and as metadata otherwise. Annotations only support strings,
+// numbers, and booleans.
+func (c *segmentConverter) convertAttributes(seg *segment, attrs pcommon.Map) {
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		_, indexed := c.indexedAttributes[k]
+		if indexed || c.indexAllAttributes {
+			switch v.Type() {
+			case pcommon.ValueTypeStr, pcommon.ValueTypeInt, pcommon.ValueTypeDouble, pcommon.ValueTypeBool:
+				if seg.Annotations == nil {
+					seg.Annotations = make(map[string]interface{})
+				}
+				seg.Annotations[sanitizeAnnotationKey(k)] = v.AsRaw()
+				return true
+			}
+		}
+
+		if seg.Metadata == nil {
+			seg.Metadata = map[string]map[string]interface{}{"default": {}}
+		}
+		seg.Metadata["default"][k] = v.AsRaw()
+		return true
+	})
+}
+
+func convertHTTP(attrs pcommon.Map) *httpData {
+	var (
+		req  httpRequest
+		resp httpResponse
+	)
+
+	req.Method = getString(attrs, semconv.AttributeHTTPMethod)
+	req.URL = getString(attrs, semconv.AttributeHTTPURL)
+	if req.URL == "" {
+		if host := getString(attrs, semconv.AttributeHTTPHost); host != "" {
+			scheme := getString(attrs, semconv.AttributeHTTPScheme)
+			if scheme == "" {
+				scheme = "http"
+			}
+			req.URL = scheme + "://" + host + getString(attrs, semconv.AttributeHTTPTarget)
+		}
+	}
+	req.UserAgent = getString(attrs, semconv.AttributeHTTPUserAgent)
+	req.ClientIP = getString(attrs, semconv.AttributeHTTPClientIP)
+
+	if v, ok := attrs.Get(semconv.AttributeHTTPStatusCode); ok && v.Type() == pcommon.ValueTypeInt {
+		resp.Status = v.Int()
+	}
+	if v, ok := attrs.Get(semconv.AttributeHTTPResponseContentLength); ok && v.Type() == pcommon.ValueTypeInt {
+		resp.ContentLength = v.Int()
+	}
+
+	if req == (httpRequest{}) && resp == (httpResponse{}) {
+		return nil
+	}
+
+	data := &httpData{}
+	if req != (httpRequest{}) {
+		data.Request = &req
+	}
+	if resp != (httpResponse{}) {
+		data.Response = &resp
+	}
+	return data
+}
+
+// convertCloud returns the origin and AWS metadata of segments from
+// resource attributes.
+func convertCloud(attrs pcommon.Map) (string, *awsData) {
+	if getString(attrs, semconv.AttributeCloudProvider) != semconv.AttributeCloudProviderAWS {
+		return "", nil
+	}
+
+	data := &awsData{AccountID: getString(attrs, semconv.AttributeCloudAccountID)}
+
+	var origin string
+	switch getString(attrs, semconv.AttributeCloudPlatform) {
+	case semconv.AttributeCloudPlatformAWSEC2:
+		origin = "AWS::EC2::Instance"
+	case semconv.AttributeCloudPlatformAWSECS:
+		origin = "AWS::ECS::Container"
+	case semconv.AttributeCloudPlatformAWSEKS:
+		origin = "AWS::EKS::Container"
+		data.EKS = &eksData{
+			ClusterName: getString(attrs, semconv.AttributeK8SClusterName),
+			Pod:         getString(attrs, semconv.AttributeK8SPodName),
+			ContainerID: getString(attrs, semconv.AttributeContainerID),
+		}
+	case semconv.AttributeCloudPlatformAWSElasticBeanstalk:
+		origin = "AWS::ElasticBeanstalk::Environment"
+	}
+
+	if instanceID := getString(attrs, semconv.AttributeHostID); instanceID != "" {
+		data.EC2 = &ec2Data{
+			InstanceID:       instanceID,
+			AvailabilityZone: getString(attrs, semconv.AttributeCloudAvailabilityZone),
+		}
+	}
+	return origin, data
+}
+
+func marshalSegment(seg segment) (string, error) {
+	b, err := json.Marshal(seg)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func toEpochSeconds(ts pcommon.Timestamp) float64 {
+	return float64(ts) / float64(time.Second)
+}
+
+func getString(attrs pcommon.Map, key string) string {
+	v, ok := attrs.Get(key)
+	if !ok {
+		return ""
+	}
+	return v.AsString()
+}
+
+// sanitizeName replaces characters X-Ray doesn't accept in segment names
+// and truncates names to the maximum length.
+func sanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsSpace(r) || strings.ContainsRune("_.:/%&#=+\\-@", r) {
+			return r
+		}
+		return '_'
+	}, name)
+
+	if runes := []rune(name); len(runes) > maxNameLength {
+		name = string(runes[:maxNameLength])
+	}
+	return name
+}
+
+// sanitizeAnnotationKey replaces characters X-Ray doesn't accept in
+// annotation keys, which may only hold letters, numbers, and underscores.
+func sanitizeAnnotationKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+func newExceptionID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,196 @@
+// Package kafka provides an otelcol.exporter.kafka component.
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/exporter"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/kafkaexporter"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	otelpexporterhelper "go.opentelemetry.io/collector/exporter/exporterhelper"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.exporter.kafka",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := encodingFactory{ExporterFactory: kafkaexporter.NewFactory()}
+			return exporter.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.exporter.kafka component.
+type Arguments struct {
+	Brokers         []string      `river:"brokers,attr,optional"`
+	ProtocolVersion string        `river:"protocol_version,attr"`
+	Topic           string        `river:"topic,attr,optional"`
+	Encoding        string        `river:"encoding,attr,optional"`
+	Timeout         time.Duration `river:"timeout,attr,optional"`
+
+	Authentication otelcol.KafkaAuthenticationArguments `river:"authentication,block,optional"`
+	Metadata       otelcol.KafkaMetadataArguments       `river:"metadata,block,optional"`
+	Producer       ProducerArguments                    `river:"producer,block,optional"`
+
+	Queue otelcol.QueueArguments `river:"sending_queue,block,optional"`
+	Retry otelcol.RetryArguments `river:"retry_on_failure,block,optional"`
+}
+
+var (
+	_ exporter.Arguments = Arguments{}
+)
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	// The topic is left empty so that each telemetry signal is sent to its own
+	// default topic.
+	Brokers:  []string{"localhost:9092"},
+	Encoding: "otlp_proto",
+	Timeout:  otelcol.DefaultTimeout,
+	Metadata: otelcol.KafkaMetadataArguments{
+		IncludeAllTopics: true,
+		Retry: otelcol.KafkaMetadataRetryArguments{
+			MaxRetries: 3,
+			Backoff:    250 * time.Millisecond,
+		},
+	},
+	Producer: ProducerArguments{
+		MaxMessageBytes:  1000000,
+		RequiredAcks:     1,
+		Compression:      "none",
+		FlushMaxMessages: 0,
+	},
+	Queue: otelcol.DefaultQueueArguments,
+	Retry: otelcol.DefaultRetryArguments,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if len(args.Brokers) == 0 {
+		return fmt.Errorf("at least one broker must be specified")
+	}
+	if len(encodingSignals[args.Encoding]) == 0 {
+		return fmt.Errorf("unsupported encoding %q", args.Encoding)
+	}
+	if _, err := sarama.ParseKafkaVersion(args.ProtocolVersion); err != nil {
+		return fmt.Errorf("invalid protocol_version: %w", err)
+	}
+
+	cfg, err := args.Convert()
+	if err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+// Convert implements exporter.Arguments.
+func (args Arguments) Convert() (otelconfig.Exporter, error) {
+	return &kafkaexporter.Config{
+		ExporterSettings: otelconfig.NewExporterSettings(otelconfig.NewComponentID("kafka")),
+		TimeoutSettings: otelpexporterhelper.TimeoutSettings{
+			Timeout: args.Timeout,
+		},
+		QueueSettings: *args.Queue.Convert(),
+		RetrySettings: *args.Retry.Convert(),
+
+		Brokers:         args.Brokers,
+		ProtocolVersion: args.ProtocolVersion,
+		Topic:           args.Topic,
+		Encoding:        args.Encoding,
+
+		Authentication: args.Authentication.Convert(),
+		Metadata:       args.Metadata.Convert(),
+		Producer:       args.Producer.Convert(),
+	}, nil
+}
+
+// Extensions implements exporter.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements exporter.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// ProducerArguments configures how the otelcol.exporter.kafka component
+// produces messages to the Kafka broker.
+type ProducerArguments struct {
+	MaxMessageBytes  int    `river:"max_message_bytes,attr,optional"`
+	RequiredAcks     int    `river:"required_acks,attr,optional"`
+	Compression      string `river:"compression,attr,optional"`
+	FlushMaxMessages int    `river:"flush_max_messages,attr,optional"`
+}
+
+// Convert converts args into the upstream type.
+func (args ProducerArguments) Convert() kafkaexporter.Producer {
+	return kafkaexporter.Producer{
+		MaxMessageBytes:  args.MaxMessageBytes,
+		RequiredAcks:     sarama.RequiredAcks(args.RequiredAcks),
+		Compression:      args.Compression,
+		FlushMaxMessages: args.FlushMaxMessages,
+	}
+}
+
+// encodingSignals holds the telemetry signals which can be sent with each
+// encoding supported by the upstream exporter.
+var encodingSignals = map[string][]otelconfig.DataType{
+	"otlp_proto":   {otelconfig.TracesDataType, otelconfig.MetricsDataType, otelconfig.LogsDataType},
+	"otlp_json":    {otelconfig.TracesDataType, otelconfig.MetricsDataType, otelconfig.LogsDataType},
+	"jaeger_proto": {otelconfig.TracesDataType},
+	"jaeger_json":  {otelconfig.TracesDataType},
+	"raw":          {otelconfig.LogsDataType},
+}
+
+// encodingFactory wraps the upstream factory so that exporters for signals
+// the configured encoding can't send aren't created. The upstream factory
+// fails to create them instead, which would prevent signal-specific
+// encodings from being used at all.
+type encodingFactory struct {
+	otelcomponent.ExporterFactory
+}
+
+func (f encodingFactory) CreateTracesExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.TracesExporter, error) {
+	if !supportsSignal(cfg, otelconfig.TracesDataType) {
+		return nil, otelcomponent.ErrDataTypeIsNotSupported
+	}
+	return f.ExporterFactory.CreateTracesExporter(ctx, set, cfg)
+}
+
+func (f encodingFactory) CreateMetricsExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.MetricsExporter, error) {
+	if !supportsSignal(cfg, otelconfig.MetricsDataType) {
+		return nil, otelcomponent.ErrDataTypeIsNotSupported
+	}
+	return f.ExporterFactory.CreateMetricsExporter(ctx, set, cfg)
+}
+
+func (f encodingFactory) CreateLogsExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.LogsExporter, error) {
+	if !supportsSignal(cfg, otelconfig.LogsDataType) {
+		return nil, otelcomponent.ErrDataTypeIsNotSupported
+	}
+	return f.ExporterFactory.CreateLogsExporter(ctx, set, cfg)
+}
+
+func supportsSignal(cfg otelconfig.Exporter, signal otelconfig.DataType) bool {
+	for _, s := range encodingSignals[cfg.(*kafkaexporter.Config).Encoding] {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package kafka_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/exporter/kafka"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/dskit/backoff"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Test performs a basic integration test which runs the
+// otelcol.exporter.kafka component and ensures that it produces traces to a
+// Kafka broker.
+func Test(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("telemetry", 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t),
+	})
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.exporter.kafka")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		brokers          = ["%s"]
+		protocol_version = "2.0.0"
+		topic            = "telemetry"
+
+		retry_on_failure {
+			enabled = false
+		}
+	`, broker.Addr())
+	var args kafka.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+
+	bo := backoff.New(ctx, backoff.Config{
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 100 * time.Millisecond,
+	})
+	for bo.Ongoing() {
+		err := exports.Input.ConsumeTraces(ctx, createTestTraces())
+		if err == nil {
+			break
+		}
+		level.Error(l).Log("msg", "failed to send traces", "err", err)
+		bo.Wait()
+	}
+	require.NoError(t, bo.Err())
+
+	require.Eventually(t, func() bool {
+		for _, rr := range broker.History() {
+			if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond, "broker never received a produce request")
+}
+
+func createTestTraces() ptrace.Traces {
+	data := ptrace.NewTraces()
+	span := data.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty()
+	span.SetName("TestSpan")
+	return data
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "defaults",
+			cfg:  `protocol_version = "2.0.0"`,
+		},
+		{
+			name: "signal-specific encoding",
+			cfg: `
+				protocol_version = "2.0.0"
+				encoding         = "jaeger_json"
+			`,
+		},
+		{
+			name: "unsupported encoding",
+			cfg: `
+				protocol_version = "2.0.0"
+				encoding         = "zipkin_json"
+			`,
+			expect: `unsupported encoding "zipkin_json"`,
+		},
+		{
+			name:   "invalid protocol version",
+			cfg:    `protocol_version = "two"`,
+			expect: "invalid protocol_version: invalid version `two`",
+		},
+		{
+			name: "invalid compression",
+			cfg: `
+				protocol_version = "2.0.0"
+
+				producer {
+					compression = "brotli"
+				}
+			`,
+			expect: "producer.compression should be one of 'none', 'gzip', 'snappy', 'lz4', or 'zstd'. configured value brotli",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args kafka.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package loadbalancing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	aws_sdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/aws/aws-sdk-go/service/servicediscovery/servicediscoveryiface"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Attributes of AWS Cloud Map instances holding their address.
+const (
+	cloudMapIPv4Attribute = "AWS_INSTANCE_IPV4"
+	cloudMapPortAttribute = "AWS_INSTANCE_PORT"
+)
+
+// AWSCloudMapResolver sends data to the instances registered to an AWS Cloud
+// Map service, such as the tasks of an ECS service.
+type AWSCloudMapResolver struct {
+	Namespace    string        `river:"namespace,attr"`
+	ServiceName  string        `river:"service_name,attr"`
+	HealthStatus string        `river:"health_status,attr,optional"`
+	Port         int           `river:"port,attr,optional"`
+	Interval     time.Duration `river:"interval,attr,optional"`
+	Timeout      time.Duration `river:"timeout,attr,optional"`
+	Region       string        `river:"region,attr,optional"`
+}
+
+// DefaultAWSCloudMapResolver holds default values for AWSCloudMapResolver.
+var DefaultAWSCloudMapResolver = AWSCloudMapResolver{
+	HealthStatus: servicediscovery.HealthStatusFilterHealthy,
+	Interval:     30 * time.Second,
+	Timeout:      5 * time.Second,
+}
+
+// SetToDefault implements river.Defaulter.
+func (r *AWSCloudMapResolver) SetToDefault() {
+	*r = DefaultAWSCloudMapResolver
+}
+
+// Validate implements river.Validator.
+func (r *AWSCloudMapResolver) Validate() error {
+	if r.Namespace == "" || r.ServiceName == "" {
+		return fmt.Errorf("namespace and service_name must not be empty")
+	}
+	switch r.HealthStatus {
+	case servicediscovery.HealthStatusFilterHealthy, servicediscovery.HealthStatusFilterUnhealthy,
+		servicediscovery.HealthStatusFilterAll, servicediscovery.HealthStatusFilterHealthyOrElseAll:
+	default:
+		return fmt.Errorf("invalid health_status %q, must be one of %v", r.HealthStatus, servicediscovery.HealthStatusFilter_Values())
+	}
+	if r.Port < 0 || r.Port > 65535 {
+		return fmt.Errorf("invalid port %d", r.Port)
+	}
+	if r.Interval <= 0 || r.Timeout <= 0 {
+		return fmt.Errorf("interval and timeout must be greater than 0")
+	}
+	return nil
+}
+
+// cloudMapDiscoverer polls the instances of an AWS Cloud Map service.
+type cloudMapDiscoverer struct {
+	log    log.Logger
+	args   AWSCloudMapResolver
+	client servicediscoveryiface.ServiceDiscoveryAPI
+}
+
+func newCloudMapDiscoverer(l log.Logger, args AWSCloudMapResolver) (*cloudMapDiscoverer, error) {
+	opts := session.Options{SharedConfigState: session.SharedConfigEnable}
+	if args.Region != "" {
+		opts.Config.Region = aws_sdk.String(args.Region)
+	}
+	sess, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not create AWS session: %w", err)
+	}
+	return &cloudMapDiscoverer{
+		log:    l,
+		args:   args,
+		client: servicediscovery.New(sess),
+	}, nil
+}
+
+// Run implements discoverer.
+func (d *cloudMapDiscoverer) Run(ctx context.Context, update func([]string)) {
+	ticker := time.NewTicker(d.args.Interval)
+	defer ticker.Stop()
+
+	for {
+		endpoints, err := d.discover(ctx)
+		if err != nil {
+			// Keep the last known backends until instances are discovered again.
+			level.Warn(d.log).Log("msg", "failed to discover AWS Cloud Map instances", "err", err)
+		} else {
+			update(endpoints)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *cloudMapDiscoverer) discover(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.args.Timeout)
+	defer cancel()
+
+	out, err := d.client.DiscoverInstancesWithContext(ctx, &servicediscovery.DiscoverInstancesInput{
+		NamespaceName: aws_sdk.String(d.args.Namespace),
+		ServiceName:   aws_sdk.String(d.args.ServiceName),
+		HealthStatus:  aws_sdk.String(d.args.HealthStatus),
+		// Maximum number of instances returned by DiscoverInstances.
+		MaxResults: aws_sdk.Int64(1000),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []string
+	for _, instance := range out.Instances {
+		ip := aws_sdk.StringValue(instance.Attributes[cloudMapIPv4Attribute])
+		if ip == "" {
+			continue
+		}
+		port := strconv.Itoa(d.args.Port)
+		if d.args.Port == 0 {
+			port = aws_sdk.StringValue(instance.Attributes[cloudMapPortAttribute])
+			if port == "" {
+				continue
+			}
+		}
+		endpoints = append(endpoints, net.JoinHostPort(ip, port))
+	}
+	return endpoints, nil
+}
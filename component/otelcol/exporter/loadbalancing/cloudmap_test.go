@@ -0,0 +1,54 @@
+package loadbalancing
+
+import (
+	"context"
+	"testing"
+
+	aws_sdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/servicediscovery"
+	"github.com/aws/aws-sdk-go/service/servicediscovery/servicediscoveryiface"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCloudMap struct {
+	servicediscoveryiface.ServiceDiscoveryAPI
+
+	input     *servicediscovery.DiscoverInstancesInput
+	instances []*servicediscovery.HttpInstanceSummary
+}
+
+func (f *fakeCloudMap) DiscoverInstancesWithContext(_ aws_sdk.Context, input *servicediscovery.DiscoverInstancesInput, _ ...request.Option) (*servicediscovery.DiscoverInstancesOutput, error) {
+	f.input = input
+	return &servicediscovery.DiscoverInstancesOutput{Instances: f.instances}, nil
+}
+
+func TestCloudMapDiscoverer(t *testing.T) {
+	client := &fakeCloudMap{
+		instances: []*servicediscovery.HttpInstanceSummary{
+			{Attributes: aws_sdk.StringMap(map[string]string{cloudMapIPv4Attribute: "10.0.0.1", cloudMapPortAttribute: "4317"})},
+			{Attributes: aws_sdk.StringMap(map[string]string{cloudMapIPv4Attribute: "10.0.0.2", cloudMapPortAttribute: "4318"})},
+			// Instances without an IPv4 address are skipped.
+			{Attributes: aws_sdk.StringMap(map[string]string{"AWS_INSTANCE_CNAME": "backend.example.com"})},
+		},
+	}
+
+	args := DefaultAWSCloudMapResolver
+	args.Namespace = "example"
+	args.ServiceName = "tail-sampling"
+	d := &cloudMapDiscoverer{log: util.TestFlowLogger(t), args: args, client: client}
+
+	endpoints, err := d.discover(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:4317", "10.0.0.2:4318"}, endpoints)
+	require.Equal(t, "example", *client.input.NamespaceName)
+	require.Equal(t, "tail-sampling", *client.input.ServiceName)
+	require.Equal(t, servicediscovery.HealthStatusFilterHealthy, *client.input.HealthStatus)
+
+	// The port of the resolver overrides the port of the instances.
+	d.args.Port = 55690
+	endpoints, err = d.discover(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"10.0.0.1:55690", "10.0.0.2:55690"}, endpoints)
+}
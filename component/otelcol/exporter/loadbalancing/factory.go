@@ -0,0 +1,74 @@
+package loadbalancing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/loadbalancingexporter"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// errNoEndpoints is returned for data received while the dynamic resolver
+// hasn't found any backend.
+var errNoEndpoints = errors.New("no backends found by the resolver")
+
+// exporterConfig extends the upstream configuration with whether the dynamic
+// resolver didn't find any backend.
+type exporterConfig struct {
+	loadbalancingexporter.Config
+
+	noEndpoints bool
+}
+
+// noEndpointsFactory wraps the upstream factory to create exporters which
+// refuse data while there are no backends, as the upstream exporter can't be
+// created without backends.
+type noEndpointsFactory struct {
+	otelcomponent.ExporterFactory
+}
+
+func newFactory(fact otelcomponent.ExporterFactory) otelcomponent.ExporterFactory {
+	return noEndpointsFactory{ExporterFactory: fact}
+}
+
+func (f noEndpointsFactory) CreateTracesExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.TracesExporter, error) {
+	ecfg := cfg.(*exporterConfig)
+	if ecfg.noEndpoints {
+		return noEndpointsExporter{}, nil
+	}
+	return f.ExporterFactory.CreateTracesExporter(ctx, set, &ecfg.Config)
+}
+
+func (f noEndpointsFactory) CreateMetricsExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.MetricsExporter, error) {
+	return f.ExporterFactory.CreateMetricsExporter(ctx, set, &cfg.(*exporterConfig).Config)
+}
+
+func (f noEndpointsFactory) CreateLogsExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.LogsExporter, error) {
+	ecfg := cfg.(*exporterConfig)
+	if ecfg.noEndpoints {
+		return noEndpointsExporter{}, nil
+	}
+	return f.ExporterFactory.CreateLogsExporter(ctx, set, &ecfg.Config)
+}
+
+// noEndpointsExporter refuses all data with errNoEndpoints.
+type noEndpointsExporter struct{}
+
+var (
+	_ otelcomponent.TracesExporter = noEndpointsExporter{}
+	_ otelcomponent.LogsExporter   = noEndpointsExporter{}
+)
+
+func (noEndpointsExporter) Start(context.Context, otelcomponent.Host) error { return nil }
+func (noEndpointsExporter) Shutdown(context.Context) error                  { return nil }
+
+func (noEndpointsExporter) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+func (noEndpointsExporter) ConsumeTraces(context.Context, ptrace.Traces) error { return errNoEndpoints }
+func (noEndpointsExporter) ConsumeLogs(context.Context, plog.Logs) error       { return errNoEndpoints }
@@ -0,0 +1,147 @@
+package loadbalancing
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	commonk8s "github.com/grafana/agent/component/common/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// namespaceFile holds the namespace of the pod the agent runs in.
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// KubernetesResolver sends data to the ready endpoints of a Kubernetes
+// Service, such as a headless Service in front of the backends.
+type KubernetesResolver struct {
+	// Service is the name of the Service, optionally followed by a dot and
+	// its namespace.
+	Service string  `river:"service,attr"`
+	Ports   []int32 `river:"ports,attr,optional"`
+
+	// Client settings to connect to Kubernetes.
+	Client commonk8s.ClientArguments `river:"client,block,optional"`
+}
+
+// DefaultKubernetesResolver holds default values for KubernetesResolver.
+var DefaultKubernetesResolver = KubernetesResolver{
+	Ports:  []int32{4317},
+	Client: commonk8s.DefaultClientArguments,
+}
+
+// SetToDefault implements river.Defaulter.
+func (r *KubernetesResolver) SetToDefault() {
+	*r = DefaultKubernetesResolver
+}
+
+// Validate implements river.Validator.
+func (r *KubernetesResolver) Validate() error {
+	if r.Service == "" {
+		return fmt.Errorf("service must not be empty")
+	}
+	if len(r.Ports) == 0 {
+		return fmt.Errorf("ports must not be empty")
+	}
+	for _, port := range r.Ports {
+		if port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid port %d", port)
+		}
+	}
+	return nil
+}
+
+// kubernetesDiscoverer watches the Endpoints object of a Service.
+type kubernetesDiscoverer struct {
+	args   KubernetesResolver
+	client kubernetes.Interface
+
+	name, namespace string
+}
+
+func newKubernetesDiscoverer(l log.Logger, args KubernetesResolver) (*kubernetesDiscoverer, error) {
+	cfg, err := args.Client.BuildRESTConfig(l)
+	if err != nil {
+		return nil, fmt.Errorf("building Kubernetes config: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("building Kubernetes client: %w", err)
+	}
+	return newKubernetesDiscovererFromClient(args, client), nil
+}
+
+func newKubernetesDiscovererFromClient(args KubernetesResolver, client kubernetes.Interface) *kubernetesDiscoverer {
+	name, namespace, ok := strings.Cut(args.Service, ".")
+	if !ok {
+		namespace = currentNamespace()
+	}
+	return &kubernetesDiscoverer{
+		args:   args,
+		client: client,
+
+		name:      name,
+		namespace: namespace,
+	}
+}
+
+// currentNamespace returns the namespace of the pod the agent runs in, or
+// "default" when it's not running in a pod.
+func currentNamespace() string {
+	bb, err := os.ReadFile(namespaceFile)
+	if ns := strings.TrimSpace(string(bb)); err == nil && ns != "" {
+		return ns
+	}
+	return "default"
+}
+
+// Run implements discoverer.
+func (d *kubernetesDiscoverer) Run(ctx context.Context, update func([]string)) {
+	factory := informers.NewSharedInformerFactoryWithOptions(d.client, 0,
+		informers.WithNamespace(d.namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.FieldSelector = fields.OneTermEqualSelector("metadata.name", d.name).String()
+		}),
+	)
+	defer factory.Shutdown()
+
+	onChange := func(obj interface{}) {
+		if endpoints, ok := obj.(*corev1.Endpoints); ok {
+			update(d.endpoints(endpoints))
+		}
+	}
+	_, err := factory.Core().V1().Endpoints().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    onChange,
+		UpdateFunc: func(_, obj interface{}) { onChange(obj) },
+		DeleteFunc: func(interface{}) { update(nil) },
+	})
+	if err != nil {
+		return
+	}
+
+	factory.Start(ctx.Done())
+	<-ctx.Done()
+}
+
+// endpoints returns the host and port pairs of the ready addresses of
+// endpoints, for each port of the resolver.
+func (d *kubernetesDiscoverer) endpoints(endpoints *corev1.Endpoints) []string {
+	var res []string
+	for _, subset := range endpoints.Subsets {
+		for _, addr := range subset.Addresses {
+			for _, port := range d.args.Ports {
+				res = append(res, net.JoinHostPort(addr.IP, strconv.Itoa(int(port))))
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,57 @@
+package loadbalancing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKubernetesDiscoverer(t *testing.T) {
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "tail-sampling", Namespace: "observability"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+			NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.3"}},
+		}},
+	}
+	client := fake.NewSimpleClientset(endpoints)
+
+	args := DefaultKubernetesResolver
+	args.Service = "tail-sampling.observability"
+	args.Ports = []int32{4317, 55690}
+	d := newKubernetesDiscovererFromClient(args, client)
+
+	updates := make(chan []string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx, func(endpoints []string) { updates <- endpoints })
+
+	next := func() []string {
+		select {
+		case endpoints := <-updates:
+			return endpoints
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "no update of the endpoints")
+			return nil
+		}
+	}
+
+	// Only ready addresses are used, with each of the ports.
+	require.ElementsMatch(t, []string{
+		"10.0.0.1:4317", "10.0.0.1:55690",
+		"10.0.0.2:4317", "10.0.0.2:55690",
+	}, next())
+
+	endpoints.Subsets[0].Addresses = endpoints.Subsets[0].Addresses[1:]
+	_, err := client.CoreV1().Endpoints("observability").Update(ctx, endpoints, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"10.0.0.2:4317", "10.0.0.2:55690"}, next())
+
+	require.NoError(t, client.CoreV1().Endpoints("observability").Delete(ctx, "tail-sampling", metav1.DeleteOptions{}))
+	require.Empty(t, next())
+}
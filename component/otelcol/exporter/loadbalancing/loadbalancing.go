@@ -0,0 +1,437 @@
+// Package loadbalancing provides an otelcol.exporter.loadbalancing component.
+package loadbalancing
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/auth"
+	"github.com/grafana/agent/component/otelcol/exporter"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/loadbalancingexporter"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	otelpexporterhelper "go.opentelemetry.io/collector/exporter/exporterhelper"
+	"go.opentelemetry.io/collector/exporter/otlpexporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.exporter.loadbalancing",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Routing keys deciding which backend data is sent to.
+const (
+	RoutingKeyTraceID = "traceID"
+	RoutingKeyService = "service"
+)
+
+// Arguments configures the otelcol.exporter.loadbalancing component.
+type Arguments struct {
+	Protocol   Protocol         `river:"protocol,block"`
+	Resolver   ResolverSettings `river:"resolver,block"`
+	RoutingKey string           `river:"routing_key,attr,optional"`
+}
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	Protocol: Protocol{
+		OTLP: DefaultOTLPConfig,
+	},
+	RoutingKey: RoutingKeyTraceID,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	switch args.RoutingKey {
+	case RoutingKeyTraceID, RoutingKeyService:
+	default:
+		return fmt.Errorf("unsupported routing_key %q, must be %q or %q", args.RoutingKey, RoutingKeyTraceID, RoutingKeyService)
+	}
+
+	var resolvers int
+	for _, set := range []bool{
+		args.Resolver.Static != nil,
+		args.Resolver.DNS != nil,
+		args.Resolver.Kubernetes != nil,
+		args.Resolver.AWSCloudMap != nil,
+	} {
+		if set {
+			resolvers++
+		}
+	}
+	if resolvers != 1 {
+		return fmt.Errorf("exactly one of the static, dns, kubernetes, or aws_cloud_map resolver blocks must be set")
+	}
+	return nil
+}
+
+// Protocol holds the settings of the protocol used to send data to the
+// backends.
+type Protocol struct {
+	OTLP OTLPConfig `river:"otlp,block"`
+}
+
+// OTLPConfig configures the OTLP exporters sending data to the backends. The
+// endpoints of the exporters are set by the resolver.
+type OTLPConfig struct {
+	Timeout time.Duration          `river:"timeout,attr,optional"`
+	Queue   otelcol.QueueArguments `river:"queue,block,optional"`
+	Retry   otelcol.RetryArguments `river:"retry,block,optional"`
+	Client  GRPCClientArguments    `river:"client,block"`
+}
+
+// DefaultOTLPConfig holds default values for OTLPConfig.
+var DefaultOTLPConfig = OTLPConfig{
+	Timeout: otelcol.DefaultTimeout,
+	Queue:   otelcol.DefaultQueueArguments,
+	Retry:   otelcol.DefaultRetryArguments,
+	Client:  DefaultGRPCClientArguments,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *OTLPConfig) SetToDefault() {
+	*args = DefaultOTLPConfig
+}
+
+// Convert converts args into the upstream type.
+func (args OTLPConfig) Convert() otlpexporter.Config {
+	return otlpexporter.Config{
+		TimeoutSettings: otelpexporterhelper.TimeoutSettings{
+			Timeout: args.Timeout,
+		},
+		QueueSettings:      *args.Queue.Convert(),
+		RetrySettings:      *args.Retry.Convert(),
+		GRPCClientSettings: *args.Client.otelcol().Convert(),
+	}
+}
+
+// GRPCClientArguments is the same as otelcol.GRPCClientArguments, without the
+// endpoint which is set by the resolver.
+type GRPCClientArguments struct {
+	Compression otelcol.CompressionType `river:"compression,attr,optional"`
+
+	TLS       otelcol.TLSClientArguments        `river:"tls,block,optional"`
+	Keepalive *otelcol.KeepaliveClientArguments `river:"keepalive,block,optional"`
+
+	ReadBufferSize  units.Base2Bytes  `river:"read_buffer_size,attr,optional"`
+	WriteBufferSize units.Base2Bytes  `river:"write_buffer_size,attr,optional"`
+	WaitForReady    bool              `river:"wait_for_ready,attr,optional"`
+	Headers         map[string]string `river:"headers,attr,optional"`
+	BalancerName    string            `river:"balancer_name,attr,optional"`
+
+	// Auth is a binding to an otelcol.auth.* component extension which handles
+	// authentication.
+	Auth *auth.Handler `river:"auth,attr,optional"`
+}
+
+// DefaultGRPCClientArguments holds component-specific default settings for
+// GRPCClientArguments.
+var DefaultGRPCClientArguments = GRPCClientArguments{
+	Headers:         map[string]string{},
+	Compression:     otelcol.CompressionTypeGzip,
+	WriteBufferSize: 512 * 1024,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *GRPCClientArguments) SetToDefault() {
+	*args = DefaultGRPCClientArguments
+}
+
+func (args *GRPCClientArguments) otelcol() *otelcol.GRPCClientArguments {
+	return &otelcol.GRPCClientArguments{
+		Compression:     args.Compression,
+		TLS:             args.TLS,
+		Keepalive:       args.Keepalive,
+		ReadBufferSize:  args.ReadBufferSize,
+		WriteBufferSize: args.WriteBufferSize,
+		WaitForReady:    args.WaitForReady,
+		Headers:         args.Headers,
+		BalancerName:    args.BalancerName,
+		Auth:            args.Auth,
+	}
+}
+
+// ResolverSettings configures how the backends are found. Exactly one
+// resolver must be set.
+type ResolverSettings struct {
+	Static      *StaticResolver      `river:"static,block,optional"`
+	DNS         *DNSResolver         `river:"dns,block,optional"`
+	Kubernetes  *KubernetesResolver  `river:"kubernetes,block,optional"`
+	AWSCloudMap *AWSCloudMapResolver `river:"aws_cloud_map,block,optional"`
+}
+
+// StaticResolver sends data to a fixed list of backends.
+type StaticResolver struct {
+	Hostnames []string `river:"hostnames,attr"`
+}
+
+// Validate implements river.Validator.
+func (r *StaticResolver) Validate() error {
+	if len(r.Hostnames) == 0 {
+		return fmt.Errorf("hostnames must not be empty")
+	}
+	return nil
+}
+
+// DNSResolver sends data to the IP addresses a hostname resolves to.
+type DNSResolver struct {
+	Hostname string        `river:"hostname,attr"`
+	Port     string        `river:"port,attr,optional"`
+	Interval time.Duration `river:"interval,attr,optional"`
+	Timeout  time.Duration `river:"timeout,attr,optional"`
+}
+
+// DefaultDNSResolver holds default values for DNSResolver.
+var DefaultDNSResolver = DNSResolver{
+	Port:     "4317",
+	Interval: 5 * time.Second,
+	Timeout:  time.Second,
+}
+
+// SetToDefault implements river.Defaulter.
+func (r *DNSResolver) SetToDefault() {
+	*r = DefaultDNSResolver
+}
+
+// discoverer watches the endpoints of the backends for the resolvers which
+// aren't implemented upstream. Discoverers call update with the full list of
+// endpoints each time it changes, until ctx is canceled.
+type discoverer interface {
+	Run(ctx context.Context, update func(endpoints []string))
+}
+
+// exporterArguments are the arguments of the wrapped exporter, along with
+// the endpoints found by the discoverer of the component when the resolver is
+// dynamic.
+type exporterArguments struct {
+	Arguments
+	endpoints []string
+}
+
+var _ exporter.Arguments = exporterArguments{}
+
+// Convert implements exporter.Arguments.
+func (args exporterArguments) Convert() (otelconfig.Exporter, error) {
+	cfg := loadbalancingexporter.Config{
+		ExporterSettings: otelconfig.NewExporterSettings(otelconfig.NewComponentID("loadbalancing")),
+		Protocol: loadbalancingexporter.Protocol{
+			OTLP: args.Protocol.OTLP.Convert(),
+		},
+		RoutingKey: args.RoutingKey,
+	}
+
+	r := args.Resolver
+	switch {
+	case r.Static != nil:
+		cfg.Resolver.Static = &loadbalancingexporter.StaticResolver{Hostnames: r.Static.Hostnames}
+	case r.DNS != nil:
+		cfg.Resolver.DNS = &loadbalancingexporter.DNSResolver{
+			Hostname: r.DNS.Hostname,
+			Port:     r.DNS.Port,
+			Interval: r.DNS.Interval,
+			Timeout:  r.DNS.Timeout,
+		}
+	case len(args.endpoints) == 0:
+		// The upstream static resolver requires at least one endpoint.
+		return &exporterConfig{Config: cfg, noEndpoints: true}, nil
+	default:
+		cfg.Resolver.Static = &loadbalancingexporter.StaticResolver{Hostnames: args.endpoints}
+	}
+	return &exporterConfig{Config: cfg}, nil
+}
+
+// Extensions implements exporter.Arguments.
+func (args exporterArguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return args.Protocol.OTLP.Client.otelcol().Extensions()
+}
+
+// Exporters implements exporter.Arguments.
+func (args exporterArguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// Component is the otelcol.exporter.loadbalancing component. It wraps the
+// upstream exporter, reconfiguring it with the backends found by its
+// discoverer when the resolver is dynamic.
+type Component struct {
+	opts     component.Options
+	exporter *exporter.Exporter
+
+	// discoverers receives the discoverer to run each time it changes, or nil
+	// to stop running the current one.
+	discoverers chan discoverer
+
+	mut        sync.Mutex
+	args       Arguments
+	discoverer discoverer
+	endpoints  []string
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
+
+// New creates a new otelcol.exporter.loadbalancing component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:        opts,
+		discoverers: make(chan discoverer, 1),
+		args:        args,
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if err := c.updateDiscoverer(args); err != nil {
+		return nil, err
+	}
+	e, err := exporter.New(opts, newFactory(loadbalancingexporter.NewFactory()), exporterArguments{Arguments: args})
+	if err != nil {
+		return nil, err
+	}
+	c.exporter = e
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	var (
+		wg          sync.WaitGroup
+		cancelDisco = func() {}
+	)
+	defer wg.Wait()
+	defer func() { cancelDisco() }()
+
+	errCh := make(chan error, 1)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errCh <- c.exporter.Run(ctx)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			return err
+		case d := <-c.discoverers:
+			cancelDisco()
+			cancelDisco = func() {}
+			if d == nil {
+				continue
+			}
+
+			discoCtx, cancel := context.WithCancel(ctx)
+			cancelDisco = cancel
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.Run(discoCtx, func(endpoints []string) { c.setEndpoints(d, endpoints) })
+			}()
+		}
+	}
+}
+
+// Update implements component.Component.
+func (c *Component) Update(newConfig component.Arguments) error {
+	args := newConfig.(Arguments)
+
+	// The exporter is updated while holding the lock so that updates from
+	// Update and setEndpoints are applied in order.
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if err := c.updateDiscoverer(args); err != nil {
+		return err
+	}
+	c.args = args
+	return c.exporter.Update(exporterArguments{Arguments: args, endpoints: c.endpoints})
+}
+
+// updateDiscoverer starts a new discoverer if the dynamic resolver of args
+// changed, forgetting the endpoints found by the previous one. c.mut must be
+// held.
+func (c *Component) updateDiscoverer(args Arguments) error {
+	var (
+		d   discoverer
+		err error
+	)
+	switch r := args.Resolver; {
+	case r.Kubernetes != nil:
+		if old, ok := c.discoverer.(*kubernetesDiscoverer); ok && reflect.DeepEqual(old.args, *r.Kubernetes) {
+			return nil
+		}
+		d, err = newKubernetesDiscoverer(c.opts.Logger, *r.Kubernetes)
+	case r.AWSCloudMap != nil:
+		if old, ok := c.discoverer.(*cloudMapDiscoverer); ok && reflect.DeepEqual(old.args, *r.AWSCloudMap) {
+			return nil
+		}
+		d, err = newCloudMapDiscoverer(c.opts.Logger, *r.AWSCloudMap)
+	}
+	if err != nil {
+		return err
+	}
+	if d == nil && c.discoverer == nil {
+		return nil
+	}
+
+	c.discoverer = d
+	c.endpoints = nil
+	// Replace the discoverer which hasn't been picked up by Run yet, if any.
+	select {
+	case <-c.discoverers:
+	default:
+	}
+	c.discoverers <- d
+	return nil
+}
+
+// setEndpoints reconfigures the exporter with the endpoints found by d when
+// they changed.
+func (c *Component) setEndpoints(d discoverer, endpoints []string) {
+	endpoints = append([]string{}, endpoints...)
+	sort.Strings(endpoints)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.discoverer != d || reflect.DeepEqual(c.endpoints, endpoints) {
+		return
+	}
+	c.endpoints = endpoints
+
+	err := c.exporter.Update(exporterArguments{Arguments: c.args, endpoints: endpoints})
+	if err != nil {
+		level.Error(c.opts.Logger).Log("msg", "failed to update backends", "err", err)
+		return
+	}
+	level.Info(c.opts.Logger).Log("msg", "updated backends", "endpoints", strings.Join(endpoints, ","))
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	return c.exporter.CurrentHealth()
+}
@@ -0,0 +1,230 @@
+package loadbalancing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/exporter/loadbalancingexporter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestArguments_Convert(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		routing_key = "service"
+		protocol {
+			otlp {
+				client {}
+			}
+		}
+		resolver {
+			dns {
+				hostname = "tail-sampling.example.com"
+			}
+		}
+	`), &args))
+
+	cfg, err := exporterArguments{Arguments: args}.Convert()
+	require.NoError(t, err)
+	lbCfg := cfg.(*exporterConfig).Config
+	require.Equal(t, "service", lbCfg.RoutingKey)
+	require.Equal(t, &loadbalancingexporter.DNSResolver{
+		Hostname: "tail-sampling.example.com",
+		Port:     "4317",
+		Interval: 5 * time.Second,
+		Timeout:  time.Second,
+	}, lbCfg.Resolver.DNS)
+	require.Equal(t, "gzip", string(lbCfg.Protocol.OTLP.Compression))
+}
+
+func TestArguments_ConvertDynamic(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		protocol {
+			otlp {
+				client {}
+			}
+		}
+		resolver {
+			kubernetes {
+				service = "tail-sampling.observability"
+			}
+		}
+	`), &args))
+
+	// Without endpoints, the exporter refuses data.
+	cfg, err := exporterArguments{Arguments: args}.Convert()
+	require.NoError(t, err)
+	require.True(t, cfg.(*exporterConfig).noEndpoints)
+
+	// The discovered endpoints are used as a static list of backends.
+	cfg, err = exporterArguments{Arguments: args, endpoints: []string{"10.0.0.1:4317"}}.Convert()
+	require.NoError(t, err)
+	require.False(t, cfg.(*exporterConfig).noEndpoints)
+	require.Equal(t, []string{"10.0.0.1:4317"}, cfg.(*exporterConfig).Resolver.Static.Hostnames)
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "no resolver",
+			cfg: `
+				protocol {
+					otlp {
+						client {}
+					}
+				}
+				resolver {}
+			`,
+			expect: "exactly one of the static, dns, kubernetes, or aws_cloud_map resolver blocks must be set",
+		},
+		{
+			name: "multiple resolvers",
+			cfg: `
+				protocol {
+					otlp {
+						client {}
+					}
+				}
+				resolver {
+					static {
+						hostnames = ["localhost:4317"]
+					}
+					kubernetes {
+						service = "tail-sampling"
+					}
+				}
+			`,
+			expect: "exactly one of the static, dns, kubernetes, or aws_cloud_map resolver blocks must be set",
+		},
+		{
+			name: "invalid routing key",
+			cfg: `
+				routing_key = "span"
+				protocol {
+					otlp {
+						client {}
+					}
+				}
+				resolver {
+					static {
+						hostnames = ["localhost:4317"]
+					}
+				}
+			`,
+			expect: `unsupported routing_key "span", must be "traceID" or "service"`,
+		},
+		{
+			name: "invalid health status",
+			cfg: `
+				protocol {
+					otlp {
+						client {}
+					}
+				}
+				resolver {
+					aws_cloud_map {
+						namespace     = "example"
+						service_name  = "tail-sampling"
+						health_status = "OK"
+					}
+				}
+			`,
+			expect: `invalid health_status "OK"`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+// TestComponent_Endpoints ensures that the exporter is reconfigured with the
+// endpoints found by the discoverer.
+func TestComponent_Endpoints(t *testing.T) {
+	// The Kubernetes client is built from a kubeconfig file pointing to a server
+	// which doesn't exist; the endpoints are set by the test rather than by
+	// the discoverer.
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(`
+apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:1
+contexts:
+- name: test
+  context:
+    cluster: test
+current-context: test
+`), 0600))
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		protocol {
+			otlp {
+				client {
+					tls {
+						insecure = true
+					}
+				}
+			}
+		}
+		resolver {
+			kubernetes {
+				service = "tail-sampling.observability"
+				client {
+					kubeconfig_file = "`+kubeconfig+`"
+				}
+			}
+		}
+	`), &args))
+
+	var exports otelcol.ConsumerExports
+	c, err := New(component.Options{
+		ID:            "otelcol.exporter.loadbalancing.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		Tracer:        trace.NewNoopTracerProvider(),
+		OnStateChange: func(e component.Exports) { exports = e.(otelcol.ConsumerExports) },
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { require.NoError(t, c.Run(ctx)) }()
+
+	traces := ptrace.NewTraces()
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+
+	require.ErrorIs(t, exports.Input.ConsumeTraces(ctx, traces), errNoEndpoints)
+
+	c.mut.Lock()
+	d := c.discoverer
+	c.mut.Unlock()
+	c.setEndpoints(d, []string{"127.0.0.1:4317"})
+	require.Equal(t, []string{"127.0.0.1:4317"}, c.endpoints)
+
+	// Data is queued for the backend rather than refused.
+	require.Eventually(t, func() bool {
+		return exports.Input.ConsumeTraces(ctx, traces) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
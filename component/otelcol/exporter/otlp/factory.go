@@ -0,0 +1,291 @@
+package otlp
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/exporter/otlpexporter"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/multierr"
+)
+
+// exporterConfig extends the upstream configuration with the settings the
+// upstream exporter doesn't support.
+type exporterConfig struct {
+	otlpexporter.Config
+
+	TracesEndpoint  string
+	MetricsEndpoint string
+	LogsEndpoint    string
+
+	ResourceAttributeHeaders map[string]string
+}
+
+// signalConfig returns the upstream configuration used for a signal, using
+// endpoint instead of the client endpoint if it's set.
+func (cfg *exporterConfig) signalConfig(endpoint string) otlpexporter.Config {
+	res := cfg.Config
+	if endpoint != "" {
+		res.GRPCClientSettings.Endpoint = endpoint
+	}
+	return res
+}
+
+// signalFactory wraps the upstream factory to apply per-signal endpoints and
+// to send data with headers taken from resource attributes.
+type signalFactory struct {
+	otelcomponent.ExporterFactory
+}
+
+func (f signalFactory) CreateTracesExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.TracesExporter, error) {
+	ecfg := cfg.(*exporterConfig)
+	r, err := newHeaderRouter(ecfg.signalConfig(ecfg.TracesEndpoint), ecfg.ResourceAttributeHeaders, func(c *otlpexporter.Config) (otelcomponent.Component, error) {
+		return f.ExporterFactory.CreateTracesExporter(ctx, set, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ecfg.ResourceAttributeHeaders) == 0 {
+		return r.base.(otelcomponent.TracesExporter), nil
+	}
+	return &tracesRouter{headerRouter: r}, nil
+}
+
+func (f signalFactory) CreateMetricsExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.MetricsExporter, error) {
+	ecfg := cfg.(*exporterConfig)
+	r, err := newHeaderRouter(ecfg.signalConfig(ecfg.MetricsEndpoint), ecfg.ResourceAttributeHeaders, func(c *otlpexporter.Config) (otelcomponent.Component, error) {
+		return f.ExporterFactory.CreateMetricsExporter(ctx, set, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ecfg.ResourceAttributeHeaders) == 0 {
+		return r.base.(otelcomponent.MetricsExporter), nil
+	}
+	return &metricsRouter{headerRouter: r}, nil
+}
+
+func (f signalFactory) CreateLogsExporter(ctx context.Context, set otelcomponent.ExporterCreateSettings, cfg otelconfig.Exporter) (otelcomponent.LogsExporter, error) {
+	ecfg := cfg.(*exporterConfig)
+	r, err := newHeaderRouter(ecfg.signalConfig(ecfg.LogsEndpoint), ecfg.ResourceAttributeHeaders, func(c *otlpexporter.Config) (otelcomponent.Component, error) {
+		return f.ExporterFactory.CreateLogsExporter(ctx, set, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ecfg.ResourceAttributeHeaders) == 0 {
+		return r.base.(otelcomponent.LogsExporter), nil
+	}
+	return &logsRouter{headerRouter: r}, nil
+}
+
+// headerRouter sends data to upstream exporters created for each distinct set
+// of headers taken from resource attributes. Data whose resource doesn't
+// have any of the attributes is sent by the base exporter, using the headers
+// of the client.
+type headerRouter struct {
+	cfg       otlpexporter.Config
+	attrs     map[string]string
+	newExport func(*otlpexporter.Config) (otelcomponent.Component, error)
+	base      otelcomponent.Component
+
+	mut       sync.Mutex
+	host      otelcomponent.Host
+	exporters map[string]otelcomponent.Component
+}
+
+func newHeaderRouter(cfg otlpexporter.Config, attrs map[string]string, newExport func(*otlpexporter.Config) (otelcomponent.Component, error)) (*headerRouter, error) {
+	base, err := newExport(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &headerRouter{
+		cfg:       cfg,
+		attrs:     attrs,
+		newExport: newExport,
+		base:      base,
+		exporters: make(map[string]otelcomponent.Component),
+	}, nil
+}
+
+// Start implements otelcomponent.Component.
+func (r *headerRouter) Start(ctx context.Context, host otelcomponent.Host) error {
+	r.mut.Lock()
+	r.host = host
+	r.mut.Unlock()
+
+	return r.base.Start(ctx, host)
+}
+
+// Shutdown implements otelcomponent.Component.
+func (r *headerRouter) Shutdown(ctx context.Context) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	err := r.base.Shutdown(ctx)
+	for _, exp := range r.exporters {
+		err = multierr.Append(err, exp.Shutdown(ctx))
+	}
+	r.exporters = make(map[string]otelcomponent.Component)
+	return err
+}
+
+// Capabilities implements consumer.baseConsumer.
+func (r *headerRouter) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+// headers returns the headers to send data of resource with, along with a
+// key identifying them. An empty key is returned if none of the headers are
+// set from the resource.
+func (r *headerRouter) headers(resource pcommon.Resource) (string, map[string]string) {
+	var (
+		keys    []string
+		headers map[string]string
+	)
+	for header, attr := range r.attrs {
+		v, ok := resource.Attributes().Get(attr)
+		if !ok || v.AsString() == "" {
+			continue
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(r.attrs))
+		}
+		headers[header] = v.AsString()
+		keys = append(keys, header+"="+v.AsString())
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, "\x00"), headers
+}
+
+// exporter returns the exporter to send data with the headers identified by
+// key with, creating it if needed.
+func (r *headerRouter) exporter(key string, headers map[string]string) (otelcomponent.Component, error) {
+	if key == "" {
+		return r.base, nil
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if exp, ok := r.exporters[key]; ok {
+		return exp, nil
+	}
+
+	cfg := r.cfg
+	cfg.GRPCClientSettings.Headers = make(map[string]string, len(r.cfg.GRPCClientSettings.Headers)+len(headers))
+	for k, v := range r.cfg.GRPCClientSettings.Headers {
+		cfg.GRPCClientSettings.Headers[k] = v
+	}
+	for k, v := range headers {
+		cfg.GRPCClientSettings.Headers[k] = v
+	}
+
+	exp, err := r.newExport(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := exp.Start(context.Background(), r.host); err != nil {
+		return nil, err
+	}
+	r.exporters[key] = exp
+	return exp, nil
+}
+
+type tracesRouter struct{ *headerRouter }
+
+func (r *tracesRouter) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	var (
+		groups  = make(map[string]ptrace.Traces)
+		headers = make(map[string]map[string]string)
+	)
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		key, h := r.headers(rss.At(i).Resource())
+		group, ok := groups[key]
+		if !ok {
+			group = ptrace.NewTraces()
+			groups[key], headers[key] = group, h
+		}
+		rss.At(i).CopyTo(group.ResourceSpans().AppendEmpty())
+	}
+
+	var err error
+	for key, group := range groups {
+		exp, expErr := r.exporter(key, headers[key])
+		if expErr != nil {
+			err = multierr.Append(err, expErr)
+			continue
+		}
+		err = multierr.Append(err, exp.(otelcomponent.TracesExporter).ConsumeTraces(ctx, group))
+	}
+	return err
+}
+
+type metricsRouter struct{ *headerRouter }
+
+func (r *metricsRouter) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	var (
+		groups  = make(map[string]pmetric.Metrics)
+		headers = make(map[string]map[string]string)
+	)
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		key, h := r.headers(rms.At(i).Resource())
+		group, ok := groups[key]
+		if !ok {
+			group = pmetric.NewMetrics()
+			groups[key], headers[key] = group, h
+		}
+		rms.At(i).CopyTo(group.ResourceMetrics().AppendEmpty())
+	}
+
+	var err error
+	for key, group := range groups {
+		exp, expErr := r.exporter(key, headers[key])
+		if expErr != nil {
+			err = multierr.Append(err, expErr)
+			continue
+		}
+		err = multierr.Append(err, exp.(otelcomponent.MetricsExporter).ConsumeMetrics(ctx, group))
+	}
+	return err
+}
+
+type logsRouter struct{ *headerRouter }
+
+func (r *logsRouter) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	var (
+		groups  = make(map[string]plog.Logs)
+		headers = make(map[string]map[string]string)
+	)
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		key, h := r.headers(rls.At(i).Resource())
+		group, ok := groups[key]
+		if !ok {
+			group = plog.NewLogs()
+			groups[key], headers[key] = group, h
+		}
+		rls.At(i).CopyTo(group.ResourceLogs().AppendEmpty())
+	}
+
+	var err error
+	for key, group := range groups {
+		exp, expErr := r.exporter(key, headers[key])
+		if expErr != nil {
+			err = multierr.Append(err, expErr)
+			continue
+		}
+		err = multierr.Append(err, exp.(otelcomponent.LogsExporter).ConsumeLogs(ctx, group))
+	}
+	return err
+}
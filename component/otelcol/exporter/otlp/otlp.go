@@ -2,6 +2,7 @@
 package otlp
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/grafana/agent/component"
@@ -20,7 +21,7 @@ func init() {
 		Exports: otelcol.ConsumerExports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := otlpexporter.NewFactory()
+			fact := signalFactory{ExporterFactory: otlpexporter.NewFactory()}
 			return exporter.New(opts, fact, args.(Arguments))
 		},
 	})
@@ -30,6 +31,16 @@ func init() {
 type Arguments struct {
 	Timeout time.Duration `river:"timeout,attr,optional"`
 
+	// Per-signal endpoints override Client.Endpoint for the corresponding
+	// signal when set.
+	TracesEndpoint  string `river:"traces_endpoint,attr,optional"`
+	MetricsEndpoint string `river:"metrics_endpoint,attr,optional"`
+	LogsEndpoint    string `river:"logs_endpoint,attr,optional"`
+
+	// ResourceAttributeHeaders maps header names to the resource attribute
+	// whose value the header is set to.
+	ResourceAttributeHeaders map[string]string `river:"resource_attribute_headers,attr,optional"`
+
 	Queue otelcol.QueueArguments `river:"sending_queue,block,optional"`
 	Retry otelcol.RetryArguments `river:"retry_on_failure,block,optional"`
 
@@ -53,16 +64,33 @@ func (args *Arguments) SetToDefault() {
 	*args = DefaultArguments
 }
 
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	for header, attr := range args.ResourceAttributeHeaders {
+		if header == "" || attr == "" {
+			return fmt.Errorf("resource_attribute_headers must map non-empty header names to non-empty attribute names")
+		}
+	}
+	return nil
+}
+
 // Convert implements exporter.Arguments.
 func (args Arguments) Convert() (otelconfig.Exporter, error) {
-	return &otlpexporter.Config{
-		ExporterSettings: otelconfig.NewExporterSettings(otelconfig.NewComponentID("otlp")),
-		TimeoutSettings: otelpexporterhelper.TimeoutSettings{
-			Timeout: args.Timeout,
+	return &exporterConfig{
+		Config: otlpexporter.Config{
+			ExporterSettings: otelconfig.NewExporterSettings(otelconfig.NewComponentID("otlp")),
+			TimeoutSettings: otelpexporterhelper.TimeoutSettings{
+				Timeout: args.Timeout,
+			},
+			QueueSettings:      *args.Queue.Convert(),
+			RetrySettings:      *args.Retry.Convert(),
+			GRPCClientSettings: *(*otelcol.GRPCClientArguments)(&args.Client).Convert(),
 		},
-		QueueSettings:      *args.Queue.Convert(),
-		RetrySettings:      *args.Retry.Convert(),
-		GRPCClientSettings: *(*otelcol.GRPCClientArguments)(&args.Client).Convert(),
+
+		TracesEndpoint:           args.TracesEndpoint,
+		MetricsEndpoint:          args.MetricsEndpoint,
+		LogsEndpoint:             args.LogsEndpoint,
+		ResourceAttributeHeaders: args.ResourceAttributeHeaders,
 	}, nil
 }
 
@@ -18,6 +18,7 @@ import (
 	"go.opentelemetry.io/collector/pdata/ptrace"
 	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 // Test performs a basic integration test which runs the otelcol.exporter.otlp
@@ -86,6 +87,84 @@ func Test(t *testing.T) {
 	}
 }
 
+// TestResourceAttributeHeaders ensures that otelcol.exporter.otlp sends traces
+// to the traces endpoint, with headers set from resource attributes.
+func TestResourceAttributeHeaders(t *testing.T) {
+	orgIDCh := make(chan []string, 2)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	ptraceotlp.RegisterGRPCServer(srv, &mockTracesReceiver{ch: make(chan ptrace.Traces, 2), md: orgIDCh})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.exporter.otlp")
+	require.NoError(t, err)
+
+	// The client endpoint is never used since traces have their own endpoint.
+	cfg := fmt.Sprintf(`
+		timeout         = "250ms"
+		traces_endpoint = "%s"
+
+		resource_attribute_headers = {
+			"X-Scope-OrgID" = "tenant",
+		}
+
+		retry_on_failure {
+			enabled = false
+		}
+
+		client {
+			endpoint    = "127.0.0.1:1"
+			compression = "none"
+			headers     = {"X-Scope-OrgID" = "anonymous"}
+
+			tls {
+				insecure = true
+			}
+		}
+	`, lis.Addr())
+	var args otlp.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	traces := ptrace.NewTraces()
+	for _, tenant := range []string{"team-a", ""} {
+		rs := traces.ResourceSpans().AppendEmpty()
+		if tenant != "" {
+			rs.Resource().Attributes().PutStr("tenant", tenant)
+		}
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("TestSpan")
+	}
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	require.Eventually(t, func() bool {
+		return exports.Input.ConsumeTraces(ctx, traces) == nil
+	}, 5*time.Second, 50*time.Millisecond, "failed to send traces")
+
+	var orgIDs []string
+	for len(orgIDs) < 2 {
+		select {
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for traces")
+		case ids := <-orgIDCh:
+			orgIDs = append(orgIDs, ids...)
+		}
+	}
+	require.ElementsMatch(t, []string{"team-a", "anonymous"}, orgIDs)
+}
+
 // makeTracesServer returns a host:port which will accept traces over insecure
 // gRPC.
 func makeTracesServer(t *testing.T, ch chan ptrace.Traces) string {
@@ -108,11 +187,18 @@ func makeTracesServer(t *testing.T, ch chan ptrace.Traces) string {
 
 type mockTracesReceiver struct {
 	ch chan ptrace.Traces
+
+	// md, if set, receives the X-Scope-OrgID header of requests.
+	md chan []string
 }
 
 var _ ptraceotlp.GRPCServer = (*mockTracesReceiver)(nil)
 
-func (ms *mockTracesReceiver) Export(_ context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+func (ms *mockTracesReceiver) Export(ctx context.Context, req ptraceotlp.ExportRequest) (ptraceotlp.ExportResponse, error) {
+	if ms.md != nil {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ms.md <- md.Get("X-Scope-OrgID")
+	}
 	ms.ch <- req.Traces()
 	return ptraceotlp.NewExportResponse(), nil
 }
@@ -82,7 +82,7 @@ func (args *Arguments) Validate() error {
 	if args.Client.Endpoint == "" && args.TracesEndpoint == "" && args.MetricsEndpoint == "" && args.LogsEndpoint == "" {
 		return errors.New("at least one endpoint must be specified")
 	}
-	return nil
+	return (*otelcol.HTTPClientArguments)(&args.Client).Validate()
 }
 
 // HTTPClientArguments is used to configure otelcol.exporter.otlphttp with
@@ -386,9 +386,8 @@ func (conv *Converter) consumeSum(app storage.Appender, memResource *memorySerie
 	case m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityCumulative && !m.Sum().IsMonotonic():
 		convType = textparse.MetricTypeGauge
 	case m.Sum().AggregationTemporality() == pmetric.AggregationTemporalityDelta && m.Sum().IsMonotonic():
-		// Drop non-cumulative summaries for now, which is permitted by the spec.
-		//
-		// TODO(rfratto): implement delta-to-cumulative for sums.
+		// Drop non-cumulative sums, which is permitted by the spec. Delta sums
+		// can be converted by otelcol.processor.deltatocumulative first.
 		return
 	default:
 		// Drop the metric.
@@ -420,9 +419,9 @@ func (conv *Converter) consumeHistogram(app storage.Appender, memResource *memor
 	metricName := prometheus.BuildPromCompliantName(m, "")
 
 	if m.Histogram().AggregationTemporality() != pmetric.AggregationTemporalityCumulative {
-		// Drop non-cumulative histograms for now, which is permitted by the spec.
-		//
-		// TODO(rfratto): implement delta-to-cumulative for histograms.
+		// Drop non-cumulative histograms, which is permitted by the spec. Delta
+		// histograms can be converted by otelcol.processor.deltatocumulative
+		// first.
 		return
 	}
 
@@ -0,0 +1,134 @@
+// Package record provides an otelcol.exporter.record component.
+package record
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/recorder"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/lazyconsumer"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.exporter.record",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(o component.Options, a component.Arguments) (component.Component, error) {
+			return New(o, a.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.exporter.record component.
+type Arguments struct {
+	Recorder recorder.Arguments `river:",squash"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	args.Recorder.SetToDefault()
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	return args.Recorder.Validate()
+}
+
+// Batch is a recorded batch of telemetry data, encoded as OTLP JSON. Only one
+// of its fields is set.
+type Batch struct {
+	Traces  json.RawMessage `json:"traces,omitempty"`
+	Metrics json.RawMessage `json:"metrics,omitempty"`
+	Logs    json.RawMessage `json:"logs,omitempty"`
+}
+
+// Component is the otelcol.exporter.record component.
+type Component struct {
+	recorder *recorder.Recorder
+}
+
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.HTTPComponent = (*Component)(nil)
+
+	_ consumer.Traces  = (*Component)(nil)
+	_ consumer.Metrics = (*Component)(nil)
+	_ consumer.Logs    = (*Component)(nil)
+)
+
+// New creates a new otelcol.exporter.record component.
+func New(o component.Options, c Arguments) (*Component, error) {
+	rec, err := recorder.New(o.Logger, o.Registerer, o.DataPath, c.Recorder)
+	if err != nil {
+		return nil, err
+	}
+	res := &Component{recorder: rec}
+
+	// The component itself records the data it consumes, so the exported
+	// consumer remains the same throughout the component's lifetime.
+	export := lazyconsumer.New(context.Background())
+	export.SetConsumers(res, res, res)
+	o.OnStateChange(otelcol.ConsumerExports{Input: export})
+
+	return res, nil
+}
+
+// Run implements Component.
+func (c *Component) Run(ctx context.Context) error {
+	c.recorder.Run(ctx)
+	return nil
+}
+
+// Update implements Component.
+func (c *Component) Update(newConfig component.Arguments) error {
+	c.recorder.ApplyConfig(newConfig.(Arguments).Recorder)
+	return nil
+}
+
+// Handler implements component.HTTPComponent. It serves /export, which
+// exports the batches recorded in a time window.
+func (c *Component) Handler() http.Handler {
+	return c.recorder.Handler()
+}
+
+// Capabilities implements consumer.Traces, consumer.Metrics and consumer.Logs.
+func (c *Component) Capabilities() consumer.Capabilities {
+	return consumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements consumer.Traces.
+func (c *Component) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	data, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+	if err != nil {
+		return err
+	}
+	return c.recorder.Record(time.Now(), Batch{Traces: data})
+}
+
+// ConsumeMetrics implements consumer.Metrics.
+func (c *Component) ConsumeMetrics(_ context.Context, md pmetric.Metrics) error {
+	data, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+	if err != nil {
+		return err
+	}
+	return c.recorder.Record(time.Now(), Batch{Metrics: data})
+}
+
+// ConsumeLogs implements consumer.Logs.
+func (c *Component) ConsumeLogs(_ context.Context, ld plog.Logs) error {
+	data, err := (&plog.JSONMarshaler{}).MarshalLogs(ld)
+	if err != nil {
+		return err
+	}
+	return c.recorder.Record(time.Now(), Batch{Logs: data})
+}
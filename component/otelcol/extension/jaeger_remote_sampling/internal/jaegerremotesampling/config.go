@@ -47,7 +47,8 @@ type Source struct {
 	// File specifies a local file as the strategies source
 	File string `mapstructure:"file"`
 
-	// ReloadInterval determines the periodicity to refresh the strategies
+	// ReloadInterval determines the periodicity to refresh the strategies. For
+	// remote sources, strategies are cached for ReloadInterval.
 	ReloadInterval time.Duration `mapstructure:"reload_interval"`
 
 	// Contents is a field added for the Grafana Agent that allows dynamic mapping of sampling rules
@@ -67,8 +67,14 @@ func (jrse *jrsExtension) Start(ctx context.Context, host component.Host) error
 			return fmt.Errorf("failed to create the local file strategy store: %w", err)
 		}
 
-		// there's a Close function on the concrete type, which is not visible to us...
-		// how can we close it then?
+		// The Close function of the concrete type stops reloading the
+		// strategies, but isn't part of the StrategyStore interface.
+		if closer, ok := ss.(interface{ Close() }); ok {
+			jrse.closers = append(jrse.closers, func() error {
+				closer.Close()
+				return nil
+			})
+		}
 		jrse.samplingStore = ss
 	}
 
@@ -83,6 +89,9 @@ func (jrse *jrsExtension) Start(ctx context.Context, host component.Host) error
 		}
 
 		jrse.samplingStore = grpcStore.NewConfigManager(conn)
+		if jrse.cfg.Source.ReloadInterval > 0 {
+			jrse.samplingStore = newCachingStore(jrse.samplingStore, jrse.cfg.Source.ReloadInterval, jrse.telemetry.Logger)
+		}
 		jrse.closers = append(jrse.closers, func() error {
 			return conn.Close()
 		})
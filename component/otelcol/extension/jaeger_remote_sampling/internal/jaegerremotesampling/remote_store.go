@@ -0,0 +1,92 @@
+package jaegerremotesampling
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+	"go.uber.org/zap"
+)
+
+// cachingStore caches the strategies retrieved from a remote store, so that
+// the remote is asked for the strategy of a service at most once per
+// interval. Invalid strategies and failed requests are logged, and the last
+// valid strategy of the service is served instead.
+type cachingStore struct {
+	next     strategystore.StrategyStore
+	interval time.Duration
+	logger   *zap.Logger
+	now      func() time.Time
+
+	mut     sync.Mutex
+	entries map[string]cachedStrategy
+}
+
+type cachedStrategy struct {
+	resp    *sampling.SamplingStrategyResponse
+	fetched time.Time
+}
+
+var _ strategystore.StrategyStore = (*cachingStore)(nil)
+
+func newCachingStore(next strategystore.StrategyStore, interval time.Duration, logger *zap.Logger) *cachingStore {
+	return &cachingStore{
+		next:     next,
+		interval: interval,
+		logger:   logger,
+		now:      time.Now,
+		entries:  make(map[string]cachedStrategy),
+	}
+}
+
+// GetSamplingStrategy implements strategystore.StrategyStore.
+func (s *cachingStore) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	s.mut.Lock()
+	cached, ok := s.entries[serviceName]
+	s.mut.Unlock()
+	if ok && s.now().Sub(cached.fetched) < s.interval {
+		return cached.resp, nil
+	}
+
+	resp, err := s.next.GetSamplingStrategy(ctx, serviceName)
+	if err == nil {
+		err = validateResponse(resp)
+	}
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		s.logger.Warn("failed to refresh sampling strategy, serving the last valid strategy",
+			zap.String("service", serviceName), zap.Error(err))
+		return cached.resp, nil
+	}
+
+	s.mut.Lock()
+	s.entries[serviceName] = cachedStrategy{resp: resp, fetched: s.now()}
+	s.mut.Unlock()
+	return resp, nil
+}
+
+// validateResponse checks that resp holds the settings of its strategy type.
+func validateResponse(resp *sampling.SamplingStrategyResponse) error {
+	if resp == nil {
+		return fmt.Errorf("empty sampling strategy")
+	}
+	switch resp.StrategyType {
+	case sampling.SamplingStrategyType_PROBABILISTIC:
+		p := resp.ProbabilisticSampling
+		if p == nil || p.SamplingRate < 0 || p.SamplingRate > 1 {
+			return fmt.Errorf("invalid probabilistic sampling strategy")
+		}
+	case sampling.SamplingStrategyType_RATE_LIMITING:
+		if r := resp.RateLimitingSampling; r == nil || r.MaxTracesPerSecond < 0 {
+			return fmt.Errorf("invalid rate limiting sampling strategy")
+		}
+	default:
+		return fmt.Errorf("unknown sampling strategy type %v", resp.StrategyType)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package jaegerremotesampling
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeStore struct {
+	calls int
+	resp  *sampling.SamplingStrategyResponse
+	err   error
+}
+
+func (s *fakeStore) GetSamplingStrategy(context.Context, string) (*sampling.SamplingStrategyResponse, error) {
+	s.calls++
+	return s.resp, s.err
+}
+
+func probabilistic(rate float64) *sampling.SamplingStrategyResponse {
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: rate},
+	}
+}
+
+func TestCachingStore(t *testing.T) {
+	now := time.Now()
+	remote := &fakeStore{resp: probabilistic(0.5)}
+	store := newCachingStore(remote, time.Minute, zap.NewNop())
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Strategies are cached for the interval.
+	resp, err := store.GetSamplingStrategy(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, probabilistic(0.5), resp)
+	_, err = store.GetSamplingStrategy(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, 1, remote.calls)
+
+	// Strategies are refreshed once the interval passed.
+	now = now.Add(time.Minute)
+	remote.resp = probabilistic(0.25)
+	resp, err = store.GetSamplingStrategy(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, probabilistic(0.25), resp)
+	require.Equal(t, 2, remote.calls)
+
+	// The last valid strategy is served if the remote fails or returns an
+	// invalid strategy.
+	now = now.Add(time.Minute)
+	remote.err = errors.New("unavailable")
+	resp, err = store.GetSamplingStrategy(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, probabilistic(0.25), resp)
+
+	remote.err = nil
+	remote.resp = probabilistic(2)
+	resp, err = store.GetSamplingStrategy(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, probabilistic(0.25), resp)
+
+	// Errors are returned if there's no strategy to fall back to.
+	_, err = store.GetSamplingStrategy(ctx, "bar")
+	require.EqualError(t, err, "invalid probabilistic sampling strategy")
+}
@@ -123,3 +123,26 @@ func makeResponse(samplerType sampling.SamplingStrategyType, param float64) (res
 	}
 	return resp
 }
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Validate(strategiesJSON(.8)))
+	require.NoError(t, Validate(`null`))
+
+	tt := []struct {
+		strats string
+		expect string
+	}{
+		{`{"default_strategy": `, "failed to unmarshal strategies"},
+		{`{"default_strategy": {"type": "probabilistic", "param": 1.5}}`, "default_strategy: probabilistic sampling probability must be between 0 and 1, got 1.5"},
+		{`{"default_strategy": {"type": "always_on"}}`, `default_strategy: unknown strategy type "always_on", must be "probabilistic" or "ratelimiting"`},
+		{`{"service_strategies": [{"type": "ratelimiting", "param": 1}]}`, "service_strategies[0]: missing service name"},
+		{`{"service_strategies": [{"service": "foo", "type": "ratelimiting", "param": -1}]}`, "service_strategies[0] (foo): ratelimiting max traces per second must not be negative, got -1"},
+		{
+			`{"service_strategies": [{"service": "foo", "type": "probabilistic", "param": 0.5, "operation_strategies": [{"operation": "op1", "type": "probabilistic", "param": 2}]}]}`,
+			`service_strategies[0] (foo): operation "op1": probabilistic sampling probability must be between 0 and 1, got 2`,
+		},
+	}
+	for _, tc := range tt {
+		require.ErrorContains(t, Validate(tc.strats), tc.expect)
+	}
+}
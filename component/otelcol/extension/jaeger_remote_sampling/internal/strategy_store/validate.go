@@ -0,0 +1,64 @@
+package strategy_store
+
+import (
+	"fmt"
+)
+
+// Validate checks that strats is a valid sampling strategies document. Every
+// strategy must either be probabilistic with a sampling probability between
+// 0 and 1, or rate limiting with a non-negative number of traces per second.
+func Validate(strats string) error {
+	s, err := loadStrategies(func() ([]byte, error) { return []byte(strats), nil })
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+
+	if s.DefaultStrategy != nil {
+		if err := validateServiceStrategy(s.DefaultStrategy); err != nil {
+			return fmt.Errorf("default_strategy: %w", err)
+		}
+	}
+	for i, ss := range s.ServiceStrategies {
+		if ss == nil || ss.Service == "" {
+			return fmt.Errorf("service_strategies[%d]: missing service name", i)
+		}
+		if err := validateServiceStrategy(ss); err != nil {
+			return fmt.Errorf("service_strategies[%d] (%s): %w", i, ss.Service, err)
+		}
+	}
+	return nil
+}
+
+func validateServiceStrategy(s *serviceStrategy) error {
+	if err := validateStrategy(&s.strategy); err != nil {
+		return err
+	}
+	for _, op := range s.OperationStrategies {
+		if op == nil {
+			continue
+		}
+		if err := validateStrategy(&op.strategy); err != nil {
+			return fmt.Errorf("operation %q: %w", op.Operation, err)
+		}
+	}
+	return nil
+}
+
+func validateStrategy(s *strategy) error {
+	switch s.Type {
+	case samplerTypeProbabilistic:
+		if s.Param < 0 || s.Param > 1 {
+			return fmt.Errorf("probabilistic sampling probability must be between 0 and 1, got %v", s.Param)
+		}
+	case samplerTypeRateLimiting:
+		if s.Param < 0 {
+			return fmt.Errorf("ratelimiting max traces per second must not be negative, got %v", s.Param)
+		}
+	default:
+		return fmt.Errorf("unknown strategy type %q, must be %q or %q", s.Type, samplerTypeProbabilistic, samplerTypeRateLimiting)
+	}
+	return nil
+}
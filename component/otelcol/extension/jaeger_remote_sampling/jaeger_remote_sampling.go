@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/extension"
 	"github.com/grafana/agent/component/otelcol/extension/jaeger_remote_sampling/internal/jaegerremotesampling"
+	"github.com/grafana/agent/component/otelcol/extension/jaeger_remote_sampling/internal/strategy_store"
 	otelcomponent "go.opentelemetry.io/collector/component"
 	otelconfig "go.opentelemetry.io/collector/config"
 )
@@ -120,6 +121,20 @@ func (a *ArgumentsSource) Validate() error {
 		return fmt.Errorf("only one of contents, file or remote can be configured")
 	}
 
+	if a.ReloadInterval < 0 {
+		return fmt.Errorf("reload_interval must not be negative")
+	}
+	// Contents are updated whenever the component is, so they have nothing
+	// to reload.
+	if a.Content != "" && a.ReloadInterval != 0 {
+		return fmt.Errorf("reload_interval can only be used with file or remote")
+	}
+	if a.Content != "" {
+		if err := strategy_store.Validate(a.Content); err != nil {
+			return fmt.Errorf("invalid content: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -88,6 +89,57 @@ func TestContentSource(t *testing.T) {
 	require.JSONEq(t, actual, expectedRemoteSamplingConfig)
 }
 
+func TestFileSourceReload(t *testing.T) {
+	remoteSamplingConfigFile := filepath.ToSlash(filepath.Join(t.TempDir(), "remote.json"))
+	writeStrategies := func(rate float64) {
+		strategies := fmt.Sprintf(`{"default_strategy": {"type": "probabilistic", "param": %v}}`, rate)
+		require.NoError(t, os.WriteFile(remoteSamplingConfigFile, []byte(strategies), 0644))
+	}
+	writeStrategies(0.5)
+
+	listenAddr := getFreeAddr(t)
+	cfg := fmt.Sprintf(`
+	    http {
+			endpoint = "%s"
+	    }
+		source {
+			file            = "%s"
+			reload_interval = "100ms"
+		}
+	`, listenAddr, remoteSamplingConfigFile)
+
+	get, cancel := startJaegerRemoteSamplingServer(t, cfg, listenAddr)
+	defer cancel()
+	require.JSONEq(t, `{"strategyType": "PROBABILISTIC", "probabilisticSampling": {"samplingRate": 0.5}}`, get("foo"))
+
+	writeStrategies(0.25)
+	util.Eventually(t, func(t require.TestingT) {
+		require.JSONEq(t, `{"strategyType": "PROBABILISTIC", "probabilisticSampling": {"samplingRate": 0.25}}`, get("foo"))
+	})
+}
+
+func TestURLSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"default_strategy": {"type": "ratelimiting", "param": 10}}`)
+	}))
+	defer srv.Close()
+
+	listenAddr := getFreeAddr(t)
+	cfg := fmt.Sprintf(`
+	    http {
+			endpoint = "%s"
+	    }
+		source {
+			file            = "%s"
+			reload_interval = "1m"
+		}
+	`, listenAddr, srv.URL)
+
+	get, cancel := startJaegerRemoteSamplingServer(t, cfg, listenAddr)
+	defer cancel()
+	require.JSONEq(t, `{"strategyType": "RATE_LIMITING", "rateLimitingSampling": {"maxTracesPerSecond": 10}}`, get("foo"))
+}
+
 func startJaegerRemoteSamplingServer(t *testing.T, cfg string, listenAddr string) (func(svc string) string, context.CancelFunc) {
 	ctx := componenttest.TestContext(t)
 	ctx, cancel := context.WithTimeout(ctx, time.Minute)
@@ -242,6 +294,49 @@ func TestUnmarshalRequiresExactlyOneSource(t *testing.T) {
 	}
 }
 
+func TestUnmarshalValidatesSource(t *testing.T) {
+	tcs := []struct {
+		cfg           string
+		expectedError string
+	}{
+		{
+			cfg: `
+				http {}
+				source {
+					file            = "remote.json"
+					reload_interval = "-1s"
+				}
+			`,
+			expectedError: "reload_interval must not be negative",
+		},
+		{
+			cfg: `
+				http {}
+				source {
+					content         = "{}"
+					reload_interval = "1m"
+				}
+			`,
+			expectedError: "reload_interval can only be used with file or remote",
+		},
+		{
+			cfg: `
+				http {}
+				source {
+					content = "{\"default_strategy\": {\"type\": \"probabilistic\", \"param\": 2}}"
+				}
+			`,
+			expectedError: "invalid content: default_strategy: probabilistic sampling probability must be between 0 and 1, got 2",
+		},
+	}
+
+	for _, tc := range tcs {
+		var args jaeger_remote_sampling.Arguments
+		err := river.Unmarshal([]byte(tc.cfg), &args)
+		require.EqualError(t, err, tc.expectedError)
+	}
+}
+
 func getFreeAddr(t *testing.T) string {
 	t.Helper()
 
@@ -0,0 +1,282 @@
+// Package ottlspanevent implements the OTTL context of span events, which
+// pkg/ottl doesn't provide in the version in use. It follows the other
+// contexts of pkg/ottl.
+package ottlspanevent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// TransformContext is the OTTL context of span events.
+type TransformContext struct {
+	event    ptrace.SpanEvent
+	span     ptrace.Span
+	scope    pcommon.InstrumentationScope
+	resource pcommon.Resource
+}
+
+// NewTransformContext returns the context of event, which belongs to span.
+func NewTransformContext(event ptrace.SpanEvent, span ptrace.Span, scope pcommon.InstrumentationScope, resource pcommon.Resource) TransformContext {
+	return TransformContext{event: event, span: span, scope: scope, resource: resource}
+}
+
+// NewParser returns a parser of statements executed against span events.
+func NewParser(functions map[string]interface{}, set component.TelemetrySettings) ottl.Parser[TransformContext] {
+	return ottl.NewParser[TransformContext](functions, parseSpanEventPath, parseSpanEventEnum, set)
+}
+
+func parseSpanEventEnum(val *ottl.EnumSymbol) (*ottl.Enum, error) {
+	if val != nil {
+		return nil, fmt.Errorf("enum symbol, %s, not found", *val)
+	}
+	return nil, fmt.Errorf("enum symbol not provided")
+}
+
+func parseSpanEventPath(val *ottl.Path) (ottl.GetSetter[TransformContext], error) {
+	if val == nil || len(val.Fields) == 0 {
+		return nil, fmt.Errorf("bad path %v", val)
+	}
+
+	path := val.Fields
+	switch path[0].Name {
+	case "resource":
+		return resourceGetSetter(path[1:])
+	case "instrumentation_scope":
+		return scopeGetSetter(path[1:])
+	case "span":
+		return spanGetSetter(path[1:])
+	case "name":
+		return getSetter(
+			func(ctx TransformContext) interface{} { return ctx.event.Name() },
+			func(ctx TransformContext, val interface{}) {
+				if s, ok := val.(string); ok {
+					ctx.event.SetName(s)
+				}
+			},
+		), nil
+	case "time_unix_nano":
+		return getSetter(
+			func(ctx TransformContext) interface{} { return ctx.event.Timestamp().AsTime().UnixNano() },
+			func(ctx TransformContext, val interface{}) {
+				if i, ok := val.(int64); ok {
+					ctx.event.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(0, i)))
+				}
+			},
+		), nil
+	case "attributes":
+		return attributesGetSetter(path[0].MapKey, func(ctx TransformContext) pcommon.Map { return ctx.event.Attributes() }), nil
+	case "dropped_attributes_count":
+		return getSetter(
+			func(ctx TransformContext) interface{} { return int64(ctx.event.DroppedAttributesCount()) },
+			func(ctx TransformContext, val interface{}) {
+				if i, ok := val.(int64); ok {
+					ctx.event.SetDroppedAttributesCount(uint32(i))
+				}
+			},
+		), nil
+	}
+	return nil, fmt.Errorf("invalid path expression %v", path)
+}
+
+func resourceGetSetter(path []ottl.Field) (ottl.GetSetter[TransformContext], error) {
+	if len(path) == 0 {
+		return getSetter(
+			func(ctx TransformContext) interface{} { return ctx.resource },
+			func(ctx TransformContext, val interface{}) {
+				if r, ok := val.(pcommon.Resource); ok {
+					r.CopyTo(ctx.resource)
+				}
+			},
+		), nil
+	}
+
+	switch path[0].Name {
+	case "attributes":
+		return attributesGetSetter(path[0].MapKey, func(ctx TransformContext) pcommon.Map { return ctx.resource.Attributes() }), nil
+	case "dropped_attributes_count":
+		return getSetter(
+			func(ctx TransformContext) interface{} { return int64(ctx.resource.DroppedAttributesCount()) },
+			func(ctx TransformContext, val interface{}) {
+				if i, ok := val.(int64); ok {
+					ctx.resource.SetDroppedAttributesCount(uint32(i))
+				}
+			},
+		), nil
+	}
+	return nil, fmt.Errorf("invalid resource path expression %v", path)
+}
+
+func scopeGetSetter(path []ottl.Field) (ottl.GetSetter[TransformContext], error) {
+	if len(path) == 0 {
+		return getSetter(
+			func(ctx TransformContext) interface{} { return ctx.scope },
+			func(ctx TransformContext, val interface{}) {
+				if s, ok := val.(pcommon.InstrumentationScope); ok {
+					s.CopyTo(ctx.scope)
+				}
+			},
+		), nil
+	}
+
+	switch path[0].Name {
+	case "name":
+		return getSetter(
+			func(ctx TransformContext) interface{} { return ctx.scope.Name() },
+			func(ctx TransformContext, val interface{}) {
+				if s, ok := val.(string); ok {
+					ctx.scope.SetName(s)
+				}
+			},
+		), nil
+	case "version":
+		return getSetter(
+			func(ctx TransformContext) interface{} { return ctx.scope.Version() },
+			func(ctx TransformContext, val interface{}) {
+				if s, ok := val.(string); ok {
+					ctx.scope.SetVersion(s)
+				}
+			},
+		), nil
+	case "attributes":
+		return attributesGetSetter(path[0].MapKey, func(ctx TransformContext) pcommon.Map { return ctx.scope.Attributes() }), nil
+	case "dropped_attributes_count":
+		return getSetter(
+			func(ctx TransformContext) interface{} { return int64(ctx.scope.DroppedAttributesCount()) },
+			func(ctx TransformContext, val interface{}) {
+				if i, ok := val.(int64); ok {
+					ctx.scope.SetDroppedAttributesCount(uint32(i))
+				}
+			},
+		), nil
+	}
+	return nil, fmt.Errorf("invalid instrumentation_scope path expression %v", path)
+}
+
+// spanGetSetter gives access to the span an event belongs to. Only the most
+// common fields are supported; use the span context for anything else.
+func spanGetSetter(path []ottl.Field) (ottl.GetSetter[TransformContext], error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("span path expressions must select a field of the span")
+	}
+
+	switch path[0].Name {
+	case "name":
+		return getSetter(
+			func(ctx TransformContext) interface{} { return ctx.span.Name() },
+			func(ctx TransformContext, val interface{}) {
+				if s, ok := val.(string); ok {
+					ctx.span.SetName(s)
+				}
+			},
+		), nil
+	case "attributes":
+		return attributesGetSetter(path[0].MapKey, func(ctx TransformContext) pcommon.Map { return ctx.span.Attributes() }), nil
+	}
+	return nil, fmt.Errorf("invalid span path expression %v", path)
+}
+
+// attributesGetSetter gives access to a map of attributes, or to one of its
+// values if mapKey is set.
+func attributesGetSetter(mapKey *string, attrs func(TransformContext) pcommon.Map) ottl.GetSetter[TransformContext] {
+	if mapKey == nil {
+		return getSetter(
+			func(ctx TransformContext) interface{} { return attrs(ctx) },
+			func(ctx TransformContext, val interface{}) {
+				if m, ok := val.(pcommon.Map); ok {
+					m.CopyTo(attrs(ctx))
+				}
+			},
+		)
+	}
+	return getSetter(
+		func(ctx TransformContext) interface{} { return getMapValue(attrs(ctx), *mapKey) },
+		func(ctx TransformContext, val interface{}) { setMapValue(attrs(ctx), *mapKey, val) },
+	)
+}
+
+// getSetter builds an ottl.GetSetter from accessors which can't fail.
+func getSetter(get func(TransformContext) interface{}, set func(TransformContext, interface{})) ottl.StandardGetSetter[TransformContext] {
+	return ottl.StandardGetSetter[TransformContext]{
+		Getter: func(ctx TransformContext) (interface{}, error) { return get(ctx), nil },
+		Setter: func(ctx TransformContext, val interface{}) error {
+			set(ctx, val)
+			return nil
+		},
+	}
+}
+
+// getMapValue and setMapValue convert between attribute values and OTTL
+// values the same way the contexts of pkg/ottl do.
+
+func getMapValue(attrs pcommon.Map, key string) interface{} {
+	val, ok := attrs.Get(key)
+	if !ok {
+		return nil
+	}
+
+	switch val.Type() {
+	case pcommon.ValueTypeStr:
+		return val.Str()
+	case pcommon.ValueTypeBool:
+		return val.Bool()
+	case pcommon.ValueTypeInt:
+		return val.Int()
+	case pcommon.ValueTypeDouble:
+		return val.Double()
+	case pcommon.ValueTypeMap:
+		return val.Map()
+	case pcommon.ValueTypeSlice:
+		return val.Slice()
+	case pcommon.ValueTypeBytes:
+		return val.Bytes().AsRaw()
+	}
+	return nil
+}
+
+func setMapValue(attrs pcommon.Map, key string, val interface{}) {
+	switch v := val.(type) {
+	case string:
+		attrs.PutStr(key, v)
+	case bool:
+		attrs.PutBool(key, v)
+	case int64:
+		attrs.PutInt(key, v)
+	case float64:
+		attrs.PutDouble(key, v)
+	case []byte:
+		attrs.PutEmptyBytes(key).FromRaw(v)
+	case []string:
+		s := attrs.PutEmptySlice(key)
+		for _, str := range v {
+			s.AppendEmpty().SetStr(str)
+		}
+	case []bool:
+		s := attrs.PutEmptySlice(key)
+		for _, b := range v {
+			s.AppendEmpty().SetBool(b)
+		}
+	case []int64:
+		s := attrs.PutEmptySlice(key)
+		for _, i := range v {
+			s.AppendEmpty().SetInt(i)
+		}
+	case []float64:
+		s := attrs.PutEmptySlice(key)
+		for _, f := range v {
+			s.AppendEmpty().SetDouble(f)
+		}
+	case [][]byte:
+		s := attrs.PutEmptySlice(key)
+		for _, b := range v {
+			s.AppendEmpty().SetEmptyBytes().FromRaw(b)
+		}
+	default:
+		attrs.PutEmpty(key)
+	}
+}
@@ -20,7 +20,7 @@ func init() {
 		Exports: otelcol.ConsumerExports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := attributesprocessor.NewFactory()
+			fact := newFactory(attributesprocessor.NewFactory())
 			return processor.New(opts, fact, args.(Arguments))
 		},
 	})
@@ -45,9 +45,20 @@ var (
 
 // Convert implements processor.Arguments.
 func (args Arguments) Convert() (otelconfig.Processor, error) {
+	for _, action := range args.Actions {
+		if action.IsCustomHash() {
+			return args.convertStages()
+		}
+	}
+	return args.convertActions(args.Actions.Convert())
+}
+
+// convertActions converts args into an upstream processor config performing
+// the given actions.
+func (args Arguments) convertActions(actions []interface{}) (*attributesprocessor.Config, error) {
 	input := make(map[string]interface{})
 
-	if actions := args.Actions.Convert(); len(actions) > 0 {
+	if len(actions) > 0 {
 		input["actions"] = actions
 	}
 
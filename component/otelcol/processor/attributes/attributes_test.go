@@ -455,6 +455,250 @@ func Test_Hash(t *testing.T) {
 	testRunProcessor(t, cfg, NewTraceSignal(inputTrace, expectedOutputTrace))
 }
 
+func Test_SaltedHash(t *testing.T) {
+	cfg := `
+		exclude {
+			match_type = "strict"
+			attribute {
+				key = "skip"
+				value = true
+			}
+		}
+		action {
+			key = "user.email"
+			pattern = "^(?P<user_name>[^@]+)@(?P<user_domain>.+)$"
+			action = "extract"
+		}
+		action {
+			key = "user.email"
+			action = "hash"
+			hash_function = "sha256"
+			salt = "s3cr3t"
+		}
+		action {
+			key = "user_name"
+			action = "hash"
+			hash_function = "sha256"
+			salt = "s3cr3t"
+		}
+		action {
+			key = "user.email.copy"
+			from_attribute = "user.email"
+			action = "insert"
+		}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+
+	var inputTrace = `{
+		"resourceSpans": [{
+			"scopeSpans": [{
+				"spans": [{
+					"name": "TestSpan",
+					"attributes": [{
+						"key": "user.email",
+						"value": { "stringValue": "user@email.com" }
+					}]
+				},
+				{
+					"name": "SkippedSpan",
+					"attributes": [{
+						"key": "user.email",
+						"value": { "stringValue": "user@email.com" }
+					},
+					{
+						"key": "skip",
+						"value": { "boolValue": true }
+					}]
+				}]
+			}]
+		}]
+	}`
+
+	expectedOutputTrace := `{
+		"resourceSpans": [{
+			"scopeSpans": [{
+				"spans": [{
+					"name": "TestSpan",
+					"attributes": [{
+						"key": "user.email",
+						"value": { "stringValue": "218dc4e3172442211fd5bad54064eb15aa0be4ef31d9d8e4c8eddf658d35dfba" }
+					},
+					{
+						"key": "user_name",
+						"value": { "stringValue": "c291de0ae23d552ad43572c7b868b7b1e223acea034ccc35eeeccb7ee7fbc734" }
+					},
+					{
+						"key": "user_domain",
+						"value": { "stringValue": "email.com" }
+					},
+					{
+						"key": "user.email.copy",
+						"value": { "stringValue": "218dc4e3172442211fd5bad54064eb15aa0be4ef31d9d8e4c8eddf658d35dfba" }
+					}]
+				},
+				{
+					"name": "SkippedSpan",
+					"attributes": [{
+						"key": "user.email",
+						"value": { "stringValue": "user@email.com" }
+					},
+					{
+						"key": "skip",
+						"value": { "boolValue": true }
+					}]
+				}]
+			}]
+		}]
+	}`
+
+	testRunProcessor(t, cfg, NewTraceSignal(inputTrace, expectedOutputTrace))
+}
+
+func Test_SaltedHashMetrics(t *testing.T) {
+	cfg := `
+		action {
+			key = "user.id"
+			action = "hash"
+			hash_function = "sha256"
+			salt = "s3cr3t"
+		}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+
+	var inputMetrics = `{
+		"resourceMetrics": [{
+			"scopeMetrics": [{
+				"metrics": [{
+					"name": "logins",
+					"sum": {
+						"dataPoints": [{
+							"asInt": "1",
+							"attributes": [{
+								"key": "user.id",
+								"value": { "intValue": "42" }
+							}]
+						}]
+					}
+				}]
+			}]
+		}]
+	}`
+
+	expectedOutputMetrics := `{
+		"resourceMetrics": [{
+			"scopeMetrics": [{
+				"metrics": [{
+					"name": "logins",
+					"sum": {
+						"dataPoints": [{
+							"asInt": "1",
+							"attributes": [{
+								"key": "user.id",
+								"value": { "stringValue": "72eb2c6410d1f0fb32f4d801920633e56fb9a4f602735987943628131c47c09e" }
+							}]
+						}]
+					}
+				}]
+			}]
+		}]
+	}`
+
+	testRunProcessor(t, cfg, NewMetricSignal(inputMetrics, expectedOutputMetrics))
+}
+
+func Test_SaltedHashSHA1(t *testing.T) {
+	cfg := `
+		action {
+			key = "user.email"
+			action = "hash"
+			salt = "pepper"
+		}
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+
+	var inputLogs = `{
+		"resourceLogs": [{
+			"scopeLogs": [{
+				"logRecords": [{
+					"attributes": [{
+						"key": "user.email",
+						"value": { "stringValue": "user@email.com" }
+					}]
+				}]
+			}]
+		}]
+	}`
+
+	expectedOutputLogs := `{
+		"resourceLogs": [{
+			"scopeLogs": [{
+				"logRecords": [{
+					"attributes": [{
+						"key": "user.email",
+						"value": { "stringValue": "57791ccc15af3069db6f6d7d068ef94c67613fd5" }
+					}]
+				}]
+			}]
+		}]
+	}`
+
+	testRunProcessor(t, cfg, NewLogSignal(inputLogs, expectedOutputLogs))
+}
+
+func Test_HashValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		expectedErr string
+	}{
+		{
+			name: "unsupported hash function",
+			action: `
+				key = "user.email"
+				action = "hash"
+				hash_function = "md5"
+			`,
+			expectedErr: `unsupported hash_function "md5"`,
+		},
+		{
+			name: "salt for other action",
+			action: `
+				key = "user.email"
+				action = "delete"
+				salt = "s3cr3t"
+			`,
+			expectedErr: "hash_function and salt can only be set for the hash action",
+		},
+		{
+			name: "salt with pattern",
+			action: `
+				key = "user.email"
+				pattern = "^user\\."
+				action = "hash"
+				salt = "s3cr3t"
+			`,
+			expectedErr: "pattern can't be used with hash_function or salt",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := "action {" + tc.action + "}\noutput {}"
+			var args attributes.Arguments
+			require.ErrorContains(t, river.Unmarshal([]byte(cfg), &args), tc.expectedErr)
+		})
+	}
+}
+
 func Test_Convert(t *testing.T) {
 	cfg := `
 		action {
@@ -0,0 +1,400 @@
+package attributes
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // SHA-1 is the default of the upstream processor.
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"math"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/attributesprocessor"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// The upstream processor only supports unsalted SHA-1 hashes, and its actions
+// can't be extended. Custom hash actions are instead performed by stages run
+// in between instances of the upstream processor:
+//
+//   - The upstream stage before a custom hash copies the attribute to hash
+//     into a temporary attribute. This way, the include and exclude blocks
+//     are still evaluated by the upstream processor, and attributes are only
+//     copied for the data they match.
+//
+//   - The hash stage replaces the attribute with the hash of the temporary
+//     attribute and removes the temporary attribute.
+//
+// This keeps actions running in the order they're specified in.
+
+// hashSourcePrefix prefixes the temporary attributes holding the values to
+// hash.
+const hashSourcePrefix = "__grafana_agent_hash_source_"
+
+// stagedConfig is the processor config used when args contain custom hash
+// actions.
+type stagedConfig struct {
+	otelconfig.ProcessorSettings `mapstructure:",squash"`
+
+	Stages []stage
+}
+
+var _ otelconfig.Processor = (*stagedConfig)(nil)
+
+// stage is a single stage of a stagedConfig. Exactly one of Attributes or
+// Hashes is set.
+type stage struct {
+	Attributes *attributesprocessor.Config
+	Hashes     []hashAction
+}
+
+// hashAction hashes the value of Source into Key.
+type hashAction struct {
+	Key      string
+	Source   string
+	Function string
+	Salt     string
+}
+
+// convertStages converts args into a stagedConfig.
+func (args Arguments) convertStages() (*stagedConfig, error) {
+	res := &stagedConfig{
+		ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID("attributes")),
+	}
+
+	var (
+		actions []interface{}
+		hashes  []hashAction
+	)
+	flush := func() error {
+		if len(actions) > 0 {
+			cfg, err := args.convertActions(actions)
+			if err != nil {
+				return err
+			}
+			res.Stages = append(res.Stages, stage{Attributes: cfg})
+		}
+		if len(hashes) > 0 {
+			res.Stages = append(res.Stages, stage{Hashes: hashes})
+		}
+		actions, hashes = nil, nil
+		return nil
+	}
+
+	for i, action := range args.Actions {
+		if !action.IsCustomHash() {
+			// Actions following a hash must see the hashed value.
+			if len(hashes) > 0 {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+			actions = append(actions, args.Actions[i:i+1].Convert()...)
+			continue
+		}
+
+		source := fmt.Sprintf("%s%d", hashSourcePrefix, i)
+		actions = append(actions, otelcol.AttrActionKeyValueSlice{{
+			Key:           source,
+			Action:        "upsert",
+			FromAttribute: action.Key,
+		}}.Convert()...)
+
+		function := action.HashFunction
+		if function == "" {
+			function = otelcol.HashFunctionSHA1
+		}
+		hashes = append(hashes, hashAction{
+			Key:      action.Key,
+			Source:   source,
+			Function: function,
+			Salt:     string(action.Salt),
+		})
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// applyHashes performs the hash actions against attrs.
+func applyHashes(hashes []hashAction, attrs pcommon.Map) {
+	for _, h := range hashes {
+		val, ok := attrs.Get(h.Source)
+		if !ok {
+			continue
+		}
+		attrs.PutStr(h.Key, h.hash(val))
+		attrs.Remove(h.Source)
+	}
+}
+
+// hash returns the hex-encoded hash of the salt followed by val. Values are
+// encoded the same way as for the upstream SHA-1 hashes; values of other
+// types hash to an empty string.
+func (h hashAction) hash(val pcommon.Value) string {
+	var b []byte
+	switch val.Type() {
+	case pcommon.ValueTypeStr:
+		b = []byte(val.Str())
+	case pcommon.ValueTypeBool:
+		if val.Bool() {
+			b = []byte{1}
+		} else {
+			b = []byte{0}
+		}
+	case pcommon.ValueTypeInt:
+		b = binary.LittleEndian.AppendUint64(nil, uint64(val.Int()))
+	case pcommon.ValueTypeDouble:
+		b = binary.LittleEndian.AppendUint64(nil, math.Float64bits(val.Double()))
+	}
+	if len(b) == 0 {
+		return ""
+	}
+
+	var hasher hash.Hash
+	switch h.Function {
+	case otelcol.HashFunctionSHA256:
+		hasher = sha256.New()
+	default:
+		hasher = sha1.New() //nolint:gosec
+	}
+	_, _ = hasher.Write([]byte(h.Salt))
+	_, _ = hasher.Write(b)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// newFactory returns a factory which creates processors from upstream configs
+// using the upstream factory, and processors chaining multiple stages from
+// stagedConfigs.
+func newFactory(upstream otelcomponent.ProcessorFactory) otelcomponent.ProcessorFactory {
+	f := &stagedFactory{upstream: upstream}
+	return otelcomponent.NewProcessorFactory(
+		upstream.Type(),
+		upstream.CreateDefaultConfig,
+		otelcomponent.WithTracesProcessor(f.createTracesProcessor, upstream.TracesProcessorStability()),
+		otelcomponent.WithMetricsProcessor(f.createMetricsProcessor, upstream.MetricsProcessorStability()),
+		otelcomponent.WithLogsProcessor(f.createLogsProcessor, upstream.LogsProcessorStability()),
+	)
+}
+
+type stagedFactory struct {
+	upstream otelcomponent.ProcessorFactory
+}
+
+func (f *stagedFactory) createTracesProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Traces) (otelcomponent.TracesProcessor, error) {
+	staged, ok := cfg.(*stagedConfig)
+	if !ok {
+		return f.upstream.CreateTracesProcessor(ctx, set, cfg, next)
+	}
+
+	var chain stageChain
+	for i := len(staged.Stages) - 1; i >= 0; i-- {
+		st := staged.Stages[i]
+		if st.Attributes == nil {
+			next = &hashTraces{hashes: st.Hashes, next: next}
+			continue
+		}
+		p, err := f.upstream.CreateTracesProcessor(ctx, set, st.Attributes, next)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, p)
+		next = p
+	}
+	return &tracesChain{Traces: next, stageChain: chain}, nil
+}
+
+func (f *stagedFactory) createMetricsProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Metrics) (otelcomponent.MetricsProcessor, error) {
+	staged, ok := cfg.(*stagedConfig)
+	if !ok {
+		return f.upstream.CreateMetricsProcessor(ctx, set, cfg, next)
+	}
+
+	var chain stageChain
+	for i := len(staged.Stages) - 1; i >= 0; i-- {
+		st := staged.Stages[i]
+		if st.Attributes == nil {
+			next = &hashMetrics{hashes: st.Hashes, next: next}
+			continue
+		}
+		p, err := f.upstream.CreateMetricsProcessor(ctx, set, st.Attributes, next)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, p)
+		next = p
+	}
+	return &metricsChain{Metrics: next, stageChain: chain}, nil
+}
+
+func (f *stagedFactory) createLogsProcessor(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Logs) (otelcomponent.LogsProcessor, error) {
+	staged, ok := cfg.(*stagedConfig)
+	if !ok {
+		return f.upstream.CreateLogsProcessor(ctx, set, cfg, next)
+	}
+
+	var chain stageChain
+	for i := len(staged.Stages) - 1; i >= 0; i-- {
+		st := staged.Stages[i]
+		if st.Attributes == nil {
+			next = &hashLogs{hashes: st.Hashes, next: next}
+			continue
+		}
+		p, err := f.upstream.CreateLogsProcessor(ctx, set, st.Attributes, next)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, p)
+		next = p
+	}
+	return &logsChain{Logs: next, stageChain: chain}, nil
+}
+
+// stageChain holds the upstream processors of a chain, ordered from last to
+// first.
+type stageChain []otelcomponent.Component
+
+// Start starts the processors, starting with the last one.
+func (c stageChain) Start(ctx context.Context, host otelcomponent.Host) error {
+	for _, p := range c {
+		if err := p.Start(ctx, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown stops the processors, starting with the first one.
+func (c stageChain) Shutdown(ctx context.Context) error {
+	var firstErr error
+	for i := len(c) - 1; i >= 0; i-- {
+		if err := c[i].Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+type tracesChain struct {
+	otelconsumer.Traces
+	stageChain
+}
+
+type metricsChain struct {
+	otelconsumer.Metrics
+	stageChain
+}
+
+type logsChain struct {
+	otelconsumer.Logs
+	stageChain
+}
+
+type hashTraces struct {
+	hashes []hashAction
+	next   otelconsumer.Traces
+}
+
+func (h *hashTraces) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+func (h *hashTraces) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	rss := td.ResourceSpans()
+	for i := 0; i < rss.Len(); i++ {
+		sss := rss.At(i).ScopeSpans()
+		for j := 0; j < sss.Len(); j++ {
+			spans := sss.At(j).Spans()
+			for k := 0; k < spans.Len(); k++ {
+				applyHashes(h.hashes, spans.At(k).Attributes())
+			}
+		}
+	}
+	return h.next.ConsumeTraces(ctx, td)
+}
+
+type hashMetrics struct {
+	hashes []hashAction
+	next   otelconsumer.Metrics
+}
+
+func (h *hashMetrics) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+func (h *hashMetrics) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	rms := md.ResourceMetrics()
+	for i := 0; i < rms.Len(); i++ {
+		sms := rms.At(i).ScopeMetrics()
+		for j := 0; j < sms.Len(); j++ {
+			metrics := sms.At(j).Metrics()
+			for k := 0; k < metrics.Len(); k++ {
+				h.hashMetric(metrics.At(k))
+			}
+		}
+	}
+	return h.next.ConsumeMetrics(ctx, md)
+}
+
+func (h *hashMetrics) hashMetric(m pmetric.Metric) {
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			applyHashes(h.hashes, dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			applyHashes(h.hashes, dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			applyHashes(h.hashes, dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			applyHashes(h.hashes, dps.At(i).Attributes())
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			applyHashes(h.hashes, dps.At(i).Attributes())
+		}
+	}
+}
+
+type hashLogs struct {
+	hashes []hashAction
+	next   otelconsumer.Logs
+}
+
+func (h *hashLogs) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+func (h *hashLogs) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	rls := ld.ResourceLogs()
+	for i := 0; i < rls.Len(); i++ {
+		sls := rls.At(i).ScopeLogs()
+		for j := 0; j < sls.Len(); j++ {
+			logs := sls.At(j).LogRecords()
+			for k := 0; k < logs.Len(); k++ {
+				applyHashes(h.hashes, logs.At(k).Attributes())
+			}
+		}
+	}
+	return h.next.ConsumeLogs(ctx, ld)
+}
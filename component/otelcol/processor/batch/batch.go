@@ -3,8 +3,10 @@ package batch
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/processor"
@@ -20,7 +22,7 @@ func init() {
 		Exports: otelcol.ConsumerExports{},
 
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			fact := batchprocessor.NewFactory()
+			fact := newFactory(batchprocessor.NewFactory())
 			return processor.New(opts, fact, args.(Arguments))
 		},
 	})
@@ -32,6 +34,11 @@ type Arguments struct {
 	SendBatchSize    uint32        `river:"send_batch_size,attr,optional"`
 	SendBatchMaxSize uint32        `river:"send_batch_max_size,attr,optional"`
 
+	SendBatchMaxBytes units.Base2Bytes `river:"send_batch_max_bytes,attr,optional"`
+
+	MetadataKeys             []string `river:"metadata_keys,attr,optional"`
+	MetadataCardinalityLimit uint32   `river:"metadata_cardinality_limit,attr,optional"`
+
 	// Output configures where to send processed data. Required.
 	Output *otelcol.ConsumerArguments `river:"output,block"`
 }
@@ -44,6 +51,8 @@ var (
 var DefaultArguments = Arguments{
 	Timeout:       200 * time.Millisecond,
 	SendBatchSize: 8192,
+
+	MetadataCardinalityLimit: 1000,
 }
 
 // SetToDefault implements river.Defaulter.
@@ -56,16 +65,37 @@ func (args *Arguments) Validate() error {
 	if args.SendBatchMaxSize > 0 && args.SendBatchMaxSize < args.SendBatchSize {
 		return fmt.Errorf("send_batch_max_size must be greater or equal to send_batch_size when not 0")
 	}
+	if args.SendBatchMaxBytes < 0 {
+		return fmt.Errorf("send_batch_max_bytes must not be negative")
+	}
+
+	seen := make(map[string]struct{}, len(args.MetadataKeys))
+	for _, key := range args.MetadataKeys {
+		// Metadata keys are case-insensitive.
+		lower := strings.ToLower(key)
+		if _, ok := seen[lower]; ok {
+			return fmt.Errorf("duplicate entry in metadata_keys: %q (case-insensitive)", key)
+		}
+		seen[lower] = struct{}{}
+	}
+	if len(args.MetadataKeys) > 0 && args.MetadataCardinalityLimit == 0 {
+		return fmt.Errorf("metadata_cardinality_limit must be greater than 0 when metadata_keys is set")
+	}
 	return nil
 }
 
 // Convert implements processor.Arguments.
 func (args Arguments) Convert() (otelconfig.Processor, error) {
-	return &batchprocessor.Config{
-		ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID("batch")),
-		Timeout:           args.Timeout,
-		SendBatchSize:     args.SendBatchSize,
-		SendBatchMaxSize:  args.SendBatchMaxSize,
+	return &config{
+		Config: batchprocessor.Config{
+			ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID("batch")),
+			Timeout:           args.Timeout,
+			SendBatchSize:     args.SendBatchSize,
+			SendBatchMaxSize:  args.SendBatchMaxSize,
+		},
+		MaxBytes:                 int(args.SendBatchMaxBytes),
+		MetadataKeys:             args.MetadataKeys,
+		MetadataCardinalityLimit: int(args.MetadataCardinalityLimit),
 	}, nil
 }
 
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/agent/pkg/util"
 	"github.com/grafana/dskit/backoff"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/client"
 	"go.opentelemetry.io/collector/pdata/ptrace"
 )
 
@@ -77,6 +78,91 @@ func Test(t *testing.T) {
 	}
 }
 
+// TestMetadataKeys ensures that spans sent by different clients are batched
+// separately when metadata_keys is set, and that exported batches carry the
+// metadata of their clients.
+func TestMetadataKeys(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.processor.batch")
+	require.NoError(t, err)
+
+	cfg := `
+		timeout       = "10ms"
+		metadata_keys = ["X-Scope-OrgID"]
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`
+	var args batch.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	type exportedBatch struct {
+		tenant []string
+		spans  int
+	}
+	batchCh := make(chan exportedBatch, 2)
+	args.Output = &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeTracesFunc: func(ctx context.Context, t ptrace.Traces) error {
+				batchCh <- exportedBatch{
+					tenant: client.FromContext(ctx).Metadata.Get("X-Scope-OrgID"),
+					spans:  t.SpanCount(),
+				}
+				return nil
+			},
+		}},
+	}
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+
+	exports := ctrl.Exports().(otelcol.ConsumerExports)
+	tenantContext := func(tenant string) context.Context {
+		return client.NewContext(ctx, client.Info{
+			Metadata: client.NewMetadata(map[string][]string{"X-Scope-OrgID": {tenant}}),
+		})
+	}
+	require.NoError(t, exports.Input.ConsumeTraces(tenantContext("a"), createTestTraces()))
+	require.NoError(t, exports.Input.ConsumeTraces(tenantContext("b"), createTestTraces()))
+	require.NoError(t, exports.Input.ConsumeTraces(tenantContext("a"), createTestTraces()))
+
+	got := map[string]int{}
+	for len(got) < 2 {
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for traces")
+		case b := <-batchCh:
+			require.Len(t, b.tenant, 1)
+			got[b.tenant[0]] += b.spans
+		}
+	}
+	require.Equal(t, map[string]int{"a": 2, "b": 1}, got)
+}
+
+func TestArguments_Validate(t *testing.T) {
+	var args batch.Arguments
+	err := river.Unmarshal([]byte(`
+		metadata_keys = ["X-Scope-OrgID", "x-scope-orgid"]
+		output {}
+	`), &args)
+	require.EqualError(t, err, `duplicate entry in metadata_keys: "x-scope-orgid" (case-insensitive)`)
+
+	err = river.Unmarshal([]byte(`
+		metadata_keys              = ["X-Scope-OrgID"]
+		metadata_cardinality_limit = 0
+		output {}
+	`), &args)
+	require.EqualError(t, err, "metadata_cardinality_limit must be greater than 0 when metadata_keys is set")
+}
+
 // makeTracesOutput returns ConsumerArguments which will forward traces to the
 // provided channel.
 func makeTracesOutput(ch chan ptrace.Traces) *otelcol.ConsumerArguments {
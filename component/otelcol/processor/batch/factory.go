@@ -0,0 +1,89 @@
+package batch
+
+import (
+	"context"
+
+	otelclient "go.opentelemetry.io/collector/client"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor/batchprocessor"
+)
+
+// config extends the configuration of the upstream batch processor with the
+// settings implemented by this package.
+type config struct {
+	batchprocessor.Config
+
+	// MaxBytes is the maximum size of exported batches in bytes once encoded
+	// as protobuf, or 0 for no maximum.
+	MaxBytes int
+	// MetadataKeys are the client metadata keys whose values partition
+	// batches.
+	MetadataKeys []string
+	// MetadataCardinalityLimit is the maximum number of partitions when
+	// MetadataKeys is set.
+	MetadataCardinalityLimit int
+}
+
+// newFactory returns a processor factory which wraps the processors created
+// by the upstream batch processor factory fact. Processors created by the
+// factory use a batch processor per combination of metadata values when
+// metadata keys are configured, and split exported batches which are larger
+// than the configured maximum number of bytes.
+func newFactory(fact otelcomponent.ProcessorFactory) otelcomponent.ProcessorFactory {
+	return otelcomponent.NewProcessorFactory(
+		fact.Type(),
+		func() otelconfig.Processor {
+			return &config{Config: *fact.CreateDefaultConfig().(*batchprocessor.Config)}
+		},
+		otelcomponent.WithTracesProcessor(func(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Traces) (otelcomponent.TracesProcessor, error) {
+			c := cfg.(*config)
+			create := func(next otelconsumer.Traces) (otelcomponent.TracesProcessor, error) {
+				return fact.CreateTracesProcessor(ctx, set, &c.Config, next)
+			}
+
+			next = splitTracesConsumer(next, c.MaxBytes)
+			if len(c.MetadataKeys) == 0 {
+				return create(next)
+			}
+			return &tracesPartitioner{
+				partitioner: newPartitioner(c, func(info otelclient.Info) (otelcomponent.TracesProcessor, error) {
+					return create(&metadataTraces{next: next, info: info})
+				}),
+			}, nil
+		}, fact.TracesProcessorStability()),
+		otelcomponent.WithMetricsProcessor(func(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Metrics) (otelcomponent.MetricsProcessor, error) {
+			c := cfg.(*config)
+			create := func(next otelconsumer.Metrics) (otelcomponent.MetricsProcessor, error) {
+				return fact.CreateMetricsProcessor(ctx, set, &c.Config, next)
+			}
+
+			next = splitMetricsConsumer(next, c.MaxBytes)
+			if len(c.MetadataKeys) == 0 {
+				return create(next)
+			}
+			return &metricsPartitioner{
+				partitioner: newPartitioner(c, func(info otelclient.Info) (otelcomponent.MetricsProcessor, error) {
+					return create(&metadataMetrics{next: next, info: info})
+				}),
+			}, nil
+		}, fact.MetricsProcessorStability()),
+		otelcomponent.WithLogsProcessor(func(ctx context.Context, set otelcomponent.ProcessorCreateSettings, cfg otelconfig.Processor, next otelconsumer.Logs) (otelcomponent.LogsProcessor, error) {
+			c := cfg.(*config)
+			create := func(next otelconsumer.Logs) (otelcomponent.LogsProcessor, error) {
+				return fact.CreateLogsProcessor(ctx, set, &c.Config, next)
+			}
+
+			next = splitLogsConsumer(next, c.MaxBytes)
+			if len(c.MetadataKeys) == 0 {
+				return create(next)
+			}
+			return &logsPartitioner{
+				partitioner: newPartitioner(c, func(info otelclient.Info) (otelcomponent.LogsProcessor, error) {
+					return create(&metadataLogs{next: next, info: info})
+				}),
+			}, nil
+		}, fact.LogsProcessorStability()),
+	)
+}
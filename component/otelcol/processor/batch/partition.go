@@ -0,0 +1,227 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	otelclient "go.opentelemetry.io/collector/client"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/multierr"
+)
+
+var errShutdown = errors.New("batch processor is shut down")
+
+// partitioner creates a batch processor per combination of the values of the
+// metadata keys of the clients sending data, so that data from different
+// clients, such as from different tenants, are never batched together.
+// Partitions are created when data is first received for them, and live
+// until the partitioner is shut down.
+type partitioner[T otelcomponent.Component] struct {
+	keys   []string
+	limit  int
+	create func(otelclient.Info) (T, error)
+
+	mut        sync.Mutex
+	host       otelcomponent.Host // Set once the partitioner is started.
+	shutdown   bool
+	partitions map[string]T
+}
+
+func newPartitioner[T otelcomponent.Component](cfg *config, create func(otelclient.Info) (T, error)) *partitioner[T] {
+	return &partitioner[T]{
+		keys:   cfg.MetadataKeys,
+		limit:  cfg.MetadataCardinalityLimit,
+		create: create,
+
+		partitions: make(map[string]T),
+	}
+}
+
+// Start implements otelcomponent.Component, starting the partitions which
+// were created before the partitioner was started.
+func (p *partitioner[T]) Start(ctx context.Context, host otelcomponent.Host) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.host = host
+	for _, bp := range p.partitions {
+		if err := bp.Start(ctx, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown implements otelcomponent.Component, shutting down every
+// partition.
+func (p *partitioner[T]) Shutdown(ctx context.Context) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.shutdown = true
+
+	var err error
+	for _, bp := range p.partitions {
+		err = multierr.Append(err, bp.Shutdown(ctx))
+	}
+	p.partitions = nil
+	return err
+}
+
+// get returns the partition for the client of ctx, creating it if it
+// doesn't exist yet.
+func (p *partitioner[T]) get(ctx context.Context) (T, error) {
+	var (
+		info   = otelclient.FromContext(ctx)
+		values = make([][]string, len(p.keys))
+		md     = make(map[string][]string, len(p.keys))
+	)
+	for i, key := range p.keys {
+		values[i] = info.Metadata.Get(key)
+		if len(values[i]) > 0 {
+			md[key] = values[i]
+		}
+	}
+	// Encoding the values as JSON keeps the partition keys unambiguous when
+	// values contain separators.
+	bb, err := json.Marshal(values)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	partitionKey := string(bb)
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if p.shutdown {
+		var zero T
+		return zero, errShutdown
+	}
+	if bp, ok := p.partitions[partitionKey]; ok {
+		return bp, nil
+	}
+
+	if len(p.partitions) >= p.limit {
+		var zero T
+		return zero, fmt.Errorf("too many batch partitions for metadata_keys, metadata_cardinality_limit is %d", p.limit)
+	}
+	bp, err := p.create(otelclient.Info{Metadata: otelclient.NewMetadata(md)})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if p.host != nil {
+		if err := bp.Start(context.Background(), p.host); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	p.partitions[partitionKey] = bp
+	return bp, nil
+}
+
+type tracesPartitioner struct {
+	*partitioner[otelcomponent.TracesProcessor]
+}
+
+var _ otelcomponent.TracesProcessor = (*tracesPartitioner)(nil)
+
+// Capabilities implements otelconsumer.Traces.
+func (p *tracesPartitioner) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+// ConsumeTraces implements otelconsumer.Traces.
+func (p *tracesPartitioner) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	bp, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	return bp.ConsumeTraces(ctx, td)
+}
+
+type metricsPartitioner struct {
+	*partitioner[otelcomponent.MetricsProcessor]
+}
+
+var _ otelcomponent.MetricsProcessor = (*metricsPartitioner)(nil)
+
+// Capabilities implements otelconsumer.Metrics.
+func (p *metricsPartitioner) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+// ConsumeMetrics implements otelconsumer.Metrics.
+func (p *metricsPartitioner) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	bp, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	return bp.ConsumeMetrics(ctx, md)
+}
+
+type logsPartitioner struct {
+	*partitioner[otelcomponent.LogsProcessor]
+}
+
+var _ otelcomponent.LogsProcessor = (*logsPartitioner)(nil)
+
+// Capabilities implements otelconsumer.Logs.
+func (p *logsPartitioner) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+// ConsumeLogs implements otelconsumer.Logs.
+func (p *logsPartitioner) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	bp, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	return bp.ConsumeLogs(ctx, ld)
+}
+
+// The upstream batch processor exports batches with a new context. The
+// metadata consumers add the client metadata of their partition back to the
+// context of exported batches, so that exporters can use it, such as to set
+// the tenant of requests.
+
+type metadataTraces struct {
+	next otelconsumer.Traces
+	info otelclient.Info
+}
+
+func (c *metadataTraces) Capabilities() otelconsumer.Capabilities { return c.next.Capabilities() }
+
+func (c *metadataTraces) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	return c.next.ConsumeTraces(otelclient.NewContext(ctx, c.info), td)
+}
+
+type metadataMetrics struct {
+	next otelconsumer.Metrics
+	info otelclient.Info
+}
+
+func (c *metadataMetrics) Capabilities() otelconsumer.Capabilities { return c.next.Capabilities() }
+
+func (c *metadataMetrics) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	return c.next.ConsumeMetrics(otelclient.NewContext(ctx, c.info), md)
+}
+
+type metadataLogs struct {
+	next otelconsumer.Logs
+	info otelclient.Info
+}
+
+func (c *metadataLogs) Capabilities() otelconsumer.Capabilities { return c.next.Capabilities() }
+
+func (c *metadataLogs) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	return c.next.ConsumeLogs(otelclient.NewContext(ctx, c.info), ld)
+}
@@ -0,0 +1,198 @@
+package batch
+
+import (
+	"context"
+
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/multierr"
+)
+
+// The split consumers split the batches exported by the batch processor
+// until they're at most the configured number of bytes once encoded as
+// protobuf. Batches are split in halves, by spans, metrics, and log records
+// respectively; a single span, metric, or log record larger than the maximum
+// is sent on its own.
+
+func splitTracesConsumer(next otelconsumer.Traces, maxBytes int) otelconsumer.Traces {
+	if maxBytes <= 0 {
+		return next
+	}
+	return &splitTraces{next: next, maxBytes: maxBytes}
+}
+
+type splitTraces struct {
+	next     otelconsumer.Traces
+	maxBytes int
+	sizer    ptrace.ProtoMarshaler
+}
+
+func (c *splitTraces) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+func (c *splitTraces) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	n := td.SpanCount()
+	if n <= 1 || c.sizer.TracesSize(td) <= c.maxBytes {
+		return c.next.ConsumeTraces(ctx, td)
+	}
+	head := splitTracesHead(n/2, td)
+	return multierr.Append(c.ConsumeTraces(ctx, head), c.ConsumeTraces(ctx, td))
+}
+
+// splitTracesHead moves the first n spans of src to a new ptrace.Traces.
+func splitTracesHead(n int, src ptrace.Traces) ptrace.Traces {
+	dest := ptrace.NewTraces()
+	src.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		if n == 0 {
+			return false
+		}
+		destRs := dest.ResourceSpans().AppendEmpty()
+		rs.Resource().CopyTo(destRs.Resource())
+		destRs.SetSchemaUrl(rs.SchemaUrl())
+
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			if n == 0 {
+				return false
+			}
+			destSs := destRs.ScopeSpans().AppendEmpty()
+			ss.Scope().CopyTo(destSs.Scope())
+			destSs.SetSchemaUrl(ss.SchemaUrl())
+
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				if n == 0 {
+					return false
+				}
+				n--
+				span.MoveTo(destSs.Spans().AppendEmpty())
+				return true
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+	return dest
+}
+
+func splitMetricsConsumer(next otelconsumer.Metrics, maxBytes int) otelconsumer.Metrics {
+	if maxBytes <= 0 {
+		return next
+	}
+	return &splitMetrics{next: next, maxBytes: maxBytes}
+}
+
+type splitMetrics struct {
+	next     otelconsumer.Metrics
+	maxBytes int
+	sizer    pmetric.ProtoMarshaler
+}
+
+func (c *splitMetrics) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+func (c *splitMetrics) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	n := md.MetricCount()
+	if n <= 1 || c.sizer.MetricsSize(md) <= c.maxBytes {
+		return c.next.ConsumeMetrics(ctx, md)
+	}
+	head := splitMetricsHead(n/2, md)
+	return multierr.Append(c.ConsumeMetrics(ctx, head), c.ConsumeMetrics(ctx, md))
+}
+
+// splitMetricsHead moves the first n metrics of src to a new
+// pmetric.Metrics.
+func splitMetricsHead(n int, src pmetric.Metrics) pmetric.Metrics {
+	dest := pmetric.NewMetrics()
+	src.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		if n == 0 {
+			return false
+		}
+		destRm := dest.ResourceMetrics().AppendEmpty()
+		rm.Resource().CopyTo(destRm.Resource())
+		destRm.SetSchemaUrl(rm.SchemaUrl())
+
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			if n == 0 {
+				return false
+			}
+			destSm := destRm.ScopeMetrics().AppendEmpty()
+			sm.Scope().CopyTo(destSm.Scope())
+			destSm.SetSchemaUrl(sm.SchemaUrl())
+
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				if n == 0 {
+					return false
+				}
+				n--
+				m.MoveTo(destSm.Metrics().AppendEmpty())
+				return true
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+	return dest
+}
+
+func splitLogsConsumer(next otelconsumer.Logs, maxBytes int) otelconsumer.Logs {
+	if maxBytes <= 0 {
+		return next
+	}
+	return &splitLogs{next: next, maxBytes: maxBytes}
+}
+
+type splitLogs struct {
+	next     otelconsumer.Logs
+	maxBytes int
+	sizer    plog.ProtoMarshaler
+}
+
+func (c *splitLogs) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: true}
+}
+
+func (c *splitLogs) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	n := ld.LogRecordCount()
+	if n <= 1 || c.sizer.LogsSize(ld) <= c.maxBytes {
+		return c.next.ConsumeLogs(ctx, ld)
+	}
+	head := splitLogsHead(n/2, ld)
+	return multierr.Append(c.ConsumeLogs(ctx, head), c.ConsumeLogs(ctx, ld))
+}
+
+// splitLogsHead moves the first n log records of src to a new plog.Logs.
+func splitLogsHead(n int, src plog.Logs) plog.Logs {
+	dest := plog.NewLogs()
+	src.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		if n == 0 {
+			return false
+		}
+		destRl := dest.ResourceLogs().AppendEmpty()
+		rl.Resource().CopyTo(destRl.Resource())
+		destRl.SetSchemaUrl(rl.SchemaUrl())
+
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			if n == 0 {
+				return false
+			}
+			destSl := destRl.ScopeLogs().AppendEmpty()
+			sl.Scope().CopyTo(destSl.Scope())
+			destSl.SetSchemaUrl(sl.SchemaUrl())
+
+			sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+				if n == 0 {
+					return false
+				}
+				n--
+				lr.MoveTo(destSl.LogRecords().AppendEmpty())
+				return true
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+	return dest
+}
@@ -0,0 +1,65 @@
+package batch
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestSplitTraces(t *testing.T) {
+	td := ptrace.NewTraces()
+	for i := 0; i < 2; i++ {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutInt("resource", int64(i))
+		spans := rs.ScopeSpans().AppendEmpty().Spans()
+		for j := 0; j < 4; j++ {
+			spans.AppendEmpty().SetName(strings.Repeat("x", 100))
+		}
+	}
+	var sizer ptrace.ProtoMarshaler
+	maxBytes := sizer.TracesSize(td) / 3
+
+	var got []ptrace.Traces
+	next := &fakeconsumer.Consumer{
+		ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+			got = append(got, td)
+			return nil
+		},
+	}
+	require.NoError(t, splitTracesConsumer(next, maxBytes).ConsumeTraces(context.Background(), td))
+
+	// Every span is sent once, in batches under the maximum size, which keep
+	// the resource of their spans.
+	var spans int
+	for _, td := range got {
+		require.LessOrEqual(t, sizer.TracesSize(td), maxBytes)
+		spans += td.SpanCount()
+	}
+	require.Equal(t, 8, spans)
+	require.Len(t, got, 4)
+	first, _ := got[0].ResourceSpans().At(0).Resource().Attributes().Get("resource")
+	last, _ := got[3].ResourceSpans().At(0).Resource().Attributes().Get("resource")
+	require.Equal(t, int64(0), first.Int())
+	require.Equal(t, int64(1), last.Int())
+}
+
+func TestSplitLogs_Oversized(t *testing.T) {
+	ld := plog.NewLogs()
+	ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr(strings.Repeat("x", 100))
+
+	// A single log record larger than the maximum is sent on its own.
+	var got int
+	next := &fakeconsumer.Consumer{
+		ConsumeLogsFunc: func(_ context.Context, ld plog.Logs) error {
+			got += ld.LogRecordCount()
+			return nil
+		},
+	}
+	require.NoError(t, splitLogsConsumer(next, 10).ConsumeLogs(context.Background(), ld))
+	require.Equal(t, 1, got)
+}
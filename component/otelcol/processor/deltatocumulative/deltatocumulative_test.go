@@ -0,0 +1,264 @@
+package deltatocumulative_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/processor/deltatocumulative"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expected    deltatocumulative.Arguments
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+			cfg: `
+				output {}
+			`,
+			expected: deltatocumulative.Arguments{MaxStale: 5 * time.Minute},
+		},
+		{
+			name: "custom",
+			cfg: `
+				max_stale   = "1m"
+				max_streams = 1000
+				output {}
+			`,
+			expected: deltatocumulative.Arguments{MaxStale: time.Minute, MaxStreams: 1000},
+		},
+		{
+			name: "zero max_stale",
+			cfg: `
+				max_stale = "0s"
+				output {}
+			`,
+			expectedErr: "max_stale must be greater than zero",
+		},
+		{
+			name: "negative max_streams",
+			cfg: `
+				max_streams = -1
+				output {}
+			`,
+			expectedErr: "max_streams must not be negative",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args deltatocumulative.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected.MaxStale, args.MaxStale)
+			require.Equal(t, tc.expected.MaxStreams, args.MaxStreams)
+		})
+	}
+}
+
+func TestProcessor_Sum(t *testing.T) {
+	ch, input := runProcessor(t, `output {}`)
+
+	// The third batch is older than the second one and is dropped.
+	for i, v := range []int64{1, 2, 10, 3} {
+		ts := []int{1, 2, 1, 3}[i]
+		md := pmetric.NewMetrics()
+		sum := newMetric(md, "requests").SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		sum.SetIsMonotonic(true)
+		dp := sum.DataPoints().AppendEmpty()
+		dp.Attributes().PutStr("path", "/")
+		dp.SetStartTimestamp(timestamp(ts - 1))
+		dp.SetTimestamp(timestamp(ts))
+		dp.SetIntValue(v)
+		require.NoError(t, input.ConsumeMetrics(context.Background(), md))
+	}
+
+	var values []int64
+	for _, md := range receive(t, ch, 3) {
+		sum := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum()
+		require.Equal(t, pmetric.AggregationTemporalityCumulative, sum.AggregationTemporality())
+		dp := sum.DataPoints().At(0)
+		require.Equal(t, timestamp(0), dp.StartTimestamp())
+		values = append(values, dp.IntValue())
+	}
+	require.Equal(t, []int64{1, 3, 6}, values)
+}
+
+func TestProcessor_Histogram(t *testing.T) {
+	ch, input := runProcessor(t, `output {}`)
+
+	points := []struct {
+		bounds  []float64
+		buckets []uint64
+		sum     float64
+		min     float64
+		max     float64
+	}{
+		{bounds: []float64{1, 5}, buckets: []uint64{1, 1, 0}, sum: 3, min: 0.5, max: 2.5},
+		{bounds: []float64{1, 5}, buckets: []uint64{0, 1, 2}, sum: 17, min: 2, max: 8},
+		// The bounds changed, so the stream starts over.
+		{bounds: []float64{10}, buckets: []uint64{2, 0}, sum: 4, min: 1, max: 3},
+	}
+	for i, p := range points {
+		md := pmetric.NewMetrics()
+		hist := newMetric(md, "latency").SetEmptyHistogram()
+		hist.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		dp := hist.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(timestamp(i))
+		dp.SetTimestamp(timestamp(i + 1))
+		dp.ExplicitBounds().FromRaw(p.bounds)
+		dp.BucketCounts().FromRaw(p.buckets)
+		var count uint64
+		for _, b := range p.buckets {
+			count += b
+		}
+		dp.SetCount(count)
+		dp.SetSum(p.sum)
+		dp.SetMin(p.min)
+		dp.SetMax(p.max)
+		require.NoError(t, input.ConsumeMetrics(context.Background(), md))
+	}
+
+	mds := receive(t, ch, 3)
+	dps := make([]pmetric.HistogramDataPoint, 0, len(mds))
+	for _, md := range mds {
+		hist := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Histogram()
+		require.Equal(t, pmetric.AggregationTemporalityCumulative, hist.AggregationTemporality())
+		dps = append(dps, hist.DataPoints().At(0))
+	}
+
+	require.Equal(t, timestamp(0), dps[1].StartTimestamp())
+	require.Equal(t, uint64(5), dps[1].Count())
+	require.Equal(t, 20.0, dps[1].Sum())
+	require.Equal(t, []uint64{1, 2, 2}, dps[1].BucketCounts().AsRaw())
+	require.Equal(t, 0.5, dps[1].Min())
+	require.Equal(t, 8.0, dps[1].Max())
+
+	require.Equal(t, timestamp(2), dps[2].StartTimestamp())
+	require.Equal(t, uint64(2), dps[2].Count())
+	require.Equal(t, []uint64{2, 0}, dps[2].BucketCounts().AsRaw())
+}
+
+func TestProcessor_Cumulative(t *testing.T) {
+	ch, input := runProcessor(t, `output {}`)
+
+	for i := 0; i < 2; i++ {
+		md := pmetric.NewMetrics()
+		sum := newMetric(md, "requests").SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+		dp := sum.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(timestamp(0))
+		dp.SetTimestamp(timestamp(i + 1))
+		dp.SetDoubleValue(5)
+		require.NoError(t, input.ConsumeMetrics(context.Background(), md))
+	}
+
+	for _, md := range receive(t, ch, 2) {
+		dp := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+		require.Equal(t, 5.0, dp.DoubleValue())
+	}
+}
+
+func TestProcessor_MaxStreams(t *testing.T) {
+	ch, input := runProcessor(t, `
+		max_streams = 1
+		output {}
+	`)
+
+	md := pmetric.NewMetrics()
+	sum := newMetric(md, "requests").SetEmptySum()
+	sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	for _, path := range []string{"/a", "/b"} {
+		dp := sum.DataPoints().AppendEmpty()
+		dp.Attributes().PutStr("path", path)
+		dp.SetTimestamp(timestamp(1))
+		dp.SetIntValue(1)
+	}
+	require.NoError(t, input.ConsumeMetrics(context.Background(), md))
+
+	dps := receive(t, ch, 1)[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints()
+	require.Equal(t, 1, dps.Len())
+	path, _ := dps.At(0).Attributes().Get("path")
+	require.Equal(t, "/a", path.Str())
+}
+
+func newMetric(md pmetric.Metrics, name string) pmetric.Metric {
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	m := rm.ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+	m.SetName(name)
+	return m
+}
+
+func timestamp(sec int) pcommon.Timestamp {
+	return pcommon.NewTimestampFromTime(time.Unix(1000+int64(sec), 0))
+}
+
+func receive(t *testing.T, ch chan pmetric.Metrics, n int) []pmetric.Metrics {
+	t.Helper()
+
+	res := make([]pmetric.Metrics, 0, n)
+	for len(res) < n {
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for metrics")
+		case md := <-ch:
+			res = append(res, md)
+		}
+	}
+
+	select {
+	case md := <-ch:
+		require.FailNow(t, "received unexpected metrics", "got %d data points", md.DataPointCount())
+	case <-time.After(100 * time.Millisecond):
+	}
+	return res
+}
+
+func runProcessor(t *testing.T, cfg string) (chan pmetric.Metrics, otelcol.Consumer) {
+	t.Helper()
+
+	ctx := componenttest.TestContext(t)
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "otelcol.processor.deltatocumulative")
+	require.NoError(t, err)
+
+	var args deltatocumulative.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override the arguments so metrics get forwarded to the test channel.
+	ch := make(chan pmetric.Metrics, 10)
+	args.Output = &otelcol.ConsumerArguments{
+		Metrics: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeMetricsFunc: func(_ context.Context, md pmetric.Metrics) error {
+				ch <- md
+				return nil
+			},
+		}},
+	}
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+	return ch, ctrl.Exports().(otelcol.ConsumerExports).Input
+}
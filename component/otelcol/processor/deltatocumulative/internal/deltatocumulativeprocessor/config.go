@@ -0,0 +1,35 @@
+package deltatocumulativeprocessor
+
+import (
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/collector/config"
+)
+
+// Config configures the delta to cumulative processor.
+type Config struct {
+	config.ProcessorSettings `mapstructure:",squash"`
+
+	// MaxStale is how long a stream is kept after its last data point. Streams
+	// which receive a data point after being stale start over from zero.
+	MaxStale time.Duration `mapstructure:"max_stale"`
+
+	// MaxStreams is the maximum number of streams being tracked at once. Data
+	// points of new streams are dropped while the limit is reached. 0 means
+	// no limit.
+	MaxStreams int `mapstructure:"max_streams"`
+}
+
+var _ config.Processor = (*Config)(nil)
+
+// Validate checks that the staleness and stream limits are valid.
+func (cfg *Config) Validate() error {
+	if cfg.MaxStale <= 0 {
+		return fmt.Errorf("max_stale must be greater than zero")
+	}
+	if cfg.MaxStreams < 0 {
+		return fmt.Errorf("max_streams must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Package deltatocumulativeprocessor implements a processor which converts
+// sums and histograms with the delta aggregation temporality to cumulative
+// ones, by accumulating the data points of each stream.
+package deltatocumulativeprocessor
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const (
+	// The value of processor "type" in configuration.
+	typeStr = "deltatocumulative"
+)
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory returns a new factory for the delta to cumulative processor.
+func NewFactory() component.ProcessorFactory {
+	return component.NewProcessorFactory(
+		typeStr,
+		createDefaultConfig,
+		component.WithMetricsProcessor(createMetricsProcessor, component.StabilityLevelAlpha),
+	)
+}
+
+func createDefaultConfig() config.Processor {
+	return &Config{
+		ProcessorSettings: config.NewProcessorSettings(config.NewComponentID(typeStr)),
+		MaxStale:          5 * time.Minute,
+	}
+}
+
+func createMetricsProcessor(ctx context.Context, set component.ProcessorCreateSettings, cfg config.Processor, next consumer.Metrics) (component.MetricsProcessor, error) {
+	p := newProcessor(cfg.(*Config), set.Logger)
+	return processorhelper.NewMetricsProcessor(ctx, set, cfg, next, p.processMetrics, processorhelper.WithCapabilities(processorCapabilities))
+}
@@ -0,0 +1,306 @@
+package deltatocumulativeprocessor
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.uber.org/zap"
+)
+
+// processor accumulates the data points of delta sums and histograms. Each
+// stream, identified by its resource, scope, metric, and data point
+// attributes, keeps the running total of its data points since the first one
+// it received.
+type processor struct {
+	log        *zap.Logger
+	maxStale   time.Duration
+	maxStreams int
+	now        func() time.Time
+
+	mut       sync.Mutex
+	streams   map[string]*stream
+	lastSweep time.Time
+	atLimit   bool
+}
+
+// stream holds the accumulated value of a stream. Only the fields for the
+// type of its metric are used.
+type stream struct {
+	start    pcommon.Timestamp
+	last     pcommon.Timestamp
+	lastSeen time.Time
+
+	// Sums.
+	intValue    int64
+	doubleValue float64
+
+	// Histograms.
+	count        uint64
+	sum          float64
+	min, max     float64
+	hasMin       bool
+	hasMax       bool
+	bounds       []float64
+	bucketCounts []uint64
+}
+
+func newProcessor(cfg *Config, log *zap.Logger) *processor {
+	return &processor{
+		log:        log,
+		maxStale:   cfg.MaxStale,
+		maxStreams: cfg.MaxStreams,
+		now:        time.Now,
+
+		streams:   make(map[string]*stream),
+		lastSweep: time.Now(),
+	}
+}
+
+func (p *processor) processMetrics(_ context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	now := p.now()
+	p.sweep(now)
+
+	md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		resourceKey := attributesKey(rm.Resource().Attributes())
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			scopeKey := resourceKey + "\xff" + sm.Scope().Name() + "\xff" + sm.Scope().Version()
+			sm.Metrics().RemoveIf(func(m pmetric.Metric) bool {
+				switch m.Type() {
+				case pmetric.MetricTypeSum:
+					sum := m.Sum()
+					if sum.AggregationTemporality() != pmetric.AggregationTemporalityDelta {
+						return false
+					}
+					metricKey := metricKey(scopeKey, m, sum.IsMonotonic())
+					sum.DataPoints().RemoveIf(func(dp pmetric.NumberDataPoint) bool {
+						return !p.accumulateNumber(metricKey, dp, now)
+					})
+					sum.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+					return sum.DataPoints().Len() == 0
+
+				case pmetric.MetricTypeHistogram:
+					hist := m.Histogram()
+					if hist.AggregationTemporality() != pmetric.AggregationTemporalityDelta {
+						return false
+					}
+					metricKey := metricKey(scopeKey, m, false)
+					hist.DataPoints().RemoveIf(func(dp pmetric.HistogramDataPoint) bool {
+						return !p.accumulateHistogram(metricKey, dp, now)
+					})
+					hist.SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+					return hist.DataPoints().Len() == 0
+				}
+				return false
+			})
+			return sm.Metrics().Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+
+	if md.ResourceMetrics().Len() == 0 {
+		return md, processorhelper.ErrSkipProcessingData
+	}
+	return md, nil
+}
+
+// accumulateNumber adds dp to its stream and replaces its value with the
+// total of the stream. It returns false if dp must be dropped.
+func (p *processor) accumulateNumber(metricKey string, dp pmetric.NumberDataPoint, now time.Time) bool {
+	key := metricKey + "\xff" + attributesKey(dp.Attributes())
+	s := p.lookup(key, dp.StartTimestamp(), dp.Timestamp(), now)
+	if s == nil {
+		return false
+	}
+
+	dp.SetStartTimestamp(s.start)
+	if dp.Flags().NoRecordedValue() {
+		// Staleness markers end the stream, so it starts over from zero if it
+		// reappears.
+		delete(p.streams, key)
+		return true
+	}
+
+	switch dp.ValueType() {
+	case pmetric.NumberDataPointValueTypeInt:
+		s.intValue += dp.IntValue()
+		dp.SetIntValue(s.intValue)
+	case pmetric.NumberDataPointValueTypeDouble:
+		s.doubleValue += dp.DoubleValue()
+		dp.SetDoubleValue(s.doubleValue)
+	}
+	s.last, s.lastSeen = dp.Timestamp(), now
+	return true
+}
+
+// accumulateHistogram adds dp to its stream and replaces its count, sum,
+// buckets, minimum, and maximum with the totals of the stream. It returns
+// false if dp must be dropped.
+func (p *processor) accumulateHistogram(metricKey string, dp pmetric.HistogramDataPoint, now time.Time) bool {
+	key := metricKey + "\xff" + attributesKey(dp.Attributes())
+	s := p.lookup(key, dp.StartTimestamp(), dp.Timestamp(), now)
+	if s == nil {
+		return false
+	}
+
+	if dp.Flags().NoRecordedValue() {
+		dp.SetStartTimestamp(s.start)
+		delete(p.streams, key)
+		return true
+	}
+
+	// Buckets can't be added up when the bounds of the histogram change, so
+	// the stream starts over from this data point.
+	bounds := dp.ExplicitBounds().AsRaw()
+	if s.bucketCounts != nil && (!equalBounds(s.bounds, bounds) || len(s.bucketCounts) != dp.BucketCounts().Len()) {
+		*s = stream{start: startTimestamp(dp.StartTimestamp(), dp.Timestamp())}
+	}
+	if s.bucketCounts == nil {
+		s.bounds = bounds
+		s.bucketCounts = make([]uint64, dp.BucketCounts().Len())
+	}
+
+	s.count += dp.Count()
+	s.sum += dp.Sum()
+	for i := range s.bucketCounts {
+		s.bucketCounts[i] += dp.BucketCounts().At(i)
+	}
+	if dp.HasMin() && (!s.hasMin || dp.Min() < s.min) {
+		s.min, s.hasMin = dp.Min(), true
+	}
+	if dp.HasMax() && (!s.hasMax || dp.Max() > s.max) {
+		s.max, s.hasMax = dp.Max(), true
+	}
+
+	dp.SetStartTimestamp(s.start)
+	dp.SetCount(s.count)
+	if dp.HasSum() {
+		dp.SetSum(s.sum)
+	}
+	dp.BucketCounts().FromRaw(s.bucketCounts)
+	if s.hasMin {
+		dp.SetMin(s.min)
+	}
+	if s.hasMax {
+		dp.SetMax(s.max)
+	}
+	s.last, s.lastSeen = dp.Timestamp(), now
+	return true
+}
+
+// lookup returns the stream for key, creating it if it doesn't exist or is
+// stale. It returns nil if the data point with the given timestamps must be
+// dropped, either because it's older than the last data point of the stream
+// or because the stream limit is reached.
+func (p *processor) lookup(key string, start, ts pcommon.Timestamp, now time.Time) *stream {
+	s, ok := p.streams[key]
+	if ok && now.Sub(s.lastSeen) > p.maxStale {
+		delete(p.streams, key)
+		ok = false
+	}
+
+	if ok {
+		if ts <= s.last {
+			return nil
+		}
+		return s
+	}
+
+	if p.maxStreams > 0 && len(p.streams) >= p.maxStreams {
+		if !p.atLimit {
+			p.log.Warn("dropping data points of new streams because the stream limit was reached", zap.Int("max_streams", p.maxStreams))
+			p.atLimit = true
+		}
+		return nil
+	}
+
+	s = &stream{start: startTimestamp(start, ts), lastSeen: now}
+	p.streams[key] = s
+	return s
+}
+
+// sweep removes stale streams, at most once per staleness period.
+func (p *processor) sweep(now time.Time) {
+	if now.Sub(p.lastSweep) < p.maxStale {
+		return
+	}
+	p.lastSweep = now
+
+	for key, s := range p.streams {
+		if now.Sub(s.lastSeen) > p.maxStale {
+			delete(p.streams, key)
+		}
+	}
+	if len(p.streams) < p.maxStreams {
+		p.atLimit = false
+	}
+}
+
+// startTimestamp returns the start of a new stream which first received a
+// data point with the given timestamps.
+func startTimestamp(start, ts pcommon.Timestamp) pcommon.Timestamp {
+	if start == 0 {
+		return ts
+	}
+	return start
+}
+
+// metricKey returns the key identifying the metric m within the scope
+// identified by scopeKey.
+func metricKey(scopeKey string, m pmetric.Metric, monotonic bool) string {
+	var sb strings.Builder
+	sb.WriteString(scopeKey)
+	sb.WriteByte(0xff)
+	sb.WriteString(m.Name())
+	sb.WriteByte(0xff)
+	sb.WriteString(m.Unit())
+	sb.WriteByte(0xff)
+	sb.WriteString(m.Type().String())
+	if monotonic {
+		sb.WriteString("/monotonic")
+	}
+	return sb.String()
+}
+
+// attributesKey returns a key identifying attrs, independent of the order of
+// the attributes.
+func attributesKey(attrs pcommon.Map) string {
+	keys := make([]string, 0, attrs.Len())
+	attrs.Range(func(k string, _ pcommon.Value) bool {
+		keys = append(keys, k)
+		return true
+	})
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		v, _ := attrs.Get(k)
+		sb.WriteString(k)
+		sb.WriteByte(0xfe)
+		sb.WriteString(v.Type().String())
+		sb.WriteByte(0xfe)
+		sb.WriteString(v.AsString())
+		sb.WriteByte(0xfd)
+	}
+	return sb.String()
+}
+
+func equalBounds(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,58 @@
+package deltatocumulativeprocessor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.uber.org/zap"
+)
+
+func TestProcessor_Staleness(t *testing.T) {
+	now := time.Unix(0, 0)
+	p := newProcessor(&Config{MaxStale: time.Minute}, zap.NewNop())
+	p.now = func() time.Time { return now }
+	p.lastSweep = now
+
+	consume := func(start, ts int, v float64, flags pmetric.DataPointFlags) pmetric.NumberDataPoint {
+		md := pmetric.NewMetrics()
+		m := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty()
+		m.SetName("requests")
+		sum := m.SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		dp := sum.DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(pcommon.Timestamp(start))
+		dp.SetTimestamp(pcommon.Timestamp(ts))
+		dp.SetDoubleValue(v)
+		dp.SetFlags(flags)
+
+		md, err := p.processMetrics(context.Background(), md)
+		require.NoError(t, err)
+		return md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Sum().DataPoints().At(0)
+	}
+
+	require.Equal(t, 1.0, consume(0, 1, 1, 0).DoubleValue())
+	require.Equal(t, 3.0, consume(1, 2, 2, 0).DoubleValue())
+
+	// Streams which didn't receive data points within max_stale start over.
+	now = now.Add(2 * time.Minute)
+	dp := consume(2, 3, 4, 0)
+	require.Equal(t, 4.0, dp.DoubleValue())
+	require.Equal(t, pcommon.Timestamp(2), dp.StartTimestamp())
+
+	// Staleness markers keep the start of the stream and end it.
+	dp = consume(3, 4, 0, pmetric.DefaultDataPointFlags.WithNoRecordedValue(true))
+	require.Equal(t, pcommon.Timestamp(2), dp.StartTimestamp())
+	require.Empty(t, p.streams)
+
+	// Stale streams are removed from memory.
+	consume(4, 5, 1, 0)
+	now = now.Add(2 * time.Minute)
+	_, err := p.processMetrics(context.Background(), pmetric.NewMetrics())
+	require.ErrorIs(t, err, processorhelper.ErrSkipProcessingData)
+	require.Empty(t, p.streams)
+}
@@ -0,0 +1,111 @@
+// Package filter provides an otelcol.processor.filter component.
+package filter
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/processor"
+	"github.com/grafana/agent/component/otelcol/processor/filter/internal/filterprocessor"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.processor.filter",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := filterprocessor.NewFactory()
+			return processor.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.processor.filter component.
+type Arguments struct {
+	// ErrorMode determines how errors evaluating conditions are handled.
+	ErrorMode string `river:"error_mode,attr,optional"`
+
+	Traces  TraceConfig  `river:"traces,block,optional"`
+	Metrics MetricConfig `river:"metrics,block,optional"`
+	Logs    LogConfig    `river:"logs,block,optional"`
+
+	// Output configures where to send processed data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// TraceConfig holds the OTTL conditions for dropping trace data.
+type TraceConfig struct {
+	Span      []string `river:"span,attr,optional"`
+	SpanEvent []string `river:"spanevent,attr,optional"`
+}
+
+// MetricConfig holds the OTTL conditions for dropping metric data.
+type MetricConfig struct {
+	Metric    []string `river:"metric,attr,optional"`
+	DataPoint []string `river:"datapoint,attr,optional"`
+}
+
+// LogConfig holds the OTTL conditions for dropping log data.
+type LogConfig struct {
+	LogRecord []string `river:"log_record,attr,optional"`
+}
+
+var (
+	_ processor.Arguments = Arguments{}
+)
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	ErrorMode: string(filterprocessor.ErrorModePropagate),
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	return args.convert().Validate()
+}
+
+// Convert implements processor.Arguments.
+func (args Arguments) Convert() (otelconfig.Processor, error) {
+	return args.convert(), nil
+}
+
+func (args Arguments) convert() *filterprocessor.Config {
+	return &filterprocessor.Config{
+		ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID("filter")),
+		ErrorMode:         filterprocessor.ErrorMode(args.ErrorMode),
+		Traces: filterprocessor.TraceConditions{
+			Span:      args.Traces.Span,
+			SpanEvent: args.Traces.SpanEvent,
+		},
+		Metrics: filterprocessor.MetricConditions{
+			Metric:    args.Metrics.Metric,
+			DataPoint: args.Metrics.DataPoint,
+		},
+		Logs: filterprocessor.LogConditions{
+			LogRecord: args.Logs.LogRecord,
+		},
+	}
+}
+
+// Extensions implements processor.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements processor.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements processor.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
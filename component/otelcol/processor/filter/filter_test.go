@@ -0,0 +1,385 @@
+package filter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/processor/filter"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "valid conditions",
+			cfg: `
+				error_mode = "ignore"
+				traces {
+					span      = ["attributes[\"http.target\"] == \"/health\""]
+					spanevent = ["name == \"cache.miss\" and span.name == \"checkout\""]
+				}
+				metrics {
+					metric    = ["name == \"unused\""]
+					datapoint = ["attributes[\"env\"] == \"dev\""]
+				}
+				logs {
+					log_record = ["severity_number < SEVERITY_NUMBER_INFO"]
+				}
+				output {}
+			`,
+		},
+		{
+			name: "invalid error mode",
+			cfg: `
+				error_mode = "panic"
+				output {}
+			`,
+			expectedErr: `unsupported error_mode "panic"`,
+		},
+		{
+			name: "invalid condition",
+			cfg: `
+				logs {
+					log_record = ["severity_number <"]
+				}
+				output {}
+			`,
+			expectedErr: "logs.log_record",
+		},
+		{
+			name: "unknown path",
+			cfg: `
+				traces {
+					span = ["does_not_exist == 1"]
+				}
+				output {}
+			`,
+			expectedErr: "traces.span",
+		},
+		{
+			name: "unknown span event path",
+			cfg: `
+				traces {
+					spanevent = ["span.kind == 1"]
+				}
+				output {}
+			`,
+			expectedErr: "traces.spanevent",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args filter.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestFilter_Traces(t *testing.T) {
+	cfg := `
+		traces {
+			span = ["attributes[\"http.target\"] == \"/health\""]
+		}
+		output {}
+	`
+	input := `{
+		"resourceSpans": [{
+			"scopeSpans": [{
+				"spans": [{
+					"name": "health",
+					"attributes": [{"key": "http.target", "value": {"stringValue": "/health"}}]
+				}, {
+					"name": "checkout",
+					"attributes": [{"key": "http.target", "value": {"stringValue": "/checkout"}}]
+				}]
+			}]
+		}, {
+			"scopeSpans": [{
+				"spans": [{
+					"name": "health",
+					"attributes": [{"key": "http.target", "value": {"stringValue": "/health"}}]
+				}]
+			}]
+		}]
+	}`
+	expected := `{
+		"resourceSpans": [{
+			"resource": {},
+			"scopeSpans": [{
+				"scope": {},
+				"spans": [{
+					"name": "checkout",
+					"attributes": [{"key": "http.target", "value": {"stringValue": "/checkout"}}],
+					"traceId": "",
+					"spanId": "",
+					"parentSpanId": "",
+					"status": {}
+				}]
+			}]
+		}]
+	}`
+
+	ch := make(chan ptrace.Traces, 1)
+	exports := runFilter(t, cfg, &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+				ch <- td
+				return nil
+			},
+		}},
+	})
+
+	td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, exports.Input.ConsumeTraces(context.Background(), td))
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for traces")
+	case td := <-ch:
+		actual, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+		require.NoError(t, err)
+		require.JSONEq(t, expected, string(actual))
+	}
+}
+
+func TestFilter_SpanEvents(t *testing.T) {
+	cfg := `
+		traces {
+			spanevent = ["name == \"cache.miss\" and span.attributes[\"env\"] == \"dev\""]
+		}
+		output {}
+	`
+	input := `{
+		"resourceSpans": [{
+			"scopeSpans": [{
+				"spans": [{
+					"name": "checkout",
+					"attributes": [{"key": "env", "value": {"stringValue": "dev"}}],
+					"events": [{"name": "cache.miss"}, {"name": "cache.hit"}]
+				}, {
+					"name": "checkout",
+					"attributes": [{"key": "env", "value": {"stringValue": "prod"}}],
+					"events": [{"name": "cache.miss"}]
+				}]
+			}]
+		}]
+	}`
+
+	ch := make(chan ptrace.Traces, 1)
+	exports := runFilter(t, cfg, &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+				ch <- td
+				return nil
+			},
+		}},
+	})
+
+	td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, exports.Input.ConsumeTraces(context.Background(), td))
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for traces")
+	case td := <-ch:
+		// Spans are kept even when their events are dropped.
+		spans := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+		require.Equal(t, 2, spans.Len())
+
+		dev := spans.At(0).Events()
+		require.Equal(t, 1, dev.Len())
+		require.Equal(t, "cache.hit", dev.At(0).Name())
+
+		prod := spans.At(1).Events()
+		require.Equal(t, 1, prod.Len())
+		require.Equal(t, "cache.miss", prod.At(0).Name())
+	}
+}
+
+func TestFilter_Metrics(t *testing.T) {
+	cfg := `
+		metrics {
+			metric    = ["name == \"dropped\""]
+			datapoint = ["attributes[\"env\"] == \"dev\""]
+		}
+		output {}
+	`
+	input := `{
+		"resourceMetrics": [{
+			"scopeMetrics": [{
+				"metrics": [{
+					"name": "dropped",
+					"gauge": {"dataPoints": [{"asInt": "1"}]}
+				}, {
+					"name": "kept",
+					"gauge": {"dataPoints": [{
+						"asInt": "1",
+						"attributes": [{"key": "env", "value": {"stringValue": "dev"}}]
+					}, {
+						"asInt": "2",
+						"attributes": [{"key": "env", "value": {"stringValue": "prod"}}]
+					}]}
+				}, {
+					"name": "only_dev",
+					"sum": {"dataPoints": [{
+						"asInt": "1",
+						"attributes": [{"key": "env", "value": {"stringValue": "dev"}}]
+					}]}
+				}]
+			}]
+		}]
+	}`
+	expected := `{
+		"resourceMetrics": [{
+			"resource": {},
+			"scopeMetrics": [{
+				"scope": {},
+				"metrics": [{
+					"name": "kept",
+					"gauge": {"dataPoints": [{
+						"asInt": "2",
+						"attributes": [{"key": "env", "value": {"stringValue": "prod"}}]
+					}]}
+				}]
+			}]
+		}]
+	}`
+
+	ch := make(chan pmetric.Metrics, 1)
+	exports := runFilter(t, cfg, &otelcol.ConsumerArguments{
+		Metrics: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeMetricsFunc: func(_ context.Context, md pmetric.Metrics) error {
+				ch <- md
+				return nil
+			},
+		}},
+	})
+
+	md, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, exports.Input.ConsumeMetrics(context.Background(), md))
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for metrics")
+	case md := <-ch:
+		actual, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+		require.NoError(t, err)
+		require.JSONEq(t, expected, string(actual))
+	}
+}
+
+func TestFilter_Logs(t *testing.T) {
+	cfg := `
+		logs {
+			log_record = [
+				"resource.attributes[\"k8s.namespace.name\"] == \"dev\" and severity_number < SEVERITY_NUMBER_INFO",
+			]
+		}
+		output {}
+	`
+	input := `{
+		"resourceLogs": [{
+			"resource": {"attributes": [{"key": "k8s.namespace.name", "value": {"stringValue": "dev"}}]},
+			"scopeLogs": [{
+				"logRecords": [
+					{"severityNumber": 5, "body": {"stringValue": "debug"}},
+					{"severityNumber": 9, "body": {"stringValue": "info"}}
+				]
+			}]
+		}, {
+			"resource": {"attributes": [{"key": "k8s.namespace.name", "value": {"stringValue": "prod"}}]},
+			"scopeLogs": [{
+				"logRecords": [
+					{"severityNumber": 5, "body": {"stringValue": "debug"}}
+				]
+			}]
+		}]
+	}`
+	expected := `{
+		"resourceLogs": [{
+			"resource": {"attributes": [{"key": "k8s.namespace.name", "value": {"stringValue": "dev"}}]},
+			"scopeLogs": [{
+				"scope": {},
+				"logRecords": [
+					{"severityNumber": 9, "body": {"stringValue": "info"}, "traceId": "", "spanId": ""}
+				]
+			}]
+		}, {
+			"resource": {"attributes": [{"key": "k8s.namespace.name", "value": {"stringValue": "prod"}}]},
+			"scopeLogs": [{
+				"scope": {},
+				"logRecords": [
+					{"severityNumber": 5, "body": {"stringValue": "debug"}, "traceId": "", "spanId": ""}
+				]
+			}]
+		}]
+	}`
+
+	ch := make(chan plog.Logs, 1)
+	exports := runFilter(t, cfg, &otelcol.ConsumerArguments{
+		Logs: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeLogsFunc: func(_ context.Context, ld plog.Logs) error {
+				ch <- ld
+				return nil
+			},
+		}},
+	})
+
+	ld, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, exports.Input.ConsumeLogs(context.Background(), ld))
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for logs")
+	case ld := <-ch:
+		actual, err := (&plog.JSONMarshaler{}).MarshalLogs(ld)
+		require.NoError(t, err)
+		require.JSONEq(t, expected, string(actual))
+	}
+}
+
+func runFilter(t *testing.T, cfg string, output *otelcol.ConsumerArguments) otelcol.ConsumerExports {
+	t.Helper()
+
+	ctx := componenttest.TestContext(t)
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "otelcol.processor.filter")
+	require.NoError(t, err)
+
+	var args filter.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override the arguments so signals get forwarded to the test channel.
+	args.Output = output
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+	return ctrl.Exports().(otelcol.ConsumerExports)
+}
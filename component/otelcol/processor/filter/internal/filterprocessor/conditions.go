@@ -0,0 +1,95 @@
+package filterprocessor
+
+import (
+	"github.com/grafana/agent/component/otelcol/internal/ottlspanevent"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoints"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllogs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlmetric"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottltraces"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"go.opentelemetry.io/collector/component"
+)
+
+// conditions is a set of OTTL conditions evaluated against a context of type
+// K.
+//
+// OTTL only parses statements, so each condition is parsed as the where
+// clause of a statement invoking a function which does nothing.
+type conditions[K any] struct {
+	statements []*ottl.Statement[K]
+}
+
+func parseConditions[K any](parser ottl.Parser[K], conds []string) (*conditions[K], error) {
+	statements := make([]string, 0, len(conds))
+	for _, cond := range conds {
+		statements = append(statements, matchFunctionName+"() where "+cond)
+	}
+
+	parsed, err := parser.ParseStatements(statements)
+	if err != nil {
+		return nil, err
+	}
+	return &conditions[K]{statements: parsed}, nil
+}
+
+// empty returns true if there are no conditions to evaluate.
+func (c *conditions[K]) empty() bool {
+	return c == nil || len(c.statements) == 0
+}
+
+// match returns true if any of the conditions is true for ctx.
+func (c *conditions[K]) match(ctx K) (bool, error) {
+	for _, s := range c.statements {
+		_, matched, err := s.Execute(ctx)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchFunctionName is the name of the function invoked by the statements
+// wrapping conditions.
+const matchFunctionName = "match"
+
+func match[K any]() (ottl.ExprFunc[K], error) {
+	return func(K) (interface{}, error) { return true, nil }, nil
+}
+
+// functions returns the functions which can be used in conditions.
+func functions[K any]() map[string]interface{} {
+	return map[string]interface{}{
+		matchFunctionName: match[K],
+
+		"Concat":  ottlfuncs.Concat[K],
+		"Int":     ottlfuncs.Int[K],
+		"IsMatch": ottlfuncs.IsMatch[K],
+		"SpanID":  ottlfuncs.SpanID[K],
+		"Split":   ottlfuncs.Split[K],
+		"TraceID": ottlfuncs.TraceID[K],
+	}
+}
+
+func newSpanConditions(conds []string, set component.TelemetrySettings) (*conditions[ottltraces.TransformContext], error) {
+	return parseConditions(ottltraces.NewParser(functions[ottltraces.TransformContext](), set), conds)
+}
+
+func newSpanEventConditions(conds []string, set component.TelemetrySettings) (*conditions[ottlspanevent.TransformContext], error) {
+	return parseConditions(ottlspanevent.NewParser(functions[ottlspanevent.TransformContext](), set), conds)
+}
+
+func newMetricConditions(conds []string, set component.TelemetrySettings) (*conditions[ottlmetric.TransformContext], error) {
+	return parseConditions(ottlmetric.NewParser(functions[ottlmetric.TransformContext](), set), conds)
+}
+
+func newDataPointConditions(conds []string, set component.TelemetrySettings) (*conditions[ottldatapoints.TransformContext], error) {
+	return parseConditions(ottldatapoints.NewParser(functions[ottldatapoints.TransformContext](), set), conds)
+}
+
+func newLogConditions(conds []string, set component.TelemetrySettings) (*conditions[ottllogs.TransformContext], error) {
+	return parseConditions(ottllogs.NewParser(functions[ottllogs.TransformContext](), set), conds)
+}
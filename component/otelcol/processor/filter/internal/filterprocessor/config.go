@@ -0,0 +1,94 @@
+package filterprocessor
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// ErrorMode determines how errors evaluating conditions are handled.
+type ErrorMode string
+
+const (
+	// ErrorModePropagate returns errors from the processor, which drops the
+	// whole payload being processed.
+	ErrorModePropagate ErrorMode = "propagate"
+	// ErrorModeIgnore logs errors and treats the failed condition as false.
+	ErrorModeIgnore ErrorMode = "ignore"
+)
+
+// Config configures the filter processor. Telemetry matching any of the
+// conditions for its type is dropped.
+type Config struct {
+	config.ProcessorSettings `mapstructure:",squash"`
+
+	ErrorMode ErrorMode `mapstructure:"error_mode"`
+
+	Traces  TraceConditions  `mapstructure:"traces"`
+	Metrics MetricConditions `mapstructure:"metrics"`
+	Logs    LogConditions    `mapstructure:"logs"`
+}
+
+// TraceConditions holds the conditions for dropping trace data.
+type TraceConditions struct {
+	// Span holds OTTL conditions using the traces context. Spans matching any
+	// of them are dropped.
+	Span []string `mapstructure:"span"`
+
+	// SpanEvent holds OTTL conditions using the span event context. Span
+	// events matching any of them are removed from their span.
+	SpanEvent []string `mapstructure:"spanevent"`
+}
+
+// MetricConditions holds the conditions for dropping metric data.
+type MetricConditions struct {
+	// Metric holds OTTL conditions using the metric context. Metrics matching
+	// any of them are dropped.
+	Metric []string `mapstructure:"metric"`
+
+	// DataPoint holds OTTL conditions using the datapoints context. Data
+	// points matching any of them are dropped, as are metrics left without
+	// any data points.
+	DataPoint []string `mapstructure:"datapoint"`
+}
+
+// LogConditions holds the conditions for dropping log data.
+type LogConditions struct {
+	// LogRecord holds OTTL conditions using the logs context. Log records
+	// matching any of them are dropped.
+	LogRecord []string `mapstructure:"log_record"`
+}
+
+var _ config.Processor = (*Config)(nil)
+
+// Validate checks that the error mode is supported and that all conditions
+// can be parsed.
+func (cfg *Config) Validate() error {
+	switch cfg.ErrorMode {
+	case ErrorModePropagate, ErrorModeIgnore:
+	default:
+		return fmt.Errorf("unsupported error_mode %q, expected %q or %q", cfg.ErrorMode, ErrorModePropagate, ErrorModeIgnore)
+	}
+
+	settings := component.TelemetrySettings{Logger: zap.NewNop()}
+	var errs error
+	if _, err := newSpanConditions(cfg.Traces.Span, settings); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("traces.span: %w", err))
+	}
+	if _, err := newSpanEventConditions(cfg.Traces.SpanEvent, settings); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("traces.spanevent: %w", err))
+	}
+	if _, err := newMetricConditions(cfg.Metrics.Metric, settings); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("metrics.metric: %w", err))
+	}
+	if _, err := newDataPointConditions(cfg.Metrics.DataPoint, settings); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("metrics.datapoint: %w", err))
+	}
+	if _, err := newLogConditions(cfg.Logs.LogRecord, settings); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("logs.log_record: %w", err))
+	}
+	return errs
+}
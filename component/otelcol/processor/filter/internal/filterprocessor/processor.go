@@ -0,0 +1,220 @@
+package filterprocessor
+
+import (
+	"context"
+
+	"github.com/grafana/agent/component/otelcol/internal/ottlspanevent"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoints"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllogs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlmetric"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottltraces"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// evaluator evaluates conditions, handling errors according to the error
+// mode. Errors are accumulated in err when they're propagated.
+type evaluator struct {
+	logger    *zap.Logger
+	errorMode ErrorMode
+	err       error
+}
+
+func (e *evaluator) handle(matched bool, err error) bool {
+	if err == nil {
+		return matched
+	}
+	if e.errorMode == ErrorModeIgnore {
+		e.logger.Warn("failed to evaluate condition, keeping telemetry", zap.Error(err))
+	} else {
+		e.err = multierr.Append(e.err, err)
+	}
+	return false
+}
+
+type filterTracesProcessor struct {
+	logger    *zap.Logger
+	errorMode ErrorMode
+	span      *conditions[ottltraces.TransformContext]
+	spanEvent *conditions[ottlspanevent.TransformContext]
+}
+
+func newFilterTracesProcessor(set component.TelemetrySettings, cfg *Config) (*filterTracesProcessor, error) {
+	span, err := newSpanConditions(cfg.Traces.Span, set)
+	if err != nil {
+		return nil, err
+	}
+	spanEvent, err := newSpanEventConditions(cfg.Traces.SpanEvent, set)
+	if err != nil {
+		return nil, err
+	}
+	return &filterTracesProcessor{logger: set.Logger, errorMode: cfg.ErrorMode, span: span, spanEvent: spanEvent}, nil
+}
+
+func (p *filterTracesProcessor) processTraces(_ context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	if p.span.empty() && p.spanEvent.empty() {
+		return td, nil
+	}
+
+	e := evaluator{logger: p.logger, errorMode: p.errorMode}
+	td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		resource := rs.Resource()
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			scope := ss.Scope()
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				if !p.span.empty() && e.handle(p.span.match(ottltraces.NewTransformContext(span, scope, resource))) {
+					return true
+				}
+				if !p.spanEvent.empty() {
+					span.Events().RemoveIf(func(event ptrace.SpanEvent) bool {
+						return e.handle(p.spanEvent.match(ottlspanevent.NewTransformContext(event, span, scope, resource)))
+					})
+				}
+				return false
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+
+	if e.err != nil {
+		return td, e.err
+	}
+	if td.ResourceSpans().Len() == 0 {
+		return td, processorhelper.ErrSkipProcessingData
+	}
+	return td, nil
+}
+
+type filterMetricsProcessor struct {
+	logger    *zap.Logger
+	errorMode ErrorMode
+	metric    *conditions[ottlmetric.TransformContext]
+	dataPoint *conditions[ottldatapoints.TransformContext]
+}
+
+func newFilterMetricsProcessor(set component.TelemetrySettings, cfg *Config) (*filterMetricsProcessor, error) {
+	metric, err := newMetricConditions(cfg.Metrics.Metric, set)
+	if err != nil {
+		return nil, err
+	}
+	dataPoint, err := newDataPointConditions(cfg.Metrics.DataPoint, set)
+	if err != nil {
+		return nil, err
+	}
+	return &filterMetricsProcessor{logger: set.Logger, errorMode: cfg.ErrorMode, metric: metric, dataPoint: dataPoint}, nil
+}
+
+func (p *filterMetricsProcessor) processMetrics(_ context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	if p.metric.empty() && p.dataPoint.empty() {
+		return md, nil
+	}
+
+	e := evaluator{logger: p.logger, errorMode: p.errorMode}
+	md.ResourceMetrics().RemoveIf(func(rm pmetric.ResourceMetrics) bool {
+		resource := rm.Resource()
+		rm.ScopeMetrics().RemoveIf(func(sm pmetric.ScopeMetrics) bool {
+			scope := sm.Scope()
+			metrics := sm.Metrics()
+			metrics.RemoveIf(func(m pmetric.Metric) bool {
+				if !p.metric.empty() && e.handle(p.metric.match(ottlmetric.NewTransformContext(m, scope, resource))) {
+					return true
+				}
+				if p.dataPoint.empty() {
+					return false
+				}
+				return p.filterDataPoints(&e, m, metrics, scope, resource)
+			})
+			return metrics.Len() == 0
+		})
+		return rm.ScopeMetrics().Len() == 0
+	})
+
+	if e.err != nil {
+		return md, e.err
+	}
+	if md.ResourceMetrics().Len() == 0 {
+		return md, processorhelper.ErrSkipProcessingData
+	}
+	return md, nil
+}
+
+// filterDataPoints drops the data points of m matching the data point
+// conditions. It returns true if m has no data points left.
+func (p *filterMetricsProcessor) filterDataPoints(e *evaluator, m pmetric.Metric, metrics pmetric.MetricSlice, scope pcommon.InstrumentationScope, resource pcommon.Resource) bool {
+	matchDataPoint := func(dp interface{}) bool {
+		return e.handle(p.dataPoint.match(ottldatapoints.NewTransformContext(dp, m, metrics, scope, resource)))
+	}
+
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		dps.RemoveIf(func(dp pmetric.NumberDataPoint) bool { return matchDataPoint(dp) })
+		return dps.Len() == 0
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		dps.RemoveIf(func(dp pmetric.NumberDataPoint) bool { return matchDataPoint(dp) })
+		return dps.Len() == 0
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		dps.RemoveIf(func(dp pmetric.HistogramDataPoint) bool { return matchDataPoint(dp) })
+		return dps.Len() == 0
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		dps.RemoveIf(func(dp pmetric.ExponentialHistogramDataPoint) bool { return matchDataPoint(dp) })
+		return dps.Len() == 0
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		dps.RemoveIf(func(dp pmetric.SummaryDataPoint) bool { return matchDataPoint(dp) })
+		return dps.Len() == 0
+	default:
+		return false
+	}
+}
+
+type filterLogsProcessor struct {
+	logger    *zap.Logger
+	errorMode ErrorMode
+	logRecord *conditions[ottllogs.TransformContext]
+}
+
+func newFilterLogsProcessor(set component.TelemetrySettings, cfg *Config) (*filterLogsProcessor, error) {
+	logRecord, err := newLogConditions(cfg.Logs.LogRecord, set)
+	if err != nil {
+		return nil, err
+	}
+	return &filterLogsProcessor{logger: set.Logger, errorMode: cfg.ErrorMode, logRecord: logRecord}, nil
+}
+
+func (p *filterLogsProcessor) processLogs(_ context.Context, ld plog.Logs) (plog.Logs, error) {
+	if p.logRecord.empty() {
+		return ld, nil
+	}
+
+	e := evaluator{logger: p.logger, errorMode: p.errorMode}
+	ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		resource := rl.Resource()
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			scope := sl.Scope()
+			sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+				return e.handle(p.logRecord.match(ottllogs.NewTransformContext(lr, scope, resource)))
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+
+	if e.err != nil {
+		return ld, e.err
+	}
+	if ld.ResourceLogs().Len() == 0 {
+		return ld, processorhelper.ErrSkipProcessingData
+	}
+	return ld, nil
+}
@@ -0,0 +1,60 @@
+package probabilisticsamplerprocessor
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/config"
+)
+
+// AttributeSource determines which value sampling decisions are based on.
+type AttributeSource string
+
+const (
+	// AttributeSourceTraceID bases sampling decisions on the trace ID.
+	AttributeSourceTraceID AttributeSource = "traceID"
+	// AttributeSourceRecord bases sampling decisions on an attribute of the
+	// span or log record, or of its resource.
+	AttributeSourceRecord AttributeSource = "record"
+)
+
+// Config configures the probabilistic sampler processor.
+type Config struct {
+	config.ProcessorSettings `mapstructure:",squash"`
+
+	// SamplingPercentage is the percentage of traces or log records to keep.
+	// Values of 100 or more keep everything.
+	SamplingPercentage float32 `mapstructure:"sampling_percentage"`
+
+	// HashSeed is mixed into the hash of sampled values. Processors with the
+	// same seed and percentage make the same sampling decisions.
+	HashSeed uint32 `mapstructure:"hash_seed"`
+
+	// AttributeSource determines which value is hashed. FromAttribute names
+	// the attribute hashed when AttributeSource is AttributeSourceRecord.
+	AttributeSource AttributeSource `mapstructure:"attribute_source"`
+	FromAttribute   string          `mapstructure:"from_attribute"`
+}
+
+var _ config.Processor = (*Config)(nil)
+
+// Validate checks that the sampling percentage and attribute source are
+// valid.
+func (cfg *Config) Validate() error {
+	if cfg.SamplingPercentage < 0 {
+		return fmt.Errorf("sampling_percentage must not be negative")
+	}
+
+	switch cfg.AttributeSource {
+	case AttributeSourceTraceID:
+		if cfg.FromAttribute != "" {
+			return fmt.Errorf("from_attribute can only be set when attribute_source is %q", AttributeSourceRecord)
+		}
+	case AttributeSourceRecord:
+		if cfg.FromAttribute == "" {
+			return fmt.Errorf("from_attribute must be set when attribute_source is %q", AttributeSourceRecord)
+		}
+	default:
+		return fmt.Errorf("unsupported attribute_source %q, expected %q or %q", cfg.AttributeSource, AttributeSourceTraceID, AttributeSourceRecord)
+	}
+	return nil
+}
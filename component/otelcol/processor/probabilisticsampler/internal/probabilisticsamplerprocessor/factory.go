@@ -0,0 +1,47 @@
+// Package probabilisticsamplerprocessor implements a processor which keeps a
+// percentage of traces and log records, based on the hash of their trace ID
+// or of an attribute.
+package probabilisticsamplerprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const (
+	// The value of processor "type" in configuration.
+	typeStr = "probabilistic_sampler"
+)
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory returns a new factory for the probabilistic sampler processor.
+func NewFactory() component.ProcessorFactory {
+	return component.NewProcessorFactory(
+		typeStr,
+		createDefaultConfig,
+		component.WithTracesProcessor(createTracesProcessor, component.StabilityLevelBeta),
+		component.WithLogsProcessor(createLogsProcessor, component.StabilityLevelAlpha),
+	)
+}
+
+func createDefaultConfig() config.Processor {
+	return &Config{
+		ProcessorSettings: config.NewProcessorSettings(config.NewComponentID(typeStr)),
+		AttributeSource:   AttributeSourceTraceID,
+	}
+}
+
+func createTracesProcessor(ctx context.Context, set component.ProcessorCreateSettings, cfg config.Processor, next consumer.Traces) (component.TracesProcessor, error) {
+	s := newSampler(cfg.(*Config))
+	return processorhelper.NewTracesProcessor(ctx, set, cfg, next, s.processTraces, processorhelper.WithCapabilities(processorCapabilities))
+}
+
+func createLogsProcessor(ctx context.Context, set component.ProcessorCreateSettings, cfg config.Processor, next consumer.Logs) (component.LogsProcessor, error) {
+	s := newSampler(cfg.(*Config))
+	return processorhelper.NewLogsProcessor(ctx, set, cfg, next, s.processLogs, processorhelper.WithCapabilities(processorCapabilities))
+}
@@ -0,0 +1,111 @@
+package probabilisticsamplerprocessor
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+// Sampling decisions compare the lowest bits of a hash against the sampling
+// percentage scaled to the number of hash buckets. The hash and bucketing
+// match the upstream probabilistic sampler, so both make the same decisions
+// for the same seed and percentage.
+const (
+	numHashBuckets     = 0x4000
+	bitMaskHashBuckets = numHashBuckets - 1
+)
+
+type sampler struct {
+	scaledSamplingRate uint32
+	hashSeed           uint32
+	attributeSource    AttributeSource
+	fromAttribute      string
+}
+
+func newSampler(cfg *Config) *sampler {
+	return &sampler{
+		scaledSamplingRate: uint32(cfg.SamplingPercentage * numHashBuckets / 100),
+		hashSeed:           cfg.HashSeed,
+		attributeSource:    cfg.AttributeSource,
+		fromAttribute:      cfg.FromAttribute,
+	}
+}
+
+// sampled returns true if the telemetry identified by key is kept. Empty keys
+// are always kept, so telemetry which can't be identified isn't lost.
+func (s *sampler) sampled(key []byte) bool {
+	if len(key) == 0 {
+		return true
+	}
+	return hash(key, s.hashSeed)&bitMaskHashBuckets < s.scaledSamplingRate
+}
+
+// key returns the value identifying telemetry with the given trace ID and
+// attributes. Record attributes take precedence over resource attributes,
+// and the trace ID is used when neither has the attribute.
+func (s *sampler) key(traceID pcommon.TraceID, attrs, resourceAttrs pcommon.Map) []byte {
+	if s.attributeSource == AttributeSourceRecord {
+		if v, ok := attrs.Get(s.fromAttribute); ok {
+			return []byte(v.AsString())
+		}
+		if v, ok := resourceAttrs.Get(s.fromAttribute); ok {
+			return []byte(v.AsString())
+		}
+	}
+	if traceID.IsEmpty() {
+		return nil
+	}
+	return traceID[:]
+}
+
+func (s *sampler) processTraces(_ context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	td.ResourceSpans().RemoveIf(func(rs ptrace.ResourceSpans) bool {
+		resourceAttrs := rs.Resource().Attributes()
+		rs.ScopeSpans().RemoveIf(func(ss ptrace.ScopeSpans) bool {
+			ss.Spans().RemoveIf(func(span ptrace.Span) bool {
+				return !s.sampled(s.key(span.TraceID(), span.Attributes(), resourceAttrs))
+			})
+			return ss.Spans().Len() == 0
+		})
+		return rs.ScopeSpans().Len() == 0
+	})
+
+	if td.ResourceSpans().Len() == 0 {
+		return td, processorhelper.ErrSkipProcessingData
+	}
+	return td, nil
+}
+
+func (s *sampler) processLogs(_ context.Context, ld plog.Logs) (plog.Logs, error) {
+	ld.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
+		resourceAttrs := rl.Resource().Attributes()
+		rl.ScopeLogs().RemoveIf(func(sl plog.ScopeLogs) bool {
+			sl.LogRecords().RemoveIf(func(lr plog.LogRecord) bool {
+				return !s.sampled(s.key(lr.TraceID(), lr.Attributes(), resourceAttrs))
+			})
+			return sl.LogRecords().Len() == 0
+		})
+		return rl.ScopeLogs().Len() == 0
+	})
+
+	if ld.ResourceLogs().Len() == 0 {
+		return ld, processorhelper.ErrSkipProcessingData
+	}
+	return ld, nil
+}
+
+// hash computes the seeded FNV-1a hash of key.
+func hash(key []byte, seed uint32) uint32 {
+	h := fnv.New32a()
+
+	var seedBytes [4]byte
+	binary.LittleEndian.PutUint32(seedBytes[:], seed)
+	_, _ = h.Write(seedBytes[:])
+	_, _ = h.Write(key)
+	return h.Sum32()
+}
@@ -0,0 +1,85 @@
+// Package probabilisticsampler provides an otelcol.processor.probabilistic_sampler
+// component.
+package probabilisticsampler
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/processor"
+	"github.com/grafana/agent/component/otelcol/processor/probabilisticsampler/internal/probabilisticsamplerprocessor"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.processor.probabilistic_sampler",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := probabilisticsamplerprocessor.NewFactory()
+			return processor.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.processor.probabilistic_sampler component.
+type Arguments struct {
+	SamplingPercentage float32 `river:"sampling_percentage,attr,optional"`
+	HashSeed           uint32  `river:"hash_seed,attr,optional"`
+	AttributeSource    string  `river:"attribute_source,attr,optional"`
+	FromAttribute      string  `river:"from_attribute,attr,optional"`
+
+	// Output configures where to send processed data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ processor.Arguments = Arguments{}
+)
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	AttributeSource: string(probabilisticsamplerprocessor.AttributeSourceTraceID),
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	return args.convert().Validate()
+}
+
+// Convert implements processor.Arguments.
+func (args Arguments) Convert() (otelconfig.Processor, error) {
+	return args.convert(), nil
+}
+
+func (args Arguments) convert() *probabilisticsamplerprocessor.Config {
+	return &probabilisticsamplerprocessor.Config{
+		ProcessorSettings:  otelconfig.NewProcessorSettings(otelconfig.NewComponentID("probabilistic_sampler")),
+		SamplingPercentage: args.SamplingPercentage,
+		HashSeed:           args.HashSeed,
+		AttributeSource:    probabilisticsamplerprocessor.AttributeSource(args.AttributeSource),
+		FromAttribute:      args.FromAttribute,
+	}
+}
+
+// Extensions implements processor.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements processor.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements processor.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
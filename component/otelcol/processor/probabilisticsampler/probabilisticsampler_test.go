@@ -0,0 +1,234 @@
+package probabilisticsampler_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/processor/probabilisticsampler"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "trace ID",
+			cfg: `
+				sampling_percentage = 10
+				hash_seed           = 123
+				output {}
+			`,
+		},
+		{
+			name: "record attribute",
+			cfg: `
+				sampling_percentage = 10
+				attribute_source    = "record"
+				from_attribute      = "session.id"
+				output {}
+			`,
+		},
+		{
+			name: "negative percentage",
+			cfg: `
+				sampling_percentage = -1
+				output {}
+			`,
+			expectedErr: "sampling_percentage must not be negative",
+		},
+		{
+			name: "missing from_attribute",
+			cfg: `
+				attribute_source = "record"
+				output {}
+			`,
+			expectedErr: `from_attribute must be set when attribute_source is "record"`,
+		},
+		{
+			name: "from_attribute with trace ID",
+			cfg: `
+				from_attribute = "session.id"
+				output {}
+			`,
+			expectedErr: `from_attribute can only be set when attribute_source is "record"`,
+		},
+		{
+			name: "invalid attribute source",
+			cfg: `
+				attribute_source = "span"
+				output {}
+			`,
+			expectedErr: `unsupported attribute_source "span", expected "traceID" or "record"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args probabilisticsampler.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestSampler_ConsistentAcrossSignals ensures that spans and log records with
+// the same attribute value get the same sampling decision.
+func TestSampler_ConsistentAcrossSignals(t *testing.T) {
+	cfg := `
+		sampling_percentage = 50
+		attribute_source    = "record"
+		from_attribute      = "session.id"
+		output {}
+	`
+
+	tracesCh := make(chan ptrace.Traces, 1)
+	logsCh := make(chan plog.Logs, 1)
+	exports := runSampler(t, cfg, &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+				tracesCh <- td
+				return nil
+			},
+		}},
+		Logs: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeLogsFunc: func(_ context.Context, ld plog.Logs) error {
+				logsCh <- ld
+				return nil
+			},
+		}},
+	})
+
+	const sessions = 100
+
+	td := ptrace.NewTraces()
+	spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+	ld := plog.NewLogs()
+	rl := ld.ResourceLogs().AppendEmpty()
+	lrs := rl.ScopeLogs().AppendEmpty().LogRecords()
+	for i := 0; i < sessions; i++ {
+		span := spans.AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{byte(i), 1})
+		span.Attributes().PutStr("session.id", fmt.Sprintf("session-%d", i))
+
+		lr := lrs.AppendEmpty()
+		lr.Attributes().PutStr("session.id", fmt.Sprintf("session-%d", i))
+	}
+	// Log records without the attribute on the record use the attribute of
+	// their resource.
+	other := ld.ResourceLogs().AppendEmpty()
+	other.Resource().Attributes().PutStr("session.id", "session-0")
+	other.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+
+	require.NoError(t, exports.Input.ConsumeTraces(context.Background(), td))
+	require.NoError(t, exports.Input.ConsumeLogs(context.Background(), ld))
+
+	var tracesSessions, logsSessions []string
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for traces")
+	case td := <-tracesCh:
+		spans := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+		for i := 0; i < spans.Len(); i++ {
+			v, _ := spans.At(i).Attributes().Get("session.id")
+			tracesSessions = append(tracesSessions, v.Str())
+		}
+	}
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for logs")
+	case ld := <-logsCh:
+		lrs := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		for i := 0; i < lrs.Len(); i++ {
+			v, _ := lrs.At(i).Attributes().Get("session.id")
+			logsSessions = append(logsSessions, v.Str())
+		}
+
+		session0Kept := len(logsSessions) > 0 && logsSessions[0] == "session-0"
+		require.Equal(t, session0Kept, ld.ResourceLogs().Len() == 2)
+	}
+
+	require.Equal(t, tracesSessions, logsSessions)
+	require.Greater(t, len(tracesSessions), 0)
+	require.Less(t, len(tracesSessions), sessions)
+}
+
+func TestSampler_TraceID(t *testing.T) {
+	tests := []struct {
+		percentage int
+		expected   int
+	}{
+		{percentage: 0, expected: 0},
+		{percentage: 100, expected: 10},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%d%%", tc.percentage), func(t *testing.T) {
+			ch := make(chan ptrace.Traces, 1)
+			exports := runSampler(t, fmt.Sprintf(`
+				sampling_percentage = %d
+				output {}
+			`, tc.percentage), &otelcol.ConsumerArguments{
+				Traces: []otelcol.Consumer{&fakeconsumer.Consumer{
+					ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+						ch <- td
+						return nil
+					},
+				}},
+			})
+
+			td := ptrace.NewTraces()
+			spans := td.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans()
+			for i := 0; i < 10; i++ {
+				spans.AppendEmpty().SetTraceID(pcommon.TraceID{byte(i + 1)})
+			}
+			require.NoError(t, exports.Input.ConsumeTraces(context.Background(), td))
+
+			select {
+			case <-time.After(100 * time.Millisecond):
+				require.Zero(t, tc.expected, "no traces were forwarded")
+			case td := <-ch:
+				require.Equal(t, tc.expected, td.SpanCount())
+			}
+		})
+	}
+}
+
+func runSampler(t *testing.T, cfg string, output *otelcol.ConsumerArguments) otelcol.ConsumerExports {
+	t.Helper()
+
+	ctx := componenttest.TestContext(t)
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "otelcol.processor.probabilistic_sampler")
+	require.NoError(t, err)
+
+	var args probabilisticsampler.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override the arguments so signals get forwarded to the test channel.
+	args.Output = output
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+	return ctrl.Exports().(otelcol.ConsumerExports)
+}
@@ -0,0 +1,254 @@
+package tap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+	"golang.org/x/time/rate"
+)
+
+// Signals which can be streamed.
+const (
+	signalTraces  = "traces"
+	signalMetrics = "metrics"
+	signalLogs    = "logs"
+)
+
+// streamBuffer is the number of batches buffered for a stream. Batches are
+// dropped when the client of a stream doesn't keep up.
+const streamBuffer = 16
+
+// Batch is a batch of telemetry data sent to a stream.
+type Batch struct {
+	Time   time.Time       `json:"time"`
+	Signal string          `json:"signal"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// stream is a client streaming the telemetry data passing a tap.
+type stream struct {
+	signals map[string]bool
+	service string // Empty to stream the data of all services.
+	limiter *rate.Limiter
+	batches chan []byte
+}
+
+// hub sends the telemetry data passing a tap to its streams.
+type hub struct {
+	streamsGauge prometheus.Gauge
+	droppedTotal prometheus.Counter
+
+	mut     sync.RWMutex
+	limit   rate.Limit
+	streams map[*stream]struct{}
+}
+
+func newHub(reg prometheus.Registerer, limit float64) (*hub, error) {
+	h := &hub{
+		streamsGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "otelcol_processor_tap_streams",
+			Help: "Number of clients streaming the telemetry data passing the tap.",
+		}),
+		droppedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "otelcol_processor_tap_dropped_batches_total",
+			Help: "Total number of sampled batches dropped because a client didn't keep up with its stream.",
+		}),
+
+		limit:   rate.Limit(limit),
+		streams: make(map[*stream]struct{}),
+	}
+	for _, c := range []prometheus.Collector{h.streamsGauge, h.droppedTotal} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// SetLimit sets the maximum number of batches per second sent to each
+// stream.
+func (h *hub) SetLimit(limit float64) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	h.limit = rate.Limit(limit)
+	for s := range h.streams {
+		s.limiter.SetLimit(h.limit)
+	}
+}
+
+// Publish sends a batch of the signal to the streams of the signal which
+// aren't over their limit. encode encodes the data of the batch of a
+// service, or of all services if service is empty, and returns nil if the
+// batch has no data of the service. Nothing is encoded when there are no
+// streams of the signal.
+func (h *hub) Publish(signal string, encode func(service string) ([]byte, error)) {
+	h.mut.RLock()
+	defer h.mut.RUnlock()
+
+	now := time.Now()
+	encoded := make(map[string][]byte)
+	for s := range h.streams {
+		// Only encode batches for streams which may send them.
+		if !s.signals[signal] || s.limiter.Tokens() < 1 {
+			continue
+		}
+
+		line, ok := encoded[s.service]
+		if !ok {
+			data, err := encode(s.service)
+			if err == nil && data != nil {
+				line, err = json.Marshal(Batch{Time: now, Signal: signal, Data: data})
+			}
+			if err != nil {
+				line = nil
+			}
+			encoded[s.service] = line
+		}
+		if line == nil || !s.limiter.Allow() {
+			continue
+		}
+
+		select {
+		case s.batches <- line:
+		default:
+			h.droppedTotal.Inc()
+		}
+	}
+}
+
+func (h *hub) subscribe(signals map[string]bool, service string) *stream {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	s := &stream{
+		signals: signals,
+		service: service,
+		limiter: rate.NewLimiter(h.limit, 1),
+		batches: make(chan []byte, streamBuffer),
+	}
+	h.streams[s] = struct{}{}
+	h.streamsGauge.Set(float64(len(h.streams)))
+	return s
+}
+
+func (h *hub) unsubscribe(s *stream) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	delete(h.streams, s)
+	h.streamsGauge.Set(float64(len(h.streams)))
+}
+
+// ServeHTTP streams the sampled batches passing the tap as newline-delimited
+// JSON until the client disconnects. The signals to stream are selected by
+// the comma-separated signal query parameter, and default to all signals.
+// The service_name query parameter restricts the stream to the data of a
+// service.
+func (h *hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	signals, err := parseSignals(r.URL.Query().Get("signal"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming isn't supported", http.StatusInternalServerError)
+		return
+	}
+
+	s := h.subscribe(signals, r.URL.Query().Get("service_name"))
+	defer h.unsubscribe(s)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-s.batches:
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func parseSignals(v string) (map[string]bool, error) {
+	if v == "" {
+		return map[string]bool{signalTraces: true, signalMetrics: true, signalLogs: true}, nil
+	}
+	res := make(map[string]bool)
+	for _, signal := range strings.Split(v, ",") {
+		switch signal = strings.TrimSpace(signal); signal {
+		case signalTraces, signalMetrics, signalLogs:
+			res[signal] = true
+		default:
+			return nil, fmt.Errorf("unknown signal %q, must be one of %s, %s, or %s", signal, signalTraces, signalMetrics, signalLogs)
+		}
+	}
+	return res, nil
+}
+
+// matchesService reports whether a resource belongs to the service. All
+// resources match an empty service.
+func matchesService(res pcommon.Resource, service string) bool {
+	if service == "" {
+		return true
+	}
+	name, ok := res.Attributes().Get(semconv.AttributeServiceName)
+	return ok && name.AsString() == service
+}
+
+func filterTraces(td ptrace.Traces, service string) ptrace.Traces {
+	if service == "" {
+		return td
+	}
+	res := ptrace.NewTraces()
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		if rs := td.ResourceSpans().At(i); matchesService(rs.Resource(), service) {
+			rs.CopyTo(res.ResourceSpans().AppendEmpty())
+		}
+	}
+	return res
+}
+
+func filterMetrics(md pmetric.Metrics, service string) pmetric.Metrics {
+	if service == "" {
+		return md
+	}
+	res := pmetric.NewMetrics()
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		if rm := md.ResourceMetrics().At(i); matchesService(rm.Resource(), service) {
+			rm.CopyTo(res.ResourceMetrics().AppendEmpty())
+		}
+	}
+	return res
+}
+
+func filterLogs(ld plog.Logs, service string) plog.Logs {
+	if service == "" {
+		return ld
+	}
+	res := plog.NewLogs()
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		if rl := ld.ResourceLogs().At(i); matchesService(rl.Resource(), service) {
+			rl.CopyTo(res.ResourceLogs().AppendEmpty())
+		}
+	}
+	return res
+}
@@ -0,0 +1,177 @@
+// Package tap provides an otelcol.processor.tap component.
+package tap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
+	"github.com/grafana/agent/component/otelcol/internal/lazyconsumer"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.processor.tap",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.processor.tap component.
+type Arguments struct {
+	// Limit is the maximum number of batches per second sent to each stream.
+	Limit float64 `river:"limit,attr,optional"`
+
+	// Output configures where to send the telemetry data passing the tap.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	Limit: 1,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.Limit <= 0 {
+		return fmt.Errorf("limit must be greater than 0")
+	}
+	return nil
+}
+
+// Component is the otelcol.processor.tap component.
+type Component struct {
+	consumer *lazyconsumer.Consumer
+	hub      *hub
+}
+
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.HTTPComponent = (*Component)(nil)
+)
+
+// New creates a new otelcol.processor.tap component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	h, err := newHub(opts.Registerer, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+	c := &Component{
+		consumer: lazyconsumer.New(context.Background()),
+		hub:      h,
+	}
+
+	opts.OnStateChange(otelcol.ConsumerExports{Input: c.consumer})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements Component.
+func (c *Component) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements Component.
+func (c *Component) Update(newConfig component.Arguments) error {
+	args := newConfig.(Arguments)
+
+	c.hub.SetLimit(args.Limit)
+	c.consumer.SetConsumers(
+		&tracesTap{hub: c.hub, next: fanoutconsumer.Traces(args.Output.Traces)},
+		&metricsTap{hub: c.hub, next: fanoutconsumer.Metrics(args.Output.Metrics)},
+		&logsTap{hub: c.hub, next: fanoutconsumer.Logs(args.Output.Logs)},
+	)
+	return nil
+}
+
+// Handler implements component.HTTPComponent. It serves /tap, which streams
+// a sampled copy of the telemetry data passing the tap.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tap", c.hub.ServeHTTP)
+	return mux
+}
+
+// The taps of each signal send a copy of the data they consume to the streams
+// of the hub before forwarding it. Copies are encoded before forwarding, as
+// the next consumers may mutate the data.
+
+type tracesTap struct {
+	hub  *hub
+	next otelconsumer.Traces
+}
+
+func (t *tracesTap) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+func (t *tracesTap) ConsumeTraces(ctx context.Context, td ptrace.Traces) error {
+	t.hub.Publish(signalTraces, func(service string) ([]byte, error) {
+		filtered := filterTraces(td, service)
+		if filtered.ResourceSpans().Len() == 0 {
+			return nil, nil
+		}
+		return (&ptrace.JSONMarshaler{}).MarshalTraces(filtered)
+	})
+	return t.next.ConsumeTraces(ctx, td)
+}
+
+type metricsTap struct {
+	hub  *hub
+	next otelconsumer.Metrics
+}
+
+func (t *metricsTap) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+func (t *metricsTap) ConsumeMetrics(ctx context.Context, md pmetric.Metrics) error {
+	t.hub.Publish(signalMetrics, func(service string) ([]byte, error) {
+		filtered := filterMetrics(md, service)
+		if filtered.ResourceMetrics().Len() == 0 {
+			return nil, nil
+		}
+		return (&pmetric.JSONMarshaler{}).MarshalMetrics(filtered)
+	})
+	return t.next.ConsumeMetrics(ctx, md)
+}
+
+type logsTap struct {
+	hub  *hub
+	next otelconsumer.Logs
+}
+
+func (t *logsTap) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+func (t *logsTap) ConsumeLogs(ctx context.Context, ld plog.Logs) error {
+	t.hub.Publish(signalLogs, func(service string) ([]byte, error) {
+		filtered := filterLogs(ld, service)
+		if filtered.ResourceLogs().Len() == 0 {
+			return nil, nil
+		}
+		return (&plog.JSONMarshaler{}).MarshalLogs(filtered)
+	})
+	return t.next.ConsumeLogs(ctx, ld)
+}
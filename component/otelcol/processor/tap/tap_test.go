@@ -0,0 +1,98 @@
+package tap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/atomic"
+)
+
+func newTraces(services ...string) ptrace.Traces {
+	td := ptrace.NewTraces()
+	for _, service := range services {
+		rs := td.ResourceSpans().AppendEmpty()
+		rs.Resource().Attributes().PutStr("service.name", service)
+		rs.ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span-of-" + service)
+	}
+	return td
+}
+
+func TestTap(t *testing.T) {
+	var forwarded atomic.Int32
+	args := DefaultArguments
+	args.Output = &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeTracesFunc: func(context.Context, ptrace.Traces) error {
+				forwarded.Inc()
+				return nil
+			},
+		}},
+	}
+	c, err := New(component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+
+	srv := httptest.NewServer(c.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/tap?signal=spans")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/tap?signal=traces&service_name=a", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	// Batches without data of the service aren't sent to the stream.
+	require.NoError(t, c.consumer.ConsumeTraces(context.Background(), newTraces("b")))
+	require.NoError(t, c.consumer.ConsumeTraces(context.Background(), newTraces("a", "b")))
+	require.Equal(t, int32(2), forwarded.Load(), "all data is forwarded")
+
+	line, err := bufio.NewReader(resp.Body).ReadBytes('\n')
+	require.NoError(t, err)
+	var batch Batch
+	require.NoError(t, json.Unmarshal(line, &batch))
+	require.Equal(t, signalTraces, batch.Signal)
+
+	td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces(batch.Data)
+	require.NoError(t, err)
+	require.Equal(t, 1, td.ResourceSpans().Len())
+	name, _ := td.ResourceSpans().At(0).Resource().Attributes().Get("service.name")
+	require.Equal(t, "a", name.AsString())
+}
+
+func TestHub_Limit(t *testing.T) {
+	h, err := newHub(prometheus.NewRegistry(), 1)
+	require.NoError(t, err)
+	s := h.subscribe(map[string]bool{signalLogs: true}, "")
+
+	encode := func(string) ([]byte, error) { return []byte(`{}`), nil }
+	for i := 0; i < 5; i++ {
+		h.Publish(signalLogs, encode)
+		h.Publish(signalTraces, encode)
+	}
+	require.Len(t, s.batches, 1, "only one batch per second is sent to the stream")
+
+	h.unsubscribe(s)
+	require.Empty(t, h.streams)
+}
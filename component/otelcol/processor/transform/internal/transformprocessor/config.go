@@ -0,0 +1,78 @@
+package transformprocessor
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// ErrorMode determines how errors executing statements are handled.
+type ErrorMode string
+
+const (
+	// ErrorModePropagate returns errors from the processor, which drops the
+	// whole payload being processed.
+	ErrorModePropagate ErrorMode = "propagate"
+	// ErrorModeIgnore logs errors and continues with the next statement.
+	ErrorModeIgnore ErrorMode = "ignore"
+)
+
+// Context names the OTTL context statements are executed in.
+type Context string
+
+// Supported contexts.
+const (
+	ContextResource  Context = "resource"
+	ContextScope     Context = "scope"
+	ContextSpan      Context = "span"
+	ContextSpanEvent Context = "spanevent"
+	ContextMetric    Context = "metric"
+	ContextDataPoint Context = "datapoint"
+	ContextLog       Context = "log"
+)
+
+// Config configures the transform processor.
+type Config struct {
+	config.ProcessorSettings `mapstructure:",squash"`
+
+	ErrorMode ErrorMode `mapstructure:"error_mode"`
+
+	TraceStatements  []ContextStatements `mapstructure:"trace_statements"`
+	MetricStatements []ContextStatements `mapstructure:"metric_statements"`
+	LogStatements    []ContextStatements `mapstructure:"log_statements"`
+}
+
+// ContextStatements is a group of OTTL statements executed in the same
+// context.
+type ContextStatements struct {
+	Context    Context  `mapstructure:"context"`
+	Statements []string `mapstructure:"statements"`
+}
+
+var _ config.Processor = (*Config)(nil)
+
+// Validate checks that the error mode is supported and that all statements
+// can be parsed in their context.
+func (cfg *Config) Validate() error {
+	switch cfg.ErrorMode {
+	case ErrorModePropagate, ErrorModeIgnore:
+	default:
+		return fmt.Errorf("unsupported error_mode %q, expected %q or %q", cfg.ErrorMode, ErrorModePropagate, ErrorModeIgnore)
+	}
+
+	settings := component.TelemetrySettings{Logger: zap.NewNop()}
+	var errs error
+	if _, err := newTraceGroups(cfg.TraceStatements, settings); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("trace_statements: %w", err))
+	}
+	if _, err := newMetricGroups(cfg.MetricStatements, settings); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("metric_statements: %w", err))
+	}
+	if _, err := newLogGroups(cfg.LogStatements, settings); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("log_statements: %w", err))
+	}
+	return errs
+}
@@ -0,0 +1,61 @@
+// Package transformprocessor implements a processor which modifies telemetry
+// with OTTL statements.
+package transformprocessor
+
+import (
+	"context"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/processor/processorhelper"
+)
+
+const (
+	// The value of processor "type" in configuration.
+	typeStr = "transform"
+)
+
+var processorCapabilities = consumer.Capabilities{MutatesData: true}
+
+// NewFactory returns a new factory for the transform processor.
+func NewFactory() component.ProcessorFactory {
+	return component.NewProcessorFactory(
+		typeStr,
+		createDefaultConfig,
+		component.WithTracesProcessor(createTracesProcessor, component.StabilityLevelAlpha),
+		component.WithMetricsProcessor(createMetricsProcessor, component.StabilityLevelAlpha),
+		component.WithLogsProcessor(createLogsProcessor, component.StabilityLevelAlpha),
+	)
+}
+
+func createDefaultConfig() config.Processor {
+	return &Config{
+		ProcessorSettings: config.NewProcessorSettings(config.NewComponentID(typeStr)),
+		ErrorMode:         ErrorModePropagate,
+	}
+}
+
+func createTracesProcessor(ctx context.Context, set component.ProcessorCreateSettings, cfg config.Processor, next consumer.Traces) (component.TracesProcessor, error) {
+	p, err := newTracesProcessor(set.TelemetrySettings, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
+	return processorhelper.NewTracesProcessor(ctx, set, cfg, next, p.processTraces, processorhelper.WithCapabilities(processorCapabilities))
+}
+
+func createMetricsProcessor(ctx context.Context, set component.ProcessorCreateSettings, cfg config.Processor, next consumer.Metrics) (component.MetricsProcessor, error) {
+	p, err := newMetricsProcessor(set.TelemetrySettings, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
+	return processorhelper.NewMetricsProcessor(ctx, set, cfg, next, p.processMetrics, processorhelper.WithCapabilities(processorCapabilities))
+}
+
+func createLogsProcessor(ctx context.Context, set component.ProcessorCreateSettings, cfg config.Processor, next consumer.Logs) (component.LogsProcessor, error) {
+	p, err := newLogsProcessor(set.TelemetrySettings, cfg.(*Config))
+	if err != nil {
+		return nil, err
+	}
+	return processorhelper.NewLogsProcessor(ctx, set, cfg, next, p.processLogs, processorhelper.WithCapabilities(processorCapabilities))
+}
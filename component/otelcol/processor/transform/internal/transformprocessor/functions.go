@@ -0,0 +1,123 @@
+package transformprocessor
+
+import (
+	"fmt"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoints"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlmetric"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+)
+
+// Functions specific to metrics, which change the type of metrics or derive
+// new metrics from them.
+
+func parseTemporality(s string) (pmetric.AggregationTemporality, error) {
+	switch s {
+	case "cumulative":
+		return pmetric.AggregationTemporalityCumulative, nil
+	case "delta":
+		return pmetric.AggregationTemporalityDelta, nil
+	default:
+		return pmetric.AggregationTemporalityUnspecified, fmt.Errorf("unknown aggregation temporality %q, expected \"cumulative\" or \"delta\"", s)
+	}
+}
+
+// convertSumToGauge converts sum metrics to gauges, keeping their data
+// points.
+func convertSumToGauge() (ottl.ExprFunc[ottlmetric.TransformContext], error) {
+	return func(ctx ottlmetric.TransformContext) (interface{}, error) {
+		m := ctx.GetMetric()
+		if m.Type() != pmetric.MetricTypeSum {
+			return nil, nil
+		}
+
+		dps := pmetric.NewNumberDataPointSlice()
+		m.Sum().DataPoints().MoveAndAppendTo(dps)
+		dps.MoveAndAppendTo(m.SetEmptyGauge().DataPoints())
+		return nil, nil
+	}, nil
+}
+
+// convertGaugeToSum converts gauges to sum metrics with the given
+// aggregation temporality and monotonicity, keeping their data points.
+func convertGaugeToSum(temporality string, monotonic bool) (ottl.ExprFunc[ottlmetric.TransformContext], error) {
+	aggTemp, err := parseTemporality(temporality)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx ottlmetric.TransformContext) (interface{}, error) {
+		m := ctx.GetMetric()
+		if m.Type() != pmetric.MetricTypeGauge {
+			return nil, nil
+		}
+
+		dps := pmetric.NewNumberDataPointSlice()
+		m.Gauge().DataPoints().MoveAndAppendTo(dps)
+		sum := m.SetEmptySum()
+		sum.SetAggregationTemporality(aggTemp)
+		sum.SetIsMonotonic(monotonic)
+		dps.MoveAndAppendTo(sum.DataPoints())
+		return nil, nil
+	}, nil
+}
+
+// convertSummaryCountValToSum adds the count of summary data points to a
+// sum metric named after the summary with a _count suffix.
+func convertSummaryCountValToSum(temporality string, monotonic bool) (ottl.ExprFunc[ottldatapoints.TransformContext], error) {
+	return summaryValToSum("_count", temporality, monotonic, func(dp pmetric.SummaryDataPoint, sumDp pmetric.NumberDataPoint) {
+		sumDp.SetIntValue(int64(dp.Count()))
+	})
+}
+
+// convertSummarySumValToSum adds the sum of summary data points to a sum
+// metric named after the summary with a _sum suffix.
+func convertSummarySumValToSum(temporality string, monotonic bool) (ottl.ExprFunc[ottldatapoints.TransformContext], error) {
+	return summaryValToSum("_sum", temporality, monotonic, func(dp pmetric.SummaryDataPoint, sumDp pmetric.NumberDataPoint) {
+		sumDp.SetDoubleValue(dp.Sum())
+	})
+}
+
+func summaryValToSum(suffix, temporality string, monotonic bool, setValue func(pmetric.SummaryDataPoint, pmetric.NumberDataPoint)) (ottl.ExprFunc[ottldatapoints.TransformContext], error) {
+	aggTemp, err := parseTemporality(temporality)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx ottldatapoints.TransformContext) (interface{}, error) {
+		dp, ok := ctx.GetDataPoint().(pmetric.SummaryDataPoint)
+		if !ok {
+			return nil, nil
+		}
+
+		m := ctx.GetMetric()
+		sum := findOrAppendSum(ctx.GetMetrics(), m, m.Name()+suffix, aggTemp, monotonic)
+
+		sumDp := sum.DataPoints().AppendEmpty()
+		dp.Attributes().CopyTo(sumDp.Attributes())
+		sumDp.SetStartTimestamp(dp.StartTimestamp())
+		sumDp.SetTimestamp(dp.Timestamp())
+		setValue(dp, sumDp)
+		return nil, nil
+	}, nil
+}
+
+// findOrAppendSum returns the sum metric called name in metrics, appending
+// it if it doesn't exist yet.
+func findOrAppendSum(metrics pmetric.MetricSlice, from pmetric.Metric, name string, aggTemp pmetric.AggregationTemporality, monotonic bool) pmetric.Sum {
+	for i := 0; i < metrics.Len(); i++ {
+		if m := metrics.At(i); m.Name() == name && m.Type() == pmetric.MetricTypeSum {
+			return m.Sum()
+		}
+	}
+
+	m := metrics.AppendEmpty()
+	m.SetName(name)
+	m.SetDescription(from.Description())
+	m.SetUnit(from.Unit())
+	sum := m.SetEmptySum()
+	sum.SetAggregationTemporality(aggTemp)
+	sum.SetIsMonotonic(monotonic)
+	return sum
+}
@@ -0,0 +1,208 @@
+package transformprocessor
+
+import (
+	"context"
+
+	"github.com/grafana/agent/component/otelcol/internal/ottlspanevent"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoints"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllogs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlmetric"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlscope"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottltraces"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.uber.org/zap"
+)
+
+// Groups of statements are executed in order, each one against all the
+// telemetry of the payload before the next group runs.
+
+type tracesProcessor struct {
+	logger    *zap.Logger
+	errorMode ErrorMode
+	groups    []contextStatements
+}
+
+func newTracesProcessor(set component.TelemetrySettings, cfg *Config) (*tracesProcessor, error) {
+	groups, err := newTraceGroups(cfg.TraceStatements, set)
+	if err != nil {
+		return nil, err
+	}
+	return &tracesProcessor{logger: set.Logger, errorMode: cfg.ErrorMode, groups: groups}, nil
+}
+
+func (p *tracesProcessor) processTraces(_ context.Context, td ptrace.Traces) (ptrace.Traces, error) {
+	e := executor{logger: p.logger, errorMode: p.errorMode}
+	for _, g := range p.groups {
+		rss := td.ResourceSpans()
+		for i := 0; i < rss.Len() && !e.failed(); i++ {
+			rs := rss.At(i)
+			resource := rs.Resource()
+			if g.resource != nil {
+				execute(&e, g.resource, ottlresource.NewTransformContext(resource))
+				continue
+			}
+
+			sss := rs.ScopeSpans()
+			for j := 0; j < sss.Len() && !e.failed(); j++ {
+				ss := sss.At(j)
+				scope := ss.Scope()
+				if g.scope != nil {
+					execute(&e, g.scope, ottlscope.NewTransformContext(scope, resource))
+					continue
+				}
+
+				spans := ss.Spans()
+				for k := 0; k < spans.Len() && !e.failed(); k++ {
+					span := spans.At(k)
+					if g.span != nil {
+						execute(&e, g.span, ottltraces.NewTransformContext(span, scope, resource))
+						continue
+					}
+
+					events := span.Events()
+					for l := 0; l < events.Len() && !e.failed(); l++ {
+						execute(&e, g.spanEvent, ottlspanevent.NewTransformContext(events.At(l), span, scope, resource))
+					}
+				}
+			}
+		}
+	}
+	return td, e.err
+}
+
+type metricsProcessor struct {
+	logger    *zap.Logger
+	errorMode ErrorMode
+	groups    []contextStatements
+}
+
+func newMetricsProcessor(set component.TelemetrySettings, cfg *Config) (*metricsProcessor, error) {
+	groups, err := newMetricGroups(cfg.MetricStatements, set)
+	if err != nil {
+		return nil, err
+	}
+	return &metricsProcessor{logger: set.Logger, errorMode: cfg.ErrorMode, groups: groups}, nil
+}
+
+func (p *metricsProcessor) processMetrics(_ context.Context, md pmetric.Metrics) (pmetric.Metrics, error) {
+	e := executor{logger: p.logger, errorMode: p.errorMode}
+	for _, g := range p.groups {
+		rms := md.ResourceMetrics()
+		for i := 0; i < rms.Len() && !e.failed(); i++ {
+			rm := rms.At(i)
+			resource := rm.Resource()
+			if g.resource != nil {
+				execute(&e, g.resource, ottlresource.NewTransformContext(resource))
+				continue
+			}
+
+			sms := rm.ScopeMetrics()
+			for j := 0; j < sms.Len() && !e.failed(); j++ {
+				sm := sms.At(j)
+				scope := sm.Scope()
+				if g.scope != nil {
+					execute(&e, g.scope, ottlscope.NewTransformContext(scope, resource))
+					continue
+				}
+
+				// Functions may append metrics; only the metrics present before
+				// the group runs are processed.
+				metrics := sm.Metrics()
+				n := metrics.Len()
+				for k := 0; k < n && !e.failed(); k++ {
+					m := metrics.At(k)
+					if g.metric != nil {
+						execute(&e, g.metric, ottlmetric.NewTransformContext(m, scope, resource))
+						continue
+					}
+					p.processDataPoints(&e, g, m, metrics, scope, resource)
+				}
+			}
+		}
+	}
+	return md, e.err
+}
+
+func (p *metricsProcessor) processDataPoints(e *executor, g contextStatements, m pmetric.Metric, metrics pmetric.MetricSlice, scope pcommon.InstrumentationScope, resource pcommon.Resource) {
+	executeDataPoint := func(dp interface{}) {
+		execute(e, g.dataPoint, ottldatapoints.NewTransformContext(dp, m, metrics, scope, resource))
+	}
+
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dps := m.Gauge().DataPoints()
+		for i := 0; i < dps.Len() && !e.failed(); i++ {
+			executeDataPoint(dps.At(i))
+		}
+	case pmetric.MetricTypeSum:
+		dps := m.Sum().DataPoints()
+		for i := 0; i < dps.Len() && !e.failed(); i++ {
+			executeDataPoint(dps.At(i))
+		}
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len() && !e.failed(); i++ {
+			executeDataPoint(dps.At(i))
+		}
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len() && !e.failed(); i++ {
+			executeDataPoint(dps.At(i))
+		}
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for i := 0; i < dps.Len() && !e.failed(); i++ {
+			executeDataPoint(dps.At(i))
+		}
+	}
+}
+
+type logsProcessor struct {
+	logger    *zap.Logger
+	errorMode ErrorMode
+	groups    []contextStatements
+}
+
+func newLogsProcessor(set component.TelemetrySettings, cfg *Config) (*logsProcessor, error) {
+	groups, err := newLogGroups(cfg.LogStatements, set)
+	if err != nil {
+		return nil, err
+	}
+	return &logsProcessor{logger: set.Logger, errorMode: cfg.ErrorMode, groups: groups}, nil
+}
+
+func (p *logsProcessor) processLogs(_ context.Context, ld plog.Logs) (plog.Logs, error) {
+	e := executor{logger: p.logger, errorMode: p.errorMode}
+	for _, g := range p.groups {
+		rls := ld.ResourceLogs()
+		for i := 0; i < rls.Len() && !e.failed(); i++ {
+			rl := rls.At(i)
+			resource := rl.Resource()
+			if g.resource != nil {
+				execute(&e, g.resource, ottlresource.NewTransformContext(resource))
+				continue
+			}
+
+			sls := rl.ScopeLogs()
+			for j := 0; j < sls.Len() && !e.failed(); j++ {
+				sl := sls.At(j)
+				scope := sl.Scope()
+				if g.scope != nil {
+					execute(&e, g.scope, ottlscope.NewTransformContext(scope, resource))
+					continue
+				}
+
+				lrs := sl.LogRecords()
+				for k := 0; k < lrs.Len() && !e.failed(); k++ {
+					execute(&e, g.log, ottllogs.NewTransformContext(lrs.At(k), scope, resource))
+				}
+			}
+		}
+	}
+	return ld, e.err
+}
@@ -0,0 +1,168 @@
+package transformprocessor
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/component/otelcol/internal/ottlspanevent"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottldatapoints"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottllogs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlmetric"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlresource"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottlscope"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/contexts/ottltraces"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/ottl/ottlfuncs"
+	"go.opentelemetry.io/collector/component"
+	"go.uber.org/zap"
+)
+
+// statements is a group of OTTL statements executed against a context of
+// type K.
+type statements[K any] struct {
+	parsed []*ottl.Statement[K]
+}
+
+func parseStatements[K any](parser ottl.Parser[K], raw []string) (*statements[K], error) {
+	parsed, err := parser.ParseStatements(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &statements[K]{parsed: parsed}, nil
+}
+
+// executor executes statements, handling errors according to the error mode.
+// The first error is kept in err when errors are propagated, and no further
+// statements are executed.
+type executor struct {
+	logger    *zap.Logger
+	errorMode ErrorMode
+	err       error
+}
+
+// failed returns true if processing must stop because of an earlier error.
+func (e *executor) failed() bool {
+	return e.err != nil
+}
+
+func execute[K any](e *executor, s *statements[K], ctx K) {
+	for _, st := range s.parsed {
+		if e.failed() {
+			return
+		}
+		if _, _, err := st.Execute(ctx); err != nil {
+			if e.errorMode == ErrorModeIgnore {
+				e.logger.Warn("failed to execute statement", zap.Error(err))
+				continue
+			}
+			e.err = err
+		}
+	}
+}
+
+// commonFunctions returns the functions which can be used in statements of
+// any context.
+func commonFunctions[K any]() map[string]interface{} {
+	return map[string]interface{}{
+		"Concat":  ottlfuncs.Concat[K],
+		"Int":     ottlfuncs.Int[K],
+		"IsMatch": ottlfuncs.IsMatch[K],
+		"SpanID":  ottlfuncs.SpanID[K],
+		"Split":   ottlfuncs.Split[K],
+		"TraceID": ottlfuncs.TraceID[K],
+
+		"delete_key":           ottlfuncs.DeleteKey[K],
+		"delete_matching_keys": ottlfuncs.DeleteMatchingKeys[K],
+		"keep_keys":            ottlfuncs.KeepKeys[K],
+		"limit":                ottlfuncs.Limit[K],
+		"replace_all_matches":  ottlfuncs.ReplaceAllMatches[K],
+		"replace_all_patterns": ottlfuncs.ReplaceAllPatterns[K],
+		"replace_match":        ottlfuncs.ReplaceMatch[K],
+		"replace_pattern":      ottlfuncs.ReplacePattern[K],
+		"set":                  ottlfuncs.Set[K],
+		"truncate_all":         ottlfuncs.TruncateAll[K],
+	}
+}
+
+func metricFunctions() map[string]interface{} {
+	funcs := commonFunctions[ottlmetric.TransformContext]()
+	funcs["convert_sum_to_gauge"] = convertSumToGauge
+	funcs["convert_gauge_to_sum"] = convertGaugeToSum
+	return funcs
+}
+
+func dataPointFunctions() map[string]interface{} {
+	funcs := commonFunctions[ottldatapoints.TransformContext]()
+	funcs["convert_summary_count_val_to_sum"] = convertSummaryCountValToSum
+	funcs["convert_summary_sum_val_to_sum"] = convertSummarySumValToSum
+	return funcs
+}
+
+// contextStatements holds the parsed statements of a group. Only the field
+// for the context of the group is set.
+type contextStatements struct {
+	context Context
+
+	resource  *statements[ottlresource.TransformContext]
+	scope     *statements[ottlscope.TransformContext]
+	span      *statements[ottltraces.TransformContext]
+	spanEvent *statements[ottlspanevent.TransformContext]
+	metric    *statements[ottlmetric.TransformContext]
+	dataPoint *statements[ottldatapoints.TransformContext]
+	log       *statements[ottllogs.TransformContext]
+}
+
+// parseGroups parses groups of statements, checking that their context is
+// one of allowed.
+func parseGroups(groups []ContextStatements, set component.TelemetrySettings, allowed ...Context) ([]contextStatements, error) {
+	res := make([]contextStatements, 0, len(groups))
+	for i, g := range groups {
+		if !contextAllowed(g.Context, allowed) {
+			return nil, fmt.Errorf("group %d: unsupported context %q, expected one of %q", i, g.Context, allowed)
+		}
+
+		cs := contextStatements{context: g.Context}
+		var err error
+		switch g.Context {
+		case ContextResource:
+			cs.resource, err = parseStatements(ottlresource.NewParser(commonFunctions[ottlresource.TransformContext](), set), g.Statements)
+		case ContextScope:
+			cs.scope, err = parseStatements(ottlscope.NewParser(commonFunctions[ottlscope.TransformContext](), set), g.Statements)
+		case ContextSpan:
+			cs.span, err = parseStatements(ottltraces.NewParser(commonFunctions[ottltraces.TransformContext](), set), g.Statements)
+		case ContextSpanEvent:
+			cs.spanEvent, err = parseStatements(ottlspanevent.NewParser(commonFunctions[ottlspanevent.TransformContext](), set), g.Statements)
+		case ContextMetric:
+			cs.metric, err = parseStatements(ottlmetric.NewParser(metricFunctions(), set), g.Statements)
+		case ContextDataPoint:
+			cs.dataPoint, err = parseStatements(ottldatapoints.NewParser(dataPointFunctions(), set), g.Statements)
+		case ContextLog:
+			cs.log, err = parseStatements(ottllogs.NewParser(commonFunctions[ottllogs.TransformContext](), set), g.Statements)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %w", i, err)
+		}
+		res = append(res, cs)
+	}
+	return res, nil
+}
+
+func contextAllowed(c Context, allowed []Context) bool {
+	for _, a := range allowed {
+		if c == a {
+			return true
+		}
+	}
+	return false
+}
+
+func newTraceGroups(groups []ContextStatements, set component.TelemetrySettings) ([]contextStatements, error) {
+	return parseGroups(groups, set, ContextResource, ContextScope, ContextSpan, ContextSpanEvent)
+}
+
+func newMetricGroups(groups []ContextStatements, set component.TelemetrySettings) ([]contextStatements, error) {
+	return parseGroups(groups, set, ContextResource, ContextScope, ContextMetric, ContextDataPoint)
+}
+
+func newLogGroups(groups []ContextStatements, set component.TelemetrySettings) ([]contextStatements, error) {
+	return parseGroups(groups, set, ContextResource, ContextScope, ContextLog)
+}
@@ -0,0 +1,104 @@
+// Package transform provides an otelcol.processor.transform component.
+package transform
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/processor"
+	"github.com/grafana/agent/component/otelcol/processor/transform/internal/transformprocessor"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "otelcol.processor.transform",
+		Args:    Arguments{},
+		Exports: otelcol.ConsumerExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := transformprocessor.NewFactory()
+			return processor.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.processor.transform component.
+type Arguments struct {
+	// ErrorMode determines how errors executing statements are handled.
+	ErrorMode string `river:"error_mode,attr,optional"`
+
+	TraceStatements  []ContextStatements `river:"trace_statements,block,optional"`
+	MetricStatements []ContextStatements `river:"metric_statements,block,optional"`
+	LogStatements    []ContextStatements `river:"log_statements,block,optional"`
+
+	// Output configures where to send processed data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// ContextStatements holds a group of OTTL statements executed in the same
+// context.
+type ContextStatements struct {
+	Context    string   `river:"context,attr"`
+	Statements []string `river:"statements,attr"`
+}
+
+var (
+	_ processor.Arguments = Arguments{}
+)
+
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	ErrorMode: string(transformprocessor.ErrorModePropagate),
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	return args.convert().Validate()
+}
+
+// Convert implements processor.Arguments.
+func (args Arguments) Convert() (otelconfig.Processor, error) {
+	return args.convert(), nil
+}
+
+func (args Arguments) convert() *transformprocessor.Config {
+	return &transformprocessor.Config{
+		ProcessorSettings: otelconfig.NewProcessorSettings(otelconfig.NewComponentID("transform")),
+		ErrorMode:         transformprocessor.ErrorMode(args.ErrorMode),
+		TraceStatements:   convertStatements(args.TraceStatements),
+		MetricStatements:  convertStatements(args.MetricStatements),
+		LogStatements:     convertStatements(args.LogStatements),
+	}
+}
+
+func convertStatements(groups []ContextStatements) []transformprocessor.ContextStatements {
+	res := make([]transformprocessor.ContextStatements, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, transformprocessor.ContextStatements{
+			Context:    transformprocessor.Context(g.Context),
+			Statements: g.Statements,
+		})
+	}
+	return res
+}
+
+// Extensions implements processor.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements processor.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements processor.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
@@ -0,0 +1,337 @@
+package transform_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/processor/transform"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "valid statements",
+			cfg: `
+				error_mode = "ignore"
+				trace_statements {
+					context    = "resource"
+					statements = ["delete_key(attributes, \"host.ip\")"]
+				}
+				trace_statements {
+					context    = "spanevent"
+					statements = ["set(attributes[\"span\"], span.name)"]
+				}
+				metric_statements {
+					context    = "metric"
+					statements = ["convert_gauge_to_sum(\"cumulative\", false) where name == \"requests\""]
+				}
+				metric_statements {
+					context    = "datapoint"
+					statements = ["convert_summary_sum_val_to_sum(\"delta\", true)"]
+				}
+				log_statements {
+					context    = "scope"
+					statements = ["set(name, \"app\")"]
+				}
+				output {}
+			`,
+		},
+		{
+			name: "invalid error mode",
+			cfg: `
+				error_mode = "panic"
+				output {}
+			`,
+			expectedErr: `unsupported error_mode "panic"`,
+		},
+		{
+			name: "context not supported by signal",
+			cfg: `
+				log_statements {
+					context    = "span"
+					statements = ["set(name, \"x\")"]
+				}
+				output {}
+			`,
+			expectedErr: `log_statements: group 0: unsupported context "span"`,
+		},
+		{
+			name: "unknown function",
+			cfg: `
+				trace_statements {
+					context    = "span"
+					statements = ["explode(name)"]
+				}
+				output {}
+			`,
+			expectedErr: "trace_statements: group 0",
+		},
+		{
+			name: "invalid temporality",
+			cfg: `
+				metric_statements {
+					context    = "metric"
+					statements = ["convert_gauge_to_sum(\"sometimes\", false)"]
+				}
+				output {}
+			`,
+			expectedErr: "metric_statements: group 0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args transform.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTransform_Traces(t *testing.T) {
+	cfg := `
+		trace_statements {
+			context    = "resource"
+			statements = ["set(attributes[\"env\"], \"prod\")"]
+		}
+		trace_statements {
+			context    = "span"
+			statements = [
+				"set(attributes[\"env\"], resource.attributes[\"env\"])",
+				"set(name, \"renamed\") where name == \"original\"",
+			]
+		}
+		trace_statements {
+			context    = "spanevent"
+			statements = ["set(attributes[\"span\"], span.name)"]
+		}
+		output {}
+	`
+	input := `{
+		"resourceSpans": [{
+			"scopeSpans": [{
+				"spans": [{
+					"name": "original",
+					"events": [{"name": "exception"}]
+				}]
+			}]
+		}]
+	}`
+	expected := `{
+		"resourceSpans": [{
+			"resource": {"attributes": [{"key": "env", "value": {"stringValue": "prod"}}]},
+			"scopeSpans": [{
+				"scope": {},
+				"spans": [{
+					"name": "renamed",
+					"attributes": [{"key": "env", "value": {"stringValue": "prod"}}],
+					"events": [{
+						"name": "exception",
+						"attributes": [{"key": "span", "value": {"stringValue": "renamed"}}]
+					}],
+					"traceId": "",
+					"spanId": "",
+					"parentSpanId": "",
+					"status": {}
+				}]
+			}]
+		}]
+	}`
+
+	ch := make(chan ptrace.Traces, 1)
+	exports := runTransform(t, cfg, &otelcol.ConsumerArguments{
+		Traces: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeTracesFunc: func(_ context.Context, td ptrace.Traces) error {
+				ch <- td
+				return nil
+			},
+		}},
+	})
+
+	td, err := (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, exports.Input.ConsumeTraces(context.Background(), td))
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for traces")
+	case td := <-ch:
+		actual, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+		require.NoError(t, err)
+		require.JSONEq(t, expected, string(actual))
+	}
+}
+
+func TestTransform_Metrics(t *testing.T) {
+	cfg := `
+		metric_statements {
+			context    = "metric"
+			statements = ["convert_gauge_to_sum(\"cumulative\", true) where name == \"requests\""]
+		}
+		metric_statements {
+			context    = "datapoint"
+			statements = [
+				"convert_summary_count_val_to_sum(\"cumulative\", true)",
+				"delete_key(attributes, \"pod\")",
+			]
+		}
+		output {}
+	`
+	input := `{
+		"resourceMetrics": [{
+			"scopeMetrics": [{
+				"metrics": [{
+					"name": "requests",
+					"gauge": {"dataPoints": [{"asInt": "1"}]}
+				}, {
+					"name": "latency",
+					"summary": {"dataPoints": [{
+						"count": "3",
+						"sum": 1.5,
+						"attributes": [{"key": "pod", "value": {"stringValue": "a"}}]
+					}]}
+				}]
+			}]
+		}]
+	}`
+	expected := `{
+		"resourceMetrics": [{
+			"resource": {},
+			"scopeMetrics": [{
+				"scope": {},
+				"metrics": [{
+					"name": "requests",
+					"sum": {
+						"dataPoints": [{"asInt": "1"}],
+						"aggregationTemporality": 2,
+						"isMonotonic": true
+					}
+				}, {
+					"name": "latency",
+					"summary": {"dataPoints": [{"count": "3", "sum": 1.5, "attributes": []}]}
+				}, {
+					"name": "latency_count",
+					"sum": {
+						"dataPoints": [{
+							"asInt": "3",
+							"attributes": [{"key": "pod", "value": {"stringValue": "a"}}]
+						}],
+						"aggregationTemporality": 2,
+						"isMonotonic": true
+					}
+				}]
+			}]
+		}]
+	}`
+
+	ch := make(chan pmetric.Metrics, 1)
+	exports := runTransform(t, cfg, &otelcol.ConsumerArguments{
+		Metrics: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeMetricsFunc: func(_ context.Context, md pmetric.Metrics) error {
+				ch <- md
+				return nil
+			},
+		}},
+	})
+
+	md, err := (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, exports.Input.ConsumeMetrics(context.Background(), md))
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for metrics")
+	case md := <-ch:
+		actual, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+		require.NoError(t, err)
+		require.JSONEq(t, expected, string(actual))
+	}
+}
+
+func TestTransform_ErrorMode(t *testing.T) {
+	// Int fails on values which can't be converted, such as maps; with the
+	// ignore error mode the next statement still runs.
+	cfg := `
+		error_mode = "ignore"
+		log_statements {
+			context    = "log"
+			statements = [
+				"set(attributes[\"n\"], Int(attributes))",
+				"set(attributes[\"done\"], true)",
+			]
+		}
+		output {}
+	`
+	input := `{
+		"resourceLogs": [{
+			"scopeLogs": [{
+				"logRecords": [{"body": {"stringValue": "hello"}}]
+			}]
+		}]
+	}`
+
+	ch := make(chan plog.Logs, 1)
+	exports := runTransform(t, cfg, &otelcol.ConsumerArguments{
+		Logs: []otelcol.Consumer{&fakeconsumer.Consumer{
+			ConsumeLogsFunc: func(_ context.Context, ld plog.Logs) error {
+				ch <- ld
+				return nil
+			},
+		}},
+	})
+
+	ld, err := (&plog.JSONUnmarshaler{}).UnmarshalLogs([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, exports.Input.ConsumeLogs(context.Background(), ld))
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for logs")
+	case ld := <-ch:
+		attrs := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+		done, ok := attrs.Get("done")
+		require.True(t, ok)
+		require.True(t, done.Bool())
+	}
+}
+
+func runTransform(t *testing.T, cfg string, output *otelcol.ConsumerArguments) otelcol.ConsumerExports {
+	t.Helper()
+
+	ctx := componenttest.TestContext(t)
+	ctrl, err := componenttest.NewControllerFromID(util.TestLogger(t), "otelcol.processor.transform")
+	require.NoError(t, err)
+
+	var args transform.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override the arguments so signals get forwarded to the test channel.
+	args.Output = output
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second), "component never started")
+	require.NoError(t, ctrl.WaitExports(time.Second), "component never exported anything")
+	return ctrl.Exports().(otelcol.ConsumerExports)
+}
@@ -0,0 +1,76 @@
+// Package datadog provides an otelcol.receiver.datadog component.
+package datadog
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/receiver"
+	"github.com/grafana/agent/component/otelcol/receiver/datadog/internal/datadogreceiver"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "otelcol.receiver.datadog",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := datadogreceiver.NewFactory()
+			return receiver.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.receiver.datadog component.
+type Arguments struct {
+	HTTPServer  otelcol.HTTPServerArguments `river:",squash"`
+	ReadTimeout time.Duration               `river:"read_timeout,attr,optional"`
+
+	// Output configures where to send received data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+var (
+	_ receiver.Arguments = Arguments{}
+)
+
+// DefaultArguments holds default settings for otelcol.receiver.datadog.
+var DefaultArguments = Arguments{
+	HTTPServer: otelcol.HTTPServerArguments{
+		Endpoint: "0.0.0.0:8126",
+	},
+	ReadTimeout: 60 * time.Second,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Convert implements receiver.Arguments.
+func (args Arguments) Convert() (otelconfig.Receiver, error) {
+	return &datadogreceiver.Config{
+		ReceiverSettings: otelconfig.NewReceiverSettings(otelconfig.NewComponentID("datadog")),
+
+		HTTPServerSettings: *args.HTTPServer.Convert(),
+		ReadTimeout:        args.ReadTimeout,
+	}, nil
+}
+
+// Extensions implements receiver.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements receiver.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements receiver.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
@@ -0,0 +1,164 @@
+package datadog_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/component/otelcol/receiver/datadog"
+	"github.com/grafana/agent/component/otelcol/receiver/datadog/internal/datadogreceiver"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/grafana/dskit/backoff"
+	"github.com/phayes/freeport"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// Test performs a basic integration test which runs the
+// otelcol.receiver.datadog component and ensures that it can receive and
+// forward traces and metrics.
+func Test(t *testing.T) {
+	httpAddr := getFreeAddr(t)
+
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.receiver.datadog")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		endpoint = "%s"
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`, httpAddr)
+	var args datadog.Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our settings so data gets forwarded to the channels.
+	traceCh := make(chan ptrace.Traces)
+	metricCh := make(chan pmetric.Metrics)
+	args.Output = makeOutput(traceCh, metricCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second))
+
+	send := func(path, body string) {
+		request := func() error {
+			url := fmt.Sprintf("http://%s%s", httpAddr, path)
+			resp, err := http.DefaultClient.Post(url, "application/json", strings.NewReader(body))
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			return nil
+		}
+
+		bo := backoff.New(ctx, backoff.Config{
+			MinBackoff: 10 * time.Millisecond,
+			MaxBackoff: 100 * time.Millisecond,
+		})
+		for bo.Ongoing() {
+			if err := request(); err != nil {
+				level.Error(l).Log("msg", "failed to send data", "err", err)
+				bo.Wait()
+				continue
+			}
+
+			return
+		}
+	}
+
+	go send("/v0.4/traces", `[[
+		{"service": "web", "name": "http.request", "resource": "GET /", "trace_id": 1, "span_id": 2, "start": 1690000000000000000, "duration": 1000, "type": "web"},
+		{"service": "web", "name": "db.query", "resource": "SELECT 1", "trace_id": 1, "span_id": 3, "parent_id": 2, "start": 1690000000000000100, "duration": 500, "type": "db"}
+	]]`)
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for traces")
+	case tr := <-traceCh:
+		require.Equal(t, 2, tr.SpanCount())
+	}
+
+	go send("/api/v1/series", `{"series": [
+		{"metric": "requests", "points": [[1690000000, 3]], "type": "count", "host": "host-1", "tags": ["env:prod"], "interval": 10}
+	]}`)
+
+	select {
+	case <-time.After(time.Second):
+		require.FailNow(t, "failed waiting for metrics")
+	case md := <-metricCh:
+		require.Equal(t, 1, md.DataPointCount())
+	}
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	in := `
+		endpoint = "localhost:8126"
+		read_timeout = "30s"
+
+		output { /* no-op */ }
+	`
+
+	var args datadog.Arguments
+	require.NoError(t, river.Unmarshal([]byte(in), &args))
+	ext, err := args.Convert()
+	require.NoError(t, err)
+	otelArgs, ok := (ext).(*datadogreceiver.Config)
+	require.True(t, ok)
+
+	require.Equal(t, "localhost:8126", otelArgs.HTTPServerSettings.Endpoint)
+	require.Equal(t, 30*time.Second, otelArgs.ReadTimeout)
+}
+
+// makeOutput returns ConsumerArguments which will forward traces and metrics
+// to the provided channels.
+func makeOutput(traceCh chan ptrace.Traces, metricCh chan pmetric.Metrics) *otelcol.ConsumerArguments {
+	consumer := fakeconsumer.Consumer{
+		ConsumeTracesFunc: func(ctx context.Context, t ptrace.Traces) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case traceCh <- t:
+				return nil
+			}
+		},
+		ConsumeMetricsFunc: func(ctx context.Context, m pmetric.Metrics) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case metricCh <- m:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Traces:  []otelcol.Consumer{&consumer},
+		Metrics: []otelcol.Consumer{&consumer},
+	}
+}
+
+func getFreeAddr(t *testing.T) string {
+	t.Helper()
+
+	portNumber, err := freeport.GetFreePort()
+	require.NoError(t, err)
+
+	return fmt.Sprintf("localhost:%d", portNumber)
+}
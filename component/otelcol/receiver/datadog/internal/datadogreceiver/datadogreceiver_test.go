@@ -0,0 +1,184 @@
+package datadogreceiver
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestDecodeTracesV05(t *testing.T) {
+	dict := []string{"web", "http.request", "GET /users", "web", "env", "prod", "error.msg", "boom"}
+	payload := []interface{}{
+		dict,
+		[]interface{}{
+			[]interface{}{
+				[]interface{}{0, 1, 2, uint64(1), uint64(2), uint64(0), int64(100), int64(50), 1, map[interface{}]interface{}{4: 5, 6: 7}, map[interface{}]interface{}{}, 3},
+			},
+		},
+	}
+	var body []byte
+	require.NoError(t, codec.NewEncoderBytes(&body, msgpackHandle).Encode(payload))
+
+	traces, err := decodeTraces("/v0.5/traces", "application/msgpack", body)
+	require.NoError(t, err)
+	require.Equal(t, [][]span{{{
+		Service:  "web",
+		Name:     "http.request",
+		Resource: "GET /users",
+		TraceID:  1,
+		SpanID:   2,
+		Start:    100,
+		Duration: 50,
+		Error:    1,
+		Meta:     map[string]string{"env": "prod", "error.msg": "boom"},
+		Metrics:  map[string]float64{},
+		Type:     "web",
+	}}}, traces)
+
+	_, err = decodeTraces("/v0.5/traces", "application/msgpack", []byte{0x90})
+	require.Error(t, err)
+}
+
+func TestTracesToOTLP(t *testing.T) {
+	traces := [][]span{{
+		{
+			Service: "web", Name: "http.request", Resource: "GET /users",
+			TraceID: 1, SpanID: 2, Start: 100, Duration: 50, Error: 1, Type: "web",
+			Meta: map[string]string{
+				"env":       "prod",
+				"version":   "1.2.3",
+				"_dd.p.tid": "640cfd8d00000000",
+				"error.msg": "boom",
+				"http.url":  "/users",
+			},
+			Metrics: map[string]float64{"_sampling_priority_v1": 1},
+		},
+		{
+			Service: "web", Name: "postgres.query", TraceID: 1, SpanID: 3, ParentID: 2,
+			Start: 110, Duration: 20, Type: "db",
+			Meta: map[string]string{"_dd.p.tid": "640cfd8d00000000"},
+		},
+	}}
+	header := http.Header{}
+	header.Set(headerLang, "go")
+
+	td := tracesToOTLP(traces, header)
+	require.Equal(t, 1, td.ResourceSpans().Len())
+
+	rs := td.ResourceSpans().At(0)
+	require.Equal(t, map[string]interface{}{
+		"service.name":           "web",
+		"service.version":        "1.2.3",
+		"deployment.environment": "prod",
+		"telemetry.sdk.language": "go",
+	}, rs.Resource().Attributes().AsRaw())
+
+	spans := rs.ScopeSpans().At(0).Spans()
+	require.Equal(t, 2, spans.Len())
+
+	root := spans.At(0)
+	require.Equal(t, "640cfd8d000000000000000000000001", root.TraceID().HexString())
+	require.Equal(t, "0000000000000002", root.SpanID().HexString())
+	require.True(t, root.ParentSpanID().IsEmpty())
+	require.Equal(t, "GET /users", root.Name())
+	require.Equal(t, ptrace.SpanKindServer, root.Kind())
+	require.Equal(t, ptrace.StatusCodeError, root.Status().Code())
+	require.Equal(t, "boom", root.Status().Message())
+	require.Equal(t, map[string]interface{}{
+		"dd.span.name":      "http.request",
+		"dd.span.resource":  "GET /users",
+		"dd.span.type":      "web",
+		"error.msg":         "boom",
+		"http.url":          "/users",
+		"sampling.priority": int64(1),
+	}, root.Attributes().AsRaw())
+
+	child := spans.At(1)
+	require.Equal(t, "postgres.query", child.Name())
+	require.Equal(t, "0000000000000002", child.ParentSpanID().HexString())
+	require.Equal(t, ptrace.SpanKindClient, child.Kind())
+	require.Equal(t, ptrace.StatusCodeUnset, child.Status().Code())
+}
+
+func TestDecodeSeriesV2(t *testing.T) {
+	var resource, pt, ser, body []byte
+	resource = protowire.AppendTag(resource, 1, protowire.BytesType)
+	resource = protowire.AppendString(resource, "host")
+	resource = protowire.AppendTag(resource, 2, protowire.BytesType)
+	resource = protowire.AppendString(resource, "host-1")
+
+	pt = protowire.AppendTag(pt, 1, protowire.Fixed64Type)
+	pt = protowire.AppendFixed64(pt, math.Float64bits(4.5))
+	pt = protowire.AppendTag(pt, 2, protowire.VarintType)
+	pt = protowire.AppendVarint(pt, 1690000000)
+
+	ser = protowire.AppendTag(ser, 1, protowire.BytesType)
+	ser = protowire.AppendBytes(ser, resource)
+	ser = protowire.AppendTag(ser, 2, protowire.BytesType)
+	ser = protowire.AppendString(ser, "requests")
+	ser = protowire.AppendTag(ser, 3, protowire.BytesType)
+	ser = protowire.AppendString(ser, "env:prod")
+	ser = protowire.AppendTag(ser, 4, protowire.BytesType)
+	ser = protowire.AppendBytes(ser, pt)
+	ser = protowire.AppendTag(ser, 5, protowire.VarintType)
+	ser = protowire.AppendVarint(ser, 1)
+	ser = protowire.AppendTag(ser, 8, protowire.VarintType)
+	ser = protowire.AppendVarint(ser, 10)
+	// Unknown fields are skipped.
+	ser = protowire.AppendTag(ser, 9, protowire.BytesType)
+	ser = protowire.AppendString(ser, "unknown")
+
+	body = protowire.AppendTag(body, 1, protowire.BytesType)
+	body = protowire.AppendBytes(body, ser)
+
+	res, err := decodeSeriesV2(body)
+	require.NoError(t, err)
+	require.Equal(t, []series{{
+		Metric:   "requests",
+		Type:     typeCount,
+		Host:     "host-1",
+		Tags:     []string{"env:prod"},
+		Interval: 10,
+		Points:   []point{{Timestamp: 1690000000, Value: 4.5}},
+	}}, res)
+
+	_, err = decodeSeriesV2(body[:len(body)-1])
+	require.Error(t, err)
+}
+
+func TestSeriesToOTLP(t *testing.T) {
+	res, err := decodeSeriesV1([]byte(`{"series": [
+		{"metric": "cpu", "points": [[1690000000, 0.5]], "host": "host-1", "tags": ["core:0", "idle"]},
+		{"metric": "requests", "points": [[1690000010, 3]], "type": "count", "host": "host-1", "interval": 10}
+	]}`))
+	require.NoError(t, err)
+
+	md := seriesToOTLP(res)
+	require.Equal(t, 1, md.ResourceMetrics().Len())
+	rm := md.ResourceMetrics().At(0)
+	require.Equal(t, map[string]interface{}{"host.name": "host-1"}, rm.Resource().Attributes().AsRaw())
+
+	metrics := rm.ScopeMetrics().At(0).Metrics()
+	require.Equal(t, 2, metrics.Len())
+
+	gauge := metrics.At(0)
+	require.Equal(t, "cpu", gauge.Name())
+	require.Equal(t, pmetric.MetricTypeGauge, gauge.Type())
+	dp := gauge.Gauge().DataPoints().At(0)
+	require.Equal(t, 0.5, dp.DoubleValue())
+	require.Equal(t, int64(1690000000), dp.Timestamp().AsTime().Unix())
+	require.Equal(t, map[string]interface{}{"core": "0", "idle": ""}, dp.Attributes().AsRaw())
+
+	count := metrics.At(1)
+	require.Equal(t, pmetric.MetricTypeSum, count.Type())
+	require.Equal(t, pmetric.AggregationTemporalityDelta, count.Sum().AggregationTemporality())
+	dp = count.Sum().DataPoints().At(0)
+	require.Equal(t, 3.0, dp.DoubleValue())
+	require.Equal(t, int64(1690000000), dp.StartTimestamp().AsTime().Unix())
+}
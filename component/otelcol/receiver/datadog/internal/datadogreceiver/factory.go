@@ -0,0 +1,89 @@
+// Package datadogreceiver implements an OpenTelemetry Collector receiver
+// accepting traces and metrics sent by Datadog agents and tracing libraries,
+// and converting them to OTLP.
+package datadogreceiver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/config/confighttp"
+	"go.opentelemetry.io/collector/consumer"
+)
+
+const (
+	typeStr   = "datadog"
+	stability = component.StabilityLevelAlpha
+
+	defaultEndpoint    = "0.0.0.0:8126"
+	defaultReadTimeout = 60 * time.Second
+)
+
+// Config configures the receiver.
+type Config struct {
+	config.ReceiverSettings       `mapstructure:",squash"`
+	confighttp.HTTPServerSettings `mapstructure:",squash"`
+
+	// ReadTimeout is the maximum duration for reading a request.
+	ReadTimeout time.Duration `mapstructure:"read_timeout"`
+}
+
+// NewFactory creates a factory for the receiver.
+func NewFactory() component.ReceiverFactory {
+	f := &factory{receivers: make(map[*Config]*datadogReceiver)}
+	return component.NewReceiverFactory(
+		typeStr,
+		createDefaultConfig,
+		component.WithTracesReceiver(f.createTracesReceiver, stability),
+		component.WithMetricsReceiver(f.createMetricsReceiver, stability),
+	)
+}
+
+func createDefaultConfig() config.Receiver {
+	return &Config{
+		ReceiverSettings: config.NewReceiverSettings(config.NewComponentID(typeStr)),
+		HTTPServerSettings: confighttp.HTTPServerSettings{
+			Endpoint: defaultEndpoint,
+		},
+		ReadTimeout: defaultReadTimeout,
+	}
+}
+
+// factory shares a single receiver between the traces and metrics receivers
+// created for the same config, since both are served by the same HTTP
+// server.
+type factory struct {
+	mut       sync.Mutex
+	receivers map[*Config]*datadogReceiver
+}
+
+func (f *factory) createTracesReceiver(_ context.Context, set component.ReceiverCreateSettings, cfg config.Receiver, next consumer.Traces) (component.TracesReceiver, error) {
+	r := f.receiver(set, cfg.(*Config))
+	r.nextTraces = next
+	return r, nil
+}
+
+func (f *factory) createMetricsReceiver(_ context.Context, set component.ReceiverCreateSettings, cfg config.Receiver, next consumer.Metrics) (component.MetricsReceiver, error) {
+	r := f.receiver(set, cfg.(*Config))
+	r.nextMetrics = next
+	return r, nil
+}
+
+func (f *factory) receiver(set component.ReceiverCreateSettings, cfg *Config) *datadogReceiver {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if r, ok := f.receivers[cfg]; ok {
+		return r
+	}
+	r := newReceiver(cfg, set, func() {
+		f.mut.Lock()
+		defer f.mut.Unlock()
+		delete(f.receivers, cfg)
+	})
+	f.receivers[cfg] = r
+	return r
+}
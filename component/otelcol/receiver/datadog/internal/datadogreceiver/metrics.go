@@ -0,0 +1,272 @@
+package datadogreceiver
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Types of series.
+const (
+	typeGauge = "gauge"
+	typeCount = "count"
+	typeRate  = "rate"
+)
+
+// series is a series of the Datadog metrics API.
+type series struct {
+	Metric   string
+	Type     string
+	Host     string
+	Tags     []string
+	Unit     string
+	Interval int64
+	Points   []point
+}
+
+type point struct {
+	// Timestamp is the time of the point, in seconds since the epoch.
+	Timestamp int64
+	Value     float64
+}
+
+// decodeSeriesV1 decodes the JSON payload of the v1 series endpoint.
+func decodeSeriesV1(body []byte) ([]series, error) {
+	var payload struct {
+		Series []struct {
+			Metric   string       `json:"metric"`
+			Points   [][2]float64 `json:"points"`
+			Type     string       `json:"type"`
+			Host     string       `json:"host"`
+			Tags     []string     `json:"tags"`
+			Interval int64        `json:"interval"`
+		} `json:"series"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decoding series: %w", err)
+	}
+
+	res := make([]series, 0, len(payload.Series))
+	for _, s := range payload.Series {
+		ser := series{
+			Metric:   s.Metric,
+			Type:     s.Type,
+			Host:     s.Host,
+			Tags:     s.Tags,
+			Interval: s.Interval,
+			Points:   make([]point, 0, len(s.Points)),
+		}
+		if ser.Type == "" {
+			ser.Type = typeGauge
+		}
+		for _, p := range s.Points {
+			ser.Points = append(ser.Points, point{Timestamp: int64(p[0]), Value: p[1]})
+		}
+		res = append(res, ser)
+	}
+	return res, nil
+}
+
+// decodeSeriesV2 decodes the protobuf payload of the v2 series endpoint. The
+// payload is decoded by hand, since only a few of its fields are used:
+//
+//	message MetricPayload {
+//	  repeated MetricSeries series = 1;
+//	}
+//	message MetricSeries {
+//	  repeated Resource resources = 1;
+//	  string metric = 2;
+//	  repeated string tags = 3;
+//	  repeated MetricPoint points = 4;
+//	  MetricType type = 5;
+//	  string unit = 6;
+//	  int64 interval = 8;
+//	}
+//	message Resource { string type = 1; string name = 2; }
+//	message MetricPoint { double value = 1; int64 timestamp = 2; }
+func decodeSeriesV2(body []byte) ([]series, error) {
+	var res []series
+	err := decodeMessage(body, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		s, err := decodeSeriesMessage(b)
+		if err != nil {
+			return err
+		}
+		res = append(res, s)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decoding series: %w", err)
+	}
+	return res, nil
+}
+
+func decodeSeriesMessage(b []byte) (series, error) {
+	// Series without a type are gauges.
+	s := series{Type: typeGauge}
+	err := decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			var resourceType, name string
+			err := decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					resourceType = string(b)
+				case num == 2 && typ == protowire.BytesType:
+					name = string(b)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if resourceType == "host" {
+				s.Host = name
+			}
+		case num == 2 && typ == protowire.BytesType:
+			s.Metric = string(b)
+		case num == 3 && typ == protowire.BytesType:
+			s.Tags = append(s.Tags, string(b))
+		case num == 4 && typ == protowire.BytesType:
+			var p point
+			err := decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) error {
+				switch {
+				case num == 1 && typ == protowire.Fixed64Type:
+					v, _ := protowire.ConsumeFixed64(b)
+					p.Value = math.Float64frombits(v)
+				case num == 2 && typ == protowire.VarintType:
+					v, _ := protowire.ConsumeVarint(b)
+					p.Timestamp = int64(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			s.Points = append(s.Points, p)
+		case num == 5 && typ == protowire.VarintType:
+			v, _ := protowire.ConsumeVarint(b)
+			switch v {
+			case 1:
+				s.Type = typeCount
+			case 2:
+				s.Type = typeRate
+			}
+		case num == 6 && typ == protowire.BytesType:
+			s.Unit = string(b)
+		case num == 8 && typ == protowire.VarintType:
+			v, _ := protowire.ConsumeVarint(b)
+			s.Interval = int64(v)
+		}
+		return nil
+	})
+	return s, err
+}
+
+// decodeMessage calls fn for every field of the protobuf message b. For
+// length-delimited fields, fn is given the contents of the field; for other
+// fields, it's given their encoded value.
+func decodeMessage(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var value []byte
+		if typ == protowire.BytesType {
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value, b = v, b[n:]
+		} else {
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			value, b = b[:n], b[n:]
+		}
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seriesToOTLP converts series to OTLP. Series are grouped into a resource
+// for every host. Gauges and rates are converted to gauges, and counts to
+// delta sums.
+func seriesToOTLP(ss []series) pmetric.Metrics {
+	byHost := make(map[string][]series)
+	for _, s := range ss {
+		byHost[s.Host] = append(byHost[s.Host], s)
+	}
+	hosts := make([]string, 0, len(byHost))
+	for host := range byHost {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	md := pmetric.NewMetrics()
+	for _, host := range hosts {
+		rm := md.ResourceMetrics().AppendEmpty()
+		if host != "" {
+			rm.Resource().Attributes().PutStr(semconv.AttributeHostName, host)
+		}
+		sm := rm.ScopeMetrics().AppendEmpty()
+		sm.Scope().SetName("datadog")
+
+		for _, s := range byHost[host] {
+			m := sm.Metrics().AppendEmpty()
+			m.SetName(s.Metric)
+			m.SetUnit(s.Unit)
+
+			var dps pmetric.NumberDataPointSlice
+			switch s.Type {
+			case typeCount:
+				sum := m.SetEmptySum()
+				sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+				sum.SetIsMonotonic(false)
+				dps = sum.DataPoints()
+			default:
+				dps = m.SetEmptyGauge().DataPoints()
+			}
+
+			for _, p := range s.Points {
+				dp := dps.AppendEmpty()
+				ts := time.Unix(p.Timestamp, 0)
+				dp.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+				if s.Type == typeCount && s.Interval > 0 {
+					dp.SetStartTimestamp(pcommon.NewTimestampFromTime(ts.Add(-time.Duration(s.Interval) * time.Second)))
+				}
+				dp.SetDoubleValue(p.Value)
+				tagsToAttributes(s.Tags, dp.Attributes())
+			}
+		}
+	}
+	return md
+}
+
+// tagsToAttributes converts the key:value tags of a series to attributes.
+// Tags without a value are kept with an empty value.
+func tagsToAttributes(tags []string, attrs pcommon.Map) {
+	for _, tag := range tags {
+		k, v, _ := strings.Cut(tag, ":")
+		if k == "" {
+			continue
+		}
+		attrs.PutStr(k, v)
+	}
+}
@@ -0,0 +1,239 @@
+package datadogreceiver
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/obsreport"
+	"go.uber.org/zap"
+)
+
+// traceEndpoints are the endpoints of the trace API served by the receiver.
+var traceEndpoints = []string{"/v0.3/traces", "/v0.4/traces", "/v0.5/traces"}
+
+type datadogReceiver struct {
+	cfg      *Config
+	settings component.ReceiverCreateSettings
+	onStop   func()
+
+	nextTraces  consumer.Traces
+	nextMetrics consumer.Metrics
+
+	startOnce, stopOnce sync.Once
+	startErr, stopErr   error
+	server              *http.Server
+	wg                  sync.WaitGroup
+}
+
+var (
+	_ component.TracesReceiver  = (*datadogReceiver)(nil)
+	_ component.MetricsReceiver = (*datadogReceiver)(nil)
+)
+
+func newReceiver(cfg *Config, set component.ReceiverCreateSettings, onStop func()) *datadogReceiver {
+	return &datadogReceiver{cfg: cfg, settings: set, onStop: onStop}
+}
+
+// Start implements component.Component. The receiver is shared by the traces
+// and metrics pipelines, so it's only started once.
+func (r *datadogReceiver) Start(_ context.Context, host component.Host) error {
+	r.startOnce.Do(func() {
+		r.startErr = r.start(host)
+	})
+	return r.startErr
+}
+
+func (r *datadogReceiver) start(host component.Host) error {
+	mux := http.NewServeMux()
+	for _, endpoint := range traceEndpoints {
+		mux.HandleFunc(endpoint, r.handleTraces)
+	}
+	mux.HandleFunc("/info", r.handleInfo)
+	mux.HandleFunc("/api/v1/series", r.handleSeries)
+	mux.HandleFunc("/api/v2/series", r.handleSeries)
+	mux.HandleFunc("/api/v1/validate", r.handleValidate)
+	// Payloads which can't be converted are accepted and dropped, so that
+	// agents don't keep retrying them.
+	mux.HandleFunc("/api/v1/check_run", r.handleDiscard)
+	mux.HandleFunc("/api/beta/sketches", r.handleDiscard)
+	mux.HandleFunc("/intake/", r.handleDiscard)
+
+	var err error
+	r.server, err = r.cfg.HTTPServerSettings.ToServer(host, r.settings.TelemetrySettings, mux)
+	if err != nil {
+		return err
+	}
+	r.server.ReadTimeout = r.cfg.ReadTimeout
+
+	listener, err := r.cfg.HTTPServerSettings.ToListener()
+	if err != nil {
+		return err
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		if err := r.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			host.ReportFatalError(err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown implements component.Component.
+func (r *datadogReceiver) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() {
+		if r.onStop != nil {
+			r.onStop()
+		}
+		if r.server != nil {
+			r.stopErr = r.server.Shutdown(ctx)
+			r.wg.Wait()
+		}
+	})
+	return r.stopErr
+}
+
+func (r *datadogReceiver) handleTraces(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost && req.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	obsrecv := obsreport.NewReceiver(obsreport.ReceiverSettings{
+		ReceiverID:             r.cfg.ID(),
+		Transport:              "http",
+		ReceiverCreateSettings: r.settings,
+	})
+	ctx := obsrecv.StartTracesOp(req.Context())
+
+	body, err := readBody(req)
+	if err != nil {
+		obsrecv.EndTracesOp(ctx, typeStr, 0, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	traces, err := decodeTraces(req.URL.Path, req.Header.Get("Content-Type"), body)
+	if err != nil {
+		r.settings.Logger.Debug("failed to decode traces", zap.String("path", req.URL.Path), zap.Error(err))
+		obsrecv.EndTracesOp(ctx, typeStr, 0, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	td := tracesToOTLP(traces, req.Header)
+
+	err = r.nextTraces.ConsumeTraces(ctx, td)
+	obsrecv.EndTracesOp(ctx, typeStr, td.SpanCount(), err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	if req.URL.Path == "/v0.3/traces" {
+		_, _ = io.WriteString(w, "OK")
+		return
+	}
+	// Tracers adjust their sampling to the rates of the response. All
+	// traces are kept, since sampling is left to the pipeline.
+	writeJSON(w, http.StatusOK, map[string]interface{}{"rate_by_service": map[string]float64{}})
+}
+
+func (r *datadogReceiver) handleInfo(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"version":         r.settings.BuildInfo.Version,
+		"endpoints":       traceEndpoints,
+		"client_drop_p0s": false,
+	})
+}
+
+func (r *datadogReceiver) handleSeries(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	obsrecv := obsreport.NewReceiver(obsreport.ReceiverSettings{
+		ReceiverID:             r.cfg.ID(),
+		Transport:              "http",
+		ReceiverCreateSettings: r.settings,
+	})
+	ctx := obsrecv.StartMetricsOp(req.Context())
+
+	body, err := readBody(req)
+	if err != nil {
+		obsrecv.EndMetricsOp(ctx, typeStr, 0, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var series []series
+	if req.URL.Path == "/api/v2/series" {
+		series, err = decodeSeriesV2(body)
+	} else {
+		series, err = decodeSeriesV1(body)
+	}
+	if err != nil {
+		r.settings.Logger.Debug("failed to decode series", zap.String("path", req.URL.Path), zap.Error(err))
+		obsrecv.EndMetricsOp(ctx, typeStr, 0, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	md := seriesToOTLP(series)
+
+	err = r.nextMetrics.ConsumeMetrics(ctx, md)
+	obsrecv.EndMetricsOp(ctx, typeStr, md.DataPointCount(), err)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "ok"})
+}
+
+func (r *datadogReceiver) handleValidate(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]bool{"valid": true})
+}
+
+func (r *datadogReceiver) handleDiscard(w http.ResponseWriter, req *http.Request) {
+	_, _ = io.Copy(io.Discard, req.Body)
+	r.settings.Logger.Debug("dropped unsupported payload", zap.String("path", req.URL.Path))
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "ok"})
+}
+
+// readBody reads the body of req, decompressing it according to its
+// Content-Encoding.
+func readBody(req *http.Request) ([]byte, error) {
+	defer req.Body.Close()
+
+	var body io.Reader = req.Body
+	switch enc := req.Header.Get("Content-Encoding"); enc {
+	case "", "identity":
+	case "gzip":
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing body: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	case "deflate":
+		zr, err := zlib.NewReader(req.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing body: %w", err)
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		return nil, fmt.Errorf("unsupported Content-Encoding %q", enc)
+	}
+	return io.ReadAll(body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
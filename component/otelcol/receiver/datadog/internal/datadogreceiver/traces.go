@@ -0,0 +1,346 @@
+package datadogreceiver
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-msgpack/codec"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+)
+
+// span is a span of the Datadog trace API.
+type span struct {
+	Service  string             `codec:"service" json:"service"`
+	Name     string             `codec:"name" json:"name"`
+	Resource string             `codec:"resource" json:"resource"`
+	TraceID  uint64             `codec:"trace_id" json:"trace_id"`
+	SpanID   uint64             `codec:"span_id" json:"span_id"`
+	ParentID uint64             `codec:"parent_id" json:"parent_id"`
+	Start    int64              `codec:"start" json:"start"`
+	Duration int64              `codec:"duration" json:"duration"`
+	Error    int32              `codec:"error" json:"error"`
+	Meta     map[string]string  `codec:"meta" json:"meta"`
+	Metrics  map[string]float64 `codec:"metrics" json:"metrics"`
+	Type     string             `codec:"type" json:"type"`
+}
+
+var msgpackHandle = &codec.MsgpackHandle{RawToString: true}
+
+// decodeTraces decodes the body of a request to the trace endpoint at path.
+// Traces are lists of spans.
+func decodeTraces(path, contentType string, body []byte) ([][]span, error) {
+	if path == "/v0.5/traces" {
+		return decodeTracesV05(body)
+	}
+
+	var traces [][]span
+	if strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "text/json") {
+		if err := json.Unmarshal(body, &traces); err != nil {
+			return nil, fmt.Errorf("decoding traces: %w", err)
+		}
+		return traces, nil
+	}
+	if err := codec.NewDecoderBytes(body, msgpackHandle).Decode(&traces); err != nil {
+		return nil, fmt.Errorf("decoding traces: %w", err)
+	}
+	return traces, nil
+}
+
+// decodeTracesV05 decodes the v0.5 format of the trace API, where the strings
+// of spans are indices in a dictionary sent along with the traces:
+//
+//	[dictionary []string, traces [][][12]interface{}]
+//
+// The fields of spans are, in order: service, name, resource, trace_id,
+// span_id, parent_id, start, duration, error, meta, metrics, and type.
+func decodeTracesV05(body []byte) ([][]span, error) {
+	var payload []interface{}
+	if err := codec.NewDecoderBytes(body, msgpackHandle).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decoding traces: %w", err)
+	}
+	if len(payload) != 2 {
+		return nil, fmt.Errorf("decoding traces: expected a dictionary and traces, got %d elements", len(payload))
+	}
+
+	rawDict, ok := payload[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("decoding traces: malformed dictionary")
+	}
+	dict := make([]string, len(rawDict))
+	for i, s := range rawDict {
+		if dict[i], ok = s.(string); !ok {
+			return nil, fmt.Errorf("decoding traces: malformed dictionary")
+		}
+	}
+	lookup := func(v interface{}) (string, error) {
+		i, ok := toUint64(v)
+		if !ok || i >= uint64(len(dict)) {
+			return "", fmt.Errorf("invalid dictionary index %v", v)
+		}
+		return dict[i], nil
+	}
+
+	rawTraces, ok := payload[1].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("decoding traces: malformed traces")
+	}
+	traces := make([][]span, 0, len(rawTraces))
+	for _, rawTrace := range rawTraces {
+		rawSpans, ok := rawTrace.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("decoding traces: malformed trace")
+		}
+		trace := make([]span, 0, len(rawSpans))
+		for _, rawSpan := range rawSpans {
+			s, err := decodeSpanV05(rawSpan, lookup)
+			if err != nil {
+				return nil, fmt.Errorf("decoding traces: %w", err)
+			}
+			trace = append(trace, s)
+		}
+		traces = append(traces, trace)
+	}
+	return traces, nil
+}
+
+func decodeSpanV05(raw interface{}, lookup func(interface{}) (string, error)) (span, error) {
+	fields, ok := raw.([]interface{})
+	if !ok || len(fields) != 12 {
+		return span{}, fmt.Errorf("malformed span")
+	}
+
+	var (
+		s   span
+		err error
+	)
+	for _, f := range []struct {
+		dst   *string
+		index int
+	}{{&s.Service, 0}, {&s.Name, 1}, {&s.Resource, 2}, {&s.Type, 11}} {
+		if *f.dst, err = lookup(fields[f.index]); err != nil {
+			return span{}, err
+		}
+	}
+	var okIDs [3]bool
+	s.TraceID, okIDs[0] = toUint64(fields[3])
+	s.SpanID, okIDs[1] = toUint64(fields[4])
+	s.ParentID, okIDs[2] = toUint64(fields[5])
+	start, okStart := toUint64(fields[6])
+	duration, okDuration := toUint64(fields[7])
+	errCode, okErr := toUint64(fields[8])
+	if !okIDs[0] || !okIDs[1] || !okIDs[2] || !okStart || !okDuration || !okErr {
+		return span{}, fmt.Errorf("malformed span")
+	}
+	s.Start, s.Duration, s.Error = int64(start), int64(duration), int32(errCode)
+
+	if meta, ok := fields[9].(map[interface{}]interface{}); ok {
+		s.Meta = make(map[string]string, len(meta))
+		for k, v := range meta {
+			key, err := lookup(k)
+			if err != nil {
+				return span{}, err
+			}
+			if s.Meta[key], err = lookup(v); err != nil {
+				return span{}, err
+			}
+		}
+	}
+	if metrics, ok := fields[10].(map[interface{}]interface{}); ok {
+		s.Metrics = make(map[string]float64, len(metrics))
+		for k, v := range metrics {
+			key, err := lookup(k)
+			if err != nil {
+				return span{}, err
+			}
+			f, ok := toFloat64(v)
+			if !ok {
+				return span{}, fmt.Errorf("malformed metric %q", key)
+			}
+			s.Metrics[key] = f
+		}
+	}
+	return s, nil
+}
+
+func toUint64(v interface{}) (uint64, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return v, true
+	case int64:
+		return uint64(v), v >= 0
+	default:
+		return 0, false
+	}
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// Headers describing the tracing library sending traces.
+const (
+	headerLang          = "Datadog-Meta-Lang"
+	headerLangVersion   = "Datadog-Meta-Lang-Version"
+	headerTracerVersion = "Datadog-Meta-Tracer-Version"
+)
+
+// tracesToOTLP converts traces to OTLP. Spans are grouped into a resource for
+// every service.
+func tracesToOTLP(traces [][]span, header http.Header) ptrace.Traces {
+	byService := make(map[string][]span)
+	for _, trace := range traces {
+		for _, s := range trace {
+			byService[s.Service] = append(byService[s.Service], s)
+		}
+	}
+	services := make([]string, 0, len(byService))
+	for service := range byService {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	td := ptrace.NewTraces()
+	for _, service := range services {
+		rs := td.ResourceSpans().AppendEmpty()
+		attrs := rs.Resource().Attributes()
+		attrs.PutStr(semconv.AttributeServiceName, service)
+		if lang := header.Get(headerLang); lang != "" {
+			attrs.PutStr(semconv.AttributeTelemetrySDKLanguage, lang)
+		}
+		if v := header.Get(headerLangVersion); v != "" {
+			attrs.PutStr("process.runtime.version", v)
+		}
+		if v := header.Get(headerTracerVersion); v != "" {
+			attrs.PutStr(semconv.AttributeTelemetrySDKVersion, v)
+		}
+
+		spans := byService[service]
+		// Resource attributes are taken from the first span holding them.
+		for _, s := range spans {
+			if env := s.Meta["env"]; env != "" {
+				attrs.PutStr(semconv.AttributeDeploymentEnvironment, env)
+				break
+			}
+		}
+		for _, s := range spans {
+			if version := s.Meta["version"]; version != "" {
+				attrs.PutStr(semconv.AttributeServiceVersion, version)
+				break
+			}
+		}
+
+		ss := rs.ScopeSpans().AppendEmpty()
+		ss.Scope().SetName("datadog")
+		for _, s := range spans {
+			spanToOTLP(s, ss.Spans().AppendEmpty())
+		}
+	}
+	return td
+}
+
+func spanToOTLP(s span, dst ptrace.Span) {
+	dst.SetTraceID(traceID(s))
+	dst.SetSpanID(spanID(s.SpanID))
+	if s.ParentID != 0 {
+		dst.SetParentSpanID(spanID(s.ParentID))
+	}
+
+	name := s.Resource
+	if name == "" {
+		name = s.Name
+	}
+	dst.SetName(name)
+	dst.SetKind(spanKind(s))
+	dst.SetStartTimestamp(pcommon.Timestamp(s.Start))
+	dst.SetEndTimestamp(pcommon.Timestamp(s.Start + s.Duration))
+
+	attrs := dst.Attributes()
+	attrs.PutStr("dd.span.name", s.Name)
+	attrs.PutStr("dd.span.resource", s.Resource)
+	if s.Type != "" {
+		attrs.PutStr("dd.span.type", s.Type)
+	}
+	for k, v := range s.Meta {
+		switch k {
+		case "env", "version", "span.kind", "_dd.p.tid":
+			continue
+		}
+		attrs.PutStr(k, v)
+	}
+	for k, v := range s.Metrics {
+		if k == "_sampling_priority_v1" {
+			attrs.PutInt("sampling.priority", int64(v))
+			continue
+		}
+		attrs.PutDouble(k, v)
+	}
+
+	if s.Error != 0 {
+		dst.Status().SetCode(ptrace.StatusCodeError)
+		for _, k := range []string{"error.message", "error.msg"} {
+			if msg := s.Meta[k]; msg != "" {
+				dst.Status().SetMessage(msg)
+				break
+			}
+		}
+	}
+}
+
+// traceID returns the 128-bit trace ID of s. The higher 64 bits are held by
+// the _dd.p.tid tag.
+func traceID(s span) pcommon.TraceID {
+	var id [16]byte
+	if high, err := hex.DecodeString(s.Meta["_dd.p.tid"]); err == nil && len(high) == 8 {
+		copy(id[:8], high)
+	}
+	binary.BigEndian.PutUint64(id[8:], s.TraceID)
+	return pcommon.TraceID(id)
+}
+
+func spanID(id uint64) pcommon.SpanID {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+	return pcommon.SpanID(b)
+}
+
+func spanKind(s span) ptrace.SpanKind {
+	switch s.Meta["span.kind"] {
+	case "server":
+		return ptrace.SpanKindServer
+	case "client":
+		return ptrace.SpanKindClient
+	case "producer":
+		return ptrace.SpanKindProducer
+	case "consumer":
+		return ptrace.SpanKindConsumer
+	case "internal":
+		return ptrace.SpanKindInternal
+	}
+
+	switch s.Type {
+	case "web":
+		return ptrace.SpanKindServer
+	case "http", "grpc", "db", "sql", "cache", "redis", "memcached", "mongodb", "elasticsearch", "cassandra":
+		return ptrace.SpanKindClient
+	default:
+		return ptrace.SpanKindInternal
+	}
+}
@@ -0,0 +1,286 @@
+// Package filelog provides an otelcol.receiver.filelog component.
+package filelog
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/receiver"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/adapter"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/helper"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "otelcol.receiver.filelog",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.receiver.filelog component.
+type Arguments struct {
+	Include []string `river:"include,attr"`
+	Exclude []string `river:"exclude,attr,optional"`
+
+	StartAt            string           `river:"start_at,attr,optional"`
+	PollInterval       time.Duration    `river:"poll_interval,attr,optional"`
+	FingerprintSize    units.Base2Bytes `river:"fingerprint_size,attr,optional"`
+	MaxLogSize         units.Base2Bytes `river:"max_log_size,attr,optional"`
+	MaxConcurrentFiles int              `river:"max_concurrent_files,attr,optional"`
+
+	IncludeFileName         bool `river:"include_file_name,attr,optional"`
+	IncludeFilePath         bool `river:"include_file_path,attr,optional"`
+	IncludeFileNameResolved bool `river:"include_file_name_resolved,attr,optional"`
+	IncludeFilePathResolved bool `river:"include_file_path_resolved,attr,optional"`
+
+	Encoding         string              `river:"encoding,attr,optional"`
+	ForceFlushPeriod time.Duration       `river:"force_flush_period,attr,optional"`
+	Multiline        *MultilineArguments `river:"multiline,block,optional"`
+
+	Attributes map[string]string `river:"attributes,attr,optional"`
+	Resource   map[string]string `river:"resource,attr,optional"`
+
+	// Operators holds the configuration of the stanza operators processing
+	// the read log lines, in order. Each operator is a map holding the
+	// upstream configuration of the operator, including its type.
+	Operators []map[string]interface{} `river:"operators,attr,optional"`
+
+	// Output configures where to send received data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// MultilineArguments configures how log lines spanning several lines of a
+// file are split.
+type MultilineArguments struct {
+	LineStartPattern string `river:"line_start_pattern,attr,optional"`
+	LineEndPattern   string `river:"line_end_pattern,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (args *MultilineArguments) Validate() error {
+	if (args.LineStartPattern == "") == (args.LineEndPattern == "") {
+		return fmt.Errorf("exactly one of line_start_pattern and line_end_pattern must be set")
+	}
+	return nil
+}
+
+var (
+	_ receiver.Arguments = Arguments{}
+)
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	StartAt:            "end",
+	PollInterval:       200 * time.Millisecond,
+	FingerprintSize:    1000,
+	MaxLogSize:         1 * units.MiB,
+	MaxConcurrentFiles: 1024,
+	IncludeFileName:    true,
+	Encoding:           "utf-8",
+	ForceFlushPeriod:   500 * time.Millisecond,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if len(args.Include) == 0 {
+		return fmt.Errorf("include must not be empty")
+	}
+	if args.StartAt != "beginning" && args.StartAt != "end" {
+		return fmt.Errorf(`start_at must be "beginning" or "end", got %q`, args.StartAt)
+	}
+
+	cfg, err := args.Convert()
+	if err != nil {
+		return err
+	}
+
+	// Building the operators validates them without starting them.
+	conv := cfg.(*receiverConfig)
+	for _, op := range append([]operator.Config{operator.NewConfig(&conv.InputConfig)}, conv.Operators...) {
+		if _, err := op.Build(nopLogger); err != nil {
+			return fmt.Errorf("invalid operator %q: %w", op.ID(), err)
+		}
+	}
+	return nil
+}
+
+// Convert implements receiver.Arguments.
+func (args Arguments) Convert() (otelconfig.Receiver, error) {
+	input := newInputConfig()
+	input.Include = args.Include
+	input.Exclude = args.Exclude
+	input.StartAt = args.StartAt
+	input.PollInterval = args.PollInterval
+	input.FingerprintSize = int(args.FingerprintSize)
+	input.MaxLogSize = int(args.MaxLogSize)
+	input.MaxConcurrentFiles = args.MaxConcurrentFiles
+	input.IncludeFileName = args.IncludeFileName
+	input.IncludeFilePath = args.IncludeFilePath
+	input.IncludeFileNameResolved = args.IncludeFileNameResolved
+	input.IncludeFilePathResolved = args.IncludeFilePathResolved
+	input.Splitter.EncodingConfig.Encoding = args.Encoding
+	input.Splitter.Flusher.Period = args.ForceFlushPeriod
+	if args.Multiline != nil {
+		input.Splitter.Multiline.LineStartPattern = args.Multiline.LineStartPattern
+		input.Splitter.Multiline.LineEndPattern = args.Multiline.LineEndPattern
+	}
+	input.Attributes = convertExprs(args.Attributes)
+	input.Resource = convertExprs(args.Resource)
+
+	operators := make([]operator.Config, 0, len(args.Operators))
+	for i, raw := range args.Operators {
+		var op operator.Config
+		if err := op.Unmarshal(confmap.NewFromStringMap(raw)); err != nil {
+			return nil, fmt.Errorf("operator %d: %w", i, err)
+		}
+		operators = append(operators, op)
+	}
+
+	return &receiverConfig{
+		BaseConfig: adapter.BaseConfig{
+			ReceiverSettings: otelconfig.NewReceiverSettings(otelconfig.NewComponentID(typeStr)),
+			Operators:        operators,
+		},
+		InputConfig: *input,
+	}, nil
+}
+
+func convertExprs(in map[string]string) map[string]helper.ExprStringConfig {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]helper.ExprStringConfig, len(in))
+	for k, v := range in {
+		out[k] = helper.ExprStringConfig(v)
+	}
+	return out
+}
+
+// Extensions implements receiver.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements receiver.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements receiver.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
+
+// Component is the otelcol.receiver.filelog component. It wraps the receiver
+// to persist the offsets of read files in the data directory of the
+// component, so that files aren't read again after a restart.
+type Component struct {
+	*receiver.Receiver
+
+	storage *fileStorage
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new otelcol.receiver.filelog component.
+func New(opts component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		storage: newFileStorage(filepath.Join(opts.DataPath, "checkpoints.json")),
+	}
+
+	r, err := receiver.New(opts, adapter.NewFactory(receiverType{}, otelcomponent.StabilityLevelBeta), c.withStorage(args))
+	if err != nil {
+		return nil, err
+	}
+	c.Receiver = r
+	return c, nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	return c.Receiver.Update(c.withStorage(args.(Arguments)))
+}
+
+func (c *Component) withStorage(args Arguments) receiver.Arguments {
+	return storageArguments{Arguments: args, storage: c.storage}
+}
+
+// storageID is the ID the checkpoint storage is exposed to the receiver as.
+var storageID = otelconfig.NewComponentID("filelog_checkpoints")
+
+// storageArguments configures the receiver to persist checkpoints to
+// storage.
+type storageArguments struct {
+	Arguments
+	storage *fileStorage
+}
+
+// Convert implements receiver.Arguments.
+func (args storageArguments) Convert() (otelconfig.Receiver, error) {
+	cfg, err := args.Arguments.Convert()
+	if err != nil {
+		return nil, err
+	}
+	cfg.(*receiverConfig).StorageID = &storageID
+	return cfg, nil
+}
+
+// Extensions implements receiver.Arguments.
+func (args storageArguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return map[otelconfig.ComponentID]otelcomponent.Extension{storageID: args.storage}
+}
+
+// typeStr is the type of the upstream receiver.
+const typeStr = "filelog"
+
+// nopLogger is used to build operators when validating them.
+var nopLogger = zap.NewNop().Sugar()
+
+// receiverConfig is the configuration of the stanza-based receiver reading
+// log files.
+type receiverConfig struct {
+	adapter.BaseConfig
+	InputConfig inputConfig
+}
+
+// receiverType implements adapter.LogReceiverType for receiverConfig.
+type receiverType struct{}
+
+var _ adapter.LogReceiverType = receiverType{}
+
+func (receiverType) Type() otelconfig.Type { return typeStr }
+
+func (receiverType) CreateDefaultConfig() otelconfig.Receiver {
+	return &receiverConfig{
+		BaseConfig: adapter.BaseConfig{
+			ReceiverSettings: otelconfig.NewReceiverSettings(otelconfig.NewComponentID(typeStr)),
+		},
+		InputConfig: *newInputConfig(),
+	}
+}
+
+func (receiverType) BaseConfig(cfg otelconfig.Receiver) adapter.BaseConfig {
+	return cfg.(*receiverConfig).BaseConfig
+}
+
+func (receiverType) InputConfig(cfg otelconfig.Receiver) operator.Config {
+	return operator.NewConfig(&cfg.(*receiverConfig).InputConfig)
+}
@@ -0,0 +1,216 @@
+package filelog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func Test(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	writeLines(t, logPath,
+		"2023-01-02 level=info msg=started",
+		"2023-01-02 level=error msg=failed",
+		"  stack trace line",
+	)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.receiver.filelog")
+	require.NoError(t, err)
+
+	cfg := fmt.Sprintf(`
+		include       = [%q]
+		start_at      = "beginning"
+		poll_interval = "10ms"
+
+		multiline {
+			line_start_pattern = "^\\d{4}-"
+		}
+
+		operators = [{
+			type  = "regex_parser",
+			regex = "^(?P<date>\\S+) level=(?P<level>\\w+) msg=(?P<msg>(?s:.*))$",
+		}]
+
+		output {
+			// no-op: will be overridden by test code.
+		}
+	`, logPath)
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+	// Override our settings so logs get forwarded to logCh.
+	logCh := make(chan plog.Logs, 10)
+	args.Output = makeLogsOutput(logCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second))
+
+	// The second entry is only complete once the next entry starts.
+	writeLines(t, logPath, "2023-01-03 level=info msg=recovered")
+
+	var records []map[string]interface{}
+	for len(records) < 3 {
+		select {
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "failed waiting for log entries", "got %v", records)
+		case logs := <-logCh:
+			rls := logs.ResourceLogs()
+			for i := 0; i < rls.Len(); i++ {
+				sls := rls.At(i).ScopeLogs()
+				for j := 0; j < sls.Len(); j++ {
+					lrs := sls.At(j).LogRecords()
+					for k := 0; k < lrs.Len(); k++ {
+						records = append(records, lrs.At(k).Attributes().AsRaw())
+					}
+				}
+			}
+		}
+	}
+
+	require.Equal(t, "started", records[0]["msg"])
+	require.Equal(t, "error", records[1]["level"])
+	require.Equal(t, "failed\n  stack trace line", records[1]["msg"])
+	require.Equal(t, "app.log", records[1]["log.file.name"])
+	require.Equal(t, "2023-01-03", records[2]["date"])
+}
+
+func TestFileStorage(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "checkpoints.json")
+
+	s := newFileStorage(path)
+	client, err := s.GetClient(ctx, 0, storageID, "")
+	require.NoError(t, err)
+	require.NoError(t, client.Set(ctx, "a", []byte("1")))
+	require.NoError(t, client.Set(ctx, "b", []byte("2")))
+	require.NoError(t, client.Delete(ctx, "b"))
+	require.NoError(t, client.Close(ctx))
+
+	// Checkpoints should survive a restart.
+	client, err = newFileStorage(path).GetClient(ctx, 0, storageID, "")
+	require.NoError(t, err)
+
+	value, err := client.Get(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), value)
+
+	value, err = client.Get(ctx, "b")
+	require.NoError(t, err)
+	require.Nil(t, value)
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "defaults",
+			cfg: `
+				include = ["/var/log/*.log"]
+				output {}
+			`,
+		},
+		{
+			name: "no include",
+			cfg: `
+				include = []
+				output {}
+			`,
+			expect: "include must not be empty",
+		},
+		{
+			name: "invalid start_at",
+			cfg: `
+				include  = ["/var/log/*.log"]
+				start_at = "middle"
+				output {}
+			`,
+			expect: `start_at must be "beginning" or "end", got "middle"`,
+		},
+		{
+			name: "both multiline patterns",
+			cfg: `
+				include = ["/var/log/*.log"]
+				multiline {
+					line_start_pattern = "^\\d"
+					line_end_pattern   = "$"
+				}
+				output {}
+			`,
+			expect: "exactly one of line_start_pattern and line_end_pattern must be set",
+		},
+		{
+			name: "unknown operator",
+			cfg: `
+				include   = ["/var/log/*.log"]
+				operators = [{type = "does_not_exist"}]
+				output {}
+			`,
+			expect: `operator 0: unsupported type 'does_not_exist'`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expect)
+			}
+		})
+	}
+}
+
+func writeLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range lines {
+		_, err := fmt.Fprintln(f, line)
+		require.NoError(t, err)
+	}
+}
+
+// makeLogsOutput returns a ConsumerArguments which will forward logs to
+// the provided channel.
+func makeLogsOutput(ch chan plog.Logs) *otelcol.ConsumerArguments {
+	logsConsumer := fakeconsumer.Consumer{
+		ConsumeLogsFunc: func(ctx context.Context, l plog.Logs) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- l:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Logs: []otelcol.Consumer{&logsConsumer},
+	}
+}
@@ -0,0 +1,412 @@
+package filelog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/entry"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/helper"
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding"
+)
+
+const (
+	inputType = "file_input"
+
+	// knownFilesKey is the key the checkpoints of read files are persisted
+	// under.
+	knownFilesKey = "knownFiles"
+)
+
+// inputConfig configures the stanza operator reading log files. It mirrors
+// the configuration of the upstream file input operator.
+type inputConfig struct {
+	helper.InputConfig
+
+	Include []string
+	Exclude []string
+
+	StartAt            string
+	PollInterval       time.Duration
+	FingerprintSize    int
+	MaxLogSize         int
+	MaxConcurrentFiles int
+
+	IncludeFileName         bool
+	IncludeFilePath         bool
+	IncludeFileNameResolved bool
+	IncludeFilePathResolved bool
+
+	Splitter helper.SplitterConfig
+}
+
+func newInputConfig() *inputConfig {
+	return &inputConfig{
+		InputConfig: helper.NewInputConfig(inputType, inputType),
+		Splitter:    helper.NewSplitterConfig(),
+	}
+}
+
+// Build implements operator.Builder.
+func (c *inputConfig) Build(logger *zap.SugaredLogger) (operator.Operator, error) {
+	inputOperator, err := c.InputConfig.Build(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range append(append([]string{}, c.Include...), c.Exclude...) {
+		if _, err := doublestar.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", pattern, err)
+		}
+	}
+	if c.FingerprintSize <= 0 {
+		return nil, fmt.Errorf("fingerprint_size must be greater than 0")
+	}
+	if c.MaxLogSize <= 0 {
+		return nil, fmt.Errorf("max_log_size must be greater than 0")
+	}
+	if c.MaxConcurrentFiles <= 0 {
+		return nil, fmt.Errorf("max_concurrent_files must be greater than 0")
+	}
+
+	// Build a splitter to validate its configuration early; each file gets its
+	// own splitter when it's read.
+	splitter, err := c.Splitter.Build(false, c.MaxLogSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &input{
+		InputOperator: inputOperator,
+		cfg:           c,
+		nop:           splitter.Encoding.Encoding == encoding.Nop,
+	}, nil
+}
+
+// input is a stanza input operator tailing log files. Files are identified by
+// a fingerprint of their first bytes rather than by their path, so that
+// files which are renamed when rotated aren't read again.
+type input struct {
+	helper.InputOperator
+	cfg *inputConfig
+	nop bool
+
+	persister operator.Persister
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	// known holds the files found during the previous poll.
+	known     []*reader
+	firstPoll bool
+}
+
+// reader tracks the progress of reading a file.
+type reader struct {
+	Fingerprint []byte `json:"fingerprint"`
+	Offset      int64  `json:"offset"`
+
+	path     string
+	splitter *helper.Splitter
+}
+
+// Start implements operator.Operator.
+func (i *input) Start(persister operator.Persister) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	i.cancel = cancel
+	i.persister = persister
+	i.firstPoll = true
+
+	bb, err := persister.Get(ctx, knownFilesKey)
+	if err != nil {
+		return fmt.Errorf("reading checkpoints: %w", err)
+	} else if len(bb) > 0 {
+		if err := json.Unmarshal(bb, &i.known); err != nil {
+			return fmt.Errorf("decoding checkpoints: %w", err)
+		}
+	}
+
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+
+		ticker := time.NewTicker(i.cfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			i.poll(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop implements operator.Operator.
+func (i *input) Stop() error {
+	if i.cancel != nil {
+		i.cancel()
+	}
+	i.wg.Wait()
+	return nil
+}
+
+// poll reads new data from all matching files and persists the checkpoints
+// of the files afterwards.
+func (i *input) poll(ctx context.Context) {
+	paths := i.findFiles()
+
+	var seen []*reader
+	for len(paths) > 0 {
+		batch := paths
+		if len(batch) > i.cfg.MaxConcurrentFiles {
+			batch = batch[:i.cfg.MaxConcurrentFiles]
+		}
+		paths = paths[len(batch):]
+
+		var wg sync.WaitGroup
+		for _, path := range batch {
+			f, r := i.openFile(path, seen)
+			if f == nil {
+				continue
+			}
+			seen = append(seen, r)
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer f.Close()
+				i.readFile(ctx, f, r)
+			}()
+		}
+		wg.Wait()
+	}
+
+	i.known = seen
+	i.firstPoll = false
+
+	bb, err := json.Marshal(i.known)
+	if err != nil {
+		i.Errorw("failed to encode checkpoints", zap.Error(err))
+		return
+	}
+	if err := i.persister.Set(ctx, knownFilesKey, bb); err != nil && ctx.Err() == nil {
+		i.Errorw("failed to persist checkpoints", zap.Error(err))
+	}
+}
+
+// findFiles returns the paths of the files matching the include patterns
+// and none of the exclude patterns.
+func (i *input) findFiles() []string {
+	var (
+		paths []string
+		found = make(map[string]struct{})
+	)
+
+	for _, include := range i.cfg.Include {
+		matches, err := doublestar.Glob(include)
+		if err != nil {
+			i.Errorw("failed to find files", zap.String("include", include), zap.Error(err))
+			continue
+		}
+
+	Matches:
+		for _, path := range matches {
+			if _, ok := found[path]; ok {
+				continue
+			}
+			for _, exclude := range i.cfg.Exclude {
+				if ok, _ := doublestar.PathMatch(exclude, path); ok {
+					continue Matches
+				}
+			}
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+
+			found[path] = struct{}{}
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// openFile opens the file at path and finds the reader tracking it. nil is
+// returned if the file can't be read or was already found at another path
+// during this poll.
+func (i *input) openFile(path string, seen []*reader) (*os.File, *reader) {
+	f, err := os.Open(path)
+	if err != nil {
+		i.Errorw("failed to open file", zap.String("path", path), zap.Error(err))
+		return nil, nil
+	}
+
+	fp := make([]byte, i.cfg.FingerprintSize)
+	n, err := io.ReadFull(f, fp)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		i.Errorw("failed to read file", zap.String("path", path), zap.Error(err))
+		f.Close()
+		return nil, nil
+	}
+	fp = fp[:n]
+
+	// Empty files can't be identified yet; they're picked up once they have
+	// content.
+	if len(fp) == 0 {
+		f.Close()
+		return nil, nil
+	}
+
+	for _, r := range seen {
+		if bytes.Equal(r.Fingerprint, fp) {
+			f.Close()
+			return nil, nil
+		}
+	}
+
+	for idx, r := range i.known {
+		// A file's fingerprint grows until it reaches the configured size, so
+		// the previously known fingerprint only needs to be a prefix.
+		if bytes.HasPrefix(fp, r.Fingerprint) {
+			i.known = append(i.known[:idx], i.known[idx+1:]...)
+			r.Fingerprint = fp
+			r.path = path
+			return f, r
+		}
+	}
+
+	r := &reader{Fingerprint: fp, path: path}
+	if i.firstPoll && i.cfg.StartAt == "end" {
+		info, err := f.Stat()
+		if err != nil {
+			i.Errorw("failed to stat file", zap.String("path", path), zap.Error(err))
+			f.Close()
+			return nil, nil
+		}
+		r.Offset = info.Size()
+	}
+	return f, r
+}
+
+// readFile emits all the log entries of f following the offset of r.
+func (i *input) readFile(ctx context.Context, f *os.File, r *reader) {
+	if r.splitter == nil {
+		splitter, err := i.cfg.Splitter.Build(false, i.cfg.MaxLogSize)
+		if err != nil {
+			i.Errorw("failed to build splitter", zap.Error(err))
+			return
+		}
+		r.splitter = splitter
+	}
+
+	if _, err := f.Seek(r.Offset, io.SeekStart); err != nil {
+		i.Errorw("failed to seek file", zap.String("path", r.path), zap.Error(err))
+		return
+	}
+
+	// The offset is only moved past an entry once it's emitted, so that it's
+	// read again if the receiver stops in the meantime.
+	var pending int64
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 16*1024), i.cfg.MaxLogSize)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := r.splitter.SplitFunc(data, atEOF)
+		if err == nil && token == nil && len(data) >= i.cfg.MaxLogSize {
+			// Truncate entries which would be longer than the max log size.
+			advance, token = i.cfg.MaxLogSize, data[:i.cfg.MaxLogSize]
+		}
+		pending += int64(advance)
+		return advance, token, err
+	})
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return
+		}
+		i.emit(ctx, r, scanner.Bytes())
+		r.Offset, pending = r.Offset+pending, 0
+	}
+	r.Offset += pending
+
+	if err := scanner.Err(); err != nil {
+		i.Errorw("failed to read file", zap.String("path", r.path), zap.Error(err))
+	}
+}
+
+func (i *input) emit(ctx context.Context, r *reader, token []byte) {
+	if len(token) == 0 {
+		return
+	}
+
+	var body interface{}
+	if i.nop {
+		body = append([]byte(nil), token...)
+	} else {
+		decoded, err := r.splitter.Encoding.Decode(token)
+		if err != nil {
+			i.Errorw("failed to decode entry", zap.String("path", r.path), zap.Error(err))
+			return
+		}
+		body = string(decoded)
+	}
+
+	ent, err := i.NewEntry(body)
+	if err != nil {
+		i.Errorw("failed to create entry", zap.Error(err))
+		return
+	}
+	if err := i.setFileAttributes(ent, r.path); err != nil {
+		i.Errorw("failed to set file attributes", zap.Error(err))
+	}
+	i.Write(ctx, ent)
+}
+
+func (i *input) setFileAttributes(ent *entry.Entry, path string) error {
+	if i.cfg.IncludeFileName {
+		if err := ent.Set(entry.NewAttributeField("log.file.name"), filepath.Base(path)); err != nil {
+			return err
+		}
+	}
+	if i.cfg.IncludeFilePath {
+		if err := ent.Set(entry.NewAttributeField("log.file.path"), path); err != nil {
+			return err
+		}
+	}
+	if !i.cfg.IncludeFileNameResolved && !i.cfg.IncludeFilePathResolved {
+		return nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return err
+	}
+	if i.cfg.IncludeFileNameResolved {
+		if err := ent.Set(entry.NewAttributeField("log.file.name_resolved"), filepath.Base(resolved)); err != nil {
+			return err
+		}
+	}
+	if i.cfg.IncludeFilePathResolved {
+		if err := ent.Set(entry.NewAttributeField("log.file.path_resolved"), resolved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
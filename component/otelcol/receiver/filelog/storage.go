@@ -0,0 +1,142 @@
+package filelog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/extension/experimental/storage"
+)
+
+// fileStorage is a storage extension persisting the checkpoints of the
+// receiver to a single JSON file. The checkpoints are small and written at
+// most once per poll interval, so the whole file is rewritten on every
+// change.
+type fileStorage struct {
+	path string
+
+	mut    sync.Mutex
+	loaded bool
+	data   map[string][]byte
+}
+
+var (
+	_ storage.Extension = (*fileStorage)(nil)
+	_ storage.Client    = (*fileStorage)(nil)
+)
+
+func newFileStorage(path string) *fileStorage {
+	return &fileStorage{path: path}
+}
+
+// Start implements otelcomponent.Extension.
+func (s *fileStorage) Start(context.Context, otelcomponent.Host) error { return nil }
+
+// Shutdown implements otelcomponent.Extension.
+func (s *fileStorage) Shutdown(context.Context) error { return nil }
+
+// GetClient implements storage.Extension. The same client is shared between
+// all instances of the receiver, so that checkpoints survive the receiver
+// being recreated when the component is updated.
+func (s *fileStorage) GetClient(context.Context, otelcomponent.Kind, otelconfig.ComponentID, string) (storage.Client, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads the checkpoints from disk the first time it's called. s.mut must
+// be held when calling load.
+func (s *fileStorage) load() error {
+	if s.loaded {
+		return nil
+	}
+
+	s.data = make(map[string][]byte)
+	bb, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading checkpoints: %w", err)
+	} else if err == nil {
+		if err := json.Unmarshal(bb, &s.data); err != nil {
+			return fmt.Errorf("decoding checkpoints %s: %w", s.path, err)
+		}
+	}
+
+	s.loaded = true
+	return nil
+}
+
+// Get implements storage.Client.
+func (s *fileStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	op := storage.GetOperation(key)
+	err := s.Batch(ctx, op)
+	return op.Value, err
+}
+
+// Set implements storage.Client.
+func (s *fileStorage) Set(ctx context.Context, key string, value []byte) error {
+	return s.Batch(ctx, storage.SetOperation(key, value))
+}
+
+// Delete implements storage.Client.
+func (s *fileStorage) Delete(ctx context.Context, key string) error {
+	return s.Batch(ctx, storage.DeleteOperation(key))
+}
+
+// Batch implements storage.Client.
+func (s *fileStorage) Batch(_ context.Context, ops ...storage.Operation) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	var changed bool
+	for _, op := range ops {
+		switch op.Type {
+		case storage.Get:
+			op.Value = s.data[op.Key]
+		case storage.Set:
+			s.data[op.Key] = op.Value
+			changed = true
+		case storage.Delete:
+			delete(s.data, op.Key)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return s.flush()
+}
+
+// flush atomically writes the checkpoints to disk. s.mut must be held when
+// calling flush.
+func (s *fileStorage) flush() error {
+	bb, err := json.Marshal(s.data)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0750); err != nil {
+		return fmt.Errorf("creating checkpoints directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, bb, 0640); err != nil {
+		return fmt.Errorf("writing checkpoints: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Close implements storage.Client. Checkpoints are flushed on every change,
+// so there is nothing to release.
+func (s *fileStorage) Close(context.Context) error { return nil }
@@ -107,17 +107,7 @@ type timeseriesMap struct {
 func (tsm *timeseriesMap) get(metric pmetric.Metric, kv pcommon.Map) (*timeseriesInfo, bool) {
 	// This should only be invoked be functions called (directly or indirectly) by AdjustMetricSlice().
 	// The lock protecting tsm.tsiMap is acquired there.
-	name := metric.Name()
-	key := timeseriesKey{
-		name:       name,
-		attributes: getAttributesSignature(kv),
-	}
-	if metric.Type() == pmetric.MetricTypeHistogram {
-		// There are 2 types of Histograms whose aggregation temporality needs distinguishing:
-		// * CumulativeHistogram
-		// * GaugeHistogram
-		key.aggTemporality = metric.Histogram().AggregationTemporality()
-	}
+	key := getTimeseriesKey(metric, kv)
 
 	tsm.mark = true
 	tsi, ok := tsm.tsiMap[key]
@@ -130,6 +120,28 @@ func (tsm *timeseriesMap) get(metric pmetric.Metric, kv pcommon.Map) (*timeserie
 }
 
 // Create a unique timeseries signature consisting of the metric name and label values.
+// Remove the timeseriesInfo for the timeseries associated with the metric and label values, so that
+// the next point of the timeseries is treated as its initial point.
+func (tsm *timeseriesMap) remove(metric pmetric.Metric, kv pcommon.Map) {
+	// This should only be invoked be functions called (directly or indirectly) by AdjustMetricSlice().
+	// The lock protecting tsm.tsiMap is acquired there.
+	delete(tsm.tsiMap, getTimeseriesKey(metric, kv))
+}
+
+func getTimeseriesKey(metric pmetric.Metric, kv pcommon.Map) timeseriesKey {
+	key := timeseriesKey{
+		name:       metric.Name(),
+		attributes: getAttributesSignature(kv),
+	}
+	if metric.Type() == pmetric.MetricTypeHistogram {
+		// There are 2 types of Histograms whose aggregation temporality needs distinguishing:
+		// * CumulativeHistogram
+		// * GaugeHistogram
+		key.aggTemporality = metric.Histogram().AggregationTemporality()
+	}
+	return key
+}
+
 func getAttributesSignature(kv pcommon.Map) string {
 	labelValues := make([]string, 0, kv.Len())
 	kv.Sort().Range(func(_ string, attrValue pcommon.Value) bool {
@@ -316,6 +328,16 @@ func adjustMetricHistogram(tsm *timeseriesMap, current pmetric.Metric) {
 	for i := 0; i < currentPoints.Len(); i++ {
 		currentDist := currentPoints.At(i)
 		tsi, found := tsm.get(current, currentDist.Attributes())
+		if currentDist.Flags().NoRecordedValue() {
+			// The timeseries went stale, e.g. because its target went away.
+			// Forget it so that the timeseries gets a new start time if it
+			// reappears, even if its value is higher than before.
+			if found {
+				currentDist.SetStartTimestamp(tsi.histogram.startTime)
+			}
+			tsm.remove(current, currentDist.Attributes())
+			continue
+		}
 		if !found {
 			// initialize everything.
 			tsi.histogram.startTime = currentDist.StartTimestamp()
@@ -324,12 +346,6 @@ func adjustMetricHistogram(tsm *timeseriesMap, current pmetric.Metric) {
 			continue
 		}
 
-		if currentDist.Flags().NoRecordedValue() {
-			// TODO: Investigate why this does not reset.
-			currentDist.SetStartTimestamp(tsi.histogram.startTime)
-			continue
-		}
-
 		if currentDist.Count() < tsi.histogram.previousCount || currentDist.Sum() < tsi.histogram.previousSum {
 			// reset re-initialize everything.
 			tsi.histogram.startTime = currentDist.StartTimestamp()
@@ -350,6 +366,16 @@ func adjustMetricSum(tsm *timeseriesMap, current pmetric.Metric) {
 	for i := 0; i < currentPoints.Len(); i++ {
 		currentSum := currentPoints.At(i)
 		tsi, found := tsm.get(current, currentSum.Attributes())
+		if currentSum.Flags().NoRecordedValue() {
+			// The timeseries went stale, e.g. because its target went away.
+			// Forget it so that the timeseries gets a new start time if it
+			// reappears, even if its value is higher than before.
+			if found {
+				currentSum.SetStartTimestamp(tsi.number.startTime)
+			}
+			tsm.remove(current, currentSum.Attributes())
+			continue
+		}
 		if !found {
 			// initialize everything.
 			tsi.number.startTime = currentSum.StartTimestamp()
@@ -357,12 +383,6 @@ func adjustMetricSum(tsm *timeseriesMap, current pmetric.Metric) {
 			continue
 		}
 
-		if currentSum.Flags().NoRecordedValue() {
-			// TODO: Investigate why this does not reset.
-			currentSum.SetStartTimestamp(tsi.number.startTime)
-			continue
-		}
-
 		if currentSum.DoubleValue() < tsi.number.previousValue {
 			// reset re-initialize everything.
 			tsi.number.startTime = currentSum.StartTimestamp()
@@ -382,6 +402,16 @@ func adjustMetricSummary(tsm *timeseriesMap, current pmetric.Metric) {
 	for i := 0; i < currentPoints.Len(); i++ {
 		currentSummary := currentPoints.At(i)
 		tsi, found := tsm.get(current, currentSummary.Attributes())
+		if currentSummary.Flags().NoRecordedValue() {
+			// The timeseries went stale, e.g. because its target went away.
+			// Forget it so that the timeseries gets a new start time if it
+			// reappears, even if its value is higher than before.
+			if found {
+				currentSummary.SetStartTimestamp(tsi.summary.startTime)
+			}
+			tsm.remove(current, currentSummary.Attributes())
+			continue
+		}
 		if !found {
 			// initialize everything.
 			tsi.summary.startTime = currentSummary.StartTimestamp()
@@ -390,12 +420,6 @@ func adjustMetricSummary(tsm *timeseriesMap, current pmetric.Metric) {
 			continue
 		}
 
-		if currentSummary.Flags().NoRecordedValue() {
-			// TODO: Investigate why this does not reset.
-			currentSummary.SetStartTimestamp(tsi.summary.startTime)
-			continue
-		}
-
 		if (currentSummary.Count() != 0 &&
 			tsi.summary.previousCount != 0 &&
 			currentSummary.Count() < tsi.summary.previousCount) ||
@@ -156,6 +156,33 @@ func TestSummaryFlagNoRecordedValue(t *testing.T) {
 	runScript(t, NewInitialPointAdjuster(zap.NewNop(), time.Minute), "job", "0", script)
 }
 
+func TestSumStaleReset(t *testing.T) {
+	script := []*metricsAdjusterTest{
+		{
+			description: "Sum: round 1 - initial instance, start time is established",
+			metrics:     metrics(sumMetric(sum1, doublePoint(k1v1k2v2, t1, t1, 44))),
+			adjusted:    metrics(sumMetric(sum1, doublePoint(k1v1k2v2, t1, t1, 44))),
+		},
+		{
+			description: "Sum: round 2 - instance goes stale, start time is kept",
+			metrics:     metrics(sumMetric(sum1, doublePointNoValue(k1v1k2v2, t2, t2))),
+			adjusted:    metrics(sumMetric(sum1, doublePointNoValue(k1v1k2v2, t1, t2))),
+		},
+		{
+			description: "Sum: round 3 - instance reappears with a higher value, new start time is established",
+			metrics:     metrics(sumMetric(sum1, doublePoint(k1v1k2v2, t3, t3, 66))),
+			adjusted:    metrics(sumMetric(sum1, doublePoint(k1v1k2v2, t3, t3, 66))),
+		},
+		{
+			description: "Sum: round 4 - instance adjusted based on round 3",
+			metrics:     metrics(sumMetric(sum1, doublePoint(k1v1k2v2, t4, t4, 72))),
+			adjusted:    metrics(sumMetric(sum1, doublePoint(k1v1k2v2, t3, t4, 72))),
+		},
+	}
+
+	runScript(t, NewInitialPointAdjuster(zap.NewNop(), time.Minute), "job", "0", script)
+}
+
 func TestSummary(t *testing.T) {
 	script := []*metricsAdjusterTest{
 		{
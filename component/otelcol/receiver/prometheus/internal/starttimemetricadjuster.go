@@ -26,6 +26,7 @@ var (
 	errNoStartTimeMetrics             = errors.New("start_time metric is missing")
 	errNoDataPointsStartTimeMetric    = errors.New("start time metric with no data points")
 	errUnsupportedTypeStartTimeMetric = errors.New("unsupported data type for start time metric")
+	errStaleStartTimeMetric           = errors.New("start time metric is stale")
 )
 
 type startTimeMetricAdjuster struct {
@@ -44,6 +45,11 @@ func NewStartTimeMetricAdjuster(logger *zap.Logger, startTimeMetricRegex *regexp
 func (stma *startTimeMetricAdjuster) AdjustMetrics(metrics pmetric.Metrics) error {
 	startTime, err := stma.getStartTime(metrics)
 	if err != nil {
+		// Staleness markers sent when a target goes away don't include a start
+		// time metric with a value, but still need to be forwarded.
+		if onlyStalePoints(metrics) {
+			return nil
+		}
 		return err
 	}
 
@@ -99,16 +105,10 @@ func (stma *startTimeMetricAdjuster) getStartTime(metrics pmetric.Metrics) (floa
 				if stma.matchStartTimeMetric(metric.Name()) {
 					switch metric.Type() {
 					case pmetric.MetricTypeGauge:
-						if metric.Gauge().DataPoints().Len() == 0 {
-							return 0.0, errNoDataPointsStartTimeMetric
-						}
-						return metric.Gauge().DataPoints().At(0).DoubleValue(), nil
+						return startTimeFromPoints(metric.Gauge().DataPoints())
 
 					case pmetric.MetricTypeSum:
-						if metric.Sum().DataPoints().Len() == 0 {
-							return 0.0, errNoDataPointsStartTimeMetric
-						}
-						return metric.Sum().DataPoints().At(0).DoubleValue(), nil
+						return startTimeFromPoints(metric.Sum().DataPoints())
 
 					default:
 						return 0, errUnsupportedTypeStartTimeMetric
@@ -126,3 +126,68 @@ func (stma *startTimeMetricAdjuster) matchStartTimeMetric(metricName string) boo
 
 	return metricName == startTimeMetricName
 }
+
+// startTimeFromPoints returns the value of the first point of a start time
+// metric.
+func startTimeFromPoints(points pmetric.NumberDataPointSlice) (float64, error) {
+	if points.Len() == 0 {
+		return 0.0, errNoDataPointsStartTimeMetric
+	}
+	if points.At(0).Flags().NoRecordedValue() {
+		return 0.0, errStaleStartTimeMetric
+	}
+	return points.At(0).DoubleValue(), nil
+}
+
+// onlyStalePoints returns true if metrics has points and all of them are
+// staleness markers.
+func onlyStalePoints(metrics pmetric.Metrics) bool {
+	var stale, total int
+	for i := 0; i < metrics.ResourceMetrics().Len(); i++ {
+		rm := metrics.ResourceMetrics().At(i)
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			ilm := rm.ScopeMetrics().At(j)
+			for k := 0; k < ilm.Metrics().Len(); k++ {
+				s, t := countStalePoints(ilm.Metrics().At(k))
+				stale += s
+				total += t
+			}
+		}
+	}
+	return total > 0 && stale == total
+}
+
+// countStalePoints returns the number of staleness markers and the total
+// number of points of metric.
+func countStalePoints(metric pmetric.Metric) (stale, total int) {
+	var flags []pmetric.DataPointFlags
+	switch metric.Type() {
+	case pmetric.MetricTypeGauge:
+		points := metric.Gauge().DataPoints()
+		for i := 0; i < points.Len(); i++ {
+			flags = append(flags, points.At(i).Flags())
+		}
+	case pmetric.MetricTypeSum:
+		points := metric.Sum().DataPoints()
+		for i := 0; i < points.Len(); i++ {
+			flags = append(flags, points.At(i).Flags())
+		}
+	case pmetric.MetricTypeHistogram:
+		points := metric.Histogram().DataPoints()
+		for i := 0; i < points.Len(); i++ {
+			flags = append(flags, points.At(i).Flags())
+		}
+	case pmetric.MetricTypeSummary:
+		points := metric.Summary().DataPoints()
+		for i := 0; i < points.Len(); i++ {
+			flags = append(flags, points.At(i).Flags())
+		}
+	}
+
+	for _, f := range flags {
+		if f.NoRecordedValue() {
+			stale++
+		}
+	}
+	return stale, len(flags)
+}
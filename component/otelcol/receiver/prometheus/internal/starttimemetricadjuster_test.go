@@ -118,6 +118,23 @@ func TestStartTimeMetricMatch(t *testing.T) {
 			),
 			expectedErr: errNoStartTimeMetrics,
 		},
+		{
+			name: "stale start time metric",
+			inputs: metrics(
+				sumMetric("test_sum_metric", doublePoint(nil, startTime, currentTime, 16)),
+				gaugeMetric("process_start_time_seconds", doublePointNoValue(nil, startTime, currentTime)),
+			),
+			expectedErr: errStaleStartTimeMetric,
+		},
+		{
+			name: "only staleness markers",
+			inputs: metrics(
+				sumMetric("test_sum_metric", doublePointNoValue(nil, startTime, currentTime)),
+				histogramMetric("test_histogram_metric", histogramPointNoValue(nil, startTime, currentTime)),
+				gaugeMetric("process_start_time_seconds", doublePointNoValue(nil, startTime, currentTime)),
+			),
+			expectedStartTime: startTime,
+		},
 	}
 
 	for _, tt := range tests {
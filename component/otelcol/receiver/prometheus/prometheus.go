@@ -3,18 +3,24 @@ package prometheus
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"regexp"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/internal/fanoutconsumer"
 	"github.com/grafana/agent/component/otelcol/receiver/prometheus/internal"
+	flowprometheus "github.com/grafana/agent/component/prometheus"
 	"github.com/grafana/agent/pkg/build"
 	"github.com/grafana/agent/pkg/util/zapadapter"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/storage"
 	otelcomponent "go.opentelemetry.io/collector/component"
@@ -37,21 +43,68 @@ func init() {
 
 // Arguments configures the otelcol.receiver.prometheus component.
 type Arguments struct {
+	// UseStartTimeMetric sets the start time of cumulative metrics from a
+	// start time metric instead of the time a series was first seen.
+	UseStartTimeMetric bool `river:"use_start_time_metric,attr,optional"`
+	// StartTimeMetricRegex overrides the name of the start time metric, which
+	// defaults to `process_start_time_seconds`.
+	StartTimeMetricRegex string `river:"start_time_metric_regex,attr,optional"`
+	// GCInterval is how long the start times of series which are no longer
+	// received are kept.
+	GCInterval time.Duration `river:"gc_interval,attr,optional"`
+
 	// Output configures where to send received data. Required.
 	Output *otelcol.ConsumerArguments `river:"output,block"`
 }
 
+// DefaultArguments holds default settings for Arguments.
+var DefaultArguments = Arguments{
+	GCInterval: 5 * time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.GCInterval <= 0 {
+		return fmt.Errorf("gc_interval must be greater than 0")
+	}
+	if args.StartTimeMetricRegex != "" {
+		if !args.UseStartTimeMetric {
+			return fmt.Errorf("start_time_metric_regex can only be set when use_start_time_metric is true")
+		}
+		if _, err := regexp.Compile(args.StartTimeMetricRegex); err != nil {
+			return fmt.Errorf("invalid start_time_metric_regex: %w", err)
+		}
+	}
+	return nil
+}
+
 // Exports are the set of fields exposed by the otelcol.receiver.prometheus
 // component.
 type Exports struct {
 	Receiver storage.Appendable `river:"receiver,attr"`
 }
 
+// Types of data which can't be converted to OTLP and are dropped.
+const (
+	unsupportedNativeHistogram = "native_histogram"
+	unsupportedExemplar        = "exemplar"
+)
+
 // Component is the otelcol.receiver.prometheus component.
 type Component struct {
 	log  log.Logger
 	opts component.Options
 
+	// unsupported counts the data which is dropped because it can't be
+	// converted, which is logged once per type.
+	unsupported     *prometheus_client.CounterVec
+	unsupportedOnce map[string]*sync.Once
+
 	mut        sync.RWMutex
 	cfg        Arguments
 	appendable storage.Appendable
@@ -64,6 +117,18 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 	res := &Component{
 		log:  o.Logger,
 		opts: o,
+
+		unsupported: prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+			Name: "otelcol_receiver_prometheus_unsupported_data_total",
+			Help: "Total number of native histograms and exemplars dropped because they can't be converted to OTLP.",
+		}, []string{"type"}),
+		unsupportedOnce: map[string]*sync.Once{
+			unsupportedNativeHistogram: {},
+			unsupportedExemplar:        {},
+		},
+	}
+	if err := o.Registerer.Register(res.unsupported); err != nil {
+		return nil, err
 	}
 
 	if err := res.Update(c); err != nil {
@@ -97,11 +162,18 @@ func (c *Component) Update(newConfig component.Arguments) error {
 	// used by the upstream scrape configs, plus a delta to avoid race
 	// conditions so that jobs are not getting GC'ed between scrapes.
 	var (
-		useStartTimeMetric   = false
+		useStartTimeMetric   = cfg.UseStartTimeMetric
 		startTimeMetricRegex *regexp.Regexp
 
-		gcInterval = 5 * time.Minute
+		gcInterval = cfg.GCInterval
 	)
+	if cfg.StartTimeMetricRegex != "" {
+		var err error
+		startTimeMetricRegex, err = regexp.Compile(cfg.StartTimeMetricRegex)
+		if err != nil {
+			return err
+		}
+	}
 	settings := otelcomponent.ReceiverCreateSettings{
 		TelemetrySettings: otelcomponent.TelemetrySettings{
 			Logger: zapadapter.New(c.opts.Logger),
@@ -128,10 +200,32 @@ func (c *Component) Update(newConfig component.Arguments) error {
 		otelconfig.NewComponentID(otelconfig.Type(c.opts.ID)),
 		labels.Labels{},
 	)
-	c.appendable = appendable
+
+	// The converter doesn't support native histograms and exemplars yet, so
+	// they're counted rather than silently dropped.
+	c.appendable = flowprometheus.NewInterceptor(
+		appendable,
+		flowprometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, next storage.Appender) (storage.SeriesRef, error) {
+			c.dropUnsupported(unsupportedNativeHistogram)
+			return next.AppendHistogram(ref, l, t, h, fh)
+		}),
+		flowprometheus.WithExemplarHook(func(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, next storage.Appender) (storage.SeriesRef, error) {
+			c.dropUnsupported(unsupportedExemplar)
+			return next.AppendExemplar(ref, l, e)
+		}),
+	)
 
 	// Export the receiver.
 	c.opts.OnStateChange(Exports{Receiver: c.appendable})
 
 	return nil
 }
+
+// dropUnsupported records that data of the given type was dropped, logging
+// it the first time.
+func (c *Component) dropUnsupported(typ string) {
+	c.unsupported.WithLabelValues(typ).Inc()
+	c.unsupportedOnce[typ].Do(func() {
+		level.Warn(c.log).Log("msg", "dropping data which can't be converted to OTLP", "type", typ)
+	})
+}
@@ -2,9 +2,12 @@ package prometheus_test
 
 import (
 	"context"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/otelcol"
 	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
 	"github.com/grafana/agent/component/otelcol/receiver/prometheus"
@@ -12,8 +15,14 @@ import (
 	"github.com/grafana/agent/pkg/flow/componenttest"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/scrape"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -82,6 +91,178 @@ func Test(t *testing.T) {
 	}
 }
 
+// TestStalenessAndMetadata ensures that metric metadata from the appender
+// context is used to convert metrics, and that staleness markers are
+// forwarded.
+func TestStalenessAndMetadata(t *testing.T) {
+	ctx := componenttest.TestContext(t)
+	l := util.TestLogger(t)
+
+	ctrl, err := componenttest.NewControllerFromID(l, "otelcol.receiver.prometheus")
+	require.NoError(t, err)
+
+	var args prometheus.Arguments
+	require.NoError(t, river.Unmarshal([]byte(`output {}`), &args))
+
+	metricCh := make(chan pmetric.Metrics)
+	args.Output = makeMetricsOutput(metricCh)
+
+	go func() {
+		err := ctrl.Run(ctx, args)
+		require.NoError(t, err)
+	}()
+
+	require.NoError(t, ctrl.WaitRunning(time.Second))
+	require.NoError(t, ctrl.WaitExports(time.Second))
+
+	exports := ctrl.Exports().(prometheus.Exports)
+
+	appCtx := context.Background()
+	appCtx = scrape.ContextWithMetricMetadataStore(appCtx, testMetadataStore{
+		"requests_total": {
+			Metric: "requests_total",
+			Type:   textparse.MetricTypeCounter,
+			Help:   "Number of requests.",
+		},
+	})
+	appCtx = scrape.ContextWithTarget(appCtx, &scrape.Target{})
+
+	lbls := labels.FromStrings(
+		model.MetricNameLabel, "requests_total",
+		model.JobLabel, "testJob",
+		model.InstanceLabel, "otelcol.receiver.prometheus",
+	)
+	send := func(ts int64, v float64) pmetric.Metric {
+		go func() {
+			app := exports.Receiver.Appender(appCtx)
+			_, err := app.Append(0, lbls, ts, v)
+			require.NoError(t, err)
+			require.NoError(t, app.Commit())
+		}()
+
+		select {
+		case <-time.After(time.Second):
+			require.FailNow(t, "failed waiting for metrics")
+			return pmetric.Metric{}
+		case m := <-metricCh:
+			require.Equal(t, 1, m.MetricCount())
+			return m.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0)
+		}
+	}
+
+	now := time.Now().UnixMilli()
+
+	m := send(now, 10)
+	require.Equal(t, pmetric.MetricTypeSum, m.Type())
+	require.True(t, m.Sum().IsMonotonic())
+	require.Equal(t, "Number of requests.", m.Description())
+	require.False(t, m.Sum().DataPoints().At(0).Flags().NoRecordedValue())
+
+	m = send(now+1000, math.Float64frombits(value.StaleNaN))
+	require.Equal(t, pmetric.MetricTypeSum, m.Type())
+	require.True(t, m.Sum().DataPoints().At(0).Flags().NoRecordedValue())
+}
+
+// TestUnsupportedData ensures that native histograms and exemplars, which
+// can't be converted yet, are counted when they're dropped.
+func TestUnsupportedData(t *testing.T) {
+	var exports prometheus.Exports
+	reg := prometheus_client.NewRegistry()
+	_, err := prometheus.NewComponent(component.Options{
+		ID:            "otelcol.receiver.prometheus.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    reg,
+		OnStateChange: func(e component.Exports) { exports = e.(prometheus.Exports) },
+	}, prometheus.Arguments{Output: &otelcol.ConsumerArguments{}})
+	require.NoError(t, err)
+
+	lbls := labels.FromStrings(model.MetricNameLabel, "latency_seconds")
+	ts := time.Now().UnixMilli()
+	app := exports.Receiver.Appender(context.Background())
+	_, err = app.AppendHistogram(0, lbls, ts, &histogram.Histogram{Count: 1, Sum: 1}, nil)
+	require.NoError(t, err)
+	_, err = app.AppendHistogram(0, lbls, ts, nil, &histogram.FloatHistogram{Count: 1, Sum: 1})
+	require.NoError(t, err)
+	_, err = app.AppendExemplar(0, lbls, exemplar.Exemplar{Value: 1, Ts: ts, HasTs: true})
+	require.NoError(t, err)
+	require.NoError(t, app.Rollback())
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP otelcol_receiver_prometheus_unsupported_data_total Total number of native histograms and exemplars dropped because they can't be converted to OTLP.
+		# TYPE otelcol_receiver_prometheus_unsupported_data_total counter
+		otelcol_receiver_prometheus_unsupported_data_total{type="exemplar"} 1
+		otelcol_receiver_prometheus_unsupported_data_total{type="native_histogram"} 2
+	`), "otelcol_receiver_prometheus_unsupported_data_total"))
+}
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "start time metric",
+			cfg: `
+				use_start_time_metric   = true
+				start_time_metric_regex = "^.+_process_start_time_seconds$"
+				gc_interval             = "10m"
+				output {}
+			`,
+		},
+		{
+			name: "regex without start time metric",
+			cfg: `
+				start_time_metric_regex = "^.+_process_start_time_seconds$"
+				output {}
+			`,
+			expectedErr: "start_time_metric_regex can only be set when use_start_time_metric is true",
+		},
+		{
+			name: "invalid regex",
+			cfg: `
+				use_start_time_metric   = true
+				start_time_metric_regex = "("
+				output {}
+			`,
+			expectedErr: "invalid start_time_metric_regex: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name: "invalid gc_interval",
+			cfg: `
+				gc_interval = "0s"
+				output {}
+			`,
+			expectedErr: "gc_interval must be greater than 0",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args prometheus.Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+type testMetadataStore map[string]scrape.MetricMetadata
+
+func (ms testMetadataStore) GetMetadata(familyName string) (scrape.MetricMetadata, bool) {
+	md, ok := ms[familyName]
+	return md, ok
+}
+
+func (ms testMetadataStore) ListMetadata() []scrape.MetricMetadata { return nil }
+
+func (ms testMetadataStore) SizeMetadata() int { return 0 }
+
+func (ms testMetadataStore) LengthMetadata() int { return len(ms) }
+
 // makeMetricsOutput returns a ConsumerArguments which will forward metrics to
 // the provided channel.
 func makeMetricsOutput(ch chan pmetric.Metrics) *otelcol.ConsumerArguments {
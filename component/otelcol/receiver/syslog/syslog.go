@@ -0,0 +1,254 @@
+// Package syslog provides an otelcol.receiver.syslog component.
+package syslog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/receiver"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/adapter"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/helper"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/input/syslog"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/input/tcp"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/input/udp"
+	syslogparser "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/parser/syslog"
+	otelcomponent "go.opentelemetry.io/collector/component"
+	otelconfig "go.opentelemetry.io/collector/config"
+	"go.opentelemetry.io/collector/confmap"
+	"go.uber.org/zap"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "otelcol.receiver.syslog",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			fact := adapter.NewFactory(receiverType{}, otelcomponent.StabilityLevelAlpha)
+			return receiver.New(opts, fact, args.(Arguments))
+		},
+	})
+}
+
+// Arguments configures the otelcol.receiver.syslog component.
+type Arguments struct {
+	Protocol                     string `river:"protocol,attr,optional"`
+	Location                     string `river:"location,attr,optional"`
+	EnableOctetCounting          bool   `river:"enable_octet_counting,attr,optional"`
+	NonTransparentFramingTrailer string `river:"non_transparent_framing_trailer,attr,optional"`
+
+	TCP *TCPArguments `river:"tcp,block,optional"`
+	UDP *UDPArguments `river:"udp,block,optional"`
+
+	Attributes map[string]string `river:"attributes,attr,optional"`
+	Resource   map[string]string `river:"resource,attr,optional"`
+
+	// Operators holds the configuration of the stanza operators processing
+	// the parsed messages, in order. Each operator is a map holding the
+	// upstream configuration of the operator, including its type.
+	Operators []map[string]interface{} `river:"operators,attr,optional"`
+
+	// Output configures where to send received data. Required.
+	Output *otelcol.ConsumerArguments `river:"output,block"`
+}
+
+// TCPArguments configures receiving syslog messages over TCP.
+type TCPArguments struct {
+	ListenAddress string                      `river:"listen_address,attr,optional"`
+	MaxLogSize    units.Base2Bytes            `river:"max_log_size,attr,optional"`
+	AddAttributes bool                        `river:"add_attributes,attr,optional"`
+	Encoding      string                      `river:"encoding,attr,optional"`
+	TLS           *otelcol.TLSServerArguments `river:"tls,block,optional"`
+}
+
+// DefaultTCPArguments holds default settings for TCPArguments.
+var DefaultTCPArguments = TCPArguments{
+	ListenAddress: "0.0.0.0:54526",
+	MaxLogSize:    1 * units.MiB,
+	Encoding:      "utf-8",
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *TCPArguments) SetToDefault() {
+	*args = DefaultTCPArguments
+}
+
+// UDPArguments configures receiving syslog messages over UDP.
+type UDPArguments struct {
+	ListenAddress string `river:"listen_address,attr,optional"`
+	AddAttributes bool   `river:"add_attributes,attr,optional"`
+	Encoding      string `river:"encoding,attr,optional"`
+}
+
+// DefaultUDPArguments holds default settings for UDPArguments.
+var DefaultUDPArguments = UDPArguments{
+	ListenAddress: "0.0.0.0:54526",
+	Encoding:      "utf-8",
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *UDPArguments) SetToDefault() {
+	*args = DefaultUDPArguments
+}
+
+var (
+	_ receiver.Arguments = Arguments{}
+)
+
+// DefaultArguments holds default values for Arguments.
+var DefaultArguments = Arguments{
+	Protocol: syslogparser.RFC5424,
+	Location: "UTC",
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if (args.TCP == nil) == (args.UDP == nil) {
+		return fmt.Errorf("exactly one of the tcp and udp blocks must be set")
+	}
+	if args.Protocol != syslogparser.RFC5424 && args.Protocol != syslogparser.RFC3164 {
+		return fmt.Errorf("protocol must be %q or %q, got %q", syslogparser.RFC5424, syslogparser.RFC3164, args.Protocol)
+	}
+	if _, err := time.LoadLocation(args.Location); err != nil {
+		return fmt.Errorf("invalid location %q: %w", args.Location, err)
+	}
+	switch args.NonTransparentFramingTrailer {
+	case "", syslogparser.LFTrailer, syslogparser.NULTrailer:
+	default:
+		return fmt.Errorf("non_transparent_framing_trailer must be %q or %q, got %q", syslogparser.LFTrailer, syslogparser.NULTrailer, args.NonTransparentFramingTrailer)
+	}
+
+	cfg, err := args.Convert()
+	if err != nil {
+		return err
+	}
+
+	// Building the operators validates them without starting them.
+	conv := cfg.(*receiverConfig)
+	for _, op := range append([]operator.Config{operator.NewConfig(&conv.InputConfig)}, conv.Operators...) {
+		if _, err := op.Build(nopLogger); err != nil {
+			return fmt.Errorf("invalid operator %q: %w", op.ID(), err)
+		}
+	}
+	return nil
+}
+
+// Convert implements receiver.Arguments.
+func (args Arguments) Convert() (otelconfig.Receiver, error) {
+	input := syslog.NewConfig()
+	input.Protocol = args.Protocol
+	input.Location = args.Location
+	input.EnableOctetCounting = args.EnableOctetCounting
+	if args.NonTransparentFramingTrailer != "" {
+		trailer := args.NonTransparentFramingTrailer
+		input.NonTransparentFramingTrailer = &trailer
+	}
+	input.Attributes = convertExprs(args.Attributes)
+	input.Resource = convertExprs(args.Resource)
+
+	if args.TCP != nil {
+		tcpConfig := tcp.NewConfig().BaseConfig
+		tcpConfig.ListenAddress = args.TCP.ListenAddress
+		tcpConfig.MaxLogSize = helper.ByteSize(args.TCP.MaxLogSize)
+		tcpConfig.AddAttributes = args.TCP.AddAttributes
+		tcpConfig.Encoding.Encoding = args.TCP.Encoding
+		tcpConfig.TLS = args.TCP.TLS.Convert()
+		input.TCP = &tcpConfig
+	}
+	if args.UDP != nil {
+		udpConfig := udp.NewConfig().BaseConfig
+		udpConfig.ListenAddress = args.UDP.ListenAddress
+		udpConfig.AddAttributes = args.UDP.AddAttributes
+		udpConfig.Encoding.Encoding = args.UDP.Encoding
+		input.UDP = &udpConfig
+	}
+
+	operators := make([]operator.Config, 0, len(args.Operators))
+	for i, raw := range args.Operators {
+		var op operator.Config
+		if err := op.Unmarshal(confmap.NewFromStringMap(raw)); err != nil {
+			return nil, fmt.Errorf("operator %d: %w", i, err)
+		}
+		operators = append(operators, op)
+	}
+
+	return &receiverConfig{
+		BaseConfig: adapter.BaseConfig{
+			ReceiverSettings: otelconfig.NewReceiverSettings(otelconfig.NewComponentID(typeStr)),
+			Operators:        operators,
+		},
+		InputConfig: *input,
+	}, nil
+}
+
+func convertExprs(in map[string]string) map[string]helper.ExprStringConfig {
+	if len(in) == 0 {
+		return nil
+	}
+	out := make(map[string]helper.ExprStringConfig, len(in))
+	for k, v := range in {
+		out[k] = helper.ExprStringConfig(v)
+	}
+	return out
+}
+
+// Extensions implements receiver.Arguments.
+func (args Arguments) Extensions() map[otelconfig.ComponentID]otelcomponent.Extension {
+	return nil
+}
+
+// Exporters implements receiver.Arguments.
+func (args Arguments) Exporters() map[otelconfig.DataType]map[otelconfig.ComponentID]otelcomponent.Exporter {
+	return nil
+}
+
+// NextConsumers implements receiver.Arguments.
+func (args Arguments) NextConsumers() *otelcol.ConsumerArguments {
+	return args.Output
+}
+
+// typeStr is the type of the upstream receiver.
+const typeStr = "syslog"
+
+// nopLogger is used to build operators when validating them.
+var nopLogger = zap.NewNop().Sugar()
+
+// receiverConfig is the configuration of the stanza-based receiver
+// listening for syslog messages.
+type receiverConfig struct {
+	adapter.BaseConfig
+	InputConfig syslog.Config
+}
+
+// receiverType implements adapter.LogReceiverType for receiverConfig.
+type receiverType struct{}
+
+var _ adapter.LogReceiverType = receiverType{}
+
+func (receiverType) Type() otelconfig.Type { return typeStr }
+
+func (receiverType) CreateDefaultConfig() otelconfig.Receiver {
+	return &receiverConfig{
+		BaseConfig: adapter.BaseConfig{
+			ReceiverSettings: otelconfig.NewReceiverSettings(otelconfig.NewComponentID(typeStr)),
+		},
+		InputConfig: *syslog.NewConfig(),
+	}
+}
+
+func (receiverType) BaseConfig(cfg otelconfig.Receiver) adapter.BaseConfig {
+	return cfg.(*receiverConfig).BaseConfig
+}
+
+func (receiverType) InputConfig(cfg otelconfig.Receiver) operator.Config {
+	return operator.NewConfig(&cfg.(*receiverConfig).InputConfig)
+}
@@ -0,0 +1,196 @@
+package syslog
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/otelcol"
+	"github.com/grafana/agent/component/otelcol/internal/fakeconsumer"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/phayes/freeport"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func Test(t *testing.T) {
+	for _, network := range []string{"tcp", "udp"} {
+		t.Run(network, func(t *testing.T) {
+			ctx := componenttest.TestContext(t)
+			l := util.TestLogger(t)
+
+			addr := getFreeAddr(t)
+
+			ctrl, err := componenttest.NewControllerFromID(l, "otelcol.receiver.syslog")
+			require.NoError(t, err)
+
+			cfg := fmt.Sprintf(`
+				%s {
+					listen_address = %q
+				}
+
+				output {
+					// no-op: will be overridden by test code.
+				}
+			`, network, addr)
+			var args Arguments
+			require.NoError(t, river.Unmarshal([]byte(cfg), &args))
+
+			// Override our settings so logs get forwarded to logCh.
+			logCh := make(chan plog.Logs, 10)
+			args.Output = makeLogsOutput(logCh)
+
+			go func() {
+				err := ctrl.Run(ctx, args)
+				require.NoError(t, err)
+			}()
+
+			require.NoError(t, ctrl.WaitRunning(time.Second))
+
+			msg := `<34>1 2023-01-02T15:04:05Z mymachine.example.com su - ID47 - 'su root' failed for lonvick on /dev/pts/8` + "\n"
+
+			var conn net.Conn
+			require.Eventually(t, func() bool {
+				conn, err = net.Dial(network, addr)
+				return err == nil
+			}, 5*time.Second, 10*time.Millisecond)
+			defer conn.Close()
+
+			timeout := time.After(5 * time.Second)
+			for {
+				_, err := conn.Write([]byte(msg))
+				require.NoError(t, err)
+
+				select {
+				case <-timeout:
+					require.FailNow(t, "failed waiting for logs")
+				case <-time.After(100 * time.Millisecond):
+					// UDP packets sent before the receiver is listening are
+					// dropped; send the message again.
+					continue
+				case logs := <-logCh:
+					require.Equal(t, 1, logs.LogRecordCount())
+
+					lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+					attrs := lr.Attributes().AsRaw()
+					require.Equal(t, "mymachine.example.com", attrs["hostname"])
+					require.Equal(t, "su", attrs["appname"])
+					require.Equal(t, "'su root' failed for lonvick on /dev/pts/8", attrs["message"])
+					require.Equal(t, "crit", lr.SeverityText())
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "tcp",
+			cfg: `
+				protocol = "rfc3164"
+				tcp {
+					listen_address = "127.0.0.1:5140"
+					add_attributes = true
+				}
+				output {}
+			`,
+		},
+		{
+			name: "invalid tls",
+			cfg: `
+				tcp {
+					tls {
+						cert_pem = "cert"
+						key_pem  = "key"
+					}
+				}
+				output {}
+			`,
+			expect: `invalid operator "syslog_input": failed to resolve tcp config: failed to load TLS config: failed to load TLS cert and key: failed to load TLS cert and key PEMs: tls: failed to find any PEM data in certificate input`,
+		},
+		{
+			name: "no listener",
+			cfg: `
+				output {}
+			`,
+			expect: "exactly one of the tcp and udp blocks must be set",
+		},
+		{
+			name: "both listeners",
+			cfg: `
+				tcp {}
+				udp {}
+				output {}
+			`,
+			expect: "exactly one of the tcp and udp blocks must be set",
+		},
+		{
+			name: "invalid protocol",
+			cfg: `
+				protocol = "rfc9999"
+				udp {}
+				output {}
+			`,
+			expect: `protocol must be "rfc5424" or "rfc3164", got "rfc9999"`,
+		},
+		{
+			name: "octet counting over udp",
+			cfg: `
+				enable_octet_counting = true
+				udp {}
+				output {}
+			`,
+			expect: `invalid operator "syslog_input": octet_counting and non_transparent_framing is not compatible with UDP`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expect == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expect)
+			}
+		})
+	}
+}
+
+func getFreeAddr(t *testing.T) string {
+	t.Helper()
+
+	portNumber, err := freeport.GetFreePort()
+	require.NoError(t, err)
+
+	return fmt.Sprintf("127.0.0.1:%d", portNumber)
+}
+
+// makeLogsOutput returns a ConsumerArguments which will forward logs to
+// the provided channel.
+func makeLogsOutput(ch chan plog.Logs) *otelcol.ConsumerArguments {
+	logsConsumer := fakeconsumer.Consumer{
+		ConsumeLogsFunc: func(ctx context.Context, l plog.Logs) error {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case ch <- l:
+				return nil
+			}
+		},
+	}
+
+	return &otelcol.ConsumerArguments{
+		Logs: []otelcol.Consumer{&logsConsumer},
+	}
+}
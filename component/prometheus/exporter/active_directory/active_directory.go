@@ -0,0 +1,93 @@
+// Package active_directory implements the prometheus.exporter.active_directory
+// component, which runs the collectors of windows_exporter for the roles of
+// domain controllers.
+package active_directory
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	windows_integration "github.com/grafana/agent/pkg/integrations/windows_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "prometheus.exporter.active_directory",
+		Args:      Arguments{},
+		Exports:   exporter.Exports{},
+		Singleton: false,
+		Build:     exporter.New(createExporter, "active_directory"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return windows_integration.New(opts.Logger, a.Convert())
+}
+
+// Roles of Windows Server which can be monitored, and the windows_exporter
+// collectors exposing their metrics.
+const (
+	RoleDomainServices = "domain_services"
+	RoleDNSServer      = "dns_server"
+	RoleDHCPServer     = "dhcp_server"
+)
+
+var roleCollectors = map[string]string{
+	RoleDomainServices: "ad",
+	RoleDNSServer:      "dns",
+	RoleDHCPServer:     "dhcp",
+}
+
+// DefaultArguments holds the default arguments of
+// prometheus.exporter.active_directory.
+var DefaultArguments = Arguments{
+	Roles: []string{RoleDomainServices},
+}
+
+// Arguments configures the prometheus.exporter.active_directory component.
+type Arguments struct {
+	// Roles whose collectors to enable.
+	Roles []string `river:"roles,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if len(a.Roles) == 0 {
+		return fmt.Errorf("roles must not be empty")
+	}
+	seen := make(map[string]struct{}, len(a.Roles))
+	for _, role := range a.Roles {
+		if _, ok := roleCollectors[role]; !ok {
+			return fmt.Errorf("unrecognized role %q, must be one of %s, %s, or %s", role, RoleDomainServices, RoleDNSServer, RoleDHCPServer)
+		}
+		if _, ok := seen[role]; ok {
+			return fmt.Errorf("role %q is listed more than once", role)
+		}
+		seen[role] = struct{}{}
+	}
+	return nil
+}
+
+// Convert converts the component's Arguments to the config of the
+// windows_exporter integration, enabling only the collectors of the roles.
+func (a *Arguments) Convert() *windows_integration.Config {
+	collectors := make([]string, 0, len(a.Roles))
+	for _, role := range a.Roles {
+		collectors = append(collectors, roleCollectors[role])
+	}
+
+	// The role collectors have no settings, but the settings of the other
+	// collectors are still converted, so they're kept at their defaults.
+	cfg := windows_integration.DefaultConfig
+	cfg.EnabledCollectors = strings.Join(collectors, ",")
+	return &cfg
+}
@@ -0,0 +1,52 @@
+package active_directory
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(""), &args))
+	require.Equal(t, "ad", args.Convert().EnabledCollectors)
+
+	riverConfig := `
+		roles = ["domain_services", "dns_server", "dhcp_server"]
+	`
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+	require.Equal(t, "ad,dns,dhcp", args.Convert().EnabledCollectors)
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect string
+	}{
+		{
+			name:   "no roles",
+			config: `roles = []`,
+			expect: "roles must not be empty",
+		},
+		{
+			name:   "unknown role",
+			config: `roles = ["certificate_services"]`,
+			expect: `unrecognized role "certificate_services"`,
+		},
+		{
+			name:   "duplicate role",
+			config: `roles = ["dns_server", "dns_server"]`,
+			expect: `role "dns_server" is listed more than once`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
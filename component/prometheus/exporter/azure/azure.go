@@ -0,0 +1,134 @@
+package azure
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/azure_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.azure",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "azure"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds the default settings for the azure exporter.
+var DefaultArguments = Arguments{
+	Timespan:              azure_exporter.DefaultConfig.Timespan,
+	MetricNameTemplate:    azure_exporter.DefaultConfig.MetricNameTemplate,
+	MetricHelpTemplate:    azure_exporter.DefaultConfig.MetricHelpTemplate,
+	IncludedResourceTags:  azure_exporter.DefaultConfig.IncludedResourceTags,
+	AzureCloudEnvironment: azure_exporter.DefaultConfig.AzureCloudEnvironment,
+	Concurrency: Concurrency{
+		ResourceTypes:         azure_exporter.DefaultConfig.ConcurrencyResourceTypes,
+		Subscriptions:         azure_exporter.DefaultConfig.ConcurrencySubscriptions,
+		SubscriptionResources: azure_exporter.DefaultConfig.ConcurrencySubscriptionResources,
+	},
+}
+
+// Arguments controls the azure exporter.
+type Arguments struct {
+	Subscriptions            []string `river:"subscriptions,attr"`
+	Regions                  []string `river:"regions,attr,optional"`
+	ResourceGraphQueryFilter string   `river:"resource_graph_query_filter,attr,optional"`
+	ResourceType             string   `river:"resource_type,attr,optional"`
+	Metrics                  []string `river:"metrics,attr,optional"`
+	MetricAggregations       []string `river:"metric_aggregations,attr,optional"`
+	Timespan                 string   `river:"timespan,attr,optional"`
+	IncludedDimensions       []string `river:"included_dimensions,attr,optional"`
+	IncludedResourceTags     []string `river:"included_resource_tags,attr,optional"`
+	MetricNamespace          string   `river:"metric_namespace,attr,optional"`
+	MetricNameTemplate       string   `river:"metric_name_template,attr,optional"`
+	MetricHelpTemplate       string   `river:"metric_help_template,attr,optional"`
+	AzureCloudEnvironment    string   `river:"azure_cloud_environment,attr,optional"`
+
+	Resources   []Resource  `river:"resource,block,optional"`
+	Concurrency Concurrency `river:"concurrency,block,optional"`
+}
+
+// Resource configures an additional resource type to collect metrics for.
+type Resource struct {
+	Subscriptions            []string `river:"subscriptions,attr,optional"`
+	Regions                  []string `river:"regions,attr,optional"`
+	ResourceType             string   `river:"resource_type,attr"`
+	ResourceGraphQueryFilter string   `river:"resource_graph_query_filter,attr,optional"`
+	MetricNamespace          string   `river:"metric_namespace,attr,optional"`
+	Metrics                  []string `river:"metrics,attr"`
+	MetricAggregations       []string `river:"metric_aggregations,attr,optional"`
+	IncludedDimensions       []string `river:"included_dimensions,attr,optional"`
+}
+
+// Concurrency limits the number of concurrent requests to Azure.
+type Concurrency struct {
+	ResourceTypes         int `river:"resource_types,attr,optional"`
+	Subscriptions         int `river:"subscriptions,attr,optional"`
+	SubscriptionResources int `river:"subscription_resources,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	for name, value := range map[string]int{
+		"resource_types":         a.Concurrency.ResourceTypes,
+		"subscriptions":          a.Concurrency.Subscriptions,
+		"subscription_resources": a.Concurrency.SubscriptionResources,
+	} {
+		if value <= 0 {
+			return fmt.Errorf("concurrency %s must be greater than 0", name)
+		}
+	}
+	return a.Convert().Validate()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *azure_exporter.Config {
+	resources := make([]azure_exporter.ResourceConfig, 0, len(a.Resources))
+	for _, r := range a.Resources {
+		resources = append(resources, azure_exporter.ResourceConfig{
+			Subscriptions:            r.Subscriptions,
+			Regions:                  r.Regions,
+			ResourceType:             r.ResourceType,
+			ResourceGraphQueryFilter: r.ResourceGraphQueryFilter,
+			MetricNamespace:          r.MetricNamespace,
+			Metrics:                  r.Metrics,
+			MetricAggregations:       r.MetricAggregations,
+			IncludedDimensions:       r.IncludedDimensions,
+		})
+	}
+
+	return &azure_exporter.Config{
+		Subscriptions:            a.Subscriptions,
+		Regions:                  a.Regions,
+		ResourceGraphQueryFilter: a.ResourceGraphQueryFilter,
+		ResourceType:             a.ResourceType,
+		Metrics:                  a.Metrics,
+		MetricAggregations:       a.MetricAggregations,
+		Timespan:                 a.Timespan,
+		IncludedDimensions:       a.IncludedDimensions,
+		IncludedResourceTags:     a.IncludedResourceTags,
+		MetricNamespace:          a.MetricNamespace,
+		MetricNameTemplate:       a.MetricNameTemplate,
+		MetricHelpTemplate:       a.MetricHelpTemplate,
+		AzureCloudEnvironment:    a.AzureCloudEnvironment,
+		Resources:                resources,
+
+		ConcurrencyResourceTypes:         a.Concurrency.ResourceTypes,
+		ConcurrencySubscriptions:         a.Concurrency.Subscriptions,
+		ConcurrencySubscriptionResources: a.Concurrency.SubscriptionResources,
+	}
+}
@@ -0,0 +1,107 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/integrations/azure_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+	subscriptions = ["subscriptionA", "subscriptionB"]
+	regions       = ["westeurope"]
+
+	resource {
+		resource_type = "Microsoft.Storage/storageAccounts"
+		metrics       = ["Availability", "Egress"]
+	}
+
+	resource {
+		resource_type       = "Microsoft.Cache/redis"
+		metrics             = ["connectedclients"]
+		metric_aggregations = ["maximum"]
+		subscriptions       = ["subscriptionC"]
+	}
+
+	concurrency {
+		resource_types = 4
+	}
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	expected := azure_exporter.Config{
+		Subscriptions:         []string{"subscriptionA", "subscriptionB"},
+		Regions:               []string{"westeurope"},
+		Timespan:              "PT1M",
+		IncludedResourceTags:  []string{"owner"},
+		MetricNameTemplate:    "azure_{type}_{metric}_{aggregation}_{unit}",
+		MetricHelpTemplate:    "Azure metric {metric} for {type} with aggregation {aggregation} as {unit}",
+		AzureCloudEnvironment: "azurecloud",
+		Resources: []azure_exporter.ResourceConfig{
+			{
+				ResourceType: "Microsoft.Storage/storageAccounts",
+				Metrics:      []string{"Availability", "Egress"},
+			},
+			{
+				ResourceType:       "Microsoft.Cache/redis",
+				Metrics:            []string{"connectedclients"},
+				MetricAggregations: []string{"maximum"},
+				Subscriptions:      []string{"subscriptionC"},
+			},
+		},
+		ConcurrencyResourceTypes:         4,
+		ConcurrencySubscriptions:         5,
+		ConcurrencySubscriptionResources: 10,
+	}
+	require.Equal(t, expected, *args.Convert())
+}
+
+func TestRiverUnmarshal_Invalid(t *testing.T) {
+	tt := []struct {
+		name        string
+		riverConfig string
+		expectErr   string
+	}{
+		{
+			name:        "no resource type",
+			riverConfig: `subscriptions = ["subscriptionA"]`,
+			expectErr:   "resource_type cannot be empty",
+		},
+		{
+			name: "invalid aggregation of a resource",
+			riverConfig: `
+			subscriptions = ["subscriptionA"]
+			resource {
+				resource_type       = "Microsoft.Cache/redis"
+				metrics             = ["connectedclients"]
+				metric_aggregations = ["median"]
+			}
+			`,
+			expectErr: "resources[0]: median is an invalid value for metric_aggregations",
+		},
+		{
+			name: "zero concurrency",
+			riverConfig: `
+			subscriptions = ["subscriptionA"]
+			resource_type = "Microsoft.Cache/redis"
+			metrics       = ["connectedclients"]
+			concurrency {
+				subscriptions = 0
+			}
+			`,
+			expectErr: "concurrency subscriptions must be greater than 0",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.riverConfig), &args)
+			require.ErrorContains(t, err, tc.expectErr)
+		})
+	}
+}
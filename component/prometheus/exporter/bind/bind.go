@@ -0,0 +1,70 @@
+package bind
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/bind_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.bind",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.NewWithTargetBuilder(createExporter, "bind", customizeTarget),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+func customizeTarget(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+	target := baseTarget
+
+	if u, err := url.Parse(a.StatsURL); err == nil {
+		target["instance"] = u.Host
+	}
+	return []discovery.Target{target}
+}
+
+// DefaultArguments holds the default arguments for the prometheus.exporter.bind component.
+var DefaultArguments = Arguments{
+	StatsURL: bind_exporter.DefaultConfig.StatsURL,
+	Timeout:  bind_exporter.DefaultConfig.Timeout,
+}
+
+// Arguments configures the prometheus.exporter.bind component.
+type Arguments struct {
+	StatsURL     string        `river:"stats_url,attr,optional"`
+	Timeout      time.Duration `river:"timeout,attr,optional"`
+	IncludeZones []string      `river:"include_zones,attr,optional"`
+	ExcludeZones []string      `river:"exclude_zones,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	return a.Convert().Validate()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *bind_exporter.Config {
+	return &bind_exporter.Config{
+		StatsURL:     a.StatsURL,
+		Timeout:      a.Timeout,
+		IncludeZones: a.IncludeZones,
+		ExcludeZones: a.ExcludeZones,
+	}
+}
@@ -0,0 +1,53 @@
+package bind
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations/bind_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+	stats_url     = "http://ns1:8053"
+	timeout       = "5s"
+	exclude_zones = [".*\\.in-addr\\.arpa"]
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	expected := Arguments{
+		StatsURL:     "http://ns1:8053",
+		Timeout:      5 * time.Second,
+		ExcludeZones: []string{`.*\.in-addr\.arpa`},
+	}
+	require.Equal(t, expected, args)
+}
+
+func TestRiverUnmarshal_Invalid(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`include_zones = ["("]`), &args)
+	require.EqualError(t, err, "invalid include_zones: error parsing regexp: missing closing ): `^(?:()$`")
+}
+
+func TestConvert(t *testing.T) {
+	args := DefaultArguments
+	args.IncludeZones = []string{"example.com"}
+
+	expected := bind_exporter.DefaultConfig
+	expected.IncludeZones = []string{"example.com"}
+	require.Equal(t, &expected, args.Convert())
+}
+
+func TestCustomizeTarget(t *testing.T) {
+	args := DefaultArguments
+	args.StatsURL = "http://ns1:8053"
+
+	targets := customizeTarget(discovery.Target{"instance": "agent"}, args)
+	require.Equal(t, []discovery.Target{{"instance": "ns1:8053"}}, targets)
+}
@@ -3,9 +3,11 @@ package blackbox
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	blackbox_config "github.com/prometheus/blackbox_exporter/config"
+	"github.com/prometheus/common/model"
 	"gopkg.in/yaml.v2"
 
 	"github.com/grafana/agent/component"
@@ -34,11 +36,14 @@ func buildBlackboxTargets(baseTarget discovery.Target, args component.Arguments)
 	var targets []discovery.Target
 
 	a := args.(Arguments)
-	for _, tgt := range a.Targets {
+	for _, tgt := range a.allTargets() {
 		target := make(discovery.Target)
 		for k, v := range baseTarget {
 			target[k] = v
 		}
+		for k, v := range tgt.labels {
+			target[k] = v
+		}
 
 		target["job"] = target["job"] + "/" + tgt.Name
 		target["__param_target"] = tgt.Target
@@ -52,6 +57,66 @@ func buildBlackboxTargets(baseTarget discovery.Target, args component.Arguments)
 	return targets
 }
 
+// Keys of discovered targets which configure how the target is probed.
+// Other keys which don't start with "__" are added as labels to the target.
+const (
+	targetKeyName    = "name"
+	targetKeyAddress = "address"
+	targetKeyModule  = "module"
+)
+
+// blackboxTarget is a blackbox target along with the extra labels of the
+// discovered target it was created from.
+type blackboxTarget struct {
+	BlackboxTarget
+	labels map[string]string
+}
+
+// allTargets returns the targets defined by target blocks followed by the
+// ones from the targets argument.
+func (a Arguments) allTargets() []blackboxTarget {
+	res := make([]blackboxTarget, 0, len(a.Targets)+len(a.TargetsList))
+	for _, tgt := range a.Targets {
+		res = append(res, blackboxTarget{BlackboxTarget: tgt})
+	}
+	for _, t := range a.TargetsList {
+		res = append(res, convertDiscoveredTarget(t))
+	}
+	return res
+}
+
+// convertDiscoveredTarget creates a blackbox target from a discovered target.
+// The address is read from the address key, falling back to __address__, and
+// the name defaults to the address.
+func convertDiscoveredTarget(t discovery.Target) blackboxTarget {
+	res := blackboxTarget{
+		BlackboxTarget: BlackboxTarget{
+			Name:   t[targetKeyName],
+			Target: t[targetKeyAddress],
+			Module: t[targetKeyModule],
+		},
+		labels: make(map[string]string),
+	}
+	if res.Target == "" {
+		res.Target = t[model.AddressLabel]
+	}
+	if res.Name == "" {
+		res.Name = res.Target
+	}
+
+	for k, v := range t {
+		switch k {
+		case targetKeyName, targetKeyAddress, targetKeyModule:
+			continue
+		}
+		if strings.HasPrefix(k, model.ReservedLabelPrefix) {
+			continue
+		}
+		res.labels[k] = v
+	}
+	return res
+}
+
 // DefaultArguments holds non-zero default options for Arguments when it is
 // unmarshaled from river.
 var DefaultArguments = Arguments{
@@ -81,10 +146,11 @@ func (t TargetBlock) Convert() []blackbox_exporter.BlackboxTarget {
 }
 
 type Arguments struct {
-	ConfigFile         string        `river:"config_file,attr,optional"`
-	Config             string        `river:"config,attr,optional"`
-	Targets            TargetBlock   `river:"target,block"`
-	ProbeTimeoutOffset time.Duration `river:"probe_timeout_offset,attr,optional"`
+	ConfigFile         string             `river:"config_file,attr,optional"`
+	Config             string             `river:"config,attr,optional"`
+	Targets            TargetBlock        `river:"target,block,optional"`
+	TargetsList        []discovery.Target `river:"targets,attr,optional"`
+	ProbeTimeoutOffset time.Duration      `river:"probe_timeout_offset,attr,optional"`
 	ConfigStruct       blackbox_config.Config
 }
 
@@ -99,6 +165,12 @@ func (a *Arguments) Validate() error {
 		return errors.New("config and config_file are mutually exclusive")
 	}
 
+	for i, t := range a.TargetsList {
+		if convertDiscoveredTarget(t).Target == "" {
+			return fmt.Errorf("targets[%d]: the %q key or the %s label must be set", i, targetKeyAddress, model.AddressLabel)
+		}
+	}
+
 	err := yaml.UnmarshalStrict([]byte(a.Config), &a.ConfigStruct)
 	if err != nil {
 		return fmt.Errorf("invalid backbox_exporter config: %s", err)
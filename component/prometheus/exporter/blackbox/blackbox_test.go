@@ -170,3 +170,65 @@ func TestBuildBlackboxTargets(t *testing.T) {
 	require.Equal(t, "http://example.com", targets[0]["__param_target"])
 	require.Equal(t, "http_2xx", targets[0]["__param_module"])
 }
+
+func TestBuildBlackboxTargets_Discovered(t *testing.T) {
+	riverCfg := `
+		config_file = "modules.yml"
+		targets = [
+			{"name" = "shop", "address" = "https://shop.example.com", "module" = "http_2xx", "namespace" = "shop"},
+			{"__address__" = "https://grafana.com", "__meta_kubernetes_ingress_name" = "grafana"},
+		]
+		target "example" {
+			address = "http://example.com"
+		}
+`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+
+	baseTarget := discovery.Target{
+		model.SchemeLabel:      "http",
+		model.MetricsPathLabel: "component/prometheus.exporter.blackbox.default/metrics",
+		"instance":             "prometheus.exporter.blackbox.default",
+		"job":                  "integrations/blackbox",
+	}
+	targets := buildBlackboxTargets(baseTarget, args)
+	require.Equal(t, []discovery.Target{
+		{
+			model.SchemeLabel:      "http",
+			model.MetricsPathLabel: "component/prometheus.exporter.blackbox.default/metrics",
+			"instance":             "prometheus.exporter.blackbox.default",
+			"job":                  "integrations/blackbox/example",
+			"__param_target":       "http://example.com",
+		},
+		{
+			model.SchemeLabel:      "http",
+			model.MetricsPathLabel: "component/prometheus.exporter.blackbox.default/metrics",
+			"instance":             "prometheus.exporter.blackbox.default",
+			"job":                  "integrations/blackbox/shop",
+			"namespace":            "shop",
+			"__param_target":       "https://shop.example.com",
+			"__param_module":       "http_2xx",
+		},
+		{
+			model.SchemeLabel:      "http",
+			model.MetricsPathLabel: "component/prometheus.exporter.blackbox.default/metrics",
+			"instance":             "prometheus.exporter.blackbox.default",
+			"job":                  "integrations/blackbox/https://grafana.com",
+			"__param_target":       "https://grafana.com",
+		},
+	}, targets)
+}
+
+func TestValidateDiscoveredTargets(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		config_file = "modules.yml"
+		targets     = []
+	`), &args))
+
+	err := river.Unmarshal([]byte(`
+		config_file = "modules.yml"
+		targets     = [{"name" = "shop"}]
+	`), &args)
+	require.ErrorContains(t, err, `targets[0]: the "address" key or the __address__ label must be set`)
+}
@@ -0,0 +1,131 @@
+package cadvisor
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/cadvisor"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.cadvisor",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "cadvisor"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds the default settings for the cadvisor exporter.
+var DefaultArguments = Arguments{
+	StoreContainerLabels: cadvisor.DefaultConfig.StoreContainerLabels,
+	ResctrlInterval:      cadvisor.DefaultConfig.ResctrlInterval,
+	StorageDuration:      cadvisor.DefaultConfig.StorageDuration,
+
+	// The metrics disabled by default in cadvisor, as well as the per-device
+	// disk I/O metrics, which are reported for every block device of every
+	// container.
+	DisabledMetrics: []string{
+		"memory_numa", "tcp", "udp", "advtcp", "sched", "process", "hugetlb",
+		"referenced_memory", "cpu_topology", "resctrl", "cpuset", "diskIO",
+	},
+
+	HousekeepingInterval:     cadvisor.DefaultConfig.HousekeepingInterval,
+	MaxHousekeepingInterval:  cadvisor.DefaultConfig.MaxHousekeepingInterval,
+	AllowDynamicHousekeeping: cadvisor.DefaultConfig.AllowDynamicHousekeeping,
+
+	Containerd:          cadvisor.DefaultConfig.Containerd,
+	ContainerdNamespace: cadvisor.DefaultConfig.ContainerdNamespace,
+
+	Docker:        cadvisor.DefaultConfig.Docker,
+	DockerTLS:     cadvisor.DefaultConfig.DockerTLS,
+	DockerTLSCert: cadvisor.DefaultConfig.DockerTLSCert,
+	DockerTLSKey:  cadvisor.DefaultConfig.DockerTLSKey,
+	DockerTLSCA:   cadvisor.DefaultConfig.DockerTLSCA,
+
+	DockerOnly: cadvisor.DefaultConfig.DockerOnly,
+}
+
+// Arguments controls the cadvisor exporter.
+type Arguments struct {
+	StoreContainerLabels       bool          `river:"store_container_labels,attr,optional"`
+	AllowlistedContainerLabels []string      `river:"allowlisted_container_labels,attr,optional"`
+	EnvMetadataAllowlist       []string      `river:"env_metadata_allowlist,attr,optional"`
+	RawCgroupPrefixAllowlist   []string      `river:"raw_cgroup_prefix_allowlist,attr,optional"`
+	PerfEventsConfig           string        `river:"perf_events_config,attr,optional"`
+	ResctrlInterval            int           `river:"resctrl_interval,attr,optional"`
+	DisabledMetrics            []string      `river:"disabled_metrics,attr,optional"`
+	EnabledMetrics             []string      `river:"enabled_metrics,attr,optional"`
+	StorageDuration            time.Duration `river:"storage_duration,attr,optional"`
+
+	HousekeepingInterval     time.Duration `river:"housekeeping_interval,attr,optional"`
+	MaxHousekeepingInterval  time.Duration `river:"max_housekeeping_interval,attr,optional"`
+	AllowDynamicHousekeeping bool          `river:"allow_dynamic_housekeeping,attr,optional"`
+
+	Containerd          string `river:"containerd_host,attr,optional"`
+	ContainerdNamespace string `river:"containerd_namespace,attr,optional"`
+
+	Docker        string `river:"docker_host,attr,optional"`
+	DockerTLS     bool   `river:"use_docker_tls,attr,optional"`
+	DockerTLSCert string `river:"docker_tls_cert,attr,optional"`
+	DockerTLSKey  string `river:"docker_tls_key,attr,optional"`
+	DockerTLSCA   string `river:"docker_tls_ca,attr,optional"`
+
+	DockerOnly bool `river:"docker_only,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	return a.Convert().Validate()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *cadvisor.Config {
+	cfg := &cadvisor.Config{
+		StoreContainerLabels:       a.StoreContainerLabels,
+		AllowlistedContainerLabels: a.AllowlistedContainerLabels,
+		EnvMetadataAllowlist:       a.EnvMetadataAllowlist,
+		RawCgroupPrefixAllowlist:   a.RawCgroupPrefixAllowlist,
+		PerfEventsConfig:           a.PerfEventsConfig,
+		ResctrlInterval:            a.ResctrlInterval,
+		DisabledMetrics:            a.DisabledMetrics,
+		EnabledMetrics:             a.EnabledMetrics,
+		StorageDuration:            a.StorageDuration,
+		HousekeepingInterval:       a.HousekeepingInterval,
+		MaxHousekeepingInterval:    a.MaxHousekeepingInterval,
+		AllowDynamicHousekeeping:   a.AllowDynamicHousekeeping,
+		Containerd:                 a.Containerd,
+		ContainerdNamespace:        a.ContainerdNamespace,
+		Docker:                     a.Docker,
+		DockerTLS:                  a.DockerTLS,
+		DockerTLSCert:              a.DockerTLSCert,
+		DockerTLSKey:               a.DockerTLSKey,
+		DockerTLSCA:                a.DockerTLSCA,
+		DockerOnly:                 a.DockerOnly,
+	}
+
+	// cadvisor expects these lists to hold at least one element, like the
+	// integration does when its config is unmarshaled.
+	if len(cfg.AllowlistedContainerLabels) == 0 {
+		cfg.AllowlistedContainerLabels = []string{""}
+	}
+	if len(cfg.RawCgroupPrefixAllowlist) == 0 {
+		cfg.RawCgroupPrefixAllowlist = []string{""}
+	}
+	if len(cfg.EnvMetadataAllowlist) == 0 {
+		cfg.EnvMetadataAllowlist = []string{""}
+	}
+	return cfg
+}
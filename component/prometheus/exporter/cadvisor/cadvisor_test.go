@@ -0,0 +1,70 @@
+package cadvisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/integrations/cadvisor"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+	store_container_labels       = false
+	allowlisted_container_labels = ["label1", "label2"]
+	perf_events_config           = "/etc/cadvisor/perf.json"
+	enabled_metrics              = ["cpu", "memory", "diskIO"]
+	housekeeping_interval        = "10s"
+	max_housekeeping_interval    = "30s"
+	allow_dynamic_housekeeping   = false
+	docker_host                  = "unix:///run/docker.sock"
+	docker_only                  = true
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	expected := cadvisor.Config{
+		StoreContainerLabels:       false,
+		AllowlistedContainerLabels: []string{"label1", "label2"},
+		EnvMetadataAllowlist:       []string{""},
+		RawCgroupPrefixAllowlist:   []string{""},
+		PerfEventsConfig:           "/etc/cadvisor/perf.json",
+		DisabledMetrics:            DefaultArguments.DisabledMetrics,
+		EnabledMetrics:             []string{"cpu", "memory", "diskIO"},
+		StorageDuration:            2 * time.Minute,
+		HousekeepingInterval:       10 * time.Second,
+		MaxHousekeepingInterval:    30 * time.Second,
+		AllowDynamicHousekeeping:   false,
+		Containerd:                 "/run/containerd/containerd.sock",
+		ContainerdNamespace:        "k8s.io",
+		Docker:                     "unix:///run/docker.sock",
+		DockerTLSCert:              "cert.pem",
+		DockerTLSKey:               "key.pem",
+		DockerTLSCA:                "ca.pem",
+		DockerOnly:                 true,
+	}
+	require.Equal(t, expected, *args.Convert())
+}
+
+func TestRiverUnmarshal_Defaults(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(``), &args))
+
+	cfg := args.Convert()
+	require.Contains(t, cfg.DisabledMetrics, "diskIO", "per-device disk metrics must be disabled by default")
+	require.Equal(t, time.Second, cfg.HousekeepingInterval)
+	require.Equal(t, time.Minute, cfg.MaxHousekeepingInterval)
+	require.True(t, cfg.AllowDynamicHousekeeping)
+}
+
+func TestRiverUnmarshal_Invalid(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+	housekeeping_interval     = "1m"
+	max_housekeeping_interval = "30s"
+	`), &args)
+	require.ErrorContains(t, err, "max_housekeeping_interval must not be lower than housekeeping_interval")
+}
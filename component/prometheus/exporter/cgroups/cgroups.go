@@ -0,0 +1,73 @@
+package cgroups
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.cgroups",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "cgroups"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	c, err := newCollector(opts.Logger, args.(Arguments))
+	if err != nil {
+		return nil, err
+	}
+	return integrations.NewCollectorIntegration("cgroups", integrations.WithCollectors(c)), nil
+}
+
+// DefaultArguments holds the default arguments for the
+// prometheus.exporter.cgroups component.
+var DefaultArguments = Arguments{
+	CgroupRoot: "/sys/fs/cgroup",
+}
+
+// Arguments configures the prometheus.exporter.cgroups component.
+type Arguments struct {
+	// CgroupRoot is the directory the cgroup filesystem is mounted at.
+	CgroupRoot string `river:"cgroup_root,attr,optional"`
+
+	// Matches select the cgroups to collect metrics for and extract labels
+	// from their paths. All cgroups are collected if there are none.
+	Matches []Match `river:"match,block,optional"`
+}
+
+// Match selects cgroups whose path matches Regex. The named capture groups
+// of Regex become labels of the metrics of the cgroup.
+type Match struct {
+	Regex string `river:"regex,attr"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.CgroupRoot == "" {
+		return fmt.Errorf("cgroup_root must not be empty")
+	}
+	for i, m := range a.Matches {
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return fmt.Errorf("match %d: invalid regex: %w", i, err)
+		}
+		for _, name := range re.SubexpNames() {
+			if name == cgroupLabel {
+				return fmt.Errorf("match %d: capture group name %q is reserved", i, cgroupLabel)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes files, by their path relative to root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestCollector_V2(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"cgroup.controllers":                       "cpu memory io",
+		"kubepods/podabc/0123/cpu.stat":            "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\nnr_periods 10\nnr_throttled 3\nthrottled_usec 1500000\n",
+		"kubepods/podabc/0123/memory.current":      "1048576\n",
+		"kubepods/podabc/0123/memory.max":          "2097152\n",
+		"kubepods/podabc/0123/memory.stat":         "anon 524288\ninactive_file 262144\n",
+		"kubepods/podabc/0123/io.stat":             "8:0 rbytes=4096 wbytes=8192 rios=1 wios=2 dbytes=0 dios=0\n",
+		"kubepods/podabc/4567/memory.current":      "4096\n",
+		"kubepods/podabc/4567/memory.max":          "max\n",
+		"system.slice/sshd.service/memory.current": "8192\n",
+	})
+
+	c, err := newCollector(log.NewNopLogger(), Arguments{
+		CgroupRoot: root,
+		Matches: []Match{
+			{Regex: `^/kubepods/pod(?P<pod_uid>[^/]+)/(?P<container_id>[^/]+)$`},
+		},
+	})
+	require.NoError(t, err)
+
+	expect := `
+# HELP cgroup_cpu_system_seconds_total CPU time consumed by the cgroup in kernel mode.
+# TYPE cgroup_cpu_system_seconds_total counter
+cgroup_cpu_system_seconds_total{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 0.5
+# HELP cgroup_cpu_throttled_periods_total Number of periods the cgroup was throttled in.
+# TYPE cgroup_cpu_throttled_periods_total counter
+cgroup_cpu_throttled_periods_total{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 3
+# HELP cgroup_cpu_throttled_seconds_total Total time the cgroup was throttled for.
+# TYPE cgroup_cpu_throttled_seconds_total counter
+cgroup_cpu_throttled_seconds_total{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 1.5
+# HELP cgroup_cpu_usage_seconds_total Total CPU time consumed by the cgroup.
+# TYPE cgroup_cpu_usage_seconds_total counter
+cgroup_cpu_usage_seconds_total{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 2.5
+# HELP cgroup_cpu_user_seconds_total CPU time consumed by the cgroup in user mode.
+# TYPE cgroup_cpu_user_seconds_total counter
+cgroup_cpu_user_seconds_total{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 2
+# HELP cgroup_io_read_bytes_total Bytes read by the cgroup, by block device.
+# TYPE cgroup_io_read_bytes_total counter
+cgroup_io_read_bytes_total{cgroup="/kubepods/podabc/0123",container_id="0123",device="8:0",pod_uid="abc"} 4096
+# HELP cgroup_io_reads_total Read operations of the cgroup, by block device.
+# TYPE cgroup_io_reads_total counter
+cgroup_io_reads_total{cgroup="/kubepods/podabc/0123",container_id="0123",device="8:0",pod_uid="abc"} 1
+# HELP cgroup_io_write_bytes_total Bytes written by the cgroup, by block device.
+# TYPE cgroup_io_write_bytes_total counter
+cgroup_io_write_bytes_total{cgroup="/kubepods/podabc/0123",container_id="0123",device="8:0",pod_uid="abc"} 8192
+# HELP cgroup_io_writes_total Write operations of the cgroup, by block device.
+# TYPE cgroup_io_writes_total counter
+cgroup_io_writes_total{cgroup="/kubepods/podabc/0123",container_id="0123",device="8:0",pod_uid="abc"} 2
+# HELP cgroup_memory_limit_bytes Memory limit of the cgroup. Unset if the cgroup has no limit.
+# TYPE cgroup_memory_limit_bytes gauge
+cgroup_memory_limit_bytes{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 2.097152e+06
+# HELP cgroup_memory_rss_bytes Anonymous memory used by the cgroup.
+# TYPE cgroup_memory_rss_bytes gauge
+cgroup_memory_rss_bytes{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 524288
+# HELP cgroup_memory_usage_bytes Memory used by the cgroup, including the page cache.
+# TYPE cgroup_memory_usage_bytes gauge
+cgroup_memory_usage_bytes{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 1.048576e+06
+cgroup_memory_usage_bytes{cgroup="/kubepods/podabc/4567",container_id="4567",pod_uid="abc"} 4096
+# HELP cgroup_memory_working_set_bytes Memory used by the cgroup, excluding inactive page cache.
+# TYPE cgroup_memory_working_set_bytes gauge
+cgroup_memory_working_set_bytes{cgroup="/kubepods/podabc/0123",container_id="0123",pod_uid="abc"} 786432
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect)))
+}
+
+func TestCollector_V1(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"cpuacct/docker/abc/cpuacct.usage":                 "3000000000\n",
+		"cpuacct/docker/abc/cpuacct.stat":                  "user 200\nsystem 100\n",
+		"cpu/docker/abc/cpu.stat":                          "nr_periods 10\nnr_throttled 4\nthrottled_time 2000000000\n",
+		"memory/docker/abc/memory.usage_in_bytes":          "1048576\n",
+		"memory/docker/abc/memory.limit_in_bytes":          "9223372036854771712\n",
+		"memory/docker/abc/memory.stat":                    "total_rss 524288\ntotal_inactive_file 1048576\n",
+		"blkio/docker/abc/blkio.throttle.io_service_bytes": "8:0 Read 4096\n8:0 Write 8192\nTotal 12288\n",
+		"blkio/docker/abc/blkio.throttle.io_serviced":      "8:0 Read 1\n8:0 Write 2\nTotal 3\n",
+	})
+
+	c, err := newCollector(log.NewNopLogger(), Arguments{
+		CgroupRoot: root,
+		Matches:    []Match{{Regex: `^/docker/(?P<container_id>[^/]+)$`}},
+	})
+	require.NoError(t, err)
+
+	expect := `
+# HELP cgroup_cpu_system_seconds_total CPU time consumed by the cgroup in kernel mode.
+# TYPE cgroup_cpu_system_seconds_total counter
+cgroup_cpu_system_seconds_total{cgroup="/docker/abc",container_id="abc"} 1
+# HELP cgroup_cpu_throttled_periods_total Number of periods the cgroup was throttled in.
+# TYPE cgroup_cpu_throttled_periods_total counter
+cgroup_cpu_throttled_periods_total{cgroup="/docker/abc",container_id="abc"} 4
+# HELP cgroup_cpu_throttled_seconds_total Total time the cgroup was throttled for.
+# TYPE cgroup_cpu_throttled_seconds_total counter
+cgroup_cpu_throttled_seconds_total{cgroup="/docker/abc",container_id="abc"} 2
+# HELP cgroup_cpu_usage_seconds_total Total CPU time consumed by the cgroup.
+# TYPE cgroup_cpu_usage_seconds_total counter
+cgroup_cpu_usage_seconds_total{cgroup="/docker/abc",container_id="abc"} 3
+# HELP cgroup_cpu_user_seconds_total CPU time consumed by the cgroup in user mode.
+# TYPE cgroup_cpu_user_seconds_total counter
+cgroup_cpu_user_seconds_total{cgroup="/docker/abc",container_id="abc"} 2
+# HELP cgroup_io_read_bytes_total Bytes read by the cgroup, by block device.
+# TYPE cgroup_io_read_bytes_total counter
+cgroup_io_read_bytes_total{cgroup="/docker/abc",container_id="abc",device="8:0"} 4096
+# HELP cgroup_io_reads_total Read operations of the cgroup, by block device.
+# TYPE cgroup_io_reads_total counter
+cgroup_io_reads_total{cgroup="/docker/abc",container_id="abc",device="8:0"} 1
+# HELP cgroup_io_write_bytes_total Bytes written by the cgroup, by block device.
+# TYPE cgroup_io_write_bytes_total counter
+cgroup_io_write_bytes_total{cgroup="/docker/abc",container_id="abc",device="8:0"} 8192
+# HELP cgroup_io_writes_total Write operations of the cgroup, by block device.
+# TYPE cgroup_io_writes_total counter
+cgroup_io_writes_total{cgroup="/docker/abc",container_id="abc",device="8:0"} 2
+# HELP cgroup_memory_rss_bytes Anonymous memory used by the cgroup.
+# TYPE cgroup_memory_rss_bytes gauge
+cgroup_memory_rss_bytes{cgroup="/docker/abc",container_id="abc"} 524288
+# HELP cgroup_memory_usage_bytes Memory used by the cgroup, including the page cache.
+# TYPE cgroup_memory_usage_bytes gauge
+cgroup_memory_usage_bytes{cgroup="/docker/abc",container_id="abc"} 1.048576e+06
+# HELP cgroup_memory_working_set_bytes Memory used by the cgroup, excluding inactive page cache.
+# TYPE cgroup_memory_working_set_bytes gauge
+cgroup_memory_working_set_bytes{cgroup="/docker/abc",container_id="abc"} 0
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect)))
+}
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverCfg := `
+		cgroup_root = "/host/sys/fs/cgroup"
+
+		match {
+			regex = "^/docker/(?P<container_id>[^/]+)$"
+		}`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+	require.Equal(t, "/host/sys/fs/cgroup", args.CgroupRoot)
+	require.Len(t, args.Matches, 1)
+
+	err := river.Unmarshal([]byte(`match { regex = "(?P<cgroup>.*)" }`), &args)
+	require.ErrorContains(t, err, "reserved")
+}
@@ -0,0 +1,408 @@
+package cgroups
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// cgroupLabel is the label holding the path of a cgroup, relative to the
+// cgroup root.
+const cgroupLabel = "cgroup"
+
+// userHZ is the unit of the times in the cpuacct.stat file of cgroup v1.
+const userHZ = 100
+
+// unlimitedMemory is the smallest memory limit cgroup v1 reports when no limit
+// is set, rounded down to a page boundary.
+const unlimitedMemory = 1 << 62
+
+// collector reads the usage of CPU, memory, and IO of cgroups from the cgroup
+// filesystem on each scrape.
+type collector struct {
+	log     log.Logger
+	root    string
+	matches []*regexp.Regexp
+	labels  []string // Labels extracted from the cgroup paths, excluding cgroupLabel.
+
+	cpuUsage      *prometheus.Desc
+	cpuUser       *prometheus.Desc
+	cpuSystem     *prometheus.Desc
+	cpuThrottled  *prometheus.Desc
+	cpuThrottledS *prometheus.Desc
+	memUsage      *prometheus.Desc
+	memLimit      *prometheus.Desc
+	memWorkingSet *prometheus.Desc
+	memRSS        *prometheus.Desc
+	ioReadBytes   *prometheus.Desc
+	ioWriteBytes  *prometheus.Desc
+	ioReads       *prometheus.Desc
+	ioWrites      *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*collector)(nil)
+
+func newCollector(l log.Logger, args Arguments) (*collector, error) {
+	c := &collector{log: l, root: args.CgroupRoot}
+
+	labelSet := make(map[string]struct{})
+	for _, m := range args.Matches {
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return nil, err
+		}
+		c.matches = append(c.matches, re)
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				labelSet[name] = struct{}{}
+			}
+		}
+	}
+	for name := range labelSet {
+		c.labels = append(c.labels, name)
+	}
+	sort.Strings(c.labels)
+
+	labels := append([]string{cgroupLabel}, c.labels...)
+	deviceLabels := append(append([]string{}, labels...), "device")
+	desc := func(name, help string, labels []string) *prometheus.Desc {
+		return prometheus.NewDesc("cgroup_"+name, help, labels, nil)
+	}
+
+	c.cpuUsage = desc("cpu_usage_seconds_total", "Total CPU time consumed by the cgroup.", labels)
+	c.cpuUser = desc("cpu_user_seconds_total", "CPU time consumed by the cgroup in user mode.", labels)
+	c.cpuSystem = desc("cpu_system_seconds_total", "CPU time consumed by the cgroup in kernel mode.", labels)
+	c.cpuThrottled = desc("cpu_throttled_periods_total", "Number of periods the cgroup was throttled in.", labels)
+	c.cpuThrottledS = desc("cpu_throttled_seconds_total", "Total time the cgroup was throttled for.", labels)
+	c.memUsage = desc("memory_usage_bytes", "Memory used by the cgroup, including the page cache.", labels)
+	c.memLimit = desc("memory_limit_bytes", "Memory limit of the cgroup. Unset if the cgroup has no limit.", labels)
+	c.memWorkingSet = desc("memory_working_set_bytes", "Memory used by the cgroup, excluding inactive page cache.", labels)
+	c.memRSS = desc("memory_rss_bytes", "Anonymous memory used by the cgroup.", labels)
+	c.ioReadBytes = desc("io_read_bytes_total", "Bytes read by the cgroup, by block device.", deviceLabels)
+	c.ioWriteBytes = desc("io_write_bytes_total", "Bytes written by the cgroup, by block device.", deviceLabels)
+	c.ioReads = desc("io_reads_total", "Read operations of the cgroup, by block device.", deviceLabels)
+	c.ioWrites = desc("io_writes_total", "Write operations of the cgroup, by block device.", deviceLabels)
+	return c, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.cpuUsage, c.cpuUser, c.cpuSystem, c.cpuThrottled, c.cpuThrottledS,
+		c.memUsage, c.memLimit, c.memWorkingSet, c.memRSS,
+		c.ioReadBytes, c.ioWriteBytes, c.ioReads, c.ioWrites,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	// cgroup v2 mounts a single unified hierarchy with a cgroup.controllers
+	// file at its root.
+	if _, err := os.Stat(filepath.Join(c.root, "cgroup.controllers")); err == nil {
+		c.walk(c.root, func(rel string, labels []string) { c.collectV2(ch, rel, labels) })
+		return
+	}
+
+	// cgroup v1 mounts one hierarchy per controller. The cgroups of the
+	// cpuacct hierarchy are assumed to exist in the other hierarchies.
+	c.walk(filepath.Join(c.root, "cpuacct"), func(rel string, labels []string) { c.collectV1(ch, rel, labels) })
+}
+
+// walk calls fn for each cgroup below dir which is selected by the matches,
+// with its path relative to dir and the values of its labels.
+func (c *collector) walk(dir string, fn func(rel string, labels []string)) {
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// cgroups can be removed while walking them.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = "/" + filepath.ToSlash(rel)
+		if rel == "/." {
+			rel = "/"
+		}
+
+		if labels, ok := c.match(rel); ok {
+			fn(rel, labels)
+		}
+		return nil
+	})
+	if err != nil {
+		level.Warn(c.log).Log("msg", "failed to walk cgroups", "dir", dir, "err", err)
+	}
+}
+
+// match returns the label values of the cgroup at rel, and whether it's
+// selected. The first match whose regex matches rel sets the labels.
+func (c *collector) match(rel string) ([]string, bool) {
+	values := make([]string, len(c.labels)+1)
+	values[0] = rel
+	if len(c.matches) == 0 {
+		return values, true
+	}
+
+	for _, re := range c.matches {
+		groups := re.FindStringSubmatch(rel)
+		if groups == nil {
+			continue
+		}
+		for i, name := range re.SubexpNames() {
+			if name == "" {
+				continue
+			}
+			idx := sort.SearchStrings(c.labels, name)
+			values[idx+1] = groups[i]
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func (c *collector) collectV2(ch chan<- prometheus.Metric, rel string, labels []string) {
+	dir := filepath.Join(c.root, rel)
+
+	if stat, err := readKeyValues(filepath.Join(dir, "cpu.stat")); err == nil {
+		c.sendIf(ch, c.cpuUsage, prometheus.CounterValue, stat, "usage_usec", 1e-6, labels)
+		c.sendIf(ch, c.cpuUser, prometheus.CounterValue, stat, "user_usec", 1e-6, labels)
+		c.sendIf(ch, c.cpuSystem, prometheus.CounterValue, stat, "system_usec", 1e-6, labels)
+		c.sendIf(ch, c.cpuThrottled, prometheus.CounterValue, stat, "nr_throttled", 1, labels)
+		c.sendIf(ch, c.cpuThrottledS, prometheus.CounterValue, stat, "throttled_usec", 1e-6, labels)
+	}
+
+	usage, usageErr := readUint(filepath.Join(dir, "memory.current"))
+	if usageErr == nil {
+		ch <- prometheus.MustNewConstMetric(c.memUsage, prometheus.GaugeValue, usage, labels...)
+	}
+	if limit, err := readUint(filepath.Join(dir, "memory.max")); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.memLimit, prometheus.GaugeValue, limit, labels...)
+	}
+	if stat, err := readKeyValues(filepath.Join(dir, "memory.stat")); err == nil {
+		c.sendIf(ch, c.memRSS, prometheus.GaugeValue, stat, "anon", 1, labels)
+		if usageErr == nil {
+			ch <- prometheus.MustNewConstMetric(c.memWorkingSet, prometheus.GaugeValue, workingSet(usage, stat["inactive_file"]), labels...)
+		}
+	}
+
+	if devices, err := readIOStatV2(filepath.Join(dir, "io.stat")); err == nil {
+		for _, dev := range devices {
+			devLabels := append(append([]string{}, labels...), dev.device)
+			ch <- prometheus.MustNewConstMetric(c.ioReadBytes, prometheus.CounterValue, dev.readBytes, devLabels...)
+			ch <- prometheus.MustNewConstMetric(c.ioWriteBytes, prometheus.CounterValue, dev.writeBytes, devLabels...)
+			ch <- prometheus.MustNewConstMetric(c.ioReads, prometheus.CounterValue, dev.reads, devLabels...)
+			ch <- prometheus.MustNewConstMetric(c.ioWrites, prometheus.CounterValue, dev.writes, devLabels...)
+		}
+	}
+}
+
+func (c *collector) collectV1(ch chan<- prometheus.Metric, rel string, labels []string) {
+	cpuacct := filepath.Join(c.root, "cpuacct", rel)
+	if usage, err := readUint(filepath.Join(cpuacct, "cpuacct.usage")); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.cpuUsage, prometheus.CounterValue, usage*1e-9, labels...)
+	}
+	if stat, err := readKeyValues(filepath.Join(cpuacct, "cpuacct.stat")); err == nil {
+		c.sendIf(ch, c.cpuUser, prometheus.CounterValue, stat, "user", 1.0/userHZ, labels)
+		c.sendIf(ch, c.cpuSystem, prometheus.CounterValue, stat, "system", 1.0/userHZ, labels)
+	}
+	if stat, err := readKeyValues(filepath.Join(c.root, "cpu", rel, "cpu.stat")); err == nil {
+		c.sendIf(ch, c.cpuThrottled, prometheus.CounterValue, stat, "nr_throttled", 1, labels)
+		c.sendIf(ch, c.cpuThrottledS, prometheus.CounterValue, stat, "throttled_time", 1e-9, labels)
+	}
+
+	memory := filepath.Join(c.root, "memory", rel)
+	usage, usageErr := readUint(filepath.Join(memory, "memory.usage_in_bytes"))
+	if usageErr == nil {
+		ch <- prometheus.MustNewConstMetric(c.memUsage, prometheus.GaugeValue, usage, labels...)
+	}
+	if limit, err := readUint(filepath.Join(memory, "memory.limit_in_bytes")); err == nil && limit < unlimitedMemory {
+		ch <- prometheus.MustNewConstMetric(c.memLimit, prometheus.GaugeValue, limit, labels...)
+	}
+	if stat, err := readKeyValues(filepath.Join(memory, "memory.stat")); err == nil {
+		c.sendIf(ch, c.memRSS, prometheus.GaugeValue, stat, "total_rss", 1, labels)
+		if usageErr == nil {
+			ch <- prometheus.MustNewConstMetric(c.memWorkingSet, prometheus.GaugeValue, workingSet(usage, stat["total_inactive_file"]), labels...)
+		}
+	}
+
+	blkio := filepath.Join(c.root, "blkio", rel)
+	bytes, bytesErr := readIOStatV1(filepath.Join(blkio, "blkio.throttle.io_service_bytes"))
+	ops, opsErr := readIOStatV1(filepath.Join(blkio, "blkio.throttle.io_serviced"))
+	for _, dev := range sortedDevices(bytes, ops) {
+		devLabels := append(append([]string{}, labels...), dev)
+		if bytesErr == nil {
+			ch <- prometheus.MustNewConstMetric(c.ioReadBytes, prometheus.CounterValue, bytes[dev]["Read"], devLabels...)
+			ch <- prometheus.MustNewConstMetric(c.ioWriteBytes, prometheus.CounterValue, bytes[dev]["Write"], devLabels...)
+		}
+		if opsErr == nil {
+			ch <- prometheus.MustNewConstMetric(c.ioReads, prometheus.CounterValue, ops[dev]["Read"], devLabels...)
+			ch <- prometheus.MustNewConstMetric(c.ioWrites, prometheus.CounterValue, ops[dev]["Write"], devLabels...)
+		}
+	}
+}
+
+// sendIf sends the value of key in stat, multiplied by scale, if stat holds
+// key.
+func (c *collector) sendIf(ch chan<- prometheus.Metric, desc *prometheus.Desc, vt prometheus.ValueType, stat map[string]float64, key string, scale float64, labels []string) {
+	if v, ok := stat[key]; ok {
+		ch <- prometheus.MustNewConstMetric(desc, vt, v*scale, labels...)
+	}
+}
+
+// workingSet returns the memory usage without the inactive page cache, which
+// the kernel can reclaim without pressure.
+func workingSet(usage, inactiveFile float64) float64 {
+	return math.Max(usage-inactiveFile, 0)
+}
+
+// readUint reads a file holding a single unsigned integer. Files holding
+// "max", which cgroup v2 uses for unset limits, return an error.
+func readUint(path string) (float64, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := strconv.ParseUint(strings.TrimSpace(string(bb)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(v), nil
+}
+
+// readKeyValues reads a file holding one "key value" pair per line, such as
+// cpu.stat or memory.stat.
+func readKeyValues(path string) (map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[string]float64)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		res[fields[0]] = float64(v)
+	}
+	return res, sc.Err()
+}
+
+// deviceIO is the IO of a cgroup on a block device.
+type deviceIO struct {
+	device                               string
+	readBytes, writeBytes, reads, writes float64
+}
+
+// readIOStatV2 reads the io.stat file of cgroup v2, which holds lines such as
+// "8:0 rbytes=4096 wbytes=0 rios=1 wios=0 dbytes=0 dios=0".
+func readIOStatV2(path string) ([]deviceIO, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []deviceIO
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		dev := deviceIO{device: fields[0]}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "rbytes":
+				dev.readBytes = float64(v)
+			case "wbytes":
+				dev.writeBytes = float64(v)
+			case "rios":
+				dev.reads = float64(v)
+			case "wios":
+				dev.writes = float64(v)
+			}
+		}
+		res = append(res, dev)
+	}
+	return res, sc.Err()
+}
+
+// readIOStatV1 reads a blkio file of cgroup v1, which holds lines such as
+// "8:0 Read 4096", and returns the values by device and operation.
+func readIOStatV1(path string) (map[string]map[string]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res := make(map[string]map[string]float64)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 3 {
+			// Skips the "Total" line.
+			continue
+		}
+		v, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		if res[fields[0]] == nil {
+			res[fields[0]] = make(map[string]float64)
+		}
+		res[fields[0]][fields[1]] = float64(v)
+	}
+	return res, sc.Err()
+}
+
+func sortedDevices(stats ...map[string]map[string]float64) []string {
+	set := make(map[string]struct{})
+	for _, stat := range stats {
+		for dev := range stat {
+			set[dev] = struct{}{}
+		}
+	}
+	res := make([]string, 0, len(set))
+	for dev := range set {
+		res = append(res, dev)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,79 @@
+package databricks
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/databricks_exporter"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	config_util "github.com/prometheus/common/config"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.databricks",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.NewWithTargetBuilder(createExporter, "databricks", customizeTarget),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+func customizeTarget(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+	target := baseTarget
+
+	if u, err := url.Parse(a.WorkspaceURL); err == nil {
+		target["instance"] = u.Host
+	}
+	return []discovery.Target{target}
+}
+
+// DefaultArguments holds the default settings for the databricks exporter.
+var DefaultArguments = Arguments{
+	JobsLookback:    databricks_exporter.DefaultConfig.JobsLookback,
+	BillingLookback: databricks_exporter.DefaultConfig.BillingLookback,
+	Timeout:         databricks_exporter.DefaultConfig.Timeout,
+}
+
+// Arguments controls the databricks exporter.
+type Arguments struct {
+	WorkspaceURL    string            `river:"workspace_url,attr"`
+	ClientID        string            `river:"client_id,attr"`
+	ClientSecret    rivertypes.Secret `river:"client_secret,attr"`
+	WarehouseID     string            `river:"warehouse_id,attr,optional"`
+	JobsLookback    time.Duration     `river:"jobs_lookback,attr,optional"`
+	BillingLookback time.Duration     `river:"billing_lookback,attr,optional"`
+	Timeout         time.Duration     `river:"timeout,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	return a.Convert().Validate()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *databricks_exporter.Config {
+	return &databricks_exporter.Config{
+		WorkspaceURL:    a.WorkspaceURL,
+		ClientID:        a.ClientID,
+		ClientSecret:    config_util.Secret(a.ClientSecret),
+		WarehouseID:     a.WarehouseID,
+		JobsLookback:    a.JobsLookback,
+		BillingLookback: a.BillingLookback,
+		Timeout:         a.Timeout,
+	}
+}
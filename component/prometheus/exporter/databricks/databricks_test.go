@@ -0,0 +1,68 @@
+package databricks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations/databricks_exporter"
+	"github.com/grafana/agent/pkg/river"
+	config_util "github.com/prometheus/common/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+	workspace_url = "https://dbc-a1b2c3d4-e5f6.cloud.databricks.com"
+	client_id     = "some_client"
+	client_secret = "some_secret"
+	warehouse_id  = "some_warehouse"
+	jobs_lookback = "30m"
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	expected := DefaultArguments
+	expected.WorkspaceURL = "https://dbc-a1b2c3d4-e5f6.cloud.databricks.com"
+	expected.ClientID = "some_client"
+	expected.ClientSecret = "some_secret"
+	expected.WarehouseID = "some_warehouse"
+	expected.JobsLookback = 30 * time.Minute
+	require.Equal(t, expected, args)
+}
+
+func TestRiverUnmarshal_Invalid(t *testing.T) {
+	riverConfig := `
+	workspace_url = "dbc-a1b2c3d4-e5f6.cloud.databricks.com"
+	client_id     = "some_client"
+	client_secret = "some_secret"
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.EqualError(t, err, `workspace_url "dbc-a1b2c3d4-e5f6.cloud.databricks.com" must include a scheme and host`)
+}
+
+func TestConvert(t *testing.T) {
+	args := DefaultArguments
+	args.WorkspaceURL = "https://dbc-a1b2c3d4-e5f6.cloud.databricks.com"
+	args.ClientID = "some_client"
+	args.ClientSecret = "some_secret"
+
+	expected := databricks_exporter.DefaultConfig
+	expected.WorkspaceURL = "https://dbc-a1b2c3d4-e5f6.cloud.databricks.com"
+	expected.ClientID = "some_client"
+	expected.ClientSecret = config_util.Secret("some_secret")
+	require.Equal(t, expected, *args.Convert())
+}
+
+func TestCustomizeTarget(t *testing.T) {
+	args := Arguments{WorkspaceURL: "https://dbc-a1b2c3d4-e5f6.cloud.databricks.com"}
+
+	baseTarget := discovery.Target{}
+	newTargets := customizeTarget(baseTarget, args)
+	require.Equal(t, 1, len(newTargets))
+	require.Equal(t, "dbc-a1b2c3d4-e5f6.cloud.databricks.com", newTargets[0]["instance"])
+}
@@ -0,0 +1,98 @@
+package dcgm
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/dcgm_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.dcgm",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.NewWithTargetBuilder(createExporter, "dcgm", buildGPUTargets),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// buildGPUTargets creates a target for each of the configured GPUs, or a
+// single target for all GPUs if none are configured.
+func buildGPUTargets(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+	if len(a.GPUs) == 0 {
+		return []discovery.Target{baseTarget}
+	}
+
+	targets := make([]discovery.Target, 0, len(a.GPUs))
+	for _, gpu := range a.GPUs {
+		target := make(discovery.Target)
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+
+		target["job"] = target["job"] + "/gpu" + gpu
+		target["__param_gpu"] = gpu
+		target["__meta_agent_dcgm_gpu"] = gpu
+
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// DefaultArguments holds the default arguments for the prometheus.exporter.dcgm component.
+var DefaultArguments = Arguments{
+	DCGMIPath:         dcgm_exporter.DefaultConfig.DCGMIPath,
+	HostEngineAddress: dcgm_exporter.DefaultConfig.HostEngineAddress,
+	Timeout:           dcgm_exporter.DefaultConfig.Timeout,
+}
+
+// Arguments configures the prometheus.exporter.dcgm component.
+type Arguments struct {
+	// DCGMIPath is the path to the dcgmi binary used to query DCGM.
+	DCGMIPath string `river:"dcgmi_path,attr,optional"`
+
+	// HostEngineAddress is the address of the DCGM host engine (host:port).
+	HostEngineAddress string `river:"host_engine_address,attr,optional"`
+
+	// GPUs are the IDs of the GPUs to collect metrics from.
+	GPUs []string `river:"gpus,attr,optional"`
+
+	// Timeout is the timeout for querying DCGM.
+	Timeout time.Duration `river:"timeout,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.DCGMIPath == "" {
+		return fmt.Errorf("dcgmi_path must not be empty")
+	}
+	if a.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	return nil
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a Arguments) Convert() *dcgm_exporter.Config {
+	return &dcgm_exporter.Config{
+		DCGMIPath:         a.DCGMIPath,
+		HostEngineAddress: a.HostEngineAddress,
+		GPUs:              a.GPUs,
+		Timeout:           a.Timeout,
+	}
+}
@@ -0,0 +1,87 @@
+package dcgm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations/dcgm_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+dcgmi_path          = "/usr/bin/dcgmi"
+host_engine_address = "gpu-node:5555"
+gpus                = ["0", "1"]
+timeout             = "5s"`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+
+	expected := Arguments{
+		DCGMIPath:         "/usr/bin/dcgmi",
+		HostEngineAddress: "gpu-node:5555",
+		GPUs:              []string{"0", "1"},
+		Timeout:           5 * time.Second,
+	}
+	require.Equal(t, expected, args)
+}
+
+func TestRiverUnmarshalDefaults(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(``), &args))
+	require.Equal(t, DefaultArguments, args)
+}
+
+func TestRiverUnmarshalInvalid(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`timeout = "0s"`), &args)
+	require.EqualError(t, err, "timeout must be greater than 0")
+}
+
+func TestRiverConvert(t *testing.T) {
+	args := Arguments{
+		DCGMIPath:         "/usr/bin/dcgmi",
+		HostEngineAddress: "gpu-node:5555",
+		GPUs:              []string{"0"},
+		Timeout:           5 * time.Second,
+	}
+
+	expected := &dcgm_exporter.Config{
+		DCGMIPath:         "/usr/bin/dcgmi",
+		HostEngineAddress: "gpu-node:5555",
+		GPUs:              []string{"0"},
+		Timeout:           5 * time.Second,
+	}
+	require.Equal(t, expected, args.Convert())
+}
+
+func TestBuildGPUTargets(t *testing.T) {
+	baseTarget := discovery.Target{
+		"job":      "integrations/dcgm",
+		"instance": "gpu-node",
+	}
+
+	targets := buildGPUTargets(baseTarget, DefaultArguments)
+	require.Equal(t, []discovery.Target{baseTarget}, targets)
+
+	args := DefaultArguments
+	args.GPUs = []string{"0", "1"}
+	targets = buildGPUTargets(baseTarget, args)
+	require.Equal(t, []discovery.Target{
+		{
+			"job":                   "integrations/dcgm/gpu0",
+			"instance":              "gpu-node",
+			"__param_gpu":           "0",
+			"__meta_agent_dcgm_gpu": "0",
+		},
+		{
+			"job":                   "integrations/dcgm/gpu1",
+			"instance":              "gpu-node",
+			"__param_gpu":           "1",
+			"__meta_agent_dcgm_gpu": "1",
+		},
+	}, targets)
+}
@@ -10,14 +10,24 @@ import (
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/throttle"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/pkg/integrations"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 )
 
 // Creator is a function provided by an implementation to create a concrete exporter instance.
 type Creator func(component.Options, component.Arguments) (integrations.Integration, error)
 
+// Throttled is implemented by the arguments of exporters whose scrapes can be
+// capped to a share of the host CPU.
+type Throttled interface {
+	// CPUQuota returns the percentage of the host CPU scrapes may use, or 0
+	// to not throttle scrapes.
+	CPUQuota() float64
+}
+
 // Exports are simply a list of targets for a scraper to consume.
 type Exports struct {
 	Targets []discovery.Target `river:"targets,attr"`
@@ -36,6 +46,9 @@ type Component struct {
 
 	exporter       integrations.Integration
 	metricsHandler http.Handler
+
+	throttle         *throttle.Throttle
+	throttledSeconds prometheus.Counter
 }
 
 // New creates a new exporter component.
@@ -87,6 +100,12 @@ func (c *Component) Update(args component.Arguments) error {
 	if err != nil {
 		return err
 	}
+	if t, ok := args.(Throttled); ok {
+		if err := c.throttle.SetQuota(t.CPUQuota()); err != nil {
+			return err
+		}
+	}
+
 	c.mut.Lock()
 	c.exporter = exporter
 
@@ -122,7 +141,20 @@ func newExporter(creator Creator, name string, targetBuilderFunc func(discovery.
 			reload:            make(chan struct{}, 1),
 			creator:           creator,
 			targetBuilderFunc: targetBuilderFunc,
+			throttledSeconds: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "agent_exporter_throttled_seconds_total",
+				Help: "Total time scrapes of the exporter were delayed to stay within its CPU quota.",
+			}),
+		}
+		if err := opts.Registerer.Register(c.throttledSeconds); err != nil {
+			return nil, err
 		}
+
+		var err error
+		if c.throttle, err = throttle.New(0); err != nil {
+			return nil, err
+		}
+
 		jobName := fmt.Sprintf("integrations/%s", name)
 		instance := defaultInstance()
 
@@ -156,7 +188,20 @@ func (c *Component) getHttpHandler(integration integrations.Integration) http.Ha
 			w.WriteHeader(http.StatusInternalServerError)
 		})
 	}
-	return h
+	return c.throttledHandler(h)
+}
+
+// throttledHandler delays scrapes served by h while the exporter exceeds its
+// CPU quota.
+func (c *Component) throttledHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		waited, err := c.throttle.Do(r.Context(), func() { h.ServeHTTP(w, r) })
+		c.throttledSeconds.Add(waited.Seconds())
+		if err != nil {
+			// The scraper gave up while the scrape was delayed.
+			http.Error(w, fmt.Sprintf("scrape throttled: %s", err), http.StatusServiceUnavailable)
+		}
+	})
 }
 
 // defaultInstance retrieves the hostname identifying the machine the process is
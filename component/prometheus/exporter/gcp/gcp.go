@@ -0,0 +1,77 @@
+package gcp
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/gcp_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.gcp",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "gcp"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+// DefaultArguments holds the default settings for the gcp exporter.
+var DefaultArguments = Arguments{
+	ClientTimeout:         gcp_exporter.DefaultConfig.ClientTimeout,
+	RequestInterval:       gcp_exporter.DefaultConfig.RequestInterval,
+	RequestOffset:         gcp_exporter.DefaultConfig.RequestOffset,
+	IngestDelay:           gcp_exporter.DefaultConfig.IngestDelay,
+	DropDelegatedProjects: gcp_exporter.DefaultConfig.DropDelegatedProjects,
+	AggregateDeltas:       gcp_exporter.DefaultConfig.AggregateDeltas,
+	AggregateDeltasTTL:    gcp_exporter.DefaultConfig.AggregateDeltasTTL,
+}
+
+// Arguments controls the gcp exporter.
+type Arguments struct {
+	ProjectIDs            []string      `river:"project_ids,attr"`
+	MetricPrefixes        []string      `river:"metrics_prefixes,attr"`
+	MetricDenyPrefixes    []string      `river:"metrics_deny_prefixes,attr,optional"`
+	ExtraFilters          []string      `river:"extra_filters,attr,optional"`
+	RequestInterval       time.Duration `river:"request_interval,attr,optional"`
+	RequestOffset         time.Duration `river:"request_offset,attr,optional"`
+	IngestDelay           bool          `river:"ingest_delay,attr,optional"`
+	DropDelegatedProjects bool          `river:"drop_delegated_projects,attr,optional"`
+	ClientTimeout         time.Duration `river:"gcp_client_timeout,attr,optional"`
+	AggregateDeltas       bool          `river:"aggregate_deltas,attr,optional"`
+	AggregateDeltasTTL    time.Duration `river:"aggregate_deltas_ttl,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	return a.Convert().Validate()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *gcp_exporter.Config {
+	return &gcp_exporter.Config{
+		ProjectIDs:            a.ProjectIDs,
+		MetricPrefixes:        a.MetricPrefixes,
+		MetricDenyPrefixes:    a.MetricDenyPrefixes,
+		ExtraFilters:          a.ExtraFilters,
+		RequestInterval:       a.RequestInterval,
+		RequestOffset:         a.RequestOffset,
+		IngestDelay:           a.IngestDelay,
+		DropDelegatedProjects: a.DropDelegatedProjects,
+		ClientTimeout:         a.ClientTimeout,
+		AggregateDeltas:       a.AggregateDeltas,
+		AggregateDeltasTTL:    a.AggregateDeltasTTL,
+	}
+}
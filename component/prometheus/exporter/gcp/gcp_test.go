@@ -0,0 +1,78 @@
+package gcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/integrations/gcp_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+	project_ids           = ["project1"]
+	metrics_prefixes      = ["monitoring.googleapis.com/"]
+	metrics_deny_prefixes = ["monitoring.googleapis.com/billing"]
+	ingest_delay          = true
+	aggregate_deltas_ttl  = "1h"
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	expected := gcp_exporter.Config{
+		ProjectIDs:         []string{"project1"},
+		MetricPrefixes:     []string{"monitoring.googleapis.com/"},
+		MetricDenyPrefixes: []string{"monitoring.googleapis.com/billing"},
+		RequestInterval:    5 * time.Minute,
+		IngestDelay:        true,
+		ClientTimeout:      15 * time.Second,
+		AggregateDeltas:    true,
+		AggregateDeltasTTL: time.Hour,
+	}
+	require.Equal(t, expected, *args.Convert())
+}
+
+func TestRiverUnmarshal_Invalid(t *testing.T) {
+	tt := []struct {
+		name        string
+		riverConfig string
+		expectErr   string
+	}{
+		{
+			name: "deny prefix outside of the metrics prefixes",
+			riverConfig: `
+			project_ids           = ["project1"]
+			metrics_prefixes      = ["pubsub.googleapis.com/"]
+			metrics_deny_prefixes = ["monitoring.googleapis.com/billing"]
+			`,
+			expectErr: "metrics_deny_prefixes monitoring.googleapis.com/billing does not start with any of the metrics_prefixes",
+		},
+		{
+			name: "zero aggregation ttl",
+			riverConfig: `
+			project_ids          = ["project1"]
+			metrics_prefixes     = ["pubsub.googleapis.com/"]
+			aggregate_deltas_ttl = "0s"
+			`,
+			expectErr: "aggregate_deltas_ttl must be greater than 0",
+		},
+		{
+			name: "no projects",
+			riverConfig: `
+			project_ids      = []
+			metrics_prefixes = ["pubsub.googleapis.com/"]
+			`,
+			expectErr: "no project_ids defined",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.riverConfig), &args)
+			require.ErrorContains(t, err, tc.expectErr)
+		})
+	}
+}
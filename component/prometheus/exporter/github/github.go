@@ -2,6 +2,7 @@ package github
 
 import (
 	"net/url"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/component/discovery"
@@ -36,13 +37,31 @@ func customizeTarget(baseTarget discovery.Target, args component.Arguments) []di
 	}
 
 	target["instance"] = url.Host
-	return []discovery.Target{target}
+	if !a.PerOrganizationTargets {
+		return []discovery.Target{target}
+	}
+
+	// Each organization is scraped by its own target, and the base target
+	// only collects metrics of the repositories and users.
+	targets := []discovery.Target{target}
+	for _, org := range a.Organizations {
+		orgTarget := make(discovery.Target, len(target)+2)
+		for k, v := range target {
+			orgTarget[k] = v
+		}
+		orgTarget["job"] = orgTarget["job"] + "/" + org
+		orgTarget["__param_organization"] = org
+		orgTarget["__meta_agent_github_organization"] = org
+		targets = append(targets, orgTarget)
+	}
+	return targets
 }
 
 // DefaultArguments holds non-zero default options for Arguments when it is
 // unmarshaled from river.
 var DefaultArguments = Arguments{
-	APIURL: github_exporter.DefaultConfig.APIURL,
+	APIURL:               github_exporter.DefaultConfig.APIURL,
+	WorkflowRunsLookback: github_exporter.DefaultConfig.WorkflowRunsLookback,
 }
 
 type Arguments struct {
@@ -52,6 +71,15 @@ type Arguments struct {
 	Users         []string          `river:"users,attr,optional"`
 	APIToken      rivertypes.Secret `river:"api_token,attr,optional"`
 	APITokenFile  string            `river:"api_token_file,attr,optional"`
+
+	AppID             int64             `river:"app_id,attr,optional"`
+	AppInstallationID int64             `river:"app_installation_id,attr,optional"`
+	AppPrivateKey     rivertypes.Secret `river:"app_private_key,attr,optional"`
+	AppPrivateKeyFile string            `river:"app_private_key_file,attr,optional"`
+
+	CollectWorkflowRuns    bool          `river:"collect_workflow_runs,attr,optional"`
+	WorkflowRunsLookback   time.Duration `river:"workflow_runs_lookback,attr,optional"`
+	PerOrganizationTargets bool          `river:"per_organization_targets,attr,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
@@ -59,6 +87,11 @@ func (a *Arguments) SetToDefault() {
 	*a = DefaultArguments
 }
 
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	return a.Convert().Validate()
+}
+
 func (a *Arguments) Convert() *github_exporter.Config {
 	return &github_exporter.Config{
 		APIURL:        a.APIURL,
@@ -67,5 +100,14 @@ func (a *Arguments) Convert() *github_exporter.Config {
 		Users:         a.Users,
 		APIToken:      config_util.Secret(a.APIToken),
 		APITokenFile:  a.APITokenFile,
+
+		AppID:             a.AppID,
+		AppInstallationID: a.AppInstallationID,
+		AppPrivateKey:     config_util.Secret(a.AppPrivateKey),
+		AppPrivateKeyFile: a.AppPrivateKeyFile,
+
+		CollectWorkflowRuns:    a.CollectWorkflowRuns,
+		WorkflowRunsLookback:   a.WorkflowRunsLookback,
+		PerOrganizationTargets: a.PerOrganizationTargets,
 	}
 }
@@ -2,6 +2,7 @@ package github
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/pkg/river"
@@ -55,3 +56,53 @@ func TestCustomizeTarget_Valid(t *testing.T) {
 	require.Equal(t, 1, len(newTargets))
 	require.Equal(t, "some-other-api.github.com", newTargets[0]["instance"])
 }
+
+func TestCustomizeTarget_PerOrganization(t *testing.T) {
+	args := Arguments{
+		APIURL:                 "https://api.github.com",
+		Organizations:          []string{"grafana", "prometheus"},
+		PerOrganizationTargets: true,
+	}
+
+	baseTarget := discovery.Target{"job": "integrations/github"}
+	newTargets := customizeTarget(baseTarget, args)
+	require.Equal(t, []discovery.Target{
+		{"job": "integrations/github", "instance": "api.github.com"},
+		{
+			"job":                              "integrations/github/grafana",
+			"instance":                         "api.github.com",
+			"__param_organization":             "grafana",
+			"__meta_agent_github_organization": "grafana",
+		},
+		{
+			"job":                              "integrations/github/prometheus",
+			"instance":                         "api.github.com",
+			"__param_organization":             "prometheus",
+			"__meta_agent_github_organization": "prometheus",
+		},
+	}, newTargets)
+}
+
+func TestUnmarshalRiver_App(t *testing.T) {
+	riverCfg := `
+		app_id                = 1234
+		app_installation_id   = 42
+		app_private_key_file  = "/etc/github-app.pem"
+		repositories          = ["grafana/agent"]
+		collect_workflow_runs = true
+`
+	var args Arguments
+	err := river.Unmarshal([]byte(riverCfg), &args)
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), args.AppID)
+	require.Equal(t, int64(42), args.AppInstallationID)
+	require.Equal(t, "/etc/github-app.pem", args.AppPrivateKeyFile)
+	require.True(t, args.CollectWorkflowRuns)
+	require.Equal(t, time.Hour, args.WorkflowRunsLookback)
+
+	err = river.Unmarshal([]byte(`
+		app_id         = 1234
+		api_token_file = "/etc/github-api-token"
+`), &args)
+	require.EqualError(t, err, "api_token and api_token_file can't be used with GitHub App authentication")
+}
@@ -0,0 +1,176 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// CloudFacts describes the cloud instance the agent runs on.
+type CloudFacts struct {
+	Provider     string `json:"provider"`
+	Region       string `json:"region,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+	InstanceID   string `json:"instance_id,omitempty"`
+	InstanceType string `json:"instance_type,omitempty"`
+}
+
+// Addresses of the instance metadata services of the supported cloud
+// providers.
+const (
+	awsMetadataURL   = "http://169.254.169.254"
+	gcpMetadataURL   = "http://metadata.google.internal"
+	azureMetadataURL = "http://169.254.169.254"
+)
+
+// cloudClient queries the instance metadata services of cloud providers.
+type cloudClient struct {
+	client *http.Client
+
+	awsURL, gcpURL, azureURL string
+}
+
+func newCloudClient(timeout time.Duration) *cloudClient {
+	return &cloudClient{
+		client:   &http.Client{Timeout: timeout},
+		awsURL:   awsMetadataURL,
+		gcpURL:   gcpMetadataURL,
+		azureURL: azureMetadataURL,
+	}
+}
+
+// facts returns the facts about the cloud instance the agent runs on, trying
+// each provider in turn. It returns nil if no metadata service responded.
+func (c *cloudClient) facts(ctx context.Context) *CloudFacts {
+	for _, f := range []func(context.Context) (*CloudFacts, error){c.aws, c.gcp, c.azure} {
+		if facts, err := f(ctx); err == nil {
+			return facts
+		}
+	}
+	return nil
+}
+
+// aws queries the EC2 instance metadata service, using a session token as
+// required by IMDSv2.
+func (c *cloudClient) aws(ctx context.Context) (*CloudFacts, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.awsURL+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.awsURL+"/latest/dynamic/instance-identity/document", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", string(token))
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return &CloudFacts{
+		Provider:     "aws",
+		Region:       doc.Region,
+		Zone:         doc.AvailabilityZone,
+		InstanceID:   doc.InstanceID,
+		InstanceType: doc.InstanceType,
+	}, nil
+}
+
+// gcp queries the Compute Engine metadata server.
+func (c *cloudClient) gcp(ctx context.Context) (*CloudFacts, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.gcpURL+"/computeMetadata/v1/instance/?recursive=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		ID          json.Number `json:"id"`
+		MachineType string      `json:"machineType"` // projects/PROJECT/machineTypes/TYPE
+		Zone        string      `json:"zone"`        // projects/PROJECT/zones/ZONE
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+
+	// Zones are named after their region, such as us-central1-a.
+	zone := path.Base(doc.Zone)
+	region := zone
+	if i := strings.LastIndexByte(zone, '-'); i > 0 {
+		region = zone[:i]
+	}
+	return &CloudFacts{
+		Provider:     "gcp",
+		Region:       region,
+		Zone:         zone,
+		InstanceID:   doc.ID.String(),
+		InstanceType: path.Base(doc.MachineType),
+	}, nil
+}
+
+// azure queries the Azure Instance Metadata Service.
+func (c *cloudClient) azure(ctx context.Context) (*CloudFacts, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.azureURL+"/metadata/instance/compute?api-version=2021-02-01", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	body, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+		VMID     string `json:"vmId"`
+		VMSize   string `json:"vmSize"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return &CloudFacts{
+		Provider:     "azure",
+		Region:       doc.Location,
+		Zone:         doc.Zone,
+		InstanceID:   doc.VMID,
+		InstanceType: doc.VMSize,
+	}, nil
+}
+
+func (c *cloudClient) do(req *http.Request) ([]byte, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
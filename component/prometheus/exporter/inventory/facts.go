@@ -0,0 +1,111 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/config/instrumentation"
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// Facts describes the host the agent runs on.
+type Facts struct {
+	Hostname      string `json:"hostname"`
+	OS            string `json:"os"`
+	OSName        string `json:"os_name,omitempty"`
+	OSVersion     string `json:"os_version,omitempty"`
+	KernelVersion string `json:"kernel_version,omitempty"`
+	Arch          string `json:"arch"`
+
+	// Packages holds the number of installed packages by package manager.
+	Packages map[string]int `json:"packages,omitempty"`
+
+	Cloud *CloudFacts `json:"cloud,omitempty"`
+
+	AgentVersion string `json:"agent_version"`
+	ConfigHash   string `json:"config_hash,omitempty"`
+}
+
+// hostFacts collects the facts about the operating system and the installed
+// packages of the host, with paths resolved relative to rootDir.
+func hostFacts(ctx context.Context, rootDir string) Facts {
+	f := Facts{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+
+	// Errors are ignored, since gopsutil fills in whatever it could find.
+	info, _ := host.InfoWithContext(ctx)
+	if info != nil {
+		f.Hostname = info.Hostname
+		f.OSName = info.Platform
+		f.OSVersion = info.PlatformVersion
+		f.KernelVersion = info.KernelVersion
+	}
+	if f.Hostname == "" {
+		f.Hostname, _ = os.Hostname()
+	}
+
+	f.Packages = installedPackages(rootDir)
+	return f
+}
+
+// withAgentFacts returns f with the facts about the running agent.
+func withAgentFacts(f Facts) Facts {
+	f.AgentVersion = build.Version
+	f.ConfigHash = instrumentation.ConfigHash()
+	return f
+}
+
+// packageDatabases holds the database of each supported package manager and
+// the prefix of the lines counted as installed packages.
+var packageDatabases = []struct {
+	manager string
+	path    string
+	prefix  string
+}{
+	{manager: "dpkg", path: "var/lib/dpkg/status", prefix: "Status: install ok installed"},
+	{manager: "apk", path: "lib/apk/db/installed", prefix: "P:"},
+}
+
+// installedPackages returns the number of packages installed by each package
+// manager found on the host.
+func installedPackages(rootDir string) map[string]int {
+	var res map[string]int
+	for _, db := range packageDatabases {
+		n, err := countLines(filepath.Join(rootDir, db.path), db.prefix)
+		if err != nil {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]int)
+		}
+		res[db.manager] = n
+	}
+	return res
+}
+
+// countLines returns the number of lines of the file at path starting with
+// prefix.
+func countLines(path string, prefix string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var n int
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if bytes.HasPrefix(scanner.Bytes(), []byte(prefix)) {
+			n++
+		}
+	}
+	return n, scanner.Err()
+}
@@ -0,0 +1,244 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.inventory",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "inventory"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	inv := newInventory(opts.Logger, args.(Arguments), "/")
+	return integrations.NewCollectorIntegration(
+		"inventory",
+		integrations.WithCollectors(inv),
+		integrations.WithRunner(inv.run),
+	), nil
+}
+
+// DefaultArguments holds the default arguments for the
+// prometheus.exporter.inventory component.
+var DefaultArguments = Arguments{
+	LogInterval:          24 * time.Hour,
+	RefreshInterval:      time.Hour,
+	CloudMetadataTimeout: 2 * time.Second,
+}
+
+// Arguments configures the prometheus.exporter.inventory component.
+type Arguments struct {
+	// Receivers for the log entries describing the host.
+	ForwardTo   []loki.LogsReceiver `river:"forward_to,attr,optional"`
+	LogInterval time.Duration       `river:"log_interval,attr,optional"`
+
+	RefreshInterval time.Duration `river:"refresh_interval,attr,optional"`
+
+	CloudMetadata        bool          `river:"cloud_metadata,attr,optional"`
+	CloudMetadataTimeout time.Duration `river:"cloud_metadata_timeout,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.LogInterval <= 0 {
+		return fmt.Errorf("log_interval must be greater than 0")
+	}
+	if a.RefreshInterval <= 0 {
+		return fmt.Errorf("refresh_interval must be greater than 0")
+	}
+	if a.CloudMetadata && a.CloudMetadataTimeout <= 0 {
+		return fmt.Errorf("cloud_metadata_timeout must be greater than 0")
+	}
+	return nil
+}
+
+// logJob is the job label of the log entries describing the host.
+const logJob = "integrations/inventory"
+
+// inventory collects the facts about the host, exposes them as metrics, and
+// periodically sends them as log entries.
+type inventory struct {
+	log     log.Logger
+	args    Arguments
+	rootDir string
+	cloud   *cloudClient
+
+	mut   sync.RWMutex
+	facts Facts
+
+	infoDesc     *prometheus.Desc
+	packagesDesc *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*inventory)(nil)
+
+func newInventory(l log.Logger, args Arguments, rootDir string) *inventory {
+	inv := &inventory{
+		log:     l,
+		args:    args,
+		rootDir: rootDir,
+
+		infoDesc: prometheus.NewDesc(
+			"agent_host_info",
+			"Facts about the host the agent runs on.",
+			[]string{
+				"hostname", "os", "os_name", "os_version", "kernel_version", "arch",
+				"agent_version", "config_hash",
+				"cloud_provider", "cloud_region", "cloud_zone", "cloud_instance_id", "cloud_instance_type",
+			},
+			nil,
+		),
+		packagesDesc: prometheus.NewDesc(
+			"agent_host_packages",
+			"Number of packages installed on the host, by package manager.",
+			[]string{"manager"},
+			nil,
+		),
+	}
+	if args.CloudMetadata {
+		inv.cloud = newCloudClient(args.CloudMetadataTimeout)
+	}
+
+	// Host facts are cheap to collect, so they're available before the first
+	// refresh. Cloud facts are only collected in run, since querying metadata
+	// services can take up to cloud_metadata_timeout.
+	inv.facts = hostFacts(context.Background(), rootDir)
+	return inv
+}
+
+// run refreshes the facts and sends them as log entries until ctx is
+// canceled.
+func (inv *inventory) run(ctx context.Context) error {
+	inv.refresh(ctx)
+	inv.sendLog(ctx)
+
+	refreshTicker := time.NewTicker(inv.args.RefreshInterval)
+	defer refreshTicker.Stop()
+	logTicker := time.NewTicker(inv.args.LogInterval)
+	defer logTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-refreshTicker.C:
+			inv.refresh(ctx)
+		case <-logTicker.C:
+			inv.sendLog(ctx)
+		}
+	}
+}
+
+// refresh collects the facts about the host again. Cloud facts are kept once
+// found, since they don't change during the lifetime of an instance.
+func (inv *inventory) refresh(ctx context.Context) {
+	facts := hostFacts(ctx, inv.rootDir)
+
+	inv.mut.RLock()
+	facts.Cloud = inv.facts.Cloud
+	inv.mut.RUnlock()
+
+	if inv.cloud != nil && facts.Cloud == nil {
+		facts.Cloud = inv.cloud.facts(ctx)
+		if facts.Cloud == nil {
+			level.Debug(inv.log).Log("msg", "no cloud instance metadata service found")
+		}
+	}
+
+	inv.mut.Lock()
+	inv.facts = facts
+	inv.mut.Unlock()
+}
+
+// current returns the current facts, including the facts about the agent.
+func (inv *inventory) current() Facts {
+	inv.mut.RLock()
+	defer inv.mut.RUnlock()
+	return withAgentFacts(inv.facts)
+}
+
+// sendLog sends the current facts as a JSON log entry.
+func (inv *inventory) sendLog(ctx context.Context) {
+	if len(inv.args.ForwardTo) == 0 {
+		return
+	}
+
+	facts := inv.current()
+	line, err := json.Marshal(facts)
+	if err != nil {
+		level.Error(inv.log).Log("msg", "failed to encode host facts", "err", err)
+		return
+	}
+
+	entry := loki.Entry{
+		Labels: model.LabelSet{
+			"job":      logJob,
+			"instance": model.LabelValue(facts.Hostname),
+		},
+		Entry: logproto.Entry{
+			Timestamp: time.Now(),
+			Line:      string(line),
+		},
+	}
+	for _, receiver := range inv.args.ForwardTo {
+		select {
+		case <-ctx.Done():
+			return
+		case receiver <- entry:
+		}
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (inv *inventory) Describe(ch chan<- *prometheus.Desc) {
+	ch <- inv.infoDesc
+	ch <- inv.packagesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (inv *inventory) Collect(ch chan<- prometheus.Metric) {
+	facts := inv.current()
+
+	var cloud CloudFacts
+	if facts.Cloud != nil {
+		cloud = *facts.Cloud
+	}
+	ch <- prometheus.MustNewConstMetric(inv.infoDesc, prometheus.GaugeValue, 1,
+		facts.Hostname, facts.OS, facts.OSName, facts.OSVersion, facts.KernelVersion, facts.Arch,
+		facts.AgentVersion, facts.ConfigHash,
+		cloud.Provider, cloud.Region, cloud.Zone, cloud.InstanceID, cloud.InstanceType,
+	)
+
+	managers := make([]string, 0, len(facts.Packages))
+	for manager := range facts.Packages {
+		managers = append(managers, manager)
+	}
+	sort.Strings(managers)
+	for _, manager := range managers {
+		ch <- prometheus.MustNewConstMetric(inv.packagesDesc, prometheus.GaugeValue, float64(facts.Packages[manager]), manager)
+	}
+}
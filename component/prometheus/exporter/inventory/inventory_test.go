@@ -0,0 +1,191 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/loki"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+		forward_to     = []
+		log_interval   = "12h"
+		cloud_metadata = true
+	`
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+
+	expected := DefaultArguments
+	expected.ForwardTo = []loki.LogsReceiver{}
+	expected.LogInterval = 12 * time.Hour
+	expected.CloudMetadata = true
+	require.Equal(t, expected, args)
+
+	err := river.Unmarshal([]byte(`log_interval = "0s"`), &args)
+	require.EqualError(t, err, "log_interval must be greater than 0")
+}
+
+func TestInstalledPackages(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "var/lib/dpkg/status"), `Package: adduser
+Status: install ok installed
+
+Package: removed
+Status: deinstall ok config-files
+
+Package: bash
+Status: install ok installed
+`)
+	writeFile(t, filepath.Join(root, "lib/apk/db/installed"), "C:Q1\nP:musl\nV:1.2.3\n\nC:Q2\nP:busybox\n")
+
+	require.Equal(t, map[string]int{"dpkg": 2, "apk": 2}, installedPackages(root))
+	require.Nil(t, installedPackages(t.TempDir()))
+}
+
+func TestInventory_Collect(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "var/lib/dpkg/status"), "Package: bash\nStatus: install ok installed\n")
+
+	inv := newInventory(util.TestLogger(t), DefaultArguments, root)
+	inv.facts.Hostname = "host-a"
+	inv.facts.OSName = "ubuntu"
+	inv.facts.OSVersion = "22.04"
+	inv.facts.KernelVersion = "5.15.0"
+	inv.facts.Cloud = &CloudFacts{Provider: "aws", Region: "us-east-1", Zone: "us-east-1a", InstanceID: "i-123", InstanceType: "t3.micro"}
+
+	reg := prometheus.NewRegistry()
+	require.NoError(t, reg.Register(inv))
+
+	facts := inv.current()
+	expected := `
+		# HELP agent_host_info Facts about the host the agent runs on.
+		# TYPE agent_host_info gauge
+		agent_host_info{agent_version="` + facts.AgentVersion + `",arch="` + facts.Arch + `",cloud_instance_id="i-123",cloud_instance_type="t3.micro",cloud_provider="aws",cloud_region="us-east-1",cloud_zone="us-east-1a",config_hash="",hostname="host-a",kernel_version="5.15.0",os="` + facts.OS + `",os_name="ubuntu",os_version="22.04"} 1
+		# HELP agent_host_packages Number of packages installed on the host, by package manager.
+		# TYPE agent_host_packages gauge
+		agent_host_packages{manager="dpkg"} 1
+	`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected)))
+}
+
+func TestInventory_SendLog(t *testing.T) {
+	receiver := make(loki.LogsReceiver)
+	args := DefaultArguments
+	args.ForwardTo = []loki.LogsReceiver{receiver}
+
+	inv := newInventory(util.TestLogger(t), args, t.TempDir())
+	inv.facts.Hostname = "host-a"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = inv.run(ctx) }()
+
+	select {
+	case entry := <-receiver:
+		require.Equal(t, logJob, string(entry.Labels["job"]))
+
+		var facts Facts
+		require.NoError(t, json.Unmarshal([]byte(entry.Line), &facts))
+		require.NotEmpty(t, facts.Hostname)
+		require.Equal(t, inv.current().AgentVersion, facts.AgentVersion)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no log entry received")
+	}
+}
+
+func TestCloudClient(t *testing.T) {
+	unavailable := httptest.NewServer(http.NotFoundHandler())
+	defer unavailable.Close()
+
+	t.Run("aws", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+				_, _ = w.Write([]byte("token"))
+			case r.URL.Path == "/latest/dynamic/instance-identity/document" && r.Header.Get("X-aws-ec2-metadata-token") == "token":
+				_, _ = w.Write([]byte(`{"region":"us-east-1","availabilityZone":"us-east-1a","instanceId":"i-123","instanceType":"t3.micro"}`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}))
+		defer srv.Close()
+
+		c := newCloudClient(time.Second)
+		c.awsURL, c.gcpURL, c.azureURL = srv.URL, unavailable.URL, unavailable.URL
+		require.Equal(t, &CloudFacts{
+			Provider:     "aws",
+			Region:       "us-east-1",
+			Zone:         "us-east-1a",
+			InstanceID:   "i-123",
+			InstanceType: "t3.micro",
+		}, c.facts(context.Background()))
+	})
+
+	t.Run("gcp", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"id":1234567890,"machineType":"projects/1/machineTypes/e2-medium","zone":"projects/1/zones/us-central1-a"}`))
+		}))
+		defer srv.Close()
+
+		c := newCloudClient(time.Second)
+		c.awsURL, c.gcpURL, c.azureURL = unavailable.URL, srv.URL, unavailable.URL
+		require.Equal(t, &CloudFacts{
+			Provider:     "gcp",
+			Region:       "us-central1",
+			Zone:         "us-central1-a",
+			InstanceID:   "1234567890",
+			InstanceType: "e2-medium",
+		}, c.facts(context.Background()))
+	})
+
+	t.Run("azure", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata") != "true" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"location":"westeurope","zone":"1","vmId":"abc","vmSize":"Standard_B1s"}`))
+		}))
+		defer srv.Close()
+
+		c := newCloudClient(time.Second)
+		c.awsURL, c.gcpURL, c.azureURL = unavailable.URL, unavailable.URL, srv.URL
+		require.Equal(t, &CloudFacts{
+			Provider:     "azure",
+			Region:       "westeurope",
+			Zone:         "1",
+			InstanceID:   "abc",
+			InstanceType: "Standard_B1s",
+		}, c.facts(context.Background()))
+	})
+
+	t.Run("none", func(t *testing.T) {
+		c := newCloudClient(time.Second)
+		c.awsURL, c.gcpURL, c.azureURL = unavailable.URL, unavailable.URL, unavailable.URL
+		require.Nil(t, c.facts(context.Background()))
+	})
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
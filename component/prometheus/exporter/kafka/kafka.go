@@ -2,6 +2,8 @@ package kafka
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Shopify/sarama"
 	"github.com/grafana/agent/component"
@@ -64,16 +66,29 @@ func (a *Arguments) SetToDefault() {
 
 // Validate implements river.Validator.
 func (a *Arguments) Validate() error {
+	if len(a.KafkaURIs) == 0 {
+		return fmt.Errorf("at least one address must be provided in kafka_uris")
+	}
 	if a.Instance == "" && len(a.KafkaURIs) > 1 {
 		return fmt.Errorf("an automatic value for `instance` cannot be determined from %d kafka servers, manually provide one for this component", len(a.KafkaURIs))
 	}
+	if a.UseSASL {
+		switch strings.ToLower(a.SASLMechanism) {
+		case "plain", "scram-sha256", "scram-sha512":
+		default:
+			return fmt.Errorf("invalid sasl_mechanism %q: must be \"plain\", \"scram-sha256\", or \"scram-sha512\" when use_sasl is true", a.SASLMechanism)
+		}
+	}
+	if _, err := time.ParseDuration(a.MetadataRefreshInterval); err != nil {
+		return fmt.Errorf("invalid metadata_refresh_interval: %w", err)
+	}
 	return nil
 }
 
 func customizeTarget(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
 	a := args.(Arguments)
 	target := baseTarget
-	if len(a.KafkaURIs) > 1 {
+	if a.Instance != "" {
 		target["instance"] = a.Instance
 	} else {
 		target["instance"] = a.KafkaURIs[0]
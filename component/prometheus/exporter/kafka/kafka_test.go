@@ -68,6 +68,44 @@ func TestUnmarshalInvalid(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestValidate(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect string
+	}{
+		{"no kafka_uris", ``, "at least one address must be provided in kafka_uris"},
+		{"no instance", `kafka_uris = ["localhost:9092", "localhost:19092"]`, "an automatic value for `instance` cannot be determined"},
+		{"invalid sasl_mechanism", `
+			kafka_uris     = ["localhost:9092"]
+			use_sasl       = true
+			sasl_mechanism = "gssapi"
+		`, `invalid sasl_mechanism "gssapi"`},
+		{"missing sasl_mechanism", `
+			kafka_uris = ["localhost:9092"]
+			use_sasl   = true
+		`, `invalid sasl_mechanism ""`},
+		{"invalid metadata_refresh_interval", `
+			kafka_uris                = ["localhost:9092"]
+			metadata_refresh_interval = "often"
+		`, "invalid metadata_refresh_interval"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			require.ErrorContains(t, river.Unmarshal([]byte(tc.config), &args), tc.expect)
+		})
+	}
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		kafka_uris     = ["localhost:9092"]
+		use_sasl       = true
+		sasl_mechanism = "SCRAM-SHA512"
+		use_tls        = true
+	`), &args))
+}
+
 func TestRiverConvert(t *testing.T) {
 	orig := Arguments{
 		Instance:                "example",
@@ -106,4 +144,11 @@ func TestCustomizeTarget(t *testing.T) {
 	newTargets := customizeTarget(baseTarget, args)
 	require.Equal(t, 1, len(newTargets))
 	require.Equal(t, "example", newTargets[0]["instance"])
+
+	// An explicit instance is used even with a single Kafka server.
+	args = Arguments{Instance: "example", KafkaURIs: []string{"localhost:9200"}}
+	require.Equal(t, "example", customizeTarget(discovery.Target{}, args)[0]["instance"])
+
+	args = Arguments{KafkaURIs: []string{"localhost:9200"}}
+	require.Equal(t, "localhost:9200", customizeTarget(discovery.Target{}, args)[0]["instance"])
 }
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/postgres_exporter"
+	config_util "github.com/prometheus/common/config"
+)
+
+// databaseParam is the query parameter used to select the database a
+// per-database target collects metrics from.
+const databaseParam = "database"
+
+// discoveryTimeout is the maximum time spent enumerating the databases of an
+// instance.
+const discoveryTimeout = 10 * time.Second
+
+// listDatabases lists databases. It's a variable so it can be replaced in
+// tests.
+var listDatabases = defaultListDatabases
+
+// defaultListDatabases returns the names of the databases of the instance dsn
+// connects to which accept connections.
+func defaultListDatabases(ctx context.Context, dsn string) ([]string, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "SELECT datname FROM pg_database WHERE datallowconn = true AND datistemplate = false ORDER BY datname")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to list databases: %w", err)
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// buildDatabaseTargets creates a target for each database of the instance
+// which passes the allowlist and denylist of a. It returns an error if the
+// databases can't be listed.
+func buildDatabaseTargets(baseTarget discovery.Target, a Arguments) ([]discovery.Target, error) {
+	dsn := string(a.DataSourceNames[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), discoveryTimeout)
+	defer cancel()
+
+	names, err := listDatabases(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := baseTarget["instance"]
+	if s, err := parsePostgresURL(dsn); err == nil {
+		if _, ok := s["host"]; !ok {
+			s["host"] = "localhost"
+			s["port"] = "5432"
+		}
+		instance = "postgresql://" + s["host"]
+		if p, ok := s["port"]; ok {
+			instance += ":" + p
+		}
+	}
+
+	var targets []discovery.Target
+	for _, name := range names {
+		if !a.AutoDiscovery.allowed(name) {
+			continue
+		}
+
+		target := make(discovery.Target, len(baseTarget)+3)
+		for k, v := range baseTarget {
+			target[k] = v
+		}
+		target["instance"] = instance + "/" + name
+		target["__param_"+databaseParam] = name
+		target["__meta_agent_postgres_database"] = name
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// allowed reports whether metrics should be collected from the database
+// called name.
+func (ad AutoDiscovery) allowed(name string) bool {
+	for _, denied := range ad.DatabaseDenylist {
+		if name == denied {
+			return false
+		}
+	}
+	if len(ad.DatabaseAllowlist) == 0 {
+		return true
+	}
+	for _, allowed := range ad.DatabaseAllowlist {
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// databaseDSN returns dsn changed to connect to the database called name.
+func databaseDSN(dsn string, name string) (string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", err
+		}
+		u.Path = "/" + name
+		u.RawPath = ""
+		return u.String(), nil
+	}
+
+	// Later keywords override earlier ones in connection strings, so there's
+	// no need to remove the existing dbname.
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return fmt.Sprintf("%s dbname='%s'", dsn, escaper.Replace(name)), nil
+}
+
+// databaseIntegration wraps the postgres_exporter integration to serve the
+// metrics of a single database when the database query parameter is set.
+// Requests without the parameter are served by the wrapped integration.
+type databaseIntegration struct {
+	integrations.Integration
+
+	logger log.Logger
+	config postgres_exporter.Config
+	filter AutoDiscovery
+
+	mut      sync.Mutex
+	handlers map[string]http.Handler // Database name -> handler.
+}
+
+func newDatabaseIntegration(l log.Logger, c *postgres_exporter.Config, filter AutoDiscovery) (integrations.Integration, error) {
+	i, err := c.NewIntegration(l)
+	if err != nil {
+		return nil, err
+	}
+	return &databaseIntegration{
+		Integration: i,
+		logger:      l,
+		config:      *c,
+		filter:      filter,
+		handlers:    make(map[string]http.Handler),
+	}, nil
+}
+
+// MetricsHandler implements integrations.Integration.
+func (i *databaseIntegration) MetricsHandler() (http.Handler, error) {
+	next, err := i.Integration.MetricsHandler()
+	if err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get(databaseParam)
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !i.filter.allowed(name) {
+			http.Error(w, fmt.Sprintf("database %q is not allowed by the autodiscovery settings", name), http.StatusBadRequest)
+			return
+		}
+
+		h, err := i.databaseHandler(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), nil
+}
+
+// databaseHandler returns the handler serving the metrics of the database
+// called name, creating it on first use.
+func (i *databaseIntegration) databaseHandler(name string) (http.Handler, error) {
+	i.mut.Lock()
+	defer i.mut.Unlock()
+
+	if h, ok := i.handlers[name]; ok {
+		return h, nil
+	}
+
+	dsn, err := databaseDSN(string(i.config.DataSourceNames[0]), name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build DSN for database %q: %w", name, err)
+	}
+
+	c := i.config
+	c.DataSourceNames = []config_util.Secret{config_util.Secret(dsn)}
+	c.AutodiscoverDatabases = false
+	c.IncludeDatabases, c.ExcludeDatabases = nil, nil
+
+	integration, err := c.NewIntegration(log.With(i.logger, "database", name))
+	if err != nil {
+		return nil, err
+	}
+	h, err := integration.MetricsHandler()
+	if err != nil {
+		return nil, err
+	}
+	i.handlers[name] = h
+	return h, nil
+}
@@ -25,11 +25,23 @@ func init() {
 
 func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
 	a := args.(Arguments)
+	if a.AutoDiscovery.perDatabaseTargets() {
+		return newDatabaseIntegration(opts.Logger, a.Convert(), a.AutoDiscovery)
+	}
 	return a.Convert().NewIntegration(opts.Logger)
 }
 
 func customizeTarget(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
 	a := args.(Arguments)
+
+	// If the databases can't be listed, fall back to a single target which
+	// collects metrics from all discovered databases.
+	if a.AutoDiscovery.perDatabaseTargets() {
+		if targets, err := buildDatabaseTargets(baseTarget, a); err == nil && len(targets) > 0 {
+			return targets
+		}
+	}
+
 	target := baseTarget
 
 	dsn := a.convertDataSourceNames()
@@ -122,6 +134,14 @@ type AutoDiscovery struct {
 	Enabled           bool     `river:"enabled,attr,optional"`
 	DatabaseAllowlist []string `river:"database_allowlist,attr,optional"`
 	DatabaseDenylist  []string `river:"database_denylist,attr,optional"`
+
+	// PerDatabaseTargets exports a target for each discovered database instead
+	// of a single target for all of them.
+	PerDatabaseTargets bool `river:"per_database_targets,attr,optional"`
+}
+
+func (ad AutoDiscovery) perDatabaseTargets() bool {
+	return ad.Enabled && ad.PerDatabaseTargets
 }
 
 // SetToDefault implements river.Defaulter.
@@ -129,6 +149,19 @@ func (a *Arguments) SetToDefault() {
 	*a = DefaultArguments
 }
 
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if a.AutoDiscovery.PerDatabaseTargets {
+		if !a.AutoDiscovery.Enabled {
+			return fmt.Errorf("autodiscovery must be enabled to use per_database_targets")
+		}
+		if len(a.DataSourceNames) != 1 {
+			return fmt.Errorf("exactly one data_source_names entry must be specified to use per_database_targets, got %d", len(a.DataSourceNames))
+		}
+	}
+	return nil
+}
+
 func (a *Arguments) Convert() *postgres_exporter.Config {
 	return &postgres_exporter.Config{
 		DataSourceNames:        a.convertDataSourceNames(),
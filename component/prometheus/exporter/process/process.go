@@ -2,6 +2,7 @@ package process
 
 import (
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/throttle"
 	"github.com/grafana/agent/component/prometheus/exporter"
 	"github.com/grafana/agent/pkg/integrations"
 	"github.com/grafana/agent/pkg/integrations/process_exporter"
@@ -41,6 +42,8 @@ type Arguments struct {
 	Threads    bool   `river:"track_threads,attr,optional"`
 	SMaps      bool   `river:"gather_smaps,attr,optional"`
 	Recheck    bool   `river:"recheck_on_scrape,attr,optional"`
+
+	CPUQuotaPercent float64 `river:"cpu_quota,attr,optional"`
 }
 
 // MatcherGroup taken and converted to River from github.com/ncabatoff/process-exporter/config
@@ -56,6 +59,16 @@ func (a *Arguments) SetToDefault() {
 	*a = DefaultArguments
 }
 
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	return throttle.ValidateQuota(a.CPUQuotaPercent)
+}
+
+// CPUQuota implements exporter.Throttled.
+func (a Arguments) CPUQuota() float64 {
+	return a.CPUQuotaPercent
+}
+
 func (a *Arguments) Convert() *process_exporter.Config {
 	return &process_exporter.Config{
 		ProcessExporter: convertMatcherGroups(a.ProcessExporter),
@@ -1,14 +1,18 @@
 package snmp
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/throttle"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus/exporter"
 	"github.com/grafana/agent/pkg/integrations"
 	"github.com/grafana/agent/pkg/integrations/snmp_exporter"
 	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/prometheus/common/model"
 	snmp_config "github.com/prometheus/snmp_exporter/config"
 )
 
@@ -31,11 +35,14 @@ func buildSNMPTargets(baseTarget discovery.Target, args component.Arguments) []d
 	var targets []discovery.Target
 
 	a := args.(Arguments)
-	for _, tgt := range a.Targets {
+	for _, tgt := range a.allTargets() {
 		target := make(discovery.Target)
 		for k, v := range baseTarget {
 			target[k] = v
 		}
+		for k, v := range tgt.labels {
+			target[k] = v
+		}
 
 		target["job"] = target["job"] + "/" + tgt.Name
 		target["__param_target"] = tgt.Target
@@ -52,6 +59,68 @@ func buildSNMPTargets(baseTarget discovery.Target, args component.Arguments) []d
 	return targets
 }
 
+// Keys of discovered targets which configure how the target is scraped.
+// Other keys which don't start with "__" are added as labels to the target.
+const (
+	targetKeyName       = "name"
+	targetKeyAddress    = "address"
+	targetKeyModule     = "module"
+	targetKeyWalkParams = "walk_params"
+)
+
+// snmpTarget is an SNMP target along with the extra labels of the discovered
+// target it was created from.
+type snmpTarget struct {
+	SNMPTarget
+	labels map[string]string
+}
+
+// allTargets returns the targets defined by target blocks followed by the
+// ones from the targets argument.
+func (a Arguments) allTargets() []snmpTarget {
+	res := make([]snmpTarget, 0, len(a.Targets)+len(a.TargetsList))
+	for _, tgt := range a.Targets {
+		res = append(res, snmpTarget{SNMPTarget: tgt})
+	}
+	for _, t := range a.TargetsList {
+		res = append(res, convertDiscoveredTarget(t))
+	}
+	return res
+}
+
+// convertDiscoveredTarget creates an SNMP target from a discovered target.
+// The address is read from the address key, falling back to __address__, and
+// the name defaults to the address.
+func convertDiscoveredTarget(t discovery.Target) snmpTarget {
+	res := snmpTarget{
+		SNMPTarget: SNMPTarget{
+			Name:       t[targetKeyName],
+			Target:     t[targetKeyAddress],
+			Module:     t[targetKeyModule],
+			WalkParams: t[targetKeyWalkParams],
+		},
+		labels: make(map[string]string),
+	}
+	if res.Target == "" {
+		res.Target = t[model.AddressLabel]
+	}
+	if res.Name == "" {
+		res.Name = res.Target
+	}
+
+	for k, v := range t {
+		switch k {
+		case targetKeyName, targetKeyAddress, targetKeyModule, targetKeyWalkParams:
+			continue
+		}
+		if strings.HasPrefix(k, model.ReservedLabelPrefix) {
+			continue
+		}
+		res.labels[k] = v
+	}
+	return res
+}
+
 // SNMPTarget defines a target to be used by the exporter.
 type SNMPTarget struct {
 	Name       string `river:",label"`
@@ -130,9 +199,34 @@ func (w WalkParams) Convert() map[string]snmp_config.WalkParams {
 }
 
 type Arguments struct {
-	ConfigFile string      `river:"config_file,attr"`
-	Targets    TargetBlock `river:"target,block"`
-	WalkParams WalkParams  `river:"walk_param,block,optional"`
+	ConfigFile  string             `river:"config_file,attr"`
+	Targets     TargetBlock        `river:"target,block,optional"`
+	WalkParams  WalkParams         `river:"walk_param,block,optional"`
+	TargetsList []discovery.Target `river:"targets,attr,optional"`
+
+	CPUQuotaPercent float64 `river:"cpu_quota,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if err := throttle.ValidateQuota(a.CPUQuotaPercent); err != nil {
+		return err
+	}
+	if len(a.Targets) == 0 && len(a.TargetsList) == 0 {
+		return fmt.Errorf("at least one target block or an element in the targets argument must be provided")
+	}
+
+	for i, t := range a.TargetsList {
+		if convertDiscoveredTarget(t).Target == "" {
+			return fmt.Errorf("targets[%d]: the %q key or the %s label must be set", i, targetKeyAddress, model.AddressLabel)
+		}
+	}
+	return nil
+}
+
+// CPUQuota implements exporter.Throttled.
+func (a Arguments) CPUQuota() float64 {
+	return a.CPUQuotaPercent
 }
 
 // Convert converts the component's Arguments to the integration's Config.
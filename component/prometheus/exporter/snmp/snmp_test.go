@@ -154,3 +154,67 @@ func TestBuildSNMPTargets(t *testing.T) {
 	require.Equal(t, "if_mib", targets[0]["__param_module"])
 	require.Equal(t, "public", targets[0]["__param_walk_params"])
 }
+
+func TestBuildSNMPTargets_Discovered(t *testing.T) {
+	riverCfg := `
+		config_file = "modules.yml"
+		targets = [
+			{"name" = "network_switch_1", "address" = "192.168.1.2", "module" = "if_mib", "walk_params" = "public", "rack" = "a1"},
+			{"__address__" = "192.168.1.3", "__meta_file" = "devices.json"},
+		]
+		target "network_router_2" {
+			address = "192.168.1.4"
+		}
+		walk_param "public" {
+			version = "2"
+		}
+`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverCfg), &args))
+
+	baseTarget := discovery.Target{
+		model.SchemeLabel:      "http",
+		model.MetricsPathLabel: "component/prometheus.exporter.snmp.default/metrics",
+		"instance":             "prometheus.exporter.snmp.default",
+		"job":                  "integrations/snmp",
+	}
+	targets := buildSNMPTargets(baseTarget, args)
+	require.Equal(t, []discovery.Target{
+		{
+			model.SchemeLabel:      "http",
+			model.MetricsPathLabel: "component/prometheus.exporter.snmp.default/metrics",
+			"instance":             "prometheus.exporter.snmp.default",
+			"job":                  "integrations/snmp/network_router_2",
+			"__param_target":       "192.168.1.4",
+		},
+		{
+			model.SchemeLabel:      "http",
+			model.MetricsPathLabel: "component/prometheus.exporter.snmp.default/metrics",
+			"instance":             "prometheus.exporter.snmp.default",
+			"job":                  "integrations/snmp/network_switch_1",
+			"rack":                 "a1",
+			"__param_target":       "192.168.1.2",
+			"__param_module":       "if_mib",
+			"__param_walk_params":  "public",
+		},
+		{
+			model.SchemeLabel:      "http",
+			model.MetricsPathLabel: "component/prometheus.exporter.snmp.default/metrics",
+			"instance":             "prometheus.exporter.snmp.default",
+			"job":                  "integrations/snmp/192.168.1.3",
+			"__param_target":       "192.168.1.3",
+		},
+	}, targets)
+}
+
+func TestValidate(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`config_file = "modules.yml"`), &args)
+	require.ErrorContains(t, err, "at least one target block or an element in the targets argument must be provided")
+
+	err = river.Unmarshal([]byte(`
+		config_file = "modules.yml"
+		targets     = [{"name" = "network_switch_1"}]
+	`), &args)
+	require.ErrorContains(t, err, `targets[0]: the "address" key or the __address__ label must be set`)
+}
@@ -0,0 +1,225 @@
+package systemd
+
+import (
+	"context"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectTimeout is how long collecting the metrics of all units may take.
+const collectTimeout = 10 * time.Second
+
+// unitStates are the active states units can be in. systemd_unit_state is
+// exposed for each of them, so that units in any state can be counted.
+var unitStates = []string{"active", "activating", "deactivating", "inactive", "failed"}
+
+// systemdConn is the subset of the D-Bus API of systemd used by the
+// collector.
+type systemdConn interface {
+	ListUnitsContext(ctx context.Context) ([]dbus.UnitStatus, error)
+	GetUnitTypePropertiesContext(ctx context.Context, unit, unitType string) (map[string]interface{}, error)
+	Close()
+}
+
+// connector opens a new connection to systemd.
+type connector func(ctx context.Context) (systemdConn, error)
+
+// dbusConnector returns a connector connecting to systemd over D-Bus, either
+// through the system bus or through the private socket of systemd.
+func dbusConnector(private bool) connector {
+	return func(ctx context.Context) (systemdConn, error) {
+		if private {
+			return dbus.NewSystemdConnectionContext(ctx)
+		}
+		return dbus.NewSystemConnectionContext(ctx)
+	}
+}
+
+// collector reads the state of systemd units over D-Bus on each scrape. A
+// new connection is opened for each scrape, so that the collector recovers
+// from restarts of systemd and of the bus.
+type collector struct {
+	log     log.Logger
+	connect connector
+	units   []string
+
+	unitState          *prometheus.Desc
+	serviceRestarts    *prometheus.Desc
+	serviceCPU         *prometheus.Desc
+	serviceMemory      *prometheus.Desc
+	serviceTasks       *prometheus.Desc
+	socketAccepted     *prometheus.Desc
+	socketConnections  *prometheus.Desc
+	socketRefused      *prometheus.Desc
+	timerLastTriggered *prometheus.Desc
+	scrapeSuccess      *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*collector)(nil)
+
+func newCollector(l log.Logger, args Arguments, connect connector) *collector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc("systemd_"+name, help, labels, nil)
+	}
+
+	return &collector{
+		log:     l,
+		connect: connect,
+		units:   args.Units,
+
+		unitState:          desc("unit_state", "Whether the unit is in the active state given by the state label.", "name", "type", "state"),
+		serviceRestarts:    desc("service_restarts_total", "Number of automatic restarts of the service.", "name"),
+		serviceCPU:         desc("service_cpu_seconds_total", "CPU time consumed by the service, from its cgroup accounting.", "name"),
+		serviceMemory:      desc("service_memory_bytes", "Memory used by the service, from its cgroup accounting.", "name"),
+		serviceTasks:       desc("service_tasks", "Number of tasks of the service, from its cgroup accounting.", "name"),
+		socketAccepted:     desc("socket_accepted_connections_total", "Number of connections accepted by the socket.", "name"),
+		socketConnections:  desc("socket_current_connections", "Number of open connections of the socket.", "name"),
+		socketRefused:      desc("socket_refused_connections_total", "Number of connections refused by the socket.", "name"),
+		timerLastTriggered: desc("timer_last_trigger_seconds", "Time the timer last triggered, in seconds since the Unix epoch.", "name"),
+		scrapeSuccess:      desc("scrape_success", "Whether the units were read from systemd successfully."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.unitState, c.serviceRestarts, c.serviceCPU, c.serviceMemory, c.serviceTasks,
+		c.socketAccepted, c.socketConnections, c.socketRefused, c.timerLastTriggered,
+		c.scrapeSuccess,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	if err := c.collect(ctx, ch); err != nil {
+		level.Error(c.log).Log("msg", "failed to collect systemd units", "err", err)
+		ch <- prometheus.MustNewConstMetric(c.scrapeSuccess, prometheus.GaugeValue, 0)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.scrapeSuccess, prometheus.GaugeValue, 1)
+}
+
+func (c *collector) collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	units, err := conn.ListUnitsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, unit := range units {
+		if unit.LoadState != "loaded" || !c.selected(unit.Name) {
+			continue
+		}
+
+		unitType := unitType(unit.Name)
+		for _, state := range unitStates {
+			var value float64
+			if unit.ActiveState == state {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.unitState, prometheus.GaugeValue, value, unit.Name, unitType, state)
+		}
+
+		switch unitType {
+		case "service":
+			c.collectProperties(ctx, ch, conn, unit.Name, "Service", []property{
+				{"NRestarts", c.serviceRestarts, prometheus.CounterValue, 1, false},
+				{"CPUUsageNSec", c.serviceCPU, prometheus.CounterValue, 1e-9, false},
+				{"MemoryCurrent", c.serviceMemory, prometheus.GaugeValue, 1, false},
+				{"TasksCurrent", c.serviceTasks, prometheus.GaugeValue, 1, false},
+			})
+		case "socket":
+			c.collectProperties(ctx, ch, conn, unit.Name, "Socket", []property{
+				{"NAccepted", c.socketAccepted, prometheus.CounterValue, 1, false},
+				{"NConnections", c.socketConnections, prometheus.GaugeValue, 1, false},
+				{"NRefused", c.socketRefused, prometheus.CounterValue, 1, false},
+			})
+		case "timer":
+			c.collectProperties(ctx, ch, conn, unit.Name, "Timer", []property{
+				{"LastTriggerUSec", c.timerLastTriggered, prometheus.GaugeValue, 1e-6, true},
+			})
+		}
+	}
+	return nil
+}
+
+// property is a numeric unit property exposed as a metric, after being
+// multiplied by scale.
+type property struct {
+	name      string
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	scale     float64
+	zeroUnset bool // Whether zero means the property is unset, like for timers which never triggered.
+}
+
+// collectProperties exposes the properties of the unit with the given name.
+// Properties which are unset, such as those of disabled cgroup accounting,
+// aren't exposed.
+func (c *collector) collectProperties(ctx context.Context, ch chan<- prometheus.Metric, conn systemdConn, name, unitType string, props []property) {
+	values, err := conn.GetUnitTypePropertiesContext(ctx, name, unitType)
+	if err != nil {
+		level.Debug(c.log).Log("msg", "failed to get unit properties", "unit", name, "err", err)
+		return
+	}
+
+	for _, p := range props {
+		value, ok := uintProperty(values[p.name])
+		if !ok || (p.zeroUnset && value == 0) {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(p.desc, p.valueType, value*p.scale, name)
+	}
+}
+
+// selected reports whether the unit with the given name matches the unit
+// patterns.
+func (c *collector) selected(name string) bool {
+	if len(c.units) == 0 {
+		return true
+	}
+	for _, pattern := range c.units {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unitType returns the type of a unit from the suffix of its name.
+func unitType(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
+
+// uintProperty returns the value of an unsigned integer property. systemd
+// reports unset properties as the maximum value of their type.
+func uintProperty(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case uint32:
+		return float64(v), v != math.MaxUint32
+	case uint64:
+		return float64(v), v != math.MaxUint64
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,46 @@
+package systemd
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.systemd",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.New(createExporter, "systemd"),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	c := newCollector(opts.Logger, a, dbusConnector(a.Private))
+	return integrations.NewCollectorIntegration("systemd", integrations.WithCollectors(c)), nil
+}
+
+// Arguments configures the prometheus.exporter.systemd component.
+type Arguments struct {
+	// Units holds glob patterns of the names of the units to collect metrics
+	// for. All loaded units are collected if there are none.
+	Units []string `river:"units,attr,optional"`
+
+	// Private connects to the private socket of systemd instead of the system
+	// bus.
+	Private bool `river:"private,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	for _, pattern := range a.Units {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid unit pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
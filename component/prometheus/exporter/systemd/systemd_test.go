@@ -0,0 +1,119 @@
+package systemd
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeConn struct {
+	units []dbus.UnitStatus
+	props map[string]map[string]interface{}
+}
+
+func (f *fakeConn) ListUnitsContext(context.Context) ([]dbus.UnitStatus, error) {
+	return f.units, nil
+}
+
+func (f *fakeConn) GetUnitTypePropertiesContext(_ context.Context, unit, _ string) (map[string]interface{}, error) {
+	return f.props[unit], nil
+}
+
+func (f *fakeConn) Close() {}
+
+func TestCollector(t *testing.T) {
+	conn := &fakeConn{
+		units: []dbus.UnitStatus{
+			{Name: "nginx.service", LoadState: "loaded", ActiveState: "active"},
+			{Name: "backup.timer", LoadState: "loaded", ActiveState: "active"},
+			{Name: "backup.service", LoadState: "loaded", ActiveState: "inactive"},
+			{Name: "sshd.socket", LoadState: "loaded", ActiveState: "failed"},
+			{Name: "missing.service", LoadState: "not-found", ActiveState: "inactive"},
+			{Name: "dev-sda.device", LoadState: "loaded", ActiveState: "active"},
+		},
+		props: map[string]map[string]interface{}{
+			"nginx.service": {
+				"NRestarts":     uint32(2),
+				"CPUUsageNSec":  uint64(1500000000),
+				"MemoryCurrent": uint64(1048576),
+				"TasksCurrent":  uint64(math.MaxUint64), // Accounting disabled.
+			},
+			"backup.service": {
+				"NRestarts":     uint32(0),
+				"CPUUsageNSec":  uint64(math.MaxUint64),
+				"MemoryCurrent": uint64(math.MaxUint64),
+			},
+			"backup.timer": {"LastTriggerUSec": uint64(1700000000000000)},
+			"sshd.socket": {
+				"NAccepted":    uint32(10),
+				"NConnections": uint32(1),
+				"NRefused":     uint32(0),
+			},
+		},
+	}
+
+	args := Arguments{Units: []string{"*.service", "*.timer", "*.socket"}}
+	c := newCollector(log.NewNopLogger(), args, func(context.Context) (systemdConn, error) { return conn, nil })
+
+	expect := `
+# HELP systemd_scrape_success Whether the units were read from systemd successfully.
+# TYPE systemd_scrape_success gauge
+systemd_scrape_success 1
+# HELP systemd_service_cpu_seconds_total CPU time consumed by the service, from its cgroup accounting.
+# TYPE systemd_service_cpu_seconds_total counter
+systemd_service_cpu_seconds_total{name="nginx.service"} 1.5
+# HELP systemd_service_memory_bytes Memory used by the service, from its cgroup accounting.
+# TYPE systemd_service_memory_bytes gauge
+systemd_service_memory_bytes{name="nginx.service"} 1.048576e+06
+# HELP systemd_service_restarts_total Number of automatic restarts of the service.
+# TYPE systemd_service_restarts_total counter
+systemd_service_restarts_total{name="backup.service"} 0
+systemd_service_restarts_total{name="nginx.service"} 2
+# HELP systemd_socket_accepted_connections_total Number of connections accepted by the socket.
+# TYPE systemd_socket_accepted_connections_total counter
+systemd_socket_accepted_connections_total{name="sshd.socket"} 10
+# HELP systemd_socket_current_connections Number of open connections of the socket.
+# TYPE systemd_socket_current_connections gauge
+systemd_socket_current_connections{name="sshd.socket"} 1
+# HELP systemd_socket_refused_connections_total Number of connections refused by the socket.
+# TYPE systemd_socket_refused_connections_total counter
+systemd_socket_refused_connections_total{name="sshd.socket"} 0
+# HELP systemd_timer_last_trigger_seconds Time the timer last triggered, in seconds since the Unix epoch.
+# TYPE systemd_timer_last_trigger_seconds gauge
+systemd_timer_last_trigger_seconds{name="backup.timer"} 1.7e+09
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect),
+		"systemd_scrape_success",
+		"systemd_service_cpu_seconds_total",
+		"systemd_service_memory_bytes",
+		"systemd_service_restarts_total",
+		"systemd_service_tasks",
+		"systemd_socket_accepted_connections_total",
+		"systemd_socket_current_connections",
+		"systemd_socket_refused_connections_total",
+		"systemd_timer_last_trigger_seconds",
+	))
+
+	// Each selected unit has one state series per active state, and unloaded
+	// or unselected units have none.
+	require.Equal(t, 4*len(unitStates), testutil.CollectAndCount(c, "systemd_unit_state"))
+}
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+		units   = ["nginx.service", "*.timer"]
+		private = true
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(riverConfig), &args))
+	require.Equal(t, Arguments{Units: []string{"nginx.service", "*.timer"}, Private: true}, args)
+
+	require.Error(t, river.Unmarshal([]byte(`units = ["[nginx"]`), &args))
+}
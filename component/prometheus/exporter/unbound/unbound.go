@@ -0,0 +1,69 @@
+package unbound
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/prometheus/exporter"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/unbound_exporter"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.exporter.unbound",
+		Args:    Arguments{},
+		Exports: exporter.Exports{},
+		Build:   exporter.NewWithTargetBuilder(createExporter, "unbound", customizeTarget),
+	})
+}
+
+func createExporter(opts component.Options, args component.Arguments) (integrations.Integration, error) {
+	a := args.(Arguments)
+	return a.Convert().NewIntegration(opts.Logger)
+}
+
+func customizeTarget(baseTarget discovery.Target, args component.Arguments) []discovery.Target {
+	a := args.(Arguments)
+	target := baseTarget
+
+	target["instance"] = a.ControlAddress
+	return []discovery.Target{target}
+}
+
+// DefaultArguments holds the default arguments for the prometheus.exporter.unbound component.
+var DefaultArguments = Arguments{
+	ControlAddress: unbound_exporter.DefaultConfig.ControlAddress,
+	Timeout:        unbound_exporter.DefaultConfig.Timeout,
+}
+
+// Arguments configures the prometheus.exporter.unbound component.
+type Arguments struct {
+	ControlAddress string        `river:"control_address,attr,optional"`
+	CAFile         string        `river:"ca_file,attr,optional"`
+	CertFile       string        `river:"cert_file,attr,optional"`
+	KeyFile        string        `river:"key_file,attr,optional"`
+	Timeout        time.Duration `river:"timeout,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	return a.Convert().Validate()
+}
+
+// Convert converts the component's Arguments to the integration's Config.
+func (a *Arguments) Convert() *unbound_exporter.Config {
+	return &unbound_exporter.Config{
+		ControlAddress: a.ControlAddress,
+		CAFile:         a.CAFile,
+		CertFile:       a.CertFile,
+		KeyFile:        a.KeyFile,
+		Timeout:        a.Timeout,
+	}
+}
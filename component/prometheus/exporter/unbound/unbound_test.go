@@ -0,0 +1,47 @@
+package unbound
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/integrations/unbound_exporter"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRiverUnmarshal(t *testing.T) {
+	riverConfig := `
+	control_address = "unbound:8953"
+	ca_file         = "/etc/unbound/unbound_server.pem"
+	cert_file       = "/etc/unbound/unbound_control.pem"
+	key_file        = "/etc/unbound/unbound_control.key"
+	`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(riverConfig), &args)
+	require.NoError(t, err)
+
+	expected := Arguments{
+		ControlAddress: "unbound:8953",
+		CAFile:         "/etc/unbound/unbound_server.pem",
+		CertFile:       "/etc/unbound/unbound_control.pem",
+		KeyFile:        "/etc/unbound/unbound_control.key",
+		Timeout:        10 * time.Second,
+	}
+	require.Equal(t, expected, args)
+}
+
+func TestRiverUnmarshal_Invalid(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`cert_file = "/etc/unbound/unbound_control.pem"`), &args)
+	require.EqualError(t, err, "ca_file, cert_file, and key_file must be specified together")
+}
+
+func TestConvert(t *testing.T) {
+	args := DefaultArguments
+	args.ControlAddress = "unix:///run/unbound.ctl"
+
+	expected := unbound_exporter.DefaultConfig
+	expected.ControlAddress = "unix:///run/unbound.ctl"
+	require.Equal(t, &expected, args.Convert())
+}
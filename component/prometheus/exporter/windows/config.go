@@ -35,6 +35,9 @@ var DefaultArguments = Arguments{
 		Include:   windows_integration.DefaultConfig.LogicalDisk.Include,
 		Exclude:   windows_integration.DefaultConfig.LogicalDisk.Exclude,
 	},
+	MSCluster: MSClusterConfig{
+		EnabledList: strings.Split(windows_integration.DefaultConfig.MSCluster.EnabledList, ","),
+	},
 	MSMQ: MSMQConfig{
 		Where: windows_integration.DefaultConfig.MSMQ.Where,
 	},
@@ -82,6 +85,7 @@ type Arguments struct {
 	Exchange      ExchangeConfig      `river:"exchange,block,optional"`
 	IIS           IISConfig           `river:"iis,block,optional"`
 	LogicalDisk   LogicalDiskConfig   `river:"logical_disk,block,optional"`
+	MSCluster     MSClusterConfig     `river:"mscluster,block,optional"`
 	MSMQ          MSMQConfig          `river:"msmq,block,optional"`
 	MSSQL         MSSQLConfig         `river:"mssql,block,optional"`
 	Network       NetworkConfig       `river:"network,block,optional"`
@@ -105,6 +109,7 @@ func (a *Arguments) Convert() *windows_integration.Config {
 		Exchange:          a.Exchange.Convert(),
 		IIS:               a.IIS.Convert(),
 		LogicalDisk:       a.LogicalDisk.Convert(),
+		MSCluster:         a.MSCluster.Convert(),
 		MSMQ:              a.MSMQ.Convert(),
 		MSSQL:             a.MSSQL.Convert(),
 		Network:           a.Network.Convert(),
@@ -272,6 +277,18 @@ func (t MSSQLConfig) Convert() windows_integration.MSSQLConfig {
 	}
 }
 
+// MSClusterConfig handles settings for the windows_exporter mscluster collectors
+type MSClusterConfig struct {
+	EnabledList []string `river:"enabled_list,attr,optional"`
+}
+
+// Convert converts the component's MSClusterConfig to the integration's MSClusterConfig.
+func (t MSClusterConfig) Convert() windows_integration.MSClusterConfig {
+	return windows_integration.MSClusterConfig{
+		EnabledList: strings.Join(t.EnabledList, ","),
+	}
+}
+
 // MSMQConfig handles settings for the windows_exporter MSMQ collector
 type MSMQConfig struct {
 	Where string `river:"where_clause,attr,optional"`
@@ -23,6 +23,7 @@ func TestRiverUnmarshalWithDefaultConfig(t *testing.T) {
 	require.Equal(t, windows_integration.DefaultConfig.IIS.SiteInclude, args.IIS.SiteInclude)
 	require.Equal(t, windows_integration.DefaultConfig.LogicalDisk.Exclude, args.LogicalDisk.Exclude)
 	require.Equal(t, windows_integration.DefaultConfig.LogicalDisk.Include, args.LogicalDisk.Include)
+	require.Equal(t, strings.Split(windows_integration.DefaultConfig.MSCluster.EnabledList, ","), args.MSCluster.EnabledList)
 	require.Equal(t, windows_integration.DefaultConfig.MSMQ.Where, args.MSMQ.Where)
 	require.Equal(t, strings.Split(windows_integration.DefaultConfig.MSSQL.EnabledClasses, ","), args.MSSQL.EnabledClasses)
 	require.Equal(t, windows_integration.DefaultConfig.Network.Exclude, args.Network.Exclude)
@@ -52,6 +52,10 @@ var (
 		msmq {
             where_clause = "where"
 		}
+
+		mscluster {
+			enabled_list = ["node", "resource"]
+		}
 		
 		logical_disk {
 			include = ".+"
@@ -81,6 +85,7 @@ func TestRiverUnmarshal(t *testing.T) {
 	require.Equal(t, ".+", args.Network.Include)
 	require.Equal(t, []string{"accessmethods"}, args.MSSQL.EnabledClasses)
 	require.Equal(t, "where", args.MSMQ.Where)
+	require.Equal(t, []string{"node", "resource"}, args.MSCluster.EnabledList)
 	require.Equal(t, "", args.LogicalDisk.Exclude)
 	require.Equal(t, ".+", args.LogicalDisk.Include)
 }
@@ -108,6 +113,7 @@ func TestConvert(t *testing.T) {
 	require.Equal(t, ".+", conf.Network.Include)
 	require.Equal(t, "accessmethods", conf.MSSQL.EnabledClasses)
 	require.Equal(t, "where", conf.MSMQ.Where)
+	require.Equal(t, "node,resource", conf.MSCluster.EnabledList)
 	require.Equal(t, "", conf.LogicalDisk.Exclude)
 	require.Equal(t, ".+", conf.LogicalDisk.Include)
 }
@@ -7,7 +7,13 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/grafana/agent/component/common/delivery"
+	"github.com/grafana/agent/component/common/usage"
+	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/hashicorp/go-multierror"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/prometheus/prometheus/model/exemplar"
 	"github.com/prometheus/prometheus/model/histogram"
@@ -29,6 +35,11 @@ type Fanout struct {
 	componentID    string
 	writeLatency   prometheus.Histogram
 	samplesCounter prometheus.Counter
+	// trackDelivery stamps appended samples to measure their delivery latency.
+	trackDelivery bool
+	// tracer creates a span for each appender when pipeline spans are
+	// enabled. It's nil if the fanout isn't traced.
+	tracer trace.TracerProvider
 }
 
 // NewFanout creates a fanout appendable.
@@ -60,24 +71,65 @@ func (f *Fanout) UpdateChildren(children []storage.Appendable) {
 	f.children = children
 }
 
+// SetDeliveryTracking sets whether samples appended to the fanout are stamped
+// to measure their delivery latency at write components.
+func (f *Fanout) SetDeliveryTracking(enabled bool) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.trackDelivery = enabled
+}
+
+// SetTracerProvider sets the TracerProvider creating a span for the samples
+// of each appender, covering their handoff to the children of the fanout
+// until they're committed. Spans are only created while pipeline spans are
+// enabled in the tracing block.
+func (f *Fanout) SetTracerProvider(tp trace.TracerProvider) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.tracer = tp
+}
+
 // Appender satisfies the Appendable interface.
 func (f *Fanout) Appender(ctx context.Context) storage.Appender {
 	f.mut.RLock()
 	defer f.mut.RUnlock()
 
-	// TODO(@tpaschalis): The `otelcol.receiver.prometheus` component reuses
-	// code from the prometheusreceiver which expects the Appender context to
-	// be contain both a scrape target and a metadata store, and fails the
-	// conversion if they are missing. We should find a way around this as both
-	// Targets and Metadata will be handled in a different way in Flow.
-	ctx = scrape.ContextWithTarget(ctx, &scrape.Target{})
-	ctx = scrape.ContextWithMetricMetadataStore(ctx, NoopMetadataStore{})
+	// The span of the appender is passed to the children in ctx, so that the
+	// spans of downstream fanouts are its children.
+	var span trace.Span
+	if f.tracer != nil && tracing.PipelineSpansEnabled(f.tracer) {
+		ctx, span = f.tracer.Tracer("").Start(ctx, "ForwardSamples", trace.WithSpanKind(trace.SpanKindInternal))
+		if target, ok := scrape.TargetFromContext(ctx); ok && target != nil {
+			span.SetAttributes(
+				attribute.String("job", target.Labels().Get("job")),
+				attribute.String("instance", target.Labels().Get("instance")),
+			)
+		}
+	}
+
+	// The `otelcol.receiver.prometheus` component reuses code from the
+	// prometheusreceiver which expects the Appender context to contain both a
+	// scrape target and a metadata store, and fails the conversion if they are
+	// missing. prometheus.scrape passes the real ones, which are kept so that
+	// metric metadata reaches the receiver; empty ones are used otherwise.
+	if _, ok := scrape.TargetFromContext(ctx); !ok {
+		ctx = scrape.ContextWithTarget(ctx, &scrape.Target{})
+	}
+	if _, ok := scrape.MetricMetadataStoreFromContext(ctx); !ok {
+		ctx = scrape.ContextWithMetricMetadataStore(ctx, NoopMetadataStore{})
+	}
+
+	if f.trackDelivery {
+		ctx = delivery.ContextWithStamp(ctx, delivery.Stamp{Source: f.componentID, Time: time.Now()})
+	}
+	ctx = usage.ContextWithSource(ctx, f.componentID)
 
 	app := &appender{
 		children:       make([]storage.Appender, 0),
 		componentID:    f.componentID,
 		writeLatency:   f.writeLatency,
 		samplesCounter: f.samplesCounter,
+		span:           span,
 	}
 
 	for _, x := range f.children {
@@ -95,6 +147,11 @@ type appender struct {
 	writeLatency   prometheus.Histogram
 	samplesCounter prometheus.Counter
 	start          time.Time
+
+	// span is the span of the appender, if traced, and samples the number of
+	// samples appended to it.
+	span    trace.Span
+	samples int
 }
 
 var _ storage.Appender = (*appender)(nil)
@@ -120,6 +177,7 @@ func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v flo
 	if updated {
 		a.samplesCounter.Inc()
 	}
+	a.samples++
 	return ref, multiErr
 }
 
@@ -133,6 +191,7 @@ func (a *appender) Commit() error {
 			multiErr = multierror.Append(multiErr, err)
 		}
 	}
+	a.endSpan(multiErr, false)
 	return multiErr
 }
 
@@ -146,9 +205,28 @@ func (a *appender) Rollback() error {
 			multiErr = multierror.Append(multiErr, err)
 		}
 	}
+	a.endSpan(multiErr, true)
 	return multiErr
 }
 
+// endSpan ends the span of the appender, if traced, once its samples were
+// committed or rolled back.
+func (a *appender) endSpan(err error, rolledBack bool) {
+	if a.span == nil {
+		return
+	}
+	a.span.SetAttributes(
+		attribute.Int("samples", a.samples),
+		attribute.Bool("rolled_back", rolledBack),
+	)
+	if err != nil {
+		a.span.SetStatus(codes.Error, err.Error())
+	} else {
+		a.span.SetStatus(codes.Ok, "")
+	}
+	a.span.End()
+}
+
 func (a *appender) recordLatency() {
 	if a.start.IsZero() {
 		return
@@ -193,6 +271,8 @@ func (a *appender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m meta
 	return ref, multiErr
 }
 
+// AppendHistogram satisfies the Appender interface. Native histograms are
+// counted as samples.
 func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
 	if a.start.IsZero() {
 		a.start = time.Now()
@@ -201,12 +281,19 @@ func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int
 		ref = storage.SeriesRef(GlobalRefMapping.GetOrAddGlobalRefID(l))
 	}
 	var multiErr error
+	updated := false
 	for _, x := range a.children {
 		_, err := x.AppendHistogram(ref, l, t, h, fh)
 		if err != nil {
 			multiErr = multierror.Append(multiErr, err)
+		} else {
+			updated = true
 		}
 	}
+	if updated {
+		a.samplesCounter.Inc()
+	}
+	a.samples++
 	return ref, multiErr
 }
 
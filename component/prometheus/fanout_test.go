@@ -3,8 +3,13 @@ package prometheus
 import (
 	"testing"
 
+	"github.com/grafana/agent/pkg/flow/tracing"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"go.opentelemetry.io/otel/trace"
 
+	"github.com/prometheus/prometheus/scrape"
 	"github.com/prometheus/prometheus/storage"
 
 	"context"
@@ -25,3 +30,75 @@ func TestCommit(t *testing.T) {
 	err := app.Commit()
 	require.NoError(t, err)
 }
+
+func TestAppender_KeepsScrapeContext(t *testing.T) {
+	target := &scrape.Target{}
+	store := NoopMetadataStore{}
+
+	var gotCtx context.Context
+	child := appendableFunc(func(ctx context.Context) storage.Appender {
+		gotCtx = ctx
+		return NewFanout(nil, "1", prometheus.NewRegistry()).Appender(ctx)
+	})
+	fanout := NewFanout([]storage.Appendable{child}, "", prometheus.NewRegistry())
+
+	ctx := scrape.ContextWithTarget(context.Background(), target)
+	ctx = scrape.ContextWithMetricMetadataStore(ctx, store)
+	fanout.Appender(ctx)
+
+	gotTarget, ok := scrape.TargetFromContext(gotCtx)
+	require.True(t, ok)
+	require.Same(t, target, gotTarget)
+	_, ok = scrape.MetricMetadataStoreFromContext(gotCtx)
+	require.True(t, ok)
+}
+
+func TestAppender_PipelineSpans(t *testing.T) {
+	tracer, err := tracing.New(tracing.DefaultOptions)
+	require.NoError(t, err)
+
+	var gotCtx context.Context
+	child := appendableFunc(func(ctx context.Context) storage.Appender {
+		gotCtx = ctx
+		return NewFanout(nil, "1", prometheus.NewRegistry()).Appender(ctx)
+	})
+	fanout := NewFanout([]storage.Appendable{child}, "", prometheus.NewRegistry())
+	fanout.SetTracerProvider(tracing.WrapTracer(tracer, "prometheus.scrape.default"))
+
+	// Spans are only created once enabled in the tracing block.
+	app := fanout.Appender(context.Background())
+	require.False(t, trace.SpanContextFromContext(gotCtx).IsValid())
+	require.NoError(t, app.Commit())
+
+	opts := tracing.DefaultOptions
+	opts.PipelineSpans = true
+	require.NoError(t, tracer.Update(opts))
+
+	app = fanout.Appender(context.Background())
+	require.True(t, trace.SpanContextFromContext(gotCtx).IsValid())
+	_, err = app.Append(0, labels.FromStrings("__name__", "up"), 1, 1)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+}
+
+type appendableFunc func(ctx context.Context) storage.Appender
+
+func (f appendableFunc) Appender(ctx context.Context) storage.Appender { return f(ctx) }
+
+func TestAppender_AllDataKinds(t *testing.T) {
+	var a, b capture
+	reg := prometheus.NewRegistry()
+	fanout := NewFanout([]storage.Appendable{a.Appendable(), b.Appendable()}, "test", reg)
+
+	appendAllDataKinds(t, fanout.Appender(context.Background()), labels.FromStrings("__name__", "test"))
+
+	// All data reaches every child, and native histograms count as samples.
+	for _, c := range []*capture{&a, &b} {
+		require.Len(t, c.samples, 1)
+		require.Len(t, c.exemplars, 1)
+		require.Equal(t, "Test histogram.", c.metadata[0].Help)
+		require.Equal(t, 3.0, c.histograms[0].Sum)
+		require.Equal(t, 3.0, c.floats[0].Sum)
+	}
+	require.Equal(t, 3.0, testutil.ToFloat64(fanout.samplesCounter))
+}
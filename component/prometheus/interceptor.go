@@ -83,7 +83,10 @@ func (f *Interceptor) Appender(ctx context.Context) storage.Appender {
 
 type interceptappender struct {
 	interceptor *Interceptor
-	child       storage.Appender
+
+	// child is nil when the interceptor has no next appendable, in which case
+	// data not handled by a hook is discarded.
+	child storage.Appender
 }
 
 var _ storage.Appender = (*interceptappender)(nil)
@@ -97,6 +100,9 @@ func (a *interceptappender) Append(ref storage.SeriesRef, l labels.Labels, t int
 	if a.interceptor.onAppend != nil {
 		return a.interceptor.onAppend(ref, l, t, v, a.child)
 	}
+	if a.child == nil {
+		return ref, nil
+	}
 	return a.child.Append(ref, l, t, v)
 }
 
@@ -130,6 +136,9 @@ func (a *interceptappender) AppendExemplar(
 	if a.interceptor.onAppendExemplar != nil {
 		return a.interceptor.onAppendExemplar(ref, l, e, a.child)
 	}
+	if a.child == nil {
+		return ref, nil
+	}
 	return a.child.AppendExemplar(ref, l, e)
 }
 
@@ -147,9 +156,13 @@ func (a *interceptappender) UpdateMetadata(
 	if a.interceptor.onUpdateMetadata != nil {
 		return a.interceptor.onUpdateMetadata(ref, l, m, a.child)
 	}
+	if a.child == nil {
+		return ref, nil
+	}
 	return a.child.UpdateMetadata(ref, l, m)
 }
 
+// AppendHistogram satisfies the Appender interface.
 func (a *interceptappender) AppendHistogram(
 	ref storage.SeriesRef,
 	l labels.Labels,
@@ -165,5 +178,8 @@ func (a *interceptappender) AppendHistogram(
 	if a.interceptor.onAppendHistogram != nil {
 		return a.interceptor.onAppendHistogram(ref, l, t, h, fh, a.child)
 	}
+	if a.child == nil {
+		return ref, nil
+	}
 	return a.child.AppendHistogram(ref, l, t, h, fh)
 }
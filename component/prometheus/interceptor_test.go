@@ -0,0 +1,95 @@
+package prometheus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterceptor_NilNext(t *testing.T) {
+	// Data not handled by a hook is discarded when there's no next appendable.
+	app := NewInterceptor(nil).Appender(context.Background())
+	lbls := labels.FromStrings("__name__", "test")
+
+	_, err := app.Append(0, lbls, 1, 1)
+	require.NoError(t, err)
+	_, err = app.AppendExemplar(0, lbls, exemplar.Exemplar{Value: 1, Ts: 1})
+	require.NoError(t, err)
+	_, err = app.UpdateMetadata(0, lbls, metadata.Metadata{Type: textparse.MetricTypeCounter})
+	require.NoError(t, err)
+	_, err = app.AppendHistogram(0, lbls, 1, &histogram.Histogram{Count: 1}, nil)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+}
+
+// capture is a storage.Appendable which records all data appended to it.
+type capture struct {
+	samples    []labels.Labels
+	exemplars  []exemplar.Exemplar
+	metadata   []metadata.Metadata
+	histograms []*histogram.Histogram
+	floats     []*histogram.FloatHistogram
+}
+
+func (c *capture) Appendable() storage.Appendable {
+	return NewInterceptor(nil,
+		WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+			c.samples = append(c.samples, l)
+			return ref, nil
+		}),
+		WithExemplarHook(func(ref storage.SeriesRef, _ labels.Labels, e exemplar.Exemplar, _ storage.Appender) (storage.SeriesRef, error) {
+			c.exemplars = append(c.exemplars, e)
+			return ref, nil
+		}),
+		WithMetadataHook(func(ref storage.SeriesRef, _ labels.Labels, m metadata.Metadata, _ storage.Appender) (storage.SeriesRef, error) {
+			c.metadata = append(c.metadata, m)
+			return ref, nil
+		}),
+		WithAppendHistogram(func(ref storage.SeriesRef, _ labels.Labels, _ int64, h *histogram.Histogram, fh *histogram.FloatHistogram, _ storage.Appender) (storage.SeriesRef, error) {
+			if h != nil {
+				c.histograms = append(c.histograms, h)
+			}
+			if fh != nil {
+				c.floats = append(c.floats, fh)
+			}
+			return ref, nil
+		}),
+	)
+}
+
+func TestInterceptor_AllDataKinds(t *testing.T) {
+	var c capture
+	app := NewInterceptor(c.Appendable()).Appender(context.Background())
+	appendAllDataKinds(t, app, labels.FromStrings("__name__", "test"))
+
+	require.Len(t, c.samples, 1)
+	require.Len(t, c.exemplars, 1)
+	require.Len(t, c.metadata, 1)
+	require.Len(t, c.histograms, 1)
+	require.Len(t, c.floats, 1)
+}
+
+// appendAllDataKinds appends a sample, an exemplar, metadata, and an integer
+// and a float native histogram of the series to app, and commits them.
+func appendAllDataKinds(t *testing.T, app storage.Appender, lbls labels.Labels) {
+	t.Helper()
+
+	_, err := app.Append(0, lbls, 1000, 1)
+	require.NoError(t, err)
+	_, err = app.AppendExemplar(0, lbls, exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "abc"), Value: 1, Ts: 1000, HasTs: true})
+	require.NoError(t, err)
+	_, err = app.UpdateMetadata(0, lbls, metadata.Metadata{Type: textparse.MetricTypeHistogram, Help: "Test histogram."})
+	require.NoError(t, err)
+	_, err = app.AppendHistogram(0, lbls, 1000, &histogram.Histogram{Count: 2, Sum: 3, Schema: 1}, nil)
+	require.NoError(t, err)
+	_, err = app.AppendHistogram(0, lbls, 2000, nil, &histogram.FloatHistogram{Count: 2, Sum: 3, Schema: 1})
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+}
@@ -0,0 +1,125 @@
+// Package v1alpha1 holds the ScrapeConfig custom resource of the Prometheus
+// Operator.
+//
+// The types mirror monitoring.coreos.com/v1alpha1 as introduced in Prometheus
+// Operator v0.65, which is newer than the Prometheus Operator API module the
+// agent depends on. ScrapeConfig resources are watched as unstructured
+// objects and converted into these types, so they don't implement
+// runtime.Object.
+package v1alpha1
+
+import (
+	promopv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ScrapeConfigKind is the kind of ScrapeConfig resources.
+const ScrapeConfigKind = "ScrapeConfig"
+
+// SchemeGroupVersion is the group version of ScrapeConfig resources.
+var SchemeGroupVersion = schema.GroupVersion{Group: "monitoring.coreos.com", Version: "v1alpha1"}
+
+// ScrapeConfig defines a namespaced Prometheus scrape_config to be aggregated
+// across multiple namespaces into the Prometheus configuration.
+type ScrapeConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ScrapeConfigSpec `json:"spec"`
+}
+
+// ScrapeConfigSpec is a specification of the desired configuration for a
+// scrape configuration.
+type ScrapeConfigSpec struct {
+	// StaticConfigs defines a list of static targets with a common label set.
+	StaticConfigs []StaticConfig `json:"staticConfigs,omitempty"`
+	// FileSDConfigs defines a list of file service discovery configurations.
+	FileSDConfigs []FileSDConfig `json:"fileSDConfigs,omitempty"`
+	// HTTPSDConfigs defines a list of HTTP service discovery configurations.
+	HTTPSDConfigs []HTTPSDConfig `json:"httpSDConfigs,omitempty"`
+	// RelabelConfigs defines how to rewrite the target's labels before
+	// scraping.
+	RelabelConfigs []*promopv1.RelabelConfig `json:"relabelings,omitempty"`
+	// MetricsPath is the HTTP path to scrape for metrics.
+	MetricsPath *string `json:"metricsPath,omitempty"`
+	// ScrapeInterval is the interval between consecutive scrapes.
+	ScrapeInterval *promopv1.Duration `json:"scrapeInterval,omitempty"`
+	// ScrapeTimeout is the number of seconds to wait until a scrape request
+	// times out.
+	ScrapeTimeout *promopv1.Duration `json:"scrapeTimeout,omitempty"`
+	// HonorTimestamps controls whether to respect the timestamps present in
+	// scraped data.
+	HonorTimestamps *bool `json:"honorTimestamps,omitempty"`
+	// HonorLabels chooses the metric's labels on collisions with target
+	// labels.
+	HonorLabels *bool `json:"honorLabels,omitempty"`
+	// Params are optional HTTP URL parameters.
+	Params map[string][]string `json:"params,omitempty"`
+	// Scheme configures the protocol scheme used for requests.
+	Scheme *string `json:"scheme,omitempty"`
+	// BasicAuth information to use on every scrape request.
+	BasicAuth *promopv1.BasicAuth `json:"basicAuth,omitempty"`
+	// Authorization header to use on every scrape request.
+	Authorization *promopv1.SafeAuthorization `json:"authorization,omitempty"`
+	// TLSConfig to use on every scrape request.
+	TLSConfig *promopv1.SafeTLSConfig `json:"tlsConfig,omitempty"`
+	// SampleLimit defines a per-scrape limit on the number of scraped samples
+	// that will be accepted.
+	SampleLimit *uint64 `json:"sampleLimit,omitempty"`
+	// TargetLimit defines a limit on the number of scraped targets that will
+	// be accepted.
+	TargetLimit *uint64 `json:"targetLimit,omitempty"`
+	// LabelLimit defines a per-scrape limit on the number of labels that will
+	// be accepted for a sample.
+	LabelLimit *uint64 `json:"labelLimit,omitempty"`
+	// LabelNameLengthLimit defines a per-scrape limit on the length of label
+	// names that will be accepted for a sample.
+	LabelNameLengthLimit *uint64 `json:"labelNameLengthLimit,omitempty"`
+	// LabelValueLengthLimit defines a per-scrape limit on the length of label
+	// values that will be accepted for a sample.
+	LabelValueLengthLimit *uint64 `json:"labelValueLengthLimit,omitempty"`
+	// MetricRelabelConfigs to apply to samples before ingestion.
+	MetricRelabelConfigs []*promopv1.RelabelConfig `json:"metricRelabelings,omitempty"`
+}
+
+// StaticConfig defines a Prometheus static configuration.
+type StaticConfig struct {
+	// Targets is a list of targets identified by a label set. Each target is
+	// uniquely identifiable in the group by its address label.
+	Targets []Target `json:"targets,omitempty"`
+	// Labels assigned to all metrics scraped from the targets.
+	Labels map[promopv1.LabelName]string `json:"labels,omitempty"`
+}
+
+// Target represents a target for Prometheus to scrape.
+type Target string
+
+// FileSDConfig defines a Prometheus file service discovery configuration.
+type FileSDConfig struct {
+	// Files is a list of paths of the files to read targets from. The last
+	// path segment may contain a single * that matches any character
+	// sequence, e.g. my/path/tg_*.json.
+	Files []SDFile `json:"files"`
+	// RefreshInterval configures the refresh interval at which the files are
+	// re-read.
+	RefreshInterval *promopv1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// SDFile represents a file used for service discovery.
+type SDFile string
+
+// HTTPSDConfig defines a Prometheus HTTP service discovery configuration.
+type HTTPSDConfig struct {
+	// URL from which the targets are fetched.
+	URL string `json:"url"`
+	// RefreshInterval configures the refresh interval at which the targets
+	// are re-fetched.
+	RefreshInterval *promopv1.Duration `json:"refreshInterval,omitempty"`
+	// BasicAuth information to authenticate against the target HTTP
+	// endpoint.
+	BasicAuth *promopv1.BasicAuth `json:"basicAuth,omitempty"`
+	// Authorization header configuration to authenticate against the target
+	// HTTP endpoint.
+	Authorization *promopv1.SafeAuthorization `json:"authorization,omitempty"`
+}
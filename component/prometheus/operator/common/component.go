@@ -2,6 +2,7 @@ package common
 
 import (
 	"context"
+	"reflect"
 	"sync"
 	"time"
 
@@ -88,6 +89,14 @@ func (c *Component) Update(args component.Arguments) error {
 	// See https://github.com/grafana/agent/pull/2688#discussion_r1152384425
 	c.mut.Lock()
 	cfg := args.(operator.Arguments)
+	// Changes of the cluster reevaluate the component without changing its
+	// arguments. The running manager only needs to distribute its targets
+	// again in that case.
+	if c.config != nil && c.manager != nil && reflect.DeepEqual(*c.config, cfg) {
+		c.manager.ClusteringUpdated()
+		c.mut.Unlock()
+		return nil
+	}
 	c.config = &cfg
 	c.mut.Unlock()
 	select {
@@ -97,6 +106,13 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// ClusterUpdatesRegistration implements component.ClusteredComponent.
+func (c *Component) ClusterUpdatesRegistration() bool {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.config.Clustering.Enabled
+}
+
 // DebugInfo returns debug information for this component.
 func (c *Component) DebugInfo() interface{} {
 	return c.manager.DebugInfo()
@@ -11,6 +11,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	flow_discovery "github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus"
 	"github.com/prometheus/prometheus/config"
 	"github.com/prometheus/prometheus/discovery"
@@ -23,9 +24,11 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/grafana/agent/component/prometheus/operator"
+	promopv1alpha1 "github.com/grafana/agent/component/prometheus/operator/apis/monitoring/v1alpha1"
 	"github.com/grafana/agent/component/prometheus/operator/configgen"
 	compscrape "github.com/grafana/agent/component/prometheus/scrape"
 	promopv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -50,27 +53,31 @@ type crdManager struct {
 	client *kubernetes.Clientset
 
 	kind string
+
+	clusteringUpdated chan struct{}
 }
 
 const (
 	KindPodMonitor     string = "podMonitor"
 	KindServiceMonitor string = "serviceMonitor"
+	KindScrapeConfig   string = "scrapeConfig"
 )
 
 func newCrdManager(opts component.Options, logger log.Logger, args *operator.Arguments, kind string) *crdManager {
 	switch kind {
-	case KindPodMonitor, KindServiceMonitor:
+	case KindPodMonitor, KindServiceMonitor, KindScrapeConfig:
 	default:
 		panic(fmt.Sprintf("Unknown kind for crdManager: %s", kind))
 	}
 	return &crdManager{
-		opts:             opts,
-		logger:           logger,
-		args:             args,
-		discoveryConfigs: map[string]discovery.Configs{},
-		scrapeConfigs:    map[string]*config.ScrapeConfig{},
-		debugInfo:        map[string]*operator.DiscoveredResource{},
-		kind:             kind,
+		opts:              opts,
+		logger:            logger,
+		args:              args,
+		discoveryConfigs:  map[string]discovery.Configs{},
+		scrapeConfigs:     map[string]*config.ScrapeConfig{},
+		debugInfo:         map[string]*operator.DiscoveredResource{},
+		kind:              kind,
+		clusteringUpdated: make(chan struct{}, 1),
 	}
 }
 
@@ -100,7 +107,12 @@ func (c *crdManager) Run(ctx context.Context) error {
 
 	// Start prometheus scrape manager.
 	flowAppendable := prometheus.NewFanout(c.args.ForwardTo, c.opts.ID, c.opts.Registerer)
-	opts := &scrape.Options{}
+	flowAppendable.SetTracerProvider(c.opts.Tracer)
+	opts := &scrape.Options{
+		// Pass metadata to the appenders, as Flow has no way of reading it from
+		// the scrape manager.
+		EnableMetadataStorage: true,
+	}
 	c.scrapeManager = scrape.NewManager(opts, c.logger, flowAppendable)
 	defer c.scrapeManager.Stop()
 	targetSetsChan := make(chan map[string][]*targetgroup.Group)
@@ -113,14 +125,64 @@ func (c *crdManager) Run(ctx context.Context) error {
 	}()
 
 	// Start the target discovery loop to update the scrape manager with new targets.
+	var targetSets map[string][]*targetgroup.Group
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case m := <-c.discoveryManager.SyncCh():
-			targetSetsChan <- m
+		case targetSets = <-c.discoveryManager.SyncCh():
+		case <-c.clusteringUpdated:
+			if targetSets == nil {
+				continue
+			}
+		}
+
+		select {
+		case targetSetsChan <- c.distributeTargets(targetSets):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// ClusteringUpdated notifies the crdManager that the cluster state changed,
+// so targets need to be distributed again.
+func (c *crdManager) ClusteringUpdated() {
+	select {
+	case c.clusteringUpdated <- struct{}{}:
+	default:
+	}
+}
+
+// distributeTargets returns the targets of targetSets owned by the local
+// node. All targets are returned if clustering is disabled.
+func (c *crdManager) distributeTargets(targetSets map[string][]*targetgroup.Group) map[string][]*targetgroup.Group {
+	if !c.args.Clustering.Enabled || c.opts.Clusterer == nil {
+		return targetSets
+	}
+
+	res := make(map[string][]*targetgroup.Group, len(targetSets))
+	for job, groups := range targetSets {
+		for _, group := range groups {
+			owned := &targetgroup.Group{Source: group.Source, Labels: group.Labels}
+			for _, target := range group.Targets {
+				tgt := make(flow_discovery.Target, len(group.Labels)+len(target))
+				for k, v := range group.Labels {
+					tgt[string(k)] = string(v)
+				}
+				for k, v := range target {
+					tgt[string(k)] = string(v)
+				}
+
+				dt := flow_discovery.NewDistributedTargets(true, c.opts.Clusterer.Node, []flow_discovery.Target{tgt})
+				if len(dt.Get()) > 0 {
+					owned.Targets = append(owned.Targets, target)
+				}
+			}
+			res[job] = append(res[job], owned)
 		}
 	}
+	return res
 }
 
 // DebugInfo returns debug information for the CRDManager.
@@ -193,6 +255,12 @@ func (c *crdManager) configureInformers(ctx context.Context, informers cache.Inf
 		prototype = &promopv1.PodMonitor{}
 	case KindServiceMonitor:
 		prototype = &promopv1.ServiceMonitor{}
+	case KindScrapeConfig:
+		// The ScrapeConfig CRD isn't part of the vendored Prometheus Operator
+		// API, so it's watched as an unstructured object.
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(promopv1alpha1.SchemeGroupVersion.WithKind(promopv1alpha1.ScrapeConfigKind))
+		prototype = u
 	default:
 		return fmt.Errorf("unknown kind to configure Informers: %s", c.kind)
 	}
@@ -223,6 +291,12 @@ func (c *crdManager) configureInformers(ctx context.Context, informers cache.Inf
 			UpdateFunc: c.onUpdateServiceMonitor,
 			DeleteFunc: c.onDeleteServiceMonitor,
 		}), resync)
+	case KindScrapeConfig:
+		_, err = informer.AddEventHandlerWithResyncPeriod((toolscache.ResourceEventHandlerFuncs{
+			AddFunc:    c.onAddScrapeConfig,
+			UpdateFunc: c.onUpdateScrapeConfig,
+			DeleteFunc: c.onDeleteScrapeConfig,
+		}), resync)
 	default:
 		return fmt.Errorf("unknown kind to configure Informers: %s", c.kind)
 	}
@@ -369,12 +443,89 @@ func (c *crdManager) onDeleteServiceMonitor(obj interface{}) {
 	}
 }
 
+func (c *crdManager) addScrapeConfig(sc *promopv1alpha1.ScrapeConfig) {
+	gen := configgen.ConfigGenerator{
+		Secrets: configgen.NewSecretManager(c.client),
+		Client:  &c.args.Client,
+	}
+	pmc, err := gen.GenerateScrapeConfigConfig(sc)
+	if err != nil {
+		// TODO(jcreixell): Generate Kubernetes event to inform of this error when running `kubectl get <scrapeconfig>`.
+		level.Error(c.logger).Log("name", sc.Name, "err", err, "msg", "error generating scrapeconfig from scrapeConfig")
+		c.addDebugInfo(sc.Namespace, sc.Name, err)
+		return
+	}
+	c.mut.Lock()
+	c.discoveryConfigs[pmc.JobName] = pmc.ServiceDiscoveryConfigs
+	c.scrapeConfigs[pmc.JobName] = pmc
+	c.mut.Unlock()
+
+	if err = c.apply(); err != nil {
+		level.Error(c.logger).Log("name", sc.Name, "err", err, "msg", "error applying scrape configs from "+c.kind)
+	}
+	c.addDebugInfo(sc.Namespace, sc.Name, err)
+}
+
+func (c *crdManager) onAddScrapeConfig(obj interface{}) {
+	sc, err := convertScrapeConfig(obj)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to convert scrape config", "err", err)
+		return
+	}
+	level.Info(c.logger).Log("msg", "found scrape config", "name", sc.Name)
+	c.addScrapeConfig(sc)
+}
+func (c *crdManager) onUpdateScrapeConfig(oldObj, newObj interface{}) {
+	oldSc, err := convertScrapeConfig(oldObj)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to convert scrape config", "err", err)
+		return
+	}
+	newSc, err := convertScrapeConfig(newObj)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to convert scrape config", "err", err)
+		return
+	}
+	c.clearConfigs("scrapeConfig", oldSc.Namespace, oldSc.Name)
+	c.addScrapeConfig(newSc)
+}
+
+func (c *crdManager) onDeleteScrapeConfig(obj interface{}) {
+	sc, err := convertScrapeConfig(obj)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "failed to convert scrape config", "err", err)
+		return
+	}
+	c.clearConfigs("scrapeConfig", sc.Namespace, sc.Name)
+	if err := c.apply(); err != nil {
+		level.Error(c.logger).Log("name", sc.Name, "err", err, "msg", "error applying scrape configs after deleting "+c.kind)
+	}
+}
+
+// convertScrapeConfig converts an unstructured ScrapeConfig resource received
+// from an informer.
+func convertScrapeConfig(obj interface{}) (*promopv1alpha1.ScrapeConfig, error) {
+	if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected object of type %T", obj)
+	}
+
+	var sc promopv1alpha1.ScrapeConfig
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &sc); err != nil {
+		return nil, fmt.Errorf("converting %s/%s: %w", u.GetNamespace(), u.GetName(), err)
+	}
+	return &sc, nil
+}
+
 func (c *crdManager) clearConfigs(kind, ns, name string) {
 	c.mut.Lock()
 	defer c.mut.Unlock()
 	prefix := fmt.Sprintf("%s/%s/%s", kind, ns, name)
 	for k := range c.discoveryConfigs {
-		if strings.HasPrefix(k, prefix) {
+		if k == prefix || strings.HasPrefix(k, prefix+"/") {
 			delete(c.discoveryConfigs, k)
 			delete(c.scrapeConfigs, k)
 		}
@@ -0,0 +1,70 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/operator"
+	promopv1alpha1 "github.com/grafana/agent/component/prometheus/operator/apis/monitoring/v1alpha1"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+func TestConvertScrapeConfig(t *testing.T) {
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1alpha1",
+		"kind":       "ScrapeConfig",
+		"metadata": map[string]interface{}{
+			"namespace": "operator",
+			"name":      "static",
+		},
+		"spec": map[string]interface{}{
+			"staticConfigs": []interface{}{
+				map[string]interface{}{
+					"targets": []interface{}{"db-0:9187"},
+					"labels":  map[string]interface{}{"env": "prod"},
+				},
+			},
+			"scrapeInterval": "30s",
+			"sampleLimit":    int64(1000),
+		},
+	}}
+
+	for _, obj := range []interface{}{u, toolscache.DeletedFinalStateUnknown{Key: "operator/static", Obj: u}} {
+		sc, err := convertScrapeConfig(obj)
+		require.NoError(t, err)
+		require.Equal(t, "operator", sc.Namespace)
+		require.Equal(t, "static", sc.Name)
+		require.Len(t, sc.Spec.StaticConfigs, 1)
+		require.Equal(t, []promopv1alpha1.Target{"db-0:9187"}, sc.Spec.StaticConfigs[0].Targets)
+		require.EqualValues(t, "prod", sc.Spec.StaticConfigs[0].Labels["env"])
+		require.EqualValues(t, "30s", *sc.Spec.ScrapeInterval)
+		require.EqualValues(t, 1000, *sc.Spec.SampleLimit)
+	}
+
+	_, err := convertScrapeConfig("not an object")
+	require.EqualError(t, err, "unexpected object of type string")
+}
+
+func TestDistributeTargets(t *testing.T) {
+	targetSets := map[string][]*targetgroup.Group{
+		"scrapeConfig/operator/static": {{
+			Source:  "scrapeConfig/operator/static/static/0",
+			Labels:  model.LabelSet{"env": "prod"},
+			Targets: []model.LabelSet{{model.AddressLabel: "db-0:9187"}, {model.AddressLabel: "db-1:9187"}},
+		}},
+	}
+
+	args := operator.DefaultArguments
+	c := newCrdManager(component.Options{}, nil, &args, KindScrapeConfig)
+	require.Equal(t, targetSets, c.distributeTargets(targetSets))
+
+	// A single node owns all targets.
+	args.Clustering.Enabled = true
+	c = newCrdManager(component.Options{Clusterer: &cluster.Clusterer{Node: cluster.NewLocalNode("localhost:12345")}}, nil, &args, KindScrapeConfig)
+	require.Equal(t, targetSets, c.distributeTargets(targetSets))
+}
@@ -0,0 +1,150 @@
+package configgen
+
+// SEE https://github.com/prometheus-operator/prometheus-operator/blob/v0.65.0/pkg/prometheus/promcfg.go
+
+import (
+	"fmt"
+
+	promopv1alpha1 "github.com/grafana/agent/component/prometheus/operator/apis/monitoring/v1alpha1"
+	namespacelabeler "github.com/prometheus-operator/prometheus-operator/pkg/namespace-labeler"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/file"
+	"github.com/prometheus/prometheus/discovery/http"
+	"github.com/prometheus/prometheus/discovery/targetgroup"
+)
+
+func (cg *ConfigGenerator) GenerateScrapeConfigConfig(m *promopv1alpha1.ScrapeConfig) (cfg *config.ScrapeConfig, err error) {
+	c := config.DefaultScrapeConfig
+	cfg = &c
+	cfg.ScrapeInterval = config.DefaultGlobalConfig.ScrapeInterval
+	cfg.ScrapeTimeout = config.DefaultGlobalConfig.ScrapeTimeout
+	cfg.JobName = fmt.Sprintf("scrapeConfig/%s/%s", m.Namespace, m.Name)
+
+	spec := m.Spec
+	if spec.HonorLabels != nil {
+		cfg.HonorLabels = *spec.HonorLabels
+	}
+	if spec.HonorTimestamps != nil {
+		cfg.HonorTimestamps = *spec.HonorTimestamps
+	}
+	if spec.ScrapeInterval != nil {
+		if cfg.ScrapeInterval, err = model.ParseDuration(string(*spec.ScrapeInterval)); err != nil {
+			return nil, fmt.Errorf("parsing interval from scrapeConfig: %w", err)
+		}
+	}
+	if spec.ScrapeTimeout != nil {
+		if cfg.ScrapeTimeout, err = model.ParseDuration(string(*spec.ScrapeTimeout)); err != nil {
+			return nil, fmt.Errorf("parsing timeout from scrapeConfig: %w", err)
+		}
+	}
+	if spec.MetricsPath != nil {
+		cfg.MetricsPath = *spec.MetricsPath
+	}
+	if spec.Params != nil {
+		cfg.Params = spec.Params
+	}
+	if spec.Scheme != nil {
+		cfg.Scheme = *spec.Scheme
+	}
+	if spec.TLSConfig != nil {
+		if cfg.HTTPClientConfig.TLSConfig, err = cg.generateSafeTLS(*spec.TLSConfig, m.Namespace); err != nil {
+			return nil, err
+		}
+	}
+	if spec.BasicAuth != nil {
+		cfg.HTTPClientConfig.BasicAuth, err = cg.generateBasicAuth(*spec.BasicAuth, m.Namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if spec.Authorization != nil {
+		cfg.HTTPClientConfig.Authorization, err = cg.generateAuthorization(*spec.Authorization, m.Namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, sc := range spec.StaticConfigs {
+		group := &targetgroup.Group{
+			Source: fmt.Sprintf("%s/static/%d", cfg.JobName, i),
+			Labels: model.LabelSet{},
+		}
+		for _, target := range sc.Targets {
+			group.Targets = append(group.Targets, model.LabelSet{model.AddressLabel: model.LabelValue(target)})
+		}
+		for name, value := range sc.Labels {
+			group.Labels[model.LabelName(name)] = model.LabelValue(value)
+		}
+		cfg.ServiceDiscoveryConfigs = append(cfg.ServiceDiscoveryConfigs, discovery.StaticConfig{group})
+	}
+
+	for _, sd := range spec.FileSDConfigs {
+		fileCfg := file.DefaultSDConfig
+		for _, f := range sd.Files {
+			fileCfg.Files = append(fileCfg.Files, string(f))
+		}
+		if sd.RefreshInterval != nil {
+			if fileCfg.RefreshInterval, err = model.ParseDuration(string(*sd.RefreshInterval)); err != nil {
+				return nil, fmt.Errorf("parsing file_sd refresh interval from scrapeConfig: %w", err)
+			}
+		}
+		cfg.ServiceDiscoveryConfigs = append(cfg.ServiceDiscoveryConfigs, &fileCfg)
+	}
+
+	for _, sd := range spec.HTTPSDConfigs {
+		httpCfg := http.DefaultSDConfig
+		httpCfg.URL = sd.URL
+		if sd.RefreshInterval != nil {
+			if httpCfg.RefreshInterval, err = model.ParseDuration(string(*sd.RefreshInterval)); err != nil {
+				return nil, fmt.Errorf("parsing http_sd refresh interval from scrapeConfig: %w", err)
+			}
+		}
+		if sd.BasicAuth != nil {
+			httpCfg.HTTPClientConfig.BasicAuth, err = cg.generateBasicAuth(*sd.BasicAuth, m.Namespace)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if sd.Authorization != nil {
+			httpCfg.HTTPClientConfig.Authorization, err = cg.generateAuthorization(*sd.Authorization, m.Namespace)
+			if err != nil {
+				return nil, err
+			}
+		}
+		cfg.ServiceDiscoveryConfigs = append(cfg.ServiceDiscoveryConfigs, &httpCfg)
+	}
+
+	labeler := namespacelabeler.New("", nil, false)
+
+	relabels := cg.initRelabelings()
+	if err = relabels.addFromV1(labeler.GetRelabelingConfigs(m.TypeMeta, m.ObjectMeta, spec.RelabelConfigs)...); err != nil {
+		return nil, fmt.Errorf("parsing relabel configs: %w", err)
+	}
+	cfg.RelabelConfigs = relabels.configs
+
+	metricRelabels := relabeler{}
+	if err = metricRelabels.addFromV1(labeler.GetRelabelingConfigs(m.TypeMeta, m.ObjectMeta, spec.MetricRelabelConfigs)...); err != nil {
+		return nil, fmt.Errorf("parsing metric relabel configs: %w", err)
+	}
+	cfg.MetricRelabelConfigs = metricRelabels.configs
+
+	if spec.SampleLimit != nil {
+		cfg.SampleLimit = uint(*spec.SampleLimit)
+	}
+	if spec.TargetLimit != nil {
+		cfg.TargetLimit = uint(*spec.TargetLimit)
+	}
+	if spec.LabelLimit != nil {
+		cfg.LabelLimit = uint(*spec.LabelLimit)
+	}
+	if spec.LabelNameLengthLimit != nil {
+		cfg.LabelNameLengthLimit = uint(*spec.LabelNameLengthLimit)
+	}
+	if spec.LabelValueLengthLimit != nil {
+		cfg.LabelValueLengthLimit = uint(*spec.LabelValueLengthLimit)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,210 @@
+package configgen
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/common/kubernetes"
+	promopv1alpha1 "github.com/grafana/agent/component/prometheus/operator/apis/monitoring/v1alpha1"
+	"github.com/grafana/agent/pkg/util"
+	promopv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	commonConfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/discovery"
+	"github.com/prometheus/prometheus/discovery/file"
+	"github.com/prometheus/prometheus/discovery/http"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateScrapeConfigConfig(t *testing.T) {
+	var (
+		falseVal    = false
+		trueVal     = true
+		path        = "/foo"
+		scheme      = "https"
+		interval    = promopv1.Duration("1s")
+		timeout     = promopv1.Duration("17m")
+		refresh     = promopv1.Duration("30s")
+		sampleLimit = uint64(101)
+	)
+
+	fileSD := file.DefaultSDConfig
+	fileSD.Files = []string{"/etc/targets/*.json"}
+	fileSD.RefreshInterval = model.Duration(30 * time.Second)
+
+	httpSD := http.DefaultSDConfig
+	httpSD.URL = "http://sd.example.com/targets"
+	httpSD.HTTPClientConfig.Authorization = &commonConfig.Authorization{
+		Type:        "Bearer",
+		Credentials: "secret/operator/sd/token",
+	}
+
+	suite := []struct {
+		name                   string
+		m                      *promopv1alpha1.ScrapeConfig
+		expectedRelabels       string
+		expectedMetricRelabels string
+		expected               *config.ScrapeConfig
+	}{
+		{
+			name: "default",
+			m: &promopv1alpha1.ScrapeConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "operator",
+					Name:      "scrapeconfig",
+				},
+			},
+			expectedRelabels: util.Untab(`
+				- source_labels: [job]
+				  target_label: __tmp_prometheus_job_name
+			`),
+			expected: &config.ScrapeConfig{
+				JobName:         "scrapeConfig/operator/scrapeconfig",
+				HonorTimestamps: true,
+				ScrapeInterval:  model.Duration(time.Minute),
+				ScrapeTimeout:   model.Duration(10 * time.Second),
+				MetricsPath:     "/metrics",
+				Scheme:          "http",
+				HTTPClientConfig: commonConfig.HTTPClientConfig{
+					FollowRedirects: true,
+					EnableHTTP2:     true,
+				},
+			},
+		},
+		{
+			name: "everything",
+			m: &promopv1alpha1.ScrapeConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "operator",
+					Name:      "scrapeconfig",
+				},
+				Spec: promopv1alpha1.ScrapeConfigSpec{
+					StaticConfigs: []promopv1alpha1.StaticConfig{{
+						Targets: []promopv1alpha1.Target{"db-0:9187", "db-1:9187"},
+						Labels:  map[promopv1.LabelName]string{"env": "prod"},
+					}},
+					FileSDConfigs: []promopv1alpha1.FileSDConfig{{
+						Files:           []promopv1alpha1.SDFile{"/etc/targets/*.json"},
+						RefreshInterval: &refresh,
+					}},
+					HTTPSDConfigs: []promopv1alpha1.HTTPSDConfig{{
+						URL: "http://sd.example.com/targets",
+						Authorization: &promopv1.SafeAuthorization{
+							Credentials: s("sd", "token"),
+						},
+					}},
+					RelabelConfigs: []*promopv1.RelabelConfig{{
+						SourceLabels: []promopv1.LabelName{"foo"},
+						TargetLabel:  "bar",
+					}},
+					MetricRelabelConfigs: []*promopv1.RelabelConfig{{
+						SourceLabels: []promopv1.LabelName{"__name__"},
+						Regex:        "go_.*",
+						Action:       "drop",
+					}},
+					MetricsPath:     &path,
+					ScrapeInterval:  &interval,
+					ScrapeTimeout:   &timeout,
+					HonorTimestamps: &falseVal,
+					HonorLabels:     &trueVal,
+					Params:          map[string][]string{"a": {"b"}},
+					Scheme:          &scheme,
+					TLSConfig: &promopv1.SafeTLSConfig{
+						ServerName:         "foo.com",
+						InsecureSkipVerify: true,
+					},
+					SampleLimit: &sampleLimit,
+				},
+			},
+			expectedRelabels: util.Untab(`
+				- source_labels: [job]
+				  target_label: __tmp_prometheus_job_name
+				- target_label: bar
+				  source_labels: [foo]
+			`),
+			expectedMetricRelabels: util.Untab(`
+				- source_labels: [__name__]
+				  regex: go_.*
+				  action: drop
+			`),
+			expected: &config.ScrapeConfig{
+				JobName:         "scrapeConfig/operator/scrapeconfig",
+				HonorTimestamps: false,
+				HonorLabels:     true,
+				ScrapeInterval:  model.Duration(time.Second),
+				ScrapeTimeout:   model.Duration(17 * time.Minute),
+				MetricsPath:     "/foo",
+				Scheme:          "https",
+				Params: url.Values{
+					"a": []string{"b"},
+				},
+				HTTPClientConfig: commonConfig.HTTPClientConfig{
+					FollowRedirects: true,
+					EnableHTTP2:     true,
+					TLSConfig: commonConfig.TLSConfig{
+						ServerName:         "foo.com",
+						InsecureSkipVerify: true,
+					},
+				},
+				ServiceDiscoveryConfigs: discovery.Configs{
+					discovery.StaticConfig{{
+						Source: "scrapeConfig/operator/scrapeconfig/static/0",
+						Labels: model.LabelSet{"env": "prod"},
+						Targets: []model.LabelSet{
+							{model.AddressLabel: "db-0:9187"},
+							{model.AddressLabel: "db-1:9187"},
+						},
+					}},
+					&fileSD,
+					&httpSD,
+				},
+				SampleLimit: 101,
+			},
+		},
+	}
+	for _, tc := range suite {
+		t.Run(tc.name, func(t *testing.T) {
+			cg := &ConfigGenerator{
+				Client:  &kubernetes.ClientArguments{},
+				Secrets: &fakeSecrets{},
+			}
+			cfg, err := cg.GenerateScrapeConfigConfig(tc.m)
+			require.NoError(t, err)
+			// check relabel configs separately
+			rlcs := cfg.RelabelConfigs
+			mrlcs := cfg.MetricRelabelConfigs
+			cfg.RelabelConfigs = nil
+			cfg.MetricRelabelConfigs = nil
+
+			assert.Equal(t, tc.expected, cfg)
+
+			checkRelabels := func(actual []*relabel.Config, expected string) {
+				// load the expected relabel rules as yaml so we get the defaults put in there.
+				ex := []*relabel.Config{}
+				err := yaml.Unmarshal([]byte(expected), &ex)
+				require.NoError(t, err)
+				y, err := yaml.Marshal(ex)
+				require.NoError(t, err)
+				expected = string(y)
+
+				y, err = yaml.Marshal(actual)
+				require.NoError(t, err)
+
+				if !assert.YAMLEq(t, expected, string(y)) {
+					fmt.Fprintln(os.Stderr, string(y))
+					fmt.Fprintln(os.Stderr, expected)
+				}
+			}
+			checkRelabels(rlcs, tc.expectedRelabels)
+			checkRelabels(mrlcs, tc.expectedMetricRelabels)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package scrapeconfigs
+
+import (
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus/operator"
+	"github.com/grafana/agent/component/prometheus/operator/common"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "prometheus.operator.scrapeconfigs",
+		Args: operator.Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return common.New(opts, args, common.KindScrapeConfig)
+		},
+	})
+}
@@ -22,6 +22,10 @@ type Arguments struct {
 
 	// LabelSelector allows filtering discovered monitor resources by labels
 	LabelSelector *config.LabelSelector `river:"selector,block,optional"`
+
+	// Clustering distributes the discovered targets between the nodes of the
+	// cluster.
+	Clustering scrape.Clustering `river:"clustering,block,optional"`
 }
 
 var DefaultArguments = Arguments{
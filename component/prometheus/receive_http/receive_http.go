@@ -53,6 +53,7 @@ type Component struct {
 
 func New(opts component.Options, args Arguments) (component.Component, error) {
 	fanout := agentprom.NewFanout(args.ForwardTo, opts.ID, opts.Registerer)
+	fanout.SetTracerProvider(opts.Tracer)
 
 	uncheckedCollector := util.NewUncheckedCollector(nil)
 	opts.Registerer.MustRegister(uncheckedCollector)
@@ -0,0 +1,162 @@
+package record
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/recorder"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/storage"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.record",
+		Args:    Arguments{},
+		Exports: Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the prometheus.record
+// component.
+type Arguments struct {
+	Recorder recorder.Arguments `river:",squash"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	args.Recorder.SetToDefault()
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	return args.Recorder.Validate()
+}
+
+// Exports holds the values exported by the prometheus.record component.
+type Exports struct {
+	Receiver storage.Appendable `river:"receiver,attr"`
+}
+
+// Sample is a recorded sample.
+type Sample struct {
+	Labels    map[string]string `json:"labels"`
+	Timestamp int64             `json:"timestamp"`
+
+	// Value is formatted like in the Prometheus HTTP API, so that NaN and
+	// infinite values can be encoded as JSON. It's empty for histograms.
+	Value     string `json:"value,omitempty"`
+	Histogram string `json:"histogram,omitempty"`
+}
+
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.HTTPComponent = (*Component)(nil)
+)
+
+// Component implements the prometheus.record component.
+type Component struct {
+	opts     component.Options
+	recorder *recorder.Recorder
+}
+
+// New creates a new prometheus.record component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	rec, err := recorder.New(o.Logger, o.Registerer, o.DataPath, args.Recorder)
+	if err != nil {
+		return nil, err
+	}
+	c := &Component{opts: o, recorder: rec}
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c})
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	c.recorder.Run(ctx)
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	c.recorder.ApplyConfig(args.(Arguments).Recorder)
+	return nil
+}
+
+// Handler implements component.HTTPComponent. It serves /export, which
+// exports the samples recorded in a time window.
+func (c *Component) Handler() http.Handler {
+	return c.recorder.Handler()
+}
+
+// Appender implements storage.Appendable. Samples are recorded once they're
+// committed.
+func (c *Component) Appender(_ context.Context) storage.Appender {
+	return &appender{c: c}
+}
+
+type appender struct {
+	c       *Component
+	samples []Sample
+}
+
+var _ storage.Appender = (*appender)(nil)
+
+func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	a.samples = append(a.samples, Sample{
+		Labels:    l.Map(),
+		Timestamp: t,
+		Value:     strconv.FormatFloat(v, 'f', -1, 64),
+	})
+	return ref, nil
+}
+
+func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	s := Sample{Labels: l.Map(), Timestamp: t}
+	if h != nil {
+		s.Histogram = h.String()
+	} else if fh != nil {
+		s.Histogram = fh.String()
+	}
+	a.samples = append(a.samples, s)
+	return ref, nil
+}
+
+func (a *appender) AppendExemplar(ref storage.SeriesRef, _ labels.Labels, _ exemplar.Exemplar) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *appender) UpdateMetadata(ref storage.SeriesRef, _ labels.Labels, _ metadata.Metadata) (storage.SeriesRef, error) {
+	return ref, nil
+}
+
+func (a *appender) Commit() error {
+	now := time.Now()
+	for _, s := range a.samples {
+		if err := a.c.recorder.Record(now, s); err != nil {
+			level.Warn(a.c.opts.Logger).Log("msg", "failed to record samples", "err", err)
+			break
+		}
+	}
+	a.samples = nil
+	return nil
+}
+
+func (a *appender) Rollback() error {
+	a.samples = nil
+	return nil
+}
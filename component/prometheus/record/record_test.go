@@ -0,0 +1,47 @@
+package record
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppender(t *testing.T) {
+	var args Arguments
+	args.SetToDefault()
+	c, err := New(component.Options{
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		DataPath:      t.TempDir(),
+		OnStateChange: func(component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+
+	app := c.Appender(context.Background())
+	_, err = app.Append(0, labels.FromStrings("__name__", "rolled_back"), 1000, 1)
+	require.NoError(t, err)
+	require.NoError(t, app.Rollback())
+
+	app = c.Appender(context.Background())
+	_, err = app.Append(0, labels.FromStrings("__name__", "up", "job", "test"), 1000, 1)
+	require.NoError(t, err)
+	_, err = app.Append(0, labels.FromStrings("__name__", "stale"), 2000, math.NaN())
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	var buf bytes.Buffer
+	require.NoError(t, c.recorder.Export(&buf, time.Now().Add(-time.Minute), time.Now()))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"data":{"labels":{"__name__":"up","job":"test"},"timestamp":1000,"value":"1"}`)
+	require.Contains(t, lines[1], `"value":"NaN"`)
+}
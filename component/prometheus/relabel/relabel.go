@@ -3,6 +3,7 @@ package relabel
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 
 	"go.uber.org/atomic"
@@ -10,11 +11,13 @@ import (
 	"github.com/prometheus/prometheus/storage"
 
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/livedebugging"
 	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/prometheus"
 	lru "github.com/hashicorp/golang-lru/v2"
 	prometheus_client "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/metadata"
 
@@ -43,15 +46,27 @@ type Arguments struct {
 	MetricRelabelConfigs []*flow_relabel.Config `river:"rule,block,optional"`
 
 	// Cache size to use for LRU cache.
-	//CacheSize int `river:"cache_size,attr,optional"`
+	CacheSize int `river:"max_cache_size,attr,optional"`
+}
+
+// DefaultArguments holds the default arguments for the prometheus.relabel
+// component.
+var DefaultArguments = Arguments{
+	CacheSize: 100_000,
 }
 
 // SetToDefault implements river.Defaulter.
-/*func (arg *Arguments) SetToDefault() {
-	*arg = Arguments{
-		CacheSize: 500_000,
+func (arg *Arguments) SetToDefault() {
+	*arg = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (arg *Arguments) Validate() error {
+	if arg.CacheSize <= 0 {
+		return fmt.Errorf("max_cache_size must be greater than 0 and is %d", arg.CacheSize)
 	}
-}*/
+	return nil
+}
 
 // Exports holds values which are exported by the prometheus.relabel component.
 type Exports struct {
@@ -73,18 +88,20 @@ type Component struct {
 	cacheDeletes     prometheus_client.Counter
 	fanout           *prometheus.Fanout
 	exited           atomic.Bool
+	debug            *livedebugging.Hub
 
 	cacheMut sync.RWMutex
 	cache    *lru.Cache[uint64, *labelAndID]
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component              = (*Component)(nil)
+	_ component.LiveDebuggingComponent = (*Component)(nil)
 )
 
 // New creates a new prometheus.relabel component.
 func New(o component.Options, args Arguments) (*Component, error) {
-	cache, err := lru.New[uint64, *labelAndID](100_000)
+	cache, err := lru.New[uint64, *labelAndID](args.CacheSize)
 	if err != nil {
 		return nil, err
 	}
@@ -124,7 +141,13 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		}
 	}
 
+	c.debug, err = livedebugging.NewHub(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
 	c.fanout = prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
+	c.fanout.SetTracerProvider(o.Tracer)
 	c.receiver = prometheus.NewInterceptor(
 		c.fanout,
 		prometheus.WithAppendHook(func(_ storage.SeriesRef, l labels.Labels, t int64, v float64, next storage.Appender) (storage.SeriesRef, error) {
@@ -132,11 +155,15 @@ func New(o component.Options, args Arguments) (*Component, error) {
 				return 0, fmt.Errorf("%s has exited", o.ID)
 			}
 
+			c.metricsProcessed.Inc()
 			newLbl := c.relabel(v, l)
 			if newLbl.IsEmpty() {
 				return 0, nil
 			}
 			c.metricsOutgoing.Inc()
+			if c.debug.Active() {
+				c.debug.Publish("series", func() interface{} { return newSeriesSample(newLbl, t, &v) })
+			}
 			return next.Append(0, newLbl, t, v)
 		}),
 		prometheus.WithExemplarHook(func(_ storage.SeriesRef, l labels.Labels, e exemplar.Exemplar, next storage.Appender) (storage.SeriesRef, error) {
@@ -161,6 +188,30 @@ func New(o component.Options, args Arguments) (*Component, error) {
 			}
 			return next.UpdateMetadata(0, newLbl, m)
 		}),
+		prometheus.WithAppendHistogram(func(_ storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, next storage.Appender) (storage.SeriesRef, error) {
+			if c.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			// Stale histograms are marked by a stale NaN sum.
+			var sum float64
+			if h != nil {
+				sum = h.Sum
+			} else if fh != nil {
+				sum = fh.Sum
+			}
+
+			c.metricsProcessed.Inc()
+			newLbl := c.relabel(sum, l)
+			if newLbl.IsEmpty() {
+				return 0, nil
+			}
+			c.metricsOutgoing.Inc()
+			if c.debug.Active() {
+				c.debug.Publish("series", func() interface{} { return newSeriesSample(newLbl, t, nil) })
+			}
+			return next.AppendHistogram(0, newLbl, t, h, fh)
+		}),
 	)
 
 	// Immediately export the receiver which remains the same for the component
@@ -189,7 +240,9 @@ func (c *Component) Update(args component.Arguments) error {
 	defer c.mut.Unlock()
 
 	newArgs := args.(Arguments)
-	c.clearCache(100_000)
+	if err := c.clearCache(newArgs.CacheSize); err != nil {
+		return err
+	}
 	c.mrc = flow_relabel.ComponentToPromRelabelConfigs(newArgs.MetricRelabelConfigs)
 	c.fanout.UpdateChildren(newArgs.ForwardTo)
 
@@ -198,6 +251,29 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// LiveDebugging implements component.LiveDebuggingComponent. Samples and
+// histograms are published after they're relabeled, unless they're dropped.
+func (c *Component) LiveDebugging() *livedebugging.Hub { return c.debug }
+
+// seriesSample is a sample published for live debugging. Value is nil for
+// histograms.
+type seriesSample struct {
+	Labels    string  `json:"labels"`
+	Timestamp int64   `json:"timestamp"`
+	Value     *string `json:"value,omitempty"`
+}
+
+func newSeriesSample(lbls labels.Labels, t int64, v *float64) seriesSample {
+	s := seriesSample{Labels: lbls.String(), Timestamp: t}
+	if v != nil {
+		// Values are formatted as strings, since JSON can't represent NaN and
+		// infinities.
+		formatted := strconv.FormatFloat(*v, 'g', -1, 64)
+		s.Value = &formatted
+	}
+	return s
+}
+
 func (c *Component) relabel(val float64, lbls labels.Labels) labels.Labels {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
@@ -249,11 +325,16 @@ func (c *Component) deleteFromCache(id uint64) {
 	c.cache.Remove(id)
 }
 
-func (c *Component) clearCache(cacheSize int) {
+func (c *Component) clearCache(cacheSize int) error {
 	c.cacheMut.Lock()
 	defer c.cacheMut.Unlock()
-	cache, _ := lru.New[uint64, *labelAndID](cacheSize)
+	cache, err := lru.New[uint64, *labelAndID](cacheSize)
+	if err != nil {
+		return err
+	}
 	c.cache = cache
+	c.cacheSize.Set(0)
+	return nil
 }
 
 func (c *Component) addToCache(originalID uint64, lbls labels.Labels, keep bool) {
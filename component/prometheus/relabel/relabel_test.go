@@ -15,8 +15,12 @@ import (
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
 	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
 	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/stretchr/testify/require"
@@ -42,6 +46,7 @@ func TestUpdateReset(t *testing.T) {
 	relabeller.relabel(0, lbls)
 	require.True(t, relabeller.cache.Len() == 1)
 	_ = relabeller.Update(Arguments{
+		CacheSize:            100_000,
 		MetricRelabelConfigs: []*flow_relabel.Config{},
 	})
 	require.True(t, relabeller.cache.Len() == 0)
@@ -58,6 +63,7 @@ func TestNil(t *testing.T) {
 		OnStateChange: func(e component.Exports) {},
 		Registerer:    prom.NewRegistry(),
 	}, Arguments{
+		CacheSize: 100_000,
 		ForwardTo: []storage.Appendable{fanout},
 		MetricRelabelConfigs: []*flow_relabel.Config{
 			{
@@ -74,6 +80,130 @@ func TestNil(t *testing.T) {
 	relabeller.relabel(0, lbls)
 }
 
+func TestHistogram(t *testing.T) {
+	var received labels.Labels
+	fanout := prometheus.NewInterceptor(nil, prometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ *histogram.Histogram, _ *histogram.FloatHistogram, _ storage.Appender) (storage.SeriesRef, error) {
+		received = l
+		return ref, nil
+	}))
+	relabeller, err := New(component.Options{
+		ID:            "1",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, Arguments{
+		CacheSize: 100_000,
+		ForwardTo: []storage.Appendable{fanout},
+		MetricRelabelConfigs: []*flow_relabel.Config{
+			{
+				SourceLabels: []string{"__address__"},
+				Regex:        flow_relabel.Regexp(relabel.MustNewRegexp("(.+)")),
+				TargetLabel:  "new_label",
+				Replacement:  "new_value",
+				Action:       "replace",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	lbls := labels.FromStrings("__address__", "localhost")
+	app := relabeller.receiver.Appender(context.Background())
+	_, err = app.AppendHistogram(0, lbls, time.Now().UnixMilli(), &histogram.Histogram{Count: 1, Sum: 1}, nil)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+	require.Equal(t, "new_value", received.Get("new_label"))
+	require.Equal(t, 1, relabeller.cache.Len())
+
+	// Stale histograms remove their series from the cache.
+	_, err = app.AppendHistogram(0, lbls, time.Now().UnixMilli(), &histogram.Histogram{Sum: math.Float64frombits(value.StaleNaN)}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, relabeller.cache.Len())
+}
+
+func TestAllDataKinds(t *testing.T) {
+	var received []string
+	record := func(kind string, l labels.Labels) {
+		received = append(received, kind+":"+l.Get("new_label"))
+	}
+	fanout := prometheus.NewInterceptor(nil,
+		prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+			record("sample", l)
+			return ref, nil
+		}),
+		prometheus.WithExemplarHook(func(ref storage.SeriesRef, l labels.Labels, _ exemplar.Exemplar, _ storage.Appender) (storage.SeriesRef, error) {
+			record("exemplar", l)
+			return ref, nil
+		}),
+		prometheus.WithMetadataHook(func(ref storage.SeriesRef, l labels.Labels, _ metadata.Metadata, _ storage.Appender) (storage.SeriesRef, error) {
+			record("metadata", l)
+			return ref, nil
+		}),
+		prometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, _ int64, h *histogram.Histogram, _ *histogram.FloatHistogram, _ storage.Appender) (storage.SeriesRef, error) {
+			if h != nil {
+				record("histogram", l)
+			} else {
+				record("float_histogram", l)
+			}
+			return ref, nil
+		}),
+	)
+	relabeller, err := New(component.Options{
+		ID:            "1",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, Arguments{
+		CacheSize: 100_000,
+		ForwardTo: []storage.Appendable{fanout},
+		MetricRelabelConfigs: []*flow_relabel.Config{
+			{
+				SourceLabels: []string{"__address__"},
+				Regex:        flow_relabel.Regexp(relabel.MustNewRegexp("(.+)")),
+				TargetLabel:  "new_label",
+				Replacement:  "new_value",
+				Action:       "replace",
+			},
+			{
+				SourceLabels: []string{"__address__"},
+				Regex:        flow_relabel.Regexp(relabel.MustNewRegexp("dropped")),
+				Action:       "drop",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	appendAll := func(lbls labels.Labels) {
+		ts := time.Now().UnixMilli()
+		app := relabeller.receiver.Appender(context.Background())
+		_, err := app.Append(0, lbls, ts, 1)
+		require.NoError(t, err)
+		_, err = app.AppendExemplar(0, lbls, exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "abc"), Value: 1, Ts: ts, HasTs: true})
+		require.NoError(t, err)
+		_, err = app.UpdateMetadata(0, lbls, metadata.Metadata{Type: textparse.MetricTypeHistogram})
+		require.NoError(t, err)
+		_, err = app.AppendHistogram(0, lbls, ts, &histogram.Histogram{Count: 1, Sum: 1}, nil)
+		require.NoError(t, err)
+		_, err = app.AppendHistogram(0, lbls, ts, nil, &histogram.FloatHistogram{Count: 1, Sum: 1})
+		require.NoError(t, err)
+		require.NoError(t, app.Commit())
+	}
+
+	// Every kind of data is relabeled like samples.
+	appendAll(labels.FromStrings("__address__", "localhost"))
+	require.Equal(t, []string{
+		"sample:new_value",
+		"exemplar:new_value",
+		"metadata:new_value",
+		"histogram:new_value",
+		"float_histogram:new_value",
+	}, received)
+
+	// And dropped like samples.
+	received = nil
+	appendAll(labels.FromStrings("__address__", "dropped"))
+	require.Empty(t, received)
+}
+
 func TestLRU(t *testing.T) {
 	relabeller := generateRelabel(t)
 
@@ -108,6 +238,7 @@ func BenchmarkCache(b *testing.B) {
 		},
 		Registerer: prom.NewRegistry(),
 	}, Arguments{
+		CacheSize: 100_000,
 		ForwardTo: []storage.Appendable{fanout},
 		MetricRelabelConfigs: []*flow_relabel.Config{
 			{
@@ -140,6 +271,7 @@ func generateRelabel(t *testing.T) *Component {
 		OnStateChange: func(e component.Exports) {},
 		Registerer:    prom.NewRegistry(),
 	}, Arguments{
+		CacheSize: 100_000,
 		ForwardTo: []storage.Appendable{fanout},
 		MetricRelabelConfigs: []*flow_relabel.Config{
 			{
@@ -202,3 +334,31 @@ func TestRuleGetter(t *testing.T) {
 	require.Equal(t, gotUpdated[0].SourceLabels, gotOriginal[0].SourceLabels)
 	require.Equal(t, gotUpdated[0].Regex, gotOriginal[0].Regex)
 }
+
+func TestCacheSize(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`forward_to = []`), &args))
+	require.Equal(t, DefaultArguments.CacheSize, args.CacheSize)
+
+	require.NoError(t, river.Unmarshal([]byte(`
+		forward_to     = []
+		max_cache_size = 10`), &args))
+	require.Equal(t, 10, args.CacheSize)
+
+	relabeller, err := New(component.Options{
+		ID:            "1",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prom.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		relabeller.relabel(0, labels.FromStrings("inc", strconv.Itoa(i)))
+	}
+	require.Equal(t, 10, relabeller.cache.Len())
+
+	err = river.Unmarshal([]byte(`
+		forward_to     = []
+		max_cache_size = 0`), &args)
+	require.ErrorContains(t, err, "max_cache_size must be greater than 0")
+}
@@ -0,0 +1,514 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/config"
+)
+
+// autoTuneCheckInterval is how often queues are checked for whether their
+// tuning interval has elapsed.
+var autoTuneCheckInterval = 15 * time.Second
+
+const (
+	// bytesPerBufferedSample is the estimated memory used by each sample
+	// buffered by a shard, including its labels once added to a batch.
+	bytesPerBufferedSample = 512
+
+	// minAutoTuneSamplesPerSend is the smallest batch size the tuner sends,
+	// unless the queue is configured with smaller batches.
+	minAutoTuneSamplesPerSend = 100
+
+	// shardHeadroom is the fraction of shards added on top of the desired
+	// shards when scaling up.
+	shardHeadroom = 0.5
+
+	// maxScaleDownDelay is the maximum delay of a queue behind the WAL for its
+	// shards to be scaled down.
+	maxScaleDownDelay = 10 * time.Second
+)
+
+// Decisions of the tuner.
+const (
+	decisionScaleUp       = "scale_up"
+	decisionScaleDown     = "scale_down"
+	decisionIncreaseBatch = "increase_batch"
+	decisionDecreaseBatch = "decrease_batch"
+	decisionMemoryLimited = "memory_limited"
+)
+
+// queueSettings are the settings of a queue chosen by the tuner.
+type queueSettings struct {
+	maxShards         int
+	maxSamplesPerSend int
+	capacity          int
+}
+
+// memory returns the estimated memory used by the buffers of the shards.
+func (s queueSettings) memory() int64 {
+	return int64(s.maxShards) * int64(s.capacity+s.maxSamplesPerSend) * bytesPerBufferedSample
+}
+
+// queueLimits bound the settings chosen by the tuner for a queue.
+type queueLimits struct {
+	minShards         int
+	maxSamplesPerSend int // Configured batch size, as the largest batches.
+	batchesPerShard   int // Ratio between the capacity and the batch size.
+	remoteTimeout     time.Duration
+	maxMemory         int64
+}
+
+func newQueueLimits(base config.QueueConfig, remoteTimeout time.Duration, opts AutoTuneOptions) queueLimits {
+	return queueLimits{
+		minShards:         base.MinShards,
+		maxSamplesPerSend: base.MaxSamplesPerSend,
+		batchesPerShard:   int(math.Ceil(float64(base.Capacity) / float64(base.MaxSamplesPerSend))),
+		remoteTimeout:     remoteTimeout,
+		maxMemory:         int64(opts.MaxMemory),
+	}
+}
+
+// minSamplesPerSend returns the smallest batch size of the queue.
+func (l queueLimits) minSamplesPerSend() int {
+	if l.maxSamplesPerSend < minAutoTuneSamplesPerSend {
+		return l.maxSamplesPerSend
+	}
+	return minAutoTuneSamplesPerSend
+}
+
+// withBatchSize returns s sending batches of size samples.
+func (l queueLimits) withBatchSize(s queueSettings, size int) queueSettings {
+	s.maxSamplesPerSend = size
+	s.capacity = size * l.batchesPerShard
+	return s
+}
+
+// maxShardsFor returns the largest number of shards fitting in the memory
+// budget when sending batches of size samples.
+func (l queueLimits) maxShardsFor(size int) int {
+	perShard := int64(size*(l.batchesPerShard+1)) * bytesPerBufferedSample
+	return int(l.maxMemory / perShard)
+}
+
+// fit returns s with shards, and then batches, shrunk to fit in the memory
+// budget.
+func (l queueLimits) fit(s queueSettings) (queueSettings, error) {
+	if s.memory() <= l.maxMemory {
+		return s, nil
+	}
+
+	if limit := l.maxShardsFor(s.maxSamplesPerSend); limit >= l.minShards {
+		s.maxShards = limit
+		return s, nil
+	}
+
+	s.maxShards = l.minShards
+	perSample := int64(l.minShards*(l.batchesPerShard+1)) * bytesPerBufferedSample
+	size := int(l.maxMemory / perSample)
+	if size < l.minSamplesPerSend() {
+		return s, fmt.Errorf("max_memory of %d bytes is too small to buffer batches of %d samples for %d shards", l.maxMemory, l.minSamplesPerSend(), l.minShards)
+	}
+	return l.withBatchSize(s, size), nil
+}
+
+// queueStats are the observations of a queue over a tuning interval.
+type queueStats struct {
+	desiredShards float64       // Shards desired by the queue.
+	batches       float64       // Batches sent during the interval.
+	batchDuration time.Duration // Average time taken to send a batch.
+	delay         time.Duration // Delay of the queue behind the WAL.
+}
+
+// tuneQueue chooses the next settings of a queue. Shards are scaled up while
+// the queue desires as many shards as it may run, and scaled down when it
+// needs far fewer. Batches are made smaller when requests get close to timing
+// out, and restored to their configured size once requests are fast again.
+// The returned decision is empty when the settings are unchanged.
+func tuneQueue(cur queueSettings, s queueStats, l queueLimits) (next queueSettings, decision string) {
+	next = cur
+	if s.batches <= 0 {
+		// Nothing was sent, so the observations say nothing about the
+		// remote endpoint.
+		return next, ""
+	}
+
+	switch {
+	case s.batchDuration > l.remoteTimeout/2 && cur.maxSamplesPerSend > l.minSamplesPerSend():
+		size := cur.maxSamplesPerSend / 2
+		if size < l.minSamplesPerSend() {
+			size = l.minSamplesPerSend()
+		}
+		return l.withBatchSize(cur, size), decisionDecreaseBatch
+
+	case s.desiredShards >= float64(cur.maxShards):
+		limit := l.maxShardsFor(cur.maxSamplesPerSend)
+		if limit <= cur.maxShards {
+			return next, decisionMemoryLimited
+		}
+
+		want := int(math.Ceil(s.desiredShards * (1 + shardHeadroom)))
+		if want > limit {
+			want = limit
+		}
+		next.maxShards = want
+		return next, decisionScaleUp
+
+	case s.batchDuration < l.remoteTimeout/10 && cur.maxSamplesPerSend < l.maxSamplesPerSend:
+		size := cur.maxSamplesPerSend * 2
+		if size > l.maxSamplesPerSend {
+			size = l.maxSamplesPerSend
+		}
+		next = l.withBatchSize(cur, size)
+		if next.memory() > l.maxMemory {
+			return cur, ""
+		}
+		return next, decisionIncreaseBatch
+
+	case s.desiredShards*4 <= float64(cur.maxShards) && s.delay < maxScaleDownDelay:
+		want := int(math.Ceil(s.desiredShards * 2))
+		if want < l.minShards {
+			want = l.minShards
+		}
+		if want >= cur.maxShards {
+			return cur, ""
+		}
+		next.maxShards = want
+		return next, decisionScaleDown
+	}
+
+	return next, ""
+}
+
+// tunedQueue is the state of a queue tuned by the queueTuner.
+type tunedQueue struct {
+	name     string             // Name of the queue, used to find its metrics.
+	base     config.QueueConfig // Configured settings of the queue.
+	opts     AutoTuneOptions
+	limits   queueLimits
+	settings queueSettings
+
+	lastTuned time.Time
+	// Totals of the sent batch durations when the queue was last tuned.
+	lastBatchCount uint64
+	lastBatchSum   float64
+}
+
+// queueTuner adjusts the shards and batch sizes of the queues with auto_tune
+// set. The tuner observes the metrics of the queues, and changes queue
+// settings by reapplying the config of the component, which restarts the
+// queues whose settings changed.
+type queueTuner struct {
+	log log.Logger
+	// reg observes the metrics registered by the remote storage.
+	reg *prometheus.Registry
+
+	maxShards         *prometheus.GaugeVec
+	maxSamplesPerSend *prometheus.GaugeVec
+	capacity          *prometheus.GaugeVec
+	memory            *prometheus.GaugeVec
+	decisions         *prometheus.CounterVec
+
+	mut    sync.Mutex
+	queues map[string]*tunedQueue // Queue key -> queue
+}
+
+func newQueueTuner(l log.Logger, reg prometheus.Registerer) (*queueTuner, error) {
+	t := &queueTuner{
+		log: l,
+		reg: prometheus.NewRegistry(),
+
+		maxShards: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "prometheus_remote_write_queue_tuned_max_shards",
+			Help: "Maximum number of shards of the queue chosen by auto-tuning.",
+		}, []string{"queue"}),
+		maxSamplesPerSend: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "prometheus_remote_write_queue_tuned_max_samples_per_send",
+			Help: "Maximum number of samples per batch of the queue chosen by auto-tuning.",
+		}, []string{"queue"}),
+		capacity: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "prometheus_remote_write_queue_tuned_capacity",
+			Help: "Number of samples buffered per shard of the queue chosen by auto-tuning.",
+		}, []string{"queue"}),
+		memory: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "prometheus_remote_write_queue_tuned_memory_bytes",
+			Help: "Estimated memory used by the buffers of the queue with the settings chosen by auto-tuning.",
+		}, []string{"queue"}),
+		decisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prometheus_remote_write_queue_tuning_decisions_total",
+			Help: "Total number of decisions made by auto-tuning, by decision.",
+		}, []string{"queue", "decision"}),
+
+		queues: make(map[string]*tunedQueue),
+	}
+
+	for _, c := range []prometheus.Collector{t.maxShards, t.maxSamplesPerSend, t.capacity, t.memory, t.decisions} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Registerer returns a Registerer registering collectors to both reg and the
+// tuner, for the tuner to observe the metrics of the remote storage.
+func (t *queueTuner) Registerer(reg prometheus.Registerer) prometheus.Registerer {
+	return teeRegisterer{reg, t.reg}
+}
+
+// Apply sets the settings chosen by the tuner on the queues with auto_tune
+// set. The settings of a queue are reset whenever its configured settings
+// change.
+func (t *queueTuner) Apply(queues []*remoteQueue) error {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	seen := make(map[string]struct{}, len(queues))
+	for _, q := range queues {
+		if q.autoTune == nil {
+			continue
+		}
+		seen[q.key] = struct{}{}
+
+		var (
+			qc = &q.config.QueueConfig
+			tq = t.queues[q.key]
+		)
+		if tq == nil || tq.base != *qc || tq.opts != *q.autoTune {
+			limits := newQueueLimits(*qc, time.Duration(q.config.RemoteTimeout), *q.autoTune)
+			settings, err := limits.fit(queueSettings{
+				maxShards:         qc.MaxShards,
+				maxSamplesPerSend: qc.MaxSamplesPerSend,
+				capacity:          qc.Capacity,
+			})
+			if err != nil {
+				return fmt.Errorf("queue %s: %w", q.key, err)
+			}
+
+			tq = &tunedQueue{
+				base:      *qc,
+				opts:      *q.autoTune,
+				limits:    limits,
+				settings:  settings,
+				lastTuned: time.Now(),
+			}
+			t.queues[q.key] = tq
+		}
+
+		qc.MaxShards = tq.settings.maxShards
+		qc.MaxSamplesPerSend = tq.settings.maxSamplesPerSend
+		qc.Capacity = tq.settings.capacity
+
+		name, err := queueName(q.config)
+		if err != nil {
+			return err
+		}
+		tq.name = name
+
+		t.maxShards.WithLabelValues(q.key).Set(float64(tq.settings.maxShards))
+		t.maxSamplesPerSend.WithLabelValues(q.key).Set(float64(tq.settings.maxSamplesPerSend))
+		t.capacity.WithLabelValues(q.key).Set(float64(tq.settings.capacity))
+		t.memory.WithLabelValues(q.key).Set(float64(tq.settings.memory()))
+	}
+
+	for key := range t.queues {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		delete(t.queues, key)
+		t.maxShards.DeleteLabelValues(key)
+		t.maxSamplesPerSend.DeleteLabelValues(key)
+		t.capacity.DeleteLabelValues(key)
+		t.memory.DeleteLabelValues(key)
+		t.decisions.DeletePartialMatch(prometheus.Labels{"queue": key})
+	}
+	return nil
+}
+
+// run tunes the queues until ctx is canceled. reapply is called whenever the
+// settings of a queue changed.
+func (t *queueTuner) run(ctx context.Context, reapply func()) {
+	ticker := time.NewTicker(autoTuneCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if t.tune(now) {
+				reapply()
+			}
+		}
+	}
+}
+
+// tune makes a decision for every queue whose tuning interval elapsed and
+// returns true if the settings of a queue changed.
+func (t *queueTuner) tune(now time.Time) bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if len(t.queues) == 0 {
+		return false
+	}
+
+	families, err := t.reg.Gather()
+	if err != nil {
+		level.Warn(t.log).Log("msg", "failed to gather queue metrics for auto-tuning", "err", err)
+		return false
+	}
+	obs := observeQueues(families)
+
+	var changed bool
+	for key, tq := range t.queues {
+		if now.Sub(tq.lastTuned) < tq.opts.Interval {
+			continue
+		}
+		o, ok := obs.queues[tq.name]
+		if !ok {
+			continue
+		}
+
+		// The totals restart from zero when the queue restarts.
+		count, sum := o.batchCount, o.batchSum
+		if count >= tq.lastBatchCount {
+			count -= tq.lastBatchCount
+			sum -= tq.lastBatchSum
+		}
+		stats := queueStats{
+			desiredShards: o.desiredShards,
+			batches:       float64(count),
+			delay:         time.Duration((obs.highestTimestamp - o.highestSentTimestamp) * float64(time.Second)),
+		}
+		if count > 0 {
+			stats.batchDuration = time.Duration(sum / float64(count) * float64(time.Second))
+		}
+
+		tq.lastTuned = now
+		tq.lastBatchCount, tq.lastBatchSum = o.batchCount, o.batchSum
+
+		settings, decision := tuneQueue(tq.settings, stats, tq.limits)
+		if decision == "" {
+			continue
+		}
+		t.decisions.WithLabelValues(key, decision).Inc()
+
+		if settings == tq.settings {
+			level.Debug(t.log).Log("msg", "queue can't be tuned further", "queue", key, "decision", decision)
+			continue
+		}
+		level.Info(t.log).Log(
+			"msg", "tuned queue",
+			"queue", key,
+			"decision", decision,
+			"desired_shards", stats.desiredShards,
+			"batch_duration", stats.batchDuration,
+			"max_shards", settings.maxShards,
+			"max_samples_per_send", settings.maxSamplesPerSend,
+			"capacity", settings.capacity,
+		)
+		tq.settings = settings
+		changed = true
+	}
+	return changed
+}
+
+// queueObservation holds the metrics of a queue.
+type queueObservation struct {
+	desiredShards        float64
+	highestSentTimestamp float64
+	batchCount           uint64
+	batchSum             float64
+}
+
+// queueObservations holds the metrics of the queues.
+type queueObservations struct {
+	highestTimestamp float64                      // Highest timestamp written to the WAL, in seconds.
+	queues           map[string]*queueObservation // Queue name -> metrics of the queue
+}
+
+func (obs *queueObservations) queue(name string) *queueObservation {
+	o, ok := obs.queues[name]
+	if !ok {
+		o = &queueObservation{}
+		obs.queues[name] = o
+	}
+	return o
+}
+
+// observeQueues extracts the metrics of the queues from the metrics
+// registered by the remote storage.
+func observeQueues(families []*dto.MetricFamily) *queueObservations {
+	obs := &queueObservations{queues: make(map[string]*queueObservation)}
+
+	for _, fam := range families {
+		for _, m := range fam.GetMetric() {
+			var name string
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "remote_name" {
+					name = l.GetValue()
+				}
+			}
+
+			switch fam.GetName() {
+			case "prometheus_remote_storage_highest_timestamp_in_seconds":
+				obs.highestTimestamp = m.GetGauge().GetValue()
+			case "prometheus_remote_storage_shards_desired":
+				obs.queue(name).desiredShards = m.GetGauge().GetValue()
+			case "prometheus_remote_storage_queue_highest_sent_timestamp_seconds":
+				obs.queue(name).highestSentTimestamp = m.GetGauge().GetValue()
+			case "prometheus_remote_storage_sent_batch_duration_seconds":
+				o := obs.queue(name)
+				o.batchCount = m.GetHistogram().GetSampleCount()
+				o.batchSum = m.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+	return obs
+}
+
+// teeRegisterer registers collectors to all of its registerers.
+type teeRegisterer []prometheus.Registerer
+
+var _ prometheus.Registerer = teeRegisterer(nil)
+
+// Register implements prometheus.Registerer.
+func (t teeRegisterer) Register(c prometheus.Collector) error {
+	for i, reg := range t {
+		if err := reg.Register(c); err != nil {
+			for _, registered := range t[:i] {
+				registered.Unregister(c)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// MustRegister implements prometheus.Registerer.
+func (t teeRegisterer) MustRegister(cs ...prometheus.Collector) {
+	for _, c := range cs {
+		if err := t.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister implements prometheus.Registerer.
+func (t teeRegisterer) Unregister(c prometheus.Collector) bool {
+	ok := true
+	for _, reg := range t {
+		if !reg.Unregister(c) {
+			ok = false
+		}
+	}
+	return ok
+}
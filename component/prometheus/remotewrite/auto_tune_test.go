@@ -0,0 +1,173 @@
+package remotewrite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTuneQueue(t *testing.T) {
+	limits := queueLimits{
+		minShards:         1,
+		maxSamplesPerSend: 2000,
+		batchesPerShard:   5,
+		remoteTimeout:     30 * time.Second,
+		maxMemory:         100 * 12000 * bytesPerBufferedSample, // 100 shards of 2000 samples
+	}
+	cur := queueSettings{maxShards: 10, maxSamplesPerSend: 2000, capacity: 10000}
+
+	tt := []struct {
+		name     string
+		cur      queueSettings
+		stats    queueStats
+		expect   queueSettings
+		decision string
+	}{
+		{
+			name:   "nothing sent",
+			cur:    cur,
+			stats:  queueStats{desiredShards: 50},
+			expect: cur,
+		},
+		{
+			name:     "scale up",
+			cur:      cur,
+			stats:    queueStats{desiredShards: 12, batches: 100, batchDuration: time.Second},
+			expect:   queueSettings{maxShards: 18, maxSamplesPerSend: 2000, capacity: 10000},
+			decision: decisionScaleUp,
+		},
+		{
+			name:     "scale up to the memory budget",
+			cur:      cur,
+			stats:    queueStats{desiredShards: 80, batches: 100, batchDuration: time.Second},
+			expect:   queueSettings{maxShards: 100, maxSamplesPerSend: 2000, capacity: 10000},
+			decision: decisionScaleUp,
+		},
+		{
+			name:     "memory limited",
+			cur:      queueSettings{maxShards: 100, maxSamplesPerSend: 2000, capacity: 10000},
+			stats:    queueStats{desiredShards: 120, batches: 100, batchDuration: time.Second},
+			expect:   queueSettings{maxShards: 100, maxSamplesPerSend: 2000, capacity: 10000},
+			decision: decisionMemoryLimited,
+		},
+		{
+			name:     "scale down",
+			cur:      cur,
+			stats:    queueStats{desiredShards: 1.2, batches: 100, batchDuration: 5 * time.Second},
+			expect:   queueSettings{maxShards: 3, maxSamplesPerSend: 2000, capacity: 10000},
+			decision: decisionScaleDown,
+		},
+		{
+			name:   "no scale down while behind",
+			cur:    cur,
+			stats:  queueStats{desiredShards: 1.2, batches: 100, batchDuration: 5 * time.Second, delay: time.Minute},
+			expect: cur,
+		},
+		{
+			name:     "decrease batch",
+			cur:      cur,
+			stats:    queueStats{desiredShards: 12, batches: 100, batchDuration: 20 * time.Second},
+			expect:   queueSettings{maxShards: 10, maxSamplesPerSend: 1000, capacity: 5000},
+			decision: decisionDecreaseBatch,
+		},
+		{
+			name:     "increase batch",
+			cur:      queueSettings{maxShards: 10, maxSamplesPerSend: 500, capacity: 2500},
+			stats:    queueStats{desiredShards: 5, batches: 100, batchDuration: time.Second},
+			expect:   queueSettings{maxShards: 10, maxSamplesPerSend: 1000, capacity: 5000},
+			decision: decisionIncreaseBatch,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			next, decision := tuneQueue(tc.cur, tc.stats, limits)
+			require.Equal(t, tc.expect, next)
+			require.Equal(t, tc.decision, decision)
+		})
+	}
+}
+
+func TestQueueLimits_Fit(t *testing.T) {
+	limits := queueLimits{
+		minShards:         2,
+		maxSamplesPerSend: 2000,
+		batchesPerShard:   5,
+		maxMemory:         10 * 12000 * bytesPerBufferedSample,
+	}
+
+	s, err := limits.fit(queueSettings{maxShards: 50, maxSamplesPerSend: 2000, capacity: 10000})
+	require.NoError(t, err)
+	require.Equal(t, queueSettings{maxShards: 10, maxSamplesPerSend: 2000, capacity: 10000}, s)
+
+	limits.minShards = 20
+	s, err = limits.fit(queueSettings{maxShards: 50, maxSamplesPerSend: 2000, capacity: 10000})
+	require.NoError(t, err)
+	require.Equal(t, queueSettings{maxShards: 20, maxSamplesPerSend: 1000, capacity: 5000}, s)
+
+	limits.minShards = 1000
+	_, err = limits.fit(queueSettings{maxShards: 1000, maxSamplesPerSend: 2000, capacity: 10000})
+	require.ErrorContains(t, err, "max_memory")
+}
+
+func TestAutoTune(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		endpoint {
+			url = "`+srv.URL+`"
+
+			queue_config {
+				auto_tune {
+					max_memory = "10MiB"
+				}
+			}
+		}
+	`), &args))
+
+	reg := prometheus.NewRegistry()
+	c, err := NewComponent(component.Options{
+		ID:            "prometheus.remote_write.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    reg,
+		DataPath:      t.TempDir(),
+		OnStateChange: func(e component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+
+	// The initial settings are shrunk to fit in the memory budget.
+	tq := c.tuner.queues[srv.URL]
+	require.NotNil(t, tq)
+	require.Equal(t, queueSettings{maxShards: 1, maxSamplesPerSend: 2000, capacity: 10000}, tq.settings)
+
+	// The metrics of the queue are found under the name of the queue.
+	families, err := c.tuner.reg.Gather()
+	require.NoError(t, err)
+	require.Contains(t, observeQueues(families).queues, tq.name)
+
+	// Decisions are exposed as metrics.
+	families, err = reg.Gather()
+	require.NoError(t, err)
+	var found bool
+	for _, fam := range families {
+		if fam.GetName() == "prometheus_remote_write_queue_tuned_max_shards" {
+			found = true
+			require.Equal(t, 1.0, fam.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+	require.True(t, found)
+
+	// Queues are forgotten when auto-tuning is disabled.
+	args.Endpoints[0].QueueOptions.AutoTune = nil
+	require.NoError(t, c.Update(args))
+	require.Empty(t, c.tuner.queues)
+}
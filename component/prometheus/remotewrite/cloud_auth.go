@@ -0,0 +1,404 @@
+package remotewrite
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Azure clouds supported by the azuread block.
+const (
+	azureCloudPublic     = "AzurePublic"
+	azureCloudChina      = "AzureChina"
+	azureCloudGovernment = "AzureGovernment"
+)
+
+var azureClouds = map[string]struct {
+	config cloud.Configuration
+	scope  string
+}{
+	azureCloudPublic:     {cloud.AzurePublic, "https://monitor.azure.com//.default"},
+	azureCloudChina:      {cloud.AzureChina, "https://monitor.azure.cn//.default"},
+	azureCloudGovernment: {cloud.AzureGovernment, "https://monitor.azure.us//.default"},
+}
+
+// DefaultGoogleIAMScopes are the scopes of the tokens requested by the
+// google_iam block by default.
+var DefaultGoogleIAMScopes = []string{"https://www.googleapis.com/auth/monitoring.write"}
+
+// AzureADOptions configures authenticating remote_write requests with an
+// Azure AD token, as required by Azure Monitor managed service for
+// Prometheus.
+type AzureADOptions struct {
+	Cloud           string                       `river:"cloud,attr,optional"`
+	ManagedIdentity *AzureManagedIdentityOptions `river:"managed_identity,block,optional"`
+	OAuth           *AzureOAuthOptions           `river:"oauth,block,optional"`
+}
+
+// AzureManagedIdentityOptions authenticates with a managed identity. The
+// system-assigned identity is used when ClientID is empty.
+type AzureManagedIdentityOptions struct {
+	ClientID string `river:"client_id,attr,optional"`
+}
+
+// AzureOAuthOptions authenticates with the client secret of an application.
+type AzureOAuthOptions struct {
+	TenantID     string            `river:"tenant_id,attr"`
+	ClientID     string            `river:"client_id,attr"`
+	ClientSecret rivertypes.Secret `river:"client_secret,attr"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (o *AzureADOptions) SetToDefault() {
+	*o = AzureADOptions{Cloud: azureCloudPublic}
+}
+
+// Validate implements river.Validator.
+func (o *AzureADOptions) Validate() error {
+	if _, ok := azureClouds[o.Cloud]; !ok {
+		return fmt.Errorf("unsupported Azure cloud %q, must be one of %s, %s, or %s", o.Cloud, azureCloudPublic, azureCloudChina, azureCloudGovernment)
+	}
+	if (o.ManagedIdentity == nil) == (o.OAuth == nil) {
+		return fmt.Errorf("exactly one of managed_identity and oauth must be configured in azuread")
+	}
+	return nil
+}
+
+func (o *AzureADOptions) tokenSource() (tokenSource, error) {
+	c := azureClouds[o.Cloud]
+	clientOptions := azcore.ClientOptions{Cloud: c.config}
+
+	var (
+		cred azcore.TokenCredential
+		err  error
+	)
+	switch {
+	case o.ManagedIdentity != nil:
+		opts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: clientOptions}
+		if o.ManagedIdentity.ClientID != "" {
+			opts.ID = azidentity.ClientID(o.ManagedIdentity.ClientID)
+		}
+		cred, err = azidentity.NewManagedIdentityCredential(opts)
+	default:
+		cred, err = azidentity.NewClientSecretCredential(
+			o.OAuth.TenantID,
+			o.OAuth.ClientID,
+			string(o.OAuth.ClientSecret),
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: clientOptions},
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating Azure AD credential: %w", err)
+	}
+	return &azureTokenSource{cred: cred, scope: c.scope}, nil
+}
+
+type azureTokenSource struct {
+	cred  azcore.TokenCredential
+	scope string
+}
+
+func (s *azureTokenSource) Token(ctx context.Context) (string, time.Time, error) {
+	token, err := s.cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{s.scope}})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token.Token, token.ExpiresOn, nil
+}
+
+// GoogleIAMOptions configures authenticating remote_write requests with the
+// OAuth2 token of a Google Cloud service account, such as to write to Google
+// Cloud Managed Service for Prometheus. The application default credentials
+// are used when CredentialsFile is empty.
+type GoogleIAMOptions struct {
+	CredentialsFile string   `river:"credentials_file,attr,optional"`
+	Scopes          []string `river:"scopes,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (o *GoogleIAMOptions) SetToDefault() {
+	*o = GoogleIAMOptions{Scopes: DefaultGoogleIAMScopes}
+}
+
+// Validate implements river.Validator.
+func (o *GoogleIAMOptions) Validate() error {
+	if len(o.Scopes) == 0 {
+		return fmt.Errorf("scopes must not be empty in google_iam")
+	}
+	return nil
+}
+
+func (o *GoogleIAMOptions) tokenSource() (tokenSource, error) {
+	var (
+		creds *google.Credentials
+		err   error
+	)
+	if o.CredentialsFile != "" {
+		var data []byte
+		data, err = os.ReadFile(o.CredentialsFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading Google credentials file: %w", err)
+		}
+		creds, err = google.CredentialsFromJSON(context.Background(), data, o.Scopes...)
+	} else {
+		creds, err = google.FindDefaultCredentials(context.Background(), o.Scopes...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading Google credentials: %w", err)
+	}
+	return &googleTokenSource{ts: creds.TokenSource}, nil
+}
+
+type googleTokenSource struct {
+	ts oauth2.TokenSource
+}
+
+func (s *googleTokenSource) Token(_ context.Context) (string, time.Time, error) {
+	token, err := s.ts.Token()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token.AccessToken, token.Expiry, nil
+}
+
+// tokenSource returns access tokens and when they expire. Sources cache
+// tokens until they're about to expire.
+type tokenSource interface {
+	Token(ctx context.Context) (token string, expiry time.Time, err error)
+}
+
+// cloudAuthOptions are the options of an endpoint authenticating with a token
+// from a cloud provider.
+type cloudAuthOptions struct {
+	AzureAD   *AzureADOptions
+	GoogleIAM *GoogleIAMOptions
+}
+
+func endpointCloudAuth(rw *EndpointOptions) (cloudAuthOptions, bool) {
+	opts := cloudAuthOptions{AzureAD: rw.AzureAD, GoogleIAM: rw.GoogleIAM}
+	return opts, opts.AzureAD != nil || opts.GoogleIAM != nil
+}
+
+// key identifies the token of the options. Endpoints with the same options
+// share their token.
+func (o cloudAuthOptions) key() string {
+	bb, _ := json.Marshal(o)
+	return fmt.Sprintf("%x", sha256.Sum256(bb))
+}
+
+func (o cloudAuthOptions) tokenSource() (tokenSource, error) {
+	if o.AzureAD != nil {
+		return o.AzureAD.tokenSource()
+	}
+	return o.GoogleIAM.tokenSource()
+}
+
+// tokenFile returns the path of the file holding the token of opts in dir.
+func tokenFile(dir string, opts cloudAuthOptions) string {
+	return filepath.Join(dir, opts.key()+".token")
+}
+
+const (
+	// tokenPollInterval is how often sources are asked for their token.
+	// Sources cache tokens, so polling them is cheap, and keeps the token
+	// file close to the token the source considers valid.
+	tokenPollInterval = 5 * time.Second
+
+	// maxTokenRetryInterval bounds the backoff between failed attempts to get
+	// a token.
+	maxTokenRetryInterval = time.Minute
+)
+
+// cloudAuth keeps the tokens of the endpoints authenticating with Azure AD or
+// Google IAM in files. The queues of these endpoints read the token from its
+// file for every request, like they do for the credentials_file of an
+// authorization block.
+type cloudAuth struct {
+	log       log.Logger
+	dir       string
+	newSource func(cloudAuthOptions) (tokenSource, error)
+
+	mut        sync.Mutex
+	refreshers map[string]*tokenRefresher
+}
+
+func newCloudAuth(l log.Logger, dir string) *cloudAuth {
+	// Tokens left over by a previous run may have expired. Requests fail
+	// with a recoverable error until a new token is written, rather than
+	// being rejected for using an expired token.
+	_ = os.RemoveAll(dir)
+
+	return &cloudAuth{
+		log:        l,
+		dir:        dir,
+		newSource:  cloudAuthOptions.tokenSource,
+		refreshers: make(map[string]*tokenRefresher),
+	}
+}
+
+// ApplyConfig starts refreshing the tokens of endpoints, and stops refreshing
+// the tokens which are no longer used.
+func (a *cloudAuth) ApplyConfig(endpoints []*EndpointOptions) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	wanted := make(map[string]cloudAuthOptions)
+	for _, rw := range endpoints {
+		if opts, ok := endpointCloudAuth(rw); ok {
+			wanted[opts.key()] = opts
+		}
+	}
+
+	// Create all the new sources before changing anything, so that an
+	// invalid config leaves the running refreshers untouched.
+	sources := make(map[string]tokenSource)
+	for key, opts := range wanted {
+		if _, ok := a.refreshers[key]; ok {
+			continue
+		}
+		source, err := a.newSource(opts)
+		if err != nil {
+			return err
+		}
+		sources[key] = source
+	}
+
+	if len(sources) > 0 {
+		if err := os.MkdirAll(a.dir, 0700); err != nil {
+			return fmt.Errorf("creating token directory: %w", err)
+		}
+	}
+	for key, r := range a.refreshers {
+		if _, ok := wanted[key]; !ok {
+			r.Stop()
+			delete(a.refreshers, key)
+		}
+	}
+	for key, source := range sources {
+		a.refreshers[key] = startTokenRefresher(a.log, source, tokenFile(a.dir, wanted[key]))
+	}
+	return nil
+}
+
+// Close stops refreshing tokens and removes their files.
+func (a *cloudAuth) Close() {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	for key, r := range a.refreshers {
+		r.Stop()
+		delete(a.refreshers, key)
+	}
+}
+
+// tokenRefresher writes the token of a source to a file whenever it changes.
+type tokenRefresher struct {
+	log    log.Logger
+	source tokenSource
+	path   string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startTokenRefresher(l log.Logger, source tokenSource, path string) *tokenRefresher {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &tokenRefresher{
+		log:    l,
+		source: source,
+		path:   path,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go r.run(ctx)
+	return r
+}
+
+func (r *tokenRefresher) run(ctx context.Context) {
+	defer close(r.done)
+
+	var (
+		current string
+		expiry  time.Time
+		wait    time.Duration
+	)
+	for {
+		token, exp, err := r.source.Token(ctx)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return
+		case err != nil:
+			level.Warn(r.log).Log("msg", "failed to get remote_write authentication token", "err", err)
+
+			// Requests without a token file fail with a recoverable error,
+			// while requests with an expired token would be rejected and
+			// their samples dropped.
+			if current != "" && time.Now().After(expiry) {
+				_ = os.Remove(r.path)
+				current = ""
+			}
+			wait = nextTokenRetryInterval(wait)
+			if untilExpiry := time.Until(expiry); current != "" && untilExpiry < wait {
+				wait = untilExpiry
+			}
+		case token != current:
+			if err := writeTokenFile(r.path, token); err != nil {
+				level.Error(r.log).Log("msg", "failed to write remote_write authentication token", "err", err)
+				wait = nextTokenRetryInterval(wait)
+				break
+			}
+			current, expiry, wait = token, exp, tokenPollInterval
+		default:
+			wait = tokenPollInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func nextTokenRetryInterval(prev time.Duration) time.Duration {
+	next := prev * 2
+	if next < tokenPollInterval {
+		next = tokenPollInterval
+	}
+	if next > maxTokenRetryInterval {
+		next = maxTokenRetryInterval
+	}
+	return next
+}
+
+// Stop stops the refresher and removes its token file.
+func (r *tokenRefresher) Stop() {
+	r.cancel()
+	<-r.done
+	_ = os.Remove(r.path)
+}
+
+// writeTokenFile atomically replaces the token file at path, so that requests
+// never read a partially written token.
+func writeTokenFile(path, token string) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(token), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
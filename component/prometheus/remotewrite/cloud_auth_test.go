@@ -0,0 +1,177 @@
+package remotewrite
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBadCloudAuthRiverConfig(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "azuread without credentials",
+			cfg: `
+				azuread {}`,
+			expect: "exactly one of managed_identity and oauth must be configured in azuread",
+		},
+		{
+			name: "azuread with unknown cloud",
+			cfg: `
+				azuread {
+					cloud = "AzureMoon"
+					managed_identity {}
+				}`,
+			expect: `unsupported Azure cloud "AzureMoon"`,
+		},
+		{
+			name: "azuread combined with google_iam",
+			cfg: `
+				azuread {
+					managed_identity {}
+				}
+				google_iam {}`,
+			expect: "at most one of azuread & google_iam must be configured",
+		},
+		{
+			name: "google_iam combined with bearer_token",
+			cfg: `
+				bearer_token = "token"
+				google_iam {}`,
+			expect: "azuread and google_iam can't be used together with other authentication settings",
+		},
+		{
+			name: "google_iam without scopes",
+			cfg: `
+				google_iam {
+					scopes = []
+				}`,
+			expect: "scopes must not be empty in google_iam",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := `
+				endpoint {
+					url = "http://0.0.0.0:11111/api/v1/write"
+					` + tc.cfg + `
+				}`
+
+			var args Arguments
+			err := river.Unmarshal([]byte(cfg), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestCloudAuthQueues(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		endpoint {
+			url = "http://azure/api/v1/write"
+			azuread {
+				oauth {
+					tenant_id     = "tenant"
+					client_id     = "client"
+					client_secret = "secret"
+				}
+			}
+		}
+		endpoint {
+			url = "http://google/api/v1/write"
+			google_iam {}
+		}
+		endpoint {
+			url = "http://plain/api/v1/write"
+		}
+	`), &args))
+	require.Equal(t, azureCloudPublic, args.Endpoints[0].AzureAD.Cloud)
+	require.Equal(t, DefaultGoogleIAMScopes, args.Endpoints[1].GoogleIAM.Scopes)
+
+	queues, err := convertQueues(args, nil, "/tokens")
+	require.NoError(t, err)
+	require.Len(t, queues, 3)
+
+	azureAuth := queues[0].config.HTTPClientConfig.Authorization
+	require.Equal(t, "Bearer", azureAuth.Type)
+	require.Equal(t, "/tokens", filepath.Dir(azureAuth.CredentialsFile))
+
+	googleAuth := queues[1].config.HTTPClientConfig.Authorization
+	require.Equal(t, "Bearer", googleAuth.Type)
+	require.NotEqual(t, azureAuth.CredentialsFile, googleAuth.CredentialsFile)
+
+	require.Nil(t, queues[2].config.HTTPClientConfig.Authorization)
+}
+
+type fakeTokenSource struct {
+	mut    sync.Mutex
+	token  string
+	expiry time.Time
+	err    error
+}
+
+func (s *fakeTokenSource) Token(_ context.Context) (string, time.Time, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.token, s.expiry, s.err
+}
+
+func (s *fakeTokenSource) set(token string, expiry time.Time, err error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.token, s.expiry, s.err = token, expiry, err
+}
+
+func TestCloudAuth(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "auth")
+	source := &fakeTokenSource{token: "first", expiry: time.Now().Add(time.Hour)}
+
+	auth := newCloudAuth(util.TestLogger(t), dir)
+	auth.newSource = func(cloudAuthOptions) (tokenSource, error) { return source, nil }
+	defer auth.Close()
+
+	endpoint := &EndpointOptions{GoogleIAM: &GoogleIAMOptions{Scopes: DefaultGoogleIAMScopes}}
+	require.NoError(t, auth.ApplyConfig([]*EndpointOptions{endpoint}))
+
+	opts, _ := endpointCloudAuth(endpoint)
+	path := tokenFile(dir, opts)
+	requireToken := func(expect string) {
+		require.Eventually(t, func() bool {
+			bb, _ := os.ReadFile(path)
+			return string(bb) == expect
+		}, 10*time.Second, 10*time.Millisecond)
+	}
+	requireToken("first")
+
+	// Refreshed tokens replace the token of the file.
+	source.set("second", time.Now().Add(time.Hour), nil)
+	requireToken("second")
+
+	// Tokens of removed endpoints are removed.
+	require.NoError(t, auth.ApplyConfig(nil))
+	_, err := os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, auth.ApplyConfig([]*EndpointOptions{endpoint}))
+	requireToken("second")
+
+	// Expired tokens are removed when they can't be refreshed.
+	source.set("third", time.Now().Add(-time.Minute), nil)
+	requireToken("third")
+	source.set("", time.Time{}, context.DeadlineExceeded)
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return os.IsNotExist(err)
+	}, 10*time.Second, 10*time.Millisecond)
+}
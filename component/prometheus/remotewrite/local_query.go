@@ -0,0 +1,339 @@
+package remotewrite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+)
+
+const (
+	// localQueryTruncateFrequency is how often samples older than the
+	// retention are removed from the local query store.
+	localQueryTruncateFrequency = time.Minute
+
+	// localQueryMaxSamples is the maximum number of samples a single local
+	// query may load into memory.
+	localQueryMaxSamples = 1_000_000
+)
+
+// localStore keeps the most recent samples written to the component in
+// memory, so that they can be queried through the component's HTTP handler.
+// It is a secondary of the component's fanout storage and never returns
+// errors to appenders: failing to record a sample locally must not prevent it
+// from being written to the WAL.
+type localStore struct {
+	log log.Logger
+	dir string
+
+	mut       sync.RWMutex
+	head      *tsdb.Head // nil when local queries are disabled.
+	retention time.Duration
+	timeout   time.Duration
+	engine    *promql.Engine
+}
+
+var _ storage.Storage = (*localStore)(nil)
+
+func newLocalStore(l log.Logger, dir string) *localStore {
+	return &localStore{log: l, dir: dir}
+}
+
+// ApplyConfig enables, disables, or reconfigures the store.
+func (s *localStore) ApplyConfig(opts LocalQueryOptions) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	s.retention = opts.Retention
+	s.timeout = opts.Timeout
+
+	switch {
+	case opts.Enabled && s.head == nil:
+		// Samples are only kept in memory, but the head stores full chunks in
+		// its chunk directory. Remove chunks left over from a previous run.
+		if err := os.RemoveAll(s.dir); err != nil {
+			return err
+		}
+
+		headOpts := tsdb.DefaultHeadOptions()
+		headOpts.ChunkDirRoot = s.dir
+		headOpts.EnableNativeHistograms.Store(true)
+		head, err := tsdb.NewHead(nil, s.log, nil, nil, headOpts, nil)
+		if err != nil {
+			return fmt.Errorf("creating local query storage: %w", err)
+		}
+		if err := head.Init(math.MinInt64); err != nil {
+			return fmt.Errorf("initializing local query storage: %w", err)
+		}
+
+		s.head = head
+		s.engine = promql.NewEngine(promql.EngineOpts{
+			Logger:               s.log,
+			MaxSamples:           localQueryMaxSamples,
+			Timeout:              opts.Timeout,
+			EnableAtModifier:     true,
+			EnableNegativeOffset: true,
+		})
+
+	case !opts.Enabled && s.head != nil:
+		err := s.head.Close()
+		s.head, s.engine = nil, nil
+		if err != nil {
+			return err
+		}
+		return os.RemoveAll(s.dir)
+	}
+
+	return nil
+}
+
+// run periodically removes samples older than the retention until ctx is
+// canceled.
+func (s *localStore) run(ctx context.Context) {
+	ticker := time.NewTicker(localQueryTruncateFrequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mut.RLock()
+			if s.head != nil {
+				mint := timestamp.FromTime(time.Now().Add(-s.retention))
+				if err := s.head.Truncate(mint); err != nil {
+					level.Warn(s.log).Log("msg", "failed to truncate local query storage", "err", err)
+				}
+			}
+			s.mut.RUnlock()
+		}
+	}
+}
+
+// Appender implements storage.Appendable.
+func (s *localStore) Appender(ctx context.Context) storage.Appender {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	if s.head == nil {
+		return localAppender{log: s.log}
+	}
+	return localAppender{log: s.log, next: s.head.Appender(ctx)}
+}
+
+// Querier implements storage.Queryable. The local store is not queried as
+// part of the fanout storage; it is only queried through its HTTP handler.
+func (s *localStore) Querier(_ context.Context, _, _ int64) (storage.Querier, error) {
+	return storage.NoopQuerier(), nil
+}
+
+// ChunkQuerier implements storage.ChunkQueryable.
+func (s *localStore) ChunkQuerier(_ context.Context, _, _ int64) (storage.ChunkQuerier, error) {
+	return storage.NoopChunkedQuerier(), nil
+}
+
+// StartTime implements storage.Storage.
+func (s *localStore) StartTime() (int64, error) {
+	return math.MaxInt64, nil
+}
+
+// Close implements storage.Storage.
+func (s *localStore) Close() error {
+	return s.ApplyConfig(LocalQueryOptions{Enabled: false})
+}
+
+// query runs an instant query against the samples held in memory. The
+// result is passed to fn, and must not be used after fn returns.
+func (s *localStore) query(ctx context.Context, qs string, ts time.Time, fn func(parser.Value)) error {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	if s.head == nil {
+		return errLocalQueryDisabled
+	}
+
+	head := s.head
+	queryable := storage.QueryableFunc(func(_ context.Context, mint, maxt int64) (storage.Querier, error) {
+		return tsdb.NewBlockQuerier(tsdb.NewRangeHead(head, mint, maxt), mint, maxt)
+	})
+
+	qry, err := s.engine.NewInstantQuery(queryable, nil, qs, ts)
+	if err != nil {
+		return err
+	}
+	defer qry.Close()
+
+	res := qry.Exec(ctx)
+	if res.Err != nil {
+		return res.Err
+	}
+	fn(res.Value)
+	return nil
+}
+
+var errLocalQueryDisabled = errors.New("local queries are disabled; enable them with the local_query block")
+
+// localAppender appends samples to the local store. Sample references are
+// owned by the primary storage of the fanout, so they are never passed on.
+type localAppender struct {
+	log  log.Logger
+	next storage.Appender // nil when local queries are disabled.
+}
+
+var _ storage.Appender = localAppender{}
+
+func (a localAppender) Append(_ storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	if a.next != nil {
+		if _, err := a.next.Append(0, l, t, v); err != nil {
+			level.Debug(a.log).Log("msg", "failed to append sample to local query storage", "series", l.String(), "err", err)
+		}
+	}
+	return 0, nil
+}
+
+func (a localAppender) AppendHistogram(_ storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	if a.next != nil {
+		if _, err := a.next.AppendHistogram(0, l, t, h, fh); err != nil {
+			level.Debug(a.log).Log("msg", "failed to append histogram to local query storage", "series", l.String(), "err", err)
+		}
+	}
+	return 0, nil
+}
+
+func (a localAppender) AppendExemplar(_ storage.SeriesRef, _ labels.Labels, _ exemplar.Exemplar) (storage.SeriesRef, error) {
+	return 0, nil
+}
+
+func (a localAppender) UpdateMetadata(_ storage.SeriesRef, _ labels.Labels, _ metadata.Metadata) (storage.SeriesRef, error) {
+	return 0, nil
+}
+
+func (a localAppender) Commit() error {
+	if a.next != nil {
+		if err := a.next.Commit(); err != nil {
+			level.Debug(a.log).Log("msg", "failed to commit samples to local query storage", "err", err)
+		}
+	}
+	return nil
+}
+
+func (a localAppender) Rollback() error {
+	if a.next != nil {
+		_ = a.next.Rollback()
+	}
+	return nil
+}
+
+// Handler implements component.HTTPComponent. It serves a subset of the
+// Prometheus HTTP API at /api/v1/query for instant queries over the samples
+// recently written to the component.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/query", c.handleQuery)
+	return mux
+}
+
+type queryResponse struct {
+	Status    string     `json:"status"`
+	Data      *queryData `json:"data,omitempty"`
+	ErrorType string     `json:"errorType,omitempty"`
+	Error     string     `json:"error,omitempty"`
+	Warnings  []string   `json:"warnings,omitempty"`
+}
+
+type queryData struct {
+	ResultType parser.ValueType `json:"resultType"`
+	Result     parser.Value     `json:"result"`
+}
+
+func (c *Component) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	qs := r.FormValue("query")
+	if qs == "" {
+		writeQueryError(w, http.StatusBadRequest, "bad_data", fmt.Errorf("missing query parameter"))
+		return
+	}
+
+	ts := time.Now()
+	if t := r.FormValue("time"); t != "" {
+		var err error
+		if ts, err = parseQueryTime(t); err != nil {
+			writeQueryError(w, http.StatusBadRequest, "bad_data", err)
+			return
+		}
+	}
+
+	ctx := r.Context()
+	if timeout := c.localStore.queryTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	err := c.localStore.query(ctx, qs, ts, func(val parser.Value) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(queryResponse{
+			Status: "success",
+			Data:   &queryData{ResultType: val.Type(), Result: val},
+		})
+	})
+	switch {
+	case errors.Is(err, errLocalQueryDisabled):
+		writeQueryError(w, http.StatusNotFound, "unavailable", err)
+	case err != nil:
+		writeQueryError(w, http.StatusUnprocessableEntity, "execution", err)
+	}
+}
+
+func (s *localStore) queryTimeout() time.Duration {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.timeout
+}
+
+func writeQueryError(w http.ResponseWriter, code int, typ string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(queryResponse{
+		Status:    "error",
+		ErrorType: typ,
+		Error:     err.Error(),
+	})
+}
+
+// parseQueryTime parses a time given either as a Unix timestamp in seconds or
+// in RFC 3339 format, like the Prometheus HTTP API.
+func parseQueryTime(s string) (time.Time, error) {
+	if t, err := strconv.ParseFloat(s, 64); err == nil {
+		sec, frac := math.Modf(t)
+		// Round to milliseconds to avoid floating point errors.
+		frac = math.Round(frac*1000) / 1000
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
@@ -0,0 +1,88 @@
+package remotewrite
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalQuery(t *testing.T) {
+	args := DefaultArguments
+	args.LocalQuery.Enabled = true
+
+	c, err := NewComponent(component.Options{
+		ID:            "prometheus.remote_write.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		DataPath:      t.TempDir(),
+		OnStateChange: func(e component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	now := time.Now()
+	app := c.receiver.Appender(ctx)
+	_, err = app.Append(0, labels.FromStrings("__name__", "up", "job", "a"), now.UnixMilli(), 1)
+	require.NoError(t, err)
+	_, err = app.Append(0, labels.FromStrings("__name__", "up", "job", "b"), now.UnixMilli(), 0)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	resp := localQuery(t, c, url.Values{"query": {`sum(up)`}, "time": {formatTime(now)}})
+	require.Equal(t, http.StatusOK, resp.code)
+	require.JSONEq(t, `{
+		"status": "success",
+		"data": {
+			"resultType": "vector",
+			"result": [{"metric": {}, "value": [`+formatTime(now)+`, "1"]}]
+		}
+	}`, resp.body)
+
+	// Samples older than the lookback delta aren't returned.
+	resp = localQuery(t, c, url.Values{"query": {`up`}, "time": {formatTime(now.Add(10 * time.Minute))}})
+	require.Equal(t, http.StatusOK, resp.code)
+	require.JSONEq(t, `{"status": "success", "data": {"resultType": "vector", "result": []}}`, resp.body)
+
+	resp = localQuery(t, c, url.Values{"query": {`up{`}})
+	require.Equal(t, http.StatusUnprocessableEntity, resp.code)
+
+	// Disabling local queries makes the endpoint unavailable.
+	args.LocalQuery.Enabled = false
+	require.NoError(t, c.Update(args))
+	resp = localQuery(t, c, url.Values{"query": {`up`}})
+	require.Equal(t, http.StatusNotFound, resp.code)
+}
+
+type queryResult struct {
+	code int
+	body string
+}
+
+func localQuery(t *testing.T, c *Component, params url.Values) queryResult {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/query?"+params.Encode(), nil)
+	rec := httptest.NewRecorder()
+	c.Handler().ServeHTTP(rec, req)
+
+	require.True(t, json.Valid(rec.Body.Bytes()), "response is not valid JSON: %s", rec.Body.String())
+	return queryResult{code: rec.Code, body: rec.Body.String()}
+}
+
+func formatTime(t time.Time) string {
+	b, _ := json.Marshal(float64(t.UnixMilli()) / 1000)
+	return string(b)
+}
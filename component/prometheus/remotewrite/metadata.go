@@ -0,0 +1,253 @@
+package remotewrite
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/config"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/prometheus/prometheus/storage/remote"
+	"gopkg.in/yaml.v2"
+)
+
+// metadataStore keeps the most recent metadata of every metric written to
+// the component and periodically sends it to the endpoints.
+//
+// The queues of the Prometheus remote storage only send the metadata of the
+// targets of a Prometheus scrape manager, which Flow doesn't have, and the WAL
+// doesn't record metadata. Metadata is sent in full on every interval like the
+// Prometheus metadata watcher does, so it doesn't need to be persisted.
+type metadataStore struct {
+	log     log.Logger
+	metrics *metadataMetrics
+
+	mut      sync.RWMutex
+	metadata map[string]metadata.Metadata // Metric name -> metadata.
+
+	sendersMut sync.Mutex
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func newMetadataStore(l log.Logger, reg prometheus.Registerer) *metadataStore {
+	return &metadataStore{
+		log:      l,
+		metrics:  newMetadataMetrics(reg),
+		metadata: make(map[string]metadata.Metadata),
+	}
+}
+
+// Update records the metadata of the metric of the series l.
+func (s *metadataStore) Update(l labels.Labels, m metadata.Metadata) {
+	name := l.Get(labels.MetricName)
+	if name == "" {
+		return
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.metadata[name] = m
+}
+
+// ApplyConfig restarts sending metadata to the endpoints of cfg.
+func (s *metadataStore) ApplyConfig(cfg *config.Config) error {
+	senders := make([]*metadataSender, 0, len(cfg.RemoteWriteConfigs))
+	for _, rwConf := range cfg.RemoteWriteConfigs {
+		if !rwConf.MetadataConfig.Send {
+			continue
+		}
+
+		name, err := queueName(rwConf)
+		if err != nil {
+			return err
+		}
+		client, err := remote.NewWriteClient(name, &remote.ClientConfig{
+			URL:              rwConf.URL,
+			Timeout:          rwConf.RemoteTimeout,
+			HTTPClientConfig: rwConf.HTTPClientConfig,
+			SigV4Config:      rwConf.SigV4Config,
+			Headers:          rwConf.Headers,
+			RetryOnRateLimit: rwConf.QueueConfig.RetryOnRateLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		endpoint := rwConf.URL.Redacted()
+		senders = append(senders, &metadataSender{
+			log:      log.With(s.log, "remote_name", name, "url", endpoint),
+			store:    s,
+			client:   client,
+			cfg:      rwConf.MetadataConfig,
+			sent:     s.metrics.sent.WithLabelValues(name, endpoint),
+			failed:   s.metrics.failed.WithLabelValues(name, endpoint),
+			interval: time.Duration(rwConf.MetadataConfig.SendInterval),
+		})
+	}
+
+	s.sendersMut.Lock()
+	defer s.sendersMut.Unlock()
+
+	s.stopSenders()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, sender := range senders {
+		s.wg.Add(1)
+		go func(sender *metadataSender) {
+			defer s.wg.Done()
+			sender.run(ctx)
+		}(sender)
+	}
+	return nil
+}
+
+// Close stops sending metadata.
+func (s *metadataStore) Close() {
+	s.sendersMut.Lock()
+	defer s.sendersMut.Unlock()
+	s.stopSenders()
+}
+
+func (s *metadataStore) stopSenders() {
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	s.wg.Wait()
+}
+
+// snapshot returns the known metadata sorted by metric name.
+func (s *metadataStore) snapshot() []prompb.MetricMetadata {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	res := make([]prompb.MetricMetadata, 0, len(s.metadata))
+	for name, m := range s.metadata {
+		res = append(res, prompb.MetricMetadata{
+			MetricFamilyName: name,
+			Type:             metricTypeToProto(string(m.Type)),
+			Help:             m.Help,
+			Unit:             m.Unit,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].MetricFamilyName < res[j].MetricFamilyName
+	})
+	return res
+}
+
+// metadataSender sends the metadata of a metadataStore to a single endpoint.
+type metadataSender struct {
+	log      log.Logger
+	store    *metadataStore
+	client   remote.WriteClient
+	cfg      config.MetadataConfig
+	sent     prometheus.Counter
+	failed   prometheus.Counter
+	interval time.Duration
+}
+
+func (s *metadataSender) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.send(ctx)
+		}
+	}
+}
+
+// send sends all known metadata in batches of at most MaxSamplesPerSend.
+// Failed batches aren't retried, as they are sent again on the next interval.
+func (s *metadataSender) send(ctx context.Context) {
+	mm := s.store.snapshot()
+	for len(mm) > 0 {
+		n := len(mm)
+		if s.cfg.MaxSamplesPerSend > 0 && n > s.cfg.MaxSamplesPerSend {
+			n = s.cfg.MaxSamplesPerSend
+		}
+		batch := mm[:n]
+		mm = mm[n:]
+
+		if err := s.sendBatch(ctx, batch); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			level.Warn(s.log).Log("msg", "failed to send metadata", "count", len(batch), "err", err)
+			s.failed.Add(float64(len(batch)))
+			continue
+		}
+		s.sent.Add(float64(len(batch)))
+	}
+}
+
+func (s *metadataSender) sendBatch(ctx context.Context, batch []prompb.MetricMetadata) error {
+	buf, err := proto.Marshal(&prompb.WriteRequest{Metadata: batch})
+	if err != nil {
+		return err
+	}
+	return s.client.Store(ctx, snappy.Encode(nil, buf))
+}
+
+type metadataMetrics struct {
+	sent   *prometheus.CounterVec
+	failed *prometheus.CounterVec
+}
+
+func newMetadataMetrics(reg prometheus.Registerer) *metadataMetrics {
+	m := &metadataMetrics{
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prometheus_remote_write_metadata_sent_total",
+			Help: "Total number of metric metadata entries sent to the endpoint.",
+		}, []string{"remote_name", "url"}),
+		failed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "prometheus_remote_write_metadata_failed_total",
+			Help: "Total number of metric metadata entries which failed to be sent to the endpoint.",
+		}, []string{"remote_name", "url"}),
+	}
+
+	if reg != nil {
+		reg.MustRegister(m.sent, m.failed)
+	}
+	return m
+}
+
+// queueName returns the name the Prometheus remote storage uses for the queue
+// of rwConf, so that metrics of the same endpoint have the same remote_name.
+func queueName(rwConf *config.RemoteWriteConfig) (string, error) {
+	if rwConf.Name != "" {
+		return rwConf.Name, nil
+	}
+
+	bb, err := yaml.Marshal(rwConf)
+	if err != nil {
+		return "", err
+	}
+	hash := md5.Sum(bb)
+	return hex.EncodeToString(hash[:])[:6], nil
+}
+
+func metricTypeToProto(t string) prompb.MetricMetadata_MetricType {
+	v, ok := prompb.MetricMetadata_MetricType_value[strings.ToUpper(t)]
+	if !ok {
+		return prompb.MetricMetadata_UNKNOWN
+	}
+	return prompb.MetricMetadata_MetricType(v)
+}
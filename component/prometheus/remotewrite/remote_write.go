@@ -12,6 +12,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/metadata"
 
@@ -20,6 +21,9 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/delivery"
+	"github.com/grafana/agent/component/common/tenant"
+	"github.com/grafana/agent/component/common/usage"
 	"github.com/grafana/agent/pkg/build"
 	"github.com/grafana/agent/pkg/metrics/wal"
 	"github.com/prometheus/prometheus/model/timestamp"
@@ -52,8 +56,16 @@ type Component struct {
 
 	walStore    *wal.Storage
 	remoteStore *remote.Storage
-	storage     storage.Storage
-	exited      atomic.Bool
+	localStore  *localStore
+	metaStore   *metadataStore
+	walSize     *walSizeMonitor
+	tuner       *queueTuner
+	tenants     *tenant.Mapper
+	auth        *cloudAuth
+	// activeSeries accounts for the series appended to storage.
+	activeSeries *usage.ActiveSeries
+	storage      storage.Storage
+	exited       atomic.Bool
 
 	mut sync.RWMutex
 	cfg Arguments
@@ -78,18 +90,54 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 		return nil, err
 	}
 
+	// The tuner observes the metrics of the queues of the remote storage.
+	tuner, err := newQueueTuner(log.With(o.Logger, "subcomponent", "auto_tune"), o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
 	remoteLogger := log.With(o.Logger, "subcomponent", "rw")
-	remoteStore := remote.NewStorage(remoteLogger, o.Registerer, startTime, o.DataPath, remoteFlushDeadline, nil)
+	remoteStore := remote.NewStorage(remoteLogger, tuner.Registerer(o.Registerer), startTime, o.DataPath, remoteFlushDeadline, nil)
+
+	localLogger := log.With(o.Logger, "subcomponent", "local_query")
+	localStore := newLocalStore(localLogger, filepath.Join(o.DataPath, "local_query"))
+
+	metaLogger := log.With(o.Logger, "subcomponent", "metadata")
+	metaStore := newMetadataStore(metaLogger, o.Registerer)
+
+	walSize, err := newWALSizeMonitor(walLogger, o.Registerer, wal.SubDirectory(o.DataPath))
+	if err != nil {
+		return nil, err
+	}
+
+	tenants, err := tenant.NewMapper(log.With(o.Logger, "subcomponent", "tenant_mapping"), o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	activeSeries, err := usage.NewActiveSeries(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := newCloudAuth(log.With(o.Logger, "subcomponent", "auth"), filepath.Join(o.DataPath, "auth"))
 
 	res := &Component{
-		log:         o.Logger,
-		opts:        o,
-		walStore:    walStorage,
-		remoteStore: remoteStore,
-		storage:     storage.NewFanout(o.Logger, walStorage, remoteStore),
+		log:          o.Logger,
+		opts:         o,
+		walStore:     walStorage,
+		remoteStore:  remoteStore,
+		localStore:   localStore,
+		metaStore:    metaStore,
+		walSize:      walSize,
+		tuner:        tuner,
+		tenants:      tenants,
+		auth:         auth,
+		activeSeries: activeSeries,
+		storage:      storage.NewFanout(o.Logger, walStorage, remoteStore, localStore),
 	}
 	res.receiver = prometheus.NewInterceptor(
-		res.storage,
+		delivery.TrackAppendable(usage.TrackAppendable(res.storage, activeSeries), delivery.NewTracker(o.Registerer)),
 
 		// In the methods below, conversion is needed because remote_writes assume
 		// they are responsible for generating ref IDs. This means two
@@ -114,6 +162,8 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 				return 0, fmt.Errorf("%s has exited", o.ID)
 			}
 
+			res.metaStore.Update(l, m)
+
 			localID := prometheus.GlobalRefMapping.GetLocalRefID(res.opts.ID, uint64(globalRef))
 			newRef, nextErr := next.UpdateMetadata(storage.SeriesRef(localID), l, m)
 			if localID == 0 {
@@ -133,6 +183,18 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 			}
 			return globalRef, nextErr
 		}),
+		prometheus.WithAppendHistogram(func(globalRef storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram, next storage.Appender) (storage.SeriesRef, error) {
+			if res.exited.Load() {
+				return 0, fmt.Errorf("%s has exited", o.ID)
+			}
+
+			localID := prometheus.GlobalRefMapping.GetLocalRefID(res.opts.ID, uint64(globalRef))
+			newRef, nextErr := next.AppendHistogram(storage.SeriesRef(localID), l, t, h, fh)
+			if localID == 0 {
+				prometheus.GlobalRefMapping.GetOrAddLink(res.opts.ID, uint64(newRef), l)
+			}
+			return globalRef, nextErr
+		}),
 	)
 
 	// Immediately export the receiver which remains the same for the component
@@ -147,12 +209,17 @@ func NewComponent(o component.Options, c Arguments) (*Component, error) {
 
 func startTime() (int64, error) { return 0, nil }
 
-var _ component.Component = (*Component)(nil)
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HTTPComponent   = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+)
 
 // Run implements Component.
 func (c *Component) Run(ctx context.Context) error {
 	defer func() {
 		c.exited.Store(true)
+		c.metaStore.Close()
 
 		level.Debug(c.log).Log("msg", "closing storage")
 		err := c.storage.Close()
@@ -160,8 +227,17 @@ func (c *Component) Run(ctx context.Context) error {
 		if err != nil {
 			level.Error(c.log).Log("msg", "error when closing storage", "err", err)
 		}
+		c.auth.Close()
 	}()
 
+	go c.localStore.run(ctx)
+
+	c.walSize.measure()
+	go c.walSize.run(ctx)
+	go c.tuner.run(ctx, c.retune)
+	go c.tenants.Run(ctx, c.remapTenants)
+	go c.activeSeries.Run(ctx)
+
 	// Track the last timestamp we truncated for to prevent segments from getting
 	// deleted until at least some new data has been sent.
 	var lastTs = int64(math.MinInt64)
@@ -224,6 +300,11 @@ func (c *Component) truncateFrequency() time.Duration {
 	return c.cfg.WALOptions.TruncateFrequency
 }
 
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	return c.walSize.CurrentHealth()
+}
+
 // Update implements Component.
 func (c *Component) Update(newConfig component.Arguments) error {
 	cfg := newConfig.(Arguments)
@@ -231,15 +312,67 @@ func (c *Component) Update(newConfig component.Arguments) error {
 	c.mut.Lock()
 	defer c.mut.Unlock()
 
-	convertedConfig, err := convertConfigs(cfg)
-	if err != nil {
+	if err := c.tenants.ApplyConfig(cfg.TenantMapping); err != nil {
 		return err
 	}
-	err = c.remoteStore.ApplyConfig(convertedConfig)
+	if err := c.auth.ApplyConfig(cfg.Endpoints); err != nil {
+		return err
+	}
+	queues, err := c.applyRemoteConfig(cfg)
 	if err != nil {
 		return err
 	}
+	c.activeSeries.ApplyConfig(cfg.Usage.activeSeriesWindow(), usageRoutes(cfg, queues))
+	if err := c.localStore.ApplyConfig(cfg.LocalQuery); err != nil {
+		return err
+	}
+	c.walSize.ApplyConfig(cfg.WALOptions.SizeThreshold)
 
 	c.cfg = cfg
 	return nil
 }
+
+// applyRemoteConfig applies the endpoints of cfg, with the queue settings
+// chosen by the tuner, to the remote storage, and returns the applied queues.
+// mut must be held when calling applyRemoteConfig.
+func (c *Component) applyRemoteConfig(cfg Arguments) ([]*remoteQueue, error) {
+	queues, err := convertQueues(cfg, c.tenants.Mapping(), c.auth.dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.tuner.Apply(queues); err != nil {
+		return nil, err
+	}
+
+	convertedConfig := newConfig(cfg, queues)
+	if err := c.remoteStore.ApplyConfig(convertedConfig); err != nil {
+		return nil, err
+	}
+	return queues, c.metaStore.ApplyConfig(convertedConfig)
+}
+
+// retune applies the queue settings changed by the tuner, restarting the
+// queues whose settings changed.
+func (c *Component) retune() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if _, err := c.applyRemoteConfig(c.cfg); err != nil {
+		level.Error(c.log).Log("msg", "failed to apply tuned queue settings", "err", err)
+	}
+}
+
+// remapTenants applies the queues of the tenants of the reloaded tenant
+// mapping.
+func (c *Component) remapTenants() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	queues, err := c.applyRemoteConfig(c.cfg)
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to apply reloaded tenant mapping", "err", err)
+		return
+	}
+	// The tenants of the queues changed with the mapping.
+	c.activeSeries.ApplyConfig(c.cfg.Usage.activeSeriesWindow(), usageRoutes(c.cfg, queues))
+}
@@ -12,7 +12,11 @@ import (
 	"github.com/grafana/agent/pkg/flow/componenttest"
 	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/prometheus/prometheus/storage/remote"
 	"github.com/stretchr/testify/require"
@@ -89,6 +93,313 @@ func Test(t *testing.T) {
 	}
 }
 
+// TestNativeHistograms ensures that native histograms sent to a
+// prometheus.remote_write component are forwarded to the remote_write server.
+func TestNativeHistograms(t *testing.T) {
+	writeResult := make(chan *prompb.WriteRequest)
+
+	srv := newTestServer(t, writeResult)
+	defer srv.Close()
+
+	args := testArgsForConfig(t, fmt.Sprintf(`
+		endpoint {
+			name                   = "test-url"
+			url                    = "%s/api/v1/write"
+			remote_timeout         = "100ms"
+			send_native_histograms = true
+
+			queue_config {
+				batch_send_deadline = "100ms"
+			}
+		}
+	`, srv.URL))
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(5*time.Second))
+
+	sampleTimestamp := time.Now().Add(time.Minute).UnixMilli()
+	h := &histogram.Histogram{
+		Count:         5,
+		ZeroCount:     1,
+		ZeroThreshold: 0.001,
+		Sum:           18.4,
+		Schema:        1,
+		PositiveSpans: []histogram.Span{{Offset: 0, Length: 2}},
+		// Buckets are delta-encoded.
+		PositiveBuckets: []int64{1, 2},
+	}
+
+	rwExports := tc.Exports().(remotewrite.Exports)
+	appender := rwExports.Receiver.Appender(context.Background())
+	_, err = appender.AppendHistogram(0, labels.FromStrings("__name__", "request_duration_seconds"), sampleTimestamp, h, nil)
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	expect := remote.HistogramToHistogramProto(sampleTimestamp, h)
+	// Empty spans aren't distinguished from missing spans on the wire.
+	expect.NegativeSpans = nil
+
+	assertReceived(t, writeResult, []prompb.TimeSeries{{
+		Labels: []prompb.Label{
+			{Name: "__name__", Value: "request_duration_seconds"},
+		},
+		Histograms: []prompb.Histogram{expect},
+	}})
+}
+
+// TestExemplars ensures that exemplars sent to a prometheus.remote_write
+// component are forwarded to the remote_write server.
+func TestExemplars(t *testing.T) {
+	writeResult := make(chan *prompb.WriteRequest)
+
+	srv := newTestServer(t, writeResult)
+	defer srv.Close()
+
+	args := testArgsForConfig(t, fmt.Sprintf(`
+		endpoint {
+			name           = "test-url"
+			url            = "%s/api/v1/write"
+			remote_timeout = "100ms"
+
+			queue_config {
+				batch_send_deadline = "100ms"
+			}
+		}
+	`, srv.URL))
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(5*time.Second))
+
+	sampleTimestamp := time.Now().Add(time.Minute).UnixMilli()
+	series := labels.FromStrings("__name__", "requests_total")
+	ex := exemplar.Exemplar{Labels: labels.FromStrings("trace_id", "abc"), Value: 1, Ts: sampleTimestamp, HasTs: true}
+
+	rwExports := tc.Exports().(remotewrite.Exports)
+	appender := rwExports.Receiver.Appender(context.Background())
+	_, err = appender.Append(0, series, sampleTimestamp, 1)
+	require.NoError(t, err)
+	_, err = appender.AppendExemplar(0, series, ex)
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	// Samples and exemplars may be sent in separate requests.
+	var actual prompb.TimeSeries
+	for len(actual.Samples) == 0 || len(actual.Exemplars) == 0 {
+		select {
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "timed out waiting for metrics")
+		case res := <-writeResult:
+			for _, ts := range res.Timeseries {
+				actual.Labels = ts.Labels
+				actual.Samples = append(actual.Samples, ts.Samples...)
+				actual.Exemplars = append(actual.Exemplars, ts.Exemplars...)
+			}
+		}
+	}
+	require.Equal(t, prompb.TimeSeries{
+		Labels:    []prompb.Label{{Name: "__name__", Value: "requests_total"}},
+		Samples:   []prompb.Sample{{Timestamp: sampleTimestamp, Value: 1}},
+		Exemplars: []prompb.Exemplar{{Labels: []prompb.Label{{Name: "trace_id", Value: "abc"}}, Value: 1, Timestamp: sampleTimestamp}},
+	}, actual)
+}
+
+// TestMetadata ensures that metric metadata sent to a prometheus.remote_write
+// component is periodically forwarded to the remote_write server.
+func TestMetadata(t *testing.T) {
+	writeResult := make(chan *prompb.WriteRequest, 10)
+
+	srv := newTestServer(t, writeResult)
+	defer srv.Close()
+
+	args := testArgsForConfig(t, fmt.Sprintf(`
+		endpoint {
+			name           = "test-url"
+			url            = "%s/api/v1/write"
+			remote_timeout = "100ms"
+
+			metadata_config {
+				send_interval        = "100ms"
+				max_samples_per_send = 1
+			}
+		}
+	`, srv.URL))
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(5*time.Second))
+
+	rwExports := tc.Exports().(remotewrite.Exports)
+	appender := rwExports.Receiver.Appender(context.Background())
+	_, err = appender.UpdateMetadata(0, labels.FromStrings("__name__", "requests_total"), metadata.Metadata{
+		Type: textparse.MetricTypeCounter,
+		Help: "Total number of requests.",
+	})
+	require.NoError(t, err)
+	_, err = appender.UpdateMetadata(0, labels.FromStrings("__name__", "queue_length"), metadata.Metadata{
+		Type: textparse.MetricTypeGauge,
+		Help: "Length of the queue.",
+		Unit: "items",
+	})
+	require.NoError(t, err)
+	require.NoError(t, appender.Commit())
+
+	// Metadata is sent in batches of max_samples_per_send.
+	var actual []prompb.MetricMetadata
+	for len(actual) < 2 {
+		select {
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "timed out waiting for metadata")
+		case res := <-writeResult:
+			require.LessOrEqual(t, len(res.Metadata), 1)
+			actual = append(actual, res.Metadata...)
+		}
+	}
+	require.Equal(t, []prompb.MetricMetadata{
+		{MetricFamilyName: "queue_length", Type: prompb.MetricMetadata_GAUGE, Help: "Length of the queue.", Unit: "items"},
+		{MetricFamilyName: "requests_total", Type: prompb.MetricMetadata_COUNTER, Help: "Total number of requests."},
+	}, actual[:2])
+}
+
+// TestTenants ensures that series are sent with the X-Scope-OrgID header of
+// the first tenant they match.
+func TestTenants(t *testing.T) {
+	type tenantWrite struct {
+		tenant string
+		series []prompb.TimeSeries
+	}
+	writeResult := make(chan tenantWrite, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := remote.DecodeWriteRequest(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeResult <- tenantWrite{tenant: r.Header.Get("X-Scope-OrgID"), series: req.Timeseries}
+	}))
+	defer srv.Close()
+
+	args := testArgsForConfig(t, fmt.Sprintf(`
+		endpoint {
+			name           = "test-url"
+			url            = "%s/api/v1/write"
+			remote_timeout = "100ms"
+			headers        = {
+				"X-Scope-OrgID" = "shared",
+			}
+
+			queue_config {
+				batch_send_deadline = "100ms"
+			}
+
+			tenant {
+				id       = "team-a"
+				selector = "{namespace=\"a\"}"
+			}
+			tenant {
+				id       = "team-b"
+				selector = "{namespace=~\"a|b\"}"
+			}
+		}
+	`, srv.URL))
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(5*time.Second))
+
+	sampleTimestamp := time.Now().Add(time.Minute).UnixMilli()
+	sendMetric(t, tc, labels.FromStrings("namespace", "a"), sampleTimestamp, 1)
+	sendMetric(t, tc, labels.FromStrings("namespace", "b"), sampleTimestamp, 2)
+	sendMetric(t, tc, labels.FromStrings("namespace", "c"), sampleTimestamp, 3)
+
+	expect := map[string][]prompb.TimeSeries{
+		"team-a": {{
+			Labels:  []prompb.Label{{Name: "namespace", Value: "a"}},
+			Samples: []prompb.Sample{{Timestamp: sampleTimestamp, Value: 1}},
+		}},
+		"team-b": {{
+			Labels:  []prompb.Label{{Name: "namespace", Value: "b"}},
+			Samples: []prompb.Sample{{Timestamp: sampleTimestamp, Value: 2}},
+		}},
+		"shared": {{
+			Labels:  []prompb.Label{{Name: "namespace", Value: "c"}},
+			Samples: []prompb.Sample{{Timestamp: sampleTimestamp, Value: 3}},
+		}},
+	}
+
+	actual := make(map[string][]prompb.TimeSeries)
+	for len(actual) < len(expect) {
+		select {
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "timed out waiting for metrics")
+		case res := <-writeResult:
+			actual[res.tenant] = append(actual[res.tenant], res.series...)
+		}
+	}
+	require.Equal(t, expect, actual)
+}
+
+// TestSigV4 ensures that requests are signed with AWS Signature Version 4
+// when the sigv4 block is set.
+func TestSigV4(t *testing.T) {
+	authorization := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case authorization <- r.Header.Get("Authorization"):
+		default:
+		}
+	}))
+	defer srv.Close()
+
+	args := testArgsForConfig(t, fmt.Sprintf(`
+		endpoint {
+			url            = "%s/api/v1/write"
+			remote_timeout = "100ms"
+
+			queue_config {
+				batch_send_deadline = "100ms"
+			}
+
+			sigv4 {
+				region     = "us-east-1"
+				access_key = "access-key"
+				secret_key = "secret-key"
+			}
+		}
+	`, srv.URL))
+	tc, err := componenttest.NewControllerFromID(util.TestLogger(t), "prometheus.remote_write")
+	require.NoError(t, err)
+	go func() {
+		err = tc.Run(componenttest.TestContext(t), args)
+		require.NoError(t, err)
+	}()
+	require.NoError(t, tc.WaitRunning(5*time.Second))
+
+	sampleTimestamp := time.Now().Add(time.Minute).UnixMilli()
+	sendMetric(t, tc, labels.FromStrings("foo", "bar"), sampleTimestamp, 12)
+
+	select {
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for metrics")
+	case auth := <-authorization:
+		require.Regexp(t, `^AWS4-HMAC-SHA256 Credential=access-key/\d{8}/us-east-1/aps/aws4_request`, auth)
+	}
+}
+
 func TestUpdate(t *testing.T) {
 	writeResult := make(chan *prompb.WriteRequest)
 
@@ -0,0 +1,177 @@
+package remotewrite
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/component/common/tenant"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// tenantHeader is the header used by Mimir, Cortex and Loki to identify the
+// tenant a request belongs to.
+const tenantHeader = "X-Scope-OrgID"
+
+// Labels used while routing a series to a tenant. They are removed before
+// the series is sent.
+const (
+	tenantMatchLabelPrefix = "__tenant_match_"
+	tenantMatchTmpLabel    = tenantMatchLabelPrefix + "tmp"
+)
+
+// TenantOptions routes the series matching Selector to a dedicated queue of
+// the endpoint which sends them with the X-Scope-OrgID header set to ID.
+type TenantOptions struct {
+	ID       string `river:"id,attr"`
+	Selector string `river:"selector,attr"`
+}
+
+// Validate implements river.Validator.
+func (t *TenantOptions) Validate() error {
+	if t.ID == "" {
+		return fmt.Errorf("tenant id must not be empty")
+	}
+	if _, err := parser.ParseMetricSelector(t.Selector); err != nil {
+		return fmt.Errorf("invalid selector for tenant %q: %w", t.ID, err)
+	}
+	return nil
+}
+
+func validateTenants(tenants []TenantOptions) error {
+	seen := make(map[string]struct{}, len(tenants))
+	for _, t := range tenants {
+		if _, ok := seen[t.ID]; ok {
+			return fmt.Errorf("tenant %q is defined multiple times", t.ID)
+		}
+		seen[t.ID] = struct{}{}
+	}
+	return nil
+}
+
+// mappedTenants returns the tenants of the label values of mapping, sorted by
+// ID.
+func mappedTenants(mapping *tenant.Mapping) []TenantOptions {
+	values := mapping.Values()
+
+	res := make([]TenantOptions, 0, len(values))
+	for id, vv := range values {
+		quoted := make([]string, 0, len(vv))
+		for _, v := range vv {
+			quoted = append(quoted, regexp.QuoteMeta(v))
+		}
+		m := labels.MustNewMatcher(labels.MatchRegexp, mapping.Label(), strings.Join(quoted, "|"))
+		res = append(res, TenantOptions{ID: id, Selector: "{" + m.String() + "}"})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
+
+// tenantHeaders returns a copy of headers with the tenant header set to id.
+func tenantHeaders(headers map[string]string, id string) map[string]string {
+	res := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(tenantHeader) {
+			continue
+		}
+		res[k] = v
+	}
+	res[tenantHeader] = id
+	return res
+}
+
+// tenantRelabelConfigs returns the write relabel rules of the queue for the
+// tenant at index. Series are routed to the first tenant they match, and
+// index len(tenants) selects the series which didn't match any tenant.
+//
+// Relabel rules can't express a conjunction of matchers directly, so for
+// every tenant up to index a marker label is set to "1" and deleted again for
+// each matcher the series doesn't satisfy. The queue then keeps the series
+// whose markers are all unset except for the marker of its own tenant.
+func tenantRelabelConfigs(tenants []TenantOptions, index int) ([]*relabel.Config, error) {
+	var (
+		res     []*relabel.Config
+		markers = make(model.LabelNames, 0, index+1)
+	)
+
+	for i := 0; i <= index && i < len(tenants); i++ {
+		matchers, err := parser.ParseMetricSelector(tenants[i].Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector for tenant %q: %w", tenants[i].ID, err)
+		}
+
+		marker := model.LabelName(fmt.Sprintf("%s%d", tenantMatchLabelPrefix, i))
+		markers = append(markers, marker)
+		res = append(res, newRelabelConfig(nil, "(.*)", relabel.Replace, string(marker), "1"))
+
+		for _, m := range matchers {
+			rules, err := matcherRelabelConfigs(m, marker)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector for tenant %q: %w", tenants[i].ID, err)
+			}
+			res = append(res, rules...)
+		}
+	}
+
+	// Concatenating the markers gives a string of separators followed by "1"
+	// if the series matched the tenant at index only.
+	want := strings.Repeat(relabel.DefaultRelabelConfig.Separator, len(markers)-1)
+	if index < len(tenants) {
+		want += "1"
+	}
+	res = append(res,
+		newRelabelConfig(markers, regexp.QuoteMeta(want), relabel.Keep, "", relabel.DefaultRelabelConfig.Replacement),
+		labelDropConfig(regexp.QuoteMeta(tenantMatchLabelPrefix)+".*"),
+	)
+	return res, nil
+}
+
+// matcherRelabelConfigs returns rules which delete marker if the series
+// doesn't satisfy m.
+func matcherRelabelConfigs(m *labels.Matcher, marker model.LabelName) ([]*relabel.Config, error) {
+	re := m.Value
+	if m.Type == labels.MatchEqual || m.Type == labels.MatchNotEqual {
+		re = regexp.QuoteMeta(m.Value)
+	}
+	if _, err := relabel.NewRegexp(re); err != nil {
+		return nil, err
+	}
+
+	name := model.LabelName(m.Name)
+	switch m.Type {
+	case labels.MatchNotEqual, labels.MatchNotRegexp:
+		return []*relabel.Config{
+			newRelabelConfig(model.LabelNames{name}, re, relabel.Replace, string(marker), ""),
+		}, nil
+	default:
+		// The temporary label is only set if the marker is still set and the
+		// label matches, and then replaces the marker.
+		return []*relabel.Config{
+			newRelabelConfig(model.LabelNames{marker, name}, "1;(?:"+re+")", relabel.Replace, tenantMatchTmpLabel, "1"),
+			newRelabelConfig(model.LabelNames{tenantMatchTmpLabel}, "(.*)", relabel.Replace, string(marker), "$1"),
+			labelDropConfig(regexp.QuoteMeta(tenantMatchTmpLabel)),
+		}, nil
+	}
+}
+
+func newRelabelConfig(source model.LabelNames, re string, action relabel.Action, target, replacement string) *relabel.Config {
+	cfg := relabel.DefaultRelabelConfig
+	cfg.SourceLabels = source
+	cfg.Action = action
+	cfg.TargetLabel = target
+	cfg.Replacement = replacement
+	cfg.Regex = relabel.MustNewRegexp(re)
+	return &cfg
+}
+
+func labelDropConfig(re string) *relabel.Config {
+	cfg := relabel.DefaultRelabelConfig
+	cfg.Action = relabel.LabelDrop
+	cfg.Regex = relabel.MustNewRegexp(re)
+	return &cfg
+}
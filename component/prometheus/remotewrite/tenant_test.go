@@ -0,0 +1,144 @@
+package remotewrite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component/common/tenant"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRelabelConfigs(t *testing.T) {
+	tenants := []TenantOptions{
+		{ID: "a", Selector: `{namespace="team-a"}`},
+		{ID: "b", Selector: `{namespace=~"team-.*", env!="dev"}`},
+		{ID: "c", Selector: `up{env=""}`},
+	}
+
+	tt := []struct {
+		series labels.Labels
+		expect string // Empty if the series doesn't match any tenant.
+	}{
+		{labels.FromStrings("namespace", "team-a"), "a"},
+		{labels.FromStrings("namespace", "team-a", "env", "dev"), "a"},
+		{labels.FromStrings("namespace", "team-b", "env", "prod"), "b"},
+		{labels.FromStrings("namespace", "team-b"), "b"},
+		{labels.FromStrings("namespace", "team-b", "env", "dev"), ""},
+		{labels.FromStrings("namespace", "other", "env", "prod"), ""},
+		{labels.FromStrings("__name__", "up", "namespace", "team-b", "env", "dev"), ""},
+		{labels.FromStrings("__name__", "up", "namespace", "other"), "c"},
+		{labels.FromStrings("namespace", "team-a.b"), "b"},
+		{labels.EmptyLabels(), ""},
+	}
+
+	for _, tc := range tt {
+		var matched []string
+		for i := 0; i <= len(tenants); i++ {
+			cfgs, err := tenantRelabelConfigs(tenants, i)
+			require.NoError(t, err)
+
+			res, keep := relabel.Process(tc.series.Copy(), cfgs...)
+			if !keep {
+				continue
+			}
+			require.Equal(t, tc.series, res, "routing labels must be removed")
+
+			if i < len(tenants) {
+				matched = append(matched, tenants[i].ID)
+			} else {
+				matched = append(matched, "")
+			}
+		}
+		require.Equal(t, []string{tc.expect}, matched, "series %s", tc.series)
+	}
+}
+
+func TestTenantHeaders(t *testing.T) {
+	headers := map[string]string{"x-scope-orgid": "old", "X-Custom": "value"}
+	require.Equal(t, map[string]string{
+		"X-Scope-OrgID": "a",
+		"X-Custom":      "value",
+	}, tenantHeaders(headers, "a"))
+	require.Len(t, headers, 2, "headers must not be modified")
+}
+
+func TestConvertQueues_TenantMapping(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "tenants.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("platform: tenant-a\nstorage: tenant-a\nweb: tenant-b\n"), 0644))
+
+	loadMapping := func(unmatched, defaultTenant string) *tenant.Mapping {
+		m, err := tenant.NewMapper(log.NewNopLogger(), prometheus.NewRegistry())
+		require.NoError(t, err)
+		require.NoError(t, m.ApplyConfig(&tenant.Arguments{
+			SourceLabel:    "team",
+			File:           file,
+			Unmatched:      unmatched,
+			DefaultTenant:  defaultTenant,
+			ReloadInterval: time.Minute,
+		}))
+		return m.Mapping()
+	}
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		endpoint {
+			name = "mimir"
+			url  = "http://localhost:9009/api/v1/push"
+		}
+	`), &args))
+
+	tenantsOf := func(queues []*remoteQueue) map[string]string {
+		res := make(map[string]string)
+		for _, q := range queues {
+			res[q.key] = q.config.Headers[tenantHeader]
+		}
+		return res
+	}
+
+	// Unmatched series aren't sent when they're rejected.
+	queues, err := convertQueues(args, loadMapping(tenant.UnmatchedReject, ""), "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"mimir/tenant-a": "tenant-a",
+		"mimir/tenant-b": "tenant-b",
+	}, tenantsOf(queues))
+
+	cfgs := queues[0].config.WriteRelabelConfigs
+	_, keep := relabel.Process(labels.FromStrings("team", "storage"), cfgs...)
+	require.True(t, keep)
+	_, keep = relabel.Process(labels.FromStrings("team", "web"), cfgs...)
+	require.False(t, keep)
+
+	queues, err = convertQueues(args, loadMapping(tenant.UnmatchedDefault, "shared"), "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"mimir":          "shared",
+		"mimir/tenant-a": "tenant-a",
+		"mimir/tenant-b": "tenant-b",
+	}, tenantsOf(queues))
+}
+
+func TestArguments_TenantMappingWithTenants(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		endpoint {
+			url = "http://localhost:9009/api/v1/push"
+			tenant {
+				id       = "a"
+				selector = "{team=\"a\"}"
+			}
+		}
+		tenant_mapping {
+			source_label = "team"
+			file         = "tenants.yaml"
+		}
+	`), &args)
+	require.ErrorContains(t, err, "can't be used together with tenant_mapping")
+}
@@ -11,15 +11,20 @@ import (
 
 	"github.com/prometheus/prometheus/config"
 
+	"github.com/alecthomas/units"
 	types "github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/common/tenant"
+	"github.com/grafana/agent/pkg/river/rivertypes"
 	common "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/sigv4"
 )
 
 // Defaults for config blocks.
 var (
 	DefaultArguments = Arguments{
 		WALOptions: DefaultWALOptions,
+		LocalQuery: DefaultLocalQueryOptions,
 	}
 
 	DefaultQueueOptions = QueueOptions{
@@ -33,6 +38,10 @@ var (
 		RetryOnHTTP429:    false,
 	}
 
+	DefaultAutoTuneOptions = AutoTuneOptions{
+		Interval: 5 * time.Minute,
+	}
+
 	DefaultMetadataOptions = MetadataOptions{
 		Send:              true,
 		SendInterval:      1 * time.Minute,
@@ -44,6 +53,12 @@ var (
 		MinKeepaliveTime:  5 * time.Minute,
 		MaxKeepaliveTime:  8 * time.Hour,
 	}
+
+	DefaultLocalQueryOptions = LocalQueryOptions{
+		Enabled:   false,
+		Retention: 5 * time.Minute,
+		Timeout:   30 * time.Second,
+	}
 )
 
 // Arguments represents the input state of the prometheus.remote_write
@@ -52,6 +67,9 @@ type Arguments struct {
 	ExternalLabels map[string]string  `river:"external_labels,attr,optional"`
 	Endpoints      []*EndpointOptions `river:"endpoint,block,optional"`
 	WALOptions     WALOptions         `river:"wal,block,optional"`
+	LocalQuery     LocalQueryOptions  `river:"local_query,block,optional"`
+	TenantMapping  *tenant.Arguments  `river:"tenant_mapping,block,optional"`
+	Usage          *UsageOptions      `river:"usage,block,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
@@ -59,6 +77,19 @@ func (rc *Arguments) SetToDefault() {
 	*rc = DefaultArguments
 }
 
+// Validate implements river.Validator.
+func (rc *Arguments) Validate() error {
+	if rc.TenantMapping == nil {
+		return nil
+	}
+	for _, e := range rc.Endpoints {
+		if len(e.Tenants) > 0 {
+			return fmt.Errorf("tenant blocks of endpoints can't be used together with tenant_mapping")
+		}
+	}
+	return nil
+}
+
 // EndpointOptions describes an individual location for where metrics in the WAL
 // should be delivered to using the remote_write protocol.
 type EndpointOptions struct {
@@ -71,6 +102,10 @@ type EndpointOptions struct {
 	HTTPClientConfig     *types.HTTPClientConfig `river:",squash"`
 	QueueOptions         *QueueOptions           `river:"queue_config,block,optional"`
 	MetadataOptions      *MetadataOptions        `river:"metadata_config,block,optional"`
+	Tenants              []TenantOptions         `river:"tenant,block,optional"`
+	SigV4                *SigV4Options           `river:"sigv4,block,optional"`
+	AzureAD              *AzureADOptions         `river:"azuread,block,optional"`
+	GoogleIAM            *GoogleIAMOptions       `river:"google_iam,block,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
@@ -84,14 +119,65 @@ func (r *EndpointOptions) SetToDefault() {
 
 // Validate implements river.Validator.
 func (r *EndpointOptions) Validate() error {
+	if err := validateTenants(r.Tenants); err != nil {
+		return err
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	if r.HTTPClientConfig != nil {
-		return r.HTTPClientConfig.Validate()
+		if err := r.HTTPClientConfig.Validate(); err != nil {
+			return err
+		}
+
+		// Validate converts bearer tokens into an authorization, so checking
+		// the authorization also covers them.
+		authEnabled := r.HTTPClientConfig.BasicAuth != nil || r.HTTPClientConfig.Authorization != nil || r.HTTPClientConfig.OAuth2 != nil
+		if authEnabled && r.SigV4 != nil {
+			return fmt.Errorf("at most one of basic_auth, authorization, oauth2, bearer_token, bearer_token_file & sigv4 must be configured")
+		}
+		if (authEnabled || r.SigV4 != nil) && (r.AzureAD != nil || r.GoogleIAM != nil) {
+			return fmt.Errorf("azuread and google_iam can't be used together with other authentication settings")
+		}
+	}
+	if r.AzureAD != nil && r.GoogleIAM != nil {
+		return fmt.Errorf("at most one of azuread & google_iam must be configured")
 	}
 
 	return nil
 }
 
+// SigV4Options configures signing remote_write requests with AWS Signature
+// Version 4, as required by Amazon Managed Service for Prometheus.
+type SigV4Options struct {
+	Region    string            `river:"region,attr,optional"`
+	AccessKey string            `river:"access_key,attr,optional"`
+	SecretKey rivertypes.Secret `river:"secret_key,attr,optional"`
+	Profile   string            `river:"profile,attr,optional"`
+	RoleARN   string            `river:"role_arn,attr,optional"`
+}
+
+// Validate implements river.Validator.
+func (o *SigV4Options) Validate() error {
+	if (o.AccessKey == "") != (o.SecretKey == "") {
+		return fmt.Errorf("access_key and secret_key must both be set if either is set")
+	}
+	return nil
+}
+
+func (o *SigV4Options) toPrometheusType() *sigv4.SigV4Config {
+	if o == nil {
+		return nil
+	}
+
+	return &sigv4.SigV4Config{
+		Region:    o.Region,
+		AccessKey: o.AccessKey,
+		SecretKey: common.Secret(o.SecretKey),
+		Profile:   o.Profile,
+		RoleARN:   o.RoleARN,
+	}
+}
+
 // QueueOptions handles the low level queue config options for a remote_write
 type QueueOptions struct {
 	Capacity          int           `river:"capacity,attr,optional"`
@@ -102,6 +188,8 @@ type QueueOptions struct {
 	MinBackoff        time.Duration `river:"min_backoff,attr,optional"`
 	MaxBackoff        time.Duration `river:"max_backoff,attr,optional"`
 	RetryOnHTTP429    bool          `river:"retry_on_http_429,attr,optional"`
+
+	AutoTune *AutoTuneOptions `river:"auto_tune,block,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
@@ -109,6 +197,26 @@ func (r *QueueOptions) SetToDefault() {
 	*r = DefaultQueueOptions
 }
 
+// Validate implements river.Validator.
+func (r *QueueOptions) Validate() error {
+	if r.AutoTune == nil {
+		return nil
+	}
+
+	// The settings of the queue are the initial settings of the tuner.
+	switch {
+	case r.MinShards <= 0:
+		return fmt.Errorf("min_shards must be greater than 0 when auto_tune is set")
+	case r.MaxShards < r.MinShards:
+		return fmt.Errorf("max_shards must not be smaller than min_shards when auto_tune is set")
+	case r.MaxSamplesPerSend <= 0:
+		return fmt.Errorf("max_samples_per_send must be greater than 0 when auto_tune is set")
+	case r.Capacity < r.MaxSamplesPerSend:
+		return fmt.Errorf("capacity must not be smaller than max_samples_per_send when auto_tune is set")
+	}
+	return nil
+}
+
 func (r *QueueOptions) toPrometheusType() config.QueueConfig {
 	if r == nil {
 		return config.DefaultQueueConfig
@@ -126,6 +234,29 @@ func (r *QueueOptions) toPrometheusType() config.QueueConfig {
 	}
 }
 
+// AutoTuneOptions configures the adaptive tuning of the shards and batch
+// sizes of a queue.
+type AutoTuneOptions struct {
+	MaxMemory units.Base2Bytes `river:"max_memory,attr"`
+	Interval  time.Duration    `river:"interval,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (o *AutoTuneOptions) SetToDefault() {
+	*o = DefaultAutoTuneOptions
+}
+
+// Validate implements river.Validator.
+func (o *AutoTuneOptions) Validate() error {
+	switch {
+	case o.MaxMemory <= 0:
+		return fmt.Errorf("max_memory must be greater than 0")
+	case o.Interval <= 0:
+		return fmt.Errorf("interval must be greater than 0")
+	}
+	return nil
+}
+
 // MetadataOptions configures how metadata gets sent over the remote_write
 // protocol.
 type MetadataOptions struct {
@@ -139,6 +270,14 @@ func (o *MetadataOptions) SetToDefault() {
 	*o = DefaultMetadataOptions
 }
 
+// Validate implements river.Validator.
+func (o *MetadataOptions) Validate() error {
+	if o.Send && o.SendInterval <= 0 {
+		return fmt.Errorf("send_interval must be greater than 0")
+	}
+	return nil
+}
+
 func (o *MetadataOptions) toPrometheusType() config.MetadataConfig {
 	if o == nil {
 		return config.DefaultMetadataConfig
@@ -153,9 +292,10 @@ func (o *MetadataOptions) toPrometheusType() config.MetadataConfig {
 
 // WALOptions configures behavior within the WAL.
 type WALOptions struct {
-	TruncateFrequency time.Duration `river:"truncate_frequency,attr,optional"`
-	MinKeepaliveTime  time.Duration `river:"min_keepalive_time,attr,optional"`
-	MaxKeepaliveTime  time.Duration `river:"max_keepalive_time,attr,optional"`
+	TruncateFrequency time.Duration    `river:"truncate_frequency,attr,optional"`
+	MinKeepaliveTime  time.Duration    `river:"min_keepalive_time,attr,optional"`
+	MaxKeepaliveTime  time.Duration    `river:"max_keepalive_time,attr,optional"`
+	SizeThreshold     units.Base2Bytes `river:"size_threshold,attr,optional"`
 }
 
 // SetToDefault implements river.Defaulter.
@@ -170,6 +310,33 @@ func (o *WALOptions) Validate() error {
 		return fmt.Errorf("truncate_frequency must not be 0")
 	case o.MaxKeepaliveTime <= o.MinKeepaliveTime:
 		return fmt.Errorf("min_keepalive_time must be smaller than max_keepalive_time")
+	case o.SizeThreshold < 0:
+		return fmt.Errorf("size_threshold must not be negative")
+	}
+
+	return nil
+}
+
+// LocalQueryOptions configures instant queries over the samples recently
+// written to the component.
+type LocalQueryOptions struct {
+	Enabled   bool          `river:"enabled,attr,optional"`
+	Retention time.Duration `river:"retention,attr,optional"`
+	Timeout   time.Duration `river:"timeout,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (o *LocalQueryOptions) SetToDefault() {
+	*o = DefaultLocalQueryOptions
+}
+
+// Validate implements river.Validator.
+func (o *LocalQueryOptions) Validate() error {
+	switch {
+	case o.Retention <= 0:
+		return fmt.Errorf("retention must be greater than 0")
+	case o.Timeout <= 0:
+		return fmt.Errorf("timeout must be greater than 0")
 	}
 
 	return nil
@@ -181,15 +348,47 @@ type Exports struct {
 	Receiver storage.Appendable `river:"receiver,attr"`
 }
 
-func convertConfigs(cfg Arguments) (*config.Config, error) {
-	var rwConfigs []*config.RemoteWriteConfig
+// remoteQueue is the configuration of one of the queues sending samples to
+// an endpoint.
+type remoteQueue struct {
+	// key identifies the queue across updates, unlike the name of the queue
+	// which is derived from its whole configuration if not set.
+	key      string
+	config   *config.RemoteWriteConfig
+	autoTune *AutoTuneOptions
+
+	// endpoint and tenant identify the queue in usage metrics.
+	endpoint string
+	tenant   string
+}
+
+// convertQueues returns the queues of the endpoints of cfg. When mapping is
+// set, the tenants of the endpoints are the tenants of the mapping. Endpoints
+// authenticating with Azure AD or Google IAM read their token from a file in
+// tokenDir.
+func convertQueues(cfg Arguments, mapping *tenant.Mapping, tokenDir string) ([]*remoteQueue, error) {
+	var (
+		queues []*remoteQueue
+		keys   = make(map[string]int)
+	)
+	addQueue := func(key string, rwConfig *config.RemoteWriteConfig, autoTune *AutoTuneOptions, endpoint, tenant string) {
+		// Endpoints with the same URL and without names get distinct keys.
+		if n := keys[key]; n > 0 {
+			keys[key]++
+			key = fmt.Sprintf("%s#%d", key, n)
+		} else {
+			keys[key] = 1
+		}
+		queues = append(queues, &remoteQueue{key: key, config: rwConfig, autoTune: autoTune, endpoint: endpoint, tenant: tenant})
+	}
+
 	for _, rw := range cfg.Endpoints {
 		parsedURL, err := url.Parse(rw.URL)
 		if err != nil {
 			return nil, fmt.Errorf("cannot parse remote_write url %q: %w", rw.URL, err)
 		}
 
-		rwConfigs = append(rwConfigs, &config.RemoteWriteConfig{
+		rwConfig := &config.RemoteWriteConfig{
 			URL:                  &common.URL{URL: parsedURL},
 			RemoteTimeout:        model.Duration(rw.RemoteTimeout),
 			Headers:              rw.Headers,
@@ -201,8 +400,78 @@ func convertConfigs(cfg Arguments) (*config.Config, error) {
 			HTTPClientConfig: *rw.HTTPClientConfig.Convert(),
 			QueueConfig:      rw.QueueOptions.toPrometheusType(),
 			MetadataConfig:   rw.MetadataOptions.toPrometheusType(),
-			// TODO(rfratto): SigV4Config
-		})
+			SigV4Config:      rw.SigV4.toPrometheusType(),
+		}
+		if opts, ok := endpointCloudAuth(rw); ok {
+			rwConfig.HTTPClientConfig.Authorization = &common.Authorization{
+				Type:            "Bearer",
+				CredentialsFile: tokenFile(tokenDir, opts),
+			}
+		}
+
+		var autoTune *AutoTuneOptions
+		if rw.QueueOptions != nil {
+			autoTune = rw.QueueOptions.AutoTune
+		}
+		key := rw.Name
+		if key == "" {
+			key = parsedURL.Redacted()
+		}
+
+		tenants := rw.Tenants
+		if mapping != nil {
+			tenants = mappedTenants(mapping)
+		}
+
+		if len(tenants) == 0 {
+			switch {
+			case mapping == nil:
+				addQueue(key, rwConfig, autoTune, key, "")
+			case mapping.DefaultTenant() != "":
+				rwConfig.Headers = tenantHeaders(rw.Headers, mapping.DefaultTenant())
+				addQueue(key, rwConfig, autoTune, key, mapping.DefaultTenant())
+			}
+			continue
+		}
+
+		// Every tenant gets its own queue, and series which don't match any
+		// tenant are sent through the endpoint's own queue, unless the tenant
+		// mapping rejects them.
+		for i := 0; i <= len(tenants); i++ {
+			if i == len(tenants) && mapping != nil && mapping.DefaultTenant() == "" {
+				break
+			}
+
+			relabelConfigs, err := tenantRelabelConfigs(tenants, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tenantConfig := *rwConfig
+			tenantConfig.WriteRelabelConfigs = relabelConfigs
+			tenantKey, tenantID := key, ""
+			switch {
+			case i < len(tenants):
+				tenantConfig.Headers = tenantHeaders(rw.Headers, tenants[i].ID)
+				if rw.Name != "" {
+					tenantConfig.Name = rw.Name + "-" + tenants[i].ID
+				}
+				tenantKey, tenantID = key+"/"+tenants[i].ID, tenants[i].ID
+			case mapping != nil:
+				tenantConfig.Headers = tenantHeaders(rw.Headers, mapping.DefaultTenant())
+				tenantID = mapping.DefaultTenant()
+			}
+			addQueue(tenantKey, &tenantConfig, autoTune, key, tenantID)
+		}
+	}
+
+	return queues, nil
+}
+
+func newConfig(cfg Arguments, queues []*remoteQueue) *config.Config {
+	rwConfigs := make([]*config.RemoteWriteConfig, 0, len(queues))
+	for _, q := range queues {
+		rwConfigs = append(rwConfigs, q.config)
 	}
 
 	return &config.Config{
@@ -210,7 +479,7 @@ func convertConfigs(cfg Arguments) (*config.Config, error) {
 			ExternalLabels: toLabels(cfg.ExternalLabels),
 		},
 		RemoteWriteConfigs: rwConfigs,
-	}, nil
+	}
 }
 
 func toLabels(in map[string]string) labels.Labels {
@@ -53,3 +53,121 @@ func TestBadRiverConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func TestBadTenantRiverConfig(t *testing.T) {
+	tt := []struct {
+		name   string
+		tenant string
+		expect string
+	}{
+		{
+			name: "duplicate tenant",
+			tenant: `
+				tenant {
+					id       = "a"
+					selector = "{namespace=\"a\"}"
+				}
+				tenant {
+					id       = "a"
+					selector = "{namespace=\"b\"}"
+				}`,
+			expect: `tenant "a" is defined multiple times`,
+		},
+		{
+			name: "invalid selector",
+			tenant: `
+				tenant {
+					id       = "a"
+					selector = "{namespace=~\"(\"}"
+				}`,
+			expect: `invalid selector for tenant "a"`,
+		},
+		{
+			name: "empty id",
+			tenant: `
+				tenant {
+					id       = ""
+					selector = "{namespace=\"a\"}"
+				}`,
+			expect: "tenant id must not be empty",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := `
+				endpoint {
+					url = "http://0.0.0.0:11111/api/v1/write"
+					` + tc.tenant + `
+				}`
+
+			var args Arguments
+			err := river.Unmarshal([]byte(cfg), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestBadMetadataRiverConfig(t *testing.T) {
+	var exampleRiverConfig = `
+		endpoint {
+			url = "http://0.0.0.0:11111/api/v1/write"
+
+			metadata_config {
+				send_interval = "0s"
+			}
+		}
+`
+
+	var args Arguments
+	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
+	require.ErrorContains(t, err, "send_interval must be greater than 0")
+}
+
+func TestBadSigV4RiverConfig(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect string
+	}{
+		{
+			name: "missing secret key",
+			cfg: `
+				sigv4 {
+					access_key = "access-key"
+				}`,
+			expect: "access_key and secret_key must both be set if either is set",
+		},
+		{
+			name: "combined with basic_auth",
+			cfg: `
+				basic_auth {
+					username = "user"
+					password = "pass"
+				}
+				sigv4 {}`,
+			expect: "at most one of basic_auth, authorization, oauth2, bearer_token, bearer_token_file & sigv4 must be configured",
+		},
+		{
+			name: "combined with bearer_token",
+			cfg: `
+				bearer_token = "token"
+				sigv4 {}`,
+			expect: "at most one of basic_auth, authorization, oauth2, bearer_token, bearer_token_file & sigv4 must be configured",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := `
+				endpoint {
+					url = "http://0.0.0.0:11111/api/v1/write"
+					` + tc.cfg + `
+				}`
+
+			var args Arguments
+			err := river.Unmarshal([]byte(cfg), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
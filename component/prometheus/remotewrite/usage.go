@@ -0,0 +1,77 @@
+package remotewrite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/grafana/agent/component/common/usage"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+// DefaultUsageOptions holds the default settings of the usage block.
+var DefaultUsageOptions = UsageOptions{
+	ActiveSeriesWindow: 10 * time.Minute,
+}
+
+// UsageOptions enables accounting for the active series forwarded to the
+// queues of the endpoints, by source component.
+type UsageOptions struct {
+	ActiveSeriesWindow time.Duration `river:"active_series_window,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (o *UsageOptions) SetToDefault() {
+	*o = DefaultUsageOptions
+}
+
+// Validate implements river.Validator.
+func (o *UsageOptions) Validate() error {
+	if o.ActiveSeriesWindow <= 0 {
+		return fmt.Errorf("active_series_window must be greater than 0")
+	}
+	return nil
+}
+
+// activeSeriesWindow returns the window of active series of o, or 0 if usage
+// accounting is disabled.
+func (o *UsageOptions) activeSeriesWindow() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.ActiveSeriesWindow
+}
+
+// usageRoutes returns the routes of the series forwarded to queues. Like the
+// queues, routes match series with the external labels of cfg added.
+func usageRoutes(cfg Arguments, queues []*remoteQueue) []usage.Route {
+	externalLabels := toLabels(cfg.ExternalLabels)
+
+	routes := make([]usage.Route, 0, len(queues))
+	for _, q := range queues {
+		r := usage.Route{Endpoint: q.endpoint, Tenant: q.tenant}
+		if rcs := q.config.WriteRelabelConfigs; len(rcs) > 0 {
+			r.Match = func(l labels.Labels) bool {
+				_, keep := relabel.Process(withExternalLabels(l, externalLabels), rcs...)
+				return keep
+			}
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// withExternalLabels returns l with the labels of externalLabels it doesn't
+// have.
+func withExternalLabels(l, externalLabels labels.Labels) labels.Labels {
+	if len(externalLabels) == 0 {
+		return l
+	}
+	b := labels.NewBuilder(l)
+	for _, e := range externalLabels {
+		if l.Get(e.Name) == "" {
+			b.Set(e.Name, e.Value)
+		}
+	}
+	return b.Labels(nil)
+}
@@ -0,0 +1,72 @@
+package remotewrite
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageRoutes(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		external_labels = { cluster = "prod" }
+
+		endpoint {
+			name = "mimir"
+			url  = "http://localhost:9009/api/v1/push"
+			tenant {
+				id       = "a"
+				selector = "{team=\"a\"}"
+			}
+			tenant {
+				id       = "prod"
+				selector = "{cluster=\"prod\", team!=\"a\"}"
+			}
+		}
+		endpoint {
+			name = "backup"
+			url  = "http://localhost:9010/api/v1/push"
+		}
+		usage { }
+	`), &args))
+	require.Equal(t, DefaultUsageOptions.ActiveSeriesWindow, args.Usage.activeSeriesWindow())
+
+	queues, err := convertQueues(args, nil, "")
+	require.NoError(t, err)
+
+	routes := usageRoutes(args, queues)
+	matched := func(l labels.Labels) []string {
+		var res []string
+		for _, r := range routes {
+			if r.Match == nil || r.Match(l) {
+				res = append(res, r.Endpoint+"/"+r.Tenant)
+			}
+		}
+		return res
+	}
+
+	require.Equal(t, []string{"mimir/a", "backup/"}, matched(labels.FromStrings("team", "a")))
+	// Series match tenants by the external labels added to them.
+	require.Equal(t, []string{"mimir/prod", "backup/"}, matched(labels.FromStrings("team", "b")))
+	// The labels of series override external labels.
+	require.Equal(t, []string{"mimir/", "backup/"}, matched(labels.FromStrings("team", "b", "cluster", "dev")))
+}
+
+func TestUsageOptions_Disabled(t *testing.T) {
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		endpoint {
+			url = "http://localhost:9009/api/v1/push"
+		}
+	`), &args))
+	require.Zero(t, args.Usage.activeSeriesWindow())
+
+	err := river.Unmarshal([]byte(`
+		usage {
+			active_series_window = "0s"
+		}
+	`), &args)
+	require.ErrorContains(t, err, "active_series_window must be greater than 0")
+}
@@ -0,0 +1,132 @@
+package remotewrite
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+)
+
+// walSizeCheckInterval is how often the size of the WAL is measured.
+var walSizeCheckInterval = time.Minute
+
+// walSizeMonitor periodically measures the size of the WAL and reports the
+// component as unhealthy while it's larger than the configured threshold.
+type walSizeMonitor struct {
+	log log.Logger
+	dir string
+
+	size      prometheus.Gauge
+	threshold prometheus.Gauge
+
+	mut       sync.RWMutex
+	maxSize   units.Base2Bytes
+	health    component.Health
+	lastBytes int64
+}
+
+func newWALSizeMonitor(l log.Logger, reg prometheus.Registerer, dir string) (*walSizeMonitor, error) {
+	m := &walSizeMonitor{
+		log: l,
+		dir: dir,
+
+		size: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_wal_storage_size_bytes",
+			Help: "Size of the WAL on disk in bytes.",
+		}),
+		threshold: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "agent_wal_storage_size_threshold_bytes",
+			Help: "Size of the WAL above which the component is reported as unhealthy. 0 if disabled.",
+		}),
+
+		health: component.Health{
+			Health:     component.HealthTypeHealthy,
+			UpdateTime: time.Now(),
+		},
+	}
+
+	for _, c := range []prometheus.Collector{m.size, m.threshold} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ApplyConfig updates the size threshold of the WAL.
+func (m *walSizeMonitor) ApplyConfig(maxSize units.Base2Bytes) {
+	m.mut.Lock()
+	m.maxSize = maxSize
+	m.mut.Unlock()
+
+	m.threshold.Set(float64(maxSize))
+	m.check()
+}
+
+func (m *walSizeMonitor) run(ctx context.Context) {
+	t := time.NewTicker(walSizeCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			m.measure()
+		}
+	}
+}
+
+// measure updates the size of the WAL and the health derived from it.
+func (m *walSizeMonitor) measure() {
+	size, err := fileutil.DirSize(m.dir)
+	if err != nil {
+		level.Warn(m.log).Log("msg", "could not measure the size of the WAL", "err", err)
+		return
+	}
+	m.size.Set(float64(size))
+
+	m.mut.Lock()
+	m.lastBytes = size
+	m.mut.Unlock()
+
+	m.check()
+}
+
+// check updates the health from the last measured size.
+func (m *walSizeMonitor) check() {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	var next component.Health
+	if m.maxSize > 0 && m.lastBytes > int64(m.maxSize) {
+		next = component.Health{
+			Health:  component.HealthTypeUnhealthy,
+			Message: fmt.Sprintf("WAL size %s exceeds wal.size_threshold of %s; data is not being sent to endpoints fast enough", units.Base2Bytes(m.lastBytes), m.maxSize),
+		}
+	} else {
+		next = component.Health{Health: component.HealthTypeHealthy}
+	}
+
+	if next.Health != m.health.Health || next.Message != m.health.Message {
+		if next.Health == component.HealthTypeUnhealthy && m.health.Health != component.HealthTypeUnhealthy {
+			level.Warn(m.log).Log("msg", next.Message)
+		}
+		next.UpdateTime = time.Now()
+		m.health = next
+	}
+}
+
+// CurrentHealth returns the health derived from the size of the WAL.
+func (m *walSizeMonitor) CurrentHealth() component.Health {
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+	return m.health
+}
@@ -0,0 +1,35 @@
+package remotewrite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWALSizeMonitor(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00000000"), make([]byte, 2048), 0600))
+
+	m, err := newWALSizeMonitor(util.TestLogger(t), prometheus.NewRegistry(), dir)
+	require.NoError(t, err)
+	m.measure()
+	require.Equal(t, float64(2048), testutil.ToFloat64(m.size))
+
+	// The threshold is disabled by default.
+	require.Equal(t, component.HealthTypeHealthy, m.CurrentHealth().Health)
+
+	m.ApplyConfig(1024)
+	require.Equal(t, float64(1024), testutil.ToFloat64(m.threshold))
+	health := m.CurrentHealth()
+	require.Equal(t, component.HealthTypeUnhealthy, health.Health)
+	require.Contains(t, health.Message, "exceeds wal.size_threshold of 1KiB")
+
+	m.ApplyConfig(4096)
+	require.Equal(t, component.HealthTypeHealthy, m.CurrentHealth().Health)
+}
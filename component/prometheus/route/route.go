@@ -0,0 +1,353 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/hashicorp/go-multierror"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "prometheus.route",
+		Args:    Arguments{},
+		Exports: Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// defaultRouteName is the name of the default route in metrics.
+const defaultRouteName = "default"
+
+// Arguments holds values which are used to configure the prometheus.route
+// component.
+type Arguments struct {
+	// The routes series are matched against, in order.
+	Routes []Route `river:"route,block,optional"`
+
+	// Whether series are only forwarded to the first matching route.
+	MatchOnce bool `river:"match_once,attr,optional"`
+
+	// Where series which match no route are forwarded to. Such series are
+	// dropped if it's empty.
+	DefaultForwardTo []storage.Appendable `river:"default_forward_to,attr,optional"`
+}
+
+// Route forwards series whose labels match Selector.
+type Route struct {
+	// The name of the route, used in metrics.
+	Name string `river:"name,attr"`
+
+	// The series selector series must match, such as {__name__=~"kube_.*"}.
+	Selector string `river:"selector,attr"`
+
+	// Where matching series are forwarded to.
+	ForwardTo []storage.Appendable `river:"forward_to,attr"`
+}
+
+// Validate implements river.Validator.
+func (a *Arguments) Validate() error {
+	if len(a.Routes) == 0 {
+		return fmt.Errorf("at least one route must be configured")
+	}
+
+	names := make(map[string]struct{}, len(a.Routes))
+	for i, r := range a.Routes {
+		switch _, dup := names[r.Name]; {
+		case r.Name == "":
+			return fmt.Errorf("route %d: name must not be empty", i)
+		case r.Name == defaultRouteName:
+			return fmt.Errorf("route %d: name %q is reserved for the default route", i, defaultRouteName)
+		case dup:
+			return fmt.Errorf("route %d: duplicate route name %q", i, r.Name)
+		}
+		names[r.Name] = struct{}{}
+
+		if _, err := parser.ParseMetricSelector(r.Selector); err != nil {
+			return fmt.Errorf("route %q: invalid selector: %w", r.Name, err)
+		}
+	}
+	return nil
+}
+
+// Exports holds values which are exported by the prometheus.route component.
+type Exports struct {
+	Receiver storage.Appendable `river:"receiver,attr"`
+}
+
+// route is a parsed Route.
+type route struct {
+	name     string
+	matchers []*labels.Matcher
+	fanout   *prometheus.Fanout
+}
+
+// matches returns true if lbls match all the matchers of the route.
+func (r *route) matches(lbls labels.Labels) bool {
+	for _, m := range r.matchers {
+		if !m.Matches(lbls.Get(m.Name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Component implements the prometheus.route component.
+type Component struct {
+	opts             component.Options
+	samplesProcessed prometheus_client.Counter
+	samplesRouted    *prometheus_client.CounterVec
+	samplesDropped   prometheus_client.Counter
+
+	mut          sync.RWMutex
+	routes       []*route
+	matchOnce    bool
+	defaultRoute *route // nil if series matching no route are dropped.
+
+	// fanouts holds the fanout of each route by name. Fanouts are reused
+	// across updates since they register metrics.
+	fanouts map[string]*prometheus.Fanout
+}
+
+var (
+	_ component.Component = (*Component)(nil)
+	_ storage.Appendable  = (*Component)(nil)
+)
+
+// New creates a new prometheus.route component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:    o,
+		fanouts: make(map[string]*prometheus.Fanout),
+	}
+	c.samplesProcessed = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_route_samples_processed_total",
+		Help: "Total number of samples processed",
+	})
+	c.samplesRouted = prometheus_client.NewCounterVec(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_route_samples_routed_total",
+		Help: "Total number of samples forwarded to each route",
+	}, []string{"route"})
+	c.samplesDropped = prometheus_client.NewCounter(prometheus_client.CounterOpts{
+		Name: "agent_prometheus_route_samples_dropped_total",
+		Help: "Total number of samples dropped because they matched no route and no default route is set",
+	})
+
+	for _, metric := range []prometheus_client.Collector{c.samplesProcessed, c.samplesRouted, c.samplesDropped} {
+		if err := o.Registerer.Register(metric); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+
+	// Immediately export the receiver which remains the same for the component
+	// lifetime.
+	o.OnStateChange(Exports{Receiver: c})
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	routes := make([]*route, 0, len(newArgs.Routes))
+	for _, r := range newArgs.Routes {
+		matchers, err := parser.ParseMetricSelector(r.Selector)
+		if err != nil {
+			return fmt.Errorf("route %q: invalid selector: %w", r.Name, err)
+		}
+		routes = append(routes, &route{
+			name:     r.Name,
+			matchers: matchers,
+		})
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	for i, r := range newArgs.Routes {
+		routes[i].fanout = c.fanout(r.Name, r.ForwardTo)
+	}
+	c.routes = routes
+	c.matchOnce = newArgs.MatchOnce
+	c.defaultRoute = nil
+	if len(newArgs.DefaultForwardTo) > 0 {
+		c.defaultRoute = &route{
+			name:   defaultRouteName,
+			fanout: c.fanout(defaultRouteName, newArgs.DefaultForwardTo),
+		}
+	}
+	return nil
+}
+
+// fanout returns the fanout of the route with the given name, updated to
+// forward to children. c.mut must be held.
+func (c *Component) fanout(name string, children []storage.Appendable) *prometheus.Fanout {
+	f, ok := c.fanouts[name]
+	if !ok {
+		reg := prometheus_client.WrapRegistererWith(prometheus_client.Labels{"route": name}, c.opts.Registerer)
+		f = prometheus.NewFanout(children, c.opts.ID, reg)
+		f.SetTracerProvider(c.opts.Tracer)
+		c.fanouts[name] = f
+	}
+	f.UpdateChildren(children)
+	return f
+}
+
+// Appender implements storage.Appendable. Appenders of the routes are only
+// opened once a series is routed to them.
+func (c *Component) Appender(ctx context.Context) storage.Appender {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	return &appender{
+		c:            c,
+		ctx:          ctx,
+		routes:       c.routes,
+		matchOnce:    c.matchOnce,
+		defaultRoute: c.defaultRoute,
+		children:     make(map[*route]storage.Appender),
+	}
+}
+
+type appender struct {
+	c            *Component
+	ctx          context.Context
+	routes       []*route
+	matchOnce    bool
+	defaultRoute *route
+
+	children map[*route]storage.Appender
+}
+
+var _ storage.Appender = (*appender)(nil)
+
+// match returns the routes series with the given labels are forwarded to.
+func (a *appender) match(lbls labels.Labels) []*route {
+	var matched []*route
+	for _, r := range a.routes {
+		if r.matches(lbls) {
+			matched = append(matched, r)
+			if a.matchOnce {
+				break
+			}
+		}
+	}
+	if len(matched) == 0 && a.defaultRoute != nil {
+		matched = append(matched, a.defaultRoute)
+	}
+	return matched
+}
+
+// forEach calls f with the appender of each route the series with the given
+// labels is forwarded to, returning those routes.
+func (a *appender) forEach(lbls labels.Labels, f func(storage.Appender) error) ([]*route, error) {
+	matched := a.match(lbls)
+
+	var errs error
+	for _, r := range matched {
+		child, ok := a.children[r]
+		if !ok {
+			child = r.fanout.Appender(a.ctx)
+			a.children[r] = child
+		}
+		if err := f(child); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return matched, errs
+}
+
+// Append implements storage.Appender.
+func (a *appender) Append(ref storage.SeriesRef, l labels.Labels, t int64, v float64) (storage.SeriesRef, error) {
+	matched, err := a.forEach(l, func(child storage.Appender) error {
+		_, err := child.Append(ref, l, t, v)
+		return err
+	})
+	a.countSample(matched)
+	return ref, err
+}
+
+// AppendExemplar implements storage.Appender.
+func (a *appender) AppendExemplar(ref storage.SeriesRef, l labels.Labels, e exemplar.Exemplar) (storage.SeriesRef, error) {
+	_, err := a.forEach(l, func(child storage.Appender) error {
+		_, err := child.AppendExemplar(ref, l, e)
+		return err
+	})
+	return ref, err
+}
+
+// UpdateMetadata implements storage.Appender.
+func (a *appender) UpdateMetadata(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata) (storage.SeriesRef, error) {
+	_, err := a.forEach(l, func(child storage.Appender) error {
+		_, err := child.UpdateMetadata(ref, l, m)
+		return err
+	})
+	return ref, err
+}
+
+// AppendHistogram implements storage.Appender.
+func (a *appender) AppendHistogram(ref storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
+	matched, err := a.forEach(l, func(child storage.Appender) error {
+		_, err := child.AppendHistogram(ref, l, t, h, fh)
+		return err
+	})
+	a.countSample(matched)
+	return ref, err
+}
+
+// countSample updates the metrics for a sample forwarded to the matched
+// routes.
+func (a *appender) countSample(matched []*route) {
+	a.c.samplesProcessed.Inc()
+	if len(matched) == 0 {
+		a.c.samplesDropped.Inc()
+		return
+	}
+	for _, r := range matched {
+		a.c.samplesRouted.WithLabelValues(r.name).Inc()
+	}
+}
+
+// Commit implements storage.Appender.
+func (a *appender) Commit() error {
+	var errs error
+	for _, child := range a.children {
+		if err := child.Commit(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Rollback implements storage.Appender.
+func (a *appender) Rollback() error {
+	var errs error
+	for _, child := range a.children {
+		if err := child.Rollback(); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,217 @@
+package route
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/prometheus"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_UnmarshalRiver(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         string
+		expectedErr string
+	}{
+		{
+			name: "routes",
+			cfg: `
+				route {
+					name       = "cluster"
+					selector   = "{__name__=~\"kube_.*\"}"
+					forward_to = []
+				}
+				default_forward_to = []
+			`,
+		},
+		{
+			name:        "no routes",
+			cfg:         `default_forward_to = []`,
+			expectedErr: "at least one route must be configured",
+		},
+		{
+			name: "duplicate name",
+			cfg: `
+				route {
+					name       = "cluster"
+					selector   = "{__name__=~\"kube_.*\"}"
+					forward_to = []
+				}
+				route {
+					name       = "cluster"
+					selector   = "{job=\"node\"}"
+					forward_to = []
+				}
+			`,
+			expectedErr: `route 1: duplicate route name "cluster"`,
+		},
+		{
+			name: "reserved name",
+			cfg: `
+				route {
+					name       = "default"
+					selector   = "{job=\"node\"}"
+					forward_to = []
+				}
+			`,
+			expectedErr: `route 0: name "default" is reserved for the default route`,
+		},
+		{
+			name: "invalid selector",
+			cfg: `
+				route {
+					name       = "infra"
+					selector   = "{job=}"
+					forward_to = []
+				}
+			`,
+			expectedErr: `route "infra": invalid selector`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.cfg), &args)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name            string
+		matchOnce       bool
+		withDefault     bool
+		expectedCluster []string
+		expectedInfra   []string
+		expectedDefault []string
+		expectedDropped float64
+	}{
+		{
+			name:            "match all",
+			expectedCluster: []string{"kube_pod_info"},
+			expectedInfra:   []string{"kube_pod_info", "node_cpu_seconds_total"},
+			expectedDropped: 1,
+		},
+		{
+			name:            "match once",
+			matchOnce:       true,
+			expectedCluster: []string{"kube_pod_info"},
+			expectedInfra:   []string{"node_cpu_seconds_total"},
+			expectedDropped: 1,
+		},
+		{
+			name:            "default route",
+			withDefault:     true,
+			expectedCluster: []string{"kube_pod_info"},
+			expectedInfra:   []string{"kube_pod_info", "node_cpu_seconds_total"},
+			expectedDefault: []string{"up"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var cluster, infra, def collector
+
+			args := Arguments{
+				Routes: []Route{
+					{Name: "cluster", Selector: `{__name__=~"kube_.*"}`, ForwardTo: []storage.Appendable{cluster.appendable()}},
+					{Name: "infra", Selector: `{job="node"}`, ForwardTo: []storage.Appendable{infra.appendable()}},
+				},
+				MatchOnce: tc.matchOnce,
+			}
+			if tc.withDefault {
+				args.DefaultForwardTo = []storage.Appendable{def.appendable()}
+			}
+
+			reg := prometheus_client.NewRegistry()
+			c, err := New(component.Options{
+				ID:            "prometheus.route.test",
+				Logger:        util.TestFlowLogger(t),
+				OnStateChange: func(e component.Exports) {},
+				Registerer:    reg,
+			}, args)
+			require.NoError(t, err)
+
+			app := c.Appender(context.Background())
+			for _, lbls := range []labels.Labels{
+				labels.FromStrings("__name__", "kube_pod_info", "job", "node"),
+				labels.FromStrings("__name__", "node_cpu_seconds_total", "job", "node"),
+				labels.FromStrings("__name__", "up", "job", "app"),
+			} {
+				_, err := app.Append(0, lbls, 1, 1)
+				require.NoError(t, err)
+			}
+			require.NoError(t, app.Commit())
+
+			require.Equal(t, tc.expectedCluster, cluster.names())
+			require.Equal(t, tc.expectedInfra, infra.names())
+			require.Equal(t, tc.expectedDefault, def.names())
+			require.Equal(t, 3.0, testutil.ToFloat64(c.samplesProcessed))
+			require.Equal(t, tc.expectedDropped, testutil.ToFloat64(c.samplesDropped))
+		})
+	}
+}
+
+func TestRoute_Update(t *testing.T) {
+	var first, second collector
+
+	args := Arguments{
+		Routes: []Route{
+			{Name: "cluster", Selector: `{__name__=~"kube_.*"}`, ForwardTo: []storage.Appendable{first.appendable()}},
+		},
+	}
+	c, err := New(component.Options{
+		ID:            "prometheus.route.test",
+		Logger:        util.TestFlowLogger(t),
+		OnStateChange: func(e component.Exports) {},
+		Registerer:    prometheus_client.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	args.Routes[0].ForwardTo = []storage.Appendable{second.appendable()}
+	require.NoError(t, c.Update(args))
+
+	app := c.Appender(context.Background())
+	_, err = app.Append(0, labels.FromStrings("__name__", "kube_pod_info"), 1, 1)
+	require.NoError(t, err)
+	require.NoError(t, app.Commit())
+
+	require.Empty(t, first.names())
+	require.Equal(t, []string{"kube_pod_info"}, second.names())
+}
+
+// collector records the names of the series appended to it.
+type collector struct {
+	mut    sync.Mutex
+	series []string
+}
+
+func (c *collector) appendable() storage.Appendable {
+	return prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		c.mut.Lock()
+		defer c.mut.Unlock()
+		c.series = append(c.series, l.Get("__name__"))
+		return ref, nil
+	}))
+}
+
+func (c *collector) names() []string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.series
+}
@@ -0,0 +1,280 @@
+package scrape
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	config_util "github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/prometheus/prometheus/scrape"
+)
+
+const (
+	// maxCapturedBodySize is the maximum number of bytes of a scrape response
+	// body returned by the capture endpoint. The full body is still parsed.
+	maxCapturedBodySize = 1 << 20
+
+	// scrapeAcceptHeader is the Accept header sent by the Prometheus scrape
+	// manager when protobuf negotiation is disabled.
+	scrapeAcceptHeader = `application/openmetrics-text;version=1.0.0,application/openmetrics-text;version=0.0.1;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1`
+
+	// redactedSecret replaces configured secrets found in captured bodies.
+	redactedSecret = "<secret>"
+)
+
+// ScrapeCapture is the result of scraping a target on demand.
+type ScrapeCapture struct {
+	URL         string    `json:"url"`
+	Timestamp   time.Time `json:"timestamp"`
+	Duration    string    `json:"duration"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	BodySize    int       `json:"body_size"`
+	Body        string    `json:"body"`
+	Truncated   bool      `json:"truncated"`
+	Samples     int       `json:"samples"`
+	ScrapeError string    `json:"scrape_error,omitempty"`
+	ParseError  string    `json:"parse_error,omitempty"`
+}
+
+// Handler implements component.HTTPComponent. It serves /scrape, which
+// scrapes one of the component's active targets on demand and returns the
+// raw response along with any error found while parsing it. Targets are
+// selected with the url query parameter, set to the URL reported in the
+// component's debug info.
+func (c *Component) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/scrape", c.handleScrape)
+	return mux
+}
+
+func (c *Component) handleScrape(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	targetURL := r.URL.Query().Get("url")
+	if targetURL == "" {
+		http.Error(w, "missing url query parameter", http.StatusBadRequest)
+		return
+	}
+
+	var target *scrape.Target
+	for _, targets := range c.scraper.TargetsActive() {
+		for _, t := range targets {
+			if t.URL().String() == targetURL {
+				target = t
+			}
+		}
+	}
+	if target == nil {
+		http.Error(w, fmt.Sprintf("no active target with URL %q", targetURL), http.StatusNotFound)
+		return
+	}
+
+	res, err := c.captureScrape(r.Context(), target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(res)
+}
+
+// captureScrape scrapes target the same way the scrape manager does and
+// returns the response.
+func (c *Component) captureScrape(ctx context.Context, target *scrape.Target) (*ScrapeCapture, error) {
+	c.mut.RLock()
+	args := c.args
+	c.mut.RUnlock()
+
+	clientConfig := args.HTTPClientConfig.Convert()
+	client, err := config_util.NewClientFromConfig(*clientConfig, c.opts.ID, config_util.WithDialContextFunc(c.opts.DialFunc))
+	if err != nil {
+		return nil, fmt.Errorf("creating HTTP client: %w", err)
+	}
+	defer client.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(ctx, args.ScrapeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL().String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", scrapeAcceptHeader)
+	req.Header.Add("Accept-Encoding", "gzip")
+	req.Header.Set("User-Agent", scrape.UserAgent)
+	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatFloat(args.ScrapeTimeout.Seconds(), 'f', -1, 64))
+
+	res := &ScrapeCapture{
+		URL:       target.URL().String(),
+		Timestamp: time.Now(),
+	}
+	start := time.Now()
+	body, err := fetchScrape(client, req, res, int64(args.BodySizeLimit))
+	res.Duration = time.Since(start).String()
+	if err != nil {
+		res.ScrapeError = err.Error()
+	}
+
+	if body != nil {
+		res.BodySize = len(body)
+		res.Samples, err = parseScrape(body, res.ContentType)
+		if err != nil {
+			res.ParseError = err.Error()
+		}
+
+		if len(body) > maxCapturedBodySize {
+			body = body[:maxCapturedBodySize]
+			res.Truncated = true
+		}
+		res.Body = string(body)
+	}
+
+	secrets := configuredSecrets(args)
+	res.Body = scrubSecrets(res.Body, secrets)
+	res.ScrapeError = scrubSecrets(res.ScrapeError, secrets)
+	res.ParseError = scrubSecrets(res.ParseError, secrets)
+	return res, nil
+}
+
+var errBodySizeLimit = errors.New("body size limit exceeded")
+
+// fetchScrape performs req and returns the decompressed response body. The
+// status code and content type of the response are stored in res.
+func fetchScrape(client *http.Client, req *http.Request, res *ScrapeCapture, bodySizeLimit int64) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	res.StatusCode = resp.StatusCode
+	res.ContentType = resp.Header.Get("Content-Type")
+
+	var r io.Reader = resp.Body
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		gzipr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipr.Close()
+		r = gzipr
+	}
+
+	if bodySizeLimit <= 0 {
+		bodySizeLimit = math.MaxInt64
+	}
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, bodySizeLimit))
+	if err != nil {
+		return buf.Bytes(), err
+	}
+
+	switch {
+	case n >= bodySizeLimit:
+		return buf.Bytes(), errBodySizeLimit
+	case resp.StatusCode != http.StatusOK:
+		// The body of failed scrapes is still returned, as it often explains
+		// the failure.
+		return buf.Bytes(), fmt.Errorf("server returned HTTP status %s", resp.Status)
+	}
+	return buf.Bytes(), nil
+}
+
+// parseScrape parses body like the scrape manager does and returns the number
+// of samples it contains, or the first error found. As parse errors don't say
+// where they happen, they mention the last series parsed successfully.
+func parseScrape(body []byte, contentType string) (int, error) {
+	p, err := textparse.New(body, contentType)
+	if err != nil {
+		return 0, fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	var (
+		samples    int
+		lastSeries string
+	)
+	for {
+		entry, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return samples, nil
+		} else if err != nil {
+			if lastSeries == "" {
+				return samples, err
+			}
+			return samples, fmt.Errorf("%w (after series %s)", err, lastSeries)
+		}
+
+		switch entry {
+		case textparse.EntrySeries:
+			series, _, _ := p.Series()
+			lastSeries = string(series)
+			samples++
+		case textparse.EntryHistogram:
+			series, _, _, _ := p.Histogram()
+			lastSeries = string(series)
+			samples++
+		}
+	}
+}
+
+// configuredSecrets returns the secrets of the component's HTTP client
+// configuration, which targets may echo back in their responses. Secrets read
+// from files are included too.
+func configuredSecrets(args Arguments) []string {
+	var (
+		cfg     = args.HTTPClientConfig
+		secrets = []string{string(cfg.BearerToken), string(cfg.TLSConfig.Key)}
+		files   = []string{cfg.BearerTokenFile}
+	)
+	if cfg.BasicAuth != nil {
+		secrets = append(secrets, string(cfg.BasicAuth.Password))
+		files = append(files, cfg.BasicAuth.PasswordFile)
+	}
+	if cfg.Authorization != nil {
+		secrets = append(secrets, string(cfg.Authorization.Credentials))
+		files = append(files, cfg.Authorization.CredentialsFile)
+	}
+	if cfg.OAuth2 != nil {
+		secrets = append(secrets, string(cfg.OAuth2.ClientSecret))
+		files = append(files, cfg.OAuth2.ClientSecretFile)
+	}
+
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		// Prometheus trims whitespace from secrets read from files.
+		if b, err := os.ReadFile(f); err == nil {
+			secrets = append(secrets, strings.TrimSpace(string(b)))
+		}
+	}
+	return secrets
+}
+
+// scrubSecrets replaces all occurrences of the given secrets in s.
+func scrubSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redactedSecret)
+		}
+	}
+	return s
+}
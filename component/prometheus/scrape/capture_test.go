@@ -0,0 +1,74 @@
+package scrape
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	prometheus_client "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureScrape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer s3cr3t-t0ken", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		_, _ = w.Write([]byte(strings.Join([]string{
+			`# TYPE up gauge`,
+			`up 1`,
+			`auth_info{token="s3cr3t-t0ken"} 1`,
+			`broken{ 1`,
+		}, "\n")))
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		targets      = []
+		forward_to   = []
+		bearer_token = "s3cr3t-t0ken"
+	`), &args))
+
+	c, err := New(component.Options{
+		ID:         "prometheus.scrape.test",
+		Logger:     util.TestFlowLogger(t),
+		Clusterer:  &cluster.Clusterer{Node: cluster.NewLocalNode("")},
+		Registerer: prometheus_client.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	target := scrape.NewTarget(labels.FromStrings(
+		"__address__", u.Host,
+		"__scheme__", "http",
+		"__metrics_path__", "/metrics",
+	), nil, nil)
+
+	res, err := c.captureScrape(context.Background(), target)
+	require.NoError(t, err)
+	require.Equal(t, srv.URL+"/metrics", res.URL)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Empty(t, res.ScrapeError)
+	require.Equal(t, 2, res.Samples)
+	require.Contains(t, res.ParseError, `after series auth_info{token="<secret>"}`)
+	require.False(t, res.Truncated)
+	require.NotContains(t, res.Body, "s3cr3t-t0ken")
+	require.Contains(t, res.Body, `auth_info{token="<secret>"} 1`)
+
+	// Targets which aren't being scraped by the component can't be captured.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/scrape?url="+url.QueryEscape(res.URL), nil)
+	c.Handler().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,46 @@
+package scrape
+
+import (
+	"github.com/grafana/agent/pkg/events"
+	"github.com/prometheus/prometheus/scrape"
+)
+
+// endpointHealthTracker publishes an event whenever a scraped endpoint goes
+// down or comes back up.
+type endpointHealthTracker struct {
+	events *events.Client
+	health map[string]scrape.TargetHealth // Health of the last scrape by endpoint URL.
+}
+
+func newEndpointHealthTracker(c *events.Client) *endpointHealthTracker {
+	return &endpointHealthTracker{
+		events: c,
+		health: make(map[string]scrape.TargetHealth),
+	}
+}
+
+// Observe compares the health of the latest scrapes of targets with the one
+// seen on the previous call.
+func (t *endpointHealthTracker) Observe(targets map[string][]*scrape.Target) {
+	cur := make(map[string]scrape.TargetHealth, len(t.health))
+	for job, tt := range targets {
+		for _, target := range tt {
+			url := target.URL().String()
+			health := target.Health()
+			cur[url] = health
+
+			prev := t.health[url]
+			switch {
+			case health == scrape.HealthBad && prev != scrape.HealthBad:
+				var msg string
+				if err := target.LastError(); err != nil {
+					msg = err.Error()
+				}
+				t.events.Publish(events.TypeEndpointDown, msg, map[string]string{"endpoint": url, "job": job})
+			case health == scrape.HealthGood && prev == scrape.HealthBad:
+				t.events.Publish(events.TypeEndpointUp, "", map[string]string{"endpoint": url, "job": job})
+			}
+		}
+	}
+	t.health = cur
+}
@@ -0,0 +1,77 @@
+package scrape
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/scrape"
+)
+
+// scrapeLimits holds the names of the limits a scrape can be rejected for.
+// Each name is the prefix of the error Prometheus reports for a target which
+// exceeds it.
+var scrapeLimits = []struct {
+	name   string
+	prefix string
+}{
+	{"body_size_limit", "body size limit exceeded"},
+	{"sample_limit", "sample limit exceeded"},
+	{"target_limit", "target_limit exceeded"},
+	{"label_limit", "label_limit exceeded"},
+	{"label_name_length_limit", "label_name_length_limit exceeded"},
+	{"label_value_length_limit", "label_value_length_limit exceeded"},
+}
+
+// exceededLimit returns the name of the limit err reports as exceeded, or an
+// empty string if err isn't caused by a limit.
+func exceededLimit(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	for _, l := range scrapeLimits {
+		if strings.HasPrefix(msg, l.prefix) {
+			return l.name
+		}
+	}
+	return ""
+}
+
+// limitsCollector reports the number of targets whose latest scrape was
+// rejected for exceeding a limit.
+type limitsCollector struct {
+	targets func() map[string][]*scrape.Target
+	desc    *prometheus.Desc
+}
+
+func newLimitsCollector(targets func() map[string][]*scrape.Target) *limitsCollector {
+	return &limitsCollector{
+		targets: targets,
+		desc: prometheus.NewDesc(
+			"agent_prometheus_scrape_targets_exceeding_limit",
+			"Number of targets whose latest scrape was rejected for exceeding a limit.",
+			[]string{"limit"}, nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *limitsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *limitsCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := make(map[string]int, len(scrapeLimits))
+	for _, targets := range c.targets() {
+		for _, t := range targets {
+			if limit := exceededLimit(t.LastError()); limit != "" {
+				counts[limit]++
+			}
+		}
+	}
+
+	for _, l := range scrapeLimits {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[l.name]), l.name)
+	}
+}
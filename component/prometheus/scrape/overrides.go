@@ -0,0 +1,63 @@
+package scrape
+
+import (
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+)
+
+// paramOverridePrefix is the prefix of the labels holding the __param_*
+// labels of targets which override the params of the component. The scrape
+// manager replaces the __param_* labels of targets with the params of the
+// component, so overrides are moved out of the way and restored by the rules
+// of paramOverrideRules. Like other meta labels, they're dropped after
+// relabeling.
+const paramOverridePrefix = model.MetaLabelPrefix + "agent_scrape_param_"
+
+// applyTargetOverrides prepares the __scrape_interval__, __scrape_timeout__,
+// and __param_* labels of a target, which override the settings of the
+// component for that target.
+func applyTargetOverrides(lset model.LabelSet, args Arguments) {
+	// Targets overriding the interval with one shorter than the timeout of
+	// the component use their interval as timeout, rather than being dropped
+	// for having a timeout longer than their interval.
+	if iv, ok := lset[model.ScrapeIntervalLabel]; ok {
+		if _, ok := lset[model.ScrapeTimeoutLabel]; !ok {
+			interval, err := model.ParseDuration(string(iv))
+			if err == nil && time.Duration(interval) < args.ScrapeTimeout {
+				lset[model.ScrapeTimeoutLabel] = iv
+			}
+		}
+	}
+
+	for name := range args.Params {
+		label := model.LabelName(model.ParamLabelPrefix + name)
+		if v, ok := lset[label]; ok {
+			delete(lset, label)
+			lset[model.LabelName(paramOverridePrefix+name)] = v
+		}
+	}
+}
+
+// paramOverrideRules returns the relabel rules restoring the __param_*
+// labels moved by applyTargetOverrides.
+func paramOverrideRules(params url.Values) []*relabel.Config {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := make([]*relabel.Config, 0, len(names))
+	for _, name := range names {
+		rule := relabel.DefaultRelabelConfig
+		rule.SourceLabels = model.LabelNames{model.LabelName(paramOverridePrefix + name)}
+		rule.Regex = relabel.MustNewRegexp("(.+)")
+		rule.TargetLabel = model.ParamLabelPrefix + name
+		rules = append(rules, &rule)
+	}
+	return rules
+}
@@ -0,0 +1,68 @@
+package scrape
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetOverrides(t *testing.T) {
+	var args Arguments
+	args.SetToDefault()
+	args.ScrapeInterval = time.Minute
+	args.ScrapeTimeout = 10 * time.Second
+	args.Params = url.Values{"module": {"default"}, "target": {"a", "b"}}
+	sc := getPromScrapeConfigs("job", args)
+
+	populate := func(tg discovery.Target) map[string]string {
+		lset := convertLabelSet(tg)
+		applyTargetOverrides(lset, args)
+		res, _, err := scrape.PopulateLabels(labels.FromMap(toStrings(lset)), sc, false)
+		require.NoError(t, err)
+		return res.Map()
+	}
+
+	// Targets without overrides use the settings of the component.
+	res := populate(discovery.Target{"__address__": "localhost:9115"})
+	require.Equal(t, "1m", res["__scrape_interval__"])
+	require.Equal(t, "10s", res["__scrape_timeout__"])
+	require.Equal(t, "default", res["__param_module"])
+	require.Equal(t, "a", res["__param_target"])
+
+	// Overrides of targets win over the settings of the component, and a
+	// shorter interval shortens the timeout.
+	res = populate(discovery.Target{
+		"__address__":         "localhost:9115",
+		"__scrape_interval__": "5s",
+		"__param_module":      "slow",
+	})
+	require.Equal(t, "5s", res["__scrape_interval__"])
+	require.Equal(t, "5s", res["__scrape_timeout__"])
+	require.Equal(t, "slow", res["__param_module"])
+	require.Equal(t, "a", res["__param_target"])
+	for name := range res {
+		require.NotContains(t, name, paramOverridePrefix)
+	}
+
+	res = populate(discovery.Target{
+		"__address__":         "localhost:9115",
+		"__scrape_interval__": "2m",
+		"__scrape_timeout__":  "90s",
+	})
+	require.Equal(t, "2m", res["__scrape_interval__"])
+	require.Equal(t, "90s", res["__scrape_timeout__"])
+}
+
+func toStrings(lset model.LabelSet) map[string]string {
+	res := make(map[string]string, len(lset))
+	for k, v := range lset {
+		res[string(k)] = string(v)
+	}
+	return res
+}
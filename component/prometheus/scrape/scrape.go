@@ -11,6 +11,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	component_config "github.com/grafana/agent/component/common/config"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/discovery"
 	"github.com/grafana/agent/component/prometheus"
 	"github.com/grafana/agent/pkg/build"
@@ -77,10 +78,19 @@ type Arguments struct {
 	// scrape to fail.
 	LabelValueLengthLimit uint `river:"label_value_length_limit,attr,optional"`
 
+	// Relabeling rules applied to scraped samples before they're forwarded.
+	MetricRelabelRules flow_relabel.Rules `river:"metric_relabel_rules,attr,optional"`
+
 	HTTPClientConfig component_config.HTTPClientConfig `river:",squash"`
 
 	// Scrape Options
 	ExtraMetrics bool `river:"extra_metrics,attr,optional"`
+	// EnableProtobufNegotiation asks targets for the protobuf exposition
+	// format, which is required to scrape native histograms.
+	EnableProtobufNegotiation bool `river:"enable_protobuf_negotiation,attr,optional"`
+	// TrackDeliveryLatency stamps scraped samples so that write components
+	// measure their end-to-end delivery latency.
+	TrackDeliveryLatency bool `river:"track_delivery_latency,attr,optional"`
 
 	Clustering Clustering `river:"clustering,block,optional"`
 }
@@ -121,17 +131,27 @@ type Component struct {
 	scraper      *scrape.Manager
 	appendable   *prometheus.Fanout
 	targetsGauge client_prometheus.Gauge
+	distribution *discovery.DistributionTracker
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component     = (*Component)(nil)
+	_ component.HTTPComponent = (*Component)(nil)
 )
 
 // New creates a new prometheus.scrape component.
 func New(o component.Options, args Arguments) (*Component, error) {
 	flowAppendable := prometheus.NewFanout(args.ForwardTo, o.ID, o.Registerer)
+	flowAppendable.SetTracerProvider(o.Tracer)
 	scrapeOptions := &scrape.Options{
-		ExtraMetrics: args.ExtraMetrics,
+		ExtraMetrics:              args.ExtraMetrics,
+		EnableProtobufNegotiation: args.EnableProtobufNegotiation,
+		// Pass metadata to the appenders, as Flow has no way of reading it from
+		// the scrape manager.
+		EnableMetadataStorage: true,
+		// Pass the metadata and target of samples in the context of appenders,
+		// which otelcol.receiver.prometheus uses to convert metric types.
+		PassMetadataInContext: true,
 		HTTPClientOptions: []config_util.HTTPClientOption{
 			config_util.WithDialContextFunc(o.DialFunc),
 		},
@@ -145,6 +165,14 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = o.Registerer.Register(newLimitsCollector(scraper.TargetsActive))
+	if err != nil {
+		return nil, err
+	}
+	distribution, err := discovery.NewDistributionTracker(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
 
 	c := &Component{
 		opts:          o,
@@ -152,6 +180,7 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		scraper:       scraper,
 		appendable:    flowAppendable,
 		targetsGauge:  targetsGauge,
+		distribution:  distribution,
 	}
 
 	// Call to Update() to set the receivers and targets once at the start.
@@ -162,6 +191,10 @@ func New(o component.Options, args Arguments) (*Component, error) {
 	return c, nil
 }
 
+// endpointHealthInterval is how often the health of scraped endpoints is
+// checked to publish events when they go down or come back up.
+const endpointHealthInterval = 15 * time.Second
+
 // Run implements component.Component.
 func (c *Component) Run(ctx context.Context) error {
 	defer c.scraper.Stop()
@@ -176,13 +209,20 @@ func (c *Component) Run(ctx context.Context) error {
 		}
 	}()
 
+	healthTicker := time.NewTicker(endpointHealthInterval)
+	defer healthTicker.Stop()
+	healthTracker := newEndpointHealthTracker(c.opts.Events)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-healthTicker.C:
+			healthTracker.Observe(c.scraper.TargetsActive())
 		case <-c.reloadTargets:
 			c.mut.RLock()
 			var (
+				args    = c.args
 				tgs     = c.args.Targets
 				jobName = c.opts.ID
 				cl      = c.args.Clustering.Enabled
@@ -195,7 +235,9 @@ func (c *Component) Run(ctx context.Context) error {
 			// NOTE(@tpaschalis) First approach, manually building the
 			// 'clustered' targets implementation every time.
 			ct := discovery.NewDistributedTargets(cl, c.opts.Clusterer.Node, tgs)
-			promTargets := c.componentTargetsToProm(jobName, ct.Get())
+			owned := ct.Get()
+			c.distribution.Observe(tgs, owned)
+			promTargets := c.componentTargetsToProm(jobName, args, owned)
 
 			select {
 			case targetSetsChan <- promTargets:
@@ -215,6 +257,7 @@ func (c *Component) Update(args component.Arguments) error {
 	c.args = newArgs
 
 	c.appendable.UpdateChildren(newArgs.ForwardTo)
+	c.appendable.SetDeliveryTracking(newArgs.TrackDeliveryLatency)
 
 	sc := getPromScrapeConfigs(c.opts.ID, newArgs)
 	err := c.scraper.ApplyConfig(&config.Config{
@@ -238,9 +281,13 @@ func (c *Component) Update(args component.Arguments) error {
 // scrape_config.
 // As explained in the Config struct, the following fields are purposefully
 // missing out, as they're being implemented by another components.
-// - RelabelConfigs
-// - MetricsRelabelConfigs
+// - RelabelConfigs, besides the rules restoring the __param_* labels of
+// targets overriding params.
 // - ServiceDiscoveryConfigs
+//
+// MetricRelabelConfigs are set from the metric_relabel_rules argument, so that
+// rules are applied by the scrape loop itself; prometheus.relabel remains
+// available for relabeling samples after they've been scraped.
 func getPromScrapeConfigs(jobName string, c Arguments) *config.ScrapeConfig {
 	dec := config.DefaultScrapeConfig
 	if c.JobName != "" {
@@ -251,6 +298,7 @@ func getPromScrapeConfigs(jobName string, c Arguments) *config.ScrapeConfig {
 	dec.HonorLabels = c.HonorLabels
 	dec.HonorTimestamps = c.HonorTimestamps
 	dec.Params = c.Params
+	dec.RelabelConfigs = paramOverrideRules(c.Params)
 	dec.ScrapeInterval = model.Duration(c.ScrapeInterval)
 	dec.ScrapeTimeout = model.Duration(c.ScrapeTimeout)
 	dec.MetricsPath = c.MetricsPath
@@ -261,6 +309,9 @@ func getPromScrapeConfigs(jobName string, c Arguments) *config.ScrapeConfig {
 	dec.LabelLimit = c.LabelLimit
 	dec.LabelNameLengthLimit = c.LabelNameLengthLimit
 	dec.LabelValueLengthLimit = c.LabelValueLengthLimit
+	if len(c.MetricRelabelRules) > 0 {
+		dec.MetricRelabelConfigs = flow_relabel.ComponentToPromRelabelConfigs(c.MetricRelabelRules)
+	}
 
 	// HTTP scrape client settings
 	dec.HTTPClientConfig = *c.HTTPClientConfig.Convert()
@@ -323,10 +374,12 @@ func (c *Component) ClusterUpdatesRegistration() bool {
 	return c.args.Clustering.Enabled
 }
 
-func (c *Component) componentTargetsToProm(jobName string, tgs []discovery.Target) map[string][]*targetgroup.Group {
+func (c *Component) componentTargetsToProm(jobName string, args Arguments, tgs []discovery.Target) map[string][]*targetgroup.Group {
 	promGroup := &targetgroup.Group{Source: jobName}
 	for _, tg := range tgs {
-		promGroup.Targets = append(promGroup.Targets, convertLabelSet(tg))
+		lset := convertLabelSet(tg)
+		applyTargetOverrides(lset, args)
+		promGroup.Targets = append(promGroup.Targets, lset)
 	}
 
 	return map[string][]*targetgroup.Group{jobName: {promGroup}}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/grafana/agent/component"
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/prometheus"
 	"github.com/grafana/agent/pkg/cluster"
 	"github.com/grafana/agent/pkg/river"
@@ -15,7 +17,11 @@ import (
 	"github.com/grafana/ckit/memconn"
 	prometheus_client "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/metadata"
+	"github.com/prometheus/prometheus/model/textparse"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/stretchr/testify/require"
 )
@@ -172,3 +178,245 @@ func TestCustomDialer(t *testing.T) {
 	err = scrapeTrigger.Wait(1 * time.Minute)
 	require.NoError(t, err, "custom dialer was not used")
 }
+
+// TestNativeHistograms ensures that native histograms are scraped and
+// forwarded when protobuf negotiation is enabled.
+func TestNativeHistograms(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		reg  = prometheus_client.NewRegistry()
+		hist = prometheus_client.NewHistogram(prometheus_client.HistogramOpts{
+			Name:                        "request_duration_seconds",
+			Help:                        "Test histogram.",
+			NativeHistogramBucketFactor: 1.1,
+		})
+
+		srv    = &http.Server{Handler: promhttp.HandlerFor(reg, promhttp.HandlerOpts{})}
+		memLis = memconn.NewListener(util.TestLogger(t))
+	)
+	reg.MustRegister(hist)
+	hist.Observe(0.5)
+	hist.Observe(2)
+
+	go srv.Serve(memLis)
+	defer srv.Shutdown(ctx)
+
+	var (
+		received         = make(chan *histogram.Histogram, 1)
+		receivedMetadata = make(chan metadata.Metadata, 1)
+	)
+	fanout := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, _ labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		return ref, nil
+	}), prometheus.WithMetadataHook(func(ref storage.SeriesRef, l labels.Labels, m metadata.Metadata, _ storage.Appender) (storage.SeriesRef, error) {
+		if l.Get(model.MetricNameLabel) == "request_duration_seconds" {
+			select {
+			case receivedMetadata <- m:
+			default:
+			}
+		}
+		return ref, nil
+	}), prometheus.WithAppendHistogram(func(ref storage.SeriesRef, l labels.Labels, _ int64, h *histogram.Histogram, _ *histogram.FloatHistogram, _ storage.Appender) (storage.SeriesRef, error) {
+		if l.Get(model.MetricNameLabel) == "request_duration_seconds" {
+			select {
+			case received <- h:
+			default:
+			}
+		}
+		return ref, nil
+	}))
+
+	var config = `
+	targets                     = [{ __address__ = "inmemory:80" }]
+	forward_to                  = []
+	scrape_interval             = "100ms"
+	scrape_timeout              = "85ms"
+	enable_protobuf_negotiation = true
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(config), &args))
+	args.ForwardTo = []storage.Appendable{fanout}
+
+	opts := component.Options{
+		Logger: util.TestFlowLogger(t),
+		Clusterer: &cluster.Clusterer{
+			Node: cluster.NewLocalNode("inmemory:80"),
+		},
+		Registerer: prometheus_client.NewRegistry(),
+		DialFunc: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return memLis.DialContext(ctx)
+		},
+	}
+
+	s, err := New(opts, args)
+	require.NoError(t, err)
+	go s.Run(ctx)
+
+	select {
+	case <-time.After(time.Minute):
+		require.FailNow(t, "timed out waiting for native histogram")
+	case h := <-received:
+		require.Equal(t, uint64(2), h.Count)
+		require.Equal(t, 2.5, h.Sum)
+	}
+
+	// Metadata of scraped series is forwarded too.
+	select {
+	case <-time.After(time.Minute):
+		require.FailNow(t, "timed out waiting for metadata")
+	case m := <-receivedMetadata:
+		require.Equal(t, textparse.MetricTypeHistogram, m.Type)
+	}
+}
+
+// TestLimitMetrics ensures that targets rejected for exceeding a limit are
+// reported by the component's metrics.
+func TestLimitMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		reg    = prometheus_client.NewRegistry()
+		srv    = &http.Server{Handler: promhttp.HandlerFor(reg, promhttp.HandlerOpts{})}
+		memLis = memconn.NewListener(util.TestLogger(t))
+	)
+	reg.MustRegister(
+		prometheus_client.NewCounter(prometheus_client.CounterOpts{Name: "a_total", Help: "a"}),
+		prometheus_client.NewCounter(prometheus_client.CounterOpts{Name: "b_total", Help: "b"}),
+	)
+
+	go srv.Serve(memLis)
+	defer srv.Shutdown(ctx)
+
+	var config = `
+	targets         = [{ __address__ = "inmemory:80" }]
+	forward_to      = []
+	scrape_interval = "100ms"
+	scrape_timeout  = "85ms"
+	sample_limit    = 1
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(config), &args))
+
+	componentReg := prometheus_client.NewRegistry()
+	opts := component.Options{
+		Logger: util.TestFlowLogger(t),
+		Clusterer: &cluster.Clusterer{
+			Node: cluster.NewLocalNode("inmemory:80"),
+		},
+		Registerer: componentReg,
+		DialFunc: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return memLis.DialContext(ctx)
+		},
+	}
+
+	s, err := New(opts, args)
+	require.NoError(t, err)
+	go s.Run(ctx)
+
+	exceeding := func(limit string) float64 {
+		families, err := componentReg.Gather()
+		require.NoError(t, err)
+		for _, mf := range families {
+			if mf.GetName() != "agent_prometheus_scrape_targets_exceeding_limit" {
+				continue
+			}
+			for _, m := range mf.GetMetric() {
+				if m.GetLabel()[0].GetValue() == limit {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+		return 0
+	}
+	require.Eventually(t, func() bool {
+		return exceeding("sample_limit") == 1
+	}, time.Minute, 50*time.Millisecond)
+	require.Equal(t, float64(0), exceeding("label_limit"))
+
+	status := s.DebugInfo().(ScraperStatus).TargetStatus
+	require.Len(t, status, 1)
+	require.Equal(t, "sample limit exceeded", status[0].LastError)
+}
+
+// TestMetricRelabelRules ensures that metric relabeling rules are applied by
+// the scrape loop, before sample limits are checked and samples are
+// forwarded.
+func TestMetricRelabelRules(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var (
+		reg    = prometheus_client.NewRegistry()
+		srv    = &http.Server{Handler: promhttp.HandlerFor(reg, promhttp.HandlerOpts{})}
+		memLis = memconn.NewListener(util.TestLogger(t))
+	)
+	reg.MustRegister(
+		prometheus_client.NewCounter(prometheus_client.CounterOpts{Name: "a_total", Help: "a"}),
+		prometheus_client.NewCounter(prometheus_client.CounterOpts{Name: "b_total", Help: "b"}),
+	)
+
+	go srv.Serve(memLis)
+	defer srv.Shutdown(ctx)
+
+	received := make(chan string, 10)
+	fanout := prometheus.NewInterceptor(nil, prometheus.WithAppendHook(func(ref storage.SeriesRef, l labels.Labels, _ int64, _ float64, _ storage.Appender) (storage.SeriesRef, error) {
+		if name := l.Get(model.MetricNameLabel); strings.HasSuffix(name, "_total") {
+			select {
+			case received <- name:
+			default:
+			}
+		}
+		return ref, nil
+	}), prometheus.WithMetadataHook(func(ref storage.SeriesRef, _ labels.Labels, _ metadata.Metadata, _ storage.Appender) (storage.SeriesRef, error) {
+		return ref, nil
+	}))
+
+	// Samples over the limit fail the scrape, so the scrape only succeeds if
+	// b_total is dropped first.
+	var config = `
+	targets         = [{ __address__ = "inmemory:80" }]
+	forward_to      = []
+	scrape_interval = "100ms"
+	scrape_timeout  = "85ms"
+	sample_limit    = 1
+	`
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(config), &args))
+	args.ForwardTo = []storage.Appendable{fanout}
+
+	var rule flow_relabel.Config
+	require.NoError(t, river.Unmarshal([]byte(`
+	source_labels = ["__name__"]
+	regex         = "b_total"
+	action        = "drop"
+	`), &rule))
+	args.MetricRelabelRules = flow_relabel.Rules{&rule}
+
+	opts := component.Options{
+		Logger: util.TestFlowLogger(t),
+		Clusterer: &cluster.Clusterer{
+			Node: cluster.NewLocalNode("inmemory:80"),
+		},
+		Registerer: prometheus_client.NewRegistry(),
+		DialFunc: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return memLis.DialContext(ctx)
+		},
+	}
+
+	s, err := New(opts, args)
+	require.NoError(t, err)
+	go s.Run(ctx)
+
+	select {
+	case <-time.After(time.Minute):
+		require.FailNow(t, "timed out waiting for samples")
+	case name := <-received:
+		require.Equal(t, "a_total", name)
+	}
+
+	status := s.DebugInfo().(ScraperStatus).TargetStatus
+	require.Len(t, status, 1)
+	require.Empty(t, status[0].LastError)
+}
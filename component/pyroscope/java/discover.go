@@ -0,0 +1,37 @@
+package java
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/grafana/agent/component/discovery"
+)
+
+// discoverJVMs returns a target for each JVM process found in procfsPath,
+// with the labels discovery.process would give it.
+func discoverJVMs(procfsPath string) ([]discovery.Target, error) {
+	entries, err := os.ReadDir(procfsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []discovery.Target
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		// The executable of processes of other users or which just exited
+		// can't be read.
+		exe, err := os.Readlink(filepath.Join(procfsPath, entry.Name(), "exe"))
+		if err != nil || filepath.Base(exe) != "java" {
+			continue
+		}
+		res = append(res, discovery.Target{
+			pidLabel: strconv.Itoa(pid),
+			exeLabel: exe,
+		})
+	}
+	return res, nil
+}
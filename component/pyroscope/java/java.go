@@ -0,0 +1,355 @@
+// Package java implements the pyroscope.java component, which continuously
+// profiles JVM processes with async-profiler.
+package java
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/pyroscope"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "pyroscope.java",
+		Args: Arguments{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Labels of the targets of discovery.process used by pyroscope.java.
+const (
+	pidLabel = "__process_pid__"
+	exeLabel = model.MetaLabelPrefix + "process_exe"
+)
+
+// Arguments holds values which are used to configure the pyroscope.java
+// component.
+type Arguments struct {
+	Targets    []discovery.Target     `river:"targets,attr,optional"`
+	ForwardTo  []pyroscope.Appendable `river:"forward_to,attr"`
+	AsprofPath string                 `river:"asprof_path,attr,optional"`
+	ProcFSPath string                 `river:"procfs_path,attr,optional"`
+
+	ProfilingConfig ProfilingConfig `river:"profiling_config,block,optional"`
+}
+
+// ProfilingConfig configures the profiles taken of JVM processes.
+type ProfilingConfig struct {
+	Interval      time.Duration    `river:"interval,attr,optional"`
+	CPU           bool             `river:"cpu,attr,optional"`
+	SampleRate    int              `river:"sample_rate,attr,optional"`
+	Alloc         bool             `river:"alloc,attr,optional"`
+	AllocInterval units.Base2Bytes `river:"alloc_interval,attr,optional"`
+	Lock          bool             `river:"lock,attr,optional"`
+	LockThreshold time.Duration    `river:"lock_threshold,attr,optional"`
+}
+
+// DefaultArguments holds the default arguments of pyroscope.java.
+var DefaultArguments = Arguments{
+	AsprofPath: "asprof",
+	ProcFSPath: "/proc",
+	ProfilingConfig: ProfilingConfig{
+		Interval:      60 * time.Second,
+		CPU:           true,
+		SampleRate:    100,
+		AllocInterval: 512 * units.KiB,
+		LockThreshold: 10 * time.Millisecond,
+	},
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.AsprofPath == "" {
+		return fmt.Errorf("asprof_path must not be empty")
+	}
+	if args.Targets == nil && args.ProcFSPath == "" {
+		return fmt.Errorf("procfs_path must not be empty when targets isn't set")
+	}
+
+	cfg := args.ProfilingConfig
+	events := cfg.events()
+	switch {
+	case len(events) == 0:
+		return fmt.Errorf("at least one of cpu, alloc, or lock must be enabled")
+	case cfg.Interval < time.Duration(len(events))*time.Second:
+		return fmt.Errorf("interval must be at least 1s for each enabled profile")
+	case cfg.SampleRate <= 0:
+		return fmt.Errorf("sample_rate must be greater than 0")
+	case cfg.AllocInterval <= 0:
+		return fmt.Errorf("alloc_interval must be greater than 0")
+	case cfg.LockThreshold <= 0:
+		return fmt.Errorf("lock_threshold must be greater than 0")
+	}
+	return nil
+}
+
+// Component implements the pyroscope.java component.
+type Component struct {
+	opts   component.Options
+	fanout *pyroscope.Fanout
+
+	failures *prometheus.CounterVec
+	jvms     prometheus.Gauge
+	updated  chan struct{}
+
+	mut     sync.RWMutex
+	args    Arguments
+	targets map[int]labels.Labels // The JVMs to profile, by PID.
+	running map[int]struct{}      // PIDs of the JVMs being profiled.
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new pyroscope.java component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:   o,
+		fanout: pyroscope.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_java_profile_failures_total",
+			Help: "Total number of profiles of JVM processes which couldn't be captured or sent.",
+		}, []string{"profile"}),
+		jvms: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "pyroscope_java_profiled_processes",
+			Help: "Number of JVM processes being profiled.",
+		}),
+		updated: make(chan struct{}, 1),
+
+		running: make(map[int]struct{}),
+	}
+	for _, m := range []prometheus.Collector{c.failures, c.jvms} {
+		if err := o.Registerer.Register(m); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component. A profiler is started for each JVM
+// process, which profiles the process until it's no longer a target. JVM
+// processes are looked up again after every update, and every profiling
+// interval when targets isn't set.
+func (c *Component) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		args := c.currentArgs()
+		c.refreshTargets(ctx, &wg, args)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.updated:
+		case <-time.After(args.ProfilingConfig.Interval):
+		}
+	}
+}
+
+// refreshTargets looks up the JVM processes to profile, and starts a
+// profiler for each new process. Profilers of processes which are no longer
+// targets stop at the end of their current profiling interval.
+func (c *Component) refreshTargets(ctx context.Context, wg *sync.WaitGroup, args Arguments) {
+	targets := args.Targets
+	if targets == nil {
+		var err error
+		if targets, err = discoverJVMs(args.ProcFSPath); err != nil {
+			level.Warn(c.opts.Logger).Log("msg", "failed to discover JVM processes", "err", err)
+			return
+		}
+	}
+	jvms := make(map[int]labels.Labels, len(targets))
+	for _, t := range targets {
+		pid, ok := targetPID(t)
+		if !ok {
+			level.Debug(c.opts.Logger).Log("msg", "skipping target which isn't a JVM process", "target", fmt.Sprint(t))
+			continue
+		}
+		jvms[pid] = targetLabels(t)
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.targets = jvms
+	for pid := range jvms {
+		if _, ok := c.running[pid]; ok {
+			continue
+		}
+		c.running[pid] = struct{}{}
+		wg.Add(1)
+		go func(pid int) {
+			defer wg.Done()
+			c.profile(ctx, pid)
+		}(pid)
+	}
+	c.jvms.Set(float64(len(c.running)))
+}
+
+// targetPID returns the PID of the JVM process of t. ok is false if t isn't
+// a JVM process.
+func targetPID(t discovery.Target) (pid int, ok bool) {
+	pid, err := strconv.Atoi(t[pidLabel])
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	if exe, ok := t[exeLabel]; ok && filepath.Base(exe) != "java" {
+		return 0, false
+	}
+	return pid, true
+}
+
+// targetLabels returns the labels of the profiles of a target, which are its
+// public labels. service_name defaults to "java".
+func targetLabels(t discovery.Target) labels.Labels {
+	lb := labels.NewBuilder(labels.FromStrings("service_name", "java"))
+	for name, value := range t {
+		if strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			continue
+		}
+		lb.Set(name, value)
+	}
+	return lb.Labels(nil)
+}
+
+// profile continuously profiles the JVM process with the given PID until
+// ctx is canceled or the process is no longer a target.
+func (c *Component) profile(ctx context.Context, pid int) {
+	defer func() {
+		c.mut.Lock()
+		delete(c.running, pid)
+		c.jvms.Set(float64(len(c.running)))
+		c.mut.Unlock()
+	}()
+
+	for ctx.Err() == nil {
+		args, lbls, ok := c.target(pid)
+		if !ok {
+			return
+		}
+
+		// async-profiler can only collect the profile of a single event at a
+		// time outside of JFR recordings, so the interval is shared by the
+		// enabled events.
+		events := args.ProfilingConfig.events()
+		duration := (args.ProfilingConfig.Interval / time.Duration(len(events))).Truncate(time.Second)
+		for _, e := range events {
+			p, err := c.collect(ctx, args, e, pid, duration)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				level.Warn(c.opts.Logger).Log("msg", "failed to profile JVM process", "pid", pid, "profile", e.profile, "err", err)
+				c.failures.WithLabelValues(e.profile).Inc()
+
+				// Wait for the duration of the profile before trying again, so
+				// that a missing asprof or an exited process isn't retried in a
+				// tight loop.
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(duration):
+				}
+				continue
+			}
+			c.send(ctx, e.profile, lbls, p)
+		}
+	}
+}
+
+// collect profiles the event of the JVM process for duration with
+// async-profiler, and returns the profile in the pprof format.
+func (c *Component) collect(ctx context.Context, args Arguments, e event, pid int, duration time.Duration) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args.AsprofPath, e.asprofArgs(args.ProfilingConfig, pid, duration)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	start := time.Now()
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			c.stop(args, pid)
+		}
+		return nil, fmt.Errorf("running %s: %w: %s", args.AsprofPath, err, strings.TrimSpace(stderr.String()))
+	}
+	return collapsedToPprof(&stdout, e.profileType(args.ProfilingConfig), start, duration)
+}
+
+// stop stops a profiling session interrupted by the agent shutting down, so
+// that the JVM process isn't left profiled.
+func (c *Component) stop(args Arguments, pid int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args.AsprofPath, "stop", strconv.Itoa(pid))
+	if err := cmd.Run(); err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to stop profiling JVM process", "pid", pid, "err", err)
+	}
+}
+
+func (c *Component) send(ctx context.Context, profile string, lbls labels.Labels, data []byte) {
+	lb := labels.NewBuilder(lbls)
+	lb.Set(model.MetricNameLabel, profile)
+	err := c.fanout.Appender().Append(ctx, lb.Labels(nil), []*pyroscope.RawSample{{RawProfile: data}})
+	if err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to send profile", "profile", profile, "err", err)
+		c.failures.WithLabelValues(profile).Inc()
+	}
+}
+
+// target returns the current arguments and the labels of the JVM process
+// with the given PID. ok is false if the process is no longer a target.
+func (c *Component) target(pid int) (args Arguments, lbls labels.Labels, ok bool) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	lbls, ok = c.targets[pid]
+	return c.args, lbls, ok
+}
+
+func (c *Component) currentArgs() Arguments {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.args
+}
+
+// Update implements component.Component. New arguments take effect at the
+// start of the next profiling interval of each process.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	c.args = newArgs
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+	c.mut.Unlock()
+
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package java
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect string
+	}{
+		{
+			name:   "no events",
+			config: "forward_to = []\nprofiling_config {\n\tcpu = false\n}",
+			expect: "at least one of cpu, alloc, or lock must be enabled",
+		},
+		{
+			name:   "interval too short",
+			config: "forward_to = []\nprofiling_config {\n\tinterval = \"1s\"\n\talloc = true\n}",
+			expect: "interval must be at least 1s for each enabled profile",
+		},
+		{
+			name:   "invalid sample rate",
+			config: "forward_to = []\nprofiling_config {\n\tsample_rate = 0\n}",
+			expect: "sample_rate must be greater than 0",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
+
+func TestTargetPID(t *testing.T) {
+	pid, ok := targetPID(discovery.Target{pidLabel: "42", exeLabel: "/usr/lib/jvm/bin/java"})
+	require.True(t, ok)
+	require.Equal(t, 42, pid)
+
+	_, ok = targetPID(discovery.Target{pidLabel: "42", exeLabel: "/usr/bin/python3"})
+	require.False(t, ok, "processes which aren't JVMs are skipped")
+	_, ok = targetPID(discovery.Target{"__address__": "localhost:8080"})
+	require.False(t, ok, "targets without a PID are skipped")
+}
+
+func TestDiscoverJVMs(t *testing.T) {
+	procfs := t.TempDir()
+	for pid, exe := range map[string]string{"10": "/usr/lib/jvm/bin/java", "11": "/usr/bin/bash"} {
+		require.NoError(t, os.Mkdir(filepath.Join(procfs, pid), 0755))
+		require.NoError(t, os.Symlink(exe, filepath.Join(procfs, pid, "exe")))
+	}
+	require.NoError(t, os.Mkdir(filepath.Join(procfs, "self"), 0755))
+
+	targets, err := discoverJVMs(procfs)
+	require.NoError(t, err)
+	require.Equal(t, []discovery.Target{{pidLabel: "10", exeLabel: "/usr/lib/jvm/bin/java"}}, targets)
+}
+
+func TestCollapsedToPprof(t *testing.T) {
+	collapsed := "java/lang/Thread.run;com/example/Main.work;com/example/Main.hash 30000000\n" +
+		"java/lang/Thread.run;com/example/Main.work 10000000\n"
+	start := time.Now()
+
+	data, err := collapsedToPprof(strings.NewReader(collapsed), eventCPU.profileType(DefaultArguments.ProfilingConfig), start, 15*time.Second)
+	require.NoError(t, err)
+	p, err := profile.ParseData(data)
+	require.NoError(t, err)
+
+	require.Equal(t, "cpu", p.SampleType[0].Type)
+	require.Equal(t, int64(10000000), p.Period)
+	require.Equal(t, (15 * time.Second).Nanoseconds(), p.DurationNanos)
+	require.Len(t, p.Sample, 2)
+	require.Len(t, p.Function, 3, "functions are shared by stacks")
+	require.Equal(t, []int64{30000000}, p.Sample[0].Value)
+	require.Equal(t, "com/example/Main.hash", p.Sample[0].Location[0].Line[0].Function.Name, "locations start from the leaf")
+
+	_, err = collapsedToPprof(strings.NewReader("no-value\n"), eventCPU.profileType(DefaultArguments.ProfilingConfig), start, time.Second)
+	require.ErrorContains(t, err, "invalid collapsed stack")
+}
+
+func TestCollect(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake asprof is a shell script")
+	}
+
+	// The fake asprof records its arguments and prints a collapsed profile.
+	dir := t.TempDir()
+	asprof := filepath.Join(dir, "asprof")
+	script := "#!/bin/sh\necho \"$@\" > " + filepath.Join(dir, "args") + "\necho 'java/lang/Thread.run;com/example/Main.alloc 2048'\n"
+	require.NoError(t, os.WriteFile(asprof, []byte(script), 0755))
+
+	args := DefaultArguments
+	args.AsprofPath = asprof
+	c, err := New(component.Options{
+		ID:            "pyroscope.java.test",
+		Logger:        util.TestFlowLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(component.Exports) {},
+	}, args)
+	require.NoError(t, err)
+
+	data, err := c.collect(context.Background(), args, eventAlloc, 42, 30*time.Second)
+	require.NoError(t, err)
+	p, err := profile.ParseData(data)
+	require.NoError(t, err)
+	require.Equal(t, "alloc_space", p.SampleType[0].Type)
+	require.Equal(t, []int64{2048}, p.Sample[0].Value)
+
+	recorded, err := os.ReadFile(filepath.Join(dir, "args"))
+	require.NoError(t, err)
+	require.Equal(t, "-e alloc -i 524288 -d 30 -o collapsed --total 42", string(bytes.TrimSpace(recorded)))
+}
@@ -0,0 +1,157 @@
+package java
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// event is an async-profiler event profiled by pyroscope.java.
+type event struct {
+	name    string // Name of the async-profiler event.
+	profile string // Name of the profile, matching the profile types of pyroscope.scrape.
+}
+
+var (
+	eventCPU   = event{name: "cpu", profile: "process_cpu"}
+	eventAlloc = event{name: "alloc", profile: "memory"}
+	eventLock  = event{name: "lock", profile: "mutex"}
+)
+
+// events returns the enabled events.
+func (cfg ProfilingConfig) events() []event {
+	var res []event
+	if cfg.CPU {
+		res = append(res, eventCPU)
+	}
+	if cfg.Alloc {
+		res = append(res, eventAlloc)
+	}
+	if cfg.Lock {
+		res = append(res, eventLock)
+	}
+	return res
+}
+
+// asprofArgs returns the arguments of asprof to profile the event of the
+// process for duration. Profiles are written to stdout in the collapsed
+// format, with the total of the counter of each stack: CPU time and lock wait
+// time in nanoseconds, and allocated bytes.
+func (e event) asprofArgs(cfg ProfilingConfig, pid int, duration time.Duration) []string {
+	return []string{
+		"-e", e.name,
+		"-i", strconv.FormatInt(e.interval(cfg), 10),
+		"-d", strconv.Itoa(int(duration.Seconds())),
+		"-o", "collapsed",
+		"--total",
+		strconv.Itoa(pid),
+	}
+}
+
+// interval returns the sampling interval of the event in the unit of its
+// counter.
+func (e event) interval(cfg ProfilingConfig) int64 {
+	switch e {
+	case eventAlloc:
+		return int64(cfg.AllocInterval)
+	case eventLock:
+		return cfg.LockThreshold.Nanoseconds()
+	default:
+		return (time.Second / time.Duration(cfg.SampleRate)).Nanoseconds()
+	}
+}
+
+// profileType describes the samples of the profile of an event.
+type profileType struct {
+	sampleType, periodType *profile.ValueType
+	period                 int64
+}
+
+func (e event) profileType(cfg ProfilingConfig) profileType {
+	switch e {
+	case eventAlloc:
+		return profileType{
+			sampleType: &profile.ValueType{Type: "alloc_space", Unit: "bytes"},
+			periodType: &profile.ValueType{Type: "space", Unit: "bytes"},
+			period:     e.interval(cfg),
+		}
+	case eventLock:
+		return profileType{
+			sampleType: &profile.ValueType{Type: "delay", Unit: "nanoseconds"},
+			periodType: &profile.ValueType{Type: "contentions", Unit: "count"},
+			period:     1,
+		}
+	default:
+		return profileType{
+			sampleType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+			periodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+			period:     e.interval(cfg),
+		}
+	}
+}
+
+// collapsedToPprof converts a profile in the collapsed format of
+// async-profiler to the pprof format. Each line of a collapsed profile is a
+// stack of frames separated by semicolons, starting from the root, followed
+// by a space and the value of the stack.
+func collapsedToPprof(r io.Reader, pt profileType, start time.Time, duration time.Duration) ([]byte, error) {
+	p := &profile.Profile{
+		SampleType:    []*profile.ValueType{pt.sampleType},
+		PeriodType:    pt.periodType,
+		Period:        pt.period,
+		TimeNanos:     start.UnixNano(),
+		DurationNanos: duration.Nanoseconds(),
+	}
+	locations := make(map[string]*profile.Location)
+
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		sep := strings.LastIndexByte(line, ' ')
+		if sep < 0 {
+			return nil, fmt.Errorf("invalid collapsed stack %q", line)
+		}
+		value, err := strconv.ParseInt(line[sep+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of collapsed stack %q: %w", line, err)
+		}
+
+		frames := strings.Split(line[:sep], ";")
+		sample := &profile.Sample{
+			Value:    []int64{value},
+			Location: make([]*profile.Location, 0, len(frames)),
+		}
+		// pprof locations start from the leaf.
+		for i := len(frames) - 1; i >= 0; i-- {
+			loc, ok := locations[frames[i]]
+			if !ok {
+				fn := &profile.Function{ID: uint64(len(p.Function) + 1), Name: frames[i]}
+				p.Function = append(p.Function, fn)
+				loc = &profile.Location{ID: uint64(len(p.Location) + 1), Line: []profile.Line{{Function: fn}}}
+				p.Location = append(p.Location, loc)
+				locations[frames[i]] = loc
+			}
+			sample.Location = append(sample.Location, loc)
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
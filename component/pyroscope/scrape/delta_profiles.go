@@ -38,7 +38,9 @@ type DeltaProfiler interface {
 
 func NewDeltaAppender(appender pyroscope.Appender, labels labels.Labels) pyroscope.Appender {
 	types, ok := deltaProfiles[labels.Get(model.MetricNameLabel)]
-	if !ok {
+	// Profiles labeled as not needing a delta, such as godeltaprof profiles,
+	// are already deltas.
+	if !ok || labels.Get(LabelNameDelta) == "false" {
 		// for profiles that we don't need to produce delta, just return the appender
 		return appender
 	}
@@ -66,6 +66,26 @@ func TestDeltaProfilerAppenderNoop(t *testing.T) {
 	require.Equal(t, in, unmarshal(t, actual[0].RawProfile))
 }
 
+func TestDeltaProfilerAppenderGoDeltaProf(t *testing.T) {
+	// Profiles of godeltaprof are reported as memory profiles, but are
+	// already deltas.
+	lbs := labels.Labels{
+		{Name: LabelNameDelta, Value: "false"},
+		{Name: model.MetricNameLabel, Value: pprofMemory},
+	}
+	actual := []*pyroscope.RawSample{}
+	appender := NewDeltaAppender(
+		pyroscope.AppendableFunc(func(ctx context.Context, lbs labels.Labels, samples []*pyroscope.RawSample) error {
+			actual = append(actual, samples...)
+			return nil
+		}), lbs)
+	in := newMemoryProfile(0, 0)
+	err := appender.Append(context.Background(), lbs, []*pyroscope.RawSample{{RawProfile: marshal(t, in)}})
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	require.Equal(t, in, unmarshal(t, actual[0].RawProfile))
+}
+
 func marshal(t *testing.T, profile *googlev1.Profile) []byte {
 	t.Helper()
 	data, err := profile.MarshalVT()
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/alecthomas/units"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component/pyroscope"
 	"github.com/prometheus/common/model"
@@ -25,8 +26,22 @@ const (
 	pprofMutex      string = "mutex"
 	pprofProcessCPU string = "process_cpu"
 	pprofFgprof     string = "fgprof"
+
+	pprofGoDeltaProfMemory string = "godeltaprof_memory"
+	pprofGoDeltaProfBlock  string = "godeltaprof_block"
+	pprofGoDeltaProfMutex  string = "godeltaprof_mutex"
 )
 
+// goDeltaProfNames maps the profile types served by godeltaprof to the name
+// of the profiles they're reported as. godeltaprof profiles are already
+// deltas, so they're reported as the profiles whose deltas would otherwise be
+// computed by the component.
+var goDeltaProfNames = map[string]string{
+	pprofGoDeltaProfMemory: pprofMemory,
+	pprofGoDeltaProfBlock:  pprofBlock,
+	pprofGoDeltaProfMutex:  pprofMutex,
+}
+
 func init() {
 	component.Register(component.Registration{
 		Name: "pyroscope.scrape",
@@ -55,10 +70,11 @@ type Arguments struct {
 	// The URL scheme with which to fetch metrics from targets.
 	Scheme string `river:"scheme,attr,optional"`
 
+	// An uncompressed response body larger than this many bytes will cause the
+	// scrape to fail. 0 means no limit.
+	BodySizeLimit units.Base2Bytes `river:"body_size_limit,attr,optional"`
+
 	// todo(ctovena): add support for limits.
-	// // An uncompressed response body larger than this many bytes will cause the
-	// // scrape to fail. 0 means no limit.
-	// BodySizeLimit units.Base2Bytes `river:"body_size_limit,attr,optional"`
 	// // More than this many targets after the target relabeling will cause the
 	// // scrapes to fail.
 	// TargetLimit uint `river:"target_limit,attr,optional"`
@@ -88,6 +104,10 @@ type ProfilingConfig struct {
 	FGProf     ProfilingTarget         `river:"profile.fgprof,block,optional"`
 	Custom     []CustomProfilingTarget `river:"profile.custom,block,optional"`
 
+	GoDeltaProfMemory ProfilingTarget `river:"profile.godeltaprof_memory,block,optional"`
+	GoDeltaProfBlock  ProfilingTarget `river:"profile.godeltaprof_block,block,optional"`
+	GoDeltaProfMutex  ProfilingTarget `river:"profile.godeltaprof_mutex,block,optional"`
+
 	PprofPrefix string `river:"path_prefix,attr,optional"`
 }
 
@@ -102,6 +122,10 @@ func (cfg ProfilingConfig) AllTargets() map[string]ProfilingTarget {
 		pprofMutex:      cfg.Mutex,
 		pprofProcessCPU: cfg.ProcessCPU,
 		pprofFgprof:     cfg.FGProf,
+
+		pprofGoDeltaProfMemory: cfg.GoDeltaProfMemory,
+		pprofGoDeltaProfBlock:  cfg.GoDeltaProfBlock,
+		pprofGoDeltaProfMutex:  cfg.GoDeltaProfMutex,
 	}
 
 	for _, custom := range cfg.Custom {
@@ -142,6 +166,18 @@ var DefaultProfilingConfig = ProfilingConfig{
 		Path:    "/debug/fgprof",
 		Delta:   true,
 	},
+	GoDeltaProfMemory: ProfilingTarget{
+		Enabled: false,
+		Path:    "/debug/pprof/delta_heap",
+	},
+	GoDeltaProfBlock: ProfilingTarget{
+		Enabled: false,
+		Path:    "/debug/pprof/delta_block",
+	},
+	GoDeltaProfMutex: ProfilingTarget{
+		Enabled: false,
+		Path:    "/debug/pprof/delta_mutex",
+	},
 }
 
 // SetToDefault implements river.Defaulter.
@@ -188,6 +224,9 @@ func (arg *Arguments) Validate() error {
 	if arg.ScrapeTimeout <= arg.ScrapeInterval {
 		return fmt.Errorf("scrape_timeout must be greater than scrape_interval")
 	}
+	if arg.BodySizeLimit < 0 {
+		return fmt.Errorf("body_size_limit must not be negative")
+	}
 
 	if cfg, ok := arg.ProfilingConfig.ProcessCPU, true; ok {
 		if cfg.Enabled && arg.ScrapeTimeout < time.Second*2 {
@@ -209,6 +248,8 @@ type Component struct {
 	args       Arguments
 	scraper    *Manager
 	appendable *pyroscope.Fanout
+
+	distribution *discovery.DistributionTracker
 }
 
 var _ component.Component = (*Component)(nil)
@@ -217,11 +258,16 @@ var _ component.Component = (*Component)(nil)
 func New(o component.Options, args Arguments) (*Component, error) {
 	flowAppendable := pyroscope.NewFanout(args.ForwardTo, o.ID, o.Registerer)
 	scraper := NewManager(flowAppendable, o.Logger)
+	distribution, err := discovery.NewDistributionTracker(o.Registerer)
+	if err != nil {
+		return nil, err
+	}
 	c := &Component{
 		opts:          o,
 		reloadTargets: make(chan struct{}, 1),
 		scraper:       scraper,
 		appendable:    flowAppendable,
+		distribution:  distribution,
 	}
 
 	// Call to Update() to set the receivers and targets once at the start.
@@ -262,7 +308,9 @@ func (c *Component) Run(ctx context.Context) error {
 			// NOTE(@tpaschalis) First approach, manually building the
 			// 'clustered' targets implementation every time.
 			ct := discovery.NewDistributedTargets(clustering, c.opts.Clusterer.Node, tgs)
-			promTargets := c.componentTargetsToProm(jobName, ct.Get())
+			owned := ct.Get()
+			c.distribution.Observe(tgs, owned)
+			promTargets := c.componentTargetsToProm(jobName, owned)
 
 			select {
 			case targetSetsChan <- promTargets:
@@ -3,6 +3,7 @@ package scrape
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -24,6 +25,8 @@ import (
 var (
 	payloadBuffers  = pool.New(1e3, 1e6, 3, func(sz int) interface{} { return make([]byte, 0, sz) })
 	userAgentHeader = fmt.Sprintf("GrafanaAgent/%s", build.Version)
+
+	errBodySizeLimit = errors.New("body size limit exceeded")
 )
 
 type scrapePool struct {
@@ -76,7 +79,7 @@ func (tg *scrapePool) sync(groups []*targetgroup.Group) {
 
 	for _, t := range actives {
 		if _, ok := tg.activeTargets[t.hash()]; !ok {
-			loop := newScrapeLoop(t, tg.scrapeClient, tg.appendable, tg.config.ScrapeInterval, tg.config.ScrapeTimeout, tg.logger)
+			loop := newScrapeLoop(t, tg.scrapeClient, tg.appendable, tg.config.ScrapeInterval, tg.config.ScrapeTimeout, int64(tg.config.BodySizeLimit), tg.logger)
 			tg.activeTargets[t.hash()] = loop
 			loop.start()
 		} else {
@@ -103,6 +106,7 @@ func (tg *scrapePool) reload(cfg Arguments) error {
 
 	if tg.config.ScrapeInterval == cfg.ScrapeInterval &&
 		tg.config.ScrapeTimeout == cfg.ScrapeTimeout &&
+		tg.config.BodySizeLimit == cfg.BodySizeLimit &&
 		reflect.DeepEqual(tg.config.HTTPClientConfig, cfg.HTTPClientConfig) {
 
 		tg.config = cfg
@@ -118,7 +122,7 @@ func (tg *scrapePool) reload(cfg Arguments) error {
 	for hash, t := range tg.activeTargets {
 		// restart the loop with the new configuration
 		t.stop(false)
-		loop := newScrapeLoop(t.Target, tg.scrapeClient, tg.appendable, tg.config.ScrapeInterval, tg.config.ScrapeTimeout, tg.logger)
+		loop := newScrapeLoop(t.Target, tg.scrapeClient, tg.appendable, tg.config.ScrapeInterval, tg.config.ScrapeTimeout, int64(tg.config.BodySizeLimit), tg.logger)
 		tg.activeTargets[hash] = loop
 		loop.start()
 	}
@@ -169,19 +173,21 @@ type scrapeLoop struct {
 	req               *http.Request
 	logger            log.Logger
 	interval, timeout time.Duration
+	bodySizeLimit     int64 // 0 means no limit.
 	graceShut         chan struct{}
 	once              sync.Once
 	wg                sync.WaitGroup
 }
 
-func newScrapeLoop(t *Target, scrapeClient *http.Client, appendable pyroscope.Appendable, interval, timeout time.Duration, logger log.Logger) *scrapeLoop {
+func newScrapeLoop(t *Target, scrapeClient *http.Client, appendable pyroscope.Appendable, interval, timeout time.Duration, bodySizeLimit int64, logger log.Logger) *scrapeLoop {
 	return &scrapeLoop{
-		Target:       t,
-		logger:       logger,
-		scrapeClient: scrapeClient,
-		appender:     NewDeltaAppender(appendable.Appender(), t.labels),
-		interval:     interval,
-		timeout:      timeout,
+		Target:        t,
+		logger:        logger,
+		scrapeClient:  scrapeClient,
+		appender:      NewDeltaAppender(appendable.Appender(), t.labels),
+		interval:      interval,
+		timeout:       timeout,
+		bodySizeLimit: bodySizeLimit,
 	}
 }
 
@@ -278,7 +284,12 @@ func (t *scrapeLoop) fetchProfile(ctx context.Context, profileType string, buf i
 	}
 	defer resp.Body.Close()
 
-	b, err := io.ReadAll(io.TeeReader(resp.Body, buf))
+	// Read one more byte than the limit to know whether the body exceeds it.
+	body := io.Reader(resp.Body)
+	if t.bodySizeLimit > 0 {
+		body = io.LimitReader(resp.Body, t.bodySizeLimit+1)
+	}
+	b, err := io.ReadAll(io.TeeReader(body, buf))
 	if err != nil {
 		return fmt.Errorf("failed to read body: %w", err)
 	}
@@ -290,6 +301,9 @@ func (t *scrapeLoop) fetchProfile(ctx context.Context, profileType string, buf i
 		return fmt.Errorf("server returned HTTP status (%d) %v", resp.StatusCode, resp.Status)
 	}
 
+	if t.bodySizeLimit > 0 && int64(len(b)) > t.bodySizeLimit {
+		return fmt.Errorf("%w: %s profile from %s is larger than %d bytes", errBodySizeLimit, profileType, t.req.URL.String(), t.bodySizeLimit)
+	}
 	if len(b) == 0 {
 		return fmt.Errorf("empty %s profile from %s", profileType, t.req.URL.String())
 	}
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"bytes"
 	"context"
 	"net/http"
 	"net/http/httptest"
@@ -185,7 +186,7 @@ func TestScrapeLoop(t *testing.T) {
 			require.Equal(t, []byte("ok"), samples[0].RawProfile)
 			return nil
 		}),
-		200*time.Millisecond, 30*time.Second, util.TestLogger(t))
+		200*time.Millisecond, 30*time.Second, 0, util.TestLogger(t))
 	defer loop.stop(true)
 
 	require.Equal(t, HealthUnknown, loop.Health())
@@ -202,3 +203,32 @@ func TestScrapeLoop(t *testing.T) {
 	require.WithinDuration(t, time.Now(), loop.LastScrape(), 1*time.Second)
 	require.NotEmpty(t, loop.LastScrapeDuration())
 }
+
+func TestScrapeLoop_BodySizeLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("profile"))
+	}))
+	defer server.Close()
+
+	newLoop := func(limit int64) *scrapeLoop {
+		return newScrapeLoop(
+			NewTarget(
+				labels.FromStrings(
+					model.SchemeLabel, "http",
+					model.AddressLabel, strings.TrimPrefix(server.URL, "http://"),
+					ProfilePath, "/debug/pprof/goroutine",
+				), labels.FromStrings(), url.Values{}),
+			server.Client(),
+			pyroscope.AppendableFunc(func(_ context.Context, labels labels.Labels, samples []*pyroscope.RawSample) error {
+				return nil
+			}),
+			time.Second, 30*time.Second, limit, util.TestLogger(t))
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, newLoop(int64(len("profile"))).fetchProfile(context.Background(), pprofGoroutine, &buf))
+	require.Equal(t, "profile", buf.String())
+
+	err := newLoop(int64(len("profile"))-1).fetchProfile(context.Background(), pprofGoroutine, &bytes.Buffer{})
+	require.ErrorIs(t, err, errBodySizeLimit)
+}
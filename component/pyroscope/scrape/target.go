@@ -204,21 +204,57 @@ func (t *Target) Health() TargetHealth {
 
 // LabelsByProfiles returns the labels for a given ProfilingConfig.
 func LabelsByProfiles(lset labels.Labels, c *ProfilingConfig) []labels.Labels {
-	res := []labels.Labels{}
-	add := func(profileType string, cfgs ...ProfilingTarget) {
-		for _, p := range cfgs {
-			if p.Enabled {
-				l := lset.Copy()
-				l = append(l, labels.Label{Name: ProfilePath, Value: p.Path}, labels.Label{Name: ProfileName, Value: profileType})
-				res = append(res, l)
-			}
-		}
+	profiles := targetProfiles(lset, c)
+	res := make([]labels.Labels, 0, len(profiles))
+	for _, p := range profiles {
+		res = append(res, p.labels)
 	}
+	return res
+}
+
+// targetProfile is a profile type enabled for a target.
+type targetProfile struct {
+	profileType string
+	config      ProfilingTarget
+	labels      labels.Labels
+}
 
-	for profilingType, profilingConfig := range c.AllTargets() {
-		add(profilingType, profilingConfig)
+// targetProfiles returns the profile types enabled for the target with the
+// given labels. A profile type is enabled for a target when it's enabled in
+// c, unless the target has a ProfileEnabledLabelPrefix label for the profile
+// type overriding it.
+func targetProfiles(lset labels.Labels, c *ProfilingConfig) []targetProfile {
+	// The labels overriding the enabled profile types are dropped from the
+	// labels of the profiles.
+	lb := labels.NewBuilder(lset)
+	for _, l := range lset {
+		if strings.HasPrefix(l.Name, ProfileEnabledLabelPrefix) && strings.HasSuffix(l.Name, "__") {
+			lb.Del(l.Name)
+		}
 	}
+	base := lb.Labels(nil)
 
+	var res []targetProfile
+	for profileType, cfg := range c.AllTargets() {
+		enabled := cfg.Enabled
+		if v := lset.Get(ProfileEnabledLabelPrefix + profileType + "__"); v != "" {
+			enabled = v == "true"
+		}
+		if !enabled {
+			continue
+		}
+
+		l := base.Copy()
+		name := profileType
+		if deltaName, ok := goDeltaProfNames[profileType]; ok {
+			// godeltaprof profiles are already deltas, so the delta of their
+			// samples mustn't be computed again.
+			name = deltaName
+			l = append(l, labels.Label{Name: LabelNameDelta, Value: "false"})
+		}
+		l = append(l, labels.Label{Name: ProfilePath, Value: cfg.Path}, labels.Label{Name: ProfileName, Value: name})
+		res = append(res, targetProfile{profileType: profileType, config: cfg, labels: labels.New(l...)})
+	}
 	return res
 }
 
@@ -233,6 +269,13 @@ const (
 	ProfilePath      = "__profile_path__"
 	ProfileName      = "__name__"
 	ProfileTraceType = "trace"
+
+	// ProfileEnabledLabelPrefix is the prefix of the labels of targets
+	// enabling or disabling a profile type for the target, by being set to
+	// "true" or "false". The label of a profile type is the prefix followed by
+	// the profile type and two underscores, such as
+	// __pyroscope_profile_enabled_godeltaprof_memory__.
+	ProfileEnabledLabelPrefix = "__pyroscope_profile_enabled_"
 )
 
 // populateLabels builds a label set from the given label set and scrape configuration.
@@ -352,15 +395,10 @@ func targetsFromGroup(group *targetgroup.Group, cfg Arguments) ([]*Target, []*Ta
 		}
 
 		lset := labels.New(lbls...)
-		lsets := LabelsByProfiles(lset, &cfg.ProfilingConfig)
+		profiles := targetProfiles(lset, &cfg.ProfilingConfig)
 
-		for _, lset := range lsets {
-			var profType string
-			for _, label := range lset {
-				if label.Name == ProfileName {
-					profType = label.Value
-				}
-			}
+		for _, profile := range profiles {
+			lset := profile.labels
 			lbls, origLabels, err := populateLabels(lset, cfg)
 			if err != nil {
 				return nil, nil, fmt.Errorf("instance %d in group %s: %s", i, group, err)
@@ -390,7 +428,7 @@ func targetsFromGroup(group *targetgroup.Group, cfg Arguments) ([]*Target, []*Ta
 					params = url.Values{}
 				}
 
-				if pcfg, found := cfg.ProfilingConfig.AllTargets()[profType]; found && pcfg.Delta {
+				if profile.config.Delta {
 					params.Add("seconds", strconv.Itoa(int((cfg.ScrapeInterval)/time.Second)-1))
 				}
 				targets = append(targets, NewTarget(lbls, origLabels, params))
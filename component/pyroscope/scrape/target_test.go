@@ -65,3 +65,38 @@ func Test_targetsFromGroup(t *testing.T) {
 	require.Equal(t, expected, active)
 	require.Empty(t, dropped)
 }
+
+func Test_targetsFromGroup_profileOverrides(t *testing.T) {
+	args := NewDefaultArguments()
+	args.ProfilingConfig = ProfilingConfig{
+		Memory:            ProfilingTarget{Enabled: true, Path: "/debug/pprof/allocs"},
+		GoDeltaProfMemory: ProfilingTarget{Enabled: false, Path: "/debug/pprof/delta_heap"},
+	}
+
+	// The delta-capable target switches from the memory profile to the
+	// godeltaprof one, while the other target keeps the configured profiles.
+	active, _, err := targetsFromGroup(&targetgroup.Group{
+		Targets: []model.LabelSet{
+			{
+				model.AddressLabel:                                 "delta:6060",
+				ProfileEnabledLabelPrefix + "memory__":             "false",
+				ProfileEnabledLabelPrefix + "godeltaprof_memory__": "true",
+			},
+			{model.AddressLabel: "legacy:6060"},
+		},
+	}, args)
+	require.NoError(t, err)
+	sort.Sort(Targets(active))
+
+	require.Len(t, active, 2)
+	require.Equal(t, "http://delta:6060/debug/pprof/delta_heap", active[0].URL().String())
+	require.Equal(t, labels.FromMap(map[string]string{
+		model.AddressLabel:    "delta:6060",
+		model.MetricNameLabel: pprofMemory,
+		ProfilePath:           "/debug/pprof/delta_heap",
+		LabelNameDelta:        "false",
+		model.SchemeLabel:     "http",
+		"instance":            "delta:6060",
+	}), active[0].labels)
+	require.Equal(t, "http://legacy:6060/debug/pprof/allocs", active[1].URL().String())
+}
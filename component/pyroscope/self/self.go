@@ -0,0 +1,244 @@
+// Package self implements the pyroscope.self component, which continuously
+// profiles the agent itself.
+package self
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/pyroscope"
+	"github.com/grafana/agent/component/pyroscope/scrape"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name: "pyroscope.self",
+		Args: Arguments{},
+
+		// Only one CPU profile can be taken at a time in a process.
+		Singleton: true,
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Names of the profiles of the agent, matching the profile types of
+// pyroscope.scrape.
+const (
+	profileCPU       = "process_cpu"
+	profileMemory    = "memory"
+	profileGoroutine = "goroutine"
+)
+
+// serviceName is the service_name label of the profiles of the agent.
+const serviceName = "grafana-agent"
+
+// Arguments holds values which are used to configure the pyroscope.self
+// component.
+type Arguments struct {
+	ForwardTo []pyroscope.Appendable `river:"forward_to,attr"`
+
+	Interval  time.Duration     `river:"profile_interval,attr,optional"`
+	CPU       bool              `river:"cpu,attr,optional"`
+	Memory    bool              `river:"memory,attr,optional"`
+	Goroutine bool              `river:"goroutine,attr,optional"`
+	Labels    map[string]string `river:"labels,attr,optional"`
+}
+
+// DefaultArguments holds the default arguments of pyroscope.self.
+var DefaultArguments = Arguments{
+	Interval:  15 * time.Second,
+	CPU:       true,
+	Memory:    true,
+	Goroutine: true,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *Arguments) SetToDefault() {
+	*args = DefaultArguments
+}
+
+// Validate implements river.Validator.
+func (args *Arguments) Validate() error {
+	if args.Interval < time.Second {
+		return fmt.Errorf("profile_interval must be at least 1s")
+	}
+	if !args.CPU && !args.Memory && !args.Goroutine {
+		return fmt.Errorf("at least one of cpu, memory, or goroutine must be enabled")
+	}
+	for name := range args.Labels {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, model.ReservedLabelPrefix) {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+	return nil
+}
+
+// Component implements the pyroscope.self component.
+type Component struct {
+	opts   component.Options
+	fanout *pyroscope.Fanout
+
+	// memory computes the deltas of the cumulative memory profiles of the
+	// process.
+	memory pyroscope.Appender
+
+	failures *prometheus.CounterVec
+
+	mut  sync.RWMutex
+	args Arguments
+}
+
+var _ component.Component = (*Component)(nil)
+
+// New creates a new pyroscope.self component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	c := &Component{
+		opts:   o,
+		fanout: pyroscope.NewFanout(args.ForwardTo, o.ID, o.Registerer),
+
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "pyroscope_self_profile_failures_total",
+			Help: "Total number of profiles of the agent which couldn't be captured or sent.",
+		}, []string{"profile"}),
+	}
+	if err := o.Registerer.Register(c.failures); err != nil {
+		return nil, err
+	}
+
+	// The children of the fanout are looked up for each profile, so that the
+	// delta appender sends to the current ones.
+	forward := pyroscope.AppendableFunc(func(ctx context.Context, l labels.Labels, samples []*pyroscope.RawSample) error {
+		return c.fanout.Appender().Append(ctx, l, samples)
+	})
+	c.memory = scrape.NewDeltaAppender(forward, labels.FromStrings(model.MetricNameLabel, profileMemory))
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component. Each profile interval, the CPU profile
+// of the interval and snapshots of the memory and goroutine profiles are
+// sent to the receivers.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		args := c.currentArgs()
+
+		var cpu bytes.Buffer
+		cpuStarted := false
+		if args.CPU {
+			if err := pprof.StartCPUProfile(&cpu); err != nil {
+				// The CPU profile may be in use by a request to /debug/pprof.
+				level.Warn(c.opts.Logger).Log("msg", "failed to start CPU profile", "err", err)
+				c.failures.WithLabelValues(profileCPU).Inc()
+			} else {
+				cpuStarted = true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if cpuStarted {
+				pprof.StopCPUProfile()
+			}
+			return nil
+		case <-time.After(args.Interval):
+		}
+
+		if cpuStarted {
+			pprof.StopCPUProfile()
+			c.send(ctx, args, profileCPU, c.fanout.Appender(), cpu.Bytes())
+		}
+		if args.Memory {
+			c.sendLookup(ctx, args, profileMemory, "allocs", c.memory)
+		}
+		if args.Goroutine {
+			c.sendLookup(ctx, args, profileGoroutine, "goroutine", c.fanout.Appender())
+		}
+	}
+}
+
+// sendLookup sends the runtime profile with the given lookup name.
+func (c *Component) sendLookup(ctx context.Context, args Arguments, profile, lookup string, app pyroscope.Appender) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup(lookup).WriteTo(&buf, 0); err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to capture profile", "profile", profile, "err", err)
+		c.failures.WithLabelValues(profile).Inc()
+		return
+	}
+	c.send(ctx, args, profile, app, buf.Bytes())
+}
+
+func (c *Component) send(ctx context.Context, args Arguments, profile string, app pyroscope.Appender, data []byte) {
+	err := app.Append(ctx, profileLabels(args, profile), []*pyroscope.RawSample{{RawProfile: data}})
+	if err != nil {
+		level.Warn(c.opts.Logger).Log("msg", "failed to send profile", "profile", profile, "err", err)
+		c.failures.WithLabelValues(profile).Inc()
+	}
+}
+
+// profileLabels returns the labels of a profile of the agent, identifying
+// the agent with its service name, instance, and version. The labels of args
+// override the identity labels.
+func profileLabels(args Arguments, profile string) labels.Labels {
+	lb := labels.NewBuilder(labels.FromStrings(
+		model.MetricNameLabel, profile,
+		"service_name", serviceName,
+		model.InstanceLabel, defaultInstance(),
+		"version", build.Version,
+	))
+	for name, value := range args.Labels {
+		lb.Set(name, value)
+	}
+	return lb.Labels(nil)
+}
+
+// defaultInstance retrieves the hostname identifying the machine the process is
+// running on. It will return the value of $HOSTNAME, if defined, and fall
+// back to Go's os.Hostname. If that fails, it will return "unknown".
+func defaultInstance() string {
+	hostname := os.Getenv("HOSTNAME")
+	if hostname != "" {
+		return hostname
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	return hostname
+}
+
+func (c *Component) currentArgs() Arguments {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.args
+}
+
+// Update implements component.Component. New arguments take effect at the
+// start of the next profile interval.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.args = newArgs
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+	return nil
+}
@@ -0,0 +1,104 @@
+package self
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/pyroscope"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponent(t *testing.T) {
+	var (
+		mut      sync.Mutex
+		profiles = make(map[string]labels.Labels)
+	)
+	receiver := pyroscope.AppendableFunc(func(_ context.Context, l labels.Labels, samples []*pyroscope.RawSample) error {
+		require.Len(t, samples, 1)
+		require.NotEmpty(t, samples[0].RawProfile)
+
+		mut.Lock()
+		defer mut.Unlock()
+		profiles[l.Get("__name__")] = l
+		return nil
+	})
+
+	args := DefaultArguments
+	args.ForwardTo = []pyroscope.Appendable{receiver}
+	args.Interval = 100 * time.Millisecond
+	args.Labels = map[string]string{"cluster": "prod"}
+
+	c, err := New(component.Options{
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		require.NoError(t, c.Run(ctx))
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The first memory profile is only used to compute the delta of the
+	// second one.
+	require.Eventually(t, func() bool {
+		mut.Lock()
+		defer mut.Unlock()
+		return len(profiles) == 3
+	}, 5*time.Second, 50*time.Millisecond)
+
+	mut.Lock()
+	defer mut.Unlock()
+	for _, name := range []string{profileCPU, profileMemory, profileGoroutine} {
+		l, ok := profiles[name]
+		require.True(t, ok, "missing %s profile", name)
+		require.Equal(t, serviceName, l.Get("service_name"))
+		require.NotEmpty(t, l.Get("instance"))
+		require.Equal(t, "prod", l.Get("cluster"))
+	}
+}
+
+func TestArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect string
+	}{
+		{
+			name:   "short interval",
+			config: "forward_to = []\nprofile_interval = \"100ms\"",
+			expect: "profile_interval must be at least 1s",
+		},
+		{
+			name:   "no profiles",
+			config: "forward_to = []\ncpu = false\nmemory = false\ngoroutine = false",
+			expect: "at least one of cpu, memory, or goroutine must be enabled",
+		},
+		{
+			name:   "reserved label",
+			config: "forward_to = []\nlabels = {\"__name__\" = \"cpu\"}",
+			expect: `invalid label name "__name__"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args Arguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	commonconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
 	"go.uber.org/multierr"
 
 	"github.com/grafana/agent/component"
@@ -25,6 +27,9 @@ import (
 	typesv1 "github.com/grafana/phlare/api/gen/proto/go/types/v1"
 )
 
+// tenantHeader is the header holding the tenant of requests.
+const tenantHeader = "X-Scope-OrgID"
+
 var (
 	userAgent        = fmt.Sprintf("GrafanaAgent/%s", build.Version)
 	DefaultArguments = func() Arguments {
@@ -63,6 +68,8 @@ type EndpointOptions struct {
 	URL               string                   `river:"url,attr"`
 	RemoteTimeout     time.Duration            `river:"remote_timeout,attr,optional"`
 	Headers           map[string]string        `river:"headers,attr,optional"`
+	TenantID          string                   `river:"tenant_id,attr,optional"`
+	Selector          string                   `river:"selector,attr,optional"`
 	HTTPClientConfig  *config.HTTPClientConfig `river:",squash"`
 	MinBackoff        time.Duration            `river:"min_backoff_period,attr,optional"`  // start backoff at this level
 	MaxBackoff        time.Duration            `river:"max_backoff_period,attr,optional"`  // increase exponentially to this level
@@ -88,6 +95,19 @@ func (r *EndpointOptions) SetToDefault() {
 
 // Validate implements river.Validator.
 func (r *EndpointOptions) Validate() error {
+	if r.Selector != "" {
+		if _, err := parser.ParseMetricSelector(r.Selector); err != nil {
+			return fmt.Errorf("invalid selector for endpoint %s: %w", r.URL, err)
+		}
+	}
+	if r.TenantID != "" {
+		for k := range r.Headers {
+			if http.CanonicalHeaderKey(k) == http.CanonicalHeaderKey(tenantHeader) {
+				return fmt.Errorf("tenant_id and the %s header can't both be set", tenantHeader)
+			}
+		}
+	}
+
 	// We must explicitly Validate because HTTPClientConfig is squashed and it won't run otherwise
 	if r.HTTPClientConfig != nil {
 		return r.HTTPClientConfig.Validate()
@@ -148,6 +168,9 @@ func (c *Component) Update(newConfig component.Arguments) error {
 type fanOutClient struct {
 	// The list of push clients to fan out to.
 	clients []pushv1connect.PusherServiceClient
+	// The matchers of the profiles sent to each client, nil if all profiles
+	// are sent to the client.
+	matchers [][]*labels.Matcher
 
 	config  Arguments
 	opts    component.Options
@@ -156,8 +179,20 @@ type fanOutClient struct {
 
 // NewFanOut creates a new fan out client that will fan out to all endpoints.
 func NewFanOut(opts component.Options, config Arguments, metrics *metrics) (*fanOutClient, error) {
-	clients := make([]pushv1connect.PusherServiceClient, 0, len(config.Endpoints))
+	var (
+		clients  = make([]pushv1connect.PusherServiceClient, 0, len(config.Endpoints))
+		matchers = make([][]*labels.Matcher, 0, len(config.Endpoints))
+	)
 	for _, endpoint := range config.Endpoints {
+		var ms []*labels.Matcher
+		if endpoint.Selector != "" {
+			var err error
+			if ms, err = parser.ParseMetricSelector(endpoint.Selector); err != nil {
+				return nil, fmt.Errorf("invalid selector for endpoint %s: %w", endpoint.URL, err)
+			}
+		}
+		matchers = append(matchers, ms)
+
 		httpClient, err := commonconfig.NewClientFromConfig(*endpoint.HTTPClientConfig.Convert(), endpoint.Name)
 		if err != nil {
 			return nil, err
@@ -165,10 +200,11 @@ func NewFanOut(opts component.Options, config Arguments, metrics *metrics) (*fan
 		clients = append(clients, pushv1connect.NewPusherServiceClient(httpClient, endpoint.URL, WithUserAgent(userAgent)))
 	}
 	return &fanOutClient{
-		clients: clients,
-		config:  config,
-		opts:    opts,
-		metrics: metrics,
+		clients:  clients,
+		matchers: matchers,
+		config:   config,
+		opts:     opts,
+		metrics:  metrics,
 	}, nil
 }
 
@@ -177,16 +213,21 @@ func (f *fanOutClient) Push(ctx context.Context, req *connect.Request[pushv1.Pus
 	// Don't flow the context down to the `run.Group`.
 	// We want to fan out to all even in case of failures to one.
 	var (
-		g                     run.Group
-		errs                  error
-		reqSize, profileCount = requestSize(req)
+		g    run.Group
+		errs error
 	)
 
 	for i, client := range f.clients {
+		series := matchingSeries(req.Msg.Series, f.matchers[i])
+		if len(series) == 0 {
+			continue
+		}
 		var (
-			client  = client
-			i       = i
-			backoff = backoff.New(ctx, backoff.Config{
+			client                = client
+			i                     = i
+			msg                   = &pushv1.PushRequest{Series: series}
+			reqSize, profileCount = requestSize(msg)
+			backoff               = backoff.New(ctx, backoff.Config{
 				MinBackoff: f.config.Endpoints[i].MinBackoff,
 				MaxBackoff: f.config.Endpoints[i].MaxBackoff,
 				MaxRetries: f.config.Endpoints[i].MaxBackoffRetries,
@@ -194,10 +235,13 @@ func (f *fanOutClient) Push(ctx context.Context, req *connect.Request[pushv1.Pus
 			err error
 		)
 		g.Add(func() error {
-			req := connect.NewRequest(req.Msg)
+			req := connect.NewRequest(msg)
 			for k, v := range f.config.Endpoints[i].Headers {
 				req.Header().Set(k, v)
 			}
+			if tenantID := f.config.Endpoints[i].TenantID; tenantID != "" {
+				req.Header().Set(tenantHeader, tenantID)
+			}
 			for {
 				err = func() error {
 					ctx, cancel := context.WithTimeout(ctx, f.config.Endpoints[i].RemoteTimeout)
@@ -252,9 +296,39 @@ func shouldRetry(err error) bool {
 	return false
 }
 
-func requestSize(req *connect.Request[pushv1.PushRequest]) (int64, int64) {
+// matchingSeries returns the series whose labels match all matchers.
+func matchingSeries(series []*pushv1.RawProfileSeries, matchers []*labels.Matcher) []*pushv1.RawProfileSeries {
+	if len(matchers) == 0 {
+		return series
+	}
+	res := make([]*pushv1.RawProfileSeries, 0, len(series))
+	for _, s := range series {
+		if seriesMatches(s, matchers) {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+func seriesMatches(s *pushv1.RawProfileSeries, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		var value string
+		for _, l := range s.Labels {
+			if l.Name == m.Name {
+				value = l.Value
+				break
+			}
+		}
+		if !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func requestSize(req *pushv1.PushRequest) (int64, int64) {
 	var size, profiles int64
-	for _, raw := range req.Msg.Series {
+	for _, raw := range req.Series {
 		for _, sample := range raw.Samples {
 			size += int64(len(sample.RawProfile))
 			profiles++
@@ -253,3 +253,69 @@ func TestBadRiverConfig(t *testing.T) {
 	err := river.Unmarshal([]byte(exampleRiverConfig), &args)
 	require.ErrorContains(t, err, "at most one of bearer_token & bearer_token_file must be configured")
 }
+
+func Test_Write_Selector(t *testing.T) {
+	var (
+		tenantA = atomic.NewString("")
+		tenantB = atomic.NewString("")
+	)
+	newServer := func(tenant *atomic.String) *httptest.Server {
+		_, handler := pushv1connect.NewPusherServiceHandler(PushFunc(
+			func(_ context.Context, req *connect.Request[pushv1.PushRequest]) (*connect.Response[pushv1.PushResponse], error) {
+				tenant.Store(req.Header().Get("X-Scope-OrgID"))
+				return &connect.Response[pushv1.PushResponse]{}, nil
+			},
+		))
+		return httptest.NewServer(handler)
+	}
+	serverA, serverB := newServer(tenantA), newServer(tenantB)
+	defer serverA.Close()
+	defer serverB.Close()
+
+	var arg Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+	endpoint {
+		url       = "`+serverA.URL+`"
+		tenant_id = "a"
+		selector  = "{service_name=~\"team-a/.*\"}"
+	}
+	endpoint {
+		url       = "`+serverB.URL+`"
+		tenant_id = "b"
+		selector  = "{service_name!~\"team-a/.*\"}"
+	}`), &arg))
+
+	f, err := NewFanOut(component.Options{Logger: util.TestFlowLogger(t)}, arg, newMetrics(prometheus.NewRegistry()))
+	require.NoError(t, err)
+
+	err = f.Append(context.Background(), labels.FromStrings("__name__", "process_cpu", "service_name", "team-a/api"), []*pyroscope.RawSample{
+		{RawProfile: []byte("pprofraw")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "a", tenantA.Load())
+	require.Equal(t, "", tenantB.Load(), "profiles not matching the selector aren't sent")
+
+	err = f.Append(context.Background(), labels.FromStrings("__name__", "process_cpu", "service_name", "team-b/api"), []*pyroscope.RawSample{
+		{RawProfile: []byte("pprofraw")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "b", tenantB.Load())
+}
+
+func Test_Endpoint_Validate(t *testing.T) {
+	var arg Arguments
+	err := river.Unmarshal([]byte(`
+	endpoint {
+		url      = "http://localhost:4100"
+		selector = "{service_name="
+	}`), &arg)
+	require.ErrorContains(t, err, "invalid selector for endpoint http://localhost:4100")
+
+	err = river.Unmarshal([]byte(`
+	endpoint {
+		url       = "http://localhost:4100"
+		tenant_id = "a"
+		headers   = {"x-scope-orgid" = "b"}
+	}`), &arg)
+	require.ErrorContains(t, err, "tenant_id and the X-Scope-OrgID header can't both be set")
+}
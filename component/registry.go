@@ -6,10 +6,13 @@ import (
 	"net"
 	"net/http"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/events"
+	"github.com/grafana/agent/pkg/kv"
 	"github.com/grafana/regexp"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.opentelemetry.io/otel/trace"
@@ -103,6 +106,17 @@ type Options struct {
 	// controller.
 	Clusterer *cluster.Clusterer
 
+	// KV allows components to persist small amounts of state, such as cursors
+	// and checkpoints, across restarts. The bucket is named after the ID of the
+	// component. May be nil if the Flow controller has no key-value store.
+	KV *kv.Bucket
+
+	// Events allows components to publish operational events and to subscribe
+	// to the events of other components. Events are published with the ID of
+	// the component as their source. May be nil if the Flow controller has no
+	// event bus; publishing to a nil client is a no-op.
+	Events *events.Client
+
 	// HTTPListenAddr is the address the server is configured to listen on.
 	HTTPListenAddr string
 
@@ -165,6 +179,11 @@ type Registration struct {
 	// Build should construct a new component from an initial Arguments and set
 	// of options.
 	Build func(opts Options, args Arguments) (Component, error)
+
+	// Migrations lists deprecated arguments of the component. Deprecated
+	// arguments are rewritten to their replacements before the component's
+	// River block is evaluated.
+	Migrations []ArgumentMigration
 }
 
 // CloneArguments returns a new zero value of the registered Arguments type.
@@ -187,6 +206,9 @@ func Register(r Registration) {
 	if err := validatePrefixMatch(parsed, parsedNames); err != nil {
 		panic(err)
 	}
+	if err := validateMigrations(r.Migrations); err != nil {
+		panic(fmt.Sprintf("invalid migrations for component %q: %s", r.Name, err))
+	}
 
 	registered[r.Name] = r
 	parsedNames[r.Name] = parsed
@@ -238,3 +260,13 @@ func Get(name string) (Registration, bool) {
 	r, ok := registered[name]
 	return r, ok
 }
+
+// AllNames returns the sorted names of all registered components.
+func AllNames() []string {
+	names := make([]string, 0, len(registered))
+	for name := range registered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
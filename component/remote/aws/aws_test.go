@@ -0,0 +1,227 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAWS serves the responses of AWS API operations, keyed by the
+// X-Amz-Target header identifying the operation.
+type fakeAWS struct {
+	mut       sync.Mutex
+	responses map[string]interface{}
+	requests  []map[string]interface{}
+}
+
+func newFakeAWS(t *testing.T) (*fakeAWS, string) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	f := &fakeAWS{responses: make(map[string]interface{})}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+func (f *fakeAWS) set(target string, response interface{}) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.responses[target] = response
+}
+
+func (f *fakeAWS) unset(target string) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	delete(f.responses, target)
+}
+
+func (f *fakeAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	var req map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	f.requests = append(f.requests, req)
+
+	resp, ok := f.responses[r.Header.Get("X-Amz-Target")]
+	if !ok {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type exportsRecorder struct {
+	mut     sync.Mutex
+	exports []Exports
+}
+
+func (r *exportsRecorder) options(t *testing.T) component.Options {
+	return component.Options{
+		ID:         "remote.aws.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {
+			r.mut.Lock()
+			defer r.mut.Unlock()
+			r.exports = append(r.exports, e.(Exports))
+		},
+	}
+}
+
+func (r *exportsRecorder) get() []Exports {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return append([]Exports(nil), r.exports...)
+}
+
+func TestSecretsManager(t *testing.T) {
+	fake, endpoint := newFakeAWS(t)
+	fake.set("secretsmanager.GetSecretValue", map[string]interface{}{
+		"SecretString": `{"username":"agent","password":"hunter2","port":5432}`,
+		"VersionId":    "v1",
+	})
+
+	var args SecretsManagerArguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		secret_id = "db-credentials"
+
+		client {
+			endpoint = "`+endpoint+`"
+		}
+	`), &args))
+
+	var rec exportsRecorder
+	c, err := newComponent(rec.options(t), args)
+	require.NoError(t, err)
+
+	require.Equal(t, []Exports{{
+		Content: `{"username":"agent","password":"hunter2","port":5432}`,
+		Data: map[string]rivertypes.Secret{
+			"username": "agent",
+			"password": "hunter2",
+			"port":     "5432",
+		},
+	}}, rec.get())
+	require.Equal(t, "db-credentials", fake.requests[0]["SecretId"])
+	require.Equal(t, "AWSCURRENT", fake.requests[0]["VersionStage"])
+
+	// Unchanged values aren't exported again.
+	require.NoError(t, c.refresh(context.Background()))
+	require.Len(t, rec.get(), 1)
+
+	// Rotated values are exported.
+	fake.set("secretsmanager.GetSecretValue", map[string]interface{}{
+		"SecretString": "rotated",
+		"VersionId":    "v2",
+	})
+	require.NoError(t, c.refresh(context.Background()))
+	exports := rec.get()
+	require.Len(t, exports, 2)
+	require.Equal(t, rivertypes.Secret("rotated"), exports[1].Content)
+	require.Empty(t, exports[1].Data)
+
+	// Failed fetches keep the last values and mark the component unhealthy.
+	fake.unset("secretsmanager.GetSecretValue")
+	require.Error(t, c.refresh(context.Background()))
+	require.Len(t, rec.get(), 2)
+	require.Equal(t, component.HealthTypeUnhealthy, c.CurrentHealth().Health)
+}
+
+func TestSSM_Name(t *testing.T) {
+	fake, endpoint := newFakeAWS(t)
+	fake.set("AmazonSSM.GetParameter", map[string]interface{}{
+		"Parameter": map[string]interface{}{
+			"Name":    "/agent/api-key",
+			"Value":   "secret",
+			"Version": 3,
+		},
+	})
+
+	var args SSMArguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		name = "/agent/api-key"
+
+		client {
+			endpoint = "`+endpoint+`"
+		}
+	`), &args))
+
+	var rec exportsRecorder
+	c, err := newComponent(rec.options(t), args)
+	require.NoError(t, err)
+	require.Equal(t, []Exports{{Content: "secret", Data: map[string]rivertypes.Secret{}}}, rec.get())
+	require.Equal(t, true, fake.requests[0]["WithDecryption"])
+	require.Equal(t, "3", c.DebugInfo().(debugInfo).Version)
+}
+
+func TestSSM_Path(t *testing.T) {
+	fake, endpoint := newFakeAWS(t)
+	fake.set("AmazonSSM.GetParametersByPath", map[string]interface{}{
+		"Parameters": []map[string]interface{}{
+			{"Name": "/agent/username", "Value": "agent", "Version": 1},
+			{"Name": "/agent/db/password", "Value": "hunter2", "Version": 2},
+		},
+	})
+
+	var args SSMArguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		path      = "/agent"
+		recursive = true
+
+		client {
+			endpoint = "`+endpoint+`"
+		}
+	`), &args))
+
+	var rec exportsRecorder
+	c, err := newComponent(rec.options(t), args)
+	require.NoError(t, err)
+	require.Equal(t, []Exports{{
+		Data: map[string]rivertypes.Secret{
+			"username":    "agent",
+			"db/password": "hunter2",
+		},
+	}}, rec.get())
+	require.Equal(t, debugInfo{
+		Version: "db/password:2,username:1",
+		Keys:    []string{"db/password", "username"},
+	}, c.DebugInfo())
+}
+
+func TestSSMArguments_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{name: "missing name and path", config: ``, err: "one of name or path must be set"},
+		{name: "name and path", config: "name = \"a\"\npath = \"/b\"", err: "mutually exclusive"},
+		{name: "recursive name", config: "name = \"a\"\nrecursive = true", err: "recursive requires path"},
+		{name: "poll frequency", config: "name = \"a\"\npoll_frequency = \"1s\"", err: "poll_frequency must be at least"},
+		{name: "external id", config: "name = \"a\"\nclient {\nexternal_id = \"x\"\n}", err: "require role_arn"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var args SSMArguments
+			err := river.Unmarshal([]byte(tc.config), &args)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Package aws implements the remote.aws.secretsmanager and remote.aws.ssm
+// components.
+package aws
+
+import (
+	"fmt"
+	"net/http"
+
+	aws_sdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/grafana/agent/component/common/config"
+)
+
+// Client configures the AWS client used to fetch values. Credentials are
+// retrieved from the default credential chain, such as environment
+// variables, the shared credentials file, or the IAM role of the instance,
+// pod, or task, and may be used to assume another IAM role.
+type Client struct {
+	Region   string `river:"region,attr,optional"`
+	Endpoint string `river:"endpoint,attr,optional"`
+
+	RoleARN     string `river:"role_arn,attr,optional"`
+	ExternalID  string `river:"external_id,attr,optional"`
+	SessionName string `river:"session_name,attr,optional"`
+
+	Proxy config.ProxyConfig `river:",squash"`
+}
+
+// Validate implements river.Validator.
+func (c *Client) Validate() error {
+	if c.RoleARN == "" && (c.ExternalID != "" || c.SessionName != "") {
+		return fmt.Errorf("external_id and session_name require role_arn to be set")
+	}
+	return c.Proxy.Validate()
+}
+
+// config returns the session and the config of AWS service clients.
+func (c *Client) config() (*session.Session, *aws_sdk.Config, error) {
+	opts := session.Options{SharedConfigState: session.SharedConfigEnable}
+	if c.Region != "" {
+		opts.Config.Region = aws_sdk.String(c.Region)
+	}
+	// The proxy is also used to assume roles.
+	if c.Proxy.Configured() {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		c.Proxy.Apply(transport)
+		opts.Config.HTTPClient = &http.Client{Transport: transport}
+	}
+	sess, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create AWS session: %w", err)
+	}
+
+	cfg := aws_sdk.NewConfig()
+	if opts.Config.HTTPClient != nil {
+		cfg = cfg.WithHTTPClient(opts.Config.HTTPClient)
+	}
+	if c.Endpoint != "" {
+		cfg = cfg.WithEndpoint(c.Endpoint)
+	}
+	if c.RoleARN != "" {
+		cfg = cfg.WithCredentials(stscreds.NewCredentials(sess, c.RoleARN, func(p *stscreds.AssumeRoleProvider) {
+			if c.ExternalID != "" {
+				p.ExternalID = aws_sdk.String(c.ExternalID)
+			}
+			if c.SessionName != "" {
+				p.RoleSessionName = c.SessionName
+			}
+		}))
+	}
+	return sess, cfg, nil
+}
@@ -0,0 +1,207 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+// fetchTimeout is the maximum time taken to fetch values from AWS.
+var fetchTimeout = time.Minute
+
+const minimumPollFrequency = 30 * time.Second
+
+// Exports holds the values exported by the remote.aws.* components.
+type Exports struct {
+	// Content holds the value of the secret or parameter.
+	Content rivertypes.Secret `river:"content,attr"`
+	// Data holds the keys and values of a secret holding a JSON object, or
+	// the parameters under a path.
+	Data map[string]rivertypes.Secret `river:"data,attr"`
+}
+
+// fetchFunc fetches the values to export from AWS. version identifies the
+// fetched values, and changes when they're rotated.
+type fetchFunc func(ctx context.Context) (exports Exports, version string, err error)
+
+// fetcher is implemented by the arguments of the remote.aws.* components.
+type fetcher interface {
+	// fetcher returns the function fetching the values to export.
+	fetcher() (fetchFunc, error)
+	// pollFrequency returns how often values are fetched.
+	pollFrequency() time.Duration
+}
+
+// Component implements the remote.aws.* components, which export values
+// fetched from AWS and refresh them periodically.
+type Component struct {
+	opts    component.Options
+	metrics *metrics
+	updated chan struct{}
+
+	mut       sync.RWMutex
+	fetch     fetchFunc
+	frequency time.Duration
+	version   string
+	exports   Exports
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+	_ component.DebugComponent  = (*Component)(nil)
+)
+
+// newComponent creates a new remote.aws.* component. It immediately fetches
+// the values to export and returns an error if they can't be fetched.
+func newComponent(opts component.Options, args fetcher) (*Component, error) {
+	c := &Component{
+		opts:    opts,
+		metrics: newMetrics(opts.Registerer),
+		updated: make(chan struct{}, 1),
+	}
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.pollFrequency())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.updated:
+			ticker.Reset(c.pollFrequency())
+		case <-ticker.C:
+			if err := c.refresh(ctx); err != nil {
+				level.Error(c.opts.Logger).Log("msg", "failed to refresh values", "err", err)
+			}
+		}
+	}
+}
+
+func (c *Component) pollFrequency() time.Duration {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.frequency
+}
+
+// Update implements component.Component. It immediately fetches the values
+// to export and returns an error if they can't be fetched.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(fetcher)
+
+	fetch, err := newArgs.fetcher()
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	c.fetch = fetch
+	c.frequency = newArgs.pollFrequency()
+	// Always export the values fetched with the new arguments.
+	c.version = ""
+	c.mut.Unlock()
+
+	select {
+	case c.updated <- struct{}{}:
+	default:
+	}
+
+	return c.refresh(context.Background())
+}
+
+// refresh fetches the values to export, and exports them if their version
+// changed.
+func (c *Component) refresh(ctx context.Context) error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	c.metrics.readsTotal.Inc()
+	exports, version, err := c.fetch(ctx)
+	if err != nil {
+		c.metrics.readErrorsTotal.Inc()
+		c.setHealth(component.HealthTypeUnhealthy, fmt.Sprintf("failed to fetch values: %s", err))
+		return err
+	}
+	c.setHealth(component.HealthTypeHealthy, "values fetched")
+
+	if exports.Data == nil {
+		exports.Data = make(map[string]rivertypes.Secret)
+	}
+	if c.version != "" && version == c.version && reflect.DeepEqual(exports, c.exports) {
+		return nil
+	}
+
+	if c.version != "" {
+		c.metrics.rotationsTotal.Inc()
+		level.Info(c.opts.Logger).Log("msg", "values changed", "version", version)
+	}
+	c.version = version
+	c.exports = exports
+
+	// Exporting new values re-evaluates the components which use them.
+	c.opts.OnStateChange(exports)
+	return nil
+}
+
+func (c *Component) setHealth(t component.HealthType, msg string) {
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+
+	c.health = component.Health{
+		Health:     t,
+		Message:    msg,
+		UpdateTime: time.Now(),
+	}
+}
+
+// CurrentHealth implements component.HealthComponent. The component is
+// healthy as long as the latest fetch succeeded.
+func (c *Component) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
+
+// DebugInfo implements component.DebugComponent. It only exposes
+// non-sensitive information about the values.
+func (c *Component) DebugInfo() interface{} {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	keys := make([]string, 0, len(c.exports.Data))
+	for key := range c.exports.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return debugInfo{
+		Version: c.version,
+		Keys:    keys,
+	}
+}
+
+type debugInfo struct {
+	Version string   `river:"version,attr"`
+	Keys    []string `river:"keys,attr"`
+}
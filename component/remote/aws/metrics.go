@@ -0,0 +1,35 @@
+package aws
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type metrics struct {
+	readsTotal      prometheus.Counter
+	readErrorsTotal prometheus.Counter
+	rotationsTotal  prometheus.Counter
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	var m metrics
+
+	m.readsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "remote_aws_reads_total",
+		Help: "Total number of times values were read from AWS",
+	})
+	m.readErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "remote_aws_read_errors_total",
+		Help: "Total number of times values failed to be read from AWS",
+	})
+	m.rotationsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "remote_aws_rotations_total",
+		Help: "Total number of times values read from AWS changed",
+	})
+
+	if r != nil {
+		r.MustRegister(
+			m.readsTotal,
+			m.readErrorsTotal,
+			m.rotationsTotal,
+		)
+	}
+	return &m
+}
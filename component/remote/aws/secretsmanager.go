@@ -0,0 +1,109 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	aws_sdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "remote.aws.secretsmanager",
+		Args:    SecretsManagerArguments{},
+		Exports: Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return newComponent(opts, args.(SecretsManagerArguments))
+		},
+	})
+}
+
+// SecretsManagerArguments configures remote.aws.secretsmanager.
+type SecretsManagerArguments struct {
+	SecretID     string `river:"secret_id,attr"`
+	VersionStage string `river:"version_stage,attr,optional"`
+
+	PollFrequency time.Duration `river:"poll_frequency,attr,optional"`
+
+	Client Client `river:"client,block,optional"`
+}
+
+// DefaultSecretsManagerArguments holds default settings for
+// SecretsManagerArguments.
+var DefaultSecretsManagerArguments = SecretsManagerArguments{
+	VersionStage:  "AWSCURRENT",
+	PollFrequency: 10 * time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *SecretsManagerArguments) SetToDefault() {
+	*a = DefaultSecretsManagerArguments
+}
+
+// Validate implements river.Validator.
+func (a *SecretsManagerArguments) Validate() error {
+	if a.SecretID == "" {
+		return fmt.Errorf("secret_id must not be empty")
+	}
+	if a.PollFrequency < minimumPollFrequency {
+		return fmt.Errorf("poll_frequency must be at least %s", minimumPollFrequency)
+	}
+	return nil
+}
+
+func (a SecretsManagerArguments) pollFrequency() time.Duration { return a.PollFrequency }
+
+func (a SecretsManagerArguments) fetcher() (fetchFunc, error) {
+	sess, cfg, err := a.Client.config()
+	if err != nil {
+		return nil, err
+	}
+	cli := secretsmanager.New(sess, cfg)
+
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId:     aws_sdk.String(a.SecretID),
+		VersionStage: aws_sdk.String(a.VersionStage),
+	}
+	return func(ctx context.Context) (Exports, string, error) {
+		out, err := cli.GetSecretValueWithContext(ctx, input)
+		if err != nil {
+			return Exports{}, "", fmt.Errorf("could not get secret %s: %w", a.SecretID, err)
+		}
+
+		var exports Exports
+		if out.SecretString != nil {
+			exports.Content = rivertypes.Secret(*out.SecretString)
+		} else {
+			exports.Content = rivertypes.Secret(out.SecretBinary)
+		}
+		exports.Data = secretData(string(exports.Content))
+		return exports, aws_sdk.StringValue(out.VersionId), nil
+	}, nil
+}
+
+// secretData returns the keys and values of a secret holding a JSON object,
+// such as the secrets of database credentials. Values which aren't strings
+// are exported as JSON.
+func secretData(content string) map[string]rivertypes.Secret {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &object); err != nil {
+		return nil
+	}
+
+	data := make(map[string]rivertypes.Secret, len(object))
+	for key, raw := range object {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			data[key] = rivertypes.Secret(s)
+			continue
+		}
+		data[key] = rivertypes.Secret(raw)
+	}
+	return data
+}
@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	aws_sdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "remote.aws.ssm",
+		Args:    SSMArguments{},
+		Exports: Exports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return newComponent(opts, args.(SSMArguments))
+		},
+	})
+}
+
+// SSMArguments configures remote.aws.ssm.
+type SSMArguments struct {
+	Name      string `river:"name,attr,optional"`
+	Path      string `river:"path,attr,optional"`
+	Recursive bool   `river:"recursive,attr,optional"`
+
+	PollFrequency time.Duration `river:"poll_frequency,attr,optional"`
+
+	Client Client `river:"client,block,optional"`
+}
+
+// DefaultSSMArguments holds default settings for SSMArguments.
+var DefaultSSMArguments = SSMArguments{
+	PollFrequency: 10 * time.Minute,
+}
+
+// SetToDefault implements river.Defaulter.
+func (a *SSMArguments) SetToDefault() {
+	*a = DefaultSSMArguments
+}
+
+// Validate implements river.Validator.
+func (a *SSMArguments) Validate() error {
+	switch {
+	case a.Name == "" && a.Path == "":
+		return fmt.Errorf("one of name or path must be set")
+	case a.Name != "" && a.Path != "":
+		return fmt.Errorf("name and path are mutually exclusive")
+	case a.Recursive && a.Path == "":
+		return fmt.Errorf("recursive requires path to be set")
+	}
+	if a.PollFrequency < minimumPollFrequency {
+		return fmt.Errorf("poll_frequency must be at least %s", minimumPollFrequency)
+	}
+	return nil
+}
+
+func (a SSMArguments) pollFrequency() time.Duration { return a.PollFrequency }
+
+func (a SSMArguments) fetcher() (fetchFunc, error) {
+	sess, cfg, err := a.Client.config()
+	if err != nil {
+		return nil, err
+	}
+	cli := ssm.New(sess, cfg)
+
+	if a.Name != "" {
+		return a.fetchParameter(cli), nil
+	}
+	return a.fetchPath(cli), nil
+}
+
+// fetchParameter fetches a single parameter, exported as content.
+func (a SSMArguments) fetchParameter(cli *ssm.SSM) fetchFunc {
+	input := &ssm.GetParameterInput{
+		Name:           aws_sdk.String(a.Name),
+		WithDecryption: aws_sdk.Bool(true),
+	}
+	return func(ctx context.Context) (Exports, string, error) {
+		out, err := cli.GetParameterWithContext(ctx, input)
+		if err != nil {
+			return Exports{}, "", fmt.Errorf("could not get parameter %s: %w", a.Name, err)
+		}
+
+		exports := Exports{Content: rivertypes.Secret(aws_sdk.StringValue(out.Parameter.Value))}
+		return exports, fmt.Sprint(aws_sdk.Int64Value(out.Parameter.Version)), nil
+	}
+}
+
+// fetchPath fetches the parameters under a path, exported as data keyed by
+// their name relative to the path.
+func (a SSMArguments) fetchPath(cli *ssm.SSM) fetchFunc {
+	prefix := strings.TrimSuffix(a.Path, "/") + "/"
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws_sdk.String(a.Path),
+		Recursive:      aws_sdk.Bool(a.Recursive),
+		WithDecryption: aws_sdk.Bool(true),
+	}
+	return func(ctx context.Context) (Exports, string, error) {
+		var (
+			data     = make(map[string]rivertypes.Secret)
+			versions []string
+		)
+		err := cli.GetParametersByPathPagesWithContext(ctx, input, func(page *ssm.GetParametersByPathOutput, _ bool) bool {
+			for _, p := range page.Parameters {
+				name := strings.TrimPrefix(aws_sdk.StringValue(p.Name), prefix)
+				data[name] = rivertypes.Secret(aws_sdk.StringValue(p.Value))
+				versions = append(versions, fmt.Sprintf("%s:%d", name, aws_sdk.Int64Value(p.Version)))
+			}
+			return true
+		})
+		if err != nil {
+			return Exports{}, "", fmt.Errorf("could not get parameters under %s: %w", a.Path, err)
+		}
+
+		// The set of parameters and their versions identifies the values.
+		sort.Strings(versions)
+		return Exports{Data: data}, strings.Join(versions, ","), nil
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"context"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	aws_config "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/grafana/agent/component"
@@ -26,6 +27,9 @@ func init() {
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
 			return New(opts, args.(Arguments))
 		},
+		Migrations: []component.ArgumentMigration{
+			{Deprecated: "client_options", Replacement: "client", Since: "v0.32.0"},
+		},
 	})
 }
 
@@ -145,14 +149,17 @@ func generateS3Config(args Arguments) (*aws.Config, error) {
 
 	// This incredibly nested option turns off SSL.
 	if args.Options.DisableSSL {
-		httpOverride := aws_config.WithHTTPClient(
-			&http.Client{
-				Transport: &http.Transport{
-					TLSClientConfig: &tls.Config{
-						InsecureSkipVerify: args.Options.DisableSSL,
-					},
-				},
+		transport := &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: args.Options.DisableSSL,
 			},
+		}
+		args.Options.Proxy.Apply(transport)
+		httpOverride := aws_config.WithHTTPClient(&http.Client{Transport: transport})
+		configOptions = append(configOptions, httpOverride)
+	} else if args.Options.Proxy.Configured() {
+		httpOverride := aws_config.WithHTTPClient(
+			awshttp.NewBuildableClient().WithTransportOptions(args.Options.Proxy.Apply),
 		)
 		configOptions = append(configOptions, httpOverride)
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/pkg/river/rivertypes"
 )
 
@@ -28,6 +29,8 @@ type Client struct {
 	UsePathStyle  bool              `river:"use_path_style,attr,optional"`
 	Region        string            `river:"region,attr,optional"`
 	SigningRegion string            `river:"signing_region,attr,optional"`
+
+	Proxy config.ProxyConfig `river:",squash"`
 }
 
 const minimumPollFrequency = 30 * time.Second
@@ -47,7 +50,7 @@ func (a *Arguments) Validate() error {
 	if a.PollFrequency <= minimumPollFrequency {
 		return fmt.Errorf("poll_frequency must be greater than 30s")
 	}
-	return nil
+	return a.Options.Proxy.Validate()
 }
 
 // Exports implements the file content
@@ -0,0 +1,125 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// fakeDynamicSecrets serves dynamic credentials whose leases can't be
+// renewed, such as once they reached their maximum TTL.
+type fakeDynamicSecrets struct {
+	mut   sync.Mutex
+	reads int
+}
+
+func (f *fakeDynamicSecrets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	switch r.URL.Path {
+	case "/v1/database/creds/agent":
+		f.reads++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"request_id":     fmt.Sprintf("request-%d", f.reads),
+			"lease_id":       fmt.Sprintf("database/creds/agent/%d", f.reads),
+			"lease_duration": 2,
+			"renewable":      true,
+			"data": map[string]interface{}{
+				"username": fmt.Sprintf("user-%d", f.reads),
+				"password": "hunter2",
+			},
+		})
+	case "/v1/sys/leases/renew":
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":["lease is not renewable"]}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestDynamicSecret_ReadOnExpiry(t *testing.T) {
+	srv := httptest.NewServer(&fakeDynamicSecrets{})
+	defer srv.Close()
+
+	var args Arguments
+	require.NoError(t, river.Unmarshal([]byte(`
+		server = "`+srv.URL+`"
+		path   = "database/creds/agent"
+		store  = "logical"
+
+		auth.token {
+			token = "root"
+		}
+	`), &args))
+
+	var (
+		exportsMut sync.Mutex
+		usernames  []rivertypes.Secret
+	)
+	c, err := New(component.Options{
+		ID:         "remote.vault.test",
+		Logger:     util.TestFlowLogger(t),
+		Registerer: prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {
+			exportsMut.Lock()
+			defer exportsMut.Unlock()
+			usernames = append(usernames, e.(Exports).Data["username"])
+		},
+	}, args)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = c.Run(ctx) }()
+
+	// New credentials are read and exported when the lease of the current
+	// ones can't be renewed.
+	require.Eventually(t, func() bool {
+		exportsMut.Lock()
+		defer exportsMut.Unlock()
+		return len(usernames) >= 2
+	}, 10*time.Second, 10*time.Millisecond)
+
+	exportsMut.Lock()
+	require.Equal(t, []rivertypes.Secret{"user-1", "user-2"}, usernames[:2])
+	exportsMut.Unlock()
+	require.GreaterOrEqual(t, testutil.ToFloat64(c.metrics.secretLeaseExpiredTotal), 1.0)
+}
+
+func TestSecretExpireTime(t *testing.T) {
+	secret := &vault.Secret{LeaseDuration: 60}
+	expire := secretExpireTime(secret)
+	require.WithinDuration(t, time.Now().Add(time.Minute), expire, 5*time.Second)
+
+	require.True(t, secretExpireTime(&vault.Secret{}).IsZero())
+}
+
+func TestArguments_Store(t *testing.T) {
+	var args Arguments
+	err := river.Unmarshal([]byte(`
+		server = "http://localhost:8200"
+		path   = "secret/test"
+		store  = "transit"
+
+		auth.token {
+			token = "root"
+		}
+	`), &args)
+	require.ErrorContains(t, err, `store must be one of "kv" or "logical"`)
+}
@@ -8,6 +8,9 @@ type metrics struct {
 
 	authLeaseRenewalTotal   prometheus.Counter
 	secretLeaseRenewalTotal prometheus.Counter
+
+	authLeaseExpiredTotal   prometheus.Counter
+	secretLeaseExpiredTotal prometheus.Counter
 }
 
 func newMetrics(r prometheus.Registerer) *metrics {
@@ -31,6 +34,15 @@ func newMetrics(r prometheus.Registerer) *metrics {
 		Help: "Total number of times this component renewed its secret lease",
 	})
 
+	m.authLeaseExpiredTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "remote_vault_auth_lease_expired_total",
+		Help: "Total number of times this component authenticated again because its auth token lease could no longer be renewed",
+	})
+	m.secretLeaseExpiredTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "remote_vault_secret_lease_expired_total",
+		Help: "Total number of times this component read the secret again because its lease could no longer be renewed",
+	})
+
 	if r != nil {
 		r.MustRegister(
 			m.authTotal,
@@ -38,6 +50,9 @@ func newMetrics(r prometheus.Registerer) *metrics {
 
 			m.authLeaseRenewalTotal,
 			m.secretLeaseRenewalTotal,
+
+			m.authLeaseExpiredTotal,
+			m.secretLeaseExpiredTotal,
 		)
 	}
 	return &m
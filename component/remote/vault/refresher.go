@@ -9,12 +9,20 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/dskit/backoff"
 	vault "github.com/hashicorp/vault/api"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 const tokenManagerInitializeTimeout = time.Minute
 
+// expiredTokenBackoff configures retries of retrieving a new token when the
+// lease of the current token can no longer be renewed.
+var expiredTokenBackoff = backoff.Config{
+	MinBackoff: time.Second,
+	MaxBackoff: time.Minute,
+}
+
 type getTokenFunc func(ctx context.Context, client *vault.Client) (*vault.Secret, error)
 
 // A tokenManager retrieves and manages the lifecycle of tokens. tokenManager,
@@ -28,6 +36,7 @@ type tokenManager struct {
 
 	readCounter    prometheus.Counter
 	refreshCounter prometheus.Counter
+	expiryCounter  prometheus.Counter
 
 	mut   sync.RWMutex
 	cli   *vault.Client
@@ -44,7 +53,7 @@ type tokenManagerOptions struct {
 	Log    log.Logger
 	Getter getTokenFunc
 
-	ReadCounter, RefreshCounter prometheus.Counter
+	ReadCounter, RefreshCounter, ExpiryCounter prometheus.Counter
 
 	Client          *vault.Client
 	RefreshInterval time.Duration
@@ -64,6 +73,7 @@ func newTokenManager(opts tokenManagerOptions) (*tokenManager, error) {
 
 		readCounter:    opts.ReadCounter,
 		refreshCounter: opts.RefreshCounter,
+		expiryCounter:  opts.ExpiryCounter,
 
 		cli: opts.Client,
 	}
@@ -91,7 +101,10 @@ func (tm *tokenManager) updateToken(ctx context.Context) (err error) {
 			})
 		}
 
-		tm.updateDebugInfo(time.Now())
+		tm.mut.RLock()
+		token := tm.token
+		tm.mut.RUnlock()
+		tm.updateDebugInfo(token, time.Now())
 	}()
 
 	tm.mut.Lock()
@@ -154,11 +167,9 @@ func (tm *tokenManager) updateHealth(h component.Health) {
 	tm.health = h
 }
 
-func (tm *tokenManager) updateDebugInfo(updateTime time.Time) {
-	tm.mut.RLock()
-	token := tm.token
-	tm.mut.RUnlock()
-
+// updateDebugInfo updates the debug info from token, which is either the
+// current token or its latest renewal.
+func (tm *tokenManager) updateDebugInfo(token *vault.Secret, updateTime time.Time) {
 	tm.debugMut.Lock()
 	defer tm.debugMut.Unlock()
 
@@ -191,17 +202,31 @@ func (tm *tokenManager) updateLifecycleWatcher(ctx context.Context) {
 				lw.Stop()
 				return
 
-			case <-lw.DoneCh():
+			case err := <-lw.DoneCh():
 				if ctx.Err() != nil {
 					return
 				}
-				// Error is logged as health and debug info.
-				_ = tm.updateToken(ctx)
+				// The lease is about to expire, either because it reached its
+				// maximum TTL or because renewing it failed. Getting a new token
+				// exports new values, such as new dynamic credentials.
+				tm.expiryCounter.Inc()
+				level.Info(tm.log).Log("msg", "token lease can no longer be renewed, retrieving a new token", "err", err)
+
+				// Retry until a new token is retrieved, as the current one is
+				// about to expire. Errors are logged as health and debug info.
+				bo := backoff.New(ctx, expiredTokenBackoff)
+				for bo.Ongoing() {
+					if err := tm.updateToken(ctx); err == nil {
+						break
+					}
+					bo.Wait()
+				}
+				return
 
 			case output := <-lw.RenewCh():
 				tm.refreshCounter.Inc()
 				level.Debug(tm.log).Log("msg", "token has renewed")
-				tm.updateDebugInfo(output.RenewedAt)
+				tm.updateDebugInfo(output.Secret, output.RenewedAt)
 			}
 		}
 	}()
@@ -283,6 +308,12 @@ func getSecretInfo(secret *vault.Secret, updateTime time.Time) secretInfo {
 }
 
 func secretExpireTime(secret *vault.Secret) time.Time {
+	// Secrets other than tokens expire at the end of their lease, such as
+	// dynamic secrets.
+	if secret.Auth == nil && secret.LeaseDuration > 0 {
+		return time.Now().UTC().Add(time.Duration(secret.LeaseDuration) * time.Second)
+	}
+
 	ttl, err := secret.TokenTTL()
 	if err != nil || ttl == 0 {
 		return time.Time{}
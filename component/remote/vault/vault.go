@@ -3,12 +3,14 @@ package vault
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/component/common/config"
 	"github.com/grafana/agent/pkg/river/rivertypes"
 	"github.com/oklog/run"
 
@@ -32,7 +34,8 @@ type Arguments struct {
 	Server    string `river:"server,attr"`
 	Namespace string `river:"namespace,attr,optional"`
 
-	Path string `river:"path,attr"`
+	Path  string `river:"path,attr"`
+	Store string `river:"store,attr,optional"`
 
 	RereadFrequency time.Duration `river:"reread_frequency,attr,optional"`
 
@@ -48,6 +51,7 @@ type Arguments struct {
 
 // DefaultArguments holds default settings for Arguments.
 var DefaultArguments = Arguments{
+	Store: storeKV,
 	ClientOptions: ClientOptions{
 		MinRetryWait: 1000 * time.Millisecond,
 		MaxRetryWait: 1500 * time.Millisecond,
@@ -56,6 +60,12 @@ var DefaultArguments = Arguments{
 	},
 }
 
+// Stores which secrets can be read from.
+const (
+	storeKV      = "kv"
+	storeLogical = "logical"
+)
+
 // client creates a Vault client from the arguments.
 func (a *Arguments) client() (*vault.Client, error) {
 	cfg := vault.DefaultConfig()
@@ -64,6 +74,9 @@ func (a *Arguments) client() (*vault.Client, error) {
 	cfg.MaxRetryWait = a.ClientOptions.MaxRetryWait
 	cfg.MaxRetries = a.ClientOptions.MaxRetries
 	cfg.Timeout = a.ClientOptions.Timeout
+	if transport, ok := cfg.HttpClient.Transport.(*http.Transport); ok {
+		a.ClientOptions.Proxy.Apply(transport)
+	}
 
 	return vault.NewClient(cfg)
 }
@@ -81,11 +94,17 @@ func (a *Arguments) Validate() error {
 		return fmt.Errorf("exactly one auth.* block must be specified; found %d", len(a.Auth))
 	}
 
+	switch a.Store {
+	case storeKV, storeLogical:
+	default:
+		return fmt.Errorf("store must be one of %q or %q, got %q", storeKV, storeLogical, a.Store)
+	}
+
 	if a.ClientOptions.Timeout == 0 {
 		return fmt.Errorf("client_options.timeout must be greater than 0")
 	}
 
-	return nil
+	return a.ClientOptions.Proxy.Validate()
 }
 
 func (a *Arguments) authMethod() authMethod {
@@ -96,7 +115,9 @@ func (a *Arguments) authMethod() authMethod {
 }
 
 func (a *Arguments) secretStore(cli *vault.Client) secretStore {
-	// TODO(rfratto): support different stores (like a logical store).
+	if a.Store == storeLogical {
+		return &logicalStore{c: cli}
+	}
 	return &kvStore{c: cli}
 }
 
@@ -106,6 +127,8 @@ type ClientOptions struct {
 	MaxRetryWait time.Duration `river:"max_retry_wait,attr,optional"`
 	MaxRetries   int           `river:"max_retries,attr,optional"`
 	Timeout      time.Duration `river:"timeout,attr,optional"`
+
+	Proxy config.ProxyConfig `river:",squash"`
 }
 
 // Exports is the values exported by remote.vault.
@@ -207,6 +230,7 @@ func (c *Component) Update(args component.Arguments) error {
 
 			ReadCounter:    c.metrics.authTotal,
 			RefreshCounter: c.metrics.authLeaseRenewalTotal,
+			ExpiryCounter:  c.metrics.authLeaseExpiredTotal,
 		})
 		if err != nil {
 			return err
@@ -225,6 +249,7 @@ func (c *Component) Update(args component.Arguments) error {
 
 			ReadCounter:    c.metrics.secretReadTotal,
 			RefreshCounter: c.metrics.secretLeaseRenewalTotal,
+			ExpiryCounter:  c.metrics.secretLeaseExpiredTotal,
 		})
 		if err != nil {
 			return err
@@ -275,6 +300,10 @@ func (c *Component) exportSecret(secret *vault.Secret) {
 			newExports.Data[key] = rivertypes.Secret(value)
 		case []byte:
 			newExports.Data[key] = rivertypes.Secret(value)
+		case nil:
+			// Dynamic secrets may have unset fields, such as the security token
+			// of AWS credentials for IAM users.
+			continue
 
 		default:
 			// Non-string secrets are ignored.
@@ -8,6 +8,7 @@ import (
 
 	"github.com/grafana/agent/converter/diag"
 	"github.com/grafana/agent/converter/internal/prometheusconvert"
+	"github.com/grafana/agent/converter/internal/promtailconvert"
 	"github.com/grafana/agent/pkg/river/parser"
 	"github.com/grafana/agent/pkg/river/printer"
 )
@@ -18,6 +19,8 @@ type Input string
 const (
 	// InputPrometheus indicates that the input file is a prometheus.yaml file.
 	InputPrometheus Input = "prometheus"
+	// InputPromtail indicates that the input file is a promtail.yaml file.
+	InputPromtail Input = "promtail"
 )
 
 // Convert generates a Grafana Agent Flow config given an input configuration
@@ -36,6 +39,8 @@ func Convert(in []byte, kind Input) ([]byte, diag.Diagnostics) {
 	switch kind {
 	case InputPrometheus:
 		return prettyPrint(prometheusconvert.Convert(in))
+	case InputPromtail:
+		return prettyPrint(promtailconvert.Convert(in))
 	}
 
 	var diags diag.Diagnostics
@@ -65,5 +70,5 @@ func prettyPrint(in []byte, diags diag.Diagnostics) ([]byte, diag.Diagnostics) {
 
 	// Add a trailing newline at the end of the file, which is omitted by Fprint.
 	_, _ = buf.Write([]byte{'\n'})
-	return buf.Bytes(), nil
+	return buf.Bytes(), diags
 }
@@ -1,4 +1,4 @@
-package prometheusconvert
+package common
 
 import (
 	"github.com/grafana/agent/component/common/config"
@@ -6,7 +6,8 @@ import (
 	promconfig "github.com/prometheus/common/config"
 )
 
-func toHttpClientConfig(httpClientConfig *promconfig.HTTPClientConfig) *config.HTTPClientConfig {
+// ToHttpClientConfig converts a Prometheus HTTP client config to its Flow equivalent.
+func ToHttpClientConfig(httpClientConfig *promconfig.HTTPClientConfig) *config.HTTPClientConfig {
 	if httpClientConfig == nil {
 		return nil
 	}
@@ -18,7 +19,7 @@ func toHttpClientConfig(httpClientConfig *promconfig.HTTPClientConfig) *config.H
 		BearerToken:     rivertypes.Secret(httpClientConfig.BearerToken),
 		BearerTokenFile: httpClientConfig.BearerTokenFile,
 		ProxyURL:        config.URL(httpClientConfig.ProxyURL),
-		TLSConfig:       *toTLSConfig(&httpClientConfig.TLSConfig),
+		TLSConfig:       *ToTLSConfig(&httpClientConfig.TLSConfig),
 		FollowRedirects: httpClientConfig.FollowRedirects,
 		EnableHTTP2:     httpClientConfig.EnableHTTP2,
 	}
@@ -61,11 +62,12 @@ func toOAuth2(oAuth2 *promconfig.OAuth2) *config.OAuth2Config {
 		TokenURL:         oAuth2.TokenURL,
 		EndpointParams:   oAuth2.EndpointParams,
 		ProxyURL:         config.URL(oAuth2.ProxyURL),
-		TLSConfig:        toTLSConfig(&oAuth2.TLSConfig),
+		TLSConfig:        ToTLSConfig(&oAuth2.TLSConfig),
 	}
 }
 
-func toTLSConfig(tlsConfig *promconfig.TLSConfig) *config.TLSConfig {
+// ToTLSConfig converts a Prometheus TLS config to its Flow equivalent.
+func ToTLSConfig(tlsConfig *promconfig.TLSConfig) *config.TLSConfig {
 	if tlsConfig == nil {
 		return nil
 	}
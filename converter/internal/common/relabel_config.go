@@ -0,0 +1,34 @@
+package common
+
+import (
+	flow_relabel "github.com/grafana/agent/component/common/relabel"
+	promrelabel "github.com/prometheus/prometheus/model/relabel"
+)
+
+// ToFlowRelabelConfigs converts Prometheus relabel configs to their Flow
+// equivalent.
+func ToFlowRelabelConfigs(relabelConfigs []*promrelabel.Config) []*flow_relabel.Config {
+	if len(relabelConfigs) == 0 {
+		return nil
+	}
+
+	var flowRelabelConfigs []*flow_relabel.Config
+	for _, relabelConfig := range relabelConfigs {
+		sourceLabels := make([]string, len(relabelConfig.SourceLabels))
+		for i, sourceLabel := range relabelConfig.SourceLabels {
+			sourceLabels[i] = string(sourceLabel)
+		}
+
+		flowRelabelConfigs = append(flowRelabelConfigs, &flow_relabel.Config{
+			SourceLabels: sourceLabels,
+			Separator:    relabelConfig.Separator,
+			Regex:        flow_relabel.Regexp(relabelConfig.Regex),
+			Modulus:      relabelConfig.Modulus,
+			TargetLabel:  relabelConfig.TargetLabel,
+			Replacement:  relabelConfig.Replacement,
+			Action:       flow_relabel.Action(relabelConfig.Action),
+		})
+	}
+
+	return flowRelabelConfigs
+}
@@ -38,7 +38,7 @@ func toDiscoveryAzure(sdConfig *promazure.SDConfig) (*azure.Arguments, diag.Diag
 		ProxyURL:        config.URL(sdConfig.HTTPClientConfig.ProxyURL),
 		FollowRedirects: sdConfig.HTTPClientConfig.FollowRedirects,
 		EnableHTTP2:     sdConfig.HTTPClientConfig.EnableHTTP2,
-		TLSConfig:       *toTLSConfig(&sdConfig.HTTPClientConfig.TLSConfig),
+		TLSConfig:       *common.ToTLSConfig(&sdConfig.HTTPClientConfig.TLSConfig),
 	}, validateDiscoveryAzure(sdConfig)
 }
 
@@ -11,6 +11,8 @@ import (
 	promconfig "github.com/prometheus/prometheus/config"
 	promdiscover "github.com/prometheus/prometheus/discovery"
 	promazure "github.com/prometheus/prometheus/discovery/azure"
+	promscaleway "github.com/prometheus/prometheus/discovery/scaleway"
+	promvultr "github.com/prometheus/prometheus/discovery/vultr"
 	"github.com/prometheus/prometheus/storage"
 
 	_ "github.com/prometheus/prometheus/discovery/install" // Register Prometheus SDs
@@ -74,6 +76,14 @@ func AppendAll(f *builder.File, promConfig *promconfig.Config) diag.Diagnostics
 				exports, newDiags := appendDiscoveryAzure(f, scrapeConfig.JobName, sdc)
 				targets = append(targets, exports.Targets...)
 				diags = append(diags, newDiags...)
+			case *promscaleway.SDConfig:
+				exports, newDiags := appendDiscoveryScaleway(f, scrapeConfig.JobName, sdc)
+				targets = append(targets, exports.Targets...)
+				diags = append(diags, newDiags...)
+			case *promvultr.SDConfig:
+				exports, newDiags := appendDiscoveryVultr(f, scrapeConfig.JobName, sdc)
+				targets = append(targets, exports.Targets...)
+				diags = append(diags, newDiags...)
 			default:
 				diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("unsupported service discovery %s was provided", serviceDiscoveryConfig.Name()))
 			}
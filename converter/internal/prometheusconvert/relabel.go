@@ -3,7 +3,6 @@ package prometheusconvert
 import (
 	"fmt"
 
-	flow_relabel "github.com/grafana/agent/component/common/relabel"
 	"github.com/grafana/agent/component/prometheus/relabel"
 	"github.com/grafana/agent/converter/internal/common"
 	"github.com/grafana/agent/pkg/river/token/builder"
@@ -29,26 +28,8 @@ func toRelabelArguments(relabelConfigs []*promrelabel.Config, forwardTo []storag
 		return nil
 	}
 
-	var metricRelabelConfigs []*flow_relabel.Config
-	for _, relabelConfig := range relabelConfigs {
-		sourceLabels := make([]string, len(relabelConfig.SourceLabels))
-		for i, sourceLabel := range relabelConfig.SourceLabels {
-			sourceLabels[i] = string(sourceLabel)
-		}
-
-		metricRelabelConfigs = append(metricRelabelConfigs, &flow_relabel.Config{
-			SourceLabels: sourceLabels,
-			Separator:    relabelConfig.Separator,
-			Regex:        flow_relabel.Regexp(relabelConfig.Regex),
-			Modulus:      relabelConfig.Modulus,
-			TargetLabel:  relabelConfig.TargetLabel,
-			Replacement:  relabelConfig.Replacement,
-			Action:       flow_relabel.Action(relabelConfig.Action),
-		})
-	}
-
 	return &relabel.Arguments{
 		ForwardTo:            forwardTo,
-		MetricRelabelConfigs: metricRelabelConfigs,
+		MetricRelabelConfigs: common.ToFlowRelabelConfigs(relabelConfigs),
 	}
 }
@@ -0,0 +1,64 @@
+package prometheusconvert
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component/common/config"
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/discovery/scaleway"
+	"github.com/grafana/agent/converter/diag"
+	"github.com/grafana/agent/converter/internal/common"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	promscaleway "github.com/prometheus/prometheus/discovery/scaleway"
+)
+
+func appendDiscoveryScaleway(f *builder.File, jobName string, sdConfig *promscaleway.SDConfig) (discovery.Exports, diag.Diagnostics) {
+	discoveryScalewayArgs, diags := toDiscoveryScaleway(sdConfig)
+	common.AppendBlockWithOverride(f, []string{"discovery", "scaleway"}, jobName, discoveryScalewayArgs)
+	return discovery.Exports{
+		Targets: []discovery.Target{map[string]string{"discovery.scaleway." + jobName + ".targets": ""}},
+	}, diags
+}
+
+func toDiscoveryScaleway(sdConfig *promscaleway.SDConfig) (*scaleway.Arguments, diag.Diagnostics) {
+	if sdConfig == nil {
+		return nil, nil
+	}
+
+	return &scaleway.Arguments{
+		Project:         sdConfig.Project,
+		Role:            string(sdConfig.Role),
+		APIURL:          sdConfig.APIURL,
+		Zone:            sdConfig.Zone,
+		AccessKey:       sdConfig.AccessKey,
+		SecretKey:       rivertypes.Secret(sdConfig.SecretKey),
+		SecretKeyFile:   sdConfig.SecretKeyFile,
+		NameFilter:      sdConfig.NameFilter,
+		TagsFilter:      sdConfig.TagsFilter,
+		RefreshInterval: time.Duration(sdConfig.RefreshInterval),
+		Port:            sdConfig.Port,
+		ProxyURL:        config.URL(sdConfig.HTTPClientConfig.ProxyURL),
+		TLSConfig:       *common.ToTLSConfig(&sdConfig.HTTPClientConfig.TLSConfig),
+		FollowRedirects: sdConfig.HTTPClientConfig.FollowRedirects,
+		EnableHTTP2:     sdConfig.HTTPClientConfig.EnableHTTP2,
+	}, validateDiscoveryScaleway(sdConfig)
+}
+
+func validateDiscoveryScaleway(sdConfig *promscaleway.SDConfig) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if sdConfig.HTTPClientConfig.NoProxy != "" {
+		diags.Add(diag.SeverityLevelWarn, "unsupported scaleway service discovery config no_proxy was provided")
+	}
+
+	if sdConfig.HTTPClientConfig.ProxyFromEnvironment {
+		diags.Add(diag.SeverityLevelWarn, "unsupported scaleway service discovery config proxy_from_environment was provided")
+	}
+
+	if len(sdConfig.HTTPClientConfig.ProxyConnectHeader) > 0 {
+		diags.Add(diag.SeverityLevelWarn, "unsupported scaleway service discovery config proxy_connect_header was provided")
+	}
+
+	return diags
+}
@@ -0,0 +1,50 @@
+package prometheusconvert
+
+import (
+	"time"
+
+	"github.com/grafana/agent/component/discovery"
+	"github.com/grafana/agent/component/discovery/vultr"
+	"github.com/grafana/agent/converter/diag"
+	"github.com/grafana/agent/converter/internal/common"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	promvultr "github.com/prometheus/prometheus/discovery/vultr"
+)
+
+func appendDiscoveryVultr(f *builder.File, jobName string, sdConfig *promvultr.SDConfig) (discovery.Exports, diag.Diagnostics) {
+	discoveryVultrArgs, diags := toDiscoveryVultr(sdConfig)
+	common.AppendBlockWithOverride(f, []string{"discovery", "vultr"}, jobName, discoveryVultrArgs)
+	return discovery.Exports{
+		Targets: []discovery.Target{map[string]string{"discovery.vultr." + jobName + ".targets": ""}},
+	}, diags
+}
+
+func toDiscoveryVultr(sdConfig *promvultr.SDConfig) (*vultr.Arguments, diag.Diagnostics) {
+	if sdConfig == nil {
+		return nil, nil
+	}
+
+	return &vultr.Arguments{
+		RefreshInterval:  time.Duration(sdConfig.RefreshInterval),
+		Port:             sdConfig.Port,
+		HTTPClientConfig: *common.ToHttpClientConfig(&sdConfig.HTTPClientConfig),
+	}, validateDiscoveryVultr(sdConfig)
+}
+
+func validateDiscoveryVultr(sdConfig *promvultr.SDConfig) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if sdConfig.HTTPClientConfig.NoProxy != "" {
+		diags.Add(diag.SeverityLevelWarn, "unsupported vultr service discovery config no_proxy was provided")
+	}
+
+	if sdConfig.HTTPClientConfig.ProxyFromEnvironment {
+		diags.Add(diag.SeverityLevelWarn, "unsupported vultr service discovery config proxy_from_environment was provided")
+	}
+
+	if len(sdConfig.HTTPClientConfig.ProxyConnectHeader) > 0 {
+		diags.Add(diag.SeverityLevelWarn, "unsupported vultr service discovery config proxy_connect_header was provided")
+	}
+
+	return diags
+}
@@ -0,0 +1,89 @@
+package promtailconvert
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/grafana/agent/converter/diag"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	promtailcfg "github.com/grafana/loki/clients/pkg/promtail/config"
+	"gopkg.in/yaml.v2"
+)
+
+// Convert implements a Promtail config converter.
+//
+// Logs are written to a single loki.write component with an endpoint for each
+// client. Each scrape config is converted to its own chain of components,
+// reading the files of its static targets:
+//
+//	discovery.relabel (if relabel_configs are set)
+//	  -> loki.source.file
+//	  -> loki.process (if pipeline_stages are set)
+//	  -> loki.write
+func Convert(in []byte) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	var cfg promtailcfg.Config
+	if err := yaml.UnmarshalStrict(in, &cfg); err != nil {
+		diags.Add(diag.SeverityLevelError, fmt.Sprintf("failed to parse Promtail config: %s", err))
+		return nil, diags
+	}
+
+	f := builder.NewFile()
+	diags = AppendAll(f, &cfg)
+
+	var buf bytes.Buffer
+	if _, err := f.WriteTo(&buf); err != nil {
+		diags.Add(diag.SeverityLevelError, fmt.Sprintf("failed to render Flow config: %s", err.Error()))
+		return nil, diags
+	}
+	return buf.Bytes(), diags
+}
+
+// AppendAll analyzes the entire promtail config in memory and transforms it
+// into Flow components. It then appends each component to the file builder.
+func AppendAll(f *builder.File, cfg *promtailcfg.Config) diag.Diagnostics {
+	clients := cfg.ClientConfigs
+	// The deprecated client field is still used by promtail along with
+	// clients.
+	if cfg.ClientConfig.URL.URL != nil {
+		clients = append(clients, cfg.ClientConfig)
+	}
+
+	diags := appendLokiWrite(f, clients)
+
+	labels := make(map[string]int)
+	for _, scrapeConfig := range cfg.ScrapeConfig {
+		label := componentLabel(scrapeConfig.JobName)
+		if n := labels[label]; n > 0 {
+			labels[label]++
+			label = fmt.Sprintf("%s_%d", label, n+1)
+		}
+		labels[label]++
+
+		diags = append(diags, appendScrapeConfig(f, label, scrapeConfig, lokiWriteReceiver)...)
+	}
+
+	return diags
+}
+
+// componentLabel returns a valid component label for the name of a scrape
+// config.
+func componentLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+
+	res := []rune(name)
+	for i, r := range res {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !isDigit {
+			res[i] = '_'
+		}
+	}
+	if res[0] >= '0' && res[0] <= '9' {
+		return "_" + string(res)
+	}
+	return string(res)
+}
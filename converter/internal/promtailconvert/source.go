@@ -0,0 +1,136 @@
+package promtailconvert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/agent/converter/diag"
+	"github.com/grafana/agent/converter/internal/common"
+	"github.com/grafana/agent/pkg/river/token"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	"github.com/grafana/loki/clients/pkg/promtail/scrapeconfig"
+	promdiscover "github.com/prometheus/prometheus/discovery"
+)
+
+// appendScrapeConfig appends the components reading the logs of a scrape
+// config, forwarding them to the receiver expression forwardTo.
+func appendScrapeConfig(f *builder.File, label string, sc scrapeconfig.Config, forwardTo string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for key, set := range map[string]bool{
+		"journal":          sc.JournalConfig != nil,
+		"syslog":           sc.SyslogConfig != nil,
+		"gcplog":           sc.GcplogConfig != nil,
+		"loki_push_api":    sc.PushConfig != nil,
+		"windows_events":   sc.WindowsConfig != nil,
+		"kafka":            sc.KafkaConfig != nil,
+		"azure_event_hubs": sc.AzureEventHubsConfig != nil,
+		"gelf":             sc.GelfConfig != nil,
+		"cloudflare":       sc.CloudflareConfig != nil,
+		"heroku_drain":     sc.HerokuDrainConfig != nil,
+		"encoding":         sc.Encoding != "",
+		"decompression":    sc.DecompressionCfg != nil,
+	} {
+		if set {
+			diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("unsupported %s config was provided in job %s", key, sc.JobName))
+		}
+	}
+	if len(sc.DockerSDConfigs) > 0 {
+		diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("unsupported service discovery docker was provided in job %s", sc.JobName))
+	}
+
+	var targets []map[string]string
+	for _, sdc := range sc.ServiceDiscoveryConfig.Configs() {
+		switch sdc := sdc.(type) {
+		case promdiscover.StaticConfig:
+			targets = append(targets, staticTargets(sdc)...)
+		default:
+			diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("unsupported service discovery %s was provided in job %s", sdc.Name(), sc.JobName))
+		}
+	}
+	for _, t := range targets {
+		if path := t["__path__"]; strings.ContainsAny(path, "*?[{") {
+			diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("loki.source.file doesn't expand the glob pattern %s of job %s, which must be replaced with the paths of the files", path, sc.JobName))
+		}
+	}
+
+	// Diagnostics are sorted, so that they don't depend on the order of
+	// iteration over the map of configs above.
+	sort.SliceStable(diags, func(i, j int) bool { return diags[i].Message < diags[j].Message })
+
+	// Nothing can be read without static targets.
+	if len(targets) == 0 {
+		return diags
+	}
+
+	targetsExpr := builder.NewExpr()
+	targetsExpr.SetValue(targets)
+	targetsTokens := targetsExpr.Tokens()
+	if len(sc.RelabelConfigs) > 0 {
+		relabel := builder.NewBlock([]string{"discovery", "relabel"}, label)
+		relabel.Body().SetAttributeTokens("targets", targetsTokens)
+		for _, rule := range common.ToFlowRelabelConfigs(sc.RelabelConfigs) {
+			b := builder.NewBlock([]string{"rule"}, "")
+			b.Body().AppendFrom(rule)
+			relabel.Body().AppendBlock(b)
+		}
+		f.Body().AppendBlock(relabel)
+
+		targetsTokens = exprTokens(fmt.Sprintf("discovery.relabel.%s.output", label))
+	}
+
+	stages, stagesDiags := convertStages(sc.PipelineStages)
+	diags = append(diags, stagesDiags...)
+	if len(stages) > 0 {
+		process := builder.NewBlock([]string{"loki", "process"}, label)
+		process.Body().SetAttributeTokens("forward_to", receiversTokens(forwardTo))
+		for _, stage := range stages {
+			process.Body().AppendBlock(stage)
+		}
+		f.Body().AppendBlock(process)
+
+		forwardTo = fmt.Sprintf("loki.process.%s.receiver", label)
+	}
+
+	source := builder.NewBlock([]string{"loki", "source", "file"}, label)
+	source.Body().SetAttributeTokens("targets", targetsTokens)
+	source.Body().SetAttributeTokens("forward_to", receiversTokens(forwardTo))
+	f.Body().AppendBlock(source)
+
+	return diags
+}
+
+// staticTargets returns the targets of static configs, with the labels of
+// their groups.
+func staticTargets(sc promdiscover.StaticConfig) []map[string]string {
+	var res []map[string]string
+	for _, group := range sc {
+		for _, target := range group.Targets {
+			t := make(map[string]string, len(group.Labels)+len(target))
+			for name, value := range group.Labels {
+				t[string(name)] = string(value)
+			}
+			for name, value := range target {
+				t[string(name)] = string(value)
+			}
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// exprTokens returns the tokens of a raw River expression.
+func exprTokens(expr string) []builder.Token {
+	return []builder.Token{{Tok: token.LITERAL, Lit: expr}}
+}
+
+// receiversTokens returns the tokens of a list holding the receiver
+// expression.
+func receiversTokens(receiver string) []builder.Token {
+	return []builder.Token{
+		{Tok: token.LBRACK},
+		{Tok: token.LITERAL, Lit: receiver},
+		{Tok: token.RBRACK},
+	}
+}
@@ -0,0 +1,490 @@
+package promtailconvert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/units"
+	"github.com/grafana/agent/converter/diag"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	promtailmetric "github.com/grafana/loki/clients/pkg/logentry/metric"
+	promtailstages "github.com/grafana/loki/clients/pkg/logentry/stages"
+	"github.com/mitchellh/mapstructure"
+)
+
+// promtailMetricsPrefix is the prefix promtail adds to the names of the
+// metrics of metrics stages without a prefix. It's set explicitly on
+// converted metrics, so that they keep their names.
+const promtailMetricsPrefix = "promtail_custom_"
+
+// convertStages returns the blocks of the stages of a promtail pipeline.
+// Stages which can't be converted are reported in the returned diagnostics.
+func convertStages(pipeline promtailstages.PipelineStages) ([]*builder.Block, diag.Diagnostics) {
+	var (
+		blocks []*builder.Block
+		diags  diag.Diagnostics
+	)
+
+	for i, s := range pipeline {
+		stage, ok := toStringMap(s)
+		if !ok || len(stage) == 0 {
+			diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid pipeline stage %d: expected a stage name with its config", i))
+			continue
+		}
+
+		names := make([]string, 0, len(stage))
+		for name := range stage {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) > 1 {
+			diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("pipeline stage %d defines several stages %v, which are converted in alphabetical order", i, names))
+		}
+
+		for _, name := range names {
+			block, err := convertStage(name, stage[name], &diags)
+			if err != nil {
+				diags.Add(diag.SeverityLevelError, fmt.Sprintf("invalid %s stage: %s", name, err))
+				continue
+			}
+			if block != nil {
+				blocks = append(blocks, block)
+			}
+		}
+	}
+
+	return blocks, diags
+}
+
+// convertStage converts the promtail stage with the given name and config to
+// a stage block of loki.process. Stages with no equivalent are reported in
+// diags and return a nil block.
+func convertStage(name string, cfg interface{}, diags *diag.Diagnostics) (*builder.Block, error) {
+	switch name {
+	case promtailstages.StageTypeJSON:
+		var c promtailstages.JSONConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("json")
+		b.Body().SetAttributeValue("expressions", c.Expressions)
+		setOptional(b.Body(), "source", c.Source)
+		if c.DropMalformed {
+			b.Body().SetAttributeValue("drop_malformed", true)
+		}
+		return b, nil
+
+	case promtailstages.StageTypeLogfmt:
+		var c promtailstages.LogfmtConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("logfmt")
+		b.Body().SetAttributeValue("mapping", c.Mapping)
+		setOptional(b.Body(), "source", c.Source)
+		return b, nil
+
+	case promtailstages.StageTypeRegex:
+		var c promtailstages.RegexConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("regex")
+		b.Body().SetAttributeValue("expression", c.Expression)
+		setOptional(b.Body(), "source", c.Source)
+		return b, nil
+
+	case promtailstages.StageTypeReplace:
+		var c promtailstages.ReplaceConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("replace")
+		b.Body().SetAttributeValue("expression", c.Expression)
+		setOptional(b.Body(), "source", c.Source)
+		if c.Replace != "" {
+			b.Body().SetAttributeValue("replace", c.Replace)
+		}
+		return b, nil
+
+	case promtailstages.StageTypeLabel:
+		var c promtailstages.LabelsConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("labels")
+		b.Body().SetAttributeValue("values", labelValues(c))
+		return b, nil
+
+	case promtailstages.StageTypeStaticLabels:
+		var c promtailstages.StaticLabelConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("static_labels")
+		b.Body().SetAttributeValue("values", labelValues(c))
+		return b, nil
+
+	case promtailstages.StageTypeLabelDrop:
+		var c promtailstages.LabelDropConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("label_drop")
+		b.Body().SetAttributeValue("values", []string(c))
+		return b, nil
+
+	case promtailstages.StageTypeLabelAllow:
+		var c promtailstages.LabelAllowConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("label_keep")
+		b.Body().SetAttributeValue("values", []string(c))
+		return b, nil
+
+	case promtailstages.StageTypeTimestamp:
+		var c promtailstages.TimestampConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("timestamp")
+		b.Body().SetAttributeValue("source", c.Source)
+		b.Body().SetAttributeValue("format", c.Format)
+		if len(c.FallbackFormats) > 0 {
+			b.Body().SetAttributeValue("fallback_formats", c.FallbackFormats)
+		}
+		setOptional(b.Body(), "location", c.Location)
+		setOptional(b.Body(), "action_on_failure", c.ActionOnFailure)
+		return b, nil
+
+	case promtailstages.StageTypeOutput:
+		var c promtailstages.OutputConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("output")
+		b.Body().SetAttributeValue("source", c.Source)
+		return b, nil
+
+	case promtailstages.StageTypeTemplate:
+		var c promtailstages.TemplateConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("template")
+		b.Body().SetAttributeValue("source", c.Source)
+		b.Body().SetAttributeValue("template", c.Template)
+		return b, nil
+
+	case promtailstages.StageTypeTenant:
+		var c promtailstages.TenantConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("tenant")
+		setNonEmpty(b.Body(), "label", c.Label)
+		setNonEmpty(b.Body(), "source", c.Source)
+		setNonEmpty(b.Body(), "value", c.Value)
+		return b, nil
+
+	case promtailstages.StageTypeDocker:
+		return stageBlock("docker"), nil
+
+	case promtailstages.StageTypeCRI:
+		return stageBlock("cri"), nil
+
+	case promtailstages.StageTypeMultiline:
+		return convertMultiline(cfg)
+
+	case promtailstages.StageTypePack:
+		var c promtailstages.PackConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("pack")
+		b.Body().SetAttributeValue("labels", c.Labels)
+		// Both promtail and loki.process ingest the timestamp by default.
+		if c.IngestTimestamp != nil && !*c.IngestTimestamp {
+			b.Body().SetAttributeValue("ingest_timestamp", false)
+		}
+		return b, nil
+
+	case promtailstages.StageTypeDrop:
+		return convertDrop(cfg, diags)
+
+	case promtailstages.StageTypeSampling:
+		var c promtailstages.SamplingConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("sampling")
+		b.Body().SetAttributeValue("rate", c.SamplingRate)
+		setOptional(b.Body(), "drop_counter_reason", c.DropReason)
+		return b, nil
+
+	case promtailstages.StageTypeLimit:
+		var c promtailstages.LimitConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		b := stageBlock("limit")
+		b.Body().SetAttributeValue("rate", c.Rate)
+		b.Body().SetAttributeValue("burst", c.Burst)
+		if c.Drop {
+			b.Body().SetAttributeValue("drop", true)
+		}
+		setNonEmpty(b.Body(), "by_label_name", c.ByLabelName)
+		if c.MaxDistinctLabels != 0 {
+			b.Body().SetAttributeValue("max_distinct_labels", c.MaxDistinctLabels)
+		}
+		return b, nil
+
+	case promtailstages.StageTypeGeoIP:
+		var c promtailstages.GeoIPConfig
+		if err := mapstructure.Decode(cfg, &c); err != nil {
+			return nil, err
+		}
+		// promtail doesn't look up anything without a source, while
+		// loki.process requires one.
+		if c.Source == nil {
+			diags.Add(diag.SeverityLevelWarn, "the geoip stage without a source has no effect and was not converted")
+			return nil, nil
+		}
+		b := stageBlock("geoip")
+		b.Body().SetAttributeValue("db", c.DB)
+		b.Body().SetAttributeValue("source", *c.Source)
+		b.Body().SetAttributeValue("db_type", c.DBType)
+		return b, nil
+
+	case promtailstages.StageTypeMetric:
+		return convertMetrics(cfg)
+
+	case promtailstages.StageTypeMatch:
+		return convertMatch(cfg)
+
+	case promtailstages.StageTypeDecolorize, promtailstages.StageTypeEventLogMessage:
+		diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the %s stage has no equivalent in loki.process and was not converted", name))
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("unknown stage type")
+	}
+}
+
+func convertMultiline(cfg interface{}) (*builder.Block, error) {
+	var c promtailstages.MultilineConfig
+	if err := mapstructure.Decode(cfg, &c); err != nil {
+		return nil, err
+	}
+	if c.Expression == nil || *c.Expression == "" {
+		return nil, fmt.Errorf("firstline must be set")
+	}
+
+	b := stageBlock("multiline")
+	b.Body().SetAttributeValue("firstline", *c.Expression)
+	// Zero isn't a valid maximum for loki.process, while promtail replaces it
+	// with the default of 128 lines.
+	if c.MaxLines != nil && *c.MaxLines != 0 && *c.MaxLines != 128 {
+		b.Body().SetAttributeValue("max_lines", *c.MaxLines)
+	}
+	if c.MaxWaitTime != nil {
+		d, err := time.ParseDuration(*c.MaxWaitTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_wait_time: %w", err)
+		}
+		if d != 3*time.Second {
+			b.Body().SetAttributeValue("max_wait_time", d)
+		}
+	}
+	return b, nil
+}
+
+func convertDrop(cfg interface{}, diags *diag.Diagnostics) (*builder.Block, error) {
+	var c promtailstages.DropConfig
+	if err := mapstructure.Decode(cfg, &c); err != nil {
+		return nil, err
+	}
+
+	b := stageBlock("drop")
+	switch source := c.Source.(type) {
+	case nil:
+	case string:
+		b.Body().SetAttributeValue("source", source)
+	case []interface{}:
+		// A list with a single source is the same as the source alone.
+		if len(source) != 1 {
+			diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the drop stage with several sources %v has no equivalent in loki.process and was not converted", source))
+			return nil, nil
+		}
+		b.Body().SetAttributeValue("source", fmt.Sprint(source[0]))
+	default:
+		return nil, fmt.Errorf("source must be a string or a list of strings")
+	}
+	setOptional(b.Body(), "value", c.Value)
+	setOptional(b.Body(), "expression", c.Expression)
+	if c.OlderThan != nil {
+		d, err := time.ParseDuration(*c.OlderThan)
+		if err != nil {
+			return nil, fmt.Errorf("invalid older_than: %w", err)
+		}
+		b.Body().SetAttributeValue("older_than", d)
+	}
+	if c.LongerThan != nil {
+		if _, err := units.ParseBase2Bytes(*c.LongerThan); err != nil {
+			return nil, fmt.Errorf("invalid longer_than: %w", err)
+		}
+		b.Body().SetAttributeValue("longer_than", *c.LongerThan)
+	}
+	setOptional(b.Body(), "drop_counter_reason", c.DropReason)
+	return b, nil
+}
+
+func convertMetrics(cfg interface{}) (*builder.Block, error) {
+	var c promtailstages.MetricsConfig
+	if err := mapstructure.Decode(cfg, &c); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := stageBlock("metrics")
+	for _, name := range names {
+		m := c[name]
+		metricType := strings.ToLower(m.MetricType)
+
+		mb := builder.NewBlock([]string{"metric", metricType}, "")
+		body := mb.Body()
+		body.SetAttributeValue("name", name)
+		setNonEmpty(body, "description", m.Description)
+		setOptional(body, "source", m.Source)
+		if m.Prefix != "" {
+			body.SetAttributeValue("prefix", m.Prefix)
+		} else {
+			body.SetAttributeValue("prefix", promtailMetricsPrefix)
+		}
+		if m.IdleDuration != nil {
+			d, err := time.ParseDuration(*m.IdleDuration)
+			if err != nil {
+				return nil, fmt.Errorf("metric %q: invalid max_idle_duration: %w", name, err)
+			}
+			body.SetAttributeValue("max_idle_duration", d)
+		}
+
+		switch metricType {
+		case promtailstages.MetricTypeCounter:
+			var mc promtailmetric.CounterConfig
+			if err := mapstructure.Decode(m.Config, &mc); err != nil {
+				return nil, fmt.Errorf("metric %q: %w", name, err)
+			}
+			setOptional(body, "value", mc.Value)
+			body.SetAttributeValue("action", mc.Action)
+			if mc.MatchAll != nil && *mc.MatchAll {
+				body.SetAttributeValue("match_all", true)
+			}
+			if mc.CountBytes != nil && *mc.CountBytes {
+				body.SetAttributeValue("count_entry_bytes", true)
+			}
+		case promtailstages.MetricTypeGauge:
+			var mc promtailmetric.GaugeConfig
+			if err := mapstructure.Decode(m.Config, &mc); err != nil {
+				return nil, fmt.Errorf("metric %q: %w", name, err)
+			}
+			setOptional(body, "value", mc.Value)
+			body.SetAttributeValue("action", mc.Action)
+		case promtailstages.MetricTypeHistogram:
+			var mc promtailmetric.HistogramConfig
+			if err := mapstructure.Decode(m.Config, &mc); err != nil {
+				return nil, fmt.Errorf("metric %q: %w", name, err)
+			}
+			setOptional(body, "value", mc.Value)
+			body.SetAttributeValue("buckets", mc.Buckets)
+		default:
+			return nil, fmt.Errorf("metric %q: invalid metric type %q", name, m.MetricType)
+		}
+
+		b.Body().AppendBlock(mb)
+	}
+	return b, nil
+}
+
+func convertMatch(cfg interface{}) (*builder.Block, error) {
+	var c promtailstages.MatcherConfig
+	if err := mapstructure.Decode(cfg, &c); err != nil {
+		return nil, err
+	}
+
+	b := stageBlock("match")
+	b.Body().SetAttributeValue("selector", c.Selector)
+	setOptional(b.Body(), "pipeline_name", c.PipelineName)
+	setNonEmpty(b.Body(), "action", c.Action)
+	setOptional(b.Body(), "drop_counter_reason", c.DropReason)
+
+	// Diagnostics of nested stages are reported as errors of the match stage,
+	// so that they're reported once with their context.
+	blocks, diags := convertStages(c.Stages)
+	for _, d := range diags {
+		if d.Severity == diag.SeverityLevelError {
+			return nil, fmt.Errorf("nested stage: %s", d.Message)
+		}
+	}
+	for _, block := range blocks {
+		b.Body().AppendBlock(block)
+	}
+	return b, nil
+}
+
+func stageBlock(name string) *builder.Block {
+	return builder.NewBlock([]string{"stage", name}, "")
+}
+
+// setOptional sets the attribute name to the value of v, if v is set.
+func setOptional(body *builder.Body, name string, v *string) {
+	if v != nil {
+		body.SetAttributeValue(name, *v)
+	}
+}
+
+// setNonEmpty sets the attribute name to v, if v isn't empty.
+func setNonEmpty(body *builder.Body, name string, v string) {
+	if v != "" {
+		body.SetAttributeValue(name, v)
+	}
+}
+
+// labelValues returns the values of a labels stage. Labels promtail sets
+// from the extracted value of the same name have no value, which
+// loki.process expects as an empty string.
+func labelValues(labels map[string]*string) map[string]string {
+	res := make(map[string]string, len(labels))
+	for name, v := range labels {
+		if v == nil {
+			res[name] = ""
+		} else {
+			res[name] = *v
+		}
+	}
+	return res
+}
+
+// toStringMap returns a YAML mapping with its keys as strings.
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			res[fmt.Sprint(k)] = val
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
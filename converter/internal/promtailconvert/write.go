@@ -0,0 +1,83 @@
+package promtailconvert
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/units"
+	lokiwrite "github.com/grafana/agent/component/loki/write"
+	"github.com/grafana/agent/converter/diag"
+	"github.com/grafana/agent/converter/internal/common"
+	"github.com/grafana/agent/pkg/river/token/builder"
+	"github.com/grafana/loki/clients/pkg/promtail/client"
+	"github.com/prometheus/common/model"
+)
+
+// lokiWriteReceiver is the receiver of the loki.write component all logs are
+// sent to.
+const lokiWriteReceiver = "loki.write.default.receiver"
+
+func appendLokiWrite(f *builder.File, clients []client.Config) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if len(clients) == 0 {
+		diags.Add(diag.SeverityLevelError, "at least one client must be configured")
+	}
+
+	args := &lokiwrite.Arguments{}
+	for i, c := range clients {
+		args.Endpoints = append(args.Endpoints, toEndpointOptions(c))
+
+		// External labels are set for all endpoints of loki.write, so they
+		// can only be converted if all clients share them.
+		labels := labelSetToMap(c.ExternalLabels.LabelSet)
+		if i == 0 {
+			args.ExternalLabels = labels
+		} else if !equalLabels(args.ExternalLabels, labels) {
+			diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("the external labels of client %s differ from the ones of the first client, which are used for all clients", c.URL.String()))
+		}
+		if c.DropRateLimitedBatches {
+			diags.Add(diag.SeverityLevelWarn, fmt.Sprintf("drop_rate_limited_batches of client %s has no equivalent in loki.write and was not converted", c.URL.String()))
+		}
+	}
+
+	common.AppendBlockWithOverride(f, []string{"loki", "write"}, "default", args)
+	return diags
+}
+
+func toEndpointOptions(c client.Config) lokiwrite.EndpointOptions {
+	endpoint := lokiwrite.GetDefaultEndpointOptions()
+	endpoint.Name = c.Name
+	endpoint.URL = c.URL.String()
+	endpoint.BatchWait = c.BatchWait
+	endpoint.BatchSize = units.Base2Bytes(c.BatchSize)
+	endpoint.RemoteTimeout = c.Timeout
+	endpoint.Headers = c.Headers
+	endpoint.MinBackoff = c.BackoffConfig.MinBackoff
+	endpoint.MaxBackoff = c.BackoffConfig.MaxBackoff
+	endpoint.MaxBackoffRetries = c.BackoffConfig.MaxRetries
+	endpoint.TenantID = c.TenantID
+	endpoint.HTTPClientConfig = common.ToHttpClientConfig(&c.Client)
+	return endpoint
+}
+
+func labelSetToMap(ls model.LabelSet) map[string]string {
+	if len(ls) == 0 {
+		return nil
+	}
+	res := make(map[string]string, len(ls))
+	for name, value := range ls {
+		res[string(name)] = string(value)
+	}
+	return res
+}
+
+func equalLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if v, ok := b[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
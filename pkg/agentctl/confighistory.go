@@ -0,0 +1,74 @@
+package agentctl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ConfigRevision describes a config which Grafana Agent Flow applied
+// successfully, as listed by its config history API.
+type ConfigRevision struct {
+	Revision int       `json:"revision"`
+	Time     time.Time `json:"time"`
+	Source   string    `json:"source"`
+	SHA256   string    `json:"sha256"`
+}
+
+// ListConfigHistory returns the revisions of the config history of the
+// Grafana Agent Flow listening at addr, latest first.
+func ListConfigHistory(ctx context.Context, addr string) ([]ConfigRevision, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(addr, "/")+"/api/v0/config/history", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var revisions []ConfigRevision
+	if err := json.NewDecoder(resp.Body).Decode(&revisions); err != nil {
+		return nil, fmt.Errorf("failed to decode config history: %w", err)
+	}
+	return revisions, nil
+}
+
+// RollbackConfig makes the Grafana Agent Flow listening at addr apply the
+// config of revision again.
+func RollbackConfig(ctx context.Context, addr string, revision int) error {
+	url := fmt.Sprintf("%s/api/v0/config/history/%d/rollback", strings.TrimSuffix(addr, "/"), revision)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// responseError returns an error holding the body of the unsuccessful
+// response resp.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, msg)
+	}
+	return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+}
@@ -116,8 +116,10 @@ func getJoinAddr(addrs []string, in string) []string {
 	return addrs
 }
 
-// New creates a Clusterer.
-func New(log log.Logger, reg prometheus.Registerer, clusterEnabled bool, listenAddr, advertiseAddr, joinAddr string) (*Clusterer, error) {
+// New creates a Clusterer. When keyFile is set, traffic between nodes is
+// encrypted and authenticated with the keys it holds, and allowedPeers is an
+// optional comma-separated list of patterns of node names allowed to connect.
+func New(log log.Logger, reg prometheus.Registerer, clusterEnabled bool, listenAddr, advertiseAddr, joinAddr, keyFile, allowedPeers string) (*Clusterer, error) {
 	// Standalone node.
 	if !clusterEnabled {
 		return &Clusterer{Node: NewLocalNode(listenAddr)}, nil
@@ -146,6 +148,11 @@ func New(log log.Logger, reg prometheus.Registerer, clusterEnabled bool, listenA
 		}
 	}
 
+	gossipConfig.KeyFile = keyFile
+	if allowedPeers != "" {
+		gossipConfig.AllowedPeers = strings.Split(allowedPeers, ",")
+	}
+
 	err = gossipConfig.ApplyDefaults(defaultPort)
 	if err != nil {
 		return nil, err
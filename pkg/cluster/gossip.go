@@ -8,6 +8,10 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/grafana/ckit"
@@ -63,6 +67,14 @@ type GossipConfig struct {
 	// Discover peers to connect to using go-discover. Mutually exclusive with
 	// JoinPeers.
 	DiscoverPeers string
+
+	// Path to a file holding the keys used to encrypt and authenticate
+	// traffic between nodes. Traffic isn't encrypted when unset.
+	KeyFile string
+
+	// Patterns of the names of nodes allowed to connect. Requires KeyFile to
+	// be set. All nodes holding a key are allowed when empty.
+	AllowedPeers flagext.StringSlice
 }
 
 // DefaultGossipConfig holds default GossipConfig options.
@@ -94,11 +106,20 @@ func (c *GossipConfig) ApplyDefaults(defaultPort int) error {
 		if err != nil {
 			return fmt.Errorf("determining advertise address: %w", err)
 		}
-		c.AdvertiseAddr = fmt.Sprintf("%s:%d", addr.String(), defaultPort)
+		c.AdvertiseAddr = net.JoinHostPort(addr.String(), strconv.Itoa(defaultPort))
 	} else {
 		c.AdvertiseAddr = appendDefaultPort(c.AdvertiseAddr, defaultPort)
 	}
 
+	if len(c.AllowedPeers) > 0 && c.KeyFile == "" {
+		return fmt.Errorf("allowed peers require a key file to be set")
+	}
+	for _, pattern := range c.AllowedPeers {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid allowed peer pattern %q: %w", pattern, err)
+		}
+	}
+
 	if len(c.JoinPeers) > 0 && c.DiscoverPeers != "" {
 		return fmt.Errorf("at most one of join peers and discover peers may be set")
 	} else if c.DiscoverPeers != "" {
@@ -142,7 +163,9 @@ func appendDefaultPort(addr string, port int) string {
 		// No error means there was a port in the string
 		return addr
 	}
-	return fmt.Sprintf("%s:%d", addr, port)
+	// JoinHostPort encloses IPv6 addresses in brackets, so they must not be
+	// enclosed already.
+	return net.JoinHostPort(strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), strconv.Itoa(port))
 }
 
 // GossipNode is a Node which uses gRPC and gossip to discover peers.
@@ -155,6 +178,7 @@ type GossipNode struct {
 	innerNode *ckit.Node
 	log       log.Logger
 	sharder   shard.Sharder
+	security  *gossipSecurity
 
 	started atomic.Bool
 }
@@ -170,6 +194,29 @@ func NewGossipNode(l log.Logger, reg prometheus.Registerer, cli *http.Client, c
 		l = log.NewNopLogger()
 	}
 
+	var security *gossipSecurity
+	if c.KeyFile != "" {
+		keys, err := newKeyring(l, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		security = &gossipSecurity{
+			log:          l,
+			keys:         keys,
+			nodeName:     c.NodeName,
+			allowedPeers: c.AllowedPeers,
+			now:          time.Now,
+		}
+
+		transport := cli.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		securedCli := *cli
+		securedCli.Transport = security.WrapTransport(transport)
+		cli = &securedCli
+	}
+
 	sharder := shard.Ring(tokensPerNode)
 
 	ckitConfig := ckit.Config{
@@ -190,6 +237,7 @@ func NewGossipNode(l log.Logger, reg prometheus.Registerer, cli *http.Client, c
 		innerNode: inner,
 		log:       l,
 		sharder:   sharder,
+		security:  security,
 	}, nil
 }
 
@@ -239,7 +287,11 @@ func (n *GossipNode) Peers() []peer.Peer {
 
 // Handler returns the base route and HTTP handlers to register for this node.
 func (n *GossipNode) Handler() (string, http.Handler) {
-	return n.innerNode.Handler()
+	route, handler := n.innerNode.Handler()
+	if n.security != nil {
+		handler = n.security.WrapHandler(handler)
+	}
+	return route, handler
 }
 
 // Start starts the node. Start will connect to peers if configured to do so.
@@ -104,6 +104,23 @@ func TestConfig_ApplyDefaults(t *testing.T) {
 		require.EqualError(t, err, "at most one of join peers and discover peers may be set")
 	})
 
+	t.Run("allowed peers require a key file", func(t *testing.T) {
+		gc := defaultConfig
+		gc.AllowedPeers = []string{"agent-*"}
+
+		err := gc.ApplyDefaults(examplePort)
+		require.EqualError(t, err, "allowed peers require a key file to be set")
+	})
+
+	t.Run("allowed peers must be valid patterns", func(t *testing.T) {
+		gc := defaultConfig
+		gc.KeyFile = "/etc/agent/cluster-keys"
+		gc.AllowedPeers = []string{"agent-["}
+
+		err := gc.ApplyDefaults(examplePort)
+		require.EqualError(t, err, `invalid allowed peer pattern "agent-[": syntax error in pattern`)
+	})
+
 	t.Run("explicit join peers can be set", func(t *testing.T) {
 		gc := defaultConfig
 		gc.JoinPeers = []string{"foobar:9999"}
@@ -171,3 +188,17 @@ func (sp *staticProvider) Help() string {
     provider: "static"
 		addrs:    Comma-separated list of addresses to return`
 }
+
+func TestAppendDefaultPort(t *testing.T) {
+	tt := map[string]string{
+		"127.0.0.1":      "127.0.0.1:8888",
+		"127.0.0.1:1234": "127.0.0.1:1234",
+		"agent-0":        "agent-0:8888",
+		"::1":            "[::1]:8888",
+		"[::1]":          "[::1]:8888",
+		"[::1]:1234":     "[::1]:1234",
+	}
+	for addr, expect := range tt {
+		require.Equal(t, expect, appendDefaultPort(addr, examplePort), addr)
+	}
+}
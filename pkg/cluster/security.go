@@ -0,0 +1,460 @@
+package cluster
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	// authHeader holds the credentials of a request between cluster nodes:
+	// the ID of the key used, a random salt, a Unix timestamp, and an HMAC of
+	// these along with the name of the node sending the request.
+	authHeader = "X-Grafana-Agent-Cluster-Auth"
+	// nodeHeader holds the name of the node sending a request.
+	nodeHeader = "X-Grafana-Agent-Cluster-Node"
+
+	// maxClockSkew is the maximum difference between the timestamp of a
+	// request and the local time for the request to be accepted.
+	maxClockSkew = 5 * time.Minute
+
+	// keyringCheckInterval is how often the key file is checked for changes.
+	keyringCheckInterval = 10 * time.Second
+
+	// maxFrameSize is the maximum size of the plaintext of a frame.
+	maxFrameSize = 64 * 1024
+
+	saltSize = 16
+)
+
+// Directions of traffic, used to derive distinct nonces for requests and
+// responses of the same stream.
+const (
+	directionRequest byte = iota
+	directionResponse
+)
+
+// keyring holds the keys used to encrypt and authenticate traffic between
+// cluster nodes. Keys are read from a file which holds one base64-encoded
+// AES key per line. The first key is used for outgoing traffic; all keys are
+// accepted for incoming traffic, so keys can be rotated without splitting the
+// cluster.
+//
+// The file is reread when it changes.
+type keyring struct {
+	log  log.Logger
+	path string
+
+	mut       sync.Mutex
+	keys      [][]byte
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func newKeyring(l log.Logger, path string) (*keyring, error) {
+	kr := &keyring{log: l, path: path}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cluster key file: %w", err)
+	}
+	keys, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	kr.keys, kr.modTime, kr.lastCheck = keys, fi.ModTime(), time.Now()
+	return kr, nil
+}
+
+func readKeyFile(path string) ([][]byte, error) {
+	bb, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cluster key file: %w", err)
+	}
+
+	var keys [][]byte
+	sc := bufio.NewScanner(bytes.NewReader(bb))
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return nil, fmt.Errorf("cluster key file line %d: invalid base64: %w", line, err)
+		}
+		switch len(key) {
+		case 16, 24, 32:
+		default:
+			return nil, fmt.Errorf("cluster key file line %d: key must be 16, 24, or 32 bytes, got %d", line, len(key))
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("cluster key file %s holds no keys", path)
+	}
+	return keys, nil
+}
+
+// Keys returns the current keys, rereading the key file if it changed. If
+// the key file can't be reread, the previous keys are kept.
+func (kr *keyring) Keys() [][]byte {
+	kr.mut.Lock()
+	defer kr.mut.Unlock()
+
+	if time.Since(kr.lastCheck) < keyringCheckInterval {
+		return kr.keys
+	}
+	kr.lastCheck = time.Now()
+
+	fi, err := os.Stat(kr.path)
+	if err != nil {
+		level.Warn(kr.log).Log("msg", "failed to check cluster key file, keeping previous keys", "err", err)
+		return kr.keys
+	} else if fi.ModTime().Equal(kr.modTime) {
+		return kr.keys
+	}
+
+	keys, err := readKeyFile(kr.path)
+	if err != nil {
+		level.Warn(kr.log).Log("msg", "failed to reload cluster key file, keeping previous keys", "err", err)
+		return kr.keys
+	}
+	level.Info(kr.log).Log("msg", "reloaded cluster key file", "keys", len(keys))
+	kr.keys, kr.modTime = keys, fi.ModTime()
+	return kr.keys
+}
+
+// keyID returns a non-secret identifier for key.
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// gossipSecurity encrypts and authenticates the HTTP/2 traffic between
+// cluster nodes with the keys of a keyring.
+type gossipSecurity struct {
+	log          log.Logger
+	keys         *keyring
+	nodeName     string
+	allowedPeers []string
+
+	now func() time.Time
+}
+
+// WrapTransport returns a RoundTripper which authenticates requests sent
+// through next and encrypts their bodies.
+func (s *gossipSecurity) WrapTransport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		key := s.keys.Keys()[0]
+
+		salt := make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		ts := s.now().Unix()
+
+		req = req.Clone(req.Context())
+		req.Header.Set(nodeHeader, s.nodeName)
+		req.Header.Set(authHeader, strings.Join([]string{
+			keyID(key),
+			base64.RawStdEncoding.EncodeToString(salt),
+			strconv.FormatInt(ts, 10),
+			base64.RawStdEncoding.EncodeToString(authMAC(key, salt, ts, s.nodeName)),
+		}, ":"))
+
+		if req.Body != nil && req.Body != http.NoBody {
+			aead, err := streamAEAD(key, salt, s.nodeName)
+			if err != nil {
+				return nil, err
+			}
+			req.Body = &encryptingReader{src: req.Body, seal: newFrameCipher(aead, directionRequest)}
+			// Frames add overhead to the body, whose length is unknown up front.
+			req.ContentLength = -1
+			req.GetBody = nil
+		}
+
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only successful responses come from the other node's transport and
+		// are encrypted.
+		if resp.StatusCode == http.StatusOK {
+			aead, err := streamAEAD(key, salt, s.nodeName)
+			if err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+			resp.Body = &decryptingReader{src: resp.Body, open: newFrameCipher(aead, directionResponse)}
+		}
+		return resp, nil
+	})
+}
+
+// WrapHandler returns a handler which rejects requests which aren't
+// authenticated with a key of the keyring or don't come from an allowed
+// peer, and decrypts and encrypts the traffic of accepted requests.
+func (s *gossipSecurity) WrapHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(nodeHeader)
+		key, salt, err := s.authenticate(r.Header.Get(authHeader), name)
+		if err != nil {
+			level.Warn(s.log).Log("msg", "rejected unauthenticated cluster request", "remote_addr", r.RemoteAddr, "err", err)
+			http.Error(w, "unauthenticated cluster request", http.StatusUnauthorized)
+			return
+		}
+		if !s.peerAllowed(name) {
+			level.Warn(s.log).Log("msg", "rejected cluster request from peer which isn't allowed", "remote_addr", r.RemoteAddr, "peer", name)
+			http.Error(w, "peer not allowed", http.StatusForbidden)
+			return
+		}
+
+		aead, err := streamAEAD(key, salt, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		r.Body = &decryptingReader{src: r.Body, open: newFrameCipher(aead, directionRequest)}
+		next.ServeHTTP(&encryptingResponseWriter{ResponseWriter: w, seal: newFrameCipher(aead, directionResponse)}, r)
+	})
+}
+
+// authenticate validates the value of an authHeader sent by the node called
+// name, and returns the key and salt used.
+func (s *gossipSecurity) authenticate(header, name string) (key, salt []byte, err error) {
+	if header == "" {
+		return nil, nil, errors.New("missing credentials")
+	}
+	parts := strings.Split(header, ":")
+	if len(parts) != 4 {
+		return nil, nil, errors.New("malformed credentials")
+	}
+
+	for _, k := range s.keys.Keys() {
+		if keyID(k) == parts[0] {
+			key = k
+			break
+		}
+	}
+	if key == nil {
+		return nil, nil, fmt.Errorf("unknown key %q", parts[0])
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil || len(salt) != saltSize {
+		return nil, nil, errors.New("malformed salt")
+	}
+	ts, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, nil, errors.New("malformed timestamp")
+	}
+	if skew := s.now().Sub(time.Unix(ts, 0)); skew > maxClockSkew || skew < -maxClockSkew {
+		return nil, nil, fmt.Errorf("timestamp is %s away from the local time", skew)
+	}
+	mac, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return nil, nil, errors.New("malformed signature")
+	}
+	if !hmac.Equal(mac, authMAC(key, salt, ts, name)) {
+		return nil, nil, errors.New("invalid signature")
+	}
+	return key, salt, nil
+}
+
+// peerAllowed returns true if the node called name may connect. Patterns
+// use the syntax of path.Match.
+func (s *gossipSecurity) peerAllowed(name string) bool {
+	if len(s.allowedPeers) == 0 {
+		return true
+	}
+	for _, pattern := range s.allowedPeers {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func authMAC(key, salt []byte, ts int64, name string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte("grafana-agent-cluster-auth\x00"))
+	_, _ = h.Write(salt)
+	_, _ = h.Write([]byte(strconv.FormatInt(ts, 10) + "\x00" + name))
+	return h.Sum(nil)
+}
+
+// streamAEAD returns the cipher for the traffic of a single request, derived
+// from key, the salt of the request, and the name of the node sending it.
+func streamAEAD(key, salt []byte, name string) (cipher.AEAD, error) {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte("grafana-agent-cluster-stream\x00"))
+	_, _ = h.Write(salt)
+	_, _ = h.Write([]byte(name))
+
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// frameCipher seals or opens the frames of one direction of a stream. Each
+// frame uses the next nonce, so frames can't be reordered or replayed within
+// a stream.
+type frameCipher struct {
+	aead      cipher.AEAD
+	direction byte
+	counter   uint64
+}
+
+func newFrameCipher(aead cipher.AEAD, direction byte) *frameCipher {
+	return &frameCipher{aead: aead, direction: direction}
+}
+
+func (fc *frameCipher) nextNonce() []byte {
+	nonce := make([]byte, fc.aead.NonceSize())
+	nonce[0] = fc.direction
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], fc.counter)
+	fc.counter++
+	return nonce
+}
+
+// sealFrame appends the frame holding plaintext to dst.
+func (fc *frameCipher) sealFrame(dst, plaintext []byte) []byte {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(plaintext)+fc.aead.Overhead()))
+	dst = append(dst, size[:]...)
+	return fc.aead.Seal(dst, fc.nextNonce(), plaintext, nil)
+}
+
+// encryptingReader encrypts the data read from src into frames.
+type encryptingReader struct {
+	src  io.ReadCloser
+	seal *frameCipher
+
+	buf     []byte
+	pending []byte
+	err     error
+}
+
+func (er *encryptingReader) Read(p []byte) (int, error) {
+	for len(er.pending) == 0 {
+		if er.err != nil {
+			return 0, er.err
+		}
+		if er.buf == nil {
+			er.buf = make([]byte, maxFrameSize)
+		}
+
+		n, err := er.src.Read(er.buf)
+		if n > 0 {
+			er.pending = er.seal.sealFrame(er.pending[:0], er.buf[:n])
+		}
+		er.err = err
+	}
+
+	n := copy(p, er.pending)
+	er.pending = er.pending[n:]
+	return n, nil
+}
+
+func (er *encryptingReader) Close() error { return er.src.Close() }
+
+// decryptingReader decrypts the frames read from src.
+type decryptingReader struct {
+	src  io.ReadCloser
+	open *frameCipher
+
+	pending []byte
+}
+
+func (dr *decryptingReader) Read(p []byte) (int, error) {
+	for len(dr.pending) == 0 {
+		var size [4]byte
+		if _, err := io.ReadFull(dr.src, size[:]); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return 0, errors.New("truncated cluster frame")
+			}
+			return 0, err
+		}
+
+		n := binary.BigEndian.Uint32(size[:])
+		if n > maxFrameSize+uint32(dr.open.aead.Overhead()) {
+			return 0, fmt.Errorf("cluster frame of %d bytes is too large", n)
+		}
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(dr.src, frame); err != nil {
+			return 0, errors.New("truncated cluster frame")
+		}
+
+		plaintext, err := dr.open.aead.Open(frame[:0], dr.open.nextNonce(), frame, nil)
+		if err != nil {
+			return 0, errors.New("failed to decrypt cluster frame")
+		}
+		dr.pending = plaintext
+	}
+
+	n := copy(p, dr.pending)
+	dr.pending = dr.pending[n:]
+	return n, nil
+}
+
+func (dr *decryptingReader) Close() error { return dr.src.Close() }
+
+// encryptingResponseWriter encrypts the data written to the body of a
+// response into frames.
+type encryptingResponseWriter struct {
+	http.ResponseWriter
+	seal *frameCipher
+}
+
+func (w *encryptingResponseWriter) Write(p []byte) (int, error) {
+	var frames []byte
+	for off := 0; off < len(p); off += maxFrameSize {
+		end := off + maxFrameSize
+		if end > len(p) {
+			end = len(p)
+		}
+		frames = w.seal.sealFrame(frames, p[off:end])
+	}
+	if _, err := w.ResponseWriter.Write(frames); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush implements http.Flusher, which streaming connections between nodes
+// require.
+func (w *encryptingResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
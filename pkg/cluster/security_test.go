@@ -0,0 +1,290 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var (
+	testKeyA = bytes.Repeat([]byte{'a'}, 32)
+	testKeyB = bytes.Repeat([]byte{'b'}, 32)
+)
+
+func TestGossipSecurity_RoundTrip(t *testing.T) {
+	server := newTestSecurity(t, "server", nil, testKeyA)
+	client := newTestSecurity(t, "client", nil, testKeyA)
+
+	const message = "hello from the client"
+
+	var wire []byte
+	srv := httptest.NewServer(recordBody(&wire, server.WrapHandler(echoHandler(t))))
+	defer srv.Close()
+
+	resp := doRequest(t, client, srv.URL, message)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, message, string(body))
+
+	require.NotEmpty(t, wire)
+	require.NotContains(t, string(wire), message, "request body must be encrypted")
+}
+
+func TestGossipSecurity_KeyRotation(t *testing.T) {
+	// The client already uses the new key, which the server accepts as a
+	// secondary key.
+	server := newTestSecurity(t, "server", nil, testKeyA, testKeyB)
+	client := newTestSecurity(t, "client", nil, testKeyB, testKeyA)
+
+	srv := httptest.NewServer(server.WrapHandler(echoHandler(t)))
+	defer srv.Close()
+
+	resp := doRequest(t, client, srv.URL, "rotated")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "rotated", string(body))
+}
+
+func TestGossipSecurity_Rejected(t *testing.T) {
+	tests := []struct {
+		name         string
+		client       *gossipSecurity
+		allowedPeers []string
+		expect       int
+	}{
+		{
+			name:   "unknown key",
+			client: newTestSecurity(t, "agent-1", nil, testKeyB),
+			expect: http.StatusUnauthorized,
+		},
+		{
+			name:   "clock skew",
+			client: withClock(newTestSecurity(t, "agent-1", nil, testKeyA), time.Now().Add(-time.Hour)),
+			expect: http.StatusUnauthorized,
+		},
+		{
+			name:         "peer not allowed",
+			client:       newTestSecurity(t, "intruder", nil, testKeyA),
+			allowedPeers: []string{"agent-*"},
+			expect:       http.StatusForbidden,
+		},
+		{
+			name:         "peer allowed",
+			client:       newTestSecurity(t, "agent-1", nil, testKeyA),
+			allowedPeers: []string{"agent-*"},
+			expect:       http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestSecurity(t, "server", tc.allowedPeers, testKeyA)
+			srv := httptest.NewServer(server.WrapHandler(echoHandler(t)))
+			defer srv.Close()
+
+			resp := doRequest(t, tc.client, srv.URL, "payload")
+			defer resp.Body.Close()
+			require.Equal(t, tc.expect, resp.StatusCode)
+		})
+	}
+
+	t.Run("missing credentials", func(t *testing.T) {
+		server := newTestSecurity(t, "server", nil, testKeyA)
+		srv := httptest.NewServer(server.WrapHandler(echoHandler(t)))
+		defer srv.Close()
+
+		resp, err := http.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestReadKeyFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		contents    string
+		expectKeys  int
+		expectedErr string
+	}{
+		{
+			name:       "keys and comments",
+			contents:   "# primary\n" + encodeKey(testKeyA) + "\n\n" + encodeKey(testKeyB) + "\n",
+			expectKeys: 2,
+		},
+		{
+			name:        "empty",
+			contents:    "# no keys\n",
+			expectedErr: "holds no keys",
+		},
+		{
+			name:        "invalid size",
+			contents:    encodeKey([]byte("short")),
+			expectedErr: "cluster key file line 1: key must be 16, 24, or 32 bytes, got 5",
+		},
+		{
+			name:        "invalid base64",
+			contents:    "not base64!",
+			expectedErr: "cluster key file line 1: invalid base64",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys")
+			require.NoError(t, os.WriteFile(path, []byte(tc.contents), 0600))
+
+			keys, err := readKeyFile(path)
+			if tc.expectedErr != "" {
+				require.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, keys, tc.expectKeys)
+		})
+	}
+}
+
+// TestGossipNode_Security ensures that two nodes sharing a key can form a
+// cluster, and that a node with another key can't join it.
+func TestGossipNode_Security(t *testing.T) {
+	a := startTestNode(t, "node-a", testKeyA)
+	b := startTestNode(t, "node-b", testKeyA)
+	intruder := startTestNode(t, "intruder", testKeyB)
+
+	require.NoError(t, a.Start())
+	t.Cleanup(func() { _ = a.Stop() })
+
+	b.cfg.JoinPeers = []string{a.cfg.AdvertiseAddr}
+	require.NoError(t, b.Start())
+	t.Cleanup(func() { _ = b.Stop() })
+
+	require.Eventually(t, func() bool {
+		return len(a.Peers()) == 2 && len(b.Peers()) == 2
+	}, 10*time.Second, 50*time.Millisecond)
+
+	intruder.cfg.JoinPeers = []string{a.cfg.AdvertiseAddr}
+	require.Error(t, intruder.Start())
+
+	for _, p := range a.Peers() {
+		require.NotEqual(t, "intruder", p.Name)
+	}
+}
+
+func startTestNode(t *testing.T, name string, key []byte) *GossipNode {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	cli := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.DialTimeout(network, addr, time.Second)
+			},
+		},
+	}
+
+	node, err := NewGossipNode(log.NewNopLogger(), prometheus.NewRegistry(), cli, &GossipConfig{
+		NodeName:      name,
+		AdvertiseAddr: lis.Addr().String(),
+		KeyFile:       writeKeyFile(t, key),
+	})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	route, handler := node.Handler()
+	mux.Handle(route, handler)
+
+	srv := &http.Server{Handler: h2c.NewHandler(mux, &http2.Server{})}
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return node
+}
+
+func newTestSecurity(t *testing.T, name string, allowedPeers []string, keys ...[]byte) *gossipSecurity {
+	t.Helper()
+
+	kr, err := newKeyring(log.NewNopLogger(), writeKeyFile(t, keys...))
+	require.NoError(t, err)
+
+	return &gossipSecurity{
+		log:          log.NewNopLogger(),
+		keys:         kr,
+		nodeName:     name,
+		allowedPeers: allowedPeers,
+		now:          time.Now,
+	}
+}
+
+func withClock(s *gossipSecurity, now time.Time) *gossipSecurity {
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func writeKeyFile(t *testing.T, keys ...[]byte) string {
+	t.Helper()
+
+	var lines []string
+	for _, k := range keys {
+		lines = append(lines, encodeKey(k))
+	}
+	path := filepath.Join(t.TempDir(), "keys")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0600))
+	return path
+}
+
+func encodeKey(key []byte) string { return base64.StdEncoding.EncodeToString(key) }
+
+func doRequest(t *testing.T, s *gossipSecurity, url, body string) *http.Response {
+	t.Helper()
+
+	cli := &http.Client{Transport: s.WrapTransport(http.DefaultTransport)}
+	resp, err := cli.Post(url, "text/plain", strings.NewReader(body))
+	require.NoError(t, err)
+	return resp
+}
+
+// echoHandler writes the request body back to the client.
+func echoHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		_, _ = w.Write(body)
+	})
+}
+
+// recordBody stores the raw request body in out before passing the request
+// to next.
+func recordBody(out *[]byte, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*out = body
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
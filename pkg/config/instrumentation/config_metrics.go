@@ -20,6 +20,11 @@ type configMetrics struct {
 var confMetrics *configMetrics
 var configMetricsInitializer sync.Once
 
+var (
+	configHashMut sync.RWMutex
+	configHash    string
+)
+
 func initializeConfigMetrics() {
 	confMetrics = newConfigMetrics()
 }
@@ -53,9 +58,21 @@ func newConfigMetrics() *configMetrics {
 // the agent_config_hash metric.
 func InstrumentConfig(buf []byte) {
 	configMetricsInitializer.Do(initializeConfigMetrics)
-	hash := sha256.Sum256(buf)
+	hash := fmt.Sprintf("%x", sha256.Sum256(buf))
 	confMetrics.configHash.Reset()
-	confMetrics.configHash.WithLabelValues(fmt.Sprintf("%x", hash)).Set(1)
+	confMetrics.configHash.WithLabelValues(hash).Set(1)
+
+	configHashMut.Lock()
+	configHash = hash
+	configHashMut.Unlock()
+}
+
+// ConfigHash returns the sha256 hash of the config last passed to
+// InstrumentConfig, or an empty string if InstrumentConfig wasn't called.
+func ConfigHash() string {
+	configHashMut.RLock()
+	defer configHashMut.RUnlock()
+	return configHash
 }
 
 // Expose metrics for load success / failures.
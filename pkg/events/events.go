@@ -0,0 +1,206 @@
+// Package events implements an in-process event bus which components use to
+// publish and subscribe to operational events, such as targets appearing or
+// a config being applied.
+//
+// Events are delivered asynchronously: publishing never blocks, and events
+// are dropped for subscribers which can't keep up. The bus is only meant for
+// signaling, not for carrying telemetry.
+package events
+
+import (
+	"path"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+// Types of the events published by the Flow controller and by components.
+const (
+	// TypeConfigApplied is published by a Flow controller when a config was
+	// loaded, with the number of errors in the "errors" attribute.
+	TypeConfigApplied = "config.applied"
+
+	// TypeComponentHealthChanged is published when the health of a component
+	// changes, with the new health in the "health" attribute.
+	TypeComponentHealthChanged = "component.health_changed"
+
+	// TypeComponentOutputStalled and TypeComponentOutputResumed are published
+	// by the watchdog when a component stops or starts producing the output
+	// it's expected to produce again.
+	TypeComponentOutputStalled = "component.output_stalled"
+	TypeComponentOutputResumed = "component.output_resumed"
+
+	// TypeTargetAppeared and TypeTargetDisappeared are published by discovery
+	// components when a target is added to or removed from their exports. The
+	// labels of the target are the attributes of the event.
+	TypeTargetAppeared    = "target.appeared"
+	TypeTargetDisappeared = "target.disappeared"
+
+	// TypeEndpointDown and TypeEndpointUp are published by components when an
+	// endpoint they send requests to stops or starts responding successfully,
+	// with the endpoint in the "endpoint" attribute.
+	TypeEndpointDown = "endpoint.down"
+	TypeEndpointUp   = "endpoint.up"
+)
+
+// Event is an operational event.
+type Event struct {
+	// Type of the event, such as "target.appeared".
+	Type string `json:"type"`
+
+	// Source is the ID of the component which published the event, or the ID
+	// of the module controller for events of the controller. It's empty for
+	// events of the root controller.
+	Source string `json:"source"`
+
+	Timestamp  time.Time         `json:"timestamp"`
+	Message    string            `json:"message,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// Bus dispatches published events to subscribers.
+type Bus struct {
+	mut  sync.RWMutex
+	subs map[*Subscription]struct{}
+
+	now func() time.Time // Overridable for tests.
+}
+
+// NewBus creates a new Bus.
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[*Subscription]struct{}),
+		now:  time.Now,
+	}
+}
+
+// Publish sends e to every subscriber interested in it. The timestamp of e is
+// set to the current time if it's zero.
+func (b *Bus) Publish(e Event) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = b.now()
+	}
+
+	b.mut.RLock()
+	defer b.mut.RUnlock()
+
+	for s := range b.subs {
+		if !s.filter.Matches(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped.Inc()
+		}
+	}
+}
+
+// Subscribe returns a new subscription to the events matching filter, which
+// buffers up to size events. Subscriptions must be closed once they're not
+// used anymore.
+func (b *Bus) Subscribe(filter Filter, size int) *Subscription {
+	s := &Subscription{
+		bus:    b,
+		filter: filter,
+		ch:     make(chan Event, size),
+	}
+
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Client returns a Client of the bus publishing events on behalf of source.
+func (b *Bus) Client(source string) *Client {
+	return &Client{bus: b, source: source}
+}
+
+// Filter selects events by type and source. Both are lists of patterns in
+// the syntax of path.Match; an empty list matches everything.
+type Filter struct {
+	Types   []string
+	Sources []string
+}
+
+// Matches returns true if e is selected by the filter.
+func (f Filter) Matches(e Event) bool {
+	return matchesAny(f.Types, e.Type) && matchesAny(f.Sources, e.Source)
+}
+
+func matchesAny(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if a pattern of the filter is invalid.
+func (f Filter) Validate() error {
+	for _, patterns := range [][]string{f.Types, f.Sources} {
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Subscription receives the events matching a filter.
+type Subscription struct {
+	bus     *Bus
+	filter  Filter
+	ch      chan Event
+	dropped atomic.Uint64
+}
+
+// Events returns the channel events are delivered to. The channel is never
+// closed.
+func (s *Subscription) Events() <-chan Event { return s.ch }
+
+// Dropped returns the number of events which were dropped because the buffer
+// of the subscription was full.
+func (s *Subscription) Dropped() uint64 { return s.dropped.Load() }
+
+// Close stops delivering events to the subscription.
+func (s *Subscription) Close() {
+	s.bus.mut.Lock()
+	defer s.bus.mut.Unlock()
+	delete(s.bus.subs, s)
+}
+
+// Client publishes events on behalf of a component and subscribes to events
+// of the bus. A nil Client discards published events, so that components
+// don't have to check whether the Flow controller has a bus.
+type Client struct {
+	bus    *Bus
+	source string
+}
+
+// Publish publishes an event of the given type, with the source of the
+// client.
+func (c *Client) Publish(typ, message string, attrs map[string]string) {
+	if c == nil {
+		return
+	}
+	c.bus.Publish(Event{
+		Type:       typ,
+		Source:     c.source,
+		Message:    message,
+		Attributes: attrs,
+	})
+}
+
+// Subscribe returns a new subscription to the events of the bus. See
+// Bus.Subscribe.
+func (c *Client) Subscribe(filter Filter, size int) *Subscription {
+	return c.bus.Subscribe(filter, size)
+}
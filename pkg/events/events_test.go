@@ -0,0 +1,66 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	bus := NewBus()
+	bus.now = func() time.Time { return now }
+
+	all := bus.Subscribe(Filter{}, 10)
+	defer all.Close()
+	targets := bus.Subscribe(Filter{
+		Types:   []string{"target.*"},
+		Sources: []string{"discovery.kubernetes.*"},
+	}, 10)
+	defer targets.Close()
+
+	bus.Client("discovery.kubernetes.pods").Publish(TypeTargetAppeared, "", map[string]string{"__address__": "10.0.0.1:80"})
+	bus.Client("prometheus.scrape.default").Publish(TypeEndpointDown, "connection refused", nil)
+
+	expect := Event{
+		Type:       TypeTargetAppeared,
+		Source:     "discovery.kubernetes.pods",
+		Timestamp:  now,
+		Attributes: map[string]string{"__address__": "10.0.0.1:80"},
+	}
+	require.Equal(t, expect, <-targets.Events())
+	require.Empty(t, targets.Events())
+
+	require.Equal(t, expect, <-all.Events())
+	require.Equal(t, Event{
+		Type:      TypeEndpointDown,
+		Source:    "prometheus.scrape.default",
+		Timestamp: now,
+		Message:   "connection refused",
+	}, <-all.Events())
+}
+
+func TestBus_Drop(t *testing.T) {
+	bus := NewBus()
+	s := bus.Subscribe(Filter{}, 1)
+
+	bus.Publish(Event{Type: "a"})
+	bus.Publish(Event{Type: "b"})
+	require.Equal(t, "a", (<-s.Events()).Type)
+	require.Equal(t, uint64(1), s.Dropped())
+
+	s.Close()
+	bus.Publish(Event{Type: "c"})
+	require.Empty(t, s.Events())
+}
+
+func TestClient_Nil(t *testing.T) {
+	var c *Client
+	require.NotPanics(t, func() { c.Publish(TypeConfigApplied, "", nil) })
+}
+
+func TestFilter_Validate(t *testing.T) {
+	require.NoError(t, Filter{Types: []string{"target.*"}}.Validate())
+	require.Error(t, Filter{Sources: []string{"[a"}}.Validate())
+}
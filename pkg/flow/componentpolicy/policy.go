@@ -0,0 +1,110 @@
+// Package componentpolicy implements policies restricting which components
+// may be used by a Flow controller, regardless of the config it loads.
+package componentpolicy
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Policy restricts which components may be used. Components are matched by
+// their name, such as "otelcol.exporter.otlp", against patterns which may
+// contain the wildcards supported by path.Match, such as "otelcol.exporter.*".
+//
+// A nil Policy allows every component.
+type Policy struct {
+	// Allow lists the patterns of components which may be used. Every component
+	// which isn't denied may be used if Allow is empty.
+	Allow []string `yaml:"allow,omitempty"`
+
+	// Deny lists the patterns of components which may not be used. Deny takes
+	// precedence over Allow.
+	Deny []string `yaml:"deny,omitempty"`
+
+	// source describes where the policy was read from in errors.
+	source string
+}
+
+// ReadFile reads a Policy from the YAML file at the given path.
+func ReadFile(filename string) (*Policy, error) {
+	bb, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(bb)
+	if err != nil {
+		return nil, fmt.Errorf("invalid component policy file %s: %w", filename, err)
+	}
+	p.source = filename
+	return p, nil
+}
+
+// Parse parses a Policy from YAML.
+func Parse(bb []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalStrict(bb, &p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate returns an error if the patterns of p are malformed.
+func (p *Policy) Validate() error {
+	for _, list := range []struct {
+		name     string
+		patterns []string
+	}{{"allow", p.Allow}, {"deny", p.Deny}} {
+		for _, pattern := range list.patterns {
+			if pattern == "" {
+				return fmt.Errorf("%s: patterns must not be empty", list.name)
+			}
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%s: invalid pattern %q: %w", list.name, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Check returns an error describing why the component with the given name
+// may not be used. It returns nil if the component may be used.
+func (p *Policy) Check(name string) error {
+	if p == nil {
+		return nil
+	}
+	if pattern, ok := match(p.Deny, name); ok {
+		return fmt.Errorf("denied by the pattern %q of the %s", pattern, p.describe())
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	if _, ok := match(p.Allow, name); !ok {
+		return fmt.Errorf("not matched by any allowed pattern of the %s", p.describe())
+	}
+	return nil
+}
+
+func (p *Policy) describe() string {
+	if p.source == "" {
+		return "component policy"
+	}
+	return fmt.Sprintf("component policy %s", p.source)
+}
+
+// match returns the first pattern which matches name.
+func match(patterns []string, name string) (string, bool) {
+	for _, pattern := range patterns {
+		// Patterns are validated when the policy is parsed, so errors can be
+		// ignored.
+		if ok, _ := path.Match(pattern, name); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,59 @@
+package componentpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	p, err := Parse([]byte(`
+allow:
+  - prometheus.*
+  - otelcol.*
+deny:
+  - otelcol.exporter.*
+`))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Check("prometheus.scrape"))
+	require.NoError(t, p.Check("prometheus.exporter.unix"))
+	require.NoError(t, p.Check("otelcol.receiver.otlp"))
+	require.EqualError(t, p.Check("otelcol.exporter.otlp"), `denied by the pattern "otelcol.exporter.*" of the component policy`)
+	require.EqualError(t, p.Check("loki.source.file"), `not matched by any allowed pattern of the component policy`)
+}
+
+func TestPolicy_DenyOnly(t *testing.T) {
+	p, err := Parse([]byte(`deny: [pyroscope.java]`))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Check("pyroscope.scrape"))
+	require.Error(t, p.Check("pyroscope.java"))
+}
+
+func TestPolicy_Nil(t *testing.T) {
+	var p *Policy
+	require.NoError(t, p.Check("otelcol.exporter.otlp"))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte(`allow: ["prometheus.[scrape"]`))
+	require.ErrorContains(t, err, `allow: invalid pattern "prometheus.[scrape"`)
+
+	_, err = Parse([]byte(`deny: [""]`))
+	require.EqualError(t, err, "deny: patterns must not be empty")
+
+	_, err = Parse([]byte(`allowed: [prometheus.*]`))
+	require.Error(t, err, "unknown fields should be rejected")
+}
+
+func TestReadFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(filename, []byte("deny: [otelcol.exporter.*]\n"), 0644))
+
+	p, err := ReadFile(filename)
+	require.NoError(t, err)
+	require.EqualError(t, p.Check("otelcol.exporter.otlp"), `denied by the pattern "otelcol.exporter.*" of the component policy `+filename)
+}
@@ -15,7 +15,9 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/events"
 	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/kv"
 )
 
 // A Controller is a testing controller which controls a single component.
@@ -33,6 +35,8 @@ type Controller struct {
 	exportsMut sync.Mutex
 	exports    component.Exports
 	exportsCh  chan struct{}
+
+	events *events.Bus
 }
 
 // NewControllerFromID returns a new testing Controller for the component with
@@ -59,9 +63,15 @@ func NewControllerFromReg(l log.Logger, reg component.Registration) *Controller
 
 		running:   make(chan struct{}, 1),
 		exportsCh: make(chan struct{}, 1),
+
+		events: events.NewBus(),
 	}
 }
 
+// Events returns the event bus of the component, so that tests can publish
+// events to it and subscribe to the events it publishes.
+func (c *Controller) Events() *events.Bus { return c.events }
+
 func (c *Controller) onStateChange(e component.Exports) {
 	c.exportsMut.Lock()
 	changed := !reflect.DeepEqual(c.exports, e)
@@ -151,11 +161,18 @@ func (c *Controller) buildComponent(dataPath string, args component.Arguments) (
 		return nil, err
 	}
 
+	kvStore, err := kv.New(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
 	opts := component.Options{
 		ID:            c.reg.Name + ".test",
 		Logger:        l,
 		Tracer:        trace.NewNoopTracerProvider(),
 		DataPath:      dataPath,
+		KV:            kvStore.Bucket(c.reg.Name + ".test"),
+		Events:        c.events.Client(c.reg.Name + ".test"),
 		OnStateChange: c.onStateChange,
 		Registerer:    prometheus.NewRegistry(),
 	}
@@ -1,7 +1,9 @@
 package flow
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/grafana/agent/pkg/river/ast"
@@ -70,6 +72,10 @@ func ReadFile(name string, bb []byte) (*File, error) {
 				configs = append(configs, stmt)
 			case "tracing":
 				configs = append(configs, stmt)
+			case "restart":
+				configs = append(configs, stmt)
+			case "watchdog":
+				configs = append(configs, stmt)
 			case "argument":
 				configs = append(configs, stmt)
 			case "export":
@@ -95,3 +101,73 @@ func ReadFile(name string, bb []byte) (*File, error) {
 		ConfigBlocks: configs,
 	}, nil
 }
+
+// ReadFiles parses the River files specified by files, keyed by file name,
+// and merges them into a single File named name. Blocks are merged in the
+// order of the names of their files, so that the result doesn't depend on
+// the order files were read in.
+//
+// Errors are reported for every file which fails to parse, and for blocks
+// declared in more than one file.
+func ReadFiles(name string, files map[string][]byte) (*File, error) {
+	names := make([]string, 0, len(files))
+	for filename := range files {
+		names = append(names, filename)
+	}
+	sort.Strings(names)
+
+	var (
+		diags  diag.Diagnostics
+		merged = &File{Name: name, Node: &ast.File{Name: name}}
+
+		// declared holds the first declaration of each block by ID.
+		declared = make(map[string]*ast.BlockStmt)
+	)
+	for _, filename := range names {
+		f, err := ReadFile(filename, files[filename])
+		if err != nil {
+			var (
+				fileDiags diag.Diagnostics
+				fileDiag  diag.Diagnostic
+			)
+			switch {
+			case errors.As(err, &fileDiags):
+				diags = append(diags, fileDiags...)
+			case errors.As(err, &fileDiag):
+				diags = append(diags, fileDiag)
+			default:
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+			continue
+		}
+
+		for _, blocks := range [][]*ast.BlockStmt{f.ConfigBlocks, f.Components} {
+			for _, block := range blocks {
+				id := strings.Join(block.Name, ".")
+				if block.Label != "" {
+					id += "." + block.Label
+				}
+				if orig, ok := declared[id]; ok {
+					diags.Add(diag.Diagnostic{
+						Severity: diag.SeverityLevelError,
+						Message:  fmt.Sprintf("%q block already declared at %s", id, ast.StartPos(orig).Position()),
+						StartPos: ast.StartPos(block).Position(),
+						EndPos:   ast.EndPos(block).Position(),
+					})
+					continue
+				}
+				declared[id] = block
+			}
+		}
+
+		merged.Node.Body = append(merged.Node.Body, f.Node.Body...)
+		merged.Node.Comments = append(merged.Node.Comments, f.Node.Comments...)
+		merged.Components = append(merged.Components, f.Components...)
+		merged.ConfigBlocks = append(merged.ConfigBlocks, f.ConfigBlocks...)
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return merged, nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/grafana/agent/pkg/flow"
 	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/stretchr/testify/require"
 
 	_ "github.com/grafana/agent/pkg/flow/internal/testcomponents" // Include test components
@@ -60,6 +61,66 @@ func TestReadFile_Defaults(t *testing.T) {
 	require.Len(t, f.Components, 0)
 }
 
+func TestReadFiles(t *testing.T) {
+	files := map[string][]byte{
+		"b.river": []byte(`
+			testcomponents.passthrough "static" {
+				input = "hello, world!"
+			}
+		`),
+		"a.river": []byte(`
+			logging {
+				log_format = "json"
+			}
+
+			testcomponents.tick "ticker_a" {
+				frequency = "1s"
+			}
+		`),
+	}
+
+	f, err := flow.ReadFiles(t.Name(), files)
+	require.NoError(t, err)
+	require.Equal(t, t.Name(), f.Name)
+
+	// Blocks are merged in the order of their file names.
+	require.Len(t, f.Components, 2)
+	require.Equal(t, "testcomponents.tick.ticker_a", getBlockID(f.Components[0]))
+	require.Equal(t, "testcomponents.passthrough.static", getBlockID(f.Components[1]))
+	require.Len(t, f.ConfigBlocks, 1)
+	require.Equal(t, "logging", getBlockID(f.ConfigBlocks[0]))
+	require.Len(t, f.Node.Body, 3)
+}
+
+func TestReadFiles_Errors(t *testing.T) {
+	files := map[string][]byte{
+		"a.river": []byte(`
+			testcomponents.tick "ticker_a" {
+				frequency = "1s"
+			}
+		`),
+		"b.river": []byte(`
+			testcomponents.tick "ticker_a" {
+				frequency = "5s"
+			}
+		`),
+		"c.river": []byte(`
+			testcomponents.tick "ticker_b" {
+		`),
+	}
+
+	_, err := flow.ReadFiles(t.Name(), files)
+
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Len(t, diags, 2)
+
+	// Errors are attributed to the file they were found in.
+	require.Equal(t, "b.river", diags[0].StartPos.Filename)
+	require.Equal(t, `"testcomponents.tick.ticker_a" block already declared at a.river:2:4`, diags[0].Message)
+	require.Equal(t, "c.river", diags[1].StartPos.Filename)
+}
+
 func getBlockID(b *ast.BlockStmt) string {
 	var parts []string
 	parts = append(parts, b.Name...)
@@ -48,14 +48,20 @@ package flow
 import (
 	"context"
 	"net"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/events"
+	"github.com/grafana/agent/pkg/flow/componentpolicy"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/kv"
+	"github.com/grafana/agent/pkg/river/diag"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/atomic"
 )
@@ -83,6 +89,14 @@ type Options struct {
 	// on different nodes.
 	Clusterer *cluster.Clusterer
 
+	// KV is the key-value store components persist state in. An in-memory
+	// store will be created if this is nil.
+	KV *kv.Store
+
+	// Events is the event bus components publish operational events to. A
+	// new bus will be created if this is nil.
+	Events *events.Bus
+
 	// Directory where components can write data. Constructed components will be
 	// given a subdirectory of DataPath using the local ID of the component.
 	//
@@ -116,6 +130,36 @@ type Options struct {
 	// DialFunc is a function to use for components to properly connect to
 	// HTTPListenAddr. If nil, DialFunc defaults to (&net.Dialer{}).DialContext.
 	DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+	// ComponentCPUSamplingInterval is how often CPU profiles are taken to
+	// estimate the CPU time spent by each component. The CPU time of
+	// components isn't sampled if ComponentCPUSamplingInterval is 0.
+	ComponentCPUSamplingInterval time.Duration
+
+	// ComponentOutputs returns the totals of the output counters of every
+	// component, keyed by global component ID and then by kind of output
+	// ("samples", "lines", "spans", or "bytes"). It's used by the watchdog
+	// block to detect components which stopped producing output. The
+	// watchdog block has no effect if ComponentOutputs is nil.
+	ComponentOutputs func() (map[string]map[string]float64, error)
+
+	// ComponentPolicy restricts which components may be used by the
+	// controller and the controllers of its modules. Loading a config which
+	// uses a component the policy doesn't allow fails. Every component may be
+	// used if ComponentPolicy is nil.
+	ComponentPolicy *componentpolicy.Policy
+
+	// restarts holds the restart policies of components. It's only set for
+	// the controllers of modules, which use the policies of their parent.
+	restarts *controller.RestartPolicies
+
+	// watchdog checks the output of components. It's only set for the
+	// controllers of modules, which use the watchdog of their parent.
+	watchdog *controller.Watchdog
+
+	// module is set for the controllers of modules, whose components are
+	// accounted for by the resource tracker of the root controller.
+	module bool
 }
 
 // Flow is the Flow system.
@@ -123,11 +167,14 @@ type Flow struct {
 	log       *logging.Logger
 	tracer    *tracing.Tracer
 	clusterer *cluster.Clusterer
+	events    *events.Client
 	opts      Options
 
 	updateQueue *controller.Queue
 	sched       *controller.Scheduler
 	loader      *controller.Loader
+	resources   *controller.ResourceTracker // nil for the controllers of modules.
+	watchdog    *controller.Watchdog        // Only run by the root controller.
 
 	loadFinished chan struct{}
 
@@ -142,8 +189,34 @@ func New(o Options) *Flow {
 		log       = o.Logger
 		tracer    = o.Tracer
 		clusterer = o.Clusterer
+		kvStore   = o.KV
+		eventBus  = o.Events
+		restarts  = o.restarts
+		watchdog  = o.watchdog
 	)
 
+	if kvStore == nil {
+		// Creating an in-memory store never fails.
+		kvStore, _ = kv.New("")
+	}
+	if eventBus == nil {
+		eventBus = events.NewBus()
+	}
+	if restarts == nil {
+		restarts = controller.NewRestartPolicies()
+	}
+	if watchdog == nil {
+		watchdog = controller.NewWatchdog(log, o.ComponentOutputs, eventBus, o.Reg)
+	}
+
+	var resources *controller.ResourceTracker
+	if !o.module {
+		resources = controller.NewResourceTracker(log, o.ComponentCPUSamplingInterval)
+		if o.Reg != nil {
+			o.Reg.MustRegister(resources)
+		}
+	}
+
 	if tracer == nil {
 		var err error
 		tracer, err = tracing.New(tracing.DefaultOptions)
@@ -164,7 +237,12 @@ func New(o Options) *Flow {
 		loader = controller.NewLoader(controller.ComponentGlobals{
 			Logger:        log,
 			TraceProvider: tracer,
+			Restarts:      restarts,
+			Watchdog:      watchdog,
+			Policy:        o.ComponentPolicy,
 			Clusterer:     clusterer,
+			KV:            kvStore,
+			Events:        eventBus,
 			DataPath:      o.DataPath,
 			OnComponentUpdate: func(cn *controller.ComponentNode) {
 				// Changed components should be queued for reevaluation.
@@ -180,7 +258,12 @@ func New(o Options) *Flow {
 				return newModuleController(&moduleControllerOptions{
 					Logger:         log,
 					Tracer:         tracer,
+					Restarts:       restarts,
+					Watchdog:       watchdog,
+					Policy:         o.ComponentPolicy,
 					Clusterer:      clusterer,
+					KV:             kvStore,
+					Events:         eventBus,
 					Reg:            o.Reg,
 					DataPath:       o.DataPath,
 					HTTPListenAddr: o.HTTPListenAddr,
@@ -197,9 +280,12 @@ func New(o Options) *Flow {
 		opts:   o,
 
 		clusterer:   clusterer,
+		events:      eventBus.Client(o.ControllerID),
 		updateQueue: queue,
 		sched:       sched,
 		loader:      loader,
+		resources:   resources,
+		watchdog:    watchdog,
 
 		loadFinished: make(chan struct{}, 1),
 	}
@@ -212,6 +298,13 @@ func (f *Flow) Run(ctx context.Context) {
 	defer f.loader.Cleanup()
 	defer level.Debug(f.log).Log("msg", "flow controller exiting")
 
+	if f.resources != nil {
+		go f.resources.Run(ctx)
+	}
+	if !f.opts.module {
+		go f.watchdog.Run(ctx)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -265,6 +358,16 @@ func (f *Flow) LoadFile(file *File, args map[string]any) error {
 	}
 	f.loadedOnce.Store(true)
 
+	var numErrors int
+	for _, d := range diags {
+		if d.Severity == diag.SeverityLevelError {
+			numErrors++
+		}
+	}
+	f.events.Publish(events.TypeConfigApplied, "config loaded", map[string]string{
+		"errors": strconv.Itoa(numErrors),
+	})
+
 	select {
 	case f.loadFinished <- struct{}{}:
 	default:
@@ -273,6 +376,17 @@ func (f *Flow) LoadFile(file *File, args map[string]any) error {
 	return diags.ErrorOrNil()
 }
 
+// ComponentChanges describes how the components of the controller changed
+// when a config file was loaded.
+type ComponentChanges = controller.ComponentChanges
+
+// ComponentChanges returns the changes made to the components by the most
+// recent call to LoadFile. Components kept between two loads are only updated
+// if their evaluated arguments changed, and are never re-created.
+func (f *Flow) ComponentChanges() ComponentChanges {
+	return f.loader.Changes()
+}
+
 // Ready returns whether the Flow controller has finished its initial load.
 func (f *Flow) Ready() bool {
 	return f.loadedOnce.Load()
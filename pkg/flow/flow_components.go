@@ -107,6 +107,7 @@ func (f *Flow) getComponentDetail(cn *controller.ComponentNode, graph *dag.Graph
 
 		Registration: cn.Registration(),
 		Health:       health,
+		Migrations:   cn.Migrations(),
 
 		Arguments: arguments,
 		Exports:   exports,
@@ -0,0 +1,106 @@
+package flow
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/river/token"
+	"github.com/grafana/agent/pkg/river/token/builder"
+)
+
+// EvaluatedConfig returns the config loaded by the Flow controller as River
+// text, with every block holding the arguments it was last evaluated to
+// rather than the expressions written in the config file. Values of secret
+// types are redacted.
+//
+// The config of the modules run by the controller's components follows the
+// config of the controller, with each module introduced by a comment holding
+// its ID.
+func (f *Flow) EvaluatedConfig() ([]byte, error) {
+	file := builder.NewFile()
+	f.appendEvaluatedConfig(file.Body())
+
+	var buf bytes.Buffer
+	if _, err := file.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (f *Flow) appendEvaluatedConfig(body *builder.Body) {
+	var (
+		nodes   []controller.BlockNode
+		modules []*module
+	)
+
+	f.loadMut.RLock()
+	for _, n := range f.loader.OriginalGraph().Nodes() {
+		bn, ok := n.(controller.BlockNode)
+		if !ok || bn.Block() == nil {
+			// Skip nodes which don't come from a block in the config, such as
+			// the default logging block.
+			continue
+		}
+		nodes = append(nodes, bn)
+
+		if cn, ok := n.(*controller.ComponentNode); ok {
+			if mc, ok := cn.ModuleController().(*moduleController); ok {
+				modules = append(modules, mc.loadedModules()...)
+			}
+		}
+	}
+	f.loadMut.RUnlock()
+
+	// Print blocks in the order they're defined in. Offsets are only
+	// comparable within the same file when the config is read from multiple
+	// files.
+	sort.Slice(nodes, func(i, j int) bool {
+		pi, pj := nodes[i].Block().NamePos.Position(), nodes[j].Block().NamePos.Position()
+		if pi.Filename != pj.Filename {
+			return pi.Filename < pj.Filename
+		}
+		return nodes[i].Block().NamePos.Offset() < nodes[j].Block().NamePos.Offset()
+	})
+	for _, bn := range nodes {
+		body.AppendBlock(evaluatedBlock(bn))
+	}
+
+	for _, mod := range modules {
+		body.AppendTokens([]builder.Token{
+			{Tok: token.LITERAL, Lit: "\n"},
+			{Tok: token.COMMENT, Lit: fmt.Sprintf("// Module %q.", mod.o.ID)},
+		})
+		mod.controller().appendEvaluatedConfig(body)
+	}
+}
+
+// evaluatedBlock returns the block of bn holding its evaluated arguments.
+func evaluatedBlock(bn controller.BlockNode) (b *builder.Block) {
+	block := bn.Block()
+	b = builder.NewBlock(block.Name, block.Label)
+
+	var args any
+	switch bn := bn.(type) {
+	case *controller.ComponentNode:
+		args = bn.Arguments()
+	case interface{ Arguments() any }:
+		args = bn.Arguments()
+	}
+	if args == nil {
+		b.Body().AppendTokens([]builder.Token{{Tok: token.COMMENT, Lit: "// Not evaluated successfully yet."}})
+		return b
+	}
+
+	defer func() {
+		// Encoding arbitrary arguments shouldn't fail, but a single block which
+		// can't be encoded mustn't prevent retrieving the rest of the config.
+		if err := recover(); err != nil {
+			b = builder.NewBlock(block.Name, block.Label)
+			b.Body().AppendTokens([]builder.Token{{Tok: token.COMMENT, Lit: fmt.Sprintf("// Failed to encode arguments: %v", err)}})
+		}
+	}()
+	b.Body().AppendFrom(args)
+	return b
+}
@@ -0,0 +1,91 @@
+package flow
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_EvaluatedConfig(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(`
+		testcomponents.passthrough "static" {
+			input = "hello, world!"
+		}
+
+		test.secret "default" {
+			password = testcomponents.passthrough.static.output
+			username = "bob"
+		}
+
+		test.module "mod" {
+			content = "export \"greeting\" { value = \"hi\" }"
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+
+	expect := strings.TrimSpace(`
+testcomponents.passthrough "static" {
+	input = "hello, world!"
+}
+
+test.secret "default" {
+	username = "bob"
+	password = (secret)
+}
+
+test.module "mod" {
+	content = "export \"greeting\" { value = \"hi\" }"
+}
+
+// Module "test.module.mod/t1".
+export "greeting" {
+	value = "hi"
+}`)
+
+	// The module is only loaded once test.module runs.
+	var actual string
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		bb, err := ctrl.EvaluatedConfig()
+		require.NoError(t, err)
+		if actual = strings.TrimSpace(string(bb)); actual == expect {
+			break
+		}
+	}
+	require.Equal(t, expect, actual)
+}
+
+func init() {
+	component.Register(component.Registration{
+		Name: "test.secret",
+		Args: testSecretArguments{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return &testSecret{}, nil
+		},
+	})
+}
+
+type testSecretArguments struct {
+	Username string            `river:"username,attr"`
+	Password rivertypes.Secret `river:"password,attr"`
+}
+
+type testSecret struct{}
+
+func (t *testSecret) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (t *testSecret) Update(args component.Arguments) error { return nil }
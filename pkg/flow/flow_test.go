@@ -1,16 +1,22 @@
 package flow
 
 import (
+	"context"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/events"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/internal/testcomponents"
 	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/river"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
 )
 
 var testFile = `
@@ -50,6 +56,192 @@ func TestController_LoadFile_Evaluation(t *testing.T) {
 	require.Equal(t, "hello, world!", out.(testcomponents.PassthroughExports).Output)
 }
 
+func TestController_LoadFile_Events(t *testing.T) {
+	opts := testOptions(t)
+	opts.Events = events.NewBus()
+	sub := opts.Events.Subscribe(events.Filter{}, 100)
+	defer sub.Close()
+
+	ctrl := New(opts)
+	f, err := ReadFile(t.Name(), []byte(testFile))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	var applied *events.Event
+	for len(sub.Events()) > 0 {
+		e := <-sub.Events()
+		if e.Type == events.TypeConfigApplied {
+			applied = &e
+		}
+	}
+	require.NotNil(t, applied)
+	require.Equal(t, "", applied.Source)
+	require.Equal(t, map[string]string{"errors": "0"}, applied.Attributes)
+}
+
+func TestController_RestartPolicy(t *testing.T) {
+	ctrl := New(testOptions(t))
+
+	f, err := ReadFile(t.Name(), []byte(`
+		restart {
+			max_restarts = 2
+			min_backoff  = "10ms"
+			max_backoff  = "10ms"
+
+			component {
+				id           = "testcomponents.fail.never"
+				max_restarts = 0
+			}
+		}
+
+		testcomponents.fail "retried" {
+			error = "oops"
+		}
+
+		testcomponents.fail "never" {
+			error = "oops"
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+
+	nodeHealth := func(nodeID string) component.Health {
+		return ctrl.loader.Graph().GetByID(nodeID).(*controller.ComponentNode).CurrentHealth()
+	}
+
+	// The component is given up on after the initial run and 2 restarts.
+	require.Eventually(t, func() bool {
+		return nodeHealth("testcomponents.fail.retried").Health == component.HealthTypeUnhealthy &&
+			strings.Contains(nodeHealth("testcomponents.fail.retried").Message, "after 2 restarts")
+	}, 5*time.Second, 10*time.Millisecond)
+	_, out := getFields(t, ctrl.loader.Graph(), "testcomponents.fail.retried")
+	require.Equal(t, 3, out.(testcomponents.FailExports).Runs)
+
+	// The component overriding the policy is never restarted.
+	require.Eventually(t, func() bool {
+		return nodeHealth("testcomponents.fail.never").Health == component.HealthTypeExited
+	}, 5*time.Second, 10*time.Millisecond)
+	_, out = getFields(t, ctrl.loader.Graph(), "testcomponents.fail.never")
+	require.Equal(t, 1, out.(testcomponents.FailExports).Runs)
+}
+
+func TestController_Watchdog(t *testing.T) {
+	var output atomic.Float64
+	opts := testOptions(t)
+	opts.Events = events.NewBus()
+	opts.ComponentOutputs = func() (map[string]map[string]float64, error) {
+		return map[string]map[string]float64{
+			"testcomponents.tick.ticker": {"lines": output.Load(), "bytes": 1000 * output.Load()},
+		}, nil
+	}
+	ctrl := New(opts)
+
+	f, err := ReadFile(t.Name(), []byte(`
+		watchdog {
+			check_interval = "10ms"
+
+			component {
+				id     = "testcomponents.tick.ticker"
+				window = "50ms"
+			}
+		}
+
+		testcomponents.tick "ticker" {
+			frequency = "1s"
+		}
+	`))
+	require.NoError(t, err)
+	sub := opts.Events.Subscribe(events.Filter{Types: []string{"component.output_*"}}, 10)
+	defer sub.Close()
+	require.NoError(t, ctrl.LoadFile(f, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctrl.Run(ctx)
+
+	nodeHealth := func() component.Health {
+		return ctrl.loader.Graph().GetByID("testcomponents.tick.ticker").(*controller.ComponentNode).CurrentHealth()
+	}
+
+	// The component is unhealthy once it produced no output for a window.
+	require.Eventually(t, func() bool {
+		return nodeHealth().Health == component.HealthTypeUnhealthy
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, nodeHealth().Message, "produced 0 items in the last 50ms")
+	stalled := <-sub.Events()
+	require.Equal(t, "component.output_stalled", stalled.Type)
+	require.Equal(t, "testcomponents.tick.ticker", stalled.Source)
+
+	// And healthy again once it produces output.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Millisecond):
+				output.Add(1)
+			}
+		}
+	}()
+	require.Eventually(t, func() bool {
+		return nodeHealth().Health == component.HealthTypeHealthy
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "component.output_resumed", (<-sub.Events()).Type)
+}
+
+func TestWatchdog_Validate(t *testing.T) {
+	tt := []struct {
+		config string
+		expect string
+	}{
+		{
+			config: `component { id = "a" }` + "\n" + `component { id = "a" }`,
+			expect: `watchdog for component "a" already declared`,
+		},
+		{
+			config: "check_interval = \"1m\"\n" + `component {
+				id     = "a"
+				window = "30s"
+			}`,
+			expect: "window must not be less than check_interval",
+		},
+		{
+			config: `component {
+				id   = "a"
+				kind = "entries"
+			}`,
+			expect: `unrecognized kind "entries"`,
+		},
+		{
+			config: `component {
+				id         = "a"
+				min_output = 0
+			}`,
+			expect: "min_output must be greater than 0",
+		},
+	}
+
+	for _, tc := range tt {
+		var args controller.WatchdogArguments
+		err := river.Unmarshal([]byte(tc.config), &args)
+		require.ErrorContains(t, err, tc.expect)
+	}
+}
+
+func TestRestartPolicy_Backoff(t *testing.T) {
+	p := controller.RestartPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	require.Equal(t, time.Second, p.Backoff(1))
+	require.Equal(t, 2*time.Second, p.Backoff(2))
+	require.Equal(t, 4*time.Second, p.Backoff(3))
+	require.Equal(t, 5*time.Second, p.Backoff(4))
+}
+
 func getFields(t *testing.T, g *dag.Graph, nodeID string) (component.Arguments, component.Exports) {
 	t.Helper()
 
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// enabledAttr is the name of the attribute which determines whether a
+// top-level block is loaded.
+const enabledAttr = "enabled"
+
+// enabledBlocks returns the blocks whose enabled attribute is true or unset,
+// with the enabled attribute removed from their body.
+//
+// The enabled attribute is evaluated before the graph is built, so it may only
+// reference the stdlib and module arguments.
+func (l *Loader) enabledBlocks(blocks []*ast.BlockStmt) ([]*ast.BlockStmt, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	scope := &vm.Scope{Variables: map[string]interface{}{}}
+	if args, ok := l.cache.BuildContext().Variables["argument"]; ok {
+		scope.Variables["argument"] = args
+	}
+
+	res := make([]*ast.BlockStmt, 0, len(blocks))
+	for _, block := range blocks {
+		attr, body := splitEnabledAttr(block.Body)
+		if attr == nil {
+			res = append(res, block)
+			continue
+		}
+
+		var enabled bool
+		if err := vm.New(attr.Value).Evaluate(scope, &enabled); err != nil {
+			var evalDiags diag.Diagnostics
+			if errors.As(err, &evalDiags) {
+				diags = append(diags, evalDiags...)
+				continue
+			}
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  fmt.Sprintf("failed to evaluate %s attribute: %s", enabledAttr, err),
+				StartPos: ast.StartPos(attr).Position(),
+				EndPos:   ast.EndPos(attr).Position(),
+			})
+			continue
+		}
+		if !enabled {
+			level.Debug(l.log).Log("msg", "skipping disabled block", "block", BlockComponentID(block).String())
+			continue
+		}
+
+		enabledBlock := *block
+		enabledBlock.Body = body
+		res = append(res, &enabledBlock)
+	}
+
+	return res, diags
+}
+
+// splitEnabledAttr returns the enabled attribute of body, if any, and body
+// without it.
+func splitEnabledAttr(body ast.Body) (*ast.AttributeStmt, ast.Body) {
+	for i, stmt := range body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok || attr.Name.Name != enabledAttr {
+			continue
+		}
+
+		rest := make(ast.Body, 0, len(body)-1)
+		rest = append(rest, body[:i]...)
+		rest = append(rest, body[i+1:]...)
+		return attr, rest
+	}
+	return nil, body
+}
@@ -17,8 +17,11 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/events"
+	"github.com/grafana/agent/pkg/flow/componentpolicy"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/kv"
 	"github.com/grafana/agent/pkg/river/ast"
 	"github.com/grafana/agent/pkg/river/vm"
 	"github.com/prometheus/client_golang/prometheus"
@@ -67,7 +70,12 @@ type DialFunc func(ctx context.Context, network, address string) (net.Conn, erro
 type ComponentGlobals struct {
 	Logger              *logging.Logger                            // Logger shared between all managed components.
 	TraceProvider       trace.TracerProvider                       // Tracer shared between all managed components.
+	Restarts            *RestartPolicies                           // Restart policies shared between all managed components.
+	Watchdog            *Watchdog                                  // Watchdog checking the output of all managed components.
+	Policy              *componentpolicy.Policy                    // Policy restricting which components may be used. May be nil.
 	Clusterer           *cluster.Clusterer                         // Clusterer shared between all managed components.
+	KV                  *kv.Store                                  // Key-value store shared between all managed components.
+	Events              *events.Bus                                // Event bus shared between all managed components.
 	DataPath            string                                     // Shared directory where component data may be stored
 	OnComponentUpdate   func(cn *ComponentNode)                    // Informs controller that we need to reevaluate
 	OnExportsChange     func(exports map[string]any)               // Invoked when the managed component updated its exports
@@ -92,6 +100,9 @@ type ComponentNode struct {
 	reg               component.Registration
 	managedOpts       component.Options
 	registry          *prometheus.Registry
+	restarts          *RestartPolicies
+	watchdog          *Watchdog
+	restartsTotal     prometheus.Counter
 	exportsType       reflect.Type
 	OnComponentUpdate func(cn *ComponentNode) // Informs controller that we need to reevaluate
 
@@ -100,6 +111,12 @@ type ComponentNode struct {
 	eval    *vm.Evaluator
 	managed component.Component // Inner managed component
 	args    component.Arguments // Evaluated arguments for the managed component
+	change  evalChange          // Outcome of the last evaluation
+
+	// Migrations of deprecated arguments applied to the current block, and
+	// the error migrating it. Guarded by mut.
+	migrations []component.AppliedMigration
+	migrateErr error
 
 	doingEval atomic.Bool
 
@@ -146,11 +163,10 @@ func NewComponentNode(globals ComponentGlobals, b *ast.BlockStmt) *ComponentNode
 		componentName:     strings.Join(b.Name, "."),
 		reg:               reg,
 		exportsType:       getExportsType(reg),
+		restarts:          globals.Restarts,
+		watchdog:          globals.Watchdog,
 		OnComponentUpdate: globals.OnComponentUpdate,
 
-		block: b,
-		eval:  vm.New(b.Body),
-
 		// Prepopulate arguments and exports with their zero values.
 		args:    reg.Args,
 		exports: reg.Exports,
@@ -159,6 +175,13 @@ func NewComponentNode(globals ComponentGlobals, b *ast.BlockStmt) *ComponentNode
 		runHealth:  initHealth,
 	}
 	cn.managedOpts = getManagedOptions(globals, cn)
+	cn.setBlock(b)
+
+	cn.restartsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "agent_component_restarts_total",
+		Help: "Total number of times the component was restarted after exiting with an error.",
+	})
+	_ = cn.managedOpts.Registerer.Register(cn.restartsTotal)
 
 	return cn
 }
@@ -180,6 +203,15 @@ func getManagedOptions(globals ComponentGlobals, cn *ComponentNode) component.Op
 		globalID = path.Join(globals.ControllerID, cn.nodeID)
 	}
 
+	var kvBucket *kv.Bucket
+	if globals.KV != nil {
+		kvBucket = globals.KV.Bucket(globalID)
+	}
+	var eventsClient *events.Client
+	if globals.Events != nil {
+		eventsClient = globals.Events.Client(globalID)
+	}
+
 	cn.registry = prometheus.NewRegistry()
 	return component.Options{
 		ID:     globalID,
@@ -189,6 +221,8 @@ func getManagedOptions(globals ComponentGlobals, cn *ComponentNode) component.Op
 		}, cn.registry),
 		Tracer:    tracing.WrapTracer(globals.TraceProvider, globalID),
 		Clusterer: globals.Clusterer,
+		KV:        kvBucket,
+		Events:    eventsClient,
 
 		DataPath:       filepath.Join(globals.DataPath, cn.nodeID),
 		HTTPListenAddr: globals.HTTPListenAddr,
@@ -245,8 +279,27 @@ func (cn *ComponentNode) UpdateBlock(b *ast.BlockStmt) {
 
 	cn.mut.Lock()
 	defer cn.mut.Unlock()
+	cn.setBlock(b)
+}
+
+// setBlock sets the current block of the component, migrating its deprecated
+// arguments. cn.mut must be held when calling setBlock, unless cn hasn't been
+// returned from NewComponentNode yet.
+func (cn *ComponentNode) setBlock(b *ast.BlockStmt) {
 	cn.block = b
-	cn.eval = vm.New(b.Body)
+
+	body, migrations, err := migrateBody(b.Body, cn.reg.Migrations)
+	if err != nil {
+		cn.migrations, cn.migrateErr = nil, err
+		cn.eval = vm.New(b.Body)
+		return
+	}
+	for _, m := range migrations {
+		level.Warn(cn.managedOpts.Logger).Log("msg", "rewrote deprecated argument, update the configuration to use its replacement",
+			"deprecated", m.Deprecated, "replacement", m.ReplacementPath(), "since", m.Since, "position", m.Position)
+	}
+	cn.migrations, cn.migrateErr = migrations, nil
+	cn.eval = vm.New(body)
 }
 
 // Evaluate implements BlockNode and updates the arguments for the managed component
@@ -287,7 +340,7 @@ func (cn *ComponentNode) Reevaluate() error {
 	}
 
 	// Update the existing managed component with the same arguments.
-	err := cn.managed.Update(cn.args)
+	err := cn.updateManaged(cn.args)
 
 	switch err {
 	case nil:
@@ -307,6 +360,13 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 	cn.doingEval.Store(true)
 	defer cn.doingEval.Store(false)
 
+	// The change is overwritten below once evaluation succeeds.
+	cn.change = evalFailed
+
+	if cn.migrateErr != nil {
+		return fmt.Errorf("migrating deprecated arguments: %w", cn.migrateErr)
+	}
+
 	argsPointer := cn.reg.CloneArguments()
 	if err := cn.eval.Evaluate(scope, argsPointer); err != nil {
 		return fmt.Errorf("decoding River: %w", err)
@@ -318,12 +378,19 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 
 	if cn.managed == nil {
 		// We haven't built the managed component successfully yet.
-		managed, err := cn.reg.Build(cn.managedOpts, argsCopyValue)
+		var (
+			managed component.Component
+			err     error
+		)
+		withComponentLabels(context.Background(), cn.managedOpts.ID, func(context.Context) {
+			managed, err = cn.reg.Build(cn.managedOpts, argsCopyValue)
+		})
 		if err != nil {
 			return fmt.Errorf("building component: %w", err)
 		}
 		cn.managed = managed
 		cn.args = argsCopyValue
+		cn.change = evalBuilt
 
 		return nil
 	}
@@ -332,22 +399,45 @@ func (cn *ComponentNode) evaluate(scope *vm.Scope) error {
 		// Ignore components which haven't changed. This reduces the cost of
 		// calling evaluate for components where evaluation is expensive (e.g., if
 		// re-evaluating requires re-starting some internal logic).
+		cn.change = evalUnchanged
 		return nil
 	}
 
 	// Update the existing managed component
-	if err := cn.managed.Update(argsCopyValue); err != nil {
+	if err := cn.updateManaged(argsCopyValue); err != nil {
 		return fmt.Errorf("updating component: %w", err)
 	}
 
 	cn.args = argsCopyValue
+	cn.change = evalUpdated
 	return nil
 }
 
+// updateManaged updates the managed component with args. cn.mut must be
+// held.
+func (cn *ComponentNode) updateManaged(args component.Arguments) error {
+	var err error
+	withComponentLabels(context.Background(), cn.managedOpts.ID, func(context.Context) {
+		err = cn.managed.Update(args)
+	})
+	return err
+}
+
+// lastChange returns the outcome of the last evaluation of the component.
+func (cn *ComponentNode) lastChange() evalChange {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.change
+}
+
 // Run runs the managed component in the calling goroutine until ctx is
 // canceled. Evaluate must have been called at least once without retuning an
 // error before calling Run.
 //
+// If the managed component exits with an error, it's restarted according to
+// its restart policy. Once the policy gives up on restarting it, the component
+// is marked as unhealthy and Run returns the error.
+//
 // Run will immediately return ErrUnevaluated if Evaluate has never been called
 // successfully. Otherwise, Run will return nil.
 func (cn *ComponentNode) Run(ctx context.Context) error {
@@ -359,21 +449,71 @@ func (cn *ComponentNode) Run(ctx context.Context) error {
 		return ErrUnevaluated
 	}
 
-	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
-
-	var exitMsg string
 	logger := cn.managedOpts.Logger
-	if err != nil {
-		level.Error(logger).Log("msg", "component exited with error", "err", err)
-		exitMsg = fmt.Sprintf("component shut down with error: %s", err)
-	} else {
-		level.Info(logger).Log("msg", "component exited")
-		exitMsg = "component shut down normally"
+
+	var restarts int
+	for {
+		cn.setRunHealth(component.HealthTypeHealthy, "started component")
+		started := time.Now()
+		var err error
+		withComponentLabels(ctx, cn.managedOpts.ID, func(ctx context.Context) {
+			err = managed.Run(ctx)
+		})
+
+		if err == nil || ctx.Err() != nil {
+			var exitMsg string
+			if err != nil {
+				level.Error(logger).Log("msg", "component exited with error", "err", err)
+				exitMsg = fmt.Sprintf("component shut down with error: %s", err)
+			} else {
+				level.Info(logger).Log("msg", "component exited")
+				exitMsg = "component shut down normally"
+			}
+			cn.setRunHealth(component.HealthTypeExited, exitMsg)
+			return err
+		}
+
+		policy := cn.restartPolicy()
+		// A component which ran for longer than the maximum backoff is
+		// considered to have recovered from its previous failures.
+		if time.Since(started) >= policy.MaxBackoff {
+			restarts = 0
+		}
+
+		switch {
+		case policy.MaxRestarts == 0:
+			level.Error(logger).Log("msg", "component exited with error", "err", err)
+			cn.setRunHealth(component.HealthTypeExited, fmt.Sprintf("component shut down with error: %s", err))
+			return err
+		case policy.MaxRestarts > 0 && restarts >= policy.MaxRestarts:
+			level.Error(logger).Log("msg", "component exited with error, giving up on restarting it", "restarts", restarts, "err", err)
+			cn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("component shut down with error after %d restarts: %s", restarts, err))
+			return err
+		}
+
+		restarts++
+		backoff := policy.Backoff(restarts)
+		level.Error(logger).Log("msg", "component exited with error, restarting it", "restart", restarts, "backoff", backoff, "err", err)
+		cn.setRunHealth(component.HealthTypeUnhealthy, fmt.Sprintf("component shut down with error, restarting in %s: %s", backoff, err))
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			cn.setRunHealth(component.HealthTypeExited, fmt.Sprintf("component shut down with error: %s", err))
+			return err
+		case <-t.C:
+		}
+		cn.restartsTotal.Inc()
 	}
+}
 
-	cn.setRunHealth(component.HealthTypeExited, exitMsg)
-	return err
+// restartPolicy returns the current restart policy of the managed component.
+func (cn *ComponentNode) restartPolicy() RestartPolicy {
+	if cn.restarts == nil {
+		return DefaultRestartPolicy
+	}
+	return cn.restarts.Policy(cn.managedOpts.ID)
 }
 
 // ErrUnevaluated is returned if ComponentNode.Run is called before a managed
@@ -387,6 +527,12 @@ func (cn *ComponentNode) Arguments() component.Arguments {
 	return cn.args
 }
 
+// ModuleController returns the controller of the modules created by the
+// managed component.
+func (cn *ComponentNode) ModuleController() component.ModuleController {
+	return cn.managedOpts.ModuleController
+}
+
 // Block implements BlockNode and returns the current block of the managed component.
 func (cn *ComponentNode) Block() *ast.BlockStmt {
 	cn.mut.RLock()
@@ -394,6 +540,14 @@ func (cn *ComponentNode) Block() *ast.BlockStmt {
 	return cn.block
 }
 
+// Migrations returns the migrations of deprecated arguments which were applied
+// to the current block of the managed component.
+func (cn *ComponentNode) Migrations() []component.AppliedMigration {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.migrations
+}
+
 // Exports returns the current set of exports from the managed component.
 // Exports returns nil if the managed component does not have exports.
 func (cn *ComponentNode) Exports() component.Exports {
@@ -451,21 +605,22 @@ func (cn *ComponentNode) setExports(e component.Exports) {
 //  1. Health from the call to Run().
 //  2. Health from the last call to Evaluate().
 //  3. Health reported from the component.
+//  4. Health reported by the watchdog, if the component stopped producing the
+//     output declared in the watchdog block.
 func (cn *ComponentNode) CurrentHealth() component.Health {
 	cn.healthMut.RLock()
 	defer cn.healthMut.RUnlock()
 
-	var (
-		runHealth  = cn.runHealth
-		evalHealth = cn.evalHealth
-	)
+	healths := []component.Health{cn.runHealth, cn.evalHealth}
 
 	if hc, ok := cn.managed.(component.HealthComponent); ok {
-		componentHealth := hc.CurrentHealth()
-		return component.LeastHealthy(runHealth, evalHealth, componentHealth)
+		healths = append(healths, hc.CurrentHealth())
+	}
+	if h, ok := cn.watchdog.Health(cn.managedOpts.ID); ok {
+		healths = append(healths, h)
 	}
 
-	return component.LeastHealthy(runHealth, evalHealth)
+	return component.LeastHealthy(healths[0], healths[1:]...)
 }
 
 // DebugInfo returns debugging information from the managed component (if any).
@@ -485,11 +640,13 @@ func (cn *ComponentNode) setEvalHealth(t component.HealthType, msg string) {
 	cn.healthMut.Lock()
 	defer cn.healthMut.Unlock()
 
+	prev := component.LeastHealthy(cn.runHealth, cn.evalHealth)
 	cn.evalHealth = component.Health{
 		Health:     t,
 		Message:    msg,
 		UpdateTime: time.Now(),
 	}
+	cn.publishHealthChange(prev)
 }
 
 // setRunHealth sets the internal health from a call to Run. See Health for
@@ -498,11 +655,27 @@ func (cn *ComponentNode) setRunHealth(t component.HealthType, msg string) {
 	cn.healthMut.Lock()
 	defer cn.healthMut.Unlock()
 
+	prev := component.LeastHealthy(cn.runHealth, cn.evalHealth)
 	cn.runHealth = component.Health{
 		Health:     t,
 		Message:    msg,
 		UpdateTime: time.Now(),
 	}
+	cn.publishHealthChange(prev)
+}
+
+// publishHealthChange publishes an event if the health tracked by the
+// controller changed from prev. healthMut must be held when calling
+// publishHealthChange.
+func (cn *ComponentNode) publishHealthChange(prev component.Health) {
+	cur := component.LeastHealthy(cn.runHealth, cn.evalHealth)
+	if cur.Health == prev.Health {
+		return
+	}
+	cn.managedOpts.Events.Publish(events.TypeComponentHealthChanged, cur.Message, map[string]string{
+		"health":          cur.Health.String(),
+		"previous_health": prev.Health.String(),
+	})
 }
 
 // HTTPHandler returns an http handler for a component IF it implements HTTPComponent.
@@ -0,0 +1,91 @@
+package controller
+
+import "sort"
+
+// evalChange describes what the most recent evaluation of a ComponentNode did
+// to its managed component.
+type evalChange int
+
+const (
+	evalFailed    evalChange = iota // Evaluation failed.
+	evalBuilt                       // The managed component was built.
+	evalUpdated                     // The managed component was updated with new arguments.
+	evalUnchanged                   // The arguments didn't change; the managed component was left untouched.
+)
+
+// String returns the name of the change, as used in the spans of component
+// evaluations.
+func (c evalChange) String() string {
+	switch c {
+	case evalFailed:
+		return "failed"
+	case evalBuilt:
+		return "built"
+	case evalUpdated:
+		return "updated"
+	case evalUnchanged:
+		return "unchanged"
+	default:
+		return "unknown"
+	}
+}
+
+// ComponentChanges describes how the components of a Loader changed during a
+// call to Apply. Components are identified by their node ID.
+//
+// Components are never re-created by Apply: components which are kept between
+// two calls to Apply are only updated in place if their evaluated arguments
+// changed, so that they keep their running state.
+type ComponentChanges struct {
+	// Added holds the components which were built.
+	Added []string `json:"added,omitempty"`
+	// Updated holds the existing components which were updated because their
+	// evaluated arguments changed.
+	Updated []string `json:"updated,omitempty"`
+	// Removed holds the components which were removed from the config and
+	// stopped.
+	Removed []string `json:"removed,omitempty"`
+	// Failed holds the components which couldn't be evaluated.
+	Failed []string `json:"failed,omitempty"`
+	// Unchanged is the number of components whose evaluated arguments didn't
+	// change.
+	Unchanged int `json:"unchanged"`
+}
+
+// record records the outcome of evaluating the component with the given node
+// ID.
+func (cc *ComponentChanges) record(nodeID string, change evalChange) {
+	switch change {
+	case evalFailed:
+		cc.Failed = append(cc.Failed, nodeID)
+	case evalBuilt:
+		cc.Added = append(cc.Added, nodeID)
+	case evalUpdated:
+		cc.Updated = append(cc.Updated, nodeID)
+	case evalUnchanged:
+		cc.Unchanged++
+	}
+}
+
+// recordRemoved records the components of prev which aren't in next as
+// removed.
+func (cc *ComponentChanges) recordRemoved(prev, next []*ComponentNode) {
+	kept := make(map[string]struct{}, len(next))
+	for _, cn := range next {
+		kept[cn.NodeID()] = struct{}{}
+	}
+	for _, cn := range prev {
+		if _, ok := kept[cn.NodeID()]; !ok {
+			cc.Removed = append(cc.Removed, cn.NodeID())
+		}
+	}
+}
+
+// sort sorts the lists of components of cc by node ID, since components are
+// recorded in evaluation order.
+func (cc *ComponentChanges) sort() {
+	sort.Strings(cc.Added)
+	sort.Strings(cc.Updated)
+	sort.Strings(cc.Removed)
+	sort.Strings(cc.Failed)
+}
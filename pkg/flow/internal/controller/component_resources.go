@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// componentIDLabel is the pprof label holding the global ID of the component
+// which started a goroutine.
+const componentIDLabel = "component_id"
+
+// goroutinesCacheTTL is how long the goroutine counts of components are
+// reused, so that frequent scrapes don't repeatedly stop the world to take
+// goroutine profiles.
+const goroutinesCacheTTL = 5 * time.Second
+
+// cpuProfileDuration is the longest a CPU profile sampling the CPU time of
+// components runs for.
+const cpuProfileDuration = 10 * time.Second
+
+// withComponentLabels calls f with the pprof labels attributing its work, and
+// the work of the goroutines it starts, to the component with the given
+// global ID. Goroutines inherit the labels of the goroutine starting them, so
+// the labels of nested components replace the labels of their module.
+func withComponentLabels(ctx context.Context, id string, f func(context.Context)) {
+	pprof.Do(ctx, pprof.Labels(componentIDLabel, id), f)
+}
+
+// ResourceTracker attributes the goroutines and CPU time of the process to
+// the components which use them, using the pprof labels set by
+// withComponentLabels. ResourceTracker implements prometheus.Collector.
+//
+// Heap profiles don't record pprof labels, so the memory allocated by
+// components can't be attributed to them.
+type ResourceTracker struct {
+	log         log.Logger
+	cpuInterval time.Duration
+
+	goroutinesDesc *prometheus.Desc
+	cpuSecondsDesc *prometheus.Desc
+
+	mut            sync.Mutex
+	goroutines     map[string]int
+	goroutinesTime time.Time
+	cpuSeconds     map[string]float64
+}
+
+var _ prometheus.Collector = (*ResourceTracker)(nil)
+
+// NewResourceTracker creates a new ResourceTracker. The CPU time of
+// components is sampled every cpuInterval while Run is running; it's not
+// sampled if cpuInterval is 0.
+func NewResourceTracker(l log.Logger, cpuInterval time.Duration) *ResourceTracker {
+	return &ResourceTracker{
+		log:         l,
+		cpuInterval: cpuInterval,
+
+		goroutinesDesc: prometheus.NewDesc(
+			"agent_component_goroutines",
+			"Number of goroutines started by the component.",
+			[]string{"component_id"}, nil,
+		),
+		cpuSecondsDesc: prometheus.NewDesc(
+			"agent_component_cpu_seconds_total",
+			"Estimated CPU time spent by the component, extrapolated from periodic CPU profiles.",
+			[]string{"component_id"}, nil,
+		),
+
+		cpuSeconds: make(map[string]float64),
+	}
+}
+
+// Run samples the CPU time of components until ctx is canceled.
+func (rt *ResourceTracker) Run(ctx context.Context) {
+	if rt.cpuInterval <= 0 {
+		return
+	}
+
+	t := time.NewTicker(rt.cpuInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := rt.sampleCPU(ctx); err != nil {
+				level.Debug(rt.log).Log("msg", "skipped sampling the CPU time of components", "err", err)
+			}
+		}
+	}
+}
+
+// sampleCPU takes a CPU profile and adds the CPU time of each component in
+// it, scaled to the sampling interval, to its total.
+func (rt *ResourceTracker) sampleCPU(ctx context.Context) error {
+	duration := cpuProfileDuration
+	if rt.cpuInterval < duration {
+		duration = rt.cpuInterval
+	}
+
+	// Starting the profile fails if another CPU profile is running, such as
+	// one requested from /debug/pprof/profile.
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return err
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(duration):
+	}
+	pprof.StopCPUProfile()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		return err
+	}
+	cpuIndex := sampleIndex(p, "cpu")
+	if cpuIndex < 0 {
+		return nil
+	}
+
+	scale := float64(rt.cpuInterval) / float64(duration)
+
+	rt.mut.Lock()
+	defer rt.mut.Unlock()
+	for _, s := range p.Sample {
+		id := sampleComponent(s)
+		if id == "" {
+			continue
+		}
+		rt.cpuSeconds[id] += time.Duration(s.Value[cpuIndex]).Seconds() * scale
+	}
+	return nil
+}
+
+// Goroutines returns the number of goroutines started by each component,
+// keyed by the global ID of the component.
+func (rt *ResourceTracker) Goroutines() (map[string]int, error) {
+	rt.mut.Lock()
+	defer rt.mut.Unlock()
+
+	if rt.goroutines != nil && time.Since(rt.goroutinesTime) < goroutinesCacheTTL {
+		return rt.goroutines, nil
+	}
+
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]int)
+	for _, s := range p.Sample {
+		if id := sampleComponent(s); id != "" {
+			res[id] += int(s.Value[0])
+		}
+	}
+
+	rt.goroutines, rt.goroutinesTime = res, time.Now()
+	return res, nil
+}
+
+// sampleIndex returns the index of the values of the given type in the
+// samples of p, or -1 if p doesn't have values of that type.
+func sampleIndex(p *profile.Profile, typ string) int {
+	for i, st := range p.SampleType {
+		if st.Type == typ {
+			return i
+		}
+	}
+	return -1
+}
+
+// sampleComponent returns the ID of the component s is attributed to, or an
+// empty string if s isn't attributed to a component.
+func sampleComponent(s *profile.Sample) string {
+	if ids := s.Label[componentIDLabel]; len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// Describe implements prometheus.Collector.
+func (rt *ResourceTracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rt.goroutinesDesc
+	ch <- rt.cpuSecondsDesc
+}
+
+// Collect implements prometheus.Collector.
+func (rt *ResourceTracker) Collect(ch chan<- prometheus.Metric) {
+	goroutines, err := rt.Goroutines()
+	if err != nil {
+		level.Warn(rt.log).Log("msg", "failed to count the goroutines of components", "err", err)
+	}
+	for id, n := range goroutines {
+		ch <- prometheus.MustNewConstMetric(rt.goroutinesDesc, prometheus.GaugeValue, float64(n), id)
+	}
+
+	rt.mut.Lock()
+	defer rt.mut.Unlock()
+	for id, seconds := range rt.cpuSeconds {
+		ch <- prometheus.MustNewConstMetric(rt.cpuSecondsDesc, prometheus.CounterValue, seconds, id)
+	}
+}
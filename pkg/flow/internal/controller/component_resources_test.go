@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceTracker_Goroutines(t *testing.T) {
+	var (
+		started sync.WaitGroup
+		stop    = make(chan struct{})
+		stopped sync.WaitGroup
+	)
+	defer func() {
+		close(stop)
+		stopped.Wait()
+	}()
+
+	// Goroutines started by a component, and the goroutines they start, are
+	// attributed to it.
+	withComponentLabels(context.Background(), "module.file.a/local.file.b", func(context.Context) {
+		started.Add(2)
+		stopped.Add(2)
+		go func() {
+			defer stopped.Done()
+			go func() {
+				defer stopped.Done()
+				started.Done()
+				<-stop
+			}()
+			started.Done()
+			<-stop
+		}()
+	})
+	started.Wait()
+
+	rt := NewResourceTracker(log.NewNopLogger(), 0)
+	goroutines, err := rt.Goroutines()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"module.file.a/local.file.b": 2}, goroutines)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(rt)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP agent_component_goroutines Number of goroutines started by the component.
+		# TYPE agent_component_goroutines gauge
+		agent_component_goroutines{component_id="module.file.a/local.file.b"} 2
+	`), "agent_component_goroutines"))
+}
+
+func TestResourceTracker_SampleCPU(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	withComponentLabels(ctx, "local.file.busy", func(ctx context.Context) {
+		go func() {
+			defer close(done)
+			for ctx.Err() == nil {
+			}
+		}()
+	})
+
+	rt := NewResourceTracker(log.NewNopLogger(), 500*time.Millisecond)
+	require.NoError(t, rt.sampleCPU(ctx))
+
+	rt.mut.Lock()
+	defer rt.mut.Unlock()
+	require.Greater(t, rt.cpuSeconds["local.file.busy"], 0.0)
+}
@@ -11,7 +11,9 @@ const (
 	argumentBlockID = "argument"
 	exportBlockID   = "export"
 	loggingBlockID  = "logging"
+	restartBlockID  = "restart"
 	tracingBlockID  = "tracing"
+	watchdogBlockID = "watchdog"
 )
 
 // NewConfigNode creates a new ConfigNode from an initial ast.BlockStmt.
@@ -24,8 +26,12 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals) (BlockNode, d
 		return NewExportConfigNode(block, globals), nil
 	case loggingBlockID:
 		return NewLoggingConfigNode(block, globals), nil
+	case restartBlockID:
+		return NewRestartConfigNode(block, globals), nil
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals), nil
+	case watchdogBlockID:
+		return NewWatchdogConfigNode(block, globals), nil
 	default:
 		var diags diag.Diagnostics
 		diags.Add(diag.Diagnostic{
@@ -43,7 +49,9 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals) (BlockNode, d
 // types.
 type ConfigNodeMap struct {
 	logging     *LoggingConfigNode
+	restart     *RestartConfigNode
 	tracing     *TracingConfigNode
+	watchdog    *WatchdogConfigNode
 	argumentMap map[string]*ArgumentConfigNode
 	exportMap   map[string]*ExportConfigNode
 }
@@ -53,7 +61,9 @@ type ConfigNodeMap struct {
 func NewConfigNodeMap() *ConfigNodeMap {
 	return &ConfigNodeMap{
 		logging:     nil,
+		restart:     nil,
 		tracing:     nil,
+		watchdog:    nil,
 		argumentMap: map[string]*ArgumentConfigNode{},
 		exportMap:   map[string]*ExportConfigNode{},
 	}
@@ -71,8 +81,12 @@ func (nodeMap *ConfigNodeMap) Append(configNode BlockNode) diag.Diagnostics {
 		nodeMap.exportMap[n.Label()] = n
 	case *LoggingConfigNode:
 		nodeMap.logging = n
+	case *RestartConfigNode:
+		nodeMap.restart = n
 	case *TracingConfigNode:
 		nodeMap.tracing = n
+	case *WatchdogConfigNode:
+		nodeMap.watchdog = n
 	default:
 		diags.Add(diag.Diagnostic{
 			Severity: diag.SeverityLevelError,
@@ -113,6 +127,15 @@ func (nodeMap *ConfigNodeMap) ValidateModuleConstraints(isInModule bool) diag.Di
 			})
 		}
 
+		if nodeMap.restart != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  "restart block not allowed inside a module",
+				StartPos: ast.StartPos(nodeMap.restart.Block()).Position(),
+				EndPos:   ast.EndPos(nodeMap.restart.Block()).Position(),
+			})
+		}
+
 		if nodeMap.tracing != nil {
 			diags.Add(diag.Diagnostic{
 				Severity: diag.SeverityLevelError,
@@ -121,6 +144,15 @@ func (nodeMap *ConfigNodeMap) ValidateModuleConstraints(isInModule bool) diag.Di
 				EndPos:   ast.EndPos(nodeMap.tracing.Block()).Position(),
 			})
 		}
+
+		if nodeMap.watchdog != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				Message:  "watchdog block not allowed inside a module",
+				StartPos: ast.StartPos(nodeMap.watchdog.Block()).Position(),
+				EndPos:   ast.EndPos(nodeMap.watchdog.Block()).Position(),
+			})
+		}
 		return diags
 	}
 
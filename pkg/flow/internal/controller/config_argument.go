@@ -1,10 +1,17 @@
 package controller
 
 import (
+	"encoding"
 	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/grafana/agent/pkg/river"
 	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/rivertypes"
 	"github.com/grafana/agent/pkg/river/vm"
 )
 
@@ -18,6 +25,8 @@ type ArgumentConfigNode struct {
 	eval         *vm.Evaluator
 	defaultValue any
 	optional     bool
+	constraints  argumentConstraints
+	args         any // Arguments from the last successful evaluation
 }
 
 var _ BlockNode = (*ArgumentConfigNode)(nil)
@@ -36,8 +45,231 @@ func NewArgumentConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *Argu
 }
 
 type argumentBlock struct {
-	Optional bool `river:"optional,attr,optional"`
-	Default  any  `river:"default,attr,optional"`
+	Optional bool   `river:"optional,attr,optional"`
+	Comment  string `river:"comment,attr,optional"`
+	Default  any    `river:"default,attr,optional"`
+
+	Type          string   `river:"type,attr,optional"`
+	AllowedValues []any    `river:"allowed_values,attr,optional"`
+	Regex         string   `river:"regex,attr,optional"`
+	Min           *float64 `river:"min,attr,optional"`
+	Max           *float64 `river:"max,attr,optional"`
+}
+
+// Types which can be declared for an argument.
+const (
+	argumentTypeString = "string"
+	argumentTypeNumber = "number"
+	argumentTypeBool   = "bool"
+	argumentTypeList   = "list"
+	argumentTypeMap    = "map"
+	argumentTypeSecret = "secret"
+)
+
+var argumentTypes = []string{
+	argumentTypeString,
+	argumentTypeNumber,
+	argumentTypeBool,
+	argumentTypeList,
+	argumentTypeMap,
+	argumentTypeSecret,
+}
+
+// argumentConstraints holds the type and validation rules of an argument.
+type argumentConstraints struct {
+	typ           string
+	allowedValues []any
+	regex         *regexp.Regexp
+	min, max      *float64
+}
+
+func newArgumentConstraints(block argumentBlock) (argumentConstraints, error) {
+	c := argumentConstraints{
+		typ:           block.Type,
+		allowedValues: block.AllowedValues,
+		min:           block.Min,
+		max:           block.Max,
+	}
+
+	if c.typ != "" && !contains(argumentTypes, c.typ) {
+		return c, fmt.Errorf("unknown type %q, must be one of %s", c.typ, strings.Join(argumentTypes, ", "))
+	}
+	if block.Regex != "" {
+		if c.typ != "" && c.typ != argumentTypeString && c.typ != argumentTypeSecret {
+			return c, fmt.Errorf("regex can only be used with arguments of type string or secret")
+		}
+		re, err := regexp.Compile("^(?:" + block.Regex + ")$")
+		if err != nil {
+			return c, fmt.Errorf("invalid regex: %w", err)
+		}
+		c.regex = re
+	}
+	if c.min != nil || c.max != nil {
+		if c.typ != "" && c.typ != argumentTypeNumber {
+			return c, fmt.Errorf("min and max can only be used with arguments of type number")
+		}
+		if c.min != nil && c.max != nil && *c.min > *c.max {
+			return c, fmt.Errorf("min must not be greater than max")
+		}
+	}
+	return c, nil
+}
+
+// check validates value against the constraints, returning the value to use
+// for the argument. Strings are converted to secrets for arguments of type
+// secret. A nil value is always valid.
+func (c argumentConstraints) check(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	actual := typeOf(value)
+	switch {
+	case c.typ == "" || c.typ == actual:
+	case c.typ == argumentTypeSecret && actual == argumentTypeString:
+		value = rivertypes.Secret(reflect.ValueOf(value).String())
+		actual = argumentTypeSecret
+	default:
+		return nil, fmt.Errorf("expected %s, got %s", c.typ, actual)
+	}
+
+	if len(c.allowedValues) > 0 {
+		allowed := false
+		for _, v := range c.allowedValues {
+			if equalValues(value, v) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			if actual == argumentTypeSecret {
+				return nil, fmt.Errorf("secret is not one of the allowed values")
+			}
+			return nil, fmt.Errorf("%v is not one of the allowed values", value)
+		}
+	}
+
+	if c.regex != nil {
+		str, ok := stringValue(value)
+		if !ok {
+			return nil, fmt.Errorf("expected string or secret to match regex, got %s", actual)
+		}
+		if !c.regex.MatchString(str) {
+			if actual == argumentTypeSecret {
+				return nil, fmt.Errorf("secret does not match regex %q", c.regex.String())
+			}
+			return nil, fmt.Errorf("%q does not match regex %q", str, c.regex.String())
+		}
+	}
+
+	if c.min != nil || c.max != nil {
+		num, ok := numberValue(value)
+		if !ok {
+			return nil, fmt.Errorf("expected number to compare with min and max, got %s", actual)
+		}
+		if c.min != nil && num < *c.min {
+			return nil, fmt.Errorf("%v is less than the minimum of %v", num, *c.min)
+		}
+		if c.max != nil && num > *c.max {
+			return nil, fmt.Errorf("%v is greater than the maximum of %v", num, *c.max)
+		}
+	}
+
+	return value, nil
+}
+
+var (
+	goCapsule       = reflect.TypeOf((*river.Capsule)(nil)).Elem()
+	goTextMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	goDuration      = reflect.TypeOf(time.Duration(0))
+	goSecret        = reflect.TypeOf(rivertypes.Secret(""))
+)
+
+// typeOf returns the name of the River type of value, using the names of
+// argument types where they exist.
+func typeOf(value any) string {
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Pointer && !t.Implements(goCapsule) && !t.Implements(goTextMarshaler) {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == goSecret:
+		return argumentTypeSecret
+	case t.Implements(goCapsule):
+		return "capsule"
+	case t.Implements(goTextMarshaler), t == goDuration:
+		return argumentTypeString
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return argumentTypeNumber
+	case reflect.String:
+		return argumentTypeString
+	case reflect.Bool:
+		return argumentTypeBool
+	case reflect.Array, reflect.Slice:
+		return argumentTypeList
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return argumentTypeMap
+		}
+		return "capsule"
+	case reflect.Struct:
+		return argumentTypeMap
+	case reflect.Func:
+		return "function"
+	default:
+		return "capsule"
+	}
+}
+
+// equalValues reports whether a and b are equal, comparing numbers by value
+// regardless of their Go type and secrets with strings by their contents.
+func equalValues(a, b any) bool {
+	if x, ok := numberValue(a); ok {
+		y, ok := numberValue(b)
+		return ok && x == y
+	}
+	if x, ok := stringValue(a); ok {
+		y, ok := stringValue(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func numberValue(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func stringValue(v any) (string, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.String {
+		return "", false
+	}
+	return rv.String(), true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 // Evaluate implements BlockNode and updates the arguments for the managed config block
@@ -55,12 +287,27 @@ func (cn *ArgumentConfigNode) Evaluate(scope *vm.Scope) error {
 		return fmt.Errorf("decoding River: %w", err)
 	}
 
+	constraints, err := newArgumentConstraints(argument)
+	if err != nil {
+		return err
+	}
+
 	cn.defaultValue = argument.Default
 	cn.optional = argument.Optional
+	cn.constraints = constraints
+	cn.args = argument
 
 	return nil
 }
 
+// Check validates value against the type and validation rules of the
+// argument, returning the value to pass to the module.
+func (cn *ArgumentConfigNode) Check(value any) (any, error) {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.constraints.check(value)
+}
+
 func (cn *ArgumentConfigNode) Optional() bool {
 	cn.mut.RLock()
 	defer cn.mut.RUnlock()
@@ -73,6 +320,14 @@ func (cn *ArgumentConfigNode) Default() any {
 	return cn.defaultValue
 }
 
+// Arguments returns the arguments the block was last successfully evaluated
+// to, or nil if it hasn't been evaluated yet.
+func (cn *ArgumentConfigNode) Arguments() any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.args
+}
+
 func (cn *ArgumentConfigNode) Label() string { return cn.label }
 
 // Block implements BlockNode and returns the current block of the managed config node.
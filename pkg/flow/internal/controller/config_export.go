@@ -17,6 +17,7 @@ type ExportConfigNode struct {
 	block *ast.BlockStmt // Current River blocks to derive config from
 	eval  *vm.Evaluator
 	value any
+	args  any // Arguments from the last successful evaluation
 }
 
 var _ BlockNode = (*ExportConfigNode)(nil)
@@ -53,6 +54,7 @@ func (cn *ExportConfigNode) Evaluate(scope *vm.Scope) error {
 		return fmt.Errorf("decoding River: %w", err)
 	}
 	cn.value = export.Value
+	cn.args = export
 	return nil
 }
 
@@ -65,6 +67,14 @@ func (cn *ExportConfigNode) Value() any {
 	return cn.value
 }
 
+// Arguments returns the arguments the block was last successfully evaluated
+// to, or nil if it hasn't been evaluated yet.
+func (cn *ExportConfigNode) Arguments() any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.args
+}
+
 // Block implements BlockNode and returns the current block of the managed config node.
 func (cn *ExportConfigNode) Block() *ast.BlockStmt {
 	cn.mut.RLock()
@@ -20,6 +20,7 @@ type LoggingConfigNode struct {
 	mut   sync.RWMutex
 	block *ast.BlockStmt // Current River blocks to derive config from
 	eval  *vm.Evaluator
+	args  any // Arguments from the last successful evaluation
 }
 
 // NewLoggingConfigNode creates a new LoggingConfigNode from an initial ast.BlockStmt.
@@ -55,8 +56,8 @@ func NewDefaultLoggingConfigNode(globals ComponentGlobals) *LoggingConfigNode {
 // Evaluate will return an error if the River block cannot be evaluated or if
 // decoding to arguments fails.
 func (cn *LoggingConfigNode) Evaluate(scope *vm.Scope) error {
-	cn.mut.RLock()
-	defer cn.mut.RUnlock()
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
 	args := logging.DefaultOptions
 	if cn.eval != nil {
 		if err := cn.eval.Evaluate(scope, &args); err != nil {
@@ -68,9 +69,18 @@ func (cn *LoggingConfigNode) Evaluate(scope *vm.Scope) error {
 		return fmt.Errorf("could not update logger: %w", err)
 	}
 
+	cn.args = args
 	return nil
 }
 
+// Arguments returns the arguments the block was last successfully evaluated
+// to, or nil if it hasn't been evaluated yet.
+func (cn *LoggingConfigNode) Arguments() any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.args
+}
+
 // Block implements BlockNode and returns the current block of the managed config node.
 func (cn *LoggingConfigNode) Block() *ast.BlockStmt {
 	cn.mut.RLock()
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// RestartPolicy determines how components are restarted after their Run
+// method exits with an error.
+type RestartPolicy struct {
+	// MaxRestarts is the number of times a failing component is restarted
+	// before it's given up on. Components are never restarted if it's 0 and
+	// always restarted if it's negative.
+	MaxRestarts int `river:"max_restarts,attr,optional"`
+	// MinBackoff is the time to wait before the first restart. The time is
+	// doubled for every following restart, up to MaxBackoff.
+	MinBackoff time.Duration `river:"min_backoff,attr,optional"`
+	MaxBackoff time.Duration `river:"max_backoff,attr,optional"`
+}
+
+// DefaultRestartPolicy never restarts failing components, leaving them exited
+// until the config is reloaded.
+var DefaultRestartPolicy = RestartPolicy{
+	MaxRestarts: 0,
+	MinBackoff:  time.Second,
+	MaxBackoff:  5 * time.Minute,
+}
+
+// Backoff returns the time to wait before the nth restart, starting from 1.
+func (p RestartPolicy) Backoff(n int) time.Duration {
+	backoff := p.MinBackoff
+	for i := 1; i < n && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	return backoff
+}
+
+// RestartArguments holds the arguments of the restart config block.
+type RestartArguments struct {
+	Policy RestartPolicy `river:",squash"`
+
+	Components []ComponentRestartArguments `river:"component,block,optional"`
+}
+
+// ComponentRestartArguments overrides the restart policy of a single
+// component. Unset attributes are taken from the restart block.
+type ComponentRestartArguments struct {
+	ID          string         `river:"id,attr"`
+	MaxRestarts *int           `river:"max_restarts,attr,optional"`
+	MinBackoff  *time.Duration `river:"min_backoff,attr,optional"`
+	MaxBackoff  *time.Duration `river:"max_backoff,attr,optional"`
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *RestartArguments) SetToDefault() {
+	*args = RestartArguments{Policy: DefaultRestartPolicy}
+}
+
+// Validate implements river.Validator.
+func (args *RestartArguments) Validate() error {
+	if err := args.Policy.validate(); err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(args.Components))
+	for _, c := range args.Components {
+		if _, ok := seen[c.ID]; ok {
+			return fmt.Errorf("restart policy for component %q already declared", c.ID)
+		}
+		seen[c.ID] = struct{}{}
+
+		if err := c.policy(args.Policy).validate(); err != nil {
+			return fmt.Errorf("restart policy for component %q: %w", c.ID, err)
+		}
+	}
+	return nil
+}
+
+func (p RestartPolicy) validate() error {
+	if p.MinBackoff <= 0 {
+		return fmt.Errorf("min_backoff must be greater than 0")
+	}
+	if p.MaxBackoff < p.MinBackoff {
+		return fmt.Errorf("max_backoff must not be less than min_backoff")
+	}
+	return nil
+}
+
+// policy returns the restart policy of the component, taking unset
+// attributes from global.
+func (c ComponentRestartArguments) policy(global RestartPolicy) RestartPolicy {
+	p := global
+	if c.MaxRestarts != nil {
+		p.MaxRestarts = *c.MaxRestarts
+	}
+	if c.MinBackoff != nil {
+		p.MinBackoff = *c.MinBackoff
+	}
+	if c.MaxBackoff != nil {
+		p.MaxBackoff = *c.MaxBackoff
+	}
+	return p
+}
+
+// RestartPolicies holds the restart policies of components. It's shared
+// between a controller and the controllers of its modules.
+type RestartPolicies struct {
+	mut        sync.RWMutex
+	global     RestartPolicy
+	components map[string]RestartPolicy
+}
+
+// NewRestartPolicies creates RestartPolicies which use DefaultRestartPolicy
+// for every component.
+func NewRestartPolicies() *RestartPolicies {
+	return &RestartPolicies{global: DefaultRestartPolicy}
+}
+
+// Policy returns the restart policy of the component with the given global
+// ID.
+func (rp *RestartPolicies) Policy(globalID string) RestartPolicy {
+	rp.mut.RLock()
+	defer rp.mut.RUnlock()
+	if p, ok := rp.components[globalID]; ok {
+		return p
+	}
+	return rp.global
+}
+
+func (rp *RestartPolicies) update(args RestartArguments) {
+	components := make(map[string]RestartPolicy, len(args.Components))
+	for _, c := range args.Components {
+		components[c.ID] = c.policy(args.Policy)
+	}
+
+	rp.mut.Lock()
+	defer rp.mut.Unlock()
+	rp.global = args.Policy
+	rp.components = components
+}
+
+type RestartConfigNode struct {
+	nodeID        string
+	componentName string
+	policies      *RestartPolicies // Policies shared between all managed components.
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River blocks to derive config from
+	eval  *vm.Evaluator
+	args  any // Arguments from the last successful evaluation
+}
+
+var _ BlockNode = (*RestartConfigNode)(nil)
+
+// NewRestartConfigNode creates a new RestartConfigNode from an initial
+// ast.BlockStmt. The underlying config isn't applied until Evaluate is called.
+func NewRestartConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *RestartConfigNode {
+	return &RestartConfigNode{
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+		policies:      globals.Restarts,
+
+		block: block,
+		eval:  vm.New(block.Body),
+	}
+}
+
+// NewDefaultRestartConfigNode creates a new RestartConfigNode with nil block
+// and eval. This will force evaluate to use the default restart policy for
+// this node.
+func NewDefaultRestartConfigNode(globals ComponentGlobals) *RestartConfigNode {
+	return &RestartConfigNode{
+		nodeID:        restartBlockID,
+		componentName: restartBlockID,
+		policies:      globals.Restarts,
+
+		block: nil,
+		eval:  nil,
+	}
+}
+
+// Evaluate implements BlockNode and updates the restart policies of
+// components by re-evaluating its River block with the provided scope.
+//
+// Evaluate will return an error if the River block cannot be evaluated or if
+// decoding to arguments fails.
+func (cn *RestartConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	var args RestartArguments
+	args.SetToDefault()
+	if cn.eval != nil {
+		if err := cn.eval.Evaluate(scope, &args); err != nil {
+			return fmt.Errorf("decoding River: %w", err)
+		}
+	}
+
+	if cn.policies != nil {
+		cn.policies.update(args)
+	}
+	cn.args = args
+	return nil
+}
+
+// Arguments returns the arguments the block was last successfully evaluated
+// to, or nil if it hasn't been evaluated yet.
+func (cn *RestartConfigNode) Arguments() any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.args
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *RestartConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *RestartConfigNode) NodeID() string { return cn.nodeID }
@@ -18,6 +18,7 @@ type TracingConfigNode struct {
 	mut   sync.RWMutex
 	block *ast.BlockStmt // Current River blocks to derive config from
 	eval  *vm.Evaluator
+	args  any // Arguments from the last successful evaluation
 }
 
 var _ BlockNode = (*TracingConfigNode)(nil)
@@ -55,8 +56,8 @@ func NewDefaulTracingConfigNode(globals ComponentGlobals) *TracingConfigNode {
 // Evaluate will return an error if the River block cannot be evaluated or if
 // decoding to arguments fails.
 func (cn *TracingConfigNode) Evaluate(scope *vm.Scope) error {
-	cn.mut.RLock()
-	defer cn.mut.RUnlock()
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
 	args := tracing.DefaultOptions
 	if cn.eval != nil {
 		if err := cn.eval.Evaluate(scope, &args); err != nil {
@@ -72,9 +73,18 @@ func (cn *TracingConfigNode) Evaluate(scope *vm.Scope) error {
 		}
 	}
 
+	cn.args = args
 	return nil
 }
 
+// Arguments returns the arguments the block was last successfully evaluated
+// to, or nil if it hasn't been evaluated yet.
+func (cn *TracingConfigNode) Arguments() any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.args
+}
+
 // Block implements BlockNode and returns the current block of the managed config node.
 func (cn *TracingConfigNode) Block() *ast.BlockStmt {
 	cn.mut.RLock()
@@ -0,0 +1,394 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/events"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/vm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Kinds of component output checked by the watchdog. They match the kinds of
+// the throughput counters of components.
+const (
+	outputSamples = "samples"
+	outputLines   = "lines"
+	outputSpans   = "spans"
+	outputBytes   = "bytes"
+)
+
+// ComponentOutputs returns the totals of the output counters of every
+// component, keyed by global component ID and then by kind of output.
+type ComponentOutputs func() (map[string]map[string]float64, error)
+
+// WatchdogArguments holds the arguments of the watchdog config block.
+type WatchdogArguments struct {
+	CheckInterval time.Duration `river:"check_interval,attr,optional"`
+
+	Components []WatchdogRule `river:"component,block,optional"`
+}
+
+// WatchdogRule declares the output expected from a component.
+type WatchdogRule struct {
+	ID string `river:"id,attr"`
+	// Window is the duration over which the output of the component is
+	// measured.
+	Window time.Duration `river:"window,attr,optional"`
+	// MinOutput is the minimum output the component must produce within
+	// Window.
+	MinOutput float64 `river:"min_output,attr,optional"`
+	// Kind of output to count. Every kind but bytes is counted if it's empty.
+	Kind string `river:"kind,attr,optional"`
+}
+
+// DefaultWatchdogArguments checks the output of components every 30 seconds.
+var DefaultWatchdogArguments = WatchdogArguments{
+	CheckInterval: 30 * time.Second,
+}
+
+// DefaultWatchdogRule expects components to produce output every 5 minutes.
+var DefaultWatchdogRule = WatchdogRule{
+	Window:    5 * time.Minute,
+	MinOutput: 1,
+}
+
+// SetToDefault implements river.Defaulter.
+func (args *WatchdogArguments) SetToDefault() {
+	*args = DefaultWatchdogArguments
+}
+
+// Validate implements river.Validator.
+func (args *WatchdogArguments) Validate() error {
+	if args.CheckInterval <= 0 {
+		return fmt.Errorf("check_interval must be greater than 0")
+	}
+
+	seen := make(map[string]struct{}, len(args.Components))
+	for _, r := range args.Components {
+		if _, ok := seen[r.ID]; ok {
+			return fmt.Errorf("watchdog for component %q already declared", r.ID)
+		}
+		seen[r.ID] = struct{}{}
+
+		if r.Window < args.CheckInterval {
+			return fmt.Errorf("watchdog for component %q: window must not be less than check_interval", r.ID)
+		}
+	}
+	return nil
+}
+
+// SetToDefault implements river.Defaulter.
+func (r *WatchdogRule) SetToDefault() {
+	*r = DefaultWatchdogRule
+}
+
+// Validate implements river.Validator.
+func (r *WatchdogRule) Validate() error {
+	if r.MinOutput <= 0 {
+		return fmt.Errorf("min_output must be greater than 0")
+	}
+	switch r.Kind {
+	case "", outputSamples, outputLines, outputSpans, outputBytes:
+	default:
+		return fmt.Errorf("unrecognized kind %q, must be one of %s, %s, %s, or %s", r.Kind, outputSamples, outputLines, outputSpans, outputBytes)
+	}
+	return nil
+}
+
+// output returns the total output of the component counted by the rule.
+func (r WatchdogRule) output(totals map[string]float64) float64 {
+	if r.Kind != "" {
+		return totals[r.Kind]
+	}
+	var sum float64
+	for kind, v := range totals {
+		if kind != outputBytes {
+			sum += v
+		}
+	}
+	return sum
+}
+
+func (r WatchdogRule) kindName() string {
+	if r.Kind == "" {
+		return "items"
+	}
+	return r.Kind
+}
+
+// outputSample is the total output of a component at a point in time.
+type outputSample struct {
+	time  time.Time
+	total float64
+}
+
+// Watchdog checks that components produce the output declared in the
+// watchdog config block, and reports the components which don't as
+// unhealthy. It's shared between a controller and the controllers of its
+// modules.
+type Watchdog struct {
+	log     log.Logger
+	outputs ComponentOutputs // May be nil, in which case nothing is checked.
+	events  *events.Bus
+
+	stalled      *prometheus.GaugeVec
+	stalledTotal *prometheus.CounterVec
+
+	mut        sync.RWMutex
+	args       WatchdogArguments
+	updated    chan struct{}
+	history    map[string][]outputSample   // Output samples by component ID.
+	violations map[string]component.Health // Health of stalled components by ID.
+}
+
+// NewWatchdog creates a new Watchdog which checks the output of components
+// returned by outputs, registering its metrics to reg if it's not nil.
+func NewWatchdog(l log.Logger, outputs ComponentOutputs, bus *events.Bus, reg prometheus.Registerer) *Watchdog {
+	w := &Watchdog{
+		log:     l,
+		outputs: outputs,
+		events:  bus,
+
+		stalled: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "agent_component_watchdog_stalled",
+			Help: "Whether the component produced less output than expected by the watchdog.",
+		}, []string{"component_id"}),
+		stalledTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "agent_component_watchdog_stalls_total",
+			Help: "Total number of times the component stopped producing the output expected by the watchdog.",
+		}, []string{"component_id"}),
+
+		args:       DefaultWatchdogArguments,
+		updated:    make(chan struct{}, 1),
+		history:    make(map[string][]outputSample),
+		violations: make(map[string]component.Health),
+	}
+	if reg != nil {
+		reg.MustRegister(w.stalled, w.stalledTotal)
+	}
+	return w
+}
+
+// Health returns the health of the component with the given global ID. ok is
+// false if the component produces the expected output.
+func (w *Watchdog) Health(globalID string) (h component.Health, ok bool) {
+	if w == nil {
+		return component.Health{}, false
+	}
+	w.mut.RLock()
+	defer w.mut.RUnlock()
+	h, ok = w.violations[globalID]
+	return h, ok
+}
+
+// Run checks the output of components every check interval until ctx is
+// canceled.
+func (w *Watchdog) Run(ctx context.Context) {
+	if w.outputs == nil {
+		return
+	}
+
+	for {
+		w.mut.RLock()
+		interval := w.args.CheckInterval
+		w.mut.RUnlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.updated:
+		case <-time.After(interval):
+			w.check(time.Now())
+		}
+	}
+}
+
+// check compares the output of each watched component over its window with
+// the output it's expected to produce.
+func (w *Watchdog) check(now time.Time) {
+	outputs, err := w.outputs()
+	if err != nil {
+		level.Warn(w.log).Log("msg", "failed to gather the output of components", "err", err)
+		return
+	}
+
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for _, r := range w.args.Components {
+		total := r.output(outputs[r.ID])
+		history := append(w.history[r.ID], outputSample{time: now, total: total})
+
+		// Only the newest sample older than the window is needed as the
+		// baseline of the output within the window.
+		baseline := -1
+		for i, s := range history {
+			if !s.time.After(now.Add(-r.Window)) {
+				baseline = i
+			}
+		}
+		if baseline < 0 {
+			// The component hasn't been watched for long enough yet.
+			w.history[r.ID] = history
+			continue
+		}
+		history = history[baseline:]
+		w.history[r.ID] = history
+
+		produced := total - history[0].total
+		if produced < 0 {
+			// The counters of the component were reset, such as when it was
+			// recreated.
+			produced = total
+		}
+		w.setStalled(r, produced < r.MinOutput, produced, now)
+	}
+}
+
+// setStalled updates whether the component of r is stalled. mut must be held
+// when calling setStalled.
+func (w *Watchdog) setStalled(r WatchdogRule, stalled bool, produced float64, now time.Time) {
+	_, wasStalled := w.violations[r.ID]
+	var client *events.Client
+	if w.events != nil {
+		client = w.events.Client(r.ID)
+	}
+
+	switch {
+	case stalled:
+		msg := fmt.Sprintf("component produced %g %s in the last %s, expected at least %g", produced, r.kindName(), r.Window, r.MinOutput)
+		w.violations[r.ID] = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    msg,
+			UpdateTime: now,
+		}
+		w.stalled.WithLabelValues(r.ID).Set(1)
+		if !wasStalled {
+			level.Warn(w.log).Log("msg", "component stopped producing the expected output", "component", r.ID, "output", produced, "window", r.Window)
+			w.stalledTotal.WithLabelValues(r.ID).Inc()
+			client.Publish(events.TypeComponentOutputStalled, msg, map[string]string{
+				"output": fmt.Sprint(produced),
+				"window": r.Window.String(),
+			})
+		}
+	case wasStalled:
+		level.Info(w.log).Log("msg", "component produces the expected output again", "component", r.ID)
+		delete(w.violations, r.ID)
+		w.stalled.WithLabelValues(r.ID).Set(0)
+		client.Publish(events.TypeComponentOutputResumed, "component produces the expected output again", nil)
+	default:
+		w.stalled.WithLabelValues(r.ID).Set(0)
+	}
+}
+
+func (w *Watchdog) update(args WatchdogArguments) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	watched := make(map[string]struct{}, len(args.Components))
+	for _, r := range args.Components {
+		watched[r.ID] = struct{}{}
+	}
+	for id := range w.history {
+		if _, ok := watched[id]; !ok {
+			delete(w.history, id)
+			delete(w.violations, id)
+			w.stalled.DeleteLabelValues(id)
+		}
+	}
+	w.args = args
+
+	select {
+	case w.updated <- struct{}{}:
+	default:
+	}
+}
+
+type WatchdogConfigNode struct {
+	nodeID        string
+	componentName string
+	watchdog      *Watchdog // Watchdog shared between all managed components.
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current River blocks to derive config from
+	eval  *vm.Evaluator
+	args  any // Arguments from the last successful evaluation
+}
+
+var _ BlockNode = (*WatchdogConfigNode)(nil)
+
+// NewWatchdogConfigNode creates a new WatchdogConfigNode from an initial
+// ast.BlockStmt. The underlying config isn't applied until Evaluate is called.
+func NewWatchdogConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *WatchdogConfigNode {
+	return &WatchdogConfigNode{
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+		watchdog:      globals.Watchdog,
+
+		block: block,
+		eval:  vm.New(block.Body),
+	}
+}
+
+// NewDefaultWatchdogConfigNode creates a new WatchdogConfigNode with nil
+// block and eval. This will force evaluate to use the default watchdog
+// arguments, which don't watch any component.
+func NewDefaultWatchdogConfigNode(globals ComponentGlobals) *WatchdogConfigNode {
+	return &WatchdogConfigNode{
+		nodeID:        watchdogBlockID,
+		componentName: watchdogBlockID,
+		watchdog:      globals.Watchdog,
+
+		block: nil,
+		eval:  nil,
+	}
+}
+
+// Evaluate implements BlockNode and updates the components checked by the
+// watchdog by re-evaluating its River block with the provided scope.
+//
+// Evaluate will return an error if the River block cannot be evaluated or if
+// decoding to arguments fails.
+func (cn *WatchdogConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	var args WatchdogArguments
+	args.SetToDefault()
+	if cn.eval != nil {
+		if err := cn.eval.Evaluate(scope, &args); err != nil {
+			return fmt.Errorf("decoding River: %w", err)
+		}
+	}
+
+	if cn.watchdog != nil {
+		cn.watchdog.update(args)
+	}
+	cn.args = args
+	return nil
+}
+
+// Arguments returns the arguments the block was last successfully evaluated
+// to, or nil if it hasn't been evaluated yet.
+func (cn *WatchdogConfigNode) Arguments() any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.args
+}
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *WatchdogConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *WatchdogConfigNode) NodeID() string { return cn.nodeID }
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/dag"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/grafana/agent/pkg/river/vm"
+)
+
+// Lint checks the provided River blocks without building or running any
+// component. Lint resolves component references, evaluates the arguments of
+// every component against the schema of the component, and checks that the
+// exports of referenced components can be used for the arguments they're
+// assigned to.
+//
+// Exports of components are only known once components run, so components
+// are evaluated using the zero value of the exports of their dependencies.
+// Failed validations of arguments which depend on the exports of other
+// components are reported as warnings, as they may pass at runtime.
+func Lint(globals ComponentGlobals, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) diag.Diagnostics {
+	l := &Loader{
+		log:     log.With(globals.Logger, "controller_id", globals.ControllerID),
+		globals: globals,
+
+		graph:         &dag.Graph{},
+		originalGraph: &dag.Graph{},
+		cache:         newValueCache(),
+	}
+
+	g, diags := l.loadNewGraph(nil, componentBlocks, configBlocks)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	_ = dag.WalkTopological(&g, g.Leaves(), func(n dag.Node) error {
+		switch n := n.(type) {
+		case *ComponentNode:
+			diags = append(diags, l.lintComponent(&g, n)...)
+		case BlockNode:
+			if err := l.lintConfigBlock(n); err != nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("Failed to evaluate node for config block: %s", err),
+					StartPos: ast.StartPos(n.Block()).Position(),
+					EndPos:   ast.EndPos(n.Block()).Position(),
+				})
+			}
+		}
+		return nil
+	})
+
+	return diags
+}
+
+// lintComponent evaluates the arguments of cn and caches them along with the
+// zero value of its exports for dependants of cn to be evaluated.
+func (l *Loader) lintComponent(g *dag.Graph, cn *ComponentNode) diag.Diagnostics {
+	args, err := cn.lint(l.cache.BuildContext())
+	l.cache.CacheArguments(cn.ID(), args)
+	l.cache.CacheExports(cn.ID(), cn.Exports())
+	if err == nil {
+		return nil
+	}
+
+	// Errors from decoding River, such as type mismatches, are reported as
+	// is.
+	var diags diag.Diagnostics
+	if errors.As(err, &diags) {
+		return diags
+	}
+
+	severity := diag.SeverityLevelError
+	message := fmt.Sprintf("Invalid arguments: %s", err)
+	if dependsOnComponents(g, cn) {
+		severity = diag.SeverityLevelWarn
+		message = fmt.Sprintf("Arguments could not be validated without the exports of the components it references: %s", err)
+	}
+	return diag.Diagnostics{{
+		Severity: severity,
+		Message:  message,
+		StartPos: ast.StartPos(cn.Block()).Position(),
+		EndPos:   ast.EndPos(cn.Block()).Position(),
+	}}
+}
+
+// lint evaluates the River block of cn into the arguments of the component
+// without building the component.
+func (cn *ComponentNode) lint(scope *vm.Scope) (component.Arguments, error) {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	if cn.migrateErr != nil {
+		return nil, fmt.Errorf("migrating deprecated arguments: %w", cn.migrateErr)
+	}
+
+	argsPointer := cn.reg.CloneArguments()
+	if err := cn.eval.Evaluate(scope, argsPointer); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(argsPointer).Elem().Interface(), nil
+}
+
+// lintConfigBlock evaluates a config block. Unlike evaluate, the logging,
+// tracing, restart, and watchdog blocks are only decoded and never applied.
+func (l *Loader) lintConfigBlock(bn BlockNode) error {
+	scope := l.cache.BuildContext()
+
+	switch n := bn.(type) {
+	case *LoggingConfigNode:
+		args := logging.DefaultOptions
+		return lintEval(n.eval, scope, &args)
+	case *TracingConfigNode:
+		args := tracing.DefaultOptions
+		return lintEval(n.eval, scope, &args)
+	case *RestartConfigNode:
+		var args RestartArguments
+		return lintEval(n.eval, scope, &args)
+	case *WatchdogConfigNode:
+		var args WatchdogArguments
+		return lintEval(n.eval, scope, &args)
+	default:
+		return l.evaluate(l.log, bn)
+	}
+}
+
+func lintEval(eval *vm.Evaluator, scope *vm.Scope, v interface{}) error {
+	if eval == nil {
+		return nil
+	}
+	if err := eval.Evaluate(scope, v); err != nil {
+		return fmt.Errorf("decoding River: %w", err)
+	}
+	return nil
+}
+
+// dependsOnComponents returns true if cn references other components.
+func dependsOnComponents(g *dag.Graph, cn *ComponentNode) bool {
+	for _, dep := range g.Dependencies(cn) {
+		if _, ok := dep.(*ComponentNode); ok {
+			return true
+		}
+	}
+	return false
+}
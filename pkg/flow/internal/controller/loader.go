@@ -36,6 +36,7 @@ type Loader struct {
 	components        []*ComponentNode
 	cache             *valueCache
 	blocks            []*ast.BlockStmt // Most recently loaded blocks, used for writing
+	changes           ComponentChanges // Changes made by the most recent Apply
 	cm                *controllerMetrics
 	cc                *controllerCollector
 	moduleExportIndex int
@@ -117,6 +118,7 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 	var (
 		components   = make([]*ComponentNode, 0, len(componentBlocks))
 		componentIDs = make([]ComponentID, 0, len(componentBlocks))
+		changes      ComponentChanges
 	)
 
 	tracer := l.tracer.Tracer("")
@@ -153,7 +155,11 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 			components = append(components, c)
 			componentIDs = append(componentIDs, c.ID())
 
-			if err = l.evaluate(logger, c); err != nil {
+			err = l.evaluate(logger, c)
+			change := c.lastChange()
+			changes.record(c.NodeID(), change)
+			span.SetAttributes(attribute.String("change", change.String()))
+			if err != nil {
 				var evalDiags diag.Diagnostics
 				if errors.As(err, &evalDiags) {
 					diags = append(diags, evalDiags...)
@@ -190,7 +196,20 @@ func (l *Loader) Apply(args map[string]any, componentBlocks []*ast.BlockStmt, co
 		return nil
 	})
 
+	changes.recordRemoved(l.components, components)
+	changes.sort()
+	level.Info(logger).Log("msg", "applied component changes", "added", len(changes.Added), "updated", len(changes.Updated),
+		"removed", len(changes.Removed), "failed", len(changes.Failed), "unchanged", changes.Unchanged)
+	span.SetAttributes(
+		attribute.Int("components_added", len(changes.Added)),
+		attribute.Int("components_updated", len(changes.Updated)),
+		attribute.Int("components_removed", len(changes.Removed)),
+		attribute.Int("components_failed", len(changes.Failed)),
+		attribute.Int("components_unchanged", changes.Unchanged),
+	)
+
 	l.components = components
+	l.changes = changes
 	l.graph = &newGraph
 	l.cache.SyncIDs(componentIDs)
 	l.blocks = componentBlocks
@@ -214,8 +233,14 @@ func (l *Loader) Cleanup() {
 // loadNewGraph creates a new graph from the provided blocks and validates it.
 func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockStmt, configBlocks []*ast.BlockStmt) (dag.Graph, diag.Diagnostics) {
 	var g dag.Graph
+	// Skip the blocks which are disabled.
+	componentBlocks, diags := l.enabledBlocks(componentBlocks)
+	configBlocks, configDiags := l.enabledBlocks(configBlocks)
+	diags = append(diags, configDiags...)
+
 	// Fill our graph with config blocks.
-	diags := l.populateConfigBlockNodes(args, &g, configBlocks)
+	configNodeDiags := l.populateConfigBlockNodes(args, &g, configBlocks)
+	diags = append(diags, configNodeDiags...)
 
 	// Fill our graph with components.
 	componentNodeDiags := l.populateComponentNodes(&g, componentBlocks)
@@ -281,12 +306,24 @@ func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, con
 		g.Add(c)
 	}
 
+	// If a restart config block is not provided, we create an empty node which uses defaults.
+	if nodeMap.restart == nil && !l.isModule() {
+		c := NewDefaultRestartConfigNode(l.globals)
+		g.Add(c)
+	}
+
 	// If a tracing config block is not provided, we create an empty node which uses defaults.
 	if nodeMap.tracing == nil && !l.isModule() {
 		c := NewDefaulTracingConfigNode(l.globals)
 		g.Add(c)
 	}
 
+	// If a watchdog config block is not provided, we create an empty node which uses defaults.
+	if nodeMap.watchdog == nil && !l.isModule() {
+		c := NewDefaultWatchdogConfigNode(l.globals)
+		g.Add(c)
+	}
+
 	return diags
 }
 
@@ -328,6 +365,16 @@ func (l *Loader) populateComponentNodes(g *dag.Graph, componentBlocks []*ast.Blo
 				continue
 			}
 
+			if err := l.globals.Policy.Check(componentName); err != nil {
+				diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					Message:  fmt.Sprintf("Component %q may not be used on this host: %s", componentName, err),
+					StartPos: block.NamePos.Position(),
+					EndPos:   block.NamePos.Add(len(componentName) - 1).Position(),
+				})
+				continue
+			}
+
 			if registration.Singleton && block.Label != "" {
 				diags.Add(diag.Diagnostic{
 					Severity: diag.SeverityLevelError,
@@ -389,6 +436,14 @@ func (l *Loader) Variables() map[string]interface{} {
 	return l.cache.BuildContext().Variables
 }
 
+// Changes returns the changes made to the components by the most recent call
+// to Apply which loaded a new graph.
+func (l *Loader) Changes() ComponentChanges {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	return l.changes
+}
+
 // Components returns the current set of loaded components.
 func (l *Loader) Components() []*ComponentNode {
 	l.mut.RLock()
@@ -462,6 +517,9 @@ func (l *Loader) EvaluateDependencies(c *ComponentNode) {
 		switch n := n.(type) {
 		case BlockNode:
 			err = l.evaluate(logger, n)
+			if c, ok := n.(*ComponentNode); ok {
+				span.SetAttributes(attribute.String("change", c.lastChange().String()))
+			}
 			if exp, ok := n.(*ExportConfigNode); ok {
 				l.cache.CacheModuleExportValue(exp.Label(), exp.Value())
 			}
@@ -496,13 +554,24 @@ func (l *Loader) evaluate(logger log.Logger, bn BlockNode) error {
 		l.cache.CacheArguments(c.ID(), c.Arguments())
 		l.cache.CacheExports(c.ID(), c.Exports())
 	case *ArgumentConfigNode:
-		if _, found := l.cache.moduleArguments[c.Label()]; !found {
-			if c.Optional() {
-				l.cache.CacheModuleArgument(c.Label(), c.Default())
-			} else {
+		if err != nil {
+			break
+		}
+
+		value, found := l.cache.moduleArguments[c.Label()]
+		if !found {
+			if !c.Optional() {
 				err = fmt.Errorf("missing required argument %q to module", c.Label())
+				break
 			}
+			value = c.Default()
+		}
+
+		if value, err = c.Check(value); err != nil {
+			err = fmt.Errorf("invalid value for argument %q: %w", c.Label(), err)
+			break
 		}
+		l.cache.CacheModuleArgument(c.Label(), value)
 	}
 
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/flow/componentpolicy"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/internal/dag"
 	"github.com/grafana/agent/pkg/flow/logging"
@@ -57,7 +58,9 @@ func TestLoader(t *testing.T) {
 			"testcomponents.passthrough.ticker",
 			"testcomponents.passthrough.forwarded",
 			"logging",
+			"restart",
 			"tracing",
+			"watchdog",
 		},
 		OutEdges: []edge{
 			{From: "testcomponents.passthrough.ticker", To: "testcomponents.tick.ticker"},
@@ -130,6 +133,18 @@ func TestLoader(t *testing.T) {
 		require.ErrorContains(t, diags.ErrorOrNil(), `Unrecognized component name "doesnotexist`)
 	})
 
+	t.Run("Load with components denied by the component policy", func(t *testing.T) {
+		policy, err := componentpolicy.Parse([]byte(`deny: ["testcomponents.passthrough"]`))
+		require.NoError(t, err)
+
+		globals := newGlobals()
+		globals.Policy = policy
+		l := controller.NewLoader(globals)
+		diags := applyFromContent(t, l, []byte(testFile), []byte(testConfig))
+		require.ErrorContains(t, diags.ErrorOrNil(), `Component "testcomponents.passthrough" may not be used on this host: denied by the pattern "testcomponents.passthrough" of the component policy`)
+		require.Nil(t, l.Graph().GetByID("testcomponents.passthrough.ticker"))
+	})
+
 	t.Run("Partial load with invalid reference", func(t *testing.T) {
 		invalidFile := `
 			testcomponents.tick "ticker" {
@@ -189,6 +204,89 @@ func TestLoader(t *testing.T) {
 		require.ErrorContains(t, diags[0], `Component "testcomponents.tick" must have a label`)
 		require.ErrorContains(t, diags[1], `Component "testcomponents.singleton" does not support labels`)
 	})
+
+	t.Run("Disabled blocks are skipped", func(t *testing.T) {
+		file := `
+			testcomponents.tick "ticker" {
+				enabled   = constants.os == "not-an-os"
+				frequency = "1s"
+			}
+
+			testcomponents.passthrough "static" {
+				enabled = constants.os != "not-an-os"
+				input   = "hello, world!"
+			}
+		`
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(file), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Nil(t, l.Graph().GetByID("testcomponents.tick.ticker"))
+		require.NotNil(t, l.Graph().GetByID("testcomponents.passthrough.static"))
+	})
+
+	t.Run("Enabled attribute can't reference components", func(t *testing.T) {
+		file := `
+			testcomponents.passthrough "static" {
+				input = "hello, world!"
+			}
+
+			testcomponents.passthrough "forwarded" {
+				enabled = testcomponents.passthrough.static.output == "hello, world!"
+				input   = testcomponents.passthrough.static.output
+			}
+		`
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(file), nil)
+		require.ErrorContains(t, diags.ErrorOrNil(), `identifier "testcomponents" does not exist`)
+	})
+
+	t.Run("Changes only include components with new arguments", func(t *testing.T) {
+		startFile := `
+			testcomponents.passthrough "kept" {
+				input = "hello, world!"
+			}
+
+			testcomponents.passthrough "changed" {
+				input = "hello"
+			}
+
+			testcomponents.passthrough "removed" {
+				input = "goodbye"
+			}
+		`
+		newFile := `
+			testcomponents.passthrough "kept" {
+				input = "hello, world!"
+			}
+
+			testcomponents.passthrough "changed" {
+				input = "hello again"
+			}
+
+			testcomponents.passthrough "added" {
+				input = testcomponents.passthrough.kept.output
+			}
+		`
+		l := controller.NewLoader(newGlobals())
+		diags := applyFromContent(t, l, []byte(startFile), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Equal(t, controller.ComponentChanges{
+			Added: []string{
+				"testcomponents.passthrough.changed",
+				"testcomponents.passthrough.kept",
+				"testcomponents.passthrough.removed",
+			},
+		}, l.Changes())
+
+		diags = applyFromContent(t, l, []byte(newFile), nil)
+		require.NoError(t, diags.ErrorOrNil())
+		require.Equal(t, controller.ComponentChanges{
+			Added:     []string{"testcomponents.passthrough.added"},
+			Updated:   []string{"testcomponents.passthrough.changed"},
+			Removed:   []string{"testcomponents.passthrough.removed"},
+			Unchanged: 1,
+		}, l.Changes())
+	})
 }
 
 // TestScopeWithFailingComponent is used to ensure that the scope is filled out, even if the component
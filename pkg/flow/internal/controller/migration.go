@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/ast"
+)
+
+// migrateBody rewrites the deprecated arguments in body to their
+// replacements, returning the rewritten body and the migrations which were
+// applied. body is not modified; statements are copied when they change.
+//
+// migrateBody fails if both a deprecated argument and its replacement are
+// set.
+func migrateBody(body ast.Body, migrations []component.ArgumentMigration) (ast.Body, []component.AppliedMigration, error) {
+	var applied []component.AppliedMigration
+	for _, m := range migrations {
+		var err error
+		body, _, err = migrateStmts(body, strings.Split(m.Deprecated, "."), m, &applied)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return body, applied, nil
+}
+
+// migrateStmts applies m to the statements of body named by path, returning
+// whether body changed.
+func migrateStmts(body ast.Body, path []string, m component.ArgumentMigration, applied *[]component.AppliedMigration) (ast.Body, bool, error) {
+	if len(path) == 1 {
+		return renameStmts(body, path[0], m, applied)
+	}
+
+	var out ast.Body
+	for i, stmt := range body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || len(block.Name) != 1 || block.Name[0] != path[0] {
+			continue
+		}
+
+		inner, changed, err := migrateStmts(block.Body, path[1:], m, applied)
+		if err != nil {
+			return nil, false, err
+		} else if !changed {
+			continue
+		}
+
+		if out == nil {
+			out = make(ast.Body, len(body))
+			copy(out, body)
+		}
+		migrated := *block
+		migrated.Body = inner
+		out[i] = &migrated
+	}
+
+	if out == nil {
+		return body, false, nil
+	}
+	return out, true, nil
+}
+
+// renameStmts renames the statements of body named name to the replacement
+// of m.
+func renameStmts(body ast.Body, name string, m component.ArgumentMigration, applied *[]component.AppliedMigration) (ast.Body, bool, error) {
+	var found []int
+	for i, stmt := range body {
+		switch stmtName(stmt) {
+		case name:
+			found = append(found, i)
+		case m.Replacement:
+			for _, other := range body {
+				if stmtName(other) == name {
+					return nil, false, fmt.Errorf("%s: %s can't be set together with its replacement %s", ast.StartPos(other).Position(), m.Deprecated, m.ReplacementPath())
+				}
+			}
+		}
+	}
+	if len(found) == 0 {
+		return body, false, nil
+	}
+
+	out := make(ast.Body, len(body))
+	copy(out, body)
+	for _, i := range found {
+		switch stmt := body[i].(type) {
+		case *ast.AttributeStmt:
+			migrated := *stmt
+			migrated.Name = &ast.Ident{Name: m.Replacement, NamePos: stmt.Name.NamePos}
+			out[i] = &migrated
+		case *ast.BlockStmt:
+			migrated := *stmt
+			migrated.Name = []string{m.Replacement}
+			out[i] = &migrated
+		}
+
+		*applied = append(*applied, component.AppliedMigration{
+			ArgumentMigration: m,
+			Position:          ast.StartPos(body[i]).Position().String(),
+		})
+	}
+	return out, true, nil
+}
+
+// stmtName returns the name of an attribute or block statement.
+func stmtName(stmt ast.Stmt) string {
+	switch stmt := stmt.(type) {
+	case *ast.AttributeStmt:
+		return stmt.Name.Name
+	case *ast.BlockStmt:
+		return strings.Join(stmt.Name, ".")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = []component.ArgumentMigration{
+	{Deprecated: "old_attr", Replacement: "new_attr", Since: "v0.35.0"},
+	{Deprecated: "old_block", Replacement: "new_block", Since: "v0.35.0"},
+	{Deprecated: "endpoint.old_token", Replacement: "token", Since: "v0.36.0"},
+}
+
+func parseBody(t *testing.T, src string) ast.Body {
+	t.Helper()
+	file, err := parser.ParseFile(t.Name(), []byte(src))
+	require.NoError(t, err)
+	return file.Body
+}
+
+// stmtNames returns the names of the statements of body, with the
+// statements of nested blocks following the name of their block.
+func stmtNames(body ast.Body) []string {
+	var names []string
+	for _, stmt := range body {
+		names = append(names, stmtName(stmt))
+		if b, ok := stmt.(*ast.BlockStmt); ok {
+			for _, name := range stmtNames(b.Body) {
+				names = append(names, stmtName(stmt)+"."+name)
+			}
+		}
+	}
+	return names
+}
+
+func TestMigrateBody(t *testing.T) {
+	body := parseBody(t, `
+		old_attr = "a"
+		other    = "b"
+
+		old_block {
+			old_attr = "c"
+		}
+
+		endpoint {
+			old_token = "d"
+		}
+		endpoint {
+			token = "e"
+		}
+	`)
+	original := stmtNames(body)
+
+	migrated, applied, err := migrateBody(body, testMigrations)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"new_attr", "other",
+		// Migrations only apply to the path they're declared for.
+		"new_block", "new_block.old_attr",
+		"endpoint", "endpoint.token",
+		"endpoint", "endpoint.token",
+	}, stmtNames(migrated))
+
+	// The original body is left untouched.
+	require.Equal(t, original, stmtNames(body))
+
+	require.Equal(t, []component.AppliedMigration{
+		{ArgumentMigration: testMigrations[0], Position: "TestMigrateBody:2:3"},
+		{ArgumentMigration: testMigrations[1], Position: "TestMigrateBody:5:3"},
+		{ArgumentMigration: testMigrations[2], Position: "TestMigrateBody:10:4"},
+	}, applied)
+	require.Equal(t, "endpoint.token", applied[2].ReplacementPath())
+}
+
+func TestMigrateBody_Unchanged(t *testing.T) {
+	body := parseBody(t, `
+		new_attr = "a"
+
+		endpoint {
+			token = "b"
+		}
+	`)
+
+	migrated, applied, err := migrateBody(body, testMigrations)
+	require.NoError(t, err)
+	require.Empty(t, applied)
+	require.Equal(t, &body[0], &migrated[0], "unchanged body should not be copied")
+}
+
+func TestMigrateBody_ReplacementSet(t *testing.T) {
+	body := parseBody(t, `
+		endpoint {
+			token     = "a"
+			old_token = "b"
+		}
+	`)
+
+	_, _, err := migrateBody(body, testMigrations)
+	require.EqualError(t, err, "TestMigrateBody_ReplacementSet:4:4: endpoint.old_token can't be set together with its replacement endpoint.token")
+}
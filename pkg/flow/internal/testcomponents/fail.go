@@ -0,0 +1,68 @@
+package testcomponents
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grafana/agent/component"
+	"go.uber.org/atomic"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:    "testcomponents.fail",
+		Args:    FailArguments{},
+		Exports: FailExports{},
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return NewFail(opts, args.(FailArguments))
+		},
+	})
+}
+
+// FailArguments configures the testcomponents.fail component.
+type FailArguments struct {
+	// Error is returned from Run.
+	Error string `river:"error,attr"`
+}
+
+// FailExports describes exported fields for the testcomponents.fail
+// component.
+type FailExports struct {
+	// Runs is the number of times Run was called.
+	Runs int `river:"runs,attr"`
+}
+
+// Fail implements the testcomponents.fail component, whose Run method
+// immediately returns an error.
+type Fail struct {
+	opts component.Options
+	err  atomic.Error
+	runs atomic.Int64
+}
+
+// NewFail creates a new fail component.
+func NewFail(o component.Options, cfg FailArguments) (*Fail, error) {
+	t := &Fail{opts: o}
+	if err := t.Update(cfg); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+var (
+	_ component.Component = (*Fail)(nil)
+)
+
+// Run implements Component.
+func (t *Fail) Run(ctx context.Context) error {
+	runs := t.runs.Inc()
+	t.opts.OnStateChange(FailExports{Runs: int(runs)})
+	return t.err.Load()
+}
+
+// Update implements Component.
+func (t *Fail) Update(args component.Arguments) error {
+	t.err.Store(errors.New(args.(FailArguments).Error))
+	return nil
+}
@@ -0,0 +1,35 @@
+package flow
+
+import (
+	"io"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/internal/controller"
+	"github.com/grafana/agent/pkg/flow/logging"
+	"github.com/grafana/agent/pkg/river/diag"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Lint checks the components and config blocks of f without building or
+// running any component. Lint reports unknown components, invalid references
+// between components, arguments which don't match the schema of their
+// component, and exports assigned to arguments of incompatible types.
+//
+// Because exports are only known at runtime, failed validations of arguments
+// which depend on the exports of other components are reported as warnings.
+func Lint(f *File) diag.Diagnostics {
+	logger, err := logging.New(io.Discard, logging.DefaultOptions)
+	if err != nil {
+		// This shouldn't happen unless there's a bug
+		panic(err)
+	}
+
+	return controller.Lint(controller.ComponentGlobals{
+		Logger:        logger,
+		TraceProvider: trace.NewNoopTracerProvider(),
+		NewModuleController: func(id string) component.ModuleController {
+			// Components aren't built, so no module controller is ever used.
+			return nil
+		},
+	}, f.Components, f.ConfigBlocks)
+}
@@ -0,0 +1,67 @@
+package flow
+
+import (
+	"testing"
+
+	"github.com/grafana/agent/pkg/river/diag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		expect []string
+	}{
+		{
+			name:   "valid",
+			config: testFile,
+		},
+		{
+			name:   "unknown component",
+			config: `testcomponents.unknown "a" {}`,
+			expect: []string{`Unrecognized component name "testcomponents.unknown"`},
+		},
+		{
+			name:   "missing required argument",
+			config: `testcomponents.passthrough "a" {}`,
+			expect: []string{`missing required attribute "input"`},
+		},
+		{
+			name: "unknown reference",
+			config: `
+				testcomponents.passthrough "a" {
+					input = testcomponents.passthrough.missing.output
+				}
+			`,
+			expect: []string{`component "testcomponents.passthrough.missing.output" does not exist`},
+		},
+		{
+			name: "incompatible export",
+			config: `
+				testcomponents.tick "ticker" {
+					frequency = "1s"
+				}
+
+				testcomponents.passthrough "a" {
+					input = testcomponents.tick.ticker
+				}
+			`,
+			expect: []string{`testcomponents.tick.ticker should be string, got object`},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := ReadFile(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+
+			diags := Lint(f)
+			require.Len(t, diags, len(tc.expect), "unexpected diagnostics: %s", diags)
+			for i, expect := range tc.expect {
+				require.Equal(t, diag.SeverityLevelError, diags[i].Severity)
+				require.Contains(t, diags[i].Message, expect)
+			}
+		})
+	}
+}
@@ -3,7 +3,9 @@ package logging
 import (
 	"fmt"
 	"io"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -63,5 +65,32 @@ func buildLogger(w io.Writer, o Options) (log.Logger, error) {
 	l = level.NewFilter(l, o.Level.Filter())
 
 	l = log.With(l, "ts", log.DefaultTimestampUTC)
+	l = withFields(l, o.Fields)
+
+	// Sampling must wrap the logger after the timestamp is bound, otherwise
+	// every line would be unique.
+	if o.Sampling != nil {
+		l = newSampler(l, *o.Sampling, time.Now)
+	}
 	return l, nil
 }
+
+// withFields binds static fields to l, sorted by key so that they're always
+// written in the same order.
+func withFields(l log.Logger, fields map[string]string) log.Logger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvps := make([]interface{}, 0, len(fields)*2)
+	for _, k := range keys {
+		kvps = append(kvps, k, fields[k])
+	}
+	return log.With(l, kvps...)
+}
@@ -3,6 +3,7 @@ package logging
 import (
 	"encoding"
 	"fmt"
+	"time"
 
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/pkg/river"
@@ -13,6 +14,13 @@ type Options struct {
 	Level  Level  `river:"level,attr,optional"`
 	Format Format `river:"format,attr,optional"`
 
+	// Fields are static key/value pairs added to every log line.
+	Fields map[string]string `river:"fields,attr,optional"`
+
+	// Sampling, when set, limits how often identical warning and error lines
+	// are written.
+	Sampling *SamplingOptions `river:"sampling,block,optional"`
+
 	// TODO: log sink parameter (e.g., to use the Windows Event logger)
 }
 
@@ -29,6 +37,42 @@ func (o *Options) SetToDefault() {
 	*o = DefaultOptions
 }
 
+// SamplingOptions configures sampling of repeated log lines.
+type SamplingOptions struct {
+	// Period is the window over which identical lines are counted.
+	Period time.Duration `river:"period,attr,optional"`
+	// Initial is the number of identical lines written per period before
+	// further lines are dropped.
+	Initial int `river:"initial,attr,optional"`
+}
+
+// DefaultSamplingOptions holds defaults for SamplingOptions.
+var DefaultSamplingOptions = SamplingOptions{
+	Period:  time.Minute,
+	Initial: 1,
+}
+
+var (
+	_ river.Defaulter = (*SamplingOptions)(nil)
+	_ river.Validator = (*SamplingOptions)(nil)
+)
+
+// SetToDefault implements river.Defaulter.
+func (o *SamplingOptions) SetToDefault() {
+	*o = DefaultSamplingOptions
+}
+
+// Validate implements river.Validator.
+func (o *SamplingOptions) Validate() error {
+	if o.Period <= 0 {
+		return fmt.Errorf("period must be greater than 0")
+	}
+	if o.Initial < 1 {
+		return fmt.Errorf("initial must be at least 1")
+	}
+	return nil
+}
+
 // Level represents how verbose logging should be.
 type Level string
 
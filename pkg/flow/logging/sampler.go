@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// sampler is a log.Logger which limits how often identical warning and
+// error lines are written. Lines are identical when all of their key/value
+// pairs are equal.
+//
+// The first lines of each period are written; once lines are written again
+// after some were dropped, the number of dropped lines is added to the line
+// under the "suppressed" key.
+type sampler struct {
+	next    log.Logger
+	opts    SamplingOptions
+	nowFunc func() time.Time
+
+	mut       sync.Mutex
+	lines     map[string]*sampledLine
+	lastSweep time.Time
+}
+
+type sampledLine struct {
+	periodStart time.Time
+	written     int // Lines written in the current period.
+	suppressed  int // Lines dropped since the last written line.
+}
+
+func newSampler(next log.Logger, opts SamplingOptions, nowFunc func() time.Time) *sampler {
+	return &sampler{
+		next:    next,
+		opts:    opts,
+		nowFunc: nowFunc,
+
+		lines:     make(map[string]*sampledLine),
+		lastSweep: nowFunc(),
+	}
+}
+
+// Log implements log.Logger.
+func (s *sampler) Log(kvps ...interface{}) error {
+	if !shouldSample(kvps) {
+		return s.next.Log(kvps...)
+	}
+
+	key := fmt.Sprint(kvps...)
+	now := s.nowFunc()
+
+	s.mut.Lock()
+	s.sweep(now)
+
+	line, ok := s.lines[key]
+	if !ok {
+		line = &sampledLine{periodStart: now}
+		s.lines[key] = line
+	}
+	if now.Sub(line.periodStart) >= s.opts.Period {
+		line.periodStart = now
+		line.written = 0
+	}
+
+	if line.written >= s.opts.Initial {
+		line.suppressed++
+		s.mut.Unlock()
+		return nil
+	}
+
+	line.written++
+	suppressed := line.suppressed
+	line.suppressed = 0
+	s.mut.Unlock()
+
+	if suppressed > 0 {
+		kvps = append(kvps, "suppressed", suppressed)
+	}
+	return s.next.Log(kvps...)
+}
+
+// sweep removes lines which haven't been logged for a whole period, so that
+// memory doesn't grow with every distinct line ever logged. s.mut must be
+// held when calling sweep.
+func (s *sampler) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.opts.Period {
+		return
+	}
+	s.lastSweep = now
+
+	for key, line := range s.lines {
+		if now.Sub(line.periodStart) >= 2*s.opts.Period {
+			delete(s.lines, key)
+		}
+	}
+}
+
+// shouldSample reports whether kvps is a warning or error line.
+func shouldSample(kvps []interface{}) bool {
+	for i := 0; i+1 < len(kvps); i += 2 {
+		if kvps[i] != level.Key() {
+			continue
+		}
+		switch kvps[i+1] {
+		case level.WarnValue(), level.ErrorValue():
+			return true
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Unix(0, 0)
+
+	s := newSampler(log.NewLogfmtLogger(&buf), SamplingOptions{Period: time.Minute, Initial: 2}, func() time.Time { return now })
+	l := log.With(s, "component", "remote.write")
+
+	for i := 0; i < 5; i++ {
+		level.Error(l).Log("msg", "failed to send batch")
+	}
+	// Info lines and distinct error lines are never dropped.
+	level.Info(l).Log("msg", "failed to send batch")
+	level.Info(l).Log("msg", "failed to send batch")
+	level.Error(l).Log("msg", "other error")
+
+	// Once the period ends, the next identical line reports how many lines
+	// were dropped.
+	now = now.Add(time.Minute)
+	level.Error(l).Log("msg", "failed to send batch")
+
+	require.Equal(t, []string{
+		`level=error component=remote.write msg="failed to send batch"`,
+		`level=error component=remote.write msg="failed to send batch"`,
+		`level=info component=remote.write msg="failed to send batch"`,
+		`level=info component=remote.write msg="failed to send batch"`,
+		`level=error component=remote.write msg="other error"`,
+		`level=error component=remote.write msg="failed to send batch" suppressed=3`,
+	}, strings.Split(strings.TrimSpace(buf.String()), "\n"))
+}
+
+func TestSampler_Sweep(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newSampler(log.NewNopLogger(), DefaultSamplingOptions, func() time.Time { return now })
+
+	level.Warn(s).Log("msg", "a")
+	level.Warn(s).Log("msg", "b")
+	require.Len(t, s.lines, 2)
+
+	now = now.Add(time.Minute)
+	level.Warn(s).Log("msg", "a")
+	require.Len(t, s.lines, 2)
+
+	now = now.Add(time.Minute)
+	level.Warn(s).Log("msg", "a")
+	require.Len(t, s.lines, 1)
+}
+
+func TestLogger_Fields(t *testing.T) {
+	var buf bytes.Buffer
+
+	l, err := New(&buf, Options{
+		Level:  LevelInfo,
+		Format: FormatJSON,
+		Fields: map[string]string{"cluster": "prod", "app": "agent"},
+	})
+	require.NoError(t, err)
+
+	level.Info(l).Log("msg", "hello")
+	require.Regexp(t, `^\{"app":"agent","cluster":"prod","level":"info","msg":"hello","ts":"[^"]+"\}\n$`, buf.String())
+}
@@ -5,22 +5,26 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"sort"
 	"sync"
 
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/component"
 	"github.com/grafana/agent/pkg/cluster"
+	"github.com/grafana/agent/pkg/events"
+	"github.com/grafana/agent/pkg/flow/componentpolicy"
 	"github.com/grafana/agent/pkg/flow/internal/controller"
 	"github.com/grafana/agent/pkg/flow/logging"
 	"github.com/grafana/agent/pkg/flow/tracing"
+	"github.com/grafana/agent/pkg/kv"
 	"github.com/grafana/agent/web/api"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 type moduleController struct {
-	mut sync.Mutex
-	o   *moduleControllerOptions
-	ids map[string]struct{}
+	mut     sync.Mutex
+	o       *moduleControllerOptions
+	modules map[string]*module
 }
 
 var (
@@ -30,8 +34,8 @@ var (
 // newModuleController is the entrypoint into creating module instances.
 func newModuleController(o *moduleControllerOptions) component.ModuleController {
 	return &moduleController{
-		o:   o,
-		ids: map[string]struct{}{},
+		o:       o,
+		modules: map[string]*module{},
 	}
 }
 
@@ -43,24 +47,44 @@ func (m *moduleController) NewModule(id string, export component.ExportFunc) (co
 	if id != "" {
 		fullPath = path.Join(fullPath, id)
 	}
-	if _, found := m.ids[fullPath]; found {
+	if _, found := m.modules[fullPath]; found {
 		return nil, fmt.Errorf("id %s already exists", id)
 	}
-	m.ids[fullPath] = struct{}{}
 
-	return newModule(&moduleOptions{
+	mod := newModule(&moduleOptions{
 		ID:                      fullPath,
 		export:                  export,
 		moduleControllerOptions: m.o,
 		parent:                  m,
-	}), nil
+	})
+	m.modules[fullPath] = mod
+	return mod, nil
 }
 
 func (m *moduleController) removeID(id string) {
 	m.mut.Lock()
 	defer m.mut.Unlock()
 
-	delete(m.ids, id)
+	delete(m.modules, id)
+}
+
+// loadedModules returns the modules which loaded a config, ordered by ID.
+func (m *moduleController) loadedModules() []*module {
+	m.mut.Lock()
+	all := make([]*module, 0, len(m.modules))
+	for _, mod := range m.modules {
+		all = append(all, mod)
+	}
+	m.mut.Unlock()
+
+	var modules []*module
+	for _, mod := range all {
+		if mod.controller() != nil {
+			modules = append(modules, mod)
+		}
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].o.ID < modules[j].o.ID })
+	return modules
 }
 
 type module struct {
@@ -93,14 +117,20 @@ func (c *module) LoadConfig(config []byte, args map[string]any) error {
 	defer c.mut.Unlock()
 	if c.f == nil {
 		f := New(Options{
-			ControllerID:   c.o.ID,
-			Tracer:         c.o.Tracer,
-			Clusterer:      c.o.Clusterer,
-			Reg:            c.o.Reg,
-			Logger:         c.o.Logger,
-			DataPath:       c.o.DataPath,
-			HTTPPathPrefix: c.o.HTTPPath,
-			HTTPListenAddr: c.o.HTTPListenAddr,
+			ControllerID:    c.o.ID,
+			Tracer:          c.o.Tracer,
+			restarts:        c.o.Restarts,
+			watchdog:        c.o.Watchdog,
+			ComponentPolicy: c.o.Policy,
+			module:          true,
+			Clusterer:       c.o.Clusterer,
+			KV:              c.o.KV,
+			Events:          c.o.Events,
+			Reg:             c.o.Reg,
+			Logger:          c.o.Logger,
+			DataPath:        c.o.DataPath,
+			HTTPPathPrefix:  c.o.HTTPPath,
+			HTTPListenAddr:  c.o.HTTPListenAddr,
 			OnExportsChange: func(exports map[string]any) {
 				c.o.export(exports)
 			},
@@ -116,6 +146,14 @@ func (c *module) LoadConfig(config []byte, args map[string]any) error {
 	return c.f.LoadFile(ff, args)
 }
 
+// controller returns the Flow controller of the module, or nil if the module
+// didn't load a config yet.
+func (c *module) controller() *Flow {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.f
+}
+
 // Run starts the Module. No components within the Module
 // will be run until Run is called.
 //
@@ -155,10 +193,25 @@ type moduleControllerOptions struct {
 	// nil.
 	Tracer *tracing.Tracer
 
+	// Restarts holds the restart policies of components.
+	Restarts *controller.RestartPolicies
+
+	// Watchdog checks the output of components.
+	Watchdog *controller.Watchdog
+
+	// Policy restricts which components may be used.
+	Policy *componentpolicy.Policy
+
 	// Clusterer for implementing distributed behavior among components running
 	// on different nodes.
 	Clusterer *cluster.Clusterer
 
+	// KV is the key-value store components persist state in.
+	KV *kv.Store
+
+	// Events is the event bus components publish operational events to.
+	Events *events.Bus
+
 	// Reg is the prometheus register to use
 	Reg prometheus.Registerer
 
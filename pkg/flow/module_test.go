@@ -92,6 +92,74 @@ func TestModule(t *testing.T) {
 			exportModuleContent:   exportStringConfig + exportStringConfig,
 			expectedErrorContains: "\"export.username\" block already declared",
 		},
+		{
+			name: "Typed argument",
+			argumentModuleContent: `
+				argument "port" {
+					type = "number"
+					min  = 1
+					max  = 65535
+				}`,
+			args: map[string]interface{}{"port": 8080},
+		},
+		{
+			name:                  "Argument of the wrong type",
+			argumentModuleContent: `argument "port" { type = "number" }`,
+			args:                  map[string]interface{}{"port": "8080"},
+			expectedErrorContains: "invalid value for argument \"port\": expected number, got string",
+		},
+		{
+			name:                  "Argument out of range",
+			argumentModuleContent: `argument "port" { min = 1 }`,
+			args:                  map[string]interface{}{"port": 0},
+			expectedErrorContains: "invalid value for argument \"port\": 0 is less than the minimum of 1",
+		},
+		{
+			name: "Argument not in allowed values",
+			argumentModuleContent: `
+				argument "env" {
+					type           = "string"
+					allowed_values = ["dev", "prod"]
+				}`,
+			args:                  map[string]interface{}{"env": "prdo"},
+			expectedErrorContains: "invalid value for argument \"env\": prdo is not one of the allowed values",
+		},
+		{
+			name: "Argument not matching regex",
+			argumentModuleContent: `
+				argument "token" {
+					type  = "secret"
+					regex = "[a-z]+"
+				}`,
+			args:                  map[string]interface{}{"token": "abc123"},
+			expectedErrorContains: "invalid value for argument \"token\": secret does not match regex",
+		},
+		{
+			name: "Default argument is validated",
+			argumentModuleContent: `
+				argument "env" {
+					optional       = true
+					default        = "staging"
+					allowed_values = ["dev", "prod"]
+				}`,
+			expectedErrorContains: "invalid value for argument \"env\": staging is not one of the allowed values",
+		},
+		{
+			name:                  "Unknown argument type",
+			argumentModuleContent: `argument "port" { type = "integer" }`,
+			args:                  map[string]interface{}{"port": 8080},
+			expectedErrorContains: "unknown type \"integer\", must be one of string, number, bool, list, map, secret",
+		},
+		{
+			name: "Min and max on non-number argument",
+			argumentModuleContent: `
+				argument "env" {
+					type = "string"
+					min  = 1
+				}`,
+			args:                  map[string]interface{}{"env": "dev"},
+			expectedErrorContains: "min and max can only be used with arguments of type number",
+		},
 		{
 			name:                "Multiple exports but none are used but still exported",
 			exportModuleContent: exportStringConfig + exportDummy,
@@ -193,7 +261,7 @@ func TestIDRemoval(t *testing.T) {
 	ctx, cncl := context.WithTimeout(ctx, 1*time.Second)
 	defer cncl()
 	m.Run(ctx)
-	require.Len(t, nc.(*moduleController).ids, 0)
+	require.Len(t, nc.(*moduleController).modules, 0)
 }
 
 func testModuleControllerOptions(t *testing.T) *moduleControllerOptions {
@@ -0,0 +1,221 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	goplugin "github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The gRPC service implemented by plugins. Its messages are well-known
+// protobuf types carrying JSON, so that the protocol doesn't need generated
+// code:
+//
+//	service Component {
+//	  // Returns the JSON encoded Schema of the component.
+//	  rpc Schema(google.protobuf.Empty) returns (google.protobuf.BytesValue);
+//	  // Updates the component with JSON encoded arguments.
+//	  rpc Update(google.protobuf.BytesValue) returns (google.protobuf.Empty);
+//	  // Runs the component, streaming JSON encoded events until the stream is
+//	  // canceled.
+//	  rpc Run(google.protobuf.Empty) returns (stream google.protobuf.BytesValue);
+//	}
+const serviceName = "grafana.agent.plugin.v1.Component"
+
+// event is streamed by Run whenever the state of the component changes.
+type event struct {
+	Exports json.RawMessage `json:"exports,omitempty"`
+	Health  *eventHealth    `json:"health,omitempty"`
+}
+
+type eventHealth struct {
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message"`
+}
+
+var componentServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*Component)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Schema", Handler: handleSchema},
+		{MethodName: "Update", Handler: handleUpdate},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Run", Handler: handleRun, ServerStreams: true},
+	},
+}
+
+func handleSchema(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handler := func(_ context.Context, _ any) (any, error) {
+		bb, err := json.Marshal(srv.(Component).Schema())
+		if err != nil {
+			return nil, err
+		}
+		return wrapperspb.Bytes(bb), nil
+	}
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Schema"}, handler)
+}
+
+func handleUpdate(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+	in := new(wrapperspb.BytesValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	handler := func(_ context.Context, req any) (any, error) {
+		if err := srv.(Component).Update(req.(*wrapperspb.BytesValue).GetValue()); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return &emptypb.Empty{}, nil
+	}
+	if interceptor == nil {
+		return handler(ctx, in)
+	}
+	return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/Update"}, handler)
+}
+
+func handleRun(srv any, stream grpc.ServerStream) error {
+	if err := stream.RecvMsg(new(emptypb.Empty)); err != nil {
+		return err
+	}
+	return srv.(Component).Run(stream.Context(), &streamHost{stream: stream})
+}
+
+// streamHost implements Host by sending events over the stream of Run.
+type streamHost struct {
+	mut    sync.Mutex
+	stream grpc.ServerStream
+}
+
+func (h *streamHost) SetExports(exports any) error {
+	bb, err := json.Marshal(exports)
+	if err != nil {
+		return fmt.Errorf("encoding exports: %w", err)
+	}
+	return h.send(event{Exports: bb})
+}
+
+func (h *streamHost) SetHealth(healthy bool, message string) error {
+	return h.send(event{Health: &eventHealth{Healthy: healthy, Message: message}})
+}
+
+func (h *streamHost) send(ev event) error {
+	bb, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+
+	// Messages mustn't be sent concurrently on the same stream.
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	return h.stream.SendMsg(wrapperspb.Bytes(bb))
+}
+
+// grpcClient calls the gRPC service of a plugin.
+type grpcClient struct {
+	conn *grpc.ClientConn
+}
+
+// Schema retrieves the schema of the component.
+func (c *grpcClient) Schema(ctx context.Context) (Schema, error) {
+	out := new(wrapperspb.BytesValue)
+	if err := c.conn.Invoke(ctx, "/"+serviceName+"/Schema", &emptypb.Empty{}, out); err != nil {
+		return Schema{}, err
+	}
+	var s Schema
+	if err := json.Unmarshal(out.GetValue(), &s); err != nil {
+		return Schema{}, fmt.Errorf("decoding schema: %w", err)
+	}
+	return s, nil
+}
+
+// Update sends JSON encoded arguments to the component.
+func (c *grpcClient) Update(ctx context.Context, args []byte) error {
+	err := c.conn.Invoke(ctx, "/"+serviceName+"/Update", wrapperspb.Bytes(args), new(emptypb.Empty))
+	if s, ok := status.FromError(err); ok && s.Code() == codes.InvalidArgument {
+		// Report errors returned by Update without the gRPC status.
+		return fmt.Errorf("%s", s.Message())
+	}
+	return err
+}
+
+// Run runs the component until ctx is canceled, calling onEvent for every
+// event it sends.
+func (c *grpcClient) Run(ctx context.Context, onEvent func(event) error) error {
+	stream, err := c.conn.NewStream(ctx, &componentServiceDesc.Streams[0], "/"+serviceName+"/Run")
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	for {
+		msg := new(wrapperspb.BytesValue)
+		err := stream.RecvMsg(msg)
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case err == io.EOF:
+			return fmt.Errorf("plugin stopped running")
+		case err != nil:
+			return err
+		}
+
+		var ev event
+		if err := json.Unmarshal(msg.GetValue(), &ev); err != nil {
+			return fmt.Errorf("decoding event: %w", err)
+		}
+		if err := onEvent(ev); err != nil {
+			return err
+		}
+	}
+}
+
+// grpcPlugin implements goplugin.GRPCPlugin. impl is only set in plugin
+// processes.
+type grpcPlugin struct {
+	goplugin.NetRPCUnsupportedPlugin
+	impl Component
+}
+
+var _ goplugin.GRPCPlugin = (*grpcPlugin)(nil)
+
+func (p *grpcPlugin) GRPCServer(_ *goplugin.GRPCBroker, s *grpc.Server) error {
+	s.RegisterService(&componentServiceDesc, p.impl)
+	return nil
+}
+
+func (p *grpcPlugin) GRPCClient(_ context.Context, _ *goplugin.GRPCBroker, conn *grpc.ClientConn) (any, error) {
+	return &grpcClient{conn: conn}, nil
+}
+
+// Serve serves c to the agent. It must be called from the main function of
+// the plugin, and only returns once the agent stops the plugin.
+//
+// Plugins must not write to stdout, which is used for the handshake with the
+// agent. Lines written to stderr are logged by the agent.
+func Serve(c Component) {
+	goplugin.Serve(&goplugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins:         map[string]goplugin.Plugin{pluginName: &grpcPlugin{impl: c}},
+		GRPCServer:      goplugin.DefaultGRPCServer,
+	})
+}
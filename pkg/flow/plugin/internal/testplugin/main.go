@@ -0,0 +1,75 @@
+// Command testplugin is a plugin implementing a test.echo component, which
+// exports the message it's given. It's used to test loading plugins.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/agent/pkg/flow/plugin"
+)
+
+func main() {
+	plugin.Serve(&echo{update: make(chan struct{}, 1)})
+}
+
+type echo struct {
+	update chan struct{}
+
+	mut     sync.Mutex
+	message string
+}
+
+func (e *echo) Schema() plugin.Schema {
+	return plugin.Schema{
+		Name: "test.echo",
+		Arguments: []plugin.Attribute{
+			{Name: "message", Type: plugin.TypeString},
+		},
+		Exports: []plugin.Attribute{
+			{Name: "message", Type: plugin.TypeString},
+		},
+	}
+}
+
+func (e *echo) Update(args []byte) error {
+	var decoded struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(args, &decoded); err != nil {
+		return err
+	}
+	if decoded.Message == "" {
+		return fmt.Errorf("message must not be empty")
+	}
+
+	e.mut.Lock()
+	e.message = decoded.Message
+	e.mut.Unlock()
+
+	select {
+	case e.update <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (e *echo) Run(ctx context.Context, host plugin.Host) error {
+	for {
+		e.mut.Lock()
+		message := e.message
+		e.mut.Unlock()
+
+		if err := host.SetExports(map[string]string{"message": message}); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-e.update:
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/component"
+	"github.com/hashicorp/go-hclog"
+	goplugin "github.com/hashicorp/go-plugin"
+)
+
+// LoadDir registers the components of the plugins found in dir. Every
+// executable file directly inside of dir is a plugin. LoadDir must be called
+// before any config using the components is loaded.
+//
+// LoadDir starts every plugin once to retrieve its Schema. Plugins are then
+// started again for every block of their component.
+func LoadDir(l log.Logger, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading plugins directory: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if ok, err := isExecutable(path); err != nil || !ok {
+			continue
+		}
+
+		schema, err := readSchema(l, path)
+		if err != nil {
+			return fmt.Errorf("loading plugin %s: %w", path, err)
+		}
+		if err := register(path, schema); err != nil {
+			return fmt.Errorf("loading plugin %s: %w", path, err)
+		}
+		level.Info(l).Log("msg", "registered component from plugin", "component", schema.Name, "path", path)
+	}
+	return nil
+}
+
+func isExecutable(path string) (bool, error) {
+	fi, err := os.Stat(path)
+	if err != nil || fi.IsDir() {
+		return false, err
+	}
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe"), nil
+	}
+	return fi.Mode().Perm()&0111 != 0, nil
+}
+
+// readSchema starts the plugin at path to retrieve its schema.
+func readSchema(l log.Logger, path string) (Schema, error) {
+	client, grpcClient, err := startPlugin(l, path)
+	if err != nil {
+		return Schema{}, err
+	}
+	defer client.Kill()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	schema, err := grpcClient.Schema(ctx)
+	if err != nil {
+		return Schema{}, fmt.Errorf("retrieving schema: %w", err)
+	}
+	if err := schema.Validate(); err != nil {
+		return Schema{}, fmt.Errorf("invalid schema: %w", err)
+	}
+	return schema, nil
+}
+
+// startPlugin starts a new process of the plugin at path.
+func startPlugin(l log.Logger, path string) (*goplugin.Client, *grpcClient, error) {
+	client := goplugin.NewClient(&goplugin.ClientConfig{
+		HandshakeConfig:  Handshake,
+		Plugins:          map[string]goplugin.Plugin{pluginName: &grpcPlugin{}},
+		Cmd:              exec.Command(path),
+		AllowedProtocols: []goplugin.Protocol{goplugin.ProtocolGRPC},
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:        filepath.Base(path),
+			Level:       hclog.Info,
+			Output:      log.NewStdlibAdapter(l),
+			DisableTime: true,
+		}),
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, nil, fmt.Errorf("starting plugin: %w", err)
+	}
+	raw, err := rpcClient.Dispense(pluginName)
+	if err != nil {
+		client.Kill()
+		return nil, nil, fmt.Errorf("starting plugin: %w", err)
+	}
+	return client, raw.(*grpcClient), nil
+}
+
+// pluginType describes the component implemented by a plugin.
+type pluginType struct {
+	path        string
+	schema      Schema
+	exportsType reflect.Type // nil if the component has no exports.
+}
+
+// register registers the component described by schema, implemented by the
+// plugin at path.
+func register(path string, schema Schema) (err error) {
+	if _, exists := component.Get(schema.Name); exists {
+		return fmt.Errorf("component %q already registered", schema.Name)
+	}
+
+	argsType, err := structType(schema.Arguments, false)
+	if err != nil {
+		return err
+	}
+	typ := pluginType{path: path, schema: schema}
+	var exports component.Exports
+	if len(schema.Exports) > 0 {
+		typ.exportsType, err = structType(schema.Exports, true)
+		if err != nil {
+			return err
+		}
+		exports = reflect.New(typ.exportsType).Elem().Interface()
+	}
+
+	// Register panics on invalid component names.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	component.Register(component.Registration{
+		Name:    schema.Name,
+		Args:    reflect.New(argsType).Elem().Interface(),
+		Exports: exports,
+
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return newPluginComponent(opts, typ, args)
+		},
+	})
+	return nil
+}
+
+// pluginComponent is a component implemented by a plugin process.
+type pluginComponent struct {
+	opts   component.Options
+	typ    pluginType
+	client *goplugin.Client
+	conn   *grpcClient
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ component.Component       = (*pluginComponent)(nil)
+	_ component.HealthComponent = (*pluginComponent)(nil)
+)
+
+func newPluginComponent(opts component.Options, typ pluginType, args component.Arguments) (*pluginComponent, error) {
+	client, conn, err := startPlugin(opts.Logger, typ.path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &pluginComponent{
+		opts:   opts,
+		typ:    typ,
+		client: client,
+		conn:   conn,
+		health: component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "plugin started",
+			UpdateTime: time.Now(),
+		},
+	}
+	if err := c.Update(args); err != nil {
+		client.Kill()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *pluginComponent) Run(ctx context.Context) error {
+	defer c.client.Kill()
+
+	err := c.conn.Run(ctx, func(ev event) error {
+		if ev.Exports != nil && c.typ.exportsType != nil {
+			exports, err := decodeValue(c.typ.schema.Exports, c.typ.exportsType, ev.Exports)
+			if err != nil {
+				return fmt.Errorf("invalid exports: %w", err)
+			}
+			c.opts.OnStateChange(exports)
+		}
+		if ev.Health != nil {
+			health := component.HealthTypeHealthy
+			if !ev.Health.Healthy {
+				health = component.HealthTypeUnhealthy
+			}
+			c.setHealth(health, ev.Health.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		c.setHealth(component.HealthTypeExited, err.Error())
+		return fmt.Errorf("plugin exited: %w", err)
+	}
+	return nil
+}
+
+// Update implements component.Component.
+func (c *pluginComponent) Update(args component.Arguments) error {
+	bb, err := encodeValue(c.typ.schema.Arguments, args)
+	if err != nil {
+		return fmt.Errorf("encoding arguments: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return c.conn.Update(ctx, bb)
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *pluginComponent) CurrentHealth() component.Health {
+	c.healthMut.RLock()
+	defer c.healthMut.RUnlock()
+	return c.health
+}
+
+func (c *pluginComponent) setHealth(health component.HealthType, message string) {
+	c.healthMut.Lock()
+	defer c.healthMut.Unlock()
+	c.health = component.Health{Health: health, Message: message, UpdateTime: time.Now()}
+}
@@ -0,0 +1,135 @@
+// Package plugin implements external Flow components, which run as separate
+// processes and are registered at runtime from a plugins directory.
+//
+// A plugin is an executable which calls Serve with its Component
+// implementation. The agent starts one plugin process for every block of the
+// component in the config, and talks to it over gRPC using the protocol of
+// hashicorp/go-plugin. The agent handles decoding the component's block,
+// health reporting, and wiring its exports to other components, based on the
+// Schema the plugin describes itself with.
+//
+// Only plain values can be passed between the agent and a plugin: strings,
+// numbers, bools, secrets, durations, lists and maps of strings, and
+// arbitrary values which can be encoded as JSON. Capsule values, such as the
+// receivers of logs or metrics, can't cross process boundaries.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	goplugin "github.com/hashicorp/go-plugin"
+)
+
+// Handshake is the handshake agreed on by the agent and its plugins. The
+// ProtocolVersion is increased whenever the protocol changes in a way which
+// isn't backwards compatible.
+var Handshake = goplugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "GRAFANA_AGENT_PLUGIN",
+	MagicCookieValue: "flow-component",
+}
+
+// pluginName is the name the component is served with by plugin processes.
+const pluginName = "component"
+
+// Component is implemented by plugins to provide the behavior of a
+// component.
+//
+// Update is called with the initial arguments of the component before Run is
+// called, and then whenever the arguments change.
+type Component interface {
+	// Schema describes the component to the agent.
+	Schema() Schema
+
+	// Update updates the component with new arguments. args is a JSON object
+	// keyed by the names of the argument attributes of the Schema; optional
+	// attributes which aren't set hold their zero value, and durations are
+	// encoded as Go duration strings such as "1m30s".
+	//
+	// An error rejects the arguments, failing the evaluation of the
+	// component's block.
+	Update(args []byte) error
+
+	// Run runs the component until ctx is canceled. An error returned by Run
+	// is reported by the agent as the component having exited.
+	Run(ctx context.Context, host Host) error
+}
+
+// Host is used by a running Component to report its state to the agent.
+type Host interface {
+	// SetExports updates the exports of the component. exports must encode
+	// to a JSON object keyed by the names of the export attributes of the
+	// Schema.
+	SetExports(exports any) error
+
+	// SetHealth updates the health of the component.
+	SetHealth(healthy bool, message string) error
+}
+
+// Schema describes a component implemented by a plugin.
+type Schema struct {
+	// Name of the component, such as "acme.source". Must not be used by
+	// another component.
+	Name      string      `json:"name"`
+	Arguments []Attribute `json:"arguments,omitempty"`
+	Exports   []Attribute `json:"exports,omitempty"`
+}
+
+// Attribute describes an attribute of the arguments or exports of a
+// component.
+type Attribute struct {
+	Name string `json:"name"`
+	Type Type   `json:"type"`
+
+	// Optional marks an argument as optional. It's ignored for exports.
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Type is the type of an Attribute.
+type Type string
+
+// Supported attribute types.
+const (
+	TypeString     Type = "string"
+	TypeNumber     Type = "number"
+	TypeBool       Type = "bool"
+	TypeSecret     Type = "secret"
+	TypeDuration   Type = "duration"
+	TypeStringList Type = "list(string)"
+	TypeStringMap  Type = "map(string)"
+	TypeAny        Type = "any"
+)
+
+var attributeNameRegex = regexp.MustCompile("^[A-Za-z_][0-9A-Za-z_]*$")
+
+// Validate validates the schema.
+func (s Schema) Validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("missing component name")
+	}
+	for _, list := range []struct {
+		kind  string
+		attrs []Attribute
+	}{
+		{"argument", s.Arguments},
+		{"export", s.Exports},
+	} {
+		seen := make(map[string]struct{}, len(list.attrs))
+		for _, attr := range list.attrs {
+			if !attributeNameRegex.MatchString(attr.Name) {
+				return fmt.Errorf("invalid %s name %q", list.kind, attr.Name)
+			}
+			if _, ok := seen[attr.Name]; ok {
+				return fmt.Errorf("%s %q declared more than once", list.kind, attr.Name)
+			}
+			seen[attr.Name] = struct{}{}
+
+			if _, err := attr.Type.goType(); err != nil {
+				return fmt.Errorf("%s %q: %w", list.kind, attr.Name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/flow/componenttest"
+	"github.com/grafana/agent/pkg/river"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/grafana/agent/pkg/util"
+	goplugin "github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/require"
+)
+
+var testSchema = Schema{
+	Name: "test.component",
+	Arguments: []Attribute{
+		{Name: "url", Type: TypeString},
+		{Name: "password", Type: TypeSecret, Optional: true},
+		{Name: "interval", Type: TypeDuration, Optional: true},
+		{Name: "labels", Type: TypeStringMap, Optional: true},
+		{Name: "extra", Type: TypeAny, Optional: true},
+	},
+	Exports: []Attribute{
+		{Name: "count", Type: TypeNumber},
+		{Name: "timeout", Type: TypeDuration},
+	},
+}
+
+func TestSchema_Validate(t *testing.T) {
+	require.NoError(t, testSchema.Validate())
+
+	tt := []struct {
+		schema Schema
+		expect string
+	}{
+		{Schema{}, "missing component name"},
+		{Schema{Name: "a.b", Arguments: []Attribute{{Name: "1st", Type: TypeString}}}, `invalid argument name "1st"`},
+		{Schema{Name: "a.b", Exports: []Attribute{{Name: "x", Type: "list(number)"}}}, `export "x": unsupported type "list(number)"`},
+		{Schema{Name: "a.b", Arguments: []Attribute{{Name: "x", Type: TypeString}, {Name: "x", Type: TypeBool}}}, `argument "x" declared more than once`},
+	}
+	for _, tc := range tt {
+		require.EqualError(t, tc.schema.Validate(), tc.expect)
+	}
+}
+
+func TestStructType(t *testing.T) {
+	argsType, err := structType(testSchema.Arguments, false)
+	require.NoError(t, err)
+
+	args := reflect.New(argsType)
+	require.NoError(t, river.Unmarshal([]byte(`
+		url      = "http://localhost:8080"
+		password = "hunter2"
+		interval = "30s"
+		extra    = { enabled = true }
+	`), args.Interface()))
+
+	bb, err := encodeValue(testSchema.Arguments, args.Elem().Interface())
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"url":      "http://localhost:8080",
+		"password": "hunter2",
+		"interval": "30s",
+		"labels":   null,
+		"extra":    {"enabled": true}
+	}`, string(bb))
+
+	// Arguments which aren't optional are required.
+	err = river.Unmarshal([]byte(`interval = "1m"`), reflect.New(argsType).Interface())
+	require.ErrorContains(t, err, `missing required attribute "url"`)
+	_, ok := args.Elem().Field(1).Interface().(rivertypes.Secret)
+	require.True(t, ok, "secret arguments must be decoded as secrets")
+}
+
+func TestDecodeValue(t *testing.T) {
+	exportsType, err := structType(testSchema.Exports, true)
+	require.NoError(t, err)
+
+	v, err := decodeValue(testSchema.Exports, exportsType, []byte(`{"count": 3, "timeout": "1m30s", "unknown": true}`))
+	require.NoError(t, err)
+	rv := reflect.ValueOf(v)
+	require.Equal(t, float64(3), rv.Field(0).Interface())
+	require.Equal(t, 90*time.Second, rv.Field(1).Interface())
+
+	// Missing exports are left to their zero value.
+	v, err = decodeValue(testSchema.Exports, exportsType, []byte(`{}`))
+	require.NoError(t, err)
+	require.Equal(t, reflect.New(exportsType).Elem().Interface(), v)
+
+	_, err = decodeValue(testSchema.Exports, exportsType, []byte(`{"timeout": "soon"}`))
+	require.ErrorContains(t, err, `decoding "timeout"`)
+}
+
+func TestGRPC(t *testing.T) {
+	impl := &fakeComponent{
+		updates: make(chan []byte, 1),
+		run: func(ctx context.Context, host Host) error {
+			if err := host.SetExports(map[string]any{"count": 1}); err != nil {
+				return err
+			}
+			if err := host.SetHealth(false, "backend unreachable"); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return nil
+		},
+	}
+	conn := testClient(t, impl)
+
+	schema, err := conn.Schema(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, testSchema, schema)
+
+	require.NoError(t, conn.Update(context.Background(), []byte(`{"url": "http://localhost"}`)))
+	require.Equal(t, `{"url": "http://localhost"}`, string(<-impl.updates))
+	require.EqualError(t, conn.Update(context.Background(), []byte(`{}`)), "url must be set")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan event, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- conn.Run(ctx, func(ev event) error {
+			events <- ev
+			return nil
+		})
+	}()
+
+	ev := <-events
+	require.JSONEq(t, `{"count": 1}`, string(ev.Exports))
+	ev = <-events
+	require.Equal(t, &eventHealth{Healthy: false, Message: "backend unreachable"}, ev.Health)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestGRPC_RunError(t *testing.T) {
+	conn := testClient(t, &fakeComponent{
+		run: func(context.Context, Host) error { return fmt.Errorf("crashed") },
+	})
+	require.ErrorContains(t, conn.Run(context.Background(), func(event) error { return nil }), "crashed")
+}
+
+// TestLoadDir builds the plugin in internal/testplugin and runs the
+// component it implements.
+func TestLoadDir(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the test plugin is slow")
+	}
+
+	dir := t.TempDir()
+	name := "testplugin"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	build := exec.Command("go", "build", "-o", filepath.Join(dir, name), "./internal/testplugin")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// Files which aren't executable are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("plugins"), 0644))
+
+	l := util.TestFlowLogger(t)
+	require.NoError(t, LoadDir(l, dir))
+	reg, ok := component.Get("test.echo")
+	require.True(t, ok, "component of the plugin wasn't registered")
+
+	// Loading the same plugin again fails, since its component is already
+	// registered.
+	require.ErrorContains(t, LoadDir(l, dir), `component "test.echo" already registered`)
+
+	ctrl := componenttest.NewControllerFromReg(l, reg)
+	args := reflect.New(reflect.TypeOf(reg.Args))
+	require.NoError(t, river.Unmarshal([]byte(`message = "hello"`), args.Interface()))
+
+	ctx := componenttest.TestContext(t)
+	go func() { _ = ctrl.Run(ctx, args.Elem().Interface()) }()
+	require.NoError(t, ctrl.WaitExports(time.Minute))
+	require.Equal(t, "hello", reflect.ValueOf(ctrl.Exports()).Field(0).Interface())
+}
+
+func testClient(t *testing.T, impl Component) *grpcClient {
+	t.Helper()
+
+	client, server := goplugin.TestPluginGRPCConn(t, map[string]goplugin.Plugin{pluginName: &grpcPlugin{impl: impl}})
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+
+	raw, err := client.Dispense(pluginName)
+	require.NoError(t, err)
+	return raw.(*grpcClient)
+}
+
+type fakeComponent struct {
+	updates chan []byte
+	run     func(ctx context.Context, host Host) error
+}
+
+func (c *fakeComponent) Schema() Schema { return testSchema }
+
+func (c *fakeComponent) Update(args []byte) error {
+	if string(args) == "{}" {
+		return fmt.Errorf("url must be set")
+	}
+	c.updates <- args
+	return nil
+}
+
+func (c *fakeComponent) Run(ctx context.Context, host Host) error { return c.run(ctx, host) }
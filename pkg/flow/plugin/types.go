@@ -0,0 +1,111 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/rivertypes"
+)
+
+// goType returns the Go type River values of t are decoded into.
+func (t Type) goType() (reflect.Type, error) {
+	switch t {
+	case TypeString:
+		return reflect.TypeOf(""), nil
+	case TypeNumber:
+		return reflect.TypeOf(float64(0)), nil
+	case TypeBool:
+		return reflect.TypeOf(false), nil
+	case TypeSecret:
+		return reflect.TypeOf(rivertypes.Secret("")), nil
+	case TypeDuration:
+		return reflect.TypeOf(time.Duration(0)), nil
+	case TypeStringList:
+		return reflect.TypeOf([]string(nil)), nil
+	case TypeStringMap:
+		return reflect.TypeOf(map[string]string(nil)), nil
+	case TypeAny:
+		return reflect.TypeOf((*any)(nil)).Elem(), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q", t)
+	}
+}
+
+// structType builds a struct type with a River attribute for each of attrs,
+// which the arguments or exports of a plugin component are decoded into.
+// Plugin components don't have Go types of their own, since the agent only
+// learns about them at runtime.
+func structType(attrs []Attribute, exports bool) (reflect.Type, error) {
+	fields := make([]reflect.StructField, 0, len(attrs))
+	for i, attr := range attrs {
+		typ, err := attr.Type.goType()
+		if err != nil {
+			return nil, err
+		}
+
+		tag := attr.Name + ",attr"
+		if attr.Optional && !exports {
+			tag += ",optional"
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fmt.Sprintf("Field%d", i),
+			Type: typ,
+			Tag:  reflect.StructTag(fmt.Sprintf("river:%q", tag)),
+		})
+	}
+	return reflect.StructOf(fields), nil
+}
+
+// encodeValue encodes v, a value of a type built by structType, as a JSON
+// object.
+func encodeValue(attrs []Attribute, v any) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	res := make(map[string]any, len(attrs))
+	for i, attr := range attrs {
+		switch field := rv.Field(i).Interface().(type) {
+		case time.Duration:
+			res[attr.Name] = field.String()
+		case rivertypes.Secret:
+			res[attr.Name] = string(field)
+		default:
+			res[attr.Name] = field
+		}
+	}
+	return json.Marshal(res)
+}
+
+// decodeValue decodes the JSON object bb into a new value of typ, which was
+// built by structType for attrs. Attributes missing from bb are left to
+// their zero value.
+func decodeValue(attrs []Attribute, typ reflect.Type, bb []byte) (any, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(bb, &raw); err != nil {
+		return nil, err
+	}
+
+	rv := reflect.New(typ).Elem()
+	for i, attr := range attrs {
+		msg, ok := raw[attr.Name]
+		if !ok {
+			continue
+		}
+
+		var err error
+		if attr.Type == TypeDuration {
+			var s string
+			if err = json.Unmarshal(msg, &s); err == nil {
+				var d time.Duration
+				d, err = time.ParseDuration(s)
+				rv.Field(i).SetInt(int64(d))
+			}
+		} else {
+			err = json.Unmarshal(msg, rv.Field(i).Addr().Interface())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding %q: %w", attr.Name, err)
+		}
+	}
+	return rv.Interface(), nil
+}
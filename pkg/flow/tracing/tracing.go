@@ -16,6 +16,7 @@ import (
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
 )
 
 const serviceName = "grafana-agent"
@@ -47,6 +48,10 @@ type Options struct {
 	// WriteTo holds a set of OpenTelemetry Collector consumers where internal
 	// traces should be sent.
 	WriteTo []otelcol.Consumer `river:"write_to,attr,optional"`
+
+	// PipelineSpans enables creating spans for the data handed over between
+	// components, such as the samples of each scrape.
+	PipelineSpans bool `river:"pipeline_spans,attr,optional"`
 }
 
 type SamplerOptions struct {
@@ -83,6 +88,8 @@ type Tracer struct {
 
 	samplerMut          sync.Mutex
 	jaegerRemoteSampler *jaegerremote.Sampler // In-use jaeger remote sampler (may be nil).
+
+	pipelineSpans atomic.Bool
 }
 
 var _ trace.TracerProvider = (*Tracer)(nil)
@@ -136,6 +143,7 @@ func (t *Tracer) Update(opts Options) error {
 	defer t.samplerMut.Unlock()
 
 	t.client.UpdateWriteTo(opts.WriteTo)
+	t.pipelineSpans.Store(opts.PipelineSpans)
 
 	// Stop the previous instance of the Jaeger remote sampler if it exists. The
 	// sampler can still make sampling decisions after being closed; it just
@@ -191,3 +199,18 @@ func (t *Tracer) Run(ctx context.Context) error {
 func (t *Tracer) Tracer(name string, options ...trace.TracerOption) trace.Tracer {
 	return t.tp.Tracer(name, options...)
 }
+
+// PipelineSpansEnabled reports whether spans should be created with tp for
+// the data handed over between components, as set by the pipeline_spans
+// argument of the tracing block. It's false unless tp is a Tracer, or a
+// TracerProvider wrapping one.
+func PipelineSpansEnabled(tp trace.TracerProvider) bool {
+	switch tp := tp.(type) {
+	case *Tracer:
+		return tp.pipelineSpans.Load()
+	case *wrappedProvider:
+		return PipelineSpansEnabled(tp.inner)
+	default:
+		return false
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 
@@ -21,18 +22,22 @@ type Exporter struct {
 	cfg               Config
 	logger            *zap.SugaredLogger // used by azure client
 	ConcurrencyConfig azure_config.Opts
+
+	// probes limits the number of resource types collected at once across
+	// all scrapes.
+	probes chan struct{}
 }
 
 func (e Exporter) MetricsHandler() (http.Handler, error) {
 	//Safe to re-use as it doesn't connect to anything directly
+	//The client is shared by all resource types so they share the same credentials and token
 	client, err := armclient.NewArmClientWithCloudName(e.cfg.AzureCloudEnvironment, e.logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create azure client, %v", err)
 	}
 
 	h := http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
-		reg := prometheus.NewRegistry()
-		ctx := context.Background()
+		ctx := req.Context()
 
 		params := req.URL.Query()
 		mergedConfig := MergeConfigWithQueryParams(e.cfg, params)
@@ -49,42 +54,87 @@ func (e Exporter) MetricsHandler() (http.Handler, error) {
 			err = fmt.Errorf("unable to create azure tag manager from included_resource_tags %s, %v", strings.Join(mergedConfig.IncludedResourceTags, ","), err)
 			e.logger.Error(err)
 			http.Error(resp, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		logEntry := e.logger.With(
-			"resource_type", mergedConfig.ResourceType,
-			"resource_graph_query_filter", mergedConfig.ResourceGraphQueryFilter,
-			"subscriptions", strings.Join(mergedConfig.Subscriptions, ","),
-			"metric_namespace", mergedConfig.MetricNamespace,
-			"metrics", strings.Join(mergedConfig.Metrics, ","),
+		resources := mergedConfig.ExpandResources()
+		var (
+			wg         sync.WaitGroup
+			mut        sync.Mutex
+			gatherers  prometheus.Gatherers
+			probeError error
 		)
-
-		settings, err := mergedConfig.ToScrapeSettings()
-		if err != nil {
-			e.logger.Error(fmt.Errorf("unexpected error mapping config to scrape settings, %v", err))
-			http.Error(resp, "unexpected scrape error", http.StatusInternalServerError)
-			return
+		for _, resource := range resources {
+			wg.Add(1)
+			go func(resource Config) {
+				defer wg.Done()
+
+				reg, err := e.probe(ctx, client, tagManager, resource)
+				mut.Lock()
+				defer mut.Unlock()
+				if err != nil {
+					probeError = err
+					return
+				}
+				gatherers = append(gatherers, reg)
+			}(resource)
 		}
+		wg.Wait()
 
-		prober := metrics.NewMetricProber(ctx, logEntry, nil, settings, e.ConcurrencyConfig)
-		prober.SetAzureClient(client)
-		prober.SetPrometheusRegistry(reg)
-		prober.SetAzureResourceTagManager(tagManager)
-
-		err = prober.ServiceDiscovery.FindResourceGraph(ctx, settings.Subscriptions, settings.ResourceType, settings.Filter)
-		if err != nil {
-			e.logger.Error(fmt.Errorf("service discovery failed, %v", err))
-			http.Error(resp, "Failed to discovery azure resources", http.StatusInternalServerError)
+		// Metrics of the resource types which were collected are still
+		// served when others fail.
+		if len(gatherers) == 0 && probeError != nil {
+			http.Error(resp, probeError.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		prober.Run()
-
-		promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).ServeHTTP(resp, req)
+		promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{}).ServeHTTP(resp, req)
 	})
 	return h, nil
 }
 
+// probe collects the metrics of the resource type of cfg into a new
+// registry. It waits for the other probes to leave room when the limit of
+// concurrently collected resource types is reached.
+func (e Exporter) probe(ctx context.Context, client *armclient.ArmClient, tagManager *armclient.ResourceTagManager, cfg Config) (*prometheus.Registry, error) {
+	select {
+	case e.probes <- struct{}{}:
+		defer func() { <-e.probes }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	logEntry := e.logger.With(
+		"resource_type", cfg.ResourceType,
+		"resource_graph_query_filter", cfg.ResourceGraphQueryFilter,
+		"subscriptions", strings.Join(cfg.Subscriptions, ","),
+		"regions", strings.Join(cfg.Regions, ","),
+		"metric_namespace", cfg.MetricNamespace,
+		"metrics", strings.Join(cfg.Metrics, ","),
+	)
+
+	settings, err := cfg.ToScrapeSettings()
+	if err != nil {
+		logEntry.Error(fmt.Errorf("unexpected error mapping config to scrape settings, %v", err))
+		return nil, fmt.Errorf("unexpected scrape error")
+	}
+
+	reg := prometheus.NewRegistry()
+	prober := metrics.NewMetricProber(ctx, logEntry, nil, settings, e.ConcurrencyConfig)
+	prober.SetAzureClient(client)
+	prober.SetPrometheusRegistry(reg)
+	prober.SetAzureResourceTagManager(tagManager)
+
+	err = prober.ServiceDiscovery.FindResourceGraph(ctx, settings.Subscriptions, settings.ResourceType, settings.Filter)
+	if err != nil {
+		logEntry.Error(fmt.Errorf("service discovery failed, %v", err))
+		return nil, fmt.Errorf("failed to discover azure resources")
+	}
+
+	prober.Run()
+	return reg, nil
+}
+
 func (e Exporter) ScrapeConfigs() []config.ScrapeConfig {
 	return []config.ScrapeConfig{{JobName: e.cfg.Name(), MetricsPath: "/metrics"}}
 }
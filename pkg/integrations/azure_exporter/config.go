@@ -33,6 +33,10 @@ var DefaultConfig = Config{
 	MetricHelpTemplate:    "Azure metric {metric} for {type} with aggregation {aggregation} as {unit}",
 	IncludedResourceTags:  []string{"owner"},
 	AzureCloudEnvironment: "azurecloud",
+
+	ConcurrencyResourceTypes:         2,
+	ConcurrencySubscriptions:         5,
+	ConcurrencySubscriptionResources: 10,
 }
 
 type Config struct {
@@ -48,6 +52,9 @@ type Config struct {
 	// Valid values are minimum, maximum, average, total, and count
 	MetricAggregations []string `yaml:"metric_aggregations"`
 
+	// Optional: Only collect metrics for resources in these regions ex. westeurope
+	Regions []string `yaml:"regions"`
+
 	// All fields below are optional
 	// Must be an ISO8601 Duration - defaults to PT1M if not specified
 	Timespan             string   `yaml:"timespan"`
@@ -70,6 +77,31 @@ type Config struct {
 	MetricHelpTemplate string `yaml:"metric_help_template"`
 
 	AzureCloudEnvironment string `yaml:"azure_cloud_environment"`
+
+	// Resources collects metrics for additional resource types, sharing the
+	// azure client and concurrency limits of the integration.
+	Resources []ResourceConfig `yaml:"resources,omitempty"`
+
+	// Limits the number of resource types which are concurrently collected, shared by concurrent scrapes
+	ConcurrencyResourceTypes int `yaml:"concurrency_resource_types,omitempty"`
+	// Limits the number of subscriptions which are concurrently collected for a resource type
+	ConcurrencySubscriptions int `yaml:"concurrency_subscriptions,omitempty"`
+	// Limits the number of concurrent metric requests for a single subscription
+	ConcurrencySubscriptionResources int `yaml:"concurrency_subscription_resources,omitempty"`
+}
+
+// ResourceConfig configures a resource type to collect metrics for in
+// addition to the ResourceType of the Config. Subscriptions and Regions
+// default to the ones of the Config when they aren't set.
+type ResourceConfig struct {
+	Subscriptions            []string `yaml:"subscriptions,omitempty"`
+	Regions                  []string `yaml:"regions,omitempty"`
+	ResourceType             string   `yaml:"resource_type"`
+	ResourceGraphQueryFilter string   `yaml:"resource_graph_query_filter,omitempty"`
+	MetricNamespace          string   `yaml:"metric_namespace,omitempty"`
+	Metrics                  []string `yaml:"metrics"`
+	MetricAggregations       []string `yaml:"metric_aggregations,omitempty"`
+	IncludedDimensions       []string `yaml:"included_dimensions,omitempty"`
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler for Config.
@@ -87,6 +119,7 @@ func (c *Config) InstanceKey(_ string) (string, error) {
 }
 
 func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error) {
+	resourceTypes := orDefault(c.ConcurrencyResourceTypes, DefaultConfig.ConcurrencyResourceTypes)
 	concurrencyConfig := azure_config.Opts{
 		// Necessary to match OSS definition
 		Prober: struct {
@@ -95,19 +128,73 @@ func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error)
 			// Limits the number of concurrent metric requests for a single subscription  - value taken from OSS exporter
 			ConcurrencySubscriptionResource int  `long:"concurrency.subscription.resource" env:"CONCURRENCY_SUBSCRIPTION_RESOURCE"  description:"Concurrent requests per resource (inside subscription requests)"  default:"10"`
 			Cache                           bool `long:"enable-caching"                    env:"ENABLE_CACHING"                     description:"Enable internal caching"`
-		}{5, 10, false},
+		}{
+			orDefault(c.ConcurrencySubscriptions, DefaultConfig.ConcurrencySubscriptions),
+			orDefault(c.ConcurrencySubscriptionResources, DefaultConfig.ConcurrencySubscriptionResources),
+			false,
+		},
 	}
 
 	return Exporter{
 		cfg:               *c,
 		logger:            zapadapter.New(l).Sugar(),
 		ConcurrencyConfig: concurrencyConfig,
+		probes:            make(chan struct{}, resourceTypes),
 	}, nil
 }
 
+// orDefault returns def if v isn't set.
+func orDefault(v, def int) int {
+	if v <= 0 {
+		return def
+	}
+	return v
+}
+
 func (c *Config) Validate() error {
 	var configErrors []string
 
+	// Without additional resources the config needs to describe a resource
+	// type to collect by itself.
+	if c.ResourceType != "" || len(c.Resources) == 0 {
+		configErrors = append(configErrors, validateResource(c)...)
+	}
+	for i, resource := range c.Resources {
+		resourceConfig := c.withResource(resource)
+		for _, resourceError := range validateResource(&resourceConfig) {
+			configErrors = append(configErrors, fmt.Sprintf("resources[%d]: %s", i, resourceError))
+		}
+	}
+
+	for _, concurrency := range []struct {
+		name  string
+		value int
+	}{
+		{"concurrency_resource_types", c.ConcurrencyResourceTypes},
+		{"concurrency_subscriptions", c.ConcurrencySubscriptions},
+		{"concurrency_subscription_resources", c.ConcurrencySubscriptionResources},
+	} {
+		if concurrency.value < 0 {
+			configErrors = append(configErrors, fmt.Sprintf("%s cannot be negative", concurrency.name))
+		}
+	}
+
+	if _, err := cloudconfig.NewCloudConfig(c.AzureCloudEnvironment); err != nil {
+		configErrors = append(configErrors, fmt.Errorf("failed to create an azure cloud configuration from azure cloud environment %s, %v", c.AzureCloudEnvironment, err).Error())
+	}
+
+	if len(configErrors) != 0 {
+		return errors.New(strings.Join(configErrors, ","))
+	}
+
+	return nil
+}
+
+// validateResource validates the settings of c which describe the resource
+// type to collect metrics for.
+func validateResource(c *Config) []string {
+	var configErrors []string
+
 	if c.Subscriptions == nil || len(c.Subscriptions) == 0 {
 		configErrors = append(configErrors, "subscriptions cannot be empty")
 	}
@@ -136,15 +223,42 @@ func (c *Config) Validate() error {
 		}
 	}
 
-	if _, err := cloudconfig.NewCloudConfig(c.AzureCloudEnvironment); err != nil {
-		configErrors = append(configErrors, fmt.Errorf("failed to create an azure cloud configuration from azure cloud environment %s, %v", c.AzureCloudEnvironment, err).Error())
-	}
+	return configErrors
+}
 
-	if len(configErrors) != 0 {
-		return errors.New(strings.Join(configErrors, ","))
+// ExpandResources returns a Config for every resource type c collects
+// metrics for, starting with the ResourceType of c if it's set.
+func (c *Config) ExpandResources() []Config {
+	var configs []Config
+	if c.ResourceType != "" {
+		config := *c
+		config.Resources = nil
+		configs = append(configs, config)
+	}
+	for _, resource := range c.Resources {
+		configs = append(configs, c.withResource(resource))
 	}
+	return configs
+}
 
-	return nil
+// withResource returns a copy of c collecting the resource type of resource.
+func (c *Config) withResource(resource ResourceConfig) Config {
+	config := *c
+	config.Resources = nil
+
+	if len(resource.Subscriptions) > 0 {
+		config.Subscriptions = resource.Subscriptions
+	}
+	if len(resource.Regions) > 0 {
+		config.Regions = resource.Regions
+	}
+	config.ResourceType = resource.ResourceType
+	config.ResourceGraphQueryFilter = resource.ResourceGraphQueryFilter
+	config.MetricNamespace = resource.MetricNamespace
+	config.Metrics = resource.Metrics
+	config.MetricAggregations = resource.MetricAggregations
+	config.IncludedDimensions = resource.IncludedDimensions
+	return config
 }
 
 func (c *Config) Name() string {
@@ -157,7 +271,7 @@ func (c *Config) ToScrapeSettings() (*metrics.RequestMetricSettings, error) {
 		Metrics:         c.Metrics,
 		ResourceType:    c.ResourceType,
 		Aggregations:    c.MetricAggregations,
-		Filter:          c.ResourceGraphQueryFilter,
+		Filter:          resourceGraphFilter(c.ResourceGraphQueryFilter, c.Regions),
 		MetricNamespace: c.MetricNamespace,
 		MetricTemplate:  c.MetricNameTemplate,
 		HelpTemplate:    c.MetricHelpTemplate,
@@ -203,6 +317,24 @@ func (c *Config) ToScrapeSettings() (*metrics.RequestMetricSettings, error) {
 	return &settings, nil
 }
 
+// resourceGraphFilter appends a filter for the location of resources to
+// filter when regions are set.
+func resourceGraphFilter(filter string, regions []string) string {
+	if len(regions) == 0 {
+		return filter
+	}
+
+	quoted := make([]string, 0, len(regions))
+	for _, region := range regions {
+		quoted = append(quoted, "'"+strings.ReplaceAll(region, "'", "\\'")+"'")
+	}
+	regionFilter := fmt.Sprintf("where location in~ (%s)", strings.Join(quoted, ", "))
+	if filter == "" {
+		return regionFilter
+	}
+	return filter + " | " + regionFilter
+}
+
 // MergeConfigWithQueryParams will map values from params which where the key
 // matches a yaml tag of the Config struct
 func MergeConfigWithQueryParams(cfg Config, params url.Values) Config {
@@ -228,6 +360,10 @@ func MergeConfigWithQueryParams(cfg Config, params url.Values) Config {
 		cfg.MetricAggregations = aggregations
 	}
 
+	if regions, exists := params["regions"]; exists {
+		cfg.Regions = regions
+	}
+
 	timespan := params.Get("timespan")
 	if len(timespan) != 0 {
 		cfg.Timespan = timespan
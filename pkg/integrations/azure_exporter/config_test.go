@@ -84,6 +84,17 @@ func TestConfig_ToScrapeSettings(t *testing.T) {
 				return settings
 			},
 		},
+		{
+			name: "can filter resources by region",
+			configModifier: func(config azure_exporter.Config) azure_exporter.Config {
+				config.Regions = []string{"westeurope", "eastus"}
+				return config
+			},
+			toExpectedSettings: func(settings metrics.RequestMetricSettings) metrics.RequestMetricSettings {
+				settings.Filter = "filter_me | where location in~ ('westeurope', 'eastus')"
+				return settings
+			},
+		},
 		{
 			name: "sets config timespan to setting interval and timespan",
 			configModifier: func(config azure_exporter.Config) azure_exporter.Config {
@@ -170,6 +181,27 @@ func TestConfig_Validate(t *testing.T) {
 				return config
 			},
 		},
+		{
+			name: "resource without a resource_type",
+			toInvalidConfig: func(config azure_exporter.Config) azure_exporter.Config {
+				config.Resources = []azure_exporter.ResourceConfig{{Metrics: []string{"MetricB"}}}
+				return config
+			},
+		},
+		{
+			name: "resource without metrics",
+			toInvalidConfig: func(config azure_exporter.Config) azure_exporter.Config {
+				config.Resources = []azure_exporter.ResourceConfig{{ResourceType: "resourceTypeB"}}
+				return config
+			},
+		},
+		{
+			name: "negative concurrency",
+			toInvalidConfig: func(config azure_exporter.Config) azure_exporter.Config {
+				config.ConcurrencySubscriptions = -1
+				return config
+			},
+		},
 		{
 			name: "invalid azure_cloud_environment",
 			toInvalidConfig: func(config azure_exporter.Config) azure_exporter.Config {
@@ -187,6 +219,69 @@ func TestConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestConfig_Validate_Resources(t *testing.T) {
+	cfg := azure_exporter.Config{
+		Subscriptions: []string{"subscriptionA"},
+		Resources: []azure_exporter.ResourceConfig{
+			{ResourceType: "resourceTypeA", Metrics: []string{"MetricA"}},
+			{ResourceType: "resourceTypeB", Metrics: []string{"MetricB"}, MetricAggregations: []string{"bogus"}},
+		},
+		AzureCloudEnvironment: "azurecloud",
+	}
+	require.EqualError(t, cfg.Validate(), "resources[1]: bogus is an invalid value for metric_aggregations. Valid options are minimum,maximum,average,total,count")
+
+	// The config doesn't need a resource type of its own when it has resources.
+	cfg.Resources[1].MetricAggregations = nil
+	require.NoError(t, cfg.Validate())
+}
+
+func TestConfig_ExpandResources(t *testing.T) {
+	cfg := azure_exporter.Config{
+		Subscriptions:        []string{"subscriptionA"},
+		Regions:              []string{"westeurope"},
+		ResourceType:         "resourceTypeA",
+		Metrics:              []string{"MetricA"},
+		Timespan:             "PT5M",
+		IncludedResourceTags: []string{"owner"},
+		Resources: []azure_exporter.ResourceConfig{
+			{ResourceType: "resourceTypeB", Metrics: []string{"MetricB"}},
+			{ResourceType: "resourceTypeC", Metrics: []string{"MetricC"}, Subscriptions: []string{"subscriptionB"}, Regions: []string{"eastus"}},
+		},
+	}
+
+	expected := []azure_exporter.Config{
+		{
+			Subscriptions:        []string{"subscriptionA"},
+			Regions:              []string{"westeurope"},
+			ResourceType:         "resourceTypeA",
+			Metrics:              []string{"MetricA"},
+			Timespan:             "PT5M",
+			IncludedResourceTags: []string{"owner"},
+		},
+		{
+			Subscriptions:        []string{"subscriptionA"},
+			Regions:              []string{"westeurope"},
+			ResourceType:         "resourceTypeB",
+			Metrics:              []string{"MetricB"},
+			Timespan:             "PT5M",
+			IncludedResourceTags: []string{"owner"},
+		},
+		{
+			Subscriptions:        []string{"subscriptionB"},
+			Regions:              []string{"eastus"},
+			ResourceType:         "resourceTypeC",
+			Metrics:              []string{"MetricC"},
+			Timespan:             "PT5M",
+			IncludedResourceTags: []string{"owner"},
+		},
+	}
+	require.Equal(t, expected, cfg.ExpandResources())
+
+	// Without a resource type of its own, only the resources are collected.
+	cfg.ResourceType = ""
+	require.Equal(t, expected[1:], cfg.ExpandResources())
+}
+
 func TestMergeConfigWithQueryParams_MapsAllExpectedFieldsByYamlNameFromConfig(t *testing.T) {
 	// We want to be sure all expected fields are mappable by the yaml name and reflect allows us to do that programmatically
 	thing := reflect.TypeOf(azure_exporter.Config{})
@@ -194,7 +289,9 @@ func TestMergeConfigWithQueryParams_MapsAllExpectedFieldsByYamlNameFromConfig(t
 	for i := 0; i < thing.NumField(); i++ {
 		field := thing.Field(i)
 		//Not available to be mapped via query param
-		if field.Name == "AzureCloudEnvironment" {
+		switch field.Name {
+		case "AzureCloudEnvironment", "Resources",
+			"ConcurrencyResourceTypes", "ConcurrencySubscriptions", "ConcurrencySubscriptionResources":
 			continue
 		}
 
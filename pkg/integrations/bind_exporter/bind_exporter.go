@@ -0,0 +1,111 @@
+// Package bind_exporter collects metrics from the statistics channel of BIND
+// DNS servers.
+package bind_exporter //nolint:golint
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+	integrations_v2 "github.com/grafana/agent/pkg/integrations/v2"
+	"github.com/grafana/agent/pkg/integrations/v2/metricsutils"
+)
+
+// DefaultConfig is the default config for the bind_exporter integration.
+var DefaultConfig = Config{
+	StatsURL: "http://localhost:8053",
+	Timeout:  10 * time.Second,
+}
+
+// Config controls the bind_exporter integration.
+type Config struct {
+	// StatsURL is the URL of the statistics channel of the BIND server.
+	StatsURL string `yaml:"stats_url,omitempty"`
+
+	// Timeout is the timeout for requests to the statistics channel.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// IncludeZones and ExcludeZones are regular expressions matched against
+	// zone names to select which zones metrics are collected for. All zones
+	// are included when IncludeZones is empty.
+	IncludeZones []string `yaml:"include_zones,omitempty"`
+	ExcludeZones []string `yaml:"exclude_zones,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for Config.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig
+
+	type plain Config
+	return unmarshal((*plain)(c))
+}
+
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	u, err := url.Parse(c.StatsURL)
+	if err != nil {
+		return fmt.Errorf("invalid stats_url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("stats_url %q must include a scheme and host", c.StatsURL)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	if _, err := compileZones(c.IncludeZones); err != nil {
+		return fmt.Errorf("invalid include_zones: %w", err)
+	}
+	if _, err := compileZones(c.ExcludeZones); err != nil {
+		return fmt.Errorf("invalid exclude_zones: %w", err)
+	}
+	return nil
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "bind_exporter"
+}
+
+// InstanceKey returns the host of the statistics channel.
+func (c *Config) InstanceKey(agentKey string) (string, error) {
+	u, err := url.Parse(c.StatsURL)
+	if err != nil {
+		return "", err
+	}
+	return u.Host, nil
+}
+
+func init() {
+	integrations.RegisterIntegration(&Config{})
+	integrations_v2.RegisterLegacy(&Config{}, integrations_v2.TypeMultiplex, metricsutils.NewNamedShim("bind"))
+}
+
+// NewIntegration creates a new integration from the config.
+func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	col, err := newCollector(l, c)
+	if err != nil {
+		return nil, err
+	}
+	return integrations.NewCollectorIntegration(c.Name(), integrations.WithCollectors(col)), nil
+}
+
+// compileZones compiles zone name patterns, which must match the whole zone
+// name.
+func compileZones(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
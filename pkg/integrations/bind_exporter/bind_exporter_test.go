@@ -0,0 +1,173 @@
+package bind_exporter //nolint:golint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfig_UnmarshalYAML(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+stats_url: http://ns1:8053
+exclude_zones: [".*\\.in-addr\\.arpa"]
+`), &cfg))
+
+	expect := DefaultConfig
+	expect.StatsURL = "http://ns1:8053"
+	expect.ExcludeZones = []string{`.*\.in-addr\.arpa`}
+	require.Equal(t, expect, cfg)
+
+	key, err := cfg.InstanceKey("agent")
+	require.NoError(t, err)
+	require.Equal(t, "ns1:8053", key)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.StatsURL = "ns1:8053"
+	require.EqualError(t, cfg.Validate(), `stats_url "ns1:8053" must include a scheme and host`)
+
+	cfg = DefaultConfig
+	cfg.IncludeZones = []string{"("}
+	require.EqualError(t, cfg.Validate(), "invalid include_zones: error parsing regexp: missing closing ): `^(?:()$`")
+}
+
+const testServerStats = `{
+  "json-stats-version": "1.2",
+  "boot-time": "2023-05-01T10:00:00.000Z",
+  "config-time": "2023-05-01T10:00:05.000Z",
+  "opcodes": {"QUERY": 120},
+  "rcodes": {"NOERROR": 100, "NXDOMAIN": 20},
+  "qtypes": {"A": 80, "AAAA": 40},
+  "nsstats": {"QrySuccess": 100},
+  "views": {
+    "_default": {
+      "resolver": {
+        "stats": {"NXDOMAIN": 3},
+        "qtypes": {"A": 12},
+        "cachestats": {"CacheHits": 40}
+      }
+    }
+  }
+}`
+
+const testZonesStats = `{
+  "views": {
+    "_default": {
+      "zones": [
+        {"name": "example.com", "class": "IN", "serial": 2023050101, "rcodes": {"QryAuthAns": 7}},
+        {"name": "10.in-addr.arpa", "class": "IN", "serial": 1}
+      ]
+    }
+  }
+}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json/v1/server":
+			_, _ = w.Write([]byte(testServerStats))
+		case "/json/v1/zones":
+			_, _ = w.Write([]byte(testZonesStats))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCollector(t *testing.T) {
+	srv := newTestServer(t)
+
+	cfg := DefaultConfig
+	cfg.StatsURL = srv.URL
+	cfg.ExcludeZones = []string{`.*\.in-addr\.arpa`}
+
+	c, err := newCollector(log.NewNopLogger(), &cfg)
+	require.NoError(t, err)
+
+	expect := `
+# HELP bind_up Whether collecting metrics from the BIND statistics channel succeeded.
+# TYPE bind_up gauge
+bind_up 1
+# HELP bind_boot_time_seconds Start time of the BIND server since the Unix epoch.
+# TYPE bind_boot_time_seconds gauge
+bind_boot_time_seconds 1.6829352e+09
+# HELP bind_incoming_queries_total Number of incoming DNS queries, by query type.
+# TYPE bind_incoming_queries_total counter
+bind_incoming_queries_total{type="A"} 80
+bind_incoming_queries_total{type="AAAA"} 40
+# HELP bind_response_rcodes_total Number of responses sent, by response code.
+# TYPE bind_response_rcodes_total counter
+bind_response_rcodes_total{rcode="NOERROR"} 100
+bind_response_rcodes_total{rcode="NXDOMAIN"} 20
+# HELP bind_resolver_cache_stats_total Cache statistics counters of the resolver.
+# TYPE bind_resolver_cache_stats_total counter
+bind_resolver_cache_stats_total{counter="CacheHits",view="_default"} 40
+# HELP bind_zone_serial Serial number of the zone.
+# TYPE bind_zone_serial gauge
+bind_zone_serial{view="_default",zone_name="example.com"} 2.023050101e+09
+# HELP bind_zone_stats_total Per-zone statistics counters, only reported for zones with zone-statistics enabled.
+# TYPE bind_zone_stats_total counter
+bind_zone_stats_total{counter="QryAuthAns",view="_default",zone_name="example.com"} 7
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect),
+		"bind_up",
+		"bind_boot_time_seconds",
+		"bind_incoming_queries_total",
+		"bind_response_rcodes_total",
+		"bind_resolver_cache_stats_total",
+		"bind_zone_serial",
+		"bind_zone_stats_total",
+	))
+}
+
+func TestCollector_IncludeZones(t *testing.T) {
+	srv := newTestServer(t)
+
+	cfg := DefaultConfig
+	cfg.StatsURL = srv.URL
+	cfg.IncludeZones = []string{`.*\.arpa`}
+
+	c, err := newCollector(log.NewNopLogger(), &cfg)
+	require.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	for _, f := range families {
+		if f.GetName() != "bind_zone_serial" {
+			continue
+		}
+		require.Len(t, f.GetMetric(), 1)
+		require.Equal(t, "10.in-addr.arpa", f.GetMetric()[0].GetLabel()[1].GetValue())
+	}
+}
+
+func TestCollector_Down(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.StatsURL = "http://127.0.0.1:1"
+
+	c, err := newCollector(log.NewNopLogger(), &cfg)
+	require.NoError(t, err)
+
+	expect := `
+# HELP bind_up Whether collecting metrics from the BIND statistics channel succeeded.
+# TYPE bind_up gauge
+bind_up 0
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect), "bind_up"))
+}
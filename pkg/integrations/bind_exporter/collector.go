@@ -0,0 +1,268 @@
+package bind_exporter //nolint:golint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "bind"
+
+var (
+	upDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "up"),
+		"Whether collecting metrics from the BIND statistics channel succeeded.",
+		nil, nil,
+	)
+	bootTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "boot_time_seconds"),
+		"Start time of the BIND server since the Unix epoch.",
+		nil, nil,
+	)
+	configTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "config_time_seconds"),
+		"Time of the last configuration load of the BIND server since the Unix epoch.",
+		nil, nil,
+	)
+	incomingQueriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "incoming_queries_total"),
+		"Number of incoming DNS queries, by query type.",
+		[]string{"type"}, nil,
+	)
+	incomingRequestsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "incoming_requests_total"),
+		"Number of incoming DNS requests, by opcode.",
+		[]string{"opcode"}, nil,
+	)
+	responseRcodesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "response_rcodes_total"),
+		"Number of responses sent, by response code.",
+		[]string{"rcode"}, nil,
+	)
+	serverStatsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "server", "stats_total"),
+		"Name server statistics counters of the BIND server.",
+		[]string{"counter"}, nil,
+	)
+	resolverQueriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "resolver", "queries_total"),
+		"Number of outgoing DNS queries of the resolver, by query type.",
+		[]string{"view", "type"}, nil,
+	)
+	resolverStatsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "resolver", "stats_total"),
+		"Resolver statistics counters of the BIND server.",
+		[]string{"view", "counter"}, nil,
+	)
+	resolverCacheStatsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "resolver", "cache_stats_total"),
+		"Cache statistics counters of the resolver.",
+		[]string{"view", "counter"}, nil,
+	)
+	zoneSerialDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "zone", "serial"),
+		"Serial number of the zone.",
+		[]string{"view", "zone_name"}, nil,
+	)
+	zoneStatsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "zone", "stats_total"),
+		"Per-zone statistics counters, only reported for zones with zone-statistics enabled.",
+		[]string{"view", "zone_name", "counter"}, nil,
+	)
+)
+
+// serverStats is the response of the /json/v1/server endpoint.
+type serverStats struct {
+	BootTime   time.Time            `json:"boot-time"`
+	ConfigTime time.Time            `json:"config-time"`
+	Opcodes    map[string]float64   `json:"opcodes"`
+	Rcodes     map[string]float64   `json:"rcodes"`
+	QTypes     map[string]float64   `json:"qtypes"`
+	NSStats    map[string]float64   `json:"nsstats"`
+	Views      map[string]viewStats `json:"views"`
+}
+
+type viewStats struct {
+	Resolver struct {
+		Stats      map[string]float64 `json:"stats"`
+		QTypes     map[string]float64 `json:"qtypes"`
+		CacheStats map[string]float64 `json:"cachestats"`
+	} `json:"resolver"`
+}
+
+type zoneStats struct {
+	Name   string             `json:"name"`
+	Class  string             `json:"class"`
+	Serial json.Number        `json:"serial"`
+	Rcodes map[string]float64 `json:"rcodes"`
+}
+
+// zonesStats is the response of the /json/v1/zones endpoint.
+type zonesStats struct {
+	Views map[string]struct {
+		Zones []zoneStats `json:"zones"`
+	} `json:"views"`
+}
+
+// collector queries the statistics channel for every collection.
+type collector struct {
+	log    log.Logger
+	cfg    *Config
+	client *http.Client
+
+	includeZones, excludeZones []*regexp.Regexp
+}
+
+func newCollector(l log.Logger, cfg *Config) (*collector, error) {
+	include, err := compileZones(cfg.IncludeZones)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileZones(cfg.ExcludeZones)
+	if err != nil {
+		return nil, err
+	}
+
+	return &collector{
+		log:    l,
+		cfg:    cfg,
+		client: &http.Client{},
+
+		includeZones: include,
+		excludeZones: exclude,
+	}, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- bootTimeDesc
+	ch <- configTimeDesc
+	ch <- incomingQueriesDesc
+	ch <- incomingRequestsDesc
+	ch <- responseRcodesDesc
+	ch <- serverStatsDesc
+	ch <- resolverQueriesDesc
+	ch <- resolverStatsDesc
+	ch <- resolverCacheStatsDesc
+	ch <- zoneSerialDesc
+	ch <- zoneStatsDesc
+}
+
+// Collect implements prometheus.Collector. The metrics which could be
+// collected are sent even if collecting others failed.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+
+	up := 1.0
+	if err := c.collectServer(ctx, ch); err != nil {
+		level.Error(c.log).Log("msg", "failed to collect BIND server metrics", "err", err)
+		up = 0
+	}
+	if err := c.collectZones(ctx, ch); err != nil {
+		level.Error(c.log).Log("msg", "failed to collect BIND zone metrics", "err", err)
+		up = 0
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, up)
+}
+
+func (c *collector) collectServer(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var stats serverStats
+	if err := c.get(ctx, "/json/v1/server", &stats); err != nil {
+		return err
+	}
+
+	if !stats.BootTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(bootTimeDesc, prometheus.GaugeValue, float64(stats.BootTime.Unix()))
+	}
+	if !stats.ConfigTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(configTimeDesc, prometheus.GaugeValue, float64(stats.ConfigTime.Unix()))
+	}
+	sendCounters(ch, incomingQueriesDesc, stats.QTypes)
+	sendCounters(ch, incomingRequestsDesc, stats.Opcodes)
+	sendCounters(ch, responseRcodesDesc, stats.Rcodes)
+	sendCounters(ch, serverStatsDesc, stats.NSStats)
+
+	for name, view := range stats.Views {
+		sendCounters(ch, resolverQueriesDesc, view.Resolver.QTypes, name)
+		sendCounters(ch, resolverStatsDesc, view.Resolver.Stats, name)
+		sendCounters(ch, resolverCacheStatsDesc, view.Resolver.CacheStats, name)
+	}
+	return nil
+}
+
+func (c *collector) collectZones(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var stats zonesStats
+	if err := c.get(ctx, "/json/v1/zones", &stats); err != nil {
+		return err
+	}
+
+	for view, v := range stats.Views {
+		for _, zone := range v.Zones {
+			if !c.zoneEnabled(zone.Name) {
+				continue
+			}
+			if serial, err := zone.Serial.Float64(); err == nil {
+				ch <- prometheus.MustNewConstMetric(zoneSerialDesc, prometheus.GaugeValue, serial, view, zone.Name)
+			}
+			sendCounters(ch, zoneStatsDesc, zone.Rcodes, view, zone.Name)
+		}
+	}
+	return nil
+}
+
+// zoneEnabled returns true if metrics should be collected for the zone
+// named name.
+func (c *collector) zoneEnabled(name string) bool {
+	if len(c.includeZones) > 0 && !matchAny(c.includeZones, name) {
+		return false
+	}
+	return !matchAny(c.excludeZones, name)
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// sendCounters sends a counter for each value of counters, with the key as
+// the last label value.
+func sendCounters(ch chan<- prometheus.Metric, desc *prometheus.Desc, counters map[string]float64, labelValues ...string) {
+	for key, value := range counters {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, append(labelValues, key)...)
+	}
+}
+
+// get decodes the JSON response of the statistics channel at path into v.
+func (c *collector) get(ctx context.Context, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.cfg.StatsURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s from %s", resp.Status, path)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid response from %s: %w", path, err)
+	}
+	return nil
+}
@@ -77,6 +77,9 @@ func (c *Config) GetIncludedMetrics() (container.MetricSet, error) {
 
 // NewIntegration creates a new cadvisor integration
 func (c *Config) NewIntegration(logger log.Logger) (integrations.Integration, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
 	return New(logger, c)
 }
 
@@ -108,6 +111,13 @@ func (i *Integration) Run(ctx context.Context) error {
 	// Raw
 	raw.DockerOnly = &i.c.DockerOnly
 
+	// Housekeeping
+	manager.HousekeepingInterval = &i.c.HousekeepingInterval
+	housekeepingConfig := manager.HouskeepingConfig{
+		Interval:     &i.c.MaxHousekeepingInterval,
+		AllowDynamic: &i.c.AllowDynamicHousekeeping,
+	}
+
 	// Only using in-memory storage, with no backup storage for cadvisor stats
 	memoryStorage := memory.New(i.c.StorageDuration, []storage.StorageDriver{})
 
@@ -120,7 +130,7 @@ func (i *Integration) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to determine included metrics: %w", err)
 	}
 
-	rm, err := manager.New(memoryStorage, sysFs, manager.HousekeepingConfigFlags, includedMetrics, &collectorHTTPClient, i.c.RawCgroupPrefixAllowlist, i.c.EnvMetadataAllowlist, i.c.PerfEventsConfig, time.Duration(i.c.ResctrlInterval))
+	rm, err := manager.New(memoryStorage, sysFs, housekeepingConfig, includedMetrics, &collectorHTTPClient, i.c.RawCgroupPrefixAllowlist, i.c.EnvMetadataAllowlist, i.c.PerfEventsConfig, time.Duration(i.c.ResctrlInterval))
 	if err != nil {
 		return fmt.Errorf("failed to create a manager: %w", err)
 	}
@@ -1,6 +1,7 @@
 package cadvisor
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/go-kit/log"
@@ -19,6 +20,11 @@ var DefaultConfig = Config{
 
 	StorageDuration: 2 * time.Minute,
 
+	// Housekeeping config defaults, matching the cadvisor flags
+	HousekeepingInterval:     1 * time.Second,
+	MaxHousekeepingInterval:  60 * time.Second,
+	AllowDynamicHousekeeping: true,
+
 	// Containerd config defaults
 	Containerd:          "/run/containerd/containerd.sock",
 	ContainerdNamespace: "k8s.io",
@@ -64,6 +70,15 @@ type Config struct {
 	// StorageDuration length of time to keep data stored in memory (Default: 2m)
 	StorageDuration time.Duration `yaml:"storage_duration,omitempty"`
 
+	// HousekeepingInterval interval between container housekeepings, which collect the stats of each container (Default: 1s)
+	HousekeepingInterval time.Duration `yaml:"housekeeping_interval,omitempty"`
+
+	// MaxHousekeepingInterval largest interval to allow between container housekeepings (Default: 60s)
+	MaxHousekeepingInterval time.Duration `yaml:"max_housekeeping_interval,omitempty"`
+
+	// AllowDynamicHousekeeping increases the housekeeping interval of containers whose stats don't change, up to MaxHousekeepingInterval (Default: true)
+	AllowDynamicHousekeeping bool `yaml:"allow_dynamic_housekeeping"`
+
 	// Containerd config options
 	// Containerd containerd endpoint
 	Containerd string `yaml:"containerd,omitempty"`
@@ -120,6 +135,17 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	return nil
 }
 
+// Validate validates the housekeeping settings of the config.
+func (c *Config) Validate() error {
+	if c.HousekeepingInterval <= 0 {
+		return fmt.Errorf("housekeeping_interval must be greater than 0")
+	}
+	if c.MaxHousekeepingInterval < c.HousekeepingInterval {
+		return fmt.Errorf("max_housekeeping_interval must not be lower than housekeeping_interval")
+	}
+	return nil
+}
+
 // Name returns the name of the integration that this config represents.
 func (c *Config) Name() string {
 	return name
@@ -0,0 +1,29 @@
+package cadvisor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_Housekeeping(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`docker_only: true`), &cfg))
+	require.NoError(t, cfg.Validate())
+	require.Equal(t, time.Second, cfg.HousekeepingInterval)
+	require.Equal(t, time.Minute, cfg.MaxHousekeepingInterval)
+	require.True(t, cfg.AllowDynamicHousekeeping)
+
+	require.NoError(t, yaml.Unmarshal([]byte(`
+housekeeping_interval: 15s
+max_housekeeping_interval: 10s
+allow_dynamic_housekeeping: false
+`), &cfg))
+	require.False(t, cfg.AllowDynamicHousekeeping)
+	require.EqualError(t, cfg.Validate(), "max_housekeeping_interval must not be lower than housekeeping_interval")
+
+	cfg.HousekeepingInterval = 0
+	require.EqualError(t, cfg.Validate(), "housekeeping_interval must be greater than 0")
+}
@@ -0,0 +1,184 @@
+package databricks_exporter //nolint:golint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// maxRunPages bounds the number of pages of job runs listed for a single
+// collection.
+const maxRunPages = 100
+
+// client queries the Databricks REST APIs of a workspace, authenticating as
+// a service principal with OAuth machine-to-machine credentials.
+type client struct {
+	base string
+	http *http.Client
+}
+
+func newClient(c *Config) *client {
+	base := strings.TrimSuffix(c.WorkspaceURL, "/")
+	oauthConfig := clientcredentials.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: string(c.ClientSecret),
+		TokenURL:     base + "/oidc/v1/token",
+		Scopes:       []string{"all-apis"},
+	}
+
+	// Tokens are fetched with the context the client is created with, so its
+	// HTTP client bounds the time spent fetching them.
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Timeout: c.Timeout})
+	return &client{
+		base: base,
+		http: oauthConfig.Client(ctx),
+	}
+}
+
+type jobRun struct {
+	JobID     int64  `json:"job_id"`
+	RunID     int64  `json:"run_id"`
+	RunName   string `json:"run_name"`
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+	State     struct {
+		ResultState string `json:"result_state"`
+	} `json:"state"`
+}
+
+// listCompletedRuns lists the completed job runs started since the given
+// time.
+func (c *client) listCompletedRuns(ctx context.Context, since time.Time) ([]jobRun, error) {
+	var runs []jobRun
+
+	query := url.Values{
+		"completed_only":  []string{"true"},
+		"start_time_from": []string{strconv.FormatInt(since.UnixMilli(), 10)},
+		"limit":           []string{"25"},
+	}
+	for page := 0; page < maxRunPages; page++ {
+		var resp struct {
+			Runs          []jobRun `json:"runs"`
+			HasMore       bool     `json:"has_more"`
+			NextPageToken string   `json:"next_page_token"`
+		}
+		if err := c.do(ctx, http.MethodGet, "/api/2.1/jobs/runs/list", query, nil, &resp); err != nil {
+			return nil, err
+		}
+		runs = append(runs, resp.Runs...)
+
+		if !resp.HasMore || resp.NextPageToken == "" {
+			return runs, nil
+		}
+		query.Set("page_token", resp.NextPageToken)
+	}
+	return runs, nil
+}
+
+type warehouse struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	State             string `json:"state"`
+	NumClusters       int    `json:"num_clusters"`
+	MaxNumClusters    int    `json:"max_num_clusters"`
+	NumActiveSessions int    `json:"num_active_sessions"`
+}
+
+// listWarehouses lists the SQL warehouses of the workspace.
+func (c *client) listWarehouses(ctx context.Context) ([]warehouse, error) {
+	var resp struct {
+		Warehouses []warehouse `json:"warehouses"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/2.0/sql/warehouses", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Warehouses, nil
+}
+
+// executeStatement runs a SQL statement on a warehouse and returns the rows
+// of its result. Values are returned as strings, and NULL values as nil.
+func (c *client) executeStatement(ctx context.Context, warehouseID, statement string) ([][]*string, error) {
+	req := map[string]string{
+		"warehouse_id":    warehouseID,
+		"statement":       statement,
+		"wait_timeout":    "30s",
+		"on_wait_timeout": "CANCEL",
+		"disposition":     "INLINE",
+		"format":          "JSON_ARRAY",
+	}
+	var resp struct {
+		Status struct {
+			State string `json:"state"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"status"`
+		Result struct {
+			DataArray [][]*string `json:"data_array"`
+		} `json:"result"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/api/2.0/sql/statements", nil, req, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Status.State != "SUCCEEDED" {
+		if msg := resp.Status.Error.Message; msg != "" {
+			return nil, fmt.Errorf("statement %s: %s", strings.ToLower(resp.Status.State), msg)
+		}
+		return nil, fmt.Errorf("statement %s", strings.ToLower(resp.Status.State))
+	}
+	return resp.Result.DataArray, nil
+}
+
+func (c *client) do(ctx context.Context, method, path string, query url.Values, body, v interface{}) error {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		bb, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(bb)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			ErrorCode string `json:"error_code"`
+			Message   string `json:"message"`
+		}
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(b, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,184 @@
+package databricks_exporter //nolint:golint
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "databricks"
+
+var (
+	upDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "up"),
+		"Whether collecting metrics from the Databricks workspace succeeded.",
+		nil, nil,
+	)
+	jobRunsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "runs"),
+		"Number of job runs completed within the jobs lookback, by result state.",
+		[]string{"job_id", "job_name", "result_state"}, nil,
+	)
+	jobLastRunDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "job", "last_run_duration_seconds"),
+		"Duration of the last job run completed within the jobs lookback.",
+		[]string{"job_id", "job_name"}, nil,
+	)
+	warehouseRunningDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "warehouse", "running"),
+		"Whether the SQL warehouse is running.",
+		[]string{"warehouse_id", "warehouse_name"}, nil,
+	)
+	warehouseClustersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "warehouse", "clusters"),
+		"Number of clusters running for the SQL warehouse.",
+		[]string{"warehouse_id", "warehouse_name"}, nil,
+	)
+	warehouseMaxClustersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "warehouse", "max_clusters"),
+		"Maximum number of clusters the SQL warehouse scales to.",
+		[]string{"warehouse_id", "warehouse_name"}, nil,
+	)
+	warehouseActiveSessionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "warehouse", "active_sessions"),
+		"Number of active sessions of the SQL warehouse.",
+		[]string{"warehouse_id", "warehouse_name"}, nil,
+	)
+	billingDBUsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "billing", "dbus"),
+		"DBUs consumed within the billing lookback, by SKU.",
+		[]string{"sku_name"}, nil,
+	)
+)
+
+// billingQuery sums the DBUs of the usage records which ended within the
+// lookback, given in seconds.
+const billingQuery = `SELECT sku_name, SUM(usage_quantity)
+FROM system.billing.usage
+WHERE usage_unit = 'DBU' AND usage_end_time >= current_timestamp() - INTERVAL %d SECONDS
+GROUP BY sku_name`
+
+// collector queries the Databricks APIs for every collection.
+type collector struct {
+	log    log.Logger
+	cfg    *Config
+	client *client
+	now    func() time.Time
+}
+
+func newCollector(l log.Logger, cfg *Config, c *client) *collector {
+	return &collector{
+		log:    l,
+		cfg:    cfg,
+		client: c,
+		now:    time.Now,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- jobRunsDesc
+	ch <- jobLastRunDurationDesc
+	ch <- warehouseRunningDesc
+	ch <- warehouseClustersDesc
+	ch <- warehouseMaxClustersDesc
+	ch <- warehouseActiveSessionsDesc
+	ch <- billingDBUsDesc
+}
+
+// Collect implements prometheus.Collector. The metrics which could be
+// collected are sent even if collecting others failed.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.Timeout)
+	defer cancel()
+
+	up := 1.0
+	if err := c.collectJobRuns(ctx, ch); err != nil {
+		level.Error(c.log).Log("msg", "failed to collect job run metrics", "err", err)
+		up = 0
+	}
+	if err := c.collectWarehouses(ctx, ch); err != nil {
+		level.Error(c.log).Log("msg", "failed to collect SQL warehouse metrics", "err", err)
+		up = 0
+	}
+	if c.cfg.WarehouseID != "" {
+		if err := c.collectBilling(ctx, ch); err != nil {
+			level.Error(c.log).Log("msg", "failed to collect billing metrics", "err", err)
+			up = 0
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, up)
+}
+
+func (c *collector) collectJobRuns(ctx context.Context, ch chan<- prometheus.Metric) error {
+	runs, err := c.client.listCompletedRuns(ctx, c.now().Add(-c.cfg.JobsLookback))
+	if err != nil {
+		return err
+	}
+
+	type runKey struct {
+		jobID, jobName, resultState string
+	}
+	counts := make(map[runKey]int)
+	last := make(map[int64]jobRun)
+	for _, r := range runs {
+		counts[runKey{strconv.FormatInt(r.JobID, 10), r.RunName, r.State.ResultState}]++
+		if l, ok := last[r.JobID]; !ok || r.EndTime > l.EndTime {
+			last[r.JobID] = r
+		}
+	}
+
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(jobRunsDesc, prometheus.GaugeValue, float64(n), k.jobID, k.jobName, k.resultState)
+	}
+	for id, r := range last {
+		duration := time.Duration(r.EndTime-r.StartTime) * time.Millisecond
+		ch <- prometheus.MustNewConstMetric(jobLastRunDurationDesc, prometheus.GaugeValue, duration.Seconds(), strconv.FormatInt(id, 10), r.RunName)
+	}
+	return nil
+}
+
+func (c *collector) collectWarehouses(ctx context.Context, ch chan<- prometheus.Metric) error {
+	warehouses, err := c.client.listWarehouses(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range warehouses {
+		var running float64
+		if w.State == "RUNNING" {
+			running = 1
+		}
+		ch <- prometheus.MustNewConstMetric(warehouseRunningDesc, prometheus.GaugeValue, running, w.ID, w.Name)
+		ch <- prometheus.MustNewConstMetric(warehouseClustersDesc, prometheus.GaugeValue, float64(w.NumClusters), w.ID, w.Name)
+		ch <- prometheus.MustNewConstMetric(warehouseMaxClustersDesc, prometheus.GaugeValue, float64(w.MaxNumClusters), w.ID, w.Name)
+		ch <- prometheus.MustNewConstMetric(warehouseActiveSessionsDesc, prometheus.GaugeValue, float64(w.NumActiveSessions), w.ID, w.Name)
+	}
+	return nil
+}
+
+func (c *collector) collectBilling(ctx context.Context, ch chan<- prometheus.Metric) error {
+	query := fmt.Sprintf(billingQuery, int64(c.cfg.BillingLookback.Seconds()))
+	rows, err := c.client.executeStatement(ctx, c.cfg.WarehouseID, query)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if len(row) != 2 || row[0] == nil || row[1] == nil {
+			continue
+		}
+		dbus, err := strconv.ParseFloat(*row[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid DBUs %q for SKU %s: %w", *row[1], *row[0], err)
+		}
+		ch <- prometheus.MustNewConstMetric(billingDBUsDesc, prometheus.GaugeValue, dbus, *row[0])
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Package databricks_exporter collects cost and usage metrics of a Databricks
+// workspace from the Databricks REST APIs.
+package databricks_exporter //nolint:golint
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+	integrations_v2 "github.com/grafana/agent/pkg/integrations/v2"
+	"github.com/grafana/agent/pkg/integrations/v2/metricsutils"
+	config_util "github.com/prometheus/common/config"
+)
+
+// DefaultConfig is the default config for the databricks integration.
+var DefaultConfig = Config{
+	JobsLookback:    time.Hour,
+	BillingLookback: 24 * time.Hour,
+	Timeout:         30 * time.Second,
+}
+
+// Config is the configuration for the databricks integration.
+type Config struct {
+	// WorkspaceURL is the URL of the Databricks workspace, like
+	// https://dbc-a1b2c3d4-e5f6.cloud.databricks.com.
+	WorkspaceURL string `yaml:"workspace_url,omitempty"`
+
+	// ClientID and ClientSecret are the OAuth credentials of the service
+	// principal used to query the workspace.
+	ClientID     string             `yaml:"client_id,omitempty"`
+	ClientSecret config_util.Secret `yaml:"client_secret,omitempty"`
+
+	// WarehouseID is the ID of the SQL warehouse used to query billing system
+	// tables. Billing metrics aren't collected if empty.
+	WarehouseID string `yaml:"warehouse_id,omitempty"`
+
+	// JobsLookback is how far back completed job runs are counted.
+	JobsLookback time.Duration `yaml:"jobs_lookback,omitempty"`
+
+	// BillingLookback is how far back consumed DBUs are summed.
+	BillingLookback time.Duration `yaml:"billing_lookback,omitempty"`
+
+	// Timeout is the timeout for collecting all metrics.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for Config.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig
+
+	type plain Config
+	return unmarshal((*plain)(c))
+}
+
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	if c.WorkspaceURL == "" {
+		return fmt.Errorf("workspace_url must be specified")
+	}
+	u, err := url.Parse(c.WorkspaceURL)
+	if err != nil {
+		return fmt.Errorf("invalid workspace_url: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("workspace_url %q must include a scheme and host", c.WorkspaceURL)
+	}
+	if c.ClientID == "" {
+		return fmt.Errorf("client_id must be specified")
+	}
+	if c.ClientSecret == "" {
+		return fmt.Errorf("client_secret must be specified")
+	}
+	if c.JobsLookback <= 0 {
+		return fmt.Errorf("jobs_lookback must be greater than 0")
+	}
+	if c.BillingLookback <= 0 {
+		return fmt.Errorf("billing_lookback must be greater than 0")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	return nil
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "databricks"
+}
+
+// InstanceKey returns the host of the workspace.
+func (c *Config) InstanceKey(agentKey string) (string, error) {
+	u, err := url.Parse(c.WorkspaceURL)
+	if err != nil {
+		return "", err
+	}
+	return u.Host, nil
+}
+
+func init() {
+	integrations.RegisterIntegration(&Config{})
+	integrations_v2.RegisterLegacy(&Config{}, integrations_v2.TypeMultiplex, metricsutils.NewNamedShim("databricks"))
+}
+
+// NewIntegration creates a new integration from the config.
+func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	return integrations.NewCollectorIntegration(
+		c.Name(),
+		integrations.WithCollectors(newCollector(l, c, newClient(c))),
+	), nil
+}
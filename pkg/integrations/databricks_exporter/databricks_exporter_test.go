@@ -0,0 +1,259 @@
+package databricks_exporter //nolint:golint
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfig_UnmarshalYAML(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+workspace_url: https://dbc-a1b2c3d4-e5f6.cloud.databricks.com
+client_id: client
+client_secret: secret
+`), &cfg))
+
+	expect := DefaultConfig
+	expect.WorkspaceURL = "https://dbc-a1b2c3d4-e5f6.cloud.databricks.com"
+	expect.ClientID = "client"
+	expect.ClientSecret = "secret"
+	require.Equal(t, expect, cfg)
+
+	key, err := cfg.InstanceKey("agent")
+	require.NoError(t, err)
+	require.Equal(t, "dbc-a1b2c3d4-e5f6.cloud.databricks.com", key)
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		modify func(c *Config)
+		expect string
+	}{
+		{
+			name:   "missing workspace URL",
+			modify: func(c *Config) { c.WorkspaceURL = "" },
+			expect: "workspace_url must be specified",
+		},
+		{
+			name:   "workspace URL without scheme",
+			modify: func(c *Config) { c.WorkspaceURL = "dbc-a1b2c3d4-e5f6.cloud.databricks.com" },
+			expect: `workspace_url "dbc-a1b2c3d4-e5f6.cloud.databricks.com" must include a scheme and host`,
+		},
+		{
+			name:   "missing client secret",
+			modify: func(c *Config) { c.ClientSecret = "" },
+			expect: "client_secret must be specified",
+		},
+		{
+			name:   "zero jobs lookback",
+			modify: func(c *Config) { c.JobsLookback = 0 },
+			expect: "jobs_lookback must be greater than 0",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultConfig
+			cfg.WorkspaceURL = "https://dbc-a1b2c3d4-e5f6.cloud.databricks.com"
+			cfg.ClientID = "client"
+			cfg.ClientSecret = "secret"
+			tc.modify(&cfg)
+			require.EqualError(t, cfg.Validate(), tc.expect)
+		})
+	}
+}
+
+// newWorkspace returns a fake Databricks workspace. Statements fail when
+// failStatements is set.
+func newWorkspace(t *testing.T, failStatements *atomic.Bool) *httptest.Server {
+	t.Helper()
+
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(v))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oidc/v1/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "all-apis", r.PostForm.Get("scope"))
+		writeJSON(w, map[string]interface{}{
+			"access_token": "token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+
+	authorized := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				writeJSON(w, map[string]string{"error_code": "UNAUTHENTICATED", "message": "invalid token"})
+				return
+			}
+			h(w, r)
+		}
+	}
+
+	mux.HandleFunc("/api/2.1/jobs/runs/list", authorized(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "true", r.URL.Query().Get("completed_only"))
+		require.NotEmpty(t, r.URL.Query().Get("start_time_from"))
+
+		switch r.URL.Query().Get("page_token") {
+		case "":
+			writeJSON(w, map[string]interface{}{
+				"runs": []map[string]interface{}{
+					{"job_id": 1, "run_id": 11, "run_name": "etl", "start_time": 1000, "end_time": 61000, "state": map[string]string{"result_state": "SUCCESS"}},
+					{"job_id": 1, "run_id": 12, "run_name": "etl", "start_time": 100000, "end_time": 130000, "state": map[string]string{"result_state": "FAILED"}},
+				},
+				"has_more":        true,
+				"next_page_token": "page2",
+			})
+		case "page2":
+			writeJSON(w, map[string]interface{}{
+				"runs": []map[string]interface{}{
+					{"job_id": 2, "run_id": 21, "run_name": "report", "start_time": 0, "end_time": 5000, "state": map[string]string{"result_state": "SUCCESS"}},
+				},
+			})
+		}
+	}))
+
+	mux.HandleFunc("/api/2.0/sql/warehouses", authorized(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"warehouses": []map[string]interface{}{
+				{"id": "abc", "name": "analytics", "state": "RUNNING", "num_clusters": 2, "max_num_clusters": 4, "num_active_sessions": 7},
+				{"id": "def", "name": "adhoc", "state": "STOPPED", "max_num_clusters": 1},
+			},
+		})
+	}))
+
+	mux.HandleFunc("/api/2.0/sql/statements", authorized(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "abc", req["warehouse_id"])
+		require.Contains(t, req["statement"], "INTERVAL 86400 SECONDS")
+
+		if failStatements.Load() {
+			writeJSON(w, map[string]interface{}{
+				"status": map[string]interface{}{
+					"state": "FAILED",
+					"error": map[string]string{"message": "table not found"},
+				},
+			})
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"status": map[string]string{"state": "SUCCEEDED"},
+			"result": map[string]interface{}{
+				"data_array": [][]interface{}{
+					{"JOBS_COMPUTE", "12.5"},
+					{"SQL_PRO_COMPUTE", "3"},
+					{"INTERACTIVE", nil},
+				},
+			},
+		})
+	}))
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCollector(t *testing.T) {
+	var failStatements atomic.Bool
+	srv := newWorkspace(t, &failStatements)
+
+	cfg := DefaultConfig
+	cfg.WorkspaceURL = srv.URL
+	cfg.ClientID = "client"
+	cfg.ClientSecret = "secret"
+	cfg.WarehouseID = "abc"
+	require.NoError(t, cfg.Validate())
+
+	c := newCollector(log.NewNopLogger(), &cfg, newClient(&cfg))
+	c.now = func() time.Time { return time.UnixMilli(3600 * 1000) }
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(c)
+
+	expect := `
+# HELP databricks_billing_dbus DBUs consumed within the billing lookback, by SKU.
+# TYPE databricks_billing_dbus gauge
+databricks_billing_dbus{sku_name="JOBS_COMPUTE"} 12.5
+databricks_billing_dbus{sku_name="SQL_PRO_COMPUTE"} 3
+# HELP databricks_job_last_run_duration_seconds Duration of the last job run completed within the jobs lookback.
+# TYPE databricks_job_last_run_duration_seconds gauge
+databricks_job_last_run_duration_seconds{job_id="1",job_name="etl"} 30
+databricks_job_last_run_duration_seconds{job_id="2",job_name="report"} 5
+# HELP databricks_job_runs Number of job runs completed within the jobs lookback, by result state.
+# TYPE databricks_job_runs gauge
+databricks_job_runs{job_id="1",job_name="etl",result_state="FAILED"} 1
+databricks_job_runs{job_id="1",job_name="etl",result_state="SUCCESS"} 1
+databricks_job_runs{job_id="2",job_name="report",result_state="SUCCESS"} 1
+# HELP databricks_up Whether collecting metrics from the Databricks workspace succeeded.
+# TYPE databricks_up gauge
+databricks_up 1
+# HELP databricks_warehouse_active_sessions Number of active sessions of the SQL warehouse.
+# TYPE databricks_warehouse_active_sessions gauge
+databricks_warehouse_active_sessions{warehouse_id="abc",warehouse_name="analytics"} 7
+databricks_warehouse_active_sessions{warehouse_id="def",warehouse_name="adhoc"} 0
+# HELP databricks_warehouse_clusters Number of clusters running for the SQL warehouse.
+# TYPE databricks_warehouse_clusters gauge
+databricks_warehouse_clusters{warehouse_id="abc",warehouse_name="analytics"} 2
+databricks_warehouse_clusters{warehouse_id="def",warehouse_name="adhoc"} 0
+# HELP databricks_warehouse_max_clusters Maximum number of clusters the SQL warehouse scales to.
+# TYPE databricks_warehouse_max_clusters gauge
+databricks_warehouse_max_clusters{warehouse_id="abc",warehouse_name="analytics"} 4
+databricks_warehouse_max_clusters{warehouse_id="def",warehouse_name="adhoc"} 1
+# HELP databricks_warehouse_running Whether the SQL warehouse is running.
+# TYPE databricks_warehouse_running gauge
+databricks_warehouse_running{warehouse_id="abc",warehouse_name="analytics"} 1
+databricks_warehouse_running{warehouse_id="def",warehouse_name="adhoc"} 0
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expect)))
+
+	// Failing to collect billing metrics doesn't prevent others from being
+	// collected.
+	failStatements.Store(true)
+	expect = `
+# HELP databricks_up Whether collecting metrics from the Databricks workspace succeeded.
+# TYPE databricks_up gauge
+databricks_up 0
+# HELP databricks_warehouse_running Whether the SQL warehouse is running.
+# TYPE databricks_warehouse_running gauge
+databricks_warehouse_running{warehouse_id="abc",warehouse_name="analytics"} 1
+databricks_warehouse_running{warehouse_id="def",warehouse_name="adhoc"} 0
+`
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expect), "databricks_up", "databricks_warehouse_running", "databricks_billing_dbus"))
+}
+
+func TestCollector_Unauthorized(t *testing.T) {
+	var failStatements atomic.Bool
+	srv := newWorkspace(t, &failStatements)
+
+	cfg := DefaultConfig
+	cfg.WorkspaceURL = srv.URL
+	cfg.ClientID = "client"
+	cfg.ClientSecret = "secret"
+
+	cl := newClient(&cfg)
+	// Requests are sent without the OAuth token.
+	cl.http = http.DefaultClient
+
+	_, err := cl.listWarehouses(context.Background())
+	require.EqualError(t, err, "GET /api/2.0/sql/warehouses: 401 Unauthorized: invalid token")
+}
@@ -0,0 +1,181 @@
+package dcgm_exporter //nolint:golint
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// field is a DCGM field exposed as a metric. Metrics are named after the
+// DCGM field like dcgm-exporter does, so that its dashboards can be reused.
+type field struct {
+	id   int
+	desc *prometheus.Desc
+}
+
+func newField(id int, name, help string) field {
+	return field{
+		id:   id,
+		desc: prometheus.NewDesc(name, help, []string{"gpu"}, nil),
+	}
+}
+
+// fields are the DCGM fields queried from the host engine, in order of the
+// columns of the dcgmi dmon output.
+var fields = []field{
+	newField(100, "DCGM_FI_DEV_SM_CLOCK", "SM clock frequency (in MHz)."),
+	newField(101, "DCGM_FI_DEV_MEM_CLOCK", "Memory clock frequency (in MHz)."),
+	newField(150, "DCGM_FI_DEV_GPU_TEMP", "GPU temperature (in C)."),
+	newField(155, "DCGM_FI_DEV_POWER_USAGE", "Power draw (in W)."),
+	newField(203, "DCGM_FI_DEV_GPU_UTIL", "GPU utilization (in %)."),
+	newField(204, "DCGM_FI_DEV_MEM_COPY_UTIL", "Memory utilization (in %)."),
+	newField(230, "DCGM_FI_DEV_XID_ERRORS", "Value of the last XID error encountered."),
+	newField(251, "DCGM_FI_DEV_FB_FREE", "Framebuffer memory free (in MiB)."),
+	newField(252, "DCGM_FI_DEV_FB_USED", "Framebuffer memory used (in MiB)."),
+}
+
+var upDesc = prometheus.NewDesc("dcgm_up", "Whether querying DCGM succeeded.", nil, nil)
+
+// runFunc runs the command name with args and returns its standard output.
+type runFunc func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+func runCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// collector queries a single sample of every field from the DCGM host engine
+// with dcgmi for every collection.
+type collector struct {
+	ctx  context.Context
+	log  log.Logger
+	cfg  *Config
+	gpus []string
+	run  runFunc
+}
+
+func newCollector(ctx context.Context, l log.Logger, cfg *Config, gpus []string, run runFunc) *collector {
+	return &collector{
+		ctx:  ctx,
+		log:  l,
+		cfg:  cfg,
+		gpus: gpus,
+		run:  run,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, f := range fields {
+		ch <- f.desc
+	}
+	ch <- upDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	samples, err := c.query()
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to query DCGM", "err", err)
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+		return
+	}
+
+	for _, s := range samples {
+		ch <- prometheus.MustNewConstMetric(fields[s.field].desc, prometheus.GaugeValue, s.value, s.gpu)
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+}
+
+func (c *collector) query() ([]sample, error) {
+	ctx, cancel := context.WithTimeout(c.ctx, c.cfg.Timeout)
+	defer cancel()
+
+	ids := make([]string, 0, len(fields))
+	for _, f := range fields {
+		ids = append(ids, strconv.Itoa(f.id))
+	}
+	args := []string{"dmon", "--host", c.cfg.HostEngineAddress, "-e", strings.Join(ids, ","), "-c", "1"}
+	if len(c.gpus) > 0 {
+		args = append(args, "-i", strings.Join(c.gpus, ","))
+	}
+
+	out, err := c.run(ctx, c.cfg.DCGMIPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseDmon(out)
+}
+
+// sample is the value of fields[field] of a GPU.
+type sample struct {
+	gpu   string
+	field int
+	value float64
+}
+
+// parseDmon parses the output of dcgmi dmon, which prints a row for every
+// GPU with a column for every field:
+//
+//	#Entity   SMCLK  MMCLK  ...
+//	ID
+//	GPU 0     1410   1215   ...
+//
+// Fields without a value are reported as N/A and skipped.
+func parseDmon(out []byte) ([]sample, error) {
+	var res []sample
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		columns := strings.Fields(scanner.Text())
+		if len(columns) < 2 || columns[0] != "GPU" {
+			continue
+		}
+
+		gpu, values := columns[1], columns[2:]
+		if !isGPUID(gpu) {
+			return nil, fmt.Errorf("unexpected GPU ID %q in dcgmi output", gpu)
+		}
+		if len(values) != len(fields) {
+			return nil, fmt.Errorf("expected %d fields for GPU %s in dcgmi output, got %d", len(fields), gpu, len(values))
+		}
+
+		for i, v := range values {
+			if v == "N/A" {
+				continue
+			}
+			value, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q of field %d for GPU %s in dcgmi output", v, fields[i].id, gpu)
+			}
+			res = append(res, sample{gpu: gpu, field: i, value: value})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// isGPUID returns whether id is a valid DCGM GPU ID.
+func isGPUID(id string) bool {
+	_, err := strconv.ParseUint(id, 10, 32)
+	return err == nil
+}
@@ -0,0 +1,166 @@
+// Package dcgm_exporter collects metrics of NVIDIA GPUs from the NVIDIA Data
+// Center GPU Manager (DCGM).
+package dcgm_exporter //nolint:golint
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/build"
+	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/config"
+	integrations_v2 "github.com/grafana/agent/pkg/integrations/v2"
+	"github.com/grafana/agent/pkg/integrations/v2/metricsutils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultConfig is the default config for dcgm_exporter.
+var DefaultConfig = Config{
+	DCGMIPath:         "dcgmi",
+	HostEngineAddress: "localhost:5555",
+	Timeout:           10 * time.Second,
+}
+
+// Config controls the dcgm_exporter integration.
+type Config struct {
+	// DCGMIPath is the path to the dcgmi binary used to query DCGM.
+	DCGMIPath string `yaml:"dcgmi_path,omitempty"`
+
+	// HostEngineAddress is the address of the DCGM host engine (host:port).
+	HostEngineAddress string `yaml:"host_engine_address,omitempty"`
+
+	// GPUs are the IDs of the GPUs to collect metrics from. Metrics of all
+	// GPUs are collected if empty.
+	GPUs []string `yaml:"gpus,omitempty"`
+
+	// Timeout is the timeout for querying DCGM.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for Config.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig
+
+	type plain Config
+	return unmarshal((*plain)(c))
+}
+
+// Name returns the name of the integration that this config represents.
+func (c *Config) Name() string {
+	return "dcgm_exporter"
+}
+
+// InstanceKey returns the address of the DCGM host engine.
+func (c *Config) InstanceKey(agentKey string) (string, error) {
+	return c.HostEngineAddress, nil
+}
+
+// NewIntegration converts this config into an instance of an integration.
+func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error) {
+	return New(l, c)
+}
+
+func init() {
+	integrations.RegisterIntegration(&Config{})
+	integrations_v2.RegisterLegacy(&Config{}, integrations_v2.TypeMultiplex, metricsutils.NewNamedShim("dcgm"))
+}
+
+// New creates a new dcgm_exporter integration. The integration collects
+// metrics of the GPUs managed by a DCGM host engine.
+func New(log log.Logger, c *Config) (integrations.Integration, error) {
+	if c.DCGMIPath == "" {
+		return nil, fmt.Errorf("dcgmi_path must not be empty")
+	}
+	if c.Timeout <= 0 {
+		return nil, fmt.Errorf("timeout must be greater than 0")
+	}
+	seen := make(map[string]struct{}, len(c.GPUs))
+	for _, gpu := range c.GPUs {
+		if !isGPUID(gpu) {
+			return nil, fmt.Errorf("invalid GPU ID %q", gpu)
+		}
+		if _, ok := seen[gpu]; ok {
+			return nil, fmt.Errorf("GPU %s is defined multiple times", gpu)
+		}
+		seen[gpu] = struct{}{}
+	}
+
+	return &Integration{
+		cfg: c,
+		log: log,
+		run: runCommand,
+	}, nil
+}
+
+// Integration is the dcgm_exporter integration. Scrapes with a gpu query
+// parameter only collect the metrics of that GPU.
+type Integration struct {
+	cfg *Config
+	log log.Logger
+	run runFunc
+}
+
+// MetricsHandler implements Integration.
+func (i *Integration) MetricsHandler() (http.Handler, error) {
+	return http.HandlerFunc(i.serveMetrics), nil
+}
+
+func (i *Integration) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if len(query["gpu"]) > 1 {
+		http.Error(w, "'gpu' parameter must only be specified once", http.StatusBadRequest)
+		return
+	}
+
+	gpus := i.cfg.GPUs
+	if gpu := query.Get("gpu"); gpu != "" {
+		if !isGPUID(gpu) {
+			http.Error(w, fmt.Sprintf("invalid GPU ID %q", gpu), http.StatusBadRequest)
+			return
+		}
+		gpus = []string{gpu}
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		newCollector(r.Context(), i.log, i.cfg, gpus, i.run),
+		build.NewCollector(i.cfg.Name()),
+	)
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+	}).ServeHTTP(w, r)
+}
+
+// Run satisfies Integration.Run.
+func (i *Integration) Run(ctx context.Context) error {
+	// We don't need to do anything here, so we can just wait for the context to
+	// finish.
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// ScrapeConfigs satisfies Integration.ScrapeConfigs. Every configured GPU is
+// scraped separately.
+func (i *Integration) ScrapeConfigs() []config.ScrapeConfig {
+	if len(i.cfg.GPUs) == 0 {
+		return []config.ScrapeConfig{{
+			JobName:     i.cfg.Name(),
+			MetricsPath: "/metrics",
+		}}
+	}
+
+	res := make([]config.ScrapeConfig, 0, len(i.cfg.GPUs))
+	for _, gpu := range i.cfg.GPUs {
+		res = append(res, config.ScrapeConfig{
+			JobName:     i.cfg.Name() + "/gpu" + gpu,
+			MetricsPath: "/metrics",
+			QueryParams: url.Values{"gpu": []string{gpu}},
+		})
+	}
+	return res
+}
@@ -0,0 +1,171 @@
+package dcgm_exporter //nolint:golint
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations/config"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+const dmonOutput = `#Entity   SMCLK  MMCLK  TMPTR  POWER  GPUTL  MCUTL  XID    FBFRE  FBUSD
+ID
+GPU 0     1410   1215   34     61.28  87     45     0      38012  2524
+GPU 1     210    N/A    29     24.03  0      0      79     40533  3
+`
+
+func TestConfig_UnmarshalYAML(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`gpus: ["0", "1"]`), &cfg))
+
+	expect := DefaultConfig
+	expect.GPUs = []string{"0", "1"}
+	require.Equal(t, expect, cfg)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	tt := []struct {
+		name   string
+		modify func(c *Config)
+		expect string
+	}{
+		{
+			name:   "empty dcgmi path",
+			modify: func(c *Config) { c.DCGMIPath = "" },
+			expect: "dcgmi_path must not be empty",
+		},
+		{
+			name:   "zero timeout",
+			modify: func(c *Config) { c.Timeout = 0 },
+			expect: "timeout must be greater than 0",
+		},
+		{
+			name:   "invalid GPU ID",
+			modify: func(c *Config) { c.GPUs = []string{"gpu0"} },
+			expect: `invalid GPU ID "gpu0"`,
+		},
+		{
+			name:   "duplicate GPU ID",
+			modify: func(c *Config) { c.GPUs = []string{"0", "0"} },
+			expect: "GPU 0 is defined multiple times",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := DefaultConfig
+			tc.modify(&cfg)
+			_, err := New(log.NewNopLogger(), &cfg)
+			require.EqualError(t, err, tc.expect)
+		})
+	}
+}
+
+func TestParseDmon(t *testing.T) {
+	samples, err := parseDmon([]byte(dmonOutput))
+	require.NoError(t, err)
+	require.Len(t, samples, 17)
+	require.Equal(t, sample{gpu: "0", field: 0, value: 1410}, samples[0])
+	require.Equal(t, sample{gpu: "0", field: 3, value: 61.28}, samples[3])
+	// The memory clock of GPU 1 is skipped.
+	require.Equal(t, sample{gpu: "1", field: 2, value: 29}, samples[10])
+	require.Equal(t, sample{gpu: "1", field: 6, value: 79}, samples[14])
+
+	_, err = parseDmon([]byte("GPU 0 1 2 3\n"))
+	require.EqualError(t, err, "expected 9 fields for GPU 0 in dcgmi output, got 3")
+
+	_, err = parseDmon([]byte("GPU 0 1 2 3 4 5 6 7 8 nan?\n"))
+	require.Error(t, err)
+}
+
+func TestIntegration_Metrics(t *testing.T) {
+	var gotArgs []string
+	i := newTestIntegration(t, func(_ context.Context, name string, args ...string) ([]byte, error) {
+		require.Equal(t, "dcgmi", name)
+		gotArgs = args
+		return []byte(dmonOutput), nil
+	})
+
+	body := scrape(t, i, "")
+	require.Equal(t, []string{"dmon", "--host", "localhost:5555", "-e", "100,101,150,155,203,204,230,251,252", "-c", "1"}, gotArgs)
+	for _, line := range []string{
+		`DCGM_FI_DEV_SM_CLOCK{gpu="0"} 1410`,
+		`DCGM_FI_DEV_POWER_USAGE{gpu="0"} 61.28`,
+		`DCGM_FI_DEV_FB_USED{gpu="0"} 2524`,
+		`DCGM_FI_DEV_XID_ERRORS{gpu="1"} 79`,
+		`dcgm_up 1`,
+	} {
+		require.Contains(t, body, line)
+	}
+	require.NotContains(t, body, `DCGM_FI_DEV_MEM_CLOCK{gpu="1"}`)
+
+	// Scrapes for a single GPU only query that GPU.
+	scrape(t, i, "1")
+	require.Equal(t, []string{"-i", "1"}, gotArgs[len(gotArgs)-2:])
+}
+
+func TestIntegration_MetricsFailure(t *testing.T) {
+	i := newTestIntegration(t, func(context.Context, string, ...string) ([]byte, error) {
+		return nil, fmt.Errorf("unable to establish a connection to the specified host")
+	})
+
+	body := scrape(t, i, "")
+	require.Contains(t, body, "dcgm_up 0")
+	require.NotContains(t, body, "DCGM_FI_DEV")
+}
+
+func TestIntegration_ScrapeConfigs(t *testing.T) {
+	i := newTestIntegration(t, nil)
+	require.Equal(t, []config.ScrapeConfig{{
+		JobName:     "dcgm_exporter",
+		MetricsPath: "/metrics",
+	}}, i.ScrapeConfigs())
+
+	i.cfg.GPUs = []string{"0", "1"}
+	require.Equal(t, []config.ScrapeConfig{
+		{JobName: "dcgm_exporter/gpu0", MetricsPath: "/metrics", QueryParams: url.Values{"gpu": []string{"0"}}},
+		{JobName: "dcgm_exporter/gpu1", MetricsPath: "/metrics", QueryParams: url.Values{"gpu": []string{"1"}}},
+	}, i.ScrapeConfigs())
+}
+
+func newTestIntegration(t *testing.T, run runFunc) *Integration {
+	t.Helper()
+
+	cfg := DefaultConfig
+	cfg.Timeout = time.Second
+	i, err := New(log.NewLogfmtLogger(os.Stderr), &cfg)
+	require.NoError(t, err)
+
+	res := i.(*Integration)
+	res.run = run
+	return res
+}
+
+func scrape(t *testing.T, i *Integration, gpu string) string {
+	t.Helper()
+
+	h, err := i.MetricsHandler()
+	require.NoError(t, err)
+
+	target := "/metrics"
+	if gpu != "" {
+		target += "?gpu=" + gpu
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return strings.TrimSpace(string(body))
+}
@@ -0,0 +1,71 @@
+package gcp_exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/monitoring/v3"
+)
+
+// denyDescriptorsTransport removes the metric descriptors matching one of
+// denyPrefixes from the responses of the metricDescriptors.list API.
+//
+// The collector queries the time series of every descriptor it's given, so
+// dropping descriptors before they reach it prevents it from making any API
+// calls for the denied metrics. Cloud Monitoring API calls are billed, which
+// makes this much cheaper than dropping the series after they're collected.
+type denyDescriptorsTransport struct {
+	next         http.RoundTripper
+	denyPrefixes []string
+}
+
+func (t *denyDescriptorsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet || resp.StatusCode != http.StatusOK ||
+		!strings.HasSuffix(req.URL.Path, "/metricDescriptors") {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var page monitoring.ListMetricDescriptorsResponse
+	if err := json.Unmarshal(body, &page); err != nil {
+		// Leave responses we don't understand for the client to report.
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+
+	kept := page.MetricDescriptors[:0]
+	for _, descriptor := range page.MetricDescriptors {
+		if !t.denied(descriptor.Type) {
+			kept = append(kept, descriptor)
+		}
+	}
+	page.MetricDescriptors = kept
+
+	body, err = json.Marshal(&page)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return resp, nil
+}
+
+func (t *denyDescriptorsTransport) denied(metricType string) bool {
+	for _, prefix := range t.denyPrefixes {
+		if strings.HasPrefix(metricType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package gcp_exporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+)
+
+func TestDenyDescriptorsTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v3/projects/project1/metricDescriptors", r.URL.Path)
+		_, _ = w.Write([]byte(`{"metricDescriptors": [
+			{"type": "monitoring.googleapis.com/uptime_check/check_passed"},
+			{"type": "monitoring.googleapis.com/billing/bytes_ingested"},
+			{"type": "monitoring.googleapis.com/billing/samples_ingested"}
+		], "nextPageToken": "next"}`))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &denyDescriptorsTransport{
+		next:         http.DefaultTransport,
+		denyPrefixes: []string{"monitoring.googleapis.com/billing"},
+	}}
+	svc, err := monitoring.NewService(context.Background(),
+		option.WithHTTPClient(client),
+		option.WithEndpoint(srv.URL),
+	)
+	require.NoError(t, err)
+
+	page, err := svc.Projects.MetricDescriptors.List("projects/project1").Do()
+	require.NoError(t, err)
+	require.Len(t, page.MetricDescriptors, 1)
+	require.Equal(t, "monitoring.googleapis.com/uptime_check/check_passed", page.MetricDescriptors[0].Type)
+	require.Equal(t, "next", page.NextPageToken)
+}
@@ -36,6 +36,8 @@ type Config struct {
 	ProjectIDs []string `yaml:"project_ids"`
 	// Comma separated Google Monitoring Metric Type prefixes.
 	MetricPrefixes []string `yaml:"metrics_prefixes"`
+	// Google Monitoring Metric Type prefixes to skip, each of which must start with one of MetricPrefixes.
+	MetricDenyPrefixes []string `yaml:"metrics_deny_prefixes,omitempty"`
 	// Filters. i.e: pubsub.googleapis.com/subscription:resource.labels.subscription_id=monitoring.regex.full_match("my-subs-prefix.*")
 	ExtraFilters []string `yaml:"extra_filters"`
 	// Interval to request the Google Monitoring Metrics for. Only the most recent data point is used.
@@ -48,6 +50,10 @@ type Config struct {
 	DropDelegatedProjects bool `yaml:"drop_delegated_projects"`
 	// How long should the collector wait for a result from the API.
 	ClientTimeout time.Duration `yaml:"gcp_client_timeout"`
+	// Turn the delta counters and distributions of GCP into cumulative Prometheus counters and histograms.
+	AggregateDeltas bool `yaml:"aggregate_deltas"`
+	// How long to keep aggregating a delta metric after its last data point was received.
+	AggregateDeltasTTL time.Duration `yaml:"aggregate_deltas_ttl"`
 }
 
 var DefaultConfig = Config{
@@ -56,6 +62,8 @@ var DefaultConfig = Config{
 	RequestOffset:         0,
 	IngestDelay:           false,
 	DropDelegatedProjects: false,
+	AggregateDeltas:       true,
+	AggregateDeltasTTL:    30 * time.Minute,
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler for Config
@@ -86,7 +94,7 @@ func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error)
 		return nil, err
 	}
 
-	svc, err := createMonitoringService(context.Background(), c.ClientTimeout)
+	svc, err := createMonitoringService(context.Background(), c.ClientTimeout, c.MetricDenyPrefixes)
 	if err != nil {
 		return nil, err
 	}
@@ -108,13 +116,14 @@ func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error)
 				// that GCP metrics have different labels but if it happens the prom registry will panic.
 				FillMissingLabels: true,
 
-				// If AggregateDeltas is disabled the data produced is not useful at all. See https://github.com/prometheus-community/stackdriver_exporter#what-to-know-about-aggregating-delta-metrics
+				// If AggregateDeltas is disabled delta metrics are reported as gauges of the last sample, which is rarely
+				// useful. See https://github.com/prometheus-community/stackdriver_exporter#what-to-know-about-aggregating-delta-metrics
 				// for more info
-				AggregateDeltas: true,
+				AggregateDeltas: c.AggregateDeltas,
 			},
 			l,
-			collectors.NewInMemoryDeltaCounterStore(l, 30*time.Minute),
-			collectors.NewInMemoryDeltaDistributionStore(l, 30*time.Minute),
+			collectors.NewInMemoryDeltaCounterStore(l, c.AggregateDeltasTTL),
+			collectors.NewInMemoryDeltaDistributionStore(l, c.AggregateDeltasTTL),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create monitoring collector: %w", err)
@@ -167,10 +176,27 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for _, denyPrefix := range c.MetricDenyPrefixes {
+		validDenyPrefix := false
+		for _, metricPrefix := range c.MetricPrefixes {
+			if strings.HasPrefix(denyPrefix, metricPrefix) {
+				validDenyPrefix = true
+				break
+			}
+		}
+		if !validDenyPrefix {
+			configErrors.Add(fmt.Errorf("metrics_deny_prefixes %s does not start with any of the metrics_prefixes and will not have any effect", denyPrefix))
+		}
+	}
+
+	if c.AggregateDeltas && c.AggregateDeltasTTL <= 0 {
+		configErrors.Add(errors.New("aggregate_deltas_ttl must be greater than 0 when aggregate_deltas is enabled"))
+	}
+
 	return configErrors.Err()
 }
 
-func createMonitoringService(ctx context.Context, httpTimeout time.Duration) (*monitoring.Service, error) {
+func createMonitoringService(ctx context.Context, httpTimeout time.Duration, denyPrefixes []string) (*monitoring.Service, error) {
 	googleClient, err := google.DefaultClient(ctx, monitoring.MonitoringReadScope)
 	if err != nil {
 		return nil, fmt.Errorf("error creating Google client: %v", err)
@@ -184,6 +210,12 @@ func createMonitoringService(ctx context.Context, httpTimeout time.Duration) (*m
 			rehttp.RetryStatuses(http.StatusServiceUnavailable)),
 		rehttp.ExpJitterDelay(time.Second, 5*time.Second),
 	)
+	if len(denyPrefixes) > 0 {
+		googleClient.Transport = &denyDescriptorsTransport{
+			next:         googleClient.Transport,
+			denyPrefixes: denyPrefixes,
+		}
+	}
 
 	monitoringService, err := monitoring.NewService(ctx,
 		option.WithHTTPClient(googleClient),
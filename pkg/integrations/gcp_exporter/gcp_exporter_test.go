@@ -115,6 +115,33 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			shouldError: false,
 		},
+		{
+			name: "deny prefix within a metric prefix",
+			configModifier: func(config gcp_exporter.Config) gcp_exporter.Config {
+				config.MetricPrefixes = []string{"monitoring.googleapis.com/"}
+				config.MetricDenyPrefixes = []string{"monitoring.googleapis.com/billing"}
+				return config
+			},
+			shouldError: false,
+		},
+		{
+			name: "deny prefix which does not match a MetricPrefix",
+			configModifier: func(config gcp_exporter.Config) gcp_exporter.Config {
+				config.MetricPrefixes = []string{"monitoring.googleapis.com/uptime_check"}
+				config.MetricDenyPrefixes = []string{"monitoring.googleapis.com/billing"}
+				return config
+			},
+			shouldError: true,
+		},
+		{
+			name: "aggregate deltas without a ttl",
+			configModifier: func(config gcp_exporter.Config) gcp_exporter.Config {
+				config.AggregateDeltas = true
+				config.AggregateDeltasTTL = 0
+				return config
+			},
+			shouldError: true,
+		},
 	}
 	for _, tt := range tests {
 		testName := tt.name
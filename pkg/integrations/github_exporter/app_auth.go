@@ -0,0 +1,170 @@
+package github_exporter //nolint:golint
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// appJWTLifetime is the lifetime of the JWTs authenticating as the GitHub
+	// App. GitHub rejects JWTs which expire more than 10 minutes in the future.
+	appJWTLifetime = 9 * time.Minute
+
+	// appTokenRefreshWindow is how long before their expiry installation
+	// access tokens are refreshed.
+	appTokenRefreshWindow = 5 * time.Minute
+)
+
+// tokenSource returns the token used to authenticate requests to the GitHub
+// API.
+type tokenSource interface {
+	Token() (string, error)
+}
+
+// staticToken is a tokenSource which always returns the same token.
+type staticToken string
+
+func (t staticToken) Token() (string, error) { return string(t), nil }
+
+// appTokenSource returns installation access tokens of a GitHub App,
+// refreshing them before they expire.
+type appTokenSource struct {
+	tokenURL string
+	appID    int64
+	key      *rsa.PrivateKey
+	client   *http.Client
+	now      func() time.Time
+
+	mut    sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func newAppTokenSource(apiURL string, appID, installationID int64, privateKey []byte) (*appTokenSource, error) {
+	key, err := parsePrivateKey(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App private key: %w", err)
+	}
+
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = path.Join(u.Path, "app", "installations", strconv.FormatInt(installationID, 10), "access_tokens")
+
+	return &appTokenSource{
+		tokenURL: u.String(),
+		appID:    appID,
+		key:      key,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("expected an RSA key, got %T", key)
+	}
+	return rsaKey, nil
+}
+
+// Token implements tokenSource.
+func (s *appTokenSource) Token() (string, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.token != "" && s.now().Add(appTokenRefreshWindow).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	jwt, err := s.signJWT()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create installation access token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("failed to create installation access token: %s: %s", resp.Status, b)
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode installation access token: %w", err)
+	}
+
+	s.token, s.expiry = body.Token, body.ExpiresAt
+	return s.token, nil
+}
+
+// signJWT returns a JWT authenticating as the GitHub App, signed with its
+// private key.
+func (s *appTokenSource) signJWT() (string, error) {
+	now := s.now()
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		// Issued a minute in the past to allow for clock drift.
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
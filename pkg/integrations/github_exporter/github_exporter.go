@@ -2,21 +2,32 @@ package github_exporter //nolint:golint
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/agent/pkg/integrations"
+	"github.com/grafana/agent/pkg/integrations/config"
 	integrations_v2 "github.com/grafana/agent/pkg/integrations/v2"
 	"github.com/grafana/agent/pkg/integrations/v2/metricsutils"
 	gh_config "github.com/infinityworks/github-exporter/config"
 	"github.com/infinityworks/github-exporter/exporter"
+	"github.com/prometheus/client_golang/prometheus"
 	config_util "github.com/prometheus/common/config"
 )
 
+// organizationParam is the query parameter used to select the organization a
+// per-organization target collects metrics from.
+const organizationParam = "organization"
+
 // DefaultConfig holds the default settings for the github_exporter integration
 var DefaultConfig = Config{
-	APIURL: "https://api.github.com",
+	APIURL:               "https://api.github.com",
+	WorkflowRunsLookback: time.Hour,
 }
 
 // Config controls github_exporter
@@ -38,6 +49,29 @@ type Config struct {
 
 	// A path to a file containing a GitHub authentication token that allows the API to be queried more often. If supplied, this supersedes `api_token`
 	APITokenFile string `yaml:"api_token_file,omitempty"`
+
+	// The ID of the GitHub App to authenticate as, instead of using a token.
+	AppID int64 `yaml:"app_id,omitempty"`
+
+	// The ID of the installation of the GitHub App to get access tokens for.
+	AppInstallationID int64 `yaml:"app_installation_id,omitempty"`
+
+	// The PEM-encoded private key of the GitHub App.
+	AppPrivateKey config_util.Secret `yaml:"app_private_key,omitempty"`
+
+	// A path to a file containing the PEM-encoded private key of the GitHub App.
+	AppPrivateKeyFile string `yaml:"app_private_key_file,omitempty"`
+
+	// Whether to collect metrics of the GitHub Actions workflow runs of
+	// Repositories.
+	CollectWorkflowRuns bool `yaml:"collect_workflow_runs,omitempty"`
+
+	// How far back completed workflow runs are counted.
+	WorkflowRunsLookback time.Duration `yaml:"workflow_runs_lookback,omitempty"`
+
+	// Whether to scrape each organization separately instead of with the
+	// repositories and users.
+	PerOrganizationTargets bool `yaml:"per_organization_targets,omitempty"`
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler for Config
@@ -72,35 +106,198 @@ func init() {
 	integrations_v2.RegisterLegacy(&Config{}, integrations_v2.TypeMultiplex, metricsutils.NewNamedShim("github"))
 }
 
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	useApp := c.AppID != 0 || c.AppInstallationID != 0 || c.AppPrivateKey != "" || c.AppPrivateKeyFile != ""
+	if useApp {
+		if c.APIToken != "" || c.APITokenFile != "" {
+			return fmt.Errorf("api_token and api_token_file can't be used with GitHub App authentication")
+		}
+		if c.AppID == 0 || c.AppInstallationID == 0 {
+			return fmt.Errorf("app_id and app_installation_id must be specified to authenticate as a GitHub App")
+		}
+		if (c.AppPrivateKey == "") == (c.AppPrivateKeyFile == "") {
+			return fmt.Errorf("exactly one of app_private_key or app_private_key_file must be specified to authenticate as a GitHub App")
+		}
+	}
+	if c.CollectWorkflowRuns && c.WorkflowRunsLookback <= 0 {
+		return fmt.Errorf("workflow_runs_lookback must be greater than 0")
+	}
+	return nil
+}
+
+// tokenSource returns the source of the tokens authenticating requests to
+// the GitHub API.
+func (c *Config) tokenSource() (tokenSource, error) {
+	if c.AppID == 0 {
+		token := string(c.APIToken)
+		if c.APITokenFile != "" {
+			conf := gh_config.Config{}
+			if err := conf.SetAPITokenFromFile(c.APITokenFile); err != nil {
+				return nil, fmt.Errorf("unable to load GitHub API token from file: %w", err)
+			}
+			token = conf.APIToken()
+		}
+		return staticToken(token), nil
+	}
+
+	key := []byte(c.AppPrivateKey)
+	if c.AppPrivateKeyFile != "" {
+		var err error
+		key, err = os.ReadFile(c.AppPrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load GitHub App private key from file: %w", err)
+		}
+	}
+	return newAppTokenSource(c.APIURL, c.AppID, c.AppInstallationID, key)
+}
+
 // New creates a new github_exporter integration.
 func New(logger log.Logger, c *Config) (integrations.Integration, error) {
-	conf := gh_config.Config{}
-	err := conf.SetAPIURL(c.APIURL)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	apiURL, err := url.Parse(c.APIURL)
 	if err != nil {
 		level.Error(logger).Log("msg", "api url is invalid", "err", err)
 		return nil, err
 	}
-	conf.SetRepositories(c.Repositories)
-	conf.SetOrganisations(c.Organizations)
-	conf.SetUsers(c.Users)
-	if c.APIToken != "" {
-		conf.SetAPIToken(string(c.APIToken))
+	token, err := c.tokenSource()
+	if err != nil {
+		level.Error(logger).Log("msg", "unable to create GitHub API token source", "err", err)
+		return nil, err
+	}
+
+	orgs := c.Organizations
+	if c.PerOrganizationTargets {
+		orgs = nil
+	}
+	collectors := []prometheus.Collector{newExporter(c.APIURL, c.Repositories, orgs, c.Users, token, logger)}
+	if c.CollectWorkflowRuns {
+		collectors = append(collectors, newWorkflowCollector(logger, apiURL, c.Repositories, c.WorkflowRunsLookback, token))
+	}
+	integration := integrations.NewCollectorIntegration(
+		c.Name(),
+		integrations.WithCollectors(collectors...),
+	)
+	if !c.PerOrganizationTargets {
+		return integration, nil
+	}
+
+	orgIntegrations := make(map[string]*integrations.CollectorIntegration, len(c.Organizations))
+	for _, org := range c.Organizations {
+		orgIntegrations[org] = integrations.NewCollectorIntegration(
+			c.Name(),
+			integrations.WithCollectors(newExporter(c.APIURL, nil, []string{org}, nil, token, log.With(logger, "organization", org))),
+		)
+	}
+	return &organizationsIntegration{
+		CollectorIntegration: integration,
+		name:                 c.Name(),
+		orgNames:             c.Organizations,
+		organizations:        orgIntegrations,
+	}, nil
+}
+
+// tokenExporter wraps the github_exporter collector to set the token used to
+// authenticate requests before every collection, as GitHub App tokens expire.
+type tokenExporter struct {
+	log   log.Logger
+	token tokenSource
+
+	mut sync.Mutex
+	exp exporter.Exporter
+}
+
+func newExporter(apiURL string, repositories, organizations, users []string, token tokenSource, l log.Logger) *tokenExporter {
+	conf := gh_config.Config{}
+	// The API URL is validated by New.
+	_ = conf.SetAPIURL(apiURL)
+	conf.SetRepositories(repositories)
+	conf.SetOrganisations(organizations)
+	conf.SetUsers(users)
+
+	return &tokenExporter{
+		log:   l,
+		token: token,
+		exp: exporter.Exporter{
+			APIMetrics: exporter.AddMetrics(),
+			Config:     conf,
+		},
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (e *tokenExporter) Describe(ch chan<- *prometheus.Desc) {
+	e.exp.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (e *tokenExporter) Collect(ch chan<- prometheus.Metric) {
+	token, err := e.token.Token()
+	if err != nil {
+		level.Error(e.log).Log("msg", "failed to get GitHub API token", "err", err)
+		return
+	}
+
+	e.mut.Lock()
+	defer e.mut.Unlock()
+	e.exp.Config.SetAPIToken(token)
+	e.exp.Collect(ch)
+}
+
+// organizationsIntegration serves the metrics of a single organization when
+// the organization query parameter is set. Requests without the parameter
+// are served by the wrapped integration, which doesn't collect metrics of
+// organizations.
+type organizationsIntegration struct {
+	*integrations.CollectorIntegration
+
+	name          string
+	orgNames      []string // Configured organizations, in order.
+	organizations map[string]*integrations.CollectorIntegration
+}
+
+// MetricsHandler implements integrations.Integration.
+func (i *organizationsIntegration) MetricsHandler() (http.Handler, error) {
+	next, err := i.CollectorIntegration.MetricsHandler()
+	if err != nil {
+		return nil, err
 	}
-	if c.APITokenFile != "" {
-		err = conf.SetAPITokenFromFile(c.APITokenFile)
+	handlers := make(map[string]http.Handler, len(i.organizations))
+	for org, integration := range i.organizations {
+		h, err := integration.MetricsHandler()
 		if err != nil {
-			level.Error(logger).Log("msg", "unable to load GitHub API token from file", "err", err)
 			return nil, err
 		}
+		handlers[org] = h
 	}
 
-	ghExporter := exporter.Exporter{
-		APIMetrics: exporter.AddMetrics(),
-		Config:     conf,
-	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		org := r.URL.Query().Get(organizationParam)
+		if org == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h, ok := handlers[org]
+		if !ok {
+			http.Error(w, fmt.Sprintf("organization %q is not configured", org), http.StatusBadRequest)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}), nil
+}
 
-	return integrations.NewCollectorIntegration(
-		c.Name(),
-		integrations.WithCollectors(&ghExporter),
-	), nil
+// ScrapeConfigs satisfies Integration.ScrapeConfigs. Every configured
+// organization is scraped separately.
+func (i *organizationsIntegration) ScrapeConfigs() []config.ScrapeConfig {
+	res := i.CollectorIntegration.ScrapeConfigs()
+	for _, org := range i.orgNames {
+		res = append(res, config.ScrapeConfig{
+			JobName:     i.name + "/" + org,
+			MetricsPath: "/metrics",
+			QueryParams: url.Values{organizationParam: []string{org}},
+		})
+	}
+	return res
 }
@@ -1,9 +1,26 @@
 package github_exporter //nolint:golint
 
 import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-kit/log"
 	"github.com/grafana/agent/pkg/config"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
 	// register github_exporter
 )
 
@@ -17,3 +34,158 @@ integrations:
     api_token: secret_api`
 	config.CheckSecret(t, stringCfg, "secret_api")
 }
+
+func TestConfig_Validate(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    Config
+		expect string
+	}{
+		{
+			name:   "app with token",
+			cfg:    Config{APIToken: "token", AppID: 1, AppInstallationID: 2, AppPrivateKeyFile: "key.pem"},
+			expect: "api_token and api_token_file can't be used with GitHub App authentication",
+		},
+		{
+			name:   "app without installation",
+			cfg:    Config{AppID: 1, AppPrivateKeyFile: "key.pem"},
+			expect: "app_id and app_installation_id must be specified to authenticate as a GitHub App",
+		},
+		{
+			name:   "app without private key",
+			cfg:    Config{AppID: 1, AppInstallationID: 2},
+			expect: "exactly one of app_private_key or app_private_key_file must be specified to authenticate as a GitHub App",
+		},
+		{
+			name:   "workflow runs without lookback",
+			cfg:    Config{CollectWorkflowRuns: true},
+			expect: "workflow_runs_lookback must be greater than 0",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.EqualError(t, tc.cfg.Validate(), tc.expect)
+		})
+	}
+}
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/api/v3/app/installations/42/access_tokens", r.URL.Path)
+
+		// The JWT must be signed with the private key of the app.
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		require.Len(t, parts, 3)
+		sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+		require.NoError(t, err)
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig))
+
+		claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+		require.NoError(t, err)
+		var claims struct {
+			Iss string `json:"iss"`
+			Iat int64  `json:"iat"`
+			Exp int64  `json:"exp"`
+		}
+		require.NoError(t, json.Unmarshal(claimsJSON, &claims))
+		require.Equal(t, "1234", claims.Iss)
+		require.Equal(t, now.Add(-time.Minute).Unix(), claims.Iat)
+		require.Equal(t, now.Add(9*time.Minute).Unix(), claims.Exp)
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, requests, now.Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer srv.Close()
+
+	ts, err := newAppTokenSource(srv.URL+"/api/v3", 1234, 42, keyPEM)
+	require.NoError(t, err)
+	ts.now = func() time.Time { return now }
+
+	token, err := ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, "ghs_1", token)
+
+	// Tokens are reused until they're about to expire.
+	now = now.Add(50 * time.Minute)
+	token, err = ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, "ghs_1", token)
+
+	now = now.Add(6 * time.Minute)
+	token, err = ts.Token()
+	require.NoError(t, err)
+	require.Equal(t, "ghs_2", token)
+}
+
+func TestWorkflowCollector(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/repos/grafana/agent/actions/runs", r.URL.Path)
+		require.Equal(t, "token secret", r.Header.Get("Authorization"))
+		require.Equal(t, "completed", r.URL.Query().Get("status"))
+		require.Equal(t, ">=2023-06-01T11:00:00Z", r.URL.Query().Get("created"))
+
+		fmt.Fprint(w, `{"workflow_runs": [
+			{"name": "Test", "workflow_id": 1, "conclusion": "success", "run_started_at": "2023-06-01T11:10:00Z", "updated_at": "2023-06-01T11:20:00Z"},
+			{"name": "Test", "workflow_id": 1, "conclusion": "failure", "run_started_at": "2023-06-01T11:30:00Z", "updated_at": "2023-06-01T11:35:00Z"},
+			{"name": "Lint", "workflow_id": 2, "conclusion": "success", "run_started_at": "2023-06-01T11:00:00Z", "updated_at": "2023-06-01T11:01:30Z"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	apiURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	c := newWorkflowCollector(log.NewNopLogger(), apiURL, []string{"grafana/agent"}, time.Hour, staticToken("secret"))
+	c.now = func() time.Time { return now }
+
+	expect := `
+# HELP github_workflow_last_run_duration_seconds Duration of the last workflow run completed within the lookback.
+# TYPE github_workflow_last_run_duration_seconds gauge
+github_workflow_last_run_duration_seconds{repo="grafana/agent",workflow="Lint"} 90
+github_workflow_last_run_duration_seconds{repo="grafana/agent",workflow="Test"} 300
+# HELP github_workflow_runs Number of workflow runs completed within the lookback, by conclusion.
+# TYPE github_workflow_runs gauge
+github_workflow_runs{conclusion="failure",repo="grafana/agent",workflow="Test"} 1
+github_workflow_runs{conclusion="success",repo="grafana/agent",workflow="Lint"} 1
+github_workflow_runs{conclusion="success",repo="grafana/agent",workflow="Test"} 1
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect)))
+}
+
+func TestNew_PerOrganizationTargets(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Repositories = []string{"grafana/agent"}
+	cfg.Organizations = []string{"grafana", "prometheus"}
+	cfg.PerOrganizationTargets = true
+
+	i, err := New(log.NewNopLogger(), &cfg)
+	require.NoError(t, err)
+
+	var jobs []string
+	for _, sc := range i.ScrapeConfigs() {
+		jobs = append(jobs, sc.JobName+"?"+sc.QueryParams.Encode())
+	}
+	require.Equal(t, []string{
+		"github_exporter?",
+		"github_exporter/grafana?organization=grafana",
+		"github_exporter/prometheus?organization=prometheus",
+	}, jobs)
+
+	h, err := i.MetricsHandler()
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics?organization=other", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `organization "other" is not configured`)
+}
@@ -0,0 +1,166 @@
+package github_exporter //nolint:golint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxWorkflowRunPages bounds the number of pages of workflow runs listed for
+// a repository on every collection.
+const maxWorkflowRunPages = 10
+
+var (
+	workflowRunsDesc = prometheus.NewDesc(
+		"github_workflow_runs",
+		"Number of workflow runs completed within the lookback, by conclusion.",
+		[]string{"repo", "workflow", "conclusion"}, nil,
+	)
+	workflowLastRunDurationDesc = prometheus.NewDesc(
+		"github_workflow_last_run_duration_seconds",
+		"Duration of the last workflow run completed within the lookback.",
+		[]string{"repo", "workflow"}, nil,
+	)
+)
+
+type workflowRun struct {
+	Name         string    `json:"name"`
+	WorkflowID   int64     `json:"workflow_id"`
+	Conclusion   string    `json:"conclusion"`
+	RunStartedAt time.Time `json:"run_started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// workflowCollector collects metrics of the GitHub Actions workflow runs of
+// repositories.
+type workflowCollector struct {
+	log          log.Logger
+	apiURL       *url.URL
+	repositories []string
+	lookback     time.Duration
+	token        tokenSource
+	client       *http.Client
+	now          func() time.Time
+}
+
+func newWorkflowCollector(l log.Logger, apiURL *url.URL, repositories []string, lookback time.Duration, token tokenSource) *workflowCollector {
+	return &workflowCollector{
+		log:          l,
+		apiURL:       apiURL,
+		repositories: repositories,
+		lookback:     lookback,
+		token:        token,
+		client:       &http.Client{Timeout: 30 * time.Second},
+		now:          time.Now,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *workflowCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workflowRunsDesc
+	ch <- workflowLastRunDurationDesc
+}
+
+// Collect implements prometheus.Collector. Repositories whose runs can't be
+// listed are skipped.
+func (c *workflowCollector) Collect(ch chan<- prometheus.Metric) {
+	token, err := c.token.Token()
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to get GitHub API token", "err", err)
+		return
+	}
+
+	since := c.now().Add(-c.lookback)
+	for _, repo := range c.repositories {
+		runs, err := c.listCompletedRuns(repo, since, token)
+		if err != nil {
+			level.Error(c.log).Log("msg", "failed to list workflow runs", "repo", repo, "err", err)
+			continue
+		}
+
+		type runKey struct {
+			workflow, conclusion string
+		}
+		counts := make(map[runKey]int)
+		last := make(map[int64]workflowRun)
+		for _, r := range runs {
+			counts[runKey{r.Name, r.Conclusion}]++
+			if l, ok := last[r.WorkflowID]; !ok || r.UpdatedAt.After(l.UpdatedAt) {
+				last[r.WorkflowID] = r
+			}
+		}
+
+		for k, n := range counts {
+			ch <- prometheus.MustNewConstMetric(workflowRunsDesc, prometheus.GaugeValue, float64(n), repo, k.workflow, k.conclusion)
+		}
+		for _, r := range last {
+			duration := r.UpdatedAt.Sub(r.RunStartedAt)
+			ch <- prometheus.MustNewConstMetric(workflowLastRunDurationDesc, prometheus.GaugeValue, duration.Seconds(), repo, r.Name)
+		}
+	}
+}
+
+// listCompletedRuns lists the workflow runs of repo created since the given
+// time which completed.
+func (c *workflowCollector) listCompletedRuns(repo string, since time.Time, token string) ([]workflowRun, error) {
+	u := *c.apiURL
+	u.Path = path.Join(u.Path, "repos", repo, "actions", "runs")
+
+	var runs []workflowRun
+	for page := 1; page <= maxWorkflowRunPages; page++ {
+		q := url.Values{
+			"status":   []string{"completed"},
+			"created":  []string{">=" + since.UTC().Format(time.RFC3339)},
+			"per_page": []string{"100"},
+			"page":     []string{strconv.Itoa(page)},
+		}
+		u.RawQuery = q.Encode()
+
+		req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token != "" {
+			req.Header.Set("Authorization", "token "+token)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var body struct {
+			WorkflowRuns []workflowRun `json:"workflow_runs"`
+		}
+		err = decodeResponse(resp, &body)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, body.WorkflowRuns...)
+
+		if len(body.WorkflowRuns) < 100 {
+			break
+		}
+	}
+	return runs, nil
+}
+
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, b)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
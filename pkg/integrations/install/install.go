@@ -9,10 +9,13 @@ import (
 	_ "github.com/grafana/agent/pkg/integrations/agent"                  // register agent
 	_ "github.com/grafana/agent/pkg/integrations/apache_http"            // register apache_exporter
 	_ "github.com/grafana/agent/pkg/integrations/azure_exporter"         // register azure_exporter
+	_ "github.com/grafana/agent/pkg/integrations/bind_exporter"          // register bind_exporter
 	_ "github.com/grafana/agent/pkg/integrations/blackbox_exporter"      // register blackbox_exporter
 	_ "github.com/grafana/agent/pkg/integrations/cadvisor"               // register cadvisor
 	_ "github.com/grafana/agent/pkg/integrations/cloudwatch_exporter"    // register cloudwatch_exporter
 	_ "github.com/grafana/agent/pkg/integrations/consul_exporter"        // register consul_exporter
+	_ "github.com/grafana/agent/pkg/integrations/databricks_exporter"    // register databricks_exporter
+	_ "github.com/grafana/agent/pkg/integrations/dcgm_exporter"          // register dcgm_exporter
 	_ "github.com/grafana/agent/pkg/integrations/dnsmasq_exporter"       // register dnsmasq_exporter
 	_ "github.com/grafana/agent/pkg/integrations/elasticsearch_exporter" // register elasticsearch_exporter
 	_ "github.com/grafana/agent/pkg/integrations/gcp_exporter"           // register gcp_exporter
@@ -31,6 +34,7 @@ import (
 	_ "github.com/grafana/agent/pkg/integrations/snowflake_exporter"     // register snowflake_exporter
 	_ "github.com/grafana/agent/pkg/integrations/squid_exporter"         // register squid_exporter
 	_ "github.com/grafana/agent/pkg/integrations/statsd_exporter"        // register statsd_exporter
+	_ "github.com/grafana/agent/pkg/integrations/unbound_exporter"       // register unbound_exporter
 	_ "github.com/grafana/agent/pkg/integrations/windows_exporter"       // register windows_exporter
 
 	//
@@ -0,0 +1,229 @@
+package unbound_exporter //nolint:golint
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "unbound"
+
+// statsCommand requests statistics without resetting the counters, so that
+// they can be exposed as Prometheus counters.
+const statsCommand = "UBCT1 stats_noreset\n"
+
+var upDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "up"),
+	"Whether collecting metrics from the Unbound remote control interface succeeded.",
+	nil, nil,
+)
+
+// metric maps statistics of Unbound to a Prometheus metric. Statistics are
+// matched by their name, or by their prefix when the metric has a label, in
+// which case the rest of the name is the value of the label.
+type metric struct {
+	stat      string
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}
+
+func newMetric(stat, name, help string, valueType prometheus.ValueType, labels ...string) metric {
+	return metric{
+		stat:      stat,
+		desc:      prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil),
+		valueType: valueType,
+	}
+}
+
+var metrics = []metric{
+	newMetric("total.num.queries", "queries_total", "Total number of queries received.", prometheus.CounterValue),
+	newMetric("total.num.cachehits", "cache_hits_total", "Total number of queries answered from the cache.", prometheus.CounterValue),
+	newMetric("total.num.cachemiss", "cache_misses_total", "Total number of queries which needed recursive processing.", prometheus.CounterValue),
+	newMetric("total.num.prefetch", "prefetches_total", "Total number of cache prefetches performed.", prometheus.CounterValue),
+	newMetric("total.num.expired", "expired_total", "Total number of replies served from expired cache entries.", prometheus.CounterValue),
+	newMetric("total.num.recursivereplies", "recursive_replies_total", "Total number of replies sent to queries which needed recursive processing.", prometheus.CounterValue),
+	newMetric("total.requestlist.current.all", "request_list_current", "Number of queries in the request list.", prometheus.GaugeValue),
+	newMetric("total.requestlist.exceeded", "request_list_exceeded_total", "Number of queries dropped because the request list was full.", prometheus.CounterValue),
+	newMetric("total.recursion.time.avg", "recursion_time_seconds_avg", "Average time to answer queries which needed recursive processing.", prometheus.GaugeValue),
+	newMetric("total.recursion.time.median", "recursion_time_seconds_median", "Median time to answer queries which needed recursive processing.", prometheus.GaugeValue),
+	newMetric("time.up", "uptime_seconds", "Uptime of the Unbound server.", prometheus.CounterValue),
+	newMetric("num.query.tcp", "query_tcp_total", "Total number of queries received over TCP.", prometheus.CounterValue),
+	newMetric("num.query.ipv6", "query_ipv6_total", "Total number of queries received over IPv6.", prometheus.CounterValue),
+	newMetric("num.answer.secure", "answers_secure_total", "Total number of answers which were DNSSEC secure.", prometheus.CounterValue),
+	newMetric("num.answer.bogus", "answers_bogus_total", "Total number of answers which were DNSSEC bogus.", prometheus.CounterValue),
+	newMetric("num.query.type.", "query_types_total", "Total number of queries received, by query type.", prometheus.CounterValue, "type"),
+	newMetric("num.answer.rcode.", "answer_rcodes_total", "Total number of answers sent, by response code.", prometheus.CounterValue, "rcode"),
+	newMetric("mem.cache.", "memory_caches_bytes", "Memory used by caches.", prometheus.GaugeValue, "cache"),
+	newMetric("mem.mod.", "memory_modules_bytes", "Memory used by modules.", prometheus.GaugeValue, "module"),
+}
+
+// threadQueriesDesc is reported for the "threadN.num.queries" statistics.
+var threadQueriesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "thread_queries_total"),
+	"Total number of queries received, by thread.",
+	[]string{"thread"}, nil,
+)
+
+// collector queries the remote control interface for every collection.
+type collector struct {
+	log       log.Logger
+	cfg       *Config
+	tlsConfig *tls.Config
+}
+
+func newCollector(l log.Logger, cfg *Config) (*collector, error) {
+	c := &collector{log: l, cfg: cfg}
+	if cfg.useTLS() {
+		var err error
+		if c.tlsConfig, err = loadTLSConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// loadTLSConfig returns the TLS config to connect to the remote control
+// interface, whose certificate is issued for the "unbound" server name by
+// unbound-control-setup.
+func loadTLSConfig(cfg *Config) (*tls.Config, error) {
+	ca, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      roots,
+		ServerName:   "unbound",
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- threadQueriesDesc
+	for _, m := range metrics {
+		ch <- m.desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.queryStats()
+	if err != nil {
+		level.Error(c.log).Log("msg", "failed to collect Unbound metrics", "err", err)
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+		return
+	}
+
+	for name, value := range stats {
+		if thread, ok := threadQueries(name); ok {
+			ch <- prometheus.MustNewConstMetric(threadQueriesDesc, prometheus.CounterValue, value, thread)
+			continue
+		}
+
+		for _, m := range metrics {
+			if name == m.stat {
+				ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, value)
+				break
+			}
+			if strings.HasSuffix(m.stat, ".") && strings.HasPrefix(name, m.stat) {
+				ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, value, strings.TrimPrefix(name, m.stat))
+				break
+			}
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+}
+
+// threadQueries returns the thread number of a "threadN.num.queries"
+// statistic.
+func threadQueries(name string) (string, bool) {
+	thread, ok := strings.CutPrefix(name, "thread")
+	if !ok {
+		return "", false
+	}
+	thread, ok = strings.CutSuffix(thread, ".num.queries")
+	if !ok {
+		return "", false
+	}
+	if _, err := strconv.Atoi(thread); err != nil {
+		return "", false
+	}
+	return thread, true
+}
+
+// queryStats returns the statistics reported by the remote control interface.
+func (c *collector) queryStats() (map[string]float64, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.cfg.Timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write([]byte(statsCommand)); err != nil {
+		return nil, err
+	}
+	return parseStats(conn)
+}
+
+func (c *collector) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: c.cfg.Timeout}
+
+	if path, ok := strings.CutPrefix(c.cfg.ControlAddress, "unix://"); ok {
+		return dialer.Dial("unix", path)
+	}
+	if c.tlsConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", c.cfg.ControlAddress, c.tlsConfig)
+	}
+	return dialer.Dial("tcp", c.cfg.ControlAddress)
+}
+
+// parseStats parses the "name=value" lines of the response to the stats
+// command.
+func parseStats(r io.Reader) (map[string]float64, error) {
+	stats := make(map[string]float64)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			// Errors are reported on a single line, such as "error command
+			// not recognized".
+			return nil, fmt.Errorf("unexpected response from Unbound: %q", line)
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", name, err)
+		}
+		stats[name] = v
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
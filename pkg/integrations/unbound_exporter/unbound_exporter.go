@@ -0,0 +1,94 @@
+// Package unbound_exporter collects metrics from Unbound DNS resolvers over
+// their remote control interface.
+package unbound_exporter //nolint:golint
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/integrations"
+	integrations_v2 "github.com/grafana/agent/pkg/integrations/v2"
+	"github.com/grafana/agent/pkg/integrations/v2/metricsutils"
+)
+
+// DefaultConfig is the default config for the unbound_exporter integration.
+var DefaultConfig = Config{
+	ControlAddress: "localhost:8953",
+	Timeout:        10 * time.Second,
+}
+
+// Config controls the unbound_exporter integration.
+type Config struct {
+	// ControlAddress is the address of the remote control interface of
+	// Unbound, either host:port or a unix:// path to the control socket.
+	ControlAddress string `yaml:"control_address,omitempty"`
+
+	// Files used to authenticate to the remote control interface over TLS,
+	// when control-use-cert is enabled. The connection isn't encrypted when
+	// they're empty.
+	CAFile   string `yaml:"ca_file,omitempty"`
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+
+	// Timeout is the timeout for querying statistics.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler for Config.
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = DefaultConfig
+
+	type plain Config
+	return unmarshal((*plain)(c))
+}
+
+// Validate returns an error if the config is invalid.
+func (c *Config) Validate() error {
+	if c.ControlAddress == "" {
+		return fmt.Errorf("control_address must be specified")
+	}
+	if strings.HasPrefix(c.ControlAddress, "unix://") && c.useTLS() {
+		return fmt.Errorf("TLS files can't be used with a unix control socket")
+	}
+	if c.useTLS() && (c.CAFile == "" || c.CertFile == "" || c.KeyFile == "") {
+		return fmt.Errorf("ca_file, cert_file, and key_file must be specified together")
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("timeout must be greater than 0")
+	}
+	return nil
+}
+
+func (c *Config) useTLS() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.KeyFile != ""
+}
+
+// Name returns the name of the integration this config is for.
+func (c *Config) Name() string {
+	return "unbound_exporter"
+}
+
+// InstanceKey returns the address of the remote control interface.
+func (c *Config) InstanceKey(agentKey string) (string, error) {
+	return c.ControlAddress, nil
+}
+
+func init() {
+	integrations.RegisterIntegration(&Config{})
+	integrations_v2.RegisterLegacy(&Config{}, integrations_v2.TypeMultiplex, metricsutils.NewNamedShim("unbound"))
+}
+
+// NewIntegration creates a new integration from the config.
+func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	col, err := newCollector(l, c)
+	if err != nil {
+		return nil, err
+	}
+	return integrations.NewCollectorIntegration(c.Name(), integrations.WithCollectors(col)), nil
+}
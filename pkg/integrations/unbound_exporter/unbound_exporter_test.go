@@ -0,0 +1,135 @@
+package unbound_exporter //nolint:golint
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfig_UnmarshalYAML(t *testing.T) {
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+control_address: unix:///run/unbound.ctl
+`), &cfg))
+
+	expect := DefaultConfig
+	expect.ControlAddress = "unix:///run/unbound.ctl"
+	require.Equal(t, expect, cfg)
+	require.NoError(t, cfg.Validate())
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.CAFile = "/etc/unbound/unbound_server.pem"
+	require.EqualError(t, cfg.Validate(), "ca_file, cert_file, and key_file must be specified together")
+
+	cfg = DefaultConfig
+	cfg.ControlAddress = "unix:///run/unbound.ctl"
+	cfg.CAFile = "/etc/unbound/unbound_server.pem"
+	require.EqualError(t, cfg.Validate(), "TLS files can't be used with a unix control socket")
+}
+
+const testStats = `thread0.num.queries=30
+thread1.num.queries=12
+total.num.queries=42
+total.num.cachehits=40
+total.num.cachemiss=2
+total.recursion.time.avg=0.012500
+time.up=3600.5
+num.query.type.A=30
+num.query.type.AAAA=12
+num.answer.rcode.NOERROR=41
+mem.cache.rrset=1024
+total.num.zero_ttl=0
+`
+
+// newTestServer returns the address of a fake remote control interface
+// replying to the stats command with response.
+func newTestServer(t *testing.T, response string) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			cmd, _ := bufio.NewReader(conn).ReadString('\n')
+			if cmd == statsCommand {
+				_, _ = conn.Write([]byte(response))
+			} else {
+				_, _ = conn.Write([]byte("error unknown command\n"))
+			}
+			_ = conn.Close()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+func TestCollector(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.ControlAddress = newTestServer(t, testStats)
+
+	c, err := newCollector(log.NewNopLogger(), &cfg)
+	require.NoError(t, err)
+
+	expect := `
+# HELP unbound_up Whether collecting metrics from the Unbound remote control interface succeeded.
+# TYPE unbound_up gauge
+unbound_up 1
+# HELP unbound_queries_total Total number of queries received.
+# TYPE unbound_queries_total counter
+unbound_queries_total 42
+# HELP unbound_cache_hits_total Total number of queries answered from the cache.
+# TYPE unbound_cache_hits_total counter
+unbound_cache_hits_total 40
+# HELP unbound_recursion_time_seconds_avg Average time to answer queries which needed recursive processing.
+# TYPE unbound_recursion_time_seconds_avg gauge
+unbound_recursion_time_seconds_avg 0.0125
+# HELP unbound_query_types_total Total number of queries received, by query type.
+# TYPE unbound_query_types_total counter
+unbound_query_types_total{type="A"} 30
+unbound_query_types_total{type="AAAA"} 12
+# HELP unbound_memory_caches_bytes Memory used by caches.
+# TYPE unbound_memory_caches_bytes gauge
+unbound_memory_caches_bytes{cache="rrset"} 1024
+# HELP unbound_thread_queries_total Total number of queries received, by thread.
+# TYPE unbound_thread_queries_total counter
+unbound_thread_queries_total{thread="0"} 30
+unbound_thread_queries_total{thread="1"} 12
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect),
+		"unbound_up",
+		"unbound_queries_total",
+		"unbound_cache_hits_total",
+		"unbound_recursion_time_seconds_avg",
+		"unbound_query_types_total",
+		"unbound_memory_caches_bytes",
+		"unbound_thread_queries_total",
+	))
+}
+
+func TestCollector_Error(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.ControlAddress = newTestServer(t, "error command not recognized\n")
+
+	c, err := newCollector(log.NewNopLogger(), &cfg)
+	require.NoError(t, err)
+
+	expect := `
+# HELP unbound_up Whether collecting metrics from the Unbound remote control interface succeeded.
+# TYPE unbound_up gauge
+unbound_up 0
+`
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expect), "unbound_up"))
+}
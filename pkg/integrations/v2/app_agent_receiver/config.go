@@ -1,6 +1,7 @@
 package app_agent_receiver
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/grafana/agent/pkg/integrations/v2"
@@ -16,6 +17,12 @@ const (
 	DefaultRateLimitingBurstiness = 50
 	// DefaultMaxPayloadSize is the max payload size in bytes
 	DefaultMaxPayloadSize = 5e6
+	// DefaultSourceMapCacheSize is the default number of source maps kept in
+	// the source map cache
+	DefaultSourceMapCacheSize = 1000
+	// DefaultSourceMapDownloadMaxSize is the default max size in bytes of
+	// downloaded sources and source maps
+	DefaultSourceMapDownloadMaxSize = 32 << 20
 )
 
 // DefaultConfig holds the default configuration of the receiver
@@ -37,6 +44,8 @@ var DefaultConfig = Config{
 	SourceMaps: SourceMapConfig{
 		DownloadFromOrigins: []string{"*"},
 		DownloadTimeout:     time.Second,
+		DownloadMaxSize:     DefaultSourceMapDownloadMaxSize,
+		CacheSize:           DefaultSourceMapCacheSize,
 	},
 }
 
@@ -47,9 +56,19 @@ type ServerConfig struct {
 	CORSAllowedOrigins    []string           `yaml:"cors_allowed_origins,omitempty"`
 	RateLimiting          RateLimitingConfig `yaml:"rate_limiting,omitempty"`
 	APIKey                string             `yaml:"api_key,omitempty"`
+	APIKeys               []APIKeyConfig     `yaml:"api_keys,omitempty"`
 	MaxAllowedPayloadSize int64              `yaml:"max_allowed_payload_size,omitempty"`
 }
 
+// APIKeyConfig holds the configuration of an API key which may only be used
+// by a single app
+type APIKeyConfig struct {
+	App                string              `yaml:"app"`
+	Key                string              `yaml:"key"`
+	CORSAllowedOrigins []string            `yaml:"cors_allowed_origins,omitempty"`
+	RateLimiting       *RateLimitingConfig `yaml:"rate_limiting,omitempty"`
+}
+
 // RateLimitingConfig holds the configuration of the rate limiter
 type RateLimitingConfig struct {
 	Enabled    bool    `yaml:"enabled,omitempty"`
@@ -63,12 +82,22 @@ type SourceMapFileLocation struct {
 	MinifiedPathPrefix string `yaml:"minified_path_prefix,omitempty"`
 }
 
+// SourceMapRemoteLocation holds sourcemap location in an S3 or GCS bucket or
+// on an HTTP server
+type SourceMapRemoteLocation struct {
+	URL                string `yaml:"url"`
+	MinifiedPathPrefix string `yaml:"minified_path_prefix,omitempty"`
+}
+
 // SourceMapConfig configure source map locations
 type SourceMapConfig struct {
-	Download            bool                    `yaml:"download"`
-	DownloadFromOrigins []string                `yaml:"download_origins,omitempty"`
-	DownloadTimeout     time.Duration           `yaml:"download_timeout,omitempty"`
-	FileSystem          []SourceMapFileLocation `yaml:"filesystem,omitempty"`
+	Download            bool                      `yaml:"download"`
+	DownloadFromOrigins []string                  `yaml:"download_origins,omitempty"`
+	DownloadTimeout     time.Duration             `yaml:"download_timeout,omitempty"`
+	DownloadMaxSize     int64                     `yaml:"download_max_size,omitempty"`
+	CacheSize           int                       `yaml:"cache_size,omitempty"`
+	FileSystem          []SourceMapFileLocation   `yaml:"filesystem,omitempty"`
+	Remote              []SourceMapRemoteLocation `yaml:"remote,omitempty"`
 }
 
 // Config is the configuration struct of the
@@ -88,7 +117,44 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	*c = DefaultConfig
 	c.LogsLabels = make(map[string]string)
 	type plain Config
-	return unmarshal((*plain)(c))
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	return c.Server.validateAPIKeys()
+}
+
+// UnmarshalYAML implements the Unmarshaler interface
+func (c *APIKeyConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain APIKeyConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.RateLimiting != nil {
+		if c.RateLimiting.RPS == 0 {
+			c.RateLimiting.RPS = DefaultRateLimitingRPS
+		}
+		if c.RateLimiting.Burstiness == 0 {
+			c.RateLimiting.Burstiness = DefaultRateLimitingBurstiness
+		}
+	}
+	return nil
+}
+
+func (c *ServerConfig) validateAPIKeys() error {
+	keys := make(map[string]struct{}, len(c.APIKeys))
+	for i, k := range c.APIKeys {
+		if k.App == "" {
+			return fmt.Errorf("api_keys[%d]: app must be set", i)
+		}
+		if k.Key == "" {
+			return fmt.Errorf("api_keys[%d]: key must be set", i)
+		}
+		if _, ok := keys[k.Key]; ok || k.Key == c.APIKey {
+			return fmt.Errorf("api_keys[%d]: key of app %q is used more than once", i, k.App)
+		}
+		keys[k.Key] = struct{}{}
+	}
+	return nil
 }
 
 // IntegrationName is the name of this integration
@@ -77,3 +77,42 @@ logs_labels:
 	}, cfg2.LogsLabels)
 	require.Equal(t, []string{"*"}, cfg2.SourceMaps.DownloadFromOrigins)
 }
+
+func TestConfig_APIKeys(t *testing.T) {
+	var cfg Config
+	cb := `
+server:
+  api_keys:
+    - app: frontend
+      key: foo
+      rate_limiting:
+        enabled: true
+        rps: 10
+    - app: backoffice
+      key: bar`
+	err := yaml.UnmarshalStrict([]byte(cb), &cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.Server.APIKeys, 2)
+	require.Equal(t, &RateLimitingConfig{Enabled: true, RPS: 10, Burstiness: 50}, cfg.Server.APIKeys[0].RateLimiting)
+	require.Nil(t, cfg.Server.APIKeys[1].RateLimiting)
+}
+
+func TestConfig_APIKeysInvalid(t *testing.T) {
+	var cfg Config
+	cb := `
+server:
+  api_keys:
+    - app: frontend
+      key: foo
+    - app: backoffice
+      key: foo`
+	err := yaml.UnmarshalStrict([]byte(cb), &cfg)
+	require.EqualError(t, err, `api_keys[1]: key of app "backoffice" is used more than once`)
+
+	cb = `
+server:
+  api_keys:
+    - key: foo`
+	err = yaml.UnmarshalStrict([]byte(cb), &cfg)
+	require.EqualError(t, err, `api_keys[0]: app must be set`)
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/subtle"
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -27,23 +28,40 @@ type AppAgentReceiverHandler struct {
 	exporters               []appAgentReceiverExporter
 	config                  *Config
 	rateLimiter             *rate.Limiter
+	apiKeys                 []*apiKey
 	exporterErrorsCollector *prometheus.CounterVec
+	droppedRequests         *prometheus.CounterVec
+}
+
+// apiKey is an API key which may only be used by a single app
+type apiKey struct {
+	app         string
+	key         []byte
+	origins     []string
+	rateLimited bool
+	rateLimiter *rate.Limiter
 }
 
 // NewAppAgentReceiverHandler creates a new AppReceiver instance based on the given configuration
 func NewAppAgentReceiverHandler(conf *Config, exporters []appAgentReceiverExporter, reg prometheus.Registerer) AppAgentReceiverHandler {
-	var rateLimiter *rate.Limiter
-	if conf.Server.RateLimiting.Enabled {
-		var rps float64
-		if conf.Server.RateLimiting.RPS > 0 {
-			rps = conf.Server.RateLimiting.RPS
+	rateLimiter := newRateLimiter(conf.Server.RateLimiting)
+
+	apiKeys := make([]*apiKey, 0, len(conf.Server.APIKeys))
+	for _, k := range conf.Server.APIKeys {
+		key := &apiKey{
+			app:     k.App,
+			key:     []byte(k.Key),
+			origins: k.CORSAllowedOrigins,
 		}
-
-		var b int
-		if conf.Server.RateLimiting.Burstiness > 0 {
-			b = conf.Server.RateLimiting.Burstiness
+		// Keys without their own rate limits share the limiter of the receiver.
+		if k.RateLimiting != nil {
+			key.rateLimited = k.RateLimiting.Enabled
+			key.rateLimiter = newRateLimiter(*k.RateLimiting)
+		} else {
+			key.rateLimited = conf.Server.RateLimiting.Enabled
+			key.rateLimiter = rateLimiter
 		}
-		rateLimiter = rate.NewLimiter(rate.Limit(rps), b)
+		apiKeys = append(apiKeys, key)
 	}
 
 	exporterErrorsCollector := prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -51,48 +69,128 @@ func NewAppAgentReceiverHandler(conf *Config, exporters []appAgentReceiverExport
 		Help: "Total number of errors produced by a receiver exporter",
 	}, []string{"exporter"})
 
-	reg.MustRegister(exporterErrorsCollector)
+	droppedRequests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "app_agent_receiver_dropped_requests_total",
+		Help: "Total number of requests dropped by the receiver",
+	}, []string{"app", "reason"})
+
+	reg.MustRegister(exporterErrorsCollector, droppedRequests)
 
 	return AppAgentReceiverHandler{
 		exporters:               exporters,
 		config:                  conf,
 		rateLimiter:             rateLimiter,
+		apiKeys:                 apiKeys,
 		exporterErrorsCollector: exporterErrorsCollector,
+		droppedRequests:         droppedRequests,
+	}
+}
+
+func newRateLimiter(c RateLimitingConfig) *rate.Limiter {
+	if !c.Enabled {
+		return nil
+	}
+
+	var rps float64
+	if c.RPS > 0 {
+		rps = c.RPS
+	}
+
+	var b int
+	if c.Burstiness > 0 {
+		b = c.Burstiness
 	}
+	return rate.NewLimiter(rate.Limit(rps), b)
+}
+
+// authenticate returns the API key of the app which sent the request. It
+// returns a nil key if the request was authenticated with the API key of the
+// receiver or if no API keys are configured, and false if the request isn't
+// authenticated.
+func (ar *AppAgentReceiverHandler) authenticate(r *http.Request) (*apiKey, bool) {
+	if len(ar.config.Server.APIKey) == 0 && len(ar.apiKeys) == 0 {
+		return nil, true
+	}
+
+	provided := []byte(r.Header.Get(apiKeyHeader))
+	if len(provided) == 0 {
+		return nil, false
+	}
+
+	// Compare every key so that the time taken doesn't reveal which keys exist.
+	var (
+		found *apiKey
+		ok    bool
+	)
+	if len(ar.config.Server.APIKey) > 0 && subtle.ConstantTimeCompare(provided, []byte(ar.config.Server.APIKey)) == 1 {
+		ok = true
+	}
+	for _, k := range ar.apiKeys {
+		if subtle.ConstantTimeCompare(provided, k.key) == 1 {
+			found, ok = k, true
+		}
+	}
+	return found, ok
+}
+
+// drop records and responds to a request which is dropped for the given
+// reason.
+func (ar *AppAgentReceiverHandler) drop(w http.ResponseWriter, key *apiKey, reason string, msg string, code int) {
+	var app string
+	if key != nil {
+		app = key.app
+	}
+	ar.droppedRequests.WithLabelValues(app, reason).Inc()
+	http.Error(w, msg, code)
 }
 
 // HTTPHandler is the http.Handler for the receiver. It will do the following
 // 0. Enable CORS for the configured hosts
-// 1. Check if the request should be rate limited
-// 2. Verify that the payload size is within limits
-// 3. Start two go routines for exporters processing and exporting data respectively
-// 4. Respond with 202 once all the work is done
+// 1. Check the API key and the origin of the request
+// 2. Check if the request should be rate limited
+// 3. Verify that the payload size is within limits
+// 4. Verify that the payload belongs to the app of the API key
+// 5. Start two go routines for exporters processing and exporting data respectively
+// 6. Respond with 202 once all the work is done
 func (ar *AppAgentReceiverHandler) HTTPHandler(logger log.Logger) http.Handler {
 	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := ar.authenticate(r)
+		if !ok {
+			ar.drop(w, nil, "unauthorized", "api key not provided or incorrect", http.StatusUnauthorized)
+			return
+		}
+		if origin := r.Header.Get("Origin"); key != nil && len(key.origins) > 0 && origin != "" && !originAllowed(key.origins, origin) {
+			ar.drop(w, key, "origin_not_allowed", "origin not allowed for api key", http.StatusForbidden)
+			return
+		}
+
 		// Check rate limiting state
-		if ar.config.Server.RateLimiting.Enabled {
-			if ok := ar.rateLimiter.Allow(); !ok {
-				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		rateLimited, rateLimiter := ar.config.Server.RateLimiting.Enabled, ar.rateLimiter
+		if key != nil {
+			rateLimited, rateLimiter = key.rateLimited, key.rateLimiter
+		}
+		if rateLimited {
+			if ok := rateLimiter.Allow(); !ok {
+				ar.drop(w, key, "rate_limited", http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
 				return
 			}
 		}
 
-		// check API key if one is provided
-		if len(ar.config.Server.APIKey) > 0 && subtle.ConstantTimeCompare([]byte(r.Header.Get(apiKeyHeader)), []byte(ar.config.Server.APIKey)) == 0 {
-			http.Error(w, "api key not provided or incorrect", http.StatusUnauthorized)
-			return
-		}
-
 		// Verify content length. We trust net/http to give us the correct number
 		if ar.config.Server.MaxAllowedPayloadSize > 0 && r.ContentLength > ar.config.Server.MaxAllowedPayloadSize {
-			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			ar.drop(w, key, "payload_too_large", http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
 			return
 		}
 
 		var p Payload
 		err := json.NewDecoder(r.Body).Decode(&p)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			ar.drop(w, key, "invalid_payload", err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if key != nil && p.Meta.App.Name != key.app {
+			ar.drop(w, key, "app_mismatch", "api key not valid for app", http.StatusForbidden)
 			return
 		}
 
@@ -114,9 +212,16 @@ func (ar *AppAgentReceiverHandler) HTTPHandler(logger log.Logger) http.Handler {
 		_, _ = w.Write([]byte("ok"))
 	})
 
-	if len(ar.config.Server.CORSAllowedOrigins) > 0 {
+	// Preflight requests don't carry the API key, so they're allowed for the
+	// origins of every app.
+	origins := append([]string{}, ar.config.Server.CORSAllowedOrigins...)
+	for _, k := range ar.apiKeys {
+		origins = append(origins, k.origins...)
+	}
+
+	if len(origins) > 0 {
 		c := cors.New(cors.Options{
-			AllowedOrigins: ar.config.Server.CORSAllowedOrigins,
+			AllowedOrigins: origins,
 			AllowedHeaders: []string{apiKeyHeader, "content-type"},
 		})
 		handler = c.Handler(handler)
@@ -124,3 +229,22 @@ func (ar *AppAgentReceiverHandler) HTTPHandler(logger log.Logger) http.Handler {
 
 	return handler
 }
+
+// originAllowed reports whether origin matches any of the allowed origins,
+// which may contain a single "*" wildcard like the origins allowed for CORS.
+func originAllowed(allowed []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range allowed {
+		o = strings.ToLower(o)
+		if o == "*" || o == origin {
+			return true
+		}
+		if i := strings.IndexByte(o, '*'); i >= 0 {
+			prefix, suffix := o[:i], o[i+1:]
+			if len(origin) >= len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
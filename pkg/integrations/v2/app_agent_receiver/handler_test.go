@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 const PAYLOAD = `
@@ -354,3 +355,103 @@ func TestRateLimiterDisabled(t *testing.T) {
 	handler.ServeHTTP(rr, req)
 	require.Equal(t, http.StatusAccepted, rr.Result().StatusCode)
 }
+
+func newAppRequest(t *testing.T, app, key string) *http.Request {
+	body := `{"meta": {"app": {"name": "` + app + `"}}}`
+	req, err := http.NewRequest("POST", "/collect", bytes.NewBuffer([]byte(body)))
+	require.NoError(t, err)
+	if key != "" {
+		req.Header.Set("x-api-key", key)
+	}
+	return req
+}
+
+func TestAppAPIKeys(t *testing.T) {
+	conf := &Config{
+		Server: ServerConfig{
+			APIKeys: []APIKeyConfig{
+				{App: "frontend", Key: "foo"},
+				{App: "backoffice", Key: "bar"},
+			},
+		},
+	}
+
+	reg := prometheus.NewRegistry()
+	fr := NewAppAgentReceiverHandler(conf, nil, reg)
+	handler := fr.HTTPHandler(nil)
+
+	for _, tc := range []struct {
+		app, key string
+		status   int
+	}{
+		{"frontend", "foo", http.StatusAccepted},
+		{"backoffice", "bar", http.StatusAccepted},
+		{"frontend", "", http.StatusUnauthorized},
+		{"frontend", "baz", http.StatusUnauthorized},
+		{"frontend", "bar", http.StatusForbidden},
+	} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newAppRequest(t, tc.app, tc.key))
+		require.Equal(t, tc.status, rr.Result().StatusCode, "app %q with key %q", tc.app, tc.key)
+	}
+
+	require.Equal(t, 2.0, testutil.ToFloat64(fr.droppedRequests.WithLabelValues("", "unauthorized")))
+	require.Equal(t, 1.0, testutil.ToFloat64(fr.droppedRequests.WithLabelValues("backoffice", "app_mismatch")))
+}
+
+func TestAppAPIKeyRateLimiting(t *testing.T) {
+	conf := &Config{
+		Server: ServerConfig{
+			RateLimiting: RateLimitingConfig{Enabled: true, RPS: 1, Burstiness: 1},
+			APIKeys: []APIKeyConfig{
+				{App: "frontend", Key: "foo", RateLimiting: &RateLimitingConfig{Enabled: true, RPS: 1, Burstiness: 2}},
+				{App: "backoffice", Key: "bar"},
+			},
+		},
+	}
+
+	fr := NewAppAgentReceiverHandler(conf, nil, prometheus.NewRegistry())
+	handler := fr.HTTPHandler(nil)
+
+	makeRequest := func(app, key string) int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newAppRequest(t, app, key))
+		return rr.Result().StatusCode
+	}
+
+	// frontend has its own limits, backoffice shares the limits of the receiver.
+	require.Equal(t, http.StatusAccepted, makeRequest("frontend", "foo"))
+	require.Equal(t, http.StatusAccepted, makeRequest("frontend", "foo"))
+	require.Equal(t, http.StatusTooManyRequests, makeRequest("frontend", "foo"))
+	require.Equal(t, http.StatusAccepted, makeRequest("backoffice", "bar"))
+	require.Equal(t, http.StatusTooManyRequests, makeRequest("backoffice", "bar"))
+
+	require.Equal(t, 1.0, testutil.ToFloat64(fr.droppedRequests.WithLabelValues("frontend", "rate_limited")))
+	require.Equal(t, 1.0, testutil.ToFloat64(fr.droppedRequests.WithLabelValues("backoffice", "rate_limited")))
+}
+
+func TestAppAPIKeyOrigins(t *testing.T) {
+	conf := &Config{
+		Server: ServerConfig{
+			APIKeys: []APIKeyConfig{
+				{App: "frontend", Key: "foo", CORSAllowedOrigins: []string{"https://*.example.com"}},
+			},
+		},
+	}
+
+	fr := NewAppAgentReceiverHandler(conf, nil, prometheus.NewRegistry())
+	handler := fr.HTTPHandler(nil)
+
+	req := newAppRequest(t, "frontend", "foo")
+	req.Header.Set("Origin", "https://shop.example.com")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusAccepted, rr.Result().StatusCode)
+	require.Equal(t, "https://shop.example.com", rr.Result().Header.Get("Access-Control-Allow-Origin"))
+
+	req = newAppRequest(t, "frontend", "foo")
+	req.Header.Set("Origin", "https://example.org")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusForbidden, rr.Result().StatusCode)
+}
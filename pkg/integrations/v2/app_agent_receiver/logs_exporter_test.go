@@ -38,7 +38,7 @@ func (i *testLogsInstance) SendEntry(entry api.Entry, dur time.Duration) bool {
 
 type MockSourceMapStore struct{}
 
-func (store *MockSourceMapStore) GetSourceMap(sourceURL string, release string) (*SourceMap, error) {
+func (store *MockSourceMapStore) GetSourceMap(sourceURL string, app App) (*SourceMap, error) {
 	return nil, nil
 }
 
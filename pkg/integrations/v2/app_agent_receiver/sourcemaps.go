@@ -2,6 +2,8 @@ package app_agent_receiver
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -17,6 +19,7 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/go-sourcemap/sourcemap"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/vincent-petithory/dataurl"
 )
@@ -24,7 +27,7 @@ import (
 // SourceMapStore is interface for a sourcemap service capable of transforming
 // minified source locations to original source location
 type SourceMapStore interface {
-	GetSourceMap(sourceURL string, release string) (*SourceMap, error)
+	GetSourceMap(sourceURL string, app App) (*SourceMap, error)
 }
 
 type httpClient interface {
@@ -56,9 +59,10 @@ type SourceMap struct {
 }
 
 type sourceMapMetrics struct {
-	cacheSize *prometheus.CounterVec
-	downloads *prometheus.CounterVec
-	fileReads *prometheus.CounterVec
+	cacheSize   *prometheus.CounterVec
+	downloads   *prometheus.CounterVec
+	fileReads   *prometheus.CounterVec
+	remoteReads *prometheus.CounterVec
 }
 
 type sourcemapFileLocation struct {
@@ -66,17 +70,38 @@ type sourcemapFileLocation struct {
 	pathTemplate *template.Template
 }
 
+type sourcemapRemoteLocation struct {
+	SourceMapRemoteLocation
+	urlTemplate *template.Template
+}
+
+// sourcemapTemplateData is the data available to the templates of source map
+// locations
+type sourcemapTemplateData struct {
+	Release string
+	App     string
+}
+
+func newSourcemapTemplateData(app App) sourcemapTemplateData {
+	return sourcemapTemplateData{
+		Release: cleanFilePathPart(app.Release),
+		App:     cleanFilePathPart(app.Name),
+	}
+}
+
 // RealSourceMapStore is an implementation of SourceMapStore
 // that can download source maps or read them from file system
 type RealSourceMapStore struct {
 	sync.Mutex
-	l             log.Logger
-	httpClient    httpClient
-	fileService   fileService
-	config        SourceMapConfig
-	cache         map[string]*SourceMap
-	fileLocations []*sourcemapFileLocation
-	metrics       *sourceMapMetrics
+	l               log.Logger
+	httpClient      httpClient
+	fileService     fileService
+	bucketClients   map[string]bucketClient
+	config          SourceMapConfig
+	cache           *lru.Cache[string, *SourceMap]
+	fileLocations   []*sourcemapFileLocation
+	remoteLocations []*sourcemapRemoteLocation
+	metrics         *sourceMapMetrics
 }
 
 // NewSourceMapStore creates an instance of SourceMapStore.
@@ -105,8 +130,12 @@ func NewSourceMapStore(l log.Logger, config SourceMapConfig, reg prometheus.Regi
 			Name: "app_agent_receiver_sourcemap_file_reads_total",
 			Help: "source map file reads from file system, by origin and status",
 		}, []string{"origin", "status"}),
+		remoteReads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "app_agent_receiver_sourcemap_remote_reads_total",
+			Help: "source map reads from remote locations, by origin and status",
+		}, []string{"origin", "status"}),
 	}
-	reg.MustRegister(metrics.cacheSize, metrics.downloads, metrics.fileReads)
+	reg.MustRegister(metrics.cacheSize, metrics.downloads, metrics.fileReads, metrics.remoteReads)
 
 	fileLocations := []*sourcemapFileLocation{}
 
@@ -122,17 +151,63 @@ func NewSourceMapStore(l log.Logger, config SourceMapConfig, reg prometheus.Regi
 		})
 	}
 
+	remoteLocations := []*sourcemapRemoteLocation{}
+
+	for _, configLocation := range config.Remote {
+		tpl, err := template.New(configLocation.URL).Parse(configLocation.URL)
+		if err != nil {
+			panic(err)
+		}
+
+		remoteLocations = append(remoteLocations, &sourcemapRemoteLocation{
+			SourceMapRemoteLocation: configLocation,
+			urlTemplate:             tpl,
+		})
+	}
+
+	cacheSize := config.CacheSize
+	if cacheSize <= 0 {
+		cacheSize = DefaultSourceMapCacheSize
+	}
+	// Creating a cache only fails if its size isn't positive.
+	cache, _ := lru.New[string, *SourceMap](cacheSize)
+
 	return &RealSourceMapStore{
-		l:             l,
-		httpClient:    httpClient,
-		fileService:   fileService,
-		config:        config,
-		cache:         make(map[string]*SourceMap),
-		metrics:       metrics,
-		fileLocations: fileLocations,
+		l:           l,
+		httpClient:  httpClient,
+		fileService: fileService,
+		bucketClients: map[string]bucketClient{
+			"s3": &s3BucketClient{},
+			"gs": &gcsBucketClient{},
+		},
+		config:          config,
+		cache:           cache,
+		metrics:         metrics,
+		fileLocations:   fileLocations,
+		remoteLocations: remoteLocations,
 	}
 }
 
+func (store *RealSourceMapStore) downloadMaxSize() int64 {
+	if store.config.DownloadMaxSize > 0 {
+		return store.config.DownloadMaxSize
+	}
+	return DefaultSourceMapDownloadMaxSize
+}
+
+// readLimited reads r, failing if it's larger than the download_max_size
+func (store *RealSourceMapStore) readLimited(r io.Reader) ([]byte, error) {
+	maxSize := store.downloadMaxSize()
+	body, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("file is larger than the download max size of %d bytes", maxSize)
+	}
+	return body, nil
+}
+
 func (store *RealSourceMapStore) downloadFileContents(url string) ([]byte, error) {
 	resp, err := store.httpClient.Get(url)
 	if err != nil {
@@ -144,11 +219,7 @@ func (store *RealSourceMapStore) downloadFileContents(url string) ([]byte, error
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("unexpected status %v", resp.StatusCode)
 	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return body, nil
+	return store.readLimited(resp.Body)
 }
 
 func (store *RealSourceMapStore) downloadSourceMapContent(sourceURL string) (content []byte, resolvedSourceMapURL string, err error) {
@@ -205,24 +276,36 @@ func (store *RealSourceMapStore) downloadSourceMapContent(sourceURL string) (con
 	return result, resolvedSourceMapURL, nil
 }
 
-func (store *RealSourceMapStore) getSourceMapFromFileSystem(sourceURL string, release string, fileconf *sourcemapFileLocation) (content []byte, sourceMapURL string, err error) {
-	if len(sourceURL) == 0 || !strings.HasPrefix(sourceURL, fileconf.MinifiedPathPrefix) || strings.HasSuffix(sourceURL, "/") {
+// minifiedPathParts returns the parts of the path of sourceURL relative to
+// prefix, or false if sourceURL doesn't match prefix
+func minifiedPathParts(sourceURL string, prefix string) ([]string, bool) {
+	if len(sourceURL) == 0 || !strings.HasPrefix(sourceURL, prefix) || strings.HasSuffix(sourceURL, "/") {
+		return nil, false
+	}
+
+	var pathParts []string
+	for _, part := range strings.Split(strings.TrimPrefix(strings.Split(sourceURL, "?")[0], prefix), "/") {
+		if len(part) > 0 && part != "." && part != ".." {
+			pathParts = append(pathParts, part)
+		}
+	}
+	return pathParts, true
+}
+
+func (store *RealSourceMapStore) getSourceMapFromFileSystem(sourceURL string, app App, fileconf *sourcemapFileLocation) (content []byte, sourceMapURL string, err error) {
+	relativeParts, ok := minifiedPathParts(sourceURL, fileconf.MinifiedPathPrefix)
+	if !ok {
 		return nil, "", nil
 	}
 
 	var rootPath bytes.Buffer
 
-	err = fileconf.pathTemplate.Execute(&rootPath, struct{ Release string }{Release: cleanFilePathPart(release)})
+	err = fileconf.pathTemplate.Execute(&rootPath, newSourcemapTemplateData(app))
 	if err != nil {
 		return nil, "", err
 	}
 
-	pathParts := []string{rootPath.String()}
-	for _, part := range strings.Split(strings.TrimPrefix(strings.Split(sourceURL, "?")[0], fileconf.MinifiedPathPrefix), "/") {
-		if len(part) > 0 && part != "." && part != ".." {
-			pathParts = append(pathParts, part)
-		}
-	}
+	pathParts := append([]string{rootPath.String()}, relativeParts...)
 	mapFilePath := filepath.Join(pathParts...) + ".map"
 
 	if _, err := store.fileService.Stat(mapFilePath); err != nil {
@@ -241,10 +324,99 @@ func (store *RealSourceMapStore) getSourceMapFromFileSystem(sourceURL string, re
 	return content, sourceURL, err
 }
 
-func (store *RealSourceMapStore) getSourceMapContent(sourceURL string, release string) (content []byte, sourceMapURL string, err error) {
+func (store *RealSourceMapStore) getSourceMapFromRemote(sourceURL string, app App, remoteconf *sourcemapRemoteLocation) (content []byte, sourceMapURL string, err error) {
+	relativeParts, ok := minifiedPathParts(sourceURL, remoteconf.MinifiedPathPrefix)
+	if !ok {
+		return nil, "", nil
+	}
+
+	var baseURL bytes.Buffer
+
+	err = remoteconf.urlTemplate.Execute(&baseURL, newSourcemapTemplateData(app))
+	if err != nil {
+		return nil, "", err
+	}
+
+	mapURL := strings.TrimSuffix(baseURL.String(), "/") + "/" + strings.Join(relativeParts, "/") + ".map"
+	origin := getOrigin(mapURL)
+
+	content, err = store.fetchRemote(mapURL)
+	switch {
+	case errors.Is(err, errSourceMapNotFound):
+		store.metrics.remoteReads.WithLabelValues(origin, "not_found").Inc()
+		level.Debug(store.l).Log("msg", "source map not found in remote location", "url", sourceURL, "source_map_url", mapURL)
+		return nil, "", nil
+	case err != nil:
+		store.metrics.remoteReads.WithLabelValues(origin, "error").Inc()
+		level.Debug(store.l).Log("msg", "failed to read source map from remote location", "url", sourceURL, "source_map_url", mapURL, "err", err)
+		return nil, "", err
+	}
+	store.metrics.remoteReads.WithLabelValues(origin, "ok").Inc()
+	level.Debug(store.l).Log("msg", "source map found in remote location", "url", sourceURL, "source_map_url", mapURL)
+	return content, sourceURL, nil
+}
+
+// fetchRemote reads the file at rawURL from an S3 or GCS bucket or an HTTP
+// server
+func (store *RealSourceMapStore) fetchRemote(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		resp, err := store.httpClient.Get(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return store.readLimited(resp.Body)
+		case http.StatusNotFound, http.StatusForbidden:
+			// Buckets served over HTTP commonly answer 403 for missing objects.
+			return nil, errSourceMapNotFound
+		default:
+			return nil, fmt.Errorf("unexpected status %v", resp.StatusCode)
+		}
+	}
+
+	client, ok := store.bucketClients[u.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unsupported source map location scheme %q, must be one of http, https, s3, or gs", u.Scheme)
+	}
+	object := strings.TrimPrefix(u.Path, "/")
+	if u.Host == "" || object == "" {
+		return nil, fmt.Errorf("source map location %q must name a bucket and an object", rawURL)
+	}
+
+	ctx := context.Background()
+	if store.config.DownloadTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, store.config.DownloadTimeout)
+		defer cancel()
+	}
+	body, err := client.GetObject(ctx, u.Host, object)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return store.readLimited(body)
+}
+
+func (store *RealSourceMapStore) getSourceMapContent(sourceURL string, app App) (content []byte, sourceMapURL string, err error) {
 	//attempt to find in fs
 	for _, fileconf := range store.fileLocations {
-		content, sourceMapURL, err = store.getSourceMapFromFileSystem(sourceURL, release, fileconf)
+		content, sourceMapURL, err = store.getSourceMapFromFileSystem(sourceURL, app, fileconf)
+		if content != nil || err != nil {
+			return content, sourceMapURL, err
+		}
+	}
+
+	//attempt to find in remote locations
+	for _, remoteconf := range store.remoteLocations {
+		content, sourceMapURL, err = store.getSourceMapFromRemote(sourceURL, app, remoteconf)
 		if content != nil || err != nil {
 			return content, sourceMapURL, err
 		}
@@ -258,32 +430,32 @@ func (store *RealSourceMapStore) getSourceMapContent(sourceURL string, release s
 }
 
 // GetSourceMap returns sourcemap for a given source url
-func (store *RealSourceMapStore) GetSourceMap(sourceURL string, release string) (*SourceMap, error) {
+func (store *RealSourceMapStore) GetSourceMap(sourceURL string, app App) (*SourceMap, error) {
 	store.Lock()
 	defer store.Unlock()
 
-	cacheKey := fmt.Sprintf("%s__%s", sourceURL, release)
+	cacheKey := fmt.Sprintf("%s__%s__%s", sourceURL, app.Name, app.Release)
 
-	if smap, ok := store.cache[cacheKey]; ok {
+	if smap, ok := store.cache.Get(cacheKey); ok {
 		return smap, nil
 	}
-	content, sourceMapURL, err := store.getSourceMapContent(sourceURL, release)
+	content, sourceMapURL, err := store.getSourceMapContent(sourceURL, app)
 	if err != nil || content == nil {
-		store.cache[cacheKey] = nil
+		store.cache.Add(cacheKey, nil)
 		return nil, err
 	}
 	if content != nil {
 		consumer, err := sourcemap.Parse(sourceMapURL, content)
 		if err != nil {
-			store.cache[cacheKey] = nil
-			level.Debug(store.l).Log("msg", "failed to parse source map", "url", sourceMapURL, "release", release, "err", err)
+			store.cache.Add(cacheKey, nil)
+			level.Debug(store.l).Log("msg", "failed to parse source map", "url", sourceMapURL, "app", app.Name, "release", app.Release, "err", err)
 			return nil, err
 		}
-		level.Info(store.l).Log("msg", "successfully parsed source map", "url", sourceMapURL, "release", release)
+		level.Info(store.l).Log("msg", "successfully parsed source map", "url", sourceMapURL, "app", app.Name, "release", app.Release)
 		smap := &SourceMap{
 			consumer: consumer,
 		}
-		store.cache[cacheKey] = smap
+		store.cache.Add(cacheKey, smap)
 		store.metrics.cacheSize.WithLabelValues(getOrigin(sourceURL)).Inc()
 		return smap, nil
 	}
@@ -291,8 +463,8 @@ func (store *RealSourceMapStore) GetSourceMap(sourceURL string, release string)
 }
 
 // ResolveSourceLocation resolves minified source location to original source location
-func ResolveSourceLocation(store SourceMapStore, frame *Frame, release string) (*Frame, error) {
-	smap, err := store.GetSourceMap(frame.Filename, release)
+func ResolveSourceLocation(store SourceMapStore, frame *Frame, app App) (*Frame, error) {
+	smap, err := store.GetSourceMap(frame.Filename, app)
 	if err != nil {
 		return nil, err
 	}
@@ -318,14 +490,14 @@ func ResolveSourceLocation(store SourceMapStore, frame *Frame, release string) (
 }
 
 // TransformException will attempt to resolve all minified source locations in the stacktrace with original source locations
-func TransformException(store SourceMapStore, log log.Logger, ex *Exception, release string) *Exception {
+func TransformException(store SourceMapStore, log log.Logger, ex *Exception, app App) *Exception {
 	if ex.Stacktrace == nil {
 		return ex
 	}
 	frames := []Frame{}
 
 	for _, frame := range ex.Stacktrace.Frames {
-		mappedFrame, err := ResolveSourceLocation(store, &frame, release)
+		mappedFrame, err := ResolveSourceLocation(store, &frame, app)
 		if err != nil {
 			level.Error(log).Log("msg", "Error resolving stack trace frame source location", "err", err)
 			frames = append(frames, frame)
@@ -0,0 +1,94 @@
+package app_agent_receiver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	aws_config "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3_types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"golang.org/x/oauth2/google"
+)
+
+// errSourceMapNotFound is returned when a remote location doesn't hold the
+// requested source map
+var errSourceMapNotFound = errors.New("source map not found")
+
+// bucketClient reads objects from a bucket
+type bucketClient interface {
+	GetObject(ctx context.Context, bucket, object string) (io.ReadCloser, error)
+}
+
+// s3BucketClient reads objects from S3 using the default AWS credentials chain
+type s3BucketClient struct {
+	once   sync.Once
+	client *s3.Client
+	err    error
+}
+
+func (c *s3BucketClient) GetObject(ctx context.Context, bucket, object string) (io.ReadCloser, error) {
+	c.once.Do(func() {
+		var cfg aws.Config
+		cfg, c.err = aws_config.LoadDefaultConfig(context.Background())
+		c.client = s3.NewFromConfig(cfg)
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(object),
+	})
+	var noSuchKey *s3_types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, errSourceMapNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// gcsBucketClient reads objects from Google Cloud Storage using the
+// application default credentials
+type gcsBucketClient struct {
+	once   sync.Once
+	client *http.Client
+	err    error
+}
+
+func (c *gcsBucketClient) GetObject(ctx context.Context, bucket, object string) (io.ReadCloser, error) {
+	c.once.Do(func() {
+		// The client outlives ctx, which only bounds the current read.
+		c.client, c.err = google.DefaultClient(context.Background(), "https://www.googleapis.com/auth/devstorage.read_only")
+	})
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	objectURL := fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o/%s?alt=media", url.PathEscape(bucket), url.PathEscape(object))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, errSourceMapNotFound
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %v", resp.StatusCode)
+	}
+}
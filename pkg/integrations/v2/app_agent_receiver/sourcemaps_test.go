@@ -2,6 +2,7 @@ package app_agent_receiver
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"io/fs"
@@ -105,7 +106,7 @@ func Test_RealSourceMapStore_DownloadSuccess(t *testing.T) {
 
 	exception := mockException()
 
-	transformed := TransformException(sourceMapStore, logger, exception, "123")
+	transformed := TransformException(sourceMapStore, logger, exception, App{Release: "123"})
 
 	require.Equal(t, []string{"http://localhost:1234/foo.js", "http://localhost:1234/foo.js.map"}, httpClient.requests)
 
@@ -157,7 +158,7 @@ func Test_RealSourceMapStore_DownloadError(t *testing.T) {
 
 	exception := mockException()
 
-	transformed := TransformException(sourceMapStore, logger, exception, "123")
+	transformed := TransformException(sourceMapStore, logger, exception, App{Release: "123"})
 
 	require.Equal(t, []string{"http://localhost:1234/foo.js"}, httpClient.requests)
 	require.Equal(t, exception, transformed)
@@ -202,7 +203,7 @@ func Test_RealSourceMapStore_DownloadHTTPOriginFiltering(t *testing.T) {
 		},
 	}
 
-	transformed := TransformException(sourceMapStore, logger, exception, "123")
+	transformed := TransformException(sourceMapStore, logger, exception, App{Release: "123"})
 
 	require.Equal(t, []string{"http://bar.com/foo.js", "http://bar.com/foo.js.map"}, httpClient.requests)
 
@@ -287,7 +288,7 @@ func Test_RealSourceMapStore_ReadFromFileSystem(t *testing.T) {
 		},
 	}
 
-	transformed := TransformException(sourceMapStore, logger, exception, "123")
+	transformed := TransformException(sourceMapStore, logger, exception, App{Release: "123"})
 
 	require.Equal(t, []string{
 		filepath.FromSlash("/var/build/latest/foo.js.map"),
@@ -386,7 +387,7 @@ func Test_RealSourceMapStore_ReadFromFileSystemAndDownload(t *testing.T) {
 		},
 	}
 
-	transformed := TransformException(sourceMapStore, logger, exception, "123")
+	transformed := TransformException(sourceMapStore, logger, exception, App{Release: "123"})
 
 	require.Equal(t, []string{filepath.FromSlash("/var/build/latest/foo.js.map")}, fileService.stats)
 	require.Equal(t, []string{filepath.FromSlash("/var/build/latest/foo.js.map")}, fileService.reads)
@@ -444,7 +445,7 @@ func Test_RealSourceMapStore_FilepathSanitized(t *testing.T) {
 		},
 	}
 
-	transformed := TransformException(sourceMapStore, logger, exception, "123")
+	transformed := TransformException(sourceMapStore, logger, exception, App{Release: "123"})
 
 	require.Equal(t, []string{
 		filepath.FromSlash("/var/build/latest/etc/passwd.map"),
@@ -484,7 +485,7 @@ func Test_RealSourceMapStore_FilepathQueryParamsOmitted(t *testing.T) {
 		},
 	}
 
-	transformed := TransformException(sourceMapStore, logger, exception, "123")
+	transformed := TransformException(sourceMapStore, logger, exception, App{Release: "123"})
 
 	require.Equal(t, []string{
 		filepath.FromSlash("/var/build/latest/static/foo.js.map"),
@@ -493,3 +494,170 @@ func Test_RealSourceMapStore_FilepathQueryParamsOmitted(t *testing.T) {
 
 	require.Equal(t, *exception, *transformed)
 }
+
+type mockBucketClient struct {
+	objects  map[string][]byte
+	requests []string
+}
+
+func (c *mockBucketClient) GetObject(_ context.Context, bucket, object string) (io.ReadCloser, error) {
+	c.requests = append(c.requests, bucket+"/"+object)
+	content, ok := c.objects[bucket+"/"+object]
+	if !ok {
+		return nil, errSourceMapNotFound
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func Test_RealSourceMapStore_ReadFromRemote(t *testing.T) {
+	conf := SourceMapConfig{
+		Remote: []SourceMapRemoteLocation{
+			{
+				MinifiedPathPrefix: "http://foo.com/",
+				URL:                "s3://sourcemaps/{{ .App }}/{{ .Release }}",
+			},
+			{
+				MinifiedPathPrefix: "http://bar.com/",
+				URL:                "https://sourcemaps.example.com/{{ .App }}/{{ .Release }}/",
+			},
+		},
+	}
+
+	bucketClient := &mockBucketClient{
+		objects: map[string][]byte{
+			"sourcemaps/frontend/123/foo.js.map": loadTestData(t, "foo.js.map"),
+		},
+	}
+	httpClient := &mockHTTPClient{
+		responses: []struct {
+			*http.Response
+			error
+		}{
+			{newResponseFromTestData(t, "foo.js.map"), nil},
+		},
+	}
+
+	logger := log.NewNopLogger()
+
+	sourceMapStore := NewSourceMapStore(logger, conf, prometheus.NewRegistry(), httpClient, &mockFileService{}).(*RealSourceMapStore)
+	sourceMapStore.bucketClients["s3"] = bucketClient
+
+	exception := &Exception{
+		Stacktrace: &Stacktrace{
+			Frames: []Frame{
+				{
+					Colno:    6,
+					Filename: "http://foo.com/foo.js",
+					Function: "eval",
+					Lineno:   5,
+				},
+				{
+					Colno:    6,
+					Filename: "http://foo.com/bar.js",
+					Function: "eval",
+					Lineno:   5,
+				},
+				{
+					Colno:    5,
+					Filename: "http://bar.com/foo.js",
+					Function: "callUndefined",
+					Lineno:   6,
+				},
+			},
+		},
+	}
+
+	transformed := TransformException(sourceMapStore, logger, exception, App{Name: "../frontend", Release: "123"})
+
+	require.Equal(t, []string{
+		"sourcemaps/frontend/123/foo.js.map",
+		"sourcemaps/frontend/123/bar.js.map",
+	}, bucketClient.requests)
+	require.Equal(t, []string{"https://sourcemaps.example.com/frontend/123/foo.js.map"}, httpClient.requests)
+
+	expected := &Exception{
+		Stacktrace: &Stacktrace{
+			Frames: []Frame{
+				{
+					Colno:    37,
+					Filename: "/__parcel_source_root/demo/src/actions.ts",
+					Function: "?",
+					Lineno:   6,
+				},
+				{
+					Colno:    6,
+					Filename: "http://foo.com/bar.js",
+					Function: "eval",
+					Lineno:   5,
+				},
+				{
+					Colno:    2,
+					Filename: "/__parcel_source_root/demo/src/actions.ts",
+					Function: "?",
+					Lineno:   7,
+				},
+			},
+		},
+	}
+
+	require.Equal(t, *expected, *transformed)
+}
+
+func Test_RealSourceMapStore_DownloadMaxSize(t *testing.T) {
+	conf := SourceMapConfig{
+		Remote: []SourceMapRemoteLocation{
+			{
+				MinifiedPathPrefix: "http://foo.com/",
+				URL:                "s3://sourcemaps",
+			},
+		},
+		DownloadMaxSize: 16,
+	}
+
+	bucketClient := &mockBucketClient{
+		objects: map[string][]byte{
+			"sourcemaps/foo.js.map": loadTestData(t, "foo.js.map"),
+		},
+	}
+
+	sourceMapStore := NewSourceMapStore(log.NewNopLogger(), conf, prometheus.NewRegistry(), &mockHTTPClient{}, &mockFileService{}).(*RealSourceMapStore)
+	sourceMapStore.bucketClients["s3"] = bucketClient
+
+	_, err := sourceMapStore.GetSourceMap("http://foo.com/foo.js", App{})
+	require.EqualError(t, err, "file is larger than the download max size of 16 bytes")
+}
+
+func Test_RealSourceMapStore_CacheEviction(t *testing.T) {
+	conf := SourceMapConfig{
+		Remote: []SourceMapRemoteLocation{
+			{
+				MinifiedPathPrefix: "http://foo.com/",
+				URL:                "s3://sourcemaps",
+			},
+		},
+		CacheSize: 1,
+	}
+
+	bucketClient := &mockBucketClient{
+		objects: map[string][]byte{
+			"sourcemaps/foo.js.map": loadTestData(t, "foo.js.map"),
+			"sourcemaps/bar.js.map": loadTestData(t, "foo.js.map"),
+		},
+	}
+
+	sourceMapStore := NewSourceMapStore(log.NewNopLogger(), conf, prometheus.NewRegistry(), &mockHTTPClient{}, &mockFileService{}).(*RealSourceMapStore)
+	sourceMapStore.bucketClients["s3"] = bucketClient
+
+	for _, sourceURL := range []string{"http://foo.com/foo.js", "http://foo.com/foo.js", "http://foo.com/bar.js", "http://foo.com/foo.js"} {
+		smap, err := sourceMapStore.GetSourceMap(sourceURL, App{})
+		require.NoError(t, err)
+		require.NotNil(t, smap)
+	}
+
+	// foo.js.map is read again after it's evicted by bar.js.map.
+	require.Equal(t, []string{
+		"sourcemaps/foo.js.map",
+		"sourcemaps/bar.js.map",
+		"sourcemaps/foo.js.map",
+	}, bucketClient.requests)
+}
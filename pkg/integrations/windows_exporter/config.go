@@ -1,6 +1,9 @@
 package windows_exporter //nolint:golint
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/go-kit/log"
 	"github.com/grafana/agent/pkg/integrations"
 	integrations_v2 "github.com/grafana/agent/pkg/integrations/v2"
@@ -35,6 +38,9 @@ var DefaultConfig = Config{
 		Include:   "",
 		Exclude:   "",
 	},
+	MSCluster: MSClusterConfig{
+		EnabledList: "cluster,network,node,resource,resourcegroup",
+	},
 	MSMQ: MSMQConfig{
 		Where: "",
 	},
@@ -91,6 +97,7 @@ type Config struct {
 	Process       ProcessConfig       `yaml:"process,omitempty"`
 	Network       NetworkConfig       `yaml:"network,omitempty"`
 	MSSQL         MSSQLConfig         `yaml:"mssql,omitempty"`
+	MSCluster     MSClusterConfig     `yaml:"mscluster,omitempty"`
 	MSMQ          MSMQConfig          `yaml:"msmq,omitempty"`
 	LogicalDisk   LogicalDiskConfig   `yaml:"logical_disk,omitempty"`
 	ScheduledTask ScheduledTaskConfig `yaml:"scheduled_task,omitempty"`
@@ -119,6 +126,35 @@ func (c *Config) NewIntegration(l log.Logger) (integrations.Integration, error)
 	return New(l, c)
 }
 
+// enabledCollectors returns the sorted, unique names of the collectors to
+// build. windows_exporter splits the mscluster collector into one collector
+// per WMI class, so "mscluster" expands to the mscluster_<name> collectors of
+// MSCluster.EnabledList.
+func (c *Config) enabledCollectors() []string {
+	unique := map[string]struct{}{}
+	for _, s := range strings.Split(c.EnabledCollectors, ",") {
+		s = strings.TrimSpace(s)
+		switch s {
+		case "":
+			continue
+		case "mscluster":
+			for _, child := range strings.Split(c.MSCluster.EnabledList, ",") {
+				if child = strings.TrimSpace(child); child != "" {
+					unique["mscluster_"+child] = struct{}{}
+				}
+			}
+		default:
+			unique[s] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(unique))
+	for s := range unique {
+		result = append(result, s)
+	}
+	sort.Strings(result)
+	return result
+}
+
 // DfsrConfig handles settings for the windows_exporter dfsr collector
 type DfsrConfig struct {
 	SourcesEnabled string `yaml:"sources_enabled,omitempty"`
@@ -181,6 +217,12 @@ type MSSQLConfig struct {
 	EnabledClasses string `yaml:"enabled_classes,omitempty"`
 }
 
+// MSClusterConfig handles settings for the windows_exporter mscluster
+// collectors.
+type MSClusterConfig struct {
+	EnabledList string `yaml:"enabled_list,omitempty"`
+}
+
 // MSMQConfig handles settings for the windows_exporter MSMQ collector
 type MSMQConfig struct {
 	Where string `yaml:"where_clause,omitempty"`
@@ -0,0 +1,54 @@
+package windows_exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConfig_EnabledCollectors(t *testing.T) {
+	tt := []struct {
+		name   string
+		cfg    string
+		expect []string
+	}{
+		{
+			name:   "defaults",
+			expect: []string{"cpu", "cs", "logical_disk", "net", "os", "service", "system"},
+		},
+		{
+			name:   "duplicates and whitespace",
+			cfg:    `enabled_collectors: "os, cpu,,os"`,
+			expect: []string{"cpu", "os"},
+		},
+		{
+			name: "mscluster defaults",
+			cfg:  `enabled_collectors: "mscluster,dhcp"`,
+			expect: []string{
+				"dhcp",
+				"mscluster_cluster",
+				"mscluster_network",
+				"mscluster_node",
+				"mscluster_resource",
+				"mscluster_resourcegroup",
+			},
+		},
+		{
+			name: "mscluster enabled list",
+			cfg: `
+enabled_collectors: "mscluster,terminal_services"
+mscluster:
+  enabled_list: "node, resourcegroup"
+`,
+			expect: []string{"mscluster_node", "mscluster_resourcegroup", "terminal_services"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c := DefaultConfig
+			require.NoError(t, yaml.Unmarshal([]byte(tc.cfg), &c))
+			require.Equal(t, tc.expect, c.enabledCollectors())
+		})
+	}
+}
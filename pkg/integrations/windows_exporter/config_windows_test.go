@@ -47,6 +47,17 @@ mssql: {}
 	require.True(t, *total3["mssql"].Settings.(*collector.MSSqlSettings).ClassesEnabled == total3mssql)
 }
 
+func TestMSClusterConfig(t *testing.T) {
+	built, _ := testConfig(t, `
+enabled_collectors: "mscluster,os"
+mscluster:
+  enabled_list: "node,resource"
+`)
+	require.Len(t, built, 3)
+	require.Contains(t, built, "mscluster_node")
+	require.Contains(t, built, "mscluster_resource")
+}
+
 func testConfig(t *testing.T, cfg string) (map[string]collector.Collector, map[string]*collector.Initializer) {
 	c := DefaultConfig
 	err := yaml.Unmarshal([]byte(cfg), &c)
@@ -61,7 +72,7 @@ func testConfig(t *testing.T, cfg string) (map[string]collector.Collector, map[s
 	// Register the performance monitors
 	collector.RegisterCollectors(collectors)
 	// Filter down to the enabled collectors
-	enabledCollectorNames := enabledCollectors(c.EnabledCollectors)
+	enabledCollectorNames := c.enabledCollectors()
 	// Finally build the collectors that we need to run.
 	builtCollectors, err := buildCollectors(collectors, enabledCollectorNames)
 	require.NoError(t, err)
@@ -28,7 +28,7 @@ func New(logger log.Logger, c *Config) (integrations.Integration, error) {
 	// Register the performance monitors
 	collector.RegisterCollectors(collectors)
 	// Filter down to the enabled collectors
-	enabledCollectorNames := enabledCollectors(c.EnabledCollectors)
+	enabledCollectorNames := c.enabledCollectors()
 	// Finally build the collectors that we need to run.
 	builtCollectors, err := buildCollectors(collectors, enabledCollectorNames)
 	if err != nil {
@@ -49,22 +49,6 @@ func New(logger log.Logger, c *Config) (integrations.Integration, error) {
 	)), nil
 }
 
-func enabledCollectors(input string) []string {
-	separated := strings.Split(input, ",")
-	unique := map[string]struct{}{}
-	for _, s := range separated {
-		s = strings.TrimSpace(s)
-		if s != "" {
-			unique[s] = struct{}{}
-		}
-	}
-	result := make([]string, 0, len(unique))
-	for s := range unique {
-		result = append(result, s)
-	}
-	return result
-}
-
 func buildCollectors(colls map[string]*collector.Initializer, enabled []string) (map[string]collector.Collector, error) {
 	collectors := map[string]collector.Collector{}
 
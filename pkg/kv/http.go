@@ -0,0 +1,108 @@
+package kv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"unicode/utf8"
+)
+
+// HTTPPrefix is the path prefix of the HTTP API of the Store.
+const HTTPPrefix = "/api/v0/kv/"
+
+// BucketInfo describes a bucket of the Store.
+type BucketInfo struct {
+	Name    string `json:"name"`
+	Entries int    `json:"entries"`
+}
+
+// Entry is an entry of a bucket. Values which aren't valid UTF-8 are base64
+// encoded.
+type Entry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Base64 bool   `json:"base64,omitempty"`
+}
+
+// Handler returns the path prefix and the handler of the HTTP API used to
+// inspect and reset entries of the Store:
+//
+//   - GET buckets lists all buckets.
+//   - GET entries?bucket=<name> lists the entries of a bucket.
+//   - DELETE entries?bucket=<name>&key=<key> deletes an entry.
+//   - DELETE entries?bucket=<name> deletes all entries of a bucket.
+func (s *Store) Handler() (string, http.Handler) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HTTPPrefix+"buckets", s.handleBuckets)
+	mux.HandleFunc(HTTPPrefix+"entries", s.handleEntries)
+	return HTTPPrefix, mux
+}
+
+func (s *Store) handleBuckets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mut.RLock()
+	infos := make([]BucketInfo, 0, len(s.buckets))
+	for name, entries := range s.buckets {
+		infos = append(infos, BucketInfo{Name: name, Entries: len(entries)})
+	}
+	s.mut.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	writeJSON(w, infos)
+}
+
+func (s *Store) handleEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	name := query.Get("bucket")
+	if name == "" {
+		http.Error(w, "'bucket' parameter must be specified", http.StatusBadRequest)
+		return
+	}
+	b := s.Bucket(name)
+
+	switch r.Method {
+	case http.MethodGet:
+		entries := b.Entries()
+		res := make([]Entry, 0, len(entries))
+		for k, v := range entries {
+			e := Entry{Key: k, Value: string(v)}
+			if !utf8.Valid(v) {
+				e.Value, e.Base64 = base64.StdEncoding.EncodeToString(v), true
+			}
+			res = append(res, e)
+		}
+		sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+		writeJSON(w, res)
+
+	case http.MethodDelete:
+		var err error
+		if query.Has("key") {
+			err = b.Delete(query.Get("key"))
+		} else {
+			err = b.Reset()
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	bb, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(bb)
+}
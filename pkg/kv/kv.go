@@ -0,0 +1,202 @@
+// Package kv implements a persistent key-value store which components use to
+// keep small amounts of state, such as cursors and checkpoints, across
+// restarts of the agent.
+//
+// Entries are grouped in buckets, and every component is given the bucket
+// named after its ID. The store is kept in memory and written to a single file
+// in the data directory whenever it changes, so it's only meant for state
+// which changes at most a few times per second.
+package kv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileName is the name of the file the store is persisted to inside of the
+// data directory.
+const FileName = "kv.json"
+
+// Store is a persistent key-value store.
+type Store struct {
+	path string // Empty for stores which aren't persisted.
+
+	mut     sync.RWMutex
+	buckets map[string]map[string][]byte // Bucket name -> key -> value.
+}
+
+// New opens the Store persisted in dataPath, creating the directory if it
+// doesn't exist. If dataPath is empty, the Store is only kept in memory.
+func New(dataPath string) (*Store, error) {
+	s := &Store{buckets: make(map[string]map[string][]byte)}
+	if dataPath == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(dataPath, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	s.path = filepath.Join(dataPath, FileName)
+
+	bb, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read key-value store: %w", err)
+	}
+	if err := json.Unmarshal(bb, &s.buckets); err != nil {
+		return nil, fmt.Errorf("failed to parse key-value store %s: %w", s.path, err)
+	}
+	if s.buckets == nil {
+		s.buckets = make(map[string]map[string][]byte)
+	}
+	return s, nil
+}
+
+// Bucket returns the bucket called name. Buckets are created on their first
+// write.
+func (s *Store) Bucket(name string) *Bucket {
+	return &Bucket{store: s, name: name}
+}
+
+// Buckets returns the sorted names of all buckets with at least one entry.
+func (s *Store) Buckets() []string {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	names := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// update runs f with the entries of the bucket called name and persists the
+// store afterwards. Changes are rolled back if persisting the store fails.
+func (s *Store) update(name string, f func(entries map[string][]byte)) error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	old := s.buckets[name]
+	entries := make(map[string][]byte, len(old)+1)
+	for k, v := range old {
+		entries[k] = v
+	}
+	f(entries)
+
+	if len(entries) == 0 {
+		delete(s.buckets, name)
+	} else {
+		s.buckets[name] = entries
+	}
+
+	if err := s.persist(); err != nil {
+		if old == nil {
+			delete(s.buckets, name)
+		} else {
+			s.buckets[name] = old
+		}
+		return err
+	}
+	return nil
+}
+
+// persist atomically writes the store to its file. s.mut must be held.
+func (s *Store) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	bb, err := json.Marshal(s.buckets)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), FileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write key-value store: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(bb); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write key-value store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write key-value store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write key-value store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write key-value store: %w", err)
+	}
+	return nil
+}
+
+// Bucket is a namespace of entries within a Store. A Bucket is safe for
+// concurrent use.
+type Bucket struct {
+	store *Store
+	name  string
+}
+
+// Name returns the name of the bucket.
+func (b *Bucket) Name() string { return b.name }
+
+// Get returns the value of key, and whether key was found.
+func (b *Bucket) Get(key string) ([]byte, bool) {
+	b.store.mut.RLock()
+	defer b.store.mut.RUnlock()
+
+	v, ok := b.store.buckets[b.name][key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), v...), true
+}
+
+// Put sets the value of key and persists it.
+func (b *Bucket) Put(key string, value []byte) error {
+	value = append([]byte(nil), value...)
+	return b.store.update(b.name, func(entries map[string][]byte) {
+		entries[key] = value
+	})
+}
+
+// Delete removes key from the bucket. Deleting a key which doesn't exist is
+// not an error.
+func (b *Bucket) Delete(key string) error {
+	return b.store.update(b.name, func(entries map[string][]byte) {
+		delete(entries, key)
+	})
+}
+
+// Reset removes all entries from the bucket.
+func (b *Bucket) Reset() error {
+	return b.store.update(b.name, func(entries map[string][]byte) {
+		for k := range entries {
+			delete(entries, k)
+		}
+	})
+}
+
+// Entries returns a copy of all entries of the bucket.
+func (b *Bucket) Entries() map[string][]byte {
+	b.store.mut.RLock()
+	defer b.store.mut.RUnlock()
+
+	entries := b.store.buckets[b.name]
+	res := make(map[string][]byte, len(entries))
+	for k, v := range entries {
+		res[k] = append([]byte(nil), v...)
+	}
+	return res
+}
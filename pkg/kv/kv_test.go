@@ -0,0 +1,139 @@
+package kv
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := New(dir)
+	require.NoError(t, err)
+
+	b := s.Bucket("loki.source.cloudflare.default")
+	_, ok := b.Get("cursor")
+	require.False(t, ok)
+
+	require.NoError(t, b.Put("cursor", []byte("1684152000")))
+	require.NoError(t, b.Put("zone", []byte("example.com")))
+	require.NoError(t, s.Bucket("other").Put("key", []byte("value")))
+	require.Equal(t, []string{"loki.source.cloudflare.default", "other"}, s.Buckets())
+
+	// Entries survive reopening the store.
+	s, err = New(dir)
+	require.NoError(t, err)
+	b = s.Bucket("loki.source.cloudflare.default")
+	v, ok := b.Get("cursor")
+	require.True(t, ok)
+	require.Equal(t, []byte("1684152000"), v)
+
+	require.NoError(t, b.Delete("cursor"))
+	require.NoError(t, b.Delete("missing"))
+	require.Equal(t, map[string][]byte{"zone": []byte("example.com")}, b.Entries())
+
+	// Buckets without entries are removed.
+	require.NoError(t, b.Reset())
+	require.Equal(t, []string{"other"}, s.Buckets())
+
+	s, err = New(dir)
+	require.NoError(t, err)
+	require.Equal(t, []string{"other"}, s.Buckets())
+
+	// Temporary files are cleaned up.
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, FileName, files[0].Name())
+}
+
+func TestStore_Copies(t *testing.T) {
+	s, err := New("")
+	require.NoError(t, err)
+	b := s.Bucket("test")
+
+	value := []byte("value")
+	require.NoError(t, b.Put("key", value))
+	value[0] = 'V'
+
+	v, _ := b.Get("key")
+	require.Equal(t, []byte("value"), v)
+	v[0] = 'V'
+
+	v, _ = b.Get("key")
+	require.Equal(t, []byte("value"), v)
+}
+
+func TestStore_Corrupt(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, FileName), []byte("{"), 0600))
+
+	_, err := New(dir)
+	require.ErrorContains(t, err, "failed to parse key-value store")
+}
+
+func TestStore_PersistFailure(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir)
+	require.NoError(t, err)
+	b := s.Bucket("test")
+	require.NoError(t, b.Put("key", []byte("old")))
+
+	// Changes are rolled back if the store can't be written.
+	require.NoError(t, os.RemoveAll(dir))
+	require.Error(t, b.Put("key", []byte("new")))
+	require.Error(t, b.Put("other", []byte("new")))
+
+	v, _ := b.Get("key")
+	require.Equal(t, []byte("old"), v)
+	_, ok := b.Get("other")
+	require.False(t, ok)
+}
+
+func TestHandler(t *testing.T) {
+	s, err := New(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, s.Bucket("a").Put("cursor", []byte("42")))
+	require.NoError(t, s.Bucket("a").Put("binary", []byte{0xff, 0x00}))
+	require.NoError(t, s.Bucket("b").Put("key", []byte("value")))
+
+	prefix, h := s.Handler()
+	require.Equal(t, HTTPPrefix, prefix)
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, prefix+target, nil))
+		return rec
+	}
+
+	rec := do(http.MethodGet, "buckets")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var buckets []BucketInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &buckets))
+	require.Equal(t, []BucketInfo{{Name: "a", Entries: 2}, {Name: "b", Entries: 1}}, buckets)
+
+	rec = do(http.MethodGet, "entries?bucket=a")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var entries []Entry
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	require.Equal(t, []Entry{
+		{Key: "binary", Value: "/wA=", Base64: true},
+		{Key: "cursor", Value: "42"},
+	}, entries)
+
+	require.Equal(t, http.StatusBadRequest, do(http.MethodGet, "entries").Code)
+	require.Equal(t, http.StatusMethodNotAllowed, do(http.MethodPost, "entries?bucket=a").Code)
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "entries?bucket=a&key=cursor").Code)
+	_, ok := s.Bucket("a").Get("cursor")
+	require.False(t, ok)
+
+	require.Equal(t, http.StatusNoContent, do(http.MethodDelete, "entries?bucket=b").Code)
+	require.Equal(t, []string{"a"}, s.Buckets())
+}
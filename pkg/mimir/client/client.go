@@ -36,6 +36,10 @@ type Config struct {
 	Address          string
 	UseLegacyRoutes  bool
 	HTTPClientConfig config.HTTPClientConfig
+
+	// APIPath overrides the path of the ruler API, for rulers which serve the
+	// same API as Mimir under a different path, such as the Loki ruler.
+	APIPath string
 }
 
 type Interface interface {
@@ -69,6 +73,9 @@ func New(logger log.Logger, cfg Config, timingHistogram *prometheus.HistogramVec
 	if cfg.UseLegacyRoutes {
 		path = legacyAPIPath
 	}
+	if cfg.APIPath != "" {
+		path = cfg.APIPath
+	}
 
 	collector := instrument.NewHistogramCollector(timingHistogram)
 	timedClient := weaveworksClient.NewTimedClient(client, collector)
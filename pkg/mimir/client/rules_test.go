@@ -73,3 +73,24 @@ func TestMimirClient_X(t *testing.T) {
 		})
 	}
 }
+
+func TestMimirClient_APIPath(t *testing.T) {
+	requestCh := make(chan *http.Request, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCh <- r
+		fmt.Fprintln(w, "hello")
+	}))
+	defer ts.Close()
+
+	client, err := New(log.NewNopLogger(), Config{
+		Address:         ts.URL,
+		UseLegacyRoutes: true,
+		APIPath:         "/loki/api/v1/rules",
+	}, prometheus.NewHistogramVec(prometheus.HistogramOpts{}, instrument.HistogramCollectorBuckets))
+	require.NoError(t, err)
+
+	require.NoError(t, client.DeleteRuleGroup(context.Background(), "my-namespace", "my-name"))
+	req := <-requestCh
+	require.Equal(t, "/loki/api/v1/rules/my-namespace/my-name", req.URL.EscapedPath())
+}
@@ -24,6 +24,8 @@ func init() {
 	SchemeBuilder.Register(
 		&GrafanaAgent{},
 		&GrafanaAgentList{},
+		&GrafanaAgentFlow{},
+		&GrafanaAgentFlowList{},
 		&MetricsInstance{},
 		&MetricsInstanceList{},
 		&LogsInstance{},
@@ -0,0 +1,183 @@
+package v1alpha1
+
+import (
+	prom_v1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path="grafanaagentflows"
+// +kubebuilder:resource:singular="grafanaagentflow"
+// +kubebuilder:resource:categories="agent-operator"
+
+// GrafanaAgentFlow defines a deployment of Grafana Agent running in Flow mode.
+// Unlike GrafanaAgent, the config of the deployed agents isn't generated from
+// other resources; it's read from a ConfigMap, a Secret, or a remote config
+// bundle.
+type GrafanaAgentFlow struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec holds the specification of the desired behavior for the Grafana
+	// Agent Flow deployment.
+	Spec GrafanaAgentFlowSpec `json:"spec,omitempty"`
+
+	// Status holds the most recently observed status of the deployment.
+	Status GrafanaAgentFlowStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// GrafanaAgentFlowList is a list of GrafanaAgentFlows.
+type GrafanaAgentFlowList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	// Items is the list of GrafanaAgentFlows.
+	Items []*GrafanaAgentFlow `json:"items"`
+}
+
+// FlowControllerType is the kind of controller which runs Flow-mode agents.
+type FlowControllerType string
+
+// Supported values for FlowControllerType.
+const (
+	// FlowControllerStatefulSet runs the agents as a StatefulSet.
+	FlowControllerStatefulSet FlowControllerType = "statefulset"
+	// FlowControllerDaemonSet runs an agent on every node as a DaemonSet.
+	FlowControllerDaemonSet FlowControllerType = "daemonset"
+)
+
+// GrafanaAgentFlowSpec is a specification of the desired behavior of a Grafana
+// Agent Flow deployment.
+type GrafanaAgentFlowSpec struct {
+	// Version of Grafana Agent to be deployed.
+	Version string `json:"version,omitempty"`
+	// Image, when specified, overrides the image used to run Agent. Specify
+	// the image along with a tag.
+	Image *string `json:"image,omitempty"`
+	// ImagePullSecrets holds an optional list of references to Secrets within
+	// the same namespace used for pulling the Grafana Agent image from
+	// registries.
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// Paused prevents actions except for deletion to be performed on the
+	// underlying managed objects.
+	Paused bool `json:"paused,omitempty"`
+
+	// Controller is the kind of controller which runs the agents, either
+	// statefulset or daemonset. Defaults to statefulset.
+	// +kubebuilder:validation:Enum=statefulset;daemonset
+	// +kubebuilder:default=statefulset
+	Controller FlowControllerType `json:"controller,omitempty"`
+	// Replicas is the number of agents to run when controller is statefulset.
+	// Defaults to 1.
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Config is the source of the River config of the agents.
+	Config FlowConfigSource `json:"config"`
+
+	// disableClustering runs the agents without clustering. Agents are
+	// clustered by default, so that components which support clustering
+	// distribute their work between the agents.
+	// +kubebuilder:default=false
+	DisableClustering bool `json:"disableClustering,omitempty"`
+	// disableReporting disables reporting of enabled components to Grafana.
+	// +kubebuilder:default=false
+	DisableReporting bool `json:"disableReporting,omitempty"`
+
+	// PodMetadata configures Labels and Annotations which are propagated to
+	// created Grafana Agent pods.
+	PodMetadata *prom_v1.EmbeddedObjectMetadata `json:"podMetadata,omitempty"`
+	// Volumes allows configuration of additional volumes on the generated
+	// pods.
+	Volumes []v1.Volume `json:"volumes,omitempty"`
+	// VolumeMounts lets you configure additional VolumeMounts in the Grafana
+	// Agent container.
+	VolumeMounts []v1.VolumeMount `json:"volumeMounts,omitempty"`
+	// Env lets you set additional environment variables in the Grafana Agent
+	// container, such as to be read by the sys.env function of the config.
+	Env []v1.EnvVar `json:"env,omitempty"`
+	// Resources holds requests and limits for individual pods.
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+	// NodeSelector defines which nodes pods should be scheduling on.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// ServiceAccountName is the name of the ServiceAccount to use for running Grafana Agent pods.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Affinity, if specified, controls pod scheduling constraints.
+	Affinity *v1.Affinity `json:"affinity,omitempty"`
+	// Tolerations, if specified, controls the pod's tolerations.
+	Tolerations []v1.Toleration `json:"tolerations,omitempty"`
+	// SecurityContext holds pod-level security attributes and common container
+	// settings. When unspecified, defaults to the default PodSecurityContext.
+	SecurityContext *v1.PodSecurityContext `json:"securityContext,omitempty"`
+	// PriorityClassName is the priority class assigned to pods.
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// FlowConfigSource is the source of the River config of a Grafana Agent Flow
+// deployment. Exactly one of ConfigMap, Secret, or Remote must be set.
+type FlowConfigSource struct {
+	// ConfigMap selects the key of a ConfigMap in the same namespace as the
+	// GrafanaAgentFlow which holds the config. Agents are rolled out again
+	// when the config changes.
+	ConfigMap *v1.ConfigMapKeySelector `json:"configMap,omitempty"`
+	// Secret selects the key of a Secret in the same namespace as the
+	// GrafanaAgentFlow which holds the config. Agents are rolled out again
+	// when the config changes.
+	Secret *v1.SecretKeySelector `json:"secret,omitempty"`
+	// Remote polls the config from a remote config bundle. Agents load new
+	// bundles without being rolled out again.
+	Remote *FlowRemoteConfig `json:"remote,omitempty"`
+}
+
+// FlowRemoteConfig configures polling the config of Flow-mode agents from a
+// remote config bundle.
+type FlowRemoteConfig struct {
+	// URL of the config bundle, using the http, https, s3, or gs scheme.
+	URL string `json:"url"`
+	// PollFrequency is how often the config bundle is polled. Defaults to 1m.
+	PollFrequency *metav1.Duration `json:"pollFrequency,omitempty"`
+	// Verify is how config bundles are verified: sha256, signature, or none.
+	// Defaults to sha256.
+	// +kubebuilder:validation:Enum=sha256;signature;none
+	Verify string `json:"verify,omitempty"`
+	// PublicKey selects the key of a Secret in the same namespace as the
+	// GrafanaAgentFlow which holds the PEM Ed25519 public key verifying the
+	// signatures of config bundles. Required when verify is signature.
+	PublicKey *v1.SecretKeySelector `json:"publicKey,omitempty"`
+}
+
+// Types of the conditions of a GrafanaAgentFlow.
+const (
+	// FlowConditionConfigValid is true when the config source of the
+	// deployment was resolved.
+	FlowConditionConfigValid = "ConfigValid"
+	// FlowConditionReconciled is true when the resources of the deployment
+	// were applied.
+	FlowConditionReconciled = "Reconciled"
+	// FlowConditionAvailable is true when every agent of the deployment is
+	// ready.
+	FlowConditionAvailable = "Available"
+)
+
+// GrafanaAgentFlowStatus is the most recently observed status of a Grafana
+// Agent Flow deployment.
+type GrafanaAgentFlowStatus struct {
+	// ObservedGeneration is the generation of the GrafanaAgentFlow last
+	// reconciled by the operator.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// ConfigHash is the hash of the config the agents were last rolled out
+	// with.
+	ConfigHash string `json:"configHash,omitempty"`
+	// Replicas is the number of agents which should be running.
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of agents which are ready.
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Conditions holds the latest observations of the state of the
+	// deployment.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
@@ -14,6 +14,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	controller "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
@@ -114,12 +116,13 @@ type Operator struct {
 
 	kubeletReconciler *lazyReconciler // Unused if kubelet service unconfigured
 	agentReconciler   *lazyReconciler
+	flowReconciler    *lazyReconciler
 }
 
 // New creates a new Operator.
 func New(l log.Logger, c *Config) (*Operator, error) {
 	var (
-		lazyKubeletReconciler, lazyAgentReconciler lazyReconciler
+		lazyKubeletReconciler, lazyAgentReconciler, lazyFlowReconciler lazyReconciler
 	)
 
 	restConfig := c.RestConfig
@@ -216,12 +219,37 @@ func New(l log.Logger, c *Config) (*Operator, error) {
 		config:   c,
 	})
 
+	// GrafanaAgentFlows are reconciled again when the ConfigMap or Secret they
+	// read their config from changes, so that the agents are rolled out with
+	// the new config.
+	flowsForObjectHandler := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, o client.Object) []reconcile.Request {
+		return flowsForObject(ctx, manager.GetClient(), o)
+	})
+
+	err = controller.NewControllerManagedBy(manager).
+		For(&gragent.GrafanaAgentFlow{}).
+		Owns(&apps_v1.StatefulSet{}).
+		Owns(&apps_v1.DaemonSet{}).
+		Owns(&core_v1.Service{}).
+		Watches(&core_v1.ConfigMap{}, flowsForObjectHandler).
+		Watches(&core_v1.Secret{}, flowsForObjectHandler).
+		Complete(&lazyFlowReconciler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GrafanaAgentFlow controller: %w", err)
+	}
+
+	lazyFlowReconciler.Set(&flowReconciler{
+		Client: manager.GetClient(),
+		config: c,
+	})
+
 	return &Operator{
 		log:     l,
 		manager: manager,
 
 		kubeletReconciler: &lazyKubeletReconciler,
 		agentReconciler:   &lazyAgentReconciler,
+		flowReconciler:    &lazyFlowReconciler,
 	}, nil
 }
 
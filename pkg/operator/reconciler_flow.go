@@ -0,0 +1,273 @@
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	gragent "github.com/grafana/agent/pkg/operator/apis/monitoring/v1alpha1"
+	"github.com/grafana/agent/pkg/operator/clientutil"
+	"github.com/grafana/agent/pkg/operator/logutil"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	controller "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// flowReconciler reconciles GrafanaAgentFlow resources.
+type flowReconciler struct {
+	client.Client
+	config *Config
+}
+
+func (r *flowReconciler) Reconcile(ctx context.Context, req controller.Request) (controller.Result, error) {
+	l := logutil.FromContext(ctx)
+	level.Info(l).Log("msg", "reconciling grafana-agent-flow")
+	defer level.Debug(l).Log("msg", "done reconciling grafana-agent-flow")
+
+	var flow gragent.GrafanaAgentFlow
+	if err := r.Get(ctx, req.NamespacedName, &flow); k8s_errors.IsNotFound(err) {
+		level.Debug(l).Log("msg", "detected deleted grafana-agent-flow")
+		return controller.Result{}, nil
+	} else if err != nil {
+		level.Error(l).Log("msg", "unable to get grafana-agent-flow", "err", err)
+		return controller.Result{}, nil
+	}
+
+	if flow.Spec.Paused {
+		return controller.Result{}, nil
+	}
+
+	status := flow.Status.DeepCopy()
+	status.ObservedGeneration = flow.Generation
+
+	result, err := r.reconcileFlow(ctx, l, &flow, status)
+	if err != nil {
+		level.Error(l).Log("msg", "error during reconciling", "err", err)
+	}
+
+	if statusErr := r.updateFlowStatus(ctx, &flow, status); statusErr != nil {
+		level.Error(l).Log("msg", "unable to update grafana-agent-flow status", "err", statusErr)
+		return controller.Result{Requeue: true}, nil
+	}
+	return result, nil
+}
+
+// reconcileFlow applies the resources of flow and records their state in
+// status.
+func (r *flowReconciler) reconcileFlow(ctx context.Context, l log.Logger, flow *gragent.GrafanaAgentFlow, status *gragent.GrafanaAgentFlowStatus) (controller.Result, error) {
+	configHash, err := r.flowConfigHash(ctx, flow)
+	if err != nil {
+		// The config source is watched, so the GrafanaAgentFlow is reconciled
+		// again once it's fixed.
+		setFlowCondition(status, flow, gragent.FlowConditionConfigValid, meta_v1.ConditionFalse, "InvalidConfig", err.Error())
+		setFlowCondition(status, flow, gragent.FlowConditionReconciled, meta_v1.ConditionFalse, "InvalidConfig", "the config source couldn't be resolved")
+		return controller.Result{}, err
+	}
+	setFlowCondition(status, flow, gragent.FlowConditionConfigValid, meta_v1.ConditionTrue, "ConfigResolved", "the config source was resolved")
+
+	replicas, ready, err := r.applyFlowResources(ctx, l, flow, configHash)
+	if err != nil {
+		setFlowCondition(status, flow, gragent.FlowConditionReconciled, meta_v1.ConditionFalse, "ReconcileFailed", err.Error())
+		return controller.Result{Requeue: true}, err
+	}
+	setFlowCondition(status, flow, gragent.FlowConditionReconciled, meta_v1.ConditionTrue, "Reconciled", "the resources of the deployment were applied")
+
+	status.ConfigHash = configHash
+	status.Replicas, status.ReadyReplicas = replicas, ready
+	if ready >= replicas {
+		setFlowCondition(status, flow, gragent.FlowConditionAvailable, meta_v1.ConditionTrue, "AgentsReady", fmt.Sprintf("%d of %d agents are ready", ready, replicas))
+	} else {
+		setFlowCondition(status, flow, gragent.FlowConditionAvailable, meta_v1.ConditionFalse, "AgentsNotReady", fmt.Sprintf("%d of %d agents are ready", ready, replicas))
+	}
+	return controller.Result{}, nil
+}
+
+// flowConfigHash returns the hash of the config of flow. It returns an error
+// if the config source can't be resolved.
+func (r *flowReconciler) flowConfigHash(ctx context.Context, flow *gragent.GrafanaAgentFlow) (string, error) {
+	src := flow.Spec.Config
+
+	var set int
+	for _, ok := range []bool{src.ConfigMap != nil, src.Secret != nil, src.Remote != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return "", fmt.Errorf("exactly one of configMap, secret, or remote must be set in config")
+	}
+
+	h := sha256.New()
+	switch {
+	case src.ConfigMap != nil:
+		var cm core_v1.ConfigMap
+		key := types.NamespacedName{Namespace: flow.Namespace, Name: src.ConfigMap.Name}
+		if err := r.Get(ctx, key, &cm); err != nil {
+			return "", fmt.Errorf("failed to get config ConfigMap %s: %w", key, err)
+		}
+		content, ok := cm.Data[src.ConfigMap.Key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found in config ConfigMap %s", src.ConfigMap.Key, key)
+		}
+		_, _ = h.Write([]byte(content))
+	case src.Secret != nil:
+		var secret core_v1.Secret
+		key := types.NamespacedName{Namespace: flow.Namespace, Name: src.Secret.Name}
+		if err := r.Get(ctx, key, &secret); err != nil {
+			return "", fmt.Errorf("failed to get config Secret %s: %w", key, err)
+		}
+		content, ok := secret.Data[src.Secret.Key]
+		if !ok {
+			return "", fmt.Errorf("key %q not found in config Secret %s", src.Secret.Key, key)
+		}
+		_, _ = h.Write(content)
+	case src.Remote != nil:
+		if src.Remote.URL == "" {
+			return "", fmt.Errorf("url must be set in remote config")
+		}
+		if src.Remote.Verify == "signature" && src.Remote.PublicKey == nil {
+			return "", fmt.Errorf("publicKey must be set in remote config when verify is signature")
+		}
+		// Remote config bundles are reloaded by the agents, so only changes to
+		// the settings of the remote config roll them out.
+		_, _ = fmt.Fprintf(h, "%s\x00%s\x00%s", src.Remote.URL, src.Remote.PollFrequency, src.Remote.Verify)
+		if key := src.Remote.PublicKey; key != nil {
+			_, _ = fmt.Fprintf(h, "\x00%s\x00%s", key.Name, key.Key)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// applyFlowResources applies the Service and the controller of the agents of
+// flow, removing the controller of the other kind if the kind changed. It
+// returns the number of agents which should be running and are ready.
+func (r *flowReconciler) applyFlowResources(ctx context.Context, l log.Logger, flow *gragent.GrafanaAgentFlow, configHash string) (replicas, ready int32, err error) {
+	svc := generateFlowClusterService(r.config, flow)
+	level.Info(l).Log("msg", "reconciling flow cluster service", "service", svc.Name)
+	if err := clientutil.CreateOrUpdateService(ctx, r.Client, svc); err != nil {
+		return 0, 0, fmt.Errorf("failed to reconcile flow cluster service: %w", err)
+	}
+
+	key := types.NamespacedName{Namespace: flow.Namespace, Name: flow.Name}
+	switch flowController(flow) {
+	case gragent.FlowControllerStatefulSet:
+		if err := r.deleteFlowResource(ctx, key, flow, &apps_v1.DaemonSet{}); err != nil {
+			return 0, 0, err
+		}
+
+		ss, err := generateFlowStatefulSet(r.config, flow, configHash)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to generate statefulset: %w", err)
+		}
+		level.Info(l).Log("msg", "reconciling flow statefulset", "statefulset", ss.Name)
+		if err := clientutil.CreateOrUpdateStatefulSet(ctx, r.Client, ss, l); err != nil {
+			return 0, 0, fmt.Errorf("failed to reconcile statefulset: %w", err)
+		}
+
+		var current apps_v1.StatefulSet
+		if err := r.Get(ctx, key, &current); err != nil {
+			return 0, 0, fmt.Errorf("failed to get statefulset: %w", err)
+		}
+		return *ss.Spec.Replicas, current.Status.ReadyReplicas, nil
+
+	case gragent.FlowControllerDaemonSet:
+		if err := r.deleteFlowResource(ctx, key, flow, &apps_v1.StatefulSet{}); err != nil {
+			return 0, 0, err
+		}
+
+		ds, err := generateFlowDaemonSet(r.config, flow, configHash)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to generate daemonset: %w", err)
+		}
+		level.Info(l).Log("msg", "reconciling flow daemonset", "daemonset", ds.Name)
+		if err := clientutil.CreateOrUpdateDaemonSet(ctx, r.Client, ds, l); err != nil {
+			return 0, 0, fmt.Errorf("failed to reconcile daemonset: %w", err)
+		}
+
+		var current apps_v1.DaemonSet
+		if err := r.Get(ctx, key, &current); err != nil {
+			return 0, 0, fmt.Errorf("failed to get daemonset: %w", err)
+		}
+		return current.Status.DesiredNumberScheduled, current.Status.NumberReady, nil
+
+	default:
+		return 0, 0, fmt.Errorf("unrecognized controller %q, must be one of %s or %s", flow.Spec.Controller, gragent.FlowControllerStatefulSet, gragent.FlowControllerDaemonSet)
+	}
+}
+
+// deleteFlowResource deletes a resource managed for flow. Resources which
+// aren't managed for flow are ignored.
+func (r *flowReconciler) deleteFlowResource(ctx context.Context, key client.ObjectKey, flow *gragent.GrafanaAgentFlow, o client.Object) error {
+	err := r.Get(ctx, key, o)
+	if k8s_errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to find stale resource %s: %w", key, err)
+	}
+	if !isManagedResource(o) || o.GetLabels()[flowNameLabelName] != flow.Name {
+		return nil
+	}
+	if err := r.Delete(ctx, o); err != nil {
+		return fmt.Errorf("failed to delete stale resource %s: %w", key, err)
+	}
+	return nil
+}
+
+func (r *flowReconciler) updateFlowStatus(ctx context.Context, flow *gragent.GrafanaAgentFlow, status *gragent.GrafanaAgentFlowStatus) error {
+	flow.Status = *status
+	return r.Status().Update(ctx, flow)
+}
+
+func setFlowCondition(status *gragent.GrafanaAgentFlowStatus, flow *gragent.GrafanaAgentFlow, ty string, value meta_v1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&status.Conditions, meta_v1.Condition{
+		Type:               ty,
+		Status:             value,
+		ObservedGeneration: flow.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// flowsForObject returns requests to reconcile the GrafanaAgentFlows which
+// read their config from the given ConfigMap or Secret, so that they're rolled
+// out when their config changes.
+func flowsForObject(ctx context.Context, cli client.Client, o client.Object) []reconcile.Request {
+	var flows gragent.GrafanaAgentFlowList
+	if err := cli.List(ctx, &flows, client.InNamespace(o.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var reqs []reconcile.Request
+	for _, f := range flows.Items {
+		if flowReferences(f, o) {
+			reqs = append(reqs, reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: f.Namespace, Name: f.Name},
+			})
+		}
+	}
+	return reqs
+}
+
+func flowReferences(f *gragent.GrafanaAgentFlow, o client.Object) bool {
+	src := f.Spec.Config
+	switch o.(type) {
+	case *core_v1.ConfigMap:
+		return src.ConfigMap != nil && src.ConfigMap.Name == o.GetName()
+	case *core_v1.Secret:
+		if src.Secret != nil && src.Secret.Name == o.GetName() {
+			return true
+		}
+		return src.Remote != nil && src.Remote.PublicKey != nil && src.Remote.PublicKey.Name == o.GetName()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,343 @@
+package operator
+
+import (
+	"fmt"
+
+	"github.com/grafana/agent/pkg/build"
+	gragent "github.com/grafana/agent/pkg/operator/apis/monitoring/v1alpha1"
+	"github.com/grafana/agent/pkg/operator/clientutil"
+	apps_v1 "k8s.io/api/apps/v1"
+	core_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+)
+
+const (
+	flowPortName       = "http-metrics"
+	flowPort     int32 = 12345
+
+	flowConfigFile    = "config.river"
+	flowConfigDir     = "/var/lib/grafana-agent/config"
+	flowDataDir       = "/var/lib/grafana-agent/data"
+	flowPublicKeyFile = "/var/lib/grafana-agent/remote/public-key.pem"
+
+	// flowNameLabelName holds the name of the GrafanaAgentFlow which manages a
+	// resource. It's distinct from agentNameLabelName so that the resources
+	// of a GrafanaAgent and a GrafanaAgentFlow are never confused.
+	flowNameLabelName = "operator.agent.grafana.com/flow-name"
+
+	// flowConfigHashAnnotation holds the hash of the config of the agents in
+	// their pod template, so that changing the config rolls out the agents.
+	flowConfigHashAnnotation = "operator.agent.grafana.com/config-hash"
+)
+
+func flowClusterServiceName(name string) string {
+	return fmt.Sprintf("%s-cluster", name)
+}
+
+func flowOwnerReferences(f *gragent.GrafanaAgentFlow) []meta_v1.OwnerReference {
+	return []meta_v1.OwnerReference{{
+		APIVersion:         gragent.SchemeGroupVersion.String(),
+		Kind:               "GrafanaAgentFlow",
+		Name:               f.Name,
+		BlockOwnerDeletion: pointer.Bool(true),
+		Controller:         pointer.Bool(true),
+		UID:                f.UID,
+	}}
+}
+
+func flowSelectorLabels(cfg *Config, f *gragent.GrafanaAgentFlow) map[string]string {
+	return cfg.Labels.Merge(map[string]string{
+		"app.kubernetes.io/name":     "grafana-agent",
+		"app.kubernetes.io/instance": f.Name,
+		managedByOperatorLabel:       managedByOperatorLabelValue,
+		flowNameLabelName:            f.Name,
+		agentTypeLabel:               "flow",
+	})
+}
+
+// generateFlowClusterService generates the headless Service used by the
+// agents of f to discover each other. It's also the governing Service of the
+// StatefulSet of f.
+func generateFlowClusterService(cfg *Config, f *gragent.GrafanaAgentFlow) *core_v1.Service {
+	return &core_v1.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            flowClusterServiceName(f.Name),
+			Namespace:       f.Namespace,
+			OwnerReferences: flowOwnerReferences(f),
+			Labels: cfg.Labels.Merge(map[string]string{
+				managedByOperatorLabel: managedByOperatorLabelValue,
+				flowNameLabelName:      f.Name,
+			}),
+		},
+		Spec: core_v1.ServiceSpec{
+			ClusterIP: "None",
+			// Agents must be able to join the cluster before they're ready.
+			PublishNotReadyAddresses: true,
+			Ports: []core_v1.ServicePort{{
+				Name:       flowPortName,
+				Port:       flowPort,
+				TargetPort: intstr.FromString(flowPortName),
+			}},
+			Selector: flowSelectorLabels(cfg, f),
+		},
+	}
+}
+
+// generateFlowPodTemplate generates the pod template of the agents of f.
+// configHash is the hash of the config of the agents, which rolls them out
+// when it changes.
+func generateFlowPodTemplate(cfg *Config, f *gragent.GrafanaAgentFlow, configHash string) (core_v1.PodTemplateSpec, *meta_v1.LabelSelector, error) {
+	spec := f.Spec
+
+	useVersion := spec.Version
+	if useVersion == "" {
+		useVersion = DefaultAgentVersion
+	}
+	imagePath := fmt.Sprintf("%s:%s", DefaultAgentBaseImage, useVersion)
+	if spec.Image != nil && *spec.Image != "" {
+		imagePath = *spec.Image
+	}
+
+	volumes := []core_v1.Volume{{
+		Name: "data",
+		VolumeSource: core_v1.VolumeSource{
+			EmptyDir: &core_v1.EmptyDirVolumeSource{},
+		},
+	}}
+	volumeMounts := []core_v1.VolumeMount{{
+		Name:      "data",
+		MountPath: flowDataDir,
+	}}
+
+	configPath := fmt.Sprintf("%s/%s", flowConfigDir, flowConfigFile)
+	var remoteArgs []string
+	switch src := spec.Config; {
+	case src.ConfigMap != nil:
+		volumes = append(volumes, core_v1.Volume{
+			Name: "config",
+			VolumeSource: core_v1.VolumeSource{
+				ConfigMap: &core_v1.ConfigMapVolumeSource{
+					LocalObjectReference: src.ConfigMap.LocalObjectReference,
+					Items:                []core_v1.KeyToPath{{Key: src.ConfigMap.Key, Path: flowConfigFile}},
+				},
+			},
+		})
+	case src.Secret != nil:
+		volumes = append(volumes, core_v1.Volume{
+			Name: "config",
+			VolumeSource: core_v1.VolumeSource{
+				Secret: &core_v1.SecretVolumeSource{
+					SecretName: src.Secret.Name,
+					Items:      []core_v1.KeyToPath{{Key: src.Secret.Key, Path: flowConfigFile}},
+				},
+			},
+		})
+	case src.Remote != nil:
+		// The config file caches the last remote config bundle which loaded
+		// successfully.
+		configPath = fmt.Sprintf("%s/%s", flowDataDir, flowConfigFile)
+		remoteArgs = append(remoteArgs, "--config.remote.url="+src.Remote.URL)
+		if src.Remote.PollFrequency != nil {
+			remoteArgs = append(remoteArgs, "--config.remote.poll-frequency="+src.Remote.PollFrequency.Duration.String())
+		}
+		if src.Remote.Verify != "" {
+			remoteArgs = append(remoteArgs, "--config.remote.verify="+src.Remote.Verify)
+		}
+		if key := src.Remote.PublicKey; key != nil {
+			remoteArgs = append(remoteArgs, "--config.remote.public-key-file="+flowPublicKeyFile)
+			volumes = append(volumes, core_v1.Volume{
+				Name: "remote-public-key",
+				VolumeSource: core_v1.VolumeSource{
+					Secret: &core_v1.SecretVolumeSource{
+						SecretName: key.Name,
+						Items:      []core_v1.KeyToPath{{Key: key.Key, Path: "public-key.pem"}},
+					},
+				},
+			})
+			volumeMounts = append(volumeMounts, core_v1.VolumeMount{
+				Name:      "remote-public-key",
+				ReadOnly:  true,
+				MountPath: "/var/lib/grafana-agent/remote",
+			})
+		}
+	default:
+		return core_v1.PodTemplateSpec{}, nil, fmt.Errorf("one of configMap, secret, or remote must be set in config")
+	}
+	if spec.Config.Remote == nil {
+		volumeMounts = append(volumeMounts, core_v1.VolumeMount{
+			Name:      "config",
+			ReadOnly:  true,
+			MountPath: flowConfigDir,
+		})
+	}
+	volumes = append(volumes, spec.Volumes...)
+	volumeMounts = append(volumeMounts, spec.VolumeMounts...)
+
+	agentArgs := []string{
+		"run",
+		configPath,
+		fmt.Sprintf("--server.http.listen-addr=0.0.0.0:%d", flowPort),
+		"--storage.path=" + flowDataDir,
+	}
+	agentArgs = append(agentArgs, remoteArgs...)
+	if !spec.DisableClustering {
+		agentArgs = append(agentArgs,
+			"--cluster.enabled=true",
+			fmt.Sprintf("--cluster.advertise-address=$(POD_IP):%d", flowPort),
+			fmt.Sprintf("--cluster.join-addresses=%s.%s.svc:%d", flowClusterServiceName(f.Name), f.Namespace, flowPort),
+		)
+	}
+	if spec.DisableReporting {
+		agentArgs = append(agentArgs, "--disable-reporting")
+	}
+
+	envVars := []core_v1.EnvVar{
+		{
+			Name: "POD_NAME",
+			ValueFrom: &core_v1.EnvVarSource{
+				FieldRef: &core_v1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+		{
+			Name: "POD_IP",
+			ValueFrom: &core_v1.EnvVarSource{
+				FieldRef: &core_v1.ObjectFieldSelector{FieldPath: "status.podIP"},
+			},
+		},
+	}
+	envVars = append(envVars, spec.Env...)
+
+	var (
+		selectorLabels = flowSelectorLabels(cfg, f)
+		podLabels      = map[string]string{
+			// version can be a pod label, but should not go in selectors
+			versionLabelName: clientutil.SanitizeVolumeName(build.Version),
+		}
+		podAnnotations = map[string]string{}
+	)
+	if spec.PodMetadata != nil {
+		for k, v := range spec.PodMetadata.Labels {
+			podLabels[k] = v
+		}
+		for k, v := range spec.PodMetadata.Annotations {
+			podAnnotations[k] = v
+		}
+	}
+	for k, v := range selectorLabels {
+		podLabels[k] = v
+	}
+	podAnnotations["kubectl.kubernetes.io/default-container"] = "grafana-agent"
+	podAnnotations[flowConfigHashAnnotation] = configHash
+
+	template := core_v1.PodTemplateSpec{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Labels:      cfg.Labels.Merge(podLabels),
+			Annotations: podAnnotations,
+		},
+		Spec: core_v1.PodSpec{
+			Containers: []core_v1.Container{{
+				Name:  "grafana-agent",
+				Image: imagePath,
+				Ports: []core_v1.ContainerPort{{
+					Name:          flowPortName,
+					ContainerPort: flowPort,
+					Protocol:      core_v1.ProtocolTCP,
+				}},
+				Args:         agentArgs,
+				VolumeMounts: volumeMounts,
+				Env:          envVars,
+				ReadinessProbe: &core_v1.Probe{
+					ProbeHandler: core_v1.ProbeHandler{
+						HTTPGet: &core_v1.HTTPGetAction{
+							Path: "/-/ready",
+							Port: intstr.FromString(flowPortName),
+						},
+					},
+					TimeoutSeconds:   probeTimeoutSeconds,
+					PeriodSeconds:    5,
+					FailureThreshold: 120,
+				},
+				Resources:                spec.Resources,
+				TerminationMessagePolicy: core_v1.TerminationMessageFallbackToLogsOnError,
+			}},
+			ImagePullSecrets:              spec.ImagePullSecrets,
+			SecurityContext:               spec.SecurityContext,
+			ServiceAccountName:            spec.ServiceAccountName,
+			NodeSelector:                  spec.NodeSelector,
+			PriorityClassName:             spec.PriorityClassName,
+			TerminationGracePeriodSeconds: pointer.Int64(4800),
+			Volumes:                       volumes,
+			Tolerations:                   spec.Tolerations,
+			Affinity:                      spec.Affinity,
+		},
+	}
+	return template, &meta_v1.LabelSelector{MatchLabels: selectorLabels}, nil
+}
+
+func flowMetadata(cfg *Config, f *gragent.GrafanaAgentFlow) meta_v1.ObjectMeta {
+	return meta_v1.ObjectMeta{
+		Name:        f.Name,
+		Namespace:   f.Namespace,
+		Labels:      flowSelectorLabels(cfg, f),
+		Annotations: prepareAnnotations(f.Annotations),
+		// The version label isn't set, as labels of StatefulSets are
+		// immutable.
+		OwnerReferences: flowOwnerReferences(f),
+	}
+}
+
+func generateFlowStatefulSet(cfg *Config, f *gragent.GrafanaAgentFlow, configHash string) (*apps_v1.StatefulSet, error) {
+	template, selector, err := generateFlowPodTemplate(cfg, f, configHash)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := f.Spec.Replicas
+	if replicas == nil {
+		replicas = &minReplicas
+	} else if *replicas < 0 {
+		replicas = pointer.Int32(0)
+	}
+
+	return &apps_v1.StatefulSet{
+		ObjectMeta: flowMetadata(cfg, f),
+		Spec: apps_v1.StatefulSetSpec{
+			ServiceName:         flowClusterServiceName(f.Name),
+			Replicas:            replicas,
+			PodManagementPolicy: apps_v1.ParallelPodManagement,
+			UpdateStrategy: apps_v1.StatefulSetUpdateStrategy{
+				Type: apps_v1.RollingUpdateStatefulSetStrategyType,
+			},
+			Selector: selector,
+			Template: template,
+		},
+	}, nil
+}
+
+func generateFlowDaemonSet(cfg *Config, f *gragent.GrafanaAgentFlow, configHash string) (*apps_v1.DaemonSet, error) {
+	template, selector, err := generateFlowPodTemplate(cfg, f, configHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apps_v1.DaemonSet{
+		ObjectMeta: flowMetadata(cfg, f),
+		Spec: apps_v1.DaemonSetSpec{
+			UpdateStrategy: apps_v1.DaemonSetUpdateStrategy{
+				Type: apps_v1.RollingUpdateDaemonSetStrategyType,
+			},
+			Selector: selector,
+			Template: template,
+		},
+	}, nil
+}
+
+// flowController returns the kind of controller which runs the agents of f.
+func flowController(f *gragent.GrafanaAgentFlow) gragent.FlowControllerType {
+	if f.Spec.Controller == "" {
+		return gragent.FlowControllerStatefulSet
+	}
+	return f.Spec.Controller
+}
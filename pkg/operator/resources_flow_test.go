@@ -0,0 +1,176 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	gragent "github.com/grafana/agent/pkg/operator/apis/monitoring/v1alpha1"
+	"github.com/stretchr/testify/require"
+	core_v1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func Test_generateFlowPodTemplate(t *testing.T) {
+	var (
+		cfg  = &Config{}
+		name = "example"
+	)
+
+	configMapSource := gragent.FlowConfigSource{
+		ConfigMap: &core_v1.ConfigMapKeySelector{
+			LocalObjectReference: core_v1.LocalObjectReference{Name: "agent-config"},
+			Key:                  "agent.river",
+		},
+	}
+
+	t.Run("mounts config from configmap", func(t *testing.T) {
+		flow := &gragent.GrafanaAgentFlow{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: name},
+			Spec:       gragent.GrafanaAgentFlowSpec{Config: configMapSource},
+		}
+
+		tmpl, selector, err := generateFlowPodTemplate(cfg, flow, "abc")
+		require.NoError(t, err)
+
+		container := tmpl.Spec.Containers[0]
+		require.Equal(t, DefaultAgentImage, container.Image)
+		require.Equal(t, []string{"run", "/var/lib/grafana-agent/config/config.river"}, container.Args[:2])
+		require.Contains(t, container.VolumeMounts, core_v1.VolumeMount{Name: "config", ReadOnly: true, MountPath: flowConfigDir})
+
+		var found bool
+		for _, v := range tmpl.Spec.Volumes {
+			if v.Name == "config" {
+				found = true
+				require.NotNil(t, v.ConfigMap)
+				require.Equal(t, "agent-config", v.ConfigMap.Name)
+				require.Equal(t, []core_v1.KeyToPath{{Key: "agent.river", Path: flowConfigFile}}, v.ConfigMap.Items)
+			}
+		}
+		require.True(t, found, "config volume not found")
+
+		require.Equal(t, "abc", tmpl.Annotations[flowConfigHashAnnotation])
+		require.Equal(t, name, selector.MatchLabels[flowNameLabelName])
+		require.NotContains(t, selector.MatchLabels, versionLabelName)
+	})
+
+	t.Run("clustering enabled by default", func(t *testing.T) {
+		flow := &gragent.GrafanaAgentFlow{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       gragent.GrafanaAgentFlowSpec{Config: configMapSource},
+		}
+
+		tmpl, _, err := generateFlowPodTemplate(cfg, flow, "")
+		require.NoError(t, err)
+		require.Subset(t, tmpl.Spec.Containers[0].Args, []string{
+			"--cluster.enabled=true",
+			"--cluster.advertise-address=$(POD_IP):12345",
+			"--cluster.join-addresses=example-cluster.default.svc:12345",
+		})
+
+		flow.Spec.DisableClustering = true
+		tmpl, _, err = generateFlowPodTemplate(cfg, flow, "")
+		require.NoError(t, err)
+		require.NotContains(t, tmpl.Spec.Containers[0].Args, "--cluster.enabled=true")
+	})
+
+	t.Run("passes remote config flags", func(t *testing.T) {
+		flow := &gragent.GrafanaAgentFlow{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: name},
+			Spec: gragent.GrafanaAgentFlowSpec{
+				Config: gragent.FlowConfigSource{
+					Remote: &gragent.FlowRemoteConfig{
+						URL:           "https://example.com/bundle.tar.gz",
+						PollFrequency: &v1.Duration{Duration: 5 * time.Minute},
+						Verify:        "signature",
+						PublicKey: &core_v1.SecretKeySelector{
+							LocalObjectReference: core_v1.LocalObjectReference{Name: "keys"},
+							Key:                  "public.pem",
+						},
+					},
+				},
+			},
+		}
+
+		tmpl, _, err := generateFlowPodTemplate(cfg, flow, "")
+		require.NoError(t, err)
+
+		container := tmpl.Spec.Containers[0]
+		require.Equal(t, "/var/lib/grafana-agent/data/config.river", container.Args[1])
+		require.Subset(t, container.Args, []string{
+			"--config.remote.url=https://example.com/bundle.tar.gz",
+			"--config.remote.poll-frequency=5m0s",
+			"--config.remote.verify=signature",
+			"--config.remote.public-key-file=" + flowPublicKeyFile,
+		})
+		for _, m := range container.VolumeMounts {
+			require.NotEqual(t, "config", m.Name, "remote config should not mount a config volume")
+		}
+	})
+
+	t.Run("requires config source", func(t *testing.T) {
+		flow := &gragent.GrafanaAgentFlow{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: name},
+		}
+
+		_, _, err := generateFlowPodTemplate(cfg, flow, "")
+		require.Error(t, err)
+	})
+}
+
+func Test_generateFlowControllers(t *testing.T) {
+	cfg := &Config{}
+	flow := &gragent.GrafanaAgentFlow{
+		ObjectMeta: v1.ObjectMeta{Name: "example", Namespace: "default"},
+		Spec: gragent.GrafanaAgentFlowSpec{
+			Config: gragent.FlowConfigSource{
+				Secret: &core_v1.SecretKeySelector{
+					LocalObjectReference: core_v1.LocalObjectReference{Name: "agent-config"},
+					Key:                  "config.river",
+				},
+			},
+		},
+	}
+
+	t.Run("statefulset by default", func(t *testing.T) {
+		require.Equal(t, gragent.FlowControllerStatefulSet, flowController(flow))
+
+		ss, err := generateFlowStatefulSet(cfg, flow, "abc")
+		require.NoError(t, err)
+		require.Equal(t, pointer.Int32(1), ss.Spec.Replicas)
+		require.Equal(t, "example-cluster", ss.Spec.ServiceName)
+		require.Equal(t, "abc", ss.Spec.Template.Annotations[flowConfigHashAnnotation])
+	})
+
+	t.Run("daemonset", func(t *testing.T) {
+		flow := flow.DeepCopy()
+		flow.Spec.Controller = gragent.FlowControllerDaemonSet
+		require.Equal(t, gragent.FlowControllerDaemonSet, flowController(flow))
+
+		ds, err := generateFlowDaemonSet(cfg, flow, "abc")
+		require.NoError(t, err)
+		require.Equal(t, "example", ds.Name)
+		require.Equal(t, "example", ds.Labels[flowNameLabelName])
+		require.Equal(t, "abc", ds.Spec.Template.Annotations[flowConfigHashAnnotation])
+	})
+}
+
+func Test_flowReferences(t *testing.T) {
+	flow := &gragent.GrafanaAgentFlow{
+		Spec: gragent.GrafanaAgentFlowSpec{
+			Config: gragent.FlowConfigSource{
+				Remote: &gragent.FlowRemoteConfig{
+					URL: "https://example.com/bundle.tar.gz",
+					PublicKey: &core_v1.SecretKeySelector{
+						LocalObjectReference: core_v1.LocalObjectReference{Name: "keys"},
+						Key:                  "public.pem",
+					},
+				},
+			},
+		},
+	}
+
+	require.True(t, flowReferences(flow, &core_v1.Secret{ObjectMeta: v1.ObjectMeta{Name: "keys"}}))
+	require.False(t, flowReferences(flow, &core_v1.Secret{ObjectMeta: v1.ObjectMeta{Name: "other"}}))
+	require.False(t, flowReferences(flow, &core_v1.ConfigMap{ObjectMeta: v1.ObjectMeta{Name: "keys"}}))
+}
@@ -0,0 +1,104 @@
+package riverjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/agent/pkg/river/internal/reflectutil"
+	"github.com/grafana/agent/pkg/river/internal/rivertags"
+	"github.com/grafana/agent/pkg/river/internal/value"
+)
+
+// jsonSchemaField describes an attribute or block which may be set in a
+// River body.
+type jsonSchemaField struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // "attr", "block", or "enum"
+
+	// ValueType is the River type of attributes.
+	ValueType string     `json:"valueType,omitempty"`
+	Default   *jsonValue `json:"default,omitempty"`
+
+	Optional bool `json:"optional"`
+	// Repeated is true for blocks which may be set multiple times.
+	Repeated bool `json:"repeated,omitempty"`
+
+	// Body is the schema of the body of blocks, or the blocks of an enum.
+	Body []jsonSchemaField `json:"body,omitempty"`
+}
+
+// MarshalSchema marshals a JSON representation of the attributes and blocks
+// which may be set in a River body decoded into the type of val, along with
+// their default values. MarshalSchema panics if not given a struct with River
+// tags.
+func MarshalSchema(val interface{}) ([]byte, error) {
+	return json.Marshal(encodeStructSchema(reflect.TypeOf(val)))
+}
+
+func encodeStructSchema(ty reflect.Type) []jsonSchemaField {
+	ty = derefType(ty)
+	if ty.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("river/encoding/riverjson: can only encode schemas of struct types, got %s", ty.Kind()))
+	}
+
+	defaults := reflect.New(ty).Elem()
+	if defaults.Addr().Type().Implements(goRiverDefaulter) {
+		defaults.Addr().Interface().(value.Defaulter).SetToDefault()
+	}
+
+	fields := []jsonSchemaField{}
+	for _, field := range rivertags.Get(ty) {
+		if field.IsLabel() {
+			continue
+		}
+		fields = append(fields, encodeFieldSchema(field, reflectutil.Get(defaults, field)))
+	}
+	return fields
+}
+
+func encodeFieldSchema(field rivertags.Field, defaultValue reflect.Value) jsonSchemaField {
+	schema := jsonSchemaField{
+		Name:     strings.Join(field.Name, "."),
+		Optional: field.IsOptional(),
+	}
+
+	switch {
+	case field.IsAttr():
+		schema.Type = "attr"
+		schema.ValueType = value.RiverType(defaultValue.Type()).String()
+		if !defaultValue.IsZero() {
+			v := buildJSONValue(value.FromRaw(defaultValue))
+			schema.Default = &v
+		}
+
+	case field.IsBlock():
+		schema.Type = "block"
+
+		ty := derefType(defaultValue.Type())
+		if k := ty.Kind(); k == reflect.Slice || k == reflect.Array {
+			schema.Repeated = true
+			ty = derefType(ty.Elem())
+		}
+		if ty.Kind() == reflect.Struct {
+			schema.Body = encodeStructSchema(ty)
+		}
+
+	case field.IsEnum():
+		schema.Type = "enum"
+		schema.Repeated = true
+
+		ty := derefType(defaultValue.Type().Elem())
+		schema.Body = encodeStructSchema(ty)
+	}
+
+	return schema
+}
+
+func derefType(ty reflect.Type) reflect.Type {
+	for ty.Kind() == reflect.Pointer {
+		ty = ty.Elem()
+	}
+	return ty
+}
@@ -0,0 +1,62 @@
+package riverjson_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/agent/pkg/river/encoding/riverjson"
+	"github.com/grafana/agent/pkg/river/rivertypes"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	type ruleBlock struct {
+		Action string `river:"action,attr,optional"`
+	}
+	type authBlock struct {
+		Username string            `river:"username,attr"`
+		Password rivertypes.Secret `river:"password,attr,optional"`
+	}
+	type squashed struct {
+		Timeout time.Duration `river:"timeout,attr,optional"`
+	}
+	type block struct {
+		Targets  []map[string]string `river:"targets,attr"`
+		Squashed squashed            `river:",squash"`
+		Auth     *authBlock          `river:"auth,block,optional"`
+		Rules    []ruleBlock         `river:"rule,block,optional"`
+	}
+
+	expect := `[
+		{ "name": "targets", "type": "attr", "valueType": "array", "optional": false },
+		{ "name": "timeout", "type": "attr", "valueType": "string", "optional": true },
+		{
+			"name": "auth", "type": "block", "optional": true,
+			"body": [
+				{ "name": "username", "type": "attr", "valueType": "string", "optional": false },
+				{ "name": "password", "type": "attr", "valueType": "capsule", "optional": true }
+			]
+		},
+		{
+			"name": "rule", "type": "block", "optional": true, "repeated": true,
+			"body": [
+				{ "name": "action", "type": "attr", "valueType": "string", "optional": true }
+			]
+		}
+	]`
+
+	bb, err := riverjson.MarshalSchema(block{})
+	require.NoError(t, err)
+	require.JSONEq(t, expect, string(bb))
+}
+
+func TestSchemaDefaults(t *testing.T) {
+	expect := `[
+		{ "name": "name", "type": "attr", "valueType": "string", "optional": true, "default": { "type": "string", "value": "John Doe" } },
+		{ "name": "age", "type": "attr", "valueType": "number", "optional": true, "default": { "type": "number", "value": 35 } }
+	]`
+
+	bb, err := riverjson.MarshalSchema(defaultsBlock{})
+	require.NoError(t, err)
+	require.JSONEq(t, expect, string(bb))
+}
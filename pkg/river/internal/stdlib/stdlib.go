@@ -2,11 +2,18 @@
 package stdlib
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/netip"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/grafana/agent/pkg/river/internal/value"
 	"github.com/grafana/agent/pkg/river/rivertypes"
+	"gopkg.in/yaml.v3"
 )
 
 // Identifiers holds a list of stdlib identifiers by name. All interface{}
@@ -79,6 +86,51 @@ var Identifiers = map[string]interface{}{
 		return res, nil
 	},
 
+	"yaml_decode": func(in string) (interface{}, error) {
+		var res interface{}
+		if err := yaml.Unmarshal([]byte(in), &res); err != nil {
+			return nil, err
+		}
+		return normalizeYAML(res), nil
+	},
+
+	"base64_encode": func(in string) string {
+		return base64.StdEncoding.EncodeToString([]byte(in))
+	},
+
+	"base64_decode": func(in string) (string, error) {
+		res, err := base64.StdEncoding.DecodeString(in)
+		if err != nil {
+			return "", err
+		}
+		return string(res), nil
+	},
+
+	"regex_replace": func(in, pattern, replacement string) (string, error) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		return re.ReplaceAllString(in, replacement), nil
+	},
+
+	"trim_prefix": strings.TrimPrefix,
+	"trim_suffix": strings.TrimSuffix,
+
+	"cidr_contains": func(prefix, ip string) (bool, error) {
+		p, err := netip.ParsePrefix(prefix)
+		if err != nil {
+			return false, err
+		}
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return false, err
+		}
+		return p.Masked().Contains(addr), nil
+	},
+
+	"cidr_host": cidrHost,
+
 	"coalesce": value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
 		if len(args) == 0 {
 			return value.Null, nil
@@ -101,3 +153,53 @@ var Identifiers = map[string]interface{}{
 		return args[len(args)-1], nil
 	}),
 }
+
+// normalizeYAML converts the maps decoded from YAML which have non-string
+// keys into objects, which River requires to have string keys.
+func normalizeYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = normalizeYAML(elem)
+		}
+		return v
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			res[fmt.Sprint(key)] = normalizeYAML(elem)
+		}
+		return res
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeYAML(elem)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// cidrHost returns the IP address of the host numbered hostnum in the network
+// prefix. Negative host numbers count back from the end of the network.
+func cidrHost(prefix string, hostnum int) (string, error) {
+	p, err := netip.ParsePrefix(prefix)
+	if err != nil {
+		return "", err
+	}
+	p = p.Masked()
+
+	size := new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+	num := big.NewInt(int64(hostnum))
+	if hostnum < 0 {
+		num.Add(num, size)
+	}
+	if num.Sign() < 0 || num.Cmp(size) >= 0 {
+		return "", fmt.Errorf("prefix %s has no host number %d", prefix, hostnum)
+	}
+
+	base := p.Addr().AsSlice()
+	host := new(big.Int).SetBytes(base)
+	host.Add(host, num)
+	addr, _ := netip.AddrFromSlice(host.FillBytes(make([]byte, len(base))))
+	return addr.String(), nil
+}
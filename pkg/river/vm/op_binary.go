@@ -6,6 +6,7 @@ import (
 	"reflect"
 
 	"github.com/grafana/agent/pkg/river/internal/value"
+	"github.com/grafana/agent/pkg/river/rivertypes"
 	"github.com/grafana/agent/pkg/river/token"
 )
 
@@ -21,6 +22,14 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 		return value.Bool(!valuesEqual(lhs, rhs)), nil
 	}
 
+	// Strings derived from secrets must remain secrets, so that they can't be
+	// exported or displayed as plain strings.
+	if op == token.ADD {
+		if res, ok := concatSecrets(lhs, rhs); ok {
+			return res, nil
+		}
+	}
+
 	// The type of lhs and rhs must be acceptable for the binary operator.
 	if !acceptableBinopType(lhs, op) {
 		return value.Null, value.Error{
@@ -188,6 +197,44 @@ func evalBinop(lhs value.Value, op token.Token, rhs value.Value) (value.Value, e
 	panic("river/vm: unreachable")
 }
 
+// concatSecrets concatenates lhs and rhs if at least one of them is a secret
+// and the other one is a string or a secret. The result is a secret if either
+// of them is sensitive. ok is false if lhs and rhs can't be concatenated as
+// secrets.
+func concatSecrets(lhs, rhs value.Value) (res value.Value, ok bool) {
+	if lhs.Type() != value.TypeCapsule && rhs.Type() != value.TypeCapsule {
+		return value.Null, false
+	}
+
+	lhsText, lhsSensitive, lhsOK := secretText(lhs)
+	rhsText, rhsSensitive, rhsOK := secretText(rhs)
+	if !lhsOK || !rhsOK {
+		return value.Null, false
+	}
+
+	if lhsSensitive || rhsSensitive {
+		return value.Encapsulate(rivertypes.Secret(lhsText + rhsText)), true
+	}
+	return value.String(lhsText + rhsText), true
+}
+
+// secretText returns the text of a string, Secret or OptionalSecret value and
+// whether the value is sensitive. ok is false for values of other types.
+func secretText(v value.Value) (text string, sensitive bool, ok bool) {
+	switch v.Type() {
+	case value.TypeString:
+		return v.Text(), false, true
+	case value.TypeCapsule:
+		switch s := v.Interface().(type) {
+		case rivertypes.Secret:
+			return string(s), true, true
+		case rivertypes.OptionalSecret:
+			return s.Value, s.IsSecret, true
+		}
+	}
+	return "", false, false
+}
+
 // valuesEqual returns true if two River Values are equal.
 func valuesEqual(lhs value.Value, rhs value.Value) bool {
 	if lhs.Type() != rhs.Type() {
@@ -26,6 +26,23 @@ func TestVM_Stdlib(t *testing.T) {
 		{"json_decode array", `json_decode("[0, 1, 2]")`, []interface{}{float64(0), float64(1), float64(2)}},
 		{"json_decode nil field", `json_decode("{\"foo\": null}")`, map[string]interface{}{"foo": nil}},
 		{"json_decode nil array element", `json_decode("[0, null]")`, []interface{}{float64(0), nil}},
+		{"yaml_decode object", `yaml_decode("foo: bar\nlist: [1, 2]")`, map[string]interface{}{"foo": "bar", "list": []interface{}{1, 2}}},
+		{"yaml_decode non-string keys", `yaml_decode("1: one\ntrue: yes")`, map[string]interface{}{"1": "one", "true": "yes"}},
+		{"yaml_decode nested", `yaml_decode("a:\n  1: [{2: b}]")`, map[string]interface{}{"a": map[string]interface{}{"1": []interface{}{map[string]interface{}{"2": "b"}}}}},
+		{"yaml_decode nil field", `yaml_decode("foo: ~")`, map[string]interface{}{"foo": nil}},
+		{"base64_encode", `base64_encode("Hello, world!")`, "SGVsbG8sIHdvcmxkIQ=="},
+		{"base64_decode", `base64_decode("SGVsbG8sIHdvcmxkIQ==")`, "Hello, world!"},
+		{"regex_replace", `regex_replace("prod-eu-west-1", "^(\\w+)-(.*)$", "$2/$1")`, "eu-west-1/prod"},
+		{"regex_replace no match", `regex_replace("foo", "bar", "baz")`, "foo"},
+		{"trim_prefix", `trim_prefix("__meta_kubernetes_pod_name", "__meta_")`, "kubernetes_pod_name"},
+		{"trim_suffix", `trim_suffix("example.com.", ".")`, "example.com"},
+		{"cidr_contains", `cidr_contains("10.0.0.0/8", "10.1.2.3")`, true},
+		{"cidr_contains outside", `cidr_contains("10.0.0.0/8", "192.168.0.1")`, false},
+		{"cidr_contains ipv6", `cidr_contains("fd00::/8", "fd12::1")`, true},
+		{"cidr_host", `cidr_host("10.12.0.0/16", 16)`, "10.12.0.16"},
+		{"cidr_host negative", `cidr_host("10.12.0.0/16", -2)`, "10.12.255.254"},
+		{"cidr_host unmasked", `cidr_host("10.12.1.5/24", 1)`, "10.12.1.1"},
+		{"cidr_host ipv6", `cidr_host("fd00::/64", 258)`, "fd00::102"},
 	}
 
 	for _, tc := range tt {
@@ -42,6 +59,34 @@ func TestVM_Stdlib(t *testing.T) {
 	}
 }
 
+func TestVM_Stdlib_Errors(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{"yaml_decode invalid", `yaml_decode("foo: [")`, "yaml"},
+		{"base64_decode invalid", `base64_decode("not base64!")`, "illegal base64 data"},
+		{"regex_replace invalid", `regex_replace("foo", "(", "")`, "missing closing )"},
+		{"cidr_contains invalid prefix", `cidr_contains("10.0.0.0", "10.0.0.1")`, "no '/'"},
+		{"cidr_contains invalid ip", `cidr_contains("10.0.0.0/8", "ten")`, "unable to parse IP"},
+		{"cidr_host out of range", `cidr_host("10.0.0.0/30", 4)`, "has no host number 4"},
+		{"cidr_host negative out of range", `cidr_host("10.0.0.0/30", -5)`, "has no host number -5"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			var v interface{}
+			require.ErrorContains(t, eval.Evaluate(nil, &v), tc.expect)
+		})
+	}
+}
+
 func TestStdlibCoalesce(t *testing.T) {
 	t.Setenv("TEST_VAR2", "Hello!")
 
@@ -7,6 +7,7 @@ import (
 	"unicode"
 
 	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/rivertypes"
 	"github.com/grafana/agent/pkg/river/scanner"
 	"github.com/grafana/agent/pkg/river/token"
 	"github.com/grafana/agent/pkg/river/vm"
@@ -155,6 +156,64 @@ func TestVM_Evaluate(t *testing.T) {
 	}
 }
 
+func TestVM_Evaluate_SecretConcat(t *testing.T) {
+	scope := &vm.Scope{
+		Variables: map[string]interface{}{
+			"secret":            rivertypes.Secret("s3cr3t"),
+			"optionalSecret":    rivertypes.OptionalSecret{IsSecret: true, Value: "token"},
+			"optionalNonSecret": rivertypes.OptionalSecret{Value: "user"},
+		},
+	}
+
+	tt := []struct {
+		input  string
+		expect interface{}
+	}{
+		{`"Bearer " + secret`, rivertypes.Secret("Bearer s3cr3t")},
+		{`secret + "!"`, rivertypes.Secret("s3cr3t!")},
+		{`secret + secret`, rivertypes.Secret("s3cr3ts3cr3t")},
+		{`"a" + secret + "b" + "c"`, rivertypes.Secret("as3cr3tbc")},
+		{`"Bearer " + optionalSecret`, rivertypes.Secret("Bearer token")},
+		{`optionalNonSecret + ":" + secret`, rivertypes.Secret("user:s3cr3t")},
+		{`"user=" + optionalNonSecret`, string("user=user")},
+		{`"Bearer " + secret`, rivertypes.OptionalSecret{IsSecret: true, Value: "Bearer s3cr3t"}},
+		{`nonsensitive("Bearer " + secret)`, string("Bearer s3cr3t")},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			vPtr := reflect.New(reflect.TypeOf(tc.expect)).Interface()
+			require.NoError(t, eval.Evaluate(scope, vPtr))
+
+			actual := reflect.ValueOf(vPtr).Elem().Interface()
+			require.Equal(t, tc.expect, actual)
+		})
+	}
+
+	t.Run("derived secrets can't be converted into strings", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`"Bearer " + secret`)
+		require.NoError(t, err)
+
+		var s string
+		err = vm.New(expr).Evaluate(scope, &s)
+		require.ErrorContains(t, err, "secrets may not be converted into strings")
+	})
+
+	t.Run("derived secrets can't be added to numbers", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`secret + 1`)
+		require.NoError(t, err)
+
+		var s rivertypes.Secret
+		err = vm.New(expr).Evaluate(scope, &s)
+		require.ErrorContains(t, err, "should be one of [number string] for binop +, got capsule")
+	})
+}
+
 func TestVM_Evaluate_Null(t *testing.T) {
 	expr, err := parser.ParseExpression("null")
 	require.NoError(t, err)
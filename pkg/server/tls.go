@@ -3,15 +3,14 @@ package server
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"errors"
 	"fmt"
 	"net"
-	"os"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/util/certreload"
 )
 
 // TLSConfig holds dynamic configuration options for TLS.
@@ -226,17 +225,20 @@ func (l *tlsListener) applyNormalTLS(c TLSConfig) error {
 	if c.TLSKeyPath == "" {
 		return fmt.Errorf("missing key file")
 	}
-	_, err := tls.LoadX509KeyPair(c.TLSCertPath, c.TLSKeyPath)
+	// Certificates rotated on disk are reloaded by new connections.
+	reloader, err := certreload.New(l.log, certreload.Files{
+		CertFile:     c.TLSCertPath,
+		KeyFile:      c.TLSKeyPath,
+		ClientCAFile: c.ClientCAs,
+	}, 0)
 	if err != nil {
-		return fmt.Errorf("failed to load key pair: %w", err)
+		return err
 	}
 
 	newConfig := &tls.Config{
 		MinVersion:               (uint16)(c.MinVersion),
 		MaxVersion:               (uint16)(c.MaxVersion),
 		PreferServerCipherSuites: c.PreferServerCipherSuites,
-
-		GetCertificate: l.getCertificate,
 	}
 
 	var cf []uint16
@@ -255,16 +257,6 @@ func (l *tlsListener) applyNormalTLS(c TLSConfig) error {
 		newConfig.CurvePreferences = cp
 	}
 
-	if c.ClientCAs != "" {
-		clientCAPool := x509.NewCertPool()
-		clientCAFile, err := os.ReadFile(c.ClientCAs)
-		if err != nil {
-			return err
-		}
-		clientCAPool.AppendCertsFromPEM(clientCAFile)
-		newConfig.ClientCAs = clientCAPool
-	}
-
 	clientAuth, err := getClientAuthFromString(c.ClientAuth)
 	if err != nil {
 		return err
@@ -273,23 +265,13 @@ func (l *tlsListener) applyNormalTLS(c TLSConfig) error {
 	if c.ClientCAs != "" && newConfig.ClientAuth == tls.NoClientCert {
 		return fmt.Errorf("Client CAs have been configured without a ClientAuth policy")
 	}
+	reloader.Apply(newConfig)
 
 	l.tlsConfig = newConfig
 	l.cfg = c
 	return nil
 }
 
-func (l *tlsListener) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
-	l.mut.RLock()
-	defer l.mut.RUnlock()
-
-	cert, err := tls.LoadX509KeyPair(l.cfg.TLSCertPath, l.cfg.TLSKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load key pair: %w", err)
-	}
-	return &cert, nil
-}
-
 func getClientAuthFromString(clientAuth string) (tls.ClientAuthType, error) {
 	switch clientAuth {
 	case "RequestClientCert":
@@ -0,0 +1,138 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/backoff"
+)
+
+// Files keeps the first X.509 SVID of a Workload API written to PEM files, so
+// that TLS settings which load certificates from files can use them.
+type Files struct {
+	// CertFile holds the certificate chain of the SVID, KeyFile its private
+	// key, and BundleFile the CA certificates of its trust domain.
+	CertFile, KeyFile, BundleFile string
+
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	mut     sync.Mutex
+	lastErr error
+}
+
+var (
+	watchedMut sync.Mutex
+	watched    = map[string]*Files{}
+)
+
+// Watch returns the Files kept up to date with the SVIDs of the Workload API
+// listening on socketPath. The first call for a socket starts fetching SVIDs
+// in the background for the lifetime of the process, reconnecting when the
+// connection fails; later calls share the same Files.
+func Watch(socketPath string) *Files {
+	watchedMut.Lock()
+	defer watchedMut.Unlock()
+
+	if f, ok := watched[socketPath]; ok {
+		return f
+	}
+
+	sum := sha256.Sum256([]byte(socketPath))
+	dir := filepath.Join(os.TempDir(), "grafana-agent-spiffe-"+hex.EncodeToString(sum[:8]))
+	f := &Files{
+		CertFile:   filepath.Join(dir, "svid.pem"),
+		KeyFile:    filepath.Join(dir, "svid_key.pem"),
+		BundleFile: filepath.Join(dir, "bundle.pem"),
+
+		ready: make(chan struct{}),
+	}
+	watched[socketPath] = f
+
+	go f.run(socketPath, dir)
+	return f
+}
+
+// Wait waits until the files have been written for the first time, or ctx
+// is canceled.
+func (f *Files) Wait(ctx context.Context) error {
+	select {
+	case <-f.ready:
+		return nil
+	case <-ctx.Done():
+		f.mut.Lock()
+		defer f.mut.Unlock()
+		if f.lastErr != nil {
+			return fmt.Errorf("waiting for an X.509 SVID: %w", f.lastErr)
+		}
+		return fmt.Errorf("waiting for an X.509 SVID: %w", ctx.Err())
+	}
+}
+
+func (f *Files) run(socketPath, dir string) {
+	bo := backoff.New(context.Background(), backoff.Config{
+		MinBackoff: time.Second,
+		MaxBackoff: 30 * time.Second,
+	})
+
+	for {
+		err := FetchX509SVIDs(context.Background(), socketPath, func(svids []X509SVID) {
+			err := f.write(dir, svids[0])
+			f.setErr(err)
+			if err == nil {
+				f.readyOnce.Do(func() { close(f.ready) })
+				bo.Reset()
+			}
+		})
+		f.setErr(err)
+		bo.Wait()
+	}
+}
+
+func (f *Files) setErr(err error) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.lastErr = err
+}
+
+func (f *Files) write(dir string, svid X509SVID) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	var certs, bundle []byte
+	for _, c := range svid.Certificates {
+		certs = append(certs, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	for _, c := range svid.Bundle {
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	key := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: svid.PrivateKey})
+
+	// The key is written before its certificate so that readers never see a
+	// new certificate paired with an old key for long.
+	if err := writeFileAtomic(f.KeyFile, key); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(f.CertFile, certs); err != nil {
+		return err
+	}
+	return writeFileAtomic(f.BundleFile, bundle)
+}
+
+// writeFileAtomic replaces the file at path with data, so that readers see
+// either the old or the new contents.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
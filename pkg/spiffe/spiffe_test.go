@@ -0,0 +1,189 @@
+package spiffe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestWatch(t *testing.T) {
+	svid := newTestSVID(t, "spiffe://example.org/agent")
+	socketPath := runFakeWorkloadAPI(t, encodeX509SVIDResponse(svid))
+
+	files := Watch(socketPath)
+	require.Same(t, files, Watch(socketPath))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, files.Wait(ctx))
+
+	cert, err := os.ReadFile(files.CertFile)
+	require.NoError(t, err)
+	requirePEM(t, cert, "CERTIFICATE", svid.Certificates[0].Raw)
+
+	key, err := os.ReadFile(files.KeyFile)
+	require.NoError(t, err)
+	requirePEM(t, key, "PRIVATE KEY", svid.PrivateKey)
+
+	bundle, err := os.ReadFile(files.BundleFile)
+	require.NoError(t, err)
+	requirePEM(t, bundle, "CERTIFICATE", svid.Bundle[0].Raw)
+
+	fi, err := os.Stat(files.KeyFile)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+}
+
+func TestFiles_Wait_Unavailable(t *testing.T) {
+	dir, err := os.MkdirTemp("", "spiffe")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	files := Watch(filepath.Join(dir, "missing.sock"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	require.ErrorContains(t, files.Wait(ctx), "waiting for an X.509 SVID")
+}
+
+func TestParseX509SVIDResponse_Invalid(t *testing.T) {
+	svid := newTestSVID(t, "spiffe://example.org/agent")
+	svid.PrivateKey = []byte("not a key")
+
+	_, err := parseX509SVIDResponse(encodeX509SVIDResponse(svid))
+	require.ErrorContains(t, err, `SVID "spiffe://example.org/agent": invalid private key`)
+}
+
+func requirePEM(t *testing.T, data []byte, typ string, der []byte) {
+	t.Helper()
+
+	block, _ := pem.Decode(data)
+	require.NotNil(t, block, "no PEM block found")
+	require.Equal(t, typ, block.Type)
+	require.Equal(t, der, block.Bytes)
+}
+
+// newTestSVID returns an SVID with a self-signed certificate for id, which
+// is also used as its bundle.
+func newTestSVID(t *testing.T, id string) X509SVID {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	uri, err := url.Parse(id)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "agent"},
+		URIs:                  []*url.URL{uri},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return X509SVID{
+		ID:           id,
+		Certificates: []*x509.Certificate{cert},
+		PrivateKey:   keyDER,
+		Bundle:       []*x509.Certificate{cert},
+	}
+}
+
+func encodeX509SVIDResponse(svid X509SVID) []byte {
+	var certs, bundle []byte
+	for _, c := range svid.Certificates {
+		certs = append(certs, c.Raw...)
+	}
+	for _, c := range svid.Bundle {
+		bundle = append(bundle, c.Raw...)
+	}
+
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.BytesType)
+	msg = protowire.AppendString(msg, svid.ID)
+	msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, certs)
+	msg = protowire.AppendTag(msg, 3, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, svid.PrivateKey)
+	msg = protowire.AppendTag(msg, 4, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, bundle)
+
+	var resp []byte
+	resp = protowire.AppendTag(resp, 1, protowire.BytesType)
+	resp = protowire.AppendBytes(resp, msg)
+	return resp
+}
+
+// runFakeWorkloadAPI runs a Workload API which sends resp to every client of
+// FetchX509SVID, and returns the path of its socket.
+func runFakeWorkloadAPI(t *testing.T, resp []byte) string {
+	t.Helper()
+
+	// The path of Unix sockets is limited in length, which paths from
+	// t.TempDir may exceed.
+	dir, err := os.MkdirTemp("", "spiffe")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socketPath := filepath.Join(dir, "agent.sock")
+	lis, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(
+		grpc.ForceServerCodec(rawCodec{}),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			if method != fetchX509SVIDMethod {
+				return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+			}
+
+			md, _ := metadata.FromIncomingContext(stream.Context())
+			if len(md.Get(securityHeader)) == 0 {
+				return status.Error(codes.InvalidArgument, "security header missing")
+			}
+
+			var req []byte
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			if err := stream.SendMsg(resp); err != nil {
+				return err
+			}
+			<-stream.Context().Done()
+			return nil
+		}),
+	)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	return socketPath
+}
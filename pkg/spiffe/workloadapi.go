@@ -0,0 +1,209 @@
+// Package spiffe retrieves X.509 SVIDs from the SPIFFE Workload API.
+//
+// Only the subset of the Workload API needed to fetch X.509 SVIDs is
+// implemented. Messages are encoded by hand so that no generated code for
+// the Workload API is needed.
+package spiffe
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	fetchX509SVIDMethod = "/SpiffeWorkloadAPI/FetchX509SVID"
+
+	// The Workload API rejects requests which don't set this header, to
+	// protect against server-side request forgery.
+	securityHeader = "workload.spiffe.io"
+)
+
+// X509SVID is an X.509 SPIFFE Verifiable Identity Document along with the
+// trust bundle of its trust domain.
+type X509SVID struct {
+	// ID is the SPIFFE ID of the SVID.
+	ID string
+
+	// Certificates holds the certificate chain of the SVID, starting with its
+	// leaf certificate.
+	Certificates []*x509.Certificate
+
+	// PrivateKey is the PKCS#8 DER-encoded private key of the SVID.
+	PrivateKey []byte
+
+	// Bundle holds the CA certificates of the trust domain of the SVID.
+	Bundle []*x509.Certificate
+}
+
+// FetchX509SVIDs connects to the Workload API listening on the Unix socket at
+// socketPath and calls fn with the SVIDs of the workload every time they're
+// rotated. FetchX509SVIDs runs until ctx is canceled or the stream of SVIDs
+// fails.
+func FetchX509SVIDs(ctx context.Context, socketPath string, fn func([]X509SVID)) error {
+	conn, err := grpc.DialContext(ctx, "unix://"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("connecting to the Workload API: %w", err)
+	}
+	defer conn.Close()
+
+	ctx = metadata.AppendToOutgoingContext(ctx, securityHeader, "true")
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, fetchX509SVIDMethod, grpc.ForceCodec(rawCodec{}))
+	if err != nil {
+		return fmt.Errorf("fetching X.509 SVIDs: %w", err)
+	}
+
+	// X509SVIDRequest has no fields, so it's encoded as an empty message.
+	if err := stream.SendMsg([]byte{}); err != nil {
+		return fmt.Errorf("fetching X.509 SVIDs: %w", err)
+	}
+	if err := stream.CloseSend(); err != nil {
+		return fmt.Errorf("fetching X.509 SVIDs: %w", err)
+	}
+
+	for {
+		var msg []byte
+		if err := stream.RecvMsg(&msg); err != nil {
+			return fmt.Errorf("receiving X.509 SVIDs: %w", err)
+		}
+
+		svids, err := parseX509SVIDResponse(msg)
+		if err != nil {
+			return fmt.Errorf("invalid X.509 SVID response: %w", err)
+		}
+		fn(svids)
+	}
+}
+
+// rawCodec passes already encoded messages through to gRPC.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("unexpected message type %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Name returns the name of the protobuf codec, as the Workload API expects
+// protobuf messages.
+func (rawCodec) Name() string { return "proto" }
+
+// parseX509SVIDResponse decodes an X509SVIDResponse message:
+//
+//	message X509SVIDResponse {
+//	  repeated X509SVID svids = 1;
+//	  ...
+//	}
+func parseX509SVIDResponse(b []byte) ([]X509SVID, error) {
+	var svids []X509SVID
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		svid, err := parseX509SVID(value)
+		if err != nil {
+			return err
+		}
+		svids = append(svids, svid)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(svids) == 0 {
+		return nil, fmt.Errorf("no SVIDs in response")
+	}
+	return svids, nil
+}
+
+// parseX509SVID decodes an X509SVID message:
+//
+//	message X509SVID {
+//	  string spiffe_id = 1;
+//	  bytes x509_svid = 2;     // ASN.1 DER certificate chain.
+//	  bytes x509_svid_key = 3; // PKCS#8 DER private key.
+//	  bytes bundle = 4;        // ASN.1 DER CA certificates.
+//	  ...
+//	}
+func parseX509SVID(b []byte) (X509SVID, error) {
+	var (
+		svid                X509SVID
+		certsDER, bundleDER []byte
+	)
+	err := forEachField(b, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			svid.ID = string(value)
+		case 2:
+			certsDER = value
+		case 3:
+			svid.PrivateKey = append([]byte(nil), value...)
+		case 4:
+			bundleDER = value
+		}
+		return nil
+	})
+	if err != nil {
+		return svid, err
+	}
+
+	if svid.Certificates, err = x509.ParseCertificates(certsDER); err != nil {
+		return svid, fmt.Errorf("SVID %q: invalid certificates: %w", svid.ID, err)
+	} else if len(svid.Certificates) == 0 {
+		return svid, fmt.Errorf("SVID %q: no certificates", svid.ID)
+	}
+	if _, err := x509.ParsePKCS8PrivateKey(svid.PrivateKey); err != nil {
+		return svid, fmt.Errorf("SVID %q: invalid private key: %w", svid.ID, err)
+	}
+	if svid.Bundle, err = x509.ParseCertificates(bundleDER); err != nil {
+		return svid, fmt.Errorf("SVID %q: invalid bundle: %w", svid.ID, err)
+	}
+	return svid, nil
+}
+
+// forEachField calls fn for every field of the encoded message b. Only the
+// values of length-delimited fields are passed to fn.
+func forEachField(b []byte, fn func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var value []byte
+		if typ == protowire.BytesType {
+			value, n = protowire.ConsumeBytes(b)
+		} else {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		if err := fn(num, typ, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+// Package certreload reloads the certificates of TLS servers from files,
+// so that certificates rotated on disk (for example by cert-manager) are
+// picked up by new connections without restarting the server.
+package certreload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// DefaultCheckInterval is how often files are checked for changes.
+const DefaultCheckInterval = 10 * time.Second
+
+// Files are the files a Reloader loads TLS material from. ClientCAFile is
+// optional.
+type Files struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+}
+
+// Reloader holds the certificate and client CAs loaded from Files. Files are
+// checked for changes during TLS handshakes, at most once per check
+// interval. When files change but can't be loaded, for example because only
+// one of the certificate and key was rewritten yet, the error is logged and
+// the previous certificates keep being used.
+type Reloader struct {
+	log      log.Logger
+	files    Files
+	interval time.Duration
+
+	mut       sync.Mutex
+	lastCheck time.Time
+	stamps    []fileStamp
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// New creates a Reloader checking files for changes every interval. The
+// files are loaded immediately, and an error is returned if they can't be.
+// If interval is zero, DefaultCheckInterval is used.
+func New(l log.Logger, files Files, interval time.Duration) (*Reloader, error) {
+	if files.CertFile == "" {
+		return nil, fmt.Errorf("missing certificate file")
+	}
+	if files.KeyFile == "" {
+		return nil, fmt.Errorf("missing key file")
+	}
+	if interval == 0 {
+		interval = DefaultCheckInterval
+	}
+
+	r := &Reloader{log: l, files: files, interval: interval}
+	if err := r.load(r.stat()); err != nil {
+		return nil, err
+	}
+	r.lastCheck = time.Now()
+	return r, nil
+}
+
+// paths returns the paths of all files to watch.
+func (r *Reloader) paths() []string {
+	paths := []string{r.files.CertFile, r.files.KeyFile}
+	if r.files.ClientCAFile != "" {
+		paths = append(paths, r.files.ClientCAFile)
+	}
+	return paths
+}
+
+// stat returns the current stamps of the watched files. Files which can't be
+// read have a zero stamp.
+func (r *Reloader) stat() []fileStamp {
+	paths := r.paths()
+	stamps := make([]fileStamp, len(paths))
+	for i, path := range paths {
+		if fi, err := os.Stat(path); err == nil {
+			stamps[i] = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+		}
+	}
+	return stamps
+}
+
+// load loads all files, remembering stamps as the version loaded. load must
+// be called with mut held.
+func (r *Reloader) load(stamps []fileStamp) error {
+	cert, err := tls.LoadX509KeyPair(r.files.CertFile, r.files.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.files.ClientCAFile != "" {
+		bb, err := os.ReadFile(r.files.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(bb) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.files.ClientCAFile)
+		}
+	}
+
+	r.cert, r.clientCAs, r.stamps = &cert, clientCAs, stamps
+	return nil
+}
+
+// maybeReload reloads the files if the check interval passed and they
+// changed since they were last loaded.
+func (r *Reloader) maybeReload() {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.lastCheck) < r.interval {
+		return
+	}
+	r.lastCheck = now
+
+	stamps := r.stat()
+	if equalStamps(stamps, r.stamps) {
+		return
+	}
+	if err := r.load(stamps); err != nil {
+		level.Error(r.log).Log("msg", "failed to reload TLS certificates, keeping the previous ones", "err", err)
+		// Remember the stamps anyway so that the error isn't logged on every
+		// check until the files change again.
+		r.stamps = stamps
+		return
+	}
+	level.Info(r.log).Log("msg", "reloaded TLS certificates", "cert_file", r.files.CertFile)
+}
+
+func equalStamps(a, b []fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
+
+// GetCertificate returns the current certificate. It can be used as
+// tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.maybeReload()
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.cert, nil
+}
+
+// ClientCAs returns the current pool of client CAs, or nil if no client CA
+// file is configured.
+func (r *Reloader) ClientCAs() *x509.CertPool {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.clientCAs
+}
+
+// Apply configures cfg to use the certificates of r. cfg.ClientAuth must be
+// set before calling Apply.
+//
+// Client certificates are verified against the current client CAs by
+// cfg.VerifyConnection rather than by crypto/tls, which only supports a
+// fixed pool. Apply doesn't replace the config, since servers such as
+// http.Server.ServeTLS clone it and add settings of their own.
+func (r *Reloader) Apply(cfg *tls.Config) {
+	cfg.Certificates = nil
+	cfg.GetCertificate = r.GetCertificate
+
+	if r.files.ClientCAFile == "" {
+		return
+	}
+	// No list of acceptable CAs is sent to clients: a list which went stale
+	// after the CAs changed would make clients withhold certificates issued
+	// by the new CAs.
+	cfg.ClientCAs = nil
+
+	switch cfg.ClientAuth {
+	case tls.VerifyClientCertIfGiven:
+		cfg.ClientAuth = tls.RequestClientCert
+	case tls.RequireAndVerifyClientCert:
+		cfg.ClientAuth = tls.RequireAnyClientCert
+	default:
+		// Client certificates aren't verified.
+		return
+	}
+	cfg.VerifyConnection = r.verifyClientCert
+}
+
+// verifyClientCert verifies the certificate presented by a client against the
+// current client CAs, as crypto/tls does for VerifyClientCertIfGiven.
+// Connections without a certificate have been rejected already if one is
+// required.
+func (r *Reloader) verifyClientCert(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return nil
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         r.ClientCAs(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	if _, err := cs.PeerCertificates[0].Verify(opts); err != nil {
+		return fmt.Errorf("failed to verify client certificate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package certreload
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_ReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := Files{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+	writeCert(t, files, newTestCert(t, "first"), time.Now())
+
+	r, err := New(log.NewNopLogger(), files, time.Nanosecond)
+	require.NoError(t, err)
+	require.Equal(t, "first", commonName(t, r))
+
+	writeCert(t, files, newTestCert(t, "second"), time.Now().Add(time.Minute))
+	require.Equal(t, "second", commonName(t, r))
+
+	// Files which can't be loaded keep the previous certificate in use.
+	require.NoError(t, os.WriteFile(files.KeyFile, []byte("garbage"), 0600))
+	require.Equal(t, "second", commonName(t, r))
+}
+
+func TestReloader_ChecksOncePerInterval(t *testing.T) {
+	dir := t.TempDir()
+	files := Files{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+	writeCert(t, files, newTestCert(t, "first"), time.Now())
+
+	r, err := New(log.NewNopLogger(), files, time.Hour)
+	require.NoError(t, err)
+
+	writeCert(t, files, newTestCert(t, "second"), time.Now().Add(time.Minute))
+	require.Equal(t, "first", commonName(t, r))
+}
+
+func TestNew_InvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := Files{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}
+	_, err := New(log.NewNopLogger(), files, 0)
+	require.ErrorContains(t, err, "failed to load key pair")
+
+	writeCert(t, files, newTestCert(t, "server"), time.Now())
+	files.ClientCAFile = files.KeyFile
+	_, err = New(log.NewNopLogger(), files, 0)
+	require.ErrorContains(t, err, "no certificates found in client CA file")
+}
+
+func TestReloader_ClientCAs(t *testing.T) {
+	dir := t.TempDir()
+	files := Files{
+		CertFile:     filepath.Join(dir, "cert.pem"),
+		KeyFile:      filepath.Join(dir, "key.pem"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	}
+	writeCert(t, files, newTestCert(t, "server"), time.Now())
+
+	oldClient, newClient := newTestCert(t, "old-client"), newTestCert(t, "new-client")
+	writeCA(t, files.ClientCAFile, oldClient, time.Now())
+
+	r, err := New(log.NewNopLogger(), files, time.Nanosecond)
+	require.NoError(t, err)
+	cfg := &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}
+	r.Apply(cfg)
+
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", cfg)
+	require.NoError(t, err)
+	defer lis.Close()
+
+	require.NoError(t, handshake(t, lis, &oldClient))
+	require.Error(t, handshake(t, lis, &newClient))
+	require.Error(t, handshake(t, lis, nil), "connections without a client certificate must be rejected")
+
+	writeCA(t, files.ClientCAFile, newClient, time.Now().Add(time.Minute))
+	require.NoError(t, handshake(t, lis, &newClient))
+	require.Error(t, handshake(t, lis, &oldClient))
+}
+
+// handshake connects to lis with the client certificate cert and returns the
+// error of the handshake on the server side.
+func handshake(t *testing.T, lis net.Listener, cert *tls.Certificate) error {
+	t.Helper()
+
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+		errc <- conn.(*tls.Conn).Handshake()
+	}()
+
+	clientCfg := &tls.Config{InsecureSkipVerify: true}
+	if cert != nil {
+		clientCfg.Certificates = []tls.Certificate{*cert}
+	}
+	conn, err := tls.Dial("tcp", lis.Addr().String(), clientCfg)
+	if err == nil {
+		defer conn.Close()
+	}
+	return <-errc
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+// newTestCert returns a self-signed certificate which can also be used as
+// its own CA.
+func newTestCert(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// writeCert writes the certificate and key of cert to files, setting their
+// modification time to mtime so that changes are detected regardless of the
+// resolution of the file system.
+func writeCert(t *testing.T, files Files, cert tls.Certificate, mtime time.Time) {
+	t.Helper()
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+	writePEM(t, files.KeyFile, "PRIVATE KEY", keyDER, mtime)
+	writePEM(t, files.CertFile, "CERTIFICATE", cert.Certificate[0], mtime)
+}
+
+func writeCA(t *testing.T, path string, cert tls.Certificate, mtime time.Time) {
+	t.Helper()
+	writePEM(t, path, "CERTIFICATE", cert.Certificate[0], mtime)
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte, mtime time.Time) {
+	t.Helper()
+
+	bb := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	require.NoError(t, os.WriteFile(path, bb, 0600))
+	require.NoError(t, os.Chtimes(path, mtime, mtime))
+}
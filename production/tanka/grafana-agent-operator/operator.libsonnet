@@ -26,13 +26,17 @@
     rbac: 
       k.util.rbac(name, [
           policyRule.withApiGroups(['monitoring.grafana.com']) +
-          policyRule.withResources(['grafanaagents', 'metricsinstances', 'logsinstances', 'podlogs', 'integrations']) +
+          policyRule.withResources(['grafanaagents', 'grafanaagentflows', 'metricsinstances', 'logsinstances', 'podlogs', 'integrations']) +
           policyRule.withVerbs(['get', 'list', 'watch']),
 
           policyRule.withApiGroups(['monitoring.grafana.com']) +
-          policyRule.withResources(['grafanaagents/finalizers', 'metricsinstances/finalizers', 'logsinstances/finalizers', 'podlogs/finalizers', 'integrations/finalizers']) +
+          policyRule.withResources(['grafanaagents/finalizers', 'grafanaagentflows/finalizers', 'metricsinstances/finalizers', 'logsinstances/finalizers', 'podlogs/finalizers', 'integrations/finalizers']) +
           policyRule.withVerbs(['get', 'list', 'watch', 'update']),
 
+          policyRule.withApiGroups(['monitoring.grafana.com']) +
+          policyRule.withResources(['grafanaagentflows/status']) +
+          policyRule.withVerbs(['get', 'update', 'patch']),
+
           policyRule.withApiGroups(['monitoring.coreos.com']) +
           policyRule.withResources(['podmonitors', 'probes', 'servicemonitors']) +
           policyRule.withVerbs(['get', 'list', 'watch']),
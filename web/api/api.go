@@ -5,18 +5,34 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"path"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/grafana/agent/component"
+	"github.com/grafana/agent/pkg/river/encoding/riverjson"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/util/httputil"
 )
 
 // FlowAPI is a wrapper around the component API.
 type FlowAPI struct {
 	flow component.Provider
+
+	// editor and editorToken are set in read-write mode.
+	editor      ArgumentsEditor
+	editorToken string
+
+	// gatherer is set when throughput, resources, and usage of components are
+	// exposed.
+	gatherer prometheus.Gatherer
+
+	// totals is set when lifetime totals of components are exposed.
+	totals *LifetimeTotals
 }
 
 // NewFlowAPI instantiates a new Flow API.
@@ -24,10 +40,39 @@ func NewFlowAPI(flow component.Provider) *FlowAPI {
 	return &FlowAPI{flow: flow}
 }
 
+// EnableReadWrite enables editing the arguments of components through the
+// API with editor. Requests reading or editing arguments must be
+// authenticated with token as a bearer token.
+func (f *FlowAPI) EnableReadWrite(editor ArgumentsEditor, token string) {
+	f.editor = editor
+	f.editorToken = token
+}
+
+// EnableThroughput exposes the throughput, resources, and usage of components, read
+// from the component metrics gathered from g.
+func (f *FlowAPI) EnableThroughput(g prometheus.Gatherer) {
+	f.gatherer = g
+}
+
+// EnableLifetimeTotals exposes the lifetime totals of components kept by
+// totals.
+func (f *FlowAPI) EnableLifetimeTotals(totals *LifetimeTotals) {
+	f.totals = totals
+}
+
 // RegisterRoutes registers all the API's routes.
 func (f *FlowAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 	r.Handle(path.Join(urlPrefix, "/components"), httputil.CompressionHandler{Handler: f.listComponentsHandler()})
 	r.Handle(path.Join(urlPrefix, "/components/{id}"), httputil.CompressionHandler{Handler: f.getComponentHandler()})
+	r.Handle(path.Join(urlPrefix, "/migrations"), httputil.CompressionHandler{Handler: f.listMigrationsHandler()})
+	r.Handle(path.Join(urlPrefix, "/components/{id}/schema"), httputil.CompressionHandler{Handler: f.getSchemaHandler()})
+	r.Handle(path.Join(urlPrefix, "/components/{id}/arguments"), f.editorHandler(f.getArgumentsHandler())).Methods(http.MethodGet)
+	r.Handle(path.Join(urlPrefix, "/components/{id}/arguments"), f.editorHandler(f.updateArgumentsHandler())).Methods(http.MethodPut)
+	r.Handle(path.Join(urlPrefix, "/components/{id}/debug/stream"), f.liveDebuggingHandler()).Methods(http.MethodGet)
+	r.Handle(path.Join(urlPrefix, "/throughput"), httputil.CompressionHandler{Handler: f.throughputHandler()})
+	r.Handle(path.Join(urlPrefix, "/totals"), httputil.CompressionHandler{Handler: f.totalsHandler()})
+	r.Handle(path.Join(urlPrefix, "/resources"), httputil.CompressionHandler{Handler: f.resourcesHandler()})
+	r.Handle(path.Join(urlPrefix, "/usage"), httputil.CompressionHandler{Handler: f.usageHandler()})
 }
 
 func (f *FlowAPI) listComponentsHandler() http.HandlerFunc {
@@ -72,3 +117,235 @@ func (f *FlowAPI) getComponentHandler() http.HandlerFunc {
 		_, _ = w.Write(bb)
 	}
 }
+
+// liveDebuggingHandler streams samples of the data flowing through a
+// component. Responses aren't compressed so that samples are flushed to the
+// client as soon as they're sent.
+func (f *FlowAPI) liveDebuggingHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		info, err := f.flow.GetComponent(component.ID{
+			ModuleID: "", // TODO(rfratto): support getting component from module.
+			LocalID:  mux.Vars(r)["id"],
+		}, component.InfoOptions{})
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		ldc, ok := info.Component.(component.LiveDebuggingComponent)
+		if !ok {
+			http.Error(w, "component doesn't support live debugging", http.StatusNotFound)
+			return
+		}
+		ldc.LiveDebugging().ServeHTTP(w, r)
+	}
+}
+
+// listMigrationsHandler returns the components whose deprecated arguments
+// were migrated to their replacements when they were loaded.
+func (f *FlowAPI) listMigrationsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		components := f.flow.ListComponents(component.InfoOptions{})
+
+		migrated := make([]*component.Info, 0, len(components))
+		for _, c := range components {
+			if len(c.Migrations) > 0 {
+				migrated = append(migrated, c)
+			}
+		}
+
+		bb, err := json.Marshal(migrated)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+func (f *FlowAPI) getSchemaHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		component, err := f.flow.GetComponent(component.ID{LocalID: mux.Vars(r)["id"]}, component.InfoOptions{})
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		bb, err := riverjson.MarshalSchema(component.Registration.Args)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+// throughputHandler returns the current totals of the throughput counters of
+// components. Rates are computed by clients from successive responses.
+func (f *FlowAPI) throughputHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if f.gatherer == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		components, err := gatherThroughput(f.gatherer)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		bb, err := json.Marshal(struct {
+			Timestamp  time.Time                      `json:"timestamp"`
+			Components map[string]ComponentThroughput `json:"components"`
+		}{time.Now(), components})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+// totalsHandler returns the lifetime totals of the throughput and loss
+// counters of components, which are kept across restarts.
+func (f *FlowAPI) totalsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if f.totals == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		components, err := f.totals.Totals()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		bb, err := json.Marshal(struct {
+			Components map[string]ComponentTotals `json:"components"`
+		}{components})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+// resourcesHandler returns the goroutines and CPU time attributed to
+// components.
+func (f *FlowAPI) resourcesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if f.gatherer == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		components, err := gatherResources(f.gatherer)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		bb, err := json.Marshal(struct {
+			Components map[string]ComponentResources `json:"components"`
+		}{components})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+// usageHandler returns the active series and log lines and bytes forwarded
+// by write components, by destination and source component.
+func (f *FlowAPI) usageHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if f.gatherer == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		components, err := gatherUsage(f.gatherer)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		bb, err := json.Marshal(struct {
+			Components map[string]ComponentUsage `json:"components"`
+		}{components})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+// editorHandler wraps handlers using the ArgumentsEditor, rejecting requests
+// when read-write mode is disabled or the request isn't authenticated.
+func (f *FlowAPI) editorHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.editor == nil {
+			http.Error(w, "read-write mode is disabled", http.StatusForbidden)
+			return
+		}
+
+		expect := "Bearer " + f.editorToken
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expect)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *FlowAPI) getArgumentsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		args, err := f.editor.ComponentArguments(mux.Vars(r)["id"])
+		if err != nil {
+			writeEditorError(w, err)
+			return
+		}
+
+		bb, err := json.Marshal(args)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(bb)
+	}
+}
+
+func (f *FlowAPI) updateArgumentsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var args EditableArguments
+		if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := f.editor.UpdateComponentArguments(mux.Vars(r)["id"], &args); err != nil {
+			writeEditorError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeEditorError(w http.ResponseWriter, err error) {
+	var validationErr ValidationError
+	switch {
+	case errors.Is(err, ErrComponentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.As(err, &validationErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/grafana/agent/pkg/river/ast"
+	"github.com/grafana/agent/pkg/river/parser"
+	"github.com/grafana/agent/pkg/river/printer"
+)
+
+// ErrComponentNotFound is returned by an ArgumentsEditor when the component to
+// edit isn't defined in the config file.
+var ErrComponentNotFound = errors.New("component not found in the config file")
+
+// ValidationError is returned by an ArgumentsEditor when the edited arguments
+// are invalid.
+type ValidationError struct {
+	Message string
+}
+
+// Error implements error.
+func (e ValidationError) Error() string { return e.Message }
+
+// EditableArguments are the arguments of a component as written in the config
+// file.
+type EditableArguments struct {
+	// Attributes holds the River expressions of the attributes of the
+	// component, keyed by name.
+	Attributes map[string]string `json:"attributes"`
+
+	// Blocks holds the River source of the blocks of the component.
+	Blocks string `json:"blocks"`
+}
+
+// ArgumentsEditor edits the arguments of components in the config file.
+type ArgumentsEditor interface {
+	// ComponentArguments returns the arguments of the component with the
+	// given ID.
+	ComponentArguments(id string) (*EditableArguments, error)
+
+	// UpdateComponentArguments replaces the arguments of the component with
+	// the given ID and reloads the config file.
+	UpdateComponentArguments(id string, args *EditableArguments) error
+}
+
+// fileEditor is an ArgumentsEditor editing a River file on disk.
+type fileEditor struct {
+	path   string
+	reload func() error
+
+	// mut serializes edits of the file.
+	mut sync.Mutex
+}
+
+// NewFileEditor returns an ArgumentsEditor which edits the top-level
+// components of the River file at path. reload is called after each edit to
+// apply the changes; the original file is restored if reload fails.
+func NewFileEditor(path string, reload func() error) ArgumentsEditor {
+	return &fileEditor{path: path, reload: reload}
+}
+
+// ComponentArguments implements ArgumentsEditor.
+func (e *fileEditor) ComponentArguments(id string) (*EditableArguments, error) {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	src, block, err := e.findBlock(id)
+	if err != nil {
+		return nil, err
+	}
+
+	args := &EditableArguments{Attributes: make(map[string]string)}
+
+	var blocks []string
+	for _, stmt := range block.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			args.Attributes[stmt.Name.Name] = string(nodeSource(src, stmt.Value))
+		case *ast.BlockStmt:
+			blocks = append(blocks, string(nodeSource(src, stmt)))
+		}
+	}
+	args.Blocks = strings.Join(blocks, "\n\n")
+
+	return args, nil
+}
+
+// UpdateComponentArguments implements ArgumentsEditor.
+func (e *fileEditor) UpdateComponentArguments(id string, args *EditableArguments) error {
+	e.mut.Lock()
+	defer e.mut.Unlock()
+
+	src, block, err := e.findBlock(id)
+	if err != nil {
+		return err
+	}
+
+	body, err := buildBody(block, args)
+	if err != nil {
+		return err
+	}
+
+	var edited bytes.Buffer
+	edited.Write(src[:block.LCurlyPos.Offset()+1])
+	edited.WriteString("\n")
+	edited.Write(body)
+	edited.Write(src[block.RCurlyPos.Offset():])
+
+	// Reformat the file so the new arguments are aligned with the rest of the
+	// file.
+	file, err := parser.ParseFile(e.path, edited.Bytes())
+	if err != nil {
+		return ValidationError{Message: err.Error()}
+	}
+	var formatted bytes.Buffer
+	if err := printer.Fprint(&formatted, file); err != nil {
+		return err
+	}
+	formatted.WriteByte('\n')
+
+	info, err := os.Stat(e.path)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(e.path, formatted.Bytes(), info.Mode()); err != nil {
+		return err
+	}
+
+	if reloadErr := e.reload(); reloadErr != nil {
+		if err := os.WriteFile(e.path, src, info.Mode()); err != nil {
+			return fmt.Errorf("%s; restoring the original config file failed: %w", reloadErr, err)
+		}
+		if err := e.reload(); err != nil {
+			return fmt.Errorf("%s; reloading the original config file failed: %w", reloadErr, err)
+		}
+		return ValidationError{Message: reloadErr.Error()}
+	}
+	return nil
+}
+
+// findBlock reads the config file and returns its source and the top-level
+// block defining the component with the given ID.
+func (e *fileEditor) findBlock(id string) ([]byte, *ast.BlockStmt, error) {
+	src, err := os.ReadFile(e.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := parser.ParseFile(e.path, src)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, stmt := range file.Body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok {
+			continue
+		}
+		blockID := strings.Join(block.Name, ".")
+		if block.Label != "" {
+			blockID += "." + block.Label
+		}
+		if blockID == id {
+			return src, block, nil
+		}
+	}
+	return nil, nil, ErrComponentNotFound
+}
+
+// buildBody returns the River source of the body of block with the given
+// arguments. Attributes keep the order they had in block.
+func buildBody(block *ast.BlockStmt, args *EditableArguments) ([]byte, error) {
+	var names []string
+	seen := make(map[string]struct{}, len(args.Attributes))
+	for _, stmt := range block.Body {
+		if attr, ok := stmt.(*ast.AttributeStmt); ok {
+			if _, ok := args.Attributes[attr.Name.Name]; ok {
+				names = append(names, attr.Name.Name)
+				seen[attr.Name.Name] = struct{}{}
+			}
+		}
+	}
+	var added []string
+	for name := range args.Attributes {
+		if _, ok := seen[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	names = append(names, added...)
+
+	var body bytes.Buffer
+	for _, name := range names {
+		expr := strings.TrimSpace(args.Attributes[name])
+		if expr == "" {
+			continue
+		}
+		if _, err := parser.ParseExpression(expr); err != nil {
+			return nil, ValidationError{Message: fmt.Sprintf("invalid value for %q: %s", name, err)}
+		}
+		fmt.Fprintf(&body, "%s = %s\n", name, expr)
+	}
+
+	if blocks := strings.TrimSpace(args.Blocks); blocks != "" {
+		file, err := parser.ParseFile("blocks", []byte(blocks))
+		if err != nil {
+			return nil, ValidationError{Message: fmt.Sprintf("invalid blocks: %s", err)}
+		}
+		for _, stmt := range file.Body {
+			if _, ok := stmt.(*ast.BlockStmt); !ok {
+				return nil, ValidationError{Message: "blocks may only contain blocks, use attributes to set attributes"}
+			}
+		}
+		body.WriteString("\n")
+		body.WriteString(blocks)
+		body.WriteString("\n")
+	}
+
+	return body.Bytes(), nil
+}
+
+// nodeSource returns the source of n.
+func nodeSource(src []byte, n ast.Node) []byte {
+	return src[ast.StartPos(n).Offset() : ast.EndPos(n).Offset()+1]
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const editorTestConfig = `// Scrapes the local agent.
+prometheus.scrape "default" {
+  targets    = [{"__address__" = "localhost:12345"}]
+  forward_to = [prometheus.remote_write.default.receiver]
+
+  clustering {
+    enabled = true
+  }
+}
+
+prometheus.remote_write "default" {
+  endpoint {
+    url = "http://localhost:9009/api/prom/push"
+  }
+}
+`
+
+func newTestEditor(t *testing.T, reload func() error) (ArgumentsEditor, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.river")
+	require.NoError(t, os.WriteFile(path, []byte(editorTestConfig), 0o640))
+	return NewFileEditor(path, reload), path
+}
+
+func TestFileEditor_ComponentArguments(t *testing.T) {
+	editor, _ := newTestEditor(t, func() error { return nil })
+
+	args, err := editor.ComponentArguments("prometheus.scrape.default")
+	require.NoError(t, err)
+	require.Equal(t, &EditableArguments{
+		Attributes: map[string]string{
+			"targets":    `[{"__address__" = "localhost:12345"}]`,
+			"forward_to": `[prometheus.remote_write.default.receiver]`,
+		},
+		Blocks: "clustering {\n    enabled = true\n  }",
+	}, args)
+
+	_, err = editor.ComponentArguments("prometheus.scrape.missing")
+	require.ErrorIs(t, err, ErrComponentNotFound)
+}
+
+func TestFileEditor_UpdateComponentArguments(t *testing.T) {
+	var reloads int
+	editor, path := newTestEditor(t, func() error {
+		reloads++
+		return nil
+	})
+
+	err := editor.UpdateComponentArguments("prometheus.scrape.default", &EditableArguments{
+		Attributes: map[string]string{
+			"targets":         `[{"__address__" = "localhost:12345"}]`,
+			"forward_to":      `[prometheus.remote_write.default.receiver]`,
+			"scrape_interval": `"15s"`,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, reloads)
+
+	bb, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, `// Scrapes the local agent.
+prometheus.scrape "default" {
+	targets         = [{"__address__" = "localhost:12345"}]
+	forward_to      = [prometheus.remote_write.default.receiver]
+	scrape_interval = "15s"
+}
+
+prometheus.remote_write "default" {
+	endpoint {
+		url = "http://localhost:9009/api/prom/push"
+	}
+}
+`, string(bb))
+}
+
+func TestFileEditor_InvalidArguments(t *testing.T) {
+	editor, path := newTestEditor(t, func() error { return nil })
+
+	err := editor.UpdateComponentArguments("prometheus.scrape.default", &EditableArguments{
+		Attributes: map[string]string{"targets": `[{"__address__" = }]`},
+	})
+	require.ErrorAs(t, err, &ValidationError{})
+
+	err = editor.UpdateComponentArguments("prometheus.scrape.default", &EditableArguments{
+		Blocks: `enabled = true`,
+	})
+	require.EqualError(t, err, "blocks may only contain blocks, use attributes to set attributes")
+
+	bb, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, editorTestConfig, string(bb))
+}
+
+func TestFileEditor_ReloadFailure(t *testing.T) {
+	var reloads int
+	editor, path := newTestEditor(t, func() error {
+		reloads++
+		if reloads == 1 {
+			return errors.New("unrecognized attribute name \"bad\"")
+		}
+		return nil
+	})
+
+	err := editor.UpdateComponentArguments("prometheus.remote_write.default", &EditableArguments{
+		Attributes: map[string]string{"bad": `true`},
+	})
+	require.EqualError(t, err, "unrecognized attribute name \"bad\"")
+	require.ErrorAs(t, err, &ValidationError{})
+
+	// The original file is restored and reloaded.
+	require.Equal(t, 2, reloads)
+	bb, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, editorTestConfig, string(bb))
+}
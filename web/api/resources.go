@@ -0,0 +1,57 @@
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ComponentResources holds the resources attributed to a component.
+type ComponentResources struct {
+	Goroutines int `json:"goroutines"`
+
+	// CPUSeconds is only set when the CPU time of components is sampled.
+	CPUSeconds *float64 `json:"cpuSeconds,omitempty"`
+}
+
+// Metrics of the controller accounting for the resources of components.
+const (
+	goroutinesMetric = "agent_component_goroutines"
+	cpuSecondsMetric = "agent_component_cpu_seconds_total"
+)
+
+// gatherResources returns the resources attributed to each component
+// gathered from g, keyed by component ID.
+func gatherResources(g prometheus.Gatherer) (map[string]ComponentResources, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]ComponentResources)
+	for _, mf := range families {
+		switch mf.GetName() {
+		case goroutinesMetric:
+			for _, m := range mf.GetMetric() {
+				id := componentID(m)
+				if id == "" || m.GetGauge() == nil {
+					continue
+				}
+				r := res[id]
+				r.Goroutines = int(m.GetGauge().GetValue())
+				res[id] = r
+			}
+
+		case cpuSecondsMetric:
+			for _, m := range mf.GetMetric() {
+				id := componentID(m)
+				if id == "" || m.GetCounter() == nil {
+					continue
+				}
+				r := res[id]
+				seconds := m.GetCounter().GetValue()
+				r.CPUSeconds = &seconds
+				res[id] = r
+			}
+		}
+	}
+	return res, nil
+}
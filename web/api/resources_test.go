@@ -0,0 +1,29 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherResources(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	goroutines := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "agent_component_goroutines"}, []string{"component_id"})
+	cpuSeconds := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "agent_component_cpu_seconds_total"}, []string{"component_id"})
+	reg.MustRegister(goroutines, cpuSeconds)
+
+	goroutines.WithLabelValues("loki.source.file.logs").Set(4)
+	goroutines.WithLabelValues("loki.write.default").Set(12)
+	cpuSeconds.WithLabelValues("loki.write.default").Add(1.5)
+
+	res, err := gatherResources(reg)
+	require.NoError(t, err)
+
+	cpu := 1.5
+	require.Equal(t, map[string]ComponentResources{
+		"loki.source.file.logs": {Goroutines: 4},
+		"loki.write.default":    {Goroutines: 12, CPUSeconds: &cpu},
+	}, res)
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ThroughputKind is the kind of data a throughput counter counts.
+type ThroughputKind string
+
+// Kinds of data counted by throughput counters.
+const (
+	ThroughputSamples ThroughputKind = "samples"
+	ThroughputLines   ThroughputKind = "lines"
+	ThroughputSpans   ThroughputKind = "spans"
+	ThroughputBytes   ThroughputKind = "bytes"
+)
+
+// throughputMetrics holds the existing component metrics counting the data
+// components send, either to the components they're connected to or out of
+// the agent. Only one metric of each kind is listed per component, so that
+// the data isn't counted twice.
+var throughputMetrics = map[string]ThroughputKind{
+	// prometheus.* components writing to their forward_to list.
+	"agent_prometheus_forwarded_samples_total": ThroughputSamples,
+
+	// prometheus.remote_write.
+	"prometheus_remote_storage_samples_total": ThroughputSamples,
+	"prometheus_remote_storage_bytes_total":   ThroughputBytes,
+
+	// loki.source.*.
+	"loki_source_file_read_lines_total":           ThroughputLines,
+	"loki_source_journal_target_lines_total":      ThroughputLines,
+	"loki_source_syslog_entries_total":            ThroughputLines,
+	"loki_source_docker_target_entries_total":     ThroughputLines,
+	"loki_source_cloudflare_target_entries_total": ThroughputLines,
+	"loki_source_gcplog_pull_entries_total":       ThroughputLines,
+	"loki_source_gcplog_push_entries_total":       ThroughputLines,
+	"loki_source_heroku_drain_entries_total":      ThroughputLines,
+	"loki_source_gelf_target_entries_total":       ThroughputLines,
+	"loki_source_fluentforward_entries_total":     ThroughputLines,
+	"loki_source_lumberjack_entries_total":        ThroughputLines,
+	"loki_source_kafka_entries_total":             ThroughputLines,
+
+	// loki.* components processing entries.
+	"loki_route_entries_processed_total":        ThroughputLines,
+	"loki_secretfilter_entries_processed_total": ThroughputLines,
+
+	// loki.write.
+	"loki_write_sent_entries_total": ThroughputLines,
+	"loki_write_sent_bytes_total":   ThroughputBytes,
+
+	// pyroscope.write.
+	"pyroscope_write_sent_bytes_total": ThroughputBytes,
+}
+
+// otelThroughputMetric matches the metrics of otelcol.* components counting
+// the telemetry accepted by receivers and processors and sent by exporters.
+var otelThroughputMetric = regexp.MustCompile(`^(?:otelcol_)?(?:receiver_accepted|processor_accepted|exporter_sent)_(spans|metric_points|log_records)(?:_total)?$`)
+
+// throughputKind returns the kind of data counted by the metric with the
+// given name, if it's a throughput counter.
+func throughputKind(name string) (ThroughputKind, bool) {
+	if kind, ok := throughputMetrics[name]; ok {
+		return kind, true
+	}
+
+	m := otelThroughputMetric.FindStringSubmatch(name)
+	if m == nil {
+		return "", false
+	}
+	switch m[1] {
+	case "spans":
+		return ThroughputSpans, true
+	case "metric_points":
+		return ThroughputSamples, true
+	default:
+		return ThroughputLines, true
+	}
+}
+
+// ComponentThroughput holds the totals of the throughput counters of a
+// component, by kind.
+type ComponentThroughput map[ThroughputKind]float64
+
+// gatherThroughput returns the totals of the throughput counters of each
+// component gathered from g, keyed by component ID.
+func gatherThroughput(g prometheus.Gatherer) (map[string]ComponentThroughput, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]ComponentThroughput)
+	for _, mf := range families {
+		kind, ok := throughputKind(mf.GetName())
+		if !ok {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			id := componentID(m)
+			if id == "" || m.GetCounter() == nil {
+				continue
+			}
+
+			if res[id] == nil {
+				res[id] = make(ComponentThroughput)
+			}
+			res[id][kind] += m.GetCounter().GetValue()
+		}
+	}
+	return res, nil
+}
+
+// componentID returns the ID of the component which exposed m, or an empty
+// string if m isn't a component metric.
+func componentID(m *dto.Metric) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == "component_id" {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+// ComponentOutputs returns a function reporting the totals of the throughput
+// counters of each component gathered from g, keyed by component ID and then
+// by kind. It's used by the Flow controller to check that components keep
+// producing output.
+func ComponentOutputs(g prometheus.Gatherer) func() (map[string]map[string]float64, error) {
+	return func() (map[string]map[string]float64, error) {
+		components, err := gatherThroughput(g)
+		if err != nil {
+			return nil, err
+		}
+		res := make(map[string]map[string]float64, len(components))
+		for id, throughput := range components {
+			res[id] = make(map[string]float64, len(throughput))
+			for kind, v := range throughput {
+				res[id][string(kind)] = v
+			}
+		}
+		return res, nil
+	}
+}
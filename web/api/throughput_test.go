@@ -0,0 +1,43 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherThroughput(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	componentRegisterer := func(id string) prometheus.Registerer {
+		return prometheus.WrapRegistererWith(prometheus.Labels{"component_id": id}, reg)
+	}
+
+	lines := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "loki_source_file_read_lines_total"}, []string{"path"})
+	componentRegisterer("loki.source.file.logs").MustRegister(lines)
+	lines.WithLabelValues("/var/log/a.log").Add(10)
+	lines.WithLabelValues("/var/log/b.log").Add(5)
+
+	sentEntries := prometheus.NewCounter(prometheus.CounterOpts{Name: "loki_write_sent_entries_total"})
+	sentBytes := prometheus.NewCounter(prometheus.CounterOpts{Name: "loki_write_sent_bytes_total"})
+	droppedEntries := prometheus.NewCounter(prometheus.CounterOpts{Name: "loki_write_dropped_entries_total"})
+	componentRegisterer("loki.write.default").MustRegister(sentEntries, sentBytes, droppedEntries)
+	sentEntries.Add(15)
+	sentBytes.Add(1024)
+	droppedEntries.Add(3)
+
+	spans := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "receiver_accepted_spans"}, []string{"transport"})
+	componentRegisterer("otelcol.receiver.otlp.default").MustRegister(spans)
+	spans.WithLabelValues("grpc").Add(7)
+
+	// Throughput metrics without a component ID aren't from components.
+	reg.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "agent_prometheus_forwarded_samples_total"}))
+
+	res, err := gatherThroughput(reg)
+	require.NoError(t, err)
+	require.Equal(t, map[string]ComponentThroughput{
+		"loki.source.file.logs":         {ThroughputLines: 15},
+		"loki.write.default":            {ThroughputLines: 15, ThroughputBytes: 1024},
+		"otelcol.receiver.otlp.default": {ThroughputSpans: 7},
+	}, res)
+}
@@ -0,0 +1,295 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/agent/pkg/kv"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// LossKind is the kind of data loss a loss counter counts.
+type LossKind string
+
+// Kinds of data loss counted by loss counters.
+const (
+	// LossDropped counts data which components discarded, such as when queues
+	// are full or filters drop it.
+	LossDropped LossKind = "dropped"
+	// LossErrors counts data which components failed to parse or send.
+	LossErrors LossKind = "errors"
+)
+
+// lossMetrics holds the existing component metrics counting data which
+// components lost, either by dropping it or because of errors.
+var lossMetrics = map[string]LossKind{
+	// prometheus.*.
+	"agent_prometheus_route_samples_dropped_total":    LossDropped,
+	"prometheus_remote_storage_samples_dropped_total": LossDropped,
+	"prometheus_remote_storage_samples_failed_total":  LossErrors,
+
+	// loki.source.*.
+	"loki_source_journal_target_parsing_errors_total": LossErrors,
+	"loki_source_syslog_parsing_errors_total":         LossErrors,
+	"loki_source_docker_target_parsing_errors_total":  LossErrors,
+	"loki_source_gcplog_pull_parsing_errors_total":    LossErrors,
+	"loki_source_gcplog_push_parsing_errors_total":    LossErrors,
+	"loki_source_heroku_drain_parsing_errors_total":   LossErrors,
+	"loki_source_gelf_target_parsing_errors_total":    LossErrors,
+	"loki_source_fluentforward_parsing_errors_total":  LossErrors,
+	"loki_source_lumberjack_parsing_errors_total":     LossErrors,
+	"loki_source_kubernetes_audit_event_errors_total": LossErrors,
+
+	// loki.* components processing entries.
+	"loki_process_dropped_lines_total": LossDropped,
+	"loki_route_entries_dropped_total": LossDropped,
+
+	// loki.write.
+	"loki_write_dropped_entries_total": LossDropped,
+
+	// pyroscope.write.
+	"pyroscope_write_dropped_profiles_total": LossDropped,
+
+	// event.route.
+	"event_route_events_dropped_total": LossDropped,
+}
+
+// otelLossMetric matches the metrics of otelcol.* components counting the
+// telemetry dropped by processors, refused by receivers and processors, and
+// which exporters failed to send.
+var otelLossMetric = regexp.MustCompile(`^(?:otelcol_)?(processor_dropped|receiver_refused|processor_refused|exporter_send_failed|exporter_enqueue_failed)_(?:spans|metric_points|log_records)(?:_total)?$`)
+
+// lossKind returns the kind of data loss counted by the metric with the given
+// name, if it's a loss counter.
+func lossKind(name string) (LossKind, bool) {
+	if kind, ok := lossMetrics[name]; ok {
+		return kind, true
+	}
+
+	m := otelLossMetric.FindStringSubmatch(name)
+	if m == nil {
+		return "", false
+	}
+	if m[1] == "processor_dropped" {
+		return LossDropped, true
+	}
+	return LossErrors, true
+}
+
+// ComponentLoss holds the totals of the loss counters of a component, by
+// kind.
+type ComponentLoss map[LossKind]float64
+
+// componentCounters holds the totals of the throughput and loss counters of a
+// component.
+type componentCounters struct {
+	Processed ComponentThroughput
+	Lost      ComponentLoss
+}
+
+// gatherCounters returns the totals of the throughput and loss counters of
+// each component gathered from g, keyed by component ID.
+func gatherCounters(g prometheus.Gatherer) (map[string]componentCounters, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]componentCounters)
+	add := func(m *dto.Metric, f func(c componentCounters, value float64)) {
+		id := componentID(m)
+		if id == "" || m.GetCounter() == nil {
+			return
+		}
+		c, ok := res[id]
+		if !ok {
+			c = componentCounters{Processed: make(ComponentThroughput), Lost: make(ComponentLoss)}
+			res[id] = c
+		}
+		f(c, m.GetCounter().GetValue())
+	}
+
+	for _, mf := range families {
+		if kind, ok := throughputKind(mf.GetName()); ok {
+			for _, m := range mf.GetMetric() {
+				add(m, func(c componentCounters, v float64) { c.Processed[kind] += v })
+			}
+		} else if kind, ok := lossKind(mf.GetName()); ok {
+			for _, m := range mf.GetMetric() {
+				add(m, func(c componentCounters, v float64) { c.Lost[kind] += v })
+			}
+		}
+	}
+	return res, nil
+}
+
+// ComponentTotals holds the lifetime totals of the throughput and loss
+// counters of a component.
+type ComponentTotals struct {
+	// Since is when the totals of the component were first recorded.
+	Since time.Time `json:"since"`
+
+	Processed ComponentThroughput `json:"processed"`
+	Lost      ComponentLoss       `json:"lost"`
+}
+
+// TotalsBucket is the name of the key-value store bucket the lifetime totals
+// of components are persisted to. Component IDs can't contain colons, so it
+// doesn't collide with the buckets of components.
+const TotalsBucket = "agent:lifetime_totals"
+
+// totalsKey is the key of the bucket entry holding the lifetime totals of all
+// components.
+const totalsKey = "components"
+
+// LifetimeTotals keeps the totals of the throughput and loss counters of
+// components across restarts of the agent. The totals are persisted to a
+// bucket of the key-value store, and the counters of the running process are
+// added to them.
+//
+// Like Prometheus rates, counters which go down are assumed to have been
+// reset, such as when a component is recreated, so increases between two
+// updates may be undercounted.
+type LifetimeTotals struct {
+	log      log.Logger
+	gatherer prometheus.Gatherer
+	bucket   *kv.Bucket
+
+	mut    sync.Mutex
+	totals map[string]*ComponentTotals
+	last   map[string]componentCounters // Counters seen by the last update.
+}
+
+// NewLifetimeTotals creates a LifetimeTotals reading counters from g, and
+// loads the totals persisted in bucket by previous runs of the agent.
+func NewLifetimeTotals(l log.Logger, g prometheus.Gatherer, bucket *kv.Bucket) (*LifetimeTotals, error) {
+	lt := &LifetimeTotals{
+		log:      l,
+		gatherer: g,
+		bucket:   bucket,
+		totals:   make(map[string]*ComponentTotals),
+		last:     make(map[string]componentCounters),
+	}
+
+	if bb, ok := bucket.Get(totalsKey); ok {
+		if err := json.Unmarshal(bb, &lt.totals); err != nil {
+			return nil, fmt.Errorf("failed to parse lifetime totals of components: %w", err)
+		}
+	}
+	return lt, nil
+}
+
+// Run updates and persists the totals every interval until ctx is canceled,
+// persisting them a last time before returning. Increases which weren't
+// persisted yet when the agent crashes are lost.
+func (lt *LifetimeTotals) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := lt.persist(); err != nil {
+				level.Error(lt.log).Log("msg", "failed to persist lifetime totals of components", "err", err)
+			}
+			return
+		case <-ticker.C:
+			if err := lt.persist(); err != nil {
+				level.Error(lt.log).Log("msg", "failed to persist lifetime totals of components", "err", err)
+			}
+		}
+	}
+}
+
+// Totals returns the current lifetime totals of components, keyed by
+// component ID. Components which have been removed are still included.
+func (lt *LifetimeTotals) Totals() (map[string]ComponentTotals, error) {
+	lt.mut.Lock()
+	defer lt.mut.Unlock()
+
+	if err := lt.update(); err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]ComponentTotals, len(lt.totals))
+	for id, t := range lt.totals {
+		res[id] = ComponentTotals{
+			Since:     t.Since,
+			Processed: copyCounters(t.Processed),
+			Lost:      copyCounters(t.Lost),
+		}
+	}
+	return res, nil
+}
+
+func (lt *LifetimeTotals) persist() error {
+	lt.mut.Lock()
+	defer lt.mut.Unlock()
+
+	if err := lt.update(); err != nil {
+		return err
+	}
+	bb, err := json.Marshal(lt.totals)
+	if err != nil {
+		return err
+	}
+	return lt.bucket.Put(totalsKey, bb)
+}
+
+// update adds the increase of the counters since the last update to the
+// totals. lt.mut must be held.
+func (lt *LifetimeTotals) update() error {
+	counters, err := gatherCounters(lt.gatherer)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for id, cur := range counters {
+		t, ok := lt.totals[id]
+		if !ok {
+			t = &ComponentTotals{Since: now}
+			lt.totals[id] = t
+		}
+		if t.Processed == nil {
+			t.Processed = make(ComponentThroughput)
+		}
+		if t.Lost == nil {
+			t.Lost = make(ComponentLoss)
+		}
+
+		last := lt.last[id]
+		addIncrease(t.Processed, last.Processed, cur.Processed)
+		addIncrease(t.Lost, last.Lost, cur.Lost)
+	}
+	lt.last = counters
+	return nil
+}
+
+// addIncrease adds the increase of each counter from last to cur to totals.
+// Counters which went down were reset, so their increase is computed from
+// zero.
+func addIncrease[K comparable](totals, last, cur map[K]float64) {
+	for kind, value := range cur {
+		if prev := last[kind]; value >= prev {
+			totals[kind] += value - prev
+		} else {
+			totals[kind] += value
+		}
+	}
+}
+
+func copyCounters[K comparable](m map[K]float64) map[K]float64 {
+	res := make(map[K]float64, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/agent/pkg/kv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+// totalsTestRun emulates a run of the agent with a loki.write component.
+type totalsTestRun struct {
+	entries, dropped prometheus.Counter
+	totals           *LifetimeTotals
+}
+
+func newTotalsTestRun(t *testing.T, dataPath string) *totalsTestRun {
+	store, err := kv.New(dataPath)
+	require.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+	componentReg := prometheus.WrapRegistererWith(prometheus.Labels{"component_id": "loki.write.default"}, reg)
+
+	run := &totalsTestRun{
+		entries: prometheus.NewCounter(prometheus.CounterOpts{Name: "loki_write_sent_entries_total"}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{Name: "loki_write_dropped_entries_total"}),
+	}
+	componentReg.MustRegister(run.entries, run.dropped)
+
+	run.totals, err = NewLifetimeTotals(log.NewNopLogger(), reg, store.Bucket(TotalsBucket))
+	require.NoError(t, err)
+	return run
+}
+
+func TestLifetimeTotals(t *testing.T) {
+	dataPath := t.TempDir()
+
+	first := newTotalsTestRun(t, dataPath)
+	first.entries.Add(10)
+	first.dropped.Add(2)
+	require.NoError(t, first.totals.persist())
+
+	// Increases after the last persist are lost when the agent crashes.
+	first.entries.Add(100)
+
+	second := newTotalsTestRun(t, dataPath)
+	second.entries.Add(5)
+	second.dropped.Add(1)
+
+	totals, err := second.totals.Totals()
+	require.NoError(t, err)
+	require.Contains(t, totals, "loki.write.default")
+	require.Equal(t, ComponentThroughput{ThroughputLines: 15}, totals["loki.write.default"].Processed)
+	require.Equal(t, ComponentLoss{LossDropped: 3}, totals["loki.write.default"].Lost)
+
+	firstTotals, err := first.totals.Totals()
+	require.NoError(t, err)
+	require.True(t, firstTotals["loki.write.default"].Since.Equal(totals["loki.write.default"].Since))
+}
+
+func TestAddIncrease(t *testing.T) {
+	totals := map[string]float64{"a": 10, "b": 10}
+	last := map[string]float64{"a": 5, "b": 5}
+
+	// b went down, so it was reset and increased by 2 since.
+	addIncrease(totals, last, map[string]float64{"a": 8, "b": 2, "c": 1})
+	require.Equal(t, map[string]float64{"a": 13, "b": 12, "c": 1}, totals)
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// ComponentUsage holds the usage accounted for by a write component.
+type ComponentUsage struct {
+	ActiveSeries []ActiveSeriesUsage `json:"activeSeries,omitempty"`
+	Logs         []LogUsage          `json:"logs,omitempty"`
+}
+
+// ActiveSeriesUsage holds the number of active series forwarded to an
+// endpoint and tenant by a source component.
+type ActiveSeriesUsage struct {
+	Endpoint string `json:"endpoint"`
+	Tenant   string `json:"tenant"`
+	Source   string `json:"source"`
+	Series   int    `json:"series"`
+}
+
+// LogUsage holds the number of log lines and bytes forwarded to a tenant by
+// a source component.
+type LogUsage struct {
+	Tenant string  `json:"tenant"`
+	Source string  `json:"source"`
+	Lines  float64 `json:"lines"`
+	Bytes  float64 `json:"bytes"`
+}
+
+// Metrics of the write components accounting for their usage.
+const (
+	activeSeriesMetric = "agent_usage_active_series"
+	logLinesMetric     = "agent_usage_log_lines_total"
+	logBytesMetric     = "agent_usage_log_bytes_total"
+)
+
+// gatherUsage returns the usage accounted for by each component gathered
+// from g, keyed by component ID.
+func gatherUsage(g prometheus.Gatherer) (map[string]ComponentUsage, error) {
+	families, err := g.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	type logKey struct{ id, tenant, source string }
+	var (
+		res  = make(map[string]ComponentUsage)
+		logs = make(map[logKey]LogUsage)
+	)
+	for _, mf := range families {
+		switch mf.GetName() {
+		case activeSeriesMetric:
+			for _, m := range mf.GetMetric() {
+				id := componentID(m)
+				if id == "" || m.GetGauge() == nil {
+					continue
+				}
+				u := res[id]
+				u.ActiveSeries = append(u.ActiveSeries, ActiveSeriesUsage{
+					Endpoint: labelValue(m, "endpoint"),
+					Tenant:   labelValue(m, "tenant"),
+					Source:   labelValue(m, "source"),
+					Series:   int(m.GetGauge().GetValue()),
+				})
+				res[id] = u
+			}
+
+		case logLinesMetric, logBytesMetric:
+			for _, m := range mf.GetMetric() {
+				id := componentID(m)
+				if id == "" || m.GetCounter() == nil {
+					continue
+				}
+				key := logKey{id: id, tenant: labelValue(m, "tenant"), source: labelValue(m, "source")}
+				l := logs[key]
+				l.Tenant, l.Source = key.tenant, key.source
+				if mf.GetName() == logLinesMetric {
+					l.Lines = m.GetCounter().GetValue()
+				} else {
+					l.Bytes = m.GetCounter().GetValue()
+				}
+				logs[key] = l
+			}
+		}
+	}
+	for key, l := range logs {
+		u := res[key.id]
+		u.Logs = append(u.Logs, l)
+		res[key.id] = u
+	}
+
+	for id, u := range res {
+		sort.Slice(u.ActiveSeries, func(i, j int) bool {
+			a, b := u.ActiveSeries[i], u.ActiveSeries[j]
+			if a.Endpoint != b.Endpoint {
+				return a.Endpoint < b.Endpoint
+			}
+			if a.Tenant != b.Tenant {
+				return a.Tenant < b.Tenant
+			}
+			return a.Source < b.Source
+		})
+		sort.Slice(u.Logs, func(i, j int) bool {
+			a, b := u.Logs[i], u.Logs[j]
+			if a.Tenant != b.Tenant {
+				return a.Tenant < b.Tenant
+			}
+			return a.Source < b.Source
+		})
+		res[id] = u
+	}
+	return res, nil
+}
+
+// labelValue returns the value of the label name of m, or an empty string if
+// m doesn't have it.
+func labelValue(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
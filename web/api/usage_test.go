@@ -0,0 +1,41 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatherUsage(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	activeSeries := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "agent_usage_active_series"}, []string{"component_id", "endpoint", "tenant", "source"})
+	logLines := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "agent_usage_log_lines_total"}, []string{"component_id", "tenant", "source"})
+	logBytes := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "agent_usage_log_bytes_total"}, []string{"component_id", "tenant", "source"})
+	reg.MustRegister(activeSeries, logLines, logBytes)
+
+	activeSeries.WithLabelValues("prometheus.remote_write.default", "mimir", "team-b", "prometheus.scrape.app").Set(20)
+	activeSeries.WithLabelValues("prometheus.remote_write.default", "mimir", "team-a", "prometheus.scrape.app").Set(10)
+	logLines.WithLabelValues("loki.write.default", "team-a", "loki.source.file.logs").Add(3)
+	logBytes.WithLabelValues("loki.write.default", "team-a", "loki.source.file.logs").Add(120)
+	logLines.WithLabelValues("loki.write.default", "", "").Add(1)
+
+	res, err := gatherUsage(reg)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]ComponentUsage{
+		"prometheus.remote_write.default": {
+			ActiveSeries: []ActiveSeriesUsage{
+				{Endpoint: "mimir", Tenant: "team-a", Source: "prometheus.scrape.app", Series: 10},
+				{Endpoint: "mimir", Tenant: "team-b", Source: "prometheus.scrape.app", Series: 20},
+			},
+		},
+		"loki.write.default": {
+			Logs: []LogUsage{
+				{Tenant: "", Source: "", Lines: 1},
+				{Tenant: "team-a", Source: "loki.source.file.logs", Lines: 3, Bytes: 120},
+			},
+		},
+	}, res)
+}
@@ -0,0 +1,217 @@
+import { FC, FormEvent, useEffect, useState } from 'react';
+
+import { riverStringify } from '../river-js/stringify';
+import { Value } from '../river-js/types';
+
+import styles from './ArgumentEditor.module.css';
+
+/**
+ * SchemaField describes an attribute or block accepted by a component, as
+ * returned by the schema API.
+ */
+interface SchemaField {
+  name: string;
+  type: 'attr' | 'block' | 'enum';
+  valueType?: string;
+  default?: Value;
+  optional: boolean;
+  repeated?: boolean;
+  body?: SchemaField[];
+}
+
+/**
+ * EditableArguments are the arguments of a component as written in the
+ * config file.
+ */
+interface EditableArguments {
+  /** River expressions of attributes, keyed by name. */
+  attributes: Record<string, string>;
+  /** River source of blocks. */
+  blocks: string;
+}
+
+type EditorState = 'loading' | 'disabled' | 'unauthorized' | 'ready';
+
+// Key of the read-write token in session storage.
+const tokenKey = 'grafana-agent-ui-token';
+
+export interface ArgumentEditorProps {
+  /** ID of the top-level component to edit. */
+  id: string;
+}
+
+/**
+ * ArgumentEditor edits the arguments of a component in the config file. It's
+ * only shown when the agent runs with the UI in read-write mode.
+ */
+export const ArgumentEditor: FC<ArgumentEditorProps> = (props) => {
+  const [state, setState] = useState<EditorState>('loading');
+  const [token, setToken] = useState(sessionStorage.getItem(tokenKey) || '');
+  const [schema, setSchema] = useState<SchemaField[]>([]);
+  const [args, setArgs] = useState<EditableArguments>({ attributes: {}, blocks: '' });
+  const [error, setError] = useState<string | undefined>(undefined);
+  const [applying, setApplying] = useState(false);
+
+  const argumentsURL = `./api/v0/web/components/${props.id}/arguments`;
+
+  useEffect(
+    function () {
+      const worker = async () => {
+        // Request is relative to the <base> tag inside of <head>.
+        const resp = await fetch(argumentsURL, {
+          cache: 'no-cache',
+          credentials: 'same-origin',
+          headers: { Authorization: `Bearer ${token}` },
+        });
+        switch (resp.status) {
+          case 403:
+            setState('disabled');
+            return;
+          case 401:
+            setState('unauthorized');
+            return;
+        }
+        if (!resp.ok) {
+          throw new Error(await resp.text());
+        }
+        setArgs(await resp.json());
+
+        const schemaResp = await fetch(`./api/v0/web/components/${props.id}/schema`, {
+          cache: 'no-cache',
+          credentials: 'same-origin',
+        });
+        setSchema(await schemaResp.json());
+        setState('ready');
+      };
+
+      worker().catch(console.error);
+    },
+    [argumentsURL, props.id, token]
+  );
+
+  if (state === 'loading' || state === 'disabled') {
+    return null;
+  }
+
+  if (state === 'unauthorized') {
+    const onLogin = (e: FormEvent<HTMLFormElement>) => {
+      e.preventDefault();
+      const input = new FormData(e.currentTarget).get('token')?.toString() || '';
+      sessionStorage.setItem(tokenKey, input);
+      setToken(input);
+    };
+
+    return (
+      <section id="edit">
+        <h2>Edit arguments</h2>
+        <div className={styles.editor}>
+          <form onSubmit={onLogin}>
+            <label htmlFor="token">Enter the read-write token of the agent to edit this component.</label>
+            <input id="token" name="token" type="password" autoComplete="off" />
+            <button type="submit">Unlock</button>
+          </form>
+        </div>
+      </section>
+    );
+  }
+
+  const attrs = schema.filter((field) => field.type === 'attr');
+  const blocks = schema.filter((field) => field.type !== 'attr');
+
+  const validate = (): string | undefined => {
+    for (const field of attrs) {
+      if (!field.optional && (args.attributes[field.name] || '').trim() === '') {
+        return `${field.name} is required`;
+      }
+    }
+    return undefined;
+  };
+
+  const onApply = async (e: FormEvent<HTMLFormElement>) => {
+    e.preventDefault();
+
+    const invalid = validate();
+    setError(invalid);
+    if (invalid) {
+      return;
+    }
+
+    setApplying(true);
+    try {
+      const resp = await fetch(argumentsURL, {
+        method: 'PUT',
+        credentials: 'same-origin',
+        headers: { Authorization: `Bearer ${token}`, 'Content-Type': 'application/json' },
+        body: JSON.stringify(args),
+      });
+      if (!resp.ok) {
+        setError(await resp.text());
+        return;
+      }
+      // Reload the page to show the new arguments of the component.
+      window.location.reload();
+    } finally {
+      setApplying(false);
+    }
+  };
+
+  const setAttribute = (name: string, expr: string) => {
+    setArgs({ ...args, attributes: { ...args.attributes, [name]: expr } });
+  };
+
+  return (
+    <section id="edit">
+      <h2>Edit arguments</h2>
+      <div className={styles.editor}>
+        <form onSubmit={onApply}>
+          <table>
+            <tbody>
+              {attrs.map((field) => (
+                <tr key={field.name}>
+                  <td className={styles.name}>
+                    <label htmlFor={`attr-${field.name}`}>
+                      {field.name}
+                      {!field.optional && <span className={styles.required}>*</span>}
+                    </label>
+                    <div className={styles.type}>{field.valueType}</div>
+                  </td>
+                  <td>
+                    <input
+                      id={`attr-${field.name}`}
+                      value={args.attributes[field.name] || ''}
+                      placeholder={field.default ? riverStringify(field.default) : ''}
+                      onChange={(e) => setAttribute(field.name, e.target.value)}
+                    />
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+
+          {blocks.length > 0 && (
+            <>
+              <label htmlFor="blocks">
+                Blocks <span className={styles.type}>({blocks.map((field) => field.name).join(', ')})</span>
+              </label>
+              <textarea
+                id="blocks"
+                rows={Math.max(args.blocks.split('\n').length + 1, 4)}
+                value={args.blocks}
+                onChange={(e) => setArgs({ ...args, blocks: e.target.value })}
+              />
+            </>
+          )}
+
+          <p className={styles.hint}>
+            Values are River expressions, such as <code>"text"</code> or <code>discovery.example.targets</code>.
+            Applying rewrites the config file and reloads it.
+          </p>
+          {error && <blockquote className={styles.error}>{error}</blockquote>}
+          <button type="submit" disabled={applying}>
+            Apply
+          </button>
+        </form>
+      </div>
+    </section>
+  );
+};
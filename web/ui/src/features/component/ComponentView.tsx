@@ -5,9 +5,15 @@ import { FontAwesomeIcon } from '@fortawesome/react-fontawesome';
 
 import { partitionBody } from '../../utils/partition';
 
+import { ArgumentEditor } from './ArgumentEditor';
 import ComponentBody from './ComponentBody';
 import ComponentList from './ComponentList';
+import { DataUsage } from './DataUsage';
 import { HealthLabel } from './HealthLabel';
+import { LifetimeTotals } from './LifetimeTotals';
+import { LiveDebugging } from './LiveDebugging';
+import { ResourceUsage } from './ResourceUsage';
+import { TapStream } from './TapStream';
 import { ComponentDetail, ComponentInfo, PartitionedBody } from './types';
 
 import styles from './ComponentView.module.css';
@@ -116,6 +122,21 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
         {exportsPartition && <ComponentBody partition={exportsPartition} />}
         {debugPartition && <ComponentBody partition={debugPartition} />}
 
+        <LifetimeTotals id={pathJoin([props.component.parent, props.component.id])} />
+        <ResourceUsage id={pathJoin([props.component.parent, props.component.id])} />
+        <DataUsage id={pathJoin([props.component.parent, props.component.id])} />
+
+        {/* Only top-level components can be edited in the config file. */}
+        {!props.component.parent && <ArgumentEditor id={props.component.id} />}
+
+        {/* Only top-level components serve their HTTP endpoints. */}
+        {!props.component.parent && props.component.name === 'otelcol.processor.tap' && (
+          <TapStream id={props.component.id} />
+        )}
+
+        {/* Only top-level components can be looked up by the API. */}
+        {!props.component.parent && props.component.liveDebugging && <LiveDebugging id={props.component.id} />}
+
         {props.component.referencesTo.length > 0 && (
           <section id="dependencies">
             <h2>Dependencies</h2>
@@ -0,0 +1,102 @@
+import { FC, useEffect, useState } from 'react';
+
+import Table from './Table';
+
+import styles from './ComponentView.module.css';
+
+/**
+ * ComponentUsage holds the usage accounted for by a write component.
+ */
+interface ComponentUsage {
+  activeSeries?: {
+    endpoint: string;
+    tenant: string;
+    source: string;
+    series: number;
+  }[];
+  logs?: {
+    tenant: string;
+    source: string;
+    lines: number;
+    bytes: number;
+  }[];
+}
+
+export interface DataUsageProps {
+  id: string;
+}
+
+/**
+ * DataUsage shows the active series and log lines forwarded by a write
+ * component, by destination and source component. Nothing is shown for
+ * components which don't account for their usage.
+ */
+export const DataUsage: FC<DataUsageProps> = ({ id }) => {
+  const [usage, setUsage] = useState<ComponentUsage | undefined>(undefined);
+
+  useEffect(
+    function () {
+      const worker = async () => {
+        // Request is relative to the <base> tag inside of <head>.
+        const resp = await fetch('./api/v0/web/usage', {
+          cache: 'no-cache',
+          credentials: 'same-origin',
+        });
+        if (!resp.ok) {
+          return;
+        }
+        const data: { components: Record<string, ComponentUsage> } = await resp.json();
+        setUsage(data.components[id]);
+      };
+
+      worker().catch(console.error);
+      const timer = setInterval(() => worker().catch(console.error), 5000);
+      return () => clearInterval(timer);
+    },
+    [id]
+  );
+
+  if (usage === undefined) {
+    return null;
+  }
+
+  const orNone = (s: string) => s || '(none)';
+
+  return (
+    <section id="data-usage">
+      <h2>Data usage</h2>
+      <div className={styles.sectionContent}>
+        {usage.activeSeries && (
+          <Table
+            tableHeaders={['Endpoint', 'Tenant', 'Source', 'Active series']}
+            renderTableData={() =>
+              (usage.activeSeries ?? []).map((row) => (
+                <tr key={`${row.endpoint}/${row.tenant}/${row.source}`}>
+                  <td>{row.endpoint}</td>
+                  <td>{orNone(row.tenant)}</td>
+                  <td>{orNone(row.source)}</td>
+                  <td>{row.series.toLocaleString()}</td>
+                </tr>
+              ))
+            }
+          />
+        )}
+        {usage.logs && (
+          <Table
+            tableHeaders={['Tenant', 'Source', 'Log lines', 'Log bytes']}
+            renderTableData={() =>
+              (usage.logs ?? []).map((row) => (
+                <tr key={`${row.tenant}/${row.source}`}>
+                  <td>{orNone(row.tenant)}</td>
+                  <td>{orNone(row.source)}</td>
+                  <td>{row.lines.toLocaleString()}</td>
+                  <td>{row.bytes.toLocaleString()}</td>
+                </tr>
+              ))
+            }
+          />
+        )}
+      </div>
+    </section>
+  );
+};
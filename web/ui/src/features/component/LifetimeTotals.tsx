@@ -0,0 +1,89 @@
+import { FC, useEffect, useState } from 'react';
+
+import { formatTotal, ThroughputKind } from '../graph/throughput';
+
+import Table from './Table';
+
+import styles from './ComponentView.module.css';
+
+/**
+ * ComponentTotals holds the lifetime totals of the counters of a component,
+ * which are kept across restarts of the agent.
+ */
+interface ComponentTotals {
+  since: string;
+  processed: Partial<Record<ThroughputKind, number>>;
+  lost: Partial<Record<'dropped' | 'errors', number>>;
+}
+
+export interface LifetimeTotalsProps {
+  id: string;
+}
+
+/**
+ * LifetimeTotals shows the lifetime totals of the data processed and lost by
+ * a component. Nothing is shown for components without such counters.
+ */
+export const LifetimeTotals: FC<LifetimeTotalsProps> = ({ id }) => {
+  const [totals, setTotals] = useState<ComponentTotals | undefined>(undefined);
+
+  useEffect(
+    function () {
+      const worker = async () => {
+        // Request is relative to the <base> tag inside of <head>.
+        const resp = await fetch('./api/v0/web/totals', {
+          cache: 'no-cache',
+          credentials: 'same-origin',
+        });
+        if (!resp.ok) {
+          return;
+        }
+        const data: { components: Record<string, ComponentTotals> } = await resp.json();
+        setTotals(data.components[id]);
+      };
+
+      worker().catch(console.error);
+      const timer = setInterval(() => worker().catch(console.error), 5000);
+      return () => clearInterval(timer);
+    },
+    [id]
+  );
+
+  if (totals === undefined) {
+    return null;
+  }
+
+  const rows: [string, string][] = [];
+  Object.values(ThroughputKind).forEach((kind) => {
+    const total = totals.processed[kind];
+    if (total !== undefined) {
+      rows.push([`Processed ${kind}`, formatTotal(kind, total)]);
+    }
+  });
+  if (totals.lost.dropped !== undefined) {
+    rows.push(['Dropped', totals.lost.dropped.toLocaleString()]);
+  }
+  if (totals.lost.errors !== undefined) {
+    rows.push(['Errors', totals.lost.errors.toLocaleString()]);
+  }
+
+  return (
+    <section id="lifetime-totals">
+      <h2>Lifetime totals</h2>
+      <div className={styles.sectionContent}>
+        <p>Counted since {new Date(totals.since).toLocaleString()}, including previous runs of the agent.</p>
+        <Table
+          tableHeaders={['Counter', 'Total']}
+          renderTableData={() =>
+            rows.map(([name, value]) => (
+              <tr key={name}>
+                <td>{name}</td>
+                <td>{value}</td>
+              </tr>
+            ))
+          }
+        />
+      </div>
+    </section>
+  );
+};
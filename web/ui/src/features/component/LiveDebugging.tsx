@@ -0,0 +1,147 @@
+import { FC, FormEvent, useEffect, useRef, useState } from 'react';
+
+import styles from './LiveDebugging.module.css';
+
+/**
+ * DebugSample is a sample of the data flowing through a component, streamed
+ * for live debugging.
+ */
+interface DebugSample {
+  time: string;
+  kind: string;
+  data: unknown;
+}
+
+// Number of samples kept on the page.
+const maxSamples = 100;
+
+export interface LiveDebuggingProps {
+  /** ID of the top-level component to stream samples of. */
+  id: string;
+}
+
+/**
+ * LiveDebugging shows samples of the data flowing through a component while
+ * it's started. Streams end on their own after the selected timeout.
+ */
+export const LiveDebugging: FC<LiveDebuggingProps> = (props) => {
+  const [ratio, setRatio] = useState('1');
+  const [rate, setRate] = useState('10');
+  const [timeoutSeconds, setTimeoutSeconds] = useState('60');
+  const [samples, setSamples] = useState<DebugSample[]>([]);
+  const [error, setError] = useState<string | undefined>(undefined);
+  const [controller, setController] = useState<AbortController | undefined>(undefined);
+
+  // Stop streaming when leaving the page.
+  const controllerRef = useRef(controller);
+  controllerRef.current = controller;
+  useEffect(() => () => controllerRef.current?.abort(), []);
+
+  const start = async (c: AbortController) => {
+    const query = new URLSearchParams({ sample: ratio, rate: rate, timeout: `${timeoutSeconds}s` });
+
+    // Request is relative to the <base> tag inside of <head>.
+    const resp = await fetch(`./api/v0/web/components/${props.id}/debug/stream?${query}`, {
+      cache: 'no-cache',
+      credentials: 'same-origin',
+      signal: c.signal,
+    });
+    if (!resp.ok || !resp.body) {
+      throw new Error(await resp.text());
+    }
+
+    const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffered = '';
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) {
+        return;
+      }
+      buffered += value;
+
+      const lines = buffered.split('\n');
+      buffered = lines.pop() || '';
+      const received = lines.filter((line) => line !== '').map((line) => JSON.parse(line) as DebugSample);
+      if (received.length > 0) {
+        setSamples((prev) => [...received.reverse(), ...prev].slice(0, maxSamples));
+      }
+    }
+  };
+
+  const onSubmit = (e: FormEvent<HTMLFormElement>) => {
+    e.preventDefault();
+
+    if (controller) {
+      controller.abort();
+      setController(undefined);
+      return;
+    }
+
+    const c = new AbortController();
+    setController(c);
+    setError(undefined);
+    start(c)
+      .catch((err) => {
+        if (!c.signal.aborted) {
+          setError(String(err));
+        }
+      })
+      .finally(() => setController((current) => (current === c ? undefined : current)));
+  };
+
+  return (
+    <section id="live-debugging">
+      <h2>Live debugging</h2>
+      <div className={styles.liveDebugging}>
+        <form onSubmit={onSubmit}>
+          <label>
+            Sample ratio
+            <input
+              type="number"
+              min="0.001"
+              max="1"
+              step="0.001"
+              value={ratio}
+              disabled={controller !== undefined}
+              onChange={(e) => setRatio(e.target.value)}
+            />
+          </label>
+          <label>
+            Samples per second
+            <input
+              type="number"
+              min="1"
+              max="100"
+              value={rate}
+              disabled={controller !== undefined}
+              onChange={(e) => setRate(e.target.value)}
+            />
+          </label>
+          <label>
+            Timeout (seconds)
+            <input
+              type="number"
+              min="1"
+              max="600"
+              value={timeoutSeconds}
+              disabled={controller !== undefined}
+              onChange={(e) => setTimeoutSeconds(e.target.value)}
+            />
+          </label>
+          <button type="submit">{controller ? 'Stop' : 'Start'}</button>
+        </form>
+
+        {error && <p className={styles.error}>{error}</p>}
+
+        {samples.map((sample, idx) => (
+          <details key={`${sample.time}-${idx}`}>
+            <summary>
+              {new Date(sample.time).toLocaleTimeString()} <span className={styles.kind}>{sample.kind}</span>
+            </summary>
+            <pre>{JSON.stringify(sample.data, null, 2)}</pre>
+          </details>
+        ))}
+      </div>
+    </section>
+  );
+};
@@ -0,0 +1,75 @@
+import { FC, useEffect, useState } from 'react';
+
+import Table from './Table';
+
+import styles from './ComponentView.module.css';
+
+/**
+ * ComponentResources holds the resources attributed to a component.
+ */
+interface ComponentResources {
+  goroutines: number;
+  cpuSeconds?: number;
+}
+
+export interface ResourceUsageProps {
+  id: string;
+}
+
+/**
+ * ResourceUsage shows the goroutines and CPU time attributed to a component.
+ * Nothing is shown for components without resources attributed to them.
+ */
+export const ResourceUsage: FC<ResourceUsageProps> = ({ id }) => {
+  const [resources, setResources] = useState<ComponentResources | undefined>(undefined);
+
+  useEffect(
+    function () {
+      const worker = async () => {
+        // Request is relative to the <base> tag inside of <head>.
+        const resp = await fetch('./api/v0/web/resources', {
+          cache: 'no-cache',
+          credentials: 'same-origin',
+        });
+        if (!resp.ok) {
+          return;
+        }
+        const data: { components: Record<string, ComponentResources> } = await resp.json();
+        setResources(data.components[id]);
+      };
+
+      worker().catch(console.error);
+      const timer = setInterval(() => worker().catch(console.error), 5000);
+      return () => clearInterval(timer);
+    },
+    [id]
+  );
+
+  if (resources === undefined) {
+    return null;
+  }
+
+  const rows: [string, string][] = [['Goroutines', resources.goroutines.toLocaleString()]];
+  if (resources.cpuSeconds !== undefined) {
+    rows.push(['Estimated CPU time', `${resources.cpuSeconds.toFixed(2)}s`]);
+  }
+
+  return (
+    <section id="resource-usage">
+      <h2>Resource usage</h2>
+      <div className={styles.sectionContent}>
+        <Table
+          tableHeaders={['Resource', 'Usage']}
+          renderTableData={() =>
+            rows.map(([name, value]) => (
+              <tr key={name}>
+                <td>{name}</td>
+                <td>{value}</td>
+              </tr>
+            ))
+          }
+        />
+      </div>
+    </section>
+  );
+};
@@ -0,0 +1,142 @@
+import { FC, FormEvent, useEffect, useRef, useState } from 'react';
+
+import styles from './TapStream.module.css';
+
+/**
+ * TapBatch is a sampled batch of telemetry data streamed by an
+ * otelcol.processor.tap component.
+ */
+interface TapBatch {
+  time: string;
+  signal: Signal;
+  data: unknown;
+}
+
+type Signal = 'traces' | 'metrics' | 'logs';
+
+const signals: Signal[] = ['traces', 'metrics', 'logs'];
+
+// Number of batches kept on the page.
+const maxBatches = 50;
+
+export interface TapStreamProps {
+  /** ID of the top-level otelcol.processor.tap component. */
+  id: string;
+}
+
+/**
+ * TapStream shows the sampled batches of telemetry data passing an
+ * otelcol.processor.tap component while it's started.
+ */
+export const TapStream: FC<TapStreamProps> = (props) => {
+  const [selected, setSelected] = useState<Signal[]>(signals);
+  const [service, setService] = useState('');
+  const [batches, setBatches] = useState<TapBatch[]>([]);
+  const [error, setError] = useState<string | undefined>(undefined);
+  const [controller, setController] = useState<AbortController | undefined>(undefined);
+
+  // Stop streaming when leaving the page.
+  const controllerRef = useRef(controller);
+  controllerRef.current = controller;
+  useEffect(() => () => controllerRef.current?.abort(), []);
+
+  const toggleSignal = (signal: Signal) => {
+    setSelected(selected.includes(signal) ? selected.filter((s) => s !== signal) : [...selected, signal]);
+  };
+
+  const start = async (c: AbortController) => {
+    const query = new URLSearchParams({ signal: selected.join(',') });
+    if (service !== '') {
+      query.set('service_name', service);
+    }
+
+    // Request is relative to the <base> tag inside of <head>.
+    const resp = await fetch(`./api/v0/component/${props.id}/tap?${query}`, {
+      cache: 'no-cache',
+      credentials: 'same-origin',
+      signal: c.signal,
+    });
+    if (!resp.ok || !resp.body) {
+      throw new Error(await resp.text());
+    }
+
+    const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffered = '';
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) {
+        return;
+      }
+      buffered += value;
+
+      const lines = buffered.split('\n');
+      buffered = lines.pop() || '';
+      const received = lines.filter((line) => line !== '').map((line) => JSON.parse(line) as TapBatch);
+      if (received.length > 0) {
+        setBatches((prev) => [...received.reverse(), ...prev].slice(0, maxBatches));
+      }
+    }
+  };
+
+  const onSubmit = (e: FormEvent<HTMLFormElement>) => {
+    e.preventDefault();
+
+    if (controller) {
+      controller.abort();
+      setController(undefined);
+      return;
+    }
+
+    const c = new AbortController();
+    setController(c);
+    setError(undefined);
+    start(c)
+      .catch((err) => {
+        if (!c.signal.aborted) {
+          setError(String(err));
+        }
+      })
+      .finally(() => setController((current) => (current === c ? undefined : current)));
+  };
+
+  return (
+    <section id="tap">
+      <h2>Live tap</h2>
+      <div className={styles.tap}>
+        <form onSubmit={onSubmit}>
+          {signals.map((signal) => (
+            <label key={signal}>
+              <input
+                type="checkbox"
+                checked={selected.includes(signal)}
+                disabled={controller !== undefined}
+                onChange={() => toggleSignal(signal)}
+              />
+              {signal}
+            </label>
+          ))}
+          <input
+            placeholder="service.name"
+            value={service}
+            disabled={controller !== undefined}
+            onChange={(e) => setService(e.target.value)}
+          />
+          <button type="submit" disabled={selected.length === 0}>
+            {controller ? 'Stop' : 'Start'}
+          </button>
+        </form>
+
+        {error && <p className={styles.error}>{error}</p>}
+
+        {batches.map((batch, idx) => (
+          <details key={`${batch.time}-${idx}`}>
+            <summary>
+              {new Date(batch.time).toLocaleTimeString()} <span className={styles.signal}>{batch.signal}</span>
+            </summary>
+            <pre>{JSON.stringify(batch.data, null, 2)}</pre>
+          </details>
+        ))}
+      </div>
+    </section>
+  );
+};
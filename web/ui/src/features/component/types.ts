@@ -98,6 +98,12 @@ export interface ComponentDetail extends ComponentInfo {
    */
   debugInfo?: RiverBody;
 
+  /**
+   * Whether samples of the data flowing through the component can be
+   * streamed for live debugging.
+   */
+  liveDebugging?: boolean;
+
   /**
    * If a component is loaded from a module, this is the parent ID.
    */
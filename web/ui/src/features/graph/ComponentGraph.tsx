@@ -7,6 +7,7 @@ import { IdOperator, ParentIdsOperator } from 'd3-dag/dist/dag/create';
 import * as d3Zoom from 'd3-zoom';
 
 import { ComponentHealthState, ComponentInfo } from '../component/types';
+import { ComponentThroughput, formatRates, ThroughputRates } from './throughput';
 
 let canvas: HTMLCanvasElement | undefined;
 
@@ -119,6 +120,27 @@ function lineIntersectionPoint(l1: Line, l2: Line): Point | undefined {
   return undefined;
 }
 
+/**
+ * polylineMidpoint returns the point halfway along the line going through
+ * points.
+ */
+function polylineMidpoint(points: Point[]): Point {
+  const lengths = points.slice(1).map((p, i) => Math.hypot(p.x - points[i].x, p.y - points[i].y));
+  let remaining = lengths.reduce((a, b) => a + b, 0) / 2;
+
+  for (let i = 0; i < lengths.length; i++) {
+    if (remaining <= lengths[i] && lengths[i] > 0) {
+      const t = remaining / lengths[i];
+      return {
+        x: points[i].x + t * (points[i + 1].x - points[i].x),
+        y: points[i].y + t * (points[i + 1].y - points[i].y),
+      };
+    }
+    remaining -= lengths[i];
+  }
+  return points[points.length - 1];
+}
+
 interface Box {
   x: number;
   y: number;
@@ -126,8 +148,52 @@ interface Box {
   h: number;
 }
 
+/**
+ * Edge is an edge of the graph, from the component sending data to the
+ * component receiving it.
+ */
+interface Edge {
+  source: string;
+  target: string;
+  /** Number of edges going to target. */
+  targetInputs: number;
+  points: Point[];
+}
+
+/**
+ * Sparkline is the area of a node where the history of its throughput is
+ * plotted.
+ */
+interface Sparkline {
+  id: string;
+  width: number;
+  height: number;
+}
+
+/**
+ * edgeRates returns the rates to display on edge. Edges show the rates sent
+ * by their source. Components without throughput metrics fall back to the
+ * rates of their target, as long as the target has no other inputs the rates
+ * could come from.
+ */
+function edgeRates(edge: Edge, throughput: Record<string, ComponentThroughput>): ThroughputRates | undefined {
+  if (throughput[edge.source] !== undefined) {
+    return throughput[edge.source].rates;
+  }
+  if (edge.targetInputs === 1) {
+    return throughput[edge.target]?.rates;
+  }
+  return undefined;
+}
+
 export interface ComponentGraphProps {
   components: ComponentInfo[];
+
+  /**
+   * Live throughput of components, keyed by component ID. Rates are shown on
+   * edges and plotted on nodes as sparklines.
+   */
+  throughput?: Record<string, ComponentThroughput>;
 }
 
 /**
@@ -254,62 +320,88 @@ export const ComponentGraph: FC<ComponentGraphProps> = (props) => {
       .x((d) => d.x)
       .y((d) => d.y);
 
+    const edges: Edge[] = dag.links().map((node) => {
+      // We want to draw arrows between boxes, but by default the arrows are
+      // obscured; d3-dag points lines to the middle of a box which is hidden
+      // by the rectangle.
+      //
+      // To fix this, we do the following:
+      //
+      // 1. Retrieve the set of generated points for d3-dag
+      // 2. Remove all points after the first point which intersects the box
+      // 3. Move the final point to the coordinates where it intersects the
+      //    box
+      // 4. The line will now stop at the box edge as expected.
+
+      const nodeBox: Box = {
+        x: (node.target.x || 0) - widthCache[node.target.data.id] / 2 - nodePadding,
+        y: (node.target.y || 0) - nodeHeight / 2 - nodePadding,
+        w: widthCache[node.target.data.id] + nodePadding * 2,
+        h: nodeHeight + nodePadding * 2,
+      };
+
+      const idx = node.points.findIndex((p) => {
+        return intersectsBox(p, nodeBox);
+      });
+      if (idx === -1) {
+        // It shouldn't be possible for this to happen; we know that the
+        // final point always goes to the center of the target box so there
+        // should always be an intersection.
+        throw new Error('could not find point of intersection with target node');
+      }
+      const trimmedPoints = node.points.slice(0, idx + 1);
+
+      const intersectingLine = {
+        start: trimmedPoints[trimmedPoints.length - 2],
+        end: trimmedPoints[trimmedPoints.length - 1],
+      };
+      const fixedPoint = boxIntersectionPoint(intersectingLine, nodeBox);
+      trimmedPoints[trimmedPoints.length - 1] = fixedPoint;
+
+      return {
+        source: node.source.data.id,
+        target: node.target.data.id,
+        targetInputs: node.target.data.referencedBy.length,
+        points: trimmedPoints,
+      };
+    });
+
     // Plot edges
     svgWrapper
       .append('g')
       .selectAll('path')
-      .data(dag.links())
+      .data(edges)
       .enter()
       .append('path')
       .attr('marker-end', 'url(#arrow)')
-      .attr('d', (node) => {
-        // We want to draw arrows between boxes, but by default the arrows are
-        // obscured; d3-dag points lines to the middle of a box which is hidden
-        // by the rectangle.
-        //
-        // To fix this, we do the following:
-        //
-        // 1. Retrieve the set of generated points for d3-dag
-        // 2. Remove all points after the first point which intersects the box
-        // 3. Move the final point to the coordinates where it intersects the
-        //    box
-        // 4. The line will now stop at the box edge as expected.
-
-        const nodeBox: Box = {
-          x: (node.target.x || 0) - widthCache[node.target.data.id] / 2 - nodePadding,
-          y: (node.target.y || 0) - nodeHeight / 2 - nodePadding,
-          w: widthCache[node.target.data.id] + nodePadding * 2,
-          h: nodeHeight + nodePadding * 2,
-        };
-
-        const idx = node.points.findIndex((p) => {
-          return intersectsBox(p, nodeBox);
-        });
-        if (idx === -1) {
-          // It shouldn't be possible for this to happen; we know that the
-          // final point always goes to the center of the target box so there
-          // should always be an intersection.
-          throw new Error('could not find point of intersection with target node');
-        }
-        const trimmedPoints = node.points.slice(0, idx + 1);
-
-        const intersectingLine = {
-          start: trimmedPoints[trimmedPoints.length - 2],
-          end: trimmedPoints[trimmedPoints.length - 1],
-        };
-        const fixedPoint = boxIntersectionPoint(intersectingLine, nodeBox);
-        trimmedPoints[trimmedPoints.length - 1] = fixedPoint;
-
-        return line(trimmedPoints);
-      })
+      .attr('d', (edge) => line(edge.points))
       .attr('fill', 'none')
       .attr('stroke-width', '2px')
       .attr('stroke', '#c8c9ca')
       .append('title') // Append tooltip to edge
-      .text((n) => {
-        return `${n.source.data.id} to ${n.target.data.id}`;
+      .text((edge) => {
+        return `${edge.source} to ${edge.target}`;
       });
 
+    // Add edge rate text. The text is filled in as throughput is received.
+    svgWrapper
+      .append('g')
+      .selectAll('text')
+      .data(edges)
+      .enter()
+      .append('text')
+      .attr('class', 'edge-rate')
+      .attr('x', (edge) => polylineMidpoint(edge.points).x)
+      .attr('y', (edge) => polylineMidpoint(edge.points).y)
+      .attr('font-size', '9')
+      .attr('font-family', '"Roboto", sans-serif')
+      .attr('text-anchor', 'middle')
+      .attr('alignment-baseline', 'middle')
+      .attr('fill', 'rgb(36, 41, 46, 0.75)')
+      .attr('stroke', '#ffffff')
+      .attr('stroke-width', '3')
+      .attr('paint-order', 'stroke');
+
     // Select nodes
     const nodes = svgWrapper
       .append('g')
@@ -411,7 +503,53 @@ export const ComponentGraph: FC<ComponentGraphProps> = (props) => {
         }
         return '#ffffff';
       });
-  });
+
+    // Add the area of the throughput sparkline, next to the health status. The
+    // sparkline is drawn as throughput is received.
+    const sparklines = nodeContent
+      .append('g')
+      .datum<Sparkline>((node) => ({ id: node.data.id, width: widthCache[node.data.id] - 50, height: 14 }))
+      .attr('class', 'sparkline')
+      .attr('transform', `translate(50, ${contentHeight - 3})`);
+    sparklines
+      .append('path')
+      .attr('fill', 'none')
+      .attr('stroke-width', '1px')
+      .attr('stroke', '#3871dc');
+    sparklines.append('title');
+  }, [props.components, baseComponentPath]);
+
+  useEffect(() => {
+    const throughput = props.throughput || {};
+    const svgSelection = d3.select(svgRef.current as Element);
+
+    svgSelection.selectAll<SVGTextElement, Edge>('text.edge-rate').text((edge) => {
+      const rates = edgeRates(edge, throughput);
+      return rates !== undefined ? formatRates(rates) : '';
+    });
+
+    svgSelection.selectAll<SVGGElement, Sparkline>('g.sparkline').each(function (sparkline) {
+      const component = throughput[sparkline.id];
+      const history = component?.history || [];
+
+      const x = d3
+        .scaleLinear()
+        .domain([0, Math.max(history.length - 1, 1)])
+        .range([0, sparkline.width]);
+      const y = d3
+        .scaleLinear()
+        .domain([0, Math.max(...history, 0) || 1])
+        .range([sparkline.height, 0]);
+      const line = d3
+        .line<number>()
+        .x((_, i) => x(i))
+        .y((d) => y(d));
+
+      const g = d3.select(this);
+      g.select('path').attr('d', history.length > 1 ? line(history) : null);
+      g.select('title').text(component !== undefined ? formatRates(component.rates) : '');
+    });
+  }, [props.throughput, props.components]);
 
   return <svg ref={svgRef} style={{ width: '100%', height: '100%', display: 'block' }} />;
 };
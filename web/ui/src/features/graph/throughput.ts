@@ -0,0 +1,129 @@
+/**
+ * ThroughputKind is the kind of data counted by the throughput of a
+ * component.
+ */
+export enum ThroughputKind {
+  SAMPLES = 'samples',
+  LINES = 'lines',
+  SPANS = 'spans',
+  BYTES = 'bytes',
+}
+
+/**
+ * ThroughputRates holds rates per second, by kind of data.
+ */
+export type ThroughputRates = Partial<Record<ThroughputKind, number>>;
+
+/**
+ * ThroughputSnapshot is the response of the throughput API: the totals of the
+ * throughput counters of each component at a point in time.
+ */
+export interface ThroughputSnapshot {
+  timestamp: string;
+  components: Record<string, Partial<Record<ThroughputKind, number>>>;
+}
+
+/**
+ * ComponentThroughput holds the live rates of a component.
+ */
+export interface ComponentThroughput {
+  /** Current rates. */
+  rates: ThroughputRates;
+
+  /** Kind of data plotted in history. */
+  primaryKind: ThroughputKind;
+
+  /** Past rates per second of primaryKind, oldest first. */
+  history: number[];
+}
+
+/**
+ * computeRates returns the rates per second of each component between two
+ * snapshots. Counters which went down were reset, so their rate is computed
+ * from zero.
+ */
+export function computeRates(prev: ThroughputSnapshot, cur: ThroughputSnapshot): Record<string, ThroughputRates> {
+  const seconds = (Date.parse(cur.timestamp) - Date.parse(prev.timestamp)) / 1000;
+  const res: Record<string, ThroughputRates> = {};
+  if (seconds <= 0) {
+    return res;
+  }
+
+  Object.entries(cur.components).forEach(([id, totals]) => {
+    const rates: ThroughputRates = {};
+    Object.entries(totals).forEach(([kind, total]) => {
+      const prevTotal = prev.components[id]?.[kind as ThroughputKind];
+      if (prevTotal === undefined || total === undefined) {
+        return;
+      }
+      const delta = total >= prevTotal ? total - prevTotal : total;
+      rates[kind as ThroughputKind] = delta / seconds;
+    });
+    if (Object.keys(rates).length > 0) {
+      res[id] = rates;
+    }
+  });
+  return res;
+}
+
+/**
+ * primaryKind returns the kind of data a component is best described by:
+ * the units of data it handles if known, otherwise bytes.
+ */
+export function primaryKind(rates: ThroughputRates): ThroughputKind {
+  for (const kind of [ThroughputKind.SAMPLES, ThroughputKind.LINES, ThroughputKind.SPANS]) {
+    if (rates[kind] !== undefined) {
+      return kind;
+    }
+  }
+  return ThroughputKind.BYTES;
+}
+
+/**
+ * formatNumber formats n with the largest prefix keeping it under base,
+ * with up to three significant digits.
+ */
+function formatNumber(n: number, base: number, prefixes: string[]): string {
+  let i = 0;
+  while (n >= base && i < prefixes.length - 1) {
+    n /= base;
+    i++;
+  }
+  const digits = n >= 100 ? n.toFixed(0) : n.toPrecision(3).replace(/(\.\d*?)0+$/, '$1').replace(/\.$/, '');
+  return `${digits}${prefixes[i]}`;
+}
+
+/**
+ * formatRate formats a rate per second of the given kind for display, such
+ * as "1.2k lines/s" or "340 KiB/s".
+ */
+export function formatRate(kind: ThroughputKind, rate: number): string {
+  if (kind === ThroughputKind.BYTES) {
+    return `${formatNumber(rate, 1024, [' B', ' KiB', ' MiB', ' GiB', ' TiB'])}/s`;
+  }
+  return `${formatNumber(rate, 1000, ['', 'k', 'M', 'G', 'T'])} ${kind}/s`;
+}
+
+/**
+ * formatRates formats the rates of a component, with the rate of its primary
+ * kind first.
+ */
+export function formatRates(rates: ThroughputRates): string {
+  const primary = primaryKind(rates);
+  const kinds = [primary, ...Object.values(ThroughputKind).filter((kind) => kind !== primary)];
+  return kinds
+    .filter((kind) => rates[kind] !== undefined)
+    .map((kind) => formatRate(kind, rates[kind] as number))
+    .join(', ');
+}
+
+/**
+ * formatTotal formats a total of the given kind for display, such as
+ * "1.2M lines" or "340 KiB".
+ */
+export function formatTotal(kind: ThroughputKind, total: number): string {
+  if (kind === ThroughputKind.BYTES) {
+    return formatNumber(total, 1024, [' B', ' KiB', ' MiB', ' GiB', ' TiB']);
+  }
+  return `${formatNumber(total, 1000, ['', 'k', 'M', 'G', 'T'])} ${kind}`;
+}
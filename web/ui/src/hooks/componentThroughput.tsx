@@ -0,0 +1,60 @@
+import { useEffect, useState } from 'react';
+
+import { ComponentThroughput, computeRates, primaryKind, ThroughputSnapshot } from '../features/graph/throughput';
+
+/** Number of past rates kept for each component. */
+const historyLength = 30;
+
+/**
+ * useComponentThroughput polls the API for the throughput of components and
+ * returns their live rates, keyed by component ID. Components are only
+ * included once two polls have measured their throughput.
+ *
+ * @param intervalMs How often to poll the API.
+ */
+export const useComponentThroughput = (intervalMs = 5000): Record<string, ComponentThroughput> => {
+  const [throughput, setThroughput] = useState<Record<string, ComponentThroughput>>({});
+
+  useEffect(
+    function () {
+      let prev: ThroughputSnapshot | undefined;
+
+      const worker = async () => {
+        // Request is relative to the <base> tag inside of <head>.
+        const resp = await fetch('./api/v0/web/throughput', {
+          cache: 'no-cache',
+          credentials: 'same-origin',
+        });
+        if (!resp.ok) {
+          return;
+        }
+        const cur: ThroughputSnapshot = await resp.json();
+
+        if (prev !== undefined) {
+          const rates = computeRates(prev, cur);
+          setThroughput((old) => {
+            const res: Record<string, ComponentThroughput> = {};
+            Object.entries(rates).forEach(([id, componentRates]) => {
+              const kind = old[id]?.primaryKind ?? primaryKind(componentRates);
+              const history = old[id]?.history ?? [];
+              res[id] = {
+                rates: componentRates,
+                primaryKind: kind,
+                history: [...history, componentRates[kind] ?? 0].slice(-historyLength),
+              };
+            });
+            return res;
+          });
+        }
+        prev = cur;
+      };
+
+      worker().catch(console.error);
+      const timer = setInterval(() => worker().catch(console.error), intervalMs);
+      return () => clearInterval(timer);
+    },
+    [intervalMs]
+  );
+
+  return throughput;
+};
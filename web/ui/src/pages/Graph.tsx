@@ -3,13 +3,15 @@ import { faDiagramProject } from '@fortawesome/free-solid-svg-icons';
 import { ComponentGraph } from '../features/graph/ComponentGraph';
 import Page from '../features/layout/Page';
 import { useComponentInfo } from '../hooks/componentInfo';
+import { useComponentThroughput } from '../hooks/componentThroughput';
 
 function Graph() {
   const components = useComponentInfo();
+  const throughput = useComponentThroughput();
 
   return (
     <Page name="Graph" desc="Relationships between defined components" icon={faDiagramProject}>
-      {components.length > 0 && <ComponentGraph components={components} />}
+      {components.length > 0 && <ComponentGraph components={components} throughput={throughput} />}
     </Page>
   );
 }